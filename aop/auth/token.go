@@ -0,0 +1,203 @@
+// Package auth issues and verifies the session tokens of the players.
+//
+// The login server issues a token when it verifies an account, and the
+// gateway verifies it when the client logs in (CSGatewayLogin), reconnects or
+// logs out. A token is signed with HMAC-SHA256 by a key shared by the login
+// servers and the gateways, so that the gateway can verify it without asking
+// the login server; it names its key, so that keys can be rotated by adding
+// the new one everywhere, issuing with it, then dropping the old one once the
+// tokens it signed expired.
+//
+// A token is valid until it expires, and can be refreshed for a new one of
+// the same session until the session ends. Revoking a session (e.g. a login
+// from another device, or a ban) is up to the servers, which keep the current
+// session of every user.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed = errors.New("auth: malformed token")
+	ErrKey       = errors.New("auth: unknown token key")
+	ErrSignature = errors.New("auth: invalid token signature")
+	ErrExpired   = errors.New("auth: token expired")
+)
+
+// Config is the config of a Signer, the same on the login servers and the
+// gateways.
+type Config struct {
+	Keys    map[string]string // secrets, by key id
+	Current string            // id of the key signing new tokens
+
+	TTL     time.Duration // lifetime of a token, defaults to 2 hours
+	Session time.Duration // lifetime of a session, over refreshes, defaults to 24 hours
+}
+
+// Claims are what a token says about its holder.
+type Claims struct {
+	Uid     uint64 `json:"uid"`
+	Account string `json:"acc"`
+	Device  string `json:"dev,omitempty"`
+	Version int    `json:"pv,omitempty"` // protocol version of the client (see protover)
+	Session string `json:"sid"` // random, the same for all the tokens of a login
+	Login   int64  `json:"lat"` // when the session started, in seconds
+	Issued  int64  `json:"iat"` // in seconds
+	Expires int64  `json:"exp"` // in seconds
+}
+
+// A Signer issues and verifies tokens. It's safe for concurrent use.
+type Signer struct {
+	keys    map[string][]byte
+	current string
+	ttl     time.Duration
+	session time.Duration
+}
+
+// NewSigner returns a Signer with the keys of a config.
+func NewSigner(conf Config) (*Signer, error) {
+	s := &Signer{keys: make(map[string][]byte, len(conf.Keys)), current: conf.Current, ttl: conf.TTL, session: conf.Session}
+	for id, secret := range conf.Keys {
+		if id == "" || strings.Contains(id, ".") {
+			return nil, fmt.Errorf("auth: invalid key id %q", id)
+		}
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("auth: key %q: secret shorter than 32 bytes", id)
+		}
+		s.keys[id] = []byte(secret)
+	}
+	if s.keys[s.current] == nil {
+		return nil, fmt.Errorf("auth: current key %q not found", s.current)
+	}
+	if s.ttl <= 0 {
+		s.ttl = 2 * time.Hour
+	}
+	if s.session <= 0 {
+		s.session = 24 * time.Hour
+	}
+	return s, nil
+}
+
+// SessionEnd returns when the session of some claims ends.
+func (s *Signer) SessionEnd(c *Claims) time.Time {
+	return time.Unix(c.Login, 0).Add(s.session)
+}
+
+// Issue returns a token for a new session of a user, on a client of a
+// protocol version, issued now.
+func (s *Signer) Issue(uid uint64, account, device string, version int, now time.Time) (string, *Claims, error) {
+	var sid [16]byte
+	if _, err := rand.Read(sid[:]); err != nil {
+		return "", nil, fmt.Errorf("auth: session id: %w", err)
+	}
+	c := &Claims{
+		Uid:     uid,
+		Account: account,
+		Device:  device,
+		Version: version,
+		Session: hex.EncodeToString(sid[:]),
+		Login:   now.Unix(),
+	}
+	token, err := s.sign(c, now)
+	return token, c, err
+}
+
+// Refresh returns a new token of the session of a token, issued now. The
+// token may have expired, but not its session.
+func (s *Signer) Refresh(token string, now time.Time) (string, *Claims, error) {
+	c, err := s.parse(token)
+	if err != nil {
+		return "", nil, err
+	}
+	if !now.Before(s.SessionEnd(c)) {
+		return "", nil, ErrExpired
+	}
+	token, err = s.sign(c, now)
+	return token, c, err
+}
+
+// sign sets the times of the claims, and signs them with the current key.
+func (s *Signer) sign(c *Claims, now time.Time) (string, error) {
+	c.Issued = now.Unix()
+	c.Expires = now.Add(s.ttl).Unix()
+	if end := s.SessionEnd(c).Unix(); c.Expires > end {
+		c.Expires = end
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("auth: %w", err)
+	}
+	head := s.current + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return head + "." + base64.RawURLEncoding.EncodeToString(mac(s.keys[s.current], head)), nil
+}
+
+// Verify returns the claims of a token, if it's signed by a known key and
+// not expired by now.
+func (s *Signer) Verify(token string, now time.Time) (*Claims, error) {
+	c, err := s.parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if now.Unix() >= c.Expires {
+		return nil, ErrExpired
+	}
+	return c, nil
+}
+
+// SessionKey returns the key encrypting the client connections of the
+// session of a token (see aop/net/transport), if the token is signed by a
+// known key, expired or not. It's derived from the secret of that key, so that
+// the login servers and the gateways agree on it without sharing it, and is
+// the same for all the tokens of the session the key signs. Each connection
+// derives a key of its own from it.
+func (s *Signer) SessionKey(token string) ([]byte, error) {
+	c, err := s.parse(token)
+	if err != nil {
+		return nil, err
+	}
+	return mac(s.keys[strings.SplitN(token, ".", 2)[0]], "transport\x00"+c.Session), nil
+}
+
+// parse returns the claims of a token signed by a known key.
+func (s *Signer) parse(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	key := s.keys[parts[0]]
+	if key == nil {
+		return nil, ErrKey
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if !hmac.Equal(sig, mac(key, parts[0]+"."+parts[1])) {
+		return nil, ErrSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	c := &Claims{}
+	if err := json.Unmarshal(payload, c); err != nil || c.Session == "" {
+		return nil, ErrMalformed
+	}
+	return c, nil
+}
+
+func mac(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
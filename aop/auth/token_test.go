@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	secret1 = "0123456789abcdef0123456789abcdef"
+	secret2 = "fedcba9876543210fedcba9876543210"
+)
+
+func newSigner(t *testing.T, current string, keys map[string]string) *Signer {
+	t.Helper()
+	s, err := NewSigner(Config{Keys: keys, Current: current, TTL: time.Hour, Session: 3 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestIssueVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newSigner(t, "k1", map[string]string{"k1": secret1})
+	token, issued, err := s.Issue(42, "alice", "mac", 3, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Verify(token, now.Add(time.Hour-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *c != *issued || c.Uid != 42 || c.Account != "alice" || c.Device != "mac" || c.Version != 3 || c.Session == "" {
+		t.Fatalf("claims: got %+v, want %+v", c, issued)
+	}
+	if _, err := s.Verify(token, now.Add(time.Hour)); err != ErrExpired {
+		t.Fatalf("expired: got %v, want %v", err, ErrExpired)
+	}
+
+	// Another session of the same user.
+	_, other, _ := s.Issue(42, "alice", "mac", 3, now)
+	if other.Session == issued.Session {
+		t.Fatalf("sessions: got %q twice", other.Session)
+	}
+}
+
+func TestTampered(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newSigner(t, "k1", map[string]string{"k1": secret1})
+	token, _, _ := s.Issue(42, "alice", "", 0, now)
+	parts := strings.Split(token, ".")
+
+	forged, _, _ := newSigner(t, "k1", map[string]string{"k1": secret2}).Issue(1, "mallory", "", 0, now)
+	for _, tc := range []struct {
+		token string
+		want  error
+	}{
+		{"", ErrMalformed},
+		{parts[0] + "." + parts[1], ErrMalformed},
+		{"k2." + parts[1] + "." + parts[2], ErrKey},
+		{parts[0] + "." + parts[1] + "x." + parts[2], ErrSignature},
+		{parts[0] + "." + parts[1] + ".!", ErrMalformed},
+		{forged, ErrSignature},
+	} {
+		if _, err := s.Verify(tc.token, now); err != tc.want {
+			t.Errorf("Verify(%q): got %v, want %v", tc.token, err, tc.want)
+		}
+	}
+}
+
+func TestRotation(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	old := newSigner(t, "k1", map[string]string{"k1": secret1})
+	token, _, _ := old.Issue(42, "alice", "", 0, now)
+
+	s := newSigner(t, "k2", map[string]string{"k1": secret1, "k2": secret2})
+	if _, err := s.Verify(token, now); err != nil {
+		t.Fatalf("old key: %v", err)
+	}
+	token, _, _ = s.Issue(42, "alice", "", 0, now)
+	if !strings.HasPrefix(token, "k2.") {
+		t.Fatalf("new key: got %q", token)
+	}
+	if _, err := old.Verify(token, now); err != ErrKey {
+		t.Fatalf("new key on old signer: got %v, want %v", err, ErrKey)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newSigner(t, "k1", map[string]string{"k1": secret1})
+	token, issued, _ := s.Issue(42, "alice", "", 0, now)
+
+	// An expired token is refreshed within its session.
+	now = now.Add(90 * time.Minute)
+	token, c, err := s.Refresh(token, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Session != issued.Session || c.Login != issued.Login || c.Expires != now.Add(time.Hour).Unix() {
+		t.Fatalf("refreshed: got %+v, issued %+v", c, issued)
+	}
+
+	// The last token of the session expires with it.
+	now = now.Add(time.Hour)
+	_, c, err = s.Refresh(token, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := issued.Login + 3*3600; c.Expires != want {
+		t.Fatalf("last expiry: got %d, want %d", c.Expires, want)
+	}
+	if _, _, err := s.Refresh(token, now.Add(30*time.Minute)); err != ErrExpired {
+		t.Fatalf("session over: got %v, want %v", err, ErrExpired)
+	}
+}
+
+func TestSessionKey(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newSigner(t, "k1", map[string]string{"k1": secret1})
+	token, _, _ := s.Issue(42, "alice", "", 0, now)
+	key, err := s.SessionKey(token)
+	if err != nil || len(key) != 32 {
+		t.Fatalf("SessionKey: got %x, %v", key, err)
+	}
+
+	// The same for the tokens of the session, not for other sessions.
+	refreshed, _, _ := s.Refresh(token, now.Add(time.Minute))
+	if k, _ := s.SessionKey(refreshed); string(k) != string(key) {
+		t.Fatal("refreshed token: another key")
+	}
+	other, _, _ := s.Issue(42, "alice", "", 0, now)
+	if k, _ := s.SessionKey(other); string(k) == string(key) {
+		t.Fatal("other session: same key")
+	}
+	if _, err := s.SessionKey(token + "x"); err != ErrSignature {
+		t.Fatalf("tampered: got %v, want %v", err, ErrSignature)
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	for _, conf := range []Config{
+		{},
+		{Keys: map[string]string{"k1": secret1}, Current: "k2"},
+		{Keys: map[string]string{"k1": "short"}, Current: "k1"},
+		{Keys: map[string]string{"k.1": secret1}, Current: "k.1"},
+	} {
+		if _, err := NewSigner(conf); err == nil {
+			t.Errorf("NewSigner(%+v): got no error", conf)
+		}
+	}
+}
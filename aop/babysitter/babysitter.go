@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"path"
 	"sort"
+	"strconv"
 	"sync"
 	"syscall"
 
@@ -29,6 +30,7 @@ import (
 	"greatestworks/aop/proxy"
 	"greatestworks/aop/retry"
 	"greatestworks/aop/status"
+	"greatestworks/aop/traceio"
 	"greatestworks/aop/versioned_map"
 )
 
@@ -69,6 +71,9 @@ type Babysitter struct {
 	// statsProcessor tracks and computes stats to be rendered on the /statusz page.
 	statsProcessor *metrics.StatsProcessor
 
+	// events records the control-plane events of the deployment. See Events.
+	events *status.EventRecorder
+
 	mu           sync.RWMutex
 	managed      map[string][]*envelope.Envelope // replica envelopes, by group
 	appState     *versioned_map.Map[*AppVersionState]
@@ -100,9 +105,14 @@ func NewBabysitter(ctx context.Context, dep *protos.Deployment, logSaver func(*p
 	if err != nil {
 		return nil, fmt.Errorf("cannot open Perfetto database: %w", err)
 	}
-	traceSaver := func(spans []trace.ReadOnlySpan) error {
-		return traceDB.Store(ctx, dep.App.Name, dep.Id, spans)
+	sampling, err := traceio.SamplingOptionsFromConfig(dep.App.Sections)
+	if err != nil {
+		return nil, fmt.Errorf("cannot configure trace sampling: %w", err)
 	}
+	sampler := traceio.NewSampler(sampling, func(spans []trace.ReadOnlySpan) error {
+		return traceDB.Store(ctx, dep.App.Name, dep.Id, spans)
+	})
+	traceSaver := sampler.Store
 
 	b := &Babysitter{
 		ctx:            ctx,
@@ -110,6 +120,7 @@ func NewBabysitter(ctx context.Context, dep *protos.Deployment, logSaver func(*p
 		logSaver:       logSaver,
 		traceSaver:     traceSaver,
 		statsProcessor: metrics.NewStatsProcessor(),
+		events:         status.NewEventRecorder(logger),
 		opts:           envelope.Options{Restart: envelope.Never, Retry: retry.DefaultOptions},
 		dep:            dep,
 		managed:        map[string][]*envelope.Envelope{},
@@ -121,6 +132,13 @@ func NewBabysitter(ctx context.Context, dep *protos.Deployment, logSaver func(*p
 	return b, nil
 }
 
+// Events returns the recorder of the control-plane events of the deployment
+// (e.g., the start of a component). The events are buffered until the
+// recorder is attached to a registry.
+func (b *Babysitter) Events() *status.EventRecorder {
+	return b.events
+}
+
 // RegisterStatusPages registers the status pages with the provided mux.
 func (b *Babysitter) RegisterStatusPages(mux *http.ServeMux) {
 	status.RegisterServer(mux, b, b.logger)
@@ -179,6 +197,10 @@ func (b *Babysitter) StartComponent(req *protos.ComponentToStart) error {
 	g := b.findOrAddGroup(state, req.ColocationGroup)
 
 	// Update routing information.
+	if _, ok := g.Components[req.Component]; !ok {
+		b.events.Record(b.dep.Id, status.EventComponentStarted,
+			"component", req.Component, "group", req.ColocationGroup)
+	}
 	g.Components[req.Component] = req.IsRouted
 	if req.IsRouted {
 		if _, ok := g.Assignments[req.Component]; !ok {
@@ -224,6 +246,8 @@ func (b *Babysitter) RegisterReplica(req *protos.ReplicaToRegister) error {
 	if !found {
 		g.Replicas = append(g.Replicas, req.Address)
 		g.ReplicaPids = append(g.ReplicaPids, req.Pid)
+		b.events.Record(b.dep.Id, status.EventReplicaRegistered,
+			"group", req.Group, "address", req.Address, "pid", strconv.FormatInt(req.Pid, 10))
 	}
 
 	// Generate routing info, now that the replica set has changed.
@@ -289,6 +313,8 @@ func (b *Babysitter) ExportListener(req *protos.ExportListenerRequest) (*protos.
 	// Update and store the state.
 	state.Listeners = append(state.Listeners, req.Listener)
 	b.appState.Update(appVersionStateKey, state)
+	b.events.Record(b.dep.Id, status.EventListenerExported,
+		"listener", req.Listener.Name, "address", req.Listener.Addr)
 
 	// Update the proxy.
 	if p, ok := b.proxies[req.Listener.Name]; ok {
@@ -306,14 +332,15 @@ func (b *Babysitter) ExportListener(req *protos.ExportListenerRequest) (*protos.
 	}
 	addr := lis.Addr().String()
 	b.logger.Info("Proxy listening", "address", addr)
-	proxy := proxy.NewProxy(b.logger)
-	proxy.AddBackend(req.Listener.Addr)
-	b.proxies[req.Listener.Name] = &proxyInfo{proxy: proxy, addr: addr}
+	p := proxy.NewProxy(b.logger)
+	p.AddBackend(req.Listener.Addr)
+	b.proxies[req.Listener.Name] = &proxyInfo{proxy: p, addr: addr}
 	go func() {
-		if err := serveHTTP(b.ctx, lis, proxy); err != nil {
+		if err := serveHTTP(b.ctx, lis, p); err != nil {
 			b.logger.Error("proxy", err)
 		}
 	}()
+	go p.RunHealthChecks(b.ctx, proxy.HealthCheckOptions{})
 	return &protos.ExportListenerReply{ProxyAddress: addr}, nil
 }
 
@@ -466,8 +493,9 @@ func (b *Babysitter) Status(ctx context.Context) (*status.Status, error) {
 	var listeners []*status.Listener
 	for name, proxy := range b.proxies {
 		listeners = append(listeners, &status.Listener{
-			Name: name,
-			Addr: proxy.addr,
+			Name:     name,
+			Addr:     proxy.addr,
+			Backends: status.ProxyBackends(proxy.proxy.Backends()),
 		})
 	}
 
@@ -500,7 +528,7 @@ func (b *Babysitter) Metrics(ctx context.Context) (*status.Metrics, error) {
 // REQUIRES: b.mu is held.
 func (b *Babysitter) mayGenerateNewRoutingInfo(g *ColocationGroupState) error {
 	for component, assignment := range g.Assignments {
-		newAssignment, err := routingAlgo(assignment, g.Replicas)
+		newAssignment, err := Assign(assignment, g.Replicas)
 		if err != nil || newAssignment == nil {
 			continue // don't update assignments
 		}
@@ -530,6 +558,7 @@ func (b *Babysitter) updateRoutingInfo(g *ColocationGroupState, info *protos.Rou
 		return nil
 	}
 	b.routingState.Update(routingKey(g.Name), info)
+	b.events.Record(b.dep.Id, status.EventRoutingUpdated, "group", g.Name, "replicas", strconv.Itoa(len(info.Replicas)))
 	return nil
 }
 
@@ -544,7 +573,7 @@ func (b *Babysitter) loadRoutingState(group, version string) (*protos.RoutingInf
 	return state, newVersion, nil
 }
 
-// routingAlgo is an implementation of a routing algorithm that distributes the
+// Assign is an implementation of a routing algorithm that distributes the
 // entire key space approximately equally across all healthy resources.
 //
 // The algorithm is as follows:
@@ -552,7 +581,10 @@ func (b *Babysitter) loadRoutingState(group, version string) (*protos.RoutingInf
 // spread uniformly the key space among all healthy resources
 //
 // - distribute the slices round robin across all healthy resources
-func routingAlgo(currAssignment *protos.Assignment, candidates []string) (*protos.Assignment, error) {
+//
+// Assign is exported so that fake babysitters (see package weavertest) route
+// requests exactly like real ones.
+func Assign(currAssignment *protos.Assignment, candidates []string) (*protos.Assignment, error) {
 	newAssignment := protomsg.Clone(currAssignment)
 	newAssignment.Version++
 
@@ -8,14 +8,23 @@ import (
 	"greatestworks/aop/tool"
 )
 
-// runProfiling runs a profiling request on a set of processes.
+// runProfiling runs a profiling request on a set of processes, keyed by
+// colocation group. If the request names a group or a pid, only the matching
+// processes are profiled.
 func runProfiling(ctx context.Context, req *protos.RunProfiling,
 	processes map[string][]*envelope.Envelope) (*protos.Profile, error) {
 	// Collect together the groups we want to profile.
 	groups := make([][]func() (*protos.Profile, error), 0, len(processes))
-	for _, envelopes := range processes {
+	for name, envelopes := range processes {
+		if req.Group != "" && name != req.Group {
+			continue
+		}
 		group := make([]func() (*protos.Profile, error), 0, len(envelopes))
 		for _, e := range envelopes {
+			if req.Pid != 0 && int64(e.Pid()) != req.Pid {
+				continue
+			}
+			e := e
 			group = append(group, func() (*protos.Profile, error) {
 				return e.RunProfiling(ctx, req)
 			})
@@ -27,6 +27,12 @@ type Bootstrap struct {
 	ToWeaveletFd int    // File descriptor on which to send to weavelet (0 if unset)
 	ToEnvelopeFd int    // File descriptor from which to send to envelope (0 if unset)
 	TestConfig   string // Configuration passed by user test code to weavertest
+
+	// ToWeavelet and ToEnvelope, if set, are in-process pipes used instead of
+	// the file descriptors. weavertest uses them to run the weavelets of a
+	// multi-replica deployment in the test process.
+	ToWeavelet io.ReadCloser
+	ToEnvelope io.WriteCloser
 }
 
 // BootstrapKey is the Context key used by weavertest to pass Bootstrap to [weaver.Init].
@@ -69,11 +75,18 @@ func GetBootstrap(ctx context.Context) (Bootstrap, error) {
 // HasPipes returns true if pipe information has been supplied. This
 // is true except in the case of singleprocess.
 func (b Bootstrap) HasPipes() bool {
+	if b.ToWeavelet != nil && b.ToEnvelope != nil {
+		return true
+	}
 	return b.ToWeaveletFd != 0 && b.ToEnvelopeFd != 0
 }
 
 // MakePipes creates pipe reader and writer. It returns an error if pipes are not configured.
+// The pipes carry the versioned protocol implemented by package envelope/conn.
 func (b Bootstrap) MakePipes() (io.ReadCloser, io.WriteCloser, error) {
+	if b.ToWeavelet != nil && b.ToEnvelope != nil {
+		return b.ToWeavelet, b.ToEnvelope, nil
+	}
 	toWeavelet, err := openFileDescriptor(b.ToWeaveletFd)
 	if err != nil {
 		return nil, nil, fmt.Errorf("open pipe to weavelet: %w", err)
@@ -0,0 +1,328 @@
+// Package chaos injects faults into a deployment, so that operators can check
+// that an application degrades gracefully before a real incident does.
+//
+// Faults are specified in the chaos section of the app config:
+//
+//	[chaos]
+//	enabled = true
+//
+//	# Delay the calls to the Get method of app.Cache by 200ms, and fail 10%
+//	# of them, for 2 minutes every 10 minutes.
+//	[[chaos.faults]]
+//	component = "app.Cache"
+//	method = "Get"
+//	latency = "200ms"
+//	error_rate = 0.1
+//	every = "10m"
+//	for = "2m"
+//
+//	# Kill a replica of the cache colocation group every 5 minutes.
+//	[[chaos.kills]]
+//	group = "cache"
+//	every = "5m"
+//
+// Weavelets inject call faults with an Injector (see
+// call.ServerOptions.Faults), which they update along with the other config
+// sections. Replica kills are carried out by the deployer (e.g., the SSH
+// manager), which calls Config.KillsDue periodically. The faults of a
+// deployment can be toggled without restarting it, by rewriting the enabled
+// field of the section (see SetEnabled).
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"greatestworks/aop"
+	"greatestworks/aop/logging"
+)
+
+const (
+	configKey      = "greatestworks/chaos"
+	shortConfigKey = "chaos"
+)
+
+func init() {
+	aop.RegisterConfigSection[Config](configKey, shortConfigKey)
+}
+
+// ErrInjected is the error of the calls failed by an Injector.
+var ErrInjected = errors.New("chaos: injected error")
+
+// Config is the config of the faults injected into a deployment, as found in
+// the chaos section of the app config. See the package documentation.
+type Config struct {
+	Enabled bool    `toml:"enabled"`
+	Faults  []Fault `toml:"faults,omitempty"`
+	Kills   []Kill  `toml:"kills,omitempty"`
+}
+
+// A Fault delays or fails the calls to the methods of a component.
+type Fault struct {
+	// Component is the full or shortened name (e.g., "app.Cache") of the
+	// component whose calls are affected.
+	Component string `toml:"component"`
+
+	// Method, if not empty, restricts the fault to a single method.
+	Method string `toml:"method,omitempty"`
+
+	// Latency is added to every affected call (e.g., "200ms").
+	Latency string `toml:"latency,omitempty"`
+
+	// ErrorRate is the fraction, between 0 and 1, of the affected calls that
+	// fail with ErrInjected.
+	ErrorRate float64 `toml:"error_rate,omitempty"`
+
+	Schedule
+}
+
+// A Kill periodically kills a replica of a colocation group.
+type Kill struct {
+	// Group is the name of the colocation group.
+	Group string `toml:"group"`
+
+	// Every is the period of the kills (e.g., "5m").
+	Every string `toml:"every"`
+}
+
+// A Schedule restricts a fault to recurring windows of time: the fault is
+// active for For at the start of every period of length Every. Periods are
+// aligned on the Unix epoch, so that all the weavelets of a deployment agree
+// on them. A fault without Every is always active.
+type Schedule struct {
+	Every string `toml:"every,omitempty"`
+	For   string `toml:"for,omitempty"`
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	for i, f := range c.Faults {
+		if f.Component == "" {
+			return fmt.Errorf("fault %d: no component provided", i)
+		}
+		if _, err := parseDuration(f.Latency); err != nil {
+			return fmt.Errorf("fault %d: invalid latency %q: %w", i, f.Latency, err)
+		}
+		if f.ErrorRate < 0 || f.ErrorRate > 1 {
+			return fmt.Errorf("fault %d: got error rate %v, want a rate between 0 and 1", i, f.ErrorRate)
+		}
+		if _, _, err := f.Schedule.parse(); err != nil {
+			return fmt.Errorf("fault %d: %w", i, err)
+		}
+	}
+	for i, k := range c.Kills {
+		if k.Group == "" {
+			return fmt.Errorf("kill %d: no group provided", i)
+		}
+		every, err := parseDuration(k.Every)
+		if err != nil || every <= 0 {
+			return fmt.Errorf("kill %d: invalid period %q: want a positive duration", i, k.Every)
+		}
+	}
+	return nil
+}
+
+// KillsDue returns the colocation groups of which a replica must be killed
+// because a kill period has started in (prev, now]. It returns nil if the
+// config is disabled.
+func (c *Config) KillsDue(prev, now time.Time) []string {
+	if c == nil || !c.Enabled {
+		return nil
+	}
+	var groups []string
+	for _, k := range c.Kills {
+		every, err := parseDuration(k.Every)
+		if err != nil || every <= 0 {
+			continue
+		}
+		if now.UnixNano()/int64(every) > prev.UnixNano()/int64(every) {
+			groups = append(groups, k.Group)
+		}
+	}
+	return groups
+}
+
+// parse returns the period and duration of the schedule. A zero period means
+// that the fault is always active.
+func (s Schedule) parse() (every, active time.Duration, err error) {
+	if every, err = parseDuration(s.Every); err != nil || every < 0 {
+		return 0, 0, fmt.Errorf("invalid period %q: want a non-negative duration", s.Every)
+	}
+	if active, err = parseDuration(s.For); err != nil || active < 0 {
+		return 0, 0, fmt.Errorf("invalid duration %q: want a non-negative duration", s.For)
+	}
+	if every == 0 && active != 0 {
+		return 0, 0, fmt.Errorf("duration %q provided without a period", s.For)
+	}
+	if active > every {
+		return 0, 0, fmt.Errorf("duration %q is longer than period %q", s.For, s.Every)
+	}
+	return every, active, nil
+}
+
+// parseDuration parses the provided duration. An empty string is a zero
+// duration.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// Parse parses the chaos section of the provided config sections. It returns
+// a disabled config if the section is missing.
+func Parse(sections map[string]string) (*Config, error) {
+	var c Config
+	if err := aop.ParseConfigSection(configKey, shortConfigKey, sections, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// SetEnabled returns a copy of the provided config sections in which the
+// faults of the chaos section are enabled or disabled.
+func SetEnabled(sections map[string]string, enabled bool) (map[string]string, error) {
+	c, err := Parse(sections)
+	if err != nil {
+		return nil, err
+	}
+	c.Enabled = enabled
+
+	// Write the section back under the key it was found under.
+	key := shortConfigKey
+	if _, ok := sections[configKey]; ok {
+		key = configKey
+	}
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(c); err != nil {
+		return nil, err
+	}
+	updated := make(map[string]string, len(sections)+1)
+	for k, s := range sections {
+		updated[k] = s
+	}
+	updated[key] = b.String()
+	return updated, nil
+}
+
+// An Injector injects the call faults of a Config into the calls handled by
+// a weavelet. The faults can be updated at any time.
+//
+// The zero value injects no fault. A nil *Injector injects no fault either.
+// You can safely use an Injector from multiple goroutines.
+type Injector struct {
+	mu     sync.RWMutex
+	faults []fault // nil if disabled
+	now    func() time.Time // if not nil, replaces time.Now in tests
+	rand   func() float64   // if not nil, replaces rand.Float64 in tests
+}
+
+// fault is a parsed Fault.
+type fault struct {
+	component string
+	method    string
+	latency   time.Duration
+	errorRate float64
+	every     time.Duration
+	active    time.Duration
+}
+
+// matches returns whether the fault applies to a call to the provided method
+// of the provided component at the provided time.
+func (f *fault) matches(component, method string, now time.Time) bool {
+	if f.component != component && f.component != logging.ShortenComponent(component) {
+		return false
+	}
+	if f.method != "" && f.method != method {
+		return false
+	}
+	return f.every == 0 || time.Duration(now.UnixNano()%int64(f.every)) < f.active
+}
+
+// Update replaces the faults with the ones specified in the chaos section of
+// the provided config sections. If the section is missing or disabled, no
+// fault is injected. On error, the faults are left unchanged.
+func (i *Injector) Update(sections map[string]string) error {
+	c, err := Parse(sections)
+	if err != nil {
+		return err
+	}
+	var faults []fault
+	if c.Enabled {
+		for _, f := range c.Faults {
+			// The durations are validated by Parse.
+			latency, _ := parseDuration(f.Latency)
+			every, active, _ := f.Schedule.parse()
+			faults = append(faults, fault{
+				component: f.Component,
+				method:    f.Method,
+				latency:   latency,
+				errorRate: f.ErrorRate,
+				every:     every,
+				active:    active,
+			})
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults = faults
+	return nil
+}
+
+// Inject injects the faults that apply to a call to the provided method,
+// named "<component>.<method>" (see call.HandlerMap). It sleeps for the
+// latency of the faults, and returns an error wrapping ErrInjected if the
+// call must fail, or the context's error if the context is done first.
+func (i *Injector) Inject(ctx context.Context, name string) error {
+	if i == nil {
+		return nil
+	}
+	dot := strings.LastIndexByte(name, '.')
+	if dot < 0 {
+		return nil
+	}
+	component, method := name[:dot], name[dot+1:]
+
+	i.mu.RLock()
+	now, random := time.Now, rand.Float64
+	if i.now != nil {
+		now = i.now
+	}
+	if i.rand != nil {
+		random = i.rand
+	}
+	var latency time.Duration
+	fail := false
+	t := now()
+	for _, f := range i.faults {
+		if !f.matches(component, method, t) {
+			continue
+		}
+		latency += f.latency
+		if f.errorRate > 0 && random() < f.errorRate {
+			fail = true
+		}
+	}
+	i.mu.RUnlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if fail {
+		return fmt.Errorf("%s: %w", name, ErrInjected)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const section = `
+enabled = true
+
+[[faults]]
+component = "github.com/my/app/Cache"
+method = "Get"
+latency = "10ms"
+
+[[faults]]
+component = "app.Store"
+error_rate = 0.5
+every = "10m"
+for = "2m"
+
+[[kills]]
+group = "cache"
+every = "5m"
+`
+
+func TestParse(t *testing.T) {
+	c, err := Parse(map[string]string{"chaos": section})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{
+		Enabled: true,
+		Faults: []Fault{
+			{Component: "github.com/my/app/Cache", Method: "Get", Latency: "10ms"},
+			{Component: "app.Store", ErrorRate: 0.5, Schedule: Schedule{Every: "10m", For: "2m"}},
+		},
+		Kills: []Kill{{Group: "cache", Every: "5m"}},
+	}
+	if diff := cmp.Diff(want, c); diff != "" {
+		t.Fatalf("Parse (-want +got):\n%s", diff)
+	}
+
+	// A missing section is a disabled config.
+	c, err = Parse(map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Enabled {
+		t.Fatal("missing section: got an enabled config")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		section string
+	}{
+		{"NoComponent", "[[faults]]\nlatency = \"1s\""},
+		{"BadLatency", "[[faults]]\ncomponent = \"a.B\"\nlatency = \"soon\""},
+		{"BadErrorRate", "[[faults]]\ncomponent = \"a.B\"\nerror_rate = 1.5"},
+		{"ForWithoutEvery", "[[faults]]\ncomponent = \"a.B\"\nfor = \"1m\""},
+		{"ForLongerThanEvery", "[[faults]]\ncomponent = \"a.B\"\nevery = \"1m\"\nfor = \"2m\""},
+		{"NoGroup", "[[kills]]\nevery = \"1m\""},
+		{"NoKillPeriod", "[[kills]]\ngroup = \"cache\""},
+		{"UnknownKey", "[[kills]]\ngroup = \"cache\"\nevery = \"1m\"\nhow = \"SIGKILL\""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Parse(map[string]string{"chaos": test.section}); err == nil {
+				t.Fatal("unexpected success")
+			}
+		})
+	}
+}
+
+func TestKillsDue(t *testing.T) {
+	c, err := Parse(map[string]string{"chaos": section})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	for _, test := range []struct {
+		prev, now time.Time
+		want      []string
+	}{
+		{at(time.Second), at(2 * time.Second), nil},
+		{at(4 * time.Minute), at(5 * time.Minute), []string{"cache"}},
+		{at(5 * time.Minute), at(6 * time.Minute), nil},
+		{at(9 * time.Minute), at(11 * time.Minute), []string{"cache"}},
+	} {
+		if diff := cmp.Diff(test.want, c.KillsDue(test.prev, test.now)); diff != "" {
+			t.Errorf("KillsDue(%v, %v) (-want +got):\n%s", test.prev, test.now, diff)
+		}
+	}
+
+	c.Enabled = false
+	if got := c.KillsDue(at(4*time.Minute), at(5*time.Minute)); got != nil {
+		t.Fatalf("disabled config: got %v, want no kills", got)
+	}
+}
+
+func TestSetEnabled(t *testing.T) {
+	sections := map[string]string{"chaos": section, "other": "x = 1"}
+	disabled, err := SetEnabled(sections, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Parse(disabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Enabled || len(c.Faults) != 2 || len(c.Kills) != 1 {
+		t.Fatalf("SetEnabled(false): got %+v, want the disabled faults", c)
+	}
+	if got, want := disabled["other"], "x = 1"; got != want {
+		t.Fatalf("other section: got %q, want %q", got, want)
+	}
+	if sections["chaos"] != section {
+		t.Fatal("SetEnabled modified its argument")
+	}
+}
+
+func TestInjector(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	i := &Injector{
+		now:  func() time.Time { return now },
+		rand: func() float64 { return 0.25 },
+	}
+
+	// No fault is injected before the first update.
+	if err := i.Inject(ctx, "github.com/my/app/Store.Put"); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Update(map[string]string{"chaos": section}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Calls to app.Store fail in the first two minutes of every 10 minutes.
+	for _, test := range []struct {
+		at   time.Duration
+		fail bool
+	}{
+		{0, true},
+		{time.Minute, true},
+		{3 * time.Minute, false},
+		{11 * time.Minute, true},
+	} {
+		now = start.Add(test.at)
+		err := i.Inject(ctx, "github.com/my/app/Store.Put")
+		if got := errors.Is(err, ErrInjected); got != test.fail {
+			t.Errorf("at %v: got %v, want failure %t", test.at, err, test.fail)
+		}
+	}
+
+	// Calls to Cache.Get are delayed; the other methods are not.
+	begin := time.Now()
+	if err := i.Inject(ctx, "github.com/my/app/Cache.Get"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed < 10*time.Millisecond {
+		t.Fatalf("Cache.Get: delayed by %v, want at least 10ms", elapsed)
+	}
+	if err := i.Inject(ctx, "github.com/my/app/Cache.Put"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A cancelled call is not delayed.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := i.Inject(cancelled, "github.com/my/app/Cache.Get"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Cache.Get: got %v, want %v", err, context.Canceled)
+	}
+
+	// Disabling the config stops the faults.
+	disabled, err := SetEnabled(map[string]string{"chaos": section}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Update(disabled); err != nil {
+		t.Fatal(err)
+	}
+	now = start
+	if err := i.Inject(ctx, "github.com/my/app/Store.Put"); err != nil {
+		t.Fatalf("disabled: %v", err)
+	}
+
+	// A nil injector injects no fault.
+	var nilInjector *Injector
+	if err := nilInjector.Inject(ctx, "github.com/my/app/Store.Put"); err != nil {
+		t.Fatal(err)
+	}
+}
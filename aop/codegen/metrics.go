@@ -1,6 +1,7 @@
 package codegen
 
 import (
+	imetrics "greatestworks/aop/metrics"
 	metrics "greatestworks/aop/metrics/impl"
 )
 
@@ -10,25 +11,25 @@ var (
 	// TODO(mwhittaker): Allow the user to disable these metrics.
 	// It adds ~169ns of latency per method call.
 	MethodCounts = metrics.NewCounterMap[MethodLabels](
-		"serviceweaver_remote_method_count",
+		imetrics.MethodCountsName,
 		"Count of Service Weaver component method invocations",
 	)
 	MethodErrors = metrics.NewCounterMap[MethodLabels](
-		"serviceweaver_remote_method_error_count",
+		imetrics.MethodErrorsName,
 		"Count of Service Weaver component method invocations that result in an error",
 	)
 	MethodLatencies = metrics.NewHistogramMap[MethodLabels](
-		"serviceweaver_remote_method_latency_micros",
+		imetrics.MethodLatenciesName,
 		"Duration, in microseconds, of Service Weaver component method execution",
 		metrics.NonNegativeBuckets,
 	)
 	MethodBytesRequest = metrics.NewHistogramMap[MethodLabels](
-		"serviceweaver_remote_method_bytes_request",
+		imetrics.MethodBytesRequestName,
 		"Number of bytes in Service Weaver component method requests",
 		metrics.NonNegativeBuckets,
 	)
 	MethodBytesReply = metrics.NewHistogramMap[MethodLabels](
-		"serviceweaver_remote_method_bytes_reply",
+		imetrics.MethodBytesReplyName,
 		"Number of bytes in Service Weaver component method replies",
 		metrics.NonNegativeBuckets,
 	)
@@ -3,7 +3,9 @@ package aop
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -16,38 +18,104 @@ import (
 // The section corresponding to the common Service Weaver application
 // configuration is parsed and returned as a *AppConfig.
 //
+// The input may include other config files and reference environment
+// variables (see configfile.go). Every section registered with
+// RegisterConfigSection is checked against its schema, and
 // sectionValidator(key, val) is used to validate every section config entry.
+// Errors are reported with the file and line of the offending section.
 func ParseConfig(file string, input string, sectionValidator func(string, string) error) (*protos.AppConfig, error) {
 	// Extract sections from toml file.
-	var sections map[string]toml.Primitive
-	_, err := toml.Decode(input, &sections)
+	loaded, err := loadConfig(file, input)
 	if err != nil {
 		return nil, err
 	}
 	config := &protos.AppConfig{Sections: map[string]string{}}
-	for k, v := range sections {
+	for k, v := range loaded.sections {
 		var buf strings.Builder
 		err := toml.NewEncoder(&buf).Encode(v)
 		if err != nil {
-			return nil, fmt.Errorf("encoding section %q: %w", k, err)
+			return nil, loaded.errorf(k, "encoding section %q: %w", k, err)
 		}
 		config.Sections[k] = buf.String()
 	}
 
 	// Parse app section.
 	if err := extractApp(file, config); err != nil {
+		if key, ok := sectionKey(config.Sections, appKey, shortAppKey); ok {
+			return nil, loaded.errorf(key, "%w", err)
+		}
 		return nil, err
 	}
 
+	// Check the registered schemas.
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	for key, schema := range schemas {
+		section, ok := sectionKey(config.Sections, key, schema.shortKey)
+		if !ok {
+			continue
+		}
+		if err := schema.check(config.Sections); err != nil {
+			return nil, loaded.errorf(section, "%w", err)
+		}
+	}
+
 	for key, val := range config.Sections {
 		if err := sectionValidator(key, val); err != nil {
-			return nil, err
+			return nil, loaded.errorf(key, "section %q: %w", key, err)
 		}
 	}
 
 	return config, nil
 }
 
+// schema is the schema of a config section registered with
+// RegisterConfigSection.
+type schema struct {
+	shortKey string
+	check    func(sections map[string]string) error
+}
+
+var (
+	schemasMu sync.Mutex
+	schemas   = map[string]schema{} // keyed by section key
+)
+
+// RegisterConfigSection registers T as the schema of the config section with
+// the provided key (and optional short key). ParseConfig decodes every
+// registered section present in a config into a new T, rejecting unknown keys
+// and mistyped values, and, if *T has a Validate() error method, invalid
+// values.
+//
+// RegisterConfigSection is typically called from an init function. It panics
+// if the key is registered more than once.
+func RegisterConfigSection[T any](key, shortKey string) {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	if _, ok := schemas[key]; ok {
+		panic(fmt.Sprintf("config section %q registered more than once", key))
+	}
+	schemas[key] = schema{
+		shortKey: shortKey,
+		check: func(sections map[string]string) error {
+			var dst T
+			return ParseConfigSection(key, shortKey, sections, &dst)
+		},
+	}
+}
+
+// sectionKey returns the key under which the section with the provided key
+// and short key appears in sections, if any.
+func sectionKey(sections map[string]string, key, shortKey string) (string, bool) {
+	if _, ok := sections[key]; ok {
+		return key, true
+	}
+	if _, ok := sections[shortKey]; ok && shortKey != "" {
+		return shortKey, true
+	}
+	return "", false
+}
+
 // ParseConfigSection parses the config section for key into dst.
 // If shortKey is not empty, either key or shortKey is accepted.
 // If the named section is not found, returns nil without changing dst.
@@ -81,19 +149,26 @@ func ParseConfigSection(key, shortKey string, sections map[string]string, dst an
 	return nil
 }
 
-func extractApp(file string, config *protos.AppConfig) error {
-	const appKey = ""
-	const shortAppKey = "greatestworks"
+// Keys of the app section.
+const (
+	appKey      = ""
+	shortAppKey = "greatestworks"
+)
 
+func extractApp(file string, config *protos.AppConfig) error {
 	// appConfig holds the data from under appKey in the TOML config.
 	// It matches the contents of the Config proto.
 	type appConfig struct {
-		Name     string
-		Binary   string
-		Args     []string
-		Env      []string
-		Colocate [][]string
-		Rollout  time.Duration
+		Name      string
+		Binary    string
+		Args      []string
+		Env       []string
+		Colocate  [][]string
+		Rollout   time.Duration
+		Resources map[string]struct {
+			CPUs   float64
+			Memory string
+		}
 	}
 
 	parsed := &appConfig{}
@@ -111,6 +186,23 @@ func extractApp(file string, config *protos.AppConfig) error {
 		group := &protos.ComponentGroup{Components: colocate}
 		config.SameProcess = append(config.SameProcess, group)
 	}
+	for group, r := range parsed.Resources {
+		if r.CPUs < 0 {
+			return fmt.Errorf("resources of %q: negative cpus %v", group, r.CPUs)
+		}
+		limits := &protos.ResourceLimits{Cpus: r.CPUs}
+		if r.Memory != "" {
+			memory, err := parseBytes(r.Memory)
+			if err != nil {
+				return fmt.Errorf("resources of %q: %w", group, err)
+			}
+			limits.MemoryBytes = memory
+		}
+		if config.ResourceLimits == nil {
+			config.ResourceLimits = map[string]*protos.ResourceLimits{}
+		}
+		config.ResourceLimits[group] = limits
+	}
 	if err := canonicalizeConfig(config, filepath.Dir(file)); err != nil {
 		return err
 	}
@@ -162,3 +254,30 @@ func checkSameProcess(c *protos.AppConfig) error {
 	}
 	return nil
 }
+
+// parseBytes parses a memory size like "512MiB", "2G", or "1048576". Decimal
+// (K, M, G, T) and binary (Ki, Mi, Gi, Ti) suffixes are accepted, optionally
+// followed by "B".
+func parseBytes(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		scale  int64
+	}{
+		// Longer suffixes must come first.
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+		{"K", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+	}
+	num := strings.TrimSuffix(strings.TrimSpace(s), "B")
+	scale := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(num, u.suffix) {
+			num, scale = strings.TrimSuffix(num, u.suffix), u.scale
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid memory size %q", s)
+	}
+	return int64(n * float64(scale)), nil
+}
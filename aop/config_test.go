@@ -2,6 +2,7 @@ package aop_test
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -150,3 +151,171 @@ rollout = "hello"
 		})
 	}
 }
+
+func TestResourceLimits(t *testing.T) {
+	const cfg = `
+[greatestworks]
+binary = "/tmp/foo"
+
+[greatestworks.resources."main"]
+cpus = 0.5
+memory = "512MiB"
+
+[greatestworks.resources."github.com/example/Cache"]
+memory = "2G"
+`
+	config, err := aop.ParseConfig("weaver.toml", cfg, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][2]float64{}
+	for group, limits := range config.ResourceLimits {
+		got[group] = [2]float64{limits.Cpus, float64(limits.MemoryBytes)}
+	}
+	want := map[string][2]float64{
+		"main":                     {0.5, 512 << 20},
+		"github.com/example/Cache": {0, 2e9},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("resource limits: (-want +got):\n%s", diff)
+	}
+}
+
+func TestResourceLimitsErrors(t *testing.T) {
+	for _, cfg := range []string{
+		"[greatestworks.resources.main]\nmemory = \"lots\"\n",
+		"[greatestworks.resources.main]\nmemory = \"-1G\"\n",
+		"[greatestworks.resources.main]\ncpus = -1.0\n",
+	} {
+		if _, err := aop.ParseConfig("weaver.toml", cfg, codegen.ComponentConfigValidator); err == nil {
+			t.Errorf("unexpected success for\n%s", cfg)
+		}
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	dir := t.TempDir()
+	for file, contents := range map[string]string{
+		"common.toml": `
+[greatestworks]
+binary = "/tmp/foo"
+args = ["common"]
+
+[shared]
+a = 1
+b = 1
+`,
+		"regions/us.toml": `
+[shared]
+b = 2
+c = 2
+`,
+	} {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const cfg = `
+include = ["common.toml", "regions/us.toml"]
+
+[greatestworks]
+args = ["main"]
+
+[shared]
+c = 3
+`
+	config, err := aop.ParseConfig(filepath.Join(dir, "weaver.toml"), cfg, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := config.Binary, "/tmp/foo"; got != want {
+		t.Errorf("binary: got %q, want %q", got, want)
+	}
+	if diff := cmp.Diff([]string{"main"}, config.Args); diff != "" {
+		t.Errorf("args: (-want +got):\n%s", diff)
+	}
+	type shared struct{ A, B, C int }
+	var got shared
+	if err := aop.ParseConfigSection("shared", "", config.Sections, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(shared{1, 2, 3}, got); diff != "" {
+		t.Errorf("shared: (-want +got):\n%s", diff)
+	}
+}
+
+func TestIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.toml"), filepath.Join(dir, "b.toml")
+	if err := os.WriteFile(a, []byte(`include = "b.toml"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte(`include = "a.toml"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := aop.ParseConfig(a, `include = "b.toml"`, codegen.ComponentConfigValidator)
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("got %v, want include cycle error", err)
+	}
+}
+
+func TestEnvSubstitution(t *testing.T) {
+	t.Setenv("WEAVER_TEST_REGION", "us-east1")
+	const cfg = `
+[greatestworks]
+binary = "/tmp/foo"
+args = ["--region=${WEAVER_TEST_REGION}", "--zone=${WEAVER_TEST_ZONE:-a}", "$${WEAVER_TEST_REGION}"]
+`
+	config, err := aop.ParseConfig("weaver.toml", cfg, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"--region=us-east1", "--zone=a", "${WEAVER_TEST_REGION}"}
+	if diff := cmp.Diff(want, config.Args); diff != "" {
+		t.Fatalf("args: (-want +got):\n%s", diff)
+	}
+
+	// Unset variables without a default are an error.
+	_, err = aop.ParseConfig("weaver.toml", `section = { a = "${WEAVER_TEST_UNSET}" }`, codegen.ComponentConfigValidator)
+	if err == nil || !strings.Contains(err.Error(), "WEAVER_TEST_UNSET") {
+		t.Fatalf("got %v, want unset variable error", err)
+	}
+}
+
+type schemaTestConfig struct {
+	Port int
+}
+
+func (c *schemaTestConfig) Validate() error {
+	if c.Port < 0 {
+		return fmt.Errorf("negative port %d", c.Port)
+	}
+	return nil
+}
+
+func TestConfigSchema(t *testing.T) {
+	aop.RegisterConfigSection[schemaTestConfig]("greatestworks/schematest", "schematest")
+	for _, c := range []struct {
+		cfg  string
+		want string // expected error; "" for success
+	}{
+		{"[schematest]\nport = 8000\n", ""},
+		{"[greatestworks]\nbinary = \"/tmp/foo\"\n\n[schematest]\nport = -1\n", "weaver.toml:4: section \"schematest\": negative port"},
+		{"[\"greatestworks/schematest\"]\nhost = \"localhost\"\n", "weaver.toml:1: section \"greatestworks/schematest\" has unknown keys"},
+		{"[schematest]\nport = \"8000\"\n", "weaver.toml:1"},
+		{"[schematest]\nport = ]\n", "weaver.toml:2: "},
+	} {
+		_, err := aop.ParseConfig("weaver.toml", c.cfg, codegen.ComponentConfigValidator)
+		switch {
+		case c.want == "" && err != nil:
+			t.Errorf("unexpected error for\n%s: %v", c.cfg, err)
+		case c.want != "" && (err == nil || !strings.Contains(err.Error(), c.want)):
+			t.Errorf("error for\n%s: got %v, want %q", c.cfg, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package aop
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// This file loads TOML config files. On top of plain TOML, config files
+// support:
+//
+//   - Includes. A top-level include key lists other config files (e.g.,
+//     shared fragments or per-region files), relative to the including file,
+//     whose sections are merged into the config. Tables are merged key by key;
+//     the including file takes precedence over the files it includes, and
+//     later includes take precedence over earlier ones.
+//
+//         include = ["common.toml", "regions/us-east.toml"]
+//
+//   - Environment variable substitution. ${VAR} in a string value is replaced
+//     with the value of the environment variable VAR, and ${VAR:-default} with
+//     default if VAR is not set. $${VAR} is the literal string ${VAR}.
+
+// includeKey is the top-level key that lists the files included by a config
+// file.
+const includeKey = "include"
+
+// maxIncludeDepth bounds the nesting of included files.
+const maxIncludeDepth = 16
+
+// envVarRegexp matches the environment variable references in a string value.
+var envVarRegexp = regexp.MustCompile(`\$(\$?)\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// configFile is a loaded config file, with its includes resolved and its
+// environment variables substituted.
+type configFile struct {
+	sections  map[string]any
+	positions map[string]string // section key -> "file:line" of its definition
+}
+
+// loadConfig loads the provided config file, whose contents are input.
+func loadConfig(file, input string) (*configFile, error) {
+	c := &configFile{sections: map[string]any{}, positions: map[string]string{}}
+	if err := c.load(file, input, nil); err != nil {
+		return nil, err
+	}
+	for key, val := range c.sections {
+		expanded, err := expandEnv(val)
+		if err != nil {
+			return nil, c.errorf(key, "section %q: %w", key, err)
+		}
+		c.sections[key] = expanded
+	}
+	return c, nil
+}
+
+// ResolveConfig returns the provided config file, whose contents are input,
+// with its includes inlined and its environment variables substituted. The
+// returned config can be parsed without access to the included files or the
+// environment.
+func ResolveConfig(file, input string) (string, error) {
+	c, err := loadConfig(file, input)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(c.sections); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// load merges the sections of the provided file into c. stack holds the
+// files that (transitively) include file.
+func (c *configFile) load(file, input string, stack []string) error {
+	if len(stack) > maxIncludeDepth {
+		return fmt.Errorf("%s: includes nested too deeply", file)
+	}
+	if abs, err := filepath.Abs(file); err == nil {
+		for _, f := range stack {
+			if f == abs {
+				return fmt.Errorf("%s: include cycle: %s", file, strings.Join(append(stack, abs), " -> "))
+			}
+		}
+		stack = append(stack, abs)
+	}
+
+	var sections map[string]any
+	if _, err := toml.Decode(input, &sections); err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			msg := perr.Message
+			if msg == "" {
+				msg = perr.Error()
+			}
+			return fmt.Errorf("%s:%d: %s", file, perr.Position.Line, msg)
+		}
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	// Merge the included files first, so that this file takes precedence.
+	if v, ok := sections[includeKey]; ok {
+		delete(sections, includeKey)
+		includes, err := includedFiles(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		for _, include := range includes {
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(file), include)
+			}
+			contents, err := os.ReadFile(include)
+			if err != nil {
+				return fmt.Errorf("%s: include: %w", file, err)
+			}
+			if err := c.load(include, string(contents), stack); err != nil {
+				return err
+			}
+		}
+	}
+
+	lines := sectionLines(input)
+	for key, val := range sections {
+		c.sections[key] = merge(c.sections[key], val)
+		if line, ok := lines[key]; ok {
+			c.positions[key] = fmt.Sprintf("%s:%d", file, line)
+		} else if _, ok := c.positions[key]; !ok {
+			c.positions[key] = file
+		}
+	}
+	return nil
+}
+
+// errorf returns an error about the provided section, prefixed with the
+// position of the section.
+func (c *configFile) errorf(key, format string, args ...any) error {
+	pos := c.positions[key]
+	if pos == "" {
+		pos = "config"
+	}
+	return fmt.Errorf("%s: %w", pos, fmt.Errorf(format, args...))
+}
+
+// includedFiles returns the files listed by the value of an include key.
+func includedFiles(v any) ([]string, error) {
+	switch x := v.(type) {
+	case string:
+		return []string{x}, nil
+	case []any:
+		files := make([]string, len(x))
+		for i, f := range x {
+			s, ok := f.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid include %v: want a file name", f)
+			}
+			files[i] = s
+		}
+		return files, nil
+	default:
+		return nil, fmt.Errorf("invalid include %v: want a file name or a list of file names", v)
+	}
+}
+
+// merge merges src into dst, and returns the result. Tables are merged
+// recursively; any other value in src replaces the value in dst.
+func merge(dst, src any) any {
+	d, ok1 := dst.(map[string]any)
+	s, ok2 := src.(map[string]any)
+	if !ok1 || !ok2 {
+		return src
+	}
+	for k, v := range s {
+		d[k] = merge(d[k], v)
+	}
+	return d
+}
+
+// expandEnv substitutes the environment variables referenced by the string
+// values in v.
+func expandEnv(v any) (any, error) {
+	switch x := v.(type) {
+	case string:
+		var err error
+		expanded := envVarRegexp.ReplaceAllStringFunc(x, func(ref string) string {
+			m := envVarRegexp.FindStringSubmatch(ref)
+			if m[1] != "" {
+				// Escaped reference.
+				return ref[1:]
+			}
+			if val, ok := os.LookupEnv(m[2]); ok {
+				return val
+			}
+			if strings.Contains(ref, ":-") {
+				return m[3]
+			}
+			if err == nil {
+				err = fmt.Errorf("environment variable %s is not set", m[2])
+			}
+			return ref
+		})
+		return expanded, err
+	case []any:
+		for i, elem := range x {
+			expanded, err := expandEnv(elem)
+			if err != nil {
+				return nil, err
+			}
+			x[i] = expanded
+		}
+		return x, nil
+	case []map[string]any:
+		for _, elem := range x {
+			if _, err := expandEnv(elem); err != nil {
+				return nil, err
+			}
+		}
+		return x, nil
+	case map[string]any:
+		for k, elem := range x {
+			expanded, err := expandEnv(elem)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			x[k] = expanded
+		}
+		return x, nil
+	default:
+		return v, nil
+	}
+}
+
+// sectionHeaderRegexp matches a table header (e.g., [ssh] or ["a/b".c]) or a
+// top-level key (e.g., ssh = {...}), capturing the first key of the header.
+var sectionHeaderRegexp = regexp.MustCompile(`^\s*(?:\[\s*)?(?:"([^"]*)"|'([^']*)'|([A-Za-z0-9_-]+))`)
+
+// sectionLines returns the line at which every section of the provided TOML
+// input is first defined.
+func sectionLines(input string) map[string]int {
+	lines := map[string]int{}
+	inTable := false
+	for i, line := range strings.Split(input, "\n") {
+		trimmed := strings.TrimSpace(line)
+		isHeader := strings.HasPrefix(trimmed, "[")
+		if !isHeader && (inTable || !strings.Contains(trimmed, "=")) {
+			// Keys within a table belong to the table's section.
+			continue
+		}
+		if isHeader {
+			inTable = true
+			trimmed = strings.TrimLeft(trimmed, "[")
+		}
+		m := sectionHeaderRegexp.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		key := m[1] + m[2] + m[3]
+		if _, ok := lines[key]; !ok {
+			lines[key] = i + 1
+		}
+	}
+	return lines
+}
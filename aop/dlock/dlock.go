@@ -0,0 +1,352 @@
+// Package dlock implements locks shared by the processes of a deployment,
+// for the critical sections spanning servers (e.g., the creation of a
+// family, the settlement of an auction, the rollover of a season).
+//
+// A lock is a lease in a Store (Redis, see RedisStore), renewed by its holder
+// while held: if the holder crashes or stalls, the lock is released when the
+// lease runs out. A stalled holder may then resume while another process
+// holds the lock; so every acquisition gets a fencing token, larger than the
+// tokens of the previous holders, which the writes of the critical section
+// pass to the resources they change, so that these reject the writes of
+// stale holders (e.g., a MongoDB update filtered on a "fence" field of the
+// document not greater than the token, which sets it to the token).
+//
+// The names of the locks are of the form "kind:id" (e.g.,
+// "family:name:foo"). The tokens increase per kind, and the metrics are by
+// kind.
+package dlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"greatestworks/aop/fn"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/redis"
+)
+
+var (
+	// ErrLocked is returned by TryLock when another holder has the lock.
+	ErrLocked = errors.New("dlock: locked")
+	// ErrTimeout is returned by Lock when the lock isn't acquired within
+	// Options.Wait.
+	ErrTimeout = errors.New("dlock: timed out waiting for the lock")
+)
+
+var (
+	waits = metrics.NewHistogramMap[kindLabels](
+		"dlock_wait_millis",
+		"Time waited to acquire the locks, in milliseconds",
+		metrics.NonNegativeBuckets,
+	)
+	holds = metrics.NewHistogramMap[kindLabels](
+		"dlock_hold_millis",
+		"Time the locks were held, in milliseconds",
+		metrics.NonNegativeBuckets,
+	)
+	failures = metrics.NewCounterMap[failureLabels](
+		"dlock_acquire_failures",
+		"Number of locks not acquired",
+	)
+	lost = metrics.NewCounterMap[kindLabels](
+		"dlock_lost",
+		"Number of locks lost by their holder, whose lease ran out before it was renewed",
+	)
+	stuck = metrics.NewCounterMap[kindLabels](
+		"dlock_stuck_waits",
+		"Number of waits for locks longer than Options.WarnAfter, possibly deadlocked",
+	)
+)
+
+type kindLabels struct {
+	Kind string
+}
+
+type failureLabels struct {
+	Kind   string
+	Reason string // "locked", "timeout" or "error"
+}
+
+// Options are the options of a Locker.
+type Options struct {
+	// TTL is the lease of a lock, renewed by its holder every TTL/3: the lock
+	// is released once its holder crashed or stalled for TTL. Defaults to 10
+	// seconds.
+	TTL time.Duration
+	// Wait is how long Lock waits for a lock. Defaults to 5 seconds.
+	Wait time.Duration
+	// Retry is how often Lock tries again while waiting. Defaults to 50ms.
+	Retry time.Duration
+	// WarnAfter is how long Lock waits before logging the holder of the lock,
+	// and the locks held by this process, which may be deadlocked. Defaults
+	// to 3 seconds.
+	WarnAfter time.Duration
+	// Owner is the name of this process, recorded in its locks. Defaults to
+	// the hostname and the pid.
+	Owner string
+}
+
+func (o *Options) setDefaults() {
+	if o.TTL <= 0 {
+		o.TTL = 10 * time.Second
+	}
+	if o.Wait <= 0 {
+		o.Wait = 5 * time.Second
+	}
+	if o.Retry <= 0 {
+		o.Retry = 50 * time.Millisecond
+	}
+	if o.WarnAfter <= 0 {
+		o.WarnAfter = 3 * time.Second
+	}
+	if o.Owner == "" {
+		host, _ := os.Hostname()
+		o.Owner = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+}
+
+// Store stores the leases of the locks.
+type Store interface {
+	// Acquire takes the lock of name for owner, for ttl, if it's free, and
+	// returns a fencing token, larger than the tokens returned before for
+	// the locks of the same kind. If the lock is held, it returns 0, the
+	// owner of the holder, and the time left on its lease.
+	Acquire(ctx context.Context, name, owner string, ttl time.Duration) (token uint64, holder string, left time.Duration, err error)
+	// Extend extends the lease of a lock held by owner with token to ttl,
+	// and returns false if the lock isn't held with token anymore.
+	Extend(ctx context.Context, name, owner string, token uint64, ttl time.Duration) (bool, error)
+	// Release releases a lock held by owner with token, unless it isn't
+	// held with token anymore.
+	Release(ctx context.Context, name, owner string, token uint64) error
+}
+
+// Locker acquires locks in a store, for a process.
+type Locker struct {
+	store Store
+	opts  Options
+
+	mu   sync.Mutex
+	held map[*Lock]struct{} // locks held by the process; guarded by mu
+}
+
+// New returns a locker of the locks of a store.
+func New(store Store, opts Options) *Locker {
+	opts.setDefaults()
+	return &Locker{store: store, opts: opts, held: map[*Lock]struct{}{}}
+}
+
+var defaultLocker = fn.NewDefault(func() *Locker {
+	return New(NewRedisStore(redis.Get()), Options{})
+})
+
+// SetDefault replaces the locker of the process, e.g., at start with the
+// options configured, or in tests.
+func SetDefault(l *Locker) {
+	defaultLocker.Set(l)
+}
+
+// Get returns the locker of the process: the one set by SetDefault, or else
+// a locker of the Redis of the process with the default options.
+func Get() *Locker {
+	return defaultLocker.Get()
+}
+
+// Lock is a lock held. Its holder must release it with Unlock.
+type Lock struct {
+	l        *Locker
+	name     string
+	token    uint64
+	acquired time.Time
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stop     chan struct{} // closed by Unlock
+	once     sync.Once
+}
+
+// Name returns the name of the lock.
+func (k *Lock) Name() string {
+	return k.name
+}
+
+// Token returns the fencing token of the lock, to pass to the writes of the
+// critical section.
+func (k *Lock) Token() uint64 {
+	return k.token
+}
+
+// Context returns a context canceled when the lock is lost, i.e., its lease
+// ran out before it was renewed, or released. The critical section should
+// run in it, so that it stops once another process may hold the lock.
+func (k *Lock) Context() context.Context {
+	return k.ctx
+}
+
+// kind returns the kind of a lock name, e.g., "family" for "family:name:foo".
+func kind(name string) string {
+	k, _, _ := strings.Cut(name, ":")
+	return k
+}
+
+// TryLock acquires the lock of name if it's free, or returns ErrLocked. The
+// context of the lock (see Lock.Context) is derived from ctx.
+func (l *Locker) TryLock(ctx context.Context, name string) (*Lock, error) {
+	token, holder, left, err := l.store.Acquire(ctx, name, l.opts.Owner, l.opts.TTL)
+	if err != nil {
+		failures.Get(failureLabels{Kind: kind(name), Reason: "error"}).Add(1)
+		return nil, fmt.Errorf("dlock: %s: %w", name, err)
+	}
+	if token == 0 {
+		failures.Get(failureLabels{Kind: kind(name), Reason: "locked"}).Add(1)
+		return nil, fmt.Errorf("%w: %s held by %s for %v", ErrLocked, name, holder, left)
+	}
+	waits.Get(kindLabels{Kind: kind(name)}).Put(0)
+	return l.hold(ctx, name, token), nil
+}
+
+// Lock acquires the lock of name, waiting for it up to Options.Wait, or
+// until ctx is done. If it waits longer than Options.WarnAfter, it logs the
+// holder of the lock, and the locks held by this process, which may
+// deadlock. The context of the lock (see Lock.Context) is derived from ctx.
+func (l *Locker) Lock(ctx context.Context, name string) (*Lock, error) {
+	start := time.Now()
+	deadline := start.Add(l.opts.Wait)
+	warned := false
+	for {
+		token, holder, left, err := l.store.Acquire(ctx, name, l.opts.Owner, l.opts.TTL)
+		if err != nil {
+			failures.Get(failureLabels{Kind: kind(name), Reason: "error"}).Add(1)
+			return nil, fmt.Errorf("dlock: %s: %w", name, err)
+		}
+		now := time.Now()
+		if token != 0 {
+			waits.Get(kindLabels{Kind: kind(name)}).Put(float64(now.Sub(start).Milliseconds()))
+			return l.hold(ctx, name, token), nil
+		}
+		if !warned && now.Sub(start) >= l.opts.WarnAfter {
+			warned = true
+			stuck.Get(kindLabels{Kind: kind(name)}).Add(1)
+			l.warn(name, holder, left, now.Sub(start))
+		}
+		if !now.Before(deadline) {
+			failures.Get(failureLabels{Kind: kind(name), Reason: "timeout"}).Add(1)
+			return nil, fmt.Errorf("%w: %s held by %s for %v, waited %v", ErrTimeout, name, holder, left, now.Sub(start))
+		}
+		wait := l.opts.Retry
+		if left > 0 && left < wait {
+			wait = left
+		}
+		if rest := deadline.Sub(now); rest < wait {
+			wait = rest
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			failures.Get(failureLabels{Kind: kind(name), Reason: "timeout"}).Add(1)
+			return nil, fmt.Errorf("dlock: %s: %w", name, ctx.Err())
+		}
+	}
+}
+
+// Do runs fn holding the lock of name, acquired with Lock, and releases it
+// after. fn gets the context of the lock, and its fencing token.
+func (l *Locker) Do(ctx context.Context, name string, fn func(ctx context.Context, token uint64) error) error {
+	k, err := l.Lock(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer k.Unlock()
+	return fn(k.Context(), k.Token())
+}
+
+// warn logs a long wait for a lock: who holds it, and which locks this
+// process holds, since waiting for a lock while holding another is how
+// processes deadlock.
+func (l *Locker) warn(name, holder string, left, waited time.Duration) {
+	var mine []string
+	l.mu.Lock()
+	for k := range l.held {
+		mine = append(mine, fmt.Sprintf("%s(%v)", k.name, time.Since(k.acquired).Round(time.Millisecond)))
+	}
+	l.mu.Unlock()
+	sort.Strings(mine)
+	if holder == l.opts.Owner {
+		logger.Error("[dlock] waited %v for lock %v held by this process (lease %v), deadlock? held here:%v", waited, name, left, mine)
+		return
+	}
+	logger.Warn("[dlock] waited %v for lock %v held by %v (lease %v), held here:%v", waited, name, holder, left, mine)
+}
+
+// hold returns a lock acquired, and starts renewing it.
+func (l *Locker) hold(ctx context.Context, name string, token uint64) *Lock {
+	k := &Lock{l: l, name: name, token: token, acquired: time.Now(), stop: make(chan struct{})}
+	k.ctx, k.cancel = context.WithCancel(ctx)
+	l.mu.Lock()
+	l.held[k] = struct{}{}
+	l.mu.Unlock()
+	go k.renew()
+	return k
+}
+
+// renew extends the lease of the lock every TTL/3, until it's released or
+// lost: either another process took it, or the lease wasn't extended for a
+// TTL (e.g., Redis was unreachable), in which case another process may
+// take it.
+func (k *Lock) renew() {
+	ttl := k.l.opts.TTL
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	extended := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+		case <-k.stop:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		ok, err := k.l.store.Extend(ctx, k.name, k.l.opts.Owner, k.token, ttl)
+		cancel()
+		switch {
+		case err == nil && ok:
+			extended = time.Now()
+			continue
+		case err == nil:
+			logger.Error("[dlock] lock %v token %v lost: taken over", k.name, k.token)
+		case time.Since(extended) < ttl:
+			logger.Warn("[dlock] renew lock %v token %v err:%v", k.name, k.token, err)
+			continue
+		default:
+			logger.Error("[dlock] lock %v token %v lost: not renewed for %v, err:%v", k.name, k.token, time.Since(extended), err)
+		}
+		lost.Get(kindLabels{Kind: kind(k.name)}).Add(1)
+		k.cancel()
+		return
+	}
+}
+
+// Unlock releases the lock, unless it was lost, and cancels its context.
+// It's a no-op after the first call.
+func (k *Lock) Unlock() {
+	k.once.Do(func() {
+		close(k.stop)
+		k.cancel()
+		k.l.mu.Lock()
+		delete(k.l.held, k)
+		k.l.mu.Unlock()
+		holds.Get(kindLabels{Kind: kind(k.name)}).Put(float64(time.Since(k.acquired).Milliseconds()))
+		ctx, cancel := context.WithTimeout(context.Background(), k.l.opts.TTL/3)
+		defer cancel()
+		if err := k.l.store.Release(ctx, k.name, k.l.opts.Owner, k.token); err != nil {
+			// The lease runs out anyway.
+			logger.Warn("[dlock] release lock %v token %v err:%v", k.name, k.token, err)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package dlock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/spoor"
+	"greatestworks/aop/logger"
+)
+
+// TestMain sets up the logger, which the package logs its failures with.
+func TestMain(m *testing.M) {
+	logger.SetLogging(&logger.LoggingSetting{WriterOption: spoor.WithConsoleWriter(os.Stderr)})
+	os.Exit(m.Run())
+}
+
+// memStore is a Store in memory.
+type memStore struct {
+	mu     sync.Mutex
+	locks  map[string]memLease
+	fences map[string]uint64
+	down   bool // fail the calls, as if the store were unreachable
+}
+
+type memLease struct {
+	owner   string
+	token   uint64
+	expires time.Time
+}
+
+func newMemStore() *memStore {
+	return &memStore{locks: map[string]memLease{}, fences: map[string]uint64{}}
+}
+
+// lease returns the lease of a lock, if it hasn't run out.
+//
+// REQUIRES: s.mu is held.
+func (s *memStore) lease(name string) (memLease, bool) {
+	l, ok := s.locks[name]
+	if ok && time.Now().After(l.expires) {
+		delete(s.locks, name)
+		return memLease{}, false
+	}
+	return l, ok
+}
+
+func (s *memStore) Acquire(_ context.Context, name, owner string, ttl time.Duration) (uint64, string, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return 0, "", 0, errors.New("down")
+	}
+	if l, ok := s.lease(name); ok {
+		return 0, l.owner, time.Until(l.expires), nil
+	}
+	s.fences[kind(name)]++
+	token := s.fences[kind(name)]
+	s.locks[name] = memLease{owner: owner, token: token, expires: time.Now().Add(ttl)}
+	return token, "", 0, nil
+}
+
+func (s *memStore) Extend(_ context.Context, name, owner string, token uint64, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return false, errors.New("down")
+	}
+	l, ok := s.lease(name)
+	if !ok || l.owner != owner || l.token != token {
+		return false, nil
+	}
+	l.expires = time.Now().Add(ttl)
+	s.locks[name] = l
+	return true, nil
+}
+
+func (s *memStore) Release(_ context.Context, name, owner string, token uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.lease(name); ok && l.owner == owner && l.token == token {
+		delete(s.locks, name)
+	}
+	return nil
+}
+
+func (s *memStore) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func TestTryLock(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	a := New(store, Options{Owner: "a"})
+	b := New(store, Options{Owner: "b"})
+
+	k1, err := a.TryLock(ctx, "family:name:foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.TryLock(ctx, "family:name:foo"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("TryLock of a lock held: got %v, want ErrLocked", err)
+	}
+	// Other names of the kind are free, with larger tokens.
+	k2, err := b.TryLock(ctx, "family:name:bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k2.Token() <= k1.Token() {
+		t.Errorf("token %d after token %d", k2.Token(), k1.Token())
+	}
+	k2.Unlock()
+
+	k1.Unlock()
+	k1.Unlock() // no-op
+	if k1.Context().Err() == nil {
+		t.Error("context of a lock released not canceled")
+	}
+	k3, err := b.TryLock(ctx, "family:name:foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k3.Unlock()
+	if k3.Token() <= k2.Token() {
+		t.Errorf("token %d after token %d", k3.Token(), k2.Token())
+	}
+}
+
+func TestLockWaits(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	a := New(store, Options{Owner: "a"})
+	b := New(store, Options{Owner: "b", Wait: time.Second, Retry: 5 * time.Millisecond})
+
+	k, err := a.Lock(ctx, "auction:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(50*time.Millisecond, k.Unlock)
+	start := time.Now()
+	var ran bool
+	err = b.Do(ctx, "auction:1", func(ctx context.Context, token uint64) error {
+		ran = token > k.Token()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Error("critical section didn't run with a larger token")
+	}
+	if waited := time.Since(start); waited < 40*time.Millisecond {
+		t.Errorf("acquired after %v, before the release", waited)
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	a := New(store, Options{Owner: "a"})
+	b := New(store, Options{Owner: "b", Wait: 30 * time.Millisecond, Retry: 5 * time.Millisecond, WarnAfter: 10 * time.Millisecond})
+	k, err := a.Lock(ctx, "rank:season:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Unlock()
+	if _, err := b.Lock(ctx, "rank:season:1"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Lock of a lock held: got %v, want ErrTimeout", err)
+	}
+}
+
+func TestRenew(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	a := New(store, Options{Owner: "a", TTL: 30 * time.Millisecond})
+	b := New(store, Options{Owner: "b", TTL: 30 * time.Millisecond})
+	k, err := a.Lock(ctx, "auction:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Unlock()
+	// Held well beyond its TTL, as it's renewed.
+	time.Sleep(100 * time.Millisecond)
+	if _, err := b.TryLock(ctx, "auction:1"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("TryLock of a lock renewed: got %v, want ErrLocked", err)
+	}
+	if k.Context().Err() != nil {
+		t.Fatal("context of a lock renewed canceled")
+	}
+}
+
+func TestLost(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	a := New(store, Options{Owner: "a", TTL: 30 * time.Millisecond})
+	k, err := a.Lock(ctx, "auction:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Unlock()
+
+	// The lease isn't renewed, and runs out.
+	store.setDown(true)
+	select {
+	case <-k.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("context of a lock lost not canceled")
+	}
+	store.setDown(false)
+	b := New(store, Options{Owner: "b"})
+	k2, err := b.TryLock(ctx, "auction:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k2.Unlock()
+	// The stale holder's release doesn't release the new holder's lock.
+	k.Unlock()
+	if _, err := a.TryLock(ctx, "auction:1"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("TryLock after a stale release: got %v, want ErrLocked", err)
+	}
+}
@@ -0,0 +1,90 @@
+package dlock
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/redis"
+)
+
+// KEYS = [lock, fence], ARGV = [owner, ttl in ms]. Returns [token, holder,
+// lease left in ms]; token is 0 if the lock is held.
+var acquireScript = redis.NewScript("dlock.acquire", `
+local v = redis.call('get', KEYS[1])
+if v then
+	return {0, v, redis.call('pttl', KEYS[1])}
+end
+local token = redis.call('incr', KEYS[2])
+redis.call('set', KEYS[1], token .. ' ' .. ARGV[1], 'px', ARGV[2])
+return {token, '', 0}`)
+
+// KEYS = [lock], ARGV = [value, ttl in ms]
+var extendScript = redis.NewScript("dlock.extend", `
+if redis.call('get', KEYS[1]) == ARGV[1] then
+	return redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 0`)
+
+// KEYS = [lock], ARGV = [value]
+var releaseScript = redis.NewScript("dlock.release", `
+if redis.call('get', KEYS[1]) == ARGV[1] then
+	return redis.call('del', KEYS[1])
+end
+return 0`)
+
+// RedisStore stores the leases of the locks in Redis: the lock "kind:id" in
+// the key "{dlock:kind}:kind:id", whose value is the token and the owner of
+// its holder, and the last token of the kind in "{dlock:kind}:fence", which
+// never expires. The locks of a kind are in the same slot of a cluster.
+type RedisStore struct {
+	rdb goredis.UniversalClient
+}
+
+// NewRedisStore returns the store of the locks in Redis.
+func NewRedisStore(rdb goredis.UniversalClient) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+func (s *RedisStore) keys(name string) (lock, fence string) {
+	tag := "{dlock:" + kind(name) + "}"
+	return tag + ":" + name, tag + ":fence"
+}
+
+// value returns the value of a lock held.
+func value(owner string, token uint64) string {
+	return strconv.FormatUint(token, 10) + " " + owner
+}
+
+func (s *RedisStore) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (uint64, string, time.Duration, error) {
+	lock, fence := s.keys(name)
+	res, err := acquireScript.Run(ctx, s.rdb, []string{lock, fence}, owner, ttl.Milliseconds()).Slice()
+	if err != nil {
+		return 0, "", 0, err
+	}
+	if len(res) != 3 {
+		return 0, "", 0, fmt.Errorf("unexpected reply %v", res)
+	}
+	token, _ := res[0].(int64)
+	if token > 0 {
+		return uint64(token), "", 0, nil
+	}
+	v, _ := res[1].(string)
+	_, holder, _ := strings.Cut(v, " ")
+	left, _ := res[2].(int64)
+	return 0, holder, time.Duration(left) * time.Millisecond, nil
+}
+
+func (s *RedisStore) Extend(ctx context.Context, name, owner string, token uint64, ttl time.Duration) (bool, error) {
+	lock, _ := s.keys(name)
+	n, err := extendScript.Run(ctx, s.rdb, []string{lock}, value(owner, token), ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+func (s *RedisStore) Release(ctx context.Context, name, owner string, token uint64) error {
+	lock, _ := s.keys(name)
+	return releaseScript.Run(ctx, s.rdb, []string{lock}, value(owner, token)).Err()
+}
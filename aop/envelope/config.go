@@ -0,0 +1,108 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/protos"
+)
+
+// This file applies config updates (see protos.ConfigUpdate) to the weavelet
+// run by an envelope.
+//
+// The envelope keeps the latest config sections of the weavelet. Updates are
+// pushed to the running weavelet, and a restarted weavelet is started with
+// the latest sections.
+
+var configGeneration = metrics.NewGaugeMap[configLabels](
+	"serviceweaver_envelope_config_generation",
+	"Generation of the config applied to the weavelet",
+)
+
+type configLabels struct {
+	App   string // application name
+	Group string // colocation group name
+}
+
+// UpdateConfig applies the provided config update to the weavelet. Updates
+// of a generation not newer than the latest applied update are ignored.
+func (e *Envelope) UpdateConfig(update *protos.ConfigUpdate) error {
+	e.mu.Lock()
+	if update.Generation <= e.configGen {
+		e.mu.Unlock()
+		return nil
+	}
+	sections := make(map[string]string, len(e.sections)+len(update.Sections))
+	for k, v := range e.sections {
+		sections[k] = v
+	}
+	for k, v := range update.Sections {
+		sections[k] = v
+	}
+	for _, k := range update.Removed {
+		delete(sections, k)
+	}
+	e.sections = sections
+	e.configGen = update.Generation
+	conn := e.conn
+	e.mu.Unlock()
+
+	if err := e.levels.Update(sections); err != nil {
+		// Keep the current log levels, but still push the other sections.
+		e.logger.Error("Unable to update log levels", err, "generation", update.Generation)
+	}
+
+	configGeneration.Get(configLabels{App: e.weavelet.App, Group: e.weavelet.Group.Name}).Set(float64(update.Generation))
+	if conn == nil {
+		// The weavelet is not running. It will receive the latest sections
+		// when it is (re)started.
+		return nil
+	}
+	if err := conn.UpdateConfigRPC(update); err != nil {
+		return fmt.Errorf("weavelet %s: update config: %w", e.weavelet.Id, err)
+	}
+	return nil
+}
+
+// weaveletInfo returns the information to send to a newly started weavelet,
+// along with the generation of its config sections.
+func (e *Envelope) weaveletInfo() (*protos.WeaveletInfo, int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	wlet := proto.Clone(e.weavelet).(*protos.WeaveletInfo)
+	wlet.Sections = e.sections
+	return wlet, e.configGen
+}
+
+// catchUpConfig returns the update that brings a weavelet started with the
+// provided sections of the provided generation up to date, or nil if it is
+// up to date.
+//
+// REQUIRES: e.mu is held.
+func (e *Envelope) catchUpConfig(sections map[string]string, gen int64) *protos.ConfigUpdate {
+	if e.configGen <= gen {
+		return nil
+	}
+	update := &protos.ConfigUpdate{Generation: e.configGen, Sections: e.sections}
+	for k := range sections {
+		if _, ok := e.sections[k]; !ok {
+			update.Removed = append(update.Removed, k)
+		}
+	}
+	return update
+}
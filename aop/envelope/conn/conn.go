@@ -14,11 +14,32 @@
 
 // Package conn implements a bi-directional communication channel between an
 // envelope and a weavelet.
+//
+// The channel is a pair of pipes (see aop.Bootstrap.MakePipes). The protocol
+// spoken over the pipes is versioned, and negotiated when the connection is
+// established (see protos.PipeHandshake):
+//
+//   - Version 1 (legacy): every message is a length-prefixed EnvelopeMsg or
+//     WeaveletMsg (see protomsg.Write).
+//
+//   - Version 2 (framed): every message is a frame, which multiplexes several
+//     logical streams (control, health, logs, metrics, traces) over the pipes:
+//
+//     [4-byte little-endian length][1-byte stream id][EnvelopeMsg or WeaveletMsg]
+//
+//     where the length covers the stream id and the message. Frames of
+//     streams unknown to the receiver are skipped.
+//
+// Peers ignore requests they don't understand (replying with an error if the
+// request expects a reply), so new message types and streams can be added
+// without breaking older envelopes or weavelets during rolling upgrades.
 package conn
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	sync "sync"
 
 	"google.golang.org/protobuf/proto"
@@ -26,17 +47,60 @@ import (
 	"greatestworks/aop/protos"
 )
 
+// Pipe protocol versions. See the package documentation.
+const (
+	legacyVersion = 1 // length-prefixed messages
+	framedVersion = 2 // framed, multiplexed messages
+
+	// pipeVersion is the highest version supported by this package.
+	pipeVersion = framedVersion
+)
+
+// stream identifies a logical stream of messages in the framed protocol.
+type stream byte
+
+// Streams of the framed protocol. New streams must be added at the end, since
+// stream ids are sent over the pipes.
+const (
+	controlStream stream = iota // everything not carried by other streams
+	healthStream                // health status requests and reports
+	logsStream                  // log entries
+	metricsStream               // metric requests and updates
+	tracesStream                // trace spans
+	numStreams                  // number of streams known to this package
+)
+
+// maxFrameSize is the largest supported frame.
+const maxFrameSize = math.MaxInt32
+
 // conn is a bi-directional communication channel that is used in the
 // implementation of EnvelopeConn and WeaveletConn.
 type conn struct {
 	name   string
 	reader io.ReadCloser
 
-	mu      sync.Mutex
-	writer  io.WriteCloser
-	lastId  int64                   // Id used for last request/response pair
-	waiters map[int64]chan response // Response waiters
-	failure error                   // Non-nil when error has been encountered
+	// readVersion is the protocol version of the messages read from reader.
+	// It is only accessed by the goroutine calling recv.
+	readVersion int
+
+	mu           sync.Mutex
+	writer       io.WriteCloser
+	writeVersion int                     // protocol version of written messages
+	lastId       int64                   // Id used for last request/response pair
+	waiters      map[int64]chan response // Response waiters
+	failure      error                   // Non-nil when error has been encountered
+}
+
+// newConn returns a conn that uses (r,w) to carry messages, speaking the
+// legacy protocol until the peers agree on a newer one.
+func newConn(name string, r io.ReadCloser, w io.WriteCloser) conn {
+	return conn{
+		name:         name,
+		reader:       r,
+		writer:       w,
+		readVersion:  legacyVersion,
+		writeVersion: legacyVersion,
+	}
 }
 
 type response struct {
@@ -64,12 +128,129 @@ func setId(msg proto.Message, id int64) {
 	}
 }
 
+// streamOf returns the stream that carries msg in the framed protocol.
+func streamOf(msg proto.Message) stream {
+	switch x := msg.(type) {
+	case *protos.EnvelopeMsg:
+		switch {
+		case x.SendHealthStatus:
+			return healthStream
+		case x.SendMetrics:
+			return metricsStream
+		}
+	case *protos.WeaveletMsg:
+		switch {
+		case x.HealthReport != nil:
+			return healthStream
+		case x.Metrics != nil:
+			return metricsStream
+		case x.LogEntry != nil:
+			return logsStream
+		case x.TraceSpans != nil:
+			return tracesStream
+		}
+	}
+	return controlStream
+}
+
+// writeFrame writes msg to dst as a frame of the provided stream. Use
+// readFrame to read it.
+func writeFrame(dst io.Writer, s stream, msg proto.Message) error {
+	enc, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("write frame: %w", err)
+	}
+	if len(enc) >= maxFrameSize {
+		return fmt.Errorf("write frame: message size %d is too large", len(enc))
+	}
+	// Write the frame with a single call, so that concurrent writers to the
+	// same pipe (if any) don't interleave partial frames.
+	frame := make([]byte, 5+len(enc))
+	binary.LittleEndian.PutUint32(frame, uint32(1+len(enc)))
+	frame[4] = byte(s)
+	copy(frame[5:], enc)
+	if _, err := dst.Write(frame); err != nil {
+		return fmt.Errorf("write frame: %w", err)
+	}
+	return nil
+}
+
+// readFrame reads the next frame of a known stream from src, and writes its
+// message to msg. Frames of unknown streams are skipped.
+func readFrame(src io.Reader, msg proto.Message) (stream, error) {
+	for {
+		var hdr [4]byte
+		if _, err := io.ReadFull(src, hdr[:]); err != nil {
+			return 0, fmt.Errorf("read frame length: %w", err)
+		}
+		n := binary.LittleEndian.Uint32(hdr[:])
+		if n == 0 || n > maxFrameSize {
+			return 0, fmt.Errorf("read frame: invalid frame size %d", n)
+		}
+		data := make([]byte, int(n))
+		if _, err := io.ReadFull(src, data); err != nil {
+			return 0, fmt.Errorf("read frame data %d: %w", n, err)
+		}
+		s := stream(data[0])
+		if s >= numStreams {
+			// The frame was written by a newer peer: skip it.
+			continue
+		}
+		if err := proto.Unmarshal(data[1:], msg); err != nil {
+			return 0, fmt.Errorf("read frame: %w", err)
+		}
+		return s, nil
+	}
+}
+
+// read reads the next message from the pipe, using the current read version.
+func (c *conn) read(msg proto.Message) error {
+	if c.readVersion == legacyVersion {
+		return protomsg.Read(c.reader, msg)
+	}
+	_, err := readFrame(c.reader, msg)
+	return err
+}
+
+// write writes msg to the pipe, using the current write version.
+//
+// REQUIRES: c.mu is held.
+func (c *conn) write(msg proto.Message) error {
+	if c.writeVersion == legacyVersion {
+		return protomsg.Write(c.writer, msg)
+	}
+	return writeFrame(c.writer, streamOf(msg), msg)
+}
+
+// upgradeReads makes recv read messages of the provided protocol version. It
+// must be called by the goroutine calling recv, right after recv returned the
+// last message of the old version.
+func (c *conn) upgradeReads(version int) {
+	c.readVersion = version
+}
+
+// upgradeWrites atomically sends msg, the last message of the old protocol
+// version, and makes later sends use the provided version.
+func (c *conn) upgradeWrites(msg proto.Message, version int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failure != nil {
+		return c.failure
+	}
+	if err := c.write(msg); err != nil {
+		c.cleanupLocked(err)
+		return err
+	}
+	c.writeVersion = version
+	return nil
+}
+
 // recv reads the next request from the pipe and writes it to msg. Note that
 // recv does NOT return RPC replies. These replies are returned directly the
 // invoker of the RPC.
 func (c *conn) recv(msg proto.Message) error {
 	for {
-		if err := protomsg.Read(c.reader, msg); err != nil {
+		if err := c.read(msg); err != nil {
 			c.cleanup(err)
 			return err
 		}
@@ -123,7 +304,7 @@ func (c *conn) send(msg proto.Message) error {
 	}
 
 	var err error
-	if err = protomsg.Write(c.writer, msg); err != nil {
+	if err = c.write(msg); err != nil {
 		c.cleanupLocked(err)
 	}
 	return err
@@ -156,7 +337,7 @@ func (c *conn) startRPC(request proto.Message) chan response {
 	c.waiters[id] = ch
 
 	setId(request, id)
-	if err := protomsg.Write(c.writer, request); err != nil {
+	if err := c.write(request); err != nil {
 		delete(c.waiters, id)
 		c.cleanupLocked(err)
 		ch <- response{nil, err}
@@ -15,6 +15,7 @@
 package conn_test
 
 import (
+	"encoding/binary"
 	"errors"
 	"io"
 	"os"
@@ -22,10 +23,14 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/pprof/profile"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/protobuf/proto"
 	"greatestworks/aop/envelope/conn"
 	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/protomsg"
 	"greatestworks/aop/protos"
 )
 
@@ -80,6 +85,246 @@ func TestMetricPropagation(t *testing.T) {
 	checkValue("TestMetricPropagation.hist", 1000)
 }
 
+func TestConfigUpdate(t *testing.T) {
+	envelope, weavelet := makeConnections(t, &handlerForTest{})
+
+	var mu sync.Mutex
+	var got []*protos.ConfigUpdate
+	weavelet.OnConfigUpdate(func(update *protos.ConfigUpdate) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, update)
+		if _, ok := update.Sections["bad"]; ok {
+			return errors.New("bad section")
+		}
+		return nil
+	})
+
+	update := func(u *protos.ConfigUpdate) error {
+		t.Helper()
+		return envelope.UpdateConfigRPC(u)
+	}
+	if err := update(&protos.ConfigUpdate{Generation: 1, Sections: map[string]string{"a": "1", "b": "2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := update(&protos.ConfigUpdate{Generation: 2, Sections: map[string]string{"a": "3"}, Removed: []string{"b"}}); err != nil {
+		t.Fatal(err)
+	}
+	// Stale updates are ignored.
+	if err := update(&protos.ConfigUpdate{Generation: 1, Sections: map[string]string{"c": "4"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a": "3"}
+	if diff := cmp.Diff(want, weavelet.Sections()); diff != "" {
+		t.Errorf("Sections (-want +got):\n%s", diff)
+	}
+	mu.Lock()
+	if len(got) != 2 {
+		t.Errorf("handler called %d times, want 2", len(got))
+	}
+	mu.Unlock()
+
+	// Errors returned by the handler are propagated to the envelope.
+	err := update(&protos.ConfigUpdate{Generation: 3, Sections: map[string]string{"bad": ""}})
+	if err == nil || !strings.Contains(err.Error(), "bad section") {
+		t.Errorf("UpdateConfigRPC: got %v, want bad section error", err)
+	}
+}
+
+func TestLegacyWeavelet(t *testing.T) {
+	// An old weavelet ignores the version offered by the envelope, and keeps
+	// speaking the legacy protocol.
+	handler := &logHandler{entries: make(chan *protos.LogEntry, 1)}
+	envelope, toWeavelet, fromWeavelet := makeEnvelope(t, handler)
+	var info protos.EnvelopeMsg
+	if err := protomsg.Read(toWeavelet, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.WeaveletInfo == nil || info.Handshake == nil {
+		t.Fatalf("first envelope message: got %v, want weavelet info and handshake", &info)
+	}
+
+	// Weavelet to envelope.
+	entry := &protos.LogEntry{Msg: "hello"}
+	if err := protomsg.Write(fromWeavelet, &protos.WeaveletMsg{LogEntry: entry}); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-handler.entries; got.Msg != entry.Msg {
+		t.Errorf("log entry: got %q, want %q", got.Msg, entry.Msg)
+	}
+
+	// Envelope to weavelet.
+	go func() {
+		var req protos.EnvelopeMsg
+		if err := protomsg.Read(toWeavelet, &req); err != nil {
+			t.Error(err)
+			return
+		}
+		reply := &protos.WeaveletMsg{Id: -req.Id, HealthReport: &protos.HealthReport{Status: protos.HealthStatus_HEALTHY}}
+		if err := protomsg.Write(fromWeavelet, reply); err != nil {
+			t.Error(err)
+		}
+	}()
+	if status, err := envelope.HealthStatusRPC(); err != nil || status != protos.HealthStatus_HEALTHY {
+		t.Errorf("HealthStatusRPC: got (%v, %v), want HEALTHY", status, err)
+	}
+}
+
+func TestLegacyEnvelope(t *testing.T) {
+	// An old envelope doesn't offer a version, so the weavelet keeps
+	// speaking the legacy protocol.
+	wReader, eWriter := makePipe(t) // envelope -> weavelet
+	eReader, wWriter := makePipe(t) // weavelet -> envelope
+	info := &protos.EnvelopeMsg{WeaveletInfo: makeWeaveletInfo()}
+	if err := protomsg.Write(eWriter, info); err != nil {
+		t.Fatal(err)
+	}
+	weavelet, err := conn.NewWeaveletConn(wReader, wWriter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weavelet.SendLogEntry(&protos.LogEntry{Msg: "hello"})
+	var msg protos.WeaveletMsg
+	if err := protomsg.Read(eReader, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.LogEntry == nil || msg.LogEntry.Msg != "hello" {
+		t.Errorf("first weavelet message: got %v, want log entry", &msg)
+	}
+}
+
+func TestFramedWeavelet(t *testing.T) {
+	// A newer weavelet agrees on the framed protocol, and sends frames of a
+	// stream and requests unknown to the envelope, which skips them.
+	handler := &logHandler{entries: make(chan *protos.LogEntry, 1)}
+	_, toWeavelet, fromWeavelet := makeEnvelope(t, handler)
+	var info protos.EnvelopeMsg
+	if err := protomsg.Read(toWeavelet, &info); err != nil {
+		t.Fatal(err)
+	}
+	handshake := &protos.WeaveletMsg{Handshake: &protos.PipeHandshake{Version: 2}}
+	if err := protomsg.Write(fromWeavelet, handshake); err != nil {
+		t.Fatal(err)
+	}
+	var ack protos.EnvelopeMsg
+	if err := protomsg.Read(toWeavelet, &ack); err != nil {
+		t.Fatal(err)
+	}
+	if ack.Handshake.GetVersion() != 2 {
+		t.Fatalf("handshake ack: got %v, want version 2", &ack)
+	}
+
+	writeFrame(t, fromWeavelet, 200, &protos.WeaveletMsg{LogEntry: &protos.LogEntry{Msg: "skipped"}})
+	writeFrame(t, fromWeavelet, 0, &protos.WeaveletMsg{Id: 1}) // unknown request
+	writeFrame(t, fromWeavelet, 2, &protos.WeaveletMsg{LogEntry: &protos.LogEntry{Msg: "hello"}})
+	if got := <-handler.entries; got.Msg != "hello" {
+		t.Errorf("log entry: got %q, want %q", got.Msg, "hello")
+	}
+
+	// The unknown request is answered with an error.
+	var reply protos.EnvelopeMsg
+	if stream := readFrame(t, toWeavelet, &reply); stream != 0 {
+		t.Errorf("reply stream: got %d, want 0", stream)
+	}
+	if reply.Id != -1 || !strings.Contains(reply.Error, "unsupported request") {
+		t.Errorf("reply: got %v, want unsupported request error", &reply)
+	}
+}
+
+func TestProfileGoroutines(t *testing.T) {
+	data, err := conn.Profile(&protos.RunProfiling{ProfileType: protos.ProfileType_Goroutine})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prof, err := profile.ParseData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prof.Sample) == 0 {
+		t.Fatal("empty goroutine profile")
+	}
+}
+
+// makeEnvelope returns an envelope connection, and the pipes of its weavelet.
+func makeEnvelope(t *testing.T, handler conn.EnvelopeHandler) (*conn.EnvelopeConn, io.Reader, io.Writer) {
+	t.Helper()
+	wReader, eWriter := makePipe(t) // envelope -> weavelet
+	eReader, wWriter := makePipe(t) // weavelet -> envelope
+	e, err := conn.NewEnvelopeConn(eReader, eWriter, handler, makeWeaveletInfo())
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.Run() //nolint:errcheck // fails when the test closes the pipes
+	}()
+	t.Cleanup(func() {
+		wWriter.Close()
+		<-done
+	})
+	return e, wReader, wWriter
+}
+
+func makePipe(t *testing.T) (*os.File, *os.File) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+	return r, w
+}
+
+// writeFrame writes msg as a frame of the framed pipe protocol.
+func writeFrame(t *testing.T, w io.Writer, stream byte, msg proto.Message) {
+	t.Helper()
+	enc, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := make([]byte, 5+len(enc))
+	binary.LittleEndian.PutUint32(frame, uint32(1+len(enc)))
+	frame[4] = stream
+	copy(frame[5:], enc)
+	if _, err := w.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFrame reads a frame of the framed pipe protocol into msg, and returns
+// its stream.
+func readFrame(t *testing.T, r io.Reader, msg proto.Message) byte {
+	t.Helper()
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, binary.LittleEndian.Uint32(hdr[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := proto.Unmarshal(data[1:], msg); err != nil {
+		t.Fatal(err)
+	}
+	return data[0]
+}
+
+func makeWeaveletInfo() *protos.WeaveletInfo {
+	return &protos.WeaveletInfo{
+		App:           "app",
+		DeploymentId:  uuid.New().String(),
+		Group:         &protos.ColocationGroup{Name: "group"},
+		GroupId:       uuid.New().String(),
+		Id:            uuid.New().String(),
+		SingleProcess: true,
+		SingleMachine: true,
+	}
+}
+
 func makeConnections(t *testing.T, handler conn.EnvelopeHandler) (*conn.EnvelopeConn, *conn.WeaveletConn) {
 	t.Helper()
 
@@ -98,16 +343,7 @@ func makeConnections(t *testing.T, handler conn.EnvelopeHandler) (*conn.Envelope
 	}
 
 	// Construct the conns.
-	wlet := &protos.WeaveletInfo{
-		App:           "app",
-		DeploymentId:  uuid.New().String(),
-		Group:         &protos.ColocationGroup{Name: "group"},
-		GroupId:       uuid.New().String(),
-		Id:            uuid.New().String(),
-		SingleProcess: true,
-		SingleMachine: true,
-	}
-	e, err := conn.NewEnvelopeConn(eReader, eWriter, handler, wlet)
+	e, err := conn.NewEnvelopeConn(eReader, eWriter, handler, makeWeaveletInfo())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -164,3 +400,11 @@ func (h *handlerForTest) GetAddress(*protos.GetAddressRequest) (*protos.GetAddre
 func (h *handlerForTest) ExportListener(*protos.ExportListenerRequest) (*protos.ExportListenerReply, error) {
 	return nil, nil
 }
+
+// logHandler is a handlerForTest that records log entries.
+type logHandler struct {
+	handlerForTest
+	entries chan *protos.LogEntry
+}
+
+func (h *logHandler) RecvLogEntry(entry *protos.LogEntry) { h.entries <- entry }
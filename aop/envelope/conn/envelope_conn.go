@@ -17,6 +17,7 @@ package conn
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"go.opentelemetry.io/otel/sdk/trace"
 	"greatestworks/aop/metrics"
@@ -75,14 +76,20 @@ type EnvelopeConn struct {
 // weavelet and an envelope. The connection uses (r,w) to carry messages.
 // Synthesized high-level events are passed to h.
 //
-// NewEnvelopeConn sends the provided protos.Weavelet to the weavelet.
+// NewEnvelopeConn sends the provided protos.Weavelet to the weavelet, along
+// with the highest pipe protocol version supported by the envelope. The
+// connection switches to a newer protocol version once the weavelet agrees on
+// it (see protos.PipeHandshake).
 func NewEnvelopeConn(r io.ReadCloser, w io.WriteCloser, h EnvelopeHandler, weavelet *protos.WeaveletInfo) (*EnvelopeConn, error) {
 	e := &EnvelopeConn{
 		handler: h,
-		conn:    conn{name: "envelope", reader: r, writer: w},
+		conn:    newConn("envelope", r, w),
 	}
 
-	req := &protos.EnvelopeMsg{WeaveletInfo: weavelet}
+	req := &protos.EnvelopeMsg{
+		WeaveletInfo: weavelet,
+		Handshake:    &protos.PipeHandshake{Version: pipeVersion},
+	}
 	err := e.send(req)
 	return e, err
 }
@@ -179,10 +186,29 @@ func (e *EnvelopeConn) handleMessage(msg *protos.WeaveletMsg) error {
 			traces[i] = &traceio.ReadSpan{Span: span}
 		}
 		return e.handler.RecvTraceSpans(traces)
+	case msg.Handshake != nil:
+		// The weavelet sent its last message of the legacy protocol. Read the
+		// next messages using the agreed on version, and acknowledge it with
+		// our last message of the legacy protocol.
+		version := int(msg.Handshake.Version)
+		if version < legacyVersion || version > pipeVersion {
+			err := fmt.Errorf("unsupported pipe protocol version %d", version)
+			e.conn.cleanup(err)
+			return err
+		}
+		e.conn.upgradeReads(version)
+		// The weavelet may exit right after the handshake, in which case the
+		// connection is torn down and recv returns an error.
+		//nolint:errcheck // error will be returned on next recv
+		e.conn.upgradeWrites(&protos.EnvelopeMsg{Handshake: msg.Handshake}, version)
+		return nil
 	default:
-		err := fmt.Errorf("unexpected message %v", msg)
-		e.conn.cleanup(err)
-		return err
+		// The message was sent by a newer weavelet. Ignore it, so that
+		// weavelets can be upgraded before their envelope.
+		if msg.Id > 0 {
+			return e.send(errReply(fmt.Errorf("envelope: unsupported request %v", msg)))
+		}
+		return nil
 	}
 }
 
@@ -232,6 +258,20 @@ func (e *EnvelopeConn) DoProfilingRPC(req *protos.RunProfiling) (*protos.Profile
 	return reply.Profile, nil
 }
 
+// UpdateConfigRPC requests the weavelet to apply the provided config update.
+func (e *EnvelopeConn) UpdateConfigRPC(update *protos.ConfigUpdate) error {
+	_, err := e.rpc(&protos.EnvelopeMsg{ConfigUpdate: update})
+	return err
+}
+
+// DrainRPC requests the weavelet to shut down gracefully, waiting at most
+// timeout for its calls in progress to complete (see protos.Drain). It
+// returns once the weavelet has drained.
+func (e *EnvelopeConn) DrainRPC(timeout time.Duration) error {
+	_, err := e.rpc(&protos.EnvelopeMsg{Drain: &protos.Drain{TimeoutNs: int64(timeout)}})
+	return err
+}
+
 func (e *EnvelopeConn) rpc(request *protos.EnvelopeMsg) (*protos.WeaveletMsg, error) {
 	response, err := e.conn.rpc(request)
 	if err != nil {
@@ -16,10 +16,12 @@ package conn
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"greatestworks/aop"
 	"io"
 	"runtime/pprof"
+	"sync"
 	"time"
 
 	"greatestworks/aop/metrics"
@@ -33,6 +35,12 @@ type WeaveletConn struct {
 	conn    conn
 	wlet    *protos.WeaveletInfo
 	metrics metrics.Exporter
+
+	mu            sync.Mutex                       // guards the following fields
+	sections      map[string]string                // latest config sections
+	generation    int64                            // generation of sections
+	configHandler func(*protos.ConfigUpdate) error // see OnConfigUpdate
+	drainHandler  func(context.Context) error      // see OnDrain
 }
 
 // NewWeaveletConn creates the weavelet side of the connection between a
@@ -40,9 +48,11 @@ type WeaveletConn struct {
 // Synthesized high-level events are passed to h.
 //
 // NewWeaveletConn blocks until it receives a protos.Weavelet from the
-// envelope.
+// envelope. If the envelope supports a newer pipe protocol version,
+// NewWeaveletConn agrees on the highest version supported by both (see
+// protos.PipeHandshake).
 func NewWeaveletConn(r io.ReadCloser, w io.WriteCloser) (*WeaveletConn, error) {
-	d := &WeaveletConn{conn: conn{name: "weavelet", reader: r, writer: w}}
+	d := &WeaveletConn{conn: newConn("weavelet", r, w)}
 
 	// Block until a weavelet is received.
 	msg := &protos.EnvelopeMsg{}
@@ -60,6 +70,19 @@ func NewWeaveletConn(r io.ReadCloser, w io.WriteCloser) (*WeaveletConn, error) {
 		d.conn.cleanup(err)
 		return nil, err
 	}
+	d.sections = d.wlet.Sections
+
+	// Old envelopes don't offer a version, and speak the legacy protocol.
+	if offer := msg.Handshake; offer != nil && offer.Version > legacyVersion {
+		version := pipeVersion
+		if int(offer.Version) < version {
+			version = int(offer.Version)
+		}
+		reply := &protos.WeaveletMsg{Handshake: &protos.PipeHandshake{Version: int32(version)}}
+		if err := d.conn.upgradeWrites(reply, version); err != nil {
+			return nil, err
+		}
+	}
 	return d, nil
 }
 
@@ -82,6 +105,83 @@ func (d *WeaveletConn) Weavelet() *protos.WeaveletInfo {
 	return d.wlet
 }
 
+// Sections returns the latest config sections of the weavelet. They are
+// initially the sections in Weavelet(), and change as the envelope pushes
+// config updates.
+func (d *WeaveletConn) Sections() map[string]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.sections
+}
+
+// OnConfigUpdate registers a function that is called whenever the envelope
+// pushes a config update, after Sections() reflects the update. If the
+// function returns an error, the error is reported to the envelope.
+func (d *WeaveletConn) OnConfigUpdate(f func(*protos.ConfigUpdate) error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.configHandler = f
+}
+
+// OnDrain registers a function that is called when the envelope asks the
+// weavelet to shut down gracefully. The function should stop accepting new
+// component method calls (see call.Drainer), wait for the calls in progress
+// to complete or the provided context to be done, and flush the telemetry
+// the weavelet buffers (e.g., trace spans). Once the function returns, the
+// envelope collects the final metrics of the weavelet and terminates it.
+func (d *WeaveletConn) OnDrain(f func(context.Context) error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.drainHandler = f
+}
+
+// drain runs the drain handler, if any, with the provided timeout.
+func (d *WeaveletConn) drain(req *protos.Drain) error {
+	d.mu.Lock()
+	handler := d.drainHandler
+	d.mu.Unlock()
+	if handler == nil {
+		return nil
+	}
+	ctx := context.Background()
+	if req.TimeoutNs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.TimeoutNs))
+		defer cancel()
+	}
+	return handler(ctx)
+}
+
+// applyConfigUpdate applies the provided config update.
+func (d *WeaveletConn) applyConfigUpdate(update *protos.ConfigUpdate) error {
+	d.mu.Lock()
+	if update.Generation <= d.generation {
+		// Stale or duplicate update.
+		d.mu.Unlock()
+		return nil
+	}
+	// Copy the sections, since callers of Sections may still be using them.
+	sections := make(map[string]string, len(d.sections)+len(update.Sections))
+	for k, v := range d.sections {
+		sections[k] = v
+	}
+	for k, v := range update.Sections {
+		sections[k] = v
+	}
+	for _, k := range update.Removed {
+		delete(sections, k)
+	}
+	d.sections = sections
+	d.generation = update.Generation
+	handler := d.configHandler
+	d.mu.Unlock()
+
+	if handler == nil {
+		return nil
+	}
+	return handler(update)
+}
+
 // handleMessage handles all messages initiated by the envelope. Note that
 // this method doesn't handle RPC reply messages sent over by the envelope.
 func (d *WeaveletConn) handleMessage(msg *protos.EnvelopeMsg) error {
@@ -123,10 +223,45 @@ func (d *WeaveletConn) handleMessage(msg *protos.EnvelopeMsg) error {
 			}})
 		}()
 		return nil
+	case msg.ConfigUpdate != nil:
+		if err := d.applyConfigUpdate(msg.ConfigUpdate); err != nil {
+			return d.send(&protos.WeaveletMsg{Id: -msg.Id, Error: err.Error()})
+		}
+		return d.send(&protos.WeaveletMsg{Id: -msg.Id})
+	case msg.Drain != nil:
+		// Draining blocks until the calls in progress complete, and therefore
+		// we process it in a separate goroutine, so that the envelope can
+		// still collect metrics and health status while draining.
+		id := msg.Id
+		req := protomsg.Clone(msg.Drain)
+		go func() {
+			if err := d.drain(req); err != nil {
+				//nolint:errcheck // error will be returned on next send
+				d.send(&protos.WeaveletMsg{Id: -id, Error: err.Error()})
+				return
+			}
+			//nolint:errcheck // error will be returned on next send
+			d.send(&protos.WeaveletMsg{Id: -id})
+		}()
+		return nil
+	case msg.Handshake != nil:
+		// The envelope acknowledged the version we agreed on, and will send
+		// the next messages using it.
+		version := int(msg.Handshake.Version)
+		if version < legacyVersion || version > pipeVersion {
+			err := fmt.Errorf("unsupported pipe protocol version %d", version)
+			d.conn.cleanup(err)
+			return err
+		}
+		d.conn.upgradeReads(version)
+		return nil
 	default:
-		err := fmt.Errorf("unexpected message %v", msg)
-		d.conn.cleanup(err)
-		return err
+		// The message was sent by a newer envelope. Ignore it, so that
+		// envelopes can be upgraded before their weavelets.
+		if msg.Id > 0 {
+			return d.send(&protos.WeaveletMsg{Id: -msg.Id, Error: fmt.Sprintf("weavelet: unsupported request %v", msg)})
+		}
+		return nil
 	}
 }
 
@@ -250,6 +385,15 @@ func Profile(req *protos.RunProfiling) ([]byte, error) {
 		}
 		time.Sleep(dur)
 		pprof.StopCPUProfile()
+	case protos.ProfileType_Goroutine, protos.ProfileType_Block, protos.ProfileType_Mutex:
+		name := map[protos.ProfileType]string{
+			protos.ProfileType_Goroutine: "goroutine",
+			protos.ProfileType_Block:     "block",
+			protos.ProfileType_Mutex:     "mutex",
+		}[req.ProfileType]
+		if err := pprof.Lookup(name).WriteTo(&buf, 0); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, fmt.Errorf("unspecified profile collection type")
 	}
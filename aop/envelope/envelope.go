@@ -27,6 +27,7 @@ import (
 	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
 	"go.opentelemetry.io/otel/sdk/trace"
 	"greatestworks/aop/envelope/conn"
@@ -124,12 +125,15 @@ type Envelope struct {
 	handler  EnvelopeHandler
 	opts     Options
 	logger   logtype.Logger
+	levels   *logging.Levels // log levels of the weavelet (see levels.go)
 
 	mu        sync.Mutex         // guards the following fields
 	process   *os.Process        // the currently running subprocess, or nil
 	conn      *conn.EnvelopeConn // conn to weavelet
 	stopped   bool               // has Stop() been called?
 	profiling bool               // are we currently collecting a profile?
+	sections  map[string]string  // latest config sections (see UpdateConfig)
+	configGen int64              // generation of sections
 }
 
 func NewEnvelope(wlet *protos.WeaveletInfo, config *protos.AppConfig, h EnvelopeHandler, opts Options) (*Envelope, error) {
@@ -138,6 +142,11 @@ func NewEnvelope(wlet *protos.WeaveletInfo, config *protos.AppConfig, h Envelope
 			"unable to create envelope for group %s due to nil handler",
 			logging.ShortenComponent(wlet.Group.Name))
 	}
+	levels := &logging.Levels{}
+	if err := levels.Update(wlet.Sections); err != nil {
+		return nil, fmt.Errorf("unable to create envelope for group %s: %w",
+			logging.ShortenComponent(wlet.Group.Name), err)
+	}
 	logger := logging.FuncLogger{
 		Opts: logging.Options{
 			App:        wlet.App,
@@ -145,6 +154,7 @@ func NewEnvelope(wlet *protos.WeaveletInfo, config *protos.AppConfig, h Envelope
 			Component:  "envelope",
 			Weavelet:   wlet.Id,
 			Attrs:      []string{"serviceweaver/system", ""},
+			Levels:     levels,
 		},
 		Write: h.RecvLogEntry,
 	}
@@ -154,6 +164,8 @@ func NewEnvelope(wlet *protos.WeaveletInfo, config *protos.AppConfig, h Envelope
 		handler:  h,
 		opts:     opts,
 		logger:   logger,
+		levels:   levels,
+		sections: wlet.Sections,
 	}, nil
 }
 
@@ -196,6 +208,11 @@ func (e *Envelope) Run(ctx context.Context) error {
 			// killed. We expect these errors, so we swallow them.
 			return nil
 		}
+		if errors.Is(err, errMemoryLimitExceeded) {
+			// A weavelet killed for exceeding its memory limit is always
+			// restarted, regardless of the restart policy.
+			continue
+		}
 		if e.opts.Restart == Never || (e.opts.Restart == OnFailure && err == nil) {
 			return err
 		}
@@ -216,6 +233,17 @@ func (e *Envelope) HealthStatus() protos.HealthStatus {
 	return healthStatus
 }
 
+// Pid returns the pid of the running weavelet, or 0 if the weavelet is not
+// running.
+func (e *Envelope) Pid() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.process == nil {
+		return 0
+	}
+	return e.process.Pid
+}
+
 // RunProfiling returns weavelet profiling information.
 func (e *Envelope) RunProfiling(_ context.Context, req *protos.RunProfiling) (*protos.Profile, error) {
 	conn := e.getConn()
@@ -254,7 +282,8 @@ func (e *Envelope) runWeavelet(ctx context.Context) error {
 		return fmt.Errorf("cannot create weavelet response pipe: %w", err)
 	}
 
-	conn, err := conn.NewEnvelopeConn(toEnvelope, toWeavelet, e.handler, e.weavelet)
+	wlet, gen := e.weaveletInfo()
+	conn, err := conn.NewEnvelopeConn(toEnvelope, toWeavelet, levelHandler{e.handler, e.levels}, wlet)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Unable to start envelope conn: %v\n", err)
 		return err
@@ -300,11 +329,21 @@ func (e *Envelope) runWeavelet(ctx context.Context) error {
 	e.mu.Lock()
 	e.process = cmd.Process
 	e.mu.Unlock()
+	limitExceeded := e.enforceLimits(cmd.Process)
 
 	// Set the connection only after the weavelet information was sent to the
 	// subprocess. Otherwise, it is possible to send over the pipe information
 	// (e.g., health checks) before the weavelet information was sent.
-	e.setConn(conn)
+	e.mu.Lock()
+	e.conn = conn
+	update := e.catchUpConfig(wlet.Sections, gen)
+	e.mu.Unlock()
+	if update != nil {
+		// The config was updated while the weavelet was starting.
+		if err := conn.UpdateConfigRPC(update); err != nil {
+			e.logger.Error("Failed to update weavelet config", err, "generation", update.Generation)
+		}
+	}
 
 	// Wait for the command to terminate.
 	//
@@ -313,6 +352,13 @@ func (e *Envelope) runWeavelet(ctx context.Context) error {
 	// ignore.
 	wait.Wait()
 	runErr := cmd.Wait()
+	if limitExceeded() {
+		e.mu.Lock()
+		e.process = nil
+		e.conn = nil
+		e.mu.Unlock()
+		return fmt.Errorf("weavelet %s: %w", e.weavelet.Id, errMemoryLimitExceeded)
+	}
 	for _, err := range []error{runErr, stdoutErr, stderrErr, weaveletConnErr} {
 		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, os.ErrClosed) && !errors.Is(err, syscall.ECHILD) {
 			return err
@@ -347,6 +393,39 @@ func (e *Envelope) Stop() error {
 	return nil
 }
 
+// drainFlushTimeout is how long Drain waits for a weavelet to flush its
+// telemetry, on top of the time given to its calls in progress.
+const drainFlushTimeout = 5 * time.Second
+
+// Drain asks the weavelet to shut down gracefully: the weavelet stops
+// accepting new component method calls, waits at most timeout for the calls
+// in progress to complete, and flushes its buffered telemetry (see
+// protos.Drain). The weavelet is not restarted after Drain is called.
+//
+// When Drain returns, the weavelet is still running, so that the caller can
+// collect its final metrics (see ReadMetrics) before calling Stop.
+func (e *Envelope) Drain(timeout time.Duration) error {
+	e.mu.Lock()
+	e.stopped = true
+	conn := e.conn
+	e.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	e.logger.Debug("Draining weavelet", "timeout", timeout)
+	errs := make(chan error, 1)
+	go func() { errs <- conn.DrainRPC(timeout) }()
+	select {
+	case err := <-errs:
+		if err != nil {
+			return fmt.Errorf("drain weavelet %s: %w", e.weavelet.Id, err)
+		}
+		return nil
+	case <-time.After(timeout + drainFlushTimeout):
+		return fmt.Errorf("drain weavelet %s: timed out after %v", e.weavelet.Id, timeout+drainFlushTimeout)
+	}
+}
+
 // ReadMetrics returns the set of all captured metrics.
 func (e *Envelope) ReadMetrics() ([]*metrics.MetricSnapshot, error) {
 	conn := e.getConn()
@@ -32,6 +32,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"golang.org/x/exp/slices"
 	"greatestworks/aop/colors"
 	"greatestworks/aop/envelope/conn"
 	"greatestworks/aop/logging"
@@ -81,6 +82,22 @@ func TestMain(m *testing.M) {
 			},
 			"writetraces": func() error { return writeTraces(conn) },
 			"serve_conn":  func() error { return conn.Run() },
+			"serve_drain": func() error {
+				conn.OnDrain(func(context.Context) error {
+					fmt.Println("drained")
+					return nil
+				})
+				return conn.Run()
+			},
+			"serve_config": func() error {
+				conn.OnConfigUpdate(func(update *protos.ConfigUpdate) error {
+					if _, ok := conn.Sections()["bad"]; ok {
+						return fmt.Errorf("bad config")
+					}
+					return nil
+				})
+				return conn.Run()
+			},
 		}
 		fn, ok := cmds[cmd]
 		if !ok {
@@ -423,6 +440,91 @@ func startEnvelopeWithServing(ctx context.Context, t *testing.T) *Envelope {
 	return e
 }
 
+func TestUpdateConfig(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := &handlerForTest{logSaver: testSaver(t)}
+	wlet, config := wlet(executable, "serve_config")
+	wlet.Sections = map[string]string{"a": "1"}
+	e, err := NewEnvelope(wlet, config, h, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Updates received before the weavelet starts are applied on start.
+	if err := e.UpdateConfig(&protos.ConfigUpdate{Generation: 1, Sections: map[string]string{"b": "2"}}); err != nil {
+		t.Fatal(err)
+	}
+	info, gen := e.weaveletInfo()
+	if want := map[string]string{"a": "1", "b": "2"}; !cmp.Equal(want, info.Sections) || gen != 1 {
+		t.Fatalf("weaveletInfo: got %v@%d, want %v@1", info.Sections, gen, want)
+	}
+
+	go func() {
+		if err := e.Run(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+	for e.getConn() == nil {
+	}
+
+	if err := e.UpdateConfig(&protos.ConfigUpdate{Generation: 2, Removed: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+	err = e.UpdateConfig(&protos.ConfigUpdate{Generation: 3, Sections: map[string]string{"bad": ""}})
+	if err == nil || !strings.Contains(err.Error(), "bad config") {
+		t.Fatalf("UpdateConfig: got %v, want bad config error", err)
+	}
+	// Stale updates are ignored.
+	if err := e.UpdateConfig(&protos.ConfigUpdate{Generation: 2, Sections: map[string]string{"bad": ""}}); err != nil {
+		t.Fatal(err)
+	}
+	info, gen = e.weaveletInfo()
+	if want := map[string]string{"b": "2", "bad": ""}; !cmp.Equal(want, info.Sections) || gen != 3 {
+		t.Fatalf("weaveletInfo: got %v@%d, want %v@3", info.Sections, gen, want)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var lines []string
+	h := &handlerForTest{logSaver: func(entry *protos.LogEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, entry.Msg)
+	}}
+	wlet, config := wlet(executable, "serve_drain")
+	e, err := NewEnvelope(wlet, config, h, Options{Restart: Always})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- e.Run(ctx) }()
+	for e.getConn() == nil {
+	}
+
+	if err := e.Drain(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// The drained weavelet can still report its metrics.
+	if _, err := e.ReadMetrics(); err != nil {
+		t.Fatalf("ReadMetrics after Drain: %v", err)
+	}
+	if err := e.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	// A drained weavelet is not restarted.
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Contains(lines, "drained") {
+		t.Errorf("drain handler not called; output: %v", lines)
+	}
+}
+
 func TestSingleProfile(t *testing.T) {
 	// Test Plan: Start an envelope and send it a heap and a cpu profile
 	// requests, one after another. Verify that both succeed and return
@@ -0,0 +1,41 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope
+
+import (
+	"greatestworks/aop/logging"
+	"greatestworks/aop/protos"
+)
+
+// The envelope enforces the log levels specified in the log_levels config
+// section (see logging.LevelsConfig): the log entries of the weavelet below
+// the level of their component are dropped before they reach the
+// EnvelopeHandler. The levels are updated along with the other config
+// sections (see UpdateConfig), so the debug logs of a component can be
+// turned on and off without restarting the weavelet.
+
+// levelHandler is an EnvelopeHandler that drops the log entries below the
+// level of their component.
+type levelHandler struct {
+	EnvelopeHandler
+	levels *logging.Levels
+}
+
+// RecvLogEntry implements the EnvelopeHandler interface.
+func (h levelHandler) RecvLogEntry(entry *protos.LogEntry) {
+	if h.levels.Allows(entry) {
+		h.EnvelopeHandler.RecvLogEntry(entry)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/protos"
+)
+
+// This file enforces the resource limits of a colocation group (see
+// protos.AppConfig.ResourceLimits) on the weavelets run by an envelope.
+//
+// On Linux, the envelope places every weavelet in its own cgroup (v2) with
+// the configured memory and CPU limits, and the kernel enforces them. If the
+// envelope cannot create a cgroup (e.g., because the cgroup hierarchy is not
+// delegated to the current user), it falls back to polling the resident
+// memory of the weavelet and killing it when it exceeds its memory limit. CPU
+// limits are only enforced with cgroups.
+//
+// Either way, a weavelet killed for exceeding its memory limit is counted in
+// the serviceweaver_envelope_oom_kill_count metric, and restarted by the
+// envelope regardless of its restart policy.
+
+var oomKills = metrics.NewCounterMap[oomLabels](
+	"serviceweaver_envelope_oom_kill_count",
+	"Number of weavelets killed for exceeding their memory limit",
+)
+
+type oomLabels struct {
+	App   string // application name
+	Group string // colocation group name
+}
+
+const (
+	// cgroupRoot is the mount point of the cgroup v2 hierarchy.
+	cgroupRoot = "/sys/fs/cgroup"
+
+	// cpuPeriod is the cgroup CPU accounting period, in microseconds.
+	cpuPeriod = 100000
+
+	// memoryPollInterval is how often the resident memory of a weavelet is
+	// checked when its memory limit cannot be enforced with a cgroup.
+	memoryPollInterval = time.Second
+)
+
+// errMemoryLimitExceeded is returned by runWeavelet if the weavelet was killed
+// for exceeding its memory limit.
+var errMemoryLimitExceeded = errors.New("memory limit exceeded")
+
+// limits returns the resource limits of the weavelet, or nil if it has none.
+func (e *Envelope) limits() *protos.ResourceLimits {
+	limits := e.config.ResourceLimits[e.weavelet.Group.Name]
+	if limits == nil || (limits.Cpus <= 0 && limits.MemoryBytes <= 0) {
+		return nil
+	}
+	return limits
+}
+
+// enforceLimits enforces the resource limits of the weavelet on the provided
+// process. The returned function must be called after the process exits; it
+// reports whether the process was killed for exceeding its memory limit.
+func (e *Envelope) enforceLimits(proc *os.Process) func() bool {
+	limits := e.limits()
+	if limits == nil {
+		return func() bool { return false }
+	}
+
+	// Prefer cgroups, where available.
+	cg, err := newCgroup(e.weavelet.Id, limits)
+	if err == nil {
+		if err = cg.add(proc.Pid); err == nil {
+			return func() bool {
+				kills := cg.oomKills()
+				if err := cg.remove(); err != nil {
+					e.logger.Error("Failed to remove cgroup", err, "path", cg.dir)
+				}
+				if kills == 0 {
+					return false
+				}
+				e.recordOOMKill(proc.Pid, limits)
+				return true
+			}
+		}
+		cg.remove()
+	}
+	if limits.MemoryBytes <= 0 {
+		e.logger.Error("Unable to enforce CPU limit", err, "pid", proc.Pid)
+		return func() bool { return false }
+	}
+	e.logger.Debug("Unable to create cgroup; monitoring memory instead", "err", err, "pid", proc.Pid)
+
+	// Fall back to polling the resident memory of the process.
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	var killed bool
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(memoryPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			rss, err := residentMemory(proc.Pid)
+			if err != nil {
+				// The process has most likely exited.
+				continue
+			}
+			if rss <= limits.MemoryBytes {
+				continue
+			}
+			e.logger.Error("Weavelet exceeded its memory limit", errMemoryLimitExceeded,
+				"pid", proc.Pid, "rss", rss, "limit", limits.MemoryBytes)
+			if err := proc.Kill(); err != nil {
+				e.logger.Error("Failed to kill process", err, "pid", proc.Pid)
+				continue
+			}
+			killed = true
+			return
+		}
+	}()
+	return func() bool {
+		close(done)
+		wg.Wait()
+		if killed {
+			e.recordOOMKill(proc.Pid, limits)
+		}
+		return killed
+	}
+}
+
+// recordOOMKill records that the weavelet running in the process with the
+// provided pid was killed for exceeding its memory limit.
+func (e *Envelope) recordOOMKill(pid int, limits *protos.ResourceLimits) {
+	oomKills.Get(oomLabels{App: e.weavelet.App, Group: e.weavelet.Group.Name}).Add(1)
+	e.logger.Error("Weavelet killed for exceeding its memory limit", errMemoryLimitExceeded,
+		"pid", pid, "limit", limits.MemoryBytes)
+}
+
+// residentMemory returns the resident memory, in bytes, of the process with
+// the provided pid.
+func residentMemory(pid int) (int64, error) {
+	if runtime.GOOS != "linux" {
+		return 0, fmt.Errorf("memory monitoring not supported on %s", runtime.GOOS)
+	}
+	// /proc/<pid>/statm holds "size resident shared ...", in pages.
+	statm, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(statm))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed statm %q", statm)
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed statm %q: %w", statm, err)
+	}
+	return pages * int64(os.Getpagesize()), nil
+}
+
+// cgroup is a cgroup v2 that holds a single weavelet.
+type cgroup struct {
+	dir string // directory of the cgroup
+}
+
+// newCgroup creates a child of the cgroup of the current process, with the
+// provided limits.
+func newCgroup(name string, limits *protos.ResourceLimits) (*cgroup, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("cgroups not supported on %s", runtime.GOOS)
+	}
+	parent, err := ownCgroup()
+	if err != nil {
+		return nil, err
+	}
+
+	// Enable the controllers we need in the children of the parent. This
+	// fails if the parent itself holds processes, unless the controllers are
+	// already enabled.
+	var controllers []string
+	if limits.MemoryBytes > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if limits.Cpus > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	subtree := filepath.Join(parent, "cgroup.subtree_control")
+	if err := os.WriteFile(subtree, []byte(strings.Join(controllers, " ")), 0); err != nil {
+		return nil, fmt.Errorf("enable cgroup controllers: %w", err)
+	}
+
+	cg := &cgroup{dir: filepath.Join(parent, "weavelet-"+name)}
+	if err := os.Mkdir(cg.dir, 0755); err != nil {
+		return nil, fmt.Errorf("create cgroup: %w", err)
+	}
+	if limits.MemoryBytes > 0 {
+		if err := cg.write("memory.max", strconv.FormatInt(limits.MemoryBytes, 10)); err != nil {
+			cg.remove()
+			return nil, err
+		}
+	}
+	if limits.Cpus > 0 {
+		quota := int64(limits.Cpus * cpuPeriod)
+		if err := cg.write("cpu.max", fmt.Sprintf("%d %d", quota, cpuPeriod)); err != nil {
+			cg.remove()
+			return nil, err
+		}
+	}
+	return cg, nil
+}
+
+// ownCgroup returns the directory of the cgroup v2 of the current process.
+func ownCgroup() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	// With cgroup v2, /proc/self/cgroup contains a single "0::<path>" line.
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "0::") {
+			return filepath.Join(cgroupRoot, strings.TrimPrefix(line, "0::")), nil
+		}
+	}
+	return "", fmt.Errorf("cgroup v2 not available")
+}
+
+// add moves the process with the provided pid into the cgroup.
+func (c *cgroup) add(pid int) error {
+	return c.write("cgroup.procs", strconv.Itoa(pid))
+}
+
+// oomKills returns the number of processes in the cgroup killed by the kernel
+// for exceeding the memory limit of the cgroup.
+func (c *cgroup) oomKills() int {
+	data, err := os.ReadFile(filepath.Join(c.dir, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "oom_kill ") {
+			kills, _ := strconv.Atoi(strings.TrimPrefix(line, "oom_kill "))
+			return kills
+		}
+	}
+	return 0
+}
+
+// remove removes the cgroup. The cgroup must not hold any processes.
+func (c *cgroup) remove() error {
+	return os.Remove(c.dir)
+}
+
+// write writes the provided value to the provided cgroup interface file.
+func (c *cgroup) write(file, value string) error {
+	if err := os.WriteFile(filepath.Join(c.dir, file), []byte(value), 0); err != nil {
+		return fmt.Errorf("write cgroup %s: %w", file, err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"greatestworks/aop/protos"
+)
+
+func TestLimits(t *testing.T) {
+	wlet, config := wlet("/bin/true")
+	config.ResourceLimits = map[string]*protos.ResourceLimits{
+		wlet.Group.Name: {MemoryBytes: 1 << 30},
+		"other":         {Cpus: 2},
+	}
+	e, err := NewEnvelope(wlet, config, &handlerForTest{logSaver: testSaver(t)}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.limits().GetMemoryBytes(), int64(1<<30); got != want {
+		t.Errorf("memory limit: got %d, want %d", got, want)
+	}
+
+	// A group with zero limits is not limited.
+	config.ResourceLimits[wlet.Group.Name] = &protos.ResourceLimits{}
+	if got := e.limits(); got != nil {
+		t.Errorf("limits: got %v, want nil", got)
+	}
+}
+
+func TestResidentMemory(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("memory monitoring is only supported on linux")
+	}
+	rss, err := residentMemory(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rss <= 0 {
+		t.Fatalf("resident memory: got %d, want > 0", rss)
+	}
+}
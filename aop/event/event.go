@@ -0,0 +1,220 @@
+// Package event implements an in-process event bus, through which modules
+// react to the events of other modules (e.g., a rank module updating scores
+// when players level up) without depending on them.
+//
+// Events are typed: a subscription receives the events of a single Go type,
+// published with Publish. Every subscription has a bounded queue, drained by
+// a goroutine of its own, so that publishers never wait for subscribers, and
+// a slow subscriber doesn't delay the others. Events published to a full
+// queue are dropped, and counted in the event_bus_dropped_events metric.
+//
+//	type LevelUp struct{ PlayerId uint64; Level uint32 }
+//
+//	sub := event.Subscribe(event.Default, "rank", func(e LevelUp) { ... })
+//	defer sub.Unsubscribe()
+//	event.Publish(event.Default, LevelUp{PlayerId: 42, Level: 10})
+package event
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+var (
+	deliveredEvents = metrics.NewCounterMap[eventLabels](
+		"event_bus_delivered_events",
+		"Number of events handled by event bus subscribers",
+	)
+	droppedEvents = metrics.NewCounterMap[eventLabels](
+		"event_bus_dropped_events",
+		"Number of events dropped because the queue of a subscriber was full",
+	)
+	panickedEvents = metrics.NewCounterMap[eventLabels](
+		"event_bus_panicked_events",
+		"Number of events whose handler panicked",
+	)
+)
+
+type eventLabels struct {
+	Module string // subscribing module
+	Event  string // event type (e.g., "playerevent.LevelUp")
+}
+
+// Default is the event bus shared by the modules of a process.
+var Default = NewBus(Options{})
+
+// Options configures an event bus.
+type Options struct {
+	// QueueSize is the number of events a subscription buffers before
+	// events are dropped. Defaults to 1024.
+	QueueSize int
+
+	// OnPanic, if not nil, is called when a handler panics, with the module
+	// of the subscription, the event, and the recovered value. Handlers that
+	// panic keep receiving events.
+	OnPanic func(module string, event any, recovered any)
+}
+
+func (o Options) withDefaults() Options {
+	if o.QueueSize <= 0 {
+		o.QueueSize = 1024
+	}
+	return o
+}
+
+// Bus is an event bus. The zero value is not usable; use NewBus.
+type Bus struct {
+	opts Options
+
+	mu     sync.Mutex
+	closed bool
+	subs   map[reflect.Type][]*Subscription // by event type
+}
+
+// NewBus returns a new event bus.
+func NewBus(opts Options) *Bus {
+	return &Bus{opts: opts.withDefaults(), subs: map[reflect.Type][]*Subscription{}}
+}
+
+// Subscription is the subscription of a module to the events of a type.
+type Subscription struct {
+	bus    *Bus
+	module string
+	typ    reflect.Type
+	labels eventLabels
+	handle func(any)
+	queue  chan any
+	once   sync.Once
+	done   chan struct{} // closed when the queue is drained
+}
+
+// Subscribe subscribes the provided module to the events of type E: handle is
+// called with every event of type E published on the bus after Subscribe
+// returns, in publication order, on a goroutine of the subscription.
+func Subscribe[E any](b *Bus, module string, handle func(E)) *Subscription {
+	typ := reflect.TypeOf((*E)(nil)).Elem()
+	s := &Subscription{
+		bus:    b,
+		module: module,
+		typ:    typ,
+		labels: eventLabels{Module: module, Event: typ.String()},
+		handle: func(e any) { handle(e.(E)) },
+		queue:  make(chan any, b.opts.QueueSize),
+		done:   make(chan struct{}),
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(s.queue)
+		close(s.done)
+		return s
+	}
+	b.subs[typ] = append(b.subs[typ], s)
+	go s.run()
+	return s
+}
+
+// Publish publishes an event to the subscribers of its type. It never blocks:
+// if the queue of a subscriber is full, the event is dropped for that
+// subscriber.
+func Publish[E any](b *Bus, e E) {
+	typ := reflect.TypeOf((*E)(nil)).Elem()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range b.subs[typ] {
+		select {
+		case s.queue <- e:
+		default:
+			droppedEvents.Get(s.labels).Add(1)
+		}
+	}
+}
+
+// Unsubscribe cancels the subscription. Events already queued are still
+// handled; use Wait to wait for them.
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		b := s.bus
+		b.mu.Lock()
+		subs := b.subs[s.typ]
+		for i, other := range subs {
+			if other == s {
+				b.subs[s.typ] = append(subs[:i:i], subs[i+1:]...)
+				close(s.queue)
+				break
+			}
+		}
+		b.mu.Unlock()
+	})
+}
+
+// Wait waits for the events queued before the subscription was cancelled to
+// be handled.
+func (s *Subscription) Wait() {
+	<-s.done
+}
+
+// UnsubscribeModule cancels the subscriptions of the provided module (e.g.,
+// when it stops), and waits for their queued events to be handled.
+func (b *Bus) UnsubscribeModule(module string) {
+	var subs []*Subscription
+	b.mu.Lock()
+	for _, ss := range b.subs {
+		for _, s := range ss {
+			if s.module == module {
+				subs = append(subs, s)
+			}
+		}
+	}
+	b.mu.Unlock()
+	for _, s := range subs {
+		s.Unsubscribe()
+		s.Wait()
+	}
+}
+
+// Close cancels all subscriptions, and waits for their queued events to be
+// handled. Subscriptions made after Close receive no events.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	b.closed = true
+	var subs []*Subscription
+	for _, ss := range b.subs {
+		subs = append(subs, ss...)
+	}
+	b.mu.Unlock()
+	for _, s := range subs {
+		s.Unsubscribe()
+		s.Wait()
+	}
+}
+
+// run handles the queued events until the subscription is cancelled.
+func (s *Subscription) run() {
+	defer close(s.done)
+	for e := range s.queue {
+		s.deliver(e)
+	}
+}
+
+// deliver handles an event, recovering from panics.
+func (s *Subscription) deliver(e any) {
+	defer func() {
+		if r := recover(); r != nil {
+			panickedEvents.Get(s.labels).Add(1)
+			if s.bus.opts.OnPanic != nil {
+				s.bus.opts.OnPanic(s.module, e, r)
+			}
+		}
+	}()
+	s.handle(e)
+	deliveredEvents.Get(s.labels).Add(1)
+}
+
+// String returns a description of the subscription, for logging.
+func (s *Subscription) String() string {
+	return fmt.Sprintf("%s <- %s", s.module, s.typ)
+}
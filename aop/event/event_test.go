@@ -0,0 +1,113 @@
+package event
+
+import (
+	"sync"
+	"testing"
+)
+
+type levelUp struct {
+	PlayerId uint64
+	Level    uint32
+}
+
+type kill struct {
+	PlayerId uint64
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	b := NewBus(Options{})
+	defer b.Close()
+
+	var got []levelUp
+	sub := Subscribe(b, "rank", func(e levelUp) { got = append(got, e) })
+	var kills int
+	Subscribe(b, "task", func(kill) { kills++ })
+
+	for i := uint32(1); i <= 3; i++ {
+		Publish(b, levelUp{PlayerId: 1, Level: i})
+	}
+	Publish(b, kill{PlayerId: 1})
+	sub.Unsubscribe()
+	sub.Wait()
+
+	if len(got) != 3 {
+		t.Fatalf("got %d events, want 3", len(got))
+	}
+	for i, e := range got {
+		if e.Level != uint32(i+1) {
+			t.Errorf("event %d: got level %d, want %d", i, e.Level, i+1)
+		}
+	}
+
+	// Events published after Unsubscribe aren't delivered.
+	Publish(b, levelUp{PlayerId: 1, Level: 4})
+	b.UnsubscribeModule("task")
+	if len(got) != 3 {
+		t.Errorf("got %d events after unsubscribing, want 3", len(got))
+	}
+	if kills != 1 {
+		t.Errorf("got %d kills, want 1", kills)
+	}
+}
+
+func TestDropWhenFull(t *testing.T) {
+	b := NewBus(Options{QueueSize: 2})
+	defer b.Close()
+
+	started := make(chan struct{}, 1)
+	block := make(chan struct{})
+	var mu sync.Mutex
+	var got int
+	sub := Subscribe(b, "slow", func(levelUp) {
+		started <- struct{}{}
+		<-block
+		mu.Lock()
+		got++
+		mu.Unlock()
+	})
+
+	// One event is being handled, two are queued, the others are dropped.
+	Publish(b, levelUp{Level: 1})
+	<-started
+	for i := 0; i < 5; i++ {
+		Publish(b, levelUp{Level: 2})
+	}
+	close(block)
+	sub.Unsubscribe()
+	go func() {
+		for range started {
+		}
+	}()
+	sub.Wait()
+	close(started)
+	if got != 3 {
+		t.Errorf("got %d events, want 3", got)
+	}
+}
+
+func TestPanickingHandler(t *testing.T) {
+	var panics []any
+	b := NewBus(Options{OnPanic: func(module string, e any, r any) { panics = append(panics, r) }})
+	var got int
+	sub := Subscribe(b, "flaky", func(e levelUp) {
+		if e.Level == 1 {
+			panic("boom")
+		}
+		got++
+	})
+	Publish(b, levelUp{Level: 1})
+	Publish(b, levelUp{Level: 2})
+	b.Close()
+	sub.Wait()
+	if len(panics) != 1 || panics[0] != "boom" {
+		t.Errorf("got panics %v, want [boom]", panics)
+	}
+	if got != 1 {
+		t.Errorf("got %d events, want 1", got)
+	}
+
+	// Subscriptions made after Close receive nothing.
+	late := Subscribe(b, "late", func(levelUp) { t.Error("unexpected event") })
+	Publish(b, levelUp{Level: 3})
+	late.Wait()
+}
@@ -0,0 +1,51 @@
+package fn
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Default is an instance of T shared by a process, e.g., the client of a
+// service: the one Set, or else the one its fallback makes at the first Get.
+// It's safe for concurrent use.
+type Default[T any] struct {
+	v        atomic.Value // box[T]
+	mu       sync.Mutex   // serializes Set and the fallback
+	fallback func() T
+}
+
+// box holds the instance in the atomic.Value, whatever its dynamic type, as
+// for an interface T.
+type box[T any] struct {
+	v T
+}
+
+// NewDefault returns a Default instance of T, made by fallback if none is Set
+// before it's needed.
+func NewDefault[T any](fallback func() T) *Default[T] {
+	return &Default[T]{fallback: fallback}
+}
+
+// Set replaces the instance, e.g., at start with the options configured, or
+// in tests.
+func (d *Default[T]) Set(v T) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.v.Store(box[T]{v: v})
+}
+
+// Get returns the instance: the one Set, or else the one made by the
+// fallback, once.
+func (d *Default[T]) Get() T {
+	if b, ok := d.v.Load().(box[T]); ok {
+		return b.v
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if b, ok := d.v.Load().(box[T]); ok {
+		return b.v
+	}
+	v := d.fallback()
+	d.v.Store(box[T]{v: v})
+	return v
+}
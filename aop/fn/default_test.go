@@ -0,0 +1,40 @@
+package fn
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	made := 0
+	d := NewDefault(func() fmt.Stringer {
+		made++
+		return stringer("fallback")
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.Get()
+		}()
+	}
+	wg.Wait()
+	if got := d.Get().String(); got != "fallback" || made != 1 {
+		t.Fatalf("Get: got %q, made %d times, want the fallback made once", got, made)
+	}
+	// An instance of another type is Set in place of the fallback.
+	d.Set(&pointer{})
+	if got := d.Get().String(); got != "pointer" {
+		t.Fatalf("Get after Set: got %q, want pointer", got)
+	}
+}
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
+
+type pointer struct{}
+
+func (*pointer) String() string { return "pointer" }
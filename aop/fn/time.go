@@ -42,3 +42,38 @@ func GetTimeStampDay0Time(tm int64) int64 {
 	d := time.Unix(tm, 0)
 	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, d.Location()).Unix()
 }
+
+// LastDailyReset returns the last daily reset at or before now: today at
+// hour, or yesterday if it's not hour yet.
+func LastDailyReset(now time.Time, hour int) time.Time {
+	reset := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if reset.After(now) {
+		reset = reset.AddDate(0, 0, -1)
+	}
+	return reset
+}
+
+// LastWeeklyReset returns the last weekly reset at or before now: the last
+// Monday at hour.
+func LastWeeklyReset(now time.Time, hour int) time.Time {
+	return LastWeekdayReset(now, time.Monday, hour)
+}
+
+// LastWeekdayReset returns the last weekly reset at or before now: the last
+// weekday at hour.
+func LastWeekdayReset(now time.Time, weekday time.Weekday, hour int) time.Time {
+	reset := LastDailyReset(now, hour)
+	days := (int(reset.Weekday()) - int(weekday) + 7) % 7 // days since weekday
+	return reset.AddDate(0, 0, -days)
+}
+
+// LastMonthlyReset returns the last monthly reset at or before now: day of
+// this month at hour, or of last month if it's not then yet. day must be
+// within 1 and 28, so that every month has it.
+func LastMonthlyReset(now time.Time, day, hour int) time.Time {
+	reset := time.Date(now.Year(), now.Month(), day, hour, 0, 0, 0, now.Location())
+	if reset.After(now) {
+		reset = reset.AddDate(0, -1, 0)
+	}
+	return reset
+}
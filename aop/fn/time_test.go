@@ -0,0 +1,63 @@
+package fn
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastResets(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	for _, test := range []struct {
+		now, daily, weekly string
+	}{
+		{"2024-05-15 10:00", "2024-05-15 05:00", "2024-05-13 05:00"}, // Wednesday
+		{"2024-05-15 04:59", "2024-05-14 05:00", "2024-05-13 05:00"},
+		{"2024-05-13 05:00", "2024-05-13 05:00", "2024-05-13 05:00"}, // Monday, at the reset
+		{"2024-05-13 04:00", "2024-05-12 05:00", "2024-05-06 05:00"}, // Monday, before the reset
+		{"2024-05-19 23:00", "2024-05-19 05:00", "2024-05-13 05:00"}, // Sunday
+	} {
+		now := at(test.now)
+		if got, want := LastDailyReset(now, 5), at(test.daily); !got.Equal(want) {
+			t.Errorf("LastDailyReset(%s) = %s, want %s", test.now, got, want)
+		}
+		if got, want := LastWeeklyReset(now, 5), at(test.weekly); !got.Equal(want) {
+			t.Errorf("LastWeeklyReset(%s) = %s, want %s", test.now, got, want)
+		}
+	}
+}
+
+func TestLastWeekdayAndMonthlyResets(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	for _, test := range []struct {
+		now, friday, monthly string
+	}{
+		{"2024-05-15 10:00", "2024-05-10 05:00", "2024-05-01 05:00"}, // Wednesday
+		{"2024-05-17 05:00", "2024-05-17 05:00", "2024-05-01 05:00"}, // Friday, at the reset
+		{"2024-05-17 04:00", "2024-05-10 05:00", "2024-05-01 05:00"}, // Friday, before the reset
+		{"2024-05-01 04:59", "2024-04-26 05:00", "2024-04-01 05:00"}, // first of the month, before the reset
+		{"2024-01-01 00:00", "2023-12-29 05:00", "2023-12-01 05:00"}, // new year
+	} {
+		now := at(test.now)
+		if got, want := LastWeekdayReset(now, time.Friday, 5), at(test.friday); !got.Equal(want) {
+			t.Errorf("LastWeekdayReset(%s, Friday) = %s, want %s", test.now, got, want)
+		}
+		if got, want := LastMonthlyReset(now, 1, 5), at(test.monthly); !got.Equal(want) {
+			t.Errorf("LastMonthlyReset(%s, 1) = %s, want %s", test.now, got, want)
+		}
+	}
+	if got, want := LastMonthlyReset(at("2024-03-10 12:00"), 15, 5), at("2024-02-15 05:00"); !got.Equal(want) {
+		t.Errorf("LastMonthlyReset(2024-03-10, 15) = %s, want %s", got, want)
+	}
+}
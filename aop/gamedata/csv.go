@@ -0,0 +1,143 @@
+package gamedata
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// decodeCSV decodes the rows of a CSV file into rows, a pointer to a slice
+// of pointers to structs. The first line names the columns by the JSON names
+// of the fields of the struct; the columns whose name starts with # are
+// ignored, as are the lines whose first cell starts with # (e.g., the
+// descriptions of the columns). The cells of the string fields are taken as
+// is; the other cells are JSON values (e.g., 12, true, [1,2] or
+// [{"id":1,"num":2}]), and the empty ones are left to zero.
+func decodeCSV(data []byte, rows any) error {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	lines, err := r.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	elem := reflect.TypeOf(rows).Elem().Elem().Elem() // *[]*V -> V
+	fields := jsonFields(elem)
+	header := lines[0]
+	quote := make([]bool, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		header[i] = name
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		f, ok := fields[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("column %d: unknown field %q", i+1, name)
+		}
+		quote[i] = f.Type.Kind() == reflect.String
+	}
+
+	// Every line is converted to a JSON object, and the lines to an array.
+	var b bytes.Buffer
+	b.WriteByte('[')
+	first := true
+	for n, line := range lines[1:] {
+		if len(line) == 0 || strings.HasPrefix(line[0], "#") || isBlank(line) {
+			continue
+		}
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+		b.WriteByte('{')
+		sep := false
+		for i, cell := range line {
+			if i >= len(header) {
+				if strings.TrimSpace(cell) != "" {
+					return fmt.Errorf("line %d: cell %d has no column", n+2, i+1)
+				}
+				continue
+			}
+			name := header[i]
+			if name == "" || strings.HasPrefix(name, "#") {
+				continue
+			}
+			var value []byte
+			if quote[i] {
+				value, _ = json.Marshal(cell)
+			} else {
+				cell = strings.TrimSpace(cell)
+				if cell == "" {
+					continue
+				}
+				if !json.Valid([]byte(cell)) {
+					return fmt.Errorf("line %d: column %s: invalid value %q", n+2, name, cell)
+				}
+				value = []byte(cell)
+			}
+			if sep {
+				b.WriteByte(',')
+			}
+			sep = true
+			key, _ := json.Marshal(name)
+			b.Write(key)
+			b.WriteByte(':')
+			b.Write(value)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(']')
+	return json.Unmarshal(b.Bytes(), rows)
+}
+
+// isBlank returns whether the cells of a line are all empty.
+func isBlank(line []string) bool {
+	for _, cell := range line {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonFields returns the fields of a struct, including those of its embedded
+// structs, by their JSON names in lower case, since encoding/json matches
+// the names case-insensitively.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && f.Anonymous {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for n, sub := range jsonFields(ft) {
+					if _, ok := fields[n]; !ok {
+						fields[n] = sub
+					}
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f
+	}
+	return fields
+}
@@ -0,0 +1,299 @@
+// Package gamedata loads the config tables exported from the design
+// spreadsheets (e.g., the items, the skills, the quests), as JSON arrays or
+// CSV files, into typed tables.
+//
+// The tables are registered at init (see Register), and loaded together into
+// an immutable Snapshot (see Registry.Load), once every table is decoded and
+// every check passed, e.g., the cross-references between tables. A reload
+// swaps the snapshot atomically: the readers see either all the old tables,
+// or all the new ones, and a failed reload keeps the old ones. A snapshot is
+// stamped with a version, the hash of the files loaded.
+package gamedata
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+var (
+	versions = metrics.NewGaugeMap[versionLabels](
+		"gamedata_version",
+		"Version of the config tables: 1 for the version loaded, 0 for the previous ones",
+	)
+	loadedAt = metrics.NewGauge(
+		"gamedata_loaded_at",
+		"Unix time the config tables were loaded at",
+	)
+	loads = metrics.NewCounterMap[loadLabels](
+		"gamedata_loads",
+		"Number of loads of the config tables",
+	)
+)
+
+type versionLabels struct {
+	Version string
+}
+
+type loadLabels struct {
+	Result string // "ok" or "error"
+}
+
+// Default is the registry of the tables of the modules.
+var Default = &Registry{}
+
+// Table is a config table: its rows, by key, and in the order of the file.
+// A table is immutable once loaded; its rows mustn't be modified.
+type Table[K comparable, V any] struct {
+	rows map[K]*V
+	keys []K
+}
+
+// Get returns the row of a key, or nil if there's no such row.
+func (t *Table[K, V]) Get(key K) *V {
+	if t == nil {
+		return nil
+	}
+	return t.rows[key]
+}
+
+// Len returns the number of rows of the table.
+func (t *Table[K, V]) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.keys)
+}
+
+// Range calls fn on the rows of the table, in the order of the file, until
+// fn returns false.
+func (t *Table[K, V]) Range(fn func(key K, row *V) bool) {
+	if t == nil {
+		return
+	}
+	for _, k := range t.keys {
+		if !fn(k, t.rows[k]) {
+			return
+		}
+	}
+}
+
+// All returns the rows of the table, in the order of the file.
+func (t *Table[K, V]) All() []*V {
+	if t == nil {
+		return nil
+	}
+	all := make([]*V, 0, len(t.keys))
+	for _, k := range t.keys {
+		all = append(all, t.rows[k])
+	}
+	return all
+}
+
+// Snapshot is a version of all the tables of a registry.
+type Snapshot struct {
+	Version  string    // hash of the files loaded, or "" if none
+	LoadedAt time.Time // zero if not loaded
+	tables   map[string]any
+}
+
+// table is a table registered.
+type table struct {
+	name string
+	file string // guarded by Registry.mu; no file if empty
+	// decode decodes the file of the table.
+	decode func(file string, data []byte) (any, error)
+	// check checks the table decoded, once every table of the snapshot is.
+	check func(s *Snapshot) error
+}
+
+// Registry is a set of tables, loaded together.
+type Registry struct {
+	mu      sync.Mutex // serializes the loads
+	tables  []*table   // in the order of registration
+	current atomic.Value
+}
+
+// Ref refers to a table of a registry, to get it from its snapshots.
+type Ref[K comparable, V any] struct {
+	r    *Registry
+	t    *table
+	name string
+}
+
+// Register registers a table in a registry, under a unique name (e.g.,
+// "item"). key returns the key of a row, which must be unique in the table.
+// check, if not nil, is called on the table decoded, once every table of
+// the snapshot is: it validates the rows, e.g., their references to the rows
+// of other tables (see Ref.In), and may fill their unexported fields. The
+// rows are shared with the readers once the snapshot is loaded: check is the
+// last to modify them.
+//
+// The tables must be registered before they're loaded, typically at init.
+func Register[K comparable, V any](r *Registry, name string, key func(*V) K, check func(s *Snapshot, t *Table[K, V]) error) *Ref[K, V] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tables {
+		if t.name == name {
+			panic(fmt.Sprintf("gamedata: repeat table %q", name))
+		}
+	}
+	t := &table{
+		name: name,
+		decode: func(file string, data []byte) (any, error) {
+			var rows []*V
+			if err := decode(file, data, &rows); err != nil {
+				return nil, err
+			}
+			tab := &Table[K, V]{rows: make(map[K]*V, len(rows)), keys: make([]K, 0, len(rows))}
+			for i, row := range rows {
+				if row == nil {
+					return nil, fmt.Errorf("row %d is null", i+1)
+				}
+				k := key(row)
+				if _, ok := tab.rows[k]; ok {
+					return nil, fmt.Errorf("repeat key %v", k)
+				}
+				tab.rows[k] = row
+				tab.keys = append(tab.keys, k)
+			}
+			return tab, nil
+		},
+	}
+	ref := &Ref[K, V]{r: r, t: t, name: name}
+	if check != nil {
+		t.check = func(s *Snapshot) error {
+			return check(s, ref.In(s))
+		}
+	}
+	r.tables = append(r.tables, t)
+	return ref
+}
+
+// Name returns the name of the table.
+func (ref *Ref[K, V]) Name() string {
+	return ref.name
+}
+
+// SetFile sets the path of the file of the table, a JSON array or a CSV
+// file (see the readme), loaded by the next load of the registry. The table
+// is empty if the path is empty.
+func (ref *Ref[K, V]) SetFile(path string) {
+	ref.r.mu.Lock()
+	defer ref.r.mu.Unlock()
+	ref.t.file = path
+}
+
+// In returns the table in a snapshot, empty if not loaded.
+func (ref *Ref[K, V]) In(s *Snapshot) *Table[K, V] {
+	if s != nil {
+		if t, ok := s.tables[ref.name].(*Table[K, V]); ok {
+			return t
+		}
+	}
+	return &Table[K, V]{}
+}
+
+// Table returns the table in the current snapshot of the registry. Get the
+// table once to read several rows of the same version.
+func (ref *Ref[K, V]) Table() *Table[K, V] {
+	return ref.In(ref.r.Current())
+}
+
+// Get returns the row of a key in the current snapshot, or nil.
+func (ref *Ref[K, V]) Get(key K) *V {
+	return ref.Table().Get(key)
+}
+
+// Current returns the current snapshot of the registry, empty if not loaded.
+func (r *Registry) Current() *Snapshot {
+	if s, ok := r.current.Load().(*Snapshot); ok {
+		return s
+	}
+	return &Snapshot{}
+}
+
+// Version returns the version of the current snapshot of the registry, or ""
+// if not loaded.
+func (r *Registry) Version() string {
+	return r.Current().Version
+}
+
+// Load loads the files of the tables of the registry into a new snapshot,
+// checks it, and makes it the current snapshot. On failure, the current
+// snapshot is unchanged. Load is used both at start and to reload the
+// tables.
+func (r *Registry) Load() (*Snapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, err := r.load()
+	if err != nil {
+		loads.Get(loadLabels{Result: "error"}).Add(1)
+		return nil, err
+	}
+	loads.Get(loadLabels{Result: "ok"}).Add(1)
+	prev := r.Current()
+	r.current.Store(s)
+	if prev.Version != "" && prev.Version != s.Version {
+		versions.Get(versionLabels{Version: prev.Version}).Set(0)
+	}
+	versions.Get(versionLabels{Version: s.Version}).Set(1)
+	loadedAt.Set(float64(s.LoadedAt.Unix()))
+	return s, nil
+}
+
+// load loads a new snapshot.
+func (r *Registry) load() (*Snapshot, error) {
+	s := &Snapshot{LoadedAt: time.Now(), tables: make(map[string]any, len(r.tables))}
+	h := sha256.New()
+	loaded := false
+	for _, t := range r.tables {
+		if t.file == "" {
+			continue
+		}
+		data, err := os.ReadFile(t.file)
+		if err != nil {
+			return nil, fmt.Errorf("gamedata: table %s: %w", t.name, err)
+		}
+		tab, err := t.decode(t.file, data)
+		if err != nil {
+			return nil, fmt.Errorf("gamedata: table %s (%s): %w", t.name, t.file, err)
+		}
+		s.tables[t.name] = tab
+		fmt.Fprintf(h, "%s\x00%d\x00", t.name, len(data))
+		h.Write(data)
+		loaded = true
+	}
+	if loaded {
+		s.Version = hex.EncodeToString(h.Sum(nil))[:12]
+	}
+	for _, t := range r.tables {
+		if t.check == nil {
+			continue
+		}
+		if err := t.check(s); err != nil {
+			return nil, fmt.Errorf("gamedata: table %s: %w", t.name, err)
+		}
+	}
+	return s, nil
+}
+
+// decode decodes the rows of a file, by extension: a CSV file if .csv, a
+// JSON array otherwise.
+func decode(file string, data []byte, rows any) error {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Excel writes a BOM
+	if strings.EqualFold(filepath.Ext(file), ".csv") {
+		return decodeCSV(data, rows)
+	}
+	return json.Unmarshal(data, rows)
+}
@@ -0,0 +1,160 @@
+package gamedata
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type item struct {
+	Id    uint32 `json:"id"`
+	Name  string `json:"name"`
+	Stack int64  `json:"stack"`
+}
+
+type stack struct {
+	Id  uint32 `json:"id"`
+	Num int64  `json:"num"`
+}
+
+type recipe struct {
+	Id     uint32  `json:"id"`
+	Inputs []stack `json:"inputs"`
+	Output uint32  `json:"output"`
+
+	total int64 // filled by the check
+}
+
+// testRegistry returns a registry of items and recipes, whose items must be
+// in the item table.
+func testRegistry() (*Registry, *Ref[uint32, item], *Ref[uint32, recipe]) {
+	r := &Registry{}
+	items := Register(r, "item", func(i *item) uint32 { return i.Id }, nil)
+	recipes := Register(r, "recipe", func(c *recipe) uint32 { return c.Id }, func(s *Snapshot, t *Table[uint32, recipe]) error {
+		known := items.In(s)
+		var err error
+		t.Range(func(id uint32, c *recipe) bool {
+			if known.Get(c.Output) == nil {
+				err = fmt.Errorf("recipe %d: unknown item %d", id, c.Output)
+				return false
+			}
+			for _, in := range c.Inputs {
+				if known.Get(in.Id) == nil {
+					err = fmt.Errorf("recipe %d: unknown item %d", id, in.Id)
+					return false
+				}
+				c.total += in.Num
+			}
+			return true
+		})
+		return err
+	})
+	return r, items, recipes
+}
+
+func write(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCSV(t *testing.T) {
+	dir := t.TempDir()
+	r, items, recipes := testRegistry()
+	items.SetFile(write(t, dir, "item.csv", "\xef\xbb\xbfid,name,stack,#note\n"+
+		"#编号,名称,叠加上限,备注\n"+
+		"1,\"Potion, small\",99,heals\n"+
+		"2,Sword,,\n"+
+		",,,\n"))
+	recipes.SetFile(write(t, dir, "recipe.json", `[{"id":7,"inputs":[{"id":1,"num":3}],"output":2}]`))
+	s, err := r.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Version == "" || r.Version() != s.Version {
+		t.Errorf("version %q, current %q", s.Version, r.Version())
+	}
+	want := []*item{{Id: 1, Name: "Potion, small", Stack: 99}, {Id: 2, Name: "Sword"}}
+	if got := items.Table().All(); !reflect.DeepEqual(got, want) {
+		t.Errorf("items %v, want %v", got, want)
+	}
+	if c := recipes.Get(7); c == nil || c.total != 3 {
+		t.Errorf("recipe 7: %+v", c)
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	r, items, recipes := testRegistry()
+	items.SetFile(write(t, dir, "item.json", `[{"id":1},{"id":2}]`))
+	recipePath := write(t, dir, "recipe.json", `[{"id":7,"output":2}]`)
+	recipes.SetFile(recipePath)
+	s1, err := r.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := items.Table()
+
+	// A broken reference fails the reload, which keeps the old tables.
+	write(t, dir, "recipe.json", `[{"id":7,"output":3}]`)
+	if _, err := r.Load(); err == nil {
+		t.Fatal("reload with an unknown item succeeded")
+	}
+	if r.Version() != s1.Version || recipes.Get(7).Output != 2 {
+		t.Errorf("failed reload changed the tables")
+	}
+
+	write(t, dir, "recipe.json", `[{"id":7,"output":1},{"id":8,"output":2}]`)
+	s2, err := r.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.Version == s1.Version {
+		t.Errorf("version unchanged by the reload: %q", s2.Version)
+	}
+	if recipes.Table().Len() != 2 || recipes.Get(7).Output != 1 {
+		t.Errorf("recipes not reloaded: %v", recipes.Table().All())
+	}
+	// The tables read before the reload are unchanged.
+	if old.Len() != 2 || old != items.In(s1) {
+		t.Errorf("old table changed")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, test := range []struct {
+		name, file, content string
+	}{
+		{"repeat key", "item.json", `[{"id":1},{"id":1}]`},
+		{"unknown column", "item.csv", "id,price\n1,2\n"},
+		{"invalid value", "item.csv", "id,stack\n1,many\n"},
+		{"extra cell", "item.csv", "id\n1,2\n"},
+		{"missing file", "", ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r, items, _ := testRegistry()
+			path := filepath.Join(t.TempDir(), "missing.json")
+			if test.file != "" {
+				path = write(t, t.TempDir(), test.file, test.content)
+			}
+			items.SetFile(path)
+			if _, err := r.Load(); err == nil {
+				t.Errorf("load succeeded")
+			} else {
+				t.Log(err)
+			}
+		})
+	}
+}
+
+func TestNotLoaded(t *testing.T) {
+	_, items, _ := testRegistry()
+	if items.Get(1) != nil || items.Table().Len() != 0 {
+		t.Errorf("table not loaded isn't empty")
+	}
+}
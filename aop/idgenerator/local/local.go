@@ -10,6 +10,9 @@ func MachineID() (uint16, error) {
 	if err != nil {
 		return 0, err
 	}
+	if ip == nil {
+		return 0, fmt.Errorf("no ipv4 address")
+	}
 
 	return uint16(ip[2])<<8 + uint16(ip[3]), nil
 }
@@ -1,37 +1,82 @@
 package idgenerator
 
 import (
-	"github.com/sony/sonyflake"
+	"context"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
 	"greatestworks/aop/idgenerator/local"
+	"greatestworks/aop/logger"
 )
 
-var (
-	sf          *sonyflake.Sonyflake
-	node2NodeId = map[string]uint16{
-		"login":   1,
-		"gateway": 2,
-		"world":   3,
-		"chat":    4,
-		"gm":      5,
-		"upload":  6,
-		"robot":   7,
-		"unknown": 8,
-	}
-)
+// Config configures the generator of the process (see Setup).
+type Config struct {
+	// Worker is the worker id of the process, in [0, MaxWorker], used if App
+	// is empty. The processes running at the same time must have distinct
+	// worker ids.
+	Worker int64
+
+	// App, if set, has the worker id allocated in Redis among the processes
+	// of the app instead.
+	App string
+
+	// Lease is the lease of a worker id allocated in Redis. Defaults to 10
+	// seconds.
+	Lease time.Duration
+
+	// MaxBackwards is how far back the clock may move: meanwhile, the ids
+	// keep the time of the last id; beyond, they fail. Defaults to 100ms.
+	MaxBackwards time.Duration
+}
+
+// Default is the generator of the process. Until it's set up (see Setup),
+// its worker id is the last bits of the local IP address, which may collide
+// with another process's.
+var Default *Generator
 
 func init() {
-	var st sonyflake.Settings
-	st.MachineID = local.MachineID //设置为nil 默认拿本机ip
-	sf = sonyflake.NewSonyflake(st)
-	if sf == nil {
-		panic("sonyflake not created")
+	worker, err := local.MachineID()
+	if err != nil {
+		logger.Warn("[idgenerator] machine id: %v", err)
 	}
+	Default, _ = New(Options{Worker: int64(worker) & MaxWorker})
+}
+
+// Setup replaces Default with the generator configured, before the ids are
+// generated. rdb allocates the worker id if conf.App is set.
+func Setup(ctx context.Context, conf Config, rdb goredis.Cmdable) error {
+	opts := Options{Worker: conf.Worker, MaxBackwards: conf.MaxBackwards}
+	var g *Generator
+	var err error
+	if conf.App != "" {
+		g, err = NewRedis(ctx, rdb, conf.App, conf.Lease, opts)
+	} else {
+		g, err = New(opts)
+	}
+	if err != nil {
+		return err
+	}
+	Default = g
+	logger.Info("[idgenerator] worker id:%v", g.Worker())
+	return nil
 }
 
 func GenerateId() map[string]uint64 {
-	id, err := sf.NextID()
+	id, err := NextId()
 	if err != nil {
 		return nil
 	}
-	return sonyflake.Decompose(id)
+	t, worker, seq := Decompose(int64(id))
+	return map[string]uint64{
+		"id":       id,
+		"time":     uint64(t.UnixMilli()),
+		"worker":   uint64(worker),
+		"sequence": uint64(seq),
+	}
+}
+
+// NextId returns a new unique id, from Default.
+func NextId() (uint64, error) {
+	id, err := Default.Next()
+	return uint64(id), err
 }
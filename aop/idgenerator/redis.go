@@ -0,0 +1,163 @@
+package idgenerator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+)
+
+// DefaultLease is the default duration of the lease of a worker id allocated
+// from Redis.
+const DefaultLease = 10 * time.Second
+
+// The worker ids of an application are allocated from Redis: a worker id is
+// held with a lease, a key set with SET NX, whose value identifies the
+// holder, and which expires unless the holder keeps renewing it. Each
+// renewal records, in a hash, a floor of the time of the ids of the worker:
+// a process that gets the worker id next, with its clock behind the floor,
+// passes it over, so that the ids of a worker don't go back across
+// processes. The keys share a hash tag, so that the scripts that access both
+// also work with Redis Cluster.
+var (
+	// KEYS = [lease, floors], ARGV = [value, lease in ms, worker, floor]
+	acquireScript = redis.NewScript("idgenerator.acquire", `
+if redis.call('set', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+  local floor = tonumber(redis.call('hget', KEYS[2], ARGV[3]) or '0')
+  if tonumber(ARGV[4]) > floor then
+    redis.call('hset', KEYS[2], ARGV[3], ARGV[4])
+  end
+  return floor
+end
+return -1`)
+
+	// KEYS = [lease, floors], ARGV = [value, lease in ms, worker, floor]
+	renewScript = redis.NewScript("idgenerator.renew", `
+if redis.call('get', KEYS[1]) == ARGV[1] then
+  redis.call('hset', KEYS[2], ARGV[3], ARGV[4])
+  return redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// KEYS = [lease, floors], ARGV = [value, worker, floor]
+	releaseScript = redis.NewScript("idgenerator.release", `
+if redis.call('get', KEYS[1]) == ARGV[1] then
+  redis.call('hset', KEYS[2], ARGV[2], ARGV[3])
+  return redis.call('del', KEYS[1])
+end
+return 0`)
+)
+
+// NewRedis returns a generator of a worker id allocated among the processes
+// of an application from Redis, and held until the generator is closed. Once
+// its lease is lost (e.g., because the process was partitioned from Redis
+// for longer than the lease), the generator fails. opts.Worker is ignored.
+func NewRedis(ctx context.Context, rdb goredis.Cmdable, app string, lease time.Duration, opts Options) (*Generator, error) {
+	if lease <= 0 {
+		lease = DefaultLease
+	}
+	tag := fmt.Sprintf("{idgenerator/%s}", app)
+	floors := tag + "/floors"
+	value := uuid.NewString()
+	start := rand.Int63n(MaxWorker + 1)
+	for i := int64(0); i <= MaxWorker; i++ {
+		worker := (start + i) % (MaxWorker + 1)
+		key := fmt.Sprintf("%s/worker/%d", tag, worker)
+		now := time.Now()
+		floor, err := acquireScript.Run(ctx, rdb, []string{key, floors}, value, lease.Milliseconds(), worker, now.Add(lease).UnixMilli()).Int64()
+		if err != nil {
+			return nil, fmt.Errorf("idgenerator: acquire worker %d: %w", worker, err)
+		}
+		if floor < 0 {
+			continue // held
+		}
+		if floor > now.UnixMilli()+defaultMaxBackwards.Milliseconds() {
+			// The clock is behind the last ids of the worker.
+			releaseScript.Run(ctx, rdb, []string{key, floors}, value, worker, floor)
+			continue
+		}
+		opts.Worker = worker
+		g, err := New(opts)
+		if err != nil {
+			return nil, err
+		}
+		g.last = time.UnixMilli(floor).Sub(epoch).Milliseconds()
+		if g.last < 0 {
+			g.last = 0
+		}
+		l := &redisLease{
+			rdb:    rdb,
+			lease:  lease,
+			keys:   []string{key, floors},
+			worker: worker,
+			value:  value,
+			stop:   make(chan struct{}),
+			lost:   make(chan struct{}),
+		}
+		g.lost = l.lost
+		g.release = l.release
+		go l.renew(g)
+		return g, nil
+	}
+	return nil, fmt.Errorf("idgenerator: no worker id of %v available", app)
+}
+
+// redisLease is the lease of a worker id allocated from Redis.
+type redisLease struct {
+	rdb    goredis.Cmdable
+	lease  time.Duration
+	keys   []string // lease, floors
+	worker int64
+	value  string
+	stop   chan struct{}
+	lost   chan struct{}
+}
+
+// renew renews the lease until it's lost, and then closes lost, or until
+// it's released. The floor it records is a lease past the time of the last
+// id: the generator may go on until it finds the lease lost.
+func (l *redisLease) renew(g *Generator) {
+	ticker := time.NewTicker(l.lease / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.stop:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), l.lease/3)
+		floor := g.lastTime().Add(l.lease).UnixMilli()
+		n, err := renewScript.Run(ctx, l.rdb, l.keys, l.value, l.lease.Milliseconds(), l.worker, floor).Int()
+		cancel()
+		switch {
+		case err == nil && n == 1:
+			renewed = time.Now()
+			continue
+		case err == nil:
+			logger.Error("[idgenerator] lease of worker %v expired", l.worker)
+		case time.Since(renewed) >= l.lease:
+			logger.Error("[idgenerator] renew worker %v: %v", l.worker, err)
+		default:
+			continue
+		}
+		close(l.lost)
+		return
+	}
+}
+
+// release releases the lease, recording the time of the last id as the
+// floor of the worker id.
+func (l *redisLease) release(last time.Time) {
+	close(l.stop)
+	ctx, cancel := context.WithTimeout(context.Background(), l.lease/3)
+	defer cancel()
+	if err := releaseScript.Run(ctx, l.rdb, l.keys, l.value, l.worker, last.UnixMilli()).Err(); err != nil {
+		logger.Error("[idgenerator] release worker %v: %v", l.worker, err)
+	}
+}
@@ -0,0 +1,152 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+// An id is | 0 | milliseconds since epoch (41 bits) | worker (10 bits) |
+// sequence (12 bits) |: 4096 ids a millisecond per worker, until 2084.
+const (
+	workerBits = 10
+	seqBits    = 12
+	timeShift  = workerBits + seqBits
+	maxSeq     = 1<<seqBits - 1
+
+	// MaxWorker is the greatest worker id.
+	MaxWorker = 1<<workerBits - 1
+)
+
+// epoch is the epoch of the ids: that of the sonyflake ids generated before
+// them, whose time unit and shift are smaller, so that the ids generated now
+// are greater than those.
+var epoch = time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+
+const defaultMaxBackwards = 100 * time.Millisecond
+
+var (
+	// ErrClockBackwards is returned when the clock went back further than
+	// Options.MaxBackwards.
+	ErrClockBackwards = errors.New("idgenerator: clock moved backwards")
+	// ErrWorkerLost is returned once the lease of the worker id of a
+	// generator is lost (see NewRedis): another process may use it.
+	ErrWorkerLost = errors.New("idgenerator: worker id lost")
+)
+
+var (
+	clockBackwards = metrics.NewCounter(
+		"idgenerator_clock_backwards",
+		"Number of ids refused because the clock moved backwards",
+	)
+	sequenceWaits = metrics.NewCounter(
+		"idgenerator_sequence_waits",
+		"Number of times the ids of a millisecond ran out, and the next millisecond was waited for",
+	)
+)
+
+// Options configures a Generator.
+type Options struct {
+	// Worker is the worker id of the generator, in [0, MaxWorker]. The
+	// generators running at the same time must have distinct worker ids.
+	Worker int64
+
+	// MaxBackwards is how far back the clock may move: meanwhile, the ids
+	// keep the time of the last id; beyond, Next fails. Defaults to 100ms.
+	MaxBackwards time.Duration
+}
+
+// Generator generates unique ids, snowflake-style: increasing for a
+// generator, and unique among the generators of distinct worker ids. It's
+// safe for concurrent use.
+type Generator struct {
+	worker       int64
+	maxBackwards int64 // milliseconds
+	now          func() time.Time
+	sleep        func(time.Duration)
+	lost         <-chan struct{} // closed once the worker id is lost; nil if it can't be
+	release      func(last time.Time)
+
+	mu   sync.Mutex
+	last int64 // milliseconds since epoch of the last id
+	seq  int64 // sequence of the last id in its millisecond
+}
+
+// New returns a generator of a worker id.
+func New(opts Options) (*Generator, error) {
+	if opts.Worker < 0 || opts.Worker > MaxWorker {
+		return nil, fmt.Errorf("idgenerator: worker %d out of [0, %d]", opts.Worker, MaxWorker)
+	}
+	if opts.MaxBackwards <= 0 {
+		opts.MaxBackwards = defaultMaxBackwards
+	}
+	return &Generator{
+		worker:       opts.Worker,
+		maxBackwards: opts.MaxBackwards.Milliseconds(),
+		now:          time.Now,
+		sleep:        time.Sleep,
+	}, nil
+}
+
+// Next returns a new id.
+func (g *Generator) Next() (int64, error) {
+	select {
+	case <-g.lost:
+		return 0, ErrWorkerLost
+	default:
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for {
+		now := g.now().Sub(epoch).Milliseconds()
+		if back := g.last - now; back > g.maxBackwards {
+			clockBackwards.Add(1)
+			return 0, fmt.Errorf("%w by %dms", ErrClockBackwards, back)
+		}
+		if now > g.last {
+			g.last, g.seq = now, 0
+			break
+		}
+		if g.seq < maxSeq {
+			g.seq++
+			break
+		}
+		// The ids of the millisecond ran out.
+		sequenceWaits.Add(1)
+		g.sleep(time.Duration(g.last-now+1) * time.Millisecond)
+	}
+	return g.last<<timeShift | g.worker<<seqBits | g.seq, nil
+}
+
+// Worker returns the worker id of the generator.
+func (g *Generator) Worker() int64 {
+	return g.worker
+}
+
+// Close releases the worker id of the generator, if it was allocated (see
+// NewRedis). The generator mustn't be used afterwards.
+func (g *Generator) Close() {
+	if g.release != nil {
+		g.release(g.lastTime())
+	}
+}
+
+// lastTime returns the time of the last id, or now if later.
+func (g *Generator) lastTime() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	last := epoch.Add(time.Duration(g.last) * time.Millisecond)
+	if now := g.now(); now.After(last) {
+		return now
+	}
+	return last
+}
+
+// Decompose returns the time, the worker id and the sequence of an id.
+func Decompose(id int64) (t time.Time, worker, seq int64) {
+	t = epoch.Add(time.Duration(id>>timeShift) * time.Millisecond)
+	return t, id >> seqBits & MaxWorker, id & maxSeq
+}
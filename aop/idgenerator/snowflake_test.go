@@ -0,0 +1,85 @@
+package idgenerator
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newFake returns a generator of a worker on a clock that only its sleeps
+// advance.
+func newFake(t *testing.T, worker int64) (*Generator, *time.Time) {
+	g, err := New(Options{Worker: worker})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+	g.sleep = func(d time.Duration) { now = now.Add(d) }
+	return g, &now
+}
+
+func TestNext(t *testing.T) {
+	g, now := newFake(t, 5)
+	h, _ := newFake(t, 6)
+	seen := map[int64]bool{}
+	var prev int64
+	for i := 0; i < 3*(maxSeq+1); i++ { // runs out of the ids of 2 milliseconds
+		id, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, _ := h.Next()
+		if id <= prev || seen[id] || seen[other] {
+			t.Fatalf("id %d after %d, or duplicated", id, prev)
+		}
+		seen[id], seen[other] = true, true
+		prev = id
+	}
+	ts, worker, seq := Decompose(prev)
+	if !ts.Equal(*now) || worker != 5 || seq != maxSeq {
+		t.Errorf("Decompose: got %v %d %d, want %v 5 %d", ts, worker, seq, *now, maxSeq)
+	}
+
+	if _, err := New(Options{Worker: MaxWorker + 1}); err == nil {
+		t.Error("worker out of range accepted")
+	}
+}
+
+func TestClockBackwards(t *testing.T) {
+	g, now := newFake(t, 1)
+	last, _ := g.Next()
+
+	// Back within MaxBackwards: the time of the last id is kept.
+	*now = now.Add(-50 * time.Millisecond)
+	id, err := g.Next()
+	if err != nil || id <= last {
+		t.Fatalf("50ms back: got %d, %v after %d", id, err, last)
+	}
+	if ts, _, _ := Decompose(id); !ts.Equal(now.Add(50 * time.Millisecond)) {
+		t.Errorf("50ms back: id of %v", ts)
+	}
+
+	// Further back: refused until the clock catches up.
+	*now = now.Add(-time.Second)
+	if _, err := g.Next(); !errors.Is(err, ErrClockBackwards) {
+		t.Fatalf("1s back: got %v", err)
+	}
+	*now = now.Add(time.Second)
+	if id2, err := g.Next(); err != nil || id2 <= id {
+		t.Fatalf("caught up: got %d, %v after %d", id2, err, id)
+	}
+}
+
+func TestWorkerLost(t *testing.T) {
+	g, _ := newFake(t, 1)
+	lost := make(chan struct{})
+	g.lost = lost
+	if _, err := g.Next(); err != nil {
+		t.Fatal(err)
+	}
+	close(lost)
+	if _, err := g.Next(); !errors.Is(err, ErrWorkerLost) {
+		t.Errorf("got %v, want ErrWorkerLost", err)
+	}
+}
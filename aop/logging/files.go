@@ -2,6 +2,7 @@ package logging
 
 import (
 	"bufio"
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -27,37 +29,88 @@ import (
 // DefaultLogDir is the default directory where Service Weaver log files are stored.
 var DefaultLogDir = filepath.Join(os.TempDir(), "serviceweaver", "logs")
 
+// maxClockSkew is the maximum difference between the time a log entry is
+// logged and the time it is written to a file, including the clock skew
+// between the machines that log and write the entry.
+const maxClockSkew = time.Minute
+
 // FileStore stores log entries in files.
 type FileStore struct {
-	dir string
-	mu  sync.Mutex
-	pp  *PrettyPrinter
+	dir  string
+	opts FileStoreOptions
+	mu   sync.Mutex
+	pp   *PrettyPrinter
+
+	// We segregate into log files by app,deployment,node,level. Every such
+	// stream of log entries is written to a sequence of segments (see
+	// segmentName), and files holds the segment being written, keyed by the
+	// filename of the stream.
+	files map[string]*segment
+
+	// next holds the sequence number of the next segment of the streams
+	// whose segment was rotated, keyed by the filename of the stream.
+	next map[string]int
+
+	stop    chan struct{}  // closed to stop the purger, nil if no purger
+	stopped sync.WaitGroup // waits for the purger to stop
+}
 
-	// We segregate into log files by app,deployment,node,level.
-	files map[string]*os.File
+// segment is the log file being written for a stream of log entries.
+type segment struct {
+	name    string    // filename, relative to the log directory
+	seq     int       // sequence number
+	f       *os.File  // nil if the file can't be written
+	size    int64     // number of bytes written to f
+	created time.Time // time when f was created
 }
 
-// NewFileStore returns a LogStore that writes files to the specified directory.
+// NewFileStore returns a LogStore that writes files to the specified
+// directory. The log files grow forever (see NewFileStoreWithOptions).
 func NewFileStore(dir string) (*FileStore, error) {
+	return NewFileStoreWithOptions(dir, FileStoreOptions{})
+}
+
+// NewFileStoreWithOptions returns a LogStore that writes files to the
+// specified directory, and rotates, compresses, and purges them as specified
+// by the provided options.
+func NewFileStoreWithOptions(dir string, opts FileStoreOptions) (*FileStore, error) {
 	if err := os.MkdirAll(dir, 0750); err != nil {
 		return nil, err
 	}
-	return &FileStore{
+	fs := &FileStore{
 		dir:   dir,
+		opts:  opts.withDefaults(),
 		pp:    NewPrettyPrinter(colors.Enabled()),
-		files: map[string]*os.File{},
-	}, nil
+		files: map[string]*segment{},
+		next:  map[string]int{},
+	}
+	if fs.opts.purges() {
+		fs.stop = make(chan struct{})
+		fs.stopped.Add(1)
+		go fs.purger()
+	}
+	return fs, nil
 }
 
 // Close closes the specified log-store, including any opened files.
 func (fs *FileStore) Close() error {
+	// Stop the purger, which acquires fs.mu.
+	fs.mu.Lock()
+	stop := fs.stop
+	fs.stop = nil
+	fs.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		fs.stopped.Wait()
+	}
+
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 	var err error
-	for name, f := range fs.files {
+	for name, s := range fs.files {
 		delete(fs.files, name)
-		if f != nil {
-			if fileErr := f.Close(); fileErr != nil && err == nil {
+		if s.f != nil {
+			if fileErr := s.f.Close(); fileErr != nil && err == nil {
 				err = fileErr
 			}
 		}
@@ -77,35 +130,86 @@ func (fs *FileStore) Add(e *protos.LogEntry) {
 		e.TimeMicros = time.Now().UnixMicro()
 	}
 
-	// Get the log file, creating it if necessary.
+	// Get the log file, rotating or creating it if necessary.
 	fname := filename(e.App, e.Version, e.Node, e.Level)
-	f, ok := fs.files[fname]
+	s, ok := fs.files[fname]
+	if ok && fs.opts.full(s, time.Now()) {
+		fs.rotate(fname, s)
+		ok = false
+	}
 	if !ok {
-		var err error
-		f, err = os.Create(filepath.Join(fs.dir, fname))
-		if err != nil {
-			// Since we can't open the log file, fall back to stderr.
-			fmt.Fprintf(os.Stderr, "create log file: %v\n", err)
-			f = nil
-		}
-		fs.files[fname] = f
+		s = fs.create(fname)
+		fs.files[fname] = s
 	}
 
 	// Write to log file if available.
-	if f != nil {
-		err := protomsg.Write(f, e)
+	if s.f != nil {
+		w := countingWriter{w: s.f}
+		err := protomsg.Write(&w, e)
+		s.size += w.n
 		if err == nil {
 			return
 		}
 		// Fall back to stderr.
 		fmt.Fprintf(os.Stderr, "write log entry: %v\n", err)
-		fs.files[fname] = nil
+		s.f.Close()
+		s.f = nil
 	}
 
 	// Log file is not available, so write to stderr.
 	fmt.Fprintln(os.Stderr, fs.pp.Format(e))
 }
 
+// create creates the next segment of the stream with the provided filename.
+//
+// REQUIRES: fs.mu is held.
+func (fs *FileStore) create(fname string) *segment {
+	seq, ok := fs.next[fname]
+	if !ok && fs.opts.rotates() {
+		// Don't overwrite the segments written before a restart.
+		seq = nextSeq(fs.dir, fname)
+	}
+	s := &segment{
+		name:    segmentName(fname, seq),
+		seq:     seq,
+		created: time.Now(),
+	}
+	f, err := os.Create(filepath.Join(fs.dir, s.name))
+	if err != nil {
+		// Since we can't open the log file, fall back to stderr.
+		fmt.Fprintf(os.Stderr, "create log file: %v\n", err)
+		return s
+	}
+	s.f = f
+	return s
+}
+
+// rotate closes the provided segment of the stream with the provided
+// filename. The next log entry of the stream is written to a new segment.
+//
+// REQUIRES: fs.mu is held.
+func (fs *FileStore) rotate(fname string, s *segment) {
+	if err := s.f.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "close log file: %v\n", err)
+	}
+	rotatedBytes.Add(float64(s.size))
+	delete(fs.files, fname)
+	fs.next[fname] = s.seq + 1
+}
+
+// countingWriter is an io.Writer that counts the bytes written to it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements the io.Writer interface.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
 // filename returns the log file for the specified (app, deployment, weavelet,
 // level) tuple.
 //
@@ -120,6 +224,15 @@ func (fs *FileStore) Add(e *protos.LogEntry) {
 //	├── todo.v1.111.info.log
 //	└── todo.v2.111.error.log
 //
+// If the files are rotated (see FileStoreOptions), every tuple has a sequence
+// of log files instead, the first of which has the name returned by filename
+// (see segmentName). Rotated files may be compressed with gzip:
+//
+//	/tmp/serviceweaver/logs
+//	├── todo.v1.111.info.log.gz
+//	├── todo.v1.111.info.1.log.gz
+//	└── todo.v1.111.info.2.log
+//
 // TODO(mwhittaker): Instead of this structure, we could instead have
 // directories for every deployment. For example, we could have
 // /tmp/serviceweaver/logs/todo/v1, /tmp/serviceweaver/logs/todo/v2, and so on. This makes
@@ -136,6 +249,25 @@ func filename(app, deployment, weavelet, level string) string {
 	return fmt.Sprintf("%s.%s.%s.%s.log", app, deployment, weavelet, level)
 }
 
+// segmentName returns the log file of the segment with the provided sequence
+// number of the stream with the provided filename. The first segment has
+// sequence number zero, and is stored in the file of the stream.
+func segmentName(filename string, seq int) string {
+	if seq == 0 {
+		return filename
+	}
+	return fmt.Sprintf("%s.%d.log", strings.TrimSuffix(filename, ".log"), seq)
+}
+
+// compressedSuffix is the suffix of compressed log files.
+const compressedSuffix = ".gz"
+
+// isTemp returns whether the provided file is a temporary file, written
+// while a log file is compressed.
+func isTemp(filename string) bool {
+	return strings.HasSuffix(filename, ".tmp")
+}
+
 // logfile represents a log file for a specific (app, deployment, weavelet,
 // level) tuple.
 type logfile struct {
@@ -143,6 +275,13 @@ type logfile struct {
 	deployment string
 	weavelet   string
 	level      string
+	seq        int  // sequence number of the segment (see segmentName)
+	compressed bool // is the file compressed with gzip?
+}
+
+// stream returns the filename of the stream of the log file.
+func (l *logfile) stream() string {
+	return filename(l.app, l.deployment, l.weavelet, l.level)
 }
 
 // parseLogfile parses a logfile filename.
@@ -150,16 +289,31 @@ func parseLogfile(filename string) (logfile, error) {
 	// TODO(mwhittaker): Ensure that apps, deployments, weavelet ids, levels
 	// don't contain a ".". Or, switch to some other delimiter that doesn't
 	// show up.
-	parts := strings.SplitN(filename, ".", 5)
-	if len(parts) < 5 || parts[4] != "log" {
-		want := "<app>.<deployment>.<weavelet>.<level>.log"
+	want := "<app>.<deployment>.<weavelet>.<level>[.<seq>].log[.gz]"
+	compressed := strings.HasSuffix(filename, compressedSuffix)
+	name := strings.TrimSuffix(filename, compressedSuffix)
+	if !strings.HasSuffix(name, ".log") {
+		return logfile{}, fmt.Errorf("filename %q must have format %q", filename, want)
+	}
+	parts := strings.Split(strings.TrimSuffix(name, ".log"), ".")
+	if len(parts) < 4 || len(parts) > 5 {
 		return logfile{}, fmt.Errorf("filename %q must have format %q", filename, want)
 	}
+	var seq int
+	if len(parts) == 5 {
+		var err error
+		seq, err = strconv.Atoi(parts[4])
+		if err != nil || seq <= 0 {
+			return logfile{}, fmt.Errorf("filename %q must have format %q", filename, want)
+		}
+	}
 	return logfile{
 		app:        parts[0],
 		deployment: parts[1],
 		weavelet:   parts[2],
 		level:      parts[3],
+		seq:        seq,
+		compressed: compressed,
 	}, nil
 }
 
@@ -275,6 +429,7 @@ func (fq *fileSource) Query(_ context.Context, q Query, follow bool) (Reader, er
 // FileLogger.
 type fileCatter struct {
 	prog   cel.Program           // query for filtering log entries
+	until  time.Time             // no entry after until matches, if not zero
 	h      *heap.Heap[*buffered] // heap of *buffered
 	files  []*os.File            // underlying files being read
 	closed bool                  // true if Close() has been called
@@ -282,18 +437,20 @@ type fileCatter struct {
 
 func newFileCatter(logdir string, q Query) (*fileCatter, error) {
 	// Compile the query.
-	env, ast, err := parse(q)
-	if err != nil {
-		return nil, err
-	}
-	prog, err := compile(env, ast)
+	c, err := compileQuery(q)
 	if err != nil {
 		return nil, err
 	}
+	prog := c.prog
 
-	// Construct the heap.
+	// Construct the heap. Entries with the same timestamp are ordered by
+	// file, so that the order of the entries is deterministic (see
+	// ReadPage).
 	h := heap.New(func(a, b *buffered) bool {
-		return a.peek().TimeMicros < b.peek().TimeMicros
+		if a.peek().TimeMicros != b.peek().TimeMicros {
+			return a.peek().TimeMicros < b.peek().TimeMicros
+		}
+		return a.filename < b.filename
 	})
 	filenames, err := ls(logdir, prog)
 	if err != nil {
@@ -302,13 +459,30 @@ func newFileCatter(logdir string, q Query) (*fileCatter, error) {
 	files := make([]*os.File, 0, len(filenames))
 	for _, filename := range filenames {
 		// TODO(mwhittaker): Close this file if we return an error.
-		file, err := os.Open(filepath.Join(logdir, filename))
-		if err != nil {
+		file, src, err := openLogfile(filepath.Join(logdir, filename))
+		if errors.Is(err, os.ErrNotExist) {
+			// The file was purged after we listed it.
+			continue
+		} else if err != nil {
 			return nil, err
 		}
+		if !c.since.IsZero() {
+			// Skip the files that were last written before the time range
+			// of the query. Entries may be written a little after they are
+			// logged, and by a different machine, so we allow for some
+			// clock skew.
+			info, err := file.Stat()
+			if err != nil {
+				return nil, err
+			}
+			if info.ModTime().Before(c.since.Add(-maxClockSkew)) {
+				file.Close()
+				continue
+			}
+		}
 		files = append(files, file)
 
-		buffered := newBuffered(file.Name(), file)
+		buffered := newBuffered(filepath.Join(logdir, filename), src)
 		if err = buffered.buffer(); err != nil {
 			return nil, err
 		}
@@ -318,6 +492,7 @@ func newFileCatter(logdir string, q Query) (*fileCatter, error) {
 	}
 	catter := fileCatter{
 		prog:   prog,
+		until:  c.until,
 		h:      h,
 		files:  files,
 		closed: false,
@@ -336,6 +511,11 @@ func (fc *fileCatter) Read(ctx context.Context) (*protos.LogEntry, error) {
 		if !ok {
 			return nil, io.EOF
 		}
+		if !fc.until.IsZero() && buffered.peek().TimeMicros > fc.until.UnixMicro() {
+			// The entries are read in timestamp order, so no other entry
+			// matches the query.
+			return nil, io.EOF
+		}
 		entry := buffered.pop()
 
 		// Update the scanner.
@@ -453,14 +633,11 @@ func (fs *fileScanner) onHeap() bool {
 
 func newFileFollower(logdir string, q Query) (*fileFollower, error) {
 	// Compile the query.
-	env, ast, err := parse(q)
-	if err != nil {
-		return nil, err
-	}
-	prog, err := compile(env, ast)
+	c, err := compileQuery(q)
 	if err != nil {
 		return nil, err
 	}
+	prog := c.prog
 
 	// Start watching logdir. Note that we have to start watching logdir before
 	// we call ls. Otherwise, in the gap between calling ls and starting
@@ -625,8 +802,11 @@ func (ff *fileFollower) created(filename string) error {
 	}
 
 	// Open the file.
-	file, err := os.Open(filename)
-	if err != nil {
+	file, src, err := openLogfile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		// The file was purged after it was created.
+		return nil
+	} else if err != nil {
 		return err
 	}
 
@@ -639,7 +819,9 @@ func (ff *fileFollower) created(filename string) error {
 		reader:  nil,
 		ready:   cond.NewCond(&ff.mu),
 	}
-	reader := newTailReader(file, func() error { return ff.waitForChanges(fs) })
+	// Note that compressed files don't grow, so their tailReader waits
+	// forever once it has read the whole file.
+	reader := newTailReader(src, func() error { return ff.waitForChanges(fs) })
 	fs.reader = reader
 	ff.scanners[filename] = fs
 
@@ -767,9 +949,20 @@ func (ff *fileFollower) watch(ctx context.Context) error {
 		case event := <-ff.watcher.Events:
 			switch event.Op {
 			case fsnotify.Remove, fsnotify.Rename, fsnotify.Chmod:
-				return fmt.Errorf("unexpected operation %v", event.Op)
+				// Log files are removed when they are compressed or
+				// purged by a FileStore, and temporary files are renamed
+				// when they are compressed. We keep reading the files we
+				// have opened until the end.
+				continue
 
 			case fsnotify.Create:
+				base := filepath.Base(event.Name)
+				if isTemp(base) || strings.HasSuffix(base, compressedSuffix) {
+					// A compressed log file is created when a log file
+					// is compressed, and the latter is either followed
+					// already, or doesn't match the query.
+					continue
+				}
 				if err := ff.created(event.Name); err != nil {
 					return fmt.Errorf("created(%q): %w", event.Name, err)
 				}
@@ -814,12 +1007,25 @@ func ls(dir string, prog cel.Program) ([]string, error) {
 		return nil, err
 	}
 
+	present := make(map[string]bool, len(direntries))
+	for _, direntry := range direntries {
+		present[direntry.Name()] = true
+	}
+
 	filenames := make([]string, 0, len(direntries))
 	for _, direntry := range direntries {
 		if direntry.IsDir() {
 			return nil, fmt.Errorf("unexpected directory %q in %q", direntry.Name(), dir)
 		}
 		filename := direntry.Name()
+		if isTemp(filename) {
+			continue
+		}
+		if strings.HasSuffix(filename, compressedSuffix) && present[strings.TrimSuffix(filename, compressedSuffix)] {
+			// The log file is being compressed. Read the uncompressed
+			// file, which is removed once compressed.
+			continue
+		}
 		logfile, err := parseLogfile(filename)
 		if err != nil {
 			return nil, err
@@ -835,6 +1041,29 @@ func ls(dir string, prog cel.Program) ([]string, error) {
 	return filenames, nil
 }
 
+// openLogfile opens the provided log file for reading, and returns the file
+// and a reader of its log entries. If the file was compressed after it was
+// listed, openLogfile opens the compressed file instead.
+func openLogfile(filename string) (*os.File, io.Reader, error) {
+	file, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) && !strings.HasSuffix(filename, compressedSuffix) {
+		filename += compressedSuffix
+		file, err = os.Open(filename)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !strings.HasSuffix(filename, compressedSuffix) {
+		return file, file, nil
+	}
+	src, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("open %q: %w", filename, err)
+	}
+	return file, src, nil
+}
+
 // buffered is an entryScanner with a buffered *Entry scanned from it.
 type buffered struct {
 	filename string           // absolute filename of the file being scanned
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"greatestworks/aop/protos"
+)
+
+// Formatter formats log entries as text. You can safely use a Formatter from
+// multiple goroutines.
+type Formatter interface {
+	// Format formats a log entry as a single line of text.
+	Format(e *protos.LogEntry) string
+}
+
+// Output formats of log entries (see NewFormatter).
+const (
+	PrettyFormat = "pretty" // human-readable text (see PrettyPrinter)
+	JSONFormat   = "json"   // one JSON object per entry (see JSONFormatter)
+)
+
+var (
+	_ Formatter = &PrettyPrinter{}
+	_ Formatter = JSONFormatter{}
+)
+
+// NewFormatter returns a Formatter for the provided output format. If color
+// is true, the pretty format is colorized. The JSON format is never
+// colorized.
+func NewFormatter(format string, color bool) (Formatter, error) {
+	switch format {
+	case "", PrettyFormat:
+		return NewPrettyPrinter(color), nil
+	case JSONFormat:
+		return JSONFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("invalid format %q; must be %q or %q", format, PrettyFormat, JSONFormat)
+	}
+}
+
+// JSONFormatter formats log entries as single-line JSON objects, which can be
+// processed with tools like jq. For example:
+//
+//	{"time":"2023-01-02T15:04:05.123456Z","level":"info","app":"todo",...,"msg":"Hello","attrs":{"player":"alice"}}
+//
+// Every object has the fields below, in this order, even if they are empty.
+// The field names match the fields of the query language (see Query).
+//
+//	time           : the time the log entry was logged, in RFC 3339 format, in UTC
+//	level          : the level of the log (e.g., debug, info)
+//	app            : the application name
+//	version        : the abbreviated application version
+//	full_version   : the unabbreviated application version
+//	component      : the abbreviated Service Weaver component name
+//	full_component : the unabbreviated Service Weaver component name
+//	node           : the abbreviated node name
+//	full_node      : the unabbreviated node name
+//	file           : the file from which the log entry was logged
+//	line           : the line from which the log entry was logged
+//	msg            : the logged message
+//	attrs          : the user provided attributes, as an object
+type JSONFormatter struct{}
+
+// jsonEntry is a log entry formatted by a JSONFormatter.
+type jsonEntry struct {
+	Time          string            `json:"time"`
+	Level         string            `json:"level"`
+	App           string            `json:"app"`
+	Version       string            `json:"version"`
+	FullVersion   string            `json:"full_version"`
+	Component     string            `json:"component"`
+	FullComponent string            `json:"full_component"`
+	Node          string            `json:"node"`
+	FullNode      string            `json:"full_node"`
+	File          string            `json:"file"`
+	Line          int32             `json:"line"`
+	Msg           string            `json:"msg"`
+	Attrs         map[string]string `json:"attrs"`
+}
+
+// Format implements the Formatter interface.
+func (JSONFormatter) Format(e *protos.LogEntry) string {
+	attrs := make(map[string]string, len(e.Attrs)/2)
+	for i := 0; i+1 < len(e.Attrs); i += 2 {
+		attrs[e.Attrs[i]] = e.Attrs[i+1]
+	}
+	bytes, err := json.Marshal(jsonEntry{
+		Time:          time.UnixMicro(e.TimeMicros).UTC().Format(time.RFC3339Nano),
+		Level:         e.Level,
+		App:           e.App,
+		Version:       Shorten(e.Version),
+		FullVersion:   e.Version,
+		Component:     ShortenComponent(e.Component),
+		FullComponent: e.Component,
+		Node:          Shorten(e.Node),
+		FullNode:      e.Node,
+		File:          e.File,
+		Line:          e.Line,
+		Msg:           e.Msg,
+		Attrs:         attrs,
+	})
+	if err != nil {
+		// Marshaling a jsonEntry never fails, as it only holds strings and
+		// integers.
+		panic(fmt.Sprintf("marshal log entry: %v", err))
+	}
+	return string(bytes)
+}
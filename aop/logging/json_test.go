@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"greatestworks/aop/protos"
+)
+
+func TestJSONFormatter(t *testing.T) {
+	entry := &protos.LogEntry{
+		App:        "todo",
+		Version:    "0123456789abcdef",
+		Component:  "github.com/my/app/Store",
+		Node:       "fedcba9876543210",
+		TimeMicros: time.Date(2023, 1, 2, 15, 4, 5, 123456000, time.UTC).UnixMicro(),
+		Level:      "info",
+		File:       "/app/store.go",
+		Line:       42,
+		Msg:        "Hello\nworld",
+		Attrs:      []string{"player", "alice", "score", "10"},
+	}
+	got := JSONFormatter{}.Format(entry)
+	if strings.Contains(got, "\n") {
+		t.Fatalf("Format: got %q, want a single line", got)
+	}
+	const want = `{"time":"2023-01-02T15:04:05.123456Z","level":"info","app":"todo",` +
+		`"version":"01234567","full_version":"0123456789abcdef",` +
+		`"component":"app.Store","full_component":"github.com/my/app/Store",` +
+		`"node":"fedcba98","full_node":"fedcba9876543210",` +
+		`"file":"/app/store.go","line":42,"msg":"Hello\nworld",` +
+		`"attrs":{"player":"alice","score":"10"}}`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Format (-want +got):\n%s", diff)
+	}
+
+	// Entries without attributes have an empty attrs object.
+	got = JSONFormatter{}.Format(&protos.LogEntry{Msg: "hi"})
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(got), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]any{}, fields["attrs"]); diff != "" {
+		t.Fatalf("attrs (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewFormatter(t *testing.T) {
+	for _, format := range []string{"", PrettyFormat, JSONFormat} {
+		if _, err := NewFormatter(format, false); err != nil {
+			t.Errorf("NewFormatter(%q): %v", format, err)
+		}
+	}
+	if _, err := NewFormatter("xml", false); err == nil {
+		t.Error(`NewFormatter("xml"): unexpected success`)
+	}
+}
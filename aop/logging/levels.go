@@ -0,0 +1,206 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"greatestworks/aop"
+	"greatestworks/aop/logtype"
+	"greatestworks/aop/protos"
+)
+
+const (
+	levelsKey      = "greatestworks/log_levels"
+	shortLevelsKey = "log_levels"
+
+	// DefaultLevel is the component name under which UpdateLevels sets the
+	// default level.
+	DefaultLevel = "default"
+)
+
+func init() {
+	aop.RegisterConfigSection[LevelsConfig](levelsKey, shortLevelsKey)
+}
+
+// LevelsConfig is the config of the log levels of a deployment, as found in
+// the log_levels section of the app config. For example:
+//
+//	[log_levels]
+//	default = "info"
+//	components = {"github.com/my/app/Store" = "debug"}
+//
+// Components are identified by their full or shortened name (e.g.,
+// "app.Store"). Components without a level log at the default level, which
+// is "debug" if not specified.
+type LevelsConfig struct {
+	Default    string            `toml:"default,omitempty"`
+	Components map[string]string `toml:"components,omitempty"`
+}
+
+// Validate validates the config.
+func (c *LevelsConfig) Validate() error {
+	if c.Default != "" {
+		if _, err := logtype.ParseLevel(c.Default); err != nil {
+			return fmt.Errorf("default: %w", err)
+		}
+	}
+	for component, level := range c.Components {
+		if _, err := logtype.ParseLevel(level); err != nil {
+			return fmt.Errorf("component %q: %w", component, err)
+		}
+	}
+	return nil
+}
+
+// Levels are the minimum levels of the log entries logged by every
+// component. Log entries below the level of their component are dropped.
+// The levels can be updated at any time, which lets operators turn on the
+// debug logs of a single component of a running deployment.
+//
+// The zero value enables all levels. A nil *Levels also enables all levels.
+// You can safely use Levels from multiple goroutines.
+type Levels struct {
+	mu         sync.RWMutex
+	def        logtype.Level            // level of unlisted components
+	components map[string]logtype.Level // levels, by component name
+}
+
+// Enabled returns whether the provided component logs entries of the
+// provided level.
+func (l *Levels) Enabled(component string, level logtype.Level) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if min, ok := l.components[component]; ok {
+		return level >= min
+	}
+	if min, ok := l.components[ShortenComponent(component)]; ok {
+		return level >= min
+	}
+	return level >= l.def
+}
+
+// Allows returns whether the provided log entry is at or above the level of
+// its component. Entries with unknown levels (e.g., the stdout and stderr of
+// a weavelet) are always allowed.
+func (l *Levels) Allows(entry *protos.LogEntry) bool {
+	level, err := logtype.ParseLevel(entry.Level)
+	if err != nil {
+		return true
+	}
+	return l.Enabled(entry.Component, level)
+}
+
+// Update replaces the levels with the ones specified in the log_levels
+// section of the provided config sections. If the section is missing, all
+// levels are enabled. On error, the levels are left unchanged.
+func (l *Levels) Update(sections map[string]string) error {
+	c, err := parseLevelsConfig(sections)
+	if err != nil {
+		return err
+	}
+	def := logtype.Debug
+	if c.Default != "" {
+		def, _ = logtype.ParseLevel(c.Default) // validated by parseLevelsConfig
+	}
+	components := make(map[string]logtype.Level, len(c.Components))
+	for component, level := range c.Components {
+		components[component], _ = logtype.ParseLevel(level)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.def = def
+	l.components = components
+	return nil
+}
+
+// parseLevelsConfig parses the log_levels section of the provided config
+// sections.
+func parseLevelsConfig(sections map[string]string) (*LevelsConfig, error) {
+	var c LevelsConfig
+	if err := aop.ParseConfigSection(levelsKey, shortLevelsKey, sections, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// UpdateLevels returns a copy of the provided config sections in which the
+// log_levels section sets the level of the provided component, or the
+// default level if component is DefaultLevel. An empty level removes the
+// level of the component, which then logs at the default level.
+func UpdateLevels(sections map[string]string, component, level string) (map[string]string, error) {
+	if component == "" {
+		return nil, fmt.Errorf("no component provided")
+	}
+	if level != "" {
+		if _, err := logtype.ParseLevel(level); err != nil {
+			return nil, err
+		}
+	}
+	c, err := parseLevelsConfig(sections)
+	if err != nil {
+		return nil, err
+	}
+	if component == DefaultLevel {
+		c.Default = level
+	} else if level == "" {
+		delete(c.Components, component)
+	} else {
+		if c.Components == nil {
+			c.Components = map[string]string{}
+		}
+		c.Components[component] = level
+	}
+
+	// Write the section back under the key it was found under.
+	key, _ := sectionOf(sections)
+	updated := make(map[string]string, len(sections)+1)
+	for k, s := range sections {
+		updated[k] = s
+	}
+	if c.Default == "" && len(c.Components) == 0 {
+		delete(updated, key)
+		return updated, nil
+	}
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(c); err != nil {
+		return nil, err
+	}
+	updated[key] = b.String()
+	return updated, nil
+}
+
+// KeepLevels returns the provided new config sections, with the log_levels
+// section of the old config sections if the new ones don't have one. It
+// preserves the levels set with UpdateLevels when a config without levels is
+// applied.
+func KeepLevels(new, old map[string]string) map[string]string {
+	if _, ok := sectionOf(new); ok {
+		return new
+	}
+	key, ok := sectionOf(old)
+	if !ok {
+		return new
+	}
+	kept := make(map[string]string, len(new)+1)
+	for k, s := range new {
+		kept[k] = s
+	}
+	kept[key] = old[key]
+	return kept
+}
+
+// sectionOf returns the key of the log_levels section of the provided config
+// sections, if any.
+func sectionOf(sections map[string]string) (string, bool) {
+	if _, ok := sections[shortLevelsKey]; ok {
+		return shortLevelsKey, true
+	}
+	_, ok := sections[levelsKey]
+	return levelsKey, ok
+}
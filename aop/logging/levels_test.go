@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"greatestworks/aop/logtype"
+	"greatestworks/aop/protos"
+)
+
+func TestLevels(t *testing.T) {
+	var levels Levels
+	if !levels.Enabled("github.com/my/app/Store", logtype.Debug) {
+		t.Fatal("Enabled: zero Levels don't enable debug")
+	}
+
+	sections := map[string]string{"log_levels": `
+		default = "error"
+		components = {"app.Store" = "debug", "github.com/my/app/Cache" = "info"}`}
+	if err := levels.Update(sections); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		component string
+		level     logtype.Level
+		want      bool
+	}{
+		{"github.com/my/app/Store", logtype.Debug, true}, // by short name
+		{"github.com/my/app/Cache", logtype.Debug, false},
+		{"github.com/my/app/Cache", logtype.Info, true},
+		{"github.com/my/app/Todo", logtype.Info, false},
+		{"github.com/my/app/Todo", logtype.Error, true},
+	} {
+		if got := levels.Enabled(test.component, test.level); got != test.want {
+			t.Errorf("Enabled(%q, %v): got %t, want %t", test.component, test.level, got, test.want)
+		}
+	}
+	if !levels.Allows(&protos.LogEntry{Component: "github.com/my/app/Todo", Level: "stdout"}) {
+		t.Error("Allows: stdout entry dropped")
+	}
+
+	// An invalid section leaves the levels unchanged.
+	if err := levels.Update(map[string]string{"log_levels": `default = "loud"`}); err == nil {
+		t.Fatal("Update: unexpected success")
+	}
+	if levels.Enabled("github.com/my/app/Todo", logtype.Info) {
+		t.Error("Enabled: levels changed by an invalid update")
+	}
+}
+
+func TestFuncLoggerLevels(t *testing.T) {
+	var levels Levels
+	if err := levels.Update(map[string]string{"log_levels": `default = "info"`}); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	logger := FuncLogger{
+		Opts:  Options{Component: "Todo", Levels: &levels},
+		Write: func(entry *protos.LogEntry) { got = append(got, entry.Level) },
+	}
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Error("error", nil)
+	if diff := cmp.Diff([]string{"info", "error"}, got); diff != "" {
+		t.Fatalf("levels (-want +got):\n%s", diff)
+	}
+}
+
+func TestUpdateLevels(t *testing.T) {
+	sections := map[string]string{"other": "x = 1"}
+	sections, err := UpdateLevels(sections, "app.Store", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections, err = UpdateLevels(sections, DefaultLevel, "error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var levels Levels
+	if err := levels.Update(sections); err != nil {
+		t.Fatal(err)
+	}
+	if !levels.Enabled("github.com/my/app/Store", logtype.Debug) || levels.Enabled("Todo", logtype.Info) {
+		t.Fatalf("levels not updated: %q", sections[levelsKey])
+	}
+	if got, want := sections["other"], "x = 1"; got != want {
+		t.Fatalf("other section: got %q, want %q", got, want)
+	}
+
+	// Unsetting all the levels removes the section.
+	if sections, err = UpdateLevels(sections, "app.Store", ""); err != nil {
+		t.Fatal(err)
+	}
+	if sections, err = UpdateLevels(sections, DefaultLevel, ""); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]string{"other": "x = 1"}, sections); diff != "" {
+		t.Fatalf("sections (-want +got):\n%s", diff)
+	}
+
+	if _, err := UpdateLevels(sections, "app.Store", "loud"); err == nil {
+		t.Fatal("UpdateLevels: unexpected success for an invalid level")
+	}
+}
+
+func TestKeepLevels(t *testing.T) {
+	old := map[string]string{"a": "1", "log_levels": `default = "info"`}
+	if diff := cmp.Diff(map[string]string{"b": "2", "log_levels": `default = "info"`}, KeepLevels(map[string]string{"b": "2"}, old)); diff != "" {
+		t.Errorf("KeepLevels (-want +got):\n%s", diff)
+	}
+	new := map[string]string{"log_levels": `default = "error"`}
+	if diff := cmp.Diff(new, KeepLevels(new, old)); diff != "" {
+		t.Errorf("KeepLevels (-want +got):\n%s", diff)
+	}
+}
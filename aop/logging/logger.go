@@ -21,6 +21,15 @@ type Options struct {
 	Weavelet   string // Service Weaver weavelet id (e.g., "36105c89-85b1...")
 
 	Attrs []string
+
+	// Format is the format in which StderrLogger writes log entries:
+	// PrettyFormat (the default) or JSONFormat.
+	Format string
+
+	// Levels, if not nil, are the minimum levels of the log entries logged
+	// by components. FuncLogger drops the entries below the level of
+	// Component.
+	Levels *Levels
 }
 
 // makeEntry returns an entry that is fully populated with the provided level,
@@ -64,16 +73,25 @@ var _ logtype.Logger = FuncLogger{}
 
 // Debug implements the [weaver.Logger] interface.
 func (l FuncLogger) Debug(msg string, attrs ...any) {
+	if !l.Opts.Levels.Enabled(l.Opts.Component, logtype.Debug) {
+		return
+	}
 	l.Write(makeEntry("debug", msg, attrs, 1, l.Opts))
 }
 
 // Info implements the [weaver.Logger] interface.
 func (l FuncLogger) Info(msg string, attrs ...any) {
+	if !l.Opts.Levels.Enabled(l.Opts.Component, logtype.Info) {
+		return
+	}
 	l.Write(makeEntry("info", msg, attrs, 1, l.Opts))
 }
 
 // Error implements the [weaver.Logger] interface.
 func (l FuncLogger) Error(msg string, err error, attrs ...any) {
+	if !l.Opts.Levels.Enabled(l.Opts.Component, logtype.Error) {
+		return
+	}
 	e := makeEntry("error", msg, attrs, 1, l.Opts)
 	if err != nil {
 		e.Attrs = append(e.Attrs, "err", err.Error())
@@ -81,11 +99,16 @@ func (l FuncLogger) Error(msg string, err error, attrs ...any) {
 	l.Write(e)
 }
 
-// StderrLogger returns a logger that pretty prints log entries to stderr.
+// StderrLogger returns a logger that writes log entries to stderr, in the
+// format specified by opts.Format. Log entries are pretty printed if the
+// format is unknown.
 func StderrLogger(opts Options) FuncLogger {
-	pp := NewPrettyPrinter(colors.Enabled())
+	f, err := NewFormatter(opts.Format, colors.Enabled())
+	if err != nil {
+		f = NewPrettyPrinter(colors.Enabled())
+	}
 	writeText := func(entry *protos.LogEntry) {
-		fmt.Fprintln(os.Stderr, pp.Format(entry))
+		fmt.Fprintln(os.Stderr, f.Format(entry))
 	}
 	return FuncLogger{opts, writeText}
 }
@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"greatestworks/aop/protos"
+)
+
+// A Cursor identifies a position in the results of a query, right after the
+// last log entry of a page of results (see ReadPage). The zero cursor
+// identifies the beginning of the results.
+//
+// A cursor holds the timestamp of the last log entry of the page, and the
+// number of entries with that timestamp in the results up to and including
+// that entry. A cursor is therefore only valid for the query that returned
+// it, and stays valid as new log entries are logged.
+type Cursor string
+
+// position is a decoded Cursor.
+type position struct {
+	timeMicros int64 // timestamp of the last returned entry
+	n          int   // number of returned entries with that timestamp
+}
+
+// encode returns the cursor of the position.
+func (p position) encode() Cursor {
+	s := fmt.Sprintf("%d.%d", p.timeMicros, p.n)
+	return Cursor(base64.RawURLEncoding.EncodeToString([]byte(s)))
+}
+
+// decode decodes the provided cursor.
+func decode(c Cursor) (position, error) {
+	if c == "" {
+		return position{}, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil {
+		return position{}, fmt.Errorf("invalid cursor %q: %w", c, err)
+	}
+	t, n, ok := strings.Cut(string(b), ".")
+	if !ok {
+		return position{}, fmt.Errorf("invalid cursor %q", c)
+	}
+	var p position
+	if p.timeMicros, err = strconv.ParseInt(t, 10, 64); err != nil {
+		return position{}, fmt.Errorf("invalid cursor %q: %w", c, err)
+	}
+	if p.n, err = strconv.Atoi(n); err != nil || p.n < 0 {
+		return position{}, fmt.Errorf("invalid cursor %q", c)
+	}
+	return p, nil
+}
+
+// ReadPage returns at most limit log entries of source that match the
+// provided query, starting right after the provided cursor. It also returns
+// the cursor of the next page, or "" if there are no more entries. If limit
+// is not positive, ReadPage returns all the remaining entries.
+//
+// For example, the following code prints all the log entries of the "todo"
+// app, one page of 100 entries at a time:
+//
+//	var cursor Cursor
+//	for {
+//	    entries, next, err := ReadPage(ctx, source, `app == "todo"`, cursor, 100)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    print(entries)
+//	    if next == "" {
+//	        break
+//	    }
+//	    cursor = next
+//	}
+func ReadPage(ctx context.Context, source Source, q Query, cursor Cursor, limit int) ([]*protos.LogEntry, Cursor, error) {
+	start, err := decode(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if cursor != "" {
+		// Only read the entries at or after the cursor.
+		since := time.UnixMicro(start.timeMicros).UTC().Format(time.RFC3339Nano)
+		q = fmt.Sprintf("(%s) && time >= timestamp(%q)", q, since)
+	}
+	r, err := source.Query(ctx, q, false)
+	if err != nil {
+		return nil, "", err
+	}
+	defer r.Close()
+
+	var entries []*protos.LogEntry
+	last := start
+	for {
+		entry, err := r.Read(ctx)
+		if errors.Is(err, io.EOF) {
+			return entries, "", nil
+		} else if err != nil {
+			return nil, "", err
+		}
+		if entry.TimeMicros == start.timeMicros && start.n > 0 {
+			// Skip the entries returned by previous pages.
+			start.n--
+			continue
+		}
+		if limit > 0 && len(entries) == limit {
+			// There is at least one more entry.
+			return entries, last.encode(), nil
+		}
+		entries = append(entries, entry)
+		if entry.TimeMicros == last.timeMicros {
+			last.n++
+		} else {
+			last = position{timeMicros: entry.TimeMicros, n: 1}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"greatestworks/aop/protomsg"
+	"greatestworks/aop/protos"
+)
+
+// writeEntries writes log entries with the provided timestamps (in seconds
+// after 2000-01-01) to a log file of the provided node in dir.
+func writeEntries(t *testing.T, dir, node string, seconds ...int) []*protos.LogEntry {
+	t.Helper()
+	file, err := os.Create(filepath.Join(dir, filename("app", "v1", node, "info")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var entries []*protos.LogEntry
+	for i, s := range seconds {
+		entry := &protos.LogEntry{
+			App:        "app",
+			Version:    "v1",
+			Node:       node,
+			TimeMicros: at(s),
+			Level:      "info",
+			Msg:        fmt.Sprintf("%s/%d", node, i),
+		}
+		if err := protomsg.Write(file, entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestReadPage(t *testing.T) {
+	dir := t.TempDir()
+	// Many entries share the same timestamp, across and within files.
+	writeEntries(t, dir, "1", 1, 2, 2, 2, 3, 5, 5)
+	writeEntries(t, dir, "2", 2, 2, 4, 5, 5, 5)
+	source := FileSource(dir)
+	ctx := context.Background()
+	const q = `app == "app"`
+
+	all, next, err := ReadPage(ctx, source, q, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != "" {
+		t.Fatalf("ReadPage without limit: got cursor %q, want none", next)
+	}
+	if got, want := len(all), 13; got != want {
+		t.Fatalf("ReadPage without limit: got %d entries, want %d", got, want)
+	}
+
+	for _, limit := range []int{1, 2, 3, 5, 13, 20} {
+		t.Run(fmt.Sprint(limit), func(t *testing.T) {
+			var got []*protos.LogEntry
+			var cursor Cursor
+			for pages := 0; ; pages++ {
+				if pages > len(all) {
+					t.Fatalf("too many pages")
+				}
+				entries, next, err := ReadPage(ctx, source, q, cursor, limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(entries) > limit {
+					t.Fatalf("ReadPage: got %d entries, want at most %d", len(entries), limit)
+				}
+				got = append(got, entries...)
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+			if diff := cmp.Diff(all, got, protocmp.Transform()); diff != "" {
+				t.Fatalf("pages (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadPageInvalidCursor(t *testing.T) {
+	for _, cursor := range []Cursor{"!", "MTIz", position{timeMicros: 1, n: -1}.encode()} {
+		if _, _, err := ReadPage(context.Background(), FileSource(t.TempDir()), `app == "app"`, cursor, 1); err == nil {
+			t.Errorf("ReadPage(%q): unexpected success", cursor)
+		}
+	}
+}
+
+func TestCatTimeRange(t *testing.T) {
+	dir := t.TempDir()
+	entries := writeEntries(t, dir, "1", 1, 2, 3, 4, 5)
+	r, err := FileSource(dir).Query(context.Background(), `time >= timestamp("2000-01-01T00:00:02Z") && time < timestamp("2000-01-01T00:00:04Z")`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got := drain(t, context.Background(), r)
+	if diff := cmp.Diff(entries[1:3], got, protocmp.Transform()); diff != "" {
+		t.Fatalf("entries (-want +got):\n%s", diff)
+	}
+}
@@ -42,9 +42,14 @@ import (
 //
 //   - boolean algebra (!, &&, ||),
 //   - equalities and inequalities (==, !=, <, <=, >, >=),
-//   - the string operations "contains" and "matches",
+//   - the string operations "contains" and "matches" (RE2 regular
+//     expressions),
 //   - map indexing (attrs["foo"]) and membership ("foo" in attrs), and
-//   - constant strings, timestamps, and ints.
+//   - constant strings, timestamps, durations, and ints.
+//
+// Constant timestamps can be absolute, like `timestamp("2022-01-01T00:00:00Z")`,
+// or relative to the time the query is executed, like `now - duration("1h")`.
+// Durations are sums of numbers with units, like "1h30m" or "100ms".
 //
 // All equalities and inequalities must look like `app == "todo"` or
 // `attrs["foo"] == "bar"`; i.e. a field or attribute on the left and a constant
@@ -99,6 +104,7 @@ func env() (*cel.Env, error) {
 		decls.NewVar("source", decls.String),
 		decls.NewVar("msg", decls.String),
 		decls.NewVar("attrs", decls.NewMapType(decls.String, decls.String)),
+		decls.NewVar("now", decls.Timestamp),
 	))
 }
 
@@ -203,6 +209,9 @@ func restrictCall(e *exprpb.Expr_Call) error {
 func restrictField(e *exprpb.Expr) error {
 	switch t := e.ExprKind.(type) {
 	case *exprpb.Expr_IdentExpr:
+		if t.IdentExpr.Name == "now" {
+			return fmt.Errorf("now is not a field")
+		}
 		return nil
 	case *exprpb.Expr_CallExpr:
 		fn := t.CallExpr.Function
@@ -222,36 +231,69 @@ func restrictField(e *exprpb.Expr) error {
 }
 
 // restrictLiteral checks whether the provided expression is a literal (e.g.,
-// 42, "foo").
+// 42, "foo", now - duration("1h")).
 func restrictLiteral(e *exprpb.Expr) error {
 	switch e.ExprKind.(type) {
 	case *exprpb.Expr_ConstExpr:
 		return nil
+	case *exprpb.Expr_IdentExpr:
+		if e.GetIdentExpr().Name != "now" {
+			return fmt.Errorf("unsupported literal: %v", e)
+		}
+		return nil
 	case *exprpb.Expr_CallExpr:
 		call := e.GetCallExpr()
-		if f := call.Function; f != "timestamp" {
+		switch call.Function {
+		case "timestamp", "duration":
+			if call.Args[0].GetConstExpr() == nil {
+				return fmt.Errorf("unsupported literal: %v", e)
+			}
+			return nil
+		case operators.Add, operators.Subtract:
+			for i := 0; i < 2; i++ {
+				if err := restrictTimeLiteral(call.Args[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		default:
 			return fmt.Errorf("unsupported literal: %v", e)
 		}
-		return restrictLiteral(call.Args[0])
 	default:
 		return fmt.Errorf("unsupported literal: %v", e)
 	}
 }
 
+// restrictTimeLiteral checks whether the provided expression is a timestamp
+// or duration literal (e.g., now, duration("1h")).
+func restrictTimeLiteral(e *exprpb.Expr) error {
+	if e.GetIdentExpr() != nil {
+		return restrictLiteral(e)
+	}
+	switch e.GetCallExpr().GetFunction() {
+	case "timestamp", "duration", operators.Add, operators.Subtract:
+		return restrictLiteral(e)
+	default:
+		return fmt.Errorf("unsupported time literal: %v", e)
+	}
+}
+
 // rewrite rewrites an expression parsed from a query into a CEL expression
 // with the same semantics as the query. Specifically, binary expressions over
 // attributes, like `attrs["foo"] == "bar"`, are translated to include an implicit
-// membership test like `"foo" in attrs && attrs["foo"] == "bar"`.
+// membership test like `"foo" in attrs && attrs["foo"] == "bar"`. Relative
+// timestamps, like `now - duration("1h")`, are translated to absolute
+// timestamps, relative to the current time.
 func rewrite(e *exprpb.Expr) (*exprpb.Expr, error) {
 	e = proto.Clone(e).(*exprpb.Expr)
-	return rewriteExpr(e)
+	return rewriteExpr(e, time.Now())
 }
 
-func rewriteExpr(e *exprpb.Expr) (*exprpb.Expr, error) {
+func rewriteExpr(e *exprpb.Expr, now time.Time) (*exprpb.Expr, error) {
 	switch e.ExprKind.(type) {
 	case *exprpb.Expr_CallExpr:
 		// Note that CEL represents operators like || and ! as calls.
-		call, err := rewriteCall(e.GetCallExpr())
+		call, err := rewriteCall(e.GetCallExpr(), now)
 		if err != nil {
 			return nil, err
 		}
@@ -261,18 +303,18 @@ func rewriteExpr(e *exprpb.Expr) (*exprpb.Expr, error) {
 	}
 }
 
-func rewriteCall(e *exprpb.Expr_Call) (*exprpb.Expr_Call, error) {
+func rewriteCall(e *exprpb.Expr_Call, now time.Time) (*exprpb.Expr_Call, error) {
 	switch e.GetFunction() {
 	// !
 	case operators.LogicalNot:
-		sub, err := rewriteExpr(e.Args[0])
+		sub, err := rewriteExpr(e.Args[0], now)
 		e.Args[0] = sub
 		return e, err
 
 	// &&, ||
 	case operators.LogicalAnd, operators.LogicalOr:
 		for i := 0; i < 2; i++ {
-			sub, err := rewriteExpr(e.Args[i])
+			sub, err := rewriteExpr(e.Args[i], now)
 			if err != nil {
 				return nil, err
 			}
@@ -284,6 +326,13 @@ func rewriteCall(e *exprpb.Expr_Call) (*exprpb.Expr_Call, error) {
 	case operators.Equals, operators.NotEquals,
 		operators.Less, operators.LessEquals,
 		operators.Greater, operators.GreaterEquals:
+		if isRelativeTime(e.Args[1]) {
+			t, err := evalTime(e.Args[1], now)
+			if err != nil {
+				return nil, err
+			}
+			e.Args[1] = timestampExpr(t)
+		}
 		attrs, attr, ok := explodeIndex(e.Args[0])
 		if !ok {
 			// There is no attrs["foo"] expression, so we don't have to
@@ -312,6 +361,141 @@ func rewriteCall(e *exprpb.Expr_Call) (*exprpb.Expr_Call, error) {
 	}
 }
 
+// isRelativeTime returns whether the provided literal is a timestamp that
+// needs to be evaluated, like `now` or `timestamp("...") + duration("1h")`.
+func isRelativeTime(e *exprpb.Expr) bool {
+	if e.GetIdentExpr() != nil {
+		return true
+	}
+	switch e.GetCallExpr().GetFunction() {
+	case operators.Add, operators.Subtract:
+		return true
+	default:
+		return false
+	}
+}
+
+// evalTime evaluates a timestamp literal, substituting now for `now`.
+func evalTime(e *exprpb.Expr, now time.Time) (time.Time, error) {
+	if e.GetIdentExpr() != nil { // now
+		return now, nil
+	}
+	call := e.GetCallExpr()
+	switch call.GetFunction() {
+	case "timestamp":
+		return time.Parse(time.RFC3339, call.Args[0].GetConstExpr().GetStringValue())
+	case operators.Add, operators.Subtract:
+		// The query is type-checked, so exactly one of the operands is a
+		// timestamp, and it is the first one in a subtraction.
+		ts, d := call.Args[0], call.Args[1]
+		if isDuration(ts) {
+			ts, d = d, ts
+		}
+		t, err := evalTime(ts, now)
+		if err != nil {
+			return time.Time{}, err
+		}
+		delta, err := evalDuration(d)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if call.GetFunction() == operators.Subtract {
+			delta = -delta
+		}
+		return t.Add(delta), nil
+	default:
+		return time.Time{}, fmt.Errorf("unsupported timestamp: %v", e)
+	}
+}
+
+// isDuration returns whether the provided literal is a duration.
+func isDuration(e *exprpb.Expr) bool {
+	call := e.GetCallExpr()
+	switch call.GetFunction() {
+	case "duration":
+		return true
+	case operators.Add, operators.Subtract:
+		return isDuration(call.Args[0]) && isDuration(call.Args[1])
+	default:
+		return false
+	}
+}
+
+// evalDuration evaluates a duration literal.
+func evalDuration(e *exprpb.Expr) (time.Duration, error) {
+	call := e.GetCallExpr()
+	switch call.GetFunction() {
+	case "duration":
+		return time.ParseDuration(call.Args[0].GetConstExpr().GetStringValue())
+	case operators.Add, operators.Subtract:
+		a, err := evalDuration(call.Args[0])
+		if err != nil {
+			return 0, err
+		}
+		b, err := evalDuration(call.Args[1])
+		if err != nil {
+			return 0, err
+		}
+		if call.GetFunction() == operators.Subtract {
+			return a - b, nil
+		}
+		return a + b, nil
+	default:
+		return 0, fmt.Errorf("unsupported duration: %v", e)
+	}
+}
+
+// timestampExpr returns the literal `timestamp("...")` for the provided time.
+func timestampExpr(t time.Time) *exprpb.Expr {
+	arg := &exprpb.Expr{ExprKind: &exprpb.Expr_ConstExpr{ConstExpr: &exprpb.Constant{
+		ConstantKind: &exprpb.Constant_StringValue{StringValue: t.Format(time.RFC3339Nano)},
+	}}}
+	return callexpr(&exprpb.Expr_Call{Function: "timestamp", Args: []*exprpb.Expr{arg}})
+}
+
+// timeRange returns the range of times of the log entries that may match the
+// provided rewritten expression, as implied by its top-level comparisons of
+// the time field (e.g., `time >= timestamp("...") && ...`). A zero since or
+// until means that the range is unbounded on that side.
+func timeRange(e *exprpb.Expr) (since, until time.Time) {
+	call := e.GetCallExpr()
+	if call == nil {
+		return
+	}
+	switch f := call.GetFunction(); f {
+	case operators.LogicalAnd:
+		s1, u1 := timeRange(call.Args[0])
+		s2, u2 := timeRange(call.Args[1])
+		since, until = s1, u1
+		if s2.After(since) {
+			since = s2
+		}
+		if !u2.IsZero() && (until.IsZero() || u2.Before(until)) {
+			until = u2
+		}
+		return
+
+	case operators.Equals, operators.Less, operators.LessEquals,
+		operators.Greater, operators.GreaterEquals:
+		if call.Args[0].GetIdentExpr().GetName() != "time" || call.Args[1].GetCallExpr().GetFunction() != "timestamp" {
+			return
+		}
+		t, err := evalTime(call.Args[1], time.Time{})
+		if err != nil {
+			return
+		}
+		switch f {
+		case operators.Equals:
+			return t, t
+		case operators.Less, operators.LessEquals:
+			return time.Time{}, t
+		default:
+			return t, time.Time{}
+		}
+	}
+	return
+}
+
 // callexpr wraps an Expr_Call into an Expr.
 func callexpr(call *exprpb.Expr_Call) *exprpb.Expr {
 	return &exprpb.Expr{ExprKind: &exprpb.Expr_CallExpr{CallExpr: call}}
@@ -438,14 +622,44 @@ func formatConst(w io.Writer, c *exprpb.Constant) error {
 	}
 }
 
+// compiled is a compiled query.
+type compiled struct {
+	prog  cel.Program
+	since time.Time // matching entries are logged at or after since, if not zero
+	until time.Time // matching entries are logged at or before until, if not zero
+}
+
+// compileQuery parses and compiles a query.
+func compileQuery(query Query) (compiled, error) {
+	env, ast, err := parse(query)
+	if err != nil {
+		return compiled{}, err
+	}
+	e, err := rewrite(ast.Expr())
+	if err != nil {
+		return compiled{}, fmt.Errorf("compile rewrite: %w", err)
+	}
+	prog, err := compileRewritten(env, e)
+	if err != nil {
+		return compiled{}, err
+	}
+	since, until := timeRange(e)
+	return compiled{prog: prog, since: since, until: until}, nil
+}
+
 // compile compiles a query into a cel.Program.
 func compile(env *cel.Env, ast *cel.Ast) (cel.Program, error) {
-	// CEL does not provide any helper functions to rewrite ASTs. Instead, we
-	// have to massage the AST, format it, and then parse it again.
 	e, err := rewrite(ast.Expr())
 	if err != nil {
 		return nil, fmt.Errorf("compile rewrite: %w", err)
 	}
+	return compileRewritten(env, e)
+}
+
+// compileRewritten compiles a rewritten query into a cel.Program.
+func compileRewritten(env *cel.Env, e *exprpb.Expr) (cel.Program, error) {
+	// CEL does not provide any helper functions to rewrite ASTs. Instead, we
+	// have to massage the AST, format it, and then parse it again.
 	q, err := format(e)
 	if err != nil {
 		return nil, fmt.Errorf("compile format: %w", err)
@@ -18,6 +18,9 @@ func TestValidQueries(t *testing.T) {
 		`attrs["name"].contains("foo")`,
 		`"foo" in attrs`,
 		`time < timestamp("1972-01-01T10:00:20.021-05:00")`,
+		`time >= now - duration("1h")`,
+		`time < timestamp("1972-01-01T10:00:20.021-05:00") + duration("1h30m")`,
+		`time <= now - duration("1h") - duration("30m")`,
 		`app == "todo" && version == "v1"`,
 		`app == "todo" && full_version == "v1"`,
 		`app == "todo" || app == "collatz"`,
@@ -68,10 +71,13 @@ func TestInvalidQueries(t *testing.T) {
 		`source in attrs`,
 		`attrs["foo"] in attrs`,
 		`timestamp("1972-01-01T10:00:20.021-05:00") > time`,
+		`now > timestamp("1972-01-01T10:00:20.021-05:00")`,
 
 		// Bad RHS.
 		`source == source`,
 		`attrs["foo"] == attrs["foo"]`,
+		`app == "to" + "do"`,
+		`time >= time - duration("1h")`,
 
 		// Unsupported root operations.
 		`true`,   // bool
@@ -350,3 +356,68 @@ func TestQueryMatches(t *testing.T) {
 		})
 	}
 }
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Now()
+	for _, test := range []struct {
+		name  string
+		query Query
+		time  time.Time
+		want  bool
+	}{
+		{"Since/In", `time >= now - duration("1h")`, now.Add(-30 * time.Minute), true},
+		{"Since/Out", `time >= now - duration("1h")`, now.Add(-2 * time.Hour), false},
+		{"Until/In", `time <= now - duration("1h")`, now.Add(-2 * time.Hour), true},
+		{"Until/Out", `time <= now - duration("1h")`, now.Add(-30 * time.Minute), false},
+		{"Sum/In", `time >= now - (duration("1h") + duration("30m"))`, now.Add(-80 * time.Minute), true},
+		{"Sum/Out", `time >= now - (duration("1h") + duration("30m"))`, now.Add(-100 * time.Minute), false},
+		{"Absolute/In", `time < timestamp("2000-01-01T00:00:00Z") + duration("1m")`, time.Unix(at(30)/1e6, 0), true},
+		{"Absolute/Out", `time < timestamp("2000-01-01T00:00:00Z") + duration("1m")`, time.Unix(at(90)/1e6, 0), false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := compileQuery(test.query)
+			if err != nil {
+				t.Fatalf("compileQuery(%v): %v", test.query, err)
+			}
+			entry := &protos.LogEntry{TimeMicros: test.time.UnixMicro()}
+			got, err := matches(c.prog, entry)
+			if err != nil {
+				t.Fatalf("matches(%v, %v): %v", test.query, entry, err)
+			}
+			if got != test.want {
+				t.Errorf("matches(%v, %v): got %t, want %t", test.query, entry, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTimeRange(t *testing.T) {
+	t10 := time.UnixMicro(at(10)).UTC()
+	t20 := time.UnixMicro(at(20)).UTC()
+	for _, test := range []struct {
+		query        Query
+		since, until time.Time
+	}{
+		{`app == "a"`, time.Time{}, time.Time{}},
+		{`time >= timestamp("2000-01-01T00:00:10Z")`, t10, time.Time{}},
+		{`time < timestamp("2000-01-01T00:00:20Z")`, time.Time{}, t20},
+		{`time == timestamp("2000-01-01T00:00:10Z")`, t10, t10},
+		{`app == "a" && time > timestamp("2000-01-01T00:00:10Z") && time <= timestamp("2000-01-01T00:00:20Z")`, t10, t20},
+		{`time >= timestamp("2000-01-01T00:00:00Z") && time >= timestamp("2000-01-01T00:00:10Z")`, t10, time.Time{}},
+		{`time >= timestamp("2000-01-01T00:00:00Z") + duration("10s")`, t10, time.Time{}},
+
+		// Disjunctions and negations don't bound the time range.
+		{`app == "a" || time >= timestamp("2000-01-01T00:00:10Z")`, time.Time{}, time.Time{}},
+		{`!(time >= timestamp("2000-01-01T00:00:10Z"))`, time.Time{}, time.Time{}},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			c, err := compileQuery(test.query)
+			if err != nil {
+				t.Fatalf("compileQuery(%v): %v", test.query, err)
+			}
+			if !c.since.Equal(test.since) || !c.until.Equal(test.until) {
+				t.Errorf("time range: got [%v, %v], want [%v, %v]", c.since, c.until, test.since, test.until)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+// This file contains code to rotate, compress, and purge the log files
+// written by a FileStore, so that they don't fill up the disk.
+//
+// A FileStore writes every stream of log entries (i.e., every (app,
+// deployment, weavelet, level) tuple) to a sequence of segments. When a
+// segment grows too big or too old, the FileStore closes it, and writes the
+// next entries of the stream to the next segment. Segments are never renamed,
+// so that readers following a segment (see fileFollower) keep reading it
+// undisturbed.
+//
+// A background goroutine, the purger, periodically compresses the closed
+// segments, and deletes the oldest log files once they exceed the retention
+// limits. A log file is compressed by writing a temporary file, renaming it,
+// and then removing the uncompressed file. Readers read the uncompressed file
+// when both files exist (see ls).
+//
+// Multiple processes may write log files in the same directory. The purger
+// only compresses sealed segments, i.e. segments for which a later segment of
+// the same stream exists, as no process writes them anymore.
+
+var (
+	rotatedBytes = metrics.NewCounter(
+		"serviceweaver_system_log_rotated_bytes",
+		"Number of bytes in the log files that were rotated",
+	)
+	droppedBytes = metrics.NewCounter(
+		"serviceweaver_system_log_dropped_bytes",
+		"Number of bytes in the log files that were deleted by the retention policy",
+	)
+)
+
+// FileStoreOptions configures the rotation and retention of the log files of
+// a FileStore. The zero value disables rotation and retention: log files grow
+// forever.
+type FileStoreOptions struct {
+	// MaxFileSize, if positive, is the size, in bytes, above which a log file
+	// is rotated.
+	MaxFileSize int64
+
+	// MaxFileAge, if positive, is the age above which a log file is rotated,
+	// even if no entry is written to it.
+	MaxFileAge time.Duration
+
+	// Compress, if true, compresses the rotated log files with gzip.
+	Compress bool
+
+	// RetainBytes, if positive, bounds the total size of the log files in
+	// the directory. The oldest rotated log files are deleted first.
+	RetainBytes int64
+
+	// RetainAge, if positive, is the age after which the log files that are
+	// not written anymore are deleted.
+	RetainAge time.Duration
+
+	// PurgeInterval is the interval between two compressions and purges of
+	// the log files. Defaults to one minute.
+	PurgeInterval time.Duration
+}
+
+// withDefaults returns a copy of the options with zero values replaced with
+// default values.
+func (o FileStoreOptions) withDefaults() FileStoreOptions {
+	if o.PurgeInterval <= 0 {
+		o.PurgeInterval = time.Minute
+	}
+	return o
+}
+
+// rotates returns whether log files are rotated.
+func (o FileStoreOptions) rotates() bool {
+	return o.MaxFileSize > 0 || o.MaxFileAge > 0
+}
+
+// purges returns whether a purger must run.
+func (o FileStoreOptions) purges() bool {
+	return o.MaxFileAge > 0 || o.Compress || o.RetainBytes > 0 || o.RetainAge > 0
+}
+
+// full returns whether the provided segment must be rotated.
+func (o FileStoreOptions) full(s *segment, now time.Time) bool {
+	if s.f == nil {
+		return false
+	}
+	return (o.MaxFileSize > 0 && s.size >= o.MaxFileSize) ||
+		(o.MaxFileAge > 0 && now.Sub(s.created) >= o.MaxFileAge)
+}
+
+// nextSeq returns the sequence number of the segment that follows the
+// segments of the stream with the provided filename in dir, or zero if there
+// are none.
+func nextSeq(dir, filename string) int {
+	direntries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	next := 0
+	for _, direntry := range direntries {
+		l, err := parseLogfile(direntry.Name())
+		if err != nil || l.stream() != filename {
+			continue
+		}
+		if l.seq >= next {
+			next = l.seq + 1
+		}
+	}
+	return next
+}
+
+// purger periodically compresses and purges the log files, until the
+// FileStore is closed.
+func (fs *FileStore) purger() {
+	defer fs.stopped.Done()
+	ticker := time.NewTicker(fs.opts.PurgeInterval)
+	defer ticker.Stop()
+
+	// Purge the files left by previous runs right away.
+	now := time.Now()
+	for {
+		if err := fs.purge(now); err != nil {
+			fmt.Fprintf(os.Stderr, "purge log files: %v\n", err)
+		}
+		select {
+		case <-fs.stop:
+			return
+		case now = <-ticker.C:
+		}
+	}
+}
+
+// archive is a log file that is not written by a FileStore.
+type archive struct {
+	name   string
+	file   logfile
+	size   int64
+	mtime  time.Time
+	sealed bool // is there a later segment of the same stream?
+}
+
+// purge rotates the segments that are too old, compresses the sealed
+// segments, and deletes the log files that exceed the retention limits.
+func (fs *FileStore) purge(now time.Time) error {
+	// Rotate the segments that are too old, even if no entry was written to
+	// them, and record the segments being written.
+	active := map[string]bool{}
+	fs.mu.Lock()
+	for fname, s := range fs.files {
+		if fs.opts.full(s, now) {
+			fs.rotate(fname, s)
+			continue
+		}
+		active[s.name] = true
+	}
+	fs.mu.Unlock()
+
+	// List the log files.
+	direntries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return err
+	}
+	var total int64
+	var archives []*archive
+	last := map[string]int{} // the last sequence number, by stream
+	for _, direntry := range direntries {
+		if direntry.IsDir() || isTemp(direntry.Name()) {
+			continue
+		}
+		l, err := parseLogfile(direntry.Name())
+		if err != nil {
+			continue
+		}
+		info, err := direntry.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		total += info.Size()
+		if seq, ok := last[l.stream()]; !ok || l.seq > seq {
+			last[l.stream()] = l.seq
+		}
+		if active[direntry.Name()] {
+			continue
+		}
+		archives = append(archives, &archive{
+			name:  direntry.Name(),
+			file:  l,
+			size:  info.Size(),
+			mtime: info.ModTime(),
+		})
+	}
+	for _, a := range archives {
+		a.sealed = a.file.seq < last[a.file.stream()]
+	}
+
+	// Compress the sealed segments.
+	if fs.opts.Compress {
+		for _, a := range archives {
+			if !a.sealed || a.file.compressed {
+				continue
+			}
+			size, err := compress(fs.dir, a.name, a.mtime)
+			if err != nil {
+				return err
+			}
+			total += size - a.size
+			a.name += compressedSuffix
+			a.size = size
+		}
+	}
+
+	// Delete the oldest log files, until the retention limits are met.
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].mtime.Before(archives[j].mtime)
+	})
+	for _, a := range archives {
+		expired := fs.opts.RetainAge > 0 && now.Sub(a.mtime) > fs.opts.RetainAge
+		exceeded := fs.opts.RetainBytes > 0 && total > fs.opts.RetainBytes && a.sealed
+		if !expired && !exceeded {
+			continue
+		}
+		if err := os.Remove(filepath.Join(fs.dir, a.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		total -= a.size
+		droppedBytes.Add(float64(a.size))
+	}
+	return nil
+}
+
+// compress compresses the log file with the provided name in dir, preserving
+// its modification time, and returns the size of the compressed file.
+func compress(dir, name string, mtime time.Time) (int64, error) {
+	src, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	// Write the compressed file to a temporary file first, so that readers
+	// never see a partially written compressed file.
+	tmp, err := os.CreateTemp(dir, name+compressedSuffix+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	w := gzip.NewWriter(tmp)
+	if _, err := io.Copy(w, src); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("compress %q: %w", name, err)
+	}
+	if err := w.Close(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("compress %q: %w", name, err)
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Chtimes(tmp.Name(), mtime, mtime); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name+compressedSuffix)); err != nil {
+		return 0, err
+	}
+	if err := os.Remove(filepath.Join(dir, name)); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
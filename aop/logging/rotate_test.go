@@ -0,0 +1,247 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"greatestworks/aop/protos"
+)
+
+// addEntries adds n log entries of the provided node to fs.
+func addEntries(fs *FileStore, node string, n int) []*protos.LogEntry {
+	var entries []*protos.LogEntry
+	for i := 0; i < n; i++ {
+		entry := &protos.LogEntry{
+			App:        "app",
+			Version:    "v1",
+			Node:       node,
+			TimeMicros: at(i),
+			Level:      "info",
+			Msg:        fmt.Sprintf("%s/%d", node, i),
+		}
+		fs.Add(entry)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// logFiles returns the sorted names of the files in dir.
+func logFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	direntries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, direntry := range direntries {
+		names = append(names, direntry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// catAll returns all the log entries in dir.
+func catAll(t *testing.T, dir string) []*protos.LogEntry {
+	t.Helper()
+	ctx := context.Background()
+	r, err := FileSource(dir).Query(ctx, `app == "app"`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	return drain(t, ctx, r)
+}
+
+func TestParseLogfile(t *testing.T) {
+	for _, test := range []struct {
+		filename string
+		want     logfile
+	}{
+		{"a.v1.n.info.log", logfile{app: "a", deployment: "v1", weavelet: "n", level: "info"}},
+		{"a.v1.n.info.3.log", logfile{app: "a", deployment: "v1", weavelet: "n", level: "info", seq: 3}},
+		{"a.v1.n.info.log.gz", logfile{app: "a", deployment: "v1", weavelet: "n", level: "info", compressed: true}},
+		{"a.v1.n.info.3.log.gz", logfile{app: "a", deployment: "v1", weavelet: "n", level: "info", seq: 3, compressed: true}},
+	} {
+		t.Run(test.filename, func(t *testing.T) {
+			got, err := parseLogfile(test.filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Fatalf("parseLogfile(%q): got %+v, want %+v", test.filename, got, test.want)
+			}
+		})
+	}
+
+	for _, filename := range []string{
+		"a.v1.n.log",
+		"a.v1.n.info.txt",
+		"a.v1.n.info.0.log",
+		"a.v1.n.info.x.log",
+		"a.v1.n.info.1.2.log",
+		"a.v1.n.info.gz",
+	} {
+		t.Run(filename, func(t *testing.T) {
+			if _, err := parseLogfile(filename); err == nil {
+				t.Fatalf("parseLogfile(%q): unexpected success", filename)
+			}
+		})
+	}
+}
+
+func TestRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFileStoreWithOptions(dir, FileStoreOptions{MaxFileSize: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := addEntries(fs, "1", 20)
+	if err := fs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := logFiles(t, dir)
+	if len(files) < 2 {
+		t.Fatalf("log files: got %v, want rotated files", files)
+	}
+	if diff := cmp.Diff(want, catAll(t, dir), protocmp.Transform()); diff != "" {
+		t.Fatalf("entries (-want +got):\n%s", diff)
+	}
+
+	// A new store doesn't overwrite the existing segments.
+	fs, err = NewFileStoreWithOptions(dir, FileStoreOptions{MaxFileSize: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.Add(&protos.LogEntry{App: "app", Version: "v1", Node: "1", Level: "info", TimeMicros: at(100)})
+	if err := fs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(logFiles(t, dir)), len(files)+1; got != want {
+		t.Fatalf("log files after restart: got %d, want %d", got, want)
+	}
+	if got, want := len(catAll(t, dir)), 21; got != want {
+		t.Fatalf("entries after restart: got %d, want %d", got, want)
+	}
+}
+
+func TestRotateByAge(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFileStoreWithOptions(dir, FileStoreOptions{MaxFileSize: 1 << 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	addEntries(fs, "1", 2)
+
+	// Idle segments are rotated by the purger.
+	fs.opts.MaxFileAge = time.Hour
+	if err := fs.purge(time.Now().Add(2 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	fs.Add(&protos.LogEntry{App: "app", Version: "v1", Node: "1", Level: "info", TimeMicros: at(10)})
+	want := []string{"app.v1.1.info.1.log", "app.v1.1.info.log"}
+	if diff := cmp.Diff(want, logFiles(t, dir)); diff != "" {
+		t.Fatalf("log files (-want +got):\n%s", diff)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	dir := t.TempDir()
+	// Don't run the purger in the background. Purge explicitly instead.
+	fs, err := NewFileStoreWithOptions(dir, FileStoreOptions{MaxFileSize: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	want := addEntries(fs, "1", 20)
+	fs.opts.Compress = true
+	if err := fs.purge(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the segment being written is not compressed, and all the entries
+	// can still be read.
+	files := logFiles(t, dir)
+	for i, file := range files {
+		l, err := parseLogfile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		active := fs.files[filename("app", "v1", "1", "info")].name == file
+		if l.compressed == active {
+			t.Errorf("file %d of %v: got compressed %v, want %v", i, files, l.compressed, !active)
+		}
+	}
+	if diff := cmp.Diff(want, catAll(t, dir), protocmp.Transform()); diff != "" {
+		t.Fatalf("entries (-want +got):\n%s", diff)
+	}
+
+	// Delete the oldest files until the files fit in the retention limit.
+	// The segment being written is never deleted.
+	fs.opts.RetainBytes = 1
+	if err := fs.purge(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{fs.files[filename("app", "v1", "1", "info")].name}, logFiles(t, dir)); diff != "" {
+		t.Fatalf("log files (-want +got):\n%s", diff)
+	}
+}
+
+func TestPurgeByAge(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, "old", 1, 2)
+	writeEntries(t, dir, "new", 3, 4)
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, filename("app", "v1", "old", "info")), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := NewFileStoreWithOptions(dir, FileStoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	fs.opts.RetainAge = 24 * time.Hour
+	if err := fs.purge(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filename("app", "v1", "new", "info")}
+	if diff := cmp.Diff(want, logFiles(t, dir)); diff != "" {
+		t.Fatalf("log files (-want +got):\n%s", diff)
+	}
+}
+
+func TestFollowCompressed(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFileStoreWithOptions(dir, FileStoreOptions{MaxFileSize: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	want := addEntries(fs, "1", 20)
+	fs.opts.Compress = true
+	if err := fs.purge(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A follower reads the compressed files, and the new entries.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r, err := FileSource(dir).Query(ctx, `app == "app"`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got := take(t, ctx, r, len(want))
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Fatalf("entries (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,156 @@
+package sinks
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"greatestworks/aop"
+)
+
+const (
+	configKey      = "greatestworks/log_sinks"
+	shortConfigKey = "log_sinks"
+)
+
+func init() {
+	aop.RegisterConfigSection[Config](configKey, shortConfigKey)
+}
+
+// Config is the config of the log sinks of a deployment, as found in the
+// log_sinks section of the app config. For example:
+//
+//	[log_sinks]
+//	batch_size = 1000
+//	batch_delay = "2s"
+//
+//	[log_sinks.loki]
+//	url = "http://loki.example.com:3100"
+//	labels = {env = "prod"}
+//
+//	[log_sinks.elasticsearch]
+//	url = "http://es.example.com:9200"
+//	index = "game-logs-{2006.01.02}"
+//
+// See Options, LokiOptions, and ElasticsearchOptions.
+type Config struct {
+	BatchSize   int    `toml:"batch_size"`
+	BatchDelay  string `toml:"batch_delay"`
+	BufferSize  int    `toml:"buffer_size"`
+	MaxBlock    string `toml:"max_block"`
+	MaxAttempts int    `toml:"max_attempts"`
+
+	Loki          *lokiConfig          `toml:"loki"`
+	Elasticsearch *elasticsearchConfig `toml:"elasticsearch"`
+}
+
+// lokiConfig is the config of a Loki sink. See LokiOptions.
+type lokiConfig struct {
+	URL      string            `toml:"url"`
+	TenantID string            `toml:"tenant_id"`
+	Labels   map[string]string `toml:"labels"`
+	Username string            `toml:"username"`
+	Password string            `toml:"password"`
+}
+
+// elasticsearchConfig is the config of an Elasticsearch sink. See
+// ElasticsearchOptions.
+type elasticsearchConfig struct {
+	URL      string `toml:"url"`
+	Index    string `toml:"index"`
+	APIKey   string `toml:"api_key"`
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	_, err := c.options()
+	return err
+}
+
+// options returns the sink options specified in the config.
+func (c *Config) options() (Options, error) {
+	opts := Options{
+		BatchSize:   c.BatchSize,
+		BufferSize:  c.BufferSize,
+		MaxAttempts: c.MaxAttempts,
+	}
+	var err error
+	if c.BatchDelay != "" {
+		if opts.BatchDelay, err = time.ParseDuration(c.BatchDelay); err != nil {
+			return opts, fmt.Errorf("invalid batch delay %q: %w", c.BatchDelay, err)
+		}
+	}
+	if c.MaxBlock != "" {
+		if opts.MaxBlock, err = time.ParseDuration(c.MaxBlock); err != nil {
+			return opts, fmt.Errorf("invalid max block %q: %w", c.MaxBlock, err)
+		}
+	}
+	if c.Loki != nil {
+		if err := validateURL(c.Loki.URL); err != nil {
+			return opts, fmt.Errorf("loki: %w", err)
+		}
+	}
+	if c.Elasticsearch != nil {
+		if err := validateURL(c.Elasticsearch.URL); err != nil {
+			return opts, fmt.Errorf("elasticsearch: %w", err)
+		}
+		if c.Elasticsearch.Index == "" {
+			return opts, fmt.Errorf("elasticsearch: no index provided")
+		}
+	}
+	return opts, nil
+}
+
+// validateURL checks that the provided URL is an absolute HTTP(S) URL.
+func validateURL(u string) error {
+	if u == "" {
+		return fmt.Errorf("no url provided")
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("invalid url %q: %w", u, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("invalid url %q: want an http or https url", u)
+	}
+	return nil
+}
+
+// FromConfig returns the sink specified in the log_sinks section of the
+// provided config sections, or nil if the section is missing or doesn't
+// specify any sink.
+func FromConfig(sections map[string]string) (Sink, error) {
+	var c Config
+	if err := aop.ParseConfigSection(configKey, shortConfigKey, sections, &c); err != nil {
+		return nil, err
+	}
+	opts, err := c.options()
+	if err != nil {
+		return nil, err
+	}
+	var sinks []Sink
+	if l := c.Loki; l != nil {
+		sinks = append(sinks, NewLoki(LokiOptions{
+			URL:      l.URL,
+			TenantID: l.TenantID,
+			Labels:   l.Labels,
+			Username: l.Username,
+			Password: l.Password,
+		}, opts))
+	}
+	if e := c.Elasticsearch; e != nil {
+		sinks = append(sinks, NewElasticsearch(ElasticsearchOptions{
+			URL:      e.URL,
+			Index:    e.Index,
+			APIKey:   e.APIKey,
+			Username: e.Username,
+			Password: e.Password,
+		}, opts))
+	}
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	return Tee(sinks...), nil
+}
@@ -0,0 +1,179 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"greatestworks/aop/protos"
+)
+
+// ElasticsearchOptions configures a sink that pushes log entries to
+// Elasticsearch.
+type ElasticsearchOptions struct {
+	// URL is the base URL of Elasticsearch (e.g.,
+	// "http://es.example.com:9200"). Log entries are pushed to URL +
+	// "/_bulk".
+	URL string
+
+	// Index is the index (or data stream) in which log entries are stored.
+	// It may contain a time layout between braces, which is replaced with
+	// the UTC time of the log entry formatted with the layout, e.g.
+	// "logs-{2006.01.02}" for daily indices.
+	Index string
+
+	// APIKey, if not empty, is the API key used to authenticate to
+	// Elasticsearch. Otherwise, Username and Password, if not empty, are the
+	// credentials used to authenticate with basic authentication.
+	APIKey   string
+	Username string
+	Password string
+}
+
+// NewElasticsearch returns a sink that pushes log entries to Elasticsearch
+// using the bulk API. Every log entry is stored as a document with the
+// following fields: @timestamp, app, version, node, component, level, file,
+// line, msg, and attrs.
+func NewElasticsearch(eopts ElasticsearchOptions, opts Options) Sink {
+	return newRemote("elasticsearch", &elasticsearchClient{opts: eopts, client: http.Client{}}, opts)
+}
+
+// elasticsearchClient pushes log entries to Elasticsearch's bulk API.
+type elasticsearchClient struct {
+	opts   ElasticsearchOptions
+	client http.Client
+}
+
+// elasticsearchDoc is the document of a log entry.
+type elasticsearchDoc struct {
+	Timestamp string            `json:"@timestamp"`
+	App       string            `json:"app"`
+	Version   string            `json:"version"`
+	Node      string            `json:"node"`
+	Component string            `json:"component"`
+	Level     string            `json:"level"`
+	File      string            `json:"file,omitempty"`
+	Line      int32             `json:"line,omitempty"`
+	Msg       string            `json:"msg"`
+	Attrs     map[string]string `json:"attrs,omitempty"`
+}
+
+// elasticsearchAction is the action line of a document in a bulk request.
+// Documents are created rather than indexed, so that they can be stored in
+// data streams.
+type elasticsearchAction struct {
+	Create struct {
+		Index string `json:"_index"`
+	} `json:"create"`
+}
+
+// elasticsearchReply is the reply to a bulk request.
+type elasticsearchReply struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// index returns the index of the provided log entry.
+func (c *elasticsearchClient) index(entry *protos.LogEntry) string {
+	start := strings.IndexByte(c.opts.Index, '{')
+	end := strings.IndexByte(c.opts.Index, '}')
+	if start < 0 || end < start {
+		return c.opts.Index
+	}
+	layout := c.opts.Index[start+1 : end]
+	return c.opts.Index[:start] + timestamp(entry).UTC().Format(layout) + c.opts.Index[end+1:]
+}
+
+// push implements the client interface.
+func (c *elasticsearchClient) push(ctx context.Context, batch []*protos.LogEntry) ([]*protos.LogEntry, int, error) {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, entry := range batch {
+		var action elasticsearchAction
+		action.Create.Index = c.index(entry)
+		doc := elasticsearchDoc{
+			Timestamp: timestamp(entry).UTC().Format(time.RFC3339Nano),
+			App:       entry.App,
+			Version:   entry.Version,
+			Node:      entry.Node,
+			Component: entry.Component,
+			Level:     entry.Level,
+			File:      entry.File,
+			Line:      entry.Line,
+			Msg:       entry.Msg,
+			Attrs:     attrs(entry),
+		}
+		// Encode appends a newline after every value, as required by the
+		// bulk API.
+		if err := enc.Encode(&action); err != nil {
+			return nil, len(batch), err
+		}
+		if err := enc.Encode(&doc); err != nil {
+			return nil, len(batch), err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.URL+"/_bulk", &body)
+	if err != nil {
+		return nil, len(batch), err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if c.opts.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+c.opts.APIKey)
+	} else if c.opts.Username != "" {
+		req.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return batch, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err := fmt.Errorf("push to Elasticsearch: %s: %s", resp.Status, bytes.TrimSpace(msg))
+		if retryable(resp.StatusCode) {
+			return batch, 0, err
+		}
+		return nil, len(batch), err
+	}
+
+	// The bulk API succeeds even if some documents fail. Retry the
+	// documents that failed because Elasticsearch is overloaded.
+	var reply elasticsearchReply
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return nil, 0, fmt.Errorf("push to Elasticsearch: decode reply: %w", err)
+	}
+	if !reply.Errors {
+		return nil, 0, nil
+	}
+	if len(reply.Items) != len(batch) {
+		return nil, 0, fmt.Errorf("push to Elasticsearch: got %d items, want %d", len(reply.Items), len(batch))
+	}
+	var retry []*protos.LogEntry
+	rejected := 0
+	for i, item := range reply.Items {
+		for _, result := range item {
+			if result.Error == nil {
+				continue
+			}
+			if retryable(result.Status) {
+				retry = append(retry, batch[i])
+			} else {
+				rejected++
+			}
+			err = fmt.Errorf("push to Elasticsearch: %s: %s", result.Error.Type, result.Error.Reason)
+		}
+	}
+	return retry, rejected, err
+}
@@ -0,0 +1,168 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"greatestworks/aop/logging"
+	"greatestworks/aop/protos"
+)
+
+// LokiOptions configures a sink that pushes log entries to Grafana Loki.
+type LokiOptions struct {
+	// URL is the base URL of Loki (e.g., "http://loki.example.com:3100").
+	// Log entries are pushed to URL + "/loki/api/v1/push".
+	URL string
+
+	// TenantID, if not empty, is the tenant of the log entries, in a
+	// multi-tenant Loki.
+	TenantID string
+
+	// Labels are labels attached to every log entry, in addition to the
+	// app, version, node, level, and component labels.
+	Labels map[string]string
+
+	// Username and Password, if not empty, are the credentials used to
+	// authenticate to Loki with basic authentication.
+	Username string
+	Password string
+}
+
+// NewLoki returns a sink that pushes log entries to Grafana Loki.
+//
+// Every log entry is pushed as a JSON line in a stream labeled with the app,
+// version, node, level, and component of the entry. The line holds the
+// message, source location, and attributes of the entry, and can be parsed
+// in LogQL queries with the json parser (e.g.,
+// `{app="todo"} | json | attrs_player="alice"`).
+func NewLoki(lopts LokiOptions, opts Options) Sink {
+	return newRemote("loki", &lokiClient{opts: lopts, client: http.Client{}}, opts)
+}
+
+// lokiClient pushes log entries to Loki's push API.
+type lokiClient struct {
+	opts   LokiOptions
+	client http.Client
+}
+
+// lokiPush is the body of a request to Loki's push API.
+type lokiPush struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+// lokiStream is a stream of log entries with the same labels.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"` // (timestamp in ns, line) pairs
+}
+
+// lokiLine is the line of a log entry pushed to Loki. The fields of the log
+// entry stored as labels are omitted.
+type lokiLine struct {
+	Msg   string            `json:"msg"`
+	File  string            `json:"file,omitempty"`
+	Line  int32             `json:"line,omitempty"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+// push implements the client interface.
+func (c *lokiClient) push(ctx context.Context, batch []*protos.LogEntry) ([]*protos.LogEntry, int, error) {
+	// Group the entries by stream. Loki requires the entries of a stream to
+	// be pushed in timestamp order, which is the order in which they were
+	// logged.
+	type key struct{ app, version, node, level, component string }
+	streams := map[key]*lokiStream{}
+	var req lokiPush
+	for _, entry := range batch {
+		k := key{entry.App, logging.Shorten(entry.Version), logging.Shorten(entry.Node), entry.Level, entry.Component}
+		stream, ok := streams[k]
+		if !ok {
+			labels := map[string]string{}
+			for name, value := range c.opts.Labels {
+				labels[name] = value
+			}
+			labels["app"] = k.app
+			labels["version"] = k.version
+			labels["node"] = k.node
+			labels["level"] = k.level
+			labels["component"] = k.component
+			stream = &lokiStream{Stream: labels}
+			streams[k] = stream
+			req.Streams = append(req.Streams, stream)
+		}
+		line, err := json.Marshal(lokiLine{
+			Msg:   entry.Msg,
+			File:  entry.File,
+			Line:  entry.Line,
+			Attrs: attrs(entry),
+		})
+		if err != nil {
+			return nil, len(batch), err
+		}
+		ns := strconv.FormatInt(timestamp(entry).UnixNano(), 10)
+		stream.Values = append(stream.Values, [2]string{ns, string(line)})
+	}
+	body, err := json.Marshal(&req)
+	if err != nil {
+		return nil, len(batch), err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.URL+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return nil, len(batch), err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.opts.TenantID != "" {
+		httpReq.Header.Set("X-Scope-OrgID", c.opts.TenantID)
+	}
+	if c.opts.Username != "" {
+		httpReq.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return batch, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil, 0, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("push to Loki: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if retryable(resp.StatusCode) {
+		return batch, 0, err
+	}
+	return nil, len(batch), err
+}
+
+// retryable returns whether a request that failed with the provided HTTP
+// status code may succeed if retried.
+func retryable(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// timestamp returns the time at which the provided log entry was logged.
+func timestamp(entry *protos.LogEntry) time.Time {
+	if entry.TimeMicros == 0 {
+		return time.Now()
+	}
+	return time.UnixMicro(entry.TimeMicros)
+}
+
+// attrs returns the attributes of the provided log entry, or nil if it has
+// none.
+func attrs(entry *protos.LogEntry) map[string]string {
+	if len(entry.Attrs) == 0 {
+		return nil
+	}
+	attrs := make(map[string]string, len(entry.Attrs)/2)
+	for i := 0; i+1 < len(entry.Attrs); i += 2 {
+		attrs[entry.Attrs[i]] = entry.Attrs[i+1]
+	}
+	return attrs
+}
@@ -0,0 +1,301 @@
+// Package sinks contains log sinks that ship log entries to remote log
+// stores, like Grafana Loki and Elasticsearch, so that logs leave the
+// machines that produce them.
+//
+// A sink batches log entries in the background, and pushes every batch to
+// its log store, retrying with exponential backoff. If the log store can't
+// keep up, the sink buffers a bounded number of entries. Once the buffer is
+// full, Add blocks for a bounded time, and then drops the entry. Dropped
+// entries are counted in the serviceweaver_system_log_sink_dropped_entries
+// metric.
+package sinks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/protos"
+	"greatestworks/aop/retry"
+)
+
+var (
+	sentEntries = metrics.NewCounterMap[sinkLabels](
+		"serviceweaver_system_log_sink_sent_entries",
+		"Number of log entries pushed to a remote log store",
+	)
+	droppedEntries = metrics.NewCounterMap[sinkLabels](
+		"serviceweaver_system_log_sink_dropped_entries",
+		"Number of log entries dropped by a remote log sink",
+	)
+)
+
+type sinkLabels struct {
+	Sink string // sink name (e.g., "loki")
+}
+
+// A Sink receives log entries. A Sink's Add method can be used as the log
+// saver of a deployer (e.g., logging.FuncLogger.Write).
+type Sink interface {
+	// Add adds a log entry to the sink. It may block for a bounded time if
+	// the sink is overloaded. It is safe to call concurrently.
+	Add(*protos.LogEntry)
+
+	// Close pushes the buffered log entries, for a bounded time, and closes
+	// the sink.
+	Close() error
+}
+
+// Options configures the batching, retries, and buffering of a sink.
+type Options struct {
+	// BatchSize is the maximum number of log entries pushed in a single
+	// request. Defaults to 512.
+	BatchSize int
+
+	// BatchDelay is the maximum time a log entry waits in a batch before the
+	// batch is pushed. Defaults to one second.
+	BatchDelay time.Duration
+
+	// BufferSize is the maximum number of log entries buffered by the sink,
+	// excluding the batch being pushed. Defaults to 8192.
+	BufferSize int
+
+	// MaxBlock is the maximum time Add blocks when the buffer is full,
+	// before dropping the log entry. Defaults to 100ms. If negative, Add
+	// never blocks.
+	MaxBlock time.Duration
+
+	// MaxAttempts is the maximum number of attempts to push a batch, with
+	// exponential backoff between attempts. Defaults to 5.
+	MaxAttempts int
+
+	// FlushTimeout bounds the time Close spends pushing the buffered log
+	// entries. Defaults to 10 seconds.
+	FlushTimeout time.Duration
+}
+
+// withDefaults returns a copy of the options with zero values replaced with
+// default values.
+func (o Options) withDefaults() Options {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 512
+	}
+	if o.BatchDelay <= 0 {
+		o.BatchDelay = time.Second
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = 8192
+	}
+	if o.MaxBlock == 0 {
+		o.MaxBlock = 100 * time.Millisecond
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.FlushTimeout <= 0 {
+		o.FlushTimeout = 10 * time.Second
+	}
+	return o
+}
+
+// backoff is the backoff between two attempts to push a batch.
+var backoff = retry.Options{BackoffMultiplier: 2, BackoffMinDuration: 100 * time.Millisecond}
+
+// requestTimeout bounds every request to a remote log store.
+const requestTimeout = 10 * time.Second
+
+// client pushes batches of log entries to a remote log store.
+type client interface {
+	// push pushes a batch of log entries. It returns the entries that
+	// weren't pushed but may be pushed by a later attempt, and the number
+	// of entries the log store rejected permanently. It returns an error if
+	// some entries weren't pushed.
+	push(ctx context.Context, batch []*protos.LogEntry) (retry []*protos.LogEntry, rejected int, err error)
+}
+
+// remote is a Sink that pushes log entries to a remote log store using a
+// client.
+type remote struct {
+	name    string
+	client  client
+	opts    Options
+	labels  sinkLabels
+	entries chan *protos.LogEntry // buffered entries
+
+	ctx       context.Context    // cancelled when the flush times out
+	cancel    context.CancelFunc // cancels ctx
+	done      chan struct{}      // closed by Close
+	closeOnce sync.Once          // closes done
+	stopped   chan struct{}      // closed when run returns
+}
+
+var _ Sink = &remote{}
+
+// newRemote returns a new sink with the provided name that pushes log
+// entries using the provided client.
+func newRemote(name string, c client, opts Options) *remote {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &remote{
+		name:    name,
+		client:  c,
+		opts:    opts,
+		labels:  sinkLabels{Sink: name},
+		entries: make(chan *protos.LogEntry, opts.BufferSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Add implements the Sink interface.
+func (s *remote) Add(entry *protos.LogEntry) {
+	select {
+	case <-s.done:
+		droppedEntries.Get(s.labels).Add(1)
+		return
+	default:
+	}
+
+	select {
+	case s.entries <- entry:
+		return
+	default:
+	}
+
+	// The buffer is full. Block for a bounded time, to slow down the
+	// producers of log entries rather than drop entries right away.
+	if s.opts.MaxBlock > 0 {
+		timer := time.NewTimer(s.opts.MaxBlock)
+		defer timer.Stop()
+		select {
+		case s.entries <- entry:
+			return
+		case <-timer.C:
+		case <-s.done:
+		}
+	}
+	droppedEntries.Get(s.labels).Add(1)
+}
+
+// Close implements the Sink interface.
+func (s *remote) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	timer := time.NewTimer(s.opts.FlushTimeout)
+	defer timer.Stop()
+	select {
+	case <-s.stopped:
+	case <-timer.C:
+		// Stop retrying, and drop the remaining entries.
+		s.cancel()
+		<-s.stopped
+	}
+	s.cancel()
+	return nil
+}
+
+// run batches the buffered log entries, and pushes the batches, until the
+// sink is closed.
+func (s *remote) run() {
+	defer close(s.stopped)
+	var batch []*protos.LogEntry
+	var timeout <-chan time.Time // fires when the batch must be pushed
+	for {
+		select {
+		case entry := <-s.entries:
+			batch = append(batch, entry)
+			if len(batch) == 1 {
+				timeout = time.After(s.opts.BatchDelay)
+			}
+			if len(batch) >= s.opts.BatchSize {
+				s.send(batch)
+				batch, timeout = nil, nil
+			}
+
+		case <-timeout:
+			s.send(batch)
+			batch, timeout = nil, nil
+
+		case <-s.done:
+			// Push the remaining entries.
+			for {
+				select {
+				case entry := <-s.entries:
+					batch = append(batch, entry)
+					if len(batch) >= s.opts.BatchSize {
+						s.send(batch)
+						batch = nil
+					}
+				default:
+					s.send(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// send pushes the provided batch, retrying with exponential backoff.
+func (s *remote) send(batch []*protos.LogEntry) {
+	if len(batch) == 0 {
+		return
+	}
+	var err error
+	attempts := 0
+	for r := retry.BeginWithOptions(backoff); attempts < s.opts.MaxAttempts && r.Continue(s.ctx); attempts++ {
+		ctx, cancel := context.WithTimeout(s.ctx, requestTimeout)
+		var retry []*protos.LogEntry
+		var rejected int
+		retry, rejected, err = s.client.push(ctx, batch)
+		cancel()
+		sentEntries.Get(s.labels).Add(float64(len(batch) - len(retry) - rejected))
+		droppedEntries.Get(s.labels).Add(float64(rejected))
+		if rejected > 0 {
+			fmt.Fprintf(os.Stderr, "%s log sink: %d log entries rejected: %v\n", s.name, rejected, err)
+		}
+		if len(retry) == 0 {
+			return
+		}
+		batch = retry
+	}
+	if err == nil {
+		err = s.ctx.Err()
+	}
+	droppedEntries.Get(s.labels).Add(float64(len(batch)))
+	fmt.Fprintf(os.Stderr, "%s log sink: %d log entries dropped after %d attempts: %v\n", s.name, len(batch), attempts, err)
+}
+
+// Tee returns a sink that adds every log entry to all the provided sinks.
+func Tee(sinks ...Sink) Sink {
+	if len(sinks) == 1 {
+		return sinks[0]
+	}
+	return tee(sinks)
+}
+
+// tee is a Sink that adds every log entry to multiple sinks.
+type tee []Sink
+
+// Add implements the Sink interface.
+func (t tee) Add(entry *protos.LogEntry) {
+	for _, s := range t {
+		s.Add(entry)
+	}
+}
+
+// Close implements the Sink interface.
+func (t tee) Close() error {
+	var err error
+	for _, s := range t {
+		if closeErr := s.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,275 @@
+package sinks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"greatestworks/aop/protos"
+)
+
+// testOptions are sink options that push every log entry right away.
+var testOptions = Options{BatchSize: 100, BatchDelay: time.Millisecond}
+
+// testEntries returns n log entries.
+func testEntries(n int) []*protos.LogEntry {
+	var entries []*protos.LogEntry
+	for i := 0; i < n; i++ {
+		entries = append(entries, &protos.LogEntry{
+			App:        "todo",
+			Version:    "v1",
+			Node:       "n1",
+			Component:  "Todo",
+			Level:      "info",
+			TimeMicros: int64(i + 1),
+			Msg:        fmt.Sprint(i),
+			Attrs:      []string{"player", "alice"},
+		})
+	}
+	return entries
+}
+
+func TestLoki(t *testing.T) {
+	var mu sync.Mutex
+	var pushes []lokiPush
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" {
+			t.Errorf("path: got %q, want /loki/api/v1/push", r.URL.Path)
+		}
+		if got, want := r.Header.Get("X-Scope-OrgID"), "game"; got != want {
+			t.Errorf("tenant: got %q, want %q", got, want)
+		}
+		var push lokiPush
+		if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		pushes = append(pushes, push)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink := NewLoki(LokiOptions{
+		URL:      server.URL,
+		TenantID: "game",
+		Labels:   map[string]string{"env": "prod"},
+	}, Options{BatchSize: 3, BatchDelay: time.Hour})
+	for _, entry := range testEntries(3) {
+		sink.Add(entry)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The entries are pushed in a single batch, in a single stream.
+	want := []lokiPush{{Streams: []*lokiStream{{
+		Stream: map[string]string{
+			"env":       "prod",
+			"app":       "todo",
+			"version":   "v1",
+			"node":      "n1",
+			"level":     "info",
+			"component": "Todo",
+		},
+		Values: [][2]string{
+			{"1000", `{"msg":"0","attrs":{"player":"alice"}}`},
+			{"2000", `{"msg":"1","attrs":{"player":"alice"}}`},
+			{"3000", `{"msg":"2","attrs":{"player":"alice"}}`},
+		},
+	}}}}
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(want, pushes); diff != "" {
+		t.Fatalf("pushes (-want +got):\n%s", diff)
+	}
+}
+
+func TestElasticsearch(t *testing.T) {
+	// The first request fails one document with a retryable error, and one
+	// with a permanent error.
+	var mu sync.Mutex
+	var msgs [][]string // messages of the documents, by request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			t.Errorf("path: got %q, want /_bulk", r.URL.Path)
+		}
+		var req []string
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action elasticsearchAction
+			if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := action.Create.Index, "logs-1970.01.01"; got != want {
+				t.Errorf("index: got %q, want %q", got, want)
+			}
+			if !scanner.Scan() {
+				t.Fatal("missing document")
+			}
+			var doc elasticsearchDoc
+			if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+				t.Fatal(err)
+			}
+			req = append(req, doc.Msg)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		msgs = append(msgs, req)
+		if len(msgs) > 1 {
+			fmt.Fprint(w, `{"errors": false, "items": []}`)
+			return
+		}
+		fmt.Fprint(w, `{"errors": true, "items": [
+			{"create": {"status": 201}},
+			{"create": {"status": 429, "error": {"type": "es_rejected_execution_exception"}}},
+			{"create": {"status": 400, "error": {"type": "mapper_parsing_exception"}}}
+		]}`)
+	}))
+	defer server.Close()
+
+	sink := NewElasticsearch(ElasticsearchOptions{
+		URL:   server.URL,
+		Index: "logs-{2006.01.02}",
+	}, Options{BatchSize: 3, BatchDelay: time.Hour})
+	for _, entry := range testEntries(3) {
+		sink.Add(entry)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the document that failed with a retryable error is retried.
+	want := [][]string{{"0", "1", "2"}, {"1"}}
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := cmp.Diff(want, msgs); diff != "" {
+		t.Fatalf("requests (-want +got):\n%s", diff)
+	}
+}
+
+// fakeClient is a client that fails the first pushes.
+type fakeClient struct {
+	mu       sync.Mutex
+	failures int                // number of pushes to fail
+	block    chan struct{}      // if not nil, pushes block until closed
+	pushed   []*protos.LogEntry // pushed entries
+}
+
+func (c *fakeClient) push(ctx context.Context, batch []*protos.LogEntry) ([]*protos.LogEntry, int, error) {
+	if c.block != nil {
+		select {
+		case <-c.block:
+		case <-ctx.Done():
+			return batch, 0, ctx.Err()
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		return batch, 0, fmt.Errorf("unavailable")
+	}
+	c.pushed = append(c.pushed, batch...)
+	return nil, 0, nil
+}
+
+func TestRetry(t *testing.T) {
+	client := &fakeClient{failures: 2}
+	sink := newRemote("fake", client, testOptions)
+	entries := testEntries(10)
+	for _, entry := range entries {
+		sink.Add(entry)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if got, want := len(client.pushed), len(entries); got != want {
+		t.Fatalf("pushed entries: got %d, want %d", got, want)
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	// The client blocks, so the buffer fills up, and Add drops entries once
+	// it has blocked for MaxBlock.
+	client := &fakeClient{block: make(chan struct{})}
+	sink := newRemote("fake", client, Options{
+		BatchSize:    1,
+		BufferSize:   2,
+		MaxBlock:     10 * time.Millisecond,
+		FlushTimeout: time.Second,
+	})
+	start := time.Now()
+	for _, entry := range testEntries(10) {
+		sink.Add(entry)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("Add didn't block: took %v", elapsed)
+	}
+	close(client.block)
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// At most one entry being pushed, and the buffered entries, are pushed.
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if got, max := len(client.pushed), 3; got == 0 || got > max {
+		t.Fatalf("pushed entries: got %d, want between 1 and %d", got, max)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		section string
+		sinks   int // number of sinks, or -1 for an error
+	}{
+		{"Empty", "", 0},
+		{"Loki", `loki = {url = "http://loki:3100"}`, 1},
+		{"Both", `
+			batch_delay = "2s"
+			loki = {url = "http://loki:3100"}
+			elasticsearch = {url = "http://es:9200", index = "logs"}`, 2},
+		{"NoURL", `loki = {tenant_id = "game"}`, -1},
+		{"BadURL", `loki = {url = "loki:3100"}`, -1},
+		{"NoIndex", `elasticsearch = {url = "http://es:9200"}`, -1},
+		{"BadDelay", `batch_delay = "soon"`, -1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			sink, err := FromConfig(map[string]string{shortConfigKey: test.section})
+			if test.sinks < 0 {
+				if err == nil {
+					t.Fatal("FromConfig: unexpected success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := 0
+			switch s := sink.(type) {
+			case nil:
+			case tee:
+				got = len(s)
+			default:
+				got = 1
+			}
+			if got != test.sinks {
+				t.Fatalf("sinks: got %d, want %d", got, test.sinks)
+			}
+			if sink != nil {
+				sink.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logtype
+
+import "fmt"
+
+// Level is the level of a log entry, i.e., the Logger method that logged it.
+// Levels are ordered by severity: Debug < Info < Error.
+type Level int
+
+const (
+	Debug Level = iota // logged by Logger.Debug
+	Info               // logged by Logger.Info
+	Error              // logged by Logger.Error
+)
+
+// String returns the name of the level, as found in the Level field of log
+// entries (e.g., "debug").
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "debug"
+	case Info:
+		return "info"
+	case Error:
+		return "error"
+	default:
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+}
+
+// ParseLevel parses the name of a level (e.g., "debug").
+func ParseLevel(s string) (Level, error) {
+	switch s {
+	case "debug":
+		return Debug, nil
+	case "info":
+		return Info, nil
+	case "error":
+		return Error, nil
+	default:
+		return 0, fmt.Errorf("invalid log level %q; must be debug, info, or error", s)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"strings"
 
 	"golang.org/x/exp/maps"
-	"greatestworks/aop/logging"
 	"greatestworks/aop/protos"
 )
 
@@ -123,7 +122,7 @@ func translateMetrics(w *bytes.Buffer, metrics []*MetricSnapshot) string {
 		delete(labels, "serviceweaver_app")
 		delete(labels, "serviceweaver_version")
 		if node, ok := labels["serviceweaver_node"]; ok {
-			labels["serviceweaver_node"] = logging.Shorten(node)
+			labels["serviceweaver_node"] = shorten(node)
 		}
 
 		// Write the metric definitions.
@@ -209,3 +208,15 @@ func writeLabels(w *bytes.Buffer, labels map[string]string,
 	}
 	w.WriteString("}")
 }
+
+// shorten returns a short prefix of the given string, as logging.Shorten
+// does. The logging package depends on this one, through the metrics of the
+// log files, so it can't be called from here.
+func shorten(s string) string {
+	const n = 8
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -2,12 +2,21 @@ package metrics
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
+)
 
-	"greatestworks/aop/codegen"
-	"greatestworks/aop/logging"
+// Names of the method metrics populated by the generated code (see
+// codegen.MethodCounts). They're declared here, rather than read from the
+// metrics of codegen, as codegen depends on this package.
+const (
+	MethodCountsName       = "serviceweaver_remote_method_count"
+	MethodErrorsName       = "serviceweaver_remote_method_error_count"
+	MethodLatenciesName    = "serviceweaver_remote_method_latency_micros"
+	MethodBytesRequestName = "serviceweaver_remote_method_bytes_request"
+	MethodBytesReplyName   = "serviceweaver_remote_method_bytes_reply"
 )
 
 // TODO(rgrandl): Right now we aggregate local and remote metrics. Show them separately.
@@ -139,7 +148,7 @@ func (s *StatsProcessor) getSnapshot(snapshot []*MetricSnapshot) {
 		var comp, method string
 		for k, v := range m.Labels {
 			if k == "component" {
-				comp = logging.ShortenComponent(v)
+				comp = shortenComponent(v)
 			} else if k == "method" {
 				method = v
 			}
@@ -161,13 +170,13 @@ func (s *StatsProcessor) getSnapshot(snapshot []*MetricSnapshot) {
 		// Aggregate stats within a bucket, based on metric values from different
 		// replicas for the method.
 		switch m.Name {
-		case codegen.MethodCounts.Name():
+		case MethodCountsName:
 			bucket.calls += m.Value
-		case codegen.MethodBytesReply.Name():
+		case MethodBytesReplyName:
 			bucket.kbSent += m.Value / 1024 // B to KB
-		case codegen.MethodBytesRequest.Name():
+		case MethodBytesRequestName:
 			bucket.kbRecvd += m.Value / 1024 // B to KB
-		case codegen.MethodLatencies.Name():
+		case MethodLatenciesName:
 			bucket.latencyMs += m.Value / 1024 // us to ms
 
 			var count uint64
@@ -278,3 +287,20 @@ func (s *statsMethod) computeStatsStatusz(startTime time.Time) methodStatuszInfo
 	}
 	return result
 }
+
+// shortenComponent shortens the given component name to be of the format
+// <pkg>.<IfaceType>, as logging.ShortenComponent does. (Recall that the full
+// component name is of the format <path1>/<path2>/.../<pathN>/<IfaceType>.)
+// The logging package depends on this one, through the metrics of the log
+// files, so it can't be called from here.
+func shortenComponent(component string) string {
+	parts := strings.Split(component, "/")
+	switch len(parts) {
+	case 0: // should never happen
+		return "nil"
+	case 1:
+		return parts[0]
+	default:
+		return fmt.Sprintf("%s.%s", parts[len(parts)-2], parts[len(parts)-1])
+	}
+}
@@ -0,0 +1,224 @@
+// Package migrate applies ordered migrations of the schemas of databases
+// (e.g., MongoDB indexes, MySQL DDL), and records the versions applied in
+// the databases themselves, so that the servers can refuse to start on a
+// database whose schema is older than they expect (see Set.Check).
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Latest is the target version of the migrations of a set up to its last
+// one.
+const Latest = -1
+
+var (
+	// ErrSchemaBehind is returned by Set.Check when the database lacks
+	// migrations the binary expects.
+	ErrSchemaBehind = errors.New("migrate: database schema is older than expected")
+	// ErrIrreversible is returned when a migration without Down would be
+	// reverted.
+	ErrIrreversible = errors.New("migrate: migration can't be reverted")
+	// ErrLocked is returned when another process is migrating the database.
+	ErrLocked = errors.New("migrate: database locked by another migration")
+)
+
+// Migration is a change of the schema of a database.
+type Migration struct {
+	Version     int64  // positive, unique in its set; the migrations are applied by increasing version
+	Description string // e.g., "index Player.uid"
+	Up          func(ctx context.Context) error
+	Down        func(ctx context.Context) error // nil if it can't be reverted
+}
+
+// Record records a migration applied to a database.
+type Record struct {
+	Version     int64
+	Description string
+	AppliedAt   time.Time
+}
+
+// Store records the migrations applied to a database, in the database.
+type Store interface {
+	// Applied returns the migrations applied.
+	Applied(ctx context.Context) ([]Record, error)
+	// Insert records a migration applied.
+	Insert(ctx context.Context, r Record) error
+	// Delete records a migration reverted.
+	Delete(ctx context.Context, version int64) error
+	// Lock locks the database for owner, or fails with ErrLocked.
+	Lock(ctx context.Context, owner string) error
+	// Unlock unlocks the database, whoever locked it.
+	Unlock(ctx context.Context) error
+}
+
+// Set is the migrations of a database.
+type Set struct {
+	Name       string // e.g., "mongo/greatest-work"
+	Store      Store
+	Migrations []Migration
+}
+
+// Step is a migration to apply, or to revert.
+type Step struct {
+	Migration
+	Down bool
+}
+
+func (s Step) String() string {
+	dir := "up"
+	if s.Down {
+		dir = "down"
+	}
+	return fmt.Sprintf("%s %d %s", dir, s.Version, s.Description)
+}
+
+// validate checks the versions of the migrations, and sorts them.
+func (s *Set) validate() error {
+	sort.Slice(s.Migrations, func(i, j int) bool {
+		return s.Migrations[i].Version < s.Migrations[j].Version
+	})
+	for i, m := range s.Migrations {
+		if m.Version <= 0 {
+			return fmt.Errorf("migrate: %s: version %d isn't positive", s.Name, m.Version)
+		}
+		if i > 0 && s.Migrations[i-1].Version == m.Version {
+			return fmt.Errorf("migrate: %s: repeat version %d", s.Name, m.Version)
+		}
+		if m.Up == nil {
+			return fmt.Errorf("migrate: %s: version %d has no Up", s.Name, m.Version)
+		}
+	}
+	return nil
+}
+
+// Latest returns the version of the last migration of the set, or 0.
+func (s *Set) Latest() int64 {
+	var latest int64
+	for _, m := range s.Migrations {
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest
+}
+
+// Status returns the migrations applied to the database, and the migrations
+// of the set not applied.
+func (s *Set) Status(ctx context.Context) (applied []Record, pending []Migration, err error) {
+	if err := s.validate(); err != nil {
+		return nil, nil, err
+	}
+	applied, err = s.Store.Applied(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("migrate: %s: %w", s.Name, err)
+	}
+	sort.Slice(applied, func(i, j int) bool { return applied[i].Version < applied[j].Version })
+	done := map[int64]bool{}
+	for _, r := range applied {
+		done[r.Version] = true
+	}
+	for _, m := range s.Migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return applied, pending, nil
+}
+
+// Plan returns the steps to migrate the database to a version (or Latest):
+// the migrations not applied up to it, by increasing version, or the
+// migrations applied beyond it, by decreasing version, to revert.
+func (s *Set) Plan(ctx context.Context, target int64) ([]Step, error) {
+	applied, pending, err := s.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if target == Latest {
+		target = s.Latest()
+	}
+	var steps []Step
+	for _, m := range pending {
+		if m.Version <= target {
+			steps = append(steps, Step{Migration: m})
+		}
+	}
+	known := map[int64]Migration{}
+	for _, m := range s.Migrations {
+		known[m.Version] = m
+	}
+	for i := len(applied) - 1; i >= 0; i-- {
+		r := applied[i]
+		if r.Version <= target {
+			break
+		}
+		m, ok := known[r.Version]
+		if !ok {
+			return nil, fmt.Errorf("migrate: %s: version %d (%s) applied by a newer binary", s.Name, r.Version, r.Description)
+		}
+		if m.Down == nil {
+			return nil, fmt.Errorf("%w: %s: version %d (%s)", ErrIrreversible, s.Name, m.Version, m.Description)
+		}
+		steps = append(steps, Step{Migration: m, Down: true})
+	}
+	return steps, nil
+}
+
+// Apply applies steps, in order, with the database locked by owner, and
+// calls done after each. A step is recorded once it succeeded: after a
+// failure, the steps done stay done.
+func (s *Set) Apply(ctx context.Context, owner string, steps []Step, done func(Step)) error {
+	if len(steps) == 0 {
+		return nil
+	}
+	if err := s.Store.Lock(ctx, owner); err != nil {
+		return fmt.Errorf("migrate: %s: %w", s.Name, err)
+	}
+	defer s.Store.Unlock(context.Background())
+	for _, step := range steps {
+		if step.Down {
+			if err := step.Migration.Down(ctx); err != nil {
+				return fmt.Errorf("migrate: %s: %v: %w", s.Name, step, err)
+			}
+			if err := s.Store.Delete(ctx, step.Version); err != nil {
+				return fmt.Errorf("migrate: %s: %v: record: %w", s.Name, step, err)
+			}
+		} else {
+			if err := step.Up(ctx); err != nil {
+				return fmt.Errorf("migrate: %s: %v: %w", s.Name, step, err)
+			}
+			r := Record{Version: step.Version, Description: step.Description, AppliedAt: time.Now()}
+			if err := s.Store.Insert(ctx, r); err != nil {
+				return fmt.Errorf("migrate: %s: %v: record: %w", s.Name, step, err)
+			}
+		}
+		if done != nil {
+			done(step)
+		}
+	}
+	return nil
+}
+
+// Check returns ErrSchemaBehind if migrations of the set aren't applied to
+// the database: the binary expects a newer schema. Migrations applied but
+// unknown to the set (i.e., by a newer binary) are fine, since migrations
+// must be compatible with the binaries running before them.
+func (s *Set) Check(ctx context.Context) error {
+	applied, pending, err := s.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	var current int64
+	if len(applied) > 0 {
+		current = applied[len(applied)-1].Version
+	}
+	return fmt.Errorf("%w: %s at version %d, %d migrations pending up to %d (first %d %s)",
+		ErrSchemaBehind, s.Name, current, len(pending), s.Latest(), pending[0].Version, pending[0].Description)
+}
@@ -0,0 +1,148 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// memStore is a Store in memory.
+type memStore struct {
+	records map[int64]Record
+	owner   string
+}
+
+func (s *memStore) Applied(context.Context) ([]Record, error) {
+	var records []Record
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func (s *memStore) Insert(_ context.Context, r Record) error {
+	s.records[r.Version] = r
+	return nil
+}
+
+func (s *memStore) Delete(_ context.Context, version int64) error {
+	delete(s.records, version)
+	return nil
+}
+
+func (s *memStore) Lock(_ context.Context, owner string) error {
+	if s.owner != "" {
+		return fmt.Errorf("%w: by %s", ErrLocked, s.owner)
+	}
+	s.owner = owner
+	return nil
+}
+
+func (s *memStore) Unlock(context.Context) error {
+	s.owner = ""
+	return nil
+}
+
+// newSet returns a set of migrations of the provided versions, which log
+// their runs in ran, and whose even versions can be reverted.
+func newSet(ran *[]string, versions ...int64) *Set {
+	set := &Set{Name: "test", Store: &memStore{records: map[int64]Record{}}}
+	for _, v := range versions {
+		v := v
+		m := Migration{
+			Version:     v,
+			Description: fmt.Sprint("m", v),
+			Up: func(context.Context) error {
+				*ran = append(*ran, fmt.Sprint("up ", v))
+				return nil
+			},
+		}
+		if v%2 == 0 {
+			m.Down = func(context.Context) error {
+				*ran = append(*ran, fmt.Sprint("down ", v))
+				return nil
+			}
+		}
+		set.Migrations = append(set.Migrations, m)
+	}
+	return set
+}
+
+func migrateTo(t *testing.T, set *Set, target int64) {
+	t.Helper()
+	ctx := context.Background()
+	steps, err := set.Plan(ctx, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := set.Apply(ctx, "test", steps, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	ctx := context.Background()
+	var ran []string
+	set := newSet(&ran, 4, 1, 2, 3) // out of order
+
+	migrateTo(t, set, 2)
+	migrateTo(t, set, Latest)
+	migrateTo(t, set, Latest) // up to date
+	migrateTo(t, set, 3)
+	want := []string{"up 1", "up 2", "up 3", "up 4", "down 4"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+
+	// 3 can't be reverted.
+	if _, err := set.Plan(ctx, 1); !errors.Is(err, ErrIrreversible) {
+		t.Errorf("Plan down to 1: got %v, want ErrIrreversible", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	var ran []string
+	set := newSet(&ran, 1, 2)
+	if err := set.Check(ctx); !errors.Is(err, ErrSchemaBehind) {
+		t.Fatalf("Check before migrating: got %v, want ErrSchemaBehind", err)
+	}
+	migrateTo(t, set, Latest)
+	if err := set.Check(ctx); err != nil {
+		t.Fatalf("Check after migrating: %v", err)
+	}
+
+	// An older binary runs on a newer schema, but can't revert it.
+	older := newSet(&ran, 1)
+	older.Store = set.Store
+	if err := older.Check(ctx); err != nil {
+		t.Errorf("Check of an older binary: %v", err)
+	}
+	if _, err := older.Plan(ctx, 0); err == nil {
+		t.Error("older binary reverts a migration it doesn't know")
+	}
+}
+
+func TestLocked(t *testing.T) {
+	ctx := context.Background()
+	var ran []string
+	set := newSet(&ran, 1)
+	set.Store.Lock(ctx, "other")
+	steps, _ := set.Plan(ctx, Latest)
+	if err := set.Apply(ctx, "test", steps, nil); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Apply while locked: got %v, want ErrLocked", err)
+	}
+	if len(ran) != 0 {
+		t.Errorf("ran %v while locked", ran)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var ran []string
+	set := newSet(&ran, 1, 1)
+	if _, err := set.Plan(context.Background(), Latest); err == nil {
+		t.Error("repeat version accepted")
+	}
+}
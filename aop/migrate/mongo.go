@@ -0,0 +1,105 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore records the migrations of a MongoDB database in its collections
+// schema_migrations and schema_migrations_lock.
+type MongoStore struct {
+	db *mongodriver.Database
+}
+
+// NewMongoStore returns the store of the migrations of a MongoDB database.
+func NewMongoStore(db *mongodriver.Database) *MongoStore {
+	return &MongoStore{db: db}
+}
+
+type mongoRecord struct {
+	Version     int64  `bson:"_id"`
+	Description string `bson:"desc"`
+	AppliedAt   int64  `bson:"at"`
+}
+
+type mongoLock struct {
+	Id       int32  `bson:"_id"`
+	Owner    string `bson:"owner"`
+	LockedAt int64  `bson:"at"`
+}
+
+func (s *MongoStore) Applied(ctx context.Context) ([]Record, error) {
+	cur, err := s.db.Collection("schema_migrations").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var docs []mongoRecord
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(docs))
+	for _, d := range docs {
+		records = append(records, Record{Version: d.Version, Description: d.Description, AppliedAt: time.Unix(d.AppliedAt, 0)})
+	}
+	return records, nil
+}
+
+func (s *MongoStore) Insert(ctx context.Context, r Record) error {
+	_, err := s.db.Collection("schema_migrations").InsertOne(ctx,
+		mongoRecord{Version: r.Version, Description: r.Description, AppliedAt: r.AppliedAt.Unix()})
+	return err
+}
+
+func (s *MongoStore) Delete(ctx context.Context, version int64) error {
+	_, err := s.db.Collection("schema_migrations").DeleteOne(ctx, bson.M{"_id": version})
+	return err
+}
+
+func (s *MongoStore) Lock(ctx context.Context, owner string) error {
+	locks := s.db.Collection("schema_migrations_lock")
+	_, err := locks.InsertOne(ctx, mongoLock{Id: 1, Owner: owner, LockedAt: time.Now().Unix()})
+	if !mongodriver.IsDuplicateKeyError(err) {
+		return err
+	}
+	var holder mongoLock
+	if locks.FindOne(ctx, bson.M{"_id": 1}).Decode(&holder) == nil {
+		return fmt.Errorf("%w: by %s since %v", ErrLocked, holder.Owner, time.Unix(holder.LockedAt, 0))
+	}
+	return ErrLocked
+}
+
+func (s *MongoStore) Unlock(ctx context.Context) error {
+	_, err := s.db.Collection("schema_migrations_lock").DeleteOne(ctx, bson.M{"_id": 1})
+	return err
+}
+
+// MongoIndex returns a migration creating an index of a collection, named
+// name, on keys (e.g., bson.D{{Key: "uid", Value: 1}}), and dropping it when
+// reverted.
+func MongoIndex(db *mongodriver.Database, version int64, collection, name string, keys bson.D, unique bool) Migration {
+	return Migration{
+		Version:     version,
+		Description: fmt.Sprintf("index %s.%s", collection, name),
+		Up: func(ctx context.Context) error {
+			_, err := db.Collection(collection).Indexes().CreateOne(ctx, mongodriver.IndexModel{
+				Keys:    keys,
+				Options: options.Index().SetName(name).SetUnique(unique),
+			})
+			return err
+		},
+		Down: func(ctx context.Context) error {
+			_, err := db.Collection(collection).Indexes().DropOne(ctx, name)
+			var cmdErr mongodriver.CommandError
+			if errors.As(err, &cmdErr) && cmdErr.Name == "IndexNotFound" {
+				return nil
+			}
+			return err
+		},
+	}
+}
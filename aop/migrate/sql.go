@@ -0,0 +1,120 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLStore records the migrations of a SQL database (MySQL) in its tables
+// schema_migrations and schema_migrations_lock, created if needed. The
+// binary must link the driver of the database (e.g.,
+// github.com/go-sql-driver/mysql).
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore returns the store of the migrations of a SQL database.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+func (s *SQLStore) init(ctx context.Context) error {
+	for _, ddl := range []string{
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			description VARCHAR(255) NOT NULL,
+			applied_at BIGINT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+			id INT PRIMARY KEY,
+			owner VARCHAR(255) NOT NULL,
+			locked_at BIGINT NOT NULL
+		)`,
+	} {
+		if _, err := s.db.ExecContext(ctx, ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) Applied(ctx context.Context) ([]Record, error) {
+	if err := s.init(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT version, description, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		var r Record
+		var at int64
+		if err := rows.Scan(&r.Version, &r.Description, &at); err != nil {
+			return nil, err
+		}
+		r.AppliedAt = time.Unix(at, 0)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+func (s *SQLStore) Insert(ctx context.Context, r Record) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)`,
+		r.Version, r.Description, r.AppliedAt.Unix())
+	return err
+}
+
+func (s *SQLStore) Delete(ctx context.Context, version int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, version)
+	return err
+}
+
+func (s *SQLStore) Lock(ctx context.Context, owner string) error {
+	if err := s.init(ctx); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO schema_migrations_lock (id, owner, locked_at) VALUES (1, ?, ?)`,
+		owner, time.Now().Unix())
+	if err == nil {
+		return nil
+	}
+	// The insert failed, most likely on the primary key: tell who holds it.
+	var holder string
+	var at int64
+	if s.db.QueryRowContext(ctx, `SELECT owner, locked_at FROM schema_migrations_lock WHERE id = 1`).Scan(&holder, &at) == nil {
+		return fmt.Errorf("%w: by %s since %v", ErrLocked, holder, time.Unix(at, 0))
+	}
+	return err
+}
+
+func (s *SQLStore) Unlock(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM schema_migrations_lock WHERE id = 1`)
+	return err
+}
+
+// SQL returns a migration executing DDL statements: up to apply it, and
+// down, if not empty, to revert it. MySQL commits DDL statements
+// implicitly, so a migration should have a single statement.
+func SQL(db *sql.DB, version int64, description, up, down string) Migration {
+	m := Migration{
+		Version:     version,
+		Description: description,
+		Up: func(ctx context.Context) error {
+			_, err := db.ExecContext(ctx, up)
+			return err
+		},
+	}
+	if down != "" {
+		m.Down = func(ctx context.Context) error {
+			_, err := db.ExecContext(ctx, down)
+			return err
+		}
+	}
+	return m
+}
@@ -0,0 +1,19 @@
+package mongo
+
+// Account 账号，登录服验证通过后映射到玩家ID
+type Account struct {
+	Id        uint64 `bson:"_id"`       // 玩家ID(userId)
+	Account   string `bson:"account"`   // 账号名，第三方账号为 验证方式:第三方ID，唯一
+	Password  []byte `bson:"password"`  // 密码的bcrypt哈希，第三方账号为空
+	Device    string `bson:"device"`    // 最近登录的设备
+	LoginTime int64  `bson:"loginTime"` // 最近登录时间
+	Time      int64  `bson:"time"`      // 注册时间
+}
+
+func (t *Account) C() string {
+	return "Account"
+}
+
+func (t *Account) DB() string {
+	return "greatest-work"
+}
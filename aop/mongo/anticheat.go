@@ -0,0 +1,23 @@
+package mongo
+
+// CheatFlag 作弊嫌疑记录，待GM审核
+type CheatFlag struct {
+	Id         uint64         `bson:"_id"`        // 记录ID
+	PlayerId   uint64         `bson:"playerId"`   // 玩家ID
+	Server     string         `bson:"server"`     // 标记的服务器
+	Score      float64        `bson:"score"`      // 嫌疑分
+	Level      int            `bson:"level"`      // 处罚等级：1标记 2禁言 3踢下线
+	Violations map[string]int `bson:"violations"` // 违规次数，按类型
+	Status     int            `bson:"status"`     // 状态：0待审核 1已确认 2已排除
+	Reviewer   uint64         `bson:"reviewer"`   // 审核的GM
+	ReviewTime int64          `bson:"reviewTime"` // 审核时间
+	Time       int64          `bson:"time"`       // 标记时间
+}
+
+func (t *CheatFlag) C() string {
+	return "CheatFlag"
+}
+
+func (t *CheatFlag) DB() string {
+	return "greatest-work"
+}
@@ -0,0 +1,22 @@
+package mongo
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// PlayerArchive 玩家数据快照，客服回档用，只追加不修改
+type PlayerArchive struct {
+	Id       uint64              `bson:"_id"`      // 快照ID
+	UId      uint64              `bson:"uid"`      // 玩家ID
+	Reason   string              `bson:"reason"`   // 原因，如工单号
+	By       string              `bson:"by"`       // 操作人
+	Time     int64               `bson:"time"`     // 快照时间
+	Restores uint64              `bson:"restores"` // 回档前自动快照时，为回档用的快照ID
+	Docs     map[string]bson.Raw `bson:"docs"`     // 玩家在各集合的文档，按名字(player为存档)；快照时没有的文档缺失
+}
+
+func (t *PlayerArchive) C() string {
+	return "PlayerArchive"
+}
+
+func (t *PlayerArchive) DB() string {
+	return "greatest-work"
+}
@@ -0,0 +1,34 @@
+package mongo
+
+// AuctionRefund 被超过的出价，待退还给出价人
+type AuctionRefund struct {
+	Bidder uint64 `bson:"uid"` // 出价人
+	Amount int64  `bson:"amt"` // 金额
+}
+
+// AuctionListing 拍卖行挂单. 道具与最高出价由拍卖行托管，结算时通过邮件发出
+type AuctionListing struct {
+	Id       uint64          `bson:"_id"`     // 挂单ID
+	Seller   uint64          `bson:"seller"`  // 卖家
+	ItemId   uint32          `bson:"item"`    // 道具ID
+	Num      int64           `bson:"num"`     // 数量
+	Category int32           `bson:"cat"`     // 道具类型，用于筛选
+	Currency int32           `bson:"cur"`     // 货币类型
+	Price    int64           `bson:"price"`   // 当前价：无人出价时为起拍价，否则为最高出价
+	Bidder   uint64          `bson:"bidder"`  // 最高出价人，0为无人出价
+	Buyout   int64           `bson:"buyout"`  // 一口价，0为不可一口价
+	Status   int32           `bson:"st"`      // 状态
+	Settled  bool            `bson:"settled"` // 已结算，即结算邮件已发出
+	Refunds  []AuctionRefund `bson:"refunds"` // 待退还的出价
+	Time     int64           `bson:"time"`    // 上架时间
+	Expire   int64           `bson:"exp"`     // 到期时间
+	Fence    uint64          `bson:"fence"`   // 最后结算它的服务器持有的分布式锁令牌(dlock)，更小令牌的结算被拒绝
+}
+
+func (t *AuctionListing) C() string {
+	return "AuctionListing"
+}
+
+func (t *AuctionListing) DB() string {
+	return "greatest-work"
+}
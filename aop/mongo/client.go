@@ -0,0 +1,150 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/broker"
+	mongobrocker "github.com/phuhao00/broker/mongo"
+	"go.mongodb.org/mongo-driver/event"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+var (
+	commandMicros = metrics.NewHistogramMap[commandLabels](
+		"mongo_command_micros",
+		"Duration, in microseconds, of the MongoDB commands",
+		metrics.NonNegativeBuckets,
+	)
+	commandErrors = metrics.NewCounterMap[commandLabels](
+		"mongo_command_errors",
+		"Number of MongoDB commands failed",
+	)
+	poolInUse = metrics.NewGauge(
+		"mongo_pool_in_use",
+		"Number of MongoDB connections checked out of the pool",
+	)
+	poolCheckoutFailures = metrics.NewCounter(
+		"mongo_pool_checkout_failures",
+		"Number of MongoDB connections that couldn't be checked out of the pool (e.g., timed out waiting for one)",
+	)
+)
+
+type commandLabels struct {
+	Collection string // empty for the commands without a collection (e.g., ping)
+	Command    string
+}
+
+// Options are the options of the connection pool.
+type Options struct {
+	URI             string        // e.g., mongodb://localhost:27017
+	MinPoolSize     uint64        // connections kept open per node
+	MaxPoolSize     uint64        // most connections per node, waited for once all in use
+	MaxConnIdleTime time.Duration // idle connections are closed after it, never if 0
+	ConnectTimeout  time.Duration // defaults to 30 seconds
+}
+
+var defaultOptions = Options{
+	URI:         "mongodb://localhost:27017",
+	MinPoolSize: 3,
+	MaxPoolSize: 3000,
+}
+
+// Init replaces Client with a client of the pool configured, once the
+// deployment answers a ping. It must be called before the modules start.
+func Init(ctx context.Context, opts Options) error {
+	cli, err := connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if err := cli.Ping(ctx, nil); err != nil {
+		cli.Disconnect(ctx)
+		return fmt.Errorf("mongo: ping %v: %w", opts.URI, err)
+	}
+	c := &mongobrocker.Client{BaseComponent: broker.NewBaseComponent(), RealCli: cli}
+	c.Launch()
+	Client = c
+	logger.Info("[mongo] pool min:%v max:%v", opts.MinPoolSize, opts.MaxPoolSize)
+	return nil
+}
+
+// connect returns a client of a pool, whose commands and connections are
+// measured. It connects lazily.
+func connect(ctx context.Context, opts Options) (*mongodriver.Client, error) {
+	o := options.Client().
+		ApplyURI(opts.URI).
+		SetMonitor(newCommandMonitor()).
+		SetPoolMonitor(newPoolMonitor())
+	if opts.MinPoolSize > 0 {
+		o.SetMinPoolSize(opts.MinPoolSize)
+	}
+	if opts.MaxPoolSize > 0 {
+		o.SetMaxPoolSize(opts.MaxPoolSize)
+	}
+	if opts.MaxConnIdleTime > 0 {
+		o.SetMaxConnIdleTime(opts.MaxConnIdleTime)
+	}
+	if opts.ConnectTimeout > 0 {
+		o.SetConnectTimeout(opts.ConnectTimeout)
+	}
+	cli, err := mongodriver.Connect(ctx, o)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: connect %v: %w", opts.URI, err)
+	}
+	return cli, nil
+}
+
+// newCommandMonitor returns a monitor measuring the commands, by collection.
+func newCommandMonitor() *event.CommandMonitor {
+	var started sync.Map // request id -> commandLabels
+	labels := func(requestId int64) commandLabels {
+		l, ok := started.LoadAndDelete(requestId)
+		if !ok {
+			return commandLabels{}
+		}
+		return l.(commandLabels)
+	}
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			l := commandLabels{Command: e.CommandName}
+			// The first element of a command on a collection is the name of
+			// the command, and its value the collection.
+			if elems, err := e.Command.Elements(); err == nil && len(elems) > 0 {
+				if coll, ok := elems[0].Value().StringValueOK(); ok {
+					l.Collection = coll
+				}
+			}
+			started.Store(e.RequestID, l)
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			commandMicros.Get(labels(e.RequestID)).Put(float64(e.Duration.Microseconds()))
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			l := labels(e.RequestID)
+			commandMicros.Get(l).Put(float64(e.Duration.Microseconds()))
+			commandErrors.Get(l).Add(1)
+		},
+	}
+}
+
+// newPoolMonitor returns a monitor of the connections checked out of the
+// pool.
+func newPoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.GetSucceeded:
+				poolInUse.Add(1)
+			case event.ConnectionReturned:
+				poolInUse.Sub(1)
+			case event.GetFailed:
+				poolCheckoutFailures.Add(1)
+			}
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package mongo
+
+// Wallet 玩家的货币余额
+type Wallet struct {
+	OwnerID  uint64           `bson:"uid"` // 玩家ID
+	Balances map[string]int64 `bson:"bal"` // 余额，key为货币类型
+}
+
+func (t *Wallet) C() string {
+	return "Wallet"
+}
+
+func (t *Wallet) DB() string {
+	return "greatest-work"
+}
+
+// CurrencyTxn 货币流水，只追加不修改
+type CurrencyTxn struct {
+	Id       uint64 `bson:"_id"`  // 流水ID
+	OwnerID  uint64 `bson:"uid"`  // 玩家ID
+	Currency int32  `bson:"cur"`  // 货币类型
+	Delta    int64  `bson:"dlt"`  // 变化量，消耗为负
+	Balance  int64  `bson:"bal"`  // 变化后余额
+	Reason   string `bson:"rsn"`  // 原因，如 shop
+	Ref      string `bson:"ref"`  // 关联单据，如商品ID、订单号
+	Time     int64  `bson:"time"` // 时间
+}
+
+func (t *CurrencyTxn) C() string {
+	return "CurrencyTxn"
+}
+
+func (t *CurrencyTxn) DB() string {
+	return "greatest-work"
+}
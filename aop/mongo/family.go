@@ -0,0 +1,72 @@
+package mongo
+
+// FamilyMember 家族成员
+type FamilyMember struct {
+	Id           uint64 `bson:"uid"`  // 玩家ID
+	Name         string `bson:"name"` // 昵称
+	Position     int32  `bson:"pos"`  // 职位
+	Contribution int64  `bson:"ctb"`  // 累计贡献
+	JoinTime     int64  `bson:"jtm"`  // 加入时间
+}
+
+// FamilyRequest 入会申请或邀请
+type FamilyRequest struct {
+	PlayerId uint64 `bson:"uid"`  // 申请人或被邀请人
+	Name     string `bson:"name"` // 申请人昵称
+	From     uint64 `bson:"from"` // 邀请人，申请为0
+	Time     int64  `bson:"tm"`   // 时间
+}
+
+// Family 家族(公会)
+type Family struct {
+	Id        uint64           `bson:"_id"`    // 家族ID
+	Name      string           `bson:"name"`   // 名字，唯一
+	Notice    string           `bson:"notice"` // 公告
+	Leader    uint64           `bson:"leader"` // 族长
+	Level     int32            `bson:"lv"`     // 等级
+	Exp       int64            `bson:"exp"`    // 累计经验
+	AutoJoin  bool             `bson:"auto"`   // 申请自动通过
+	MinLevel  uint32           `bson:"minlv"`  // 申请的最低玩家等级
+	Members   []FamilyMember   `bson:"members"`
+	Requests  []FamilyRequest  `bson:"reqs"` // 入会申请
+	Invites   []FamilyRequest  `bson:"invs"` // 邀请
+	Treasury  map[string]int64 `bson:"bank"` // 金库，key为货币类型
+	Version   int64            `bson:"ver"`  // 版本号，每次修改加一
+	CreatedAt int64            `bson:"ctm"`  // 创建时间
+}
+
+func (t *Family) C() string {
+	return "Family"
+}
+
+func (t *Family) DB() string {
+	return "greatest-work"
+}
+
+// FamilyMembership 玩家所在的家族. 以玩家ID为主键，保证一个玩家只在一个家族
+type FamilyMembership struct {
+	OwnerID  uint64 `bson:"_id"` // 玩家ID
+	FamilyId uint64 `bson:"fid"` // 家族ID
+}
+
+func (t *FamilyMembership) C() string {
+	return "FamilyMembership"
+}
+
+func (t *FamilyMembership) DB() string {
+	return "greatest-work"
+}
+
+// FamilyName 家族名字. 以名字为主键，保证名字唯一
+type FamilyName struct {
+	Name     string `bson:"_id"` // 名字
+	FamilyId uint64 `bson:"fid"` // 家族ID
+}
+
+func (t *FamilyName) C() string {
+	return "FamilyName"
+}
+
+func (t *FamilyName) DB() string {
+	return "greatest-work"
+}
@@ -0,0 +1,29 @@
+package mongo
+
+type FriendInfo struct {
+	UId     uint64 `bson:"uid"` // 好友ID
+	AddTime int64  `bson:"atm"` // 成为好友的时间
+	Tag     string `bson:"tag"` // 备注
+}
+
+type FriendRequest struct {
+	UId     uint64 `bson:"uid"`  // 申请人ID
+	OpTime  int64  `bson:"otm"`  // 申请时间
+	AddType int32  `bson:"type"` // 申请加好友的途径
+}
+
+// FriendSystem 玩家的好友关系
+type FriendSystem struct {
+	OwnerID  uint64          `bson:"uid"`      // 玩家ID
+	Friends  []FriendInfo    `bson:"friends"`  // 好友
+	Blocked  []uint64        `bson:"blocked"`  // 黑名单
+	Requests []FriendRequest `bson:"requests"` // 收到的好友申请
+}
+
+func (t *FriendSystem) C() string {
+	return "FriendSystem"
+}
+
+func (t *FriendSystem) DB() string {
+	return "greatest-work"
+}
@@ -5,26 +5,34 @@ type MailItem struct {
 	Num    int32  `bson:"num"`
 }
 
+// MailCurrency 邮件附带的货币
+type MailCurrency struct {
+	Type   uint32 `bson:"type"` // 货币类型
+	Amount int64  `bson:"amt"`  // 数量
+}
+
 type MailInfo struct {
-	MUuid     uint64     `bson:"uid"`      //
-	MailID    uint32     `bson:"mid"`      // 邮件表ID
-	MType     int32      `bson:"type"`     // 邮件类型
-	MSender   uint64     `bson:"send"`     // 邮件发送人
-	MSNick    string     `bson:"nick"`     // 邮件发送人
-	MSHead    uint32     `bson:"head"`     // 头像
-	MStatus   uint32     `bson:"stat"`     // 邮件状态
-	MItems    []MailItem `bson:"items"`    // 邮件发放物品
-	MContent  string     `bson:"con"`      // 邮件内容
-	MTime     int64      `bson:"time"`     // 邮件发送时间
-	Template  uint32     `bson:"tpl"`      // 邮件模板
-	Topic     string     `bson:"topic"`    // 邮件主题
-	Paster    []uint32   `bson:"past"`     // 邮件贴纸
-	Decorator []string   `bson:"deco"`     // 装饰
-	Color     []float32  `bson:"color"`    // 颜色
-	Validity  []int64    `bson:"validity"` // 生效日期
-	RegTm     []int64    `bson:"reg"`      // 注册日期
-	Chan      []string   `bson:"chan"`     // 渠道
-	Version   string     `bson:"ver"`      // 版本号
+	MUuid     uint64         `bson:"uid"`      //
+	MailID    uint32         `bson:"mid"`      // 邮件表ID
+	MType     int32          `bson:"type"`     // 邮件类型
+	MSender   uint64         `bson:"send"`     // 邮件发送人
+	MSNick    string         `bson:"nick"`     // 邮件发送人
+	MSHead    uint32         `bson:"head"`     // 头像
+	MStatus   uint32         `bson:"stat"`     // 邮件状态
+	MItems    []MailItem     `bson:"items"`    // 邮件发放物品
+	MContent  string         `bson:"con"`      // 邮件内容
+	MTime     int64          `bson:"time"`     // 邮件发送时间
+	Template  uint32         `bson:"tpl"`      // 邮件模板
+	Topic     string         `bson:"topic"`    // 邮件主题
+	Paster    []uint32       `bson:"past"`     // 邮件贴纸
+	Decorator []string       `bson:"deco"`     // 装饰
+	Color     []float32      `bson:"color"`    // 颜色
+	Validity  []int64        `bson:"validity"` // 生效日期
+	RegTm     []int64        `bson:"reg"`      // 注册日期
+	Chan      []string       `bson:"chan"`     // 渠道
+	Version   string         `bson:"ver"`      // 版本号
+	Currency  []MailCurrency `bson:"cur"`      // 邮件发放货币
+	Expire    int64          `bson:"exp"`      // 过期时间, 0为不过期
 }
 
 type MailSystem struct {
@@ -43,3 +51,20 @@ func (t *MailSystem) C() string {
 func (t *MailSystem) DB() string {
 	return "greatest-work"
 }
+
+// GlobalMail 全服邮件. 玩家登录时, 符合条件的玩家收到邮件
+type GlobalMail struct {
+	Info      MailInfo `bson:"info"`  // 邮件内容, Info.MUuid为全服邮件ID
+	MinLevel  uint32   `bson:"minlv"` // 最低等级, 0为不限
+	MaxLevel  uint32   `bson:"maxlv"` // 最高等级, 0为不限
+	RegBefore int64    `bson:"regb"`  // 在此时间前注册的玩家, 0为不限
+	SendTime  int64    `bson:"stm"`   // 发送时间
+}
+
+func (t *GlobalMail) C() string {
+	return "GlobalMail"
+}
+
+func (t *GlobalMail) DB() string {
+	return "greatest-work"
+}
@@ -0,0 +1,15 @@
+package mongo
+
+// MatchRating 玩家的匹配分(MMR)
+type MatchRating struct {
+	OwnerID uint64           `bson:"_id"` // 玩家ID
+	MMR     map[string]int64 `bson:"mmr"` // 匹配分，key为匹配模式
+}
+
+func (t *MatchRating) C() string {
+	return "MatchRating"
+}
+
+func (t *MatchRating) DB() string {
+	return "greatest-work"
+}
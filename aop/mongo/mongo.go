@@ -6,6 +6,7 @@ import (
 
 	"github.com/phuhao00/broker"
 	mongobrocker "github.com/phuhao00/broker/mongo"
+	"greatestworks/aop/logger"
 )
 
 var (
@@ -16,14 +17,16 @@ var (
 func init() {
 	onceInitMongo.Do(func() {
 		ctx := context.Background()
+		// A client of a local deployment, for development, until Init.
+		cli, err := connect(ctx, defaultOptions)
+		if err != nil {
+			logger.Fatal("[mongo] connect err:%v", err)
+		}
 		tc := &mongobrocker.Client{
 			BaseComponent: broker.NewBaseComponent(),
-			RealCli: mongobrocker.NewClient(ctx, &mongobrocker.Config{
-				URI:         "mongodb://localhost:27017",
-				MinPoolSize: 3,
-				MaxPoolSize: 3000,
-			}),
+			RealCli:       cli,
 		}
 		tc.Launch()
+		Client = tc
 	})
 }
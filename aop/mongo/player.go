@@ -1,3 +1,19 @@
 package mongo
 
 var PrimaryKey = "uid" // 主键
+
+// PlayerDoc 玩家存档. 各系统的数据按名字存在Sections中, 只有修改过的系统会被保存
+type PlayerDoc struct {
+	UId      uint64                 `bson:"uid"`      // 玩家ID
+	Version  uint32                 `bson:"ver"`      // 存档结构版本
+	SaveTime int64                  `bson:"stm"`      // 保存时间
+	Sections map[string]interface{} `bson:"sections"` // 各系统数据
+}
+
+func (t *PlayerDoc) C() string {
+	return "Player"
+}
+
+func (t *PlayerDoc) DB() string {
+	return "greatest-work"
+}
@@ -0,0 +1,27 @@
+package mongo
+
+type RankStanding struct {
+	Rank     uint32 `bson:"rank"`  // 名次, 从1开始
+	PlayerId uint64 `bson:"pid"`   // 玩家ID
+	Score    int64  `bson:"score"` // 积分
+	SetTM    int64  `bson:"stm"`   // 积分时间
+}
+
+// RankSeasonArchive 排行榜赛季结算存档
+type RankSeasonArchive struct {
+	RankId      uint32         `bson:"rankid"`    // 排行榜ID
+	ServerId    string         `bson:"server"`    // 服务器ID
+	SeasonId    uint32         `bson:"season"`    // 赛季ID
+	Start       int64          `bson:"start"`     // 赛季开始时间
+	End         int64          `bson:"end"`       // 赛季结束时间
+	ArchiveTime int64          `bson:"atm"`       // 结算时间
+	Standings   []RankStanding `bson:"standings"` // 最终排名
+}
+
+func (t *RankSeasonArchive) C() string {
+	return "RankSeasonArchive"
+}
+
+func (t *RankSeasonArchive) DB() string {
+	return "greatest-work"
+}
@@ -0,0 +1,24 @@
+package mongo
+
+// RechargeOrder 充值订单，验证通过的每笔支付一条，只追加，发放货币时标记已发放
+type RechargeOrder struct {
+	Id      string `bson:"_id"`     // 渠道:商店订单号，同一笔支付只入账一次
+	OwnerID uint64 `bson:"uid"`     // 玩家ID
+	Product uint32 `bson:"product"` // 充值商品ID
+	Channel string `bson:"channel"` // 渠道，如appstore
+	Sku     string `bson:"sku"`     // 商店里的商品id
+	Money   int32  `bson:"money"`   // 货币类型
+	Price   int64  `bson:"price"`   // 价格，分
+	Sandbox bool   `bson:"sandbox"` // 是否测试环境的支付
+	PayTime int64  `bson:"payTime"` // 支付时间，毫秒
+	Time    int64  `bson:"time"`    // 入账时间
+	Granted bool   `bson:"granted"` // 货币是否已发放，和货币在同一个事务中标记
+}
+
+func (t *RechargeOrder) C() string {
+	return "RechargeOrder"
+}
+
+func (t *RechargeOrder) DB() string {
+	return "greatest-work"
+}
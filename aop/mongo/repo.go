@@ -0,0 +1,199 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+// SchemaField is the field of the schema version of the documents of a Repo.
+// The documents without it are of version 0.
+const SchemaField = "_sv"
+
+var migratedDocuments = metrics.NewCounterMap[collectionLabels](
+	"mongo_migrated_documents",
+	"Number of documents read with an older schema version, and migrated",
+)
+
+type collectionLabels struct {
+	Collection string
+}
+
+// Document is a document of a collection.
+type Document interface {
+	C() string  // collection
+	DB() string // database
+}
+
+// Migration upgrades a document from a schema version to the next one, in
+// place.
+type Migration func(doc bson.M) error
+
+// Repo is a typed repository of the documents of type T of a collection,
+// whose schema has a version: the documents it writes are stamped with it
+// (see SchemaField), and the documents read with an older version are
+// migrated, lazily, when they're read (see RegisterMigration). A migrated
+// document is stored with the current version once it's written back with
+// Replace; collections updated in place (e.g., with $set) keep being
+// migrated when read, so their migrations must be idempotent.
+type Repo[T any, PT interface {
+	*T
+	Document
+}] struct {
+	version    uint32
+	migrations map[uint32]Migration
+}
+
+// NewRepo returns the repository of the documents of type T, of a schema
+// version.
+func NewRepo[T any, PT interface {
+	*T
+	Document
+}](version uint32) *Repo[T, PT] {
+	return &Repo[T, PT]{version: version, migrations: map[uint32]Migration{}}
+}
+
+// RegisterMigration registers the migration of the documents from version
+// from to version from+1. It is typically called at init time.
+func (r *Repo[T, PT]) RegisterMigration(from uint32, m Migration) {
+	if from >= r.version {
+		panic(fmt.Sprintf("migration from version %d: schema version is %d", from, r.version))
+	}
+	if _, ok := r.migrations[from]; ok {
+		panic(fmt.Sprintf("repeat register migration from version %d", from))
+	}
+	r.migrations[from] = m
+}
+
+// Version returns the schema version of the documents written.
+func (r *Repo[T, PT]) Version() uint32 {
+	return r.version
+}
+
+// Collection returns the collection of the documents, for the operations the
+// repository lacks (e.g., updates in place).
+func (r *Repo[T, PT]) Collection() *mongodriver.Collection {
+	var doc PT = new(T)
+	return Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+// Get returns the document matching filter, or mongodriver.ErrNoDocuments.
+func (r *Repo[T, PT]) Get(ctx context.Context, filter interface{}) (*T, error) {
+	var raw bson.Raw
+	if err := r.Collection().FindOne(ctx, filter).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return r.decode(raw)
+}
+
+// Find returns the documents matching filter.
+func (r *Repo[T, PT]) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*T, error) {
+	cur, err := r.Collection().Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	var docs []*T
+	for cur.Next(ctx) {
+		doc, err := r.decode(cur.Current)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, cur.Err()
+}
+
+// Insert inserts a document.
+func (r *Repo[T, PT]) Insert(ctx context.Context, doc PT) error {
+	d, err := r.stamp(doc)
+	if err != nil {
+		return err
+	}
+	_, err = r.Collection().InsertOne(ctx, d)
+	return err
+}
+
+// Replace replaces the document matching filter with doc, and returns whether
+// one matched.
+func (r *Repo[T, PT]) Replace(ctx context.Context, filter interface{}, doc PT) (bool, error) {
+	d, err := r.stamp(doc)
+	if err != nil {
+		return false, err
+	}
+	res, err := r.Collection().ReplaceOne(ctx, filter, d)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// Delete deletes the documents matching filter, and returns their number.
+func (r *Repo[T, PT]) Delete(ctx context.Context, filter interface{}) (int64, error) {
+	res, err := r.Collection().DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// decode decodes a document, migrated to the current version.
+func (r *Repo[T, PT]) decode(raw bson.Raw) (*T, error) {
+	var header struct {
+		Version uint32 `bson:"_sv"`
+	}
+	if err := bson.Unmarshal(raw, &header); err != nil {
+		return nil, err
+	}
+	if header.Version > r.version {
+		return nil, fmt.Errorf("document version %d is newer than %d", header.Version, r.version)
+	}
+	if header.Version < r.version {
+		var m bson.M
+		if err := bson.Unmarshal(raw, &m); err != nil {
+			return nil, err
+		}
+		for v := header.Version; v < r.version; v++ {
+			if migrate, ok := r.migrations[v]; ok {
+				if err := migrate(m); err != nil {
+					return nil, fmt.Errorf("migrate document %v from version %d: %w", m["_id"], v, err)
+				}
+			}
+		}
+		data, err := bson.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		raw = data
+		var doc PT = new(T)
+		migratedDocuments.Get(collectionLabels{Collection: doc.C()}).Add(1)
+	}
+	doc := new(T)
+	if err := bson.Unmarshal(raw, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// stamp returns a document, with the current version.
+func (r *Repo[T, PT]) stamp(doc PT) (bson.D, error) {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var d bson.D
+	if err := bson.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	for i := range d {
+		if d[i].Key == SchemaField {
+			d[i].Value = r.version
+			return d, nil
+		}
+	}
+	return append(d, bson.E{Key: SchemaField, Value: r.version}), nil
+}
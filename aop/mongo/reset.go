@@ -0,0 +1,20 @@
+package mongo
+
+// ResetLog 重置执行记录，只追加不修改
+type ResetLog struct {
+	Id      uint64 `bson:"_id"`     // 记录ID
+	Region  string `bson:"region"`  // 区域
+	Server  string `bson:"server"`  // 执行的服务器
+	Period  int    `bson:"period"`  // 周期：1每日 2每周 3每月
+	At      int64  `bson:"at"`      // 重置时间点
+	Players int    `bson:"players"` // 重置的在线玩家数
+	Time    int64  `bson:"time"`    // 执行时间
+}
+
+func (t *ResetLog) C() string {
+	return "ResetLog"
+}
+
+func (t *ResetLog) DB() string {
+	return "greatest-work"
+}
@@ -0,0 +1,38 @@
+package mongo
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"greatestworks/aop/migrate"
+)
+
+// Migrations returns the migrations of the schema of the game database:
+// indexes, so far. New migrations are appended with the next version, and
+// must be compatible with the servers running before them, since the
+// database is migrated before they're replaced. The servers refuse to start
+// on a database lacking any of them (see migrate.Set.Check).
+func Migrations(db *mongodriver.Database) *migrate.Set {
+	index := func(version int64, doc Document, name string, unique bool, keys ...string) migrate.Migration {
+		d := bson.D{}
+		for _, k := range keys {
+			d = append(d, bson.E{Key: k, Value: 1})
+		}
+		return migrate.MongoIndex(db, version, doc.C(), name, d, unique)
+	}
+	return &migrate.Set{
+		Name:  "mongo/" + db.Name(),
+		Store: migrate.NewMongoStore(db),
+		Migrations: []migrate.Migration{
+			index(1, &PlayerDoc{}, "uid_unique", true, PrimaryKey),
+			index(2, &MailSystem{}, "uid_unique", true, PrimaryKey),
+			index(3, &FriendSystem{}, "uid_unique", true, PrimaryKey),
+			index(4, &Wallet{}, "uid_unique", true, PrimaryKey),
+			index(5, &Account{}, "account_unique", true, "account"),
+			index(6, &CurrencyTxn{}, "uid_rsn_ref", false, "uid", "rsn", "ref"),
+			index(7, &AuctionListing{}, "seller_st", false, "seller", "st"),
+			index(8, &GlobalMail{}, "info_exp", false, "info.exp"),
+			index(9, &FamilyMembership{}, "fid", false, "fid"),
+			index(10, &PlayerArchive{}, "uid_time", false, "uid", "time"),
+		},
+	}
+}
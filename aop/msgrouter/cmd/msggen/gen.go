@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+// handler is a generated typed handler: the message ID and the message it
+// decodes, named alike.
+type handler struct {
+	id      string // enum value
+	pkg     string // Go package name of the message, as imported
+	message string
+}
+
+// generate returns the source of the typed handlers of the message IDs of
+// enum starting with one of the prefixes, in Go package pkg, and the message
+// IDs without a message named alike.
+func generate(files []*protoFile, enum, pkg string, prefixes []string) ([]byte, []string, error) {
+	var enumFile *protoFile
+	owners := map[string][]*protoFile{} // by message
+	for _, f := range files {
+		if len(f.ids) > 0 {
+			if enumFile != nil {
+				return nil, nil, fmt.Errorf("enum %s defined in %s and %s", enum, enumFile.name, f.name)
+			}
+			enumFile = f
+		}
+		for _, m := range f.messages {
+			owners[m] = append(owners[m], f)
+		}
+	}
+	if enumFile == nil {
+		return nil, nil, fmt.Errorf("enum %s not found", enum)
+	}
+	if enumFile.goPackage == "" {
+		return nil, nil, fmt.Errorf("%s: no go_package", enumFile.name)
+	}
+
+	imports := newImports()
+	enumPkg := imports.add(enumFile.goPackage, enumFile.goName)
+	var handlers []handler
+	var skipped []string
+	for _, v := range enumFile.ids {
+		if !hasPrefix(v.name, prefixes) {
+			continue
+		}
+		fs := owners[v.name]
+		if len(fs) != 1 || fs[0].goPackage == "" {
+			// No message, or several messages, named like the message ID.
+			skipped = append(skipped, v.name)
+			continue
+		}
+		handlers = append(handlers, handler{
+			id:      v.name,
+			pkg:     imports.add(fs[0].goPackage, fs[0].goName),
+			message: v.name,
+		})
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by msggen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	for _, imp := range imports.sorted() {
+		fmt.Fprintf(&b, "\t%s %q\n", imp.name, imp.path)
+	}
+	b.WriteString("\t\"github.com/phuhao00/network\"\n\t\"greatestworks/aop/msgrouter\"\n)\n")
+	for _, h := range handlers {
+		fmt.Fprintf(&b, `
+// Handle%[1]s registers the handler of %[2]s.%[3]s_%[1]s, which receives it
+// decoded into a %[4]s.%[5]s.
+func Handle%[1]s(m *msgrouter.Module, h func(packet *network.Packet, msg *%[4]s.%[5]s), use ...msgrouter.Middleware) {
+	m.Handle(%[2]s.%[3]s_%[1]s, msgrouter.Typed(h), use...)
+}
+`, h.id, enumPkg, enum, h.pkg, h.message)
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("format generated code: %w\n%s", err, b.Bytes())
+	}
+	return src, skipped, nil
+}
+
+func hasPrefix(s string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// imports are the imports of the generated code, named after their Go
+// package, renamed on conflicts.
+type imports struct {
+	byPath map[string]string // name by import path
+	names  map[string]bool
+}
+
+type goImport struct {
+	name, path string
+}
+
+func newImports() *imports {
+	return &imports{
+		byPath: map[string]string{},
+		names:  map[string]bool{"network": true, "msgrouter": true},
+	}
+}
+
+// add imports a Go package, and returns its name.
+func (i *imports) add(path, name string) string {
+	if n, ok := i.byPath[path]; ok {
+		return n
+	}
+	n := name
+	for k := 2; i.names[n]; k++ {
+		n = fmt.Sprintf("%s%d", name, k)
+	}
+	i.byPath[path], i.names[n] = n, true
+	return n
+}
+
+func (i *imports) sorted() []goImport {
+	var imps []goImport
+	for path, name := range i.byPath {
+		imps = append(imps, goImport{name, path})
+	}
+	sort.Slice(imps, func(a, b int) bool {
+		return imps[a].path < imps[b].path
+	})
+	return imps
+}
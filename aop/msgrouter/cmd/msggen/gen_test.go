@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const messageIdProto = `syntax = "proto3";
+package messageId;
+option go_package = "github.com/phuhao00/greatestworks-proto/messageId";
+
+enum MessageId {
+  None = 0;
+  CSLogin = 1001; // login
+  SCLogin = 1002;
+  CSCreatePlayer = 1003;
+  CSAddFriend = 1004 [deprecated = true];
+}
+`
+
+const playerProto = `syntax = "proto3";
+package player;
+option go_package = "github.com/phuhao00/greatestworks-proto/player";
+
+message CSLogin {
+  string UserName = 1;
+  enum Kind {
+    CSCreatePlayer = 0;
+  }
+}
+
+message SCLogin {}
+
+// message CSCreatePlayer {}
+message CSCreateUser {
+  message CSAddFriend {}
+}
+`
+
+const friendProto = `syntax = "proto3";
+package friend;
+option go_package = "github.com/phuhao00/greatestworks-proto/friend;player";
+
+message CSAddFriend
+{
+  uint64 UId = 1;
+}
+`
+
+func parse(t *testing.T, files map[string]string) []*protoFile {
+	t.Helper()
+	var pfs []*protoFile
+	for name, src := range files {
+		pf, err := parseProto(name, strings.NewReader(src), "MessageId")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pfs = append(pfs, pf)
+	}
+	return pfs
+}
+
+func TestParseProto(t *testing.T) {
+	pfs := parse(t, map[string]string{"messageId.proto": messageIdProto})
+	pf := pfs[0]
+	if pf.goPackage != "github.com/phuhao00/greatestworks-proto/messageId" || pf.goName != "messageId" {
+		t.Fatalf("go_package: got %q %q", pf.goPackage, pf.goName)
+	}
+	want := []enumValue{{"None", 0}, {"CSLogin", 1001}, {"SCLogin", 1002}, {"CSCreatePlayer", 1003}, {"CSAddFriend", 1004}}
+	if len(pf.ids) != len(want) {
+		t.Fatalf("ids: got %v, want %v", pf.ids, want)
+	}
+	for i := range want {
+		if pf.ids[i] != want[i] {
+			t.Fatalf("ids: got %v, want %v", pf.ids, want)
+		}
+	}
+
+	pf = parse(t, map[string]string{"player.proto": playerProto})[0]
+	if got := strings.Join(pf.messages, ","); got != "CSLogin,SCLogin,CSCreateUser" {
+		t.Fatalf("messages: got %v", got)
+	}
+	if len(pf.ids) != 0 {
+		t.Fatalf("nested enum parsed: %v", pf.ids)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	pfs := parse(t, map[string]string{
+		"messageId.proto": messageIdProto,
+		"player.proto":    playerProto,
+		"friend.proto":    friendProto,
+	})
+	src, skipped, err := generate(pfs, "MessageId", "server", []string{"CS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(src)
+	for _, want := range []string{
+		"package server",
+		`player "github.com/phuhao00/greatestworks-proto/player"`,
+		`player2 "github.com/phuhao00/greatestworks-proto/friend"`,
+		"func HandleCSLogin(m *msgrouter.Module, h func(packet *network.Packet, msg *player.CSLogin), use ...msgrouter.Middleware) {",
+		"m.Handle(messageId.MessageId_CSLogin, msgrouter.Typed(h), use...)",
+		"msg *player2.CSAddFriend)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("generated code: no %q in\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "SCLogin") {
+		t.Errorf("generated code: SCLogin not filtered out\n%s", got)
+	}
+	if len(skipped) != 1 || skipped[0] != "CSCreatePlayer" {
+		t.Errorf("skipped: got %v, want [CSCreatePlayer]", skipped)
+	}
+}
@@ -0,0 +1,55 @@
+// msggen generates the typed handlers of the messages of the proto
+// definitions, for msgrouter: for every message ID with a message named
+// alike (e.g. MessageId_CSLogin and CSLogin), a HandleCSLogin function
+// registering a handler that receives the message decoded.
+//
+// Usage:
+//
+//	msggen -proto ../greatestworks-proto -prefix CS -package server -o handlers_gen.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	var (
+		protoDir = flag.String("proto", "../greatestworks-proto", "directory of the proto definitions")
+		enum     = flag.String("enum", "MessageId", "enum of the message IDs")
+		prefix   = flag.String("prefix", "", "comma-separated prefixes of the message IDs to generate, e.g. CS for the server, SC for the client; all if empty")
+		pkg      = flag.String("package", "main", "Go package of the generated code")
+		out      = flag.String("o", "", "output file; stdout if empty")
+	)
+	flag.Parse()
+
+	files, err := parseDir(*protoDir, *enum)
+	if err != nil {
+		fail(err)
+	}
+	var prefixes []string
+	if *prefix != "" {
+		prefixes = strings.Split(*prefix, ",")
+	}
+	src, skipped, err := generate(files, *enum, *pkg, prefixes)
+	if err != nil {
+		fail(err)
+	}
+	for _, id := range skipped {
+		fmt.Fprintf(os.Stderr, "msggen: %s_%s: no message %s, skipped\n", *enum, id, id)
+	}
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "msggen:", err)
+	os.Exit(1)
+}
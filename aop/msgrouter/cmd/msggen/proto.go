@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// protoFile is what msggen needs from a proto file.
+type protoFile struct {
+	name      string   // path of the file
+	goPackage string   // import path of its Go package
+	goName    string   // name of its Go package
+	messages  []string // top-level messages
+	ids       []enumValue
+}
+
+type enumValue struct {
+	name  string
+	value int
+}
+
+var (
+	goPackageRe = regexp.MustCompile(`^option\s+go_package\s*=\s*"([^"]+)"\s*;`)
+	messageRe   = regexp.MustCompile(`^message\s+(\w+)\s*\{?`)
+	enumRe      = regexp.MustCompile(`^enum\s+(\w+)\s*\{?`)
+	valueRe     = regexp.MustCompile(`^(\w+)\s*=\s*(-?\d+)\s*[;\[]`)
+)
+
+// parseDir parses the proto files of a directory and its subdirectories.
+func parseDir(dir, enum string) ([]*protoFile, error) {
+	var files []*protoFile
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(p) != ".proto" {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		pf, err := parseProto(p, f, enum)
+		if err != nil {
+			return err
+		}
+		files = append(files, pf)
+		return nil
+	})
+	return files, err
+}
+
+// parseProto parses the go_package option, the top-level messages, and the
+// values of the top-level enum named enum of a proto file. It only
+// understands the layout of the proto definitions of the project: one
+// declaration per line, braces at the end of the lines.
+func parseProto(name string, r io.Reader, enum string) (*protoFile, error) {
+	pf := &protoFile{name: name}
+	depth, inEnum := 0, false
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if depth == 0 {
+			if m := goPackageRe.FindStringSubmatch(line); m != nil {
+				pf.goPackage, pf.goName = splitGoPackage(m[1])
+			} else if m := messageRe.FindStringSubmatch(line); m != nil {
+				pf.messages = append(pf.messages, m[1])
+			} else if m := enumRe.FindStringSubmatch(line); m != nil && m[1] == enum {
+				inEnum = true
+			}
+		} else if depth == 1 && inEnum {
+			if m := valueRe.FindStringSubmatch(line); m != nil {
+				v, err := strconv.Atoi(m[2])
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: %w", name, n, err)
+				}
+				pf.ids = append(pf.ids, enumValue{m[1], v})
+			}
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth < 0 {
+			return nil, fmt.Errorf("%s:%d: unbalanced braces", name, n)
+		}
+		if depth == 0 {
+			inEnum = false
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return pf, nil
+}
+
+// splitGoPackage splits a go_package option, "<import path>[;<name>]".
+func splitGoPackage(opt string) (string, string) {
+	if i := strings.IndexByte(opt, ';'); i >= 0 {
+		return opt[:i], opt[i+1:]
+	}
+	return opt, path.Base(opt)
+}
@@ -0,0 +1,102 @@
+package msgrouter
+
+import (
+	"runtime/debug"
+	"time"
+
+	"github.com/phuhao00/network"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/net/flood"
+)
+
+var (
+	rejected = metrics.NewCounterMap[rejectLabels](
+		"msgrouter_rejected_messages",
+		"Number of messages rejected by a middleware before their handler",
+	)
+	panics = metrics.NewCounterMap[routeLabels](
+		"msgrouter_handler_panics",
+		"Number of messages whose handler panicked",
+	)
+)
+
+type rejectLabels struct {
+	MessageId uint32
+	Reason    string // "auth", "flood" or "decode"
+}
+
+type routeLabels struct {
+	Module    string
+	MessageId uint32
+}
+
+// Auth drops the messages of the connections that aren't authenticated, as
+// reported by authed.
+func Auth(authed func(packet *network.Packet) bool) Middleware {
+	return func(route Route, next Handler) Handler {
+		labels := rejectLabels{MessageId: uint32(route.Id), Reason: "auth"}
+		return func(packet *network.Packet) {
+			if !authed(packet) {
+				rejected.Get(labels).Add(1)
+				logger.Warn("[msgrouter] ConnID:%v msg:%v unauthenticated, dropped", packet.Conn.ConnID, route.Id)
+				return
+			}
+			next(packet)
+		}
+	}
+}
+
+// RateLimit drops the messages over the limits of l, and disconnects the
+// connections flooding the handlers.
+func RateLimit(l *flood.Limiter) Middleware {
+	return func(route Route, next Handler) Handler {
+		labels := rejectLabels{MessageId: uint32(route.Id), Reason: "flood"}
+		return func(packet *network.Packet) {
+			switch l.Allow(uint64(packet.Conn.ConnID), uint32(route.Id), time.Now()) {
+			case flood.Drop:
+				rejected.Get(labels).Add(1)
+				return
+			case flood.Disconnect:
+				rejected.Get(labels).Add(1)
+				logger.Warn("[msgrouter] ConnID:%v flooding msg:%v, disconnected", packet.Conn.ConnID, route.Id)
+				packet.Conn.Close()
+				return
+			}
+			next(packet)
+		}
+	}
+}
+
+// Logging logs the messages at debug level, and the ones whose handler took
+// longer than slow as warnings. Slow is ignored if 0.
+func Logging(slow time.Duration) Middleware {
+	return func(route Route, next Handler) Handler {
+		return func(packet *network.Packet) {
+			start := time.Now()
+			next(packet)
+			elapsed := time.Since(start)
+			logger.Debug("[msgrouter] module:%v msg:%v handled in %v", route.Module, route.Id, elapsed)
+			if slow > 0 && elapsed > slow {
+				logger.Warn("[msgrouter] module:%v msg:%v handled in %v, slow", route.Module, route.Id, elapsed)
+			}
+		}
+	}
+}
+
+// Recover logs and counts the panics of the handlers, instead of crashing
+// the process.
+func Recover() Middleware {
+	return func(route Route, next Handler) Handler {
+		labels := routeLabels{Module: route.Module, MessageId: uint32(route.Id)}
+		return func(packet *network.Packet) {
+			defer func() {
+				if r := recover(); r != nil {
+					panics.Get(labels).Add(1)
+					logger.Error("[msgrouter] module:%v msg:%v handler panic: %v\n%s", route.Module, route.Id, r, debug.Stack())
+				}
+			}()
+			next(packet)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package msgrouter routes the messages received on a connection to the
+// handlers registered for their message ID by the modules, through chains of
+// middleware (see Auth, RateLimit, Logging and Recover).
+//
+// Register the handlers of every module at startup, then check that no
+// message ID is handled twice before dispatching:
+//
+//	r := msgrouter.NewRouter(msgrouter.Recover(), msgrouter.Logging(time.Second))
+//	player := r.Module("player")
+//	player.Handle(messageId.MessageId_CSLogin, msgrouter.Typed(w.UserLogin))
+//	if err := r.Check(); err != nil {
+//	    logger.Fatal("%v", err)
+//	}
+//	...
+//	r.Dispatch(packet)
+package msgrouter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/network"
+)
+
+// Handler handles a message.
+type Handler func(packet *network.Packet)
+
+// Route is a message ID, and the module handling it.
+type Route struct {
+	Module string
+	Id     messageId.MessageId
+}
+
+// Middleware wraps the handler of a route, e.g. to drop some of its
+// messages. It's called once per route, at registration.
+type Middleware func(route Route, next Handler) Handler
+
+// Router routes messages to the handlers of their message ID. The handlers
+// must be registered before the messages are dispatched: a Router may be
+// used from multiple goroutines once it's checked, but not while handlers are
+// registered.
+type Router struct {
+	use    []Middleware
+	routes map[messageId.MessageId]Handler
+	owners map[messageId.MessageId]string // module of each route
+	dups   []string                       // duplicate registrations, see Check
+}
+
+// NewRouter returns a router wrapping all its handlers with the provided
+// middleware, the first one outermost.
+func NewRouter(use ...Middleware) *Router {
+	return &Router{
+		use:    use,
+		routes: map[messageId.MessageId]Handler{},
+		owners: map[messageId.MessageId]string{},
+	}
+}
+
+// Module is the set of routes of a module.
+type Module struct {
+	r    *Router
+	name string
+	use  []Middleware
+}
+
+// Module returns the routes of a module, whose handlers are wrapped with the
+// provided middleware, inside the middleware of the router.
+func (r *Router) Module(name string, use ...Middleware) *Module {
+	return &Module{r: r, name: name, use: use}
+}
+
+// Handle registers the handler of a message ID, wrapped with the provided
+// middleware, inside the middleware of the module. A message ID registered
+// twice keeps its first handler, and fails Check.
+func (m *Module) Handle(id messageId.MessageId, h Handler, use ...Middleware) {
+	r := m.r
+	if owner, ok := r.owners[id]; ok {
+		r.dups = append(r.dups, fmt.Sprintf("%v by %s and %s", id, owner, m.name))
+		return
+	}
+	route := Route{Module: m.name, Id: id}
+	chain := make([]Middleware, 0, len(r.use)+len(m.use)+len(use))
+	chain = append(append(append(chain, r.use...), m.use...), use...)
+	for i := len(chain) - 1; i >= 0; i-- {
+		h = chain[i](route, h)
+	}
+	r.routes[id] = h
+	r.owners[id] = m.name
+}
+
+// Check returns an error listing the message IDs registered more than once.
+func (r *Router) Check() error {
+	if len(r.dups) == 0 {
+		return nil
+	}
+	return fmt.Errorf("msgrouter: message IDs registered twice: %s", strings.Join(r.dups, "; "))
+}
+
+// Dispatch handles a message, and returns whether it has a handler.
+func (r *Router) Dispatch(packet *network.Packet) bool {
+	h, ok := r.routes[messageId.MessageId(packet.Msg.ID)]
+	if !ok {
+		return false
+	}
+	h(packet)
+	return true
+}
+
+// Routes returns the routes of the router, by message ID.
+func (r *Router) Routes() []Route {
+	routes := make([]Route, 0, len(r.owners))
+	for id, module := range r.owners {
+		routes = append(routes, Route{Module: module, Id: id})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Id < routes[j].Id
+	})
+	return routes
+}
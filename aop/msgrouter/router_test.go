@@ -0,0 +1,113 @@
+package msgrouter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func packet(id messageId.MessageId) *network.Packet {
+	return &network.Packet{Msg: &network.Message{ID: uint64(id)}, Conn: &network.TcpSession{}}
+}
+
+// trace returns a middleware appending its name and route to a log.
+func trace(name string, log *[]string) Middleware {
+	return func(route Route, next Handler) Handler {
+		return func(p *network.Packet) {
+			*log = append(*log, name+":"+route.Module)
+			next(p)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	var log []string
+	r := NewRouter(trace("router", &log))
+	player := r.Module("player", trace("module", &log))
+	player.Handle(messageId.MessageId_CSLogin, func(*network.Packet) {
+		log = append(log, "login")
+	}, trace("handler", &log))
+	player.Handle(messageId.MessageId_CSCreatePlayer, func(*network.Packet) {
+		log = append(log, "create")
+	})
+	if err := r.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Dispatch(packet(messageId.MessageId_CSLogin)) {
+		t.Fatal("CSLogin: not dispatched")
+	}
+	if got, want := strings.Join(log, ","), "router:player,module:player,handler:player,login"; got != want {
+		t.Fatalf("CSLogin: got %v, want %v", got, want)
+	}
+	log = nil
+	r.Dispatch(packet(messageId.MessageId_CSCreatePlayer))
+	if got, want := strings.Join(log, ","), "router:player,module:player,create"; got != want {
+		t.Fatalf("CSCreatePlayer: got %v, want %v", got, want)
+	}
+	if r.Dispatch(packet(messageId.MessageId_CSAddFriend)) {
+		t.Fatal("CSAddFriend: dispatched without a handler")
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	r := NewRouter()
+	var handled string
+	r.Module("player").Handle(messageId.MessageId_CSLogin, func(*network.Packet) { handled = "player" })
+	r.Module("friend").Handle(messageId.MessageId_CSLogin, func(*network.Packet) { handled = "friend" })
+	err := r.Check()
+	if err == nil || !strings.Contains(err.Error(), "by player and friend") {
+		t.Fatalf("Check: got %v", err)
+	}
+	r.Dispatch(packet(messageId.MessageId_CSLogin))
+	if handled != "player" {
+		t.Fatalf("handled by %q, want the first handler", handled)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	authed := false
+	var panicked, handled int
+	r := NewRouter(Recover(), Auth(func(*network.Packet) bool { return authed }))
+	r.Module("player").Handle(messageId.MessageId_CSLogin, func(*network.Packet) {
+		handled++
+		if handled == 2 {
+			panicked++
+			panic("boom")
+		}
+	})
+
+	p := packet(messageId.MessageId_CSLogin)
+	r.Dispatch(p)
+	if handled != 0 {
+		t.Fatal("unauthenticated message handled")
+	}
+	authed = true
+	r.Dispatch(p)
+	r.Dispatch(p) // recovered
+	r.Dispatch(p)
+	if handled != 3 || panicked != 1 {
+		t.Fatalf("handled %d, panicked %d; want 3, 1", handled, panicked)
+	}
+}
+
+func TestTyped(t *testing.T) {
+	var got []string
+	r := NewRouter()
+	r.Module("player").Handle(messageId.MessageId_CSLogin, Typed(func(_ *network.Packet, msg *wrapperspb.StringValue) {
+		got = append(got, msg.Value)
+	}))
+
+	p := packet(messageId.MessageId_CSLogin)
+	p.Msg.Data, _ = proto.Marshal(wrapperspb.String("alice"))
+	r.Dispatch(p)
+	p.Msg.Data = []byte{0xff} // malformed, dropped
+	r.Dispatch(p)
+	if len(got) != 1 || got[0] != "alice" {
+		t.Fatalf("got %v, want [alice]", got)
+	}
+}
@@ -0,0 +1,29 @@
+package msgrouter
+
+import (
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+)
+
+// Message is a pointer to a proto message.
+type Message[T any] interface {
+	*T
+	proto.Message
+}
+
+// Typed returns a handler decoding the messages into a T, and passing them
+// to h. The messages that fail to decode are dropped. The typed handlers of
+// the messages of the proto definitions can be generated with msggen (see
+// cmd/msggen).
+func Typed[T any, M Message[T]](h func(packet *network.Packet, msg M)) Handler {
+	return func(packet *network.Packet) {
+		msg := M(new(T))
+		if err := proto.Unmarshal(packet.Msg.Data, msg); err != nil {
+			rejected.Get(rejectLabels{MessageId: uint32(packet.Msg.ID), Reason: "decode"}).Add(1)
+			logger.Warn("[msgrouter] msg:%v decode %T err:%v, dropped", packet.Msg.ID, msg, err)
+			return
+		}
+		h(packet, msg)
+	}
+}
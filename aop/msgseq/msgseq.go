@@ -0,0 +1,172 @@
+// Package msgseq numbers the client messages of a session, so that the server
+// applies every message at most once, however many times the client retries
+// it after a timeout (e.g., a purchase whose response was lost).
+//
+// The client numbers the messages whose retries must not be applied twice
+// from 1, increasingly over its session, and prepends the number to their
+// payload (see Inject). The server strips it (see Extract), and checks the
+// number against the Window of the session before handling the message: it
+// handles a number once, acks it (see AckId) once handled, and answers its
+// duplicates with the ack again, and, if it caches them, the responses of the
+// first handling. Messages without a number are handled as before.
+package msgseq
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+const (
+	// magic is the first byte of a payload carrying a sequence number. A
+	// serialized protobuf never starts with 0xfe, as the field tag would have
+	// the invalid wire type 6, so a numbered payload can't be mistaken for an
+	// unnumbered one, nor for a traced one (see msgtrace).
+	magic = 0xfe
+
+	// headerLen is the length of the header of a numbered payload: the magic
+	// byte and the sequence number (4 bytes, big-endian).
+	headerLen = 1 + 4
+)
+
+// AckId is the ID of the message acking a numbered message, whose payload is
+// a google.protobuf.UInt32Value of its number. It's reserved, out of the
+// range of the message IDs of the proto definitions.
+const AckId = 0xffff
+
+// Inject returns the provided payload prefixed with a sequence number, which
+// must not be 0. A trace context is prepended after it (see msgtrace.Inject),
+// as the server extracts it first.
+func Inject(seq uint32, payload []byte) []byte {
+	b := make([]byte, headerLen+len(payload))
+	b[0] = magic
+	binary.BigEndian.PutUint32(b[1:], seq)
+	copy(b[headerLen:], payload)
+	return b
+}
+
+// Extract returns the sequence number prepended to data by Inject, and the
+// payload of data. If data doesn't carry a sequence number, it returns 0 and
+// data unchanged.
+func Extract(data []byte) (uint32, []byte) {
+	if len(data) < headerLen || data[0] != magic {
+		return 0, data
+	}
+	seq := binary.BigEndian.Uint32(data[1:])
+	if seq == 0 {
+		return 0, data
+	}
+	return seq, data[headerLen:]
+}
+
+// keyKey is the key of the context value of the numbered message handled.
+type keyKey struct{}
+
+// NewContext returns a copy of ctx in which a numbered message of a session
+// is handled. The session tells the numbers of the sessions apart, as every
+// session numbers its messages from 1.
+func NewContext(ctx context.Context, session string, seq uint32) context.Context {
+	return context.WithValue(ctx, keyKey{}, fmt.Sprintf("%s:%d", session, seq))
+}
+
+// Key returns a key of the numbered message handled in ctx (see NewContext),
+// unique across the sessions, for the operations it makes to be applied at
+// most once (e.g., the idempotency key of a saga). It returns "" if the
+// message handled isn't numbered.
+func Key(ctx context.Context) string {
+	key, _ := ctx.Value(keyKey{}).(string)
+	return key
+}
+
+// Verdict is what to do with a numbered message.
+type Verdict int
+
+const (
+	// New: the first of its number. Handle it, then call Done.
+	New Verdict = iota
+	// Pending: a duplicate of a message being handled. Drop it; the first
+	// one is acked once handled.
+	Pending
+	// Replay: a duplicate of a message handled. Ack it, after resending the
+	// responses of the first handling, if cached.
+	Replay
+	// Stale: older than the window. It was handled long ago, or the client
+	// skipped it, which it only does if it gave up on it; ack it.
+	Stale
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case New:
+		return "new"
+	case Pending:
+		return "pending"
+	case Replay:
+		return "replay"
+	case Stale:
+		return "stale"
+	}
+	return "unknown"
+}
+
+// Window tracks the latest numbers of a session, with the responses R sent
+// while handling them, if cached. It's safe for concurrent use, so that
+// messages of a session may be handled concurrently, in any order.
+type Window[R any] struct {
+	mu      sync.Mutex
+	size    uint32
+	highest uint32
+	entries map[uint32]*entry[R] // of the numbers in (highest-size, highest]
+}
+
+type entry[R any] struct {
+	done      bool
+	responses []R
+}
+
+// NewWindow returns a window of the size latest numbers of a session,
+// defaulting to 128. The duplicates of the older numbers are Stale.
+func NewWindow[R any](size int) *Window[R] {
+	if size <= 0 {
+		size = 128
+	}
+	return &Window[R]{size: uint32(size), entries: map[uint32]*entry[R]{}}
+}
+
+// Begin returns what to do with a message of a number, and, to Replay it, the
+// responses cached of its first handling.
+func (w *Window[R]) Begin(seq uint32) (Verdict, []R) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if e := w.entries[seq]; e != nil {
+		if !e.done {
+			return Pending, nil
+		}
+		return Replay, e.responses
+	}
+	if w.highest >= w.size && seq <= w.highest-w.size {
+		return Stale, nil
+	}
+	w.entries[seq] = &entry[R]{}
+	if seq > w.highest {
+		// Slide the window, forgetting the numbers falling out of it.
+		for old := range w.entries {
+			if seq >= w.size && old <= seq-w.size {
+				delete(w.entries, old)
+			}
+		}
+		w.highest = seq
+	}
+	return New, nil
+}
+
+// Done records that a message of a number Begin returned New for was handled,
+// sending responses, which are cached for its duplicates if not nil.
+func (w *Window[R]) Done(seq uint32, responses []R) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if e := w.entries[seq]; e != nil {
+		e.done, e.responses = true, responses
+	}
+}
@@ -0,0 +1,66 @@
+package msgseq
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestInjectExtract(t *testing.T) {
+	payload := []byte("payload")
+	if seq, data := Extract(payload); seq != 0 || !bytes.Equal(data, payload) {
+		t.Fatalf("Extract of an unnumbered payload: got %d %q", seq, data)
+	}
+	seq, data := Extract(Inject(42, payload))
+	if seq != 42 || !bytes.Equal(data, payload) {
+		t.Fatalf("Extract: got %d %q, want 42 %q", seq, data, payload)
+	}
+	if seq, _ := Extract(Inject(0, payload)); seq != 0 {
+		t.Fatalf("Extract of number 0: got %d", seq)
+	}
+}
+
+func TestKey(t *testing.T) {
+	ctx := context.Background()
+	if key := Key(ctx); key != "" {
+		t.Fatalf("Key of an unnumbered message: got %q", key)
+	}
+	a, b := Key(NewContext(ctx, "a", 1)), Key(NewContext(ctx, "b", 1))
+	if a == "" || a == b {
+		t.Fatalf("Key of number 1 of two sessions: got %q and %q", a, b)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	w := NewWindow[string](4)
+	check := func(seq uint32, want Verdict, wantResponses ...string) {
+		t.Helper()
+		got, responses := w.Begin(seq)
+		if got != want || len(responses) != len(wantResponses) {
+			t.Fatalf("Begin(%d): got %v %v, want %v %v", seq, got, responses, want, wantResponses)
+		}
+		for i := range responses {
+			if responses[i] != wantResponses[i] {
+				t.Fatalf("Begin(%d): got %v, want %v", seq, responses, wantResponses)
+			}
+		}
+	}
+
+	check(1, New)
+	check(1, Pending)
+	w.Done(1, []string{"bought"})
+	check(1, Replay, "bought")
+
+	// Out of order, within the window.
+	check(3, New)
+	check(2, New)
+	w.Done(2, nil)
+	check(2, Replay)
+
+	// 1 falls out of the window once 5 is handled.
+	check(5, New)
+	check(1, Stale)
+	check(2, Replay)
+	check(3, Pending)
+	check(4, New)
+}
@@ -0,0 +1,95 @@
+// Package msgtrace propagates trace contexts through game messages, so that a
+// client request handled by several modules (e.g., player, chat, rank),
+// possibly in several processes, produces a single trace.
+//
+// Within a process, the trace context travels in a context.Context along with
+// the message (see Start). Across processes, the trace context is prepended to
+// the message payload (see Inject and Extract), using the same encoding as the
+// trace header of the aop/net/call protocol.
+//
+// Spans are created with the global tracer provider (see otel.Tracer). To
+// store them in the trace database of the deployment, register a provider
+// that exports spans with a traceio.Writer.
+package msgtrace
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// magic is the first byte of a payload carrying a trace context. A
+	// serialized protobuf never starts with 0xff, as the field tag would have
+	// the invalid wire type 7, so a traced payload can't be mistaken for an
+	// untraced one.
+	magic = 0xff
+
+	// headerLen is the length of the trace header of a traced payload: the
+	// magic byte, the trace id (16 bytes), the span id (8 bytes), and the
+	// trace flags (1 byte).
+	headerLen = 1 + 16 + 8 + 1
+
+	instrumentationName = "greatestworks/aop/msgtrace"
+)
+
+// Trace attribute keys of the spans created by Start.
+const (
+	MessageIDKey = attribute.Key("greatestworks.message_id")
+	ModuleKey    = attribute.Key("greatestworks.module")
+)
+
+// Start starts a span for the handling of the message with the provided id
+// by the provided module (e.g., "chat"), as a child of the span in ctx, if
+// any. The returned context carries the new span, and should be passed along
+// with the message to the other modules handling it. The caller must end the
+// span once the module is done with the message.
+func Start(ctx context.Context, module string, msgID uint64) (context.Context, trace.Span) {
+	kind := trace.SpanKindInternal
+	if sc := trace.SpanContextFromContext(ctx); sc.IsRemote() {
+		kind = trace.SpanKindServer
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, fmt.Sprintf("%s.%d", module, msgID),
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(ModuleKey.String(module), MessageIDKey.Int64(int64(msgID))))
+}
+
+// Inject returns the provided payload prefixed with the trace context of ctx,
+// to be sent to another process that calls Extract. If ctx doesn't carry a
+// trace context, the payload is returned unchanged.
+func Inject(ctx context.Context, payload []byte) []byte {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return payload
+	}
+	traceID, spanID := sc.TraceID(), sc.SpanID()
+	b := make([]byte, headerLen+len(payload))
+	b[0] = magic
+	copy(b[1:], traceID[:])
+	copy(b[17:], spanID[:])
+	b[25] = byte(sc.TraceFlags())
+	copy(b[headerLen:], payload)
+	return b
+}
+
+// Extract returns the payload of data, stripped of the trace context
+// prepended by Inject, and ctx extended with that trace context. If data
+// doesn't carry a trace context, it returns ctx and data unchanged.
+func Extract(ctx context.Context, data []byte) (context.Context, []byte) {
+	if len(data) < headerLen || data[0] != magic {
+		return ctx, data
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    *(*trace.TraceID)(data[1:17]),
+		SpanID:     *(*trace.SpanID)(data[17:25]),
+		TraceFlags: trace.TraceFlags(data[25]),
+		Remote:     true,
+	})
+	if !sc.IsValid() {
+		return ctx, data
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc), data[headerLen:]
+}
@@ -0,0 +1,85 @@
+package msgtrace
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectExtract(t *testing.T) {
+	payload := []byte("payload")
+
+	// Without a trace context, the payload is unchanged.
+	ctx := context.Background()
+	if got := Inject(ctx, payload); !bytes.Equal(got, payload) {
+		t.Fatalf("Inject without trace context: got %q, want %q", got, payload)
+	}
+	if got, data := Extract(ctx, payload); got != ctx || !bytes.Equal(data, payload) {
+		t.Fatalf("Extract of an untraced payload: got %q, want %q", data, payload)
+	}
+
+	// With a trace context, the payload round trips along with the context.
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	data := Inject(trace.ContextWithSpanContext(ctx, sc), payload)
+	got, rest := Extract(ctx, data)
+	if !bytes.Equal(rest, payload) {
+		t.Fatalf("Extract: got payload %q, want %q", rest, payload)
+	}
+	want := sc.WithRemote(true)
+	if gotSC := trace.SpanContextFromContext(got); !gotSC.Equal(want) {
+		t.Fatalf("Extract: got span context %v, want %v", gotSC, want)
+	}
+}
+
+func TestStart(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	old := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(old)
+
+	// The world server receives a traced message from another process, and
+	// the player forwards it to the chat module.
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	})
+	data := Inject(trace.ContextWithSpanContext(context.Background(), sc), []byte("hello"))
+	ctx, _ := Extract(context.Background(), data)
+	ctx, world := Start(ctx, "world", 42)
+	_, chat := Start(ctx, "chat", 42)
+	chat.End()
+	world.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	for _, span := range spans {
+		if got := span.SpanContext().TraceID(); got != sc.TraceID() {
+			t.Errorf("span %q: got trace id %v, want %v", span.Name(), got, sc.TraceID())
+		}
+	}
+	chatSpan, worldSpan := spans[0], spans[1]
+	if got, want := worldSpan.SpanKind(), trace.SpanKindServer; got != want {
+		t.Errorf("world span kind: got %v, want %v", got, want)
+	}
+	if got, want := worldSpan.Parent().SpanID(), sc.SpanID(); got != want {
+		t.Errorf("world span parent: got %v, want %v", got, want)
+	}
+	if got, want := chatSpan.Parent().SpanID(), worldSpan.SpanContext().SpanID(); got != want {
+		t.Errorf("chat span parent: got %v, want %v", got, want)
+	}
+	if got, want := chatSpan.Name(), "chat.42"; got != want {
+		t.Errorf("chat span name: got %q, want %q", got, want)
+	}
+}
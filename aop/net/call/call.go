@@ -765,14 +765,22 @@ func (c *serverConnection) runHandler(hmap *HandlerMap, id uint64, msg []byte) {
 	fn, ok := hmap.handlers[hkey]
 	if !ok {
 		err = fmt.Errorf("internal error: unknown function")
+	} else if !c.opts.Drainer.begin() {
+		err = Draining
 	} else {
+		defer c.opts.Drainer.end()
 		if err := c.startRequest(id, cancelFunc); err != nil {
 			logError(c.opts.Logger, "handle "+hmap.names[hkey], err)
 			return
 		}
 		cancelFunc = nil // endRequest() or cancellation will deal with it
 		defer c.endRequest(id)
-		result, err = fn(ctx, payload)
+		if c.opts.Faults != nil {
+			err = c.opts.Faults.Inject(ctx, hmap.names[hkey])
+		}
+		if err == nil {
+			result, err = fn(ctx, payload)
+		}
 	}
 
 	mt := responseMessage
@@ -1348,6 +1348,98 @@ func TestCancelServe(t *testing.T) {
 	}
 }
 
+func TestDrainServer(t *testing.T) {
+	// Start a server with a drainer, and a call that sleeps for a while.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	drainer := call.NewDrainer()
+	opts := call.ServerOptions{Logger: logging.NewTestLogger(t), Drainer: drainer}
+	endpoints := startServers(ctx, opts)
+	client := getClientConn(t, "tcp", endpoints["tcp"], resolverMakers["Constant"])
+
+	sleep := 200 * time.Millisecond
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Call(ctx, sleepKey, []byte(sleep.String()), call.CallOptions{})
+		done <- err
+	}()
+	for drainer.Active() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Drain the server. The call in progress completes, and Drain waits for
+	// it.
+	drained := make(chan error, 1)
+	go func() {
+		drained <- drainer.Drain(ctx)
+	}()
+	select {
+	case err := <-drained:
+		t.Fatalf("Drain returned %v before the call in progress completed", err)
+	case <-time.After(shortDelay):
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("call in progress: %v", err)
+	}
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+
+	// New calls are rejected.
+	_, err := client.Call(ctx, echoKey, []byte("hello"), call.CallOptions{})
+	if !errors.Is(err, call.Draining) {
+		t.Fatalf("call after Drain: got %v, want %v", err, call.Draining)
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	drainer := call.NewDrainer()
+	opts := call.ServerOptions{Logger: logging.NewTestLogger(t), Drainer: drainer}
+	endpoints := startServers(ctx, opts)
+	client := getClientConn(t, "tcp", endpoints["tcp"], resolverMakers["Constant"])
+
+	go client.Call(ctx, sleepKey, []byte(testTimeout.String()), call.CallOptions{})
+	for drainer.Active() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	drainCtx, drainCancel := context.WithTimeout(ctx, shortDelay)
+	defer drainCancel()
+	if err := drainer.Drain(drainCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain: got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// faultInjector is a call.FaultInjector that fails the calls to a method.
+type faultInjector struct {
+	method string // name of the failed method
+}
+
+// Inject implements the call.FaultInjector interface.
+func (f faultInjector) Inject(_ context.Context, method string) error {
+	if method == f.method {
+		return fmt.Errorf("injected %s error", method)
+	}
+	return nil
+}
+
+func TestFaultInjection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := call.ServerOptions{Logger: logging.NewTestLogger(t), Faults: faultInjector{".echo"}}
+	endpoints := startServers(ctx, opts)
+	client := getClientConn(t, "tcp", endpoints["tcp"], resolverMakers["Constant"])
+
+	_, err := client.Call(ctx, echoKey, []byte("hello"), call.CallOptions{})
+	if err == nil || !strings.Contains(err.Error(), "injected .echo error") {
+		t.Fatalf("echo: got %v, want an injected error", err)
+	}
+	if _, err := client.Call(ctx, sleepKey, []byte("1ms"), call.CallOptions{}); err != nil {
+		t.Fatalf("sleep: %v", err)
+	}
+}
+
 // failResolver is a resolver with a Resolve method that always fails after the
 // first time it's called.
 type failResolver struct {
@@ -0,0 +1,83 @@
+package call
+
+import (
+	"context"
+	"sync"
+)
+
+// A Drainer drains the calls handled by a set of servers (see
+// ServerOptions.Drainer) during a graceful shutdown. Once Drain is called,
+// the servers reject new calls with a Draining error, and Drain waits for the
+// calls in progress to complete.
+//
+// A nil *Drainer never drains.
+type Drainer struct {
+	mu       sync.Mutex
+	draining bool          // has Drain been called?
+	active   int           // number of calls in progress
+	idle     chan struct{} // closed once draining and active == 0
+}
+
+// NewDrainer returns a new Drainer.
+func NewDrainer() *Drainer {
+	return &Drainer{idle: make(chan struct{})}
+}
+
+// Drain rejects new calls, and waits until the calls in progress complete or
+// the provided context is done, in which case it returns the context's error.
+// Drain can be called more than once.
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.draining {
+		d.draining = true
+		if d.active == 0 {
+			close(d.idle)
+		}
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Active returns the number of calls in progress.
+func (d *Drainer) Active() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.active
+}
+
+// begin registers the start of a call. It returns false if the call must be
+// rejected because the drainer is draining.
+func (d *Drainer) begin() bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.active++
+	return true
+}
+
+// end registers the end of a call started by a successful begin.
+func (d *Drainer) end() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.draining && d.active == 0 {
+		close(d.idle)
+	}
+}
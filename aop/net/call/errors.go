@@ -18,6 +18,12 @@ const (
 	// server is unreachable. Check for it via errors.Is(call.Unreachable).
 	Unreachable
 
+	// Draining is the type of the error returned by a server that is shutting
+	// down and no longer accepts calls (see Drainer). Calls rejected with a
+	// Draining error were not executed, and can be safely retried at a
+	// different server.
+	Draining
+
 	// TODO: Decide what error most applications will want to check for. We may
 	// need to combine CommunicationError and Unreachable. We may also want to
 	// make errors.Is(CommunicationError) return true for both types of errors.
@@ -31,6 +37,8 @@ func (e transportError) Error() string {
 		return "communication error"
 	case Unreachable:
 		return "unreachable"
+	case Draining:
+		return "server draining"
 	default:
 		return fmt.Sprintf("unknown error %d", e)
 	}
@@ -1,6 +1,7 @@
 package call
 
 import (
+	"context"
 	"time"
 
 	"go.opentelemetry.io/otel/trace"
@@ -41,6 +42,22 @@ type ServerOptions struct {
 	// If non-zero, all writes smaller than this limit are flattened into
 	// a single buffer before being written on the connection.
 	WriteFlattenLimit int
+
+	// If not nil, the calls handled by the server are tracked by Drainer, so
+	// that they can be drained during a graceful shutdown.
+	Drainer *Drainer
+
+	// If not nil, faults are injected into the calls handled by the server
+	// (see chaos.Injector).
+	Faults FaultInjector
+}
+
+// A FaultInjector injects faults into the calls handled by a server.
+type FaultInjector interface {
+	// Inject is called before the handler of a call to the provided method,
+	// named "<component>.<method>". It may delay the call. If it returns an
+	// error, the call fails with the error, and the handler is not run.
+	Inject(ctx context.Context, method string) error
 }
 
 // CallOptions are call-specific options.
@@ -0,0 +1,262 @@
+package fanout
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+var (
+	updates = metrics.NewCounterMap[updateLabels](
+		"fanout_updates",
+		"Number of scene updates broadcast, or superseded within their tick",
+	)
+	batchBytes = metrics.NewCounter(
+		"fanout_batch_bytes",
+		"Number of bytes of the update batches built",
+	)
+	sentBytes = metrics.NewCounter(
+		"fanout_sent_bytes",
+		"Number of bytes of the update batches sent, over all their recipients",
+	)
+)
+
+type updateLabels struct {
+	Result string // "sent", "coalesced" (superseded by a later update) or "dropped" (no entity)
+}
+
+// Options configures a Broadcaster.
+type Options struct {
+	// CellSize is the side of the cells of the grid, in scene units.
+	// Defaults to 32.
+	CellSize float64
+
+	// Radius is the number of cells around its own a recipient receives the
+	// updates of: 1, the default, is the 3x3 cells around it.
+	Radius int
+
+	// MaxBatch is the size in bytes over which the updates of a tick are
+	// split into several batches. Defaults to 16 KB.
+	MaxBatch int
+}
+
+func (o Options) withDefaults() Options {
+	if o.CellSize <= 0 {
+		o.CellSize = 32
+	}
+	if o.Radius <= 0 {
+		o.Radius = 1
+	}
+	if o.MaxBatch <= 0 {
+		o.MaxBatch = 16 << 10
+	}
+	return o
+}
+
+// Broadcaster broadcasts the updates of the entities of a scene to the
+// recipients nearby, once per tick (see Flush). It's safe for concurrent use.
+type Broadcaster struct {
+	opts     Options
+	mu       sync.Mutex
+	entities map[uint64]*entity
+	cells    map[cell]map[uint64]*entity
+	pending  map[updateKey]proto.Message // latest update of the tick
+}
+
+type cell struct {
+	X, Y int32
+}
+
+type entity struct {
+	id        uint64
+	cell      cell
+	recipient bool
+}
+
+type updateKey struct {
+	entity uint64
+	id     uint64
+}
+
+// NewBroadcaster returns a broadcaster without entities.
+func NewBroadcaster(opts Options) *Broadcaster {
+	return &Broadcaster{
+		opts:     opts.withDefaults(),
+		entities: map[uint64]*entity{},
+		cells:    map[cell]map[uint64]*entity{},
+		pending:  map[updateKey]proto.Message{},
+	}
+}
+
+// Move places an entity at a position, adding it if it's not in the scene.
+// A recipient (a player) receives the updates nearby; other entities only
+// send updates.
+func (b *Broadcaster) Move(id uint64, x, y float64, recipient bool) {
+	c := b.cellOf(x, y)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e := b.entities[id]
+	if e == nil {
+		e = &entity{id: id}
+		b.entities[id] = e
+	} else if e.cell != c {
+		b.leave(e)
+	}
+	e.cell, e.recipient = c, recipient
+	entities := b.cells[c]
+	if entities == nil {
+		entities = map[uint64]*entity{}
+		b.cells[c] = entities
+	}
+	entities[id] = e
+}
+
+// Remove removes an entity from the scene, with its pending updates.
+func (b *Broadcaster) Remove(id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e := b.entities[id]
+	if e == nil {
+		return
+	}
+	delete(b.entities, id)
+	b.leave(e)
+	for k := range b.pending {
+		if k.entity == id {
+			delete(b.pending, k)
+		}
+	}
+}
+
+// leave removes an entity from its cell.
+func (b *Broadcaster) leave(e *entity) {
+	entities := b.cells[e.cell]
+	delete(entities, e.id)
+	if len(entities) == 0 {
+		delete(b.cells, e.cell)
+	}
+}
+
+// Update records an update of an entity, sent to the recipients nearby at
+// the next Flush. It supersedes the update of the entity with the same
+// message ID recorded since the last Flush. msg must not be modified
+// afterwards.
+func (b *Broadcaster) Update(entity, id uint64, msg proto.Message) {
+	k := updateKey{entity: entity, id: id}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.pending[k]; ok {
+		updates.Get(updateLabels{Result: "coalesced"}).Add(1)
+	}
+	b.pending[k] = msg
+}
+
+// Flush sends the updates recorded since the last Flush, calling send for
+// every batch of every recipient. The recipients of a cell are sent the same
+// batches, which they must not modify. It returns the first error
+// marshalling an update, which is skipped.
+func (b *Broadcaster) Flush(send func(recipient uint64, batch *wrapperspb.BytesValue)) error {
+	type delivery struct {
+		recipients []uint64
+		batches    []*wrapperspb.BytesValue
+	}
+	var deliveries []delivery
+	var firstErr error
+
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[updateKey]proto.Message, len(pending))
+
+	// Marshal every update once, by cell, in a stable order.
+	keys := make([]updateKey, 0, len(pending))
+	for k := range pending {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].entity != keys[j].entity {
+			return keys[i].entity < keys[j].entity
+		}
+		return keys[i].id < keys[j].id
+	})
+	entries := map[cell][][]byte{}
+	for _, k := range keys {
+		e := b.entities[k.entity]
+		if e == nil {
+			updates.Get(updateLabels{Result: "dropped"}).Add(1)
+			continue
+		}
+		payload, err := proto.Marshal(pending[k])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		updates.Get(updateLabels{Result: "sent"}).Add(1)
+		entries[e.cell] = append(entries[e.cell], appendEntry(nil, k.id, payload))
+	}
+
+	// Build the batches of every cell with recipients, from the updates of
+	// the cells around it.
+	if len(entries) > 0 {
+		r := int32(b.opts.Radius)
+		for c, entities := range b.cells {
+			var recipients []uint64
+			for id, e := range entities {
+				if e.recipient {
+					recipients = append(recipients, id)
+				}
+			}
+			if len(recipients) == 0 {
+				continue
+			}
+			var batches []*wrapperspb.BytesValue
+			var batch []byte
+			for dx := -r; dx <= r; dx++ {
+				for dy := -r; dy <= r; dy++ {
+					for _, entry := range entries[cell{X: c.X + dx, Y: c.Y + dy}] {
+						if len(batch) > 0 && len(batch)+len(entry) > b.opts.MaxBatch {
+							batches = append(batches, wrapperspb.Bytes(batch))
+							batch = nil
+						}
+						batch = append(batch, entry...)
+					}
+				}
+			}
+			if len(batch) > 0 {
+				batches = append(batches, wrapperspb.Bytes(batch))
+			}
+			if len(batches) > 0 {
+				deliveries = append(deliveries, delivery{recipients: recipients, batches: batches})
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	for _, d := range deliveries {
+		size := 0
+		for _, batch := range d.batches {
+			size += len(batch.Value)
+		}
+		batchBytes.Add(float64(size))
+		sentBytes.Add(float64(size * len(d.recipients)))
+		for _, recipient := range d.recipients {
+			for _, batch := range d.batches {
+				send(recipient, batch)
+			}
+		}
+	}
+	return firstErr
+}
+
+// cellOf returns the cell of a position.
+func (b *Broadcaster) cellOf(x, y float64) cell {
+	return cell{
+		X: int32(math.Floor(x / b.opts.CellSize)),
+		Y: int32(math.Floor(y / b.opts.CellSize)),
+	}
+}
@@ -0,0 +1,56 @@
+// Package fanout sends the frequent updates of a scene (positions, states) to
+// the players nearby, at a cost that grows with the number of grid cells
+// rather than with the square of the number of players.
+//
+// A Broadcaster coalesces the updates of a tick, keeping the latest update of
+// an entity per message ID, marshals every update once, and sends the
+// recipients of a cell of its grid the same batch of the updates of the
+// cells around it (see BatchId). A Queue bounds the messages pending for a
+// connection, dropping the batches of a client that doesn't keep up, as the
+// next tick supersedes them, before disconnecting it.
+package fanout
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// BatchId is the ID of the message batching updates, whose payload is a
+// google.protobuf.BytesValue of its updates, each encoded as
+//
+//	| message ID (uvarint) | length (uvarint) | payload |
+//
+// It's reserved, out of the range of the message IDs of the proto
+// definitions (see msgseq.AckId).
+const BatchId = 0xfffe
+
+// ErrBatch is returned by Split for a malformed batch.
+var ErrBatch = errors.New("fanout: malformed batch")
+
+// appendEntry appends an update to a batch.
+func appendEntry(b []byte, id uint64, payload []byte) []byte {
+	var header [2 * binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(header[:], id)
+	n += binary.PutUvarint(header[n:], uint64(len(payload)))
+	return append(append(b, header[:n]...), payload...)
+}
+
+// Split calls fn with the message ID and the payload of every update of a
+// batch, in order. The payloads alias batch.
+func Split(batch []byte, fn func(id uint64, payload []byte)) error {
+	for len(batch) > 0 {
+		id, n := binary.Uvarint(batch)
+		if n <= 0 {
+			return ErrBatch
+		}
+		batch = batch[n:]
+		size, n := binary.Uvarint(batch)
+		if n <= 0 || size > uint64(len(batch)-n) {
+			return ErrBatch
+		}
+		batch = batch[n:]
+		fn(id, batch[:size])
+		batch = batch[size:]
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package fanout
+
+import (
+	"fmt"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestFlush(t *testing.T) {
+	b := NewBroadcaster(Options{CellSize: 10})
+	b.Move(1, 5, 5, true)   // cell (0, 0)
+	b.Move(2, 15, 5, true)  // cell (1, 0), next to 1
+	b.Move(3, 8, 2, true)   // cell (0, 0), with 1
+	b.Move(4, 95, 95, true) // cell (9, 9), far away
+	b.Move(5, 25, 5, false) // cell (2, 0), next to 2 only
+
+	b.Update(1, 7, wrapperspb.String("stale"))
+	b.Update(1, 7, wrapperspb.String("p1")) // supersedes stale
+	b.Update(5, 7, wrapperspb.String("m5"))
+	b.Update(6, 7, wrapperspb.String("gone")) // not in the scene
+
+	got := map[uint64][]string{}
+	batches := map[uint64]*wrapperspb.BytesValue{}
+	err := b.Flush(func(recipient uint64, batch *wrapperspb.BytesValue) {
+		batches[recipient] = batch
+		if err := Split(batch.Value, func(id uint64, payload []byte) {
+			var s wrapperspb.StringValue
+			if err := proto.Unmarshal(payload, &s); err != nil {
+				t.Fatal(err)
+			}
+			got[recipient] = append(got[recipient], fmt.Sprintf("%d:%s", id, s.Value))
+		}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint64]string{1: "[7:p1]", 2: "[7:p1 7:m5]", 3: "[7:p1]"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for recipient, w := range want {
+		if fmt.Sprint(got[recipient]) != w {
+			t.Errorf("recipient %d: got %v, want %v", recipient, got[recipient], w)
+		}
+	}
+	if batches[1] != batches[3] {
+		t.Error("the recipients of a cell don't share their batch")
+	}
+
+	// Nothing left for the next tick.
+	b.Flush(func(recipient uint64, _ *wrapperspb.BytesValue) {
+		t.Errorf("recipient %d sent an empty tick", recipient)
+	})
+}
+
+func TestFlushSplits(t *testing.T) {
+	b := NewBroadcaster(Options{MaxBatch: 64})
+	for i := uint64(1); i <= 10; i++ {
+		b.Move(i, 0, 0, i == 1)
+		b.Update(i, 1, wrapperspb.Bytes(make([]byte, 20)))
+	}
+	n, updates := 0, 0
+	b.Flush(func(_ uint64, batch *wrapperspb.BytesValue) {
+		n++
+		if len(batch.Value) > 64 {
+			t.Errorf("batch of %d bytes", len(batch.Value))
+		}
+		Split(batch.Value, func(uint64, []byte) { updates++ })
+	})
+	if n < 2 || updates != 10 {
+		t.Errorf("got %d updates in %d batches", updates, n)
+	}
+}
+
+func TestSplitMalformed(t *testing.T) {
+	batch := appendEntry(nil, 3, []byte("abc"))
+	if err := Split(batch[:len(batch)-1], func(uint64, []byte) {}); err != ErrBatch {
+		t.Errorf("got %v, want %v", err, ErrBatch)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	for _, tc := range []struct {
+		policy Policy
+		want   string
+	}{
+		{DropOldest, "[r1 b2 b3]"},
+		{DropNewest, "[r1 b1 b2]"},
+	} {
+		q := NewQueue[string](3, tc.policy)
+		q.Push("r1", false)
+		q.Push("b1", true)
+		q.Push("b2", true)
+		if err := q.Push("b3", true); err != nil {
+			t.Fatalf("%s: %v", tc.policy, err)
+		}
+		var got []string
+		done := make(chan struct{})
+		close(done)
+		for v, ok := q.Pop(done); ok; v, ok = q.Pop(done) {
+			got = append(got, v)
+		}
+		if fmt.Sprint(got) != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.policy, got, tc.want)
+		}
+	}
+
+	// A reliable message makes room by dropping a batch, then overflows.
+	q := NewQueue[string](2, DropNewest)
+	q.Push("r1", false)
+	q.Push("b1", true)
+	if err := q.Push("r2", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Push("r3", false); err != ErrOverflow {
+		t.Fatalf("got %v, want %v", err, ErrOverflow)
+	}
+	if err := q.Push("b2", true); err != ErrOverflow || q.Len() != 0 {
+		t.Fatalf("after the overflow: got %v, %d queued", err, q.Len())
+	}
+
+	q = NewQueue[string](2, Disconnect)
+	q.Push("b1", true)
+	q.Push("b2", true)
+	if err := q.Push("b3", true); err != ErrOverflow {
+		t.Fatalf("disconnect: got %v, want %v", err, ErrOverflow)
+	}
+}
+
+func TestQueuePopWaits(t *testing.T) {
+	q := NewQueue[int](0, "")
+	go q.Push(42, false)
+	if v, ok := q.Pop(nil); !ok || v != 42 {
+		t.Fatalf("got %v %v", v, ok)
+	}
+}
@@ -0,0 +1,158 @@
+package fanout
+
+import (
+	"errors"
+	"sync"
+
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+var (
+	droppedMessages = metrics.NewCounterMap[dropLabels](
+		"fanout_dropped_messages",
+		"Number of messages dropped from a full send queue",
+	)
+	overflows = metrics.NewCounter(
+		"fanout_overflows",
+		"Number of send queues overflowing, whose connection is to be disconnected",
+	)
+)
+
+type dropLabels struct {
+	Policy string // see Policy
+}
+
+// Policy is what a full Queue does with a message. With either drop policy, a
+// message that isn't droppable makes room by dropping the oldest droppable
+// message queued.
+type Policy string
+
+const (
+	// DropOldest drops the oldest droppable message queued to make room.
+	DropOldest Policy = "drop-oldest"
+	// DropNewest drops the message pushed, if droppable.
+	DropNewest Policy = "drop-newest"
+	// Disconnect drops nothing: the queue overflows.
+	Disconnect Policy = "disconnect"
+)
+
+// ErrOverflow is returned by Queue.Push once the queue is full of messages it
+// may not drop. The connection is to be disconnected, as it doesn't keep up.
+var ErrOverflow = errors.New("fanout: send queue overflow")
+
+// Queue is the queue of the messages T pending for a connection, at most
+// Depth of them. Once full, droppable messages (e.g., update batches, which
+// the next tick supersedes) are dropped by its Policy; other messages, or
+// any message with Disconnect, overflow it. It's safe for concurrent use.
+type Queue[T any] struct {
+	depth    int
+	policy   Policy
+	mu       sync.Mutex
+	items    []item[T]
+	overflow bool
+	ready    chan struct{} // signaled when items are pushed
+}
+
+type item[T any] struct {
+	v         T
+	droppable bool
+}
+
+// NewQueue returns a queue of depth messages, defaulting to 256, and of a
+// policy, defaulting to DropOldest.
+func NewQueue[T any](depth int, policy Policy) *Queue[T] {
+	if depth <= 0 {
+		depth = 256
+	}
+	if policy == "" {
+		policy = DropOldest
+	}
+	return &Queue[T]{depth: depth, policy: policy, ready: make(chan struct{}, 1)}
+}
+
+// Push queues a message, or drops it, or a droppable message queued, if the
+// queue is full. It returns ErrOverflow if the queue overflows, and for
+// every message pushed afterwards.
+func (q *Queue[T]) Push(v T, droppable bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.overflow {
+		return ErrOverflow
+	}
+	if len(q.items) >= q.depth {
+		if !q.makeRoom(droppable) {
+			if droppable && q.policy != Disconnect {
+				return nil
+			}
+			q.overflow = true
+			q.items = nil
+			overflows.Add(1)
+			return ErrOverflow
+		}
+	}
+	q.items = append(q.items, item[T]{v: v, droppable: droppable})
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// makeRoom drops a message of a full queue by its policy. It returns false if
+// the message pushed must be dropped instead, or, if it's not droppable, if
+// the queue overflows.
+func (q *Queue[T]) makeRoom(droppable bool) bool {
+	if q.policy == Disconnect {
+		return false
+	}
+	if q.policy == DropNewest && droppable {
+		droppedMessages.Get(dropLabels{Policy: string(q.policy)}).Add(1)
+		return false
+	}
+	for i := range q.items {
+		if q.items[i].droppable {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			droppedMessages.Get(dropLabels{Policy: string(q.policy)}).Add(1)
+			return true
+		}
+	}
+	if droppable {
+		droppedMessages.Get(dropLabels{Policy: string(q.policy)}).Add(1)
+	}
+	return false
+}
+
+// Pop returns the oldest message queued, waiting for one until done is
+// closed. The messages queued before done is closed are still returned; it
+// returns false once there are none.
+func (q *Queue[T]) Pop(done <-chan struct{}) (T, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			v := q.items[0].v
+			q.items[0] = item[T]{}
+			q.items = q.items[1:]
+			q.mu.Unlock()
+			return v, true
+		}
+		q.mu.Unlock()
+		select {
+		case <-q.ready:
+		case <-done:
+			q.mu.Lock()
+			empty := len(q.items) == 0
+			q.mu.Unlock()
+			if empty {
+				var zero T
+				return zero, false
+			}
+		}
+	}
+}
+
+// Len returns the number of messages queued.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
@@ -0,0 +1,257 @@
+// Package flood protects a message dispatcher from clients flooding it, with
+// token buckets per connection, and per connection and message ID.
+//
+// A message over a limit is dropped. A connection that sends Strikes messages
+// over a limit within StrikeWindow is to be disconnected. Dropped messages are
+// counted in the flood_dropped_messages metric, by message ID and by the
+// limit they were over, and the connections that flood the most, with the
+// messages they flood, are reported by Limiter.Top.
+package flood
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+var (
+	droppedMessages = metrics.NewCounterMap[dropLabels](
+		"flood_dropped_messages",
+		"Number of client messages dropped over a rate limit",
+	)
+	disconnects = metrics.NewCounter(
+		"flood_disconnects",
+		"Number of client connections disconnected for flooding",
+	)
+)
+
+type dropLabels struct {
+	MessageId uint32
+	Scope     string // "conn": over the limit of the connection; "message": over the limit of the message ID
+}
+
+// Limit is the rate limit of a token bucket: Burst messages at once, refilled
+// at Rate messages per second. The zero Limit doesn't limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Options configures a Limiter.
+type Options struct {
+	// Conn is the limit of all the messages of a connection. Defaults to 30
+	// messages per second, with bursts of 60.
+	Conn Limit
+
+	// Messages are the limits of message IDs, per connection. The message
+	// IDs missing are limited by Default, which defaults to 10 messages per
+	// second, with bursts of 20.
+	Messages map[uint32]Limit
+	Default  Limit
+
+	// Strikes is the number of messages over a limit within StrikeWindow
+	// after which a connection is to be disconnected. Defaults to 100. If
+	// negative, connections are never disconnected.
+	Strikes      int
+	StrikeWindow time.Duration // defaults to 10 seconds
+
+	// Idle is how long the buckets of a connection that sent no message are
+	// kept, for the connections that close without being forgotten. Defaults
+	// to 5 minutes.
+	Idle time.Duration
+}
+
+// withDefaults returns a copy of the options, with default values filled in.
+func (o Options) withDefaults() Options {
+	if o.Conn == (Limit{}) {
+		o.Conn = Limit{Rate: 30, Burst: 60}
+	}
+	if o.Default == (Limit{}) {
+		o.Default = Limit{Rate: 10, Burst: 20}
+	}
+	if o.Strikes == 0 {
+		o.Strikes = 100
+	}
+	if o.StrikeWindow <= 0 {
+		o.StrikeWindow = 10 * time.Second
+	}
+	if o.Idle <= 0 {
+		o.Idle = 5 * time.Minute
+	}
+	return o
+}
+
+// Verdict is what to do with a message.
+type Verdict int
+
+const (
+	Allow      Verdict = iota // dispatch the message
+	Drop                      // drop the message
+	Disconnect                // drop the message, and disconnect the connection
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time // when tokens was computed
+}
+
+// take takes a token from the bucket, refilled by now, and returns whether
+// there was one.
+func (b *bucket) take(l Limit, now time.Time) bool {
+	if b.last.IsZero() {
+		b.tokens = float64(l.Burst)
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * l.Rate
+		if b.tokens > float64(l.Burst) {
+			b.tokens = float64(l.Burst)
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// conn is the state of a connection.
+type conn struct {
+	all      bucket
+	messages map[uint32]*bucket
+	last     time.Time // last message
+
+	strikes     int       // since strikesFrom
+	strikesFrom time.Time // start of the strike window
+	dropped     map[uint32]int64
+}
+
+// Flood is the messages dropped from a connection.
+type Flood struct {
+	Conn     uint64
+	Dropped  int64            // messages dropped
+	Messages map[uint32]int64 // messages dropped, by message ID
+}
+
+// A Limiter rate limits the messages of connections. It's safe for
+// concurrent use.
+type Limiter struct {
+	opts Options
+
+	mu        sync.Mutex
+	conns     map[uint64]*conn // guarded by mu
+	lastSweep time.Time        // guarded by mu
+}
+
+// New returns a new Limiter.
+func New(opts Options) *Limiter {
+	return &Limiter{opts: opts.withDefaults(), conns: map[uint64]*conn{}}
+}
+
+// Allow returns what to do with a message of a connection received now.
+func (l *Limiter) Allow(connId uint64, messageId uint32, now time.Time) Verdict {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= l.opts.Idle {
+		l.sweep(now)
+	}
+	c := l.conns[connId]
+	if c == nil {
+		c = &conn{messages: map[uint32]*bucket{}, dropped: map[uint32]int64{}}
+		l.conns[connId] = c
+	}
+	c.last = now
+
+	scope := ""
+	if !l.opts.Conn.unlimited() && !c.all.take(l.opts.Conn, now) {
+		scope = "conn"
+	} else if limit := l.limit(messageId); !limit.unlimited() {
+		b := c.messages[messageId]
+		if b == nil {
+			b = &bucket{}
+			c.messages[messageId] = b
+		}
+		if !b.take(limit, now) {
+			scope = "message"
+		}
+	}
+	if scope == "" {
+		return Allow
+	}
+
+	droppedMessages.Get(dropLabels{MessageId: messageId, Scope: scope}).Add(1)
+	c.dropped[messageId]++
+	if l.opts.Strikes < 0 {
+		return Drop
+	}
+	if now.Sub(c.strikesFrom) > l.opts.StrikeWindow {
+		c.strikes, c.strikesFrom = 0, now
+	}
+	c.strikes++
+	if c.strikes < l.opts.Strikes {
+		return Drop
+	}
+	disconnects.Add(1)
+	delete(l.conns, connId)
+	return Disconnect
+}
+
+func (l *Limiter) limit(messageId uint32) Limit {
+	if limit, ok := l.opts.Messages[messageId]; ok {
+		return limit
+	}
+	return l.opts.Default
+}
+
+// Forget drops the state of a connection, e.g. when it closes.
+func (l *Limiter) Forget(connId uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.conns, connId)
+}
+
+// sweep drops the state of the connections idle for Options.Idle.
+//
+// REQUIRES: l.mu is held.
+func (l *Limiter) sweep(now time.Time) {
+	l.lastSweep = now
+	for id, c := range l.conns {
+		if now.Sub(c.last) >= l.opts.Idle {
+			delete(l.conns, id)
+		}
+	}
+}
+
+// Top returns the n connections that dropped the most messages, the most
+// first.
+func (l *Limiter) Top(n int) []Flood {
+	l.mu.Lock()
+	var floods []Flood
+	for id, c := range l.conns {
+		if len(c.dropped) == 0 {
+			continue
+		}
+		f := Flood{Conn: id, Messages: make(map[uint32]int64, len(c.dropped))}
+		for msg, count := range c.dropped {
+			f.Messages[msg] = count
+			f.Dropped += count
+		}
+		floods = append(floods, f)
+	}
+	l.mu.Unlock()
+	sort.Slice(floods, func(i, j int) bool {
+		if floods[i].Dropped != floods[j].Dropped {
+			return floods[i].Dropped > floods[j].Dropped
+		}
+		return floods[i].Conn < floods[j].Conn
+	})
+	if len(floods) > n {
+		floods = floods[:n]
+	}
+	return floods
+}
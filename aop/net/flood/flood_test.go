@@ -0,0 +1,97 @@
+package flood
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimits(t *testing.T) {
+	now := time.Now()
+	l := New(Options{
+		Conn:     Limit{Rate: 100, Burst: 5},
+		Messages: map[uint32]Limit{1: {Rate: 1, Burst: 2}, 2: {}},
+		Default:  Limit{Rate: 10, Burst: 3},
+		Strikes:  -1,
+	})
+
+	// Message 1 allows a burst of 2, then 1 per second.
+	for i, want := range []Verdict{Allow, Allow, Drop} {
+		if got := l.Allow(1, 1, now); got != want {
+			t.Fatalf("message 1, call %d: got %v, want %v", i, got, want)
+		}
+	}
+	// The limits are per connection and per message.
+	if got := l.Allow(2, 1, now); got != Allow {
+		t.Fatalf("other connection: got %v, want %v", got, Allow)
+	}
+	if got := l.Allow(1, 3, now); got != Allow {
+		t.Fatalf("other message: got %v, want %v", got, Allow)
+	}
+	// Message 2 is unlimited, but the connection isn't: 5 messages so far.
+	if got := l.Allow(1, 2, now); got != Allow {
+		t.Fatalf("unlimited message: got %v, want %v", got, Allow)
+	}
+	if got := l.Allow(1, 2, now); got != Drop {
+		t.Fatalf("over the connection limit: got %v, want %v", got, Drop)
+	}
+
+	now = now.Add(time.Second)
+	if got := l.Allow(1, 1, now); got != Allow {
+		t.Fatalf("refilled: got %v, want %v", got, Allow)
+	}
+
+	top := l.Top(10)
+	if len(top) != 1 || top[0].Conn != 1 || top[0].Dropped != 2 || top[0].Messages[1] != 1 || top[0].Messages[2] != 1 {
+		t.Fatalf("top: got %+v", top)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	now := time.Now()
+	l := New(Options{
+		Default:      Limit{Rate: 1, Burst: 1},
+		Strikes:      3,
+		StrikeWindow: time.Second,
+	})
+	if got := l.Allow(1, 1, now); got != Allow {
+		t.Fatalf("got %v, want %v", got, Allow)
+	}
+	// Two strikes, then the window expires.
+	l.Allow(1, 1, now)
+	l.Allow(1, 1, now)
+	now = now.Add(2 * time.Second)
+	l.Allow(1, 1, now)
+	if got := l.Allow(1, 1, now); got != Drop {
+		t.Fatalf("new window: got %v, want %v", got, Drop)
+	}
+	if got := l.Allow(1, 1, now); got != Drop {
+		t.Fatalf("second strike: got %v, want %v", got, Drop)
+	}
+	if got := l.Allow(1, 1, now); got != Disconnect {
+		t.Fatalf("third strike: got %v, want %v", got, Disconnect)
+	}
+	// A disconnected connection starts over.
+	if got := l.Allow(1, 1, now); got != Allow {
+		t.Fatalf("after disconnect: got %v, want %v", got, Allow)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	now := time.Now()
+	l := New(Options{Default: Limit{Rate: 1, Burst: 1}, Idle: time.Minute})
+	l.Allow(1, 1, now)
+	l.Allow(1, 1, now)
+	if got := len(l.Top(10)); got != 1 {
+		t.Fatalf("top: got %d connections, want 1", got)
+	}
+	now = now.Add(time.Minute)
+	l.Allow(2, 1, now)
+	if got := len(l.Top(10)); got != 0 {
+		t.Fatalf("top after sweep: got %d connections, want 0", got)
+	}
+	l.Allow(2, 1, now)
+	l.Forget(2)
+	if got := len(l.Top(10)); got != 0 {
+		t.Fatalf("top after forget: got %d connections, want 0", got)
+	}
+}
@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zlib"
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+// maxOverhead is the most a frame body may exceed its payload: the tag of
+// AES-GCM. A compressed body is always smaller than its payload.
+const maxOverhead = 16
+
+var (
+	payloadBytes = metrics.NewCounterMap[byteLabels](
+		"transport_payload_bytes",
+		"Number of bytes of the payloads of the client connections, before compression and encryption",
+	)
+	wireBytes = metrics.NewCounterMap[byteLabels](
+		"transport_wire_bytes",
+		"Number of bytes of the frame bodies of the client connections, after compression and encryption",
+	)
+	savedBytes = metrics.NewCounterMap[byteLabels](
+		"transport_saved_bytes",
+		"Number of bytes saved by compressing the payloads of the client connections",
+	)
+)
+
+type byteLabels struct {
+	Direction   string // "in" or "out"
+	Compression string // "zlib", "snappy" or "none"
+}
+
+// record records the bytes of a payload and of its frame body.
+func record(flags byte, direction string, payload, wire int) {
+	l := byteLabels{Direction: direction, Compression: "none"}
+	switch {
+	case flags&flagZlib != 0:
+		l.Compression = "zlib"
+	case flags&flagSnappy != 0:
+		l.Compression = "snappy"
+	}
+	payloadBytes.Get(l).Add(float64(payload))
+	wireBytes.Get(l).Add(float64(wire))
+	if l.Compression != "none" {
+		savedBytes.Get(l).Add(float64(payload - wire + sealOverhead(flags)))
+	}
+}
+
+// sealOverhead returns the bytes sealing added to a frame body.
+func sealOverhead(flags byte) int {
+	if flags&flagSealed != 0 {
+		return maxOverhead
+	}
+	return 0
+}
+
+// compressors compress payloads, by name.
+var compressors = map[string]struct {
+	flag     byte
+	compress func([]byte) []byte
+}{
+	"zlib":   {flagZlib, zlibCompress},
+	"snappy": {flagSnappy, func(p []byte) []byte { return snappy.Encode(nil, p) }},
+}
+
+// compress returns the flags and the body of a payload compressed by the
+// named algorithm, or the payload itself if it doesn't get smaller.
+func compress(name string, payload []byte) (byte, []byte) {
+	c, ok := compressors[name]
+	if !ok {
+		return 0, payload
+	}
+	if body := c.compress(payload); len(body) < len(payload) {
+		return c.flag, body
+	}
+	return 0, payload
+}
+
+// decompress returns the payload of a frame body opened, of at most
+// MaxPayload bytes.
+func decompress(flags byte, body []byte) ([]byte, error) {
+	switch {
+	case flags&flagZlib != 0:
+		r, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, ErrFrame
+		}
+		defer r.Close()
+		payload, err := io.ReadAll(io.LimitReader(r, MaxPayload+1))
+		if err != nil || len(payload) > MaxPayload {
+			return nil, ErrFrame
+		}
+		return payload, nil
+	case flags&flagSnappy != 0:
+		if n, err := snappy.DecodedLen(body); err != nil || n > MaxPayload {
+			return nil, ErrFrame
+		}
+		payload, err := snappy.Decode(nil, body)
+		if err != nil {
+			return nil, ErrFrame
+		}
+		return payload, nil
+	}
+	return body, nil
+}
+
+var zlibWriters = sync.Pool{New: func() any {
+	w, _ := zlib.NewWriterLevel(nil, zlib.BestSpeed)
+	return w
+}}
+
+func zlibCompress(p []byte) []byte {
+	var b bytes.Buffer
+	w := zlibWriters.Get().(*zlib.Writer)
+	defer zlibWriters.Put(w)
+	w.Reset(&b)
+	w.Write(p)
+	w.Close()
+	return b.Bytes()
+}
@@ -0,0 +1,330 @@
+// Package transport frames the byte streams of the client connections, and
+// compresses and encrypts their payloads.
+//
+// A connection starts with a handshake: the client sends a random value and
+// the session token the login server issued it, and the server replies
+// whether the connection is encrypted, and a random value of its own. Then
+// every Write is sent as frames of at most MaxPayload bytes of payload:
+//
+//	| length uint32 | flags byte | body |
+//
+// length is the length of the flags and the body, big-endian. A payload of at
+// least Options.Threshold bytes is compressed, with zlib or snappy, if it gets
+// smaller; the receiver reads the algorithm in the flags, so that both sides
+// may use different ones. On an encrypted connection, the body is then sealed
+// with AES-GCM by the key of the connection, with the flags as additional
+// data, and the direction and the number of the frame as nonce: a frame can't
+// be altered, replayed, reordered or reflected without the connection failing.
+//
+// The key of a session is derived from its token by the login server and the
+// gateways (auth.Signer.SessionKey), and given to the client over HTTPS with
+// the token: it's never sent over the connection. The key of a connection is
+// derived from it with HKDF, salted with the random values of the handshake,
+// so that the connections of a session, whose frames are numbered from 0
+// each, never seal with the same key and nonce.
+package transport
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// MaxPayload is the largest payload of a frame; larger writes are split.
+const MaxPayload = 1 << 20
+
+const (
+	flagZlib      = 1 << iota // body compressed with zlib
+	flagSnappy                // body compressed with snappy
+	flagSealed                // body sealed with AES-GCM
+	flagHandshake             // handshake frame, in plain
+)
+
+// randomSize is the length of the random values of the handshake.
+const randomSize = 16
+
+// The directions of the frames, first byte of their nonce.
+const (
+	fromClient byte = 1
+	fromServer byte = 2
+)
+
+var (
+	ErrHandshake = errors.New("transport: handshake failed")
+	ErrFrame     = errors.New("transport: malformed frame")
+	ErrKey       = errors.New("transport: no key for an encrypted connection")
+)
+
+// Options are the options of a side of a connection.
+type Options struct {
+	Compression string        // "zlib", "snappy", or empty not to compress
+	Threshold   int           // payloads of fewer bytes aren't compressed, defaults to 512
+	Encrypt     bool          // server only: whether the connection is encrypted
+	Timeout     time.Duration // of the handshake, defaults to 10 seconds
+}
+
+func (o *Options) init() error {
+	if _, ok := compressors[o.Compression]; !ok && o.Compression != "" {
+		return fmt.Errorf("transport: unknown compression %q", o.Compression)
+	}
+	if o.Threshold <= 0 {
+		o.Threshold = 512
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	return nil
+}
+
+// Conn is a connection framed by the transport. Its Read and Write may be
+// called concurrently, but not Read or Write with itself.
+type Conn struct {
+	net.Conn
+	opts Options
+	dir  byte        // of the frames written
+	aead cipher.AEAD // nil if not encrypted
+
+	r       *bufio.Reader
+	rbuf    []byte // payload read but not returned yet
+	rseq    uint64 // frames read
+	rheader [5]byte
+
+	wmu  sync.Mutex
+	wseq uint64 // frames written
+	wbuf []byte
+}
+
+// Server runs the server side of the handshake on a connection: it reads the
+// token of the client, which accept verifies, returning the key of its
+// session, and replies whether the connection is encrypted. The key is only
+// used if opts.Encrypt, to derive the key of the connection.
+func Server(conn net.Conn, opts Options, accept func(token string) ([]byte, error)) (*Conn, error) {
+	if err := opts.init(); err != nil {
+		return nil, err
+	}
+	c := newConn(conn, opts, fromServer)
+	conn.SetDeadline(time.Now().Add(opts.Timeout))
+	flags, hello, err := c.readFrame()
+	if err != nil {
+		return nil, err
+	}
+	if flags != flagHandshake || len(hello) < randomSize {
+		return nil, ErrHandshake
+	}
+	key, err := accept(string(hello[randomSize:]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrHandshake, err)
+	}
+	reply := make([]byte, 1+randomSize)
+	if _, err := rand.Read(reply[1:]); err != nil {
+		return nil, fmt.Errorf("transport: %w", err)
+	}
+	if opts.Encrypt {
+		if err := c.setKey(key, hello[:randomSize], reply[1:]); err != nil {
+			return nil, err
+		}
+		reply[0] = 1
+	}
+	if err := c.writeRaw(flagHandshake, reply); err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+// Client runs the client side of the handshake on a connection, with the
+// session token and key the login server issued. The key is only needed if
+// the server encrypts the connection.
+func Client(conn net.Conn, opts Options, token string, key []byte) (*Conn, error) {
+	if err := opts.init(); err != nil {
+		return nil, err
+	}
+	c := newConn(conn, opts, fromClient)
+	conn.SetDeadline(time.Now().Add(opts.Timeout))
+	hello := make([]byte, randomSize+len(token))
+	if _, err := rand.Read(hello[:randomSize]); err != nil {
+		return nil, fmt.Errorf("transport: %w", err)
+	}
+	copy(hello[randomSize:], token)
+	if err := c.writeRaw(flagHandshake, hello); err != nil {
+		return nil, err
+	}
+	flags, reply, err := c.readFrame()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrHandshake // rejected
+		}
+		return nil, err
+	}
+	if flags != flagHandshake || len(reply) != 1+randomSize {
+		return nil, ErrHandshake
+	}
+	if reply[0] == 1 {
+		if err := c.setKey(key, hello[:randomSize], reply[1:]); err != nil {
+			return nil, err
+		}
+	}
+	conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+func newConn(conn net.Conn, opts Options, dir byte) *Conn {
+	return &Conn{Conn: conn, opts: opts, dir: dir, r: bufio.NewReader(conn)}
+}
+
+// setKey encrypts the connection by the key derived from the key of its
+// session and the random values of the client and the server.
+func (c *Conn) setKey(sessionKey, client, server []byte) error {
+	if len(sessionKey) == 0 {
+		return ErrKey
+	}
+	salt := append(append([]byte(nil), client...), server...)
+	key := make([]byte, len(sessionKey))
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sessionKey, salt, []byte("transport")), key); err != nil {
+		return fmt.Errorf("transport: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("transport: %w", err)
+	}
+	c.aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("transport: %w", err)
+	}
+	return nil
+}
+
+// Encrypted returns whether the connection is encrypted.
+func (c *Conn) Encrypted() bool {
+	return c.aead != nil
+}
+
+// Read reads the payloads of the frames, as a stream.
+func (c *Conn) Read(p []byte) (int, error) {
+	for len(c.rbuf) == 0 {
+		flags, body, err := c.readFrame()
+		if err != nil {
+			return 0, err
+		}
+		if c.rbuf, err = c.open(flags, body); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, c.rbuf)
+	c.rbuf = c.rbuf[n:]
+	return n, nil
+}
+
+// readFrame reads the flags and the body of a frame.
+func (c *Conn) readFrame() (byte, []byte, error) {
+	if _, err := io.ReadFull(c.r, c.rheader[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(c.rheader[:4])
+	if n == 0 || n-1 > MaxPayload+maxOverhead {
+		return 0, nil, ErrFrame
+	}
+	body := make([]byte, n-1)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return c.rheader[4], body, nil
+}
+
+// open returns the payload of the body of a frame read.
+func (c *Conn) open(flags byte, body []byte) ([]byte, error) {
+	if flags&flagHandshake != 0 || (flags&flagSealed != 0) != (c.aead != nil) {
+		return nil, ErrFrame
+	}
+	wire := len(body)
+	if c.aead != nil {
+		var err error
+		body, err = c.aead.Open(body[:0], c.nonce(c.peer(), c.rseq), body, []byte{flags})
+		if err != nil {
+			return nil, ErrFrame
+		}
+	}
+	c.rseq++
+	payload, err := decompress(flags, body)
+	if err != nil {
+		return nil, err
+	}
+	record(flags, "in", len(payload), wire)
+	return payload, nil
+}
+
+// Write writes p as frames of at most MaxPayload bytes of payload.
+func (c *Conn) Write(p []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	written := 0
+	for len(p) > 0 {
+		payload := p
+		if len(payload) > MaxPayload {
+			payload = payload[:MaxPayload]
+		}
+		if err := c.writeFrame(payload); err != nil {
+			return written, err
+		}
+		written += len(payload)
+		p = p[len(payload):]
+	}
+	return written, nil
+}
+
+// writeFrame compresses and seals a payload, and writes its frame.
+func (c *Conn) writeFrame(payload []byte) error {
+	var flags byte
+	body := payload
+	if len(payload) >= c.opts.Threshold {
+		flags, body = compress(c.opts.Compression, payload)
+	}
+	if c.aead != nil {
+		flags |= flagSealed
+		body = c.aead.Seal(nil, c.nonce(c.dir, c.wseq), body, []byte{flags})
+	}
+	c.wseq++
+	record(flags, "out", len(payload), len(body))
+	return c.writeRaw(flags, body)
+}
+
+// writeRaw writes a frame.
+func (c *Conn) writeRaw(flags byte, body []byte) error {
+	n := 5 + len(body)
+	if cap(c.wbuf) < n {
+		c.wbuf = make([]byte, n)
+	}
+	buf := c.wbuf[:n]
+	binary.BigEndian.PutUint32(buf, uint32(1+len(body)))
+	buf[4] = flags
+	copy(buf[5:], body)
+	_, err := c.Conn.Write(buf)
+	return err
+}
+
+// nonce returns the nonce of the frame seq of a direction.
+func (c *Conn) nonce(dir byte, seq uint64) []byte {
+	nonce := make([]byte, c.aead.NonceSize())
+	nonce[0] = dir
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], seq)
+	return nonce
+}
+
+// peer returns the direction of the frames read.
+func (c *Conn) peer() byte {
+	if c.dir == fromClient {
+		return fromServer
+	}
+	return fromClient
+}
@@ -0,0 +1,198 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+var key = bytes.Repeat([]byte{7}, 32)
+
+// pair returns the client and server sides of a connection, handshaken.
+func pair(t *testing.T, client, server Options) (*Conn, *Conn) {
+	t.Helper()
+	cc, sc := net.Pipe()
+	t.Cleanup(func() {
+		cc.Close()
+		sc.Close()
+	})
+	done := make(chan error, 1)
+	var s *Conn
+	go func() {
+		var err error
+		s, err = Server(sc, server, func(token string) ([]byte, error) {
+			if token != "token" {
+				return nil, errors.New("bad token")
+			}
+			return key, nil
+		})
+		done <- err
+	}()
+	c, err := Client(cc, client, "token", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	return c, s
+}
+
+// roundTrip writes p on a side and reads it from the other.
+func roundTrip(t *testing.T, w, r *Conn, p []byte) {
+	t.Helper()
+	go w.Write(p)
+	got := make([]byte, len(p))
+	if _, err := io.ReadFull(r, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, p) {
+		t.Fatalf("read %d bytes, not the %d written", len(got), len(p))
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	small := []byte("hello")
+	large := []byte(strings.Repeat("compressible payload ", 100))
+	huge := bytes.Repeat([]byte{1, 2, 3}, MaxPayload) // split in frames
+	for _, tc := range []struct {
+		name           string
+		client, server Options
+	}{
+		{"plain", Options{}, Options{}},
+		{"zlib", Options{Compression: "zlib"}, Options{Compression: "zlib"}},
+		{"snappy/zlib", Options{Compression: "snappy"}, Options{Compression: "zlib", Encrypt: true}},
+		{"encrypted", Options{}, Options{Encrypt: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, s := pair(t, tc.client, tc.server)
+			if c.Encrypted() != tc.server.Encrypt || s.Encrypted() != tc.server.Encrypt {
+				t.Fatalf("encrypted: client %v, server %v, want %v", c.Encrypted(), s.Encrypted(), tc.server.Encrypt)
+			}
+			for _, p := range [][]byte{small, large, huge} {
+				roundTrip(t, c, s, p)
+				roundTrip(t, s, c, p)
+			}
+		})
+	}
+}
+
+func TestCompress(t *testing.T) {
+	large := []byte(strings.Repeat("compressible payload ", 100))
+	for _, name := range []string{"zlib", "snappy"} {
+		flags, body := compress(name, large)
+		if flags == 0 || len(body) >= len(large) {
+			t.Fatalf("%s: not compressed", name)
+		}
+		payload, err := decompress(flags, body)
+		if err != nil || !bytes.Equal(payload, large) {
+			t.Fatalf("%s: decompressed %d bytes, %v", name, len(payload), err)
+		}
+	}
+	if flags, _ := compress("zlib", []byte{1, 2, 3}); flags != 0 {
+		t.Fatal("incompressible payload compressed")
+	}
+}
+
+func TestRejected(t *testing.T) {
+	cc, sc := net.Pipe()
+	defer cc.Close()
+	go func() {
+		Server(sc, Options{}, func(string) ([]byte, error) { return nil, errors.New("bad token") })
+		sc.Close()
+	}()
+	if _, err := Client(cc, Options{}, "forged", nil); !errors.Is(err, ErrHandshake) {
+		t.Fatalf("got %v, want %v", err, ErrHandshake)
+	}
+}
+
+// TestTampered checks that an encrypted connection fails on a frame altered,
+// replayed or reflected.
+func TestTampered(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		tamper func(frame []byte) [][]byte
+	}{
+		{"altered", func(f []byte) [][]byte { f[len(f)-1] ^= 1; return [][]byte{f} }},
+		{"flags", func(f []byte) [][]byte { f[4] |= flagZlib; return [][]byte{f} }},
+		{"replayed", func(f []byte) [][]byte { return [][]byte{f, f} }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cc, sc := net.Pipe()
+			defer cc.Close()
+			defer sc.Close()
+			go Client(cc, Options{}, "token", key)
+			var s *Conn
+			done := make(chan error)
+			go func() {
+				var err error
+				s, err = Server(sc, Options{Encrypt: true}, func(string) ([]byte, error) { return key, nil })
+				done <- err
+			}()
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+			// Seal a frame as the client, then tamper with it on the wire.
+			c := newConn(nil, Options{Threshold: 512}, fromClient)
+			c.aead = s.aead
+			var wire bytes.Buffer
+			c.Conn = writerConn{w: &wire}
+			c.writeFrame([]byte("move"))
+			frames := tc.tamper(wire.Bytes())
+			go func() {
+				for _, f := range frames {
+					cc.Write(f)
+				}
+			}()
+			buf := make([]byte, 16)
+			var err error
+			for range frames {
+				if _, err = s.Read(buf); err != nil {
+					break
+				}
+			}
+			if !errors.Is(err, ErrFrame) {
+				t.Fatalf("got %v, want %v", err, ErrFrame)
+			}
+		})
+	}
+
+	// Reflected: a frame of the server is not accepted back by the server.
+	c, s := pair(t, Options{}, Options{Encrypt: true})
+	var wire bytes.Buffer
+	conn := s.Conn
+	s.Conn = writerConn{w: &wire}
+	s.Write([]byte("echo"))
+	s.Conn = conn
+	go c.Conn.Write(wire.Bytes())
+	if _, err := s.Read(make([]byte, 16)); !errors.Is(err, ErrFrame) {
+		t.Fatalf("reflected: got %v, want %v", err, ErrFrame)
+	}
+}
+
+// TestOtherConnection checks that a frame of a connection isn't accepted on
+// another connection of the same session, whose frames are numbered alike.
+func TestOtherConnection(t *testing.T) {
+	c1, _ := pair(t, Options{}, Options{Encrypt: true})
+	c2, s2 := pair(t, Options{}, Options{Encrypt: true})
+	var wire bytes.Buffer
+	c1.Conn = writerConn{w: &wire}
+	c1.Write([]byte("move"))
+	go c2.Conn.Write(wire.Bytes())
+	if _, err := s2.Read(make([]byte, 16)); !errors.Is(err, ErrFrame) {
+		t.Fatalf("got %v, want %v", err, ErrFrame)
+	}
+}
+
+// writerConn is a net.Conn writing to a writer.
+type writerConn struct {
+	net.Conn
+	w io.Writer
+}
+
+func (c writerConn) Write(p []byte) (int, error) {
+	return c.w.Write(p)
+}
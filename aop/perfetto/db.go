@@ -20,6 +20,8 @@ import (
 	"time"
 
 	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel/codes"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
@@ -67,11 +69,15 @@ type replicaCacheKey struct {
 // [1] https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU/preview#
 // [2] https://ui.perfetto.dev/
 type DB struct {
-	// Trace data is stored in a sqlite DB spread across three tables:
-	// (1) traces:           trace data in a Perfetto-UI-compattible JSON format
-	// (2) replica_num:      map from colocation group replica id to a replica
+	// Trace data is stored in a sqlite DB spread across four tables:
+	// (1) spans:            trace data in a Perfetto-UI-compattible JSON format,
+	//                       along with the span fields that traces are
+	//                       searched by (see QueryTraces)
+	// (2) traces:           trace data written by older versions, in the same
+	//                       format but without the span fields
+	// (3) replica_num:      map from colocation group replica id to a replica
 	//                       number
-	// (3) next_replica_num: the next replica number to use for a given
+	// (4) next_replica_num: the next replica number to use for a given
 	//                       colocation group
 	fname string
 	db    *sql.DB
@@ -97,7 +103,7 @@ func open(ctx context.Context, fname string) (*DB, error) {
 	//   https://www.sqlite.org/pragma.html#pragma_locking_mode
 	//   https://www.sqlite.org/pragma.html#pragma_busy_timeout
 	const params = "?_locking_mode=NORMAL&_busy_timeout=10000"
-	db, err := sql.Open("sqlite", fname+params)
+	db, err := sql.Open("sqlite3", fname+params)
 	if err != nil {
 		return nil, fmt.Errorf("open perfetto db %q: %w", fname, err)
 	}
@@ -117,13 +123,26 @@ func open(ctx context.Context, fname string) (*DB, error) {
 	}
 
 	const initTables = `
--- Trace data.
+-- Trace data written by older versions, one row per batch of spans.
 CREATE TABLE IF NOT EXISTS traces (
 	app TEXT NOT NULL,
 	version TEXT NOT NULL,
 	events TEXT NOT NULL
 );
 
+-- Trace data, one row per span.
+CREATE TABLE IF NOT EXISTS spans (
+	app TEXT NOT NULL,
+	version TEXT NOT NULL,
+	trace_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	start_micros INTEGER NOT NULL,
+	end_micros INTEGER NOT NULL,
+	error INTEGER NOT NULL,
+	events TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS spans_by_trace ON spans(app, version, trace_id);
+
 -- Map from a group replica id to a replica number.
 CREATE TABLE IF NOT EXISTS replica_num (
 	app TEXT NOT NULL,
@@ -157,20 +176,39 @@ func (d *DB) Close() error {
 
 // Store stores the given traces in the database.
 func (d *DB) Store(ctx context.Context, app, version string, spans []sdktrace.ReadOnlySpan) error {
-	encoded, err := d.encodeSpans(ctx, app, version, spans)
+	// Encode the spans before starting the transaction, as encoding may
+	// itself access the database (see getReplicaNumber).
+	encoded := make([][]byte, len(spans))
+	for i, span := range spans {
+		var b bytes.Buffer
+		if err := d.encodeSpan(ctx, app, version, span, &b); err != nil {
+			return err
+		}
+		encoded[i] = b.Bytes()
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	return d.storeEncoded(ctx, app, version, encoded)
-}
-
-func (d *DB) storeEncoded(ctx context.Context, app, version string, encoded []byte) error {
+	defer tx.Rollback() //nolint:errcheck // rollback errors can be ignored
 	const stmt = `
-		INSERT INTO traces(app, version, events)
-		VALUES (?,?,?);
+		INSERT INTO spans(app, version, trace_id, name, start_micros, end_micros, error, events)
+		VALUES (?,?,?,?,?,?,?,?);
 	`
-	_, err := d.execDB(ctx, stmt, app, version, string(encoded))
-	return err
+	for i, span := range spans {
+		failed := 0
+		if span.Status().Code == codes.Error {
+			failed = 1
+		}
+		if _, err := tx.ExecContext(ctx, stmt, app, version,
+			span.SpanContext().TraceID().String(), span.Name(),
+			span.StartTime().UnixMicro(), span.EndTime().UnixMicro(), failed,
+			string(encoded[i])); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 func (d *DB) encodeSpans(ctx context.Context, app, version string, spans []sdktrace.ReadOnlySpan) ([]byte, error) {
@@ -414,15 +452,20 @@ func (d *DB) getNextReplicaNumber(ctx context.Context, tx *sql.Tx, app, version,
 	return replicaNum, nil
 }
 
-// fetch returns all trace events for the given application version.
-func (d *DB) fetch(ctx context.Context, app, version string) ([]byte, error) {
+// fetch returns all trace events for the given application version. If
+// traceID is not empty, only the events of the given trace are returned.
+func (d *DB) fetch(ctx context.Context, app, version, traceID string) ([]byte, error) {
 	const query = `
 		SELECT GROUP_CONCAT(events, ',')
-		FROM traces
+		FROM (
+			SELECT app, version, '' AS trace_id, events FROM traces
+			UNION ALL
+			SELECT app, version, trace_id, events FROM spans
+		)
 		WHERE
-		(app=? OR ?="") AND (version=? OR ?="");
+		(app=? OR ?="") AND (version=? OR ?="") AND (trace_id=? OR ?="");
 	`
-	rows, err := d.queryDB(ctx, query, app, app, version, version)
+	rows, err := d.queryDB(ctx, query, app, app, version, version, traceID, traceID)
 	if err != nil {
 		return nil, err
 	}
@@ -436,6 +479,101 @@ func (d *DB) fetch(ctx context.Context, app, version string) ([]byte, error) {
 	return traces, nil
 }
 
+// A TraceQuery selects the traces of an application version (see
+// QueryTraces).
+type TraceQuery struct {
+	App     string // application name
+	Version string // application version
+
+	// Component, if not empty, selects the traces with a span whose name
+	// contains Component (e.g., "Server" or "todo.Server").
+	Component string
+
+	// Method, if not empty, selects the traces with a span whose name is
+	// Method, or ends with "." followed by Method (e.g., "Add" matches
+	// "todo.Server.Add"). If both Component and Method are provided, the
+	// same span must match both.
+	Method string
+
+	// MinDuration, if positive, selects the traces that last at least
+	// MinDuration, from the start of their first span to the end of their
+	// last span.
+	MinDuration time.Duration
+
+	// OnlyErrors, if true, selects the traces with a failed span.
+	OnlyErrors bool
+
+	// Limit is the maximum number of traces returned. Defaults to 100.
+	Limit int
+}
+
+// A TraceSummary summarizes a trace returned by QueryTraces.
+type TraceSummary struct {
+	TraceID  string        // trace id, in hex
+	Root     string        // name of the first span of the trace
+	Start    time.Time     // start time of the first span of the trace
+	Duration time.Duration // from the start of the first span to the end of the last one
+	Spans    int           // number of spans
+	Error    bool          // whether any span failed
+}
+
+// QueryTraces returns the most recent traces that match the provided query,
+// most recent first. Traces written by older versions, which didn't record
+// trace ids, are never returned.
+func (d *DB) QueryTraces(ctx context.Context, q TraceQuery) ([]TraceSummary, error) {
+	if q.Limit <= 0 {
+		q.Limit = 100
+	}
+	onlyErrors := 0
+	if q.OnlyErrors {
+		onlyErrors = 1
+	}
+	const query = `
+		SELECT
+			trace_id,
+			(SELECT name FROM spans AS first
+			 WHERE first.app=s.app AND first.version=s.version AND first.trace_id=s.trace_id
+			 ORDER BY first.start_micros LIMIT 1),
+			MIN(start_micros),
+			MAX(end_micros) - MIN(start_micros),
+			COUNT(*),
+			MAX(error)
+		FROM spans AS s
+		WHERE app=? AND version=?
+		GROUP BY app, version, trace_id
+		HAVING
+			MAX(end_micros) - MIN(start_micros) >= ?
+			AND MAX(error) >= ?
+			AND SUM(
+				(?="" OR INSTR(name, ?) > 0) AND
+				(?="" OR name=? OR SUBSTR(name, -LENGTH(?)-1)='.' || ?)
+			) > 0
+		ORDER BY MIN(start_micros) DESC
+		LIMIT ?;
+	`
+	rows, err := d.queryDB(ctx, query, q.App, q.Version,
+		q.MinDuration.Microseconds(), onlyErrors,
+		q.Component, q.Component,
+		q.Method, q.Method, q.Method, q.Method,
+		q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var traces []TraceSummary
+	for rows.Next() {
+		var t TraceSummary
+		var start, duration int64
+		if err := rows.Scan(&t.TraceID, &t.Root, &start, &duration, &t.Spans, &t.Error); err != nil {
+			return nil, err
+		}
+		t.Start = time.UnixMicro(start)
+		t.Duration = time.Duration(duration) * time.Microsecond
+		traces = append(traces, t)
+	}
+	return traces, rows.Err()
+}
+
 func (d *DB) queryDB(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
 	// Keep retrying as long as we are getting the "locked" error.
 	for r := retry.Begin(); r.Continue(ctx); {
@@ -481,12 +619,13 @@ func (d *DB) Serve(ctx context.Context) {
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		app := r.URL.Query().Get("app")
 		version := r.URL.Query().Get("version")
+		traceID := r.URL.Query().Get("trace")
 
 		// Set access control according to:
 		//   https://perfetto.dev/docs/visualization/deep-linking-to-perfetto-ui.
 		w.Header().Set("Access-Control-Allow-Origin", "https://ui.perfetto.dev")
 
-		data, err := d.fetch(r.Context(), app, version)
+		data, err := d.fetch(r.Context(), app, version, traceID)
 		if err != nil || len(data) == 0 {
 			w.WriteHeader(http.StatusNotFound)
 			return
@@ -527,10 +666,9 @@ func (d *DB) Serve(ctx context.Context) {
 
 // isLocked returns whether the error is a "database is locked" error.
 func isLocked(err error) bool {
-	//sqlError := &sqlite.Error{}
-	//ok := errors.As(err, &sqlError)
-	//return ok && (sqlError.Code() == sqlite3.SQLITE_BUSY || sqlError.Code() == sqlite3.SQLITE_LOCKED)
-	return true
+	var sqlError sqlite3.Error
+	ok := errors.As(err, &sqlError)
+	return ok && (sqlError.Code == sqlite3.ErrBusy || sqlError.Code == sqlite3.ErrLocked)
 }
 
 func fp(name string) int {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
@@ -98,7 +99,7 @@ func TestStoreFetch(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			actualEnc, err := db.fetch(ctx, tc.app, tc.version)
+			actualEnc, err := db.fetch(ctx, tc.app, tc.version, "")
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -109,6 +110,97 @@ func TestStoreFetch(t *testing.T) {
 	}
 }
 
+// makeTraceSpan creates a test span of the given trace number with the given
+// information.
+func makeTraceSpan(traceNum byte, name string, start, dur time.Duration, failed bool) sdktrace.ReadOnlySpan {
+	var tid trace.TraceID
+	tid[15] = traceNum
+	stub := tracetest.SpanStub{
+		Name:        name,
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid}),
+		StartTime:   now.Add(start),
+		EndTime:     now.Add(start + dur),
+	}
+	if failed {
+		stub.Status = sdktrace.Status{Code: codes.Error}
+	}
+	return stub.Snapshot()
+}
+
+func TestQueryTraces(t *testing.T) {
+	// Test Plan: store the spans of a few traces, and check that the traces
+	// returned by QueryTraces for various queries are as expected.
+	ctx := context.Background()
+	fname := filepath.Join(t.TempDir(), "tracedb.db_test.db")
+	db, err := open(ctx, fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Trace 1 is slow, trace 2 failed, and trace 3 is fast. The traces start
+	// one after another.
+	storeSpans(ctx, t, db, "app", "v1",
+		makeTraceSpan(1, "todo.Server.Add", 0, 2*time.Second, false),
+		makeTraceSpan(1, "todo.Store.Put", time.Millisecond, time.Second, false),
+		makeTraceSpan(2, "todo.Server.Get", time.Second, time.Millisecond, false),
+		makeTraceSpan(2, "todo.Store.Get", time.Second, time.Millisecond, true),
+		makeTraceSpan(3, "todo.Server.Get", 2*time.Second, time.Millisecond, false),
+	)
+	storeSpans(ctx, t, db, "app", "v2", makeTraceSpan(4, "todo.Server.Add", 0, time.Second, false))
+
+	for _, tc := range []struct {
+		help   string
+		query  TraceQuery
+		expect []byte // the expected traces, most recent first
+	}{
+		{"all", TraceQuery{}, []byte{3, 2, 1}},
+		{"component", TraceQuery{Component: "Store"}, []byte{2, 1}},
+		{"method", TraceQuery{Method: "Get"}, []byte{3, 2}},
+		{"component and method", TraceQuery{Component: "Store", Method: "Get"}, []byte{2}},
+		{"no method prefix", TraceQuery{Method: "et"}, nil},
+		{"min duration", TraceQuery{MinDuration: time.Second}, []byte{1}},
+		{"errors", TraceQuery{OnlyErrors: true}, []byte{2}},
+		{"limit", TraceQuery{Limit: 1}, []byte{3}},
+	} {
+		t.Run(tc.help, func(t *testing.T) {
+			tc.query.App, tc.query.Version = "app", "v1"
+			traces, err := db.QueryTraces(ctx, tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []byte
+			for _, summary := range traces {
+				tid, err := trace.TraceIDFromHex(summary.TraceID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, tid[15])
+			}
+			if diff := cmp.Diff(tc.expect, got); diff != "" {
+				t.Fatalf("unexpected traces (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// Check the summary of a trace.
+	traces, err := db.QueryTraces(ctx, TraceQuery{App: "app", Version: "v1", OnlyErrors: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TraceSummary{{
+		TraceID:  "00000000000000000000000000000002",
+		Root:     "todo.Server.Get",
+		Start:    time.UnixMicro(now.Add(time.Second).UnixMicro()),
+		Duration: time.Millisecond,
+		Spans:    2,
+		Error:    true,
+	}}
+	if diff := cmp.Diff(want, traces); diff != "" {
+		t.Fatalf("unexpected summary (-want +got):\n%s", diff)
+	}
+}
+
 func TestReplicaNum(t *testing.T) {
 	// Test Plan: Retrieve replica numbers for a number of different
 	// application versions and their colocation groups. Ensure that different
@@ -33,6 +33,10 @@ type CallArgs struct {
 	URLPath string
 	Request proto.Message
 	Reply   proto.Message
+
+	// Encoding, if not empty, is the encoding with which the request is
+	// compressed (e.g., GzipEncoding).
+	Encoding string
 }
 
 // Call invokes an HTTP method on the given address/path combo, passing it a
@@ -69,6 +73,9 @@ func Call(ctx context.Context, args CallArgs) error {
 		if in, err = toWire(args.Request); err != nil {
 			return fmt.Errorf("bad request for %s: %w", url, err)
 		}
+		if in, err = encode(args.Encoding, in); err != nil {
+			return fmt.Errorf("bad request for %s: %w", url, err)
+		}
 	}
 	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(in))
 	if err != nil {
@@ -77,6 +84,9 @@ func Call(ctx context.Context, args CallArgs) error {
 	if args.Host != "" {
 		req.Host = args.Host
 	}
+	if args.Request != nil && args.Encoding != "" {
+		req.Header.Set("Content-Encoding", args.Encoding)
+	}
 
 	out, err = args.Client.Do(req)
 	if err != nil {
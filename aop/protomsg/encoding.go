@@ -0,0 +1,91 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protomsg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content encodings of request bodies (see CallArgs.Encoding). Handlers
+// decode request bodies with any of these encodings, as indicated by the
+// Content-Encoding header of the request.
+const (
+	GzipEncoding = "gzip"
+	ZstdEncoding = "zstd"
+)
+
+// ValidEncoding returns whether the provided encoding is a valid
+// CallArgs.Encoding.
+func ValidEncoding(encoding string) bool {
+	switch encoding {
+	case "", GzipEncoding, ZstdEncoding:
+		return true
+	default:
+		return false
+	}
+}
+
+// Shared zstd encoder and decoder. Their EncodeAll and DecodeAll methods are
+// safe for concurrent use.
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// encode compresses data with the provided encoding.
+func encode(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case "":
+		return data, nil
+	case GzipEncoding:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case ZstdEncoding:
+		return zstdEncoder.EncodeAll(data, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
+
+// decode decompresses data compressed with the provided encoding.
+func decode(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case "", "identity":
+		return data, nil
+	case GzipEncoding:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case ZstdEncoding:
+		return zstdDecoder.DecodeAll(data, nil)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
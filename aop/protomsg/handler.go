@@ -62,13 +62,14 @@ type errorLabels struct {
 	// HTTP request URL path (e.g., "/manager/start_process").
 	Path string
 
-	// The type of error. An HTTP request can fail in five places:
+	// The type of error. An HTTP request can fail in six places:
 	//
 	//   1. Reading the request.
-	//   2. Unmarshaling the request.
-	//   3. Executing the request.
-	//   4. Marshaling the response.
-	//   5. Writing the response.
+	//   2. Decoding (i.e., decompressing) the request.
+	//   3. Unmarshaling the request.
+	//   4. Executing the request.
+	//   5. Marshaling the response.
+	//   6. Writing the response.
 	Error string
 }
 
@@ -211,6 +212,12 @@ func fromHTTP(w http.ResponseWriter, r *http.Request, msgs ...proto.Message) err
 		return errors.New(msg)
 	}
 	httpRequestBytesReceived.Get(handlerLabels{r.URL.Path}).Put(float64(len(in)))
+	if in, err = decode(r.Header.Get("Content-Encoding"), in); err != nil {
+		httpRequestErrorCounts.Get(errorLabels{r.URL.Path, "decode request"}).Add(1.0)
+		msg := fmt.Sprintf("cannot decode request body: %v", err)
+		http.Error(w, msg, http.StatusUnsupportedMediaType)
+		return errors.New(msg)
+	}
 	if err := fromWire(in, msgs...); err != nil {
 		httpRequestErrorCounts.Get(errorLabels{r.URL.Path, "unmarshal request"}).Add(1.0)
 		msg := fmt.Sprintf("cannot unmarshal request protos from %q: %v", in, err)
@@ -15,11 +15,16 @@
 package protomsg
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"greatestworks/aop/protos"
 )
 
 // discardingLogger implements the logtype.Logger interface. We can't use a
@@ -58,3 +63,50 @@ func TestPanicHandler(t *testing.T) {
 		t.Fatalf("message does not contain %q:\n%s", msg, s)
 	}
 }
+
+func TestEncodedCall(t *testing.T) {
+	// Echo the request.
+	server := httptest.NewServer(HandlerFunc(discardingLogger{}, func(_ context.Context, req *protos.MetricUpdate) (*protos.MetricUpdate, error) {
+		return req, nil
+	}))
+	defer server.Close()
+
+	for _, encoding := range []string{"", GzipEncoding, ZstdEncoding} {
+		t.Run(encoding, func(t *testing.T) {
+			reply := &protos.MetricUpdate{}
+			if err := Call(context.Background(), CallArgs{
+				Client:   server.Client(),
+				Addr:     server.URL,
+				Request:  msg3,
+				Reply:    reply,
+				Encoding: encoding,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(msg3, reply, protocmp.Transform()); diff != "" {
+				t.Fatalf("reply (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnknownEncoding(t *testing.T) {
+	server := httptest.NewServer(HandlerDo(discardingLogger{}, func(context.Context, *protos.MetricUpdate) error {
+		return nil
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("POST", server.URL, strings.NewReader("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Encoding", "br")
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusUnsupportedMediaType; got != want {
+		t.Fatalf("status code: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protomsg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logtype"
+)
+
+// Streaming calls exchange many messages over a single HTTP request, instead
+// of one message per request. The body of the request (resp. response) is a
+// stream of length-prefixed messages, written with Write and read with Read,
+// and the end of the body marks the end of the stream. A streaming call is
+// half-duplex: the client sends all of its messages (e.g., log entries)
+// before the server sends any of its messages (e.g., routing info updates).
+// Errors returned by the server after it sent some messages are carried in
+// the streamErrorTrailer HTTP trailer.
+
+const (
+	// streamContentType is the content type of the body of streaming calls.
+	streamContentType = "application/x-protomsg-stream"
+
+	// streamErrorTrailer is the HTTP trailer that carries the error that
+	// ended a stream of messages, if any.
+	streamErrorTrailer = "Protomsg-Stream-Error"
+)
+
+// StreamArgs holds arguments for the CallStream method.
+type StreamArgs struct {
+	Client  *http.Client
+	Host    string
+	Addr    string
+	URLPath string
+}
+
+// A ClientStream is the client side of a streaming call, started by
+// CallStream. Send the requests with Send and CloseSend, then receive the
+// replies with Recv. Close the stream when done with it.
+type ClientStream struct {
+	url    string
+	cancel context.CancelFunc
+	pw     *io.PipeWriter // writes the request body
+	done   chan struct{}  // closed when resp and err are set
+
+	resp *http.Response // the response, if err is nil
+	err  error          // the error of the HTTP request
+
+	sendMu sync.Mutex // guards pw
+}
+
+// CallStream starts a streaming call to the HTTP method on the given
+// address/path combo. See ClientStream.
+func CallStream(ctx context.Context, args StreamArgs) (*ClientStream, error) {
+	url := fmt.Sprintf("%s%s", args.Addr, args.URLPath)
+	ctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+	req, err := http.NewRequestWithContext(ctx, "POST", url, pr)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("cannot create an HTTP request: %w", err)
+	}
+	if args.Host != "" {
+		req.Host = args.Host
+	}
+	req.Header.Set("Content-Type", streamContentType)
+
+	s := &ClientStream{url: url, cancel: cancel, pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		// Do returns once the server starts replying, which happens after it
+		// received all requests (see HandlerStream).
+		resp, err := args.Client.Do(req)
+		if err != nil {
+			// Unblock senders.
+			pr.CloseWithError(err)
+			s.err = err
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			pr.CloseWithError(fmt.Errorf("HTTP status %d", resp.StatusCode))
+			b, err := io.ReadAll(resp.Body)
+			if err != nil || len(b) == 0 {
+				s.err = fmt.Errorf("cannot call %q: HTTP status %d", url, resp.StatusCode)
+			} else {
+				s.err = fmt.Errorf("cannot call %q: HTTP status %d: %s", url, resp.StatusCode, b)
+			}
+			return
+		}
+		s.resp = resp
+	}()
+	return s, nil
+}
+
+// Send sends a request to the server.
+func (s *ClientStream) Send(msg proto.Message) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if err := Write(s.pw, msg); err != nil {
+		return fmt.Errorf("send to %s: %w", s.url, err)
+	}
+	return nil
+}
+
+// CloseSend tells the server that there are no more requests.
+func (s *ClientStream) CloseSend() error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.pw.Close()
+}
+
+// Recv waits for the next reply of the server, and stores it in msg. It
+// returns io.EOF once the server is done sending replies, or the error that
+// made the server stop. Call CloseSend before calling Recv.
+func (s *ClientStream) Recv(msg proto.Message) error {
+	<-s.done
+	if s.err != nil {
+		return s.err
+	}
+	err := Read(s.resp.Body, msg)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, io.EOF) {
+		return fmt.Errorf("bad response from %s: %w", s.url, err)
+	}
+	// The trailers are available once the body has been fully read.
+	if msg := s.resp.Trailer.Get(streamErrorTrailer); msg != "" {
+		return fmt.Errorf("stream from %s: %s", s.url, msg)
+	}
+	return io.EOF
+}
+
+// Close ends the call, releasing its resources.
+func (s *ClientStream) Close() error {
+	s.cancel()
+	s.pw.Close()
+	<-s.done
+	if s.resp != nil {
+		return s.resp.Body.Close()
+	}
+	return nil
+}
+
+// HandlerStream converts a streaming protobuf based handler into an
+// http.HandlerFunc. The handler receives the requests of the client with
+// recv, which returns io.EOF once the client is done sending requests, and
+// sends replies with send. Since streaming calls are half-duplex, the handler
+// must not call recv after it called send. If the handler returns an error
+// before it sends any reply, the error is returned in the HTTP response, like
+// HandlerFunc does; otherwise, the error is returned to the client's
+// ClientStream.Recv. The context passed to the handler is the HTTP request's
+// context.
+func HandlerStream[I, O any, IP ProtoPointer[I], OP ProtoPointer[O]](logger logtype.Logger, handler func(ctx context.Context, recv func() (*I, error), send func(*O) error) error) http.HandlerFunc {
+	f := func(w http.ResponseWriter, r *http.Request) {
+		recv := func() (*I, error) {
+			var in I
+			if err := Read(r.Body, IP(&in)); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil, io.EOF
+				}
+				httpRequestErrorCounts.Get(errorLabels{r.URL.Path, "read request"}).Add(1.0)
+				return nil, err
+			}
+			return &in, nil
+		}
+
+		started := false // whether the response headers have been written
+		send := func(out *O) error {
+			if !started {
+				// Declare the trailer before writing the headers.
+				w.Header().Set("Trailer", streamErrorTrailer)
+				w.Header().Set("Content-Type", streamContentType)
+				w.WriteHeader(http.StatusOK)
+				started = true
+			}
+			if err := Write(w, OP(out)); err != nil {
+				httpRequestErrorCounts.Get(errorLabels{r.URL.Path, "write response"}).Add(1.0)
+				return err
+			}
+			// Push the reply to the client right away.
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+			return nil
+		}
+
+		err := handler(r.Context(), recv, send)
+		if err == nil {
+			return
+		}
+		httpRequestErrorCounts.Get(errorLabels{r.URL.Path, "execute request"}).Add(1.0)
+		logger.Error("handle http stream", err, "method", r.Method, "url", r.URL)
+		if !started {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// Header values can't span multiple lines.
+		w.Header().Set(streamErrorTrailer, strings.ReplaceAll(err.Error(), "\n", " "))
+	}
+	return metricHandler(panicHandler(logger, f))
+}
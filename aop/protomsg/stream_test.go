@@ -0,0 +1,134 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protomsg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"greatestworks/aop/protos"
+)
+
+// streamServer returns a server that receives log entries until the client is
+// done, then replies with the received entries in reverse order. If fail is
+// not zero, the server fails after sending fail-1 replies.
+func streamServer(t *testing.T, fail int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(HandlerStream(discardingLogger{}, func(_ context.Context, recv func() (*protos.LogEntry, error), send func(*protos.LogEntry) error) error {
+		var entries []*protos.LogEntry
+		for {
+			entry, err := recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		for i := len(entries) - 1; i >= 0; i-- {
+			if fail > 0 && len(entries)-1-i == fail-1 {
+				return fmt.Errorf("failed after %d replies", fail-1)
+			}
+			if err := send(entries[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// callStream sends the provided messages to the server, and returns the
+// replies, along with the error that ended the replies.
+func callStream(t *testing.T, server *httptest.Server, msgs ...string) ([]string, error) {
+	t.Helper()
+	s, err := CallStream(context.Background(), StreamArgs{Client: server.Client(), Addr: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, msg := range msgs {
+		if err := s.Send(&protos.LogEntry{Msg: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for {
+		entry := &protos.LogEntry{}
+		if err := s.Recv(entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return got, err
+		}
+		got = append(got, entry.Msg)
+	}
+}
+
+func TestStream(t *testing.T) {
+	server := streamServer(t, 0)
+	for _, msgs := range [][]string{
+		nil,
+		{"a"},
+		{"a", "b", "c"},
+	} {
+		got, err := callStream(t, server, msgs...)
+		if err != nil {
+			t.Fatalf("%v: %v", msgs, err)
+		}
+		var want []string
+		for i := len(msgs) - 1; i >= 0; i-- {
+			want = append(want, msgs[i])
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%v: replies (-want +got):\n%s", msgs, diff)
+		}
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		fail int
+		want []string
+	}{
+		// Errors before the first reply are returned in the HTTP response.
+		{"BeforeReplies", 1, nil},
+		// Errors after some replies are returned in a trailer.
+		{"AfterReplies", 3, []string{"c", "b"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := callStream(t, streamServer(t, test.fail), "a", "b", "c")
+			wantErr := fmt.Sprintf("failed after %d replies", test.fail-1)
+			if err == nil || !strings.Contains(err.Error(), wantErr) {
+				t.Fatalf("got error %v, want %q", err, wantErr)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("replies (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
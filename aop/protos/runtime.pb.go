@@ -41,6 +41,9 @@ const (
 	ProfileType_Unspecified ProfileType = 0
 	ProfileType_Heap        ProfileType = 1
 	ProfileType_CPU         ProfileType = 2
+	ProfileType_Goroutine   ProfileType = 3
+	ProfileType_Block       ProfileType = 4
+	ProfileType_Mutex       ProfileType = 5
 )
 
 // Enum value maps for ProfileType.
@@ -49,11 +52,17 @@ var (
 		0: "Unspecified",
 		1: "Heap",
 		2: "CPU",
+		3: "Goroutine",
+		4: "Block",
+		5: "Mutex",
 	}
 	ProfileType_value = map[string]int32{
 		"Unspecified": 0,
 		"Heap":        1,
 		"CPU":         2,
+		"Goroutine":   3,
+		"Block":       4,
+		"Mutex":       5,
 	}
 )
 
@@ -295,7 +304,7 @@ func (x Span_Status_Code) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use Span_Status_Code.Descriptor instead.
 func (Span_Status_Code) EnumDescriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{29, 2, 0}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{33, 2, 0}
 }
 
 // Type describes the type of the value.
@@ -363,7 +372,7 @@ func (x Attribute_Value_Type) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use Attribute_Value_Type.Descriptor instead.
 func (Attribute_Value_Type) EnumDescriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{31, 0, 0}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{35, 0, 0}
 }
 
 // EnvelopeMsg holds a message sent by the envelope to the managed weavelet.
@@ -396,6 +405,14 @@ type EnvelopeMsg struct {
 	ExportListenerReply *ExportListenerReply `protobuf:"bytes,9,opt,name=export_listener_reply,json=exportListenerReply,proto3" json:"export_listener_reply,omitempty"`
 	// A reply to the weavelet with an error status.
 	Error string `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	// A request to the weavelet to apply an updated config.
+	ConfigUpdate *ConfigUpdate `protobuf:"bytes,11,opt,name=config_update,json=configUpdate,proto3" json:"config_update,omitempty"`
+	// A request to the weavelet to shut down gracefully.
+	Drain *Drain `protobuf:"bytes,12,opt,name=drain,proto3" json:"drain,omitempty"`
+	// The pipe protocol versions supported by the envelope, sent along with
+	// weavelet_info, or the version agreed on by the envelope, sent in reply to
+	// the handshake of the weavelet. See PipeHandshake.
+	Handshake *PipeHandshake `protobuf:"bytes,13,opt,name=handshake,proto3" json:"handshake,omitempty"`
 }
 
 func (x *EnvelopeMsg) Reset() {
@@ -500,6 +517,208 @@ func (x *EnvelopeMsg) GetError() string {
 	return ""
 }
 
+func (x *EnvelopeMsg) GetConfigUpdate() *ConfigUpdate {
+	if x != nil {
+		return x.ConfigUpdate
+	}
+	return nil
+}
+
+func (x *EnvelopeMsg) GetDrain() *Drain {
+	if x != nil {
+		return x.Drain
+	}
+	return nil
+}
+
+func (x *EnvelopeMsg) GetHandshake() *PipeHandshake {
+	if x != nil {
+		return x.Handshake
+	}
+	return nil
+}
+
+// PipeHandshake negotiates the version of the protocol spoken over the pipes
+// between an envelope and a weavelet. Versions are:
+//
+//  1. Every message is a length-prefixed EnvelopeMsg or WeaveletMsg.
+//  2. Every message is a frame holding the length of the frame, the id of
+//     the stream the message belongs to (e.g., logs, metrics), and an
+//     EnvelopeMsg or WeaveletMsg. Frames of unknown streams are skipped.
+//
+// The pipes start at version 1. The envelope offers its highest version along
+// with the weavelet info. If the weavelet supports a version above 1, it
+// replies with the highest version supported by both, and speaks that version
+// from then on. The envelope acknowledges with the same version, and speaks it
+// from then on. Old weavelets ignore the offer and never reply, so the pipes
+// stay at version 1.
+type PipeHandshake struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version int32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *PipeHandshake) Reset() {
+	*x = PipeHandshake{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_protos_runtime_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PipeHandshake) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PipeHandshake) ProtoMessage() {}
+
+func (x *PipeHandshake) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_protos_runtime_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PipeHandshake.ProtoReflect.Descriptor instead.
+func (*PipeHandshake) Descriptor() ([]byte, []int) {
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PipeHandshake) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// Drain is a request to a weavelet to shut down gracefully. The weavelet stops
+// accepting new component method calls, waits for the calls in progress to
+// complete (for at most timeout_ns), flushes its buffered telemetry (e.g.,
+// metrics, logs, and traces), and replies. After the reply, the envelope
+// terminates the weavelet.
+type Drain struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Maximum time to wait for the calls in progress, in nanoseconds.
+	TimeoutNs int64 `protobuf:"varint,1,opt,name=timeout_ns,json=timeoutNs,proto3" json:"timeout_ns,omitempty"`
+}
+
+func (x *Drain) Reset() {
+	*x = Drain{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_protos_runtime_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Drain) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Drain) ProtoMessage() {}
+
+func (x *Drain) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_protos_runtime_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Drain.ProtoReflect.Descriptor instead.
+func (*Drain) Descriptor() ([]byte, []int) {
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Drain) GetTimeoutNs() int64 {
+	if x != nil {
+		return x.TimeoutNs
+	}
+	return 0
+}
+
+// ConfigUpdate is an update of the config sections of a running application
+// (see AppConfig.sections). Updates are numbered by generation: an update of
+// generation g contains the sections that changed since generation g-1, or
+// since an older generation if the receiver is known to be further behind.
+type ConfigUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Generation int64             `protobuf:"varint,1,opt,name=generation,proto3" json:"generation,omitempty"`                                                                                    // generation of the updated config
+	Sections   map[string]string `protobuf:"bytes,2,rep,name=sections,proto3" json:"sections,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"` // added or changed sections
+	Removed    []string          `protobuf:"bytes,3,rep,name=removed,proto3" json:"removed,omitempty"`                                                                                           // keys of the removed sections
+}
+
+func (x *ConfigUpdate) Reset() {
+	*x = ConfigUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_protos_runtime_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ConfigUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigUpdate) ProtoMessage() {}
+
+func (x *ConfigUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_protos_runtime_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigUpdate.ProtoReflect.Descriptor instead.
+func (*ConfigUpdate) Descriptor() ([]byte, []int) {
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ConfigUpdate) GetGeneration() int64 {
+	if x != nil {
+		return x.Generation
+	}
+	return 0
+}
+
+func (x *ConfigUpdate) GetSections() map[string]string {
+	if x != nil {
+		return x.Sections
+	}
+	return nil
+}
+
+func (x *ConfigUpdate) GetRemoved() []string {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
 // WeaveletMsg holds a message sent by a weavelet to the envelope that manages it.
 type WeaveletMsg struct {
 	state         protoimpl.MessageState
@@ -534,12 +753,14 @@ type WeaveletMsg struct {
 	GetAddressRequest *GetAddressRequest `protobuf:"bytes,14,opt,name=get_address_request,json=getAddressRequest,proto3" json:"get_address_request,omitempty"`
 	// Request to export a listener.
 	ExportListenerRequest *ExportListenerRequest `protobuf:"bytes,15,opt,name=export_listener_request,json=exportListenerRequest,proto3" json:"export_listener_request,omitempty"`
+	// The pipe protocol version chosen by the weavelet. See PipeHandshake.
+	Handshake *PipeHandshake `protobuf:"bytes,16,opt,name=handshake,proto3" json:"handshake,omitempty"`
 }
 
 func (x *WeaveletMsg) Reset() {
 	*x = WeaveletMsg{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[1]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -552,7 +773,7 @@ func (x *WeaveletMsg) String() string {
 func (*WeaveletMsg) ProtoMessage() {}
 
 func (x *WeaveletMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[1]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -565,7 +786,7 @@ func (x *WeaveletMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WeaveletMsg.ProtoReflect.Descriptor instead.
 func (*WeaveletMsg) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{1}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *WeaveletMsg) GetId() int64 {
@@ -666,6 +887,13 @@ func (x *WeaveletMsg) GetExportListenerRequest() *ExportListenerRequest {
 	return nil
 }
 
+func (x *WeaveletMsg) GetHandshake() *PipeHandshake {
+	if x != nil {
+		return x.Handshake
+	}
+	return nil
+}
+
 // Deployment holds internal information necessary for an application
 // deployment.
 type Deployment struct {
@@ -683,7 +911,7 @@ type Deployment struct {
 func (x *Deployment) Reset() {
 	*x = Deployment{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[2]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -696,7 +924,7 @@ func (x *Deployment) String() string {
 func (*Deployment) ProtoMessage() {}
 
 func (x *Deployment) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[2]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -709,7 +937,7 @@ func (x *Deployment) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Deployment.ProtoReflect.Descriptor instead.
 func (*Deployment) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{2}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Deployment) GetId() string {
@@ -746,7 +974,7 @@ type ComponentGroup struct {
 func (x *ComponentGroup) Reset() {
 	*x = ComponentGroup{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[3]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -759,7 +987,7 @@ func (x *ComponentGroup) String() string {
 func (*ComponentGroup) ProtoMessage() {}
 
 func (x *ComponentGroup) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[3]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -772,7 +1000,7 @@ func (x *ComponentGroup) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ComponentGroup.ProtoReflect.Descriptor instead.
 func (*ComponentGroup) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{3}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ComponentGroup) GetComponents() []string {
@@ -807,9 +1035,9 @@ type AppConfig struct {
 	// Furthermore, suppose that the developer specifies the following
 	// grouping in the config.
 	//
-	//	same_process = [[A, B], [C, D, E]]
+	//  same_process = [[A, B], [C, D, E]]
 	//
-	// In that impl, no matter what algorithm the Service Weaver runtime uses to
+	// In that case, no matter what algorithm the Service Weaver runtime uses to
 	// place the components, components A and B will always be grouped together,
 	// and components C, D, and E will always be grouped together. This means that
 	// a pair of replicas of the components can share in-memory state and
@@ -824,11 +1052,10 @@ type AppConfig struct {
 	// will end up in the same group.
 	//
 	// Components are identified using their full package paths, e.g.:
-	//
-	//	"github.com/my/project/package/ComponentName"
+	//   "github.com/my/project/package/ComponentName"
 	//
 	// , with the exception of the component associated with the application
-	// main.go(), which is identified using the name "main.go".
+	// main(), which is identified using the name "main".
 	//
 	// TODO(spetrovic): Rename to colocate.
 	SameProcess []*ComponentGroup `protobuf:"bytes,20,rep,name=same_process,json=sameProcess,proto3" json:"same_process,omitempty"`
@@ -838,6 +1065,10 @@ type AppConfig struct {
 	//
 	// If not specified, Service Weaver will pick a default value.
 	RolloutNanos int64 `protobuf:"varint,4,opt,name=rollout_nanos,json=rolloutNanos,proto3" json:"rollout_nanos,omitempty"`
+	// Resource limits of the replicas of every colocation group, keyed by the
+	// name of the colocation group (i.e., the name of its first component).
+	// Colocation groups without an entry are not limited.
+	ResourceLimits map[string]*ResourceLimits `protobuf:"bytes,21,rep,name=resource_limits,json=resourceLimits,proto3" json:"resource_limits,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	// All config sections (includes [serviceweaver], [<deployer>], and
 	// [<component>] sections).
 	Sections map[string]string `protobuf:"bytes,7,rep,name=sections,proto3" json:"sections,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
@@ -846,7 +1077,7 @@ type AppConfig struct {
 func (x *AppConfig) Reset() {
 	*x = AppConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[4]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -859,7 +1090,7 @@ func (x *AppConfig) String() string {
 func (*AppConfig) ProtoMessage() {}
 
 func (x *AppConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[4]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -872,7 +1103,7 @@ func (x *AppConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AppConfig.ProtoReflect.Descriptor instead.
 func (*AppConfig) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{4}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *AppConfig) GetName() string {
@@ -917,6 +1148,13 @@ func (x *AppConfig) GetRolloutNanos() int64 {
 	return 0
 }
 
+func (x *AppConfig) GetResourceLimits() map[string]*ResourceLimits {
+	if x != nil {
+		return x.ResourceLimits
+	}
+	return nil
+}
+
 func (x *AppConfig) GetSections() map[string]string {
 	if x != nil {
 		return x.Sections
@@ -924,9 +1162,68 @@ func (x *AppConfig) GetSections() map[string]string {
 	return nil
 }
 
+// ResourceLimits bounds the resources used by a colocation group replica.
+type ResourceLimits struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Maximum number of CPUs the replica may use (e.g., 0.5). Zero means no
+	// limit.
+	Cpus float64 `protobuf:"fixed64,1,opt,name=cpus,proto3" json:"cpus,omitempty"`
+	// Maximum memory, in bytes, the replica may use. Zero means no limit.
+	MemoryBytes int64 `protobuf:"varint,2,opt,name=memory_bytes,json=memoryBytes,proto3" json:"memory_bytes,omitempty"`
+}
+
+func (x *ResourceLimits) Reset() {
+	*x = ResourceLimits{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_protos_runtime_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResourceLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceLimits) ProtoMessage() {}
+
+func (x *ResourceLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_protos_runtime_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceLimits.ProtoReflect.Descriptor instead.
+func (*ResourceLimits) Descriptor() ([]byte, []int) {
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ResourceLimits) GetCpus() float64 {
+	if x != nil {
+		return x.Cpus
+	}
+	return 0
+}
+
+func (x *ResourceLimits) GetMemoryBytes() int64 {
+	if x != nil {
+		return x.MemoryBytes
+	}
+	return 0
+}
+
 // A colocation group defines a set of components that are colocated together
 // on the same machine or a VM. A colocation group may be replicated, in which
-// impl the communication between components in the same colocation group replica
+// case the communication between components in the same colocation group replica
 // remains local to that replica.
 type ColocationGroup struct {
 	state         protoimpl.MessageState
@@ -939,7 +1236,7 @@ type ColocationGroup struct {
 func (x *ColocationGroup) Reset() {
 	*x = ColocationGroup{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[5]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -952,7 +1249,7 @@ func (x *ColocationGroup) String() string {
 func (*ColocationGroup) ProtoMessage() {}
 
 func (x *ColocationGroup) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[5]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -965,7 +1262,7 @@ func (x *ColocationGroup) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ColocationGroup.ProtoReflect.Descriptor instead.
 func (*ColocationGroup) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{5}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ColocationGroup) GetName() string {
@@ -996,7 +1293,7 @@ type WeaveletInfo struct {
 func (x *WeaveletInfo) Reset() {
 	*x = WeaveletInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[6]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1009,7 +1306,7 @@ func (x *WeaveletInfo) String() string {
 func (*WeaveletInfo) ProtoMessage() {}
 
 func (x *WeaveletInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[6]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1022,7 +1319,7 @@ func (x *WeaveletInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WeaveletInfo.ProtoReflect.Descriptor instead.
 func (*WeaveletInfo) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{6}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *WeaveletInfo) GetApp() string {
@@ -1104,7 +1401,7 @@ type GetRoutingInfo struct {
 func (x *GetRoutingInfo) Reset() {
 	*x = GetRoutingInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[7]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1117,7 +1414,7 @@ func (x *GetRoutingInfo) String() string {
 func (*GetRoutingInfo) ProtoMessage() {}
 
 func (x *GetRoutingInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[7]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1130,7 +1427,7 @@ func (x *GetRoutingInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRoutingInfo.ProtoReflect.Descriptor instead.
 func (*GetRoutingInfo) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{7}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *GetRoutingInfo) GetApp() string {
@@ -1168,13 +1465,12 @@ type RoutingInfo struct {
 	unknownFields protoimpl.UnknownFields
 
 	// unchanged is set to true if the request was ended prematurely without
-	// detecting a change. In this impl, version and replicas are empty.
+	// detecting a change. In this case, version and replicas are empty.
 	Unchanged bool   `protobuf:"varint,1,opt,name=unchanged,proto3" json:"unchanged,omitempty"`
 	Version   string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
 	// Each replica is a string of the form "<net>://<addr>". E.g.,
-	//
-	//	tcp://host:1234
-	//	unix:///tmp/unix.sock
+	//    tcp://host:1234
+	//    unix:///tmp/unix.sock
 	Replicas []string `protobuf:"bytes,3,rep,name=replicas,proto3" json:"replicas,omitempty"`
 	// Assignments for all the routed components that are hosted by the
 	// colocation group.
@@ -1184,7 +1480,7 @@ type RoutingInfo struct {
 func (x *RoutingInfo) Reset() {
 	*x = RoutingInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[8]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1197,7 +1493,7 @@ func (x *RoutingInfo) String() string {
 func (*RoutingInfo) ProtoMessage() {}
 
 func (x *RoutingInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[8]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1210,7 +1506,7 @@ func (x *RoutingInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RoutingInfo.ProtoReflect.Descriptor instead.
 func (*RoutingInfo) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{8}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *RoutingInfo) GetUnchanged() bool {
@@ -1254,12 +1550,14 @@ type RunProfiling struct {
 	ProfileType ProfileType `protobuf:"varint,4,opt,name=profile_type,json=profileType,proto3,enum=runtime.ProfileType" json:"profile_type,omitempty"`
 	// Duration for the CPU profile collection, in nanoseconds.
 	CpuDurationNs int64 `protobuf:"varint,5,opt,name=cpu_duration_ns,json=cpuDurationNs,proto3" json:"cpu_duration_ns,omitempty"`
+	// If non-zero, only profile the weavelet with the given pid.
+	Pid int64 `protobuf:"varint,6,opt,name=pid,proto3" json:"pid,omitempty"`
 }
 
 func (x *RunProfiling) Reset() {
 	*x = RunProfiling{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[9]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1272,7 +1570,7 @@ func (x *RunProfiling) String() string {
 func (*RunProfiling) ProtoMessage() {}
 
 func (x *RunProfiling) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[9]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1285,7 +1583,7 @@ func (x *RunProfiling) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RunProfiling.ProtoReflect.Descriptor instead.
 func (*RunProfiling) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{9}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *RunProfiling) GetAppName() string {
@@ -1323,6 +1621,13 @@ func (x *RunProfiling) GetCpuDurationNs() int64 {
 	return 0
 }
 
+func (x *RunProfiling) GetPid() int64 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
 // Profile stores profile data for a given application version.
 type Profile struct {
 	state         protoimpl.MessageState
@@ -1341,7 +1646,7 @@ type Profile struct {
 func (x *Profile) Reset() {
 	*x = Profile{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[10]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1354,7 +1659,7 @@ func (x *Profile) String() string {
 func (*Profile) ProtoMessage() {}
 
 func (x *Profile) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[10]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1367,7 +1672,7 @@ func (x *Profile) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Profile.ProtoReflect.Descriptor instead.
 func (*Profile) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{10}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *Profile) GetAppName() string {
@@ -1410,7 +1715,7 @@ type HealthReport struct {
 func (x *HealthReport) Reset() {
 	*x = HealthReport{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[11]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1423,7 +1728,7 @@ func (x *HealthReport) String() string {
 func (*HealthReport) ProtoMessage() {}
 
 func (x *HealthReport) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[11]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1436,7 +1741,7 @@ func (x *HealthReport) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthReport.ProtoReflect.Descriptor instead.
 func (*HealthReport) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{11}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *HealthReport) GetStatus() HealthStatus {
@@ -1464,7 +1769,7 @@ type ReplicaToRegister struct {
 func (x *ReplicaToRegister) Reset() {
 	*x = ReplicaToRegister{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[12]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1477,7 +1782,7 @@ func (x *ReplicaToRegister) String() string {
 func (*ReplicaToRegister) ProtoMessage() {}
 
 func (x *ReplicaToRegister) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[12]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1490,7 +1795,7 @@ func (x *ReplicaToRegister) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReplicaToRegister.ProtoReflect.Descriptor instead.
 func (*ReplicaToRegister) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{12}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *ReplicaToRegister) GetApp() string {
@@ -1550,7 +1855,7 @@ type ComponentToStart struct {
 func (x *ComponentToStart) Reset() {
 	*x = ComponentToStart{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[13]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1563,7 +1868,7 @@ func (x *ComponentToStart) String() string {
 func (*ComponentToStart) ProtoMessage() {}
 
 func (x *ComponentToStart) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[13]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1576,7 +1881,7 @@ func (x *ComponentToStart) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ComponentToStart.ProtoReflect.Descriptor instead.
 func (*ComponentToStart) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{13}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *ComponentToStart) GetColocationGroup() string {
@@ -1616,7 +1921,7 @@ type GetComponentsToStart struct {
 func (x *GetComponentsToStart) Reset() {
 	*x = GetComponentsToStart{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[14]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1629,7 +1934,7 @@ func (x *GetComponentsToStart) String() string {
 func (*GetComponentsToStart) ProtoMessage() {}
 
 func (x *GetComponentsToStart) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[14]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1642,7 +1947,7 @@ func (x *GetComponentsToStart) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetComponentsToStart.ProtoReflect.Descriptor instead.
 func (*GetComponentsToStart) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{14}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *GetComponentsToStart) GetApp() string {
@@ -1680,7 +1985,7 @@ type ComponentsToStart struct {
 	unknownFields protoimpl.UnknownFields
 
 	// unchanged is set to true if the request was ended prematurely without
-	// detecting a change. In this impl, version and components to start are empty.
+	// detecting a change. In this case, version and components to start are empty.
 	Unchanged  bool     `protobuf:"varint,1,opt,name=unchanged,proto3" json:"unchanged,omitempty"`
 	Version    string   `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
 	Components []string `protobuf:"bytes,3,rep,name=components,proto3" json:"components,omitempty"`
@@ -1689,7 +1994,7 @@ type ComponentsToStart struct {
 func (x *ComponentsToStart) Reset() {
 	*x = ComponentsToStart{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[15]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1702,7 +2007,7 @@ func (x *ComponentsToStart) String() string {
 func (*ComponentsToStart) ProtoMessage() {}
 
 func (x *ComponentsToStart) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[15]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1715,7 +2020,7 @@ func (x *ComponentsToStart) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ComponentsToStart.ProtoReflect.Descriptor instead.
 func (*ComponentsToStart) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{15}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *ComponentsToStart) GetUnchanged() bool {
@@ -1763,7 +2068,7 @@ type GetAddressRequest struct {
 func (x *GetAddressRequest) Reset() {
 	*x = GetAddressRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[16]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1776,7 +2081,7 @@ func (x *GetAddressRequest) String() string {
 func (*GetAddressRequest) ProtoMessage() {}
 
 func (x *GetAddressRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[16]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1789,7 +2094,7 @@ func (x *GetAddressRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAddressRequest.ProtoReflect.Descriptor instead.
 func (*GetAddressRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{16}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *GetAddressRequest) GetName() string {
@@ -1818,7 +2123,7 @@ type GetAddressReply struct {
 func (x *GetAddressReply) Reset() {
 	*x = GetAddressReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[17]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1831,7 +2136,7 @@ func (x *GetAddressReply) String() string {
 func (*GetAddressReply) ProtoMessage() {}
 
 func (x *GetAddressReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[17]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1844,7 +2149,7 @@ func (x *GetAddressReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetAddressReply.ProtoReflect.Descriptor instead.
 func (*GetAddressReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{17}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *GetAddressReply) GetAddress() string {
@@ -1867,7 +2172,7 @@ type ExportListenerRequest struct {
 func (x *ExportListenerRequest) Reset() {
 	*x = ExportListenerRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[18]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1880,7 +2185,7 @@ func (x *ExportListenerRequest) String() string {
 func (*ExportListenerRequest) ProtoMessage() {}
 
 func (x *ExportListenerRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[18]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1893,7 +2198,7 @@ func (x *ExportListenerRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExportListenerRequest.ProtoReflect.Descriptor instead.
 func (*ExportListenerRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{18}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *ExportListenerRequest) GetListener() *Listener {
@@ -1930,7 +2235,7 @@ type ExportListenerReply struct {
 func (x *ExportListenerReply) Reset() {
 	*x = ExportListenerReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[19]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1943,7 +2248,7 @@ func (x *ExportListenerReply) String() string {
 func (*ExportListenerReply) ProtoMessage() {}
 
 func (x *ExportListenerReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[19]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1956,7 +2261,7 @@ func (x *ExportListenerReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExportListenerReply.ProtoReflect.Descriptor instead.
 func (*ExportListenerReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{19}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *ExportListenerReply) GetError() string {
@@ -1986,7 +2291,7 @@ type Listener struct {
 func (x *Listener) Reset() {
 	*x = Listener{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[20]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1999,7 +2304,7 @@ func (x *Listener) String() string {
 func (*Listener) ProtoMessage() {}
 
 func (x *Listener) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[20]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2012,7 +2317,7 @@ func (x *Listener) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Listener.ProtoReflect.Descriptor instead.
 func (*Listener) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{20}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *Listener) GetName() string {
@@ -2041,7 +2346,7 @@ type Listeners struct {
 func (x *Listeners) Reset() {
 	*x = Listeners{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[21]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2054,7 +2359,7 @@ func (x *Listeners) String() string {
 func (*Listeners) ProtoMessage() {}
 
 func (x *Listeners) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[21]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2067,7 +2372,7 @@ func (x *Listeners) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Listeners.ProtoReflect.Descriptor instead.
 func (*Listeners) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{21}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *Listeners) GetListeners() []*Listener {
@@ -2103,7 +2408,7 @@ type Assignment struct {
 func (x *Assignment) Reset() {
 	*x = Assignment{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[22]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[26]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2116,7 +2421,7 @@ func (x *Assignment) String() string {
 func (*Assignment) ProtoMessage() {}
 
 func (x *Assignment) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[22]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[26]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2129,7 +2434,7 @@ func (x *Assignment) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Assignment.ProtoReflect.Descriptor instead.
 func (*Assignment) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{22}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *Assignment) GetSlices() []*Assignment_Slice {
@@ -2184,7 +2489,7 @@ type WeaveletLoadReport struct {
 func (x *WeaveletLoadReport) Reset() {
 	*x = WeaveletLoadReport{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[23]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[27]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2197,7 +2502,7 @@ func (x *WeaveletLoadReport) String() string {
 func (*WeaveletLoadReport) ProtoMessage() {}
 
 func (x *WeaveletLoadReport) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[23]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[27]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2210,7 +2515,7 @@ func (x *WeaveletLoadReport) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WeaveletLoadReport.ProtoReflect.Descriptor instead.
 func (*WeaveletLoadReport) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{23}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *WeaveletLoadReport) GetApp() string {
@@ -2267,7 +2572,7 @@ type MetricUpdate struct {
 func (x *MetricUpdate) Reset() {
 	*x = MetricUpdate{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[24]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[28]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2280,7 +2585,7 @@ func (x *MetricUpdate) String() string {
 func (*MetricUpdate) ProtoMessage() {}
 
 func (x *MetricUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[24]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2293,7 +2598,7 @@ func (x *MetricUpdate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MetricUpdate.ProtoReflect.Descriptor instead.
 func (*MetricUpdate) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{24}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *MetricUpdate) GetDefs() []*MetricDef {
@@ -2327,7 +2632,7 @@ type MetricDef struct {
 func (x *MetricDef) Reset() {
 	*x = MetricDef{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[25]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[29]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2340,7 +2645,7 @@ func (x *MetricDef) String() string {
 func (*MetricDef) ProtoMessage() {}
 
 func (x *MetricDef) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[25]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[29]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2353,7 +2658,7 @@ func (x *MetricDef) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MetricDef.ProtoReflect.Descriptor instead.
 func (*MetricDef) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{25}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *MetricDef) GetId() uint64 {
@@ -2412,7 +2717,7 @@ type MetricValue struct {
 func (x *MetricValue) Reset() {
 	*x = MetricValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[26]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[30]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2425,7 +2730,7 @@ func (x *MetricValue) String() string {
 func (*MetricValue) ProtoMessage() {}
 
 func (x *MetricValue) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[26]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[30]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2438,7 +2743,7 @@ func (x *MetricValue) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MetricValue.ProtoReflect.Descriptor instead.
 func (*MetricValue) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{26}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *MetricValue) GetId() uint64 {
@@ -2482,7 +2787,7 @@ type MetricSnapshot struct {
 func (x *MetricSnapshot) Reset() {
 	*x = MetricSnapshot{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[27]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[31]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2495,7 +2800,7 @@ func (x *MetricSnapshot) String() string {
 func (*MetricSnapshot) ProtoMessage() {}
 
 func (x *MetricSnapshot) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[27]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[31]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2508,7 +2813,7 @@ func (x *MetricSnapshot) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MetricSnapshot.ProtoReflect.Descriptor instead.
 func (*MetricSnapshot) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{27}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *MetricSnapshot) GetId() uint64 {
@@ -2580,20 +2885,19 @@ type LogEntry struct {
 	Node       string `protobuf:"bytes,4,opt,name=node,proto3" json:"node,omitempty"`                                 // weavelet id (e.g., uuid.New().String())
 	TimeMicros int64  `protobuf:"fixed64,5,opt,name=time_micros,json=timeMicros,proto3" json:"time_micros,omitempty"` // time when logged (microseconds since epoch)
 	Level      string `protobuf:"bytes,6,opt,name=level,proto3" json:"level,omitempty"`                               // log level (e.g., debug, info, warn, error, fatal)
-	File       string `protobuf:"bytes,7,opt,name=file,proto3" json:"file,omitempty"`                                 // source file (e.g., // /tmp/serviceweaver/tcp_server.go)
+	File       string `protobuf:"bytes,7,opt,name=file,proto3" json:"file,omitempty"`                                 // source file (e.g., // /tmp/serviceweaver/server.go)
 	Line       int32  `protobuf:"varint,8,opt,name=line,proto3" json:"line,omitempty"`                                // source line // (e.g., 80)
 	Msg        string `protobuf:"bytes,9,opt,name=msg,proto3" json:"msg,omitempty"`                                   // user provided msg (e.g., "Hello!")
 	// User-provided attributes and their associated values. The storage format
 	// is a name followed by its value, i.e.:
-	//
-	//	name1, value1, name2, value2, name3, value3, ...
+	//   name1, value1, name2, value2, name3, value3, ...
 	Attrs []string `protobuf:"bytes,10,rep,name=attrs,proto3" json:"attrs,omitempty"`
 }
 
 func (x *LogEntry) Reset() {
 	*x = LogEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[28]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[32]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2606,7 +2910,7 @@ func (x *LogEntry) String() string {
 func (*LogEntry) ProtoMessage() {}
 
 func (x *LogEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[28]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[32]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2619,7 +2923,7 @@ func (x *LogEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
 func (*LogEntry) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{28}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *LogEntry) GetApp() string {
@@ -2724,7 +3028,7 @@ type Span struct {
 func (x *Span) Reset() {
 	*x = Span{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[29]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[33]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2737,7 +3041,7 @@ func (x *Span) String() string {
 func (*Span) ProtoMessage() {}
 
 func (x *Span) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[29]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[33]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2750,7 +3054,7 @@ func (x *Span) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span.ProtoReflect.Descriptor instead.
 func (*Span) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{29}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *Span) GetName() string {
@@ -2884,7 +3188,7 @@ type Spans struct {
 func (x *Spans) Reset() {
 	*x = Spans{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[30]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[34]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2897,7 +3201,7 @@ func (x *Spans) String() string {
 func (*Spans) ProtoMessage() {}
 
 func (x *Spans) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[30]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[34]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2910,7 +3214,7 @@ func (x *Spans) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Spans.ProtoReflect.Descriptor instead.
 func (*Spans) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{30}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *Spans) GetSpan() []*Span {
@@ -2935,7 +3239,7 @@ type Attribute struct {
 func (x *Attribute) Reset() {
 	*x = Attribute{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[31]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[35]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2948,7 +3252,7 @@ func (x *Attribute) String() string {
 func (*Attribute) ProtoMessage() {}
 
 func (x *Attribute) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[31]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[35]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2961,7 +3265,7 @@ func (x *Attribute) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Attribute.ProtoReflect.Descriptor instead.
 func (*Attribute) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{31}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *Attribute) GetKey() string {
@@ -2991,16 +3295,15 @@ type Assignment_Slice struct {
 	Start uint64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
 	// Replicas assigned to host this slice. The implementation will set
 	// these values to strings of the form "<net>://<addr>". E.g.,
-	//
-	//	tcp://host:1234
-	//	unix:///tmp/unix.sock
+	//  tcp://host:1234
+	//  unix:///tmp/unix.sock
 	Replicas []string `protobuf:"bytes,2,rep,name=replicas,proto3" json:"replicas,omitempty"`
 }
 
 func (x *Assignment_Slice) Reset() {
 	*x = Assignment_Slice{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[34]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[40]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3013,7 +3316,7 @@ func (x *Assignment_Slice) String() string {
 func (*Assignment_Slice) ProtoMessage() {}
 
 func (x *Assignment_Slice) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[34]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[40]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3026,7 +3329,7 @@ func (x *Assignment_Slice) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Assignment_Slice.ProtoReflect.Descriptor instead.
 func (*Assignment_Slice) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{22, 0}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{26, 0}
 }
 
 func (x *Assignment_Slice) GetStart() uint64 {
@@ -3070,7 +3373,7 @@ type WeaveletLoadReport_ComponentLoad struct {
 func (x *WeaveletLoadReport_ComponentLoad) Reset() {
 	*x = WeaveletLoadReport_ComponentLoad{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[35]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[41]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3083,7 +3386,7 @@ func (x *WeaveletLoadReport_ComponentLoad) String() string {
 func (*WeaveletLoadReport_ComponentLoad) ProtoMessage() {}
 
 func (x *WeaveletLoadReport_ComponentLoad) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[35]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[41]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3096,7 +3399,7 @@ func (x *WeaveletLoadReport_ComponentLoad) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WeaveletLoadReport_ComponentLoad.ProtoReflect.Descriptor instead.
 func (*WeaveletLoadReport_ComponentLoad) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{23, 0}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{27, 0}
 }
 
 func (x *WeaveletLoadReport_ComponentLoad) GetLoad() []*WeaveletLoadReport_ComponentLoad_SliceLoad {
@@ -3126,12 +3429,12 @@ type WeaveletLoadReport_ComponentLoad_SliceLoad struct {
 	// The "size" of the slice. Given a slice [a, b), it's tempting to think
 	// the slice has size b - a. This has a couple of problems.
 	//
-	//  1. Even if a slice is large, only a few keys in the slice may be
-	//     used. For example, a weavelet with slice [0, 2^60) may only
-	//     receive requests for keys in the range [0, 100).
-	//  2. a and b are not necessarily numeric. Imagine we are performing
-	//     range sharding on strings and have the slice ["ccbae",
-	//     "hhaefi"). What is the size of this slice?
+	//     1. Even if a slice is large, only a few keys in the slice may be
+	//        used. For example, a weavelet with slice [0, 2^60) may only
+	//        receive requests for keys in the range [0, 100).
+	//     2. a and b are not necessarily numeric. Imagine we are performing
+	//        range sharding on strings and have the slice ["ccbae",
+	//        "hhaefi"). What is the size of this slice?
 	//
 	// We instead measure the "size" of a slice as an estimate of the number
 	// of keys accessed in the slice. For example, if a slice [0, 1000)
@@ -3146,7 +3449,7 @@ type WeaveletLoadReport_ComponentLoad_SliceLoad struct {
 func (x *WeaveletLoadReport_ComponentLoad_SliceLoad) Reset() {
 	*x = WeaveletLoadReport_ComponentLoad_SliceLoad{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[37]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[43]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3159,7 +3462,7 @@ func (x *WeaveletLoadReport_ComponentLoad_SliceLoad) String() string {
 func (*WeaveletLoadReport_ComponentLoad_SliceLoad) ProtoMessage() {}
 
 func (x *WeaveletLoadReport_ComponentLoad_SliceLoad) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[37]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[43]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3172,7 +3475,7 @@ func (x *WeaveletLoadReport_ComponentLoad_SliceLoad) ProtoReflect() protoreflect
 
 // Deprecated: Use WeaveletLoadReport_ComponentLoad_SliceLoad.ProtoReflect.Descriptor instead.
 func (*WeaveletLoadReport_ComponentLoad_SliceLoad) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{23, 0, 0}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{27, 0, 0}
 }
 
 func (x *WeaveletLoadReport_ComponentLoad_SliceLoad) GetStart() uint64 {
@@ -3234,7 +3537,7 @@ type WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad struct {
 func (x *WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad) Reset() {
 	*x = WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[38]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[44]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3247,7 +3550,7 @@ func (x *WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad) String() strin
 func (*WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad) ProtoMessage() {}
 
 func (x *WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[38]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[44]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3260,7 +3563,7 @@ func (x *WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad) ProtoReflect()
 
 // Deprecated: Use WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad.ProtoReflect.Descriptor instead.
 func (*WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{23, 0, 0, 0}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{27, 0, 0, 0}
 }
 
 func (x *WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad) GetStart() uint64 {
@@ -3293,7 +3596,7 @@ type Span_Link struct {
 func (x *Span_Link) Reset() {
 	*x = Span_Link{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[41]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[47]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3306,7 +3609,7 @@ func (x *Span_Link) String() string {
 func (*Span_Link) ProtoMessage() {}
 
 func (x *Span_Link) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[41]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[47]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3319,7 +3622,7 @@ func (x *Span_Link) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Link.ProtoReflect.Descriptor instead.
 func (*Span_Link) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{29, 0}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{33, 0}
 }
 
 func (x *Span_Link) GetTraceId() []byte {
@@ -3366,7 +3669,7 @@ type Span_Event struct {
 func (x *Span_Event) Reset() {
 	*x = Span_Event{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[42]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[48]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3379,7 +3682,7 @@ func (x *Span_Event) String() string {
 func (*Span_Event) ProtoMessage() {}
 
 func (x *Span_Event) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[42]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[48]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3392,7 +3695,7 @@ func (x *Span_Event) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Event.ProtoReflect.Descriptor instead.
 func (*Span_Event) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{29, 1}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{33, 1}
 }
 
 func (x *Span_Event) GetName() string {
@@ -3436,7 +3739,7 @@ type Span_Status struct {
 func (x *Span_Status) Reset() {
 	*x = Span_Status{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[43]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[49]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3449,7 +3752,7 @@ func (x *Span_Status) String() string {
 func (*Span_Status) ProtoMessage() {}
 
 func (x *Span_Status) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[43]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[49]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3462,7 +3765,7 @@ func (x *Span_Status) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Status.ProtoReflect.Descriptor instead.
 func (*Span_Status) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{29, 2}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{33, 2}
 }
 
 func (x *Span_Status) GetCode() Span_Status_Code {
@@ -3496,7 +3799,7 @@ type Span_Library struct {
 func (x *Span_Library) Reset() {
 	*x = Span_Library{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[44]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[50]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3509,7 +3812,7 @@ func (x *Span_Library) String() string {
 func (*Span_Library) ProtoMessage() {}
 
 func (x *Span_Library) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[44]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[50]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3522,7 +3825,7 @@ func (x *Span_Library) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Library.ProtoReflect.Descriptor instead.
 func (*Span_Library) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{29, 3}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{33, 3}
 }
 
 func (x *Span_Library) GetName() string {
@@ -3561,7 +3864,7 @@ type Span_Resource struct {
 func (x *Span_Resource) Reset() {
 	*x = Span_Resource{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[45]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[51]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3574,7 +3877,7 @@ func (x *Span_Resource) String() string {
 func (*Span_Resource) ProtoMessage() {}
 
 func (x *Span_Resource) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[45]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[51]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3587,7 +3890,7 @@ func (x *Span_Resource) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Resource.ProtoReflect.Descriptor instead.
 func (*Span_Resource) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{29, 4}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{33, 4}
 }
 
 func (x *Span_Resource) GetSchemaUrl() string {
@@ -3611,7 +3914,6 @@ type Attribute_Value struct {
 
 	Type Attribute_Value_Type `protobuf:"varint,1,opt,name=type,proto3,enum=runtime.Attribute_Value_Type" json:"type,omitempty"` // Attribute type
 	// Types that are assignable to Value:
-	//
 	//	*Attribute_Value_Num
 	//	*Attribute_Value_Str
 	//	*Attribute_Value_Nums
@@ -3622,7 +3924,7 @@ type Attribute_Value struct {
 func (x *Attribute_Value) Reset() {
 	*x = Attribute_Value{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[46]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[52]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3635,7 +3937,7 @@ func (x *Attribute_Value) String() string {
 func (*Attribute_Value) ProtoMessage() {}
 
 func (x *Attribute_Value) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[46]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[52]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3648,7 +3950,7 @@ func (x *Attribute_Value) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Attribute_Value.ProtoReflect.Descriptor instead.
 func (*Attribute_Value) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{31, 0}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{35, 0}
 }
 
 func (x *Attribute_Value) GetType() Attribute_Value_Type {
@@ -3732,7 +4034,7 @@ type Attribute_Value_NumberList struct {
 func (x *Attribute_Value_NumberList) Reset() {
 	*x = Attribute_Value_NumberList{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[47]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[53]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3745,7 +4047,7 @@ func (x *Attribute_Value_NumberList) String() string {
 func (*Attribute_Value_NumberList) ProtoMessage() {}
 
 func (x *Attribute_Value_NumberList) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[47]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[53]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3758,7 +4060,7 @@ func (x *Attribute_Value_NumberList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Attribute_Value_NumberList.ProtoReflect.Descriptor instead.
 func (*Attribute_Value_NumberList) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{31, 0, 0}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{35, 0, 0}
 }
 
 func (x *Attribute_Value_NumberList) GetNums() []uint64 {
@@ -3779,7 +4081,7 @@ type Attribute_Value_StringList struct {
 func (x *Attribute_Value_StringList) Reset() {
 	*x = Attribute_Value_StringList{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[48]
+		mi := &file_runtime_protos_runtime_proto_msgTypes[54]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3792,7 +4094,7 @@ func (x *Attribute_Value_StringList) String() string {
 func (*Attribute_Value_StringList) ProtoMessage() {}
 
 func (x *Attribute_Value_StringList) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[48]
+	mi := &file_runtime_protos_runtime_proto_msgTypes[54]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3805,7 +4107,7 @@ func (x *Attribute_Value_StringList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Attribute_Value_StringList.ProtoReflect.Descriptor instead.
 func (*Attribute_Value_StringList) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{31, 0, 1}
+	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{35, 0, 1}
 }
 
 func (x *Attribute_Value_StringList) GetStrs() []string {
@@ -3820,7 +4122,7 @@ var File_runtime_protos_runtime_proto protoreflect.FileDescriptor
 var file_runtime_protos_runtime_proto_rawDesc = []byte{
 	0x0a, 0x1c, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
 	0x2f, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07,
-	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x22, 0x99, 0x04, 0x0a, 0x0b, 0x45, 0x6e, 0x76, 0x65,
+	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x22, 0xb1, 0x05, 0x0a, 0x0b, 0x45, 0x6e, 0x76, 0x65,
 	0x6c, 0x6f, 0x70, 0x65, 0x4d, 0x73, 0x67, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
 	0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x65, 0x6e, 0x64, 0x5f,
 	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x73,
@@ -3854,88 +4156,134 @@ var file_runtime_protos_runtime_proto_rawDesc = []byte{
 	0x6e, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x52, 0x13, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74,
 	0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a,
 	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72,
-	0x72, 0x6f, 0x72, 0x22, 0xbd, 0x06, 0x0a, 0x0b, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74,
-	0x4d, 0x73, 0x67, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x02, 0x69, 0x64, 0x12, 0x2f, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d,
-	0x65, 0x74, 0x72, 0x69, 0x63, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x07, 0x6d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x73, 0x12, 0x2e, 0x0a, 0x09, 0x6c, 0x6f, 0x67, 0x5f, 0x65, 0x6e, 0x74, 0x72,
-	0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
-	0x65, 0x2e, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x6c, 0x6f, 0x67, 0x45,
-	0x6e, 0x74, 0x72, 0x79, 0x12, 0x2f, 0x0a, 0x0b, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x73, 0x70,
-	0x61, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x72, 0x75, 0x6e, 0x74,
-	0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x73, 0x52, 0x0a, 0x74, 0x72, 0x61, 0x63, 0x65,
-	0x53, 0x70, 0x61, 0x6e, 0x73, 0x12, 0x47, 0x0a, 0x12, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65,
-	0x6e, 0x74, 0x5f, 0x74, 0x6f, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x19, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x70,
-	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x54, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74, 0x52, 0x10, 0x63, 0x6f,
-	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x54, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x4a,
-	0x0a, 0x13, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x5f, 0x74, 0x6f, 0x5f, 0x72, 0x65, 0x67,
-	0x69, 0x73, 0x74, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x72, 0x75,
-	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x54, 0x6f, 0x52,
-	0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x11, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61,
-	0x54, 0x6f, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x3c, 0x0a, 0x0b, 0x6c, 0x6f,
-	0x61, 0x64, 0x5f, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x1b, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c,
-	0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x0a, 0x6c, 0x6f,
-	0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x3a, 0x0a, 0x0d, 0x68, 0x65, 0x61, 0x6c,
-	0x74, 0x68, 0x5f, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68,
-	0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x0c, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65,
-	0x70, 0x6f, 0x72, 0x74, 0x12, 0x2a, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x18,
-	0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e,
-	0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65,
-	0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x41, 0x0a, 0x10, 0x67, 0x65, 0x74, 0x5f, 0x72, 0x6f,
-	0x75, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x17, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x6f,
-	0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0e, 0x67, 0x65, 0x74, 0x52, 0x6f,
-	0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x54, 0x0a, 0x17, 0x67, 0x65, 0x74,
-	0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x5f, 0x74, 0x6f, 0x5f, 0x73,
-	0x74, 0x61, 0x72, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x72, 0x75, 0x6e,
-	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e,
-	0x74, 0x73, 0x54, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74, 0x52, 0x14, 0x67, 0x65, 0x74, 0x43, 0x6f,
-	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x54, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12,
-	0x4a, 0x0a, 0x13, 0x67, 0x65, 0x74, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x5f, 0x72,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x72,
-	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73,
-	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x11, 0x67, 0x65, 0x74, 0x41, 0x64, 0x64,
-	0x72, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x56, 0x0a, 0x17, 0x65,
-	0x78, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x5f, 0x72,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x72,
-	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x4c, 0x69, 0x73,
-	0x74, 0x65, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x15, 0x65, 0x78,
-	0x70, 0x6f, 0x72, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x22, 0x69, 0x0a, 0x0a, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e,
-	0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69,
-	0x64, 0x12, 0x24, 0x0a, 0x03, 0x61, 0x70, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12,
+	0x72, 0x6f, 0x72, 0x12, 0x3a, 0x0a, 0x0d, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x75, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e,
+	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x55, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x52, 0x0c, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12,
+	0x24, 0x0a, 0x05, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e,
+	0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x44, 0x72, 0x61, 0x69, 0x6e, 0x52, 0x05,
+	0x64, 0x72, 0x61, 0x69, 0x6e, 0x12, 0x34, 0x0a, 0x09, 0x68, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61,
+	0x6b, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69,
+	0x6d, 0x65, 0x2e, 0x50, 0x69, 0x70, 0x65, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65,
+	0x52, 0x09, 0x68, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x22, 0x29, 0x0a, 0x0d, 0x50,
+	0x69, 0x70, 0x65, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x26, 0x0a, 0x05, 0x44, 0x72, 0x61, 0x69, 0x6e, 0x12,
+	0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4e, 0x73, 0x22, 0xc6,
+	0x01, 0x0a, 0x0c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12,
+	0x1e, 0x0a, 0x0a, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0a, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x3f, 0x0a, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x23, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x2e, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x07, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x1a, 0x3b, 0x0a, 0x0d, 0x53, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xf3, 0x06, 0x0a, 0x0b, 0x57, 0x65, 0x61, 0x76,
+	0x65, 0x6c, 0x65, 0x74, 0x4d, 0x73, 0x67, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x2f, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69,
+	0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52,
+	0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x2e, 0x0a, 0x09, 0x6c, 0x6f, 0x67, 0x5f,
+	0x65, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08,
+	0x6c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x2f, 0x0a, 0x0b, 0x74, 0x72, 0x61, 0x63,
+	0x65, 0x5f, 0x73, 0x70, 0x61, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e,
+	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x73, 0x52, 0x0a, 0x74,
+	0x72, 0x61, 0x63, 0x65, 0x53, 0x70, 0x61, 0x6e, 0x73, 0x12, 0x47, 0x0a, 0x12, 0x63, 0x6f, 0x6d,
+	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x5f, 0x74, 0x6f, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e,
+	0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x54, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74,
+	0x52, 0x10, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x54, 0x6f, 0x53, 0x74, 0x61,
+	0x72, 0x74, 0x12, 0x4a, 0x0a, 0x13, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x5f, 0x74, 0x6f,
+	0x5f, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63,
+	0x61, 0x54, 0x6f, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x11, 0x72, 0x65, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x54, 0x6f, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x3c,
+	0x0a, 0x0b, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x57, 0x65,
+	0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x52, 0x0a, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x3a, 0x0a, 0x0d,
+	0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x5f, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x48, 0x65,
+	0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x0c, 0x68, 0x65, 0x61, 0x6c,
+	0x74, 0x68, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x2a, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x72, 0x75, 0x6e, 0x74,
+	0x69, 0x6d, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x52, 0x07, 0x70, 0x72, 0x6f,
+	0x66, 0x69, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x41, 0x0a, 0x10, 0x67, 0x65,
+	0x74, 0x5f, 0x72, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x0c,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x47,
+	0x65, 0x74, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0e, 0x67,
+	0x65, 0x74, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x54, 0x0a,
+	0x17, 0x67, 0x65, 0x74, 0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x5f,
+	0x74, 0x6f, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d,
+	0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6d, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x54, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74, 0x52, 0x14, 0x67,
+	0x65, 0x74, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x54, 0x6f, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x12, 0x4a, 0x0a, 0x13, 0x67, 0x65, 0x74, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x41, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x11, 0x67, 0x65,
+	0x74, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x56, 0x0a, 0x17, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e,
+	0x65, 0x72, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1e, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72,
+	0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x52, 0x15, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x34, 0x0a, 0x09, 0x68, 0x61, 0x6e, 0x64, 0x73,
+	0x68, 0x61, 0x6b, 0x65, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x72, 0x75, 0x6e,
+	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x50, 0x69, 0x70, 0x65, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61,
+	0x6b, 0x65, 0x52, 0x09, 0x68, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x22, 0x69, 0x0a,
+	0x0a, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x24, 0x0a, 0x03, 0x61,
+	0x70, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69,
+	0x6d, 0x65, 0x2e, 0x41, 0x70, 0x70, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x03, 0x61, 0x70,
+	0x70, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x69, 0x6e, 0x67, 0x6c, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x63,
+	0x65, 0x73, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x73, 0x69, 0x6e, 0x67, 0x6c,
+	0x65, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x22, 0x30, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f,
+	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a,
+	0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x22, 0xe6, 0x03, 0x0a, 0x09, 0x41,
+	0x70, 0x70, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x62, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x62, 0x69,
+	0x6e, 0x61, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x76, 0x18,
+	0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x65, 0x6e, 0x76, 0x12, 0x3a, 0x0a, 0x0c, 0x73, 0x61,
+	0x6d, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x18, 0x14, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6f,
+	0x6e, 0x65, 0x6e, 0x74, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x52, 0x0b, 0x73, 0x61, 0x6d, 0x65, 0x50,
+	0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x6f, 0x6c, 0x6c, 0x6f, 0x75,
+	0x74, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x72,
+	0x6f, 0x6c, 0x6c, 0x6f, 0x75, 0x74, 0x4e, 0x61, 0x6e, 0x6f, 0x73, 0x12, 0x4f, 0x0a, 0x0f, 0x72,
+	0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x15,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41,
+	0x70, 0x70, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x72, 0x65,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x3c, 0x0a, 0x08,
+	0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20,
 	0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x70, 0x70, 0x43, 0x6f, 0x6e, 0x66,
-	0x69, 0x67, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x69, 0x6e, 0x67, 0x6c,
-	0x65, 0x5f, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52,
-	0x0d, 0x73, 0x69, 0x6e, 0x67, 0x6c, 0x65, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x22, 0x30,
-	0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x47, 0x72, 0x6f, 0x75, 0x70,
-	0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01,
-	0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73,
-	0x22, 0xb9, 0x02, 0x0a, 0x09, 0x41, 0x70, 0x70, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x12,
-	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
-	0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x06, 0x62, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72,
-	0x67, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x10,
-	0x0a, 0x03, 0x65, 0x6e, 0x76, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x65, 0x6e, 0x76,
-	0x12, 0x3a, 0x0a, 0x0c, 0x73, 0x61, 0x6d, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73,
-	0x18, 0x14, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65,
-	0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x52,
-	0x0b, 0x73, 0x61, 0x6d, 0x65, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x12, 0x23, 0x0a, 0x0d,
-	0x72, 0x6f, 0x6c, 0x6c, 0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x18, 0x04, 0x20,
-	0x01, 0x28, 0x03, 0x52, 0x0c, 0x72, 0x6f, 0x6c, 0x6c, 0x6f, 0x75, 0x74, 0x4e, 0x61, 0x6e, 0x6f,
-	0x73, 0x12, 0x3c, 0x0a, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x07, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x70,
-	0x70, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x1a,
-	0x3b, 0x0a, 0x0d, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
-	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
-	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x25, 0x0a, 0x0f,
+	0x69, 0x67, 0x2e, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x1a, 0x5a, 0x0a, 0x13, 0x52, 0x65,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x2d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x52, 0x65, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3b, 0x0a, 0x0d, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x22, 0x47, 0x0a, 0x0e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c,
+	0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x70, 0x75, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x04, 0x63, 0x70, 0x75, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x65, 0x6d,
+	0x6f, 0x72, 0x79, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0b, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x42, 0x79, 0x74, 0x65, 0x73, 0x22, 0x25, 0x0a, 0x0f,
 	0x43, 0x6f, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x12,
 	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
 	0x61, 0x6d, 0x65, 0x22, 0xa8, 0x03, 0x0a, 0x0c, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74,
@@ -3982,7 +4330,7 @@ var file_runtime_protos_runtime_proto_rawDesc = []byte{
 	0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b,
 	0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x73, 0x73, 0x69, 0x67,
 	0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x0b, 0x61, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e,
-	0x74, 0x73, 0x22, 0xbf, 0x01, 0x0a, 0x0c, 0x52, 0x75, 0x6e, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c,
+	0x74, 0x73, 0x22, 0xd1, 0x01, 0x0a, 0x0c, 0x52, 0x75, 0x6e, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c,
 	0x69, 0x6e, 0x67, 0x12, 0x19, 0x0a, 0x08, 0x61, 0x70, 0x70, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x70, 0x70, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d,
 	0x0a, 0x0a, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
@@ -3994,334 +4342,337 @@ var file_runtime_protos_runtime_proto_rawDesc = []byte{
 	0x0b, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x26, 0x0a, 0x0f,
 	0x63, 0x70, 0x75, 0x5f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6e, 0x73, 0x18,
 	0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x63, 0x70, 0x75, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x4e, 0x73, 0x22, 0x6f, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12,
-	0x19, 0x0a, 0x08, 0x61, 0x70, 0x70, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x07, 0x61, 0x70, 0x70, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x76, 0x65,
-	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
-	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74,
-	0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x16, 0x0a,
-	0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x65,
-	0x72, 0x72, 0x6f, 0x72, 0x73, 0x22, 0x3d, 0x0a, 0x0c, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52,
-	0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x2d, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e,
-	0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x22, 0xb6, 0x01, 0x0a, 0x11, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61,
-	0x54, 0x6f, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x70,
-	0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x28, 0x0a, 0x10,
-	0x67, 0x72, 0x6f, 0x75, 0x70, 0x5f, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x5f, 0x69, 0x64,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x52, 0x65, 0x70,
-	0x6c, 0x69, 0x63, 0x61, 0x49, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79,
-	0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64,
-	0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x67,
-	0x72, 0x6f, 0x75, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75,
-	0x70, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x05, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x70,
-	0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x70, 0x69, 0x64, 0x22, 0x77, 0x0a,
-	0x10, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x54, 0x6f, 0x53, 0x74, 0x61, 0x72,
-	0x74, 0x12, 0x29, 0x0a, 0x10, 0x63, 0x6f, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
-	0x67, 0x72, 0x6f, 0x75, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x63, 0x6f, 0x6c,
-	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x1c, 0x0a, 0x09,
-	0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x73,
-	0x52, 0x6f, 0x75, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x69, 0x73,
-	0x52, 0x6f, 0x75, 0x74, 0x65, 0x64, 0x22, 0x7d, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6d,
-	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x54, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x10,
-	0x0a, 0x03, 0x61, 0x70, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x70,
-	0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x69,
-	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d,
-	0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x76,
-	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65,
-	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x6b, 0x0a, 0x11, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65,
-	0x6e, 0x74, 0x73, 0x54, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x75, 0x6e,
-	0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x75,
-	0x6e, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73,
-	0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
-	0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73,
-	0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e,
-	0x74, 0x73, 0x22, 0x4c, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x6c,
-	0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x0c, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
-	0x22, 0x2b, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x6b, 0x0a,
-	0x15, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x08, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e,
-	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69,
-	0x6d, 0x65, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x52, 0x08, 0x6c, 0x69, 0x73,
-	0x74, 0x65, 0x6e, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x61,
-	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6c, 0x6f,
-	0x63, 0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x50, 0x0a, 0x13, 0x45, 0x78,
-	0x70, 0x6f, 0x72, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x72, 0x6f, 0x78, 0x79,
-	0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
-	0x70, 0x72, 0x6f, 0x78, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x32, 0x0a, 0x08,
-	0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04,
-	0x61, 0x64, 0x64, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61, 0x64, 0x64, 0x72,
-	0x22, 0x3c, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x73, 0x12, 0x2f, 0x0a,
-	0x09, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x11, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x65,
-	0x6e, 0x65, 0x72, 0x52, 0x09, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x73, 0x22, 0xe9,
-	0x01, 0x0a, 0x0a, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x31, 0x0a,
-	0x06, 0x73, 0x6c, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e,
-	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65,
-	0x6e, 0x74, 0x2e, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x52, 0x06, 0x73, 0x6c, 0x69, 0x63, 0x65, 0x73,
-	0x12, 0x10, 0x0a, 0x03, 0x61, 0x70, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61,
+	0x6f, 0x6e, 0x4e, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x03, 0x70, 0x69, 0x64, 0x22, 0x6f, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c,
+	0x65, 0x12, 0x19, 0x0a, 0x08, 0x61, 0x70, 0x70, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x70, 0x70, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64,
+	0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12,
+	0x16, 0x0a, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x22, 0x3d, 0x0a, 0x0c, 0x48, 0x65, 0x61, 0x6c, 0x74,
+	0x68, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x2d, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
+	0x65, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0xb6, 0x01, 0x0a, 0x11, 0x52, 0x65, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x54, 0x6f, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03,
+	0x61, 0x70, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x28,
+	0x0a, 0x10, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x5f, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x5f,
+	0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x52,
+	0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x49, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x70, 0x6c,
+	0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x14, 0x0a,
+	0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x67, 0x72,
+	0x6f, 0x75, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x10, 0x0a,
+	0x03, 0x70, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x70, 0x69, 0x64, 0x22,
+	0x77, 0x0a, 0x10, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x54, 0x6f, 0x53, 0x74,
+	0x61, 0x72, 0x74, 0x12, 0x29, 0x0a, 0x10, 0x63, 0x6f, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x63,
+	0x6f, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x1c,
+	0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x69, 0x73, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08,
+	0x69, 0x73, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x64, 0x22, 0x7d, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x43,
+	0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x54, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74,
+	0x12, 0x10, 0x0a, 0x03, 0x61, 0x70, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61,
 	0x70, 0x70, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74,
-	0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x70, 0x6c, 0x6f,
-	0x79, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f,
-	0x6e, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70,
-	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
-	0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x1a,
-	0x39, 0x0a, 0x05, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72,
-	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x1a,
-	0x0a, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
-	0x52, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x22, 0x85, 0x05, 0x0a, 0x12, 0x57,
-	0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72,
-	0x74, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x70, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x61, 0x70, 0x70, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e,
-	0x74, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x70, 0x6c,
-	0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x67, 0x72, 0x6f, 0x75,
-	0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x18,
-	0x0a, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x12, 0x3c, 0x0a, 0x05, 0x6c, 0x6f, 0x61, 0x64,
-	0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
+	0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x70, 0x6c, 0x6f,
+	0x79, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x18, 0x0a,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x6b, 0x0a, 0x11, 0x43, 0x6f, 0x6d, 0x70, 0x6f,
+	0x6e, 0x65, 0x6e, 0x74, 0x73, 0x54, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x1c, 0x0a, 0x09,
+	0x75, 0x6e, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x09, 0x75, 0x6e, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e,
+	0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
+	0x65, 0x6e, 0x74, 0x73, 0x22, 0x4c, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x41, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a,
+	0x0d, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x22, 0x2b, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22,
+	0x6b, 0x0a, 0x15, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65,
+	0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x08, 0x6c, 0x69, 0x73, 0x74,
+	0x65, 0x6e, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x75, 0x6e,
+	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x52, 0x08, 0x6c,
+	0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x6c, 0x6f, 0x63, 0x61, 0x6c,
+	0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x50, 0x0a, 0x13,
+	0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x72, 0x6f,
+	0x78, 0x79, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0c, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x32,
+	0x0a, 0x08, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12,
+	0x0a, 0x04, 0x61, 0x64, 0x64, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61, 0x64,
+	0x64, 0x72, 0x22, 0x3c, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x73, 0x12,
+	0x2f, 0x0a, 0x09, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x65, 0x6e, 0x65, 0x72, 0x52, 0x09, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x73,
+	0x22, 0xe9, 0x01, 0x0a, 0x0a, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x12,
+	0x31, 0x0a, 0x06, 0x73, 0x6c, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e,
+	0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x52, 0x06, 0x73, 0x6c, 0x69, 0x63,
+	0x65, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x70, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x61, 0x70, 0x70, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65,
+	0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x70,
+	0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d,
+	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f,
+	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x1a, 0x39, 0x0a, 0x05, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x22, 0x85, 0x05, 0x0a,
+	0x12, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70,
+	0x6f, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x70, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d,
+	0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x65,
+	0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x67, 0x72,
+	0x6f, 0x75, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70,
+	0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x12, 0x3c, 0x0a, 0x05, 0x6c, 0x6f,
+	0x61, 0x64, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x72, 0x75, 0x6e, 0x74,
+	0x69, 0x6d, 0x65, 0x2e, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64,
+	0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x52, 0x05, 0x6c, 0x6f, 0x61, 0x64, 0x73, 0x1a, 0xe4, 0x02, 0x0a, 0x0d, 0x43, 0x6f, 0x6d,
+	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x47, 0x0a, 0x04, 0x6c, 0x6f,
+	0x61, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x33, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69,
+	0x6d, 0x65, 0x2e, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52,
+	0x65, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4c,
+	0x6f, 0x61, 0x64, 0x2e, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x04, 0x6c,
+	0x6f, 0x61, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x1a, 0xef, 0x01,
+	0x0a, 0x09, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03,
+	0x65, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x58, 0x0a, 0x06, 0x73, 0x70, 0x6c, 0x69, 0x74,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x40, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
 	0x65, 0x2e, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65,
-	0x70, 0x6f, 0x72, 0x74, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
-	0x05, 0x6c, 0x6f, 0x61, 0x64, 0x73, 0x1a, 0xe4, 0x02, 0x0a, 0x0d, 0x43, 0x6f, 0x6d, 0x70, 0x6f,
-	0x6e, 0x65, 0x6e, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x47, 0x0a, 0x04, 0x6c, 0x6f, 0x61, 0x64,
-	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x33, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65,
-	0x2e, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70,
-	0x6f, 0x72, 0x74, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4c, 0x6f, 0x61,
-	0x64, 0x2e, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x04, 0x6c, 0x6f, 0x61,
-	0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x1a, 0xef, 0x01, 0x0a, 0x09,
-	0x53, 0x6c, 0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61,
-	0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12,
-	0x10, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x65, 0x6e,
-	0x64, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52,
-	0x04, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x58, 0x0a, 0x06, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x73, 0x18,
-	0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x40, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e,
-	0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f,
-	0x72, 0x74, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4c, 0x6f, 0x61, 0x64,
-	0x2e, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x6c,
-	0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x06, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x73, 0x12,
-	0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73,
-	0x69, 0x7a, 0x65, 0x1a, 0x38, 0x0a, 0x0c, 0x53, 0x75, 0x62, 0x73, 0x6c, 0x69, 0x63, 0x65, 0x4c,
-	0x6f, 0x61, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x6f, 0x61,
-	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x1a, 0x63, 0x0a,
-	0x0a, 0x4c, 0x6f, 0x61, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
-	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x3f, 0x0a,
-	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x29, 0x2e, 0x72,
-	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x4c,
-	0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
-	0x65, 0x6e, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
-	0x38, 0x01, 0x22, 0x64, 0x0a, 0x0c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x55, 0x70, 0x64, 0x61,
-	0x74, 0x65, 0x12, 0x26, 0x0a, 0x04, 0x64, 0x65, 0x66, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69,
-	0x63, 0x44, 0x65, 0x66, 0x52, 0x04, 0x64, 0x65, 0x66, 0x73, 0x12, 0x2c, 0x0a, 0x06, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x72, 0x75, 0x6e,
-	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x56, 0x61, 0x6c, 0x75, 0x65,
-	0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x22, 0xf5, 0x01, 0x0a, 0x09, 0x4d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x44, 0x65, 0x66, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x03, 0x74, 0x79,
-	0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
-	0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x54, 0x79, 0x70, 0x65, 0x52, 0x03, 0x74, 0x79,
-	0x70, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x65, 0x6c, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x04, 0x68, 0x65, 0x6c, 0x70, 0x12, 0x36, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18,
-	0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e,
-	0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x44, 0x65, 0x66, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73,
-	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12, 0x16, 0x0a,
-	0x06, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x01, 0x52, 0x06, 0x62,
-	0x6f, 0x75, 0x6e, 0x64, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45,
-	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
-	0x22, 0x4b, 0x0a, 0x0b, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12,
-	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12,
-	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x18,
-	0x03, 0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x22, 0xad, 0x02,
-	0x0a, 0x0e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
-	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64,
-	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
-	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x03, 0x74, 0x79, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x0e, 0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x54, 0x79, 0x70, 0x65, 0x52, 0x03, 0x74, 0x79, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x68,
-	0x65, 0x6c, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x65, 0x6c, 0x70, 0x12,
-	0x3b, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32,
-	0x23, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63,
-	0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45,
-	0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12, 0x16, 0x0a, 0x06,
-	0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x01, 0x52, 0x06, 0x62, 0x6f,
-	0x75, 0x6e, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x07, 0x20,
-	0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f,
-	0x75, 0x6e, 0x74, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x63, 0x6f, 0x75, 0x6e,
-	0x74, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xef, 0x01,
-	0x0a, 0x08, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x70,
-	0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x18, 0x0a, 0x07,
-	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76,
-	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
-	0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f,
-	0x6e, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x69, 0x6d, 0x65,
-	0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x10, 0x52, 0x0a, 0x74,
-	0x69, 0x6d, 0x65, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76,
-	0x65, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12,
-	0x12, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66,
-	0x69, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28,
-	0x05, 0x52, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x73, 0x67, 0x18, 0x09,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x74, 0x74,
-	0x72, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x22,
-	0xbb, 0x0a, 0x0a, 0x04, 0x53, 0x70, 0x61, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08,
-	0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07,
-	0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x6e, 0x5f,
-	0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x70, 0x61, 0x6e, 0x49, 0x64,
-	0x12, 0x24, 0x0a, 0x0e, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x73, 0x70, 0x61, 0x6e, 0x5f,
-	0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74,
-	0x53, 0x70, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x05,
-	0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53,
-	0x70, 0x61, 0x6e, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x21, 0x0a,
-	0x0c, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x06, 0x20,
-	0x01, 0x28, 0x10, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73,
-	0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x64, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x07,
-	0x20, 0x01, 0x28, 0x10, 0x52, 0x09, 0x65, 0x6e, 0x64, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12,
-	0x32, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x08, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74,
-	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75,
-	0x74, 0x65, 0x73, 0x12, 0x28, 0x0a, 0x05, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x18, 0x09, 0x20, 0x03,
-	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61,
-	0x6e, 0x2e, 0x4c, 0x69, 0x6e, 0x6b, 0x52, 0x05, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x12, 0x2b, 0x0a,
-	0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e,
-	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x45, 0x76, 0x65,
-	0x6e, 0x74, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x2c, 0x0a, 0x06, 0x73, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x72, 0x75, 0x6e,
-	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2f, 0x0a, 0x07, 0x6c, 0x69, 0x62, 0x72,
-	0x61, 0x72, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74,
-	0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x4c, 0x69, 0x62, 0x72, 0x61, 0x72, 0x79,
-	0x52, 0x07, 0x6c, 0x69, 0x62, 0x72, 0x61, 0x72, 0x79, 0x12, 0x32, 0x0a, 0x08, 0x72, 0x65, 0x73,
-	0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x72, 0x75,
-	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75,
-	0x72, 0x63, 0x65, 0x52, 0x08, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x36, 0x0a,
-	0x17, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75,
-	0x74, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x03, 0x52, 0x15,
-	0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65,
-	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2c, 0x0a, 0x12, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64,
-	0x5f, 0x6c, 0x69, 0x6e, 0x6b, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28,
-	0x03, 0x52, 0x10, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x4c, 0x69, 0x6e, 0x6b, 0x43, 0x6f,
-	0x75, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x13, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x65,
-	0x76, 0x65, 0x6e, 0x74, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x10, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x11, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x43, 0x6f,
-	0x75, 0x6e, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x5f, 0x73, 0x70, 0x61,
-	0x6e, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x11, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x63,
-	0x68, 0x69, 0x6c, 0x64, 0x53, 0x70, 0x61, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x1a, 0xa6, 0x01,
-	0x0a, 0x04, 0x4c, 0x69, 0x6e, 0x6b, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f,
-	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x74, 0x72, 0x61, 0x63, 0x65, 0x49,
-	0x64, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x06, 0x73, 0x70, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x32, 0x0a, 0x0a, 0x61, 0x74,
-	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12,
+	0x70, 0x6f, 0x72, 0x74, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4c, 0x6f,
+	0x61, 0x64, 0x2e, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x2e, 0x53, 0x75, 0x62,
+	0x73, 0x6c, 0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x06, 0x73, 0x70, 0x6c, 0x69, 0x74,
+	0x73, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x04, 0x73, 0x69, 0x7a, 0x65, 0x1a, 0x38, 0x0a, 0x0c, 0x53, 0x75, 0x62, 0x73, 0x6c, 0x69, 0x63,
+	0x65, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6c,
+	0x6f, 0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x1a,
+	0x63, 0x0a, 0x0a, 0x4c, 0x6f, 0x61, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x3f, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x29,
+	0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65,
+	0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x43, 0x6f, 0x6d, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x22, 0x64, 0x0a, 0x0c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x12, 0x26, 0x0a, 0x04, 0x64, 0x65, 0x66, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74,
+	0x72, 0x69, 0x63, 0x44, 0x65, 0x66, 0x52, 0x04, 0x64, 0x65, 0x66, 0x73, 0x12, 0x2c, 0x0a, 0x06,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x72,
+	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x56, 0x61, 0x6c,
+	0x75, 0x65, 0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x22, 0xf5, 0x01, 0x0a, 0x09, 0x4d,
+	0x65, 0x74, 0x72, 0x69, 0x63, 0x44, 0x65, 0x66, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x03,
+	0x74, 0x79, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74,
+	0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x54, 0x79, 0x70, 0x65, 0x52, 0x03,
+	0x74, 0x79, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x65, 0x6c, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x68, 0x65, 0x6c, 0x70, 0x12, 0x36, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c,
+	0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
+	0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x44, 0x65, 0x66, 0x2e, 0x4c, 0x61, 0x62, 0x65,
+	0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12,
+	0x16, 0x0a, 0x06, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x01, 0x52,
+	0x06, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65, 0x6c,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
+	0x38, 0x01, 0x22, 0x4b, 0x0a, 0x0b, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x56, 0x61, 0x6c, 0x75,
+	0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69,
+	0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x22,
+	0xad, 0x02, 0x0a, 0x0e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68,
+	0x6f, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02,
+	0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x03, 0x74, 0x79, 0x70, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65,
+	0x74, 0x72, 0x69, 0x63, 0x54, 0x79, 0x70, 0x65, 0x52, 0x03, 0x74, 0x79, 0x70, 0x12, 0x12, 0x0a,
+	0x04, 0x68, 0x65, 0x6c, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x65, 0x6c,
+	0x70, 0x12, 0x3b, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x23, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72,
+	0x69, 0x63, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12, 0x16,
+	0x0a, 0x06, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x01, 0x52, 0x06,
+	0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22,
+	0xef, 0x01, 0x0a, 0x08, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x61, 0x70, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x18,
+	0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d,
+	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x69,
+	0x6d, 0x65, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x10, 0x52,
+	0x0a, 0x74, 0x69, 0x6d, 0x65, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6c,
+	0x65, 0x76, 0x65, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65,
+	0x6c, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x73, 0x67,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x61,
+	0x74, 0x74, 0x72, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x61, 0x74, 0x74, 0x72,
+	0x73, 0x22, 0xbb, 0x0a, 0x0a, 0x04, 0x53, 0x70, 0x61, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x19,
+	0x0a, 0x08, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x07, 0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x70, 0x61,
+	0x6e, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x70, 0x61, 0x6e,
+	0x49, 0x64, 0x12, 0x24, 0x0a, 0x0e, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x73, 0x70, 0x61,
+	0x6e, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70, 0x61, 0x72, 0x65,
+	0x6e, 0x74, 0x53, 0x70, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65,
+	0x2e, 0x53, 0x70, 0x61, 0x6e, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12,
+	0x21, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x10, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74, 0x4d, 0x69, 0x63, 0x72,
+	0x6f, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x64, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x10, 0x52, 0x09, 0x65, 0x6e, 0x64, 0x4d, 0x69, 0x63, 0x72, 0x6f,
+	0x73, 0x12, 0x32, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18,
+	0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e,
+	0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x73, 0x12, 0x28, 0x0a, 0x05, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x18, 0x09,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53,
+	0x70, 0x61, 0x6e, 0x2e, 0x4c, 0x69, 0x6e, 0x6b, 0x52, 0x05, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x12,
+	0x2b, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x2c, 0x0a, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x72,
+	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2f, 0x0a, 0x07, 0x6c, 0x69,
+	0x62, 0x72, 0x61, 0x72, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x4c, 0x69, 0x62, 0x72, 0x61,
+	0x72, 0x79, 0x52, 0x07, 0x6c, 0x69, 0x62, 0x72, 0x61, 0x72, 0x79, 0x12, 0x32, 0x0a, 0x08, 0x72,
+	0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x52, 0x65, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x08, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12,
+	0x36, 0x0a, 0x17, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x15, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75,
+	0x74, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2c, 0x0a, 0x12, 0x64, 0x72, 0x6f, 0x70, 0x70,
+	0x65, 0x64, 0x5f, 0x6c, 0x69, 0x6e, 0x6b, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0f, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x10, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x4c, 0x69, 0x6e, 0x6b,
+	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x13, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64,
+	0x5f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x10, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x11, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x5f, 0x73,
+	0x70, 0x61, 0x6e, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x11, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x53, 0x70, 0x61, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x1a,
+	0xa6, 0x01, 0x0a, 0x04, 0x4c, 0x69, 0x6e, 0x6b, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x72, 0x61, 0x63,
+	0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x74, 0x72, 0x61, 0x63,
+	0x65, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x70, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x32, 0x0a, 0x0a,
+	0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73,
+	0x12, 0x36, 0x0a, 0x17, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x15, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62,
+	0x75, 0x74, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x1a, 0xa8, 0x01, 0x0a, 0x05, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x6d,
+	0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x10, 0x52, 0x0a, 0x74, 0x69, 0x6d,
+	0x65, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x32, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x52,
+	0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x12, 0x36, 0x0a, 0x17, 0x64,
+	0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65,
+	0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x15, 0x64, 0x72,
+	0x6f, 0x70, 0x70, 0x65, 0x64, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x1a, 0x73, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2d, 0x0a,
+	0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x2e, 0x43, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x22, 0x24, 0x0a, 0x04, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x09, 0x0a, 0x05, 0x55, 0x4e,
+	0x53, 0x45, 0x54, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01,
+	0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x02, 0x1a, 0x56, 0x0a, 0x07, 0x4c, 0x69, 0x62, 0x72,
+	0x61, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x75, 0x72, 0x6c, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x55, 0x72, 0x6c,
+	0x1a, 0x5d, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x55, 0x72, 0x6c, 0x12, 0x32, 0x0a, 0x0a, 0x61,
+	0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62,
+	0x75, 0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x22,
+	0x2a, 0x0a, 0x05, 0x53, 0x70, 0x61, 0x6e, 0x73, 0x12, 0x21, 0x0a, 0x04, 0x73, 0x70, 0x61, 0x6e,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65,
+	0x2e, 0x53, 0x70, 0x61, 0x6e, 0x52, 0x04, 0x73, 0x70, 0x61, 0x6e, 0x22, 0xf6, 0x03, 0x0a, 0x09,
+	0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2e, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x72, 0x75, 0x6e,
+	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x2e, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x1a, 0xa6, 0x03, 0x0a, 0x05,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x31, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x1d, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74,
+	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x2e, 0x54, 0x79,
+	0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x03, 0x6e, 0x75, 0x6d, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52, 0x03, 0x6e, 0x75, 0x6d, 0x12, 0x12, 0x0a, 0x03,
+	0x73, 0x74, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x03, 0x73, 0x74, 0x72,
+	0x12, 0x39, 0x0a, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23,
 	0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75,
-	0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x12, 0x36,
-	0x0a, 0x17, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62,
-	0x75, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x15, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74,
-	0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x1a, 0xa8, 0x01, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74,
-	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
-	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x6d, 0x69, 0x63,
-	0x72, 0x6f, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x10, 0x52, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x4d,
-	0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x32, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75,
-	0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74,
-	0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x52, 0x0a, 0x61,
-	0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x12, 0x36, 0x0a, 0x17, 0x64, 0x72, 0x6f,
-	0x70, 0x70, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x5f, 0x63,
-	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x15, 0x64, 0x72, 0x6f, 0x70,
-	0x70, 0x65, 0x64, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x75, 0x6e,
-	0x74, 0x1a, 0x73, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2d, 0x0a, 0x04, 0x63,
-	0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x72, 0x75, 0x6e, 0x74,
-	0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e,
-	0x43, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72,
-	0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
-	0x22, 0x24, 0x0a, 0x04, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x09, 0x0a, 0x05, 0x55, 0x4e, 0x53, 0x45,
-	0x54, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x12, 0x06,
-	0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x02, 0x1a, 0x56, 0x0a, 0x07, 0x4c, 0x69, 0x62, 0x72, 0x61, 0x72,
-	0x79, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12,
-	0x1d, 0x0a, 0x0a, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x55, 0x72, 0x6c, 0x1a, 0x5d,
-	0x0a, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x55, 0x72, 0x6c, 0x12, 0x32, 0x0a, 0x0a, 0x61, 0x74, 0x74,
-	0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e,
-	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74,
-	0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x22, 0x2a, 0x0a,
-	0x05, 0x53, 0x70, 0x61, 0x6e, 0x73, 0x12, 0x21, 0x0a, 0x04, 0x73, 0x70, 0x61, 0x6e, 0x18, 0x01,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53,
-	0x70, 0x61, 0x6e, 0x52, 0x04, 0x73, 0x70, 0x61, 0x6e, 0x22, 0xf6, 0x03, 0x0a, 0x09, 0x41, 0x74,
-	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2e, 0x0a, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69,
-	0x6d, 0x65, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c,
-	0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x1a, 0xa6, 0x03, 0x0a, 0x05, 0x56, 0x61,
-	0x6c, 0x75, 0x65, 0x12, 0x31, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0e, 0x32, 0x1d, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74, 0x74, 0x72,
-	0x69, 0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x2e, 0x54, 0x79, 0x70, 0x65,
-	0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x03, 0x6e, 0x75, 0x6d, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x04, 0x48, 0x00, 0x52, 0x03, 0x6e, 0x75, 0x6d, 0x12, 0x12, 0x0a, 0x03, 0x73, 0x74,
-	0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x03, 0x73, 0x74, 0x72, 0x12, 0x39,
-	0x0a, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x72,
-	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65,
-	0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x2e, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x4c, 0x69, 0x73,
-	0x74, 0x48, 0x00, 0x52, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x12, 0x39, 0x0a, 0x04, 0x73, 0x74, 0x72,
-	0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
-	0x65, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75,
-	0x65, 0x2e, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04,
-	0x73, 0x74, 0x72, 0x73, 0x1a, 0x20, 0x0a, 0x0a, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x4c, 0x69,
-	0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x04,
-	0x52, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x1a, 0x20, 0x0a, 0x0a, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67,
-	0x4c, 0x69, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x74, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03,
-	0x28, 0x09, 0x52, 0x04, 0x73, 0x74, 0x72, 0x73, 0x22, 0x7f, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65,
-	0x12, 0x0b, 0x0a, 0x07, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x10, 0x00, 0x12, 0x08, 0x0a,
-	0x04, 0x42, 0x4f, 0x4f, 0x4c, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x49, 0x4e, 0x54, 0x36, 0x34,
-	0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x46, 0x4c, 0x4f, 0x41, 0x54, 0x36, 0x34, 0x10, 0x03, 0x12,
-	0x0a, 0x0a, 0x06, 0x53, 0x54, 0x52, 0x49, 0x4e, 0x47, 0x10, 0x04, 0x12, 0x0c, 0x0a, 0x08, 0x42,
-	0x4f, 0x4f, 0x4c, 0x4c, 0x49, 0x53, 0x54, 0x10, 0x05, 0x12, 0x0d, 0x0a, 0x09, 0x49, 0x4e, 0x54,
-	0x36, 0x34, 0x4c, 0x49, 0x53, 0x54, 0x10, 0x06, 0x12, 0x0f, 0x0a, 0x0b, 0x46, 0x4c, 0x4f, 0x41,
-	0x54, 0x36, 0x34, 0x4c, 0x49, 0x53, 0x54, 0x10, 0x07, 0x12, 0x0e, 0x0a, 0x0a, 0x53, 0x54, 0x52,
-	0x49, 0x4e, 0x47, 0x4c, 0x49, 0x53, 0x54, 0x10, 0x08, 0x42, 0x07, 0x0a, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x2a, 0x31, 0x0a, 0x0b, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x54, 0x79, 0x70,
-	0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x6e, 0x73, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x65, 0x64,
-	0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x65, 0x61, 0x70, 0x10, 0x01, 0x12, 0x07, 0x0a, 0x03,
-	0x43, 0x50, 0x55, 0x10, 0x02, 0x2a, 0x47, 0x0a, 0x0c, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x53,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e,
-	0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x48, 0x45, 0x41, 0x4c, 0x54, 0x48, 0x59, 0x10, 0x01, 0x12,
-	0x0d, 0x0a, 0x09, 0x55, 0x4e, 0x48, 0x45, 0x41, 0x4c, 0x54, 0x48, 0x59, 0x10, 0x02, 0x12, 0x0e,
-	0x0a, 0x0a, 0x54, 0x45, 0x52, 0x4d, 0x49, 0x4e, 0x41, 0x54, 0x45, 0x44, 0x10, 0x03, 0x2a, 0x40,
-	0x0a, 0x0a, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07,
-	0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x4f, 0x55,
-	0x4e, 0x54, 0x45, 0x52, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x47, 0x41, 0x55, 0x47, 0x45, 0x10,
-	0x02, 0x12, 0x0d, 0x0a, 0x09, 0x48, 0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x10, 0x03,
-	0x2a, 0x5d, 0x0a, 0x08, 0x53, 0x70, 0x61, 0x6e, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x0f, 0x0a, 0x0b,
-	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0c, 0x0a,
-	0x08, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x4e, 0x41, 0x4c, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x53,
-	0x45, 0x52, 0x56, 0x45, 0x52, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x4c, 0x49, 0x45, 0x4e,
-	0x54, 0x10, 0x03, 0x12, 0x0c, 0x0a, 0x08, 0x50, 0x52, 0x4f, 0x44, 0x55, 0x43, 0x45, 0x52, 0x10,
-	0x04, 0x12, 0x0c, 0x0a, 0x08, 0x43, 0x4f, 0x4e, 0x53, 0x55, 0x4d, 0x45, 0x52, 0x10, 0x05, 0x42,
-	0x30, 0x5a, 0x2e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x53, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x57, 0x65, 0x61, 0x76, 0x65, 0x72, 0x2f, 0x77, 0x65, 0x61, 0x76,
-	0x65, 0x72, 0x2f, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x2e, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x4c,
+	0x69, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x12, 0x39, 0x0a, 0x04, 0x73,
+	0x74, 0x72, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x72, 0x75, 0x6e, 0x74,
+	0x69, 0x6d, 0x65, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61,
+	0x6c, 0x75, 0x65, 0x2e, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x00,
+	0x52, 0x04, 0x73, 0x74, 0x72, 0x73, 0x1a, 0x20, 0x0a, 0x0a, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72,
+	0x4c, 0x69, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x04, 0x52, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x1a, 0x20, 0x0a, 0x0a, 0x53, 0x74, 0x72, 0x69,
+	0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x74, 0x72, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x73, 0x74, 0x72, 0x73, 0x22, 0x7f, 0x0a, 0x04, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x10, 0x00, 0x12,
+	0x08, 0x0a, 0x04, 0x42, 0x4f, 0x4f, 0x4c, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x49, 0x4e, 0x54,
+	0x36, 0x34, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x46, 0x4c, 0x4f, 0x41, 0x54, 0x36, 0x34, 0x10,
+	0x03, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x54, 0x52, 0x49, 0x4e, 0x47, 0x10, 0x04, 0x12, 0x0c, 0x0a,
+	0x08, 0x42, 0x4f, 0x4f, 0x4c, 0x4c, 0x49, 0x53, 0x54, 0x10, 0x05, 0x12, 0x0d, 0x0a, 0x09, 0x49,
+	0x4e, 0x54, 0x36, 0x34, 0x4c, 0x49, 0x53, 0x54, 0x10, 0x06, 0x12, 0x0f, 0x0a, 0x0b, 0x46, 0x4c,
+	0x4f, 0x41, 0x54, 0x36, 0x34, 0x4c, 0x49, 0x53, 0x54, 0x10, 0x07, 0x12, 0x0e, 0x0a, 0x0a, 0x53,
+	0x54, 0x52, 0x49, 0x4e, 0x47, 0x4c, 0x49, 0x53, 0x54, 0x10, 0x08, 0x42, 0x07, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x2a, 0x56, 0x0a, 0x0b, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x6e, 0x73, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69,
+	0x65, 0x64, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x65, 0x61, 0x70, 0x10, 0x01, 0x12, 0x07,
+	0x0a, 0x03, 0x43, 0x50, 0x55, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x47, 0x6f, 0x72, 0x6f, 0x75,
+	0x74, 0x69, 0x6e, 0x65, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x10,
+	0x04, 0x12, 0x09, 0x0a, 0x05, 0x4d, 0x75, 0x74, 0x65, 0x78, 0x10, 0x05, 0x2a, 0x47, 0x0a, 0x0c,
+	0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07,
+	0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x48, 0x45, 0x41,
+	0x4c, 0x54, 0x48, 0x59, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x55, 0x4e, 0x48, 0x45, 0x41, 0x4c,
+	0x54, 0x48, 0x59, 0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x54, 0x45, 0x52, 0x4d, 0x49, 0x4e, 0x41,
+	0x54, 0x45, 0x44, 0x10, 0x03, 0x2a, 0x40, 0x0a, 0x0a, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x10, 0x00,
+	0x12, 0x0b, 0x0a, 0x07, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x45, 0x52, 0x10, 0x01, 0x12, 0x09, 0x0a,
+	0x05, 0x47, 0x41, 0x55, 0x47, 0x45, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x48, 0x49, 0x53, 0x54,
+	0x4f, 0x47, 0x52, 0x41, 0x4d, 0x10, 0x03, 0x2a, 0x5d, 0x0a, 0x08, 0x53, 0x70, 0x61, 0x6e, 0x4b,
+	0x69, 0x6e, 0x64, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49,
+	0x45, 0x44, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x4e, 0x41, 0x4c,
+	0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x45, 0x52, 0x56, 0x45, 0x52, 0x10, 0x02, 0x12, 0x0a,
+	0x0a, 0x06, 0x43, 0x4c, 0x49, 0x45, 0x4e, 0x54, 0x10, 0x03, 0x12, 0x0c, 0x0a, 0x08, 0x50, 0x52,
+	0x4f, 0x44, 0x55, 0x43, 0x45, 0x52, 0x10, 0x04, 0x12, 0x0c, 0x0a, 0x08, 0x43, 0x4f, 0x4e, 0x53,
+	0x55, 0x4d, 0x45, 0x52, 0x10, 0x05, 0x42, 0x30, 0x5a, 0x2e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
+	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x57, 0x65, 0x61, 0x76,
+	0x65, 0x72, 0x2f, 0x77, 0x65, 0x61, 0x76, 0x65, 0x72, 0x2f, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
+	0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -4337,7 +4688,7 @@ func file_runtime_protos_runtime_proto_rawDescGZIP() []byte {
 }
 
 var file_runtime_protos_runtime_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
-var file_runtime_protos_runtime_proto_msgTypes = make([]protoimpl.MessageInfo, 49)
+var file_runtime_protos_runtime_proto_msgTypes = make([]protoimpl.MessageInfo, 55)
 var file_runtime_protos_runtime_proto_goTypes = []interface{}{
 	(ProfileType)(0),                         // 0: runtime.ProfileType
 	(HealthStatus)(0),                        // 1: runtime.HealthStatus
@@ -4346,117 +4697,130 @@ var file_runtime_protos_runtime_proto_goTypes = []interface{}{
 	(Span_Status_Code)(0),                    // 4: runtime.Span.Status.Code
 	(Attribute_Value_Type)(0),                // 5: runtime.Attribute.Value.Type
 	(*EnvelopeMsg)(nil),                      // 6: runtime.EnvelopeMsg
-	(*WeaveletMsg)(nil),                      // 7: runtime.WeaveletMsg
-	(*Deployment)(nil),                       // 8: runtime.Deployment
-	(*ComponentGroup)(nil),                   // 9: runtime.ComponentGroup
-	(*AppConfig)(nil),                        // 10: runtime.AppConfig
-	(*ColocationGroup)(nil),                  // 11: runtime.ColocationGroup
-	(*WeaveletInfo)(nil),                     // 12: runtime.WeaveletInfo
-	(*GetRoutingInfo)(nil),                   // 13: runtime.GetRoutingInfo
-	(*RoutingInfo)(nil),                      // 14: runtime.RoutingInfo
-	(*RunProfiling)(nil),                     // 15: runtime.RunProfiling
-	(*Profile)(nil),                          // 16: runtime.Profile
-	(*HealthReport)(nil),                     // 17: runtime.HealthReport
-	(*ReplicaToRegister)(nil),                // 18: runtime.ReplicaToRegister
-	(*ComponentToStart)(nil),                 // 19: runtime.ComponentToStart
-	(*GetComponentsToStart)(nil),             // 20: runtime.GetComponentsToStart
-	(*ComponentsToStart)(nil),                // 21: runtime.ComponentsToStart
-	(*GetAddressRequest)(nil),                // 22: runtime.GetAddressRequest
-	(*GetAddressReply)(nil),                  // 23: runtime.GetAddressReply
-	(*ExportListenerRequest)(nil),            // 24: runtime.ExportListenerRequest
-	(*ExportListenerReply)(nil),              // 25: runtime.ExportListenerReply
-	(*Listener)(nil),                         // 26: runtime.Listener
-	(*Listeners)(nil),                        // 27: runtime.Listeners
-	(*Assignment)(nil),                       // 28: runtime.Assignment
-	(*WeaveletLoadReport)(nil),               // 29: runtime.WeaveletLoadReport
-	(*MetricUpdate)(nil),                     // 30: runtime.MetricUpdate
-	(*MetricDef)(nil),                        // 31: runtime.MetricDef
-	(*MetricValue)(nil),                      // 32: runtime.MetricValue
-	(*MetricSnapshot)(nil),                   // 33: runtime.MetricSnapshot
-	(*LogEntry)(nil),                         // 34: runtime.LogEntry
-	(*Span)(nil),                             // 35: runtime.Span
-	(*Spans)(nil),                            // 36: runtime.Spans
-	(*Attribute)(nil),                        // 37: runtime.Attribute
-	nil,                                      // 38: runtime.AppConfig.SectionsEntry
-	nil,                                      // 39: runtime.WeaveletInfo.SectionsEntry
-	(*Assignment_Slice)(nil),                 // 40: runtime.Assignment.Slice
-	(*WeaveletLoadReport_ComponentLoad)(nil), // 41: runtime.WeaveletLoadReport.ComponentLoad
-	nil,                                      // 42: runtime.WeaveletLoadReport.LoadsEntry
-	(*WeaveletLoadReport_ComponentLoad_SliceLoad)(nil),              // 43: runtime.WeaveletLoadReport.ComponentLoad.SliceLoad
-	(*WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad)(nil), // 44: runtime.WeaveletLoadReport.ComponentLoad.SliceLoad.SubsliceLoad
-	nil,                                // 45: runtime.MetricDef.LabelsEntry
-	nil,                                // 46: runtime.MetricSnapshot.LabelsEntry
-	(*Span_Link)(nil),                  // 47: runtime.Span.Link
-	(*Span_Event)(nil),                 // 48: runtime.Span.Event
-	(*Span_Status)(nil),                // 49: runtime.Span.Status
-	(*Span_Library)(nil),               // 50: runtime.Span.Library
-	(*Span_Resource)(nil),              // 51: runtime.Span.Resource
-	(*Attribute_Value)(nil),            // 52: runtime.Attribute.Value
-	(*Attribute_Value_NumberList)(nil), // 53: runtime.Attribute.Value.NumberList
-	(*Attribute_Value_StringList)(nil), // 54: runtime.Attribute.Value.StringList
+	(*PipeHandshake)(nil),                    // 7: runtime.PipeHandshake
+	(*Drain)(nil),                            // 8: runtime.Drain
+	(*ConfigUpdate)(nil),                     // 9: runtime.ConfigUpdate
+	(*WeaveletMsg)(nil),                      // 10: runtime.WeaveletMsg
+	(*Deployment)(nil),                       // 11: runtime.Deployment
+	(*ComponentGroup)(nil),                   // 12: runtime.ComponentGroup
+	(*AppConfig)(nil),                        // 13: runtime.AppConfig
+	(*ResourceLimits)(nil),                   // 14: runtime.ResourceLimits
+	(*ColocationGroup)(nil),                  // 15: runtime.ColocationGroup
+	(*WeaveletInfo)(nil),                     // 16: runtime.WeaveletInfo
+	(*GetRoutingInfo)(nil),                   // 17: runtime.GetRoutingInfo
+	(*RoutingInfo)(nil),                      // 18: runtime.RoutingInfo
+	(*RunProfiling)(nil),                     // 19: runtime.RunProfiling
+	(*Profile)(nil),                          // 20: runtime.Profile
+	(*HealthReport)(nil),                     // 21: runtime.HealthReport
+	(*ReplicaToRegister)(nil),                // 22: runtime.ReplicaToRegister
+	(*ComponentToStart)(nil),                 // 23: runtime.ComponentToStart
+	(*GetComponentsToStart)(nil),             // 24: runtime.GetComponentsToStart
+	(*ComponentsToStart)(nil),                // 25: runtime.ComponentsToStart
+	(*GetAddressRequest)(nil),                // 26: runtime.GetAddressRequest
+	(*GetAddressReply)(nil),                  // 27: runtime.GetAddressReply
+	(*ExportListenerRequest)(nil),            // 28: runtime.ExportListenerRequest
+	(*ExportListenerReply)(nil),              // 29: runtime.ExportListenerReply
+	(*Listener)(nil),                         // 30: runtime.Listener
+	(*Listeners)(nil),                        // 31: runtime.Listeners
+	(*Assignment)(nil),                       // 32: runtime.Assignment
+	(*WeaveletLoadReport)(nil),               // 33: runtime.WeaveletLoadReport
+	(*MetricUpdate)(nil),                     // 34: runtime.MetricUpdate
+	(*MetricDef)(nil),                        // 35: runtime.MetricDef
+	(*MetricValue)(nil),                      // 36: runtime.MetricValue
+	(*MetricSnapshot)(nil),                   // 37: runtime.MetricSnapshot
+	(*LogEntry)(nil),                         // 38: runtime.LogEntry
+	(*Span)(nil),                             // 39: runtime.Span
+	(*Spans)(nil),                            // 40: runtime.Spans
+	(*Attribute)(nil),                        // 41: runtime.Attribute
+	nil,                                      // 42: runtime.ConfigUpdate.SectionsEntry
+	nil,                                      // 43: runtime.AppConfig.ResourceLimitsEntry
+	nil,                                      // 44: runtime.AppConfig.SectionsEntry
+	nil,                                      // 45: runtime.WeaveletInfo.SectionsEntry
+	(*Assignment_Slice)(nil),                 // 46: runtime.Assignment.Slice
+	(*WeaveletLoadReport_ComponentLoad)(nil), // 47: runtime.WeaveletLoadReport.ComponentLoad
+	nil,                                      // 48: runtime.WeaveletLoadReport.LoadsEntry
+	(*WeaveletLoadReport_ComponentLoad_SliceLoad)(nil),              // 49: runtime.WeaveletLoadReport.ComponentLoad.SliceLoad
+	(*WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad)(nil), // 50: runtime.WeaveletLoadReport.ComponentLoad.SliceLoad.SubsliceLoad
+	nil,                                // 51: runtime.MetricDef.LabelsEntry
+	nil,                                // 52: runtime.MetricSnapshot.LabelsEntry
+	(*Span_Link)(nil),                  // 53: runtime.Span.Link
+	(*Span_Event)(nil),                 // 54: runtime.Span.Event
+	(*Span_Status)(nil),                // 55: runtime.Span.Status
+	(*Span_Library)(nil),               // 56: runtime.Span.Library
+	(*Span_Resource)(nil),              // 57: runtime.Span.Resource
+	(*Attribute_Value)(nil),            // 58: runtime.Attribute.Value
+	(*Attribute_Value_NumberList)(nil), // 59: runtime.Attribute.Value.NumberList
+	(*Attribute_Value_StringList)(nil), // 60: runtime.Attribute.Value.StringList
 }
 var file_runtime_protos_runtime_proto_depIdxs = []int32{
-	15, // 0: runtime.EnvelopeMsg.run_profiling:type_name -> runtime.RunProfiling
-	12, // 1: runtime.EnvelopeMsg.weavelet_info:type_name -> runtime.WeaveletInfo
-	14, // 2: runtime.EnvelopeMsg.routing_info:type_name -> runtime.RoutingInfo
-	21, // 3: runtime.EnvelopeMsg.components_to_start:type_name -> runtime.ComponentsToStart
-	23, // 4: runtime.EnvelopeMsg.get_address_reply:type_name -> runtime.GetAddressReply
-	25, // 5: runtime.EnvelopeMsg.export_listener_reply:type_name -> runtime.ExportListenerReply
-	30, // 6: runtime.WeaveletMsg.metrics:type_name -> runtime.MetricUpdate
-	34, // 7: runtime.WeaveletMsg.log_entry:type_name -> runtime.LogEntry
-	36, // 8: runtime.WeaveletMsg.trace_spans:type_name -> runtime.Spans
-	19, // 9: runtime.WeaveletMsg.component_to_start:type_name -> runtime.ComponentToStart
-	18, // 10: runtime.WeaveletMsg.replica_to_register:type_name -> runtime.ReplicaToRegister
-	29, // 11: runtime.WeaveletMsg.load_report:type_name -> runtime.WeaveletLoadReport
-	17, // 12: runtime.WeaveletMsg.health_report:type_name -> runtime.HealthReport
-	16, // 13: runtime.WeaveletMsg.profile:type_name -> runtime.Profile
-	13, // 14: runtime.WeaveletMsg.get_routing_info:type_name -> runtime.GetRoutingInfo
-	20, // 15: runtime.WeaveletMsg.get_components_to_start:type_name -> runtime.GetComponentsToStart
-	22, // 16: runtime.WeaveletMsg.get_address_request:type_name -> runtime.GetAddressRequest
-	24, // 17: runtime.WeaveletMsg.export_listener_request:type_name -> runtime.ExportListenerRequest
-	10, // 18: runtime.Deployment.app:type_name -> runtime.AppConfig
-	9,  // 19: runtime.AppConfig.same_process:type_name -> runtime.ComponentGroup
-	38, // 20: runtime.AppConfig.sections:type_name -> runtime.AppConfig.SectionsEntry
-	11, // 21: runtime.WeaveletInfo.group:type_name -> runtime.ColocationGroup
-	9,  // 22: runtime.WeaveletInfo.same_process:type_name -> runtime.ComponentGroup
-	39, // 23: runtime.WeaveletInfo.sections:type_name -> runtime.WeaveletInfo.SectionsEntry
-	28, // 24: runtime.RoutingInfo.assignments:type_name -> runtime.Assignment
-	0,  // 25: runtime.RunProfiling.profile_type:type_name -> runtime.ProfileType
-	1,  // 26: runtime.HealthReport.status:type_name -> runtime.HealthStatus
-	26, // 27: runtime.ExportListenerRequest.listener:type_name -> runtime.Listener
-	26, // 28: runtime.Listeners.listeners:type_name -> runtime.Listener
-	40, // 29: runtime.Assignment.slices:type_name -> runtime.Assignment.Slice
-	42, // 30: runtime.WeaveletLoadReport.loads:type_name -> runtime.WeaveletLoadReport.LoadsEntry
-	31, // 31: runtime.MetricUpdate.defs:type_name -> runtime.MetricDef
-	32, // 32: runtime.MetricUpdate.values:type_name -> runtime.MetricValue
-	2,  // 33: runtime.MetricDef.typ:type_name -> runtime.MetricType
-	45, // 34: runtime.MetricDef.labels:type_name -> runtime.MetricDef.LabelsEntry
-	2,  // 35: runtime.MetricSnapshot.typ:type_name -> runtime.MetricType
-	46, // 36: runtime.MetricSnapshot.labels:type_name -> runtime.MetricSnapshot.LabelsEntry
-	3,  // 37: runtime.Span.kind:type_name -> runtime.SpanKind
-	37, // 38: runtime.Span.attributes:type_name -> runtime.Attribute
-	47, // 39: runtime.Span.links:type_name -> runtime.Span.Link
-	48, // 40: runtime.Span.events:type_name -> runtime.Span.Event
-	49, // 41: runtime.Span.status:type_name -> runtime.Span.Status
-	50, // 42: runtime.Span.library:type_name -> runtime.Span.Library
-	51, // 43: runtime.Span.resource:type_name -> runtime.Span.Resource
-	35, // 44: runtime.Spans.span:type_name -> runtime.Span
-	52, // 45: runtime.Attribute.value:type_name -> runtime.Attribute.Value
-	43, // 46: runtime.WeaveletLoadReport.ComponentLoad.load:type_name -> runtime.WeaveletLoadReport.ComponentLoad.SliceLoad
-	41, // 47: runtime.WeaveletLoadReport.LoadsEntry.value:type_name -> runtime.WeaveletLoadReport.ComponentLoad
-	44, // 48: runtime.WeaveletLoadReport.ComponentLoad.SliceLoad.splits:type_name -> runtime.WeaveletLoadReport.ComponentLoad.SliceLoad.SubsliceLoad
-	37, // 49: runtime.Span.Link.attributes:type_name -> runtime.Attribute
-	37, // 50: runtime.Span.Event.attributes:type_name -> runtime.Attribute
-	4,  // 51: runtime.Span.Status.code:type_name -> runtime.Span.Status.Code
-	37, // 52: runtime.Span.Resource.attributes:type_name -> runtime.Attribute
-	5,  // 53: runtime.Attribute.Value.type:type_name -> runtime.Attribute.Value.Type
-	53, // 54: runtime.Attribute.Value.nums:type_name -> runtime.Attribute.Value.NumberList
-	54, // 55: runtime.Attribute.Value.strs:type_name -> runtime.Attribute.Value.StringList
-	56, // [56:56] is the sub-list for method output_type
-	56, // [56:56] is the sub-list for method input_type
-	56, // [56:56] is the sub-list for extension type_name
-	56, // [56:56] is the sub-list for extension extendee
-	0,  // [0:56] is the sub-list for field type_name
+	19, // 0: runtime.EnvelopeMsg.run_profiling:type_name -> runtime.RunProfiling
+	16, // 1: runtime.EnvelopeMsg.weavelet_info:type_name -> runtime.WeaveletInfo
+	18, // 2: runtime.EnvelopeMsg.routing_info:type_name -> runtime.RoutingInfo
+	25, // 3: runtime.EnvelopeMsg.components_to_start:type_name -> runtime.ComponentsToStart
+	27, // 4: runtime.EnvelopeMsg.get_address_reply:type_name -> runtime.GetAddressReply
+	29, // 5: runtime.EnvelopeMsg.export_listener_reply:type_name -> runtime.ExportListenerReply
+	9,  // 6: runtime.EnvelopeMsg.config_update:type_name -> runtime.ConfigUpdate
+	8,  // 7: runtime.EnvelopeMsg.drain:type_name -> runtime.Drain
+	7,  // 8: runtime.EnvelopeMsg.handshake:type_name -> runtime.PipeHandshake
+	42, // 9: runtime.ConfigUpdate.sections:type_name -> runtime.ConfigUpdate.SectionsEntry
+	34, // 10: runtime.WeaveletMsg.metrics:type_name -> runtime.MetricUpdate
+	38, // 11: runtime.WeaveletMsg.log_entry:type_name -> runtime.LogEntry
+	40, // 12: runtime.WeaveletMsg.trace_spans:type_name -> runtime.Spans
+	23, // 13: runtime.WeaveletMsg.component_to_start:type_name -> runtime.ComponentToStart
+	22, // 14: runtime.WeaveletMsg.replica_to_register:type_name -> runtime.ReplicaToRegister
+	33, // 15: runtime.WeaveletMsg.load_report:type_name -> runtime.WeaveletLoadReport
+	21, // 16: runtime.WeaveletMsg.health_report:type_name -> runtime.HealthReport
+	20, // 17: runtime.WeaveletMsg.profile:type_name -> runtime.Profile
+	17, // 18: runtime.WeaveletMsg.get_routing_info:type_name -> runtime.GetRoutingInfo
+	24, // 19: runtime.WeaveletMsg.get_components_to_start:type_name -> runtime.GetComponentsToStart
+	26, // 20: runtime.WeaveletMsg.get_address_request:type_name -> runtime.GetAddressRequest
+	28, // 21: runtime.WeaveletMsg.export_listener_request:type_name -> runtime.ExportListenerRequest
+	7,  // 22: runtime.WeaveletMsg.handshake:type_name -> runtime.PipeHandshake
+	13, // 23: runtime.Deployment.app:type_name -> runtime.AppConfig
+	12, // 24: runtime.AppConfig.same_process:type_name -> runtime.ComponentGroup
+	43, // 25: runtime.AppConfig.resource_limits:type_name -> runtime.AppConfig.ResourceLimitsEntry
+	44, // 26: runtime.AppConfig.sections:type_name -> runtime.AppConfig.SectionsEntry
+	15, // 27: runtime.WeaveletInfo.group:type_name -> runtime.ColocationGroup
+	12, // 28: runtime.WeaveletInfo.same_process:type_name -> runtime.ComponentGroup
+	45, // 29: runtime.WeaveletInfo.sections:type_name -> runtime.WeaveletInfo.SectionsEntry
+	32, // 30: runtime.RoutingInfo.assignments:type_name -> runtime.Assignment
+	0,  // 31: runtime.RunProfiling.profile_type:type_name -> runtime.ProfileType
+	1,  // 32: runtime.HealthReport.status:type_name -> runtime.HealthStatus
+	30, // 33: runtime.ExportListenerRequest.listener:type_name -> runtime.Listener
+	30, // 34: runtime.Listeners.listeners:type_name -> runtime.Listener
+	46, // 35: runtime.Assignment.slices:type_name -> runtime.Assignment.Slice
+	48, // 36: runtime.WeaveletLoadReport.loads:type_name -> runtime.WeaveletLoadReport.LoadsEntry
+	35, // 37: runtime.MetricUpdate.defs:type_name -> runtime.MetricDef
+	36, // 38: runtime.MetricUpdate.values:type_name -> runtime.MetricValue
+	2,  // 39: runtime.MetricDef.typ:type_name -> runtime.MetricType
+	51, // 40: runtime.MetricDef.labels:type_name -> runtime.MetricDef.LabelsEntry
+	2,  // 41: runtime.MetricSnapshot.typ:type_name -> runtime.MetricType
+	52, // 42: runtime.MetricSnapshot.labels:type_name -> runtime.MetricSnapshot.LabelsEntry
+	3,  // 43: runtime.Span.kind:type_name -> runtime.SpanKind
+	41, // 44: runtime.Span.attributes:type_name -> runtime.Attribute
+	53, // 45: runtime.Span.links:type_name -> runtime.Span.Link
+	54, // 46: runtime.Span.events:type_name -> runtime.Span.Event
+	55, // 47: runtime.Span.status:type_name -> runtime.Span.Status
+	56, // 48: runtime.Span.library:type_name -> runtime.Span.Library
+	57, // 49: runtime.Span.resource:type_name -> runtime.Span.Resource
+	39, // 50: runtime.Spans.span:type_name -> runtime.Span
+	58, // 51: runtime.Attribute.value:type_name -> runtime.Attribute.Value
+	14, // 52: runtime.AppConfig.ResourceLimitsEntry.value:type_name -> runtime.ResourceLimits
+	49, // 53: runtime.WeaveletLoadReport.ComponentLoad.load:type_name -> runtime.WeaveletLoadReport.ComponentLoad.SliceLoad
+	47, // 54: runtime.WeaveletLoadReport.LoadsEntry.value:type_name -> runtime.WeaveletLoadReport.ComponentLoad
+	50, // 55: runtime.WeaveletLoadReport.ComponentLoad.SliceLoad.splits:type_name -> runtime.WeaveletLoadReport.ComponentLoad.SliceLoad.SubsliceLoad
+	41, // 56: runtime.Span.Link.attributes:type_name -> runtime.Attribute
+	41, // 57: runtime.Span.Event.attributes:type_name -> runtime.Attribute
+	4,  // 58: runtime.Span.Status.code:type_name -> runtime.Span.Status.Code
+	41, // 59: runtime.Span.Resource.attributes:type_name -> runtime.Attribute
+	5,  // 60: runtime.Attribute.Value.type:type_name -> runtime.Attribute.Value.Type
+	59, // 61: runtime.Attribute.Value.nums:type_name -> runtime.Attribute.Value.NumberList
+	60, // 62: runtime.Attribute.Value.strs:type_name -> runtime.Attribute.Value.StringList
+	63, // [63:63] is the sub-list for method output_type
+	63, // [63:63] is the sub-list for method input_type
+	63, // [63:63] is the sub-list for extension type_name
+	63, // [63:63] is the sub-list for extension extendee
+	0,  // [0:63] is the sub-list for field type_name
 }
 
 func init() { file_runtime_protos_runtime_proto_init() }
@@ -4478,7 +4842,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*WeaveletMsg); i {
+			switch v := v.(*PipeHandshake); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4490,7 +4854,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Deployment); i {
+			switch v := v.(*Drain); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4502,7 +4866,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ComponentGroup); i {
+			switch v := v.(*ConfigUpdate); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4514,7 +4878,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AppConfig); i {
+			switch v := v.(*WeaveletMsg); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4526,7 +4890,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ColocationGroup); i {
+			switch v := v.(*Deployment); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4538,7 +4902,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*WeaveletInfo); i {
+			switch v := v.(*ComponentGroup); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4550,7 +4914,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetRoutingInfo); i {
+			switch v := v.(*AppConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4562,7 +4926,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RoutingInfo); i {
+			switch v := v.(*ResourceLimits); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4574,7 +4938,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RunProfiling); i {
+			switch v := v.(*ColocationGroup); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4586,7 +4950,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Profile); i {
+			switch v := v.(*WeaveletInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4598,7 +4962,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*HealthReport); i {
+			switch v := v.(*GetRoutingInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4610,7 +4974,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReplicaToRegister); i {
+			switch v := v.(*RoutingInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4622,7 +4986,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ComponentToStart); i {
+			switch v := v.(*RunProfiling); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4634,7 +4998,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetComponentsToStart); i {
+			switch v := v.(*Profile); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4646,7 +5010,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ComponentsToStart); i {
+			switch v := v.(*HealthReport); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4658,7 +5022,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetAddressRequest); i {
+			switch v := v.(*ReplicaToRegister); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4670,7 +5034,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetAddressReply); i {
+			switch v := v.(*ComponentToStart); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4682,7 +5046,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ExportListenerRequest); i {
+			switch v := v.(*GetComponentsToStart); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4694,7 +5058,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ExportListenerReply); i {
+			switch v := v.(*ComponentsToStart); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4706,7 +5070,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Listener); i {
+			switch v := v.(*GetAddressRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4718,7 +5082,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Listeners); i {
+			switch v := v.(*GetAddressReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4730,7 +5094,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Assignment); i {
+			switch v := v.(*ExportListenerRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4742,7 +5106,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*WeaveletLoadReport); i {
+			switch v := v.(*ExportListenerReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4754,7 +5118,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MetricUpdate); i {
+			switch v := v.(*Listener); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4766,7 +5130,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MetricDef); i {
+			switch v := v.(*Listeners); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4778,7 +5142,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MetricValue); i {
+			switch v := v.(*Assignment); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4790,7 +5154,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MetricSnapshot); i {
+			switch v := v.(*WeaveletLoadReport); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4802,7 +5166,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LogEntry); i {
+			switch v := v.(*MetricUpdate); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4814,7 +5178,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Span); i {
+			switch v := v.(*MetricDef); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4826,7 +5190,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Spans); i {
+			switch v := v.(*MetricValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4838,7 +5202,31 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Attribute); i {
+			switch v := v.(*MetricSnapshot); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_protos_runtime_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LogEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_protos_runtime_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Span); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4850,7 +5238,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Assignment_Slice); i {
+			switch v := v.(*Spans); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -4862,6 +5250,30 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 		file_runtime_protos_runtime_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Attribute); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_protos_runtime_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Assignment_Slice); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_protos_runtime_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*WeaveletLoadReport_ComponentLoad); i {
 			case 0:
 				return &v.state
@@ -4873,7 +5285,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_protos_runtime_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*WeaveletLoadReport_ComponentLoad_SliceLoad); i {
 			case 0:
 				return &v.state
@@ -4885,7 +5297,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_protos_runtime_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*WeaveletLoadReport_ComponentLoad_SliceLoad_SubsliceLoad); i {
 			case 0:
 				return &v.state
@@ -4897,7 +5309,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_protos_runtime_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Link); i {
 			case 0:
 				return &v.state
@@ -4909,7 +5321,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_protos_runtime_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Event); i {
 			case 0:
 				return &v.state
@@ -4921,7 +5333,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_protos_runtime_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Status); i {
 			case 0:
 				return &v.state
@@ -4933,7 +5345,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_protos_runtime_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Library); i {
 			case 0:
 				return &v.state
@@ -4945,7 +5357,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_protos_runtime_proto_msgTypes[51].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Resource); i {
 			case 0:
 				return &v.state
@@ -4957,7 +5369,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_protos_runtime_proto_msgTypes[52].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Attribute_Value); i {
 			case 0:
 				return &v.state
@@ -4969,7 +5381,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_protos_runtime_proto_msgTypes[53].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Attribute_Value_NumberList); i {
 			case 0:
 				return &v.state
@@ -4981,7 +5393,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_protos_runtime_proto_msgTypes[54].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Attribute_Value_StringList); i {
 			case 0:
 				return &v.state
@@ -4994,7 +5406,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 	}
-	file_runtime_protos_runtime_proto_msgTypes[46].OneofWrappers = []interface{}{
+	file_runtime_protos_runtime_proto_msgTypes[52].OneofWrappers = []interface{}{
 		(*Attribute_Value_Num)(nil),
 		(*Attribute_Value_Str)(nil),
 		(*Attribute_Value_Nums)(nil),
@@ -5006,7 +5418,7 @@ func file_runtime_protos_runtime_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_runtime_protos_runtime_proto_rawDesc,
 			NumEnums:      6,
-			NumMessages:   49,
+			NumMessages:   55,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
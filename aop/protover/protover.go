@@ -0,0 +1,167 @@
+// Package protover negotiates the protocol version of the clients, and adapts
+// the messages of the older clients, so that a server can be updated ahead
+// of the clients.
+//
+// The protocol version is an integer, incremented by every change of a
+// message the older clients can't handle as is (e.g., a field whose meaning
+// changed, or a new enum value). The client sends its version with its build
+// at login, which rejects it if it's not within the Policy of the servers,
+// with a message telling its player to upgrade; the version is then carried
+// by the session token.
+//
+// The changes of a message are bridged by a Shim, registered for the version
+// introducing them: the messages of the clients older than that version are
+// upgraded before they're handled, and the messages sent to them downgraded.
+package protover
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrDrop is returned by a downgrade for a message the older clients don't
+// know, and must not receive.
+var ErrDrop = errors.New("protover: message dropped for an older client")
+
+// Policy is the range of protocol versions the servers support.
+type Policy struct {
+	Current int    // version of the servers, 0 for no check
+	Min     int    // oldest version supported; the clients not sending theirs are version 0
+	Message string // shown to the players of the clients rejected
+	URL     string // where to get a supported client
+}
+
+// IncompatibleError is the error of a client whose version isn't supported.
+type IncompatibleError struct {
+	Version int
+	Policy  Policy
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("protover: client protocol %d not in [%d, %d]", e.Version, e.Policy.min(), e.Policy.Current)
+}
+
+// Upgrade returns whether the client must be upgraded, rather than the
+// servers (a client newer than the servers, e.g. during their rollout, can't
+// be helped by an upgrade).
+func (e *IncompatibleError) Upgrade() bool {
+	return e.Version < e.Policy.min()
+}
+
+func (p Policy) min() int {
+	if p.Min > p.Current {
+		return p.Current
+	}
+	return p.Min
+}
+
+// Check returns an *IncompatibleError if a client protocol version isn't
+// supported.
+func (p Policy) Check(version int) error {
+	if p.Current <= 0 {
+		return nil
+	}
+	if version < p.min() || version > p.Current {
+		return &IncompatibleError{Version: version, Policy: p}
+	}
+	return nil
+}
+
+// Shim adapts a message to the clients older than the protocol version
+// changing it. Upgrade and Downgrade may be nil, if only one direction of the
+// message changed.
+type Shim struct {
+	Id      uint64 // message ID
+	Version int    // protocol version changing the message
+
+	// Upgrade converts the payload of the message sent by an older client to
+	// the payload the server expects.
+	Upgrade func(data []byte) ([]byte, error)
+
+	// Downgrade converts the message sent by the server to the message the
+	// older client expects, or returns ErrDrop. It must not modify msg,
+	// which may be sent to other clients as well.
+	Downgrade func(msg proto.Message) (proto.Message, error)
+}
+
+// Shims are the shims of the messages. They're safe for concurrent use.
+type Shims struct {
+	mu   sync.RWMutex
+	byId map[uint64][]Shim // by increasing version
+}
+
+// Default are the shims of the process, registered by the modules changing
+// their messages.
+var Default = &Shims{}
+
+// Register registers a shim with Default.
+func Register(s Shim) {
+	Default.Register(s)
+}
+
+// Register registers a shim. It panics if the message already has a shim for
+// the version, as it's a programming error.
+func (s *Shims) Register(shim Shim) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.byId == nil {
+		s.byId = map[uint64][]Shim{}
+	}
+	shims := s.byId[shim.Id]
+	for _, other := range shims {
+		if other.Version == shim.Version {
+			panic(fmt.Sprintf("protover: message %d: shim of version %d registered twice", shim.Id, shim.Version))
+		}
+	}
+	shims = append(shims, shim)
+	sort.Slice(shims, func(i, j int) bool { return shims[i].Version < shims[j].Version })
+	s.byId[shim.Id] = shims
+}
+
+// shims returns the shims of a message newer than a version, by increasing
+// version.
+func (s *Shims) shims(id uint64, version int) []Shim {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	shims := s.byId[id]
+	i := sort.Search(len(shims), func(i int) bool { return shims[i].Version > version })
+	return shims[i:]
+}
+
+// Upgrade returns the payload of a message sent by a client of a version,
+// upgraded through the shims of the newer versions, oldest first.
+func (s *Shims) Upgrade(id uint64, version int, data []byte) ([]byte, error) {
+	for _, shim := range s.shims(id, version) {
+		if shim.Upgrade == nil {
+			continue
+		}
+		var err error
+		if data, err = shim.Upgrade(data); err != nil {
+			return nil, fmt.Errorf("protover: upgrade message %d to version %d: %w", id, shim.Version, err)
+		}
+	}
+	return data, nil
+}
+
+// Downgrade returns a message to send to a client of a version, downgraded
+// through the shims of the newer versions, newest first, or ErrDrop.
+func (s *Shims) Downgrade(id uint64, version int, msg proto.Message) (proto.Message, error) {
+	shims := s.shims(id, version)
+	for i := len(shims) - 1; i >= 0; i-- {
+		if shims[i].Downgrade == nil {
+			continue
+		}
+		var err error
+		if msg, err = shims[i].Downgrade(msg); err != nil {
+			if errors.Is(err, ErrDrop) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("protover: downgrade message %d from version %d: %w", id, shims[i].Version, err)
+		}
+	}
+	return msg, nil
+}
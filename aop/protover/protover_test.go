@@ -0,0 +1,84 @@
+package protover
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCheck(t *testing.T) {
+	p := Policy{Current: 5, Min: 3}
+	for _, tc := range []struct {
+		version int
+		ok      bool
+		upgrade bool
+	}{
+		{0, false, true},
+		{2, false, true},
+		{3, true, false},
+		{5, true, false},
+		{6, false, false}, // newer than the servers
+	} {
+		err := p.Check(tc.version)
+		var incompatible *IncompatibleError
+		if tc.ok != (err == nil) || (err != nil && (!errors.As(err, &incompatible) || incompatible.Upgrade() != tc.upgrade)) {
+			t.Errorf("Check(%d): got %v", tc.version, err)
+		}
+	}
+	if err := (Policy{}).Check(1); err != nil {
+		t.Errorf("no policy: got %v", err)
+	}
+}
+
+func TestShims(t *testing.T) {
+	var s Shims
+	// Version 3 prefixes the names with "v3:", version 4 with "v4:"; version
+	// 5 drops the message for the older clients.
+	for _, v := range []int{4, 3} {
+		prefix := []byte{byte('0' + v), ':'}
+		s.Register(Shim{
+			Id:      1,
+			Version: v,
+			Upgrade: func(data []byte) ([]byte, error) {
+				return append(append([]byte{}, prefix...), data...), nil
+			},
+			Downgrade: func(msg proto.Message) (proto.Message, error) {
+				return wrapperspb.String(msg.(*wrapperspb.StringValue).Value[len(prefix):]), nil
+			},
+		})
+	}
+	s.Register(Shim{Id: 2, Version: 5, Downgrade: func(proto.Message) (proto.Message, error) { return nil, ErrDrop }})
+
+	for _, tc := range []struct {
+		version int
+		want    string
+	}{
+		{2, "4:3:name"},
+		{3, "4:name"},
+		{4, "name"},
+	} {
+		data, err := s.Upgrade(1, tc.version, []byte("name"))
+		if err != nil || string(data) != tc.want {
+			t.Errorf("Upgrade from %d: got %q, %v; want %q", tc.version, data, err, tc.want)
+		}
+		msg, err := s.Downgrade(1, tc.version, wrapperspb.String(tc.want))
+		if err != nil || msg.(*wrapperspb.StringValue).Value != "name" {
+			t.Errorf("Downgrade to %d: got %v, %v; want name", tc.version, msg, err)
+		}
+	}
+	if _, err := s.Downgrade(2, 4, wrapperspb.String("new")); err != ErrDrop {
+		t.Errorf("Downgrade of a new message: got %v, want %v", err, ErrDrop)
+	}
+	if msg, err := s.Downgrade(2, 5, wrapperspb.String("new")); err != nil || msg == nil {
+		t.Errorf("Downgrade to the current version: got %v, %v", msg, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate shim registered")
+		}
+	}()
+	s.Register(Shim{Id: 1, Version: 3})
+}
@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"hash/fnv"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// This file implements session affinity (a.k.a. sticky sessions). If session
+// affinity is enabled (see SetAffinity), every request is assigned a session
+// key, either from a request header (e.g., a player id) or from a cookie that
+// the proxy hands out. Requests with the same session key are routed to the
+// same backend.
+//
+// The proxy doesn't keep a table of sessions. Instead, a session key is
+// mapped to a backend using weighted rendezvous hashing [1], a form of
+// consistent hashing. When a backend is added or removed, only the sessions
+// of that backend move to a different backend; every other session stays
+// where it is.
+//
+// [1]: https://en.wikipedia.org/wiki/Rendezvous_hashing
+
+// AffinityOptions configures the session affinity of a proxy. If both Header
+// and Cookie are empty, session affinity is disabled.
+type AffinityOptions struct {
+	// Header is the name of a request header (e.g., "X-Player-Id") whose
+	// value identifies the session of a request. Requests without the header
+	// fall back to Cookie, if set.
+	Header string
+
+	// Cookie is the name of a cookie (e.g., "lobby_session") that identifies
+	// the session of a request. The proxy assigns a new session to a request
+	// without the cookie, and sets the cookie in the response.
+	Cookie string
+
+	// MaxAge is the lifetime of the cookies set by the proxy. If zero, the
+	// cookies expire when the client's browser session ends.
+	MaxAge time.Duration
+}
+
+// enabled returns whether session affinity is enabled.
+func (o AffinityOptions) enabled() bool {
+	return o.Header != "" || o.Cookie != ""
+}
+
+// SetAffinity sets the session affinity options of the proxy.
+func (p *Proxy) SetAffinity(opts AffinityOptions) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.affinity = opts
+}
+
+// sessionKey returns the session key of the provided request, or "" if
+// session affinity is disabled. If the request is assigned a new session, the
+// session cookie is added to the response headers.
+func (p *Proxy) sessionKey(w http.ResponseWriter, r *http.Request) string {
+	p.mu.Lock()
+	opts := p.affinity
+	p.mu.Unlock()
+
+	if opts.Header != "" {
+		if key := r.Header.Get(opts.Header); key != "" {
+			return key
+		}
+	}
+	if opts.Cookie == "" {
+		return ""
+	}
+	if c, err := r.Cookie(opts.Cookie); err == nil && c.Value != "" {
+		return c.Value
+	}
+	key := uuid.New().String()
+	c := &http.Cookie{Name: opts.Cookie, Value: key, Path: "/", HttpOnly: true}
+	if opts.MaxAge > 0 {
+		c.MaxAge = int(opts.MaxAge.Seconds())
+	}
+	http.SetCookie(w, c)
+	return key
+}
+
+// rendezvous returns the backend, among the provided backends, with the
+// highest weighted rendezvous score for the provided session key. It returns
+// false if no backend has a positive weight.
+func rendezvous(key string, backends []backend, weight func(backend) int) (string, bool) {
+	var best string
+	bestScore := math.Inf(-1)
+	for _, b := range backends {
+		w := weight(b)
+		if w <= 0 {
+			continue
+		}
+		if s := score(key, b.addr, w); s > bestScore {
+			best, bestScore = b.addr, s
+		}
+	}
+	return best, best != ""
+}
+
+// score returns the weighted rendezvous score of the provided backend for the
+// provided session key.
+func score(key, addr string, weight int) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))  //nolint:errcheck // hash writes never fail
+	h.Write([]byte{0})    //nolint:errcheck // hash writes never fail
+	h.Write([]byte(addr)) //nolint:errcheck // hash writes never fail
+
+	// Mix the bits of the hash (FNV alone poorly mixes similar inputs), and
+	// map it to a uniform value in (0, 1). See [1] for the weighting.
+	//
+	// [1]: https://en.wikipedia.org/wiki/Rendezvous_hashing#Weighted_rendezvous_hash
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	u := (float64(x>>11) + 0.5) / (1 << 53)
+	return -float64(weight) / math.Log(u)
+}
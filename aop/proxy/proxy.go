@@ -1,53 +1,448 @@
 package proxy
 
 import (
+	"context"
 	"errors"
+	"io"
 	"math/rand"
 	"net/http"
 	"net/http/httputil"
+	"strings"
 	"sync"
+	"time"
 
 	"greatestworks/aop/logtype"
 )
 
+// DefaultWeight is the weight assigned to backends added via AddBackend.
+const DefaultWeight = 100
+
+// drainPollInterval is how often DrainBackend checks whether the requests of
+// a drained backend have completed.
+const drainPollInterval = 100 * time.Millisecond
+
 // Proxy is an HTTP proxy that forwards traffic to a set of backends.
+//
+// Every backend has a non-negative weight, and traffic is distributed across
+// the backends proportionally to their weights. A backend with a weight of
+// zero doesn't receive any new traffic.
+//
+// If health checks are enabled (see RunHealthChecks), backends that fail their
+// health checks are ejected, i.e., they don't receive any new traffic until
+// they pass their health checks again. If every backend with a positive
+// weight is ejected, the proxy fails open and distributes traffic across all
+// of them, as if none were ejected.
+//
+// The proxy supports protocol upgrades (e.g., WebSocket). An upgraded
+// connection is a long-lived request that is in flight until either side
+// closes the connection. To remove a backend without abruptly closing its
+// connections, use DrainBackend.
+//
+// If session affinity is enabled (see SetAffinity), requests of the same
+// session are routed to the same backend, as long as it is available.
 type Proxy struct {
-	logger   logtype.Logger        // logger
-	reverse  httputil.ReverseProxy // underlying proxy
-	mu       sync.Mutex            // guards backends
-	backends []string              // backend addresses
+	logger   logtype.Logger               // logger
+	reverse  httputil.ReverseProxy        // underlying proxy
+	mu       sync.Mutex                   // guards the following fields
+	backends []backend                    // backends
+	inFlight map[string]map[*request]bool // in-flight requests, by backend
+	affinity AffinityOptions              // session affinity options
+}
+
+// request is an in-flight request.
+type request struct {
+	upgrade bool               // is the request a protocol upgrade?
+	cancel  context.CancelFunc // cancels the request, closing its connection
+}
+
+// backend is a proxy backend.
+type backend struct {
+	addr      string // backend address
+	weight    int    // backend weight
+	ejected   bool   // ejected by health checks?
+	failures  int    // number of consecutive failed health checks
+	successes int    // number of consecutive successful health checks
+}
+
+// Backend is a snapshot of the state of a proxy backend.
+type Backend struct {
+	Addr     string // backend address
+	Weight   int    // backend weight
+	InFlight int    // number of in-flight requests, including Conns
+	Conns    int    // number of upgraded (e.g., WebSocket) connections
+	Healthy  bool   // false if the backend is ejected by health checks
+}
+
+// HealthCheckOptions configures the health checks of the proxy backends.
+type HealthCheckOptions struct {
+	// Path is the path of the URL to check (e.g., "/healthz"). Defaults to
+	// "/". A backend passes a health check if it replies with a status code
+	// below 500 within Timeout.
+	Path string
+
+	// Interval is the time between two health checks of a backend. Defaults
+	// to 5 seconds.
+	Interval time.Duration
+
+	// Timeout is the timeout of a health check. Defaults to 2 seconds.
+	Timeout time.Duration
+
+	// UnhealthyThreshold is the number of consecutive failed health checks
+	// after which a backend is ejected. Defaults to 3.
+	UnhealthyThreshold int
+
+	// HealthyThreshold is the number of consecutive successful health checks
+	// after which an ejected backend is readmitted. Defaults to 2.
+	HealthyThreshold int
+
+	// Client is the HTTP client used to check the backends. Defaults to a
+	// client with the provided Timeout.
+	Client *http.Client
 }
 
+// withDefaults returns a copy of the options with zero values replaced with
+// default values.
+func (o HealthCheckOptions) withDefaults() HealthCheckOptions {
+	if o.Path == "" {
+		o.Path = "/"
+	}
+	if o.Interval <= 0 {
+		o.Interval = 5 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 2 * time.Second
+	}
+	if o.UnhealthyThreshold <= 0 {
+		o.UnhealthyThreshold = 3
+	}
+	if o.HealthyThreshold <= 0 {
+		o.HealthyThreshold = 2
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: o.Timeout}
+	}
+	return o
+}
+
+// backendKey is the context key under which ServeHTTP stores the address of
+// the backend picked for a request.
+type backendKey struct{}
+
 // NewProxy returns a new proxy.
 func NewProxy(logger logtype.Logger) *Proxy {
-	p := &Proxy{logger: logger}
+	p := &Proxy{logger: logger, inFlight: map[string]map[*request]bool{}}
 	p.reverse = httputil.ReverseProxy{Director: p.director}
 	return p
 }
 
 // ServeHTTP implements the http.Handler interface.
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	p.reverse.ServeHTTP(w, r)
+	// Cancelling the context of an upgraded request closes its connection to
+	// the backend, which lets DrainBackend close lingering connections.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	req := &request{upgrade: isUpgrade(r), cancel: cancel}
+	key := p.sessionKey(w, r)
+
+	p.mu.Lock()
+	addr, ok := p.pick(key)
+	if ok {
+		if p.inFlight[addr] == nil {
+			p.inFlight[addr] = map[*request]bool{}
+		}
+		p.inFlight[addr][req] = true
+	}
+	p.mu.Unlock()
+	if !ok {
+		p.logger.Error("ServeHTTP", errors.New("no backends"), "url", r.URL)
+		http.Error(w, "no backends", http.StatusServiceUnavailable)
+		return
+	}
+	defer func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.inFlight[addr], req)
+		if len(p.inFlight[addr]) == 0 {
+			delete(p.inFlight, addr)
+		}
+	}()
+	p.reverse.ServeHTTP(w, r.WithContext(context.WithValue(ctx, backendKey{}, addr)))
 }
 
-// AddBackend adds a backend to the proxy. Note that backends cannot be
-// removed.
+// isUpgrade returns whether the provided request asks for a protocol upgrade
+// (e.g., to WebSocket).
+func isUpgrade(r *http.Request) bool {
+	if r.Header.Get("Upgrade") == "" {
+		return false
+	}
+	for _, v := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddBackend adds a backend with weight DefaultWeight to the proxy. If the
+// backend already exists, its weight is updated instead.
 func (p *Proxy) AddBackend(backend string) {
+	p.AddWeightedBackend(backend, DefaultWeight)
+}
+
+// AddWeightedBackend adds a backend with the provided weight to the proxy. If
+// the backend already exists, its weight is updated instead.
+func (p *Proxy) AddWeightedBackend(addr string, weight int) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.backends = append(p.backends, backend)
+	if weight < 0 {
+		weight = 0
+	}
+	for i := range p.backends {
+		if p.backends[i].addr == addr {
+			p.backends[i].weight = weight
+			return
+		}
+	}
+	p.backends = append(p.backends, backend{addr: addr, weight: weight})
+}
+
+// SetWeight updates the weight of the provided backend. It returns false if
+// the backend doesn't exist.
+func (p *Proxy) SetWeight(addr string, weight int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if weight < 0 {
+		weight = 0
+	}
+	for i := range p.backends {
+		if p.backends[i].addr == addr {
+			p.backends[i].weight = weight
+			return true
+		}
+	}
+	return false
 }
 
-// director implements a ReverseProxy.Director function [1].
+// RemoveBackend removes the provided backend from the proxy. The backend
+// doesn't receive any new traffic, but its in-flight requests are not
+// interrupted (see InFlight). It returns false if the backend doesn't exist.
+func (p *Proxy) RemoveBackend(addr string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.backends {
+		if p.backends[i].addr == addr {
+			p.backends = append(p.backends[:i], p.backends[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Backends returns a snapshot of the state of all backends.
+func (p *Proxy) Backends() []Backend {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	backends := make([]Backend, len(p.backends))
+	for i, b := range p.backends {
+		backends[i] = Backend{
+			Addr:     b.addr,
+			Weight:   b.weight,
+			InFlight: len(p.inFlight[b.addr]),
+			Conns:    p.conns(b.addr),
+			Healthy:  !b.ejected,
+		}
+	}
+	return backends
+}
+
+// Weights returns the weights of all backends, keyed by backend address.
+func (p *Proxy) Weights() map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	weights := make(map[string]int, len(p.backends))
+	for _, b := range p.backends {
+		weights[b.addr] = b.weight
+	}
+	return weights
+}
+
+// InFlight returns the number of requests currently being forwarded to the
+// provided backend.
+func (p *Proxy) InFlight(addr string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.inFlight[addr])
+}
+
+// conns returns the number of upgraded connections to the provided backend.
 //
-// [1]: https://pkg.go.dev/net/http/httputil#ReverseProxy
-func (p *Proxy) director(r *http.Request) {
+// REQUIRES: p.mu is held.
+func (p *Proxy) conns(addr string) int {
+	n := 0
+	for req := range p.inFlight[addr] {
+		if req.upgrade {
+			n++
+		}
+	}
+	return n
+}
+
+// DrainBackend removes the provided backend from the proxy, and waits for its
+// in-flight requests and connections to complete. If the provided context is
+// done first, DrainBackend cancels the remaining requests, which closes the
+// remaining connections to the backend, and returns the context's error.
+func (p *Proxy) DrainBackend(ctx context.Context, addr string) error {
+	p.RemoveBackend(addr)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for p.InFlight(addr) > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			p.mu.Lock()
+			n := len(p.inFlight[addr])
+			for req := range p.inFlight[addr] {
+				req.cancel()
+			}
+			p.mu.Unlock()
+			p.logger.Debug("Closed lingering requests of drained backend", "backend", addr, "requests", n)
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// RunHealthChecks periodically checks the health of all backends, ejecting
+// the backends that fail their health checks and readmitting them once they
+// pass again, until the provided context is cancelled.
+func (p *Proxy) RunHealthChecks(ctx context.Context, opts HealthCheckOptions) {
+	opts = opts.withDefaults()
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.checkHealth(ctx, opts)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkHealth checks the health of all backends once, concurrently.
+func (p *Proxy) checkHealth(ctx context.Context, opts HealthCheckOptions) {
+	p.mu.Lock()
+	addrs := make([]string, len(p.backends))
+	for i, b := range p.backends {
+		addrs[i] = b.addr
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, addr := range addrs {
+		addr := addr
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.recordHealth(addr, checkBackend(ctx, opts, addr), opts)
+		}()
+	}
+	wg.Wait()
+}
+
+// checkBackend returns whether the provided backend passes a health check.
+func checkBackend(ctx context.Context, opts HealthCheckOptions, addr string) bool {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+opts.Path, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10)) //nolint:errcheck // drain for connection reuse
+	return resp.StatusCode < http.StatusInternalServerError
+}
+
+// recordHealth records the result of a health check of the provided backend,
+// ejecting or readmitting the backend as needed.
+func (p *Proxy) recordHealth(addr string, healthy bool, opts HealthCheckOptions) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	if len(p.backends) == 0 {
-		p.logger.Error("director", errors.New("no backends"), "url", r.URL)
+	for i := range p.backends {
+		b := &p.backends[i]
+		if b.addr != addr {
+			continue
+		}
+		if healthy {
+			b.failures = 0
+			b.successes++
+			if b.ejected && b.successes >= opts.HealthyThreshold {
+				b.ejected = false
+				p.logger.Info("Proxy backend readmitted", "backend", addr)
+			}
+		} else {
+			b.successes = 0
+			b.failures++
+			if !b.ejected && b.failures >= opts.UnhealthyThreshold {
+				b.ejected = true
+				p.logger.Error("Proxy backend ejected", errors.New("failed health checks"), "backend", addr, "failures", b.failures)
+			}
+		}
 		return
 	}
+}
+
+// director implements a ReverseProxy.Director function [1]. It forwards the
+// request to the backend picked by ServeHTTP.
+//
+// [1]: https://pkg.go.dev/net/http/httputil#ReverseProxy
+func (p *Proxy) director(r *http.Request) {
 	r.URL.Scheme = "http" // TODO(mwhittaker): Support HTTPS.
-	r.URL.Host = p.backends[rand.Intn(len(p.backends))]
+	r.URL.Host = r.Context().Value(backendKey{}).(string)
+}
+
+// pick picks a backend at random, proportionally to the backend weights,
+// skipping the ejected backends (unless they all are). If the provided
+// session key is not empty, the backend is picked by rendezvous hashing of the
+// key instead. It returns false if no backend has a positive weight.
+//
+// REQUIRES: p.mu is held.
+func (p *Proxy) pick(key string) (string, bool) {
+	weight := func(b backend) int {
+		if b.ejected {
+			return 0
+		}
+		return b.weight
+	}
+	total := 0
+	for _, b := range p.backends {
+		total += weight(b)
+	}
+	if total == 0 {
+		// Fail open if every backend is ejected.
+		weight = func(b backend) int { return b.weight }
+		for _, b := range p.backends {
+			total += weight(b)
+		}
+	}
+	if total == 0 {
+		return "", false
+	}
+	if key != "" {
+		return rendezvous(key, p.backends, weight)
+	}
+	n := rand.Intn(total)
+	for _, b := range p.backends {
+		if n < weight(b) {
+			return b.addr, true
+		}
+		n -= weight(b)
+	}
+	panic("unreachable")
 }
@@ -0,0 +1,263 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"greatestworks/aop/logging"
+)
+
+// backendServer returns a backend that replies with the provided status code.
+func backendServer(t *testing.T, code int) string {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(s.Close)
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+func TestRemoveBackend(t *testing.T) {
+	p := NewProxy(logging.NewTestLogger(t))
+	p.AddBackend("a")
+	p.AddWeightedBackend("b", 10)
+	if !p.RemoveBackend("a") {
+		t.Fatal("RemoveBackend(a): backend not found")
+	}
+	if p.RemoveBackend("a") {
+		t.Fatal("RemoveBackend(a): backend removed twice")
+	}
+	want := []Backend{{Addr: "b", Weight: 10, Healthy: true}}
+	if diff := cmp.Diff(want, p.Backends()); diff != "" {
+		t.Fatalf("Backends (-want +got):\n%s", diff)
+	}
+	for i := 0; i < 100; i++ {
+		if addr, _ := p.pick(""); addr != "b" {
+			t.Fatalf("pick: got %q, want b", addr)
+		}
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	ctx := context.Background()
+	opts := HealthCheckOptions{UnhealthyThreshold: 2, HealthyThreshold: 1}.withDefaults()
+	healthy := backendServer(t, http.StatusNotFound) // any reply below 500 is healthy
+	unhealthy := backendServer(t, http.StatusInternalServerError)
+
+	p := NewProxy(logging.NewTestLogger(t))
+	p.AddBackend(healthy)
+	p.AddBackend(unhealthy)
+	p.AddBackend("localhost:1") // unreachable
+
+	check := func(want map[string]bool) {
+		t.Helper()
+		p.checkHealth(ctx, opts)
+		got := map[string]bool{}
+		for _, b := range p.Backends() {
+			got[b.Addr] = b.Healthy
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("health (-want +got):\n%s", diff)
+		}
+	}
+
+	// Backends are ejected after two failed health checks.
+	check(map[string]bool{healthy: true, unhealthy: true, "localhost:1": true})
+	check(map[string]bool{healthy: true, unhealthy: false, "localhost:1": false})
+	for i := 0; i < 100; i++ {
+		if addr, _ := p.pick(""); addr != healthy {
+			t.Fatalf("pick: got %q, want %q", addr, healthy)
+		}
+	}
+
+	// If every backend is ejected, the proxy fails open.
+	p.RemoveBackend(healthy)
+	if _, ok := p.pick(""); !ok {
+		t.Fatal("pick: no backend picked")
+	}
+
+	// Ejected backends are readmitted once they pass a health check.
+	p.recordHealth(unhealthy, true, opts)
+	for i := 0; i < 100; i++ {
+		if addr, _ := p.pick(""); addr != unhealthy {
+			t.Fatalf("pick: got %q, want %q", addr, unhealthy)
+		}
+	}
+}
+
+// echoServer returns a backend that upgrades connections to an echo protocol.
+func echoServer(t *testing.T) string {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n")
+		buf.Flush()
+		io.Copy(conn, buf) //nolint:errcheck // connection closed by the client
+	}))
+	t.Cleanup(s.Close)
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+func TestUpgradeAndDrain(t *testing.T) {
+	backend := echoServer(t)
+	p := NewProxy(logging.NewTestLogger(t))
+	p.AddBackend(backend)
+	s := httptest.NewServer(p)
+	defer s.Close()
+
+	// Upgrade a connection through the proxy.
+	conn, err := net.Dial("tcp", strings.TrimPrefix(s.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: proxy\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status: got %d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+	if _, err := io.WriteString(conn, "hello\n"); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := r.ReadString('\n'); err != nil || line != "hello\n" {
+		t.Fatalf("echo: got %q, %v; want %q", line, err, "hello\n")
+	}
+	want := []Backend{{Addr: backend, Weight: DefaultWeight, InFlight: 1, Conns: 1, Healthy: true}}
+	if diff := cmp.Diff(want, p.Backends()); diff != "" {
+		t.Fatalf("Backends (-want +got):\n%s", diff)
+	}
+
+	// Draining the backend waits for the connection, and closes it once the
+	// context expires.
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := p.DrainBackend(ctx, backend); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DrainBackend: got %v, want %v", err, context.DeadlineExceeded)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second)) //nolint:errcheck // checked by ReadString
+	if _, err := r.ReadString('\n'); err != io.EOF {
+		t.Fatalf("read after drain: got %v, want %v", err, io.EOF)
+	}
+	if n := p.InFlight(backend); n != 0 {
+		t.Fatalf("InFlight after drain: got %d, want 0", n)
+	}
+}
+
+func TestAffinityConsistentHashing(t *testing.T) {
+	p := NewProxy(logging.NewTestLogger(t))
+	for _, addr := range []string{"a", "b", "c", "d"} {
+		p.AddBackend(addr)
+	}
+	keys := make([]string, 1000)
+	before := map[string]string{}
+	for i := range keys {
+		keys[i] = fmt.Sprintf("player-%d", i)
+		before[keys[i]], _ = p.pick(keys[i])
+		if addr, _ := p.pick(keys[i]); addr != before[keys[i]] {
+			t.Fatalf("pick(%q): got %q, then %q", keys[i], before[keys[i]], addr)
+		}
+	}
+
+	// Removing a backend only moves the sessions of that backend.
+	p.RemoveBackend("b")
+	moved := 0
+	for _, key := range keys {
+		addr, _ := p.pick(key)
+		switch {
+		case before[key] == "b" && addr == "b":
+			t.Fatalf("pick(%q): got removed backend b", key)
+		case before[key] != "b" && addr != before[key]:
+			t.Fatalf("pick(%q): got %q, want %q", key, addr, before[key])
+		case before[key] == "b":
+			moved++
+		}
+	}
+	if moved < 150 || moved > 350 {
+		t.Fatalf("moved %d of %d sessions, want about a quarter", moved, len(keys))
+	}
+
+	// Adding the backend back restores the original assignment.
+	p.AddBackend("b")
+	for _, key := range keys {
+		if addr, _ := p.pick(key); addr != before[key] {
+			t.Fatalf("pick(%q): got %q, want %q", key, addr, before[key])
+		}
+	}
+}
+
+func TestAffinityCookie(t *testing.T) {
+	p := NewProxy(logging.NewTestLogger(t))
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprint(i)
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name) //nolint:errcheck // test server
+		}))
+		defer s.Close()
+		p.AddBackend(strings.TrimPrefix(s.URL, "http://"))
+	}
+	p.SetAffinity(AffinityOptions{Header: "X-Player-Id", Cookie: "session"})
+
+	get := func(header string, cookie *http.Cookie) (string, *http.Response) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			r.Header.Set("X-Player-Id", header)
+		}
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, r)
+		resp := w.Result()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body), resp
+	}
+
+	// A request without a session is assigned one.
+	first, resp := get("", nil)
+	cookies := resp.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "session" {
+		t.Fatalf("cookies: got %v, want a session cookie", cookies)
+	}
+	for i := 0; i < 20; i++ {
+		got, resp := get("", cookies[0])
+		if got != first {
+			t.Fatalf("request %d: got backend %s, want %s", i, got, first)
+		}
+		if len(resp.Cookies()) != 0 {
+			t.Fatalf("request %d: got cookies %v, want none", i, resp.Cookies())
+		}
+	}
+
+	// Requests with the same header are routed to the same backend.
+	want, _ := get("alice", nil)
+	for i := 0; i < 20; i++ {
+		if got, _ := get("alice", nil); got != want {
+			t.Fatalf("request %d: got backend %s, want %s", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/fn"
+	"greatestworks/aop/logger"
+)
+
+// Modes of the Redis deployments.
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// Client is a Redis client, of a standalone Redis, of the master behind
+// sentinels, or of a cluster (see New). The modules get the client of the
+// process from Get, so that their tests can replace it (see SetDefault),
+// e.g. with a client of a test server, or with a Client embedding another and
+// overriding the commands under test.
+type Client interface {
+	goredis.UniversalClient
+}
+
+// Options are the options of a client.
+type Options struct {
+	Mode         string        // ModeStandalone (the default), ModeSentinel or ModeCluster
+	Addrs        []string      // of the server, of the sentinels, or of the nodes of the cluster
+	MasterName   string        // of the master, in sentinel mode
+	Username     string        // ACL user
+	Password     string        // of the user, or of the server without ACL
+	DB           int           // unsupported in cluster mode
+	PoolSize     int           // connections per node, defaults to 10 per CPU
+	DialTimeout  time.Duration // defaults to 5 seconds
+	ReadTimeout  time.Duration // defaults to 3 seconds
+	WriteTimeout time.Duration // defaults to ReadTimeout
+}
+
+// New returns a client of the Redis deployment configured, once it answers a
+// PING. The commands of the client are measured (see redis_command_micros).
+func New(ctx context.Context, opts Options) (Client, error) {
+	if len(opts.Addrs) == 0 {
+		return nil, fmt.Errorf("redis: no address")
+	}
+	var c Client
+	switch opts.Mode {
+	case "", ModeStandalone:
+		c = goredis.NewClient(&goredis.Options{
+			Addr:         opts.Addrs[0],
+			Username:     opts.Username,
+			Password:     opts.Password,
+			DB:           opts.DB,
+			PoolSize:     opts.PoolSize,
+			DialTimeout:  opts.DialTimeout,
+			ReadTimeout:  opts.ReadTimeout,
+			WriteTimeout: opts.WriteTimeout,
+		})
+	case ModeSentinel:
+		if opts.MasterName == "" {
+			return nil, fmt.Errorf("redis: sentinel mode without a master name")
+		}
+		c = goredis.NewFailoverClient(&goredis.FailoverOptions{
+			MasterName:    opts.MasterName,
+			SentinelAddrs: opts.Addrs,
+			Username:      opts.Username,
+			Password:      opts.Password,
+			DB:            opts.DB,
+			PoolSize:      opts.PoolSize,
+			DialTimeout:   opts.DialTimeout,
+			ReadTimeout:   opts.ReadTimeout,
+			WriteTimeout:  opts.WriteTimeout,
+		})
+	case ModeCluster:
+		if opts.DB != 0 {
+			return nil, fmt.Errorf("redis: cluster mode with db %d", opts.DB)
+		}
+		c = goredis.NewClusterClient(&goredis.ClusterOptions{
+			Addrs:        opts.Addrs,
+			Username:     opts.Username,
+			Password:     opts.Password,
+			PoolSize:     opts.PoolSize,
+			DialTimeout:  opts.DialTimeout,
+			ReadTimeout:  opts.ReadTimeout,
+			WriteTimeout: opts.WriteTimeout,
+		})
+	default:
+		return nil, fmt.Errorf("redis: unknown mode %q", opts.Mode)
+	}
+	if err := c.Ping(ctx).Err(); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("redis: ping %v %v: %w", opts.Mode, opts.Addrs, err)
+	}
+	c.AddHook(metricsHook{})
+	return c, nil
+}
+
+var defaultClient = fn.NewDefault(func() Client {
+	c := goredis.NewClusterClient(&goredis.ClusterOptions{
+		Addrs: []string{":7000", ":7001", ":7002", ":7003", ":7004", ":7005"},
+	})
+	c.AddHook(metricsHook{})
+	return c
+})
+
+// Init sets up the client of the process (see Get), and loads the scripts
+// registered (see NewScript).
+func Init(ctx context.Context, opts Options) error {
+	c, err := New(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if err := LoadScripts(ctx, c); err != nil {
+		// They're loaded at their first run instead.
+		logger.Error("[redis] load scripts err:%v", err)
+	}
+	SetDefault(c)
+	logger.Info("[redis] %v %v", opts.Mode, opts.Addrs)
+	return nil
+}
+
+// SetDefault replaces the client of the process, e.g. in tests.
+func SetDefault(c Client) {
+	defaultClient.Set(c)
+}
+
+// Get returns the client of the process: the one set up by Init, or else a
+// client of a local cluster on the ports 7000 to 7005, for development.
+func Get() Client {
+	return defaultClient.Get()
+}
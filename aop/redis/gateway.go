@@ -12,3 +12,11 @@ func MakeGatewayKey(addr string) string {
 func MakeTokenKey(userid uint64) string {
 	return "token:" + strconv.FormatInt(int64(userid), 10)
 }
+
+func MakeBanUserKey(userId uint64) string {
+	return "ban:" + strconv.FormatUint(userId, 10)
+}
+
+func MakeBanDeviceKey(device string) string {
+	return "ban:device:" + device
+}
@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+var (
+	commandMicros = metrics.NewHistogramMap[commandLabels](
+		"redis_command_micros",
+		"Duration, in microseconds, of the Redis commands, and of the pipelines",
+		metrics.NonNegativeBuckets,
+	)
+	commandErrors = metrics.NewCounterMap[commandLabels](
+		"redis_command_errors",
+		"Number of Redis commands failed, but for missing keys",
+	)
+)
+
+type commandLabels struct {
+	Command string // name of the command, or "pipeline"
+}
+
+type startKey struct{}
+
+// metricsHook measures the commands of a client.
+type metricsHook struct{}
+
+func (metricsHook) BeforeProcess(ctx context.Context, _ goredis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+func (metricsHook) AfterProcess(ctx context.Context, cmd goredis.Cmder) error {
+	observe(ctx, commandLabels{Command: cmd.Name()}, []goredis.Cmder{cmd})
+	return nil
+}
+
+func (metricsHook) BeforeProcessPipeline(ctx context.Context, _ []goredis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+func (metricsHook) AfterProcessPipeline(ctx context.Context, cmds []goredis.Cmder) error {
+	observe(ctx, commandLabels{Command: "pipeline"}, cmds)
+	return nil
+}
+
+// observe records the duration of a command, or of a pipeline, and the
+// commands failed.
+func observe(ctx context.Context, labels commandLabels, cmds []goredis.Cmder) {
+	if start, ok := ctx.Value(startKey{}).(time.Time); ok {
+		commandMicros.Get(labels).Put(float64(time.Since(start).Microseconds()))
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && err != goredis.Nil {
+			commandErrors.Get(commandLabels{Command: cmd.Name()}).Add(1)
+		}
+	}
+}
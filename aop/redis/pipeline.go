@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// DefaultBatch is the default number of commands of a pipeline of Batch.
+const DefaultBatch = 500
+
+// Batch queues a command per item, fn queuing it, in pipelines of up to size
+// commands (DefaultBatch if 0): a pipeline takes a round trip, on a cluster
+// a round trip per node, whatever the slots of its keys, where a multi-key
+// command (e.g., MGET) fails across slots. It returns the commands, in the
+// order of the items, and the first error of a command but a missing key.
+func Batch[T any](ctx context.Context, c goredis.Cmdable, items []T, size int, fn func(pipe goredis.Pipeliner, item T)) ([]goredis.Cmder, error) {
+	if size <= 0 {
+		size = DefaultBatch
+	}
+	cmds := make([]goredis.Cmder, 0, len(items))
+	for begin := 0; begin < len(items); begin += size {
+		end := begin + size
+		if end > len(items) {
+			end = len(items)
+		}
+		pipe := c.Pipeline()
+		for _, item := range items[begin:end] {
+			fn(pipe, item)
+		}
+		batch, _ := pipe.Exec(ctx)
+		cmds = append(cmds, batch...)
+		for _, cmd := range batch {
+			if err := cmd.Err(); err != nil && err != goredis.Nil {
+				return cmds, err
+			}
+		}
+	}
+	return cmds, nil
+}
+
+// MGet gets the values of keys of any slots, in pipelines of GETs. The value
+// of a missing key is nil, as with MGET.
+func MGet(ctx context.Context, c goredis.Cmdable, keys ...string) ([]interface{}, error) {
+	cmds, err := Batch(ctx, c, keys, 0, func(pipe goredis.Pipeliner, key string) {
+		pipe.Get(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]interface{}, len(cmds))
+	for i, cmd := range cmds {
+		if val, err := cmd.(*goredis.StringCmd).Result(); err == nil {
+			vals[i] = val
+		}
+	}
+	return vals, nil
+}
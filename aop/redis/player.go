@@ -5,3 +5,9 @@ import "strconv"
 func MakeAccountKey(userid int64) string {
 	return "account:" + strconv.FormatInt(userid, 10)
 }
+
+// MakeTransferKey returns the key of the state of a player handed off to
+// another world server.
+func MakeTransferKey(userid uint64) string {
+	return "transfer:" + strconv.FormatUint(userid, 10)
+}
@@ -0,0 +1,88 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	goredis "github.com/go-redis/redis/v8"
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+var scriptFallbacks = metrics.NewCounterMap[scriptLabels](
+	"redis_script_fallbacks",
+	"Number of Lua scripts run with EVAL, as Redis didn't have them cached",
+)
+
+type scriptLabels struct {
+	Script string
+}
+
+// Script is a Lua script, registered by name (see NewScript). It runs with
+// EVALSHA, so that its source isn't sent with every run, and falls back to
+// EVAL when Redis doesn't have it cached (e.g., after a restart, or on a
+// node added to a cluster), which caches it.
+type Script struct {
+	name string
+	src  string
+	hash string
+}
+
+var (
+	scriptsMu sync.Mutex
+	scripts   = map[string]*Script{} // by name
+)
+
+// NewScript registers a script, loaded on the Redis of the process at Init.
+// Scripts are registered by package variables; registering two scripts of
+// the same name panics.
+func NewScript(name, src string) *Script {
+	scriptsMu.Lock()
+	defer scriptsMu.Unlock()
+	if _, ok := scripts[name]; ok {
+		panic(fmt.Sprintf("redis: script %q registered twice", name))
+	}
+	sum := sha1.Sum([]byte(src))
+	s := &Script{name: name, src: src, hash: hex.EncodeToString(sum[:])}
+	scripts[name] = s
+	return s
+}
+
+// Run runs the script. It must not be run in a pipeline, where it can't
+// fall back to EVAL.
+func (s *Script) Run(ctx context.Context, c goredis.Scripter, keys []string, args ...interface{}) *goredis.Cmd {
+	cmd := c.EvalSha(ctx, s.hash, keys, args...)
+	if err := cmd.Err(); err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		scriptFallbacks.Get(scriptLabels{Script: s.name}).Add(1)
+		cmd = c.Eval(ctx, s.src, keys, args...)
+	}
+	return cmd
+}
+
+// LoadScripts loads the scripts registered on Redis: on every master of a
+// cluster.
+func LoadScripts(ctx context.Context, c Client) error {
+	scriptsMu.Lock()
+	list := make([]*Script, 0, len(scripts))
+	for _, s := range scripts {
+		list = append(list, s)
+	}
+	scriptsMu.Unlock()
+	load := func(ctx context.Context, c goredis.Cmdable) error {
+		for _, s := range list {
+			if err := c.ScriptLoad(ctx, s.src).Err(); err != nil {
+				return fmt.Errorf("load script %v: %w", s.name, err)
+			}
+		}
+		return nil
+	}
+	if cluster, ok := c.(*goredis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *goredis.Client) error {
+			return load(ctx, node)
+		})
+	}
+	return load(ctx, c)
+}
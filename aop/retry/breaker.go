@@ -0,0 +1,130 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned (wrapped) by Breaker.Allow when the circuit breaker is
+// open.
+var ErrOpen = errors.New("retry: circuit breaker open")
+
+// BreakerOptions configure a Breaker.
+type BreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures that open the
+	// breaker. Defaults to 5.
+	FailureThreshold int
+
+	// OpenDuration is how long the breaker stays open before letting a
+	// probe call through. The actual duration is randomized between 50% and
+	// 100% of OpenDuration (see EqualJitter), so that the breakers of many
+	// processes calling the same server don't probe it in sync. Defaults to
+	// 10 seconds.
+	OpenDuration time.Duration
+}
+
+// withDefaults returns a copy of the options, with default values filled in.
+func (o BreakerOptions) withDefaults() BreakerOptions {
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = 5
+	}
+	if o.OpenDuration <= 0 {
+		o.OpenDuration = 10 * time.Second
+	}
+	return o
+}
+
+// A Breaker is a circuit breaker, which fails calls to a server fast after
+// the server failed a number of consecutive calls. Use it like this:
+//
+//	if err := breaker.Allow(); err != nil {
+//	    return err
+//	}
+//	err := call()
+//	breaker.Record(err)
+//
+// A Breaker is closed (calls are allowed) until FailureThreshold consecutive
+// calls fail. It then opens (calls fail with ErrOpen) for OpenDuration, after
+// which it lets a single probe call through. If the probe succeeds, the
+// breaker closes; otherwise, it opens again.
+//
+// You can safely use a Breaker from multiple goroutines.
+type Breaker struct {
+	opts BreakerOptions
+	now  func() time.Time // the current time; replaced in tests
+
+	mu        sync.Mutex
+	failures  int       // number of consecutive failures
+	lastErr   error     // the last failure
+	openUntil time.Time // if after now, the breaker is open
+	probing   bool      // whether a probe call is in flight
+}
+
+// NewBreaker returns a closed circuit breaker configured with the provided
+// options.
+func NewBreaker(opts BreakerOptions) *Breaker {
+	return &Breaker{opts: opts.withDefaults(), now: time.Now}
+}
+
+// Allow returns nil if a call is allowed. Otherwise, it returns an error that
+// wraps both ErrOpen and the last failure. Every allowed call must be
+// followed by a call to Record or Release.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.opts.FailureThreshold {
+		return nil // closed
+	}
+	if b.now().Before(b.openUntil) || b.probing {
+		return &openError{b.lastErr}
+	}
+	b.probing = true // half-open
+	return nil
+}
+
+// Record records the outcome of a call allowed by Allow.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		b.lastErr = nil
+		return
+	}
+	b.failures++
+	b.lastErr = err
+	if b.failures >= b.opts.FailureThreshold {
+		b.openUntil = b.now().Add(jittered(b.opts.OpenDuration, EqualJitter))
+	}
+}
+
+// Release releases a call allowed by Allow whose outcome says nothing about
+// the health of the server (e.g., the caller canceled the call).
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// openError is the error returned by Allow when the breaker is open.
+type openError struct {
+	last error // the last failure
+}
+
+// Error implements the error interface.
+func (e *openError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrOpen, e.last)
+}
+
+// Is makes errors.Is(err, ErrOpen) return true.
+func (e *openError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+// Unwrap returns the last failure.
+func (e *openError) Unwrap() error {
+	return e.last
+}
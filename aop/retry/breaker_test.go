@@ -0,0 +1,56 @@
+package retry
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker(BreakerOptions{FailureThreshold: 2, OpenDuration: time.Minute})
+	b.now = func() time.Time { return now }
+	call := func(err error) error {
+		if err := b.Allow(); err != nil {
+			return err
+		}
+		b.Record(err)
+		return err
+	}
+
+	// Two consecutive failures open the breaker.
+	failure := syscall.ECONNREFUSED
+	for i := 0; i < 2; i++ {
+		if err := call(failure); err != failure {
+			t.Fatalf("call %d: got %v, want %v", i, err, failure)
+		}
+	}
+	err := call(nil)
+	if !errors.Is(err, ErrOpen) || !errors.Is(err, failure) {
+		t.Fatalf("open breaker: got %v, want %v wrapping %v", err, ErrOpen, failure)
+	}
+
+	// Once the breaker has been open long enough, a single probe goes
+	// through. A failed probe opens the breaker again.
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("concurrent probe: got %v, want %v", err, ErrOpen)
+	}
+	b.Record(failure)
+	if err := call(nil); !errors.Is(err, ErrOpen) {
+		t.Fatalf("after failed probe: got %v, want %v", err, ErrOpen)
+	}
+
+	// A successful probe closes the breaker.
+	now = now.Add(time.Minute)
+	if err := call(nil); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if err := call(failure); err != failure {
+		t.Fatalf("closed breaker: got %v, want %v", err, failure)
+	}
+}
@@ -0,0 +1,70 @@
+package retry
+
+import "sync"
+
+// A Budget limits the retries of the retry loops that share it (see
+// Options.Budget) to a fraction of their first attempts, so that a server
+// that is down doesn't get flooded with retries once it comes back up.
+//
+// Every retry loop deposits Ratio tokens in the budget when it starts, and
+// withdraws one token before every retry. A retry loop stops when the budget
+// holds less than one token. The budget starts full, with MaxTokens tokens,
+// so that occasional failures are retried right away.
+//
+// You can safely use a Budget from multiple goroutines.
+type Budget struct {
+	opts BudgetOptions
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// BudgetOptions configure a Budget.
+type BudgetOptions struct {
+	// Ratio is the number of retries allowed per retry loop, on average.
+	// Defaults to 0.1.
+	Ratio float64
+
+	// MaxTokens is the maximum number of tokens in the budget, i.e., the
+	// number of retries allowed in a burst. Defaults to 10.
+	MaxTokens float64
+}
+
+// withDefaults returns a copy of the options, with default values filled in.
+func (o BudgetOptions) withDefaults() BudgetOptions {
+	if o.Ratio <= 0 {
+		o.Ratio = 0.1
+	}
+	if o.MaxTokens <= 0 {
+		o.MaxTokens = 10
+	}
+	return o
+}
+
+// NewBudget returns a full retry budget configured with the provided options.
+func NewBudget(opts BudgetOptions) *Budget {
+	opts = opts.withDefaults()
+	return &Budget{opts: opts, tokens: opts.MaxTokens}
+}
+
+// deposit deposits the tokens earned by the start of a retry loop.
+func (b *Budget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.opts.Ratio
+	if b.tokens > b.opts.MaxTokens {
+		b.tokens = b.opts.MaxTokens
+	}
+}
+
+// withdraw withdraws the token spent by a retry, returning false if the
+// budget is exhausted.
+func (b *Budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
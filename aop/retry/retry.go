@@ -1,4 +1,5 @@
-// Package retry contains code to perform retries with exponential backoff.
+// Package retry contains code to perform retries with exponential backoff,
+// and to stop retrying calls to unavailable servers (see Budget and Breaker).
 //
 // Example: loop until doSomething() returns true or context hits deadline or is canceled.
 //
@@ -11,6 +12,7 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"math"
 	"math/rand"
 	"sync"
@@ -21,16 +23,62 @@ import (
 type Retry struct {
 	options Options
 	attempt int
+	err     error // why Continue returned false
 }
 
 // Options are the options that configure a retry loop. Before the ith
 // iteration of a retry loop, retry.Continue() sleeps for a duration of
-// BackoffMinDuration * BackoffMultiplier^i, with added jitter.
+// BackoffMinDuration * BackoffMultiplier^i, capped at BackoffMaxDuration, with
+// added jitter.
 type Options struct {
 	BackoffMultiplier  float64 // If specified, must be at least 1.
 	BackoffMinDuration time.Duration
+	BackoffMaxDuration time.Duration // If zero, the backoff is not capped.
+
+	// Jitter is how the backoff is randomized. Loops that retry calls to the
+	// same server from many processes should use FullJitter or EqualJitter,
+	// so that the retries of the processes don't stay in sync.
+	Jitter Jitter
+
+	// MaxAttempts, if positive, is the maximum number of iterations of the
+	// retry loop.
+	MaxAttempts int
+
+	// Budget, if not nil, limits the retries of all the retry loops that
+	// share it. See Budget.
+	Budget *Budget
 }
 
+// Jitter is a strategy for randomizing backoff delays. See
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+type Jitter int
+
+const (
+	// DefaultJitter sleeps for a random duration between 60% and 100% of the
+	// backoff.
+	DefaultJitter Jitter = iota
+
+	// NoJitter sleeps for exactly the backoff.
+	NoJitter
+
+	// FullJitter sleeps for a random duration between 0 and the backoff.
+	FullJitter
+
+	// EqualJitter sleeps for a random duration between 50% and 100% of the
+	// backoff.
+	EqualJitter
+)
+
+var (
+	// ErrMaxAttempts is returned by Retry.Err when a retry loop stopped after
+	// Options.MaxAttempts iterations.
+	ErrMaxAttempts = errors.New("retry: too many attempts")
+
+	// ErrBudgetExhausted is returned by Retry.Err when a retry loop stopped
+	// because its Options.Budget was exhausted.
+	ErrBudgetExhausted = errors.New("retry: retry budget exhausted")
+)
+
 // DefaultOptions is the default set of Options.
 var DefaultOptions = Options{
 	BackoffMultiplier:  1.3,
@@ -64,15 +112,41 @@ func BeginWithOptions(options Options) *Retry {
 }
 
 // Continue sleeps for an exponentially increasing interval (with jitter). It
-// stops its sleep early and returns false if context becomes done. If the
-// return value is false, ctx.Err() is guaranteed to be non-nil. The first
-// call does not sleep.
+// stops its sleep early and returns false if context becomes done. It also
+// returns false, without sleeping, if the loop ran out of attempts or retry
+// budget. If the return value is false, r.Err() is guaranteed to be non-nil,
+// and is ctx.Err() if the context is done. The first call does not sleep.
 func (r *Retry) Continue(ctx context.Context) bool {
+	if r.err != nil {
+		return false
+	}
+	if max := r.options.MaxAttempts; max > 0 && r.attempt >= max {
+		r.err = ErrMaxAttempts
+		return false
+	}
+	if b := r.options.Budget; b != nil {
+		if r.attempt == 0 {
+			b.deposit()
+		} else if !b.withdraw() {
+			r.err = ErrBudgetExhausted
+			return false
+		}
+	}
 	if r.attempt != 0 {
-		randomized(ctx, backoffDelay(r.attempt, r.options))
+		sleep(ctx, jittered(backoffDelay(r.attempt, r.options), r.options.Jitter))
 	}
 	r.attempt++
-	return ctx.Err() == nil
+	if err := ctx.Err(); err != nil {
+		r.err = err
+		return false
+	}
+	return true
+}
+
+// Err returns why the last call to Continue returned false, or nil if it
+// returned true.
+func (r *Retry) Err() error {
+	return r.err
 }
 
 // Reset resets a Retry to its initial state. Reset is useful if you want to
@@ -89,19 +163,34 @@ func (r *Retry) Continue(ctx context.Context) bool {
 //	}
 func (r *Retry) Reset() {
 	r.attempt = 0
+	r.err = nil
 }
 
 func backoffDelay(i int, opts Options) time.Duration {
 	mult := math.Pow(opts.BackoffMultiplier, float64(i))
-	return time.Duration(float64(opts.BackoffMinDuration) * mult)
+	d := float64(opts.BackoffMinDuration) * mult
+	if max := opts.BackoffMaxDuration; max > 0 && d > float64(max) {
+		return max
+	}
+	return time.Duration(d)
 }
 
-// randomized sleeps for a random duration close to d, or until context is done,
-// whichever occurs first.
-func randomized(ctx context.Context, d time.Duration) {
-	const jitter = 0.4
-	mult := 1 - jitter*randomFloat() // Subtract up to 40%
-	sleep(ctx, time.Duration(float64(d)*mult))
+// jittered returns a random duration close to d, according to the provided
+// jitter strategy.
+func jittered(d time.Duration, jitter Jitter) time.Duration {
+	var mult float64
+	switch jitter {
+	case NoJitter:
+		mult = 1
+	case FullJitter:
+		mult = randomFloat()
+	case EqualJitter:
+		mult = 0.5 + 0.5*randomFloat()
+	default:
+		const jitter = 0.4
+		mult = 1 - jitter*randomFloat() // Subtract up to 40%
+	}
+	return time.Duration(float64(d) * mult)
 }
 
 // sleep sleeps for the specified duration d, or until context is done,
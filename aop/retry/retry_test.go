@@ -2,7 +2,9 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"math"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -74,7 +76,7 @@ func TestRandomization(t *testing.T) {
 	var sum time.Duration
 	for i := 0; i < N; i++ {
 		start := time.Now()
-		randomized(context.Background(), delay)
+		sleep(context.Background(), jittered(delay, DefaultJitter))
 		elapsed := time.Since(start)
 		t.Logf("sleep duration: %v", elapsed)
 		diff := float64(elapsed - delay)
@@ -90,3 +92,72 @@ func TestRandomization(t *testing.T) {
 		t.Errorf("sleep interval was too consistent (+- %.1f%%)", stdDevFraction*100)
 	}
 }
+
+func TestJitter(t *testing.T) {
+	const d = time.Second
+	for _, test := range []struct {
+		jitter   Jitter
+		min, max time.Duration
+	}{
+		{DefaultJitter, 6 * d / 10, d},
+		{NoJitter, d, d},
+		{FullJitter, 0, d},
+		{EqualJitter, d / 2, d},
+	} {
+		for i := 0; i < 100; i++ {
+			if got := jittered(d, test.jitter); got < test.min || got > test.max {
+				t.Fatalf("jittered(%v, %v): got %v, want in [%v, %v]", d, test.jitter, got, test.min, test.max)
+			}
+		}
+	}
+}
+
+func TestBackoffMaxDuration(t *testing.T) {
+	opts := Options{BackoffMultiplier: 2, BackoffMinDuration: time.Second, BackoffMaxDuration: 5 * time.Second}
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := backoffDelay(i, opts); got != want {
+			t.Errorf("backoffDelay(%d): got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	r := BeginWithOptions(Options{BackoffMultiplier: 1, BackoffMinDuration: time.Millisecond, MaxAttempts: 3})
+	attempts := 0
+	for r.Continue(ctx) {
+		attempts++
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts, want 3", attempts)
+	}
+	if !errors.Is(r.Err(), ErrMaxAttempts) {
+		t.Errorf("got error %v, want %v", r.Err(), ErrMaxAttempts)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	// The budget allows two retries in a burst, then one retry every two
+	// retry loops.
+	ctx := context.Background()
+	budget := NewBudget(BudgetOptions{Ratio: 0.5, MaxTokens: 2})
+	opts := Options{BackoffMultiplier: 1, BackoffMinDuration: time.Microsecond, Budget: budget}
+	loop := func() int {
+		r := BeginWithOptions(opts)
+		attempts := 0
+		for r.Continue(ctx) {
+			attempts++
+		}
+		if !errors.Is(r.Err(), ErrBudgetExhausted) {
+			t.Fatalf("got error %v, want %v", r.Err(), ErrBudgetExhausted)
+		}
+		return attempts
+	}
+	var got []int
+	for i := 0; i < 4; i++ {
+		got = append(got, loop())
+	}
+	if want := []int{3, 1, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("attempts: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,230 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"greatestworks/aop/codegen"
+	"greatestworks/aop/net/call"
+	"greatestworks/aop/retry"
+)
+
+// Options configure a Caller.
+type Options struct {
+	// Caller names the calling process (e.g. its server id) in the method
+	// metrics (see codegen.MethodMetricsFor).
+	Caller string
+
+	// Nodes are the addresses of the processes serving a module (see Serve),
+	// by module. The calls to a module are balanced across its nodes. The
+	// modules without nodes are called in process.
+	Nodes map[string][]string
+
+	// Timeout is the deadline of the calls whose context has none, including
+	// their retries. Defaults to 3 seconds.
+	Timeout time.Duration
+
+	// Retry is the backoff between the attempts of a call. Defaults to 3
+	// attempts, 20-40ms then 40-80ms apart.
+	Retry retry.Options
+
+	// Budget limits the retries of all the calls of the Caller, so that a
+	// node that is down isn't flooded with retries once it's back up.
+	Budget retry.BudgetOptions
+}
+
+// CallOptions configure a call.
+type CallOptions struct {
+	// Timeout, if positive and if the context of the call has no deadline,
+	// replaces Options.Timeout.
+	Timeout time.Duration
+
+	// Idempotent is whether the call can be retried after a communication
+	// error, which it may have run despite. The calls that didn't reach a
+	// node, or that a draining node rejected, are retried either way.
+	Idempotent bool
+
+	// ShardKey, if not 0, routes the calls with the same key to the same
+	// node, e.g. the calls about the same player.
+	ShardKey uint64
+}
+
+var defaultRetry = retry.Options{
+	BackoffMultiplier:  2,
+	BackoffMinDuration: 20 * time.Millisecond,
+	BackoffMaxDuration: 500 * time.Millisecond,
+	Jitter:             retry.EqualJitter,
+	MaxAttempts:        3,
+}
+
+// A Caller calls the methods of the modules (see Handle), on the nodes that
+// serve them, or in process.
+//
+// You can safely use a Caller from multiple goroutines.
+type Caller struct {
+	opts    Options
+	conns   map[string]call.Connection // by module
+	budget  *retry.Budget
+	metrics sync.Map // methodKey -> *codegen.MethodMetrics
+}
+
+// Connect returns a Caller of the modules of Options.Nodes, and of the
+// modules of this process.
+func Connect(ctx context.Context, opts Options) (*Caller, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 3 * time.Second
+	}
+	if opts.Retry == (retry.Options{}) {
+		opts.Retry = defaultRetry
+	}
+	c := &Caller{
+		opts:   opts,
+		conns:  map[string]call.Connection{},
+		budget: retry.NewBudget(opts.Budget),
+	}
+	for module, addrs := range opts.Nodes {
+		if len(addrs) == 0 {
+			continue
+		}
+		endpoints := make([]call.Endpoint, len(addrs))
+		for i, addr := range addrs {
+			endpoints[i] = call.TCP(addr)
+		}
+		conn, err := call.Connect(ctx, call.NewConstantResolver(endpoints...), call.ClientOptions{Balancer: call.Sharded()})
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("rpc: connect to module %s: %w", module, err)
+		}
+		c.conns[module] = conn
+	}
+	return c, nil
+}
+
+// Close closes the connections of the Caller. Its pending calls fail.
+func (c *Caller) Close() {
+	for _, conn := range c.conns {
+		conn.Close()
+	}
+}
+
+// Call calls a method of a module with args, and decodes its result into
+// reply, unless reply is nil. The call is retried with backoff until its
+// deadline, as long as it fails to reach a node (see CallOptions.Idempotent).
+func (c *Caller) Call(ctx context.Context, module, method string, args, reply any, opts CallOptions) error {
+	start := time.Now()
+	metrics := c.metricsFor(module, method)
+	data, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("rpc: encode %s.%s args: %w", module, method, err)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := opts.Timeout
+		if timeout <= 0 {
+			timeout = c.opts.Timeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var out []byte
+	if conn, ok := c.conns[module]; ok {
+		out, err = c.call(ctx, conn, call.MakeMethodKey(module, method), data, opts)
+	} else if h := handler(module, method); h != nil {
+		out, err = h(ctx, data)
+	} else {
+		err = fmt.Errorf("%w %s.%s", ErrUnknownMethod, module, method)
+	}
+
+	metrics.Count.Add(1)
+	metrics.Latency.Put(float64(time.Since(start).Microseconds()))
+	metrics.BytesRequest.Put(float64(len(data)))
+	if err != nil {
+		metrics.ErrorCount.Add(1)
+		return err
+	}
+	metrics.BytesReply.Put(float64(len(out)))
+	if reply == nil {
+		return nil
+	}
+	if err := json.Unmarshal(out, reply); err != nil {
+		return fmt.Errorf("rpc: decode %s.%s reply: %w", module, method, err)
+	}
+	return nil
+}
+
+// call calls a method on a node, retrying the calls that can be.
+func (c *Caller) call(ctx context.Context, conn call.Connection, key call.MethodKey, data []byte, opts CallOptions) ([]byte, error) {
+	ropts := c.opts.Retry
+	ropts.Budget = c.budget
+	var err error
+	for r := retry.BeginWithOptions(ropts); r.Continue(ctx); {
+		var out []byte
+		out, err = conn.Call(ctx, key, data, call.CallOptions{ShardKey: opts.ShardKey})
+		if err == nil || !retriable(err, opts.Idempotent) {
+			return out, err
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return nil, err
+}
+
+// retriable returns whether a call that failed with err can be retried.
+func retriable(err error, idempotent bool) bool {
+	switch {
+	case errors.Is(err, call.Unreachable), errors.Is(err, call.Draining):
+		return true
+	case errors.Is(err, call.CommunicationError):
+		return idempotent
+	default:
+		return false
+	}
+}
+
+func (c *Caller) metricsFor(module, method string) *codegen.MethodMetrics {
+	key := methodKey{module, method}
+	if m, ok := c.metrics.Load(key); ok {
+		return m.(*codegen.MethodMetrics)
+	}
+	m, _ := c.metrics.LoadOrStore(key, codegen.MethodMetricsFor(codegen.MethodLabels{
+		Caller:    c.opts.Caller,
+		Component: module,
+		Method:    method,
+	}))
+	return m.(*codegen.MethodMetrics)
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultCaller = &Caller{conns: map[string]call.Connection{}, opts: Options{Timeout: 3 * time.Second}}
+)
+
+// Init connects the Caller used by Call, replacing the previous one. Until
+// then, Call calls the modules in process.
+func Init(ctx context.Context, opts Options) error {
+	c, err := Connect(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defaultMu.Lock()
+	old := defaultCaller
+	defaultCaller = c
+	defaultMu.Unlock()
+	old.Close()
+	return nil
+}
+
+// Call calls a method of a module with the Caller connected by Init (see
+// Caller.Call).
+func Call(ctx context.Context, module, method string, args, reply any, opts CallOptions) error {
+	defaultMu.Lock()
+	c := defaultCaller
+	defaultMu.Unlock()
+	return c.Call(ctx, module, method, args, reply, opts)
+}
@@ -0,0 +1,159 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/spoor"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/net/call"
+	"greatestworks/aop/retry"
+)
+
+// TestMain sets up the logger, which the package logs its failures with.
+func TestMain(m *testing.M) {
+	logger.SetLogging(&logger.LoggingSetting{WriterOption: spoor.WithConsoleWriter(os.Stderr)})
+	os.Exit(m.Run())
+}
+
+var errNoRank = errors.New("no such rank")
+
+type topArgs struct {
+	RankId uint32
+	N      int
+}
+
+type topReply struct {
+	Players []uint64
+}
+
+func init() {
+	Handle("rank", "Top", func(ctx context.Context, args *topArgs) (*topReply, error) {
+		if args.RankId != 1 {
+			return nil, errNoRank
+		}
+		reply := &topReply{}
+		for i := 0; i < args.N; i++ {
+			reply.Players = append(reply.Players, uint64(100+i))
+		}
+		return reply, nil
+	})
+	Handle("rank", "Panic", func(ctx context.Context, args *topArgs) (*topReply, error) {
+		panic("boom")
+	})
+}
+
+// freeAddr returns a local address that nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+// serve serves the handlers on addr until the test ends.
+func serve(t *testing.T, addr string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, addr) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+}
+
+func connect(t *testing.T, opts Options) *Caller {
+	t.Helper()
+	c, err := Connect(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func testCalls(t *testing.T, c *Caller) {
+	ctx := context.Background()
+	var reply topReply
+	if err := c.Call(ctx, "rank", "Top", &topArgs{RankId: 1, N: 3}, &reply, CallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Players) != 3 || reply.Players[0] != 100 || reply.Players[2] != 102 {
+		t.Fatalf("Top: got %v", reply.Players)
+	}
+	if err := c.Call(ctx, "rank", "Top", &topArgs{RankId: 2}, &reply, CallOptions{}); !errors.Is(err, errNoRank) {
+		t.Fatalf("Top unknown rank: got %v, want %v", err, errNoRank)
+	}
+	if err := c.Call(ctx, "rank", "Panic", &topArgs{}, &reply, CallOptions{}); err == nil {
+		t.Fatal("Panic: got no error")
+	}
+}
+
+func TestLocal(t *testing.T) {
+	c := connect(t, Options{})
+	testCalls(t, c)
+	if err := c.Call(context.Background(), "rank", "Missing", nil, nil, CallOptions{}); !errors.Is(err, ErrUnknownMethod) {
+		t.Fatalf("Missing: got %v, want %v", err, ErrUnknownMethod)
+	}
+}
+
+func TestRemote(t *testing.T) {
+	addr := freeAddr(t)
+	serve(t, addr)
+	c := connect(t, Options{
+		Nodes: map[string][]string{"rank": {addr}},
+		Retry: retry.Options{BackoffMultiplier: 1, BackoffMinDuration: 20 * time.Millisecond, MaxAttempts: 10},
+	})
+	testCalls(t, c)
+}
+
+func TestRetry(t *testing.T) {
+	addr := freeAddr(t)
+	c := connect(t, Options{
+		Nodes: map[string][]string{"rank": {addr}},
+		Retry: retry.Options{BackoffMultiplier: 1, BackoffMinDuration: 20 * time.Millisecond, MaxAttempts: 20},
+	})
+
+	// The node is down: the call may have run, unless it's idempotent.
+	ctx := context.Background()
+	err := c.Call(ctx, "rank", "Top", &topArgs{RankId: 1}, nil, CallOptions{})
+	if !errors.Is(err, call.CommunicationError) {
+		t.Fatalf("node down: got %v, want %v", err, call.CommunicationError)
+	}
+
+	// The node comes back up while an idempotent call is retried.
+	time.AfterFunc(100*time.Millisecond, func() { serve(t, addr) })
+	var reply topReply
+	if err := c.Call(ctx, "rank", "Top", &topArgs{RankId: 1, N: 1}, &reply, CallOptions{Idempotent: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Players) != 1 {
+		t.Fatalf("Top: got %v", reply.Players)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	addr := freeAddr(t)
+	c := connect(t, Options{
+		Nodes: map[string][]string{"rank": {addr}},
+		Retry: retry.Options{BackoffMultiplier: 1, BackoffMinDuration: 20 * time.Millisecond},
+	})
+	start := time.Now()
+	err := c.Call(context.Background(), "rank", "Top", &topArgs{RankId: 1}, nil, CallOptions{Idempotent: true, Timeout: 100 * time.Millisecond})
+	if err == nil {
+		t.Fatal("node down: got no error")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("node down: retried for %v", d)
+	}
+}
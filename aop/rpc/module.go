@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"sync"
+
+	"greatestworks/aop/logger"
+	"greatestworks/aop/net/call"
+)
+
+// ErrUnknownMethod is returned by the calls to a method that no handler is
+// registered for, in process.
+var ErrUnknownMethod = errors.New("rpc: unknown method")
+
+// methodKey identifies a method of a module.
+type methodKey struct {
+	module, method string
+}
+
+var (
+	handlersMu sync.Mutex
+	handlers   = map[methodKey]call.Handler{}
+)
+
+// Handle registers the handler of a method of a module, which the modules of
+// this process and of other processes (see Serve) call with Call. The
+// arguments and the replies are encoded in JSON. An error returned by the
+// handler is returned by the call; errors.Is matches it against the sentinel
+// errors of the module, across processes, by type and message.
+//
+// The handlers must be registered before Serve, e.g. in the Init of their
+// module.
+func Handle[A, R any](module, method string, handler func(ctx context.Context, args *A) (*R, error)) {
+	key, name := methodKey{module, method}, module+"."+method
+	h := func(ctx context.Context, data []byte) (out []byte, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("[rpc] %s panic %v\n%s", name, r, debug.Stack())
+				err = fmt.Errorf("rpc: %s panicked: %v", name, r)
+			}
+		}()
+		args := new(A)
+		if err := json.Unmarshal(data, args); err != nil {
+			return nil, fmt.Errorf("rpc: decode %s args: %w", name, err)
+		}
+		reply, err := handler(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(reply)
+	}
+
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	if _, ok := handlers[key]; ok {
+		panic(fmt.Sprintf("[rpc] repeated register %s", name))
+	}
+	handlers[key] = h
+}
+
+// handler returns the handler of a method of a module, nil if there's none.
+func handler(module, method string) call.Handler {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	return handlers[methodKey{module, method}]
+}
+
+// Serve serves the handlers registered so far to the other processes, on a
+// TCP address, until ctx is done.
+func Serve(ctx context.Context, addr string) error {
+	var hmap call.HandlerMap
+	handlersMu.Lock()
+	for key, h := range handlers {
+		hmap.Set(key.module, key.method, h)
+	}
+	n := len(handlers)
+	handlersMu.Unlock()
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	logger.Info("[rpc] Serve %d methods on %s", n, l.Addr())
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	err = call.Serve(ctx, l, &hmap, call.ServerOptions{})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package saga
+
+import (
+	"context"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/redis"
+)
+
+// The leases are deadlines in milliseconds on the clock of Redis, as the
+// scripts run on it: they call TIME before writing, which needs Redis 5 or
+// later (the effects of the scripts are replicated, not the scripts).
+const nowLua = `
+local t = redis.call('time')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+`
+
+// KEYS = [record], ARGV = [running state, lease in ms, ttl in ms]. Returns
+// [state, token, steps done].
+var beginScript = redis.NewScript("saga.begin", nowLua+`
+local r = redis.call('hmget', KEYS[1], 'st', 'tok', 'done', 'lease')
+if r[1] then
+	local st = tonumber(r[1])
+	if r[1] ~= ARGV[1] or tonumber(r[4]) > now then
+		return {st, 0, 0}
+	end
+	local tok = tonumber(r[2]) + 1
+	redis.call('hset', KEYS[1], 'tok', tok, 'lease', now + ARGV[2])
+	return {st, tok, tonumber(r[3])}
+end
+redis.call('hset', KEYS[1], 'st', ARGV[1], 'tok', 1, 'done', 0, 'lease', now + ARGV[2])
+redis.call('pexpire', KEYS[1], ARGV[3])
+return {0, 1, 0}`)
+
+// KEYS = [record], ARGV = [running state, token, lease in ms]
+var renewScript = redis.NewScript("saga.renew", nowLua+`
+local r = redis.call('hmget', KEYS[1], 'st', 'tok')
+if r[1] ~= ARGV[1] or r[2] ~= ARGV[2] then
+	return 0
+end
+redis.call('hset', KEYS[1], 'lease', now + ARGV[3])
+return 1`)
+
+// KEYS = [record], ARGV = [running state, token, steps done]
+var progressScript = redis.NewScript("saga.progress", `
+local r = redis.call('hmget', KEYS[1], 'st', 'tok')
+if r[1] ~= ARGV[1] or r[2] ~= ARGV[2] then
+	return 0
+end
+redis.call('hset', KEYS[1], 'done', ARGV[3])
+return 1`)
+
+// KEYS = [record], ARGV = [token, state, ttl in ms]; state 0 deletes the
+// record.
+var finishScript = redis.NewScript("saga.finish", `
+if redis.call('hget', KEYS[1], 'tok') ~= ARGV[1] then
+	return 0
+end
+if ARGV[2] == '0' then
+	return redis.call('del', KEYS[1])
+end
+redis.call('hset', KEYS[1], 'st', ARGV[2])
+redis.call('hdel', KEYS[1], 'lease')
+return redis.call('pexpire', KEYS[1], ARGV[3])`)
+
+// RedisStore records the sagas in Redis: the saga of key in the hash
+// "saga:key", of its state, the token of its run, the steps it did and the
+// deadline of its lease.
+type RedisStore struct {
+	rdb goredis.UniversalClient
+}
+
+// NewRedisStore returns the store of the sagas in Redis.
+func NewRedisStore(rdb goredis.UniversalClient) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+func (s *RedisStore) Begin(ctx context.Context, key string, lease, ttl time.Duration) (Record, error) {
+	res, err := beginScript.Run(ctx, s.rdb, []string{"saga:" + key}, int(StateRunning), lease.Milliseconds(), ttl.Milliseconds()).Int64Slice()
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{State: State(res[0]), Token: uint64(res[1]), Done: int(res[2])}, nil
+}
+
+func (s *RedisStore) Renew(ctx context.Context, key string, token uint64, lease time.Duration) (bool, error) {
+	n, err := renewScript.Run(ctx, s.rdb, []string{"saga:" + key}, int(StateRunning), token, lease.Milliseconds()).Int()
+	return n == 1, err
+}
+
+func (s *RedisStore) Progress(ctx context.Context, key string, token uint64, done int) (bool, error) {
+	n, err := progressScript.Run(ctx, s.rdb, []string{"saga:" + key}, int(StateRunning), token, done).Int()
+	return n == 1, err
+}
+
+func (s *RedisStore) Finish(ctx context.Context, key string, token uint64, state State, ttl time.Duration) error {
+	return finishScript.Run(ctx, s.rdb, []string{"saga:" + key}, token, int(state), ttl.Milliseconds()).Err()
+}
@@ -0,0 +1,485 @@
+// Package saga runs the operations spanning modules (e.g., spend currency,
+// then grant items, then mark a mail claimed) as sagas: sequences of steps,
+// each with an undo compensating it. If a step fails, the undos of the steps
+// done run in reverse order, so that the operation is applied all or not at
+// all, instead of every handler cleaning up its partial failures by hand.
+//
+// A saga may have an idempotency key, naming the operation (e.g., the claim
+// of a mail, the bid of a player on a listing in a given state). The Runner
+// records the sagas run by key in a Store (Redis, see RedisStore): a saga
+// whose key was run already, or is running, isn't run again, however many
+// times, or on however many servers, it's retried. A saga running is leased
+// to its runner, which records the steps it does: if the runner crashes, the
+// lease runs out, and the next Run of the key compensates the steps done
+// before it runs the saga again.
+//
+// The undos run once, in a context of their own, as the context of the
+// operation may be what failed it. An undo that fails leaves the operation
+// partially applied: it's logged, counted, and its key is recorded as failed
+// for an operator to fix it, e.g., from the ledger of the currencies.
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"greatestworks/aop/fn"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/redis"
+)
+
+var (
+	// ErrDone is returned by Run for a saga whose key was run successfully.
+	ErrDone = errors.New("saga: already done")
+	// ErrInProgress is returned by Run for a saga whose key is running.
+	ErrInProgress = errors.New("saga: in progress")
+	// ErrFailed is returned by Run for a saga whose key failed and couldn't
+	// be compensated.
+	ErrFailed = errors.New("saga: failed, not compensated")
+	// ErrAbandoned is the error of the step of a saga whose runner crashed,
+	// or lost the lease of its key, while it ran, wrapped by an *Error.
+	ErrAbandoned = errors.New("saga: abandoned by its runner")
+)
+
+var (
+	runs = metrics.NewCounterMap[runLabels](
+		"saga_runs",
+		"Number of sagas run, by result",
+	)
+	durations = metrics.NewHistogramMap[sagaLabels](
+		"saga_duration_millis",
+		"Duration of the sagas, compensation included, in milliseconds",
+		metrics.NonNegativeBuckets,
+	)
+	compensationFailures = metrics.NewCounterMap[stepLabels](
+		"saga_compensation_failures",
+		"Number of undos of steps that failed, leaving their saga partially applied",
+	)
+)
+
+type sagaLabels struct {
+	Saga string
+}
+
+type runLabels struct {
+	Saga   string
+	Result string // "done", "compensated", "failed", "duplicate", "recovered" or "abandoned"
+}
+
+type stepLabels struct {
+	Saga string
+	Step string
+}
+
+// Step is a step of a saga.
+type Step struct {
+	Name string
+	// Do applies the step.
+	Do func(ctx context.Context) error
+	// Undo compensates the step, once Do succeeded, if a later step fails.
+	// Nil if the step needs no compensation (e.g., the last step, or a
+	// check).
+	Undo func(ctx context.Context) error
+}
+
+// Saga is an operation of steps, run by Runner.Run.
+type Saga struct {
+	// Name is the kind of the operation (e.g., "shop_buy"), for the logs and
+	// the metrics.
+	Name string
+	// Key is the idempotency key of the operation, or "" if it has none:
+	// a saga with a key is run at most once per key.
+	Key   string
+	Steps []Step
+}
+
+// Step appends a step to the saga, and returns it.
+func (s *Saga) Step(name string, do, undo func(ctx context.Context) error) *Saga {
+	s.Steps = append(s.Steps, Step{Name: name, Do: do, Undo: undo})
+	return s
+}
+
+// Error is the error of a saga that failed: the error of its step that
+// failed, which it wraps, and the errors of the undos that failed, if any.
+type Error struct {
+	Saga   string
+	Step   string
+	Err    error
+	Undone []error // errors of the undos that failed
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("saga %s: step %s: %v", e.Saga, e.Step, e.Err)
+	if len(e.Undone) > 0 {
+		undone := make([]string, len(e.Undone))
+		for i, err := range e.Undone {
+			undone[i] = err.Error()
+		}
+		msg += fmt.Sprintf(" (not compensated: %s)", strings.Join(undone, "; "))
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Compensated returns whether the steps done before the failure were all
+// undone.
+func (e *Error) Compensated() bool {
+	return len(e.Undone) == 0
+}
+
+// State is the state of the saga of a key.
+type State int
+
+const (
+	StateNone    State = iota // not run, or compensated
+	StateRunning              // running, or its runner crashed
+	StateDone                 // done
+	StateFailed               // failed, and not compensated
+)
+
+// Record is the record of the saga of a key, as returned by Store.Begin.
+type Record struct {
+	State State
+	// Token identifies the run of the caller, if it's to run the saga: the
+	// key wasn't recorded (StateNone), or the lease of its runner ran out
+	// (StateRunning). It's 0 otherwise.
+	Token uint64
+	// Done is the number of steps done by the runner whose lease ran out.
+	Done int
+}
+
+// Store records the sagas run by key. The calls with a token are ignored
+// (Renew and Progress return false) once the lease of the run of the token
+// was taken over.
+type Store interface {
+	// Begin records the saga of key as running, leased to the caller for
+	// lease and recorded for ttl, unless the key is recorded. If the lease
+	// of the saga running ran out, it takes it over for the caller.
+	Begin(ctx context.Context, key string, lease, ttl time.Duration) (Record, error)
+	// Renew extends the lease of the saga of key to lease.
+	Renew(ctx context.Context, key string, token uint64, lease time.Duration) (bool, error)
+	// Progress records that the first done steps of the saga of key are
+	// done.
+	Progress(ctx context.Context, key string, token uint64, done int) (bool, error)
+	// Finish records the state of the saga of key, for ttl, or deletes its
+	// record for StateNone.
+	Finish(ctx context.Context, key string, token uint64, state State, ttl time.Duration) error
+}
+
+// Options are the options of a Runner.
+type Options struct {
+	// Retain is how long the outcome of a saga with a key is recorded, during
+	// which the sagas of the same key aren't run. Defaults to 24 hours.
+	Retain time.Duration
+	// Lease is how long a saga with a key is leased to its runner, which
+	// renews it every Lease/3 while it runs. Once it runs out (e.g., the
+	// runner crashed), the next Run of the key takes the saga over.
+	// Defaults to 10 seconds.
+	Lease time.Duration
+	// UndoTimeout bounds each undo of a saga that failed. Defaults to 10
+	// seconds.
+	UndoTimeout time.Duration
+}
+
+func (o *Options) setDefaults() {
+	if o.Retain <= 0 {
+		o.Retain = 24 * time.Hour
+	}
+	if o.Lease <= 0 {
+		o.Lease = 10 * time.Second
+	}
+	if o.UndoTimeout <= 0 {
+		o.UndoTimeout = 10 * time.Second
+	}
+}
+
+// Runner runs sagas, recording them by key in a store.
+type Runner struct {
+	store Store
+	opts  Options
+}
+
+// New returns a runner recording the sagas in a store.
+func New(store Store, opts Options) *Runner {
+	opts.setDefaults()
+	return &Runner{store: store, opts: opts}
+}
+
+var defaultRunner = fn.NewDefault(func() *Runner {
+	return New(NewRedisStore(redis.Get()), Options{})
+})
+
+// SetDefault replaces the runner of the process, e.g., at start with the
+// options configured, or in tests.
+func SetDefault(r *Runner) {
+	defaultRunner.Set(r)
+}
+
+// Get returns the runner of the process: the one set by SetDefault, or else
+// a runner recording the sagas in the Redis of the process, with the default
+// options.
+func Get() *Runner {
+	return defaultRunner.Get()
+}
+
+// Run runs the steps of a saga in order, in ctx. If a step fails (or
+// panics), the undos of the steps done run in reverse order, and Run
+// returns an *Error wrapping the error of the step, so that errors.Is sees
+// it. If the saga has a key already recorded, Run returns ErrDone,
+// ErrInProgress or ErrFailed, without running it, unless the lease of the
+// saga running ran out: then it compensates the steps done by its runner
+// first. The sagas of a key must have the same steps.
+func (r *Runner) Run(ctx context.Context, s *Saga) error {
+	var l *lease
+	if s.Key != "" {
+		rec, err := r.store.Begin(ctx, s.Key, r.opts.Lease, r.opts.Retain)
+		if err != nil {
+			return fmt.Errorf("saga %s: begin %s: %w", s.Name, s.Key, err)
+		}
+		if rec.Token == 0 {
+			switch rec.State {
+			case StateRunning:
+				err = ErrInProgress
+			case StateDone:
+				err = ErrDone
+			default:
+				err = ErrFailed
+			}
+			runs.Get(runLabels{Saga: s.Name, Result: "duplicate"}).Add(1)
+			return fmt.Errorf("saga %s: %s: %w", s.Name, s.Key, err)
+		}
+		ctx, l = r.hold(ctx, s, rec.Token)
+		defer l.release()
+		if rec.State == StateRunning {
+			if err := r.recover(s, l, rec.Done); err != nil {
+				return err
+			}
+		}
+	}
+
+	start := time.Now()
+	err := r.run(ctx, s, l)
+	durations.Get(sagaLabels{Saga: s.Name}).Put(float64(time.Since(start).Milliseconds()))
+
+	result, state := "done", StateDone
+	var serr *Error
+	if errors.As(err, &serr) {
+		result, state = "compensated", StateNone
+		if !serr.Compensated() {
+			result, state = "failed", StateFailed
+			logger.Error("[saga] %v key:%v err:%v", s.Name, s.Key, serr)
+		}
+	}
+	if l != nil && l.lost {
+		// The steps recorded done are left to the run taking the saga over.
+		result = "abandoned"
+		logger.Warn("[saga] %v key:%v abandoned after %v steps err:%v", s.Name, s.Key, l.done, err)
+	}
+	runs.Get(runLabels{Saga: s.Name, Result: result}).Add(1)
+	if l != nil && !l.lost {
+		l.finish(state)
+	}
+	return err
+}
+
+// recover compensates the first done steps of a saga, done by a runner whose
+// lease ran out, recording its progress after every undo, so that the saga
+// runs again from the start.
+func (r *Runner) recover(s *Saga, l *lease, done int) error {
+	logger.Warn("[saga] %v key:%v taken over after %v steps", s.Name, s.Key, done)
+	step := ""
+	if done < len(s.Steps) {
+		step = s.Steps[done].Name
+	}
+	var undone []error
+	for i := done - 1; i >= 0; i-- {
+		if err := r.undo(s, i); err != nil {
+			undone = append(undone, err)
+		}
+		if err := l.progress(i); err != nil {
+			l.lost = true
+			return &Error{Saga: s.Name, Step: step, Err: err, Undone: undone}
+		}
+	}
+	if len(undone) > 0 {
+		serr := &Error{Saga: s.Name, Step: step, Err: ErrAbandoned, Undone: undone}
+		logger.Error("[saga] %v key:%v err:%v", s.Name, s.Key, serr)
+		runs.Get(runLabels{Saga: s.Name, Result: "failed"}).Add(1)
+		l.finish(StateFailed)
+		return serr
+	}
+	runs.Get(runLabels{Saga: s.Name, Result: "recovered"}).Add(1)
+	return nil
+}
+
+// run runs the steps of a saga, recording them done if it has a lease, and
+// compensates them if one fails.
+func (r *Runner) run(ctx context.Context, s *Saga, l *lease) error {
+	for i, st := range s.Steps {
+		if err := do(ctx, st.Do); err != nil {
+			return r.fail(s, l, i, st.Name, err)
+		}
+		if l != nil {
+			if err := l.progress(i + 1); err != nil {
+				return r.fail(s, l, i+1, st.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// fail compensates the first n steps of a saga, as its step named step
+// failed with err. If the runner doesn't hold the lease of the saga anymore
+// (or can't tell), it only compensates the steps it didn't record done: the
+// others are left to the run taking the saga over.
+func (r *Runner) fail(s *Saga, l *lease, n int, step string, err error) error {
+	from := 0
+	if l != nil && !l.held() {
+		from = l.done
+	}
+	return &Error{Saga: s.Name, Step: step, Err: err, Undone: r.compensate(s, from, n)}
+}
+
+// compensate runs the undos of the steps of a saga from the fromth to the
+// one before the nth, in reverse order, and returns their errors.
+func (r *Runner) compensate(s *Saga, from, n int) []error {
+	var errs []error
+	for i := n - 1; i >= from; i-- {
+		if err := r.undo(s, i); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// undo runs the undo of the ith step of a saga, if it has one.
+func (r *Runner) undo(s *Saga, i int) error {
+	st := s.Steps[i]
+	if st.Undo == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.UndoTimeout)
+	defer cancel()
+	if err := do(ctx, st.Undo); err != nil {
+		compensationFailures.Get(stepLabels{Saga: s.Name, Step: st.Name}).Add(1)
+		logger.Error("[saga] undo %v of %v err:%v", st.Name, s.Name, err)
+		return fmt.Errorf("undo %s: %w", st.Name, err)
+	}
+	return nil
+}
+
+// lease is the lease of a saga with a key, held by its runner.
+type lease struct {
+	r      *Runner
+	s      *Saga
+	token  uint64
+	cancel context.CancelFunc
+	stop   chan struct{}
+	done   int  // steps recorded done
+	lost   bool // whether the lease was lost, or couldn't be told held
+}
+
+// hold returns the lease of a saga taken with token, and a context of ctx
+// canceled once it's lost, and starts renewing it.
+func (r *Runner) hold(ctx context.Context, s *Saga, token uint64) (context.Context, *lease) {
+	l := &lease{r: r, s: s, token: token, stop: make(chan struct{})}
+	ctx, l.cancel = context.WithCancel(ctx)
+	go l.renew()
+	return ctx, l
+}
+
+// renew extends the lease every Lease/3, until it's released or lost:
+// either it was taken over, or it wasn't extended for a Lease (e.g., Redis
+// was unreachable), in which case it may be taken over.
+func (l *lease) renew() {
+	ttl := l.r.opts.Lease
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.stop:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		ok, err := l.r.store.Renew(ctx, l.s.Key, l.token, ttl)
+		cancel()
+		switch {
+		case err == nil && ok:
+			renewed = time.Now()
+			continue
+		case err == nil:
+			logger.Error("[saga] %v key:%v lease lost: taken over", l.s.Name, l.s.Key)
+		case time.Since(renewed) < ttl:
+			logger.Warn("[saga] renew %v key:%v err:%v", l.s.Name, l.s.Key, err)
+			continue
+		default:
+			logger.Error("[saga] %v key:%v lease lost: not renewed for %v, err:%v", l.s.Name, l.s.Key, time.Since(renewed), err)
+		}
+		l.cancel()
+		return
+	}
+}
+
+// progress records the first done steps of the saga done. It returns
+// ErrAbandoned if the lease was taken over.
+func (l *lease) progress(done int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.r.opts.Lease/3)
+	defer cancel()
+	ok, err := l.r.store.Progress(ctx, l.s.Key, l.token, done)
+	if err != nil {
+		return fmt.Errorf("record progress: %w", err)
+	}
+	if !ok {
+		return ErrAbandoned
+	}
+	l.done = done
+	return nil
+}
+
+// held returns whether the lease is still held, renewing it. Once it isn't,
+// or can't be told, the lease is lost.
+func (l *lease) held() bool {
+	if l.lost {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), l.r.opts.Lease/3)
+	defer cancel()
+	ok, err := l.r.store.Renew(ctx, l.s.Key, l.token, l.r.opts.Lease)
+	l.lost = err != nil || !ok
+	return !l.lost
+}
+
+// finish records the outcome of the saga. It's recorded even if the context
+// of the saga is what failed it.
+func (l *lease) finish(state State) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.r.opts.UndoTimeout)
+	defer cancel()
+	if err := l.r.store.Finish(ctx, l.s.Key, l.token, state, l.r.opts.Retain); err != nil {
+		logger.Warn("[saga] record %v key:%v state:%v err:%v", l.s.Name, l.s.Key, state, err)
+	}
+}
+
+// release stops renewing the lease.
+func (l *lease) release() {
+	close(l.stop)
+	l.cancel()
+}
+
+// do calls f, turning a panic into an error.
+func do(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return f(ctx)
+}
@@ -0,0 +1,368 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/spoor"
+	"greatestworks/aop/logger"
+)
+
+// TestMain sets up the logger, which the package logs its failures with.
+func TestMain(m *testing.M) {
+	logger.SetLogging(&logger.LoggingSetting{WriterOption: spoor.WithConsoleWriter(os.Stderr)})
+	os.Exit(m.Run())
+}
+
+// memStore is a Store in memory, whose records don't expire.
+type memStore struct {
+	mu      sync.Mutex
+	records map[string]*memRecord
+	down    bool // whether the calls fail, as if the store was unreachable
+}
+
+type memRecord struct {
+	state State
+	token uint64
+	done  int
+	lease time.Time
+}
+
+var errDown = errors.New("store down")
+
+func newMemStore() *memStore {
+	return &memStore{records: map[string]*memRecord{}}
+}
+
+func (s *memStore) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+// running returns the record of the saga of key, if it's running with token.
+func (s *memStore) running(key string, token uint64) *memRecord {
+	if rec := s.records[key]; rec != nil && rec.state == StateRunning && rec.token == token {
+		return rec
+	}
+	return nil
+}
+
+func (s *memStore) Begin(_ context.Context, key string, lease, _ time.Duration) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return Record{}, errDown
+	}
+	rec := s.records[key]
+	if rec == nil {
+		s.records[key] = &memRecord{state: StateRunning, token: 1, lease: time.Now().Add(lease)}
+		return Record{Token: 1}, nil
+	}
+	if rec.state != StateRunning || time.Now().Before(rec.lease) {
+		return Record{State: rec.state}, nil
+	}
+	rec.token++
+	rec.lease = time.Now().Add(lease)
+	return Record{State: StateRunning, Token: rec.token, Done: rec.done}, nil
+}
+
+func (s *memStore) Renew(_ context.Context, key string, token uint64, lease time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return false, errDown
+	}
+	rec := s.running(key, token)
+	if rec != nil {
+		rec.lease = time.Now().Add(lease)
+	}
+	return rec != nil, nil
+}
+
+func (s *memStore) Progress(_ context.Context, key string, token uint64, done int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return false, errDown
+	}
+	rec := s.running(key, token)
+	if rec != nil {
+		rec.done = done
+	}
+	return rec != nil, nil
+}
+
+func (s *memStore) Finish(_ context.Context, key string, token uint64, state State, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return errDown
+	}
+	if rec := s.records[key]; rec != nil && rec.token == token {
+		if state == StateNone {
+			delete(s.records, key)
+		} else {
+			rec.state = state
+		}
+	}
+	return nil
+}
+
+// recorder records the calls of the steps of a saga.
+type recorder struct {
+	calls []string
+}
+
+// step returns a step that records its calls, whose Do fails with doErr and
+// whose Undo fails with undoErr.
+func (r *recorder) step(name string, doErr, undoErr error) (string, func(context.Context) error, func(context.Context) error) {
+	return name,
+		func(context.Context) error {
+			r.calls = append(r.calls, name)
+			return doErr
+		},
+		func(context.Context) error {
+			r.calls = append(r.calls, "undo "+name)
+			return undoErr
+		}
+}
+
+func TestRun(t *testing.T) {
+	var rec recorder
+	s := (&Saga{Name: "test"}).
+		Step(rec.step("spend", nil, nil)).
+		Step(rec.step("grant", nil, nil))
+	if err := New(newMemStore(), Options{}).Run(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"spend", "grant"}; !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("calls %v, want %v", rec.calls, want)
+	}
+}
+
+func TestCompensate(t *testing.T) {
+	errFull := errors.New("bag full")
+	var rec recorder
+	s := (&Saga{Name: "test"}).
+		Step(rec.step("claim", nil, nil)).
+		Step("check", func(context.Context) error { return nil }, nil).
+		Step(rec.step("spend", nil, nil)).
+		Step(rec.step("grant", errFull, nil)).
+		Step(rec.step("notify", nil, nil))
+	err := New(newMemStore(), Options{}).Run(context.Background(), s)
+	if !errors.Is(err, errFull) {
+		t.Fatalf("Run: got %v, want %v", err, errFull)
+	}
+	var serr *Error
+	if !errors.As(err, &serr) || serr.Step != "grant" || !serr.Compensated() {
+		t.Errorf("Run: got %#v", err)
+	}
+	if want := []string{"claim", "spend", "grant", "undo spend", "undo claim"}; !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("calls %v, want %v", rec.calls, want)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	var rec recorder
+	s := (&Saga{Name: "test"}).
+		Step(rec.step("spend", nil, nil)).
+		Step("grant", func(context.Context) error { panic("boom") }, nil)
+	var serr *Error
+	if err := New(newMemStore(), Options{}).Run(context.Background(), s); !errors.As(err, &serr) || serr.Step != "grant" {
+		t.Fatalf("Run: got %v", err)
+	}
+	if want := []string{"spend", "undo spend"}; !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("calls %v, want %v", rec.calls, want)
+	}
+}
+
+func TestUndoContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var undoErr error
+	s := (&Saga{Name: "test"}).
+		Step("spend", func(context.Context) error { return nil }, func(ctx context.Context) error {
+			undoErr = ctx.Err()
+			return nil
+		}).
+		Step("grant", func(ctx context.Context) error {
+			cancel()
+			return ctx.Err()
+		}, nil)
+	if err := New(newMemStore(), Options{}).Run(ctx, s); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run: got %v, want %v", err, context.Canceled)
+	}
+	if undoErr != nil {
+		t.Errorf("undo ran in a context done: %v", undoErr)
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	r := New(store, Options{})
+	errFull := errors.New("bag full")
+
+	// A saga compensated may run again.
+	var rec recorder
+	s := (&Saga{Name: "test", Key: "mail:1:2"}).Step(rec.step("grant", errFull, nil))
+	if err := r.Run(ctx, s); !errors.Is(err, errFull) {
+		t.Fatalf("Run: got %v, want %v", err, errFull)
+	}
+	s = (&Saga{Name: "test", Key: "mail:1:2"}).Step(rec.step("grant", nil, nil))
+	if err := r.Run(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	// A saga done doesn't.
+	if err := r.Run(ctx, s); !errors.Is(err, ErrDone) {
+		t.Fatalf("Run of a saga done: got %v, want ErrDone", err)
+	}
+	if want := []string{"grant", "grant"}; !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("calls %v, want %v", rec.calls, want)
+	}
+
+	// Nor does a saga running, or one not compensated.
+	store.records["mail:1:3"] = &memRecord{state: StateRunning, token: 1, lease: time.Now().Add(time.Hour)}
+	if err := r.Run(ctx, &Saga{Name: "test", Key: "mail:1:3"}); !errors.Is(err, ErrInProgress) {
+		t.Fatalf("Run of a saga running: got %v, want ErrInProgress", err)
+	}
+	rec.calls = nil
+	s = (&Saga{Name: "test", Key: "mail:1:4"}).
+		Step(rec.step("spend", nil, errors.New("down"))).
+		Step(rec.step("grant", errFull, nil))
+	var serr *Error
+	if err := r.Run(ctx, s); !errors.As(err, &serr) || serr.Compensated() {
+		t.Fatalf("Run: got %v, want an error not compensated", err)
+	}
+	if err := r.Run(ctx, s); !errors.Is(err, ErrFailed) {
+		t.Fatalf("Run of a saga failed: got %v, want ErrFailed", err)
+	}
+	if want := []string{"spend", "grant", "undo spend"}; !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("calls %v, want %v", rec.calls, want)
+	}
+}
+
+func TestCrash(t *testing.T) {
+	ctx := context.Background()
+	r := New(newMemStore(), Options{Lease: 30 * time.Millisecond})
+
+	// The runner crashes in the last step: it neither finishes the saga nor
+	// renews its lease anymore.
+	var crashed recorder
+	crash := make(chan struct{})
+	s := (&Saga{Name: "test", Key: "shop:1:2"}).
+		Step(crashed.step("spend", nil, nil)).
+		Step(crashed.step("grant", nil, nil)).
+		Step("notify", func(context.Context) error {
+			defer close(crash)
+			runtime.Goexit()
+			return nil
+		}, nil)
+	go r.Run(ctx, s)
+	<-crash
+
+	var rec recorder
+	retry := func() *Saga {
+		return (&Saga{Name: "test", Key: "shop:1:2"}).
+			Step(rec.step("spend", nil, nil)).
+			Step(rec.step("grant", nil, nil)).
+			Step(rec.step("notify", nil, nil))
+	}
+	if err := r.Run(ctx, retry()); !errors.Is(err, ErrInProgress) {
+		t.Fatalf("Run of a saga leased: got %v, want ErrInProgress", err)
+	}
+	// Once the lease runs out, the steps done are compensated, and the saga
+	// runs again.
+	time.Sleep(60 * time.Millisecond)
+	if err := r.Run(ctx, retry()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"spend", "grant"}; !reflect.DeepEqual(crashed.calls, want) {
+		t.Errorf("calls of the crashed run %v, want %v", crashed.calls, want)
+	}
+	if want := []string{"undo grant", "undo spend", "spend", "grant", "notify"}; !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("calls %v, want %v", rec.calls, want)
+	}
+	if err := r.Run(ctx, retry()); !errors.Is(err, ErrDone) {
+		t.Fatalf("Run of a saga recovered: got %v, want ErrDone", err)
+	}
+}
+
+func TestCrashUndoFailed(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	r := New(store, Options{})
+	store.records["shop:1:2"] = &memRecord{state: StateRunning, token: 1, done: 2, lease: time.Now()}
+
+	var rec recorder
+	s := (&Saga{Name: "test", Key: "shop:1:2"}).
+		Step(rec.step("spend", nil, nil)).
+		Step(rec.step("grant", nil, errors.New("down"))).
+		Step(rec.step("notify", nil, nil))
+	var serr *Error
+	if err := r.Run(ctx, s); !errors.As(err, &serr) || serr.Compensated() || !errors.Is(err, ErrAbandoned) || serr.Step != "notify" {
+		t.Fatalf("Run: got %v, want the abandoned step not compensated", err)
+	}
+	if want := []string{"undo grant", "undo spend"}; !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("calls %v, want %v", rec.calls, want)
+	}
+	if err := r.Run(ctx, s); !errors.Is(err, ErrFailed) {
+		t.Fatalf("Run of a saga not recovered: got %v, want ErrFailed", err)
+	}
+}
+
+func TestLeaseLost(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	r := New(store, Options{Lease: 30 * time.Millisecond})
+
+	// The store is unreachable while the second step runs, which outlives
+	// the lease: its context is canceled, and the saga taken over.
+	var a recorder
+	lost, resume := make(chan struct{}), make(chan struct{})
+	s := (&Saga{Name: "test", Key: "shop:1:2"}).
+		Step(a.step("spend", nil, nil)).
+		Step("grant", func(ctx context.Context) error {
+			a.calls = append(a.calls, "grant")
+			store.setDown(true)
+			<-ctx.Done()
+			store.setDown(false)
+			close(lost)
+			<-resume
+			return nil
+		}, func(context.Context) error {
+			a.calls = append(a.calls, "undo grant")
+			return nil
+		})
+	errc := make(chan error, 1)
+	go func() { errc <- r.Run(ctx, s) }()
+	<-lost
+
+	var b recorder
+	s = (&Saga{Name: "test", Key: "shop:1:2"}).
+		Step(b.step("spend", nil, nil)).
+		Step(b.step("grant", nil, nil))
+	if err := r.Run(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	close(resume)
+	// The first runner only compensates the step it didn't record done.
+	if err := <-errc; !errors.Is(err, ErrAbandoned) {
+		t.Fatalf("Run of the lease lost: got %v, want ErrAbandoned", err)
+	}
+	if want := []string{"spend", "grant", "undo grant"}; !reflect.DeepEqual(a.calls, want) {
+		t.Errorf("calls of the lease lost %v, want %v", a.calls, want)
+	}
+	if want := []string{"undo spend", "spend", "grant"}; !reflect.DeepEqual(b.calls, want) {
+		t.Errorf("calls of the takeover %v, want %v", b.calls, want)
+	}
+	if err := r.Run(ctx, s); !errors.Is(err, ErrDone) {
+		t.Fatalf("Run of a saga taken over: got %v, want ErrDone", err)
+	}
+}
@@ -3,28 +3,79 @@ package status
 import (
 	"context"
 	"net/http"
+	"sync"
+	"time"
 
 	"greatestworks/aop/protomsg"
 	"greatestworks/aop/protos"
+	"greatestworks/aop/retry"
 )
 
 // Client is an HTTP client to a status server. It's assumed the status server
 // registered itself with RegisterServer.
+//
+// The clients to the same status server share a circuit breaker, so that
+// once the server fails a few consecutive calls, the calls of all the
+// clients fail fast with an error wrapping retry.ErrOpen, until the server
+// recovers.
 type Client struct {
-	addr string // status server (e.g., "localhost:12345")
+	addr    string         // status server (e.g., "localhost:12345")
+	breaker *retry.Breaker // circuit breaker of the status server
 }
 
 var _ Server = &Client{}
 
+// RetryOptions are the options of the retry loops that call a status server
+// (e.g., to wait for it to start). The capped backoff and the full jitter
+// keep the loops of many processes from calling the server in sync.
+var RetryOptions = retry.Options{
+	BackoffMultiplier:  1.3,
+	BackoffMinDuration: 10 * time.Millisecond,
+	BackoffMaxDuration: 2 * time.Second,
+	Jitter:             retry.FullJitter,
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*retry.Breaker{} // circuit breakers, by address
+
+	// breakerOptions configure the circuit breakers of the status servers.
+	// A breaker opens for a short time only, so that it doesn't delay the
+	// start of a deployment that waits for its status server.
+	breakerOptions = retry.BreakerOptions{OpenDuration: 2 * time.Second}
+)
+
 // NewClient returns a client to the status server on the provided address.
 func NewClient(addr string) *Client {
-	return &Client{addr}
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	breaker, ok := breakers[addr]
+	if !ok {
+		breaker = retry.NewBreaker(breakerOptions)
+		breakers[addr] = breaker
+	}
+	return &Client{addr, breaker}
+}
+
+// call calls the status server, unless its circuit breaker is open.
+func (c *Client) call(ctx context.Context, args protomsg.CallArgs) error {
+	if err := c.breaker.Allow(); err != nil {
+		return err
+	}
+	err := protomsg.Call(ctx, args)
+	if err != nil && ctx.Err() != nil {
+		// The caller gave up on the call; the server may be fine.
+		c.breaker.Release()
+		return err
+	}
+	c.breaker.Record(err)
+	return err
 }
 
 // Status implements the Server interface.
 func (c *Client) Status(ctx context.Context) (*Status, error) {
 	status := &Status{}
-	err := protomsg.Call(ctx, protomsg.CallArgs{
+	err := c.call(ctx, protomsg.CallArgs{
 		Client:  http.DefaultClient,
 		Addr:    "http://" + c.addr,
 		URLPath: statusEndpoint,
@@ -40,7 +91,7 @@ func (c *Client) Status(ctx context.Context) (*Status, error) {
 // Metrics implements the Server interface.
 func (c *Client) Metrics(ctx context.Context) (*Metrics, error) {
 	metrics := &Metrics{}
-	err := protomsg.Call(ctx, protomsg.CallArgs{
+	err := c.call(ctx, protomsg.CallArgs{
 		Client:  http.DefaultClient,
 		Addr:    "http://" + c.addr,
 		URLPath: metricsEndpoint,
@@ -52,7 +103,7 @@ func (c *Client) Metrics(ctx context.Context) (*Metrics, error) {
 // Profile implements the Server interface.
 func (c *Client) Profile(ctx context.Context, req *protos.RunProfiling) (*protos.Profile, error) {
 	profile := &protos.Profile{}
-	err := protomsg.Call(ctx, protomsg.CallArgs{
+	err := c.call(ctx, protomsg.CallArgs{
 		Client:  http.DefaultClient,
 		Addr:    "http://" + c.addr,
 		URLPath: profileEndpoint,
@@ -9,7 +9,6 @@ import (
 	"html/template"
 	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"sort"
 	"strings"
@@ -30,6 +29,7 @@ var (
 	dashboardFlags = flag.NewFlagSet("dashboard", flag.ContinueOnError)
 	dashboardHost  = dashboardFlags.String("host", "localhost", "Dashboard host")
 	dashboardPort  = dashboardFlags.Int("port", 0, "Dashboart port")
+	dashboardStore = dashboardFlags.String("registry", "", "Address of a shared registry store (e.g., redis://host:6379)")
 
 	//go:embed templates/index.html
 	indexHTML     string
@@ -39,13 +39,6 @@ var (
 	deploymentHTML     string
 	deploymentTemplate = template.Must(template.New("deployment").Funcs(template.FuncMap{
 		"shorten": logging.ShortenComponent,
-		"pidjoin": func(pids []int64) string {
-			s := make([]string, len(pids))
-			for i, x := range pids {
-				s[i] = fmt.Sprint(x)
-			}
-			return strings.Join(s, ", ")
-		},
 		"age": func(t *timestamppb.Timestamp) string {
 			return time.Since(t.AsTime()).Truncate(time.Second).String()
 		},
@@ -53,11 +46,7 @@ var (
 			return x - 1
 		},
 		"traceurl": func(app, version string) string {
-			v := url.Values{}
-			v.Set("app", app)
-			v.Set("version", version)
-			tracerURL := url.QueryEscape("http://127.0.0.1:9001?" + v.Encode())
-			return "https://ui.perfetto.dev/#!/?url=" + tracerURL
+			return traceURL(app, version, "")
 		},
 	}).Parse(deploymentHTML))
 
@@ -77,13 +66,50 @@ type DashboardSpec struct {
 	Tool     string                                   // tool name (e.g., "weaver single")
 	Registry func(context.Context) (*Registry, error) // registry of deployments
 	Commands func(deploymentId string) []Command      // commands for a deployment
+
+	// Logs, if not nil, returns the source of the logs of the deployments,
+	// which are then streamed on the logs page of the deployments.
+	Logs func(context.Context) (logging.Source, error)
+
+	// Store, if not nil, returns the store of the registrations of the
+	// deployments, which replaces the store of Registry. With a shared store
+	// (see OpenRegistryStore), a single dashboard covers the deployments of
+	// a whole cluster. The --registry flag takes precedence over Store.
+	Store func(context.Context) (RegistryStore, error)
+}
+
+// registry returns the registry of the dashboard, which uses the store
+// selected by the --registry flag or by the spec, if any.
+func (spec *DashboardSpec) registry(ctx context.Context) (*Registry, error) {
+	r, err := spec.Registry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var store RegistryStore
+	switch {
+	case *dashboardStore != "":
+		store, err = OpenRegistryStore(ctx, *dashboardStore)
+	case spec.Store != nil:
+		store, err = spec.Store(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return r, nil
+	}
+	return r.WithStore(store), nil
 }
 
 // DashboardCommand returns a "dashboard" subcommand that serves a dashboard
 // with information about the active applications.
 func DashboardCommand(spec *DashboardSpec) *dtool.Command {
 	const help = `Usage:
-  {{.Tool}} dashboard [--host=<host>] [--port=<port>]
+  {{.Tool}} dashboard [--host=<host>] [--port=<port>] [--registry=<address>]
+
+The dashboard serves its registry of deployments at /registry/, so that
+tools on other machines can register their deployments with it by setting
+` + RegistryEnvKey + `=http://<host>:<port>.
 
 Flags:
   -h, --help	Print this help message.
@@ -101,15 +127,24 @@ Flags:
 		Help:        b.String(),
 		Flags:       dashboardFlags,
 		Fn: func(ctx context.Context, _ []string) error {
-			r, err := spec.Registry(ctx)
+			r, err := spec.registry(ctx)
 			if err != nil {
 				return err
 			}
-			dashboard := &dashboard{spec, r}
+			traceDB, err := perfetto.Open(ctx)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "cannot open Perfetto database: %v\n", err)
+			} else {
+				go traceDB.Serve(ctx)
+			}
+
+			dashboard := &dashboard{spec, r, traceDB}
 			http.HandleFunc("/", dashboard.handleIndex)
 			http.HandleFunc("/favicon.ico", http.NotFound)
 			http.HandleFunc("/deployment", dashboard.handleDeployment)
+			http.HandleFunc("/deployment/", dashboard.handleDeploymentPath)
 			http.HandleFunc("/metrics", dashboard.handleMetrics)
+			http.Handle("/registry/", RegistryStoreHandler(r.Store()))
 			http.Handle("/assets/", http.FileServer(http.FS(assets)))
 
 			lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", *dashboardHost, *dashboardPort))
@@ -118,12 +153,6 @@ Flags:
 			}
 			url := "http://" + lis.Addr().String()
 
-			traceDB, err := perfetto.Open(ctx)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "cannot open Perfetto database: %v\n", err)
-			}
-			go traceDB.Serve(ctx)
-
 			fmt.Fprintln(os.Stderr, "Dashboard available at:", url)
 			go browser.OpenURL(url) //nolint:errcheck // browser open is optional
 			return http.Serve(lis, nil)
@@ -135,6 +164,7 @@ Flags:
 type dashboard struct {
 	spec     *DashboardSpec // e.g., "weaver multi" or "weaver single"
 	registry *Registry      // registry of deployments
+	traceDB  *perfetto.DB   // trace database; nil if it couldn't be opened
 }
 
 // handleIndex handles requests to /
@@ -209,17 +239,28 @@ func (d *dashboard) handleDeployment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Fetch the most recent events.
+	events, err := d.registry.Events(r.Context(), id, EventQuery{Limit: timelineSize})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	// Display content.
 	content := struct {
 		*Status
 		Tool     string
 		Traffic  []edge
 		Commands []Command
+		Logs     bool
+		Events   []Event
 	}{
 		Status:   status,
 		Tool:     d.spec.Tool,
 		Traffic:  computeTraffic(status, metrics.Metrics),
 		Commands: d.spec.Commands(id),
+		Logs:     d.spec.Logs != nil,
+		Events:   events,
 	}
 	if err := deploymentTemplate.Execute(w, content); err != nil {
 		fmt.Println(err)
@@ -0,0 +1,291 @@
+package status
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pprof "github.com/google/pprof/profile"
+	"greatestworks/aop/protos"
+)
+
+// This file implements the pprof endpoints of a status server, which proxy
+// the profiles of the weavelets of a deployment (e.g., for "go tool pprof"),
+// and the diagnostics page of the dashboard, which summarizes the goroutines
+// and heap of the weavelets. Both fetch the profiles on demand, through the
+// babysitter of the deployment (see Server.Profile), so that debugging a
+// wedged colocation group doesn't require access to its machine.
+
+// defaultCPUDuration is the duration of CPU profiles collected through the
+// pprof endpoints, unless specified otherwise.
+const defaultCPUDuration = 30 * time.Second
+
+// diagnosticsTopN is the number of entries in every table of the diagnostics
+// page.
+const diagnosticsTopN = 10
+
+// pprofTypes maps the names of the profiles served by the pprof endpoints
+// (the names used by net/http/pprof) to profile types.
+var pprofTypes = map[string]protos.ProfileType{
+	"profile":   protos.ProfileType_CPU,
+	"heap":      protos.ProfileType_Heap,
+	"goroutine": protos.ProfileType_Goroutine,
+	"block":     protos.ProfileType_Block,
+	"mutex":     protos.ProfileType_Mutex,
+}
+
+var (
+	//go:embed templates/diagnostics.html
+	diagnosticsHTML     string
+	diagnosticsTemplate = template.Must(template.New("diagnostics").Funcs(template.FuncMap{
+		"bytes": formatBytes,
+	}).Parse(diagnosticsHTML))
+)
+
+// pprofRequest returns the request for the named profile (e.g., "heap"),
+// given the query parameters of a pprof endpoint:
+//
+//   - group: only profile this colocation group.
+//   - pid: only profile the weavelet with this pid.
+//   - seconds: the duration of CPU profiles; defaults to 30.
+func pprofRequest(name string, params url.Values) (*protos.RunProfiling, error) {
+	typ, ok := pprofTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+	req := &protos.RunProfiling{Group: params.Get("group"), ProfileType: typ}
+	if s := params.Get("pid"); s != "" {
+		pid, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || pid <= 0 {
+			return nil, fmt.Errorf("invalid pid %q", s)
+		}
+		req.Pid = pid
+	}
+	if typ == protos.ProfileType_CPU {
+		d := defaultCPUDuration
+		if s := params.Get("seconds"); s != "" {
+			seconds, err := strconv.Atoi(s)
+			if err != nil || seconds <= 0 {
+				return nil, fmt.Errorf("invalid seconds %q: must be a positive integer", s)
+			}
+			d = time.Duration(seconds) * time.Second
+		}
+		req.CpuDurationNs = d.Nanoseconds()
+	}
+	return req, nil
+}
+
+// servePprof serves the named profile of the weavelets managed by the
+// provided server. See pprofRequest for the accepted query parameters.
+func servePprof(w http.ResponseWriter, r *http.Request, server Server, name string) {
+	req, err := pprofRequest(name, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	prof, err := server.Profile(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(prof.Data) == 0 {
+		msg := "no weavelet profiled"
+		if len(prof.Errors) > 0 {
+			msg = strings.Join(prof.Errors, "\n")
+		}
+		http.Error(w, msg, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Write(prof.Data) //nolint:errcheck // response write error
+}
+
+// A FunctionCount is an entry of a diagnostics table: a function and the
+// number of goroutines (resp. bytes) attributed to it.
+type FunctionCount struct {
+	Function string
+	Count    int64
+}
+
+// A Diagnostics summarizes the goroutines and heap of a set of weavelets.
+type Diagnostics struct {
+	Goroutines    int64           // number of goroutines
+	GoroutinesTop []FunctionCount // goroutines, by the function they wait in
+	HeapInuse     int64           // bytes of live heap objects
+	HeapTop       []FunctionCount // bytes of live heap objects, by allocator
+	Errors        []string        // errors encountered collecting the profiles
+}
+
+// diagnose fetches the goroutine and heap profiles of the weavelets selected
+// by params (see pprofRequest) from the provided server, and summarizes them.
+func diagnose(r *http.Request, server Server, params url.Values) (*Diagnostics, error) {
+	d := &Diagnostics{}
+	fetch := func(name string) (*pprof.Profile, error) {
+		req, err := pprofRequest(name, params)
+		if err != nil {
+			return nil, err
+		}
+		prof, err := server.Profile(r.Context(), req)
+		if err != nil {
+			return nil, fmt.Errorf("%s profile: %w", name, err)
+		}
+		d.Errors = append(d.Errors, prof.Errors...)
+		if len(prof.Data) == 0 {
+			return nil, fmt.Errorf("%s profile: no weavelet profiled", name)
+		}
+		p, err := pprof.ParseData(prof.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%s profile: %w", name, err)
+		}
+		return p, nil
+	}
+
+	// Fetch the profiles one after the other, since a weavelet collects at
+	// most one profile at a time.
+	goroutines, err := fetch("goroutine")
+	if err != nil {
+		return nil, err
+	}
+	heap, err := fetch("heap")
+	if err != nil {
+		return nil, err
+	}
+	d.Goroutines, d.GoroutinesTop = summarize(goroutines, "goroutine", diagnosticsTopN)
+	d.HeapInuse, d.HeapTop = summarize(heap, "inuse_space", diagnosticsTopN)
+	return d, nil
+}
+
+// summarize returns the total of the samples of the provided type (e.g.,
+// "inuse_space") in the provided profile, and the n functions with the
+// largest share of the total. Samples are attributed to the innermost
+// function of their stack that is not part of the Go runtime (e.g., the
+// function that blocked a goroutine, rather than runtime.gopark).
+func summarize(p *pprof.Profile, sampleType string, n int) (int64, []FunctionCount) {
+	index := -1
+	for i, st := range p.SampleType {
+		if st.Type == sampleType {
+			index = i
+		}
+	}
+	if index < 0 {
+		return 0, nil
+	}
+
+	var total int64
+	byFunction := map[string]int64{}
+	for _, sample := range p.Sample {
+		value := sample.Value[index]
+		total += value
+		byFunction[attribute(sample)] += value
+	}
+	top := make([]FunctionCount, 0, len(byFunction))
+	for fn, count := range byFunction {
+		if count > 0 {
+			top = append(top, FunctionCount{fn, count})
+		}
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Function < top[j].Function
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return total, top
+}
+
+// attribute returns the function a sample is attributed to. See summarize.
+func attribute(sample *pprof.Sample) string {
+	first := ""
+	for _, loc := range sample.Location {
+		// Inlined functions come first.
+		for _, line := range loc.Line {
+			if line.Function == nil {
+				continue
+			}
+			name := line.Function.Name
+			if first == "" {
+				first = name
+			}
+			if !strings.HasPrefix(name, "runtime.") {
+				return name
+			}
+		}
+	}
+	if first == "" {
+		return "unknown"
+	}
+	return first
+}
+
+// formatBytes pretty prints the provided number of bytes (e.g., "1.5 MiB").
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// handlePprof handles requests to /deployment/<deployment id>/pprof/<name>,
+// which proxy the pprof endpoint of the status server of the deployment.
+func (d *dashboard) handlePprof(w http.ResponseWriter, r *http.Request, id, name string) {
+	reg, err := d.registry.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	servePprof(w, r, NewClient(reg.Addr), name)
+}
+
+// handleDiagnostics handles requests to /deployment/<deployment id>/diagnostics.
+// It accepts the group and pid query parameters of the pprof endpoints.
+func (d *dashboard) handleDiagnostics(w http.ResponseWriter, r *http.Request, id string) {
+	reg, err := d.registry.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params := url.Values{}
+	for _, key := range []string{"group", "pid"} {
+		if v := r.URL.Query().Get(key); v != "" {
+			params.Set(key, v)
+		}
+	}
+	diagnostics, err := diagnose(r, NewClient(reg.Addr), params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	content := struct {
+		Tool         string
+		App          string
+		DeploymentId string
+		Selector     template.URL // e.g., "pid=1234"
+		Diagnostics  *Diagnostics
+	}{
+		Tool:         d.spec.Tool,
+		App:          reg.App,
+		DeploymentId: reg.DeploymentId,
+		Selector:     template.URL(params.Encode()),
+		Diagnostics:  diagnostics,
+	}
+	if err := diagnosticsTemplate.Execute(w, content); err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,168 @@
+package status
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	pprof "github.com/google/pprof/profile"
+	"google.golang.org/protobuf/testing/protocmp"
+	"greatestworks/aop/logging"
+	"greatestworks/aop/protos"
+)
+
+// profileServer is a fake Server that records profile requests, and replies
+// with the provided profile.
+type profileServer struct {
+	fakeClient
+	data []byte                 // returned by the Profile method
+	reqs []*protos.RunProfiling // requests received by the Profile method
+}
+
+// Profile implements the Server interface.
+func (p *profileServer) Profile(_ context.Context, req *protos.RunProfiling) (*protos.Profile, error) {
+	p.reqs = append(p.reqs, req)
+	return &protos.Profile{Data: p.data}, nil
+}
+
+func TestPprofRequest(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		params string
+		want   *protos.RunProfiling
+	}{
+		{"heap", "", &protos.RunProfiling{ProfileType: protos.ProfileType_Heap}},
+		{"goroutine", "group=main&pid=42", &protos.RunProfiling{Group: "main", Pid: 42, ProfileType: protos.ProfileType_Goroutine}},
+		{"profile", "", &protos.RunProfiling{ProfileType: protos.ProfileType_CPU, CpuDurationNs: int64(30 * time.Second)}},
+		{"profile", "seconds=5", &protos.RunProfiling{ProfileType: protos.ProfileType_CPU, CpuDurationNs: int64(5 * time.Second)}},
+	} {
+		params, err := url.ParseQuery(test.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := pprofRequest(test.name, params)
+		if err != nil {
+			t.Fatalf("pprofRequest(%q, %q): %v", test.name, test.params, err)
+		}
+		if diff := cmp.Diff(test.want, got, protocmp.Transform()); diff != "" {
+			t.Errorf("pprofRequest(%q, %q) (-want +got):\n%s", test.name, test.params, diff)
+		}
+	}
+	for _, bad := range []struct{ name, params string }{
+		{"threadcreate", ""},
+		{"heap", "pid=-1"},
+		{"profile", "seconds=forever"},
+	} {
+		params, err := url.ParseQuery(bad.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pprofRequest(bad.name, params); err == nil {
+			t.Errorf("pprofRequest(%q, %q): unexpected success", bad.name, bad.params)
+		}
+	}
+}
+
+func TestPprofEndpoint(t *testing.T) {
+	server := &profileServer{data: []byte("profile data")}
+	mux := http.NewServeMux()
+	RegisterServer(mux, server, logging.NewTestLogger(t))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/debug/serviceweaver/pprof/goroutine?pid=42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || string(body) != "profile data" {
+		t.Fatalf("got (%d, %q), want (200, %q)", resp.StatusCode, body, "profile data")
+	}
+	want := []*protos.RunProfiling{{Pid: 42, ProfileType: protos.ProfileType_Goroutine}}
+	if diff := cmp.Diff(want, server.reqs, protocmp.Transform()); diff != "" {
+		t.Errorf("requests (-want +got):\n%s", diff)
+	}
+}
+
+// makeProfile returns a profile with a sample of the provided value for
+// every stack, given innermost function first.
+func makeProfile(sampleType string, stacks map[int64][]string) *pprof.Profile {
+	p := &pprof.Profile{SampleType: []*pprof.ValueType{{Type: sampleType}}}
+	for value, stack := range stacks {
+		sample := &pprof.Sample{Value: []int64{value}}
+		for _, name := range stack {
+			fn := &pprof.Function{Name: name}
+			sample.Location = append(sample.Location, &pprof.Location{Line: []pprof.Line{{Function: fn}}})
+		}
+		p.Sample = append(p.Sample, sample)
+	}
+	return p
+}
+
+func TestSummarize(t *testing.T) {
+	p := makeProfile("goroutine", map[int64][]string{
+		10: {"runtime.gopark", "runtime.chanrecv", "main.worker", "main.main"},
+		3:  {"runtime.gopark", "net/http.(*conn).serve"},
+		2:  {"main.worker"},
+		1:  {"runtime.main"},
+	})
+	total, top := summarize(p, "goroutine", 2)
+	if total != 16 {
+		t.Errorf("total: got %d, want 16", total)
+	}
+	want := []FunctionCount{{"main.worker", 12}, {"net/http.(*conn).serve", 3}}
+	if diff := cmp.Diff(want, top); diff != "" {
+		t.Errorf("top (-want +got):\n%s", diff)
+	}
+
+	// Missing sample types are ignored.
+	if total, top := summarize(p, "inuse_space", 2); total != 0 || top != nil {
+		t.Errorf("summarize(inuse_space): got (%d, %v), want (0, nil)", total, top)
+	}
+}
+
+func TestDiagnosticsTemplate(t *testing.T) {
+	content := struct {
+		Tool         string
+		App          string
+		DeploymentId string
+		Selector     template.URL
+		Diagnostics  *Diagnostics
+	}{
+		Tool:         "weaver multi",
+		App:          "todo",
+		DeploymentId: "v1",
+		Selector:     "pid=42",
+		Diagnostics: &Diagnostics{
+			Goroutines:    12,
+			GoroutinesTop: []FunctionCount{{"main.worker", 12}},
+			HeapInuse:     3 << 20,
+			HeapTop:       []FunctionCount{{"main.alloc", 3 << 20}},
+		},
+	}
+	var b strings.Builder
+	if err := diagnosticsTemplate.Execute(&b, content); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	for _, want := range []string{
+		"main.worker",
+		"3.0 MiB",
+		"/deployment/v1/pprof/heap?pid=42",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("diagnostics page doesn't contain %q:\n%s", want, page)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package status
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"greatestworks/aop/logtype"
+)
+
+// Kinds of deployment events.
+const (
+	EventComponentStarted  = "ComponentStarted"  // a component was started
+	EventReplicaRegistered = "ReplicaRegistered" // a weavelet registered itself
+	EventListenerExported  = "ListenerExported"  // a listener was exported
+	EventRoutingUpdated    = "RoutingUpdated"    // routing info was regenerated
+	EventConfigChanged     = "ConfigChanged"     // config sections were updated
+	EventReplicaKilled     = "ReplicaKilled"     // a replica was killed by chaos testing
+)
+
+// historyEvents is the name of the file, in the history directory of a
+// deployment, that holds the events of the deployment, one JSON encoded Event
+// per line, oldest first.
+const historyEvents = "events.jsonl"
+
+// maxPendingEvents is the maximum number of events an EventRecorder buffers
+// before it is attached to a registry. Older events are dropped.
+const maxPendingEvents = 1024
+
+// timelineSize is the number of recent events shown on the deployment page
+// of the dashboard.
+const timelineSize = 50
+
+// An Event is a significant control-plane event of a deployment, e.g., the
+// start of a component. The events of a deployment are persisted alongside
+// its history, and shown as a timeline on the dashboard.
+type Event struct {
+	Time  time.Time
+	Kind  string   // e.g., EventComponentStarted
+	Attrs []string // alternating key/value pairs, e.g., ["component", "Cache"]
+}
+
+// Details returns the attributes of the event, formatted as "key=value"
+// pairs.
+func (e Event) Details() string {
+	var b strings.Builder
+	for i := 0; i+1 < len(e.Attrs); i += 2 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s=%s", e.Attrs[i], e.Attrs[i+1])
+	}
+	return b.String()
+}
+
+// An EventQuery selects the events returned by Registry.Events.
+type EventQuery struct {
+	Kinds []string  // if not empty, only the events of these kinds
+	Since time.Time // if not zero, only the events at or after Since
+	Limit int       // if positive, only the Limit most recent events
+}
+
+// matches returns whether the provided event is selected by the query,
+// ignoring Limit.
+func (q EventQuery) matches(e Event) bool {
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if len(q.Kinds) == 0 {
+		return true
+	}
+	for _, kind := range q.Kinds {
+		if e.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordEvents appends the provided events to the event log of the provided
+// deployment.
+func (r *Registry) RecordEvents(_ context.Context, deploymentId string, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var b []byte
+	for _, e := range events {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b = append(b, line...)
+		b = append(b, '\n')
+	}
+	dir := r.historyDir(deploymentId)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, historyEvents), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return err
+	}
+	// Write all the events at once, so that the events of concurrent
+	// recorders don't interleave.
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Events returns the events of the provided deployment selected by the
+// provided query, oldest first.
+func (r *Registry) Events(_ context.Context, deploymentId string, q EventQuery) ([]Event, error) {
+	f, err := os.Open(filepath.Join(r.historyDir(deploymentId), historyEvents))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// The last event may be partially written.
+			continue
+		}
+		if !q.matches(e) {
+			continue
+		}
+		events = append(events, e)
+		if q.Limit > 0 && len(events) > 2*q.Limit {
+			events = append(events[:0], events[len(events)-q.Limit:]...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if q.Limit > 0 && len(events) > q.Limit {
+		events = events[len(events)-q.Limit:]
+	}
+	return events, nil
+}
+
+// An EventRecorder records the events of deployments in a registry.
+//
+// Deployers usually start components before they register the deployment,
+// so an EventRecorder buffers the events it records until it is attached to
+// a registry with Attach. Record on a nil EventRecorder is a no-op.
+//
+// You can safely use an EventRecorder from multiple goroutines.
+type EventRecorder struct {
+	logger logtype.Logger
+
+	mu       sync.Mutex
+	registry *Registry      // nil until Attach is called
+	pending  []pendingEvent // events recorded before Attach, oldest first
+}
+
+// pendingEvent is an event recorded before an EventRecorder is attached to a
+// registry.
+type pendingEvent struct {
+	deploymentId string
+	event        Event
+}
+
+// NewEventRecorder returns a new EventRecorder, which logs the errors it
+// encounters to the provided logger.
+func NewEventRecorder(logger logtype.Logger) *EventRecorder {
+	return &EventRecorder{logger: logger}
+}
+
+// Record records an event of the provided kind for the provided deployment.
+// attrs are alternating key/value pairs that describe the event (e.g.,
+// "component", "Cache").
+func (e *EventRecorder) Record(deploymentId, kind string, attrs ...string) {
+	if e == nil {
+		return
+	}
+	event := Event{Time: time.Now(), Kind: kind, Attrs: attrs}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.registry == nil {
+		if len(e.pending) == maxPendingEvents {
+			e.pending = e.pending[1:]
+		}
+		e.pending = append(e.pending, pendingEvent{deploymentId, event})
+		return
+	}
+	if err := e.registry.RecordEvents(context.Background(), deploymentId, event); err != nil {
+		e.logger.Error("Unable to record event", err, "deployment", deploymentId, "kind", kind)
+	}
+}
+
+// Attach makes the recorder record events in the provided registry, starting
+// with the events recorded so far.
+func (e *EventRecorder) Attach(ctx context.Context, r *Registry) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.registry = r
+	pending := e.pending
+	e.pending = nil
+
+	byDeployment := map[string][]Event{}
+	var order []string
+	for _, p := range pending {
+		if _, ok := byDeployment[p.deploymentId]; !ok {
+			order = append(order, p.deploymentId)
+		}
+		byDeployment[p.deploymentId] = append(byDeployment[p.deploymentId], p.event)
+	}
+	for _, id := range order {
+		if err := r.RecordEvents(ctx, id, byDeployment[id]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventQuery returns the event query specified by the query parameters of a
+// request to the events endpoint of the dashboard:
+//
+//   - kind: only the events of this kind; may be repeated.
+//   - since: only the events at or after this time (RFC 3339).
+//   - limit: only this many of the most recent events.
+func eventQuery(r *http.Request) (EventQuery, error) {
+	params := r.URL.Query()
+	q := EventQuery{Kinds: params["kind"]}
+	if s := params.Get("since"); s != "" {
+		since, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return q, fmt.Errorf("invalid since %q: %w", s, err)
+		}
+		q.Since = since
+	}
+	if s := params.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			return q, fmt.Errorf("invalid limit %q: must be a non-negative integer", s)
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
+
+// handleEvents handles requests to /deployment/<deployment id>/events, which
+// returns the JSON encoded events of the deployment. See eventQuery for the
+// accepted query parameters.
+func (d *dashboard) handleEvents(w http.ResponseWriter, r *http.Request, id string) {
+	q, err := eventQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	events, err := d.registry.Events(r.Context(), id, q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []Event{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,143 @@
+package status
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"greatestworks/aop/logging"
+)
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+	registry, err := NewRegistry(ctx, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	events := []Event{
+		{Time: at(0), Kind: EventComponentStarted, Attrs: []string{"component", "main"}},
+		{Time: at(1), Kind: EventReplicaRegistered, Attrs: []string{"group", "main", "address", "a"}},
+		{Time: at(2), Kind: EventRoutingUpdated, Attrs: []string{"group", "main", "replicas", "1"}},
+		{Time: at(3), Kind: EventListenerExported, Attrs: []string{"listener", "lobby"}},
+	}
+	if err := registry.RecordEvents(ctx, "v1", events[:2]...); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.RecordEvents(ctx, "v1", events[2:]...); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.RecordEvents(ctx, "v2", Event{Time: at(4), Kind: EventConfigChanged}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name  string
+		query EventQuery
+		want  []Event
+	}{
+		{"All", EventQuery{}, events},
+		{"Kinds", EventQuery{Kinds: []string{EventComponentStarted, EventListenerExported}}, []Event{events[0], events[3]}},
+		{"Since", EventQuery{Since: at(2)}, events[2:]},
+		{"Limit", EventQuery{Limit: 1}, events[3:]},
+		{"KindsAndLimit", EventQuery{Kinds: []string{EventComponentStarted, EventReplicaRegistered}, Limit: 1}, events[1:2]},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := registry.Events(ctx, "v1", test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Fatalf("Events (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// Unknown deployments have no events.
+	got, err := registry.Events(ctx, "v3", EventQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("Events(v3): got %v, want no events", got)
+	}
+}
+
+func TestEventRecorder(t *testing.T) {
+	ctx := context.Background()
+	registry, err := NewRegistry(ctx, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := func(id string) []string {
+		events, err := registry.Events(ctx, id, EventQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var kinds []string
+		for _, e := range events {
+			kinds = append(kinds, e.Kind)
+		}
+		return kinds
+	}
+
+	// Events are buffered until the recorder is attached.
+	recorder := NewEventRecorder(logging.NewTestLogger(t))
+	recorder.Record("v1", EventComponentStarted, "component", "main")
+	recorder.Record("v2", EventComponentStarted, "component", "main")
+	recorder.Record("v1", EventReplicaRegistered, "group", "main")
+	if got := kinds("v1"); len(got) != 0 {
+		t.Fatalf("events before Attach: got %v, want no events", got)
+	}
+	if err := recorder.Attach(ctx, registry); err != nil {
+		t.Fatal(err)
+	}
+	recorder.Record("v1", EventListenerExported, "listener", "lobby")
+
+	want := []string{EventComponentStarted, EventReplicaRegistered, EventListenerExported}
+	if diff := cmp.Diff(want, kinds("v1")); diff != "" {
+		t.Fatalf("v1 events (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{EventComponentStarted}, kinds("v2")); diff != "" {
+		t.Fatalf("v2 events (-want +got):\n%s", diff)
+	}
+
+	// Recording with a nil recorder is a no-op.
+	var nilRecorder *EventRecorder
+	nilRecorder.Record("v1", EventComponentStarted)
+}
+
+func TestEventQuery(t *testing.T) {
+	r := httptest.NewRequest("GET", "/deployment/v1/events?kind=A&kind=B&since=2023-01-01T00:00:00Z&limit=10", nil)
+	got, err := eventQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := EventQuery{
+		Kinds: []string{"A", "B"},
+		Since: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Limit: 10,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("eventQuery (-want +got):\n%s", diff)
+	}
+
+	for _, query := range []string{"since=yesterday", "limit=-1", "limit=ten"} {
+		r := httptest.NewRequest("GET", "/deployment/v1/events?"+query, nil)
+		if _, err := eventQuery(r); err == nil {
+			t.Errorf("eventQuery(%q): unexpected success", query)
+		}
+	}
+}
+
+func TestEventDetails(t *testing.T) {
+	e := Event{Attrs: []string{"group", "main", "replicas", "2"}}
+	if got, want := e.Details(), "group=main replicas=2"; got != want {
+		t.Fatalf("Details: got %q, want %q", got, want)
+	}
+}
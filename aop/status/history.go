@@ -0,0 +1,263 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"greatestworks/aop/colors"
+	"greatestworks/aop/files"
+	dtool "greatestworks/aop/tool"
+)
+
+// historySize is the number of past deployments of every application that a
+// registry retains.
+const historySize = 16
+
+// Names of the files in the history directory of a deployment.
+const (
+	historyEntryFile = "registration.json"
+	historyBinary    = "binary"
+	historyConfig    = "weaver.toml"
+)
+
+// A HistoryEntry records a past or present deployment of an application.
+//
+// If the deployment was snapshotted (see Registry.Snapshot), Binary and Config
+// hold copies of the application binary and config file used by the
+// deployment, which can be used to re-deploy it.
+type HistoryEntry struct {
+	Registration
+	Time   time.Time // when the deployment was registered
+	Binary string    // path of the snapshotted binary, or ""
+	Config string    // path of the snapshotted config file, or ""
+}
+
+// Snapshotted returns whether the entry holds a snapshot of the binary and
+// config of the deployment.
+func (h HistoryEntry) Snapshotted() bool {
+	return h.Binary != "" && h.Config != ""
+}
+
+// historyDir returns the directory that holds the history of the provided
+// deployment.
+func (r *Registry) historyDir(deploymentId string) string {
+	return filepath.Join(r.dir, "history", deploymentId)
+}
+
+// record adds the provided registration to the history, unless it is already
+// there, and garbage collects the oldest entries of the application.
+func (r *Registry) record(ctx context.Context, reg Registration) error {
+	dir := r.historyDir(reg.DeploymentId)
+	if _, err := os.Stat(filepath.Join(dir, historyEntryFile)); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	if err := r.writeEntry(HistoryEntry{Registration: reg, Time: time.Now()}); err != nil {
+		return err
+	}
+	return r.prune(ctx, reg.App)
+}
+
+// Snapshot saves copies of the provided binary and config file contents in
+// the history entry of the provided deployment, so that the deployment can be
+// re-deployed later. The deployment must have been registered.
+func (r *Registry) Snapshot(_ context.Context, deploymentId string, binary string, config []byte) error {
+	entry, err := r.readEntry(deploymentId)
+	if err != nil {
+		return err
+	}
+	dir := r.historyDir(deploymentId)
+	entry.Binary = filepath.Join(dir, historyBinary)
+	entry.Config = filepath.Join(dir, historyConfig)
+	if err := copyFile(binary, entry.Binary, 0755); err != nil {
+		return fmt.Errorf("snapshot binary: %w", err)
+	}
+	if err := writeFile(entry.Config, config); err != nil {
+		return fmt.Errorf("snapshot config: %w", err)
+	}
+	return r.writeEntry(entry)
+}
+
+// History returns the history of the provided application, oldest deployment
+// first.
+func (r *Registry) History(_ context.Context, app string) ([]HistoryEntry, error) {
+	dirs, err := os.ReadDir(filepath.Join(r.dir, "history"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var entries []HistoryEntry
+	for _, dir := range dirs {
+		entry, err := r.readEntry(dir.Name())
+		if errors.Is(err, os.ErrNotExist) {
+			// The entry is being written or removed.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if entry.App == app {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
+
+// FindHistory returns the history entry of the provided application whose
+// deployment id starts with the provided prefix (e.g., "fdeeb059").
+func (r *Registry) FindHistory(ctx context.Context, app, prefix string) (HistoryEntry, error) {
+	entries, err := r.History(ctx, app)
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+	var matches []HistoryEntry
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.DeploymentId, prefix) {
+			matches = append(matches, entry)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return HistoryEntry{}, fmt.Errorf("no deployment %q of app %q found", prefix, app)
+	case 1:
+		return matches[0], nil
+	default:
+		return HistoryEntry{}, fmt.Errorf("deployment %q of app %q is ambiguous", prefix, app)
+	}
+}
+
+// prune removes the oldest history entries of the provided application, so
+// that at most historySize entries remain.
+func (r *Registry) prune(ctx context.Context, app string) error {
+	entries, err := r.History(ctx, app)
+	if err != nil {
+		return err
+	}
+	for len(entries) > historySize {
+		if err := os.RemoveAll(r.historyDir(entries[0].DeploymentId)); err != nil {
+			return err
+		}
+		entries = entries[1:]
+	}
+	return nil
+}
+
+// readEntry reads the history entry of the provided deployment.
+func (r *Registry) readEntry(deploymentId string) (HistoryEntry, error) {
+	bytes, err := os.ReadFile(filepath.Join(r.historyDir(deploymentId), historyEntryFile))
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+	var entry HistoryEntry
+	if err := json.Unmarshal(bytes, &entry); err != nil {
+		return HistoryEntry{}, err
+	}
+	return entry, nil
+}
+
+// writeEntry writes the provided history entry.
+func (r *Registry) writeEntry(entry HistoryEntry) error {
+	bytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(r.historyDir(entry.DeploymentId), historyEntryFile), bytes)
+}
+
+// writeFile atomically writes the provided contents to the provided file.
+func writeFile(filename string, contents []byte) error {
+	w := files.NewWriter(filename)
+	defer w.Cleanup()
+	if _, err := w.Write(contents); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// copyFile atomically copies src to dst, and sets the mode of dst.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	w := files.NewWriter(dst)
+	defer w.Cleanup()
+	if _, err := io.Copy(w, in); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(dst, mode)
+}
+
+// HistoryCommand returns a "history" subcommand that pretty prints the past
+// deployments of an application registered with the provided registry. tool
+// is the name of the command-line tool the returned subcommand runs as (e.g.,
+// "weaver multi").
+func HistoryCommand(tool string, registry func(context.Context) (*Registry, error)) *dtool.Command {
+	return &dtool.Command{
+		Name:        "history",
+		Description: "Show the past deployments of a Service Weaver application",
+		Help: fmt.Sprintf(`Usage:
+  %s history <app>
+
+Flags:
+  -h, --help	Print this help message.`, tool),
+		Flags: flag.NewFlagSet("history", flag.ContinueOnError),
+		Fn: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("usage: %s history <app>", tool)
+			}
+			r, err := registry(ctx)
+			if err != nil {
+				return err
+			}
+			entries, err := r.History(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			active, err := r.List(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Print(formatHistory(entries, active))
+			return nil
+		},
+	}
+}
+
+// formatHistory pretty-prints the provided history entries.
+func formatHistory(entries []HistoryEntry, active []Registration) string {
+	alive := map[string]bool{}
+	for _, reg := range active {
+		alive[reg.DeploymentId] = true
+	}
+
+	var b strings.Builder
+	title := []colors.Text{{{S: "HISTORY", Bold: true}}}
+	t := colors.NewTabularizer(&b, title, colors.PrefixDim)
+	t.Row("APP", "DEPLOYMENT", "DEPLOYED", "ACTIVE", "ROLLBACK")
+	for _, entry := range entries {
+		prefix, suffix := formatId(entry.DeploymentId)
+		deployed := entry.Time.Format("2006-01-02 15:04:05")
+		t.Row(entry.App, colors.Text{prefix, suffix}, deployed, alive[entry.DeploymentId], entry.Snapshotted())
+	}
+	t.Flush()
+	return b.String()
+}
@@ -0,0 +1,204 @@
+package status
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+	"greatestworks/aop/logging"
+)
+
+// This file implements the logs page of the dashboard, which tails the logs
+// of a deployment in the browser. The page opens a WebSocket to
+// /deployment/<id>/logs/ws, over which the dashboard streams the log entries
+// of the deployment, one JSON object per message (see
+// logging.JSONFormatter). The page filters the entries by component and level
+// itself, so changing the filters doesn't reopen the WebSocket.
+
+var (
+	//go:embed templates/logs.html
+	logsHTML     string
+	logsTemplate = template.Must(template.New("logs").Parse(logsHTML))
+)
+
+// defaultLogsSince is how far back in time the logs page starts tailing logs,
+// unless the since query parameter is provided.
+const defaultLogsSince = 5 * time.Minute
+
+// handleDeploymentPath handles requests to /deployment/<deployment id>/logs,
+// /deployment/<deployment id>/logs/ws, and /deployment/<deployment id>/traces.
+func (d *dashboard) handleDeploymentPath(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/deployment/")
+	id, page, ok := strings.Cut(path, "/")
+	if !ok || id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	switch page {
+	case "logs":
+		d.handleLogs(w, r, id)
+	case "logs/ws":
+		d.handleLogsWebSocket(w, r, id)
+	case "traces":
+		d.handleTraces(w, r, id)
+	case "diagnostics":
+		d.handleDiagnostics(w, r, id)
+	case "events":
+		d.handleEvents(w, r, id)
+	default:
+		if strings.HasPrefix(page, "pprof/") {
+			d.handlePprof(w, r, id, strings.TrimPrefix(page, "pprof/"))
+			return
+		}
+		http.NotFound(w, r)
+	}
+}
+
+// handleLogs handles requests to /deployment/<deployment id>/logs.
+func (d *dashboard) handleLogs(w http.ResponseWriter, r *http.Request, id string) {
+	if d.spec.Logs == nil {
+		http.Error(w, fmt.Sprintf("%s doesn't support streaming logs", d.spec.Tool), http.StatusNotFound)
+		return
+	}
+	reg, err := d.registry.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The WebSocket path forwards the query parameters of the page (e.g.,
+	// since and system).
+	wsPath := r.URL.Path + "/ws"
+	if r.URL.RawQuery != "" {
+		wsPath += "?" + r.URL.RawQuery
+	}
+	content := struct {
+		Tool          string
+		App           string
+		DeploymentId  string
+		WebSocketPath string
+	}{
+		Tool:          d.spec.Tool,
+		App:           reg.App,
+		DeploymentId:  reg.DeploymentId,
+		WebSocketPath: wsPath,
+	}
+	if err := logsTemplate.Execute(w, content); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// handleLogsWebSocket handles requests to /deployment/<deployment id>/logs/ws.
+// It follows the logs of the deployment, sending every log entry to the
+// WebSocket, until the WebSocket is closed.
+//
+// The following query parameters are accepted:
+//
+//   - since: how far back in time to start following logs (e.g., "1h");
+//     defaults to defaultLogsSince.
+//   - system: if "true", system logs are included.
+func (d *dashboard) handleLogsWebSocket(w http.ResponseWriter, r *http.Request, id string) {
+	if d.spec.Logs == nil {
+		http.Error(w, fmt.Sprintf("%s doesn't support streaming logs", d.spec.Tool), http.StatusNotFound)
+		return
+	}
+	reg, err := d.registry.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query, err := logsQuery(reg.DeploymentId, r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	source, err := d.spec.Logs(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			if err := streamLogs(ws, source, query); err != nil {
+				websocket.Message.Send(ws, fmt.Sprintf(`{"error":%q}`, err.Error())) //nolint:errcheck // best effort
+			}
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// checkOrigin rejects WebSocket handshakes from pages not served by the
+// dashboard, so that other web sites opened in the browser can't read the
+// logs of the deployments.
+func checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	if origin == nil || origin.Host != r.Host {
+		return fmt.Errorf("websocket: cross-origin request from %v", origin)
+	}
+	return nil
+}
+
+// logsQuery returns the query for the log entries of the provided deployment
+// shown on the logs page, given the query parameters of the page.
+func logsQuery(deploymentId string, params url.Values, now time.Time) (logging.Query, error) {
+	since := defaultLogsSince
+	if s := params.Get("since"); s != "" {
+		var err error
+		if since, err = time.ParseDuration(s); err != nil {
+			return "", fmt.Errorf("invalid since %q: %w", s, err)
+		}
+	}
+	start := now.Add(-since).UTC().Format(time.RFC3339Nano)
+	query := fmt.Sprintf(`full_version == %q && time >= timestamp(%q)`, deploymentId, start)
+	if params.Get("system") != "true" {
+		query += ` && !("serviceweaver/system" in attrs)`
+	}
+	return query, nil
+}
+
+// streamLogs follows the log entries that match the provided query, sending
+// every entry to the provided WebSocket, until the WebSocket is closed.
+func streamLogs(ws *websocket.Conn, source logging.Source, query logging.Query) error {
+	// The page never sends anything, so a failed read means that the
+	// WebSocket is closed.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		defer cancel()
+		var discard string
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	reader, err := source.Query(ctx, query, true)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	var f logging.JSONFormatter
+	for {
+		entry, err := reader.Read(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := websocket.Message.Send(ws, f.Format(entry)); err != nil {
+			// The WebSocket is closed.
+			return nil
+		}
+	}
+}
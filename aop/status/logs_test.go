@@ -0,0 +1,151 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+	"greatestworks/aop/logging"
+	"greatestworks/aop/protos"
+)
+
+// fakeSource is a log source that returns the provided entries and then
+// blocks, like a followed source with no new log entries.
+type fakeSource struct {
+	entries []*protos.LogEntry
+	queries chan logging.Query // receives every query
+}
+
+// Query implements the logging.Source interface.
+func (s *fakeSource) Query(_ context.Context, q logging.Query, follow bool) (logging.Reader, error) {
+	s.queries <- q
+	return &fakeReader{entries: s.entries}, nil
+}
+
+// fakeReader is the logging.Reader returned by a fakeSource.
+type fakeReader struct {
+	entries []*protos.LogEntry
+}
+
+// Read implements the logging.Reader interface.
+func (r *fakeReader) Read(ctx context.Context) (*protos.LogEntry, error) {
+	if len(r.entries) == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	entry := r.entries[0]
+	r.entries = r.entries[1:]
+	return entry, nil
+}
+
+// Close implements the logging.Reader interface.
+func (r *fakeReader) Close() {}
+
+func TestLogsQuery(t *testing.T) {
+	now := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, test := range []struct {
+		params string
+		want   string
+	}{
+		{"", `full_version == "v1" && time >= timestamp("2023-01-02T14:59:05Z") && !("serviceweaver/system" in attrs)`},
+		{"since=1h&system=true", `full_version == "v1" && time >= timestamp("2023-01-02T14:04:05Z")`},
+	} {
+		params, err := url.ParseQuery(test.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := logsQuery("v1", params, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("logsQuery(%q): got %q, want %q", test.params, got, test.want)
+		}
+		if _, err := logging.Parse(got); err != nil {
+			t.Errorf("logsQuery(%q): invalid query: %v", test.params, err)
+		}
+	}
+	if _, err := logsQuery("v1", url.Values{"since": {"yesterday"}}, now); err == nil {
+		t.Error("logsQuery: unexpected success for an invalid since")
+	}
+}
+
+func TestStreamLogs(t *testing.T) {
+	ctx := context.Background()
+	registry, err := NewRegistry(ctx, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.newClient = func(string) Server {
+		return fakeClient{status: &Status{DeploymentId: "v1"}}
+	}
+	if err := registry.Register(ctx, Registration{"v1", "todo", "localhost:0"}); err != nil {
+		t.Fatal(err)
+	}
+	source := &fakeSource{
+		entries: []*protos.LogEntry{
+			{Version: "v1", Component: "Todo", Level: "info", Msg: "hello"},
+			{Version: "v1", Component: "Todo", Level: "debug", Msg: "world"},
+		},
+		queries: make(chan logging.Query, 1),
+	}
+	d := &dashboard{
+		spec: &DashboardSpec{
+			Tool: "weaver test",
+			Logs: func(context.Context) (logging.Source, error) { return source, nil },
+		},
+		registry: registry,
+	}
+	server := httptest.NewServer(http.HandlerFunc(d.handleDeploymentPath))
+	defer server.Close()
+
+	// The logs page links to the WebSocket.
+	resp, err := http.Get(server.URL + "/deployment/v1/logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(page), "/deployment/v1/logs/ws") {
+		t.Fatalf("logs page: got %s:\n%s", resp.Status, page)
+	}
+
+	// The WebSocket streams the entries.
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/deployment/v1/logs/ws"
+	ws, err := websocket.Dial(wsURL, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	if q := <-source.queries; !strings.Contains(q, `full_version == "v1"`) {
+		t.Errorf("query: got %q, want the logs of v1", q)
+	}
+	for _, want := range []string{"hello", "world"} {
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			t.Fatal(err)
+		}
+		var entry struct{ Msg string }
+		if err := json.Unmarshal([]byte(msg), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Msg != want {
+			t.Errorf("msg: got %q, want %q", entry.Msg, want)
+		}
+	}
+
+	// WebSockets opened by other web sites are rejected.
+	if _, err := websocket.Dial(wsURL, "", "http://evil.example.com"); err == nil {
+		t.Error("Dial: unexpected success for a cross-origin WebSocket")
+	}
+}
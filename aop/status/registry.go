@@ -4,7 +4,6 @@ package status
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -13,7 +12,6 @@ import (
 	"syscall"
 
 	"greatestworks/aop/colors"
-	"greatestworks/aop/files"
 )
 
 // A Registry is a persistent collection of Service Weaver application metadata.
@@ -22,11 +20,15 @@ import (
 // to know which applications are running and to fetch the status of the
 // running applications.
 type Registry struct {
-	// A Registry stores registrations as files in a directory. Every
-	// registration r is stored in a JSON file called {r.DeploymentId}.json.
+	// A Registry stores registrations in a RegistryStore, which by default
+	// stores every registration r as a JSON file called {r.DeploymentId}.json
+	// in a directory. The history of every registration, which outlives the
+	// registration, is stored in the history/{r.DeploymentId}/ subdirectory
+	// of the directory, even if the registrations are stored elsewhere.
 	//
 	// TODO(mwhittaker): Store as protos instead of JSON?
-	dir string
+	dir   string
+	store RegistryStore
 
 	// newClient returns a new status client that curls the provided address.
 	// It is a field of Registry to enable dependency injection in
@@ -88,53 +90,71 @@ func (r Registration) Rolodex() string {
 }
 
 // NewRegistry returns a registry that persists data to the provided directory.
-func NewRegistry(_ context.Context, dir string) (*Registry, error) {
+//
+// If the RegistryEnvKey environment variable holds the address of a shared
+// registry store (see OpenRegistryStore), the registrations are stored there
+// instead, so that they are visible to the tools running on other machines.
+func NewRegistry(ctx context.Context, dir string) (*Registry, error) {
+	store, err := OpenRegistryStore(ctx, os.Getenv(RegistryEnvKey))
+	if err != nil {
+		return nil, err
+	}
+	return NewRegistryWithStore(ctx, dir, store)
+}
+
+// NewRegistryWithStore returns a registry that stores registrations in the
+// provided store, and the history of the registrations in the provided
+// directory. If store is nil, registrations are also stored in the directory.
+func NewRegistryWithStore(_ context.Context, dir string, store RegistryStore) (*Registry, error) {
 	dir, err := filepath.Abs(dir)
 	if err != nil {
 		return nil, err
 	}
-	err = os.MkdirAll(dir, 0750)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+	if store == nil {
+		store = dirStore{dir}
+	}
 	newClient := func(addr string) Server { return NewClient(addr) }
-	return &Registry{dir, newClient}, err
+	return &Registry{dir, store, newClient}, nil
+}
+
+// Store returns the store of the registrations.
+func (r *Registry) Store() RegistryStore {
+	return r.store
+}
+
+// WithStore returns a copy of the registry that stores registrations in the
+// provided store. The copy shares the history of the registry.
+func (r *Registry) WithStore(store RegistryStore) *Registry {
+	copy := *r
+	copy.store = store
+	return &copy
 }
 
-// Register adds a registration to the registry.
+// Register adds a registration to the registry, and records it in the
+// history of its application.
 func (r *Registry) Register(ctx context.Context, reg Registration) error {
-	bytes, err := json.Marshal(reg)
-	if err != nil {
-		return err
-	}
-	filename := fmt.Sprintf("%s.json", reg.DeploymentId)
-	w := files.NewWriter(filepath.Join(r.dir, filename))
-	defer w.Cleanup()
-	if _, err := w.Write(bytes); err != nil {
+	if err := r.store.Put(ctx, reg); err != nil {
 		return err
 	}
-	return w.Close()
+	return r.record(ctx, reg)
 }
 
 // Unregister removes a registration from the registry.
-func (r *Registry) Unregister(_ context.Context, deploymentId string) error {
-	filename := fmt.Sprintf("%s.json", deploymentId)
-	return os.Remove(filepath.Join(r.dir, filename))
+func (r *Registry) Unregister(ctx context.Context, deploymentId string) error {
+	return r.store.Delete(ctx, deploymentId)
 }
 
 // Get returns the Registration for the provided deployment. If the deployment
 // doesn't exist or is not active, a non-nil error is returned.
 func (r *Registry) Get(ctx context.Context, deploymentId string) (Registration, error) {
-	entries, err := os.ReadDir(r.dir)
+	regs, err := r.list(ctx)
 	if err != nil {
 		return Registration{}, err
 	}
-	for _, entry := range entries {
-		bytes, err := os.ReadFile(filepath.Join(r.dir, entry.Name()))
-		if err != nil {
-			return Registration{}, err
-		}
-		var reg Registration
-		if err := json.Unmarshal(bytes, &reg); err != nil {
-			return Registration{}, err
-		}
+	for _, reg := range regs {
 		if reg.DeploymentId != deploymentId {
 			continue
 		}
@@ -148,7 +168,7 @@ func (r *Registry) Get(ctx context.Context, deploymentId string) (Registration,
 
 // List returns all active Registrations.
 func (r *Registry) List(ctx context.Context) ([]Registration, error) {
-	regs, err := r.list()
+	regs, err := r.list(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -178,25 +198,8 @@ func (r *Registry) List(ctx context.Context) ([]Registration, error) {
 }
 
 // list returns all registrations, dead or alive.
-func (r *Registry) list() ([]Registration, error) {
-	entries, err := os.ReadDir(r.dir)
-	if err != nil {
-		return nil, err
-	}
-
-	var regs []Registration
-	for _, entry := range entries {
-		bytes, err := os.ReadFile(filepath.Join(r.dir, entry.Name()))
-		if err != nil {
-			return nil, err
-		}
-		var reg Registration
-		if err := json.Unmarshal(bytes, &reg); err != nil {
-			return nil, err
-		}
-		regs = append(regs, reg)
-	}
-	return regs, nil
+func (r *Registry) list(ctx context.Context) ([]Registration, error) {
+	return r.store.List(ctx)
 }
 
 // dead returns whether the provided registration is associated with a
@@ -0,0 +1,298 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"greatestworks/aop/files"
+)
+
+// RegistryEnvKey is the environment variable that holds the address of the
+// shared registry store used by NewRegistry, if any (see OpenRegistryStore).
+const RegistryEnvKey = "WEAVER_REGISTRY"
+
+// A RegistryStore stores the registrations of a Registry.
+//
+// By default, a Registry stores registrations in a local directory, so that
+// tools running on one machine (e.g., "weaver multi dashboard") can't see the
+// deployments registered on another. A shared store (e.g., Redis) lets a
+// single dashboard cover the deployments of a whole cluster. Note that the
+// status servers of the deployments must then be reachable from every
+// machine: a Registry garbage collects the registrations whose status server
+// refuses connections.
+type RegistryStore interface {
+	// Put adds or replaces a registration.
+	Put(ctx context.Context, reg Registration) error
+
+	// Delete removes the registration of the provided deployment, if any.
+	Delete(ctx context.Context, deploymentId string) error
+
+	// List returns all registrations, sorted by deployment id.
+	List(ctx context.Context) ([]Registration, error)
+}
+
+// OpenRegistryStore returns the registry store with the provided address:
+//
+//   - "redis://<user>:<password>@<host>:<port>/<db>" is a Redis store (see
+//     NewRedisRegistryStore).
+//   - "http://<host>:<port>" (or https) is a registry service, e.g., the
+//     /registry/ endpoints of "weaver multi dashboard" (see
+//     RegistryStoreHandler).
+//   - "" is the default store of a Registry, for which OpenRegistryStore
+//     returns nil.
+func OpenRegistryStore(_ context.Context, addr string) (RegistryStore, error) {
+	if addr == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("registry store %q: %w", addr, err)
+	}
+	switch u.Scheme {
+	case "redis", "rediss":
+		opts, err := redis.ParseURL(addr)
+		if err != nil {
+			return nil, fmt.Errorf("registry store %q: %w", addr, err)
+		}
+		return NewRedisRegistryStore(redis.NewClient(opts), ""), nil
+	case "http", "https":
+		return NewHTTPRegistryStore(http.DefaultClient, addr), nil
+	default:
+		return nil, fmt.Errorf("registry store %q: unsupported scheme %q", addr, u.Scheme)
+	}
+}
+
+// dirStore is a RegistryStore that stores every registration r as a JSON file
+// called {r.DeploymentId}.json in a directory.
+type dirStore struct {
+	dir string
+}
+
+var _ RegistryStore = dirStore{}
+
+// Put implements the RegistryStore interface.
+func (d dirStore) Put(_ context.Context, reg Registration) error {
+	bytes, err := json.Marshal(reg)
+	if err != nil {
+		return err
+	}
+	filename := fmt.Sprintf("%s.json", reg.DeploymentId)
+	w := files.NewWriter(filepath.Join(d.dir, filename))
+	defer w.Cleanup()
+	if _, err := w.Write(bytes); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Delete implements the RegistryStore interface.
+func (d dirStore) Delete(_ context.Context, deploymentId string) error {
+	filename := fmt.Sprintf("%s.json", deploymentId)
+	return os.Remove(filepath.Join(d.dir, filename))
+}
+
+// List implements the RegistryStore interface.
+func (d dirStore) List(context.Context) ([]Registration, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var regs []Registration
+	for _, entry := range entries {
+		if entry.IsDir() {
+			// Skip the history directory.
+			continue
+		}
+		bytes, err := os.ReadFile(filepath.Join(d.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var reg Registration
+		if err := json.Unmarshal(bytes, &reg); err != nil {
+			return nil, err
+		}
+		regs = append(regs, reg)
+	}
+	return regs, nil
+}
+
+// redisStore is a RegistryStore that stores registrations in a Redis hash,
+// keyed by deployment id.
+type redisStore struct {
+	client *redis.Client
+	key    string // the key of the hash
+}
+
+var _ RegistryStore = &redisStore{}
+
+// NewRedisRegistryStore returns a registry store that stores registrations in
+// the Redis hash with the provided key. If key is empty, it defaults to
+// "weaver:registry".
+func NewRedisRegistryStore(client *redis.Client, key string) RegistryStore {
+	if key == "" {
+		key = "weaver:registry"
+	}
+	return &redisStore{client: client, key: key}
+}
+
+// Put implements the RegistryStore interface.
+func (r *redisStore) Put(ctx context.Context, reg Registration) error {
+	bytes, err := json.Marshal(reg)
+	if err != nil {
+		return err
+	}
+	return r.client.HSet(ctx, r.key, reg.DeploymentId, bytes).Err()
+}
+
+// Delete implements the RegistryStore interface.
+func (r *redisStore) Delete(ctx context.Context, deploymentId string) error {
+	return r.client.HDel(ctx, r.key, deploymentId).Err()
+}
+
+// List implements the RegistryStore interface.
+func (r *redisStore) List(ctx context.Context) ([]Registration, error) {
+	values, err := r.client.HGetAll(ctx, r.key).Result()
+	if err != nil {
+		return nil, err
+	}
+	regs := make([]Registration, 0, len(values))
+	for _, value := range values {
+		var reg Registration
+		if err := json.Unmarshal([]byte(value), &reg); err != nil {
+			return nil, err
+		}
+		regs = append(regs, reg)
+	}
+	sort.Slice(regs, func(i, j int) bool {
+		return regs[i].DeploymentId < regs[j].DeploymentId
+	})
+	return regs, nil
+}
+
+// Paths of the endpoints of a registry service, relative to its address.
+const (
+	registryPutPath    = "/registry/put"
+	registryDeletePath = "/registry/delete"
+	registryListPath   = "/registry/list"
+)
+
+// httpStore is a RegistryStore that stores registrations in a registry
+// service (see RegistryStoreHandler).
+type httpStore struct {
+	client *http.Client
+	addr   string // e.g., "http://dashboard:8000"
+}
+
+var _ RegistryStore = &httpStore{}
+
+// NewHTTPRegistryStore returns a registry store that stores registrations in
+// the registry service at the provided address (e.g., "http://host:8000").
+func NewHTTPRegistryStore(client *http.Client, addr string) RegistryStore {
+	return &httpStore{client: client, addr: strings.TrimSuffix(addr, "/")}
+}
+
+// Put implements the RegistryStore interface.
+func (h *httpStore) Put(ctx context.Context, reg Registration) error {
+	bytes, err := json.Marshal(reg)
+	if err != nil {
+		return err
+	}
+	return h.do(ctx, "POST", registryPutPath, bytes, nil)
+}
+
+// Delete implements the RegistryStore interface.
+func (h *httpStore) Delete(ctx context.Context, deploymentId string) error {
+	path := registryDeletePath + "?id=" + url.QueryEscape(deploymentId)
+	return h.do(ctx, "POST", path, nil, nil)
+}
+
+// List implements the RegistryStore interface.
+func (h *httpStore) List(ctx context.Context) ([]Registration, error) {
+	var regs []Registration
+	if err := h.do(ctx, "GET", registryListPath, nil, &regs); err != nil {
+		return nil, err
+	}
+	return regs, nil
+}
+
+// do issues an HTTP request to the registry service, and decodes the JSON
+// reply into reply, if not nil.
+func (h *httpStore) do(ctx context.Context, method, path string, body []byte, reply any) error {
+	req, err := http.NewRequestWithContext(ctx, method, h.addr+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("registry %s: HTTP status %d: %s", h.addr, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if reply == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(reply)
+}
+
+// RegistryStoreHandler returns an HTTP handler that serves the provided store
+// as a registry service, which tools on other machines can use as their
+// registry store (see NewHTTPRegistryStore). The handler serves the /registry/
+// path.
+func RegistryStoreHandler(store RegistryStore) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(registryPutPath, func(w http.ResponseWriter, r *http.Request) {
+		var reg Registration
+		if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if reg.DeploymentId == "" {
+			http.Error(w, "no deployment id provided", http.StatusBadRequest)
+			return
+		}
+		if err := store.Put(r.Context(), reg); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc(registryDeletePath, func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "no deployment id provided", http.StatusBadRequest)
+			return
+		}
+		err := store.Delete(r.Context(), id)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc(registryListPath, func(w http.ResponseWriter, r *http.Request) {
+		regs, err := store.List(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if regs == nil {
+			regs = []Registration{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(regs); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	return mux
+}
@@ -3,6 +3,9 @@ package status
 import (
 	"context"
 	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 
@@ -54,7 +57,7 @@ func TestRegister(t *testing.T) {
 	}
 
 	// List the deployments.
-	got, err := registry.list()
+	got, err := registry.list(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -92,7 +95,7 @@ func TestUnregister(t *testing.T) {
 	}
 
 	// List the deployments.
-	got, err := registry.list()
+	got, err := registry.list(ctx)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -172,3 +175,146 @@ func TestListAndGet(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPRegistryStore(t *testing.T) {
+	// The dashboard serves its registry to the tools running on another
+	// machine, which have their own history.
+	ctx := context.Background()
+	dashboard, err := NewRegistry(ctx, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(RegistryStoreHandler(dashboard.Store()))
+	defer server.Close()
+	store, err := OpenRegistryStore(ctx, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := NewRegistryWithStore(ctx, t.TempDir(), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	regs := []Registration{
+		{"0", "todo", "host1:0"},
+		{"1", "chat", "host2:0"},
+	}
+	for _, reg := range regs {
+		if err := remote.Register(ctx, reg); err != nil {
+			t.Fatalf("%v: %v", reg, err)
+		}
+	}
+	if err := remote.Unregister(ctx, regs[0].DeploymentId); err != nil {
+		t.Fatal(err)
+	}
+	// Unregistering a missing deployment is not an error.
+	if err := remote.Unregister(ctx, regs[0].DeploymentId); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, registry := range map[string]*Registry{"dashboard": dashboard, "remote": remote} {
+		got, err := registry.list(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(regs[1:], got); diff != "" {
+			t.Errorf("%s: List (-want +got):\n%s", name, diff)
+		}
+	}
+
+	// The history is recorded by the registering machine.
+	history, err := remote.History(ctx, "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 {
+		t.Errorf("remote history: got %d entries, want 1", len(history))
+	}
+}
+
+func TestOpenRegistryStore(t *testing.T) {
+	ctx := context.Background()
+	for _, addr := range []string{"redis://localhost:6379/0", "http://localhost:8000"} {
+		store, err := OpenRegistryStore(ctx, addr)
+		if err != nil || store == nil {
+			t.Errorf("OpenRegistryStore(%q): got (%v, %v), want store", addr, store, err)
+		}
+	}
+	if store, err := OpenRegistryStore(ctx, ""); err != nil || store != nil {
+		t.Errorf(`OpenRegistryStore(""): got (%v, %v), want (nil, nil)`, store, err)
+	}
+	if _, err := OpenRegistryStore(ctx, "ftp://localhost"); err == nil {
+		t.Error("OpenRegistryStore(ftp://localhost): unexpected success")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	// Create the registry.
+	ctx := context.Background()
+	registry, err := NewRegistry(ctx, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Register and unregister more deployments than the history retains.
+	var regs []Registration
+	for i := 0; i < historySize+2; i++ {
+		reg := Registration{fmt.Sprintf("todo-%02d", i), "todo", "localhost:0"}
+		if err := registry.Register(ctx, reg); err != nil {
+			t.Fatalf("%v: %v", reg, err)
+		}
+		if err := registry.Unregister(ctx, reg.DeploymentId); err != nil {
+			t.Fatalf("%v: %v", reg, err)
+		}
+		regs = append(regs, reg)
+	}
+	if err := registry.Register(ctx, Registration{"chat-00", "chat", "localhost:1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the most recent deployments are retained.
+	entries, err := registry.History(ctx, "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Registration
+	for _, entry := range entries {
+		got = append(got, entry.Registration)
+	}
+	if diff := cmp.Diff(regs[2:], got); diff != "" {
+		t.Fatalf("History (-want +got):\n%s", diff)
+	}
+
+	// Snapshot a deployment, and find it.
+	binary := filepath.Join(t.TempDir(), "todo")
+	if err := os.WriteFile(binary, []byte("binary"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	last := regs[len(regs)-1].DeploymentId
+	if err := registry.Snapshot(ctx, last, binary, []byte("config")); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := registry.FindHistory(ctx, "todo", last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !entry.Snapshotted() {
+		t.Fatalf("FindHistory: got %v, want a snapshot", entry)
+	}
+	for file, want := range map[string]string{entry.Binary: "binary", entry.Config: "config"} {
+		got, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("snapshot %s: got %q, want %q", file, got, want)
+		}
+	}
+
+	// Ambiguous and missing prefixes.
+	for _, prefix := range []string{"todo-1", "chat-00", "todo-99"} {
+		if _, err := registry.FindHistory(ctx, "todo", prefix); err == nil {
+			t.Errorf("FindHistory(%q): unexpected success", prefix)
+		}
+	}
+}
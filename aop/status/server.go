@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"net/http"
+	"strings"
 
 	"greatestworks/aop/logtype"
 	"greatestworks/aop/metrics"
@@ -17,6 +18,7 @@ const (
 	metricsEndpoint    = "/debug/serviceweaver/metrics"
 	prometheusEndpoint = "/debug/serviceweaver/prometheus"
 	profileEndpoint    = "/debug/serviceweaver/profile"
+	pprofEndpoint      = "/debug/serviceweaver/pprof/"
 )
 
 // A Server returns information about a Service Weaver deployment.
@@ -33,10 +35,17 @@ type Server interface {
 
 // RegisterServer registers a Server's methods with the provided mux under the
 // /debug/serviceweaver/ prefix. You can use a Client to interact with a Status server.
+//
+// RegisterServer also serves the profiles of the weavelets under the
+// /debug/serviceweaver/pprof/ prefix (e.g., /debug/serviceweaver/pprof/heap),
+// in the format expected by "go tool pprof".
 func RegisterServer(mux *http.ServeMux, server Server, logger logtype.Logger) {
 	mux.Handle(statusEndpoint, protomsg.HandlerThunk(logger, server.Status))
 	mux.Handle(metricsEndpoint, protomsg.HandlerThunk(logger, server.Metrics))
 	mux.Handle(profileEndpoint, protomsg.HandlerFunc(logger, server.Profile))
+	mux.HandleFunc(pprofEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		servePprof(w, r, server, strings.TrimPrefix(r.URL.Path, pprofEndpoint))
+	})
 	mux.HandleFunc(prometheusEndpoint, func(w http.ResponseWriter, r *http.Request) {
 		ms, err := server.Metrics(r.Context())
 		if err != nil {
@@ -11,6 +11,7 @@ import (
 
 	"greatestworks/aop/colors"
 	"greatestworks/aop/logging"
+	"greatestworks/aop/proxy"
 	dtool "greatestworks/aop/tool"
 )
 
@@ -122,14 +123,35 @@ func formatListeners(w io.Writer, statuses []*Status) {
 	title := []colors.Text{{{S: "LISTENERS", Bold: true}}}
 	t := colors.NewTabularizer(w, title, colors.PrefixDim)
 	defer t.Flush()
-	t.Row("APP", "DEPLOYMENT", "LISTENER", "ADDRESS")
+	t.Row("APP", "DEPLOYMENT", "LISTENER", "ADDRESS", "HEALTHY BACKENDS")
 	for _, status := range statuses {
 		sort.Slice(status.Listeners, func(i, j int) bool {
 			return status.Listeners[i].Name < status.Listeners[j].Name
 		})
 		for _, lis := range status.Listeners {
 			prefix, _ := formatId(status.DeploymentId)
-			t.Row(status.App, prefix, lis.Name, lis.Addr)
+			healthy := 0
+			for _, b := range lis.Backends {
+				if b.Healthy {
+					healthy++
+				}
+			}
+			backends := fmt.Sprintf("%d/%d", healthy, len(lis.Backends))
+			t.Row(status.App, prefix, lis.Name, lis.Addr, backends)
+		}
+	}
+}
+
+// ProxyBackends returns the status of the provided proxy backends.
+func ProxyBackends(backends []proxy.Backend) []*Backend {
+	result := make([]*Backend, len(backends))
+	for i, b := range backends {
+		result[i] = &Backend{
+			Addr:     b.Addr,
+			Weight:   int64(b.Weight),
+			InFlight: int64(b.InFlight),
+			Healthy:  b.Healthy,
 		}
 	}
+	return result
 }
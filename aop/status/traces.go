@@ -0,0 +1,122 @@
+package status
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"greatestworks/aop/perfetto"
+)
+
+// This file implements the traces page of the dashboard, which searches the
+// traces of a deployment stored in the Perfetto database (see
+// perfetto.DB.QueryTraces), and links every matching trace to the Perfetto
+// UI.
+
+var (
+	//go:embed templates/traces.html
+	tracesHTML     string
+	tracesTemplate = template.Must(template.New("traces").Funcs(template.FuncMap{
+		"traceurl": traceURL,
+		"duration": func(d time.Duration) string {
+			return d.Round(time.Microsecond).String()
+		},
+		"timestamp": func(t time.Time) string {
+			return t.Format("2006-01-02 15:04:05.000")
+		},
+	}).Parse(tracesHTML))
+)
+
+// traceURL returns the URL of the Perfetto UI that shows the traces of the
+// provided application version, or only the provided trace if traceID is not
+// empty. See perfetto.DB.Serve.
+func traceURL(app, version, traceID string) string {
+	v := url.Values{}
+	v.Set("app", app)
+	v.Set("version", version)
+	if traceID != "" {
+		v.Set("trace", traceID)
+	}
+	tracerURL := url.QueryEscape("http://127.0.0.1:9001?" + v.Encode())
+	return "https://ui.perfetto.dev/#!/?url=" + tracerURL
+}
+
+// handleTraces handles requests to /deployment/<deployment id>/traces.
+//
+// The following query parameters are accepted:
+//
+//   - component: only show traces with a call to this component.
+//   - method: only show traces with a call to this method.
+//   - min_duration: only show traces that last at least this long (e.g.,
+//     "100ms").
+//   - errors: if "true", only show traces with a failed span.
+//   - limit: the maximum number of traces shown; defaults to 100.
+func (d *dashboard) handleTraces(w http.ResponseWriter, r *http.Request, id string) {
+	if d.traceDB == nil {
+		http.Error(w, "the Perfetto database is not available", http.StatusNotFound)
+		return
+	}
+	reg, err := d.registry.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query, err := tracesQuery(reg.App, reg.DeploymentId, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	traces, err := d.traceDB.QueryTraces(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	content := struct {
+		Tool         string
+		App          string
+		DeploymentId string
+		Query        perfetto.TraceQuery
+		Traces       []perfetto.TraceSummary
+	}{
+		Tool:         d.spec.Tool,
+		App:          reg.App,
+		DeploymentId: reg.DeploymentId,
+		Query:        query,
+		Traces:       traces,
+	}
+	if err := tracesTemplate.Execute(w, content); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// tracesQuery returns the query for the traces of the provided application
+// version shown on the traces page, given the query parameters of the page.
+func tracesQuery(app, version string, params url.Values) (perfetto.TraceQuery, error) {
+	query := perfetto.TraceQuery{
+		App:        app,
+		Version:    version,
+		Component:  params.Get("component"),
+		Method:     params.Get("method"),
+		OnlyErrors: params.Get("errors") == "true",
+	}
+	if s := params.Get("min_duration"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return query, fmt.Errorf("invalid min_duration %q: %w", s, err)
+		}
+		query.MinDuration = d
+	}
+	if s := params.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit <= 0 {
+			return query, fmt.Errorf("invalid limit %q: must be a positive integer", s)
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
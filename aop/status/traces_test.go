@@ -0,0 +1,93 @@
+package status
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"greatestworks/aop/perfetto"
+)
+
+func TestTracesQuery(t *testing.T) {
+	for _, test := range []struct {
+		params string
+		want   perfetto.TraceQuery
+	}{
+		{"", perfetto.TraceQuery{App: "todo", Version: "v1"}},
+		{
+			"component=Server&method=Add&min_duration=100ms&errors=true&limit=10",
+			perfetto.TraceQuery{
+				App:         "todo",
+				Version:     "v1",
+				Component:   "Server",
+				Method:      "Add",
+				MinDuration: 100 * time.Millisecond,
+				OnlyErrors:  true,
+				Limit:       10,
+			},
+		},
+	} {
+		params, err := url.ParseQuery(test.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := tracesQuery("todo", "v1", params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("tracesQuery(%q) (-want +got):\n%s", test.params, diff)
+		}
+	}
+	for _, params := range []string{"min_duration=slow", "limit=0", "limit=many"} {
+		values, err := url.ParseQuery(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tracesQuery("todo", "v1", values); err == nil {
+			t.Errorf("tracesQuery(%q): unexpected success", params)
+		}
+	}
+}
+
+func TestTracesTemplate(t *testing.T) {
+	content := struct {
+		Tool         string
+		App          string
+		DeploymentId string
+		Query        perfetto.TraceQuery
+		Traces       []perfetto.TraceSummary
+	}{
+		Tool:         "weaver test",
+		App:          "todo",
+		DeploymentId: "v1",
+		Query:        perfetto.TraceQuery{Component: "Server", MinDuration: time.Second},
+		Traces: []perfetto.TraceSummary{{
+			TraceID:  "0123456789abcdef0123456789abcdef",
+			Root:     "todo.Server.Add",
+			Start:    time.Now(),
+			Duration: 1500 * time.Millisecond,
+			Spans:    3,
+			Error:    true,
+		}},
+	}
+	var b strings.Builder
+	if err := tracesTemplate.Execute(&b, content); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	for _, want := range []string{
+		`value="Server"`,
+		`value="1s"`,
+		"todo.Server.Add (failed)",
+		"1.5s",
+		// The trace links to the Perfetto UI, showing only the trace.
+		url.QueryEscape("trace=0123456789abcdef0123456789abcdef"),
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("traces page doesn't contain %q:\n%s", want, page)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package tick runs the periodic work of a server (moving monsters, flushing
+// scene updates, regenerating stamina) in frames, at a fixed rate, from a
+// single goroutine.
+//
+// Callbacks are registered with a Priority, and called once per frame, by
+// priority. Frames are scheduled from the start of the scheduler, not from
+// the end of the previous frame, so that the rate doesn't drift; a frame
+// that starts late is followed by the frames it delayed, back to back, up to
+// MaxLag of them, after which the frames missed are skipped. When a frame
+// exceeds its Budget, the Low priority callbacks left are shed for that
+// frame, and called at the next frame with the time elapsed since their last
+// call.
+package tick
+
+import (
+	"context"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+var (
+	callbackMicros = metrics.NewHistogramMap[callbackLabels](
+		"tick_callback_micros",
+		"Duration, in microseconds, of the tick callbacks",
+		metrics.NonNegativeBuckets,
+	)
+	frameMicros = metrics.NewHistogram(
+		"tick_frame_micros",
+		"Duration, in microseconds, of the frames",
+		metrics.NonNegativeBuckets,
+	)
+	shedCallbacks = metrics.NewCounterMap[callbackLabels](
+		"tick_shed_callbacks",
+		"Number of low priority tick callbacks shed from a frame over its budget",
+	)
+	overruns = metrics.NewCounter(
+		"tick_frame_overruns",
+		"Number of frames over their budget",
+	)
+	skippedFrames = metrics.NewCounter(
+		"tick_skipped_frames",
+		"Number of frames skipped by a scheduler lagging behind",
+	)
+)
+
+type callbackLabels struct {
+	Module string
+}
+
+// Priority is the priority of a callback. Callbacks are called by priority,
+// then in the order they were registered.
+type Priority int
+
+const (
+	High Priority = iota
+	Normal
+	// Low callbacks are shed from the frames over their budget.
+	Low
+)
+
+// Frame is a frame, passed to the callbacks.
+type Frame struct {
+	Seq   uint64        // number of the frame, from 1
+	Time  time.Time     // time the frame was scheduled at
+	Delta time.Duration // since the previous call of the callback; the interval between frames at its first call
+}
+
+// Options configures a Scheduler.
+type Options struct {
+	// Rate is the number of frames per second. Defaults to 20.
+	Rate int
+
+	// Budget is the duration of a frame after which its Low priority
+	// callbacks are shed. Defaults to the interval between frames.
+	Budget time.Duration
+
+	// MaxLag is the number of frames a scheduler may be behind; it skips
+	// the frames it's further behind. Defaults to 5.
+	MaxLag int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Rate <= 0 {
+		o.Rate = 20
+	}
+	if o.Budget <= 0 {
+		o.Budget = time.Second / time.Duration(o.Rate)
+	}
+	if o.MaxLag <= 0 {
+		o.MaxLag = 5
+	}
+	return o
+}
+
+// Scheduler calls the callbacks registered at every frame, once running (see
+// Run). It's safe for concurrent use; a callback may register or cancel
+// callbacks, which take effect at the next frame.
+type Scheduler struct {
+	opts     Options
+	interval time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	tasks []*task // by priority, then registration
+}
+
+type task struct {
+	module   string
+	priority Priority
+	fn       func(Frame)
+	last     time.Time // scheduled time of the frame of the last call, zero before the first
+}
+
+// New returns a scheduler without callbacks.
+func New(opts Options) *Scheduler {
+	opts = opts.withDefaults()
+	return &Scheduler{
+		opts:     opts,
+		interval: time.Second / time.Duration(opts.Rate),
+		now:      time.Now,
+	}
+}
+
+// Default is the scheduler of the process, run by its server, which replaces
+// it with its configured one before its modules start. The modules register
+// their callbacks with it once started.
+var Default = New(Options{})
+
+// Register registers a callback with Default.
+func Register(module string, priority Priority, fn func(Frame)) (cancel func()) {
+	return Default.Register(module, priority, fn)
+}
+
+// Register registers the callback of a module, called at every frame from the
+// next one until cancelled. The module names the metrics of the callback.
+func (s *Scheduler) Register(module string, priority Priority, fn func(Frame)) (cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := &task{module: module, priority: priority, fn: fn}
+	s.tasks = append(s.tasks, t)
+	sort.SliceStable(s.tasks, func(i, j int) bool { return s.tasks[i].priority < s.tasks[j].priority })
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, other := range s.tasks {
+			if other == t {
+				s.tasks = append(s.tasks[:i:i], s.tasks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Run runs the frames until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	start := s.now()
+	timer := time.NewTimer(s.interval)
+	defer timer.Stop()
+	for seq := uint64(1); ; seq++ {
+		at := start.Add(time.Duration(seq) * s.interval)
+		if wait := at.Sub(s.now()); wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				return
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+		s.frame(Frame{Seq: seq, Time: at})
+
+		// Skip the frames beyond MaxLag.
+		if lag := s.now().Sub(at) / s.interval; lag > time.Duration(s.opts.MaxLag) {
+			skipped := uint64(lag) - uint64(s.opts.MaxLag)
+			skippedFrames.Add(float64(skipped))
+			seq += skipped
+		}
+	}
+}
+
+// frame calls the callbacks for a frame.
+func (s *Scheduler) frame(f Frame) {
+	s.mu.Lock()
+	tasks := append([]*task(nil), s.tasks...)
+	s.mu.Unlock()
+
+	begin := s.now()
+	// A frame starting late has less of its budget left.
+	deadline := f.Time.Add(s.opts.Budget)
+	over := false
+	for _, t := range tasks {
+		if t.priority >= Low && s.now().After(deadline) {
+			shedCallbacks.Get(callbackLabels{Module: t.module}).Add(1)
+			over = true
+			continue
+		}
+		tf := f
+		tf.Delta = s.interval
+		if !t.last.IsZero() {
+			tf.Delta = f.Time.Sub(t.last)
+		}
+		t.last = f.Time
+		start := s.now()
+		s.call(t, tf)
+		callbackMicros.Get(callbackLabels{Module: t.module}).Put(float64(s.now().Sub(start).Microseconds()))
+	}
+	end := s.now()
+	frameMicros.Put(float64(end.Sub(begin).Microseconds()))
+	if over || end.After(deadline) {
+		overruns.Add(1)
+	}
+}
+
+// call calls a callback, logging its panic.
+func (s *Scheduler) call(t *task, f Frame) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("[tick] module:%v frame:%v panic: %v\n%s", t.module, f.Seq, r, debug.Stack())
+		}
+	}()
+	t.fn(f)
+}
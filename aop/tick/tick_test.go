@@ -0,0 +1,143 @@
+package tick
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phuhao00/spoor"
+	"greatestworks/aop/logger"
+)
+
+// TestMain sets up the logger, which the package logs its failures with.
+func TestMain(m *testing.M) {
+	logger.SetLogging(&logger.LoggingSetting{WriterOption: spoor.WithConsoleWriter(os.Stderr)})
+	os.Exit(m.Run())
+}
+
+// fakeClock is a clock the callbacks advance.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+func (c *fakeClock) Frame(seq uint64) Frame  { return Frame{Seq: seq, Time: c.now} }
+
+// newFake returns a scheduler of 10 frames per second on a fake clock.
+func newFake() (*Scheduler, *fakeClock) {
+	s := New(Options{Rate: 10})
+	c := &fakeClock{now: time.Unix(1000, 0)}
+	s.now = c.Now
+	return s, c
+}
+
+func TestFramePriorities(t *testing.T) {
+	s, c := newFake()
+	var calls []string
+	record := func(name string) func(Frame) {
+		return func(Frame) { calls = append(calls, name) }
+	}
+	s.Register("low", Low, record("low"))
+	s.Register("high", High, record("high"))
+	cancel := s.Register("normal1", Normal, record("normal1"))
+	s.Register("normal2", Normal, record("normal2"))
+	s.Register("panic", Normal, func(Frame) { panic("boom") })
+
+	s.frame(c.Frame(1))
+	if got := fmt.Sprint(calls); got != "[high normal1 normal2 low]" {
+		t.Errorf("got %v", got)
+	}
+	cancel()
+	calls = nil
+	s.frame(c.Frame(2))
+	if got := fmt.Sprint(calls); got != "[high normal2 low]" {
+		t.Errorf("after cancel: got %v", got)
+	}
+}
+
+func TestFrameSheds(t *testing.T) {
+	s, c := newFake() // 100ms budget
+	slow := 0
+	s.Register("slow", Normal, func(Frame) {
+		slow++
+		if slow == 1 {
+			c.Advance(150 * time.Millisecond)
+		}
+	})
+	var deltas []time.Duration
+	s.Register("low", Low, func(f Frame) { deltas = append(deltas, f.Delta) })
+
+	s.frame(c.Frame(1)) // over its budget: low is shed
+	c.Advance(100 * time.Millisecond)
+	s.frame(c.Frame(2))
+	c.Advance(100 * time.Millisecond)
+	s.frame(c.Frame(3))
+	if got := fmt.Sprint(deltas); got != "[100ms 100ms]" {
+		t.Errorf("deltas of the low callback: got %v", got)
+	}
+
+	// The delta of a shed callback covers the frames it missed.
+	s, c = newFake()
+	over := true
+	s.Register("slow", Normal, func(Frame) {
+		if over {
+			c.Advance(150 * time.Millisecond)
+		}
+	})
+	deltas = nil
+	s.Register("low", Low, func(f Frame) { deltas = append(deltas, f.Delta) })
+	over = false
+	s.frame(c.Frame(1))
+	over = true
+	c.Advance(100 * time.Millisecond)
+	s.frame(c.Frame(2))
+	over = false
+	c.Advance(100 * time.Millisecond)
+	s.frame(c.Frame(3))
+	if got := fmt.Sprint(deltas); got != "[100ms 350ms]" {
+		t.Errorf("deltas of the shed callback: got %v", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	s := New(Options{Rate: 50, MaxLag: 3})
+	var mu sync.Mutex
+	var frames []Frame
+	s.Register("record", Normal, func(f Frame) {
+		mu.Lock()
+		frames = append(frames, f)
+		mu.Unlock()
+		if f.Seq == 5 {
+			time.Sleep(300 * time.Millisecond) // 15 frames behind
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(frames) < 10 {
+		t.Fatalf("got %d frames", len(frames))
+	}
+	start := frames[0].Time.Add(-s.interval)
+	skipped := false
+	for i, f := range frames {
+		if want := start.Add(time.Duration(f.Seq) * s.interval); !f.Time.Equal(want) {
+			t.Fatalf("frame %d scheduled at %v, want %v", f.Seq, f.Time, want)
+		}
+		if i > 0 && f.Seq != frames[i-1].Seq+1 {
+			skipped = true
+			if frames[i-1].Seq != 5 {
+				t.Errorf("skipped from frame %d to %d", frames[i-1].Seq, f.Seq)
+			}
+		}
+	}
+	if !skipped {
+		t.Error("no frame skipped")
+	}
+}
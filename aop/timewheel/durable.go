@@ -0,0 +1,149 @@
+package timewheel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"greatestworks/aop/logger"
+)
+
+// storeTimeout is the timeout of the Store operations of the durable timers
+// fired.
+const storeTimeout = 5 * time.Second
+
+// Durable is a durable timer: once At is past, it's handled by the handler of
+// its Kind (see Wheel.Handle), with its Payload. A durable timer is
+// identified by its Kind and its Key (e.g., "mail.global" and the ID of the
+// mail): scheduling it again replaces it.
+type Durable struct {
+	Kind    string // mustn't contain '/'
+	Key     string
+	At      time.Time
+	Payload []byte
+}
+
+type durableKey struct {
+	kind, key string
+}
+
+func (d *Durable) key() durableKey {
+	return durableKey{kind: d.Kind, key: d.Key}
+}
+
+// Store persists the durable timers of a wheel.
+type Store interface {
+	Save(ctx context.Context, d Durable) error
+	Delete(ctx context.Context, kind, key string) error
+	Load(ctx context.Context) ([]Durable, error)
+}
+
+// Handle sets the handler of the durable timers of a kind. It must be called
+// before the timers of the kind are restored.
+func (w *Wheel) Handle(kind string, fn func(Durable)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[kind] = fn
+}
+
+// Schedule persists a durable timer, and schedules it, replacing the timer of
+// the same kind and key.
+func (w *Wheel) Schedule(ctx context.Context, d Durable) error {
+	if strings.Contains(d.Kind, "/") {
+		return fmt.Errorf("timewheel: durable timer kind %q contains '/'", d.Kind)
+	}
+	if w.opts.Store != nil {
+		if err := w.opts.Store.Save(ctx, d); err != nil {
+			return fmt.Errorf("timewheel: save durable timer %v/%v: %w", d.Kind, d.Key, err)
+		}
+	}
+	w.schedule(d)
+	return nil
+}
+
+// schedule schedules a durable timer, replacing the timer of the same kind
+// and key.
+func (w *Wheel) schedule(d Durable) {
+	t := &Timer{w: w, when: d.At, durable: &d}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	k := d.key()
+	if prev := w.durables[k]; prev != nil && prev.bucket != nil {
+		w.remove(prev)
+	}
+	w.durables[k] = t
+	w.insert(t)
+}
+
+// Cancel stops a durable timer, and deletes it from the Store.
+func (w *Wheel) Cancel(ctx context.Context, kind, key string) error {
+	w.mu.Lock()
+	k := durableKey{kind: kind, key: key}
+	if t := w.durables[k]; t != nil {
+		if t.bucket != nil {
+			w.remove(t)
+		}
+		delete(w.durables, k)
+	}
+	w.mu.Unlock()
+	if w.opts.Store == nil {
+		return nil
+	}
+	if err := w.opts.Store.Delete(ctx, kind, key); err != nil {
+		return fmt.Errorf("timewheel: delete durable timer %v/%v: %w", kind, key, err)
+	}
+	return nil
+}
+
+// Restore schedules the durable timers of the Store, once the process
+// restarted. The timers past fire at the next Advance. It returns the number
+// of timers restored.
+func (w *Wheel) Restore(ctx context.Context) (int, error) {
+	if w.opts.Store == nil {
+		return 0, nil
+	}
+	durables, err := w.opts.Store.Load(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("timewheel: load durable timers: %w", err)
+	}
+	for _, d := range durables {
+		w.schedule(d)
+	}
+	return len(durables), nil
+}
+
+// fireDurable handles a durable timer due, then deletes it from the Store,
+// unless it was scheduled again meanwhile. A timer whose handler ran, but
+// whose deletion didn't (e.g., the process crashed in between), fires again
+// once restored, so handlers must be idempotent.
+func (w *Wheel) fireDurable(t *Timer) {
+	d := *t.durable
+	k := d.key()
+	w.mu.Lock()
+	fn := w.handlers[d.Kind]
+	current := w.durables[k] == t
+	if current {
+		delete(w.durables, k)
+	}
+	w.mu.Unlock()
+	if !current {
+		return
+	}
+	if fn == nil {
+		logger.Error("[timewheel] %v: no handler of durable timer %v/%v", w.opts.Name, d.Kind, d.Key)
+		return
+	}
+	fn(d)
+	w.mu.Lock()
+	_, rescheduled := w.durables[k]
+	w.mu.Unlock()
+	if w.opts.Store == nil || rescheduled {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := w.opts.Store.Delete(ctx, d.Kind, d.Key); err != nil {
+		logger.Error("[timewheel] %v: delete durable timer %v/%v: %v", w.opts.Name, d.Kind, d.Key, err)
+	}
+}
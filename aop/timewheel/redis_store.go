@@ -0,0 +1,73 @@
+package timewheel
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStore stores the durable timers in Redis: a sorted set of the timers
+// by time, and a hash of their payloads.
+type RedisStore struct {
+	rdb redis.Cmdable
+	key string
+}
+
+// NewRedisStore returns a store of the durable timers in the sorted set key,
+// and the hash key:payload. On a cluster, key must have a hash tag (e.g.,
+// "{mail:timers}"), so that both are in the same slot.
+func NewRedisStore(rdb redis.Cmdable, key string) *RedisStore {
+	return &RedisStore{rdb: rdb, key: key}
+}
+
+func (s *RedisStore) payloadKey() string {
+	return s.key + ":payload"
+}
+
+func (s *RedisStore) Save(ctx context.Context, d Durable) error {
+	member := d.Kind + "/" + d.Key
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, s.key, &redis.Z{Score: float64(d.At.UnixMilli()), Member: member})
+		pipe.HSet(ctx, s.payloadKey(), member, d.Payload)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Delete(ctx context.Context, kind, key string) error {
+	member := kind + "/" + key
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, s.key, member)
+		pipe.HDel(ctx, s.payloadKey(), member)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Load(ctx context.Context) ([]Durable, error) {
+	zs, err := s.rdb.ZRangeWithScores(ctx, s.key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	payloads, err := s.rdb.HGetAll(ctx, s.payloadKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+	durables := make([]Durable, 0, len(zs))
+	for _, z := range zs {
+		member, _ := z.Member.(string)
+		kind, key, ok := strings.Cut(member, "/")
+		if !ok {
+			continue
+		}
+		durables = append(durables, Durable{
+			Kind:    kind,
+			Key:     key,
+			At:      time.UnixMilli(int64(z.Score)),
+			Payload: []byte(payloads[member]),
+		})
+	}
+	return durables, nil
+}
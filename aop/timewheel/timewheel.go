@@ -0,0 +1,280 @@
+// Package timewheel schedules a great many timers (buffs running out,
+// monsters respawning, mails expiring) at a fixed resolution, in a
+// hierarchical timer wheel: inserting and stopping a timer take constant
+// time, whatever the number of timers pending, and advancing the wheel only
+// visits the timers due.
+//
+// The first level of the wheel has a slot per tick, for the timers due
+// within 256 ticks; every other level has 64 slots, each covering a slot of
+// the level below, and its timers cascade down once their slot comes up. A
+// wheel doesn't run by itself: Advance fires the timers due, typically from
+// a frame callback (see tick).
+//
+// Durable timers are persisted in a Store, so that they fire after a restart
+// (see Schedule and Restore).
+package timewheel
+
+import (
+	"sync"
+	"time"
+
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+const (
+	level0Bits = 8 // slots of the first level
+	levelBits  = 6 // slots of the other levels
+	levels     = 5 // up to 2^32 ticks ahead: 13 years at 100ms
+	level0Mask = 1<<level0Bits - 1
+	levelMask  = 1<<levelBits - 1
+	maxTicks   = 1<<(level0Bits+(levels-1)*levelBits) - 1
+)
+
+var (
+	pendingTimers = metrics.NewGaugeMap[wheelLabels](
+		"timewheel_pending_timers",
+		"Number of timers pending in a timer wheel",
+	)
+	firedTimers = metrics.NewCounterMap[wheelLabels](
+		"timewheel_fired_timers",
+		"Number of timers fired by a timer wheel",
+	)
+	fireDelay = metrics.NewHistogramMap[wheelLabels](
+		"timewheel_fire_delay_millis",
+		"Delay, in milliseconds, of the timers fired after their time",
+		metrics.NonNegativeBuckets,
+	)
+)
+
+type wheelLabels struct {
+	Wheel string
+}
+
+// Options configures a Wheel.
+type Options struct {
+	// Name names the metrics of the wheel.
+	Name string
+
+	// Tick is the resolution of the wheel: timers fire at the first Advance
+	// after the tick they're due at. Defaults to 100ms.
+	Tick time.Duration
+
+	// Store persists the durable timers. If nil, durable timers are lost
+	// when the process restarts.
+	Store Store
+}
+
+// Wheel is a hierarchical timer wheel. It's safe for concurrent use.
+type Wheel struct {
+	opts   Options
+	labels wheelLabels
+	start  time.Time
+	now    func() time.Time
+
+	advanceMu sync.Mutex // serializes Advance, so that timers fire in order
+
+	mu       sync.Mutex
+	current  uint64 // ticks advanced
+	buckets  [levels][]bucket
+	pending  int
+	handlers map[string]func(Durable)
+	durables map[durableKey]*Timer
+}
+
+type bucket struct {
+	head *Timer
+}
+
+// Timer is a timer pending in a wheel.
+type Timer struct {
+	w          *Wheel
+	at         uint64 // tick it's due at
+	when       time.Time
+	fn         func()
+	bucket     *bucket // nil once fired or stopped
+	prev, next *Timer
+	durable    *Durable
+}
+
+// New returns a wheel without timers, starting now.
+func New(opts Options) *Wheel {
+	if opts.Tick <= 0 {
+		opts.Tick = 100 * time.Millisecond
+	}
+	w := &Wheel{
+		opts:     opts,
+		labels:   wheelLabels{Wheel: opts.Name},
+		now:      time.Now,
+		handlers: map[string]func(Durable){},
+		durables: map[durableKey]*Timer{},
+	}
+	w.start = w.now()
+	w.buckets[0] = make([]bucket, 1<<level0Bits)
+	for l := 1; l < levels; l++ {
+		w.buckets[l] = make([]bucket, 1<<levelBits)
+	}
+	return w
+}
+
+// AfterFunc calls fn, from Advance, once d elapsed.
+func (w *Wheel) AfterFunc(d time.Duration, fn func()) *Timer {
+	return w.At(w.now().Add(d), fn)
+}
+
+// At calls fn, from Advance, once t is past.
+func (w *Wheel) At(t time.Time, fn func()) *Timer {
+	timer := &Timer{w: w, when: t, fn: fn}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.insert(timer)
+	return timer
+}
+
+// Stop stops a timer. It returns false if it already fired or was stopped.
+// Stopping a durable timer doesn't delete it from the Store (see Cancel).
+func (t *Timer) Stop() bool {
+	t.w.mu.Lock()
+	defer t.w.mu.Unlock()
+	if t.bucket == nil {
+		return false
+	}
+	t.w.remove(t)
+	if t.durable != nil {
+		k := t.durable.key()
+		if t.w.durables[k] == t {
+			delete(t.w.durables, k)
+		}
+	}
+	return true
+}
+
+// Len returns the number of timers pending.
+func (w *Wheel) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.pending
+}
+
+// Advance fires the timers due by now, in order. The timers fire on the
+// calling goroutine; they may schedule and stop timers.
+func (w *Wheel) Advance(now time.Time) {
+	w.advanceMu.Lock()
+	defer w.advanceMu.Unlock()
+	target := uint64(0)
+	if now.After(w.start) {
+		target = uint64(now.Sub(w.start) / w.opts.Tick)
+	}
+	for {
+		w.mu.Lock()
+		if w.pending == 0 && w.current < target {
+			w.current = target // nothing to cascade
+		}
+		if w.current >= target {
+			w.mu.Unlock()
+			return
+		}
+		due := w.step()
+		w.mu.Unlock()
+		for _, t := range due {
+			if delay := now.Sub(t.when); delay > 0 {
+				fireDelay.Get(w.labels).Put(float64(delay.Milliseconds()))
+			}
+			firedTimers.Get(w.labels).Add(1)
+			if t.durable != nil {
+				w.fireDurable(t)
+			} else {
+				t.fn()
+			}
+		}
+	}
+}
+
+// step advances the wheel by a tick, and returns the timers due. w.mu must be
+// held.
+func (w *Wheel) step() []*Timer {
+	w.current++
+	// Cascade the slots of the upper levels coming up.
+	shift := uint(level0Bits)
+	for l := 1; l < levels && w.current&(1<<shift-1) == 0; l++ {
+		b := &w.buckets[l][(w.current>>shift)&levelMask]
+		timers := b.head
+		b.head = nil
+		for t := timers; t != nil; {
+			next := t.next
+			w.link(t)
+			t = next
+		}
+		shift += levelBits
+	}
+
+	b := &w.buckets[0][w.current&level0Mask]
+	var due []*Timer
+	for t := b.head; t != nil; {
+		next := t.next
+		if t.at <= w.current {
+			w.remove(t)
+			due = append(due, t)
+		}
+		t = next
+	}
+	return due
+}
+
+// insert inserts a timer in the slot of its tick. w.mu must be held.
+func (w *Wheel) insert(t *Timer) {
+	t.at = w.tickOf(t.when)
+	w.link(t)
+	w.pending++
+	pendingTimers.Get(w.labels).Add(1)
+}
+
+// link links a timer to the slot of its tick, from the current tick.
+func (w *Wheel) link(t *Timer) {
+	at := t.at
+	if at <= w.current {
+		at = w.current + 1
+	}
+	if at-w.current > maxTicks {
+		at = w.current + maxTicks
+	}
+	delta := at - w.current
+	var b *bucket
+	if delta < 1<<level0Bits {
+		b = &w.buckets[0][at&level0Mask]
+	} else {
+		shift := uint(level0Bits)
+		l := 1
+		for ; l < levels-1 && delta >= 1<<(shift+levelBits); l++ {
+			shift += levelBits
+		}
+		b = &w.buckets[l][(at>>shift)&levelMask]
+	}
+	t.bucket, t.prev, t.next = b, nil, b.head
+	if b.head != nil {
+		b.head.prev = t
+	}
+	b.head = t
+}
+
+// remove removes a timer from its slot. w.mu must be held.
+func (w *Wheel) remove(t *Timer) {
+	if t.prev != nil {
+		t.prev.next = t.next
+	} else {
+		t.bucket.head = t.next
+	}
+	if t.next != nil {
+		t.next.prev = t.prev
+	}
+	t.bucket, t.prev, t.next = nil, nil, nil
+	w.pending--
+	pendingTimers.Get(w.labels).Sub(1)
+}
+
+// tickOf returns the first tick at or after a time.
+func (w *Wheel) tickOf(t time.Time) uint64 {
+	if !t.After(w.start) {
+		return 0
+	}
+	return uint64((t.Sub(w.start) + w.opts.Tick - 1) / w.opts.Tick)
+}
@@ -0,0 +1,157 @@
+package timewheel
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+var base = time.Unix(1000, 0)
+
+// newWheel returns a wheel of 1ms ticks, started at base.
+func newWheel(store Store) *Wheel {
+	w := New(Options{Name: "test", Tick: time.Millisecond, Store: store})
+	w.start = base
+	w.now = func() time.Time { return base }
+	return w
+}
+
+func TestWheel(t *testing.T) {
+	w := newWheel(nil)
+	var fired []string
+	for _, d := range []time.Duration{
+		0,
+		5 * time.Millisecond,
+		255 * time.Millisecond, // last slot of the first level
+		256 * time.Millisecond, // cascades from the second level
+		20 * time.Second,       // from the third level
+		time.Hour,              // from the fourth level
+	} {
+		d := d
+		w.AfterFunc(d, func() { fired = append(fired, d.String()) })
+	}
+	stopped := w.AfterFunc(10*time.Millisecond, func() { t.Error("stopped timer fired") })
+	if !stopped.Stop() || stopped.Stop() {
+		t.Error("Stop: want true, then false")
+	}
+	if w.Len() != 6 {
+		t.Fatalf("Len: got %d, want 6", w.Len())
+	}
+
+	w.Advance(base.Add(4 * time.Millisecond))
+	if got := fmt.Sprint(fired); got != "[0s]" {
+		t.Fatalf("after 4ms: got %v", got)
+	}
+	w.Advance(base.Add(300 * time.Millisecond))
+	if got := fmt.Sprint(fired); got != "[0s 5ms 255ms 256ms]" {
+		t.Fatalf("after 300ms: got %v", got)
+	}
+	w.Advance(base.Add(time.Hour - time.Millisecond))
+	if got := fmt.Sprint(fired); got != "[0s 5ms 255ms 256ms 20s]" {
+		t.Fatalf("before 1h: got %v", got)
+	}
+	w.Advance(base.Add(time.Hour))
+	if len(fired) != 6 || w.Len() != 0 {
+		t.Fatalf("after 1h: got %v, %d pending", fired, w.Len())
+	}
+}
+
+func TestWheelRandom(t *testing.T) {
+	w := newWheel(nil)
+	r := rand.New(rand.NewSource(1))
+	var got, want []int
+	for i := 0; i < 2000; i++ {
+		at := r.Intn(100000)
+		if i%7 == 0 {
+			w.AfterFunc(time.Duration(at)*time.Millisecond, func() { t.Error("stopped timer fired") }).Stop()
+			continue
+		}
+		want = append(want, at)
+		at2 := at
+		w.AfterFunc(time.Duration(at)*time.Millisecond, func() {
+			if now := int(w.current); now < at2 {
+				t.Errorf("timer of %dms fired at %dms", at2, now)
+			}
+			got = append(got, at2)
+		})
+	}
+	for now := 0; now <= 100000; now += 1 + r.Intn(500) {
+		w.Advance(base.Add(time.Duration(now) * time.Millisecond))
+	}
+	w.Advance(base.Add(100 * time.Second))
+	sort.Ints(want)
+	if !sort.IntsAreSorted(got) || fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("fired %d timers out of order or not all of %d", len(got), len(want))
+	}
+}
+
+// memStore is a Store in memory.
+type memStore struct {
+	mu     sync.Mutex
+	timers map[durableKey]Durable
+}
+
+func (s *memStore) Save(_ context.Context, d Durable) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timers[d.key()] = d
+	return nil
+}
+
+func (s *memStore) Delete(_ context.Context, kind, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.timers, durableKey{kind: kind, key: key})
+	return nil
+}
+
+func (s *memStore) Load(context.Context) ([]Durable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var durables []Durable
+	for _, d := range s.timers {
+		durables = append(durables, d)
+	}
+	return durables, nil
+}
+
+func TestDurable(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{timers: map[durableKey]Durable{}}
+	w := newWheel(store)
+	for _, key := range []string{"a", "b", "c"} {
+		if err := w.Schedule(ctx, Durable{Kind: "mail", Key: key, At: base.Add(time.Second), Payload: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Schedule(ctx, Durable{Kind: "mail", Key: "b", At: base.Add(2 * time.Second)}) // replaces b
+	if err := w.Cancel(ctx, "mail", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Schedule(ctx, Durable{Kind: "bad/kind"}); err == nil {
+		t.Error("kind with a '/' scheduled")
+	}
+	if w.Len() != 2 || len(store.timers) != 2 {
+		t.Fatalf("got %d pending, %d stored; want 2", w.Len(), len(store.timers))
+	}
+
+	// The process restarts before the timers fire.
+	w = newWheel(store)
+	var fired []string
+	w.Handle("mail", func(d Durable) { fired = append(fired, d.Key+":"+string(d.Payload)) })
+	if n, err := w.Restore(ctx); n != 2 || err != nil {
+		t.Fatalf("Restore: got %d, %v", n, err)
+	}
+	w.Advance(base.Add(time.Second))
+	if got := fmt.Sprint(fired); got != "[a:a]" || len(store.timers) != 1 {
+		t.Fatalf("after 1s: got %v, %d stored", got, len(store.timers))
+	}
+	w.Advance(base.Add(2 * time.Second))
+	if got := fmt.Sprint(fired); got != "[a:a b:]" || len(store.timers) != 0 {
+		t.Fatalf("after 2s: got %v, %d stored", got, len(store.timers))
+	}
+}
@@ -0,0 +1,26 @@
+package docker
+
+import (
+	"context"
+
+	"greatestworks/aop/tool"
+	sshimpl "greatestworks/aop/tool/ssh/impl"
+)
+
+var babysitterCmd = tool.Command{
+	Name:        "babysitter",
+	Description: "The weaver docker babysitter",
+	Help: `Usage:
+  weaver docker babysitter [tag]
+
+Runs the babysitter of a colocation group replica within a container. The
+optional tag is ignored by the babysitter.
+
+Flags:
+  -h, --help   Print this help message.`,
+	Fn: func(ctx context.Context, args []string) error {
+		// The docker deployer reuses the babysitter of the SSH deployer.
+		return sshimpl.RunBabysitter(ctx)
+	},
+	Hidden: true,
+}
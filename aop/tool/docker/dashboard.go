@@ -0,0 +1,22 @@
+package docker
+
+import (
+	"fmt"
+
+	"greatestworks/aop/logging"
+	"greatestworks/aop/status"
+	"greatestworks/aop/tool/docker/impl"
+)
+
+var dashboardSpec = &status.DashboardSpec{
+	Tool:     "weaver docker",
+	Registry: impl.DefaultRegistry,
+	Commands: func(deploymentId string) []status.Command {
+		return []status.Command{
+			{Label: "status", Command: "weaver docker status"},
+			{Label: "cat logs", Command: fmt.Sprintf("weaver docker logs 'version==%q'", logging.Shorten(deploymentId))},
+			{Label: "follow logs", Command: fmt.Sprintf("weaver docker logs --follow 'version==%q'", logging.Shorten(deploymentId))},
+			{Label: "containers", Command: fmt.Sprintf("docker ps --filter label=serviceweaver/deployment=%s", deploymentId)},
+		}
+	},
+}
@@ -0,0 +1,123 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/google/uuid"
+
+	"greatestworks/aop"
+	"greatestworks/aop/codegen"
+	"greatestworks/aop/colors"
+	"greatestworks/aop/logging"
+	"greatestworks/aop/protos"
+	"greatestworks/aop/tool"
+	"greatestworks/aop/tool/docker/impl"
+	sshimpl "greatestworks/aop/tool/ssh/impl"
+)
+
+var deployCmd = tool.Command{
+	Name:        "deploy",
+	Description: "Deploy a Service Weaver app",
+	Help: `Usage:
+  weaver docker deploy <configfile>
+
+Builds a docker image that contains the app binary, and runs every colocation
+group replica of the app in its own container on the local machine. The
+optional [docker] section of the config file limits the resources of the
+containers. For example:
+
+    [docker]
+    cpus = "1.5"
+    memory = "512m"`,
+	Flags: flag.NewFlagSet("deploy", flag.ContinueOnError),
+	Fn:    deploy,
+}
+
+// deploy deploys an application on the local machine using docker. Note that
+// each colocation group replica is deployed in a separate container.
+func deploy(ctx context.Context, args []string) error {
+	// Validate command line arguments.
+	if len(args) == 0 {
+		return fmt.Errorf("no config file provided")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments")
+	}
+
+	// Load the config file.
+	cfgFile := args[0]
+	cfg, err := os.ReadFile(cfgFile)
+	if err != nil {
+		return fmt.Errorf("load config file %q: %w", cfgFile, err)
+	}
+	app, err := aop.ParseConfig(cfgFile, string(cfg), codegen.ComponentConfigValidator)
+	if err != nil {
+		return fmt.Errorf("load config file %q: %w", cfgFile, err)
+	}
+
+	// Sanity check the config.
+	if _, err := os.Stat(app.Binary); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("binary %q doesn't exist", app.Binary)
+	}
+	config, err := impl.ParseDockerConfig(app)
+	if err != nil {
+		return err
+	}
+
+	// The log directory is mounted in the containers, so it must exist.
+	if err := os.MkdirAll(logDir, 0o700); err != nil {
+		return fmt.Errorf("create log directory: %w", err)
+	}
+
+	// Run the manager.
+	dep := &protos.Deployment{
+		Id:  uuid.New().String(),
+		App: app,
+	}
+	opts := sshimpl.ManagerOptions{
+		Launcher: impl.NewLauncher(config, logDir),
+		Host:     "localhost",
+		Registry: impl.DefaultRegistry,
+	}
+	stopFn, err := sshimpl.RunManager(ctx, dep, []string{"localhost"}, logDir, opts)
+	if err != nil {
+		return fmt.Errorf("cannot instantiate the manager: %w", err)
+	}
+
+	// Wait for the user to kill the app.
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-done // Will block here until user hits ctrl+c
+		if err := stopFn(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to terminate deployment: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Application %s terminated\n", app.Name)
+		os.Exit(1)
+	}()
+
+	// Follow the logs.
+	source := logging.FileSource(logDir)
+	query := fmt.Sprintf(`full_version == %q && !("serviceweaver/system" in attrs)`, dep.Id)
+	r, err := source.Query(ctx, query, true)
+	if err != nil {
+		return err
+	}
+	pp := logging.NewPrettyPrinter(colors.Enabled())
+	for {
+		entry, err := r.Read(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		fmt.Println(pp.Format(entry))
+	}
+}
@@ -0,0 +1,31 @@
+package docker
+
+import (
+	"context"
+	"path/filepath"
+
+	"greatestworks/aop/logging"
+	"greatestworks/aop/status"
+	"greatestworks/aop/tool"
+	"greatestworks/aop/tool/docker/impl"
+)
+
+var (
+	// logDir is where weaver docker deployed applications store their logs.
+	logDir = filepath.Join(logging.DefaultLogDir, "weaver_docker")
+
+	Commands = map[string]*tool.Command{
+		"deploy": &deployCmd,
+		"logs": tool.LogsCmd(&tool.LogsSpec{
+			Tool: "weaver docker",
+			Source: func(context.Context) (logging.Source, error) {
+				return logging.FileSource(logDir), nil
+			},
+		}),
+		"dashboard": status.DashboardCommand(dashboardSpec),
+		"status":    status.StatusCommand("weaver docker", impl.DefaultRegistry),
+
+		// Hidden commands.
+		"babysitter": &babysitterCmd,
+	}
+)
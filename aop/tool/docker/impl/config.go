@@ -0,0 +1,86 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"greatestworks/aop"
+	"greatestworks/aop/files"
+	"greatestworks/aop/protos"
+	"greatestworks/aop/status"
+)
+
+const (
+	// imageDir is the directory of the deployment images that contains the
+	// weaver and application binaries.
+	imageDir = "/weaver"
+
+	// Labels attached to the containers of a deployment.
+	deploymentLabel = "serviceweaver/deployment"
+	tagLabel        = "serviceweaver/tag"
+)
+
+// DockerConfig is the docker config as found in the TOML config file.
+type DockerConfig struct {
+	// BaseImage is the image on top of which the image of a deployment is
+	// built. It must be able to run the weaver and application binaries.
+	// Defaults to "debian:stable-slim".
+	BaseImage string `toml:"base_image"`
+
+	// CPUs is the number of CPUs every container can use (e.g., "1.5"). If
+	// empty, the CPU usage of the containers is not limited.
+	CPUs string `toml:"cpus"`
+
+	// Memory is the maximum amount of memory every container can use (e.g.,
+	// "512m"). If empty, the memory usage of the containers is not limited.
+	Memory string `toml:"memory"`
+}
+
+// Keys of the docker section of the app config.
+const (
+	dockerKey      = "greatestworks/docker"
+	shortDockerKey = "docker"
+)
+
+func init() {
+	aop.RegisterConfigSection[DockerConfig](dockerKey, shortDockerKey)
+}
+
+// ParseDockerConfig parses the docker section of the provided app config, and
+// fills in the default values.
+func ParseDockerConfig(app *protos.AppConfig) (*DockerConfig, error) {
+	c := &DockerConfig{}
+	if err := aop.ParseConfigSection(dockerKey, shortDockerKey, app.Sections, c); err != nil {
+		return nil, fmt.Errorf("unable to parse docker config: %w", err)
+	}
+	if c.BaseImage == "" {
+		c.BaseImage = "debian:stable-slim"
+	}
+	return c, nil
+}
+
+// DefaultRegistry returns the default registry in
+// $XDG_DATA_HOME/serviceweaver/docker_registry, or
+// ~/.local/share/serviceweaver/docker_registry if XDG_DATA_HOME is not set.
+func DefaultRegistry(ctx context.Context) (*status.Registry, error) {
+	dir, err := files.DefaultDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return status.NewRegistry(ctx, filepath.Join(dir, "docker_registry"))
+}
@@ -0,0 +1,182 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"greatestworks/aop/protos"
+	sshimpl "greatestworks/aop/tool/ssh/impl"
+)
+
+// Launcher is an sshimpl.Launcher that runs every babysitter in its own
+// docker container on the local machine. The only location is "localhost".
+//
+// The containers use the network of the host, so the babysitters and
+// weavelets reach the manager and each other exactly like the processes of
+// the multiprocess deployer do. The log directory is mounted in every
+// container, so the logs of the containers end up in the same place as the
+// logs of the manager.
+type Launcher struct {
+	config *DockerConfig
+	logDir string
+}
+
+var _ sshimpl.Launcher = &Launcher{}
+
+// NewLauncher returns a new docker launcher.
+func NewLauncher(config *DockerConfig, logDir string) *Launcher {
+	return &Launcher{config: config, logDir: logDir}
+}
+
+// Prepare implements the sshimpl.Launcher interface. It builds the image of
+// the provided deployment, which contains the weaver and application
+// binaries, and updates the deployment to refer to the application binary in
+// the image.
+func (l *Launcher) Prepare(ctx context.Context, _ []string, dep *protos.Deployment) error {
+	tool, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "weaver-docker")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	binary := filepath.Base(dep.App.Binary)
+	if err := copyFile(tool, filepath.Join(dir, "weaver")); err != nil {
+		return err
+	}
+	if err := copyFile(dep.App.Binary, filepath.Join(dir, binary)); err != nil {
+		return err
+	}
+	dockerfile := fmt.Sprintf("FROM %s\nCOPY weaver %s %s/\n", l.config.BaseImage, binary, imageDir)
+	cmd := exec.CommandContext(ctx, "docker", "build", "--quiet", "--tag", imageName(dep), "--file", "-", dir)
+	cmd.Stdin = strings.NewReader(dockerfile)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("docker build: %w: %s", err, out)
+	}
+	dep.App.Binary = path.Join(imageDir, binary)
+	return nil
+}
+
+// Launch implements the sshimpl.Launcher interface.
+func (l *Launcher) Launch(ctx context.Context, _ string, dep *protos.Deployment, tag string, env []string) error {
+	args := []string{
+		"run", "--detach", "--rm",
+		"--network", "host",
+		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		"--volume", fmt.Sprintf("%s:%s", l.logDir, l.logDir),
+		"--label", fmt.Sprintf("%s=%s", deploymentLabel, dep.Id),
+		"--label", fmt.Sprintf("%s=%s", tagLabel, tag),
+	}
+	if l.config.CPUs != "" {
+		args = append(args, "--cpus", l.config.CPUs)
+	}
+	if l.config.Memory != "" {
+		args = append(args, "--memory", l.config.Memory)
+	}
+	// The values of the environment variables are passed through the
+	// environment of the docker client, rather than on the command line.
+	cmd := exec.CommandContext(ctx, "docker")
+	cmd.Env = os.Environ()
+	for _, kv := range env {
+		k, _, _ := strings.Cut(kv, "=")
+		args = append(args, "--env", k)
+		cmd.Env = append(cmd.Env, kv)
+	}
+	args = append(args, imageName(dep), path.Join(imageDir, "weaver"), "docker", "babysitter", tag)
+	cmd.Args = append(cmd.Args, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("docker run: %w: %s", err, out)
+	}
+	return nil
+}
+
+// Stop implements the sshimpl.Launcher interface.
+func (l *Launcher) Stop(ctx context.Context, _ string, tag string) error {
+	return removeContainers(ctx, tagLabel+"="+tag)
+}
+
+// StopAll implements the sshimpl.Launcher interface.
+func (l *Launcher) StopAll(ctx context.Context, _ string, dep *protos.Deployment) error {
+	return removeContainers(ctx, deploymentLabel+"="+dep.Id)
+}
+
+// Close implements the sshimpl.Launcher interface.
+func (l *Launcher) Close() error {
+	return nil
+}
+
+// removeContainers removes the containers with the provided label.
+func removeContainers(ctx context.Context, label string) error {
+	out, err := exec.CommandContext(ctx, "docker", "ps", "--quiet", "--filter", "label="+label).Output()
+	if err != nil {
+		return fmt.Errorf("docker ps: %w", err)
+	}
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return nil
+	}
+	args := append([]string{"rm", "--force"}, ids...)
+	if out, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("docker rm: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// imageName returns the name of the image of the provided deployment.
+func imageName(dep *protos.Deployment) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(dep.App.Name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	return fmt.Sprintf("weaver-%s:%s", strings.Trim(b.String(), "-"), dep.Id)
+}
+
+// copyFile copies the file src to dst, preserving its permissions.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
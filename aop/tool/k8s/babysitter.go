@@ -0,0 +1,26 @@
+package k8s
+
+import (
+	"context"
+
+	"greatestworks/aop/tool"
+	sshimpl "greatestworks/aop/tool/ssh/impl"
+)
+
+var babysitterCmd = tool.Command{
+	Name:        "babysitter",
+	Description: "The weaver k8s babysitter",
+	Help: `Usage:
+  weaver k8s babysitter
+
+Runs the babysitter of a colocation group replica within a Kubernetes pod.
+The babysitter pods are started by the controller.
+
+Flags:
+  -h, --help   Print this help message.`,
+	Fn: func(ctx context.Context, args []string) error {
+		// The k8s deployer reuses the babysitter of the SSH deployer.
+		return sshimpl.RunBabysitter(ctx)
+	},
+	Hidden: true,
+}
@@ -0,0 +1,25 @@
+package k8s
+
+import (
+	"context"
+
+	"greatestworks/aop/tool"
+	"greatestworks/aop/tool/k8s/impl"
+)
+
+var controllerCmd = tool.Command{
+	Name:        "controller",
+	Description: "The weaver k8s controller",
+	Help: `Usage:
+  weaver k8s controller
+
+Runs the controller of a deployment within a Kubernetes cluster. The
+controller is started by the manifests generated by "weaver k8s deploy".
+
+Flags:
+  -h, --help   Print this help message.`,
+	Fn: func(ctx context.Context, args []string) error {
+		return impl.RunController(ctx)
+	},
+	Hidden: true,
+}
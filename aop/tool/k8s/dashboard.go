@@ -0,0 +1,20 @@
+package k8s
+
+import (
+	"fmt"
+
+	"greatestworks/aop/status"
+	"greatestworks/aop/tool/k8s/impl"
+)
+
+var dashboardSpec = &status.DashboardSpec{
+	Tool:     "weaver k8s",
+	Registry: impl.DefaultRegistry,
+	Commands: func(deploymentId string) []status.Command {
+		return []status.Command{
+			{Label: "status", Command: "weaver k8s status"},
+			{Label: "logs", Command: fmt.Sprintf("kubectl logs -l serviceweaver/deployment=%s --all-containers --prefix", deploymentId)},
+			{Label: "pods", Command: fmt.Sprintf("kubectl get pods -l serviceweaver/deployment=%s", deploymentId)},
+		}
+	},
+}
@@ -0,0 +1,121 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/google/uuid"
+
+	"greatestworks/aop"
+	"greatestworks/aop/codegen"
+	"greatestworks/aop/protos"
+	"greatestworks/aop/status"
+	"greatestworks/aop/tool"
+	"greatestworks/aop/tool/k8s/impl"
+)
+
+var (
+	deployFlags  = flag.NewFlagSet("deploy", flag.ContinueOnError)
+	deployDryRun = deployFlags.Bool("dry-run", false, "Print the Kubernetes manifests instead of applying them")
+
+	deployCmd = tool.Command{
+		Name:        "deploy",
+		Description: "Deploy a Service Weaver app to Kubernetes",
+		Help: fmt.Sprintf(`Usage:
+  weaver k8s deploy [--dry-run] <configfile>
+
+Flags:
+  -h, --help	Print this help message.
+%s
+
+Translates the app config into Kubernetes manifests and applies them using
+kubectl. The manifests run a controller that starts the colocation groups of
+the app as pods, and a service that exposes the controller.
+
+The [k8s] section of the config file must specify the container image that
+contains the weaver and application binaries. For example:
+
+    [k8s]
+    image = "example.com/collatz:v1"
+    ports = [9000]`, tool.FlagsHelp(deployFlags)),
+		Flags: deployFlags,
+		Fn:    deploy,
+	}
+)
+
+// deploy deploys an application to a Kubernetes cluster.
+func deploy(ctx context.Context, args []string) error {
+	// Validate command line arguments.
+	if len(args) == 0 {
+		return fmt.Errorf("no config file provided")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments")
+	}
+
+	// Load the config file.
+	cfgFile := args[0]
+	cfg, err := os.ReadFile(cfgFile)
+	if err != nil {
+		return fmt.Errorf("load config file %q: %w", cfgFile, err)
+	}
+	app, err := aop.ParseConfig(cfgFile, string(cfg), codegen.ComponentConfigValidator)
+	if err != nil {
+		return fmt.Errorf("load config file %q: %w", cfgFile, err)
+	}
+	config, err := impl.ParseKubeConfig(app)
+	if err != nil {
+		return err
+	}
+
+	// Generate the manifests.
+	dep := &protos.Deployment{
+		Id:  uuid.New().String(),
+		App: app,
+	}
+	manifests, err := impl.Manifests(dep, config)
+	if err != nil {
+		return fmt.Errorf("generate manifests: %w", err)
+	}
+	out, err := json.MarshalIndent(manifests, "", "  ")
+	if err != nil {
+		return err
+	}
+	if *deployDryRun {
+		fmt.Println(string(out))
+		return nil
+	}
+
+	// Apply the manifests.
+	cmd := exec.CommandContext(ctx, "kubectl", "apply", "-f", "-")
+	cmd.Stdin = bytes.NewReader(out)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("kubectl apply: %w", err)
+	}
+
+	// Register the deployment, so that the dashboard can reach the status
+	// pages served by the controller.
+	registry, err := impl.DefaultRegistry(ctx)
+	if err != nil {
+		return fmt.Errorf("create registry: %w", err)
+	}
+	reg := status.Registration{
+		DeploymentId: dep.Id,
+		App:          app.Name,
+		Addr:         config.StatusAddress,
+	}
+	if err := registry.Register(ctx, reg); err != nil {
+		return fmt.Errorf("register deployment: %w", err)
+	}
+	fmt.Fprint(os.Stderr, reg.Rolodex())
+	fmt.Fprintf(os.Stderr, "The status pages are served at %s (e.g., kubectl port-forward -n %s service/%s %d)\n",
+		config.StatusAddress, config.Namespace, impl.ServiceName(app), impl.ControllerPort)
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"time"
+
+	"greatestworks/aop"
+	"greatestworks/aop/files"
+	"greatestworks/aop/protos"
+	"greatestworks/aop/status"
+	sshimpl "greatestworks/aop/tool/ssh/impl"
+)
+
+const (
+	// ControllerPort is the port on which the controller serves the manager
+	// endpoints used by the babysitters, and the status pages.
+	ControllerPort = 20000
+
+	// Names of the environment variables passed to the controller.
+	deploymentKey       = "WEAVER_K8S_DEPLOYMENT"
+	controllerConfigKey = "WEAVER_K8S_CONTROLLER"
+	podIPKey            = "WEAVER_K8S_POD_IP"
+
+	// Keys of the config map of a deployment.
+	deploymentConfigKey    = "deployment"
+	controllerConfigMapKey = "controller"
+
+	// Labels attached to the Kubernetes objects of a deployment.
+	appLabel        = "serviceweaver/app"
+	deploymentLabel = "serviceweaver/deployment"
+	roleLabel       = "serviceweaver/role"
+	tagLabel        = "serviceweaver/tag"
+
+	// defaultImageDir is the directory of the container image that contains
+	// the weaver and application binaries, unless specified otherwise.
+	defaultImageDir = "/weaver"
+)
+
+// KubeConfig is the k8s config as found in the TOML config file.
+type KubeConfig struct {
+	// Image is the container image that runs the controller and the
+	// babysitters. It must contain the weaver binary and the application
+	// binary.
+	Image string `toml:"image"`
+
+	// Namespace is the namespace in which the application is deployed.
+	// Defaults to "default".
+	Namespace string `toml:"namespace"`
+
+	// ToolPath is the path of the weaver binary in the image. Defaults to
+	// "/weaver/weaver".
+	ToolPath string `toml:"tool_path"`
+
+	// BinaryPath is the path of the application binary in the image.
+	// Defaults to "/weaver/<binary name>".
+	BinaryPath string `toml:"binary_path"`
+
+	// Ports are the ports of the listeners exported by the application. The
+	// controller proxies the traffic of every listener, and the service of
+	// the application exposes these ports.
+	Ports []int32 `toml:"ports"`
+
+	// ServiceType is the type of the service of the application (e.g.,
+	// "ClusterIP", "NodePort", or "LoadBalancer"). Defaults to "ClusterIP".
+	ServiceType string `toml:"service_type"`
+
+	// StatusAddress is the address at which the status pages of the
+	// controller can be reached from the machine running "weaver k8s"
+	// commands. Defaults to "localhost:20000", which works with:
+	//
+	//     kubectl port-forward service/<app> 20000
+	StatusAddress string `toml:"status_address"`
+
+	// MinReplicas and MaxReplicas bound the number of replicas of every
+	// colocation group. If MaxReplicas is not set, autoscaling is disabled
+	// and every colocation group runs a single replica.
+	MinReplicas int `toml:"min_replicas"`
+	MaxReplicas int `toml:"max_replicas"`
+
+	// TargetQPS is the number of method calls per second a single replica
+	// should handle.
+	TargetQPS float64 `toml:"target_qps"`
+
+	// TargetLatency is the maximum average method latency (e.g., "100ms").
+	TargetLatency string `toml:"target_latency"`
+}
+
+// Keys of the k8s section of the app config.
+const (
+	kubeKey      = "greatestworks/k8s"
+	shortKubeKey = "k8s"
+)
+
+func init() {
+	aop.RegisterConfigSection[KubeConfig](kubeKey, shortKubeKey)
+}
+
+// ParseKubeConfig parses the k8s section of the provided app config, and
+// fills in the default values.
+func ParseKubeConfig(app *protos.AppConfig) (*KubeConfig, error) {
+	c := &KubeConfig{}
+	if err := aop.ParseConfigSection(kubeKey, shortKubeKey, app.Sections, c); err != nil {
+		return nil, fmt.Errorf("unable to parse k8s config: %w", err)
+	}
+	if c.Image == "" {
+		return nil, fmt.Errorf("k8s config: no image provided")
+	}
+	if c.Namespace == "" {
+		c.Namespace = "default"
+	}
+	if c.ToolPath == "" {
+		c.ToolPath = path.Join(defaultImageDir, "weaver")
+	}
+	if c.BinaryPath == "" {
+		c.BinaryPath = path.Join(defaultImageDir, filepath.Base(app.Binary))
+	}
+	if c.ServiceType == "" {
+		c.ServiceType = "ClusterIP"
+	}
+	if c.StatusAddress == "" {
+		c.StatusAddress = fmt.Sprintf("localhost:%d", ControllerPort)
+	}
+	if c.MaxReplicas > 0 && c.MinReplicas > c.MaxReplicas {
+		return nil, fmt.Errorf("k8s config: min_replicas (%d) > max_replicas (%d)", c.MinReplicas, c.MaxReplicas)
+	}
+	if c.TargetLatency != "" {
+		if _, err := time.ParseDuration(c.TargetLatency); err != nil {
+			return nil, fmt.Errorf("k8s config: invalid target latency %q: %w", c.TargetLatency, err)
+		}
+	}
+	return c, nil
+}
+
+// managerOptions returns the options of the manager run by the controller.
+func (c *KubeConfig) managerOptions() sshimpl.ManagerOptions {
+	opts := sshimpl.ManagerOptions{
+		Autoscaler: sshimpl.AutoscalerOptions{
+			MinReplicas: c.MinReplicas,
+			MaxReplicas: c.MaxReplicas,
+			TargetQPS:   c.TargetQPS,
+		},
+		Port: ControllerPort,
+	}
+	// The latency has been validated by ParseKubeConfig.
+	opts.Autoscaler.TargetLatency, _ = time.ParseDuration(c.TargetLatency)
+	return opts
+}
+
+// DefaultRegistry returns the default registry in
+// $XDG_DATA_HOME/serviceweaver/k8s_registry, or
+// ~/.local/share/serviceweaver/k8s_registry if XDG_DATA_HOME is not set.
+func DefaultRegistry(ctx context.Context) (*status.Registry, error) {
+	dir, err := files.DefaultDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return status.NewRegistry(ctx, filepath.Join(dir, "k8s_registry"))
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"greatestworks/aop/logging"
+	"greatestworks/aop/proto"
+	"greatestworks/aop/protos"
+	sshimpl "greatestworks/aop/tool/ssh/impl"
+)
+
+// RunController runs the controller of a deployment within a Kubernetes
+// cluster, until the provided context is cancelled.
+//
+// The controller runs the same manager as the SSH deployer, with a launcher
+// that runs every colocation group replica in its own pod. The manager
+// serves the status pages of the deployment, so the dashboard works the same
+// way it does for the SSH deployer.
+func RunController(ctx context.Context) error {
+	dep := &protos.Deployment{}
+	if err := proto.FromEnv(os.Getenv(deploymentKey), dep); err != nil {
+		return fmt.Errorf("unable to retrieve deployment: %w", err)
+	}
+	config := &KubeConfig{}
+	if err := json.Unmarshal([]byte(os.Getenv(controllerConfigKey)), config); err != nil {
+		return fmt.Errorf("unable to retrieve controller config: %w", err)
+	}
+	client, err := newInClusterClient()
+	if err != nil {
+		return err
+	}
+
+	opts := config.managerOptions()
+	opts.Host = os.Getenv(podIPKey)
+	opts.Launcher = &podLauncher{client: client, config: config}
+	logDir := filepath.Join(logging.DefaultLogDir, "weaver_k8s")
+	stop, err := sshimpl.RunManager(ctx, dep, []string{config.Namespace}, logDir, opts)
+	if err != nil {
+		return fmt.Errorf("cannot instantiate the manager: %w", err)
+	}
+	<-ctx.Done()
+	return stop()
+}
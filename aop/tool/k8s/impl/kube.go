@@ -0,0 +1,285 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// This file contains the subset of the Kubernetes API used by the k8s
+// deployer: the objects the deployer creates, and a minimal client for the
+// Kubernetes API server that works from within a cluster.
+
+// ObjectMeta is the metadata of a Kubernetes object.
+type ObjectMeta struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// TypeMeta identifies the type of a Kubernetes object.
+type TypeMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// List is a list of Kubernetes objects, as accepted by "kubectl apply".
+type List struct {
+	TypeMeta
+	Items []any `json:"items"`
+}
+
+// ConfigMap holds configuration data.
+type ConfigMap struct {
+	TypeMeta
+	Metadata ObjectMeta        `json:"metadata"`
+	Data     map[string]string `json:"data"`
+}
+
+// ServiceAccount is the identity of the processes running in a pod.
+type ServiceAccount struct {
+	TypeMeta
+	Metadata ObjectMeta `json:"metadata"`
+}
+
+// Role is a set of permissions within a namespace.
+type Role struct {
+	TypeMeta
+	Metadata ObjectMeta   `json:"metadata"`
+	Rules    []PolicyRule `json:"rules"`
+}
+
+// PolicyRule grants permissions on a set of resources.
+type PolicyRule struct {
+	APIGroups []string `json:"apiGroups"`
+	Resources []string `json:"resources"`
+	Verbs     []string `json:"verbs"`
+}
+
+// RoleBinding grants the permissions of a role to a set of subjects.
+type RoleBinding struct {
+	TypeMeta
+	Metadata ObjectMeta `json:"metadata"`
+	Subjects []Subject  `json:"subjects"`
+	RoleRef  RoleRef    `json:"roleRef"`
+}
+
+// Subject is the subject of a role binding.
+type Subject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// RoleRef refers to a role.
+type RoleRef struct {
+	APIGroup string `json:"apiGroup"`
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+}
+
+// Deployment runs a set of replicated pods.
+type Deployment struct {
+	TypeMeta
+	Metadata ObjectMeta     `json:"metadata"`
+	Spec     DeploymentSpec `json:"spec"`
+}
+
+// DeploymentSpec specifies a deployment.
+type DeploymentSpec struct {
+	Replicas int32           `json:"replicas"`
+	Selector LabelSelector   `json:"selector"`
+	Template PodTemplateSpec `json:"template"`
+}
+
+// LabelSelector selects objects by label.
+type LabelSelector struct {
+	MatchLabels map[string]string `json:"matchLabels"`
+}
+
+// PodTemplateSpec is the template of the pods created by a deployment.
+type PodTemplateSpec struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     PodSpec    `json:"spec"`
+}
+
+// Pod is a set of containers running on a node.
+type Pod struct {
+	TypeMeta
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     PodSpec    `json:"spec"`
+}
+
+// PodSpec specifies a pod.
+type PodSpec struct {
+	ServiceAccountName string      `json:"serviceAccountName,omitempty"`
+	RestartPolicy      string      `json:"restartPolicy,omitempty"`
+	Containers         []Container `json:"containers"`
+}
+
+// Container specifies a container of a pod.
+type Container struct {
+	Name    string          `json:"name"`
+	Image   string          `json:"image"`
+	Command []string        `json:"command,omitempty"`
+	Env     []EnvVar        `json:"env,omitempty"`
+	Ports   []ContainerPort `json:"ports,omitempty"`
+}
+
+// EnvVar is an environment variable of a container.
+type EnvVar struct {
+	Name      string        `json:"name"`
+	Value     string        `json:"value,omitempty"`
+	ValueFrom *EnvVarSource `json:"valueFrom,omitempty"`
+}
+
+// EnvVarSource is the source of the value of an environment variable.
+type EnvVarSource struct {
+	FieldRef        *FieldSelector        `json:"fieldRef,omitempty"`
+	ConfigMapKeyRef *ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// FieldSelector selects a field of the pod.
+type FieldSelector struct {
+	FieldPath string `json:"fieldPath"`
+}
+
+// ConfigMapKeySelector selects a key of a config map.
+type ConfigMapKeySelector struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// ContainerPort is a port exposed by a container.
+type ContainerPort struct {
+	Name          string `json:"name,omitempty"`
+	ContainerPort int32  `json:"containerPort"`
+}
+
+// Service exposes a set of pods.
+type Service struct {
+	TypeMeta
+	Metadata ObjectMeta  `json:"metadata"`
+	Spec     ServiceSpec `json:"spec"`
+}
+
+// ServiceSpec specifies a service.
+type ServiceSpec struct {
+	Type     string            `json:"type,omitempty"`
+	Selector map[string]string `json:"selector"`
+	Ports    []ServicePort     `json:"ports"`
+}
+
+// ServicePort is a port exposed by a service.
+type ServicePort struct {
+	Name       string `json:"name"`
+	Port       int32  `json:"port"`
+	TargetPort int32  `json:"targetPort"`
+}
+
+const (
+	// Paths of the service account credentials mounted in every pod.
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	tokenFile         = serviceAccountDir + "/token"
+	caFile            = serviceAccountDir + "/ca.crt"
+)
+
+// kubeClient is a minimal client for the Kubernetes API server, for use
+// from within a cluster.
+type kubeClient struct {
+	base   string // API server URL
+	client *http.Client
+}
+
+// newInClusterClient returns a client for the API server of the cluster in
+// which the caller runs, authenticated as the service account of its pod.
+func newInClusterClient() (*kubeClient, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster")
+	}
+	ca, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("read cluster CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid cluster CA in %s", caFile)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &kubeClient{
+		base:   "https://" + net.JoinHostPort(host, port),
+		client: &http.Client{Transport: transport},
+	}, nil
+}
+
+// create creates the provided object in the collection at the provided API
+// path (e.g., "/api/v1/namespaces/default/pods").
+func (c *kubeClient) create(ctx context.Context, path string, obj any) error {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, path, body)
+}
+
+// deleteCollection deletes the objects in the collection at the provided API
+// path that match the provided label selector.
+func (c *kubeClient) deleteCollection(ctx context.Context, path string, selector map[string]string) error {
+	var terms []string
+	for k, v := range selector {
+		terms = append(terms, k+"="+v)
+	}
+	query := url.Values{"labelSelector": {strings.Join(terms, ",")}}
+	return c.do(ctx, http.MethodDelete, path+"?"+query.Encode(), nil)
+}
+
+// do issues a request to the API server.
+func (c *kubeClient) do(ctx context.Context, method, path string, body []byte) error {
+	// Service account tokens are rotated, so we re-read the token on every
+	// request.
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return fmt.Errorf("read service account token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, msg)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"greatestworks/aop/protos"
+	sshimpl "greatestworks/aop/tool/ssh/impl"
+)
+
+// podLauncher is an sshimpl.Launcher that runs every babysitter in its own
+// pod. The only location is the namespace in which the pods run.
+type podLauncher struct {
+	client *kubeClient
+	config *KubeConfig
+}
+
+var _ sshimpl.Launcher = &podLauncher{}
+
+// Prepare implements the sshimpl.Launcher interface.
+func (l *podLauncher) Prepare(context.Context, []string, *protos.Deployment) error {
+	// The binaries are already included in the image.
+	return nil
+}
+
+// Launch implements the sshimpl.Launcher interface.
+func (l *podLauncher) Launch(ctx context.Context, namespace string, dep *protos.Deployment, tag string, env []string) error {
+	var vars []EnvVar
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		vars = append(vars, EnvVar{Name: k, Value: v})
+	}
+	pod := &Pod{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		Metadata: ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", objectName(dep), shortId(tag)),
+			Namespace: namespace,
+			Labels: map[string]string{
+				appLabel:        dnsLabel(dep.App.Name),
+				deploymentLabel: dep.Id,
+				roleLabel:       "babysitter",
+				tagLabel:        tag,
+			},
+		},
+		Spec: PodSpec{
+			// The babysitter restarts failed weavelets, and the manager
+			// replaces babysitters that stop sending heartbeats.
+			RestartPolicy: "Always",
+			Containers: []Container{{
+				Name:    "babysitter",
+				Image:   l.config.Image,
+				Command: []string{l.config.ToolPath, "k8s", "babysitter"},
+				Env:     vars,
+			}},
+		},
+	}
+	return l.client.create(ctx, podsPath(namespace), pod)
+}
+
+// Stop implements the sshimpl.Launcher interface.
+func (l *podLauncher) Stop(ctx context.Context, namespace string, tag string) error {
+	return l.client.deleteCollection(ctx, podsPath(namespace), map[string]string{tagLabel: tag})
+}
+
+// StopAll implements the sshimpl.Launcher interface.
+func (l *podLauncher) StopAll(ctx context.Context, namespace string, dep *protos.Deployment) error {
+	return l.client.deleteCollection(ctx, podsPath(namespace), map[string]string{
+		deploymentLabel: dep.Id,
+		roleLabel:       "babysitter",
+	})
+}
+
+// Close implements the sshimpl.Launcher interface.
+func (l *podLauncher) Close() error {
+	return nil
+}
+
+// podsPath returns the API path of the pods in the provided namespace.
+func podsPath(namespace string) string {
+	return fmt.Sprintf("/api/v1/namespaces/%s/pods", namespace)
+}
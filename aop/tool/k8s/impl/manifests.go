@@ -0,0 +1,188 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"greatestworks/aop/proto"
+	"greatestworks/aop/protomsg"
+	"greatestworks/aop/protos"
+)
+
+// Manifests returns the Kubernetes objects that deploy the provided
+// deployment, as configured by the provided k8s config:
+//
+//   - A config map that holds the deployment and the k8s config.
+//   - A deployment that runs the controller. The controller runs the manager
+//     of the application, which starts the babysitters of the colocation
+//     groups as pods, and proxies the traffic of the application listeners.
+//   - A service that exposes the controller, i.e., the status pages and the
+//     application listeners.
+//   - A service account, role, and role binding that let the controller
+//     manage the babysitter pods.
+//
+// The returned list can be applied with "kubectl apply".
+func Manifests(dep *protos.Deployment, c *KubeConfig) (*List, error) {
+	// The babysitters run the application binary included in the image.
+	dep = protomsg.Clone(dep)
+	dep.App.Binary = c.BinaryPath
+	depEnv, err := proto.ToEnv(dep)
+	if err != nil {
+		return nil, err
+	}
+	config, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	name := objectName(dep)
+	labels := map[string]string{
+		appLabel:        dnsLabel(dep.App.Name),
+		deploymentLabel: dep.Id,
+	}
+	controllerLabels := map[string]string{
+		appLabel:        dnsLabel(dep.App.Name),
+		deploymentLabel: dep.Id,
+		roleLabel:       "controller",
+	}
+	meta := func(name string, labels map[string]string) ObjectMeta {
+		return ObjectMeta{Name: name, Namespace: c.Namespace, Labels: labels}
+	}
+	fromConfigMap := func(key string) *EnvVarSource {
+		return &EnvVarSource{ConfigMapKeyRef: &ConfigMapKeySelector{Name: name, Key: key}}
+	}
+
+	containerPorts := []ContainerPort{{Name: "controller", ContainerPort: ControllerPort}}
+	servicePorts := []ServicePort{{Name: "controller", Port: ControllerPort, TargetPort: ControllerPort}}
+	for _, port := range c.Ports {
+		portName := fmt.Sprintf("listener-%d", port)
+		containerPorts = append(containerPorts, ContainerPort{Name: portName, ContainerPort: port})
+		servicePorts = append(servicePorts, ServicePort{Name: portName, Port: port, TargetPort: port})
+	}
+
+	return &List{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "List"},
+		Items: []any{
+			&ServiceAccount{
+				TypeMeta: TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+				Metadata: meta(name, labels),
+			},
+			&Role{
+				TypeMeta: TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+				Metadata: meta(name, labels),
+				Rules: []PolicyRule{{
+					APIGroups: []string{""},
+					Resources: []string{"pods"},
+					Verbs:     []string{"create", "delete", "deletecollection", "get", "list"},
+				}},
+			},
+			&RoleBinding{
+				TypeMeta: TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+				Metadata: meta(name, labels),
+				Subjects: []Subject{{Kind: "ServiceAccount", Name: name, Namespace: c.Namespace}},
+				RoleRef:  RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: name},
+			},
+			&ConfigMap{
+				TypeMeta: TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				Metadata: meta(name, labels),
+				Data: map[string]string{
+					deploymentConfigKey:    depEnv,
+					controllerConfigMapKey: string(config),
+				},
+			},
+			&Deployment{
+				TypeMeta: TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				Metadata: meta(name+"-controller", controllerLabels),
+				Spec: DeploymentSpec{
+					Replicas: 1,
+					Selector: LabelSelector{MatchLabels: controllerLabels},
+					Template: PodTemplateSpec{
+						Metadata: ObjectMeta{Labels: controllerLabels},
+						Spec: PodSpec{
+							ServiceAccountName: name,
+							Containers: []Container{{
+								Name:    "controller",
+								Image:   c.Image,
+								Command: []string{c.ToolPath, "k8s", "controller"},
+								Env: []EnvVar{
+									{Name: deploymentKey, ValueFrom: fromConfigMap(deploymentConfigKey)},
+									{Name: controllerConfigKey, ValueFrom: fromConfigMap(controllerConfigMapKey)},
+									{Name: podIPKey, ValueFrom: &EnvVarSource{FieldRef: &FieldSelector{FieldPath: "status.podIP"}}},
+								},
+								Ports: containerPorts,
+							}},
+						},
+					},
+				},
+			},
+			&Service{
+				TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Service"},
+				Metadata: meta(ServiceName(dep.App), labels),
+				Spec: ServiceSpec{
+					Type:     c.ServiceType,
+					Selector: controllerLabels,
+					Ports:    servicePorts,
+				},
+			},
+		},
+	}, nil
+}
+
+// ServiceName returns the name of the service that exposes the controller of
+// the provided app.
+func ServiceName(app *protos.AppConfig) string {
+	return dnsLabel(app.Name)
+}
+
+// objectName returns the name of the Kubernetes objects of the provided
+// deployment.
+func objectName(dep *protos.Deployment) string {
+	return dnsLabel(fmt.Sprintf("%s-%s", dep.App.Name, shortId(dep.Id)))
+}
+
+// shortId returns a short prefix of the provided id.
+func shortId(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// dnsLabel converts the provided string into a valid DNS label (RFC 1123), as
+// required for the names of most Kubernetes objects.
+func dnsLabel(s string) string {
+	const maxLen = 63
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	label := b.String()
+	if len(label) > maxLen {
+		label = label[:maxLen]
+	}
+	label = strings.Trim(label, "-")
+	if label == "" {
+		return "weaver"
+	}
+	return label
+}
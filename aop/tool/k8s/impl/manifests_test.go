@@ -0,0 +1,83 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"testing"
+
+	"golang.org/x/exp/slices"
+	"greatestworks/aop/protos"
+)
+
+func TestDNSLabel(t *testing.T) {
+	for _, test := range []struct{ in, want string }{
+		{"collatz", "collatz"},
+		{"Collatz_App", "collatz-app"},
+		{"-main.go-", "main-go"},
+		{"", "weaver"},
+	} {
+		if got := dnsLabel(test.in); got != test.want {
+			t.Errorf("dnsLabel(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestManifests(t *testing.T) {
+	dep := &protos.Deployment{
+		Id:  "eba18295-0000-0000-0000-000000000000",
+		App: &protos.AppConfig{Name: "collatz", Binary: "/home/alice/collatz"},
+	}
+	config := &KubeConfig{
+		Image:       "example.com/collatz:v1",
+		Namespace:   "games",
+		ToolPath:    "/weaver/weaver",
+		BinaryPath:  "/weaver/collatz",
+		Ports:       []int32{9000},
+		ServiceType: "ClusterIP",
+	}
+	list, err := Manifests(dep, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dep.App.Binary != "/home/alice/collatz" {
+		t.Errorf("Manifests modified the deployment")
+	}
+
+	var service *Service
+	var controller *Deployment
+	for _, item := range list.Items {
+		switch x := item.(type) {
+		case *Service:
+			service = x
+		case *Deployment:
+			controller = x
+		}
+	}
+	if service == nil || controller == nil {
+		t.Fatalf("missing service or controller deployment in %v", list.Items)
+	}
+	if got, want := service.Metadata.Name, "collatz"; got != want {
+		t.Errorf("service name: got %q, want %q", got, want)
+	}
+	if got, want := len(service.Spec.Ports), 2; got != want {
+		t.Errorf("service ports: got %d, want %d", got, want)
+	}
+	if got, want := controller.Metadata.Name, "collatz-eba18295-controller"; got != want {
+		t.Errorf("controller name: got %q, want %q", got, want)
+	}
+	if got, want := controller.Spec.Template.Spec.Containers[0].Command, []string{"/weaver/weaver", "k8s", "controller"}; !slices.Equal(got, want) {
+		t.Errorf("controller command: got %v, want %v", got, want)
+	}
+}
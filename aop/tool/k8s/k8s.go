@@ -0,0 +1,17 @@
+package k8s
+
+import (
+	"greatestworks/aop/status"
+	"greatestworks/aop/tool"
+	"greatestworks/aop/tool/k8s/impl"
+)
+
+var Commands = map[string]*tool.Command{
+	"deploy":    &deployCmd,
+	"dashboard": status.DashboardCommand(dashboardSpec),
+	"status":    status.StatusCommand("weaver k8s", impl.DefaultRegistry),
+
+	// Hidden commands.
+	"controller": &controllerCmd,
+	"babysitter": &babysitterCmd,
+}
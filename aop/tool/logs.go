@@ -2,17 +2,18 @@ package tool
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 	"text/template"
 	"time"
 
 	"greatestworks/aop/colors"
 	"greatestworks/aop/logging"
+	"greatestworks/aop/protos"
 )
 
 // LogSpec configures the command returned by LogsCmd.
@@ -26,24 +27,10 @@ type LogsSpec struct {
 	follow bool
 	format string
 	system bool
-}
-
-// fullEntry is like runtime.LogEntry, but has all the fields present in the
-// query language. Some entries, like full_version and full_node, are not
-// present in a runtime.LogEntry; they are derived fields.
-type fullEntry struct {
-	App           string `json:"app"`
-	Version       string `json:"version"`
-	FullVersion   string `json:"full_version"`
-	Component     string `json:"component"`
-	FullComponent string `json:"full_component"`
-	Node          string `json:"node"`
-	FullNode      string `json:"full_node"`
-	Time          string `json:"time"`
-	Level         string `json:"level"`
-	File          string `json:"file"`
-	Line          int32  `json:"line"`
-	Msg           string `json:"msg"`
+	since  string
+	until  string
+	limit  int
+	cursor string
 }
 
 // LogsCmd returns a command to query log entries.
@@ -53,10 +40,15 @@ func LogsCmd(spec *LogsSpec) *Command {
 		spec.Flags = flag.NewFlagSet("logs", flag.ContinueOnError)
 	}
 	spec.Flags.BoolVar(&spec.follow, "follow", false, "Act like tail -f")
-	spec.Flags.StringVar(&spec.format, "format", "pretty", "Output format (pretty or json)")
+	spec.Flags.StringVar(&spec.format, "format", logging.PrettyFormat, "Output format (pretty or json)")
 	spec.Flags.BoolVar(&spec.system, "system", false, "Show system internal logs")
+	spec.Flags.StringVar(&spec.since, "since", "", "Only show logs since a timestamp (e.g., 2022-01-01T00:00:00Z) or a duration ago (e.g., 1h)")
+	spec.Flags.StringVar(&spec.until, "until", "", "Only show logs until a timestamp or a duration ago")
+	spec.Flags.IntVar(&spec.limit, "limit", 0, "Show at most this many logs (0 for no limit)")
+	spec.Flags.StringVar(&spec.cursor, "cursor", "", "Show the page of logs at this cursor, as printed by a previous --limit query")
 	const help = `Usage:
-  {{.Tool}} logs [--follow] [--format=<format>] [--system] [query]
+  {{.Tool}} logs [--follow] [--format=<format>] [--system] [--since=<time>]
+    [--until=<time>] [--limit=<n>] [--cursor=<cursor>] [query]
 
 Flags:
   -h, --help	Print this help message.
@@ -116,6 +108,21 @@ Examples:
   # the following command.
   date --rfc-3339=s --date="3 hours ago" | tr ' ' 'T'
 
+  # Display all of the logs for the "todo" app that were logged in the last 3
+  # hours. The following commands are equivalent.
+  {{.Tool}} logs 'app=="todo" && time >= now - duration("3h")'
+  {{.Tool}} logs --since=3h 'app=="todo"'
+
+  # Display all of the logs that were logged between 1h and 30m ago.
+  {{.Tool}} logs --since=1h --until=30m
+
+  # Display the first 100 logs for the "todo" app. The command prints a cursor
+  # to display the next 100 logs.
+  {{.Tool}} logs --limit=100 'app=="todo"'
+
+  # Display the next 100 logs for the "todo" app, using the printed cursor.
+  {{.Tool}} logs --limit=100 --cursor=<cursor> 'app=="todo"'
+
   # Display all of the debug logs for the "todo" app.
   {{.Tool}} logs 'app=="todo" && level=="debug"'
 
@@ -157,9 +164,9 @@ Examples:
   # Display all of the logs that don't have a "foo" attribute.
   {{.Tool}} logs '!("foo" in attrs)'
 
-  # Display all of the logs in JSON format. This is useful if you want to
-  # perform some sort of post-processing on the logs.
-  {{.Tool}} logs --format=json
+  # Display all of the logs in JSON format, one JSON object per line. This is
+  # useful if you want to perform some sort of post-processing on the logs.
+  {{.Tool}} logs --format=json | jq -r 'select(.attrs.player == "alice") | .msg'
 
   # Display all of the logs, including internal system logs that are hidden by
   # default.
@@ -192,7 +199,10 @@ Query Reference:
       * equalities and inequalities (==, !=, <, <=, >, >=),
       * the string operations "contains" and "matches",
       * map indexing (attrs["foo"]), and
-      * constant strings, timestamps, and ints.
+      * constant strings, timestamps, durations, and ints.
+
+  Constant timestamps can be absolute, like timestamp("2022-01-01T00:00:00Z"),
+  or relative to the time the query is executed, like now - duration("1h").
 
   Queries have the same semantics as CEL programs except for one small
   exception. An attribute expression like attrs["foo"] has an implicit
@@ -229,13 +239,19 @@ func (s *LogsSpec) logFn(ctx context.Context, args []string) error {
 	} else {
 		query = args[0]
 	}
-	if s.format != "pretty" && s.format != "json" {
-		return fmt.Errorf("invalid format %q; must be %q or %q", s.format, "pretty", "json")
+	formatter, err := logging.NewFormatter(s.format, colors.Enabled())
+	if err != nil {
+		return err
+	}
+	if s.limit < 0 {
+		return fmt.Errorf("invalid limit %d; must be non-negative", s.limit)
+	}
+	if s.follow && s.cursor != "" {
+		return fmt.Errorf("--cursor cannot be used with --follow")
 	}
 
 	// Rewrite the query, if needed.
 	if s.Rewrite != nil {
-		var err error
 		query, err = s.Rewrite(query)
 		if err != nil {
 			return err
@@ -247,49 +263,81 @@ func (s *LogsSpec) logFn(ctx context.Context, args []string) error {
 		query += ` && !("serviceweaver/system" in attrs)`
 	}
 
+	// Restrict the time range.
+	if s.since != "" {
+		t, err := timeLiteral(s.since)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		query += " && time >= " + t
+	}
+	if s.until != "" {
+		t, err := timeLiteral(s.until)
+		if err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+		query += " && time <= " + t
+	}
+
 	// Construct the reader.
 	source, err := s.Source(ctx)
 	if err != nil {
 		return err
 	}
+
+	if !s.follow && (s.limit > 0 || s.cursor != "") {
+		// Show a page of logs.
+		entries, next, err := logging.ReadPage(ctx, source, query, logging.Cursor(s.cursor), s.limit)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := s.print(formatter, entry); err != nil {
+				return err
+			}
+		}
+		if next != "" {
+			fmt.Fprintf(os.Stderr, "To show the next page of logs, pass --cursor=%s\n", next)
+		}
+		return nil
+	}
+
 	r, err := source.Query(ctx, query, s.follow)
 	if err != nil {
 		return err
 	}
+	defer r.Close()
 
 	// Cat or follow the logs.
-	pp := logging.NewPrettyPrinter(colors.Enabled())
-	for {
+	for n := 0; s.limit == 0 || n < s.limit; n++ {
 		entry, err := r.Read(ctx)
 		if errors.Is(err, io.EOF) {
 			return nil
 		} else if err != nil {
 			return err
 		}
-		switch s.format {
-		case "pretty":
-			fmt.Println(pp.Format(entry))
-		case "json":
-			bytes, err := json.MarshalIndent(fullEntry{
-				App:           entry.App,
-				Version:       logging.Shorten(entry.Version),
-				FullVersion:   entry.Version,
-				Component:     logging.ShortenComponent(entry.Component),
-				FullComponent: entry.Component,
-				Node:          logging.Shorten(entry.Node),
-				FullNode:      entry.Node,
-				Time:          time.UnixMicro(entry.TimeMicros).Format(time.RFC3339Nano),
-				Level:         entry.Level,
-				File:          entry.File,
-				Line:          entry.Line,
-				Msg:           entry.Msg,
-			}, "", "    ")
-			if err != nil {
-				return err
-			}
-			fmt.Println(string(bytes))
-		default:
-			panic(fmt.Sprintf("unexpected format %q", s.format))
+		if err := s.print(formatter, entry); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// print prints a log entry in the format specified by the --format flag.
+func (s *LogsSpec) print(f logging.Formatter, entry *protos.LogEntry) error {
+	_, err := fmt.Println(f.Format(entry))
+	return err
+}
+
+// timeLiteral returns the query literal of the provided --since or --until
+// flag value, which is either an RFC 3339 timestamp, or a duration ago (e.g.,
+// "1h").
+func timeLiteral(value string) (string, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return fmt.Sprintf("now - duration(%q)", d.String()), nil
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return "", fmt.Errorf("%q is neither a timestamp nor a duration", value)
+	}
+	return fmt.Sprintf("timestamp(%q)", value), nil
 }
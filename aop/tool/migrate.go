@@ -0,0 +1,172 @@
+package tool
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"greatestworks/aop/migrate"
+)
+
+// MigrateSpec configures the command returned by MigrateCmd.
+type MigrateSpec struct {
+	Tool  string        // tool name, e.g., "weaver multi"
+	Flags *flag.FlagSet // optional additional flags, e.g., the addresses of the databases
+
+	// Sets returns the migrations of the databases, once the flags are
+	// parsed.
+	Sets func(context.Context) ([]*migrate.Set, error)
+
+	// Flags.
+	to     int64
+	dryRun bool
+	db     string
+}
+
+// MigrateCmd returns a command to apply the migrations of the schemas of
+// databases, or to revert them.
+func MigrateCmd(spec *MigrateSpec) *Command {
+	if spec.Flags == nil {
+		spec.Flags = flag.NewFlagSet("migrate", flag.ContinueOnError)
+	}
+	spec.Flags.Int64Var(&spec.to, "to", migrate.Latest, "Version to migrate to (-1 for the latest)")
+	spec.Flags.BoolVar(&spec.dryRun, "dry-run", false, "Print the migrations without applying them")
+	spec.Flags.StringVar(&spec.db, "db", "", "Only migrate the database of this name (e.g., mongo/greatest-work)")
+	const help = `Usage:
+  {{.Tool}} migrate [--db=<name>] status
+  {{.Tool}} migrate [--to=<version>] [--dry-run] [--db=<name>] up
+  {{.Tool}} migrate --to=<version> [--dry-run] [--db=<name>] down
+  {{.Tool}} migrate --db=<name> unlock
+
+Flags:
+  -h, --help	Print this help message.
+{{.Flags}}
+
+Description:
+  "status" prints the migrations applied to the databases, and those pending.
+  "up" applies the migrations pending, up to --to. "down" reverts the
+  migrations applied beyond --to, by decreasing version; it fails if one of
+  them can't be reverted. The versions applied are recorded in the databases,
+  which are locked while migrated; "unlock" removes the lock of a migration
+  that crashed.
+
+  The servers refuse to start on a database lacking migrations they expect:
+  migrate it before deploying them.
+
+Examples:
+  # Print the status of the databases.
+  {{.Tool}} migrate status
+
+  # Print the migrations to apply, then apply them.
+  {{.Tool}} migrate --dry-run up
+  {{.Tool}} migrate up
+
+  # Revert the migrations of a database beyond version 3.
+  {{.Tool}} migrate --to=3 --db=mongo/greatest-work down`
+	var b strings.Builder
+	t := template.Must(template.New(spec.Tool).Parse(help))
+	content := struct{ Tool, Flags string }{spec.Tool, FlagsHelp(spec.Flags)}
+	if err := t.Execute(&b, content); err != nil {
+		panic(err)
+	}
+
+	return &Command{
+		Name:        "migrate",
+		Description: "Migrate the schemas of the databases",
+		Help:        b.String(),
+		Flags:       spec.Flags,
+		Fn:          spec.migrate,
+	}
+}
+
+func (s *MigrateSpec) migrate(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s migrate status|up|down|unlock", s.Tool)
+	}
+	action := args[0]
+	switch action {
+	case "status", "up", "down", "unlock":
+	default:
+		return fmt.Errorf("migrate: unknown action %q", action)
+	}
+	if action == "down" && s.to == migrate.Latest {
+		return fmt.Errorf("migrate: down needs --to")
+	}
+	if action == "unlock" && s.db == "" {
+		return fmt.Errorf("migrate: unlock needs --db")
+	}
+
+	sets, err := s.Sets(ctx)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, set := range sets {
+		if s.db != "" && set.Name != s.db {
+			continue
+		}
+		found = true
+		switch action {
+		case "status":
+			err = s.status(ctx, set)
+		case "up", "down":
+			err = s.apply(ctx, set, action == "down")
+		case "unlock":
+			err = set.Store.Unlock(ctx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("migrate: database %q not found", s.db)
+	}
+	return nil
+}
+
+func (s *MigrateSpec) status(ctx context.Context, set *migrate.Set) error {
+	applied, pending, err := set.Status(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d applied, %d pending\n", set.Name, len(applied), len(pending))
+	for _, r := range applied {
+		fmt.Printf("  applied %4d %-40s %s\n", r.Version, r.Description, r.AppliedAt.Format("2006-01-02 15:04:05"))
+	}
+	for _, m := range pending {
+		fmt.Printf("  pending %4d %s\n", m.Version, m.Description)
+	}
+	return nil
+}
+
+func (s *MigrateSpec) apply(ctx context.Context, set *migrate.Set, down bool) error {
+	steps, err := set.Plan(ctx, s.to)
+	if err != nil {
+		return err
+	}
+	// up never reverts, and down never applies: the target must be on the
+	// side of the action.
+	for _, step := range steps {
+		if step.Down != down {
+			return fmt.Errorf("migrate: %s: --to=%d would %v; use %q", set.Name, s.to, step, map[bool]string{true: "down", false: "up"}[step.Down])
+		}
+	}
+	if len(steps) == 0 {
+		fmt.Printf("%s: up to date\n", set.Name)
+		return nil
+	}
+	if s.dryRun {
+		for _, step := range steps {
+			fmt.Printf("%s: would %v\n", set.Name, step)
+		}
+		return nil
+	}
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s (%s, pid %d)", s.Tool, host, os.Getpid())
+	return set.Apply(ctx, owner, steps, func(step migrate.Step) {
+		fmt.Printf("%s: %v\n", set.Name, step)
+	})
+}
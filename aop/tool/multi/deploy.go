@@ -56,6 +56,18 @@ func deploy(ctx context.Context, args []string) error {
 		return fmt.Errorf("load config file %q: %w\n", cfgFile, err)
 	}
 
+	// Snapshot the config with its includes and environment variables
+	// resolved, so it can be re-deployed on its own.
+	resolved, err := aop.ResolveConfig(cfgFile, string(cfg))
+	if err != nil {
+		return fmt.Errorf("load config file %q: %w\n", cfgFile, err)
+	}
+	return deployApp(ctx, app, []byte(resolved))
+}
+
+// deployApp deploys the provided application, whose config file has the
+// provided contents, and follows its logs until the user kills it.
+func deployApp(ctx context.Context, app *protos.AppConfig, cfg []byte) error {
 	// Sanity check the config.
 	if _, err := os.Stat(app.Binary); errors.Is(err, os.ErrNotExist) {
 		return fmt.Errorf("binary %q doesn't exist", app.Binary)
@@ -102,7 +114,7 @@ func deploy(ctx context.Context, args []string) error {
 
 	// Wait for the status server to become active.
 	client := status.NewClient(lis.Addr().String())
-	for r := retry.Begin(); r.Continue(ctx); {
+	for r := retry.BeginWithOptions(status.RetryOptions); r.Continue(ctx); {
 		_, err := client.Status(ctx)
 		if err == nil {
 			break
@@ -124,6 +136,15 @@ func deploy(ctx context.Context, args []string) error {
 	if err := registry.Register(ctx, reg); err != nil {
 		return fmt.Errorf("register deployment: %w", err)
 	}
+	if err := b.Events().Attach(ctx, registry); err != nil {
+		fmt.Fprintf(os.Stderr, "record deployment events: %v\n", err)
+	}
+
+	// Snapshot the binary and config, so the deployment can be rolled back
+	// to later.
+	if err := registry.Snapshot(ctx, dep.Id, app.Binary, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "snapshot deployment: %v\n", err)
+	}
 
 	// Wait for the user to kill the app.
 	done := make(chan os.Signal, 1)
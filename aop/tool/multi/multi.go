@@ -25,10 +25,14 @@ var (
 				{Label: "profile", Command: fmt.Sprintf("weaver multi profile --duration=30s %s", deploymentId)},
 			}
 		},
+		Logs: func(context.Context) (logging.Source, error) {
+			return logging.FileSource(logdir), nil
+		},
 	}
 
 	Commands = map[string]*tool.Command{
-		"deploy": &deployCmd,
+		"deploy":   &deployCmd,
+		"rollback": &rollbackCmd,
 		"logs": tool.LogsCmd(&tool.LogsSpec{
 			Tool: "weaver multi",
 			Source: func(context.Context) (logging.Source, error) {
@@ -37,6 +41,7 @@ var (
 		}),
 		"dashboard": status.DashboardCommand(dashboardSpec),
 		"status":    status.StatusCommand("weaver multi", defaultRegistry),
+		"history":   status.HistoryCommand("weaver multi", defaultRegistry),
 		"metrics":   status.MetricsCommand("weaver multi", defaultRegistry),
 		"profile":   status.ProfileCommand("weaver multi", defaultRegistry),
 	}
@@ -0,0 +1,64 @@
+package multi
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"greatestworks/aop"
+	"greatestworks/aop/codegen"
+	"greatestworks/aop/tool"
+)
+
+var rollbackCmd = tool.Command{
+	Name:        "rollback",
+	Description: "Re-deploy a previous version of a Service Weaver app",
+	Help: `Usage:
+  weaver multi rollback <app> <version>
+
+Flags:
+  -h, --help	Print this help message.
+
+Re-deploys the binary and config of a previous deployment of the app, as
+recorded by "weaver multi deploy". <version> is the deployment id, or a prefix
+of it, of the deployment to roll back to. Use "weaver multi history <app>" to
+list the previous deployments.`,
+	Flags: flag.NewFlagSet("rollback", flag.ContinueOnError),
+	Fn:    rollback,
+}
+
+// rollback re-deploys a previous deployment of an application.
+func rollback(ctx context.Context, args []string) error {
+	// Validate command line arguments.
+	if len(args) != 2 {
+		return fmt.Errorf("usage: weaver multi rollback <app> <version>")
+	}
+	appName, version := args[0], args[1]
+
+	// Find the snapshot of the deployment.
+	registry, err := defaultRegistry(ctx)
+	if err != nil {
+		return fmt.Errorf("create registry: %w", err)
+	}
+	entry, err := registry.FindHistory(ctx, appName, version)
+	if err != nil {
+		return err
+	}
+	if !entry.Snapshotted() {
+		return fmt.Errorf("deployment %q has no snapshot to roll back to", entry.DeploymentId)
+	}
+
+	// Load the snapshotted config, and point it at the snapshotted binary.
+	cfg, err := os.ReadFile(entry.Config)
+	if err != nil {
+		return fmt.Errorf("load config snapshot: %w", err)
+	}
+	app, err := aop.ParseConfig(entry.Config, string(cfg), codegen.ComponentConfigValidator)
+	if err != nil {
+		return fmt.Errorf("load config snapshot: %w", err)
+	}
+	app.Binary = entry.Binary
+	fmt.Fprintf(os.Stderr, "Rolling back %s to deployment %s\n", appName, entry.DeploymentId)
+	return deployApp(ctx, app, cfg)
+}
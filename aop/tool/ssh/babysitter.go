@@ -11,7 +11,10 @@ var babysitterCmd = tool.Command{
 	Name:        "babysitter",
 	Description: "The weaver ssh babysitter",
 	Help: `Usage:
-  weaver ssh babysitter
+  weaver ssh babysitter [tag]
+
+The optional tag is ignored by the babysitter. The manager uses it to identify
+the babysitter process of a given replica.
 
 Flags:
   -h, --help   Print this help message.`,
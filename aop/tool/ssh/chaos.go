@@ -0,0 +1,102 @@
+package ssh
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"greatestworks/aop/logging"
+	"greatestworks/aop/tool"
+	"greatestworks/aop/tool/ssh/impl"
+)
+
+var (
+	chaosFlags = flag.NewFlagSet("chaos", flag.ContinueOnError)
+	chaosDep   = chaosFlags.String("deployment", "", "Only update the deployment with the provided id (prefix)")
+
+	chaosCmd = tool.Command{
+		Name:        "chaos",
+		Description: "Enable or disable fault injection in running deployments",
+		Help: fmt.Sprintf(`Usage:
+  weaver ssh chaos [--deployment=<id>] <on|off>
+
+Flags:
+  -h, --help	Print this help message.
+%s
+
+Enables or disables the faults specified in the chaos section of the config of
+the running deployments, without restarting them. The faults delay or fail the
+calls to selected components, and kill replicas of selected colocation groups
+on a schedule. For example:
+
+  [chaos]
+  [[chaos.faults]]
+  component = "app.Cache"
+  latency = "200ms"
+  error_rate = 0.1
+  every = "10m"
+  for = "2m"
+
+  [[chaos.kills]]
+  group = "cache"
+  every = "5m"
+
+Examples:
+  # Start injecting faults into a deployment.
+  weaver ssh chaos --deployment=1234abcd on
+
+  # Stop injecting faults into all deployments.
+  weaver ssh chaos off`, tool.FlagsHelp(chaosFlags)),
+		Flags: chaosFlags,
+		Fn:    setChaos,
+	}
+)
+
+// setChaos enables or disables fault injection in all the running SSH
+// deployments, or in the deployment specified by the --deployment flag.
+func setChaos(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no on or off provided")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments")
+	}
+	req := &impl.SetChaosRequest{}
+	switch args[0] {
+	case "on":
+		req.Enabled = true
+	case "off":
+		req.Enabled = false
+	default:
+		return fmt.Errorf("got %q, want on or off", args[0])
+	}
+
+	registry, err := impl.DefaultRegistry(ctx)
+	if err != nil {
+		return fmt.Errorf("create registry: %w", err)
+	}
+	regs, err := registry.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list deployments: %w", err)
+	}
+	var updated int
+	for _, reg := range regs {
+		if !strings.HasPrefix(reg.DeploymentId, *chaosDep) {
+			continue
+		}
+		reply, err := impl.SetChaos(ctx, reg.Addr, req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to set chaos in deployment %s: %v\n", logging.Shorten(reg.DeploymentId), err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Turned chaos %s in deployment %s (generation %d)\n",
+			args[0], logging.Shorten(reg.DeploymentId), reply.Generation)
+		updated++
+	}
+	if updated == 0 {
+		return fmt.Errorf("chaos not set in any deployment")
+	}
+	return nil
+}
@@ -15,6 +15,9 @@ var dashboardSpec = &status.DashboardSpec{
 		return []status.Command{
 			{Label: "cat logs", Command: fmt.Sprintf("weaver ssh logs 'version==%q'", logging.Shorten(deploymentId))},
 			{Label: "follow logs", Command: fmt.Sprintf("weaver ssh logs --follow 'version==%q'", logging.Shorten(deploymentId))},
+			{Label: "debug a component", Command: fmt.Sprintf("weaver ssh set-log-level --deployment=%s <component> debug", logging.Shorten(deploymentId))},
+			{Label: "reset log levels", Command: fmt.Sprintf("weaver ssh set-log-level --deployment=%s default ''", logging.Shorten(deploymentId))},
 		}
 	},
+	Logs: logsSpec.Source,
 }
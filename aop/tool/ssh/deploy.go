@@ -9,28 +9,430 @@ import (
 	"greatestworks/aop"
 	"io"
 	"os"
-	"os/exec"
 	"os/signal"
 	"os/user"
 	"path/filepath"
 	"syscall"
+	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"greatestworks/aop/codegen"
 	"greatestworks/aop/colors"
 	"greatestworks/aop/logging"
+	"greatestworks/aop/protomsg"
 	"greatestworks/aop/protos"
+	"greatestworks/aop/proxy"
+	"greatestworks/aop/status"
 	"greatestworks/aop/tool"
 	"greatestworks/aop/tool/ssh/impl"
+	"greatestworks/aop/traceio/otlp"
 )
 
 var deployCmd = tool.Command{
 	Name:        "deploy",
 	Description: "Deploy a Service Weaver app",
-	Help:        "Usage:\n  weaver ssh deploy <configfile>",
-	Flags:       flag.NewFlagSet("deploy", flag.ContinueOnError),
-	Fn:          deploy,
+	Help: `Usage:
+  weaver ssh deploy <configfile>
+
+If the app is already deployed using the SSH deployer, the running deployment
+is upgraded to the new version using a rolling update.`,
+	Flags: flag.NewFlagSet("deploy", flag.ContinueOnError),
+	Fn:    deploy,
+}
+
+// sshConfig is the SSH config as found in the TOML config file.
+type sshConfig struct {
+	// LocationsFile is a file containing the list of locations to deploy.
+	LocationsFile string `toml:"locations_file"`
+
+	// RolloutSteps is the number of steps in which traffic is shifted to a
+	// new application version during a rolling update.
+	RolloutSteps int `toml:"rollout_steps"`
+
+	// RolloutInterval is the time to wait between two consecutive steps of a
+	// rolling update (e.g., "30s").
+	RolloutInterval string `toml:"rollout_interval"`
+
+	// MinReplicas and MaxReplicas bound the number of replicas of every
+	// colocation group. If MaxReplicas is not set, autoscaling is disabled
+	// and every colocation group runs one replica per location.
+	MinReplicas int `toml:"min_replicas"`
+	MaxReplicas int `toml:"max_replicas"`
+
+	// TargetQPS is the number of method calls per second a single replica
+	// should handle.
+	TargetQPS float64 `toml:"target_qps"`
+
+	// TargetLatency is the maximum average method latency (e.g., "100ms").
+	TargetLatency string `toml:"target_latency"`
+
+	// Affinity configures the session affinity of the proxies of the
+	// exported listeners, by listener name. For example:
+	//
+	//     [ssh.affinity.lobby]
+	//     header = "X-Player-Id"
+	//     cookie = "lobby_session"
+	//     max_age = "1h"
+	Affinity map[string]affinityConfig `toml:"affinity"`
+
+	// Assignment is the strategy used to assign the slices of a routed
+	// component to replicas ("round_robin" or "consistent_hash"), by
+	// component name. For example:
+	//
+	//     [ssh.assignment]
+	//     "github.com/my/game/Lobby" = "consistent_hash"
+	Assignment map[string]string `toml:"assignment"`
+
+	// Capacity is the relative capacity of every location (e.g., its number
+	// of cores), by location. The slices of routed components are assigned
+	// to replicas proportionally to the capacity of their locations.
+	// Locations without a declared capacity have capacity 1. For example:
+	//
+	//     [ssh.capacity]
+	//     "small.example.com" = 8
+	//     "large.example.com" = 32
+	Capacity map[string]int `toml:"capacity"`
+
+	// HA configures the high-availability mode of the manager, in which
+	// multiple managers elect a leader through a coordination backend. For
+	// example:
+	//
+	//     [ssh.ha]
+	//     coordinator = "redis://redis.example.com:6379/0"
+	//     managers = ["mgr1.example.com:9000", "mgr2.example.com:9000"]
+	//     port = 9000
+	//     lease = "10s"
+	//
+	// Run "weaver ssh deploy" on one of the managers, and
+	// "weaver ssh standby" on the others.
+	HA *haConfig `toml:"ha"`
+
+	// Transport is the transport over which the babysitters send their
+	// telemetry (log entries, trace spans, and metrics) to the manager:
+	// "http" (the default) or "grpc". The "grpc" transport streams the
+	// telemetry, which is cheaper for applications that log a lot.
+	Transport string `toml:"transport"`
+
+	// BatchSize and BatchDelay bound the batches of log entries and trace
+	// spans that the babysitters send to the manager over HTTP: a batch is
+	// sent when it holds BatchSize items, or when its oldest item has waited
+	// for BatchDelay (e.g., "100ms"). See impl.TelemetryOptions.
+	BatchSize  int    `toml:"batch_size"`
+	BatchDelay string `toml:"batch_delay"`
+
+	// Compression is the compression of the telemetry requests of the
+	// babysitters: "gzip", "zstd", or "" (no compression).
+	Compression string `toml:"compression"`
+
+	// Logs configures the rotation and retention of the log files of the
+	// manager and of the babysitters. For example:
+	//
+	//     [ssh.logs]
+	//     max_file_mb = 64
+	//     max_file_age = "1h"
+	//     compress = true
+	//     retain_mb = 1024
+	//     retain_age = "168h"
+	Logs *logsConfig `toml:"logs"`
+
+	// Traces configures where the trace spans of the application are
+	// stored: in the local Perfetto database ("perfetto", the default),
+	// in an OTLP/gRPC collector such as Jaeger or Grafana Tempo ("otlp"), or
+	// both. For example:
+	//
+	//     [ssh.traces]
+	//     exporters = ["otlp"]
+	//
+	//     [ssh.traces.otlp]
+	//     endpoint = "tempo.example.com:4317"
+	//     insecure = true
+	//     headers = {"X-Scope-OrgID" = "game"}
+	//
+	// If exporters is not set, spans are stored in the Perfetto database,
+	// and in the OTLP collector if one is configured.
+	Traces *tracesConfig `toml:"traces"`
+
+	// Alerts are the alerting rules evaluated by the manager against the
+	// metrics of the application. Firing alerts are shown on the dashboard,
+	// and posted to the webhooks of their rule. For example:
+	//
+	//     [[ssh.alerts]]
+	//     name = "LobbyErrors"
+	//     expr = 'rate(serviceweaver_method_error_count{component="Lobby"})'
+	//     op = ">"
+	//     threshold = 5
+	//     for = "5m"
+	//     webhooks = ["https://hooks.slack.com/services/..."]
+	//
+	// See impl.AlertRule for the syntax of expr.
+	Alerts []alertConfig `toml:"alerts"`
+}
+
+// logsConfig is the log retention config, as found in the TOML config file.
+// See logging.FileStoreOptions.
+type logsConfig struct {
+	MaxFileMB  int64  `toml:"max_file_mb"`
+	MaxFileAge string `toml:"max_file_age"`
+	Compress   bool   `toml:"compress"`
+	RetainMB   int64  `toml:"retain_mb"`
+	RetainAge  string `toml:"retain_age"`
+}
+
+// tracesConfig is the trace export config, as found in the TOML config file.
+// See impl.TraceOptions.
+type tracesConfig struct {
+	Exporters []string    `toml:"exporters"`
+	OTLP      *otlpConfig `toml:"otlp"`
+}
+
+// otlpConfig is the config of an OTLP/gRPC collector, as found in the TOML
+// config file. See otlp.Options.
+type otlpConfig struct {
+	Endpoint string            `toml:"endpoint"`
+	Insecure bool              `toml:"insecure"`
+	Headers  map[string]string `toml:"headers"`
+	Timeout  string            `toml:"timeout"`
+}
+
+// alertConfig is an alerting rule, as found in the TOML config file. See
+// impl.AlertRule.
+type alertConfig struct {
+	Name      string   `toml:"name"`
+	Expr      string   `toml:"expr"`
+	Op        string   `toml:"op"`
+	Threshold float64  `toml:"threshold"`
+	For       string   `toml:"for"`
+	Webhooks  []string `toml:"webhooks"`
+}
+
+// haConfig is the high-availability config of the manager, as found in the
+// TOML config file. See impl.HAOptions.
+type haConfig struct {
+	// Coordinator is the URL of the Redis server shared by the managers.
+	Coordinator string `toml:"coordinator"`
+
+	// Managers are the addresses (host:port) of all the managers.
+	Managers []string `toml:"managers"`
+
+	// Port is the port the managers listen on.
+	Port int `toml:"port"`
+
+	// Lease is the duration of the lease of the leader (e.g., "10s").
+	Lease string `toml:"lease"`
+}
+
+// affinityConfig is the session affinity config of a listener, as found in
+// the TOML config file. See proxy.AffinityOptions.
+type affinityConfig struct {
+	Header string `toml:"header"`
+	Cookie string `toml:"cookie"`
+	MaxAge string `toml:"max_age"`
+}
+
+// managerOptions returns the manager options specified in the SSH config.
+func (c *sshConfig) managerOptions() (impl.ManagerOptions, error) {
+	opts := impl.ManagerOptions{
+		Autoscaler: impl.AutoscalerOptions{
+			MinReplicas: c.MinReplicas,
+			MaxReplicas: c.MaxReplicas,
+			TargetQPS:   c.TargetQPS,
+		},
+	}
+	if c.MaxReplicas > 0 && c.MinReplicas > c.MaxReplicas {
+		return opts, fmt.Errorf("min_replicas (%d) > max_replicas (%d)", c.MinReplicas, c.MaxReplicas)
+	}
+	if c.TargetLatency != "" {
+		latency, err := time.ParseDuration(c.TargetLatency)
+		if err != nil {
+			return opts, fmt.Errorf("invalid target latency %q: %w", c.TargetLatency, err)
+		}
+		opts.Autoscaler.TargetLatency = latency
+	}
+	for component, strategy := range c.Assignment {
+		switch strategy {
+		case impl.RoundRobinAssignment, impl.ConsistentHashAssignment:
+		default:
+			return opts, fmt.Errorf("assignment of component %q: unknown strategy %q", component, strategy)
+		}
+	}
+	opts.Assignments = c.Assignment
+	for loc, capacity := range c.Capacity {
+		if capacity <= 0 {
+			return opts, fmt.Errorf("capacity of location %q: got %d, want a positive capacity", loc, capacity)
+		}
+	}
+	opts.Capacities = c.Capacity
+	switch c.Transport {
+	case "", impl.HTTPTransport, impl.GRPCTransport:
+		opts.Transport = c.Transport
+	default:
+		return opts, fmt.Errorf("unknown transport %q", c.Transport)
+	}
+	if c.BatchSize < 0 {
+		return opts, fmt.Errorf("batch_size: got %d, want a non-negative size", c.BatchSize)
+	}
+	opts.Telemetry.MaxBatchSize = c.BatchSize
+	if c.BatchDelay != "" {
+		delay, err := time.ParseDuration(c.BatchDelay)
+		if err != nil {
+			return opts, fmt.Errorf("invalid batch delay %q: %w", c.BatchDelay, err)
+		}
+		opts.Telemetry.MaxBatchDelay = delay
+	}
+	if !protomsg.ValidEncoding(c.Compression) {
+		return opts, fmt.Errorf("unknown compression %q", c.Compression)
+	}
+	opts.Telemetry.Encoding = c.Compression
+	if c.Logs != nil {
+		logs, err := c.Logs.fileStoreOptions()
+		if err != nil {
+			return opts, err
+		}
+		opts.Logs = logs
+	}
+	if c.Traces != nil {
+		traces, err := c.Traces.traceOptions()
+		if err != nil {
+			return opts, err
+		}
+		opts.Traces = traces
+	}
+	for _, a := range c.Alerts {
+		rule := impl.AlertRule{
+			Name:      a.Name,
+			Expr:      a.Expr,
+			Op:        a.Op,
+			Threshold: a.Threshold,
+			Webhooks:  a.Webhooks,
+		}
+		if a.For != "" {
+			d, err := time.ParseDuration(a.For)
+			if err != nil {
+				return opts, fmt.Errorf("alert %q: invalid duration %q: %w", a.Name, a.For, err)
+			}
+			rule.For = d
+		}
+		opts.Alerts.Rules = append(opts.Alerts.Rules, rule)
+	}
+	for name, a := range c.Affinity {
+		if a.Header == "" && a.Cookie == "" {
+			return opts, fmt.Errorf("affinity of listener %q: no header or cookie provided", name)
+		}
+		affinity := proxy.AffinityOptions{Header: a.Header, Cookie: a.Cookie}
+		if a.MaxAge != "" {
+			maxAge, err := time.ParseDuration(a.MaxAge)
+			if err != nil {
+				return opts, fmt.Errorf("affinity of listener %q: invalid max age %q: %w", name, a.MaxAge, err)
+			}
+			affinity.MaxAge = maxAge
+		}
+		if opts.ProxyAffinity == nil {
+			opts.ProxyAffinity = map[string]proxy.AffinityOptions{}
+		}
+		opts.ProxyAffinity[name] = affinity
+	}
+	return opts, nil
+}
+
+// fileStoreOptions returns the log file options specified in the logs
+// config.
+func (c *logsConfig) fileStoreOptions() (logging.FileStoreOptions, error) {
+	if c.MaxFileMB < 0 {
+		return logging.FileStoreOptions{}, fmt.Errorf("logs: got max_file_mb %d, want a non-negative size", c.MaxFileMB)
+	}
+	if c.RetainMB < 0 {
+		return logging.FileStoreOptions{}, fmt.Errorf("logs: got retain_mb %d, want a non-negative size", c.RetainMB)
+	}
+	opts := logging.FileStoreOptions{
+		MaxFileSize: c.MaxFileMB << 20,
+		Compress:    c.Compress,
+		RetainBytes: c.RetainMB << 20,
+	}
+	var err error
+	if c.MaxFileAge != "" {
+		if opts.MaxFileAge, err = time.ParseDuration(c.MaxFileAge); err != nil {
+			return logging.FileStoreOptions{}, fmt.Errorf("logs: invalid max file age %q: %w", c.MaxFileAge, err)
+		}
+	}
+	if c.RetainAge != "" {
+		if opts.RetainAge, err = time.ParseDuration(c.RetainAge); err != nil {
+			return logging.FileStoreOptions{}, fmt.Errorf("logs: invalid retain age %q: %w", c.RetainAge, err)
+		}
+	}
+	return opts, nil
+}
+
+// traceOptions returns the trace export options specified in the traces
+// config.
+func (c *tracesConfig) traceOptions() (impl.TraceOptions, error) {
+	exporters := c.Exporters
+	if len(exporters) == 0 {
+		exporters = []string{"perfetto"}
+		if c.OTLP != nil {
+			exporters = append(exporters, "otlp")
+		}
+	}
+	opts := impl.TraceOptions{DisablePerfetto: true}
+	for _, exporter := range exporters {
+		switch exporter {
+		case "perfetto":
+			opts.DisablePerfetto = false
+		case "otlp":
+			if c.OTLP == nil || c.OTLP.Endpoint == "" {
+				return opts, fmt.Errorf("traces: no otlp endpoint provided")
+			}
+			opts.OTLP = otlp.Options{
+				Endpoint: c.OTLP.Endpoint,
+				Insecure: c.OTLP.Insecure,
+				Headers:  c.OTLP.Headers,
+			}
+			if c.OTLP.Timeout != "" {
+				timeout, err := time.ParseDuration(c.OTLP.Timeout)
+				if err != nil {
+					return opts, fmt.Errorf("traces: invalid otlp timeout %q: %w", c.OTLP.Timeout, err)
+				}
+				opts.OTLP.Timeout = timeout
+			}
+		default:
+			return opts, fmt.Errorf("traces: unknown exporter %q; must be perfetto or otlp", exporter)
+		}
+	}
+	return opts, nil
+}
+
+// haOptions returns the high-availability options of the manager of the
+// provided app, or false if high-availability mode is not configured.
+func (c *sshConfig) haOptions(app string) (impl.HAOptions, int, bool, error) {
+	if c.HA == nil {
+		return impl.HAOptions{}, 0, false, nil
+	}
+	if c.HA.Coordinator == "" {
+		return impl.HAOptions{}, 0, false, fmt.Errorf("ha: no coordinator provided")
+	}
+	if c.HA.Port <= 0 {
+		return impl.HAOptions{}, 0, false, fmt.Errorf("ha: invalid port %d", c.HA.Port)
+	}
+	var lease time.Duration
+	if c.HA.Lease != "" {
+		var err error
+		if lease, err = time.ParseDuration(c.HA.Lease); err != nil {
+			return impl.HAOptions{}, 0, false, fmt.Errorf("ha: invalid lease %q: %w", c.HA.Lease, err)
+		}
+	}
+	redisOpts, err := redis.ParseURL(c.HA.Coordinator)
+	if err != nil {
+		return impl.HAOptions{}, 0, false, fmt.Errorf("ha: invalid coordinator %q: %w", c.HA.Coordinator, err)
+	}
+	opts := impl.HAOptions{
+		Coordinator: impl.NewRedisCoordinator(redis.NewClient(redisOpts), app, lease),
+	}
+	for _, m := range c.HA.Managers {
+		opts.Peers = append(opts.Peers, "http://"+m)
+	}
+	return opts, c.HA.Port, true, nil
 }
 
 // deploy deploys an application on a cluster of machines using an SSH deployer.
@@ -46,13 +448,9 @@ func deploy(ctx context.Context, args []string) error {
 
 	// Load the config file.
 	cfgFile := args[0]
-	cfg, err := os.ReadFile(cfgFile)
-	if err != nil {
-		return fmt.Errorf("load config file %q: %w", cfgFile, err)
-	}
-	app, err := aop.ParseConfig(cfgFile, string(cfg), codegen.ComponentConfigValidator)
+	app, config, err := loadConfig(cfgFile)
 	if err != nil {
-		return fmt.Errorf("load config file %q: %w", cfgFile, err)
+		return err
 	}
 
 	// Sanity check the config.
@@ -61,10 +459,17 @@ func deploy(ctx context.Context, args []string) error {
 	}
 
 	// Retrieve the list of locations to deploy.
-	locs, err := getLocations(app)
+	locs, err := getLocations(config)
 	if err != nil {
 		return err
 	}
+	opts, err := config.managerOptions()
+	if err != nil {
+		return err
+	}
+	if opts.StateDir, err = impl.DefaultStateDir(app.Name); err != nil {
+		return err
+	}
 
 	// Create a deployment.
 	dep := &protos.Deployment{
@@ -72,35 +477,88 @@ func deploy(ctx context.Context, args []string) error {
 		App: app,
 	}
 
-	// Copy the binaries to each location.
-	if err := copyBinaries(locs, dep); err != nil {
+	// Find out whether the app is already running, in which case we perform
+	// a rolling update of the running deployment.
+	ha, port, haEnabled, err := config.haOptions(app.Name)
+	if err != nil {
 		return err
 	}
-
-	// Run the manager.
-	stopFn, err := impl.RunManager(ctx, dep, locs, logDir)
+	var running *status.Registration
+	if haEnabled {
+		running, err = findLeader(ctx, app.Name, ha.Coordinator)
+	} else {
+		running, err = findRunningDeployment(ctx, app.Name)
+	}
 	if err != nil {
-		return fmt.Errorf("cannot instantiate the manager: %w", err)
+		return err
+	}
+
+	if running != nil {
+		// Ask the manager of the running deployment to roll out the new
+		// deployment.
+		req := &impl.RolloutRequest{
+			Deployment: dep,
+			NumSteps:   int32(config.RolloutSteps),
+		}
+		if config.RolloutInterval != "" {
+			interval, err := time.ParseDuration(config.RolloutInterval)
+			if err != nil {
+				return fmt.Errorf("invalid rollout interval %q: %w", config.RolloutInterval, err)
+			}
+			req.StepInterval = durationpb.New(interval)
+		}
+		if err := impl.Rollout(ctx, running.Addr, req); err != nil {
+			return fmt.Errorf("cannot roll out deployment %s: %w", dep.Id, err)
+		}
+		fmt.Fprintf(os.Stderr, "Rolling out deployment %s over deployment %s\n",
+			logging.Shorten(dep.Id), logging.Shorten(running.DeploymentId))
+	} else if haEnabled {
+		// Run a highly available manager.
+		opts.HA = ha
+		opts.Port = port
+		opts.ConfigFile = cfgFile
+		stopFn, err := impl.RunHAManager(ctx, app.Name, dep, locs, logDir, opts)
+		if err != nil {
+			return fmt.Errorf("cannot instantiate the manager: %w", err)
+		}
+		stopOnSignal(app.Name, stopFn)
+	} else {
+		// Refuse to start a new manager over the state of a manager that
+		// didn't terminate cleanly, whose babysitters may still be running.
+		if impl.HasState(opts.StateDir) {
+			return fmt.Errorf("the manager of app %s didn't terminate cleanly; run \"weaver ssh recover %s\" to recover it", app.Name, cfgFile)
+		}
+
+		// Run the manager.
+		opts.ConfigFile = cfgFile
+		stopFn, err := impl.RunManager(ctx, dep, locs, logDir, opts)
+		if err != nil {
+			return fmt.Errorf("cannot instantiate the manager: %w", err)
+		}
+		stopOnSignal(app.Name, stopFn)
 	}
+	return followLogs(ctx, dep.Id)
+}
 
-	// Wait for the user to kill the app.
+// stopOnSignal calls stopFn and exits when the user kills the app.
+func stopOnSignal(app string, stopFn func() error) {
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-done // Will block here until user hits ctrl+c
-		if err := terminateDeployment(locs, dep); err != nil {
-			fmt.Fprintf(os.Stderr, "failed to terminate deployment: %v\n", err)
-		}
-		fmt.Fprintf(os.Stderr, "Application %s terminated\n", app.Name)
 		if err := stopFn(); err != nil {
-			fmt.Fprintf(os.Stderr, "stop the manager: %v\n", err)
+			fmt.Fprintf(os.Stderr, "failed to terminate deployment: %v\n", err)
 		}
+		fmt.Fprintf(os.Stderr, "Application %s terminated\n", app)
 		os.Exit(1)
 	}()
+}
 
-	// Follow the logs.
+// followLogs prints the logs of the provided deployment until ctx is
+// cancelled.
+func followLogs(ctx context.Context, depId string) error {
 	source := logging.FileSource(logDir)
-	query := fmt.Sprintf(`full_version == %q && !("serviceweaver/system" in attrs)`, dep.Id)
+	query := fmt.Sprintf(`full_version == %q && !("serviceweaver/system" in attrs)`, depId)
 	r, err := source.Query(ctx, query, true)
 	if err != nil {
 		return err
@@ -117,62 +575,83 @@ func deploy(ctx context.Context, args []string) error {
 	}
 }
 
-// copyBinaries copies the tool and the application binary to the given set
-// of locations.
-func copyBinaries(locs []string, dep *protos.Deployment) error {
-	ex, err := os.Executable()
+// loadConfig loads the app config and the SSH config in the provided config
+// file.
+func loadConfig(cfgFile string) (*protos.AppConfig, *sshConfig, error) {
+	cfg, err := os.ReadFile(cfgFile)
 	if err != nil {
-		return err
+		return nil, nil, fmt.Errorf("load config file %q: %w", cfgFile, err)
 	}
+	app, err := aop.ParseConfig(cfgFile, string(cfg), codegen.ComponentConfigValidator)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load config file %q: %w", cfgFile, err)
+	}
+	config, err := parseSSHConfig(app)
+	if err != nil {
+		return nil, nil, err
+	}
+	return app, config, nil
+}
 
-	binary := dep.App.Binary
-	remoteDepDir := filepath.Join(os.TempDir(), dep.Id)
-	dep.App.Binary = filepath.Join(remoteDepDir, filepath.Base(dep.App.Binary))
-	for _, loc := range locs {
-		// Make an app deployment directory at each location.
-		cmd := exec.Command("ssh", loc, "mkdir", "-p", remoteDepDir)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("unable to create deployment directory at location %s: %w\n", loc, err)
-		}
-
-		cmd = exec.Command("scp", ex, binary, loc+":"+remoteDepDir)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("unable to copy app binary at location %s: %w\n", loc, err)
+// findRunningDeployment returns the registration of the running SSH deployment
+// of the provided app, or nil if the app is not running.
+func findRunningDeployment(ctx context.Context, app string) (*status.Registration, error) {
+	registry, err := impl.DefaultRegistry(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create registry: %w", err)
+	}
+	regs, err := registry.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list deployments: %w", err)
+	}
+	for _, reg := range regs {
+		if reg.App == app {
+			return &reg, nil
 		}
 	}
-	return nil
+	return nil, nil
 }
 
-// terminateDeployment terminates all the processes corresponding to the deployment
-// at all locations.
-//
-// TODO(rgrandl): Find a different way to kill the deployment if the pkill command
-// is not installed.
-func terminateDeployment(locs []string, dep *protos.Deployment) error {
-	for _, loc := range locs {
-		cmd := exec.Command("ssh", loc, "pkill", "-f", dep.Id)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("unable to terminate deployment at location %s: %w", loc, err)
-		}
+// findLeader returns the registration of the deployment run by the leader of
+// the highly available managers of the provided app, or nil if there is no
+// leader.
+func findLeader(ctx context.Context, app string, coordinator impl.Coordinator) (*status.Registration, error) {
+	addr, err := impl.Leader(ctx, coordinator)
+	if err != nil {
+		return nil, fmt.Errorf("find leader: %w", err)
+	}
+	if addr == "" {
+		return nil, nil
 	}
-	return nil
+	s, err := status.NewClient(addr).Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get status of leader %s: %w", addr, err)
+	}
+	return &status.Registration{DeploymentId: s.DeploymentId, App: app, Addr: addr}, nil
 }
 
-// getLocations returns the list of locations at which to deploy the application.
-func getLocations(app *protos.AppConfig) ([]string, error) {
-	// SSH config as found in TOML config file.
-	const sshKey = "greatestworks/ssh"
-	const shortSSHKey = "ssh"
+// Keys of the SSH section of the app config.
+const (
+	sshKey      = "greatestworks/ssh"
+	shortSSHKey = "ssh"
+)
 
-	type sshConfigSchema struct {
-		LocationsFile string `toml:"locations_file"`
-	}
-	parsed := &sshConfigSchema{}
+func init() {
+	aop.RegisterConfigSection[sshConfig](sshKey, shortSSHKey)
+}
+
+// parseSSHConfig parses the SSH section of the provided app config.
+func parseSSHConfig(app *protos.AppConfig) (*sshConfig, error) {
+	parsed := &sshConfig{}
 	if err := aop.ParseConfigSection(sshKey, shortSSHKey, app.Sections, parsed); err != nil {
 		return nil, fmt.Errorf("unable to parse ssh config: %w", err)
 	}
+	return parsed, nil
+}
 
-	file, err := getAbsoluteFilePath(parsed.LocationsFile)
+// getLocations returns the list of locations at which to deploy the application.
+func getLocations(config *sshConfig) ([]string, error) {
+	file, err := getAbsoluteFilePath(config.LocationsFile)
 	if err != nil {
 		return nil, err
 	}
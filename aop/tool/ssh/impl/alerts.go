@@ -0,0 +1,441 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"greatestworks/aop/protos"
+	"greatestworks/aop/status"
+)
+
+// AlertOptions configures the alerting rules evaluated by the manager.
+type AlertOptions struct {
+	// Rules are the alerting rules. If empty, alerting is disabled.
+	Rules []AlertRule
+
+	// Interval is how often the rules are evaluated. Defaults to one minute,
+	// which is how often babysitters report their metrics.
+	Interval time.Duration
+
+	// Client is the HTTP client used to call webhooks. Defaults to a client
+	// with a 10 second timeout.
+	Client *http.Client
+}
+
+// An AlertRule fires an alert when a metric expression crosses a threshold
+// for some time. For example, the following rule fires when the method calls
+// of the Lobby component fail at a rate above 5 errors per second for five
+// minutes:
+//
+//	AlertRule{
+//	    Name:      "LobbyErrors",
+//	    Expr:      `rate(serviceweaver_method_error_count{component="Lobby"})`,
+//	    Threshold: 5,
+//	    For:       5 * time.Minute,
+//	}
+type AlertRule struct {
+	// Name is the name of the rule, which identifies its alerts.
+	Name string
+
+	// Expr is the metric expression the rule watches, of the form
+	// "<aggregation>(<metric>{<label>="<value>", ...})", where:
+	//
+	//   - metric is the name of a metric (e.g., serviceweaver_method_count).
+	//   - the labels, if any, select the metric snapshots whose labels have
+	//     the provided values. Values must not contain commas.
+	//   - aggregation combines the values of the selected snapshots of all
+	//     replicas: sum, min, max, avg, or rate (the per-second increase of
+	//     the sum, for counters). Defaults to sum.
+	Expr string
+
+	// Op compares the value of Expr to Threshold: ">", ">=", "<", or "<=".
+	// Defaults to ">".
+	Op string
+
+	// Threshold is the value Expr is compared to.
+	Threshold float64
+
+	// For is how long the condition must hold before the alert fires. If
+	// zero, the alert fires the first time the condition holds.
+	For time.Duration
+
+	// Webhooks are the URLs the manager POSTs a JSON notification to when
+	// the alert fires and when it resolves (e.g., a Slack incoming webhook,
+	// or a PagerDuty generic webhook). The "text" field of the notification
+	// summarizes it.
+	Webhooks []string
+}
+
+// condition returns a human-readable description of the rule's condition,
+// e.g., "rate(errors) > 5".
+func (r AlertRule) condition() string {
+	return fmt.Sprintf("%s %s %g", r.Expr, r.Op, r.Threshold)
+}
+
+// alertExpr is a parsed AlertRule.Expr.
+type alertExpr struct {
+	aggregation string            // sum, min, max, avg, or rate
+	metric      string            // metric name
+	labels      map[string]string // required label values
+}
+
+// parseAlertExpr parses an AlertRule.Expr.
+func parseAlertExpr(s string) (alertExpr, error) {
+	expr := alertExpr{aggregation: "sum"}
+	orig := s
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '('); i >= 0 {
+		if !strings.HasSuffix(s, ")") {
+			return expr, fmt.Errorf("expression %q: missing )", orig)
+		}
+		expr.aggregation = strings.TrimSpace(s[:i])
+		s = strings.TrimSpace(s[i+1 : len(s)-1])
+	}
+	switch expr.aggregation {
+	case "sum", "min", "max", "avg", "rate":
+	default:
+		return expr, fmt.Errorf("expression %q: unknown aggregation %q", orig, expr.aggregation)
+	}
+
+	if i := strings.IndexByte(s, '{'); i >= 0 {
+		if !strings.HasSuffix(s, "}") {
+			return expr, fmt.Errorf("expression %q: missing }", orig)
+		}
+		expr.labels = map[string]string{}
+		for _, pair := range strings.Split(s[i+1:len(s)-1], ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return expr, fmt.Errorf("expression %q: invalid label %q", orig, pair)
+			}
+			value, err := strconv.Unquote(strings.TrimSpace(value))
+			if err != nil {
+				return expr, fmt.Errorf("expression %q: invalid value of label %q: %w", orig, pair, err)
+			}
+			expr.labels[strings.TrimSpace(key)] = value
+		}
+		s = strings.TrimSpace(s[:i])
+	}
+	if s == "" {
+		return expr, fmt.Errorf("expression %q: missing metric name", orig)
+	}
+	expr.metric = s
+	return expr, nil
+}
+
+// matches returns whether the provided metric snapshot is selected by the
+// expression.
+func (e alertExpr) matches(s *protos.MetricSnapshot) bool {
+	if s.Name != e.metric {
+		return false
+	}
+	for key, value := range e.labels {
+		if s.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// aggregate returns the aggregated value of the selected metric snapshots, or
+// false if no snapshot is selected. For the rate aggregation, it returns the
+// sum of the values; see alerter.evaluate.
+func (e alertExpr) aggregate(snapshots []*protos.MetricSnapshot) (float64, bool) {
+	var sum, min, max float64
+	n := 0
+	for _, s := range snapshots {
+		if !e.matches(s) {
+			continue
+		}
+		if n == 0 || s.Value < min {
+			min = s.Value
+		}
+		if n == 0 || s.Value > max {
+			max = s.Value
+		}
+		sum += s.Value
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	switch e.aggregation {
+	case "min":
+		return min, true
+	case "max":
+		return max, true
+	case "avg":
+		return sum / float64(n), true
+	default:
+		return sum, true
+	}
+}
+
+// compare returns whether value op threshold holds.
+func compare(value float64, op string, threshold float64) bool {
+	switch op {
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	default:
+		return value > threshold
+	}
+}
+
+// alertNotification is the JSON body of the requests sent to webhooks.
+type alertNotification struct {
+	Text         string    `json:"text"`   // human-readable summary, e.g., for Slack
+	Status       string    `json:"status"` // "firing" or "resolved"
+	Alert        string    `json:"alert"`  // name of the alerting rule
+	Condition    string    `json:"condition"`
+	Value        float64   `json:"value"` // latest value of the expression
+	App          string    `json:"app"`
+	DeploymentId string    `json:"deployment_id"`
+	Time         time.Time `json:"time"`
+}
+
+// alerter periodically evaluates the alerting rules of the manager against
+// the latest metrics reported by the babysitters, and calls the webhooks of
+// the rules when their alerts fire or resolve.
+type alerter struct {
+	m    *manager
+	opts AlertOptions
+
+	mu    sync.Mutex
+	rules []*ruleState
+}
+
+// ruleState is the evaluation state of an alerting rule.
+type ruleState struct {
+	rule AlertRule
+	expr alertExpr
+
+	value        float64   // latest value of the expression
+	pendingSince time.Time // when the condition started to hold, or zero
+	firingSince  time.Time // when the alert fired, or zero if not firing
+
+	// Previous sum of the expression, for the rate aggregation.
+	lastTime time.Time // zero if there is no previous sum
+	lastSum  float64
+}
+
+// parseAlertRules validates the provided rules, fills in their defaults, and
+// returns their evaluation states.
+func parseAlertRules(rules []AlertRule) ([]*ruleState, error) {
+	var states []*ruleState
+	names := map[string]bool{}
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("alerting rule %q: no name provided", rule.Expr)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("alerting rule %q: duplicate name", rule.Name)
+		}
+		names[rule.Name] = true
+		expr, err := parseAlertExpr(rule.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("alerting rule %q: %w", rule.Name, err)
+		}
+		switch rule.Op {
+		case "":
+			rule.Op = ">"
+		case ">", ">=", "<", "<=":
+		default:
+			return nil, fmt.Errorf("alerting rule %q: unknown comparison %q", rule.Name, rule.Op)
+		}
+		if rule.For < 0 {
+			return nil, fmt.Errorf("alerting rule %q: negative duration %v", rule.Name, rule.For)
+		}
+		states = append(states, &ruleState{rule: rule, expr: expr})
+	}
+	return states, nil
+}
+
+func newAlerter(m *manager, opts AlertOptions, rules []*ruleState) *alerter {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &alerter{m: m, opts: opts, rules: rules}
+}
+
+// run runs the alerter until the provided context is cancelled.
+func (a *alerter) run(ctx context.Context) {
+	ticker := time.NewTicker(a.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.m.mu.Lock()
+			var snapshots []*protos.MetricSnapshot
+			for _, ms := range a.m.metrics {
+				snapshots = append(snapshots, ms...)
+			}
+			app, depId := a.m.dep.App.Name, a.m.dep.Id
+			a.m.mu.Unlock()
+
+			for _, n := range a.evaluate(snapshots, time.Now()) {
+				n.App, n.DeploymentId = app, depId
+				a.notify(ctx, n)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// evaluate evaluates the rules against the provided metric snapshots, and
+// returns the notifications of the alerts that fired or resolved, along with
+// the webhooks to send them to.
+func (a *alerter) evaluate(snapshots []*protos.MetricSnapshot, now time.Time) []pendingNotification {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var notifications []pendingNotification
+	for _, s := range a.rules {
+		value, ok := s.expr.aggregate(snapshots)
+		if ok && s.expr.aggregation == "rate" {
+			sum, elapsed := value, now.Sub(s.lastTime).Seconds()
+			ok = !s.lastTime.IsZero() && elapsed > 0 && sum >= s.lastSum
+			value = (sum - s.lastSum) / elapsed
+			s.lastTime, s.lastSum = now, sum
+		}
+		if !ok {
+			// Either no replica reports the metric, or some replicas have
+			// restarted since the previous evaluation, so their counters
+			// have been reset. Don't make any decision.
+			continue
+		}
+		s.value = value
+
+		if !compare(value, s.rule.Op, s.rule.Threshold) {
+			s.pendingSince = time.Time{}
+			if !s.firingSince.IsZero() {
+				s.firingSince = time.Time{}
+				notifications = append(notifications, s.notification("resolved", now))
+			}
+			continue
+		}
+		if s.pendingSince.IsZero() {
+			s.pendingSince = now
+		}
+		if s.firingSince.IsZero() && now.Sub(s.pendingSince) >= s.rule.For {
+			s.firingSince = now
+			notifications = append(notifications, s.notification("firing", now))
+		}
+	}
+	return notifications
+}
+
+// pendingNotification is a notification to send to webhooks.
+type pendingNotification struct {
+	*alertNotification
+	webhooks []string
+}
+
+// notification returns the notification of an alert that fired or resolved.
+//
+// REQUIRES: alerter.mu is held.
+func (s *ruleState) notification(statusName string, now time.Time) pendingNotification {
+	text := fmt.Sprintf("[%s] %s: %s (value %g)", strings.ToUpper(statusName), s.rule.Name, s.rule.condition(), s.value)
+	return pendingNotification{
+		alertNotification: &alertNotification{
+			Text:      text,
+			Status:    statusName,
+			Alert:     s.rule.Name,
+			Condition: s.rule.condition(),
+			Value:     s.value,
+			Time:      now,
+		},
+		webhooks: s.rule.Webhooks,
+	}
+}
+
+// notify sends the provided notification to its webhooks.
+func (a *alerter) notify(ctx context.Context, n pendingNotification) {
+	logger := a.m.logger
+	if n.Status == "firing" {
+		logger.Error("Alert firing", nil, "alert", n.Alert, "condition", n.Condition, "value", n.Value)
+	} else {
+		logger.Info("Alert resolved", "alert", n.Alert, "condition", n.Condition, "value", n.Value)
+	}
+	body, err := json.Marshal(n.alertNotification)
+	if err != nil {
+		logger.Error("Unable to encode alert notification", err, "alert", n.Alert)
+		return
+	}
+	for _, url := range n.webhooks {
+		if err := a.post(ctx, url, body); err != nil {
+			logger.Error("Unable to call alert webhook", err, "alert", n.Alert, "url", url)
+		}
+	}
+}
+
+// post POSTs the provided JSON body to the provided webhook.
+func (a *alerter) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("HTTP status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// alerts returns the alerts that are currently firing, sorted by name.
+func (a *alerter) alerts() []*status.Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var alerts []*status.Alert
+	for _, s := range a.rules {
+		if s.firingSince.IsZero() {
+			continue
+		}
+		alerts = append(alerts, &status.Alert{
+			Name:        s.rule.Name,
+			Condition:   s.rule.condition(),
+			Value:       s.value,
+			FiringSince: timestamppb.New(s.firingSince),
+		})
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Name < alerts[j].Name })
+	return alerts
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"greatestworks/aop/logging"
+	"greatestworks/aop/protos"
+)
+
+func TestParseAlertExpr(t *testing.T) {
+	for _, test := range []struct {
+		expr string
+		want alertExpr
+	}{
+		{"errors", alertExpr{aggregation: "sum", metric: "errors"}},
+		{"max(queue_size)", alertExpr{aggregation: "max", metric: "queue_size"}},
+		{
+			`rate(errors{component="Lobby", method = "Join"})`,
+			alertExpr{
+				aggregation: "rate",
+				metric:      "errors",
+				labels:      map[string]string{"component": "Lobby", "method": "Join"},
+			},
+		},
+	} {
+		t.Run(test.expr, func(t *testing.T) {
+			got, err := parseAlertExpr(test.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(alertExpr{})); diff != "" {
+				t.Fatalf("parseAlertExpr (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	for _, expr := range []string{
+		"",
+		"p99(latency)",
+		"rate(errors",
+		"errors{component}",
+		"errors{component=Lobby}",
+		"sum({component=\"Lobby\"})",
+	} {
+		if _, err := parseAlertExpr(expr); err == nil {
+			t.Errorf("parseAlertExpr(%q): unexpected success", expr)
+		}
+	}
+}
+
+func TestParseAlertRules(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		rules []AlertRule
+	}{
+		{"NoName", []AlertRule{{Expr: "errors"}}},
+		{"Duplicate", []AlertRule{{Name: "a", Expr: "errors"}, {Name: "a", Expr: "calls"}}},
+		{"BadOp", []AlertRule{{Name: "a", Expr: "errors", Op: "=="}}},
+		{"NegativeFor", []AlertRule{{Name: "a", Expr: "errors", For: -time.Second}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseAlertRules(test.rules); err == nil {
+				t.Fatal("unexpected success")
+			}
+		})
+	}
+}
+
+func TestAlerterEvaluate(t *testing.T) {
+	rules, err := parseAlertRules([]AlertRule{
+		{Name: "Errors", Expr: `rate(errors{component="Lobby"})`, Threshold: 1, For: 2 * time.Minute},
+		{Name: "Queue", Expr: "max(queue_size)", Op: ">=", Threshold: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newAlerter(&manager{}, AlertOptions{}, rules)
+
+	snapshots := func(errors, queue1, queue2 float64) []*protos.MetricSnapshot {
+		return []*protos.MetricSnapshot{
+			{Name: "errors", Labels: map[string]string{"component": "Lobby"}, Value: errors},
+			{Name: "errors", Labels: map[string]string{"component": "Chat"}, Value: 1000 * errors},
+			{Name: "queue_size", Value: queue1},
+			{Name: "queue_size", Value: queue2},
+		}
+	}
+	start := time.Now()
+	for i, step := range []struct {
+		snapshots []*protos.MetricSnapshot
+		want      []string // notifications, as "<status> <alert>"
+		firing    []string // firing alerts
+	}{
+		// No rate is known yet.
+		{snapshots(0, 1, 2), nil, nil},
+		// 120 errors in a minute: the condition holds, but not for long
+		// enough. The queue of a replica is full.
+		{snapshots(120, 1, 10), []string{"firing Queue"}, []string{"Queue"}},
+		{snapshots(240, 1, 1), []string{"resolved Queue"}, nil},
+		// The condition has held for two minutes.
+		{snapshots(360, 1, 1), []string{"firing Errors"}, []string{"Errors"}},
+		// A replica restarted, and reset its counter. No decision is made.
+		{snapshots(0, 1, 1), nil, []string{"Errors"}},
+		{snapshots(0, 1, 1), []string{"resolved Errors"}, nil},
+	} {
+		now := start.Add(time.Duration(i) * time.Minute)
+		var got []string
+		for _, n := range a.evaluate(step.snapshots, now) {
+			got = append(got, n.Status+" "+n.Alert)
+		}
+		if diff := cmp.Diff(step.want, got); diff != "" {
+			t.Errorf("step %d: notifications (-want +got):\n%s", i, diff)
+		}
+		var firing []string
+		for _, alert := range a.alerts() {
+			firing = append(firing, alert.Name)
+		}
+		if diff := cmp.Diff(step.firing, firing); diff != "" {
+			t.Errorf("step %d: firing alerts (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestAlerterNotify(t *testing.T) {
+	received := make(chan alertNotification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n alertNotification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received <- n
+	}))
+	defer server.Close()
+
+	rules, err := parseAlertRules([]AlertRule{
+		{Name: "Queue", Expr: "queue_size", Threshold: 10, Webhooks: []string{server.URL}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newAlerter(&manager{logger: logging.NewTestLogger(t)}, AlertOptions{}, rules)
+	notifications := a.evaluate([]*protos.MetricSnapshot{{Name: "queue_size", Value: 12}}, time.Now())
+	if len(notifications) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(notifications))
+	}
+	a.notify(context.Background(), notifications[0])
+
+	got := <-received
+	want := alertNotification{
+		Text:      "[FIRING] Queue: queue_size > 10 (value 12)",
+		Status:    "firing",
+		Alert:     "Queue",
+		Condition: "queue_size > 10",
+		Value:     12,
+	}
+	got.Time = time.Time{}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("notification (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"greatestworks/aop/protomsg"
+	"greatestworks/aop/protos"
+)
+
+// Strategies to assign the slices of a routed component to the replicas of
+// its colocation group (see ManagerOptions.Assignments).
+const (
+	// RoundRobinAssignment splits the key space into equal slices, assigned
+	// round robin to the replicas (see routingAlgo). It is the default. When
+	// the set of replicas changes, most of the key space moves to a different
+	// replica.
+	RoundRobinAssignment = "round_robin"
+
+	// ConsistentHashAssignment places every replica at many points (virtual
+	// nodes) of a hash ring, and assigns every key to the replica of the next
+	// point on the ring (see consistentHashAlgo). When a replica is added or
+	// removed, only about 1/N of the key space moves, where N is the number of
+	// replicas.
+	ConsistentHashAssignment = "consistent_hash"
+)
+
+// virtualNodes is the number of points of every replica on the hash ring of
+// ConsistentHashAssignment. More points spread the key space more evenly
+// across the replicas, at the cost of more slices.
+const virtualNodes = 64
+
+// maxVirtualNodes bounds the number of points of a replica with a large
+// weight.
+const maxVirtualNodes = 16 * virtualNodes
+
+// DefaultCapacity is the capacity of a location whose capacity is not
+// declared (see ManagerOptions.Capacities).
+const DefaultCapacity = 1
+
+// maxWeightedSlices is the maximum number of slices of an assignment across
+// replicas with different weights.
+const maxWeightedSlices = 1024
+
+// assign returns a new assignment of the provided component across the
+// provided replicas, using the assignment strategy of the component.
+func (m *manager) assign(component string, currAssignment *protos.Assignment, candidates []string, weights map[string]int) (*protos.Assignment, error) {
+	if m.opts.Assignments[component] == ConsistentHashAssignment {
+		return consistentHashAlgo(currAssignment, candidates, weights)
+	}
+	return routingAlgo(currAssignment, candidates, weights)
+}
+
+// replicaWeights returns the weights of the registered replicas of the
+// provided colocation group, by replica address. The weight of a replica is
+// the capacity of its location (see ManagerOptions.Capacities).
+//
+// REQUIRES: m.mu is held.
+func (m *manager) replicaWeights(v *appVersion, g *ColocationGroupState) map[string]int {
+	if len(m.opts.Capacities) == 0 {
+		return nil
+	}
+	weights := map[string]int{}
+	for _, r := range v.replicas {
+		if r.group == g.Name && r.addr != "" {
+			weights[r.addr] = m.capacity(r.loc)
+		}
+	}
+	return weights
+}
+
+// capacity returns the capacity of the provided location.
+func (m *manager) capacity(loc string) int {
+	if c, ok := m.opts.Capacities[loc]; ok && c > 0 {
+		return c
+	}
+	return DefaultCapacity
+}
+
+// weight returns the weight of the provided replica. Replicas without a
+// positive weight have weight DefaultCapacity.
+func weight(weights map[string]int, replica string) int {
+	if w, ok := weights[replica]; ok && w > 0 {
+		return w
+	}
+	return DefaultCapacity
+}
+
+// uniformWeights returns whether all of the provided replicas have the same
+// weight.
+func uniformWeights(replicas []string, weights map[string]int) bool {
+	for _, r := range replicas {
+		if weight(weights, r) != weight(weights, replicas[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// weightedSlices returns the number of slices in which to split the key space
+// to approximate the weights of the provided replicas: a power of two with
+// about four slices per unit of the smallest weight, and at most
+// maxWeightedSlices.
+func weightedSlices(replicas []string, weights map[string]int) int {
+	minWeight, total := weight(weights, replicas[0]), 0
+	for _, r := range replicas {
+		w := weight(weights, r)
+		total += w
+		if w < minWeight {
+			minWeight = w
+		}
+	}
+	units := (total + minWeight - 1) / minWeight
+	if units > maxWeightedSlices/4 {
+		return maxWeightedSlices
+	}
+	return nextPowerOfTwo(4 * units)
+}
+
+// weightedRoundRobin returns the owners of n consecutive slices, distributed
+// across the provided replicas proportionally to their weights, and
+// interleaved using smooth weighted round robin. With equal weights, it
+// degenerates to plain round robin.
+func weightedRoundRobin(replicas []string, weights map[string]int, n int) []string {
+	total := 0
+	for _, r := range replicas {
+		total += weight(weights, r)
+	}
+	current := make([]int, len(replicas))
+	owners := make([]string, n)
+	for i := range owners {
+		best := 0
+		for j, r := range replicas {
+			current[j] += weight(weights, r)
+			if current[j] > current[best] {
+				best = j
+			}
+		}
+		current[best] -= total
+		owners[i] = replicas[best]
+	}
+	return owners
+}
+
+// consistentHashAlgo is an implementation of a routing algorithm based on
+// consistent hashing with virtual nodes.
+//
+// Every replica is hashed to virtualNodes points of a ring covering the key
+// space (proportionally more if it is heavier than the lightest replica), and
+// every key is assigned to the replica of the first point at or after the
+// key, wrapping around at the end of the key space. Because the points of a
+// replica don't depend on the other replicas, adding or removing a replica
+// only moves the keys between the points of that replica and their
+// predecessors (unless it changes the weight of the lightest replica).
+func consistentHashAlgo(currAssignment *protos.Assignment, candidates []string, weights map[string]int) (*protos.Assignment, error) {
+	newAssignment := protomsg.Clone(currAssignment)
+	newAssignment.Version++
+	if len(candidates) == 0 {
+		newAssignment.Slices = nil
+		return newAssignment, nil
+	}
+
+	// Place the replicas on the ring. Points are sorted by position, and
+	// collisions are broken by replica, to keep the assignment deterministic.
+	type point struct {
+		pos     uint64
+		replica string
+	}
+	minWeight := weight(weights, candidates[0])
+	for _, c := range candidates {
+		if w := weight(weights, c); w < minWeight {
+			minWeight = w
+		}
+	}
+	var points []point
+	for _, c := range candidates {
+		n := virtualNodes * weight(weights, c) / minWeight
+		if n > maxVirtualNodes {
+			n = maxVirtualNodes
+		}
+		for i := 0; i < n; i++ {
+			points = append(points, point{ringHash(fmt.Sprintf("%s#%d", c, i)), c})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].pos != points[j].pos {
+			return points[i].pos < points[j].pos
+		}
+		return points[i].replica < points[j].replica
+	})
+
+	// The point at position p owns the keys in (previous point, p]. The keys
+	// after the last point wrap around to the first point.
+	var slices []*protos.Assignment_Slice
+	add := func(start uint64, replica string) {
+		if n := len(slices); n > 0 && slices[n-1].Replicas[0] == replica {
+			return // merge with the previous slice
+		}
+		slices = append(slices, &protos.Assignment_Slice{Start: start, Replicas: []string{replica}})
+	}
+	add(0, points[0].replica)
+	for i := 1; i < len(points); i++ {
+		if points[i].pos == points[i-1].pos {
+			continue
+		}
+		add(points[i-1].pos+1, points[i].replica)
+	}
+	if last := points[len(points)-1].pos; last < math.MaxUint64 {
+		add(last+1, points[0].replica)
+	}
+	newAssignment.Slices = slices
+	return newAssignment, nil
+}
+
+// ringHash returns the position on the hash ring of the provided string.
+func ringHash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s)) //nolint:errcheck // hash writes never fail
+
+	// FNV alone poorly mixes similar inputs (e.g., "a#1" and "a#2"), so we
+	// mix its bits with the finalizer of MurmurHash3.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
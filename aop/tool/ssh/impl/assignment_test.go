@@ -0,0 +1,153 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"greatestworks/aop/protos"
+)
+
+// owner returns the replica to which the provided assignment assigns the
+// provided key.
+func owner(a *protos.Assignment, key uint64) string {
+	i := sort.Search(len(a.Slices), func(i int) bool { return a.Slices[i].Start > key })
+	return a.Slices[i-1].Replicas[0]
+}
+
+func TestConsistentHashAlgo(t *testing.T) {
+	var replicas []string
+	for i := 0; i < 10; i++ {
+		replicas = append(replicas, fmt.Sprintf("tcp://10.0.0.%d:9000", i))
+	}
+	a, err := consistentHashAlgo(&protos.Assignment{}, replicas, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Version != 1 {
+		t.Fatalf("version: got %d, want 1", a.Version)
+	}
+	if a.Slices[0].Start != 0 {
+		t.Fatalf("first slice: got start %d, want 0", a.Slices[0].Start)
+	}
+	for i := 1; i < len(a.Slices); i++ {
+		if a.Slices[i].Start <= a.Slices[i-1].Start {
+			t.Fatalf("slice %d: start %d not after %d", i, a.Slices[i].Start, a.Slices[i-1].Start)
+		}
+	}
+
+	// Every replica owns a fair share of the key space.
+	rand := rand.New(rand.NewSource(0))
+	keys := make([]uint64, 10000)
+	counts := map[string]int{}
+	for i := range keys {
+		keys[i] = rand.Uint64()
+		counts[owner(a, keys[i])]++
+	}
+	for _, r := range replicas {
+		if share := float64(counts[r]) / float64(len(keys)); math.Abs(share-0.1) > 0.05 {
+			t.Errorf("replica %s: owns %.3f of the keys, want about 0.1", r, share)
+		}
+	}
+
+	// Adding a replica only moves keys to the new replica, and about 1/11 of
+	// the keys.
+	added := "tcp://10.0.0.10:9000"
+	b, err := consistentHashAlgo(a, append(replicas, added), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := 0
+	for _, key := range keys {
+		before, after := owner(a, key), owner(b, key)
+		if before == after {
+			continue
+		}
+		if after != added {
+			t.Fatalf("key %d: moved from %s to %s, want %s", key, before, after, added)
+		}
+		moved++
+	}
+	if share := float64(moved) / float64(len(keys)); share > 0.2 {
+		t.Fatalf("%.3f of the keys moved, want about 1/11", share)
+	}
+
+	// Removing the replica restores the original assignment.
+	c, err := consistentHashAlgo(b, replicas, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if owner(a, key) != owner(c, key) {
+			t.Fatalf("key %d: got %s, want %s", key, owner(c, key), owner(a, key))
+		}
+	}
+}
+
+func TestWeightedRoutingAlgo(t *testing.T) {
+	// Equal weights split the key space round robin.
+	a, err := routingAlgo(&protos.Assignment{}, []string{"a", "b", "c"}, map[string]int{"a": 8, "b": 8, "c": 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range a.Slices {
+		got = append(got, s.Replicas[0])
+	}
+	if want := []string{"a", "b", "c", "a"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("owners: got %v, want %v", got, want)
+	}
+
+	// Different weights split the key space proportionally.
+	weights := map[string]int{"small": 8, "large1": 32, "large2": 32}
+	a, err = routingAlgo(&protos.Assignment{}, []string{"small", "large1", "large2"}, weights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := map[string]float64{}
+	for i, s := range a.Slices {
+		end := uint64(math.MaxUint64)
+		if i+1 < len(a.Slices) {
+			end = a.Slices[i+1].Start
+		}
+		shares[s.Replicas[0]] += float64(end-s.Start) / math.MaxUint64
+	}
+	for r, w := range weights {
+		if want := float64(w) / 72; math.Abs(shares[r]-want) > 0.02 {
+			t.Errorf("replica %s: owns %.3f of the key space, want %.3f", r, shares[r], want)
+		}
+	}
+
+	// So do consistent hashing assignments.
+	a, err = consistentHashAlgo(&protos.Assignment{}, []string{"small", "large1", "large2"}, weights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rand := rand.New(rand.NewSource(0))
+	counts := map[string]int{}
+	const n = 10000
+	for i := 0; i < n; i++ {
+		counts[owner(a, rand.Uint64())]++
+	}
+	for r, w := range weights {
+		if share, want := float64(counts[r])/n, float64(w)/72; math.Abs(share-want) > 0.05 {
+			t.Errorf("replica %s: owns %.3f of the keys, want %.3f", r, share, want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"greatestworks/aop/codegen"
+)
+
+// AutoscalerOptions configures the autoscaling of colocation group replicas.
+type AutoscalerOptions struct {
+	MinReplicas int // minimum number of replicas per colocation group
+	MaxReplicas int // maximum number of replicas per colocation group
+
+	// TargetQPS is the number of method calls per second a single replica
+	// should handle. If zero, the autoscaler ignores the call rate.
+	TargetQPS float64
+
+	// TargetLatency is the maximum average method latency. If the average
+	// latency of a colocation group exceeds it, a replica is added to the
+	// group. If zero, the autoscaler ignores latencies.
+	TargetLatency time.Duration
+
+	// Interval is how often the autoscaler runs. Defaults to one minute,
+	// which is how often babysitters report their metrics.
+	Interval time.Duration
+}
+
+// enabled returns whether autoscaling is enabled.
+func (o AutoscalerOptions) enabled() bool {
+	return o.MaxReplicas > 0
+}
+
+// autoscaler periodically adjusts the number of replicas of every colocation
+// group, based on the method call rate and latency of the components hosted
+// by the group.
+type autoscaler struct {
+	m    *manager
+	opts AutoscalerOptions
+	last map[groupKey]groupLoad // load observed during the previous run
+}
+
+// groupKey identifies a colocation group of an application version.
+type groupKey struct {
+	version string // deployment id
+	group   string // colocation group name
+}
+
+// groupLoad is the cumulative load handled by a colocation group, as
+// reported by the metrics of all the group replicas.
+type groupLoad struct {
+	time          time.Time // time the load was observed
+	calls         float64   // number of method calls
+	latencyMicros float64   // sum of method latencies, in microseconds
+	latencyCount  float64   // number of method latency samples
+}
+
+func newAutoscaler(m *manager, opts AutoscalerOptions) *autoscaler {
+	if opts.MinReplicas <= 0 {
+		opts.MinReplicas = 1
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	return &autoscaler{m: m, opts: opts, last: map[groupKey]groupLoad{}}
+}
+
+// run runs the autoscaler until the provided context is cancelled.
+func (a *autoscaler) run(ctx context.Context) {
+	ticker := time.NewTicker(a.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.scale()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// scale adjusts the number of replicas of every started colocation group.
+func (a *autoscaler) scale() {
+	m := a.m
+	var stops []func() error
+
+	m.mu.Lock()
+	now := time.Now()
+	seen := map[groupKey]bool{}
+	for _, v := range m.versions {
+		state, _, err := m.loadAppState(v, "" /*version*/)
+		if err != nil {
+			m.logger.Error("Autoscaler: unable to load app state", err, "version", v.dep.Id)
+			continue
+		}
+		for name, g := range state.Groups {
+			if !v.started[name] {
+				continue
+			}
+			key := groupKey{version: v.dep.Id, group: name}
+			seen[key] = true
+			load := a.load(v, g, now)
+			prev, ok := a.last[key]
+			a.last[key] = load
+			if !ok {
+				continue
+			}
+
+			var replicas []*replica
+			for _, r := range v.replicas {
+				if r.group == name {
+					replicas = append(replicas, r)
+				}
+			}
+			desired := a.desiredReplicas(prev, load, len(replicas))
+			for n := len(replicas); n < desired; n++ {
+				if err := m.startReplica(v, name, "" /*avoid*/); err != nil {
+					m.logger.Error("Autoscaler: unable to add replica", err, "colocation group", name)
+					break
+				}
+			}
+			if desired < len(replicas) {
+				// Scale down conservatively, by removing the most recently
+				// started replica, one replica at a time.
+				newest := replicas[0]
+				for _, r := range replicas[1:] {
+					if r.id > newest.id {
+						newest = r
+					}
+				}
+				stop, err := m.stopReplica(v, newest)
+				if err != nil {
+					m.logger.Error("Autoscaler: unable to remove replica", err, "colocation group", name)
+					continue
+				}
+				stops = append(stops, stop)
+			}
+		}
+	}
+	for key := range a.last {
+		if !seen[key] {
+			delete(a.last, key)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, stop := range stops {
+		if err := stop(); err != nil {
+			m.logger.Error("Autoscaler: unable to stop babysitter", err)
+		}
+	}
+}
+
+// load returns the cumulative load handled by the components of the provided
+// colocation group.
+//
+// REQUIRES: a.m.mu is held.
+func (a *autoscaler) load(v *appVersion, g *ColocationGroupState, now time.Time) groupLoad {
+	load := groupLoad{time: now}
+	for info, snapshots := range a.m.metrics {
+		if info.version != v.dep.Id {
+			continue
+		}
+		for _, s := range snapshots {
+			if _, ok := g.Components[s.Labels["component"]]; !ok {
+				continue
+			}
+			switch s.Name {
+			case codegen.MethodCounts.Name():
+				load.calls += s.Value
+			case codegen.MethodLatencies.Name():
+				load.latencyMicros += s.Value
+				for _, c := range s.Counts {
+					load.latencyCount += float64(c)
+				}
+			}
+		}
+	}
+	return load
+}
+
+// desiredReplicas returns the number of replicas a colocation group should
+// run, given the load it handled between two consecutive autoscaler runs and
+// its current number of replicas.
+func (a *autoscaler) desiredReplicas(prev, curr groupLoad, replicas int) int {
+	desired := replicas
+	calls := curr.calls - prev.calls
+	elapsed := curr.time.Sub(prev.time).Seconds()
+	if calls < 0 {
+		// Some replicas have been removed or restarted since the previous
+		// run, so their counters have been reset. Don't make any decision.
+		return replicas
+	}
+	if a.opts.TargetQPS > 0 && elapsed > 0 {
+		desired = int(math.Ceil(calls / elapsed / a.opts.TargetQPS))
+	}
+	if count := curr.latencyCount - prev.latencyCount; a.opts.TargetLatency > 0 && count > 0 {
+		latency := time.Duration((curr.latencyMicros-prev.latencyMicros)/count) * time.Microsecond
+		if latency > a.opts.TargetLatency && desired <= replicas {
+			desired = replicas + 1
+		}
+	}
+	if desired < a.opts.MinReplicas {
+		desired = a.opts.MinReplicas
+	}
+	if desired > a.opts.MaxReplicas {
+		desired = a.opts.MaxReplicas
+	}
+	return desired
+}
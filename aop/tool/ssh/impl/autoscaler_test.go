@@ -0,0 +1,79 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDesiredReplicas(t *testing.T) {
+	start := time.Now()
+	prev := groupLoad{time: start}
+	for _, test := range []struct {
+		name     string
+		opts     AutoscalerOptions
+		curr     groupLoad
+		replicas int
+		want     int
+	}{
+		{
+			name:     "Idle",
+			opts:     AutoscalerOptions{MinReplicas: 2, MaxReplicas: 10, TargetQPS: 100},
+			curr:     groupLoad{time: start.Add(time.Minute)},
+			replicas: 5,
+			want:     2,
+		},
+		{
+			name:     "ScaleUp",
+			opts:     AutoscalerOptions{MaxReplicas: 10, TargetQPS: 100},
+			curr:     groupLoad{time: start.Add(time.Minute), calls: 60 * 350},
+			replicas: 2,
+			want:     4,
+		},
+		{
+			name:     "MaxReplicas",
+			opts:     AutoscalerOptions{MaxReplicas: 3, TargetQPS: 100},
+			curr:     groupLoad{time: start.Add(time.Minute), calls: 60 * 1000},
+			replicas: 2,
+			want:     3,
+		},
+		{
+			name: "HighLatency",
+			opts: AutoscalerOptions{MaxReplicas: 10, TargetLatency: 10 * time.Millisecond},
+			curr: groupLoad{
+				time:          start.Add(time.Minute),
+				latencyMicros: 100 * 20000,
+				latencyCount:  100,
+			},
+			replicas: 2,
+			want:     3,
+		},
+		{
+			name:     "CounterReset",
+			opts:     AutoscalerOptions{MaxReplicas: 10, TargetQPS: 100},
+			curr:     groupLoad{time: start.Add(time.Minute), calls: -1},
+			replicas: 4,
+			want:     4,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a := newAutoscaler(nil, test.opts)
+			if got := a.desiredReplicas(prev, test.curr, test.replicas); got != test.want {
+				t.Fatalf("desiredReplicas: got %d, want %d", got, test.want)
+			}
+		})
+	}
+}
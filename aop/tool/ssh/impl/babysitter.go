@@ -17,8 +17,9 @@ package impl
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"greatestworks/aop/logtype"
@@ -27,7 +28,6 @@ import (
 	"greatestworks/aop/envelope"
 	"greatestworks/aop/logging"
 	"greatestworks/aop/metrics"
-	"greatestworks/aop/protomsg"
 	"greatestworks/aop/protos"
 	"greatestworks/aop/retry"
 	"github.com/google/uuid"
@@ -40,9 +40,13 @@ type babysitter struct {
 	ctx           context.Context
 	opts          envelope.Options
 	dep           *protos.Deployment
-	mgrAddr       string
+	replicaId     int32 // id of the colocation group replica
+	mgr           *managerAddr
+	telemetry     *telemetryClient // nil if the HTTP transport is used
 	logger        logtype.Logger
 	traceExporter *traceio.Writer // to export traces to the manager
+	logBatcher    *batcher[*protos.LogEntry]
+	spanBatcher   *batcher[*protos.Span]
 }
 
 var _ envelope.EnvelopeHandler = &babysitter{}
@@ -56,18 +60,38 @@ func RunBabysitter(ctx context.Context) error {
 		return fmt.Errorf("unable to retrieve deployment info: %w", err)
 	}
 
+	// The babysitter terminates if the manager asks it to stop.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Create the log saver.
-	fs, err := logging.NewFileStore(info.LogDir)
+	fs, err := logging.NewFileStoreWithOptions(info.LogDir, fileStoreOptions(info.LogRetention))
 	if err != nil {
 		return fmt.Errorf("cannot create log storage: %w", err)
 	}
 	logSaver := fs.Add
 
 	id := uuid.New().String()
+	mgr := &managerAddr{
+		prefix:   versionPrefix(info.Deployment.Id),
+		peers:    info.ManagerPeers,
+		addr:     info.ManagerAddr,
+		encoding: info.Encoding,
+	}
+	var telemetry *telemetryClient
+	if info.GrpcAddr != "" {
+		telemetry, err = newTelemetryClient(info.GrpcAddr, info.Deployment.Id)
+		if err != nil {
+			return err
+		}
+		defer telemetry.close()
+	}
 	b := &babysitter{
-		ctx:     ctx,
-		dep:     info.Deployment,
-		mgrAddr: info.ManagerAddr,
+		ctx:       ctx,
+		dep:       info.Deployment,
+		replicaId: info.ReplicaId,
+		mgr:       mgr,
+		telemetry: telemetry,
 		logger: logging.FuncLogger{
 			Opts: logging.Options{
 				App:        info.Deployment.App.Name,
@@ -78,16 +102,17 @@ func RunBabysitter(ctx context.Context) error {
 			},
 			Write: logSaver,
 		},
-		traceExporter: traceio.NewWriter(func(spans *protos.Spans) error {
-			return protomsg.Call(ctx, protomsg.CallArgs{
-				Client:  http.DefaultClient,
-				Addr:    info.ManagerAddr,
-				URLPath: recvTraceSpansURL,
-				Request: spans,
-			})
-		}),
 		opts: envelope.Options{Restart: envelope.OnFailure, Retry: retry.DefaultOptions},
 	}
+	b.traceExporter = traceio.NewWriter(b.exportSpans)
+
+	// Send log entries and trace spans to the manager in batches. The
+	// remaining batches are sent when the babysitter stops.
+	maxDelay := time.Duration(info.MaxBatchDelayMicros) * time.Microsecond
+	b.logBatcher = newBatcher(int(info.MaxBatchSize), maxDelay, b.sendLogEntries)
+	b.spanBatcher = newBatcher(int(info.MaxBatchSize), maxDelay, b.sendSpans)
+	defer b.logBatcher.flush()
+	defer b.spanBatcher.flush()
 
 	// Start the envelope.
 	wlet := &protos.WeaveletInfo{
@@ -104,15 +129,44 @@ func RunBabysitter(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	c := metricsCollector{logger: b.logger, envelope: e, info: info}
+	c := &metricsCollector{logger: b.logger, envelope: e, info: info, mgr: mgr, telemetry: telemetry}
 	go c.run(ctx)
+	h := heartbeater{logger: b.logger, info: info, mgr: mgr, envelope: e, stop: cancel}
+	go h.run(ctx)
+	go b.drainOnSignal(ctx, e, c)
 	return e.Run(ctx)
 }
 
+// drainOnSignal gracefully shuts down the weavelet when the babysitter is
+// asked to terminate (e.g., when the manager stops it): the weavelet finishes
+// its calls in progress and flushes its telemetry, and its final metrics are
+// sent to the manager before the weavelet is terminated.
+func (b *babysitter) drainOnSignal(ctx context.Context, e *envelope.Envelope, c *metricsCollector) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	select {
+	case <-sigs:
+	case <-ctx.Done():
+		return
+	}
+
+	b.logger.Info("Draining weavelet", "timeout", shutdownTimeout)
+	if err := e.Drain(shutdownTimeout); err != nil {
+		b.logger.Error("Unable to drain weavelet", err)
+	}
+	c.collect(ctx)
+	if err := e.Stop(); err != nil {
+		b.logger.Error("Unable to stop weavelet", err)
+	}
+}
+
 type metricsCollector struct {
-	logger   logtype.Logger
-	envelope *envelope.Envelope
-	info     *BabysitterInfo
+	logger    logtype.Logger
+	envelope  *envelope.Envelope
+	info      *BabysitterInfo
+	mgr       *managerAddr
+	telemetry *telemetryClient // nil if the HTTP transport is used
 }
 
 func (b *metricsCollector) run(ctx context.Context) {
@@ -121,30 +175,79 @@ func (b *metricsCollector) run(ctx context.Context) {
 	for {
 		select {
 		case <-tickerCollectMetrics.C:
-			ms, err := b.envelope.ReadMetrics()
-			if err != nil {
-				b.logger.Error("Unable to collect metrics", err)
-				continue
-			}
-			ms = append(ms, metrics.Snapshot()...)
+			b.collect(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
 
-			// Convert metrics to metrics protos.
-			var metrics []*protos.MetricSnapshot
-			for _, m := range ms {
-				metrics = append(metrics, m.ToProto())
-			}
-			if err := protomsg.Call(ctx, protomsg.CallArgs{
-				Client:  http.DefaultClient,
-				Addr:    b.info.ManagerAddr,
-				URLPath: recvMetricsURL,
-				Request: &BabysitterMetrics{
-					GroupName: b.info.Group.Name,
-					ReplicaId: b.info.ReplicaId,
-					Metrics:   metrics,
-				},
-			}); err != nil {
-				b.logger.Error("Error collecting metrics", err)
+// collect sends the latest metrics of the weavelet and the babysitter to the
+// manager.
+func (b *metricsCollector) collect(ctx context.Context) {
+	ms, err := b.envelope.ReadMetrics()
+	if err != nil {
+		b.logger.Error("Unable to collect metrics", err)
+		return
+	}
+	ms = append(ms, metrics.Snapshot()...)
+
+	// Convert metrics to metrics protos.
+	var metrics []*protos.MetricSnapshot
+	for _, m := range ms {
+		metrics = append(metrics, m.ToProto())
+	}
+	req := &BabysitterMetrics{
+		GroupName: b.info.Group.Name,
+		ReplicaId: b.info.ReplicaId,
+		Metrics:   metrics,
+	}
+	if b.telemetry != nil && b.telemetry.metrics.send(req) == nil {
+		return
+	}
+	if err := b.mgr.send(ctx, recvMetricsURL, req); err != nil {
+		b.logger.Error("Error collecting metrics", err)
+	}
+}
+
+// heartbeater periodically sends heartbeats to the manager, so that the
+// manager can detect and replace unhealthy replicas. The manager replies with
+// the config updates the babysitter has not applied yet, if any.
+type heartbeater struct {
+	logger   logtype.Logger
+	info     *BabysitterInfo
+	mgr      *managerAddr
+	envelope *envelope.Envelope
+	stop     func() // terminates the babysitter
+}
+
+func (h *heartbeater) run(ctx context.Context) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	gen := h.info.ConfigGeneration
+	for {
+		reply := &HeartbeatReply{}
+		if err := h.mgr.call(ctx, heartbeatURL, &HeartbeatRequest{
+			Group:            h.info.Group.Name,
+			ReplicaId:        h.info.ReplicaId,
+			ConfigGeneration: gen,
+		}, reply); err != nil {
+			h.logger.Error("Error sending heartbeat", err)
+		} else if reply.Stop {
+			h.logger.Info("Replica removed by the manager; stopping")
+			h.stop()
+			return
+		} else if reply.Config != nil {
+			// The envelope keeps the update even if the weavelet fails to
+			// apply it, so we don't retry failed updates.
+			if err := h.envelope.UpdateConfig(reply.Config); err != nil {
+				h.logger.Error("Error updating config", err, "generation", reply.Config.Generation)
 			}
+			gen = reply.Config.Generation
+		}
+
+		select {
+		case <-ticker.C:
 		case <-ctx.Done():
 			return
 		}
@@ -153,12 +256,7 @@ func (b *metricsCollector) run(ctx context.Context) {
 
 // StartComponent implements the protos.EnvelopeHandler interface.
 func (b *babysitter) StartComponent(req *protos.ComponentToStart) error {
-	return protomsg.Call(b.ctx, protomsg.CallArgs{
-		Client:  http.DefaultClient,
-		Addr:    b.mgrAddr,
-		URLPath: startComponentURL,
-		Request: req,
-	})
+	return b.mgr.call(b.ctx, startComponentURL, req, nil)
 }
 
 // RegisterReplica implements the protos.EnvelopeHandler interface.
@@ -166,17 +264,12 @@ func (b *babysitter) RegisterReplica(replica *protos.ReplicaToRegister) error {
 	b.logger.Info("Replica (re)started with new address",
 		"group", logging.ShortenComponent(replica.Group),
 		"address", replica.Address)
-	return protomsg.Call(b.ctx, protomsg.CallArgs{
-		Client:  http.DefaultClient,
-		Addr:    b.mgrAddr,
-		URLPath: registerReplicaURL,
-		Request: replica,
-	})
+	return b.mgr.call(b.ctx, registerReplicaURL, &RegisterReplicaRequest{ReplicaId: b.replicaId, Replica: replica}, nil)
 }
 
 // ReportLoad implements the protos.EnvelopeHandler interface.
-func (b *babysitter) ReportLoad(*protos.WeaveletLoadReport) error {
-	return nil
+func (b *babysitter) ReportLoad(report *protos.WeaveletLoadReport) error {
+	return b.mgr.call(b.ctx, reportLoadURL, report, nil)
 }
 
 // ExportListener implements the protos.EnvelopeHandler interface.
@@ -190,13 +283,7 @@ func (b *babysitter) GetAddress(req *protos.GetAddressRequest) (*protos.GetAddre
 
 func (b *babysitter) ExportListener(req *protos.ExportListenerRequest) (*protos.ExportListenerReply, error) {
 	reply := &protos.ExportListenerReply{}
-	if err := protomsg.Call(b.ctx, protomsg.CallArgs{
-		Client:  http.DefaultClient,
-		Addr:    b.mgrAddr,
-		URLPath: exportListenerURL,
-		Request: req,
-		Reply:   reply,
-	}); err != nil {
+	if err := b.mgr.call(b.ctx, exportListenerURL, &ExportReplicaListenerRequest{ReplicaId: b.replicaId, Request: req}, reply); err != nil {
 		return nil, err
 	}
 	return reply, nil
@@ -205,13 +292,7 @@ func (b *babysitter) ExportListener(req *protos.ExportListenerRequest) (*protos.
 // GetRoutingInfo implements the protos.EnvelopeHandler interface.
 func (b *babysitter) GetRoutingInfo(req *protos.GetRoutingInfo) (*protos.RoutingInfo, error) {
 	reply := &protos.RoutingInfo{}
-	if err := protomsg.Call(b.ctx, protomsg.CallArgs{
-		Client:  http.DefaultClient,
-		Addr:    b.mgrAddr,
-		URLPath: getRoutingInfoURL,
-		Request: req,
-		Reply:   reply,
-	}); err != nil {
+	if err := b.mgr.call(b.ctx, getRoutingInfoURL, req, reply); err != nil {
 		return nil, err
 	}
 	return reply, nil
@@ -220,26 +301,45 @@ func (b *babysitter) GetRoutingInfo(req *protos.GetRoutingInfo) (*protos.Routing
 // GetComponentsToStart implements the protos.EnvelopeHandler interface.
 func (b *babysitter) GetComponentsToStart(req *protos.GetComponentsToStart) (*protos.ComponentsToStart, error) {
 	reply := &protos.ComponentsToStart{}
-	err := protomsg.Call(b.ctx, protomsg.CallArgs{
-		Client:  http.DefaultClient,
-		Addr:    b.mgrAddr,
-		URLPath: getComponentsToStartURL,
-		Request: req,
-		Reply:   reply,
-	})
+	err := b.mgr.call(b.ctx, getComponentsToStartURL, req, reply)
 	return reply, err
 }
 
 // RecvLogEntry implements the protos.EnvelopeHandler interface.
 func (b *babysitter) RecvLogEntry(req *protos.LogEntry) {
-	err := protomsg.Call(b.ctx, protomsg.CallArgs{
-		Client:  http.DefaultClient,
-		Addr:    b.mgrAddr,
-		URLPath: recvLogEntryURL,
-		Request: req,
-	})
-	if err != nil {
-		b.logger.Error("Error receiving logs", err, "fromAddr", b.mgrAddr)
+	if b.telemetry != nil && b.telemetry.logs.send(req) == nil {
+		return
+	}
+	b.logBatcher.add(req)
+}
+
+// sendLogEntries sends a batch of log entries to the manager.
+func (b *babysitter) sendLogEntries(entries []*protos.LogEntry) {
+	// The last batches are sent after b.ctx is cancelled.
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryTimeout)
+	defer cancel()
+	if err := b.mgr.send(ctx, recvLogEntriesURL, &LogEntryBatch{Entries: entries}); err != nil {
+		b.logger.Error("Error receiving logs", err, "fromAddr", b.mgr.get(), "entries", len(entries))
+	}
+}
+
+// exportSpans exports trace spans to the manager.
+func (b *babysitter) exportSpans(spans *protos.Spans) error {
+	if b.telemetry != nil && b.telemetry.spans.send(spans) == nil {
+		return nil
+	}
+	for _, span := range spans.Span {
+		b.spanBatcher.add(span)
+	}
+	return nil
+}
+
+// sendSpans sends a batch of trace spans to the manager.
+func (b *babysitter) sendSpans(spans []*protos.Span) {
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryTimeout)
+	defer cancel()
+	if err := b.mgr.send(ctx, recvTraceSpansURL, &protos.Spans{Span: spans}); err != nil {
+		b.logger.Error("Error exporting trace spans", err, "fromAddr", b.mgr.get(), "spans", len(spans))
 	}
 }
 
@@ -0,0 +1,107 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"sync"
+	"time"
+)
+
+// telemetryTimeout bounds the sending of a batch of telemetry to the manager.
+const telemetryTimeout = 10 * time.Second
+
+// TelemetryOptions configures how babysitters send their telemetry (log
+// entries, trace spans, and metrics) to the manager over HTTP.
+type TelemetryOptions struct {
+	// MaxBatchSize is the maximum number of log entries, or of trace spans,
+	// that a babysitter sends in a single request. Defaults to 256. If one,
+	// every log entry is sent in its own request.
+	MaxBatchSize int
+
+	// MaxBatchDelay is the maximum time a log entry or a trace span waits in
+	// a batch before the batch is sent. Defaults to 100ms.
+	MaxBatchDelay time.Duration
+
+	// Encoding, if not empty, is the encoding with which the requests are
+	// compressed (protomsg.GzipEncoding or protomsg.ZstdEncoding). Metrics
+	// are already sent in batches, once per minute, but are compressed too.
+	Encoding string
+}
+
+// withDefaults returns a copy of the options with zero values replaced with
+// default values.
+func (o TelemetryOptions) withDefaults() TelemetryOptions {
+	if o.MaxBatchSize <= 0 {
+		o.MaxBatchSize = 256
+	}
+	if o.MaxBatchDelay <= 0 {
+		o.MaxBatchDelay = 100 * time.Millisecond
+	}
+	return o
+}
+
+// batcher groups items into batches. A batch is sent when it is full, or
+// when its oldest item has waited for the maximum delay. Batches are sent one
+// at a time, in order.
+type batcher[T any] struct {
+	maxSize  int
+	maxDelay time.Duration
+	send     func([]T) // sends a batch; called without mu held
+
+	sendMu sync.Mutex // serializes the sending of batches
+
+	mu    sync.Mutex
+	items []T         // the current batch
+	timer *time.Timer // flushes the current batch, if not empty
+}
+
+// newBatcher returns a batcher that sends batches of at most maxSize items
+// using the provided function. If maxSize is one or less, every item is sent
+// in its own batch as soon as it is added.
+func newBatcher[T any](maxSize int, maxDelay time.Duration, send func([]T)) *batcher[T] {
+	return &batcher[T]{maxSize: maxSize, maxDelay: maxDelay, send: send}
+}
+
+// add adds an item to the current batch. If the batch is full, add sends it.
+func (b *batcher[T]) add(item T) {
+	b.mu.Lock()
+	b.items = append(b.items, item)
+	full := len(b.items) >= b.maxSize
+	if !full && len(b.items) == 1 {
+		b.timer = time.AfterFunc(b.maxDelay, b.flush)
+	}
+	b.mu.Unlock()
+	if full {
+		b.flush()
+	}
+}
+
+// flush sends the current batch, if not empty.
+func (b *batcher[T]) flush() {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	b.mu.Lock()
+	items := b.items
+	b.items = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+	if len(items) > 0 {
+		b.send(items)
+	}
+}
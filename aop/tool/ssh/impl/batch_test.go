@@ -0,0 +1,82 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// recorder records the batches sent by a batcher.
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+}
+
+func (r *recorder) send(batch []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, batch)
+}
+
+func (r *recorder) get() [][]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.batches
+}
+
+func TestBatcherSize(t *testing.T) {
+	var r recorder
+	b := newBatcher(3, time.Hour, r.send)
+	for i := 0; i < 7; i++ {
+		b.add(i)
+	}
+	if diff := cmp.Diff([][]int{{0, 1, 2}, {3, 4, 5}}, r.get()); diff != "" {
+		t.Fatalf("batches (-want, +got):\n%s", diff)
+	}
+	b.flush()
+	if diff := cmp.Diff([][]int{{0, 1, 2}, {3, 4, 5}, {6}}, r.get()); diff != "" {
+		t.Fatalf("batches after flush (-want, +got):\n%s", diff)
+	}
+}
+
+func TestBatcherDelay(t *testing.T) {
+	var r recorder
+	b := newBatcher(100, 10*time.Millisecond, r.send)
+	b.add(1)
+	b.add(2)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(r.get()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if diff := cmp.Diff([][]int{{1, 2}}, r.get()); diff != "" {
+		t.Fatalf("batches (-want, +got):\n%s", diff)
+	}
+}
+
+func TestBatcherDisabled(t *testing.T) {
+	// A batcher with a size of zero sends every item in its own batch, e.g.,
+	// when the manager doesn't configure batching.
+	var r recorder
+	b := newBatcher(0, 0, r.send)
+	b.add(1)
+	b.add(2)
+	if diff := cmp.Diff([][]int{{1}, {2}}, r.get()); diff != "" {
+		t.Fatalf("batches (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"greatestworks/aop/chaos"
+	"greatestworks/aop/protomsg"
+	"greatestworks/aop/status"
+)
+
+// This file implements fault injection (see package chaos). The faults are
+// specified in the chaos section of the config of the deployment currently
+// serving traffic:
+//
+//   - Call faults (latency and errors) are injected by the weavelets, which
+//     receive the chaos section along with the other config sections (see
+//     config.go).
+//   - Replica kills are injected by the manager, which periodically stops a
+//     replica of the selected colocation groups and starts a replacement,
+//     like it does for unhealthy replicas.
+//
+// The faults can be enabled and disabled without restarting the deployment,
+// by calling SetChaos.
+
+// chaosInterval is how often the manager checks whether replicas must be
+// killed.
+const chaosInterval = time.Second
+
+// SetChaos asks the SSH manager running at the provided address to enable or
+// disable the faults injected into the deployment it manages.
+func SetChaos(ctx context.Context, mgrAddr string, req *SetChaosRequest) (*SetChaosReply, error) {
+	reply := &SetChaosReply{}
+	err := protomsg.Call(ctx, protomsg.CallArgs{
+		Client:  http.DefaultClient,
+		Addr:    "http://" + mgrAddr,
+		URLPath: setChaosURL,
+		Request: req,
+		Reply:   reply,
+	})
+	return reply, err
+}
+
+// setChaos enables or disables the faults injected into the deployment
+// currently serving traffic, by updating its chaos config section.
+func (m *manager) setChaos(_ context.Context, req *SetChaosRequest) (*SetChaosReply, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rollingOut {
+		return nil, fmt.Errorf("set chaos: a rollout is in progress")
+	}
+	v := m.versions[m.dep.Id]
+	sections, err := chaos.SetEnabled(v.sections, req.Enabled)
+	if err != nil {
+		return nil, fmt.Errorf("set chaos: %w", err)
+	}
+	if changed := v.applyConfig(sections); len(changed) > 0 {
+		m.logger.Info("Chaos set", "generation", v.configGen, "enabled", req.Enabled)
+		m.events.Record(v.dep.Id, status.EventConfigChanged,
+			"generation", strconv.FormatInt(v.configGen, 10), "chaos", strconv.FormatBool(req.Enabled))
+	}
+	return &SetChaosReply{Generation: v.configGen}, nil
+}
+
+// runChaos periodically kills the replicas specified in the chaos config
+// section, until the provided context is cancelled.
+func (m *manager) runChaos(ctx context.Context) {
+	ticker := time.NewTicker(chaosInterval)
+	defer ticker.Stop()
+	prev := time.Now()
+	for {
+		select {
+		case now := <-ticker.C:
+			m.killReplicas(prev, now)
+			prev = now
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// killReplicas kills a random replica of every colocation group whose kill
+// period started in (prev, now], and starts a replacement replica.
+func (m *manager) killReplicas(prev, now time.Time) {
+	var stops []func() error
+
+	m.mu.Lock()
+	v := m.versions[m.dep.Id]
+	config, err := chaos.Parse(v.sections)
+	if err != nil {
+		// The sections are validated before they are applied.
+		m.mu.Unlock()
+		m.logger.Error("Unable to parse the chaos config", err)
+		return
+	}
+	for _, group := range config.KillsDue(prev, now) {
+		var candidates []*replica
+		for _, r := range v.replicas {
+			if r.group == group {
+				candidates = append(candidates, r)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].id < candidates[j].id })
+		r := candidates[rand.Intn(len(candidates))]
+		m.logger.Info("Killing replica", "location", r.loc, "colocation group", r.group, "replica", r.id, "version", v.dep.Id)
+		stop, err := m.stopReplica(v, r)
+		if err != nil {
+			m.logger.Error("Unable to kill replica", err, "replica", r.id)
+			continue
+		}
+		stops = append(stops, stop)
+		m.events.Record(v.dep.Id, status.EventReplicaKilled,
+			"group", r.group, "replica", strconv.Itoa(int(r.id)), "location", r.loc)
+		if err := m.startReplica(v, r.group, r.loc); err != nil {
+			m.logger.Error("Unable to replace killed replica", err, "replica", r.id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, stop := range stops {
+		stop := stop
+		go func() {
+			if err := stop(); err != nil {
+				m.logger.Error("Unable to stop killed babysitter", err)
+			}
+		}()
+	}
+}
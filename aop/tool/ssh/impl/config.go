@@ -0,0 +1,257 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	gproto "google.golang.org/protobuf/proto"
+	"greatestworks/aop"
+	"greatestworks/aop/codegen"
+	"greatestworks/aop/logging"
+	"greatestworks/aop/protomsg"
+	"greatestworks/aop/protos"
+	"greatestworks/aop/status"
+)
+
+// This file implements hot config updates. The config sections of the
+// deployment currently serving traffic can be updated without restarting it,
+// either by calling UpdateConfig or, if ManagerOptions.ConfigFile is set, by
+// editing the config file.
+//
+// Every update of the config sections bumps the config generation of the
+// application version. Babysitters report the generation of their config in
+// their heartbeats, and the manager replies with the sections that changed
+// since then, which the babysitters push to their weavelets.
+//
+// Only the config sections can be updated; the other fields of the app config
+// (e.g., the binary, its arguments, or the colocation groups) require a new
+// deployment.
+//
+// The log levels of the components (see logging.LevelsConfig) can also be
+// set one at a time by calling SetLogLevel, which updates the log_levels
+// section. A config without a log_levels section keeps the current levels.
+
+// configDebounce is how long the manager waits for a config file to settle
+// after a change before it applies the file.
+const configDebounce = 500 * time.Millisecond
+
+// UpdateConfig asks the SSH manager running at the provided address to apply
+// the provided config to the deployment it manages.
+func UpdateConfig(ctx context.Context, mgrAddr string, req *UpdateConfigRequest) (*UpdateConfigReply, error) {
+	reply := &UpdateConfigReply{}
+	err := protomsg.Call(ctx, protomsg.CallArgs{
+		Client:  http.DefaultClient,
+		Addr:    "http://" + mgrAddr,
+		URLPath: updateConfigURL,
+		Request: req,
+		Reply:   reply,
+	})
+	return reply, err
+}
+
+// updateConfig validates the config in the provided request and applies its
+// sections to the deployment currently serving traffic.
+func (m *manager) updateConfig(_ context.Context, req *UpdateConfigRequest) (*UpdateConfigReply, error) {
+	app, err := aop.ParseConfig(req.File, req.Config, codegen.ComponentConfigValidator)
+	if err != nil {
+		return nil, fmt.Errorf("update config: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rollingOut {
+		return nil, fmt.Errorf("update config: a rollout is in progress")
+	}
+	v := m.versions[m.dep.Id]
+	if err := checkConfigUpdate(v.dep.App, app); err != nil {
+		return nil, fmt.Errorf("update config: %w", err)
+	}
+	changed := v.applyConfig(logging.KeepLevels(app.Sections, v.sections))
+	if len(changed) > 0 {
+		m.logger.Info("Config updated", "generation", v.configGen, "sections", changed)
+		m.events.Record(v.dep.Id, status.EventConfigChanged,
+			"generation", strconv.FormatInt(v.configGen, 10), "sections", strings.Join(changed, ","))
+	}
+	return &UpdateConfigReply{Generation: v.configGen, Changed: changed}, nil
+}
+
+// SetLogLevel asks the SSH manager running at the provided address to set the
+// log level of a component of the deployment it manages.
+func SetLogLevel(ctx context.Context, mgrAddr string, req *SetLogLevelRequest) (*SetLogLevelReply, error) {
+	reply := &SetLogLevelReply{}
+	err := protomsg.Call(ctx, protomsg.CallArgs{
+		Client:  http.DefaultClient,
+		Addr:    "http://" + mgrAddr,
+		URLPath: setLogLevelURL,
+		Request: req,
+		Reply:   reply,
+	})
+	return reply, err
+}
+
+// setLogLevel sets the log level of a component of the deployment currently
+// serving traffic, by updating its log_levels config section.
+func (m *manager) setLogLevel(_ context.Context, req *SetLogLevelRequest) (*SetLogLevelReply, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rollingOut {
+		return nil, fmt.Errorf("set log level: a rollout is in progress")
+	}
+	v := m.versions[m.dep.Id]
+	sections, err := logging.UpdateLevels(v.sections, req.Component, req.Level)
+	if err != nil {
+		return nil, fmt.Errorf("set log level: %w", err)
+	}
+	if changed := v.applyConfig(sections); len(changed) > 0 {
+		m.logger.Info("Log level set", "generation", v.configGen, "component", req.Component, "level", req.Level)
+		m.events.Record(v.dep.Id, status.EventConfigChanged,
+			"generation", strconv.FormatInt(v.configGen, 10), "component", req.Component, "level", req.Level)
+	}
+	return &SetLogLevelReply{Generation: v.configGen}, nil
+}
+
+// checkConfigUpdate checks that the provided app configs differ only in their
+// config sections.
+func checkConfigUpdate(old, new *protos.AppConfig) error {
+	o := gproto.Clone(old).(*protos.AppConfig)
+	n := gproto.Clone(new).(*protos.AppConfig)
+	o.Sections, n.Sections = nil, nil
+	if !gproto.Equal(o, n) {
+		return fmt.Errorf("only config sections can be updated; changes to the application settings (e.g., binary, args, env, colocation, resources) require a new deployment")
+	}
+	return nil
+}
+
+// applyConfig replaces the config sections of the application version with
+// the provided sections, and returns the keys of the added, changed, or
+// removed sections, in sorted order. If any section changed, the config
+// generation of the application version is bumped.
+//
+// REQUIRES: m.mu is held.
+func (v *appVersion) applyConfig(sections map[string]string) []string {
+	var changed []string
+	for k, s := range sections {
+		if old, ok := v.sections[k]; !ok || old != s {
+			changed = append(changed, k)
+		}
+	}
+	for k := range v.sections {
+		if _, ok := sections[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+	v.configGen++
+	for _, k := range changed {
+		v.changedAt[k] = v.configGen
+	}
+	v.sections = sections
+	return changed
+}
+
+// configUpdate returns the update that brings a babysitter whose config is of
+// the provided generation up to date, or nil if it is up to date.
+//
+// REQUIRES: m.mu is held.
+func (v *appVersion) configUpdate(gen int64) *protos.ConfigUpdate {
+	if gen >= v.configGen {
+		return nil
+	}
+	update := &protos.ConfigUpdate{Generation: v.configGen, Sections: map[string]string{}}
+	for k, g := range v.changedAt {
+		if g <= gen {
+			continue
+		}
+		if s, ok := v.sections[k]; ok {
+			update.Sections[k] = s
+		} else {
+			update.Removed = append(update.Removed, k)
+		}
+	}
+	sort.Strings(update.Removed)
+	return update
+}
+
+// deployment returns the deployment of the application version, with its
+// latest config sections.
+//
+// REQUIRES: m.mu is held.
+func (v *appVersion) deployment() *protos.Deployment {
+	if v.configGen == 0 {
+		return v.dep
+	}
+	dep := gproto.Clone(v.dep).(*protos.Deployment)
+	dep.App.Sections = v.sections
+	return dep
+}
+
+// watchConfig applies the provided config file whenever it changes, until
+// the manager's context is cancelled.
+func (m *manager) watchConfig(file string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// We watch the directory rather than the file, since editors often
+	// replace a file rather than write to it.
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		return err
+	}
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-m.ctx.Done():
+			return m.ctx.Err()
+		case err := <-watcher.Errors:
+			m.logger.Error("Config file watcher", err, "file", file)
+		case event := <-watcher.Events:
+			if filepath.Clean(event.Name) != filepath.Clean(file) {
+				continue
+			}
+			// Wait for the file to settle before applying it.
+			timer = time.After(configDebounce)
+		case <-timer:
+			timer = nil
+			config, err := os.ReadFile(file)
+			if err != nil {
+				m.logger.Error("Unable to read config file", err, "file", file)
+				continue
+			}
+			reply, err := m.updateConfig(m.ctx, &UpdateConfigRequest{Config: string(config), File: file})
+			if err != nil {
+				m.logger.Error("Unable to apply config file", err, "file", file)
+				continue
+			}
+			if len(reply.Changed) > 0 {
+				fmt.Fprintf(os.Stderr, "Applied config generation %d (changed: %v)\n", reply.Generation, reply.Changed)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"greatestworks/aop/protos"
+)
+
+func TestConfigUpdate(t *testing.T) {
+	v := newAppVersion(&protos.Deployment{
+		App: &protos.AppConfig{Sections: map[string]string{"a": "1", "b": "2"}},
+	})
+
+	// Unchanged sections don't bump the generation.
+	if changed := v.applyConfig(map[string]string{"a": "1", "b": "2"}); changed != nil || v.configGen != 0 {
+		t.Fatalf("applyConfig: got %v@%d, want nil@0", changed, v.configGen)
+	}
+	if update := v.configUpdate(0); update != nil {
+		t.Fatalf("configUpdate(0): got %v, want nil", update)
+	}
+
+	// Generation 1 changes a and removes b; generation 2 adds c.
+	if diff := cmp.Diff([]string{"a", "b"}, v.applyConfig(map[string]string{"a": "3"})); diff != "" {
+		t.Fatalf("applyConfig (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"c"}, v.applyConfig(map[string]string{"a": "3", "c": "4"})); diff != "" {
+		t.Fatalf("applyConfig (-want +got):\n%s", diff)
+	}
+
+	for _, test := range []struct {
+		gen  int64
+		want *protos.ConfigUpdate
+	}{
+		{0, &protos.ConfigUpdate{Generation: 2, Sections: map[string]string{"a": "3", "c": "4"}, Removed: []string{"b"}}},
+		{1, &protos.ConfigUpdate{Generation: 2, Sections: map[string]string{"c": "4"}}},
+		{2, nil},
+	} {
+		got := v.configUpdate(test.gen)
+		if diff := cmp.Diff(test.want, got, protocmp.Transform()); diff != "" {
+			t.Errorf("configUpdate(%d) (-want +got):\n%s", test.gen, diff)
+		}
+	}
+
+	// Babysitters started now get the latest sections.
+	if diff := cmp.Diff(map[string]string{"a": "3", "c": "4"}, v.deployment().App.Sections); diff != "" {
+		t.Errorf("deployment sections (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckConfigUpdate(t *testing.T) {
+	old := &protos.AppConfig{Name: "app", Binary: "/bin/app", Sections: map[string]string{"a": "1"}}
+	ok := &protos.AppConfig{Name: "app", Binary: "/bin/app", Sections: map[string]string{"a": "2"}}
+	if err := checkConfigUpdate(old, ok); err != nil {
+		t.Errorf("checkConfigUpdate: unexpected error: %v", err)
+	}
+	bad := &protos.AppConfig{Name: "app", Binary: "/bin/other", Sections: map[string]string{"a": "1"}}
+	if err := checkConfigUpdate(old, bad); err == nil {
+		t.Errorf("checkConfigUpdate: unexpected success for a changed binary")
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"greatestworks/aop/versioned_map"
+)
+
+// ErrNotLeader is returned by a Coordinator when a manager that is not the
+// leader tries to modify the shared state.
+var ErrNotLeader = errors.New("manager is not the leader")
+
+// A Coordinator is a coordination backend (e.g., etcd or Redis) shared by the
+// highly available managers of an application. The managers use it to elect
+// a leader, which is the only manager that manages the application, and to
+// share the state of the leader, so that a new leader can take over when the
+// leader fails. See NewRedisCoordinator for a Redis based implementation.
+type Coordinator interface {
+	// Campaign blocks until the manager with the provided base address
+	// (e.g., "http://host:port") becomes the leader, or until ctx is
+	// cancelled. It returns a channel that is closed when the manager loses
+	// its leadership (e.g., because it could not renew its lease in time).
+	Campaign(ctx context.Context, addr string) (<-chan struct{}, error)
+
+	// Resign gives up the leadership of the manager, if it is the leader.
+	Resign(ctx context.Context) error
+
+	// Leader returns the base address of the leader, or "" if there is no
+	// leader.
+	Leader(ctx context.Context) (string, error)
+
+	// Get returns the value of the provided key of the shared state, or nil
+	// if the key is not set.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// List returns the keys of the shared state with the provided prefix,
+	// and their values.
+	List(ctx context.Context, prefix string) (map[string][]byte, error)
+
+	// Put sets the value of the provided key of the shared state. It
+	// returns ErrNotLeader if the manager is not the leader.
+	Put(ctx context.Context, key string, value []byte) error
+
+	// Delete removes the keys of the shared state with the provided prefix.
+	// It returns ErrNotLeader if the manager is not the leader.
+	Delete(ctx context.Context, prefix string) error
+}
+
+// HAOptions configures the high-availability mode of a manager. See
+// RunHAManager.
+type HAOptions struct {
+	// Coordinator is the coordination backend shared by the managers.
+	Coordinator Coordinator
+
+	// Peers are the base addresses of all the managers of the application
+	// (e.g., "http://host:port"), including this one. Babysitters ask them
+	// for the address of the leader when the leader fails.
+	Peers []string
+
+	// Timeout bounds every operation on the coordination backend. Defaults
+	// to five seconds.
+	Timeout time.Duration
+}
+
+// withDefaults returns a copy of the options with zero values replaced with
+// default values.
+func (o HAOptions) withDefaults() HAOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	return o
+}
+
+// Keys of the shared state of highly available managers.
+const (
+	managerStateKey = "manager"
+	versionsKey     = "versions/"
+)
+
+// coordinatorStateStore is a stateStore that persists the state of a manager
+// in the shared state of a Coordinator, so that a new leader can recover it.
+type coordinatorStateStore struct {
+	opts HAOptions
+}
+
+var _ stateStore = &coordinatorStateStore{}
+
+// saveManager implements the stateStore interface.
+func (c *coordinatorStateStore) saveManager(data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+	return c.opts.Coordinator.Put(ctx, managerStateKey, data)
+}
+
+// loadManager implements the stateStore interface.
+func (c *coordinatorStateStore) loadManager() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+	data, err := c.opts.Coordinator.Get(ctx, managerStateKey)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrNoState
+	}
+	return data, nil
+}
+
+// versionStore implements the stateStore interface.
+func (c *coordinatorStateStore) versionStore(depId, name string) (versioned_map.Store, error) {
+	return &coordinatorStore{opts: c.opts, prefix: versionsKey + depId + "/" + name + "/"}, nil
+}
+
+// removeVersion implements the stateStore interface.
+func (c *coordinatorStateStore) removeVersion(depId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+	return c.opts.Coordinator.Delete(ctx, versionsKey+depId+"/")
+}
+
+// clear implements the stateStore interface.
+func (c *coordinatorStateStore) clear() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+	return c.opts.Coordinator.Delete(ctx, "")
+}
+
+// coordinatorStore is a versioned_map.Store that stores every key of a
+// versioned map in its own key of the shared state of a Coordinator.
+type coordinatorStore struct {
+	opts   HAOptions
+	prefix string
+}
+
+var _ versioned_map.Store = &coordinatorStore{}
+
+// Load implements the versioned_map.Store interface.
+func (c *coordinatorStore) Load() ([]versioned_map.Entry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+	values, err := c.opts.Coordinator.List(ctx, c.prefix)
+	if err != nil {
+		return nil, err
+	}
+	var entries []versioned_map.Entry
+	for key, value := range values {
+		var e versioned_map.Entry
+		if err := json.Unmarshal(value, &e); err != nil {
+			return nil, err
+		}
+		e.Key = strings.TrimPrefix(key, c.prefix)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Put implements the versioned_map.Store interface.
+func (c *coordinatorStore) Put(e versioned_map.Entry) error {
+	value, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+	return c.opts.Coordinator.Put(ctx, c.prefix+e.Key, value)
+}
@@ -0,0 +1,245 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// redisCoordinator is a Coordinator backed by Redis.
+//
+// The leader holds a lease: a key, set with SET NX, whose value identifies the
+// leader and which expires unless the leader keeps renewing it. The shared
+// state is stored in a hash. Writes to the hash are conditional on the lease,
+// so that a manager that lost its leadership (e.g., because it was partitioned
+// from Redis for longer than the lease) can't overwrite the state of the new
+// leader.
+//
+// Both keys share a hash tag, so that the scripts that access both of them
+// also work with Redis Cluster.
+type redisCoordinator struct {
+	client redis.UniversalClient
+	lease  time.Duration
+	leader string // key of the lease
+	state  string // key of the shared state
+
+	mu    sync.Mutex
+	value string // value of the lease held by this manager, or ""
+}
+
+var _ Coordinator = &redisCoordinator{}
+
+// DefaultLease is the default duration of the lease of the leader of highly
+// available managers.
+const DefaultLease = 10 * time.Second
+
+// Lua scripts that access the lease and the shared state atomically.
+var (
+	// KEYS = [lease], ARGV = [value, lease in ms]
+	renewScript = redis.NewScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+  return redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// KEYS = [lease], ARGV = [value]
+	resignScript = redis.NewScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+  return redis.call('del', KEYS[1])
+end
+return 0`)
+
+	// KEYS = [lease, state], ARGV = [value, field, field value]
+	putScript = redis.NewScript(`
+if redis.call('get', KEYS[1]) ~= ARGV[1] then
+  return -1
+end
+return redis.call('hset', KEYS[2], ARGV[2], ARGV[3])`)
+
+	// KEYS = [lease, state], ARGV = [value, prefix]
+	deleteScript = redis.NewScript(`
+if redis.call('get', KEYS[1]) ~= ARGV[1] then
+  return -1
+end
+local n = 0
+for _, f in ipairs(redis.call('hkeys', KEYS[2])) do
+  if string.sub(f, 1, #ARGV[2]) == ARGV[2] then
+    redis.call('hdel', KEYS[2], f)
+    n = n + 1
+  end
+end
+return n`)
+)
+
+// NewRedisCoordinator returns a Coordinator for the managers of the provided
+// application, backed by the provided Redis client. The leader must renew its
+// lease within the provided duration; if zero, DefaultLease is used.
+func NewRedisCoordinator(client redis.UniversalClient, app string, lease time.Duration) Coordinator {
+	if lease <= 0 {
+		lease = DefaultLease
+	}
+	tag := fmt.Sprintf("{serviceweaver/ssh/%s}", app)
+	return &redisCoordinator{
+		client: client,
+		lease:  lease,
+		leader: tag + "/leader",
+		state:  tag + "/state",
+	}
+}
+
+// Campaign implements the Coordinator interface.
+func (c *redisCoordinator) Campaign(ctx context.Context, addr string) (<-chan struct{}, error) {
+	value := uuid.NewString() + " " + addr
+	ticker := time.NewTicker(c.lease / 3)
+	defer ticker.Stop()
+	for {
+		ok, err := c.client.SetNX(ctx, c.leader, value, c.lease).Result()
+		if ok {
+			break
+		}
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	c.mu.Lock()
+	c.value = value
+	c.mu.Unlock()
+	lost := make(chan struct{})
+	go c.renew(ctx, value, lost)
+	return lost, nil
+}
+
+// renew renews the provided lease until it is lost, and then closes lost.
+func (c *redisCoordinator) renew(ctx context.Context, value string, lost chan struct{}) {
+	defer close(lost)
+	defer func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.value == value {
+			c.value = ""
+		}
+	}()
+
+	ticker := time.NewTicker(c.lease / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		n, err := renewScript.Run(ctx, c.client, []string{c.leader}, value, c.lease.Milliseconds()).Int()
+		switch {
+		case err == nil && n == 1:
+			renewed = time.Now()
+		case err == nil:
+			return // the lease expired, or the manager resigned
+		case time.Since(renewed) >= c.lease:
+			return // the lease may have expired
+		}
+	}
+}
+
+// Resign implements the Coordinator interface.
+func (c *redisCoordinator) Resign(ctx context.Context) error {
+	c.mu.Lock()
+	value := c.value
+	c.value = ""
+	c.mu.Unlock()
+	if value == "" {
+		return nil
+	}
+	return resignScript.Run(ctx, c.client, []string{c.leader}, value).Err()
+}
+
+// Leader implements the Coordinator interface.
+func (c *redisCoordinator) Leader(ctx context.Context) (string, error) {
+	value, err := c.client.Get(ctx, c.leader).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	_, addr, _ := strings.Cut(value, " ")
+	return addr, nil
+}
+
+// Get implements the Coordinator interface.
+func (c *redisCoordinator) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.HGet(ctx, c.state, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return value, err
+}
+
+// List implements the Coordinator interface.
+func (c *redisCoordinator) List(ctx context.Context, prefix string) (map[string][]byte, error) {
+	all, err := c.client.HGetAll(ctx, c.state).Result()
+	if err != nil {
+		return nil, err
+	}
+	values := map[string][]byte{}
+	for key, value := range all {
+		if strings.HasPrefix(key, prefix) {
+			values[key] = []byte(value)
+		}
+	}
+	return values, nil
+}
+
+// Put implements the Coordinator interface.
+func (c *redisCoordinator) Put(ctx context.Context, key string, value []byte) error {
+	return c.fenced(ctx, putScript, key, value)
+}
+
+// Delete implements the Coordinator interface.
+func (c *redisCoordinator) Delete(ctx context.Context, prefix string) error {
+	return c.fenced(ctx, deleteScript, prefix)
+}
+
+// fenced runs the provided script, which modifies the shared state only if
+// the manager holds the lease.
+func (c *redisCoordinator) fenced(ctx context.Context, script *redis.Script, args ...interface{}) error {
+	c.mu.Lock()
+	value := c.value
+	c.mu.Unlock()
+	if value == "" {
+		return ErrNotLeader
+	}
+	n, err := script.Run(ctx, c.client, []string{c.leader, c.state}, append([]interface{}{value}, args...)...).Int()
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return ErrNotLeader
+	}
+	return nil
+}
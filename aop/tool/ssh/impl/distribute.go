@@ -0,0 +1,214 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"greatestworks/aop/protos"
+	"greatestworks/aop/retry"
+)
+
+const (
+	// maxParallelUploads is the maximum number of locations to which files
+	// are uploaded concurrently.
+	maxParallelUploads = 8
+
+	// maxUploadAttempts is the maximum number of times the upload of a file
+	// to a location is attempted.
+	maxUploadAttempts = 5
+)
+
+// distFile is a file that is distributed to every location.
+type distFile struct {
+	local    string // local path
+	remote   string // remote path
+	size     int64  // size of the file, in bytes
+	checksum string // hex-encoded SHA-256 checksum of the file
+}
+
+// distributeBinaries copies the weaver binary and the application binary of
+// the provided deployment to every location, and updates the deployment to
+// refer to the remote application binary.
+//
+// The binaries are uploaded to the locations in parallel. A binary that is
+// already present at a location with the right checksum is not uploaded
+// again, and every uploaded binary is verified against its local checksum.
+// Binaries are first uploaded to a temporary file, which lets a failed
+// upload resume where it left off.
+func distributeBinaries(ctx context.Context, e Executor, locs []string, dep *protos.Deployment) error {
+	tool, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	remoteDir := filepath.Join(os.TempDir(), dep.Id)
+	remoteBinary := filepath.Join(remoteDir, filepath.Base(dep.App.Binary))
+	files := []*distFile{
+		// startBabysitter expects the weaver binary to be named "weaver".
+		{local: tool, remote: filepath.Join(remoteDir, "weaver")},
+		{local: dep.App.Binary, remote: remoteBinary},
+	}
+	for _, f := range files {
+		if err := f.stat(); err != nil {
+			return err
+		}
+	}
+
+	var mu sync.Mutex
+	var errs []string
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxParallelUploads)
+	for _, loc := range locs {
+		loc := loc
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := distributeTo(ctx, e, loc, remoteDir, files); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, fmt.Sprintf("location %s: %v", loc, err))
+			}
+		}()
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return fmt.Errorf("unable to distribute binaries: %s", strings.Join(errs, "; "))
+	}
+	dep.App.Binary = remoteBinary
+	return nil
+}
+
+// stat computes the size and the checksum of the local file.
+func (f *distFile) stat() error {
+	in, err := os.Open(f.local)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, in)
+	if err != nil {
+		return fmt.Errorf("checksum %q: %w", f.local, err)
+	}
+	f.size = n
+	f.checksum = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// distributeTo copies the provided files to the provided location.
+func distributeTo(ctx context.Context, e Executor, loc, remoteDir string, files []*distFile) error {
+	if _, err := e.Run(ctx, loc, shellQuote("mkdir", "-p", remoteDir), nil); err != nil {
+		return fmt.Errorf("unable to create deployment directory: %w", err)
+	}
+	for _, f := range files {
+		if err := upload(ctx, e, loc, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upload uploads a file to the provided location, unless the location already
+// has an identical copy of the file. Failed uploads are retried with
+// exponential backoff.
+func upload(ctx context.Context, e Executor, loc string, f *distFile) error {
+	if sum, err := remoteChecksum(ctx, e, loc, f.remote); err == nil && sum == f.checksum {
+		return nil
+	}
+	opts := retry.Options{BackoffMultiplier: 2, BackoffMinDuration: time.Second}
+	var lastErr error
+	attempts := 0
+	for r := retry.BeginWithOptions(opts); r.Continue(ctx) && attempts < maxUploadAttempts; attempts++ {
+		if lastErr = uploadOnce(ctx, e, loc, f); lastErr == nil {
+			return nil
+		}
+	}
+	if lastErr == nil {
+		lastErr = ctx.Err()
+	}
+	return fmt.Errorf("unable to upload %q after %d attempts: %w", f.local, attempts, lastErr)
+}
+
+// uploadOnce uploads a file to the provided location. The file is appended to
+// a temporary file, starting from the bytes already present in the temporary
+// file, and renamed once its checksum has been verified.
+func uploadOnce(ctx context.Context, e Executor, loc string, f *distFile) error {
+	tmp := f.remote + ".part"
+	out, err := e.Run(ctx, loc, fmt.Sprintf("stat -c %%s %s 2>/dev/null || echo 0", shellQuote(tmp)), nil)
+	if err != nil {
+		return err
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil || offset > f.size {
+		// Corrupted temporary file. Start from scratch.
+		offset = 0
+	}
+
+	in, err := os.Open(f.local)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if _, err := in.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	redirect := ">>"
+	if offset == 0 {
+		redirect = ">"
+	}
+	if _, err := e.Run(ctx, loc, fmt.Sprintf("cat %s %s", redirect, shellQuote(tmp)), in); err != nil {
+		return fmt.Errorf("copy %q: %w", f.local, err)
+	}
+
+	sum, err := remoteChecksum(ctx, e, loc, tmp)
+	if err != nil {
+		return err
+	}
+	if sum != f.checksum {
+		// Discard the temporary file, so that the next attempt starts from
+		// scratch.
+		e.Run(ctx, loc, shellQuote("rm", "-f", tmp), nil)
+		return fmt.Errorf("checksum mismatch for %q: got %s, want %s", f.remote, sum, f.checksum)
+	}
+	cmd := fmt.Sprintf("chmod +x %s && mv %s %s", shellQuote(tmp), shellQuote(tmp), shellQuote(f.remote))
+	_, err = e.Run(ctx, loc, cmd, nil)
+	return err
+}
+
+// remoteChecksum returns the hex-encoded SHA-256 checksum of a file at the
+// provided location.
+func remoteChecksum(ctx context.Context, e Executor, loc, file string) (string, error) {
+	out, err := e.Run(ctx, loc, shellQuote("sha256sum", file), nil)
+	if err != nil {
+		return "", fmt.Errorf("checksum %q: %w", file, err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("checksum %q at location %s: empty output", file, loc)
+	}
+	return fields[0], nil
+}
@@ -0,0 +1,387 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Executor executes commands at locations.
+//
+// Commands are shell command lines, interpreted by the default shell of the
+// location. Use shellQuote to quote arguments that may contain special
+// characters.
+type Executor interface {
+	// Run runs the provided command at the provided location, waits for it
+	// to complete, and returns its standard output. If stdin is not nil, it
+	// is used as the standard input of the command. If the command fails,
+	// the returned error includes its standard error.
+	Run(ctx context.Context, loc, cmd string, stdin io.Reader) ([]byte, error)
+
+	// Start starts the provided command at the provided location, without
+	// waiting for it to complete. The provided context only bounds the
+	// start of the command. The returned function blocks until the command
+	// completes; if the command fails, the returned error includes its
+	// standard error.
+	Start(ctx context.Context, loc, cmd string) (wait func() error, err error)
+
+	// Close releases the resources held by the executor.
+	Close() error
+}
+
+const (
+	// defaultSSHPort is the port used for locations that don't specify one.
+	defaultSSHPort = "22"
+
+	// maxSessionsPerConn is the maximum number of concurrent sessions
+	// multiplexed over a single SSH connection. sshd allows 10 sessions per
+	// connection by default (see MaxSessions in sshd_config).
+	maxSessionsPerConn = 8
+
+	// maxStderr is the maximum number of bytes of standard error captured
+	// for a command.
+	maxStderr = 4 << 10
+
+	// sshDialTimeout is the timeout for establishing an SSH connection.
+	sshDialTimeout = 30 * time.Second
+)
+
+// sshExecutor is an Executor that runs commands using the SSH protocol.
+//
+// Locations are of the form [user@]host[:port]. The user defaults to the
+// current user, and the port defaults to 22. Clients authenticate using the
+// keys held by the SSH agent (if SSH_AUTH_SOCK is set) and the default
+// private keys in ~/.ssh, and host keys are verified against
+// ~/.ssh/known_hosts.
+//
+// Connections are pooled: commands run at the same location share
+// connections, with up to maxSessionsPerConn sessions per connection.
+type sshExecutor struct {
+	auth     []ssh.AuthMethod
+	hostKeys ssh.HostKeyCallback
+	agent    net.Conn // connection to the SSH agent, or nil
+
+	mu    sync.Mutex
+	conns map[string][]*sshConn // open connections, by location
+}
+
+var _ Executor = &sshExecutor{}
+
+// sshConn is a pooled SSH connection.
+type sshConn struct {
+	client   *ssh.Client
+	sessions int  // number of open sessions; guarded by sshExecutor.mu
+	broken   bool // is the connection broken? guarded by sshExecutor.mu
+}
+
+// NewSSHExecutor returns a new Executor that runs commands using the SSH
+// protocol. See sshExecutor for details.
+func NewSSHExecutor() (Executor, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	hostKeys, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("load known hosts: %w", err)
+	}
+
+	e := &sshExecutor{hostKeys: hostKeys, conns: map[string][]*sshConn{}}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			e.agent = conn
+			e.auth = append(e.auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	var signers []ssh.Signer
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		key, err := os.ReadFile(filepath.Join(home, ".ssh", name))
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			// Passphrase-protected keys are expected to be held by the agent.
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		e.auth = append(e.auth, ssh.PublicKeys(signers...))
+	}
+	if len(e.auth) == 0 {
+		e.Close()
+		return nil, errors.New("no SSH credentials: start an SSH agent or create a key in ~/.ssh")
+	}
+	return e, nil
+}
+
+// Run implements the Executor interface.
+func (e *sshExecutor) Run(ctx context.Context, loc, cmd string, stdin io.Reader) ([]byte, error) {
+	session, release, err := e.session(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var stdout bytes.Buffer
+	stderr := &limitedBuffer{limit: maxStderr}
+	session.Stdin = stdin
+	session.Stdout = &stdout
+	session.Stderr = stderr
+	if err := session.Start(cmd); err != nil {
+		return nil, fmt.Errorf("run %q at location %s: %w", cmd, loc, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- session.Wait() }()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, commandError(loc, cmd, err, stderr)
+	}
+	return stdout.Bytes(), nil
+}
+
+// Start implements the Executor interface.
+func (e *sshExecutor) Start(ctx context.Context, loc, cmd string) (func() error, error) {
+	session, release, err := e.session(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	stderr := &limitedBuffer{limit: maxStderr}
+	session.Stderr = stderr
+	if err := session.Start(cmd); err != nil {
+		release()
+		return nil, fmt.Errorf("start %q at location %s: %w", cmd, loc, err)
+	}
+	wait := func() error {
+		defer release()
+		if err := session.Wait(); err != nil {
+			return commandError(loc, cmd, err, stderr)
+		}
+		return nil
+	}
+	return wait, nil
+}
+
+// Close implements the Executor interface.
+func (e *sshExecutor) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, conns := range e.conns {
+		for _, c := range conns {
+			c.client.Close()
+		}
+	}
+	e.conns = map[string][]*sshConn{}
+	if e.agent != nil {
+		e.agent.Close()
+	}
+	return nil
+}
+
+// session opens a new session at the provided location, reusing a pooled
+// connection if possible. The returned function closes the session and must
+// be called once the session is no longer used.
+func (e *sshExecutor) session(ctx context.Context, loc string) (*ssh.Session, func(), error) {
+	c, err := e.conn(ctx, loc)
+	if err != nil {
+		return nil, nil, err
+	}
+	session, err := c.client.NewSession()
+	if err != nil {
+		// The connection is likely broken (e.g., the location rebooted).
+		// Discard it and retry once with a fresh connection.
+		e.discard(loc, c)
+		if c, err = e.conn(ctx, loc); err != nil {
+			return nil, nil, err
+		}
+		if session, err = c.client.NewSession(); err != nil {
+			e.discard(loc, c)
+			return nil, nil, fmt.Errorf("open session at location %s: %w", loc, err)
+		}
+	}
+	release := func() {
+		session.Close()
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		c.sessions--
+		if c.broken && c.sessions == 0 {
+			c.client.Close()
+		}
+	}
+	return session, release, nil
+}
+
+// conn returns a connection to the provided location with room for one more
+// session, and reserves that session. It dials a new connection if none of
+// the pooled connections has room.
+func (e *sshExecutor) conn(ctx context.Context, loc string) (*sshConn, error) {
+	e.mu.Lock()
+	for _, c := range e.conns[loc] {
+		if c.sessions < maxSessionsPerConn {
+			c.sessions++
+			e.mu.Unlock()
+			return c, nil
+		}
+	}
+	e.mu.Unlock()
+
+	client, err := e.dial(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	c := &sshConn{client: client, sessions: 1}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.conns[loc] = append(e.conns[loc], c)
+	return c, nil
+}
+
+// discard removes the provided connection from the pool and releases the
+// session reserved on it.
+func (e *sshExecutor) discard(loc string, c *sshConn) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	conns := e.conns[loc]
+	for i, other := range conns {
+		if other == c {
+			e.conns[loc] = append(conns[:i], conns[i+1:]...)
+			break
+		}
+	}
+	c.sessions--
+	c.broken = true
+	if c.sessions == 0 {
+		c.client.Close()
+	}
+}
+
+// dial establishes a new SSH connection to the provided location.
+func (e *sshExecutor) dial(ctx context.Context, loc string) (*ssh.Client, error) {
+	username, addr, err := parseLocation(loc)
+	if err != nil {
+		return nil, err
+	}
+	config := &ssh.ClientConfig{
+		User:            username,
+		Auth:            e.auth,
+		HostKeyCallback: e.hostKeys,
+		Timeout:         sshDialTimeout,
+	}
+	dialer := net.Dialer{Timeout: sshDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial location %s: %w", loc, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh handshake with location %s: %w", loc, err)
+	}
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// parseLocation parses a location of the form [user@]host[:port] into a user
+// name and a dialable address.
+func parseLocation(loc string) (string, string, error) {
+	username := ""
+	host := loc
+	if i := strings.LastIndex(loc, "@"); i >= 0 {
+		username, host = loc[:i], loc[i+1:]
+	}
+	if username == "" {
+		u, err := user.Current()
+		if err != nil {
+			return "", "", fmt.Errorf("location %q: unknown user: %w", loc, err)
+		}
+		username = u.Username
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("location %q: missing host", loc)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), defaultSSHPort)
+	}
+	return username, host, nil
+}
+
+// commandError returns the error for a failed command, including the
+// captured standard error of the command.
+func commandError(loc, cmd string, err error, stderr *limitedBuffer) error {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%q at location %s: %w: %s", cmd, loc, err, msg)
+	}
+	return fmt.Errorf("%q at location %s: %w", cmd, loc, err)
+}
+
+// shellQuote quotes the provided arguments for the POSIX shell, and joins
+// them into a single command line.
+func shellQuote(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// limitedBuffer is an io.Writer that keeps the first limit bytes written to
+// it, and discards the rest.
+type limitedBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+// Write implements the io.Writer interface.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n := b.limit - b.buf.Len(); n > 0 {
+		if len(p) < n {
+			n = len(p)
+		}
+		b.buf.Write(p[:n])
+	}
+	return len(p), nil
+}
+
+// String returns the captured bytes.
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
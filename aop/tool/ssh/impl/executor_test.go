@@ -0,0 +1,49 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import "testing"
+
+func TestParseLocation(t *testing.T) {
+	for _, test := range []struct {
+		loc      string
+		wantUser string
+		wantAddr string
+	}{
+		{"alice@host", "alice", "host:22"},
+		{"alice@host:2222", "alice", "host:2222"},
+		{"alice@10.0.0.1", "alice", "10.0.0.1:22"},
+		{"alice@[::1]", "alice", "[::1]:22"},
+		{"alice@[::1]:2222", "alice", "[::1]:2222"},
+	} {
+		t.Run(test.loc, func(t *testing.T) {
+			user, addr, err := parseLocation(test.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user != test.wantUser || addr != test.wantAddr {
+				t.Fatalf("parseLocation(%q) = %q, %q; want %q, %q", test.loc, user, addr, test.wantUser, test.wantAddr)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	got := shellQuote("echo", "it's", "$HOME")
+	want := `'echo' 'it'\''s' '$HOME'`
+	if got != want {
+		t.Fatalf("shellQuote = %s, want %s", got, want)
+	}
+}
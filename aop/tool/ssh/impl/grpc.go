@@ -0,0 +1,248 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"greatestworks/aop/protos"
+)
+
+// This file implements the gRPC transport of the telemetry (log entries,
+// trace spans, and metrics) that babysitters send to the manager. With the
+// default HTTP transport, every log entry is sent in its own HTTP request,
+// which is expensive for babysitters that log thousands of entries per
+// second. With the gRPC transport, every babysitter opens one long-lived
+// stream per kind of telemetry, and sends every log entry as a message on its
+// stream.
+//
+// The control plane (e.g., heartbeats, routing info) remains over HTTP. If a
+// gRPC stream fails (e.g., the manager failed over to a new leader, see
+// ha.go), the babysitter sends the telemetry over HTTP instead.
+
+// Transports of the telemetry of the babysitters (see
+// ManagerOptions.Transport).
+const (
+	// HTTPTransport sends every log entry, batch of trace spans, and metrics
+	// snapshot in its own HTTP request. It is the default.
+	HTTPTransport = "http"
+
+	// GRPCTransport streams the telemetry over long-lived gRPC streams.
+	GRPCTransport = "grpc"
+)
+
+// deploymentIdKey is the gRPC metadata key that holds the deployment id of
+// the babysitter that opened a stream.
+const deploymentIdKey = "serviceweaver-deployment-id"
+
+// grpcServer implements the Manager gRPC service.
+type grpcServer struct {
+	UnimplementedManagerServer
+	m *manager
+}
+
+var _ ManagerServer = &grpcServer{}
+
+// serveGRPC serves the Manager gRPC service on the provided listener until
+// the manager's context is cancelled.
+func (m *manager) serveGRPC(lis net.Listener) error {
+	server := grpc.NewServer()
+	RegisterManagerServer(server, &grpcServer{m: m})
+	go func() {
+		<-m.ctx.Done()
+		server.Stop()
+	}()
+	return server.Serve(lis)
+}
+
+// version returns the application version of the babysitter that opened the
+// stream with the provided context.
+func (s *grpcServer) version(ctx context.Context) (*appVersion, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ids := md.Get(deploymentIdKey)
+	if len(ids) != 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "missing %s metadata", deploymentIdKey)
+	}
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	v, ok := s.m.versions[ids[0]]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "deployment %s not found", ids[0])
+	}
+	return v, nil
+}
+
+// SendLogEntries implements the ManagerServer interface.
+func (s *grpcServer) SendLogEntries(stream Manager_SendLogEntriesServer) error {
+	v, err := s.version(stream.Context())
+	if err != nil {
+		return err
+	}
+	for {
+		entry, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&StreamReply{})
+		} else if err != nil {
+			return err
+		}
+		if err := s.m.handleLogEntry(stream.Context(), v, entry); err != nil {
+			return err
+		}
+	}
+}
+
+// SendTraceSpans implements the ManagerServer interface.
+func (s *grpcServer) SendTraceSpans(stream Manager_SendTraceSpansServer) error {
+	v, err := s.version(stream.Context())
+	if err != nil {
+		return err
+	}
+	for {
+		spans, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&StreamReply{})
+		} else if err != nil {
+			return err
+		}
+		if err := s.m.handleTraceSpans(stream.Context(), v, spans); err != nil {
+			s.m.logger.Error("Unable to save trace spans", err, "version", v.dep.Id)
+		}
+	}
+}
+
+// SendMetrics implements the ManagerServer interface.
+func (s *grpcServer) SendMetrics(stream Manager_SendMetricsServer) error {
+	v, err := s.version(stream.Context())
+	if err != nil {
+		return err
+	}
+	for {
+		metrics, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&StreamReply{})
+		} else if err != nil {
+			return err
+		}
+		if err := s.m.handleRecvMetrics(stream.Context(), v, metrics); err != nil {
+			return err
+		}
+	}
+}
+
+// telemetryClient streams the telemetry of a babysitter to the manager over
+// gRPC.
+type telemetryClient struct {
+	conn    *grpc.ClientConn
+	cancel  context.CancelFunc
+	logs    clientStream[*protos.LogEntry]
+	spans   clientStream[*protos.Spans]
+	metrics clientStream[*BabysitterMetrics]
+}
+
+// newTelemetryClient returns a client that streams the telemetry of a
+// babysitter of the provided deployment to the gRPC server at the provided
+// address.
+func newTelemetryClient(addr, depId string) (*telemetryClient, error) {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("dial manager %s: %w", addr, err)
+	}
+	client := NewManagerClient(conn)
+
+	// The streams outlive the babysitter's context, so that they can be
+	// flushed when the babysitter stops (see close).
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = metadata.AppendToOutgoingContext(ctx, deploymentIdKey, depId)
+	t := &telemetryClient{conn: conn, cancel: cancel}
+	t.logs.open = func() (sender[*protos.LogEntry], error) {
+		return client.SendLogEntries(ctx)
+	}
+	t.spans.open = func() (sender[*protos.Spans], error) {
+		return client.SendTraceSpans(ctx)
+	}
+	t.metrics.open = func() (sender[*BabysitterMetrics], error) {
+		return client.SendMetrics(ctx)
+	}
+	return t, nil
+}
+
+// close flushes the streams, and closes the connection to the manager.
+func (t *telemetryClient) close() error {
+	defer t.cancel()
+	t.logs.close()
+	t.spans.close()
+	t.metrics.close()
+	return t.conn.Close()
+}
+
+// sender is a gRPC client stream.
+type sender[T any] interface {
+	Send(T) error
+	CloseAndRecv() (*StreamReply, error)
+}
+
+// clientStream is a gRPC client stream that is opened when the first message
+// is sent, and re-opened after a failure.
+type clientStream[T any] struct {
+	open func() (sender[T], error)
+
+	mu     sync.Mutex
+	stream sender[T] // nil if not open
+}
+
+// send sends the provided message on the stream.
+func (c *clientStream[T]) send(msg T) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stream == nil {
+		stream, err := c.open()
+		if err != nil {
+			return err
+		}
+		c.stream = stream
+	}
+	if err := c.stream.Send(msg); err != nil {
+		// The stream is broken. Re-open it on the next send. Send returns
+		// io.EOF when the manager ended the stream; the reason is returned
+		// by CloseAndRecv.
+		if _, rerr := c.stream.CloseAndRecv(); errors.Is(err, io.EOF) && rerr != nil {
+			err = rerr
+		}
+		c.stream = nil
+		return err
+	}
+	return nil
+}
+
+// close closes the stream, if open, and waits for the manager to receive all
+// the messages sent on it.
+func (c *clientStream[T]) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stream != nil {
+		c.stream.CloseAndRecv() //nolint:errcheck // best effort
+		c.stream = nil
+	}
+}
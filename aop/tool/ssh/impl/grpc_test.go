@@ -0,0 +1,126 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"greatestworks/aop/protos"
+)
+
+func TestGRPCTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var msgs []string
+	var spans int
+	m := testManager(t, "")
+	m.ctx = ctx
+	m.metrics = map[groupReplicaInfo][]*protos.MetricSnapshot{}
+	m.logSaver = func(entry *protos.LogEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		msgs = append(msgs, entry.Msg)
+	}
+	m.traceSaver = func(depId string, s *protos.Spans) error {
+		mu.Lock()
+		defer mu.Unlock()
+		spans += len(s.Span)
+		return nil
+	}
+	dep := &protos.Deployment{Id: "v1", App: &protos.AppConfig{Name: "app"}}
+	m.versions[dep.Id] = newAppVersion(dep)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go m.serveGRPC(lis)
+
+	// Stream telemetry to the manager.
+	client, err := newTelemetryClient(lis.Addr().String(), dep.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"a", "b", "c"} {
+		if err := client.logs.send(&protos.LogEntry{Msg: msg}); err != nil {
+			t.Fatalf("send log entry: %v", err)
+		}
+	}
+	if err := client.spans.send(&protos.Spans{Span: []*protos.Span{{Name: "s"}}}); err != nil {
+		t.Fatalf("send spans: %v", err)
+	}
+	if err := client.metrics.send(&BabysitterMetrics{GroupName: "main", ReplicaId: 1}); err != nil {
+		t.Fatalf("send metrics: %v", err)
+	}
+	if err := client.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing the client flushes the streams.
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := len(msgs), 3; got != want {
+		t.Errorf("log entries: got %d, want %d", got, want)
+	}
+	if got, want := spans, 1; got != want {
+		t.Errorf("spans: got %d, want %d", got, want)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.metrics[groupReplicaInfo{version: dep.Id, name: "main", id: 1}]; !ok {
+		t.Errorf("metrics not received")
+	}
+}
+
+func TestGRPCTransportUnknownDeployment(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := testManager(t, "")
+	m.ctx = ctx
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go m.serveGRPC(lis)
+
+	// The manager rejects the stream, so that the babysitter falls back to
+	// HTTP. Messages are sent asynchronously, so the rejection is only
+	// noticed by a later send.
+	client, err := newTelemetryClient(lis.Addr().String(), "unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.close()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		err := client.metrics.send(&BabysitterMetrics{})
+		if status.Code(err) == codes.NotFound {
+			return
+		} else if err != nil {
+			t.Fatalf("send metrics: got %v, want NotFound", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("send metrics to an unknown deployment: unexpected success")
+}
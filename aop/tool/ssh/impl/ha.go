@@ -0,0 +1,344 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	gproto "google.golang.org/protobuf/proto"
+	"greatestworks/aop/logging"
+	"greatestworks/aop/logtype"
+	"greatestworks/aop/protomsg"
+	"greatestworks/aop/protos"
+)
+
+// This file implements the high-availability mode of the manager. In this
+// mode, multiple managers of the same application run on different machines,
+// and elect a leader through a Coordinator. Only the leader manages the
+// application; it persists its state in the coordinator (see state.go).
+//
+// When the leader fails, a standby manager is elected, recovers the state of
+// the previous leader from the coordinator, and re-attaches to the running
+// babysitters. The babysitters are told the addresses of all the managers
+// (HAOptions.Peers), and ask them for the address of the new leader when
+// they fail to reach the previous one (see managerAddr).
+
+// standbyRetryInterval is how long an elected standby manager with no
+// deployment to take over waits before it campaigns again.
+const standbyRetryInterval = 10 * time.Second
+
+// haManager runs a manager in high-availability mode.
+type haManager struct {
+	ctx       context.Context
+	logger    logtype.Logger
+	logDir    string
+	opts      ManagerOptions
+	addr      string // listening address, host:port
+	dep       *protos.Deployment
+	locations []string
+
+	mu     sync.Mutex
+	leader *manager // the running manager, if this manager is the leader
+}
+
+// RunHAManager runs a manager of the provided application in
+// high-availability mode, configured by opts.HA.
+//
+// The manager campaigns to become the leader in the background. Once elected,
+// it takes over the deployment of the previous leader, if any, by recovering
+// its state from the coordinator. Otherwise, it runs the provided deployment.
+// If dep is nil, the manager is a standby: once elected, it resigns if there
+// is no deployment to take over, and campaigns again later.
+//
+// Until elected, the manager serves the address of the leader to the
+// babysitters. If the manager loses its leadership, it stops managing the
+// application, without stopping its babysitters, and campaigns again.
+//
+// The returned function stops the manager. If the manager is the leader, it
+// also stops the application.
+func RunHAManager(ctx context.Context, app string, dep *protos.Deployment, locations []string,
+	logDir string, opts ManagerOptions) (func() error, error) {
+	if opts.HA.Coordinator == nil {
+		return nil, fmt.Errorf("run manager: no coordinator provided")
+	}
+	if opts.Port == 0 {
+		return nil, fmt.Errorf("run manager: a highly available manager needs a fixed port")
+	}
+	opts.HA = opts.HA.withDefaults()
+	if opts.Host == "" {
+		opts.Host, _ = os.Hostname()
+	}
+	fs, err := logging.NewFileStoreWithOptions(logDir, opts.Logs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create log storage: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	h := &haManager{
+		ctx: ctx,
+		logger: logging.FuncLogger{
+			Opts: logging.Options{
+				App:       app,
+				Component: "manager",
+				Weavelet:  uuid.NewString(),
+				Attrs:     []string{"serviceweaver/system", ""},
+			},
+			Write: fs.Add,
+		},
+		logDir:    logDir,
+		opts:      opts,
+		addr:      net.JoinHostPort(opts.Host, fmt.Sprint(opts.Port)),
+		dep:       dep,
+		locations: locations,
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.run()
+	}()
+	return func() error {
+		h.mu.Lock()
+		leader := h.leader
+		h.leader = nil
+		h.mu.Unlock()
+		var err error
+		if leader != nil {
+			err = leader.stop()
+		}
+		cancel()
+		<-done
+		resignCtx, cancelResign := context.WithTimeout(context.Background(), opts.HA.Timeout)
+		defer cancelResign()
+		if rerr := opts.HA.Coordinator.Resign(resignCtx); rerr != nil && err == nil {
+			err = rerr
+		}
+		return err
+	}, nil
+}
+
+// run campaigns to become the leader, and manages the application while it
+// is the leader, until h.ctx is cancelled.
+func (h *haManager) run() {
+	for h.ctx.Err() == nil {
+		// Serve the address of the leader until elected.
+		standbyCtx, stopStandby := context.WithCancel(h.ctx)
+		standbyDone := make(chan struct{})
+		go func() {
+			defer close(standbyDone)
+			if err := h.serveStandby(standbyCtx); err != nil {
+				h.logger.Error("Unable to serve standby manager", err, "address", h.addr)
+			}
+		}()
+		h.logger.Info("Campaigning to become the leader", "address", h.addr)
+		lost, err := h.opts.HA.Coordinator.Campaign(h.ctx, "http://"+h.addr)
+		stopStandby()
+		<-standbyDone
+		if err != nil {
+			if h.ctx.Err() == nil {
+				h.logger.Error("Unable to campaign", err)
+				h.wait(standbyRetryInterval)
+			}
+			continue
+		}
+
+		h.logger.Info("Elected leader", "address", h.addr)
+		termCtx, endTerm := context.WithCancel(h.ctx)
+		m, err := h.lead(termCtx)
+		if err != nil {
+			if errors.Is(err, ErrNoState) {
+				h.logger.Info("No deployment to take over; resigning")
+			} else {
+				h.logger.Error("Unable to run the manager; resigning", err)
+			}
+			endTerm()
+			h.resign()
+			h.wait(standbyRetryInterval)
+			continue
+		}
+
+		select {
+		case <-lost:
+			h.logger.Error("Lost the leadership", nil, "address", h.addr)
+		case <-h.ctx.Done():
+		}
+		h.mu.Lock()
+		abandoned := h.leader == m // not stopped by the stop function
+		h.leader = nil
+		h.mu.Unlock()
+		endTerm()
+		if abandoned {
+			// Leave the babysitters running for the next leader.
+			m.launcher.Close()
+		}
+	}
+}
+
+// lead runs a manager that takes over the deployment of the previous leader,
+// if any, or that runs h.dep.
+func (h *haManager) lead(ctx context.Context) (*manager, error) {
+	m, err := recoverManager(ctx, h.logDir, h.opts)
+	if errors.Is(err, ErrNoState) && h.dep != nil {
+		m, err = prepareManager(ctx, h.dep, h.locations, h.logDir, h.opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The deployment is now in the shared state, from which later leaders
+	// recover it.
+	h.dep = nil
+
+	h.mu.Lock()
+	h.leader = m
+	h.mu.Unlock()
+	m.start()
+	return m, nil
+}
+
+// resign gives up the leadership of the manager.
+func (h *haManager) resign() {
+	ctx, cancel := context.WithTimeout(h.ctx, h.opts.HA.Timeout)
+	defer cancel()
+	if err := h.opts.HA.Coordinator.Resign(ctx); err != nil {
+		h.logger.Error("Unable to resign", err)
+	}
+}
+
+// wait waits for the provided duration, or until h.ctx is cancelled.
+func (h *haManager) wait(d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-h.ctx.Done():
+	}
+}
+
+// serveStandby serves the address of the leader to the babysitters until ctx
+// is cancelled.
+func (h *haManager) serveStandby(ctx context.Context) error {
+	lis, err := net.Listen("tcp", h.addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(leaderURL, protomsg.HandlerThunk(h.logger, func(ctx context.Context) (*LeaderReply, error) {
+		ctx, cancel := context.WithTimeout(ctx, h.opts.HA.Timeout)
+		defer cancel()
+		addr, err := h.opts.HA.Coordinator.Leader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &LeaderReply{Addr: addr}, nil
+	}))
+	return serveHTTP(ctx, lis, mux)
+}
+
+// Leader returns the address (host:port) of the leader of the highly
+// available managers that use the provided coordinator, or "" if there is no
+// leader.
+func Leader(ctx context.Context, coordinator Coordinator) (string, error) {
+	addr, err := coordinator.Leader(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(addr, "http://"), nil
+}
+
+// managerAddr is the address of the manager of a babysitter. In
+// high-availability mode, the address changes when a new manager becomes the
+// leader.
+type managerAddr struct {
+	prefix   string   // path prefix of the application version
+	peers    []string // base addresses of the highly available managers
+	encoding string   // encoding of the telemetry requests (see send)
+
+	mu   sync.Mutex
+	addr string // address of the manager, including prefix
+}
+
+// get returns the address of the manager.
+func (a *managerAddr) get() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.addr
+}
+
+// call calls the manager with the provided request, and stores the reply in
+// reply, if not nil. If the call fails and the manager is no longer the
+// leader, the call is retried once on the new leader.
+func (a *managerAddr) call(ctx context.Context, path string, req, reply gproto.Message) error {
+	return a.do(ctx, path, "", req, reply)
+}
+
+// send is like call, but for the telemetry requests, which are compressed
+// with a.encoding, and have no reply.
+func (a *managerAddr) send(ctx context.Context, path string, req gproto.Message) error {
+	return a.do(ctx, path, a.encoding, req, nil)
+}
+
+func (a *managerAddr) do(ctx context.Context, path, encoding string, req, reply gproto.Message) error {
+	addr := a.get()
+	err := a.callAt(ctx, addr, path, encoding, req, reply)
+	if err == nil || len(a.peers) == 0 || ctx.Err() != nil {
+		return err
+	}
+	if !a.failover(ctx, addr) {
+		return err
+	}
+	return a.callAt(ctx, a.get(), path, encoding, req, reply)
+}
+
+func (a *managerAddr) callAt(ctx context.Context, addr, path, encoding string, req, reply gproto.Message) error {
+	return protomsg.Call(ctx, protomsg.CallArgs{
+		Client:   http.DefaultClient,
+		Addr:     addr,
+		URLPath:  path,
+		Request:  req,
+		Reply:    reply,
+		Encoding: encoding,
+	})
+}
+
+// failover asks the peers for the address of the leader, and switches to it.
+// It returns whether the address changed. failed is the address of the
+// manager that failed to reply.
+func (a *managerAddr) failover(ctx context.Context, failed string) bool {
+	for _, peer := range a.peers {
+		reply := &LeaderReply{}
+		if err := a.callAt(ctx, peer, leaderURL, "", nil, reply); err != nil || reply.Addr == "" {
+			continue
+		}
+		addr := reply.Addr + a.prefix
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if a.addr != failed {
+			// Another call already failed over.
+			return true
+		}
+		if addr == a.addr {
+			return false
+		}
+		a.addr = addr
+		return true
+	}
+	return false
+}
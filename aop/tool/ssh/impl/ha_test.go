@@ -0,0 +1,205 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"greatestworks/aop/logging"
+	"greatestworks/aop/protomsg"
+	"greatestworks/aop/protos"
+)
+
+// memCoordinator is an in-memory Coordinator, for tests.
+type memCoordinator struct {
+	mu     sync.Mutex
+	leader string
+	state  map[string][]byte
+}
+
+var _ Coordinator = &memCoordinator{}
+
+func (c *memCoordinator) Campaign(_ context.Context, addr string) (<-chan struct{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.leader = addr
+	return make(chan struct{}), nil
+}
+
+func (c *memCoordinator) Resign(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.leader = ""
+	return nil
+}
+
+func (c *memCoordinator) Leader(context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.leader, nil
+}
+
+func (c *memCoordinator) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state[key], nil
+}
+
+func (c *memCoordinator) List(_ context.Context, prefix string) (map[string][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := map[string][]byte{}
+	for key, value := range c.state {
+		if strings.HasPrefix(key, prefix) {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func (c *memCoordinator) Put(_ context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.leader == "" {
+		return ErrNotLeader
+	}
+	if c.state == nil {
+		c.state = map[string][]byte{}
+	}
+	c.state[key] = value
+	return nil
+}
+
+func (c *memCoordinator) Delete(_ context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.leader == "" {
+		return ErrNotLeader
+	}
+	for key := range c.state {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.state, key)
+		}
+	}
+	return nil
+}
+
+func TestCoordinatorState(t *testing.T) {
+	ctx := context.Background()
+	coordinator := &memCoordinator{}
+	if _, err := coordinator.Campaign(ctx, "http://leader"); err != nil {
+		t.Fatal(err)
+	}
+	opts := ManagerOptions{HA: HAOptions{Coordinator: coordinator}}
+	newTestManager := func() *manager {
+		return &manager{
+			ctx:      ctx,
+			logger:   logging.NewTestLogger(t),
+			opts:     opts,
+			state:    newStateStore(opts),
+			versions: map[string]*appVersion{},
+			proxies:  map[string]*proxyInfo{},
+		}
+	}
+
+	// Persist the state of a version in the coordinator.
+	dep := &protos.Deployment{Id: "v1", App: &protos.AppConfig{Name: "app"}}
+	m := newTestManager()
+	m.dep = dep
+	m.mgrAddress = "http://leader"
+	v := newAppVersion(dep)
+	if err := m.persistVersion(v); err != nil {
+		t.Fatal(err)
+	}
+	m.versions[dep.Id] = v
+	v.routingState.Update(routingKey("main"), &protos.RoutingInfo{Replicas: []string{"tcp://r0"}})
+	m.saveState()
+	if _, err := m.state.loadManager(); err != nil {
+		t.Fatalf("loadManager: %v", err)
+	}
+
+	// A new leader recovers it.
+	recovered := newTestManager()
+	rv := newAppVersion(dep)
+	if err := recovered.persistVersion(rv); err != nil {
+		t.Fatal(err)
+	}
+	routing, _, err := recovered.loadRoutingState(rv, "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(routing.Replicas, ","), "tcp://r0"; got != want {
+		t.Errorf("recovered replicas: got %q, want %q", got, want)
+	}
+
+	// Stopping the deployment clears the state.
+	if err := recovered.state.clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recovered.state.loadManager(); err != ErrNoState {
+		t.Errorf("loadManager after clear: got %v, want %v", err, ErrNoState)
+	}
+}
+
+func TestManagerFailover(t *testing.T) {
+	ctx := context.Background()
+	prefix := versionPrefix("v1")
+
+	// The previous leader is dead.
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	// The new leader only replies to heartbeats.
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc(prefix+heartbeatURL, protomsg.HandlerFunc(logging.NewTestLogger(t), func(context.Context, *HeartbeatRequest) (*HeartbeatReply, error) {
+		return &HeartbeatReply{}, nil
+	}))
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		mux.ServeHTTP(w, r)
+	}))
+	defer leader.Close()
+
+	// A standby knows the new leader.
+	standbyMux := http.NewServeMux()
+	standbyMux.HandleFunc(leaderURL, protomsg.HandlerThunk(logging.NewTestLogger(t), func(context.Context) (*LeaderReply, error) {
+		return &LeaderReply{Addr: leader.URL}, nil
+	}))
+	standby := httptest.NewServer(standbyMux)
+	defer standby.Close()
+
+	a := &managerAddr{prefix: prefix, peers: []string{dead.URL, standby.URL}, addr: dead.URL + prefix}
+	if err := a.call(ctx, heartbeatURL, &HeartbeatRequest{}, &HeartbeatReply{}); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if got, want := a.get(), leader.URL+prefix; got != want {
+		t.Errorf("manager address: got %q, want %q", got, want)
+	}
+
+	// Failed calls to the leader are not retried.
+	if err := a.call(ctx, registerReplicaURL, &RegisterReplicaRequest{}, nil); err == nil {
+		t.Errorf("register replica: unexpected success")
+	}
+	if got, want := atomic.LoadInt32(&calls), int32(2); got != want {
+		t.Errorf("calls to the leader: got %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"time"
+)
+
+// heartbeat handles a heartbeat sent by the babysitter of a replica.
+func (m *manager) heartbeat(_ context.Context, v *appVersion, req *HeartbeatRequest) (*HeartbeatReply, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := v.replicas[req.ReplicaId]
+	if !ok {
+		// The replica has been removed (e.g., it was deemed unhealthy and
+		// replaced, or its version was stopped). Ask the babysitter to stop.
+		return &HeartbeatReply{Stop: true}, nil
+	}
+	r.lastHeartbeat = time.Now()
+	return &HeartbeatReply{Config: v.configUpdate(req.ConfigGeneration)}, nil
+}
+
+// checkHealth periodically checks the health of all replicas until the
+// provided context is cancelled. A replica that hasn't sent a heartbeat
+// for heartbeatTimeout is removed from the routing information of its
+// colocation group, and a replacement replica is started, preferably at a
+// different location.
+func (m *manager) checkHealth(ctx context.Context) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.replaceUnhealthyReplicas(time.Now())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// replaceUnhealthyReplicas replaces the replicas that haven't sent a
+// heartbeat since now - heartbeatTimeout.
+func (m *manager) replaceUnhealthyReplicas(now time.Time) {
+	var stops []func() error
+
+	m.mu.Lock()
+	for _, v := range m.versions {
+		for _, r := range v.replicas {
+			if now.Sub(r.lastHeartbeat) < heartbeatTimeout {
+				continue
+			}
+			m.logger.Error("Replica is unhealthy", nil, "location", r.loc, "colocation group", r.group,
+				"replica", r.id, "version", v.dep.Id, "last heartbeat", r.lastHeartbeat)
+			stop, err := m.stopReplica(v, r)
+			if err != nil {
+				m.logger.Error("Unable to remove unhealthy replica", err, "replica", r.id)
+				continue
+			}
+			stops = append(stops, stop)
+			if err := m.startReplica(v, r.group, r.loc); err != nil {
+				m.logger.Error("Unable to replace unhealthy replica", err, "replica", r.id)
+			}
+		}
+	}
+	// Persist the manager state periodically, to capture changes (e.g.,
+	// removed locations) that are not persisted when they happen.
+	m.saveState()
+	m.mu.Unlock()
+
+	// The babysitter of an unhealthy replica may already be dead, or its
+	// location may be unreachable, so we stop it on a best-effort basis. If
+	// the babysitter is alive, it will also stop itself on its next
+	// heartbeat.
+	for _, stop := range stops {
+		stop := stop
+		go func() {
+			if err := stop(); err != nil {
+				m.logger.Debug("Unable to stop unhealthy babysitter", "err", err)
+			}
+		}()
+	}
+}
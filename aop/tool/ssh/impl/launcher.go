@@ -0,0 +1,107 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"greatestworks/aop/logtype"
+	"greatestworks/aop/protos"
+)
+
+// Launcher launches and stops the babysitters of colocation group replicas.
+// Every babysitter runs at a location, and is identified by a tag that is
+// unique across all babysitters.
+type Launcher interface {
+	// Prepare prepares the provided locations to run the babysitters of the
+	// provided deployment. It may update the deployment (e.g., to refer to
+	// the application binary at the locations).
+	Prepare(ctx context.Context, locs []string, dep *protos.Deployment) error
+
+	// Launch launches a babysitter of the provided deployment at the provided
+	// location. env contains the environment variables, in KEY=VALUE form,
+	// that the babysitter must run with.
+	Launch(ctx context.Context, loc string, dep *protos.Deployment, tag string, env []string) error
+
+	// Stop stops the babysitter with the provided tag.
+	Stop(ctx context.Context, loc string, tag string) error
+
+	// StopAll stops all the babysitters of the provided deployment at the
+	// provided location.
+	StopAll(ctx context.Context, loc string, dep *protos.Deployment) error
+
+	// Close releases the resources held by the launcher.
+	Close() error
+}
+
+// sshLauncher is a Launcher that runs babysitters as processes at the
+// locations, using an Executor. Prepare copies the weaver and application
+// binaries to every location.
+type sshLauncher struct {
+	executor Executor
+	logger   logtype.Logger
+}
+
+var _ Launcher = &sshLauncher{}
+
+// Prepare implements the Launcher interface.
+func (l *sshLauncher) Prepare(ctx context.Context, locs []string, dep *protos.Deployment) error {
+	return distributeBinaries(ctx, l.executor, locs, dep)
+}
+
+// Launch implements the Launcher interface.
+func (l *sshLauncher) Launch(ctx context.Context, loc string, dep *protos.Deployment, tag string, env []string) error {
+	var cmd strings.Builder
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		fmt.Fprintf(&cmd, "%s=%s ", k, shellQuote(v))
+	}
+	binaryPath := filepath.Join(os.TempDir(), dep.Id, "weaver")
+	cmd.WriteString(shellQuote(binaryPath, "ssh", "babysitter", tag))
+	wait, err := l.executor.Start(ctx, loc, cmd.String())
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := wait(); err != nil {
+			l.logger.Error("Babysitter exited", err, "location", loc, "tag", tag, "version", dep.Id)
+		}
+	}()
+	return nil
+}
+
+// Stop implements the Launcher interface.
+func (l *sshLauncher) Stop(ctx context.Context, loc string, tag string) error {
+	_, err := l.executor.Run(ctx, loc, shellQuote("pkill", "-f", tag), nil)
+	return err
+}
+
+// StopAll implements the Launcher interface.
+//
+// TODO(rgrandl): Find a different way to kill the deployment if the pkill command
+// is not installed.
+func (l *sshLauncher) StopAll(ctx context.Context, loc string, dep *protos.Deployment) error {
+	_, err := l.executor.Run(ctx, loc, shellQuote("pkill", "-f", dep.Id), nil)
+	return err
+}
+
+// Close implements the Launcher interface.
+func (l *sshLauncher) Close() error {
+	return l.executor.Close()
+}
@@ -0,0 +1,194 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"greatestworks/aop/protomsg"
+	"greatestworks/aop/proxy"
+)
+
+// RemoveLocation asks the SSH manager running at the provided address to
+// drain and remove the provided location. It blocks until the location has
+// been removed.
+func RemoveLocation(ctx context.Context, mgrAddr string, loc string) error {
+	return protomsg.Call(ctx, protomsg.CallArgs{
+		Client:  http.DefaultClient,
+		Addr:    "http://" + mgrAddr,
+		URLPath: removeLocationURL,
+		Request: &RemoveLocationRequest{Location: loc},
+	})
+}
+
+// RemoveLocation drains and removes the provided location, which enables
+// planned maintenance of the location without downtime. In particular:
+//
+//  1. No new replicas are started at the location.
+//  2. Every replica running at the location is replaced by a replica running
+//     at a different location.
+//  3. Once the replacement replicas are registered, the replicas running at
+//     the location are removed from the routing information of their
+//     colocation groups, and the proxies stop sending them traffic.
+//  4. Once the in-flight traffic to the replicas running at the location
+//     completes (or drainTimeout elapses), their babysitters are stopped.
+func (m *manager) RemoveLocation(ctx context.Context, loc string) error {
+	m.mu.Lock()
+	idx := -1
+	for i, l := range m.locations {
+		if l == loc {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		m.mu.Unlock()
+		return fmt.Errorf("location %q not found", loc)
+	}
+	if len(m.locations) == 1 {
+		m.mu.Unlock()
+		return fmt.Errorf("cannot remove %q: it is the only location", loc)
+	}
+	m.locations = append(m.locations[:idx], m.locations[idx+1:]...)
+
+	// Start the replacement replicas.
+	type drained struct {
+		v *appVersion
+		r *replica
+	}
+	var toDrain []drained
+	var replacements []*replica
+	for _, v := range m.versions {
+		for _, r := range v.replicas {
+			if r.loc != loc {
+				continue
+			}
+			toDrain = append(toDrain, drained{v, r})
+			id := v.nextReplicaId
+			if err := m.startReplica(v, r.group, loc); err != nil {
+				m.logger.Error("Unable to replace drained replica", err, "replica", r.id, "location", loc)
+				continue
+			}
+			replacements = append(replacements, v.replicas[id])
+		}
+	}
+	m.mu.Unlock()
+	m.logger.Info("Draining location", "location", loc, "replicas", len(toDrain))
+
+	// Wait for the replacement replicas to register.
+	if err := m.waitForReplicas(ctx, replacements); err != nil {
+		m.logger.Error("Replacement replicas not ready; draining anyway", err, "location", loc)
+	}
+
+	// Stop routing traffic to the drained replicas.
+	var stops []func() error
+	var listeners []string
+	m.mu.Lock()
+	for _, d := range toDrain {
+		if _, ok := d.v.replicas[d.r.id]; !ok {
+			// The replica has been removed in the meantime.
+			continue
+		}
+		stop, err := m.stopReplica(d.v, d.r)
+		if err != nil {
+			m.mu.Unlock()
+			return err
+		}
+		stops = append(stops, stop)
+		listeners = append(listeners, d.r.listeners...)
+	}
+	m.mu.Unlock()
+
+	// Wait for the in-flight traffic to complete, and stop the babysitters.
+	m.waitForDrain(ctx, listeners)
+	for _, stop := range stops {
+		if err := stop(); err != nil {
+			m.logger.Error("Unable to stop drained babysitter", err, "location", loc)
+		}
+	}
+	m.logger.Info("Removed location", "location", loc)
+	return nil
+}
+
+// waitForReplicas blocks until all of the provided replicas have registered
+// their weavelets with the manager, or until heartbeatTimeout elapses.
+func (m *manager) waitForReplicas(ctx context.Context, replicas []*replica) error {
+	ctx, cancel := context.WithTimeout(ctx, heartbeatTimeout)
+	defer cancel()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		m.mu.Lock()
+		ready := true
+		for _, r := range replicas {
+			if r.addr == "" {
+				ready = false
+				break
+			}
+		}
+		m.mu.Unlock()
+		if ready {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForDrain blocks until the proxies don't have any in-flight requests or
+// open connections (e.g., WebSocket connections) to the provided listener
+// addresses, or until drainTimeout elapses, in which case the remaining
+// connections are closed. It always waits for at least one heartbeat
+// interval, to give weavelets a chance to pick up the latest routing
+// information.
+func (m *manager) waitForDrain(ctx context.Context, listeners []string) {
+	ctx, cancel := context.WithTimeout(ctx, drainTimeout)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(heartbeatInterval):
+	}
+
+	m.mu.Lock()
+	proxies := make([]*proxy.Proxy, 0, len(m.proxies))
+	for _, p := range m.proxies {
+		proxies = append(proxies, p.proxy)
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range proxies {
+		for _, addr := range listeners {
+			p, addr := p, addr
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := p.DrainBackend(ctx, addr); err != nil {
+					m.logger.Error("Closed connections to drained listener", err, "address", addr)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
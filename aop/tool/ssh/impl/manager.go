@@ -22,18 +22,19 @@ import (
 	"net"
 	"net/http"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 	"greatestworks/aop/files"
 	"greatestworks/aop/metrics"
 	imetrics "greatestworks/aop/metrics"
-	"greatestworks/aop/perfetto"
 	"greatestworks/aop/protos"
 
 	"github.com/google/uuid"
@@ -41,6 +42,7 @@ import (
 	gproto "google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"greatestworks/aop/logging"
+	"greatestworks/aop/logging/sinks"
 	"greatestworks/aop/logtype"
 	"greatestworks/aop/proto"
 	"greatestworks/aop/protomsg"
@@ -59,8 +61,22 @@ const (
 	startComponentURL       = "/manager/start_component"
 	getRoutingInfoURL       = "/manager/get_routing_info"
 	recvLogEntryURL         = "/manager/recv_log_entry"
+	recvLogEntriesURL       = "/manager/recv_log_entries"
 	recvTraceSpansURL       = "/manager/recv_trace_spans"
 	recvMetricsURL          = "/manager/recv_metrics"
+	rolloutURL              = "/manager/rollout"
+	heartbeatURL            = "/manager/heartbeat"
+	removeLocationURL       = "/manager/remove_location"
+	updateConfigURL         = "/manager/update_config"
+	setLogLevelURL          = "/manager/set_log_level"
+	setChaosURL             = "/manager/set_chaos"
+	reportLoadURL           = "/manager/report_load"
+	leaderURL               = "/manager/leader"
+
+	// versionURLPrefix is the URL prefix under which the manager handlers for
+	// a given application version are registered. Every babysitter talks to
+	// the handlers of the application version it belongs to.
+	versionURLPrefix = "/version/"
 
 	// babysitterInfoKey is the name of the env variable that contains deployment
 	// information for a babysitter deployed using SSH.
@@ -71,22 +87,56 @@ const (
 
 	// appVersionStateKey is the key where we track the state for a given application version.
 	appVersionStateKey = "app_version_state"
+
+	// Default rollout parameters, used if a rollout request doesn't specify
+	// them.
+	defaultRolloutSteps    = 5
+	defaultRolloutInterval = 30 * time.Second
+
+	// listenerWaitTimeout is how long a rollout waits for the new application
+	// version to export all the listeners exported by the old version.
+	listenerWaitTimeout = 5 * time.Minute
+
+	// rollbackTimeout bounds the registry calls undoing a failed rollout,
+	// which don't use the context of the rollout, as it may be what failed.
+	rollbackTimeout = 30 * time.Second
+
+	// heartbeatInterval is how often babysitters send heartbeats to the
+	// manager, and how often the manager checks the health of the replicas.
+	heartbeatInterval = 5 * time.Second
+
+	// heartbeatTimeout is how long a replica can go without sending a
+	// heartbeat before the manager considers it unhealthy. A newly started
+	// replica has heartbeatTimeout to send its first heartbeat.
+	heartbeatTimeout = 30 * time.Second
+
+	// drainTimeout is the maximum time to wait for the in-flight traffic of
+	// the replicas at a removed location to complete.
+	drainTimeout = time.Minute
+
+	// shutdownTimeout is the maximum time a babysitter that is asked to
+	// terminate waits for the in-flight calls of its weavelet to complete.
+	shutdownTimeout = 10 * time.Second
 )
 
 // manager manages an application version deployment across a set of locations,
-// where a location can be a physical or a virtual machine.
+// where a location can be a physical or a virtual machine. During a rolling
+// update, the manager runs the old and the new application versions side by
+// side (see rollout).
 //
 // TODO(rgrandl): Right now there is a lot of duplicate code between the
 // internal/babysitter and the internal/tool/ssh/impl/manager. See if we can reduce the
 // duplicated code.
 type manager struct {
 	ctx        context.Context
-	dep        *protos.Deployment
 	logger     logtype.Logger
 	logDir     string
-	locations  []string // addresses of the locations
-	mgrAddress string   // manager address
+	mgrAddress string // manager address
+	grpcAddr   string // address of the gRPC server, if GRPCTransport is used
 	registry   *status.Registry
+	mux        *http.ServeMux // mux on which version handlers are registered
+	opts       ManagerOptions
+	launcher   Launcher // launches babysitters at the locations
 
 	// logSaver processes log entries generated by the weavelets and babysitters.
 	// The entries either have the timestamp produced by the weavelet/babysitter,
@@ -97,40 +147,226 @@ type manager struct {
 	// be thread safe.
 	logSaver func(*protos.LogEntry)
 
-	// traceSaver processes trace spans generated by the weavelet. If nil,
-	// weavelet traces are dropped.
+	// traceSaver processes trace spans generated by the weavelets of a given
+	// deployment. If nil, weavelet traces are dropped.
 	//
 	// traceSaver is called concurrently from multiple goroutines, so it should
 	// be thread safe.
-	traceSaver func(spans *protos.Spans) error
+	traceSaver func(depId string, spans *protos.Spans) error
 
 	// statsProcessor tracks and computes stats to be rendered on the /statusz page.
 	statsProcessor *imetrics.StatsProcessor
 
-	mu           sync.Mutex
-	started      map[string]bool //  colocation groups started, by group name
-	appState     *versioned_map.Map[*AppVersionState]
-	routingState *versioned_map.Map[*protos.RoutingInfo]
-	proxies      map[string]*proxyInfo                         // proxies, by listener name
-	metrics      map[groupReplicaInfo][]*protos.MetricSnapshot // latest metrics, by group name and replica id
+	// autoscaler adjusts the number of replicas of every colocation group. It
+	// is nil if autoscaling is disabled.
+	autoscaler *autoscaler
+
+	// events records the control-plane events of the deployment.
+	events *status.EventRecorder
+
+	// alerter evaluates the alerting rules of the deployment. It is nil if no
+	// alerting rule is configured.
+	alerter *alerter
+
+	// recovered is the state of a previous manager recovered by
+	// RecoverManager, or nil. See state.go.
+	recovered *ManagerState
+
+	// state persists the state of the manager, or is nil if the manager
+	// doesn't persist its state. See state.go.
+	state stateStore
+
+	// savedState is the latest persisted state.
+	savedState []byte
+
+	mu         sync.Mutex
+	locations  []string                                      // addresses of the locations
+	dep        *protos.Deployment                            // deployment currently serving traffic
+	versions   map[string]*appVersion                        // application versions, by deployment id
+	rollingOut bool                                          // is a rollout in progress?
+	proxies    map[string]*proxyInfo                         // proxies, by listener name
+	metrics    map[groupReplicaInfo][]*protos.MetricSnapshot // latest metrics, by version, group name and replica id
+}
+
+// appVersion contains the state the manager maintains for a single
+// application version (i.e., deployment). Multiple application versions are
+// running side by side during a rollout.
+type appVersion struct {
+	dep           *protos.Deployment
+	started       map[string]bool    // colocation groups started, by group name
+	replicas      map[int32]*replica // colocation group replicas, by replica id
+	nextReplicaId int32              // id of the next replica to start
+	appState      *versioned_map.Map[*AppVersionState]
+	routingState  *versioned_map.Map[*protos.RoutingInfo]
+
+	// Config sections, see config.go.
+	sections  map[string]string // latest config sections
+	configGen int64             // generation of sections
+	changedAt map[string]int64  // generation at which a section last changed, by key
+
+	// Load reports of routed components, see rebalance.go.
+	loads        map[string]componentLoads // latest load reports, by component
+	rebalancedAt map[string]time.Time      // time of the last rebalance, by component
+
+	// Remote log sink of the version, see logSink.
+	sinkOnce sync.Once  // creates sink
+	sink     sinks.Sink // nil if the version doesn't configure a sink
+}
+
+// replica is a replica of a colocation group, i.e., a babysitter and the
+// weavelet it manages.
+type replica struct {
+	id            int32     // replica id, unique within an application version
+	group         string    // colocation group name
+	loc           string    // location at which the babysitter runs
+	tag           string    // unique tag that identifies the babysitter process
+	addr          string    // weavelet address, or empty if not registered yet
+	pid           int64     // weavelet pid
+	listeners     []string  // addresses of the listeners exported by the weavelet
+	lastHeartbeat time.Time // time of the last heartbeat, or start time
 }
 
 type proxyInfo struct {
-	proxy *proxy.Proxy
-	addr  string // dialable address of the proxy
+	proxy    *proxy.Proxy
+	addr     string              // dialable address of the proxy
+	backends map[string][]string // backend addresses, by deployment id
 }
 
 type groupReplicaInfo struct {
-	name string
-	id   int32
+	version string
+	name    string
+	id      int32
 }
 
 var _ status.Server = &manager{}
 
+// ManagerOptions configures a manager.
+type ManagerOptions struct {
+	// Autoscaler configures the autoscaling of colocation group replicas. If
+	// Autoscaler.MaxReplicas is zero, autoscaling is disabled and every
+	// colocation group runs one replica per location.
+	Autoscaler AutoscalerOptions
+
+	// Alerts configures the alerting rules evaluated against the metrics of
+	// the application.
+	Alerts AlertOptions
+
+	// Executor executes commands at the locations. Defaults to an executor
+	// that uses the SSH protocol (see NewSSHExecutor). Ignored if Launcher
+	// is set.
+	Executor Executor
+
+	// Launcher launches the babysitters at the locations. Defaults to a
+	// launcher that copies the binaries to every location and runs the
+	// babysitters as processes, using Executor.
+	Launcher Launcher
+
+	// Host is the host on which the manager listens, and which the
+	// babysitters use to reach the manager. Defaults to the hostname.
+	Host string
+
+	// Port is the port on which the manager listens. Defaults to a random
+	// port.
+	Port int
+
+	// Registry returns the registry in which the manager registers the
+	// deployment. Defaults to DefaultRegistry.
+	Registry func(context.Context) (*status.Registry, error)
+
+	// ProxyHealthChecks configures the health checks of the backends of the
+	// proxies of the exported listeners.
+	ProxyHealthChecks proxy.HealthCheckOptions
+
+	// ProxyAffinity configures the session affinity of the proxies of the
+	// exported listeners, by listener name. The proxies of the listeners not
+	// in the map don't use session affinity.
+	ProxyAffinity map[string]proxy.AffinityOptions
+
+	// Assignments holds the strategy used to assign the slices of a routed
+	// component to replicas (e.g., ConsistentHashAssignment), by component
+	// name. Defaults to RoundRobinAssignment.
+	Assignments map[string]string
+
+	// Capacities holds the relative capacity of every location (e.g., its
+	// number of cores). The slices of routed components are distributed
+	// across replicas proportionally to the capacity of their locations.
+	// Locations not in the map have capacity DefaultCapacity.
+	Capacities map[string]int
+
+	// Rebalancer configures the load-aware assignment of the slices of
+	// routed components to replicas.
+	Rebalancer RebalancerOptions
+
+	// Transport is the transport over which the babysitters send their
+	// telemetry (log entries, trace spans, and metrics) to the manager:
+	// HTTPTransport or GRPCTransport. Defaults to HTTPTransport.
+	Transport string
+
+	// Telemetry configures how the babysitters send their telemetry to the
+	// manager over HTTP (e.g., in batches).
+	Telemetry TelemetryOptions
+
+	// Logs configures the rotation and retention of the log files written by
+	// the manager and by the babysitters. By default, log files grow forever.
+	Logs logging.FileStoreOptions
+
+	// Traces configures where the trace spans of the application are stored.
+	// By default, they are stored in the local Perfetto database.
+	Traces TraceOptions
+
+	// HA configures the high-availability mode of the manager. It is only
+	// used by RunHAManager.
+	HA HAOptions
+
+	// StateDir, if not empty, is the directory in which the manager persists
+	// its state, so that a new manager can recover it after a crash, and
+	// re-attach to the running babysitters (see RecoverManager).
+	StateDir string
+
+	// ConfigFile, if not empty, is the config file of the deployment. The
+	// manager watches the file and applies the changes to its config
+	// sections to the running deployment (see UpdateConfig).
+	ConfigFile string
+}
+
 // RunManager creates and runs a new manager.
 func RunManager(ctx context.Context, dep *protos.Deployment, locations []string,
-	logDir string) (func() error, error) {
-	fs, err := logging.NewFileStore(logDir)
+	logDir string, opts ManagerOptions) (func() error, error) {
+	m, err := prepareManager(ctx, dep, locations, logDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	m.start()
+	return m.stop, nil
+}
+
+// prepareManager returns a new manager of the provided deployment, and
+// prepares the locations to run it. The manager doesn't run until start is
+// called.
+func prepareManager(ctx context.Context, dep *protos.Deployment, locations []string,
+	logDir string, opts ManagerOptions) (*manager, error) {
+	m, err := newManager(ctx, dep, locations, logDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare the locations (e.g., copy the binaries to every location).
+	if err := m.launcher.Prepare(ctx, locations, dep); err != nil {
+		m.launcher.Close()
+		return nil, err
+	}
+	if err := m.persistVersion(m.versions[dep.Id]); err != nil {
+		m.launcher.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// newManager returns a new manager of the provided deployment. The manager
+// doesn't run until start is called.
+func newManager(ctx context.Context, dep *protos.Deployment, locations []string,
+	logDir string, opts ManagerOptions) (*manager, error) {
+	fs, err := logging.NewFileStoreWithOptions(logDir, opts.Logs)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create log storage: %w", err)
 	}
@@ -147,17 +383,53 @@ func RunManager(ctx context.Context, dep *protos.Deployment, locations []string,
 	}
 
 	// Create the trace saver.
-	traceDB, err := perfetto.Open(ctx)
+	store, err := newTraceStore(ctx, dep.App.Name, opts.Traces)
 	if err != nil {
-		return nil, fmt.Errorf("cannot open Perfetto database: %w", err)
+		return nil, err
+	}
+	// All the spans of the application pass through the manager, so the
+	// manager samples them (see traceio.Sampler), with one sampler per
+	// application version.
+	sampling, err := traceio.SamplingOptionsFromConfig(dep.App.Sections)
+	if err != nil {
+		return nil, fmt.Errorf("cannot configure trace sampling: %w", err)
 	}
-	traceSaver := func(spans *protos.Spans) error {
+	var samplersMu sync.Mutex
+	samplers := map[string]*traceio.Sampler{}
+	traceSaver := func(depId string, spans *protos.Spans) error {
+		samplersMu.Lock()
+		sampler, ok := samplers[depId]
+		if !ok {
+			sampler = traceio.NewSampler(sampling, func(traces []trace.ReadOnlySpan) error {
+				return store(depId, traces)
+			})
+			samplers[depId] = sampler
+		}
+		samplersMu.Unlock()
+
 		var traces []trace.ReadOnlySpan
 		for _, span := range spans.Span {
 			traces = append(traces, &traceio.ReadSpan{Span: span})
 		}
-		return traceDB.Store(ctx, dep.App.Name, dep.Id, traces)
+		return sampler.Store(traces)
+	}
+
+	rules, err := parseAlertRules(opts.Alerts.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("cannot configure alerts: %w", err)
+	}
+
+	launcher := opts.Launcher
+	if launcher == nil {
+		executor := opts.Executor
+		if executor == nil {
+			if executor, err = NewSSHExecutor(); err != nil {
+				return nil, fmt.Errorf("cannot create executor: %w", err)
+			}
+		}
+		launcher = &sshLauncher{executor: executor, logger: logger}
 	}
+
 	m := &manager{
 		ctx:            ctx,
 		dep:            dep,
@@ -166,19 +438,45 @@ func RunManager(ctx context.Context, dep *protos.Deployment, locations []string,
 		logDir:         logDir,
 		logSaver:       logSaver,
 		traceSaver:     traceSaver,
+		opts:           opts,
+		launcher:       launcher,
 		statsProcessor: imetrics.NewStatsProcessor(),
-		started:        map[string]bool{},
-		appState:       versioned_map.NewMap[*AppVersionState](),
-		routingState:   versioned_map.NewMap[*protos.RoutingInfo](),
+		events:         status.NewEventRecorder(logger),
+		versions:       map[string]*appVersion{dep.Id: newAppVersion(dep)},
 		proxies:        map[string]*proxyInfo{},
+		state:          newStateStore(opts),
 		metrics:        map[groupReplicaInfo][]*protos.MetricSnapshot{},
 	}
+	if len(rules) > 0 {
+		m.alerter = newAlerter(m, opts.Alerts, rules)
+	}
+	return m, nil
+}
 
+// start runs the manager and its background tasks.
+func (m *manager) start() {
+	opts := m.opts
 	go func() {
 		if err := m.run(); err != nil {
 			m.logger.Error("Unable to run the manager", err)
 		}
 	}()
+	if opts.Autoscaler.enabled() {
+		m.autoscaler = newAutoscaler(m, opts.Autoscaler)
+		go m.autoscaler.run(m.ctx)
+	}
+	if m.alerter != nil {
+		go m.alerter.run(m.ctx)
+	}
+	go m.checkHealth(m.ctx)
+	go m.runChaos(m.ctx)
+	if opts.ConfigFile != "" {
+		go func() {
+			if err := m.watchConfig(opts.ConfigFile); err != nil && m.ctx.Err() == nil {
+				m.logger.Error("Unable to watch the config file", err, "file", opts.ConfigFile)
+			}
+		}()
+	}
 	go m.statsProcessor.CollectMetrics(m.ctx, func() []*metrics.MetricSnapshot {
 		m.mu.Lock()
 		defer m.mu.Unlock()
@@ -190,46 +488,122 @@ func RunManager(ctx context.Context, dep *protos.Deployment, locations []string,
 		}
 		return result
 	})
-	return func() error {
-		return m.registry.Unregister(m.ctx, m.dep.Id)
-	}, nil
+}
+
+// newAppVersion returns the initial state for the provided deployment.
+func newAppVersion(dep *protos.Deployment) *appVersion {
+	return &appVersion{
+		dep:          dep,
+		started:      map[string]bool{},
+		replicas:     map[int32]*replica{},
+		appState:     versioned_map.NewMap[*AppVersionState](),
+		routingState: versioned_map.NewMap[*protos.RoutingInfo](),
+		sections:     dep.App.Sections,
+		changedAt:    map[string]int64{},
+		loads:        map[string]componentLoads{},
+		rebalancedAt: map[string]time.Time{},
+	}
+}
+
+// stop terminates the babysitters of all application versions and
+// unregisters the deployment.
+func (m *manager) stop() error {
+	m.mu.Lock()
+	dep := m.dep
+	versions := maps.Values(m.versions)
+	m.mu.Unlock()
+
+	var result error
+	for _, v := range versions {
+		if err := m.stopBabysitters(v); err != nil && result == nil {
+			result = err
+		}
+		m.closeLogSink(v)
+	}
+	if err := m.registry.Unregister(m.ctx, dep.Id); err != nil && result == nil {
+		result = err
+	}
+	if err := m.launcher.Close(); err != nil && result == nil {
+		result = err
+	}
+	if m.state != nil {
+		// The deployment is terminated, so there is nothing to recover.
+		if err := m.state.clear(); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
 }
 
 func (m *manager) run() error {
-	host, _ := os.Hostname()
-	lis, err := net.Listen("tcp", fmt.Sprintf("%s:0", host))
+	host := m.opts.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	addr := fmt.Sprintf("%s:%d", host, m.opts.Port)
+	if m.recovered != nil && m.opts.HA.Coordinator == nil {
+		// Listen on the address of the previous manager, which is where the
+		// running babysitters reach the manager. In high-availability mode,
+		// the babysitters find the new leader instead (see managerAddr).
+		addr = m.recovered.Addr
+	}
+	lis, err := net.Listen("tcp", addr)
 	if err != nil {
 		return fmt.Errorf("listen: %w", err)
 	}
+	m.mu.Lock()
 	m.mgrAddress = fmt.Sprintf("http://%s", lis.Addr())
+	m.mu.Unlock()
 
 	m.logger.Info("Manager listening", "address", m.mgrAddress)
 
-	mux := http.NewServeMux()
-	m.addHTTPHandlers(mux)
-	m.registerStatusPages(mux)
+	m.mux = http.NewServeMux()
+	m.addHTTPHandlers(m.mux)
+	m.registerStatusPages(m.mux)
+
+	m.mu.Lock()
+	v := m.versions[m.dep.Id]
+	versions := maps.Values(m.versions)
+	m.mu.Unlock()
+	for _, v := range versions {
+		m.addVersionHandlers(m.mux, v)
+	}
 
 	go func() {
-		if err := serveHTTP(m.ctx, lis, mux); err != nil {
+		if err := serveHTTP(m.ctx, lis, m.mux); err != nil {
 			m.logger.Error("Unable to start HTTP server", err)
 		}
 	}()
 
-	// Start the main.go process.
-	group := &protos.ColocationGroup{Name: "main.go"}
-	if err := m.startComponent(m.ctx, &protos.ComponentToStart{
-		ColocationGroup: group.Name,
-		Component:       "main.go",
-	}); err != nil {
-		return err
+	if m.opts.Transport == GRPCTransport {
+		grpcLis, err := net.Listen("tcp", fmt.Sprintf("%s:0", host))
+		if err != nil {
+			return fmt.Errorf("listen: %w", err)
+		}
+		m.mu.Lock()
+		m.grpcAddr = grpcLis.Addr().String()
+		m.mu.Unlock()
+		m.logger.Info("Manager listening for telemetry", "address", m.grpcAddr)
+		go func() {
+			if err := m.serveGRPC(grpcLis); err != nil {
+				m.logger.Error("Unable to start gRPC server", err)
+			}
+		}()
 	}
-	if err := m.startColocationGroup(m.ctx, group); err != nil {
+
+	if m.recovered != nil {
+		// Re-attach to the running babysitters.
+		if err := m.reattach(m.recovered); err != nil {
+			return err
+		}
+	} else if err := m.startVersion(m.ctx, v); err != nil {
+		// Start the main.go process.
 		return err
 	}
 
 	// Wait for the status server to become active.
 	client := status.NewClient(lis.Addr().String())
-	for r := retry.Begin(); r.Continue(m.ctx); {
+	for r := retry.BeginWithOptions(status.RetryOptions); r.Continue(m.ctx); {
 		_, err := client.Status(m.ctx)
 		if err == nil {
 			break
@@ -238,30 +612,95 @@ func (m *manager) run() error {
 	}
 
 	// AddHandler the deployment.
-	registry, err := DefaultRegistry(m.ctx)
+	newRegistry := m.opts.Registry
+	if newRegistry == nil {
+		newRegistry = DefaultRegistry
+	}
+	registry, err := newRegistry(m.ctx)
 	if err != nil {
 		return fmt.Errorf("create registry: %w", err)
 	}
 	m.registry = registry
+	m.mu.Lock()
 	reg := status.Registration{
 		DeploymentId: m.dep.Id,
 		App:          m.dep.App.Name,
 		Addr:         lis.Addr().String(),
 	}
+	m.mu.Unlock()
 	fmt.Fprint(os.Stderr, reg.Rolodex())
-	return registry.Register(m.ctx, reg)
+	if err := registry.Register(m.ctx, reg); err != nil {
+		return err
+	}
+	if err := m.events.Attach(m.ctx, registry); err != nil {
+		m.logger.Error("Unable to record deployment events", err)
+	}
+	return nil
 }
 
-// addHTTPHandlers adds handlers for the HTTP endpoints exposed by the SSH manager.
+// startVersion starts the main.go colocation group of the provided application
+// version. The remaining colocation groups are started on demand.
+func (m *manager) startVersion(ctx context.Context, v *appVersion) error {
+	return m.startComponent(ctx, v, &protos.ComponentToStart{
+		ColocationGroup: "main.go",
+		Component:       "main.go",
+	})
+}
+
+// addHTTPHandlers adds handlers for the HTTP endpoints exposed by the SSH
+// manager that are not specific to an application version.
 func (m *manager) addHTTPHandlers(mux *http.ServeMux) {
-	mux.HandleFunc(getComponentsToStartURL, protomsg.HandlerFunc(m.logger, m.getComponentsToStart))
-	mux.HandleFunc(registerReplicaURL, protomsg.HandlerDo(m.logger, m.registerReplica))
-	mux.HandleFunc(exportListenerURL, protomsg.HandlerFunc(m.logger, m.exportListener))
-	mux.HandleFunc(startComponentURL, protomsg.HandlerDo(m.logger, m.startComponent))
-	mux.HandleFunc(getRoutingInfoURL, protomsg.HandlerFunc(m.logger, m.getRoutingInfo))
-	mux.HandleFunc(recvLogEntryURL, protomsg.HandlerDo(m.logger, m.handleLogEntry))
-	mux.HandleFunc(recvTraceSpansURL, protomsg.HandlerDo(m.logger, m.handleTraceSpans))
-	mux.HandleFunc(recvMetricsURL, protomsg.HandlerDo(m.logger, m.handleRecvMetrics))
+	mux.HandleFunc(rolloutURL, protomsg.HandlerDo(m.logger, m.rollout))
+	mux.HandleFunc(removeLocationURL, protomsg.HandlerDo(m.logger, func(ctx context.Context, req *RemoveLocationRequest) error {
+		return m.RemoveLocation(ctx, req.Location)
+	}))
+	mux.HandleFunc(updateConfigURL, protomsg.HandlerFunc(m.logger, m.updateConfig))
+	mux.HandleFunc(setLogLevelURL, protomsg.HandlerFunc(m.logger, m.setLogLevel))
+	mux.HandleFunc(setChaosURL, protomsg.HandlerFunc(m.logger, m.setChaos))
+	mux.HandleFunc(leaderURL, protomsg.HandlerThunk(m.logger, func(context.Context) (*LeaderReply, error) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return &LeaderReply{Addr: m.mgrAddress}, nil
+	}))
+}
+
+// addVersionHandlers adds handlers for the HTTP endpoints exposed by the SSH
+// manager to the babysitters of the provided application version.
+func (m *manager) addVersionHandlers(mux *http.ServeMux, v *appVersion) {
+	prefix := versionPrefix(v.dep.Id)
+	mux.HandleFunc(prefix+getComponentsToStartURL, protomsg.HandlerFunc(m.logger, func(ctx context.Context, req *protos.GetComponentsToStart) (*protos.ComponentsToStart, error) {
+		return m.getComponentsToStart(ctx, v, req)
+	}))
+	mux.HandleFunc(prefix+registerReplicaURL, protomsg.HandlerDo(m.logger, func(ctx context.Context, req *RegisterReplicaRequest) error {
+		return m.registerReplica(ctx, v, req)
+	}))
+	mux.HandleFunc(prefix+exportListenerURL, protomsg.HandlerFunc(m.logger, func(ctx context.Context, req *ExportReplicaListenerRequest) (*protos.ExportListenerReply, error) {
+		return m.exportListener(ctx, v, req)
+	}))
+	mux.HandleFunc(prefix+startComponentURL, protomsg.HandlerDo(m.logger, func(ctx context.Context, req *protos.ComponentToStart) error {
+		return m.startComponent(ctx, v, req)
+	}))
+	mux.HandleFunc(prefix+getRoutingInfoURL, protomsg.HandlerFunc(m.logger, func(ctx context.Context, req *protos.GetRoutingInfo) (*protos.RoutingInfo, error) {
+		return m.getRoutingInfo(ctx, v, req)
+	}))
+	mux.HandleFunc(prefix+heartbeatURL, protomsg.HandlerFunc(m.logger, func(ctx context.Context, req *HeartbeatRequest) (*HeartbeatReply, error) {
+		return m.heartbeat(ctx, v, req)
+	}))
+	mux.HandleFunc(prefix+recvLogEntryURL, protomsg.HandlerDo(m.logger, func(ctx context.Context, entry *protos.LogEntry) error {
+		return m.handleLogEntry(ctx, v, entry)
+	}))
+	mux.HandleFunc(prefix+recvLogEntriesURL, protomsg.HandlerDo(m.logger, func(ctx context.Context, batch *LogEntryBatch) error {
+		return m.handleLogEntries(ctx, v, batch)
+	}))
+	mux.HandleFunc(prefix+recvTraceSpansURL, protomsg.HandlerDo(m.logger, func(ctx context.Context, spans *protos.Spans) error {
+		return m.handleTraceSpans(ctx, v, spans)
+	}))
+	mux.HandleFunc(prefix+reportLoadURL, protomsg.HandlerDo(m.logger, func(ctx context.Context, report *protos.WeaveletLoadReport) error {
+		return m.reportLoad(ctx, v, report)
+	}))
+	mux.HandleFunc(prefix+recvMetricsURL, protomsg.HandlerDo(m.logger, func(ctx context.Context, metrics *BabysitterMetrics) error {
+		return m.handleRecvMetrics(ctx, v, metrics)
+	}))
 }
 
 // registerStatusPages registers the status pages with the provided mux.
@@ -274,7 +713,12 @@ func (m *manager) registerStatusPages(mux *http.ServeMux) {
 // TODO(rgrandl): the implementation is the same as the internal/babysitter.go.
 // See if we can remove duplication.
 func (m *manager) Status(ctx context.Context) (*status.Status, error) {
-	state, _, err := m.loadAppState("" /*version*/)
+	m.mu.Lock()
+	dep := m.dep
+	v := m.versions[dep.Id]
+	m.mu.Unlock()
+
+	state, _, err := m.loadAppState(v, "" /*version*/)
 	if err != nil {
 		return nil, err
 	}
@@ -325,17 +769,23 @@ func (m *manager) Status(ctx context.Context) (*status.Status, error) {
 	var listeners []*status.Listener
 	for name, proxy := range m.proxies {
 		listeners = append(listeners, &status.Listener{
-			Name: name,
-			Addr: proxy.addr,
+			Name:     name,
+			Addr:     proxy.addr,
+			Backends: status.ProxyBackends(proxy.proxy.Backends()),
 		})
 	}
+	var alerts []*status.Alert
+	if m.alerter != nil {
+		alerts = m.alerter.alerts()
+	}
 	return &status.Status{
 		App:            state.App,
 		DeploymentId:   state.DeploymentId,
 		SubmissionTime: state.SubmissionTime,
 		Components:     components,
 		Listeners:      listeners,
-		Config:         m.dep.App,
+		Config:         dep.App,
+		Alerts:         alerts,
 	}, nil
 }
 
@@ -355,13 +805,19 @@ func (m *manager) Profile(context.Context, *protos.RunProfiling) (*protos.Profil
 	return nil, nil
 }
 
-func (m *manager) getComponentsToStart(_ context.Context, req *protos.GetComponentsToStart) (
+func (m *manager) getComponentsToStart(ctx context.Context, v *appVersion, req *protos.GetComponentsToStart) (
 	*protos.ComponentsToStart, error) {
-	// Load app state.
-	state, newVersion, err := m.loadAppState(req.Version)
+	// Wait for the app state to change.
+	state, newVersion, err := watchKey(ctx, m.ctx, v.appState, appVersionStateKey, req.Version)
 	if err != nil {
 		return nil, err
 	}
+	if state == nil {
+		state = &AppVersionState{}
+	}
+	if state.Groups == nil {
+		state.Groups = map[string]*ColocationGroupState{}
+	}
 	g := m.findOrAddGroup(state, req.Group)
 
 	// Return the components.
@@ -371,17 +827,30 @@ func (m *manager) getComponentsToStart(_ context.Context, req *protos.GetCompone
 	return &reply, nil
 }
 
-func (m *manager) registerReplica(_ context.Context, req *protos.ReplicaToRegister) error {
+func (m *manager) registerReplica(_ context.Context, v *appVersion, rreq *RegisterReplicaRequest) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	r, ok := v.replicas[rreq.ReplicaId]
+	if !ok {
+		return fmt.Errorf("replica %d of deployment %s not found", rreq.ReplicaId, v.dep.Id)
+	}
+	req := rreq.Replica
+
 	// Load app state.
-	state, _, err := m.loadAppState("" /*version*/)
+	state, _, err := m.loadAppState(v, "" /*version*/)
 	if err != nil {
 		return err
 	}
 	g := m.findOrAddGroup(state, req.Group)
 
+	// If the weavelet has been restarted by the babysitter, forget its
+	// previous address.
+	if r.addr != "" && r.addr != req.Address {
+		removeReplica(g, r)
+	}
+	r.addr, r.pid = req.Address, req.Pid
+
 	// Append the replica, if not already appended.
 	var found bool
 	for _, replica := range g.Replicas {
@@ -393,36 +862,53 @@ func (m *manager) registerReplica(_ context.Context, req *protos.ReplicaToRegist
 	if !found {
 		g.Replicas = append(g.Replicas, req.Address)
 		g.ReplicaPids = append(g.ReplicaPids, req.Pid)
+		m.events.Record(v.dep.Id, status.EventReplicaRegistered, "group", req.Group,
+			"replica", strconv.Itoa(int(r.id)), "location", r.loc, "address", req.Address)
 	}
 
 	// Generate routing info, now that the replica set has changed.
-	if err := m.mayGenerateNewRoutingInfo(g); err != nil {
+	if err := m.mayGenerateNewRoutingInfo(v, g); err != nil {
 		return err
 	}
 
 	// Store app state.
-	m.appState.Update(appVersionStateKey, state)
+	v.appState.Update(appVersionStateKey, state)
+	m.saveState()
 	return nil
 }
 
-func (m *manager) exportListener(_ context.Context, req *protos.ExportListenerRequest) (
+func (m *manager) exportListener(_ context.Context, v *appVersion, rreq *ExportReplicaListenerRequest) (
 	*protos.ExportListenerReply, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	req := rreq.Request
+	if r, ok := v.replicas[rreq.ReplicaId]; ok {
+		r.listeners = append(r.listeners, req.Listener.Addr)
+	}
+
 	// Load app state.
-	state, _, err := m.loadAppState("" /*version*/)
+	state, _, err := m.loadAppState(v, "" /*version*/)
 	if err != nil {
 		return nil, err
 	}
 
 	// Update and store the state.
 	state.Listeners = append(state.Listeners, req.Listener)
-	m.appState.Update(appVersionStateKey, state)
+	v.appState.Update(appVersionStateKey, state)
+	m.events.Record(v.dep.Id, status.EventListenerExported,
+		"listener", req.Listener.Name, "address", req.Listener.Addr)
 
-	// Update the proxy.
+	// Update the proxy. Note that the listeners of an application version that
+	// is being rolled out don't receive any traffic until the rollout starts
+	// shifting traffic to them.
 	if p, ok := m.proxies[req.Listener.Name]; ok {
-		p.proxy.AddBackend(req.Listener.Addr)
+		weight := proxy.DefaultWeight
+		if v.dep.Id != m.dep.Id {
+			weight = 0
+		}
+		p.proxy.AddWeightedBackend(req.Listener.Addr, weight)
+		p.backends[v.dep.Id] = append(p.backends[v.dep.Id], req.Listener.Addr)
 		return &protos.ExportListenerReply{ProxyAddress: p.addr}, nil
 	}
 
@@ -434,115 +920,305 @@ func (m *manager) exportListener(_ context.Context, req *protos.ExportListenerRe
 	if err != nil {
 		return nil, fmt.Errorf("proxy listen: %w", err)
 	}
-	addr := lis.Addr().String()
-	m.logger.Info("Proxy listening", "address", addr)
-	proxy := proxy.NewProxy(m.logger)
-	proxy.AddBackend(req.Listener.Addr)
-	m.proxies[req.Listener.Name] = &proxyInfo{proxy: proxy, addr: addr}
-	go func() {
-		if err := serveHTTP(m.ctx, lis, proxy); err != nil {
-			m.logger.Error("Proxy", err)
-		}
-	}()
-	return &protos.ExportListenerReply{ProxyAddress: addr}, nil
+	p := m.newProxy(req.Listener.Name, lis, []string{req.Listener.Addr})
+	p.backends[v.dep.Id] = []string{req.Listener.Addr}
+	m.saveState()
+	return &protos.ExportListenerReply{ProxyAddress: p.addr}, nil
 }
 
-func (m *manager) startComponent(ctx context.Context, req *protos.ComponentToStart) error {
+func (m *manager) startComponent(ctx context.Context, v *appVersion, req *protos.ComponentToStart) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Load app state.
-	state, _, err := m.loadAppState("" /*version*/)
+	state, _, err := m.loadAppState(v, "" /*version*/)
 	if err != nil {
 		return err
 	}
 	g := m.findOrAddGroup(state, req.ColocationGroup)
 
 	// Update routing information.
+	if _, ok := g.Components[req.Component]; !ok {
+		m.events.Record(v.dep.Id, status.EventComponentStarted,
+			"component", req.Component, "group", req.ColocationGroup)
+	}
 	g.Components[req.Component] = req.IsRouted
 	if req.IsRouted {
 		if _, ok := g.Assignments[req.Component]; !ok {
 			// Create an initial assignment for the component.
 			g.Assignments[req.Component] = &protos.Assignment{
-				App:          m.dep.App.Name,
-				DeploymentId: m.dep.Id,
+				App:          v.dep.App.Name,
+				DeploymentId: v.dep.Id,
 				Component:    req.Component,
 			}
 		}
 	}
-	if err := m.mayGenerateNewRoutingInfo(g); err != nil {
+	if err := m.mayGenerateNewRoutingInfo(v, g); err != nil {
 		return err
 	}
 
 	// Store app state
-	m.appState.Update(appVersionStateKey, state)
+	v.appState.Update(appVersionStateKey, state)
 
 	// Start the colocation group, if it hasn't started already.
-	return m.startColocationGroup(ctx, &protos.ColocationGroup{Name: req.ColocationGroup})
+	return m.startColocationGroup(ctx, v, &protos.ColocationGroup{Name: req.ColocationGroup})
 }
 
-func (m *manager) startColocationGroup(_ context.Context, group *protos.ColocationGroup) error {
+// startColocationGroup starts the provided colocation group of the provided
+// application version, if it hasn't started already.
+//
+// REQUIRES: m.mu is held.
+func (m *manager) startColocationGroup(_ context.Context, v *appVersion, group *protos.ColocationGroup) error {
 	// If the group is already started, ignore.
-	if _, found := m.started[group.Name]; found {
+	if _, found := v.started[group.Name]; found {
 		return nil
 	}
 
-	// Start the main.go colocation group. Right now, the number of replicas for
-	// each colocation group is equal with the number of locations.
-	//
-	// TODO(rgrandl): Implement some smarter logic to determine the number of
-	// replicas for each group.
-	for replicaId, loc := range m.locations {
-		if err := m.startBabysitter(loc, group, replicaId); err != nil {
-			return fmt.Errorf("unable to start babysitter for group %s at location %s: %w\n", group.Name, loc, err)
+	// Start the initial replicas of the colocation group. If autoscaling is
+	// enabled, the autoscaler adjusts the number of replicas later on.
+	for i := 0; i < m.initialReplicas(); i++ {
+		if err := m.startReplica(v, group.Name, "" /*avoid*/); err != nil {
+			return err
 		}
-		m.logger.Info("Started babysitter", "location", loc, "colocation group", group.Name)
 	}
-	m.started[group.Name] = true
+	v.started[group.Name] = true
 	return nil
 }
 
-func (m *manager) handleLogEntry(_ context.Context, entry *protos.LogEntry) error {
-	m.logSaver(entry)
+// initialReplicas returns the number of replicas a colocation group starts
+// with. Without autoscaling, every colocation group runs one replica per
+// location.
+func (m *manager) initialReplicas() int {
+	n := len(m.locations)
+	if !m.opts.Autoscaler.enabled() {
+		return n
+	}
+	if n < m.opts.Autoscaler.MinReplicas {
+		n = m.opts.Autoscaler.MinReplicas
+	}
+	if n > m.opts.Autoscaler.MaxReplicas {
+		n = m.opts.Autoscaler.MaxReplicas
+	}
+	return n
+}
+
+// startReplica starts a new replica of the provided colocation group, at the
+// location that runs the fewest replicas of the application version. If
+// possible, the replica is not started at location avoid.
+//
+// REQUIRES: m.mu is held.
+func (m *manager) startReplica(v *appVersion, group string, avoid string) error {
+	load := map[string]int{}
+	for _, r := range v.replicas {
+		load[r.loc]++
+	}
+	loc := ""
+	for _, l := range m.locations {
+		if l == avoid && len(m.locations) > 1 {
+			continue
+		}
+		if loc == "" || load[l] < load[loc] {
+			loc = l
+		}
+	}
+
+	r := &replica{
+		id:            v.nextReplicaId,
+		group:         group,
+		loc:           loc,
+		tag:           uuid.NewString(),
+		lastHeartbeat: time.Now(),
+	}
+	if err := m.startBabysitter(v, r); err != nil {
+		return fmt.Errorf("unable to start babysitter for group %s at location %s: %w\n", group, loc, err)
+	}
+	v.nextReplicaId++
+	v.replicas[r.id] = r
+	m.saveState()
+	m.logger.Info("Started babysitter", "location", loc, "colocation group", group, "replica", r.id, "version", v.dep.Id)
+	return nil
+}
+
+// stopReplica removes the provided replica from the routing information of
+// its colocation group, stops sending traffic to its listeners, and returns a
+// function that stops its babysitter. The returned function should be called
+// without holding m.mu.
+//
+// REQUIRES: m.mu is held.
+func (m *manager) stopReplica(v *appVersion, r *replica) (func() error, error) {
+	delete(v.replicas, r.id)
+	delete(m.metrics, groupReplicaInfo{version: v.dep.Id, name: r.group, id: r.id})
+	for _, p := range m.proxies {
+		for _, addr := range r.listeners {
+			p.proxy.RemoveBackend(addr)
+		}
+	}
+
+	state, _, err := m.loadAppState(v, "" /*version*/)
+	if err != nil {
+		return nil, err
+	}
+	g := m.findOrAddGroup(state, r.group)
+	removeReplica(g, r)
+	if err := m.mayGenerateNewRoutingInfo(v, g); err != nil {
+		return nil, err
+	}
+	v.appState.Update(appVersionStateKey, state)
+	m.logger.Info("Stopping babysitter", "location", r.loc, "colocation group", r.group, "replica", r.id, "version", v.dep.Id)
+	return func() error {
+		return m.launcher.Stop(context.Background(), r.loc, r.tag)
+	}, nil
+}
+
+// removeReplica removes the weavelet managed by the provided replica from
+// the provided colocation group state.
+func removeReplica(g *ColocationGroupState, r *replica) {
+	for i, addr := range g.Replicas {
+		if addr == r.addr {
+			g.Replicas = append(g.Replicas[:i], g.Replicas[i+1:]...)
+			break
+		}
+	}
+	for i, pid := range g.ReplicaPids {
+		if pid == r.pid {
+			g.ReplicaPids = append(g.ReplicaPids[:i], g.ReplicaPids[i+1:]...)
+			break
+		}
+	}
+}
+
+func (m *manager) handleLogEntry(_ context.Context, v *appVersion, entry *protos.LogEntry) error {
+	m.saveLogEntry(v, entry)
 	return nil
 }
 
-func (m *manager) handleTraceSpans(_ context.Context, spans *protos.Spans) error {
+func (m *manager) handleLogEntries(_ context.Context, v *appVersion, batch *LogEntryBatch) error {
+	for _, entry := range batch.Entries {
+		m.saveLogEntry(v, entry)
+	}
+	return nil
+}
+
+// saveLogEntry saves a log entry of the provided version, and adds it to the
+// remote log sink of the version, if any.
+func (m *manager) saveLogEntry(v *appVersion, entry *protos.LogEntry) {
+	m.logSaver(entry)
+	if sink := m.logSink(v); sink != nil {
+		sink.Add(entry)
+	}
+}
+
+// logSink returns the remote log sink configured in the log_sinks config
+// section of the provided version, creating it if necessary, or nil if the
+// version doesn't configure one.
+func (m *manager) logSink(v *appVersion) sinks.Sink {
+	v.sinkOnce.Do(func() {
+		sink, err := sinks.FromConfig(v.dep.App.Sections)
+		if err != nil {
+			m.logger.Error("Unable to create log sink", err, "version", v.dep.Id)
+			return
+		}
+		v.sink = sink
+	})
+	return v.sink
+}
+
+// closeLogSink pushes the buffered log entries of the provided version to its
+// remote log sink, if any, and closes the sink.
+func (m *manager) closeLogSink(v *appVersion) {
+	v.sinkOnce.Do(func() {}) // don't create the sink from now on
+	if v.sink == nil {
+		return
+	}
+	if err := v.sink.Close(); err != nil {
+		m.logger.Error("Unable to close log sink", err, "version", v.dep.Id)
+	}
+}
+
+func (m *manager) handleTraceSpans(_ context.Context, v *appVersion, spans *protos.Spans) error {
 	if m.traceSaver == nil {
 		return nil
 	}
-	return m.traceSaver(spans)
+	return m.traceSaver(v.dep.Id, spans)
 }
 
-func (m *manager) handleRecvMetrics(_ context.Context, metrics *BabysitterMetrics) error {
+func (m *manager) handleRecvMetrics(_ context.Context, v *appVersion, metrics *BabysitterMetrics) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.metrics[groupReplicaInfo{name: metrics.GroupName, id: metrics.ReplicaId}] = metrics.Metrics
+	if _, ok := m.versions[v.dep.Id]; !ok {
+		// The version has been stopped.
+		return nil
+	}
+	m.metrics[groupReplicaInfo{version: v.dep.Id, name: metrics.GroupName, id: metrics.ReplicaId}] = metrics.Metrics
 	return nil
 }
 
-// startBabysitter starts a new babysitter that manages a colocation group using SSH.
-func (m *manager) startBabysitter(loc string, group *protos.ColocationGroup, replicaId int) error {
+// startBabysitter starts a new babysitter that manages a colocation group
+// replica, using the launcher.
+func (m *manager) startBabysitter(v *appVersion, r *replica) error {
+	telemetry := m.opts.Telemetry.withDefaults()
 	input, err := proto.ToEnv(&BabysitterInfo{
-		ManagerAddr: m.mgrAddress,
-		Deployment:  m.dep,
-		Group:       group,
-		ReplicaId:   int32(replicaId),
-		LogDir:      m.logDir,
+		ManagerAddr:         m.mgrAddress + versionPrefix(v.dep.Id),
+		ManagerPeers:        m.opts.HA.Peers,
+		GrpcAddr:            m.grpcAddr,
+		MaxBatchSize:        int32(telemetry.MaxBatchSize),
+		MaxBatchDelayMicros: telemetry.MaxBatchDelay.Microseconds(),
+		Encoding:            telemetry.Encoding,
+		LogRetention:        logRetention(m.opts.Logs),
+		Deployment:          v.deployment(),
+		Group:               &protos.ColocationGroup{Name: r.group},
+		ReplicaId:           r.id,
+		LogDir:              m.logDir,
+		ConfigGeneration:    v.configGen,
 	})
 	if err != nil {
 		return err
 	}
 
-	env := fmt.Sprintf("%s=%s", babysitterInfoKey, input)
-	binaryPath := filepath.Join(os.TempDir(), m.dep.Id, "weaver")
-	cmd := exec.Command("ssh", loc, env, binaryPath, "ssh", "babysitter")
-	return cmd.Start()
+	env := []string{fmt.Sprintf("%s=%s", babysitterInfoKey, input)}
+	return m.launcher.Launch(m.ctx, r.loc, v.dep, r.tag, env)
+}
+
+// logRetention returns the LogRetention of the provided options.
+func logRetention(opts logging.FileStoreOptions) *LogRetention {
+	return &LogRetention{
+		MaxFileSize:      opts.MaxFileSize,
+		MaxFileAgeMicros: opts.MaxFileAge.Microseconds(),
+		Compress:         opts.Compress,
+		RetainBytes:      opts.RetainBytes,
+		RetainAgeMicros:  opts.RetainAge.Microseconds(),
+	}
+}
+
+// fileStoreOptions returns the options of the provided LogRetention.
+func fileStoreOptions(r *LogRetention) logging.FileStoreOptions {
+	return logging.FileStoreOptions{
+		MaxFileSize: r.GetMaxFileSize(),
+		MaxFileAge:  time.Duration(r.GetMaxFileAgeMicros()) * time.Microsecond,
+		Compress:    r.GetCompress(),
+		RetainBytes: r.GetRetainBytes(),
+		RetainAge:   time.Duration(r.GetRetainAgeMicros()) * time.Microsecond,
+	}
+}
+
+// stopBabysitters terminates all the babysitters of the provided application
+// version at all locations.
+func (m *manager) stopBabysitters(v *appVersion) error {
+	m.mu.Lock()
+	locations := slices.Clone(m.locations)
+	m.mu.Unlock()
+	for _, loc := range locations {
+		if err := m.launcher.StopAll(context.Background(), loc, v.dep); err != nil {
+			return fmt.Errorf("unable to terminate deployment %s at location %s: %w", v.dep.Id, loc, err)
+		}
+	}
+	return nil
 }
 
-func (m *manager) getRoutingInfo(_ context.Context, req *protos.GetRoutingInfo) (
+func (m *manager) getRoutingInfo(ctx context.Context, v *appVersion, req *protos.GetRoutingInfo) (
 	*protos.RoutingInfo, error) {
-	existing, newVersion, err := m.loadRoutingState(req.Group, req.Version)
+	// Wait for the routing info to change.
+	existing, newVersion, err := watchKey(ctx, m.ctx, v.routingState, routingKey(req.Group), req.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -561,16 +1237,22 @@ func (m *manager) getRoutingInfo(_ context.Context, req *protos.GetRoutingInfo)
 // started.
 //
 // REQUIRES: m.mu is held.
-func (m *manager) mayGenerateNewRoutingInfo(g *ColocationGroupState) error {
+func (m *manager) mayGenerateNewRoutingInfo(v *appVersion, g *ColocationGroupState) error {
 	for component, currAssignment := range g.Assignments {
-		newAssignment, err := routingAlgo(currAssignment, g.Replicas)
+		newAssignment, err := m.assign(component, currAssignment, g.Replicas, m.replicaWeights(v, g))
 		if err != nil || newAssignment == nil {
 			continue // don't update assignments
 		}
 		g.Assignments[component] = newAssignment
 	}
+	return m.publishRoutingInfo(v, g)
+}
 
-	// Update the routing information.
+// publishRoutingInfo updates the routing information of the provided
+// colocation group with its latest replicas and assignments.
+//
+// REQUIRES: m.mu is held.
+func (m *manager) publishRoutingInfo(v *appVersion, g *ColocationGroupState) error {
 	sort.Strings(g.Replicas)
 	routingInfo := protos.RoutingInfo{
 		Replicas: g.Replicas,
@@ -578,26 +1260,27 @@ func (m *manager) mayGenerateNewRoutingInfo(g *ColocationGroupState) error {
 	for _, assignment := range g.Assignments {
 		routingInfo.Assignments = append(routingInfo.Assignments, assignment)
 	}
-	return m.updateRoutingInfo(g, &routingInfo)
+	return m.updateRoutingInfo(v, g, &routingInfo)
 }
 
 // updateRoutingInfo update the state with the latest routing info for a
 // colocation group.
 // REQUIRES: m.mu is held.
-func (m *manager) updateRoutingInfo(g *ColocationGroupState, info *protos.RoutingInfo) error {
-	state, _, err := m.loadRoutingState(g.Name, "" /*version*/)
+func (m *manager) updateRoutingInfo(v *appVersion, g *ColocationGroupState, info *protos.RoutingInfo) error {
+	state, _, err := m.loadRoutingState(v, g.Name, "" /*version*/)
 	if err != nil {
 		return err
 	}
 	if gproto.Equal(state, info) { // Nothing to update
 		return nil
 	}
-	m.routingState.Update(routingKey(g.Name), info)
+	v.routingState.Update(routingKey(g.Name), info)
+	m.events.Record(v.dep.Id, status.EventRoutingUpdated, "group", g.Name, "replicas", strconv.Itoa(len(info.Replicas)))
 	return nil
 }
 
-func (m *manager) loadRoutingState(group, version string) (*protos.RoutingInfo, string, error) {
-	state, newVersion, err := m.routingState.Read(m.ctx, routingKey(group), version)
+func (m *manager) loadRoutingState(v *appVersion, group, version string) (*protos.RoutingInfo, string, error) {
+	state, newVersion, err := v.routingState.Read(m.ctx, routingKey(group), version)
 	if err != nil {
 		return nil, "", err
 	}
@@ -615,7 +1298,14 @@ func (m *manager) loadRoutingState(group, version string) (*protos.RoutingInfo,
 // spread uniformly the key space among all healthy resources
 //
 // - distribute the slices round robin across all healthy resources
-func routingAlgo(currAssignment *protos.Assignment, candidates []string) (*protos.Assignment, error) {
+//
+// If the resources have different weights (see replicaWeights), the key space
+// is split in more slices, and the slices are distributed across the
+// resources proportionally to their weights instead.
+//
+// The rebalancer later moves slices across resources based on their load (see
+// rebalance.go).
+func routingAlgo(currAssignment *protos.Assignment, candidates []string, weights map[string]int) (*protos.Assignment, error) {
 	newAssignment := protomsg.Clone(currAssignment)
 	newAssignment.Version++
 
@@ -643,6 +1333,10 @@ func routingAlgo(currAssignment *protos.Assignment, candidates []string) (*proto
 
 	// Compute the total number of slices in the assignment.
 	numSlices := nextPowerOfTwo(len(candidates))
+	weighted := !uniformWeights(candidates, weights)
+	if weighted {
+		numSlices = weightedSlices(candidates, weights)
+	}
 
 	// Split slices in equal subslices in order to generate numSlices.
 	splits := [][]uint64{{minSliceKey, maxSliceKey}}
@@ -662,8 +1356,22 @@ func routingAlgo(currAssignment *protos.Assignment, candidates []string) (*proto
 		return splits[i][0] <= splits[j][0]
 	})
 
-	// Assign the computed slices to resources in a round robin fashion.
 	slices := make([]*protos.Assignment_Slice, len(splits))
+	if weighted {
+		// Assign the computed slices to resources proportionally to their
+		// weights.
+		owners := weightedRoundRobin(candidates, weights, len(splits))
+		for i, s := range splits {
+			slices[i] = &protos.Assignment_Slice{
+				Start:    s[0],
+				Replicas: []string{owners[i]},
+			}
+		}
+		newAssignment.Slices = slices
+		return newAssignment, nil
+	}
+
+	// Assign the computed slices to resources in a round robin fashion.
 	rId := 0
 	for i, s := range splits {
 		slices[i] = &protos.Assignment_Slice{
@@ -676,15 +1384,15 @@ func routingAlgo(currAssignment *protos.Assignment, candidates []string) (*proto
 	return newAssignment, nil
 }
 
-func (m *manager) loadAppState(version string) (*AppVersionState, string, error) {
-	state, newVersion, err := m.appState.Read(m.ctx, appVersionStateKey, version)
+func (m *manager) loadAppState(v *appVersion, version string) (*AppVersionState, string, error) {
+	state, newVersion, err := v.appState.Read(m.ctx, appVersionStateKey, version)
 	if err != nil {
 		return nil, "", err
 	}
 	if state == nil {
 		state = &AppVersionState{
-			App:            m.dep.App.Name,
-			DeploymentId:   m.dep.Id,
+			App:            v.dep.App.Name,
+			DeploymentId:   v.dep.Id,
 			SubmissionTime: timestamppb.Now(),
 			Groups:         map[string]*ColocationGroupState{},
 		}
@@ -698,6 +1406,31 @@ func (m *manager) loadAppState(version string) (*AppVersionState, string, error)
 	return state, newVersion, nil
 }
 
+// watchKey blocks until the value of the provided key in the provided map is
+// newer than version, and returns it along with its version. It returns an
+// error if either ctx, the context of a long-polling request, or mgrCtx, the
+// context of the manager, is cancelled first.
+//
+// Blocking on the context of the request, rather than on the context of the
+// manager, releases the resources of a long poll as soon as its caller (e.g.,
+// the babysitter of a stopped weavelet) goes away.
+func watchKey[T gproto.Message](ctx, mgrCtx context.Context, vm *versioned_map.Map[T], key, version string) (T, string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	select {
+	case u, ok := <-vm.Watch(ctx, key, version):
+		if ok {
+			return u.Value, u.Version, nil
+		}
+	case <-mgrCtx.Done():
+	}
+	var zero T
+	if err := mgrCtx.Err(); err != nil {
+		return zero, "", err
+	}
+	return zero, "", ctx.Err()
+}
+
 func (m *manager) findOrAddGroup(state *AppVersionState, group string) *ColocationGroupState {
 	g := state.Groups[group]
 	if g == nil {
@@ -755,3 +1488,9 @@ func nextPowerOfTwo(x int) int {
 func routingKey(group string) string {
 	return path.Join(routingInfoKey, group)
 }
+
+// versionPrefix returns the URL prefix of the manager handlers for the
+// application version with the provided deployment id.
+func versionPrefix(depId string) string {
+	return versionURLPrefix + depId
+}
@@ -0,0 +1,255 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"greatestworks/aop/protomsg"
+	"greatestworks/aop/protos"
+)
+
+// This file implements load-aware slice assignment. routingAlgo splits the
+// key space of a routed component evenly across the replicas of its
+// colocation group, regardless of load. Weavelets periodically report the
+// load (requests per second) of every slice they are assigned, and the
+// rebalancer uses these reports to move hot slices from the most loaded
+// replica to the least loaded one.
+//
+// To avoid churn, the rebalancer:
+//
+//   - only acts once every replica of the assignment has reported its load
+//     for the current assignment version. Every change bumps the version, so
+//     a new decision always requires a fresh set of reports;
+//   - only acts if the most loaded replica exceeds the average load by more
+//     than RebalancerOptions.Imbalance;
+//   - only moves a slice if the move strictly lowers the load of the most
+//     loaded replica, so that a slice never bounces back and forth; and
+//   - waits at least RebalancerOptions.Cooldown between two changes of the
+//     assignment of a component.
+//
+// If the most loaded replica owns no slice that can be moved (e.g., a single
+// hot slice), the hottest slice is split in two. One of the halves can then
+// be moved once the load of the halves is reported.
+//
+// Components assigned with ConsistentHashAssignment are not rebalanced, since
+// their slices are meant to move only when replicas are added or removed.
+
+// RebalancerOptions configures the load-aware assignment of slices.
+type RebalancerOptions struct {
+	// Imbalance is how much the load of the most loaded replica may exceed
+	// the average load, as a fraction of the average load, before slices are
+	// moved. Defaults to 0.2.
+	Imbalance float64
+
+	// MinLoad is the total load (in requests per second) of a component
+	// below which its slices are not moved. Defaults to 1.
+	MinLoad float64
+
+	// Cooldown is the minimum time between two changes of the assignment of
+	// a component. Defaults to one minute.
+	Cooldown time.Duration
+
+	// MaxSlices is the maximum number of slices of an assignment. Hot slices
+	// are not split beyond it. Defaults to 1024.
+	MaxSlices int
+}
+
+// withDefaults returns a copy of the options with zero values replaced with
+// default values.
+func (o RebalancerOptions) withDefaults() RebalancerOptions {
+	if o.Imbalance <= 0 {
+		o.Imbalance = 0.2
+	}
+	if o.MinLoad <= 0 {
+		o.MinLoad = 1
+	}
+	if o.Cooldown <= 0 {
+		o.Cooldown = time.Minute
+	}
+	if o.MaxSlices <= 0 {
+		o.MaxSlices = 1024
+	}
+	return o
+}
+
+// componentLoads holds the latest load reports of a routed component, by
+// replica address.
+type componentLoads map[string]*protos.WeaveletLoadReport_ComponentLoad
+
+// reportLoad records the provided load report, and rebalances the
+// assignments of the reported components if needed.
+func (m *manager) reportLoad(_ context.Context, v *appVersion, report *protos.WeaveletLoadReport) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, _, err := m.loadAppState(v, "" /*version*/)
+	if err != nil {
+		return err
+	}
+	g, ok := state.Groups[report.Group]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	opts := m.opts.Rebalancer.withDefaults()
+	changed := false
+	for component, load := range report.Loads {
+		a, ok := g.Assignments[component]
+		if !ok || load.Version != a.Version {
+			// The report is about a stale assignment.
+			continue
+		}
+		if m.opts.Assignments[component] == ConsistentHashAssignment {
+			continue
+		}
+		if v.loads[component] == nil {
+			v.loads[component] = componentLoads{}
+		}
+		v.loads[component][report.Replica] = load
+		if now.Sub(v.rebalancedAt[component]) < opts.Cooldown {
+			continue
+		}
+		newAssignment := rebalance(a, v.loads[component], m.replicaWeights(v, g), opts)
+		if newAssignment == nil {
+			continue
+		}
+		m.logger.Info("Rebalanced slices", "component", component, "version", newAssignment.Version, "slices", len(newAssignment.Slices))
+		g.Assignments[component] = newAssignment
+		v.rebalancedAt[component] = now
+		delete(v.loads, component)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	if err := m.publishRoutingInfo(v, g); err != nil {
+		return err
+	}
+	v.appState.Update(appVersionStateKey, state)
+	return nil
+}
+
+// rebalance returns a new version of the provided assignment that moves a hot
+// slice from the most loaded replica to the least loaded one, or splits the
+// hottest slice of the most loaded replica in two. It returns nil if the
+// assignment should not change.
+//
+// The load of a replica is relative to its weight (see replicaWeights), so
+// that heavier replicas are assigned proportionally more load.
+func rebalance(a *protos.Assignment, loads componentLoads, weights map[string]int, opts RebalancerOptions) *protos.Assignment {
+	// Compute the load of every slice and replica. Every replica of the
+	// assignment must have reported its load for the current version.
+	sliceLoads := make([]float64, len(a.Slices))
+	replicaLoads := map[string]float64{}
+	for i, s := range a.Slices {
+		if len(s.Replicas) != 1 {
+			// Only single-replica slices can be moved.
+			return nil
+		}
+		r := s.Replicas[0]
+		load, ok := loads[r]
+		if !ok || load.Version != a.Version {
+			return nil
+		}
+		for _, l := range load.Load {
+			if l.Start == s.Start {
+				sliceLoads[i] += l.Load
+			}
+		}
+		replicaLoads[r] += sliceLoads[i]
+	}
+	if len(replicaLoads) < 2 {
+		return nil
+	}
+
+	// Find the most and least loaded replicas, relative to their weights.
+	// Ties are broken by address, to keep decisions deterministic.
+	replicas := make([]string, 0, len(replicaLoads))
+	total, totalWeight := 0.0, 0.0
+	for r, l := range replicaLoads {
+		replicas = append(replicas, r)
+		total += l
+		totalWeight += float64(weight(weights, r))
+	}
+	relative := func(r string, load float64) float64 {
+		return load / float64(weight(weights, r))
+	}
+	sort.Slice(replicas, func(i, j int) bool {
+		ri, rj := replicas[i], replicas[j]
+		li, lj := relative(ri, replicaLoads[ri]), relative(rj, replicaLoads[rj])
+		if li != lj {
+			return li > lj
+		}
+		return ri < rj
+	})
+	src, dst := replicas[0], replicas[len(replicas)-1]
+	mean := total / totalWeight
+	if total < opts.MinLoad || relative(src, replicaLoads[src]) <= mean*(1+opts.Imbalance) {
+		return nil
+	}
+
+	// Move the slice of src that best evens out the relative load of src and
+	// dst. Moving a slice only helps if dst ends up less loaded than src was.
+	diff := func(l float64) float64 {
+		return math.Abs(relative(src, replicaLoads[src]-l) - relative(dst, replicaLoads[dst]+l))
+	}
+	best, hottest := -1, -1
+	for i, s := range a.Slices {
+		if s.Replicas[0] != src || sliceLoads[i] <= 0 {
+			continue
+		}
+		if hottest == -1 || sliceLoads[i] > sliceLoads[hottest] {
+			hottest = i
+		}
+		if relative(dst, replicaLoads[dst]+sliceLoads[i]) >= relative(src, replicaLoads[src]) {
+			continue
+		}
+		if best == -1 || diff(sliceLoads[i]) < diff(sliceLoads[best]) {
+			best = i
+		}
+	}
+	if best != -1 {
+		newAssignment := protomsg.Clone(a)
+		newAssignment.Version++
+		newAssignment.Slices[best].Replicas = []string{dst}
+		return newAssignment
+	}
+
+	// No slice can be moved. Split the hottest slice of src.
+	if hottest == -1 || len(a.Slices) >= opts.MaxSlices {
+		return nil
+	}
+	start := a.Slices[hottest].Start
+	end := uint64(math.MaxUint64)
+	if hottest+1 < len(a.Slices) {
+		end = a.Slices[hottest+1].Start
+	}
+	if end-start < 2 {
+		return nil
+	}
+	newAssignment := protomsg.Clone(a)
+	newAssignment.Version++
+	split := &protos.Assignment_Slice{Start: start + (end-start)/2, Replicas: []string{src}}
+	slices := append([]*protos.Assignment_Slice{}, newAssignment.Slices[:hottest+1]...)
+	slices = append(slices, split)
+	slices = append(slices, newAssignment.Slices[hottest+1:]...)
+	newAssignment.Slices = slices
+	return newAssignment
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"greatestworks/aop/protos"
+)
+
+// testAssignment returns a version 1 assignment with slices starting at the
+// provided keys, assigned to the provided replicas.
+func testAssignment(starts []uint64, replicas []string) *protos.Assignment {
+	a := &protos.Assignment{Version: 1}
+	for i, start := range starts {
+		a.Slices = append(a.Slices, &protos.Assignment_Slice{Start: start, Replicas: []string{replicas[i]}})
+	}
+	return a
+}
+
+// testLoads returns the load reports of the provided assignment, given the
+// load of every slice.
+func testLoads(a *protos.Assignment, loads []float64) componentLoads {
+	reports := componentLoads{}
+	for i, s := range a.Slices {
+		r := s.Replicas[0]
+		if reports[r] == nil {
+			reports[r] = &protos.WeaveletLoadReport_ComponentLoad{Version: a.Version}
+		}
+		reports[r].Load = append(reports[r].Load, &protos.WeaveletLoadReport_ComponentLoad_SliceLoad{
+			Start: s.Start,
+			Load:  loads[i],
+		})
+	}
+	return reports
+}
+
+func TestRebalance(t *testing.T) {
+	const half = math.MaxUint64 / 2
+	opts := RebalancerOptions{}.withDefaults()
+	for _, test := range []struct {
+		name    string
+		a       *protos.Assignment
+		loads   []float64
+		modify  func(componentLoads) // modifies the reported loads
+		weights map[string]int       // replica weights
+		want    *protos.Assignment   // nil if unchanged
+	}{
+		{
+			name:  "Balanced",
+			a:     testAssignment([]uint64{0, 100, 200, 300}, []string{"a", "b", "a", "b"}),
+			loads: []float64{10, 10, 10, 10},
+		},
+		{
+			name:  "WithinImbalance",
+			a:     testAssignment([]uint64{0, 100, 200, 300}, []string{"a", "b", "a", "b"}),
+			loads: []float64{11, 10, 11, 10},
+		},
+		{
+			name:  "Idle",
+			a:     testAssignment([]uint64{0, 100, 200, 300}, []string{"a", "b", "a", "b"}),
+			loads: []float64{0.5, 0, 0, 0},
+		},
+		{
+			name:  "MoveHotSlice",
+			a:     testAssignment([]uint64{0, 100, 200, 300}, []string{"a", "b", "a", "b"}),
+			loads: []float64{10, 5, 30, 5},
+			want: &protos.Assignment{Version: 2, Slices: []*protos.Assignment_Slice{
+				{Start: 0, Replicas: []string{"b"}},
+				{Start: 100, Replicas: []string{"b"}},
+				{Start: 200, Replicas: []string{"a"}},
+				{Start: 300, Replicas: []string{"b"}},
+			}},
+		},
+		{
+			name:    "Weighted",
+			a:       testAssignment([]uint64{0, 100, 200, 300}, []string{"a", "b", "a", "b"}),
+			loads:   []float64{10, 10, 10, 10},
+			weights: map[string]int{"a": 1, "b": 3},
+			want: &protos.Assignment{Version: 2, Slices: []*protos.Assignment_Slice{
+				{Start: 0, Replicas: []string{"b"}},
+				{Start: 100, Replicas: []string{"b"}},
+				{Start: 200, Replicas: []string{"a"}},
+				{Start: 300, Replicas: []string{"b"}},
+			}},
+		},
+		{
+			name:    "WeightedBalanced",
+			a:       testAssignment([]uint64{0, 100, 200, 300}, []string{"a", "b", "b", "b"}),
+			loads:   []float64{10, 10, 10, 10},
+			weights: map[string]int{"a": 1, "b": 3},
+		},
+		{
+			name:  "SplitHotSlice",
+			a:     testAssignment([]uint64{0, half}, []string{"a", "b"}),
+			loads: []float64{100, 10},
+			want: &protos.Assignment{Version: 2, Slices: []*protos.Assignment_Slice{
+				{Start: 0, Replicas: []string{"a"}},
+				{Start: half / 2, Replicas: []string{"a"}},
+				{Start: half, Replicas: []string{"b"}},
+			}},
+		},
+		{
+			name:  "MissingReport",
+			a:     testAssignment([]uint64{0, 100, 200, 300}, []string{"a", "b", "a", "c"}),
+			loads: []float64{10, 5, 30, 5},
+			modify: func(loads componentLoads) {
+				delete(loads, "c")
+			},
+		},
+		{
+			name:  "StaleReport",
+			a:     testAssignment([]uint64{0, 100, 200, 300}, []string{"a", "b", "a", "b"}),
+			loads: []float64{10, 5, 30, 5},
+			modify: func(loads componentLoads) {
+				loads["b"].Version = 0
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			loads := testLoads(test.a, test.loads)
+			if test.modify != nil {
+				test.modify(loads)
+			}
+			got := rebalance(test.a, loads, test.weights, opts)
+			if diff := cmp.Diff(test.want, got, protocmp.Transform()); diff != "" {
+				t.Fatalf("rebalance (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRebalanceConverges(t *testing.T) {
+	// One replica owns a hot key range. Repeated rebalancing should spread
+	// the load without oscillating.
+	opts := RebalancerOptions{}.withDefaults()
+	a := testAssignment([]uint64{0, 1 << 62, 2 << 62, 3 << 62}, []string{"a", "b", "c", "a"})
+	load := func(start, end uint64) float64 {
+		// Keys in [0, 2^62) are 10 times hotter than the others.
+		hot := uint64(1 << 62)
+		l := 0.0
+		if start < hot {
+			l += 10 * float64(minUint64(end, hot)-start)
+		}
+		if end > hot {
+			l += float64(end - maxUint64(start, hot))
+		}
+		return l / float64(uint64(1)<<58)
+	}
+	for i := 0; i < 50; i++ {
+		loads := make([]float64, len(a.Slices))
+		for j, s := range a.Slices {
+			end := uint64(math.MaxUint64)
+			if j+1 < len(a.Slices) {
+				end = a.Slices[j+1].Start
+			}
+			loads[j] = load(s.Start, end)
+		}
+		next := rebalance(a, testLoads(a, loads), nil, opts)
+		if next == nil {
+			// Converged. Check that the load is within the imbalance bound.
+			perReplica := map[string]float64{}
+			total := 0.0
+			for j, s := range a.Slices {
+				perReplica[s.Replicas[0]] += loads[j]
+				total += loads[j]
+			}
+			for r, l := range perReplica {
+				if l > total/3*(1+opts.Imbalance) {
+					t.Fatalf("replica %s: load %v exceeds bound %v", r, l, total/3*(1+opts.Imbalance))
+				}
+			}
+			return
+		}
+		a = next
+	}
+	t.Fatalf("rebalance did not converge: %v", a)
+}
+
+func minUint64(x, y uint64) uint64 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func maxUint64(x, y uint64) uint64 {
+	if x > y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,286 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"greatestworks/aop/protomsg"
+	"greatestworks/aop/status"
+)
+
+// Rollout asks the SSH manager running at the provided address to perform a
+// rolling update of the application it manages to the provided deployment.
+//
+// Rollout returns as soon as the manager has accepted the request; the
+// rollout itself is performed asynchronously by the manager.
+func Rollout(ctx context.Context, mgrAddr string, req *RolloutRequest) error {
+	return protomsg.Call(ctx, protomsg.CallArgs{
+		Client:  http.DefaultClient,
+		Addr:    "http://" + mgrAddr,
+		URLPath: rolloutURL,
+		Request: req,
+	})
+}
+
+// rollout starts a rolling update from the deployment currently managed by
+// the manager to the deployment in the provided request.
+//
+// A rolling update proceeds as follows:
+//
+//  1. The new application version is started alongside the current one.
+//  2. Once the new version has exported all the listeners exported by the
+//     current version, traffic is gradually shifted from the current version
+//     to the new version, by adjusting the weights of the proxy backends.
+//  3. Once all the traffic has been shifted, the babysitters of the old
+//     version are drained and stopped.
+//
+// If the rollout fails (or the manager stops) before the new version is made
+// current, the traffic is shifted back to the current version, and the new
+// version is stopped.
+func (m *manager) rollout(_ context.Context, req *RolloutRequest) error {
+	if req.Deployment == nil || req.Deployment.App == nil {
+		return fmt.Errorf("rollout: no deployment specified")
+	}
+
+	m.mu.Lock()
+	if m.rollingOut {
+		m.mu.Unlock()
+		return fmt.Errorf("rollout: a rollout is already in progress")
+	}
+	if req.Deployment.App.Name != m.dep.App.Name {
+		m.mu.Unlock()
+		return fmt.Errorf("rollout: cannot roll out app %q over app %q", req.Deployment.App.Name, m.dep.App.Name)
+	}
+	if _, ok := m.versions[req.Deployment.Id]; ok {
+		m.mu.Unlock()
+		return fmt.Errorf("rollout: deployment %q already exists", req.Deployment.Id)
+	}
+	from := m.versions[m.dep.Id]
+	to := newAppVersion(req.Deployment)
+	if err := m.persistVersion(to); err != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("rollout: %w", err)
+	}
+	m.versions[to.dep.Id] = to
+	m.rollingOut = true
+	m.mu.Unlock()
+
+	m.addVersionHandlers(m.mux, to)
+
+	steps := int(req.NumSteps)
+	if steps <= 0 {
+		steps = defaultRolloutSteps
+	}
+	interval := req.StepInterval.AsDuration()
+	if interval <= 0 {
+		interval = defaultRolloutInterval
+	}
+
+	// The rollout can take a long time, so we run it in the background, bound
+	// to the lifetime of the manager rather than the lifetime of the request.
+	go func() {
+		if err := m.runRollout(m.ctx, from, to, steps, interval); err != nil {
+			m.logger.Error("Rollout failed", err, "from", from.dep.Id, "to", to.dep.Id)
+		}
+	}()
+	return nil
+}
+
+// runRollout performs a rolling update from one application version to
+// another. See rollout for details.
+func (m *manager) runRollout(ctx context.Context, from, to *appVersion, steps int, interval time.Duration) error {
+	m.logger.Info("Starting rollout", "from", from.dep.Id, "to", to.dep.Id, "steps", steps, "interval", interval)
+	defer func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.rollingOut = false
+	}()
+
+	// Prepare the locations to run the new version.
+	m.mu.Lock()
+	locs := slices.Clone(m.locations)
+	m.mu.Unlock()
+	if err := m.launcher.Prepare(ctx, locs, to.dep); err != nil {
+		m.abortRollout(to)
+		return err
+	}
+
+	// Start the new version.
+	if err := m.startVersion(ctx, to); err != nil {
+		m.abortRollout(to)
+		return err
+	}
+
+	// Wait for the new version to export all of the listeners exported by the
+	// old version.
+	if err := m.waitForListeners(ctx, from, to); err != nil {
+		m.abortRollout(to)
+		return err
+	}
+
+	// Gradually shift traffic from the old version to the new version.
+	for step := 1; step <= steps; step++ {
+		m.shiftTraffic(from, to, step, steps)
+		m.logger.Info("Shifted traffic", "to", to.dep.Id, "percent", 100*step/steps)
+		if step == steps {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			m.rollBack(from, to)
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+
+	// The new version is now serving all the traffic. Make it the current
+	// version.
+	if err := m.makeCurrent(ctx, from, to); err != nil {
+		m.rollBack(from, to)
+		return err
+	}
+
+	// Give in-flight requests to the old version a chance to complete, and
+	// then stop it. If the manager is stopping, the old version is stopped
+	// at once: the rollout is done either way.
+	select {
+	case <-ctx.Done():
+	case <-time.After(interval):
+	}
+	if err := m.stopVersion(from); err != nil {
+		return err
+	}
+	m.logger.Info("Rollout completed", "from", from.dep.Id, "to", to.dep.Id)
+	return nil
+}
+
+// makeCurrent registers the application version to in place of the
+// application version from, and makes it the current version. If it fails,
+// from is left registered, and current.
+func (m *manager) makeCurrent(ctx context.Context, from, to *appVersion) error {
+	reg, err := m.registry.Get(ctx, from.dep.Id)
+	if err != nil {
+		return err
+	}
+	if err := m.registry.Register(ctx, status.Registration{
+		DeploymentId: to.dep.Id,
+		App:          to.dep.App.Name,
+		Addr:         reg.Addr,
+	}); err != nil {
+		return err
+	}
+	if err := m.registry.Unregister(ctx, from.dep.Id); err != nil {
+		uctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+		defer cancel()
+		if uerr := m.registry.Unregister(uctx, to.dep.Id); uerr != nil {
+			m.logger.Error("Unable to unregister aborted version", uerr, "version", to.dep.Id)
+		}
+		return err
+	}
+	m.mu.Lock()
+	m.dep = to.dep
+	m.saveState()
+	m.mu.Unlock()
+	return nil
+}
+
+// waitForListeners blocks until the application version to has exported
+// all the listeners exported by the application version from.
+func (m *manager) waitForListeners(ctx context.Context, from, to *appVersion) error {
+	ctx, cancel := context.WithTimeout(ctx, listenerWaitTimeout)
+	defer cancel()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		m.mu.Lock()
+		var missing []string
+		for name, p := range m.proxies {
+			if len(p.backends[from.dep.Id]) > 0 && len(p.backends[to.dep.Id]) == 0 {
+				missing = append(missing, name)
+			}
+		}
+		m.mu.Unlock()
+		if len(missing) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("deployment %s didn't export listeners %v: %w", to.dep.Id, missing, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// shiftTraffic updates the proxy backend weights such that the application
+// version to receives step/steps of the traffic, and the application version
+// from receives the rest.
+func (m *manager) shiftTraffic(from, to *appVersion, step, steps int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.proxies {
+		for _, addr := range p.backends[from.dep.Id] {
+			p.proxy.SetWeight(addr, (steps-step)*100/steps)
+		}
+		for _, addr := range p.backends[to.dep.Id] {
+			p.proxy.SetWeight(addr, step*100/steps)
+		}
+	}
+}
+
+// rollBack undoes a rollout from the application version from to the
+// application version to that failed once the traffic started shifting: the
+// traffic is shifted back to from, and to is aborted.
+func (m *manager) rollBack(from, to *appVersion) {
+	m.shiftTraffic(from, to, 0, 1)
+	m.abortRollout(to)
+}
+
+// abortRollout stops an application version whose rollout failed, and
+// removes its backends from the proxies.
+func (m *manager) abortRollout(v *appVersion) {
+	if err := m.stopVersion(v); err != nil {
+		m.logger.Error("Unable to stop aborted version", err, "version", v.dep.Id)
+	}
+}
+
+// stopVersion stops the babysitters of the provided application version, and
+// removes all the state the manager maintains for it.
+func (m *manager) stopVersion(v *appVersion) error {
+	m.mu.Lock()
+	for _, p := range m.proxies {
+		for _, addr := range p.backends[v.dep.Id] {
+			p.proxy.RemoveBackend(addr)
+		}
+		delete(p.backends, v.dep.Id)
+	}
+	for info := range m.metrics {
+		if info.version == v.dep.Id {
+			delete(m.metrics, info)
+		}
+	}
+	delete(m.versions, v.dep.Id)
+	m.saveState()
+	m.mu.Unlock()
+	m.removeVersionState(v)
+	err := m.stopBabysitters(v)
+	m.closeLogSink(v)
+	return err
+}
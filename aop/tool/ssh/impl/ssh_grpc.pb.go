@@ -0,0 +1,286 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.12
+// source: internal/tool/ssh/impl/ssh.proto
+
+package impl
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protos "greatestworks/aop/protos"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ManagerClient is the client API for Manager service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ManagerClient interface {
+	// SendLogEntries streams log entries to the manager.
+	SendLogEntries(ctx context.Context, opts ...grpc.CallOption) (Manager_SendLogEntriesClient, error)
+	// SendTraceSpans streams trace spans to the manager.
+	SendTraceSpans(ctx context.Context, opts ...grpc.CallOption) (Manager_SendTraceSpansClient, error)
+	// SendMetrics streams metrics snapshots to the manager.
+	SendMetrics(ctx context.Context, opts ...grpc.CallOption) (Manager_SendMetricsClient, error)
+}
+
+type managerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewManagerClient(cc grpc.ClientConnInterface) ManagerClient {
+	return &managerClient{cc}
+}
+
+func (c *managerClient) SendLogEntries(ctx context.Context, opts ...grpc.CallOption) (Manager_SendLogEntriesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[0], "/impl.Manager/SendLogEntries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerSendLogEntriesClient{stream}
+	return x, nil
+}
+
+type Manager_SendLogEntriesClient interface {
+	Send(*protos.LogEntry) error
+	CloseAndRecv() (*StreamReply, error)
+	grpc.ClientStream
+}
+
+type managerSendLogEntriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerSendLogEntriesClient) Send(m *protos.LogEntry) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *managerSendLogEntriesClient) CloseAndRecv() (*StreamReply, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(StreamReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *managerClient) SendTraceSpans(ctx context.Context, opts ...grpc.CallOption) (Manager_SendTraceSpansClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[1], "/impl.Manager/SendTraceSpans", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerSendTraceSpansClient{stream}
+	return x, nil
+}
+
+type Manager_SendTraceSpansClient interface {
+	Send(*protos.Spans) error
+	CloseAndRecv() (*StreamReply, error)
+	grpc.ClientStream
+}
+
+type managerSendTraceSpansClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerSendTraceSpansClient) Send(m *protos.Spans) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *managerSendTraceSpansClient) CloseAndRecv() (*StreamReply, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(StreamReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *managerClient) SendMetrics(ctx context.Context, opts ...grpc.CallOption) (Manager_SendMetricsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[2], "/impl.Manager/SendMetrics", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerSendMetricsClient{stream}
+	return x, nil
+}
+
+type Manager_SendMetricsClient interface {
+	Send(*BabysitterMetrics) error
+	CloseAndRecv() (*StreamReply, error)
+	grpc.ClientStream
+}
+
+type managerSendMetricsClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerSendMetricsClient) Send(m *BabysitterMetrics) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *managerSendMetricsClient) CloseAndRecv() (*StreamReply, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(StreamReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ManagerServer is the server API for Manager service.
+// All implementations must embed UnimplementedManagerServer
+// for forward compatibility
+type ManagerServer interface {
+	// SendLogEntries streams log entries to the manager.
+	SendLogEntries(Manager_SendLogEntriesServer) error
+	// SendTraceSpans streams trace spans to the manager.
+	SendTraceSpans(Manager_SendTraceSpansServer) error
+	// SendMetrics streams metrics snapshots to the manager.
+	SendMetrics(Manager_SendMetricsServer) error
+	mustEmbedUnimplementedManagerServer()
+}
+
+// UnimplementedManagerServer must be embedded to have forward compatible implementations.
+type UnimplementedManagerServer struct {
+}
+
+func (UnimplementedManagerServer) SendLogEntries(Manager_SendLogEntriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method SendLogEntries not implemented")
+}
+func (UnimplementedManagerServer) SendTraceSpans(Manager_SendTraceSpansServer) error {
+	return status.Errorf(codes.Unimplemented, "method SendTraceSpans not implemented")
+}
+func (UnimplementedManagerServer) SendMetrics(Manager_SendMetricsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SendMetrics not implemented")
+}
+func (UnimplementedManagerServer) mustEmbedUnimplementedManagerServer() {}
+
+// UnsafeManagerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ManagerServer will
+// result in compilation errors.
+type UnsafeManagerServer interface {
+	mustEmbedUnimplementedManagerServer()
+}
+
+func RegisterManagerServer(s grpc.ServiceRegistrar, srv ManagerServer) {
+	s.RegisterService(&Manager_ServiceDesc, srv)
+}
+
+func _Manager_SendLogEntries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ManagerServer).SendLogEntries(&managerSendLogEntriesServer{stream})
+}
+
+type Manager_SendLogEntriesServer interface {
+	SendAndClose(*StreamReply) error
+	Recv() (*protos.LogEntry, error)
+	grpc.ServerStream
+}
+
+type managerSendLogEntriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerSendLogEntriesServer) SendAndClose(m *StreamReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *managerSendLogEntriesServer) Recv() (*protos.LogEntry, error) {
+	m := new(protos.LogEntry)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Manager_SendTraceSpans_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ManagerServer).SendTraceSpans(&managerSendTraceSpansServer{stream})
+}
+
+type Manager_SendTraceSpansServer interface {
+	SendAndClose(*StreamReply) error
+	Recv() (*protos.Spans, error)
+	grpc.ServerStream
+}
+
+type managerSendTraceSpansServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerSendTraceSpansServer) SendAndClose(m *StreamReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *managerSendTraceSpansServer) Recv() (*protos.Spans, error) {
+	m := new(protos.Spans)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Manager_SendMetrics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ManagerServer).SendMetrics(&managerSendMetricsServer{stream})
+}
+
+type Manager_SendMetricsServer interface {
+	SendAndClose(*StreamReply) error
+	Recv() (*BabysitterMetrics, error)
+	grpc.ServerStream
+}
+
+type managerSendMetricsServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerSendMetricsServer) SendAndClose(m *StreamReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *managerSendMetricsServer) Recv() (*BabysitterMetrics, error) {
+	m := new(BabysitterMetrics)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Manager_ServiceDesc is the grpc.ServiceDesc for Manager service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Manager_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "impl.Manager",
+	HandlerType: (*ManagerServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SendLogEntries",
+			Handler:       _Manager_SendLogEntries_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SendTraceSpans",
+			Handler:       _Manager_SendTraceSpans_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SendMetrics",
+			Handler:       _Manager_SendMetrics_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "internal/tool/ssh/impl/ssh.proto",
+}
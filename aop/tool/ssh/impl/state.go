@@ -0,0 +1,468 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/maps"
+	gproto "google.golang.org/protobuf/proto"
+	"greatestworks/aop/files"
+	"greatestworks/aop/protos"
+	"greatestworks/aop/proxy"
+	"greatestworks/aop/versioned_map"
+)
+
+// This file implements the persistence and recovery of the manager state. If
+// ManagerOptions.StateDir is set, the manager persists in it (or, in
+// high-availability mode, in the coordinator; see ha.go):
+//
+//   - the app and routing state of every application version, in persistent
+//     versioned maps (see versioned_map.NewPersistentMap), on every update;
+//     and
+//   - the rest of its state (e.g., the replicas and their babysitters), as a
+//     ManagerState, whenever it changes significantly and on every health
+//     check.
+//
+// If the manager crashes, RecoverManager recovers the state and listens on
+// the address of the crashed manager, where the running babysitters keep
+// sending their heartbeats. Replicas started after the latest ManagerState was
+// persisted are unknown to the recovered manager, which asks their
+// babysitters to stop on their next heartbeat; replicas that stopped are
+// replaced once they miss their heartbeats.
+//
+// A rollout interrupted by the crash is aborted: the recovered manager keeps
+// the version that was serving traffic, and stops the others.
+
+// Names of the files and directories in the state directory.
+const (
+	managerStateFile = "manager.pb"
+	versionsStateDir = "versions"
+	appStateDir      = "app"
+	routingStateDir  = "routing"
+)
+
+// ErrNoState is returned by RecoverManager if there is no state to recover.
+var ErrNoState = errors.New("no manager state to recover")
+
+// DefaultStateDir returns the default state directory of the manager of the
+// provided application, in $XDG_DATA_HOME/serviceweaver/ssh_state/<app>, or
+// ~/.local/share/serviceweaver/ssh_state/<app> if XDG_DATA_HOME is not set.
+func DefaultStateDir(app string) (string, error) {
+	dir, err := files.DefaultDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ssh_state", app), nil
+}
+
+// HasState returns whether the provided state directory holds the state of a
+// manager that didn't terminate cleanly.
+func HasState(stateDir string) bool {
+	_, err := os.Stat(filepath.Join(stateDir, managerStateFile))
+	return err == nil
+}
+
+// RecoverManager recovers the manager whose state is in opts.StateDir, and
+// re-attaches to its running babysitters. It returns the deployment serving
+// traffic, and a function that stops the manager. It returns ErrNoState if
+// there is no state to recover.
+func RecoverManager(ctx context.Context, logDir string, opts ManagerOptions) (*protos.Deployment, func() error, error) {
+	if opts.StateDir == "" {
+		return nil, nil, fmt.Errorf("recover manager: no state directory provided")
+	}
+	m, err := recoverManager(ctx, logDir, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	m.start()
+	return m.dep, m.stop, nil
+}
+
+// recoverManager returns a manager that recovers the state persisted in the
+// state store of the provided options. The manager doesn't run until start is
+// called.
+func recoverManager(ctx context.Context, logDir string, opts ManagerOptions) (*manager, error) {
+	bytes, err := newStateStore(opts).loadManager()
+	if err != nil {
+		return nil, err
+	}
+	state := &ManagerState{}
+	if err := gproto.Unmarshal(bytes, state); err != nil {
+		return nil, fmt.Errorf("recover manager: %w", err)
+	}
+	var dep *protos.Deployment
+	for _, v := range state.Versions {
+		if v.Deployment.Id == state.DeploymentId {
+			dep = v.Deployment
+		}
+	}
+	if dep == nil {
+		return nil, fmt.Errorf("recover manager: deployment %q not found", state.DeploymentId)
+	}
+
+	m, err := newManager(ctx, dep, state.Locations, logDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.recoverVersions(state); err != nil {
+		m.launcher.Close()
+		return nil, fmt.Errorf("recover manager: %w", err)
+	}
+	m.recovered = state
+	m.savedState = bytes
+	return m, nil
+}
+
+// recoverVersions recovers the application versions in the provided state.
+func (m *manager) recoverVersions(state *ManagerState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.versions = map[string]*appVersion{}
+	now := time.Now()
+	for _, vs := range state.Versions {
+		v := newAppVersion(vs.Deployment)
+		if err := m.persistVersion(v); err != nil {
+			return err
+		}
+		for _, g := range vs.Started {
+			v.started[g] = true
+		}
+		for _, rs := range vs.Replicas {
+			v.replicas[rs.Id] = &replica{
+				id:        rs.Id,
+				group:     rs.Group,
+				loc:       rs.Location,
+				tag:       rs.Tag,
+				addr:      rs.Addr,
+				pid:       rs.Pid,
+				listeners: rs.Listeners,
+				// Give the babysitter a full heartbeat timeout to reach the
+				// recovered manager.
+				lastHeartbeat: now,
+			}
+		}
+		v.nextReplicaId = vs.NextReplicaId
+		v.sections = vs.Sections
+		v.configGen = vs.ConfigGeneration
+		if vs.ChangedAt != nil {
+			v.changedAt = vs.ChangedAt
+		}
+		if err := m.reconcileRouting(v); err != nil {
+			return err
+		}
+		m.versions[v.dep.Id] = v
+	}
+	return nil
+}
+
+// reconcileRouting removes from the routing information of the provided
+// version the weavelets of replicas that are not known to the manager (e.g.,
+// replicas started after the manager state was last persisted).
+//
+// REQUIRES: m.mu is held.
+func (m *manager) reconcileRouting(v *appVersion) error {
+	known := map[string]bool{}
+	for _, r := range v.replicas {
+		if r.addr != "" {
+			known[r.addr] = true
+		}
+	}
+	state, _, err := m.loadAppState(v, "" /*version*/)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, g := range state.Groups {
+		var replicas []string
+		for _, addr := range g.Replicas {
+			if known[addr] {
+				replicas = append(replicas, addr)
+			}
+		}
+		if len(replicas) == len(g.Replicas) {
+			continue
+		}
+		g.Replicas = replicas
+		g = m.findOrAddGroup(state, g.Name)
+		if err := m.mayGenerateNewRoutingInfo(v, g); err != nil {
+			return err
+		}
+		changed = true
+	}
+	if changed {
+		v.appState.Update(appVersionStateKey, state)
+	}
+	return nil
+}
+
+// reattach re-creates the proxies of the recovered manager, and aborts the
+// rollout that was in progress, if any.
+func (m *manager) reattach(state *ManagerState) error {
+	m.mu.Lock()
+	current := m.versions[m.dep.Id]
+	var aborted []*appVersion
+	for _, v := range m.versions {
+		if v != current {
+			aborted = append(aborted, v)
+		}
+	}
+
+	// Map the listeners of the current version to their proxies.
+	appState, _, err := m.loadAppState(current, "" /*version*/)
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	exported := map[string]bool{}
+	for _, r := range current.replicas {
+		for _, addr := range r.listeners {
+			exported[addr] = true
+		}
+	}
+	backends := map[string][]string{}
+	for _, l := range appState.Listeners {
+		if exported[l.Addr] {
+			backends[l.Name] = append(backends[l.Name], l.Addr)
+		}
+	}
+	for _, ps := range state.Proxies {
+		addr := ps.Addr
+		if m.opts.HA.Coordinator != nil {
+			// The new leader runs on a different machine. Listen on the same
+			// port on this machine.
+			_, port, err := net.SplitHostPort(ps.Addr)
+			if err != nil {
+				m.mu.Unlock()
+				return err
+			}
+			addr = net.JoinHostPort(m.opts.Host, port)
+		}
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			m.mu.Unlock()
+			return fmt.Errorf("proxy listen: %w", err)
+		}
+		p := m.newProxy(ps.Listener, lis, backends[ps.Listener])
+		p.backends[current.dep.Id] = backends[ps.Listener]
+	}
+	m.mu.Unlock()
+
+	m.logger.Info("Recovered manager", "version", current.dep.Id, "replicas", len(current.replicas))
+	for _, v := range aborted {
+		m.logger.Info("Aborting interrupted rollout", "version", v.dep.Id)
+		if err := m.stopVersion(v); err != nil {
+			m.logger.Error("Unable to stop aborted version", err, "version", v.dep.Id)
+		}
+	}
+	return nil
+}
+
+// newProxy creates and serves a proxy for the provided listener on the
+// provided network listener, forwarding traffic to the provided backends.
+//
+// REQUIRES: m.mu is held.
+func (m *manager) newProxy(listener string, lis net.Listener, backends []string) *proxyInfo {
+	addr := lis.Addr().String()
+	m.logger.Info("Proxy listening", "address", addr)
+	p := proxy.NewProxy(m.logger)
+	for _, backend := range backends {
+		p.AddBackend(backend)
+	}
+	if affinity, ok := m.opts.ProxyAffinity[listener]; ok {
+		p.SetAffinity(affinity)
+	}
+	info := &proxyInfo{proxy: p, addr: addr, backends: map[string][]string{}}
+	m.proxies[listener] = info
+	go func() {
+		if err := serveHTTP(m.ctx, lis, p); err != nil {
+			m.logger.Error("Proxy", err)
+		}
+	}()
+	go p.RunHealthChecks(m.ctx, m.opts.ProxyHealthChecks)
+	return info
+}
+
+// persistVersion makes the app and routing state of the provided version
+// persistent, if the manager persists its state, and recovers their persisted
+// content.
+func (m *manager) persistVersion(v *appVersion) error {
+	if m.state == nil {
+		return nil
+	}
+	onError := func(key string, err error) {
+		m.logger.Error("Unable to persist manager state", err, "key", key, "version", v.dep.Id)
+	}
+	store, err := m.state.versionStore(v.dep.Id, appStateDir)
+	if err != nil {
+		return err
+	}
+	if v.appState, err = versioned_map.NewPersistentMap[*AppVersionState](store, onError); err != nil {
+		return err
+	}
+	if store, err = m.state.versionStore(v.dep.Id, routingStateDir); err != nil {
+		return err
+	}
+	v.routingState, err = versioned_map.NewPersistentMap[*protos.RoutingInfo](store, onError)
+	return err
+}
+
+// removeVersionState removes the persisted state of the provided version.
+func (m *manager) removeVersionState(v *appVersion) {
+	if m.state == nil {
+		return
+	}
+	if err := m.state.removeVersion(v.dep.Id); err != nil {
+		m.logger.Error("Unable to remove version state", err, "version", v.dep.Id)
+	}
+}
+
+// saveState persists the manager state, if the manager persists its state and
+// the state changed since it was last persisted.
+//
+// REQUIRES: m.mu is held.
+func (m *manager) saveState() {
+	if m.state == nil || m.mgrAddress == "" {
+		return
+	}
+	state := &ManagerState{
+		Addr:         strings.TrimPrefix(m.mgrAddress, "http://"),
+		Locations:    m.locations,
+		DeploymentId: m.dep.Id,
+	}
+	for _, v := range m.versions {
+		vs := &VersionState{
+			Deployment:       v.dep,
+			Started:          maps.Keys(v.started),
+			NextReplicaId:    v.nextReplicaId,
+			Sections:         v.sections,
+			ConfigGeneration: v.configGen,
+			ChangedAt:        v.changedAt,
+		}
+		for _, r := range v.replicas {
+			vs.Replicas = append(vs.Replicas, &ReplicaState{
+				Id:        r.id,
+				Group:     r.group,
+				Location:  r.loc,
+				Tag:       r.tag,
+				Addr:      r.addr,
+				Pid:       r.pid,
+				Listeners: r.listeners,
+			})
+		}
+		state.Versions = append(state.Versions, vs)
+	}
+	for name, p := range m.proxies {
+		state.Proxies = append(state.Proxies, &ProxyState{Listener: name, Addr: p.addr})
+	}
+
+	data, err := gproto.MarshalOptions{Deterministic: true}.Marshal(state)
+	if err != nil {
+		m.logger.Error("Unable to encode manager state", err)
+		return
+	}
+	if bytes.Equal(data, m.savedState) {
+		return
+	}
+	if err := m.state.saveManager(data); err != nil {
+		m.logger.Error("Unable to persist manager state", err)
+		return
+	}
+	m.savedState = data
+}
+
+// A stateStore persists the state of a manager.
+type stateStore interface {
+	// saveManager persists the provided encoded ManagerState.
+	saveManager(data []byte) error
+
+	// loadManager returns the persisted ManagerState, or ErrNoState if
+	// there is none.
+	loadManager() ([]byte, error)
+
+	// versionStore returns the store of the provided versioned map (e.g.,
+	// appStateDir) of the provided application version.
+	versionStore(depId, name string) (versioned_map.Store, error)
+
+	// removeVersion removes the persisted state of the provided
+	// application version.
+	removeVersion(depId string) error
+
+	// clear removes all the persisted state.
+	clear() error
+}
+
+// newStateStore returns the state store of a manager with the provided
+// options, or nil if the manager doesn't persist its state.
+func newStateStore(opts ManagerOptions) stateStore {
+	if opts.HA.Coordinator != nil {
+		return &coordinatorStateStore{opts: opts.HA.withDefaults()}
+	}
+	if opts.StateDir == "" {
+		return nil
+	}
+	return dirStateStore(opts.StateDir)
+}
+
+// dirStateStore is a stateStore that persists the state of a manager in a
+// local directory.
+type dirStateStore string
+
+var _ stateStore = dirStateStore("")
+
+// saveManager implements the stateStore interface.
+func (d dirStateStore) saveManager(data []byte) error {
+	if err := os.MkdirAll(string(d), 0700); err != nil {
+		return err
+	}
+	w := files.NewWriter(filepath.Join(string(d), managerStateFile))
+	defer w.Cleanup()
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// loadManager implements the stateStore interface.
+func (d dirStateStore) loadManager() ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(string(d), managerStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoState
+	}
+	return data, err
+}
+
+// versionStore implements the stateStore interface.
+func (d dirStateStore) versionStore(depId, name string) (versioned_map.Store, error) {
+	return versioned_map.NewFileStore(filepath.Join(string(d), versionsStateDir, depId, name))
+}
+
+// removeVersion implements the stateStore interface.
+func (d dirStateStore) removeVersion(depId string) error {
+	return os.RemoveAll(filepath.Join(string(d), versionsStateDir, depId))
+}
+
+// clear implements the stateStore interface.
+func (d dirStateStore) clear() error {
+	return os.RemoveAll(string(d))
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	gproto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"greatestworks/aop/logging"
+	"greatestworks/aop/protos"
+)
+
+// testManager returns a manager that persists its state in stateDir, without
+// starting it.
+func testManager(t *testing.T, stateDir string) *manager {
+	return &manager{
+		ctx:       context.Background(),
+		logger:    logging.NewTestLogger(t),
+		opts:      ManagerOptions{StateDir: stateDir},
+		state:     dirStateStore(stateDir),
+		locations: []string{"loc1", "loc2"},
+		versions:  map[string]*appVersion{},
+		proxies:   map[string]*proxyInfo{},
+	}
+}
+
+func TestRecoverState(t *testing.T) {
+	dir := t.TempDir()
+	dep := &protos.Deployment{
+		Id:  "v1",
+		App: &protos.AppConfig{Name: "app", Sections: map[string]string{"a": "1"}},
+	}
+
+	// Populate the state of a manager with two registered replicas and one
+	// replica that is not yet persisted.
+	m := testManager(t, dir)
+	m.dep = dep
+	m.mgrAddress = "http://localhost:1234"
+	v := newAppVersion(dep)
+	if err := m.persistVersion(v); err != nil {
+		t.Fatal(err)
+	}
+	m.versions[dep.Id] = v
+	v.started["main"] = true
+	v.applyConfig(map[string]string{"a": "2"})
+	v.replicas[0] = &replica{id: 0, group: "main", loc: "loc1", tag: "t0", addr: "tcp://r0", pid: 10}
+	v.replicas[1] = &replica{id: 1, group: "main", loc: "loc2", tag: "t1", addr: "tcp://r1", pid: 11, listeners: []string{"l1"}}
+	v.nextReplicaId = 2
+	state, _, err := m.loadAppState(v, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := m.findOrAddGroup(state, "main")
+	g.Replicas = []string{"tcp://r0", "tcp://r1"}
+	if err := m.mayGenerateNewRoutingInfo(v, g); err != nil {
+		t.Fatal(err)
+	}
+	v.appState.Update(appVersionStateKey, state)
+	m.proxies["lis"] = &proxyInfo{addr: "localhost:8000", backends: map[string][]string{}}
+	m.saveState()
+
+	// Register a replica without persisting the manager state.
+	g.Replicas = append(g.Replicas, "tcp://r2")
+	if err := m.mayGenerateNewRoutingInfo(v, g); err != nil {
+		t.Fatal(err)
+	}
+	v.appState.Update(appVersionStateKey, state)
+
+	// Recover the state.
+	saved := &ManagerState{}
+	bytes, err := os.ReadFile(filepath.Join(dir, managerStateFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gproto.Unmarshal(bytes, saved); err != nil {
+		t.Fatal(err)
+	}
+	want := &ManagerState{
+		Addr:         "localhost:1234",
+		Locations:    []string{"loc1", "loc2"},
+		DeploymentId: "v1",
+		Proxies:      []*ProxyState{{Listener: "lis", Addr: "localhost:8000"}},
+	}
+	opts := []cmp.Option{protocmp.Transform(), protocmp.IgnoreFields(&ManagerState{}, "versions")}
+	if diff := cmp.Diff(want, saved, opts...); diff != "" {
+		t.Fatalf("saved state (-want +got):\n%s", diff)
+	}
+
+	recovered := testManager(t, dir)
+	recovered.dep = dep
+	if err := recovered.recoverVersions(saved); err != nil {
+		t.Fatal(err)
+	}
+	rv := recovered.versions[dep.Id]
+	if rv == nil {
+		t.Fatalf("version %q not recovered", dep.Id)
+	}
+	if got, want := len(rv.replicas), 2; got != want {
+		t.Errorf("recovered replicas: got %d, want %d", got, want)
+	}
+	if r := rv.replicas[1]; r == nil || r.addr != "tcp://r1" || r.pid != 11 || r.tag != "t1" {
+		t.Errorf("recovered replica 1: got %+v", r)
+	}
+	if rv.nextReplicaId != 2 || !rv.started["main"] || rv.configGen != 1 || rv.sections["a"] != "2" {
+		t.Errorf("recovered version: got next id %d, started %v, config %v@%d",
+			rv.nextReplicaId, rv.started, rv.sections, rv.configGen)
+	}
+
+	// The unpersisted replica is removed from the routing information.
+	routing, _, err := recovered.loadRoutingState(rv, "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"tcp://r0", "tcp://r1"}, routing.Replicas); diff != "" {
+		t.Errorf("recovered routing replicas (-want +got):\n%s", diff)
+	}
+
+	// Removing the version removes its state.
+	recovered.removeVersionState(rv)
+	if _, err := os.Stat(filepath.Join(dir, versionsStateDir, dep.Id)); !os.IsNotExist(err) {
+		t.Errorf("version state not removed: %v", err)
+	}
+}
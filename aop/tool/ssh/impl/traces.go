@@ -0,0 +1,78 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/sdk/trace"
+	"greatestworks/aop/perfetto"
+	"greatestworks/aop/traceio/otlp"
+)
+
+// TraceOptions configures where the manager stores the trace spans of the
+// application. By default, spans are stored in the local Perfetto database.
+type TraceOptions struct {
+	// DisablePerfetto, if true, doesn't store spans in the local Perfetto
+	// database.
+	DisablePerfetto bool
+
+	// OTLP, if OTLP.Endpoint is not empty, configures the OTLP/gRPC
+	// collector (e.g., Jaeger or Grafana Tempo) to which spans are exported.
+	OTLP otlp.Options
+}
+
+// traceStore stores the sampled trace spans of an application version in the
+// stores specified by TraceOptions.
+type traceStore func(depId string, spans []trace.ReadOnlySpan) error
+
+// newTraceStore returns a traceStore for the spans of the provided
+// application. The OTLP exporter, if any, is closed when ctx is cancelled.
+func newTraceStore(ctx context.Context, app string, opts TraceOptions) (traceStore, error) {
+	var stores []traceStore
+	if !opts.DisablePerfetto {
+		traceDB, err := perfetto.Open(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open Perfetto database: %w", err)
+		}
+		stores = append(stores, func(depId string, spans []trace.ReadOnlySpan) error {
+			return traceDB.Store(ctx, app, depId, spans)
+		})
+	}
+	if opts.OTLP.Endpoint != "" {
+		exporter, err := otlp.NewExporter(opts.OTLP)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create OTLP exporter: %w", err)
+		}
+		go func() {
+			<-ctx.Done()
+			exporter.Close()
+		}()
+		stores = append(stores, func(depId string, spans []trace.ReadOnlySpan) error {
+			return exporter.Export(ctx, app, depId, spans)
+		})
+	}
+	return func(depId string, spans []trace.ReadOnlySpan) error {
+		// Store the spans everywhere, even if a store fails.
+		var first error
+		for _, store := range stores {
+			if err := store(depId, spans); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	}, nil
+}
@@ -0,0 +1,66 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"greatestworks/aop/logging"
+	"greatestworks/aop/tool"
+	"greatestworks/aop/tool/ssh/impl"
+)
+
+var recoverCmd = tool.Command{
+	Name:        "recover",
+	Description: "Recover the manager of a Service Weaver app",
+	Help: `Usage:
+  weaver ssh recover <configfile>
+
+Recovers the manager of an app deployed using the SSH deployer that didn't
+terminate cleanly (e.g., because its machine crashed), and re-attaches to the
+babysitters of the app that are still running. Run it on the machine where
+the manager was running.`,
+	Flags: flag.NewFlagSet("recover", flag.ContinueOnError),
+	Fn:    recoverManager,
+}
+
+// recoverManager recovers the manager of the app in the provided config file.
+func recoverManager(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no config file provided")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments")
+	}
+	cfgFile := args[0]
+	app, config, err := loadConfig(cfgFile)
+	if err != nil {
+		return err
+	}
+	running, err := findRunningDeployment(ctx, app.Name)
+	if err != nil {
+		return err
+	}
+	if running != nil {
+		return fmt.Errorf("the manager of app %s is running", app.Name)
+	}
+	opts, err := config.managerOptions()
+	if err != nil {
+		return err
+	}
+	if opts.StateDir, err = impl.DefaultStateDir(app.Name); err != nil {
+		return err
+	}
+	opts.ConfigFile = cfgFile
+	dep, stopFn, err := impl.RecoverManager(ctx, logDir, opts)
+	if errors.Is(err, impl.ErrNoState) {
+		return fmt.Errorf("no manager of app %s to recover", app.Name)
+	} else if err != nil {
+		return fmt.Errorf("cannot recover the manager: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Recovered deployment %s\n", logging.Shorten(dep.Id))
+	stopOnSignal(app.Name, stopFn)
+	return followLogs(ctx, dep.Id)
+}
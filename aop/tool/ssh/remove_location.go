@@ -0,0 +1,74 @@
+package ssh
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"greatestworks/aop/logging"
+	"greatestworks/aop/tool"
+	"greatestworks/aop/tool/ssh/impl"
+)
+
+var (
+	removeLocationFlags = flag.NewFlagSet("remove-location", flag.ContinueOnError)
+	removeLocationDep   = removeLocationFlags.String("deployment", "", "Only drain the deployment with the provided id (prefix)")
+
+	removeLocationCmd = tool.Command{
+		Name:        "remove-location",
+		Description: "Drain and remove a location from running deployments",
+		Help: fmt.Sprintf(`Usage:
+  weaver ssh remove-location [--deployment=<id>] <location>
+
+Flags:
+  -h, --help	Print this help message.
+%s
+
+Drains all the replicas running at the provided location, replacing them with
+replicas running at the remaining locations, and removes the location from the
+deployments. Use it to perform planned maintenance of a location without
+downtime.`, tool.FlagsHelp(removeLocationFlags)),
+		Flags: removeLocationFlags,
+		Fn:    removeLocation,
+	}
+)
+
+// removeLocation drains and removes a location from all the running SSH
+// deployments, or from the deployment specified by the --deployment flag.
+func removeLocation(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no location provided")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments")
+	}
+	loc := args[0]
+
+	registry, err := impl.DefaultRegistry(ctx)
+	if err != nil {
+		return fmt.Errorf("create registry: %w", err)
+	}
+	regs, err := registry.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list deployments: %w", err)
+	}
+	var removed int
+	for _, reg := range regs {
+		if !strings.HasPrefix(reg.DeploymentId, *removeLocationDep) {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Draining location %s of deployment %s...\n", loc, logging.Shorten(reg.DeploymentId))
+		if err := impl.RemoveLocation(ctx, reg.Addr, loc); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to remove location %s from deployment %s: %v\n", loc, logging.Shorten(reg.DeploymentId), err)
+			continue
+		}
+		removed++
+	}
+	if removed == 0 {
+		return fmt.Errorf("location %s not removed from any deployment", loc)
+	}
+	fmt.Fprintf(os.Stderr, "Location %s removed from %d deployment(s)\n", loc, removed)
+	return nil
+}
@@ -0,0 +1,90 @@
+package ssh
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"greatestworks/aop/logging"
+	"greatestworks/aop/tool"
+	"greatestworks/aop/tool/ssh/impl"
+)
+
+var (
+	setLogLevelFlags = flag.NewFlagSet("set-log-level", flag.ContinueOnError)
+	setLogLevelDep   = setLogLevelFlags.String("deployment", "", "Only update the deployment with the provided id (prefix)")
+
+	setLogLevelCmd = tool.Command{
+		Name:        "set-log-level",
+		Description: "Set the log level of a component of running deployments",
+		Help: fmt.Sprintf(`Usage:
+  weaver ssh set-log-level [--deployment=<id>] <component> <level>
+
+Flags:
+  -h, --help	Print this help message.
+%s
+
+Sets the minimum level (debug, info, or error) of the log entries of the
+provided component in the running deployments, without restarting them. The
+component is identified by its full or shortened name (e.g., app.Store), or is
+"default" to set the level of the components without a level. An empty level
+unsets the level of the component.
+
+The levels are stored in the log_levels section of the config of the
+deployments, and are kept when a config without a log_levels section is
+applied.
+
+Examples:
+  # Log the debug entries of component app.Store.
+  weaver ssh set-log-level --deployment=1234abcd app.Store debug
+
+  # Only log the errors of the components without a level.
+  weaver ssh set-log-level default error
+
+  # Unset the level of component app.Store.
+  weaver ssh set-log-level app.Store ''`, tool.FlagsHelp(setLogLevelFlags)),
+		Flags: setLogLevelFlags,
+		Fn:    setLogLevel,
+	}
+)
+
+// setLogLevel sets the log level of a component of all the running SSH
+// deployments, or of the deployment specified by the --deployment flag.
+func setLogLevel(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("no component and level provided")
+	}
+	if len(args) > 2 {
+		return fmt.Errorf("too many arguments")
+	}
+	req := &impl.SetLogLevelRequest{Component: args[0], Level: args[1]}
+
+	registry, err := impl.DefaultRegistry(ctx)
+	if err != nil {
+		return fmt.Errorf("create registry: %w", err)
+	}
+	regs, err := registry.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list deployments: %w", err)
+	}
+	var updated int
+	for _, reg := range regs {
+		if !strings.HasPrefix(reg.DeploymentId, *setLogLevelDep) {
+			continue
+		}
+		reply, err := impl.SetLogLevel(ctx, reg.Addr, req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to set the log level of deployment %s: %v\n", logging.Shorten(reg.DeploymentId), err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Set log level of %s to %q in deployment %s (generation %d)\n",
+			req.Component, req.Level, logging.Shorten(reg.DeploymentId), reply.Generation)
+		updated++
+	}
+	if updated == 0 {
+		return fmt.Errorf("log level not set in any deployment")
+	}
+	return nil
+}
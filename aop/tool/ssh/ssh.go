@@ -13,9 +13,15 @@ var (
 	logDir = filepath.Join(logging.DefaultLogDir, "weaver_ssh")
 
 	Commands = map[string]*tool.Command{
-		"deploy":    &deployCmd,
-		"logs":      tool.LogsCmd(&logsSpec),
-		"dashboard": status.DashboardCommand(dashboardSpec),
+		"deploy":          &deployCmd,
+		"recover":         &recoverCmd,
+		"standby":         &standbyCmd,
+		"logs":            tool.LogsCmd(&logsSpec),
+		"dashboard":       status.DashboardCommand(dashboardSpec),
+		"remove-location": &removeLocationCmd,
+		"update-config":   &updateConfigCmd,
+		"set-log-level":   &setLogLevelCmd,
+		"chaos":           &chaosCmd,
 
 		// Hidden commands.
 		"babysitter": &babysitterCmd,
@@ -0,0 +1,64 @@
+package ssh
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"greatestworks/aop/tool"
+	"greatestworks/aop/tool/ssh/impl"
+)
+
+var standbyCmd = tool.Command{
+	Name:        "standby",
+	Description: "Run a standby manager of a Service Weaver app",
+	Help: `Usage:
+  weaver ssh standby <configfile>
+
+Runs a standby manager of an app deployed using the SSH deployer in
+high-availability mode (see the [ssh.ha] section of the config file). If the
+manager of the app fails, a standby manager takes over the app, and the
+babysitters of the app fail over to it.`,
+	Flags: flag.NewFlagSet("standby", flag.ContinueOnError),
+	Fn:    standby,
+}
+
+// standby runs a standby manager of the app in the provided config file.
+func standby(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no config file provided")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments")
+	}
+	cfgFile := args[0]
+	app, config, err := loadConfig(cfgFile)
+	if err != nil {
+		return err
+	}
+	ha, port, ok, err := config.haOptions(app.Name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("high-availability mode is not configured for app %s", app.Name)
+	}
+	locs, err := getLocations(config)
+	if err != nil {
+		return err
+	}
+	opts, err := config.managerOptions()
+	if err != nil {
+		return err
+	}
+	opts.HA = ha
+	opts.Port = port
+	opts.ConfigFile = cfgFile
+	stopFn, err := impl.RunHAManager(ctx, app.Name, nil, locs, logDir, opts)
+	if err != nil {
+		return fmt.Errorf("cannot instantiate the manager: %w", err)
+	}
+	stopOnSignal(app.Name, stopFn)
+	<-ctx.Done()
+	return ctx.Err()
+}
@@ -0,0 +1,99 @@
+package ssh
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"greatestworks/aop"
+	"greatestworks/aop/codegen"
+	"greatestworks/aop/logging"
+	"greatestworks/aop/tool"
+	"greatestworks/aop/tool/ssh/impl"
+)
+
+var (
+	updateConfigFlags = flag.NewFlagSet("update-config", flag.ContinueOnError)
+	updateConfigDep   = updateConfigFlags.String("deployment", "", "Only update the deployment with the provided id (prefix)")
+
+	updateConfigCmd = tool.Command{
+		Name:        "update-config",
+		Description: "Apply a config file to running deployments",
+		Help: fmt.Sprintf(`Usage:
+  weaver ssh update-config [--deployment=<id>] <configfile>
+
+Flags:
+  -h, --help	Print this help message.
+%s
+
+Applies the config sections of the provided config file to the running
+deployments of the application, without restarting them. Only the config
+sections can be updated; changes to the application settings (e.g., the
+binary or its arguments) require a new deployment.
+
+Running deployments also apply their config file whenever it changes.`, tool.FlagsHelp(updateConfigFlags)),
+		Flags: updateConfigFlags,
+		Fn:    updateConfig,
+	}
+)
+
+// updateConfig applies a config file to all the running SSH deployments of
+// its application, or to the deployment specified by the --deployment flag.
+func updateConfig(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no config file provided")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments")
+	}
+
+	// Load the config file. We resolve its includes and environment
+	// variables here, so that the managers don't need access to them.
+	cfgFile := args[0]
+	cfg, err := os.ReadFile(cfgFile)
+	if err != nil {
+		return fmt.Errorf("load config file %q: %w", cfgFile, err)
+	}
+	app, err := aop.ParseConfig(cfgFile, string(cfg), codegen.ComponentConfigValidator)
+	if err != nil {
+		return fmt.Errorf("load config file %q: %w", cfgFile, err)
+	}
+	resolved, err := aop.ResolveConfig(cfgFile, string(cfg))
+	if err != nil {
+		return fmt.Errorf("load config file %q: %w", cfgFile, err)
+	}
+
+	registry, err := impl.DefaultRegistry(ctx)
+	if err != nil {
+		return fmt.Errorf("create registry: %w", err)
+	}
+	regs, err := registry.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list deployments: %w", err)
+	}
+	var updated int
+	for _, reg := range regs {
+		if reg.App != app.Name || !strings.HasPrefix(reg.DeploymentId, *updateConfigDep) {
+			continue
+		}
+		req := &impl.UpdateConfigRequest{Config: resolved, File: cfgFile}
+		reply, err := impl.UpdateConfig(ctx, reg.Addr, req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to update the config of deployment %s: %v\n", logging.Shorten(reg.DeploymentId), err)
+			continue
+		}
+		if len(reply.Changed) == 0 {
+			fmt.Fprintf(os.Stderr, "Config of deployment %s is up to date (generation %d)\n", logging.Shorten(reg.DeploymentId), reply.Generation)
+		} else {
+			fmt.Fprintf(os.Stderr, "Updated config of deployment %s to generation %d (changed: %s)\n",
+				logging.Shorten(reg.DeploymentId), reply.Generation, strings.Join(reply.Changed, ", "))
+		}
+		updated++
+	}
+	if updated == 0 {
+		return fmt.Errorf("no deployment of app %q updated", app.Name)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+// Package otlp exports trace spans to an OpenTelemetry collector (e.g.,
+// Jaeger, Grafana Tempo) using the OTLP/gRPC protocol.
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"greatestworks/aop/traceio"
+)
+
+// Options configures an Exporter.
+type Options struct {
+	// Endpoint is the address (host:port) of the OTLP/gRPC endpoint of the
+	// collector, e.g. "tempo.example.com:4317".
+	Endpoint string
+
+	// Insecure, if true, disables TLS.
+	Insecure bool
+
+	// Headers are sent with every request, e.g. to authenticate to the
+	// collector or to select a tenant ("X-Scope-OrgID" for Tempo).
+	Headers map[string]string
+
+	// Timeout bounds every export request. Defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// withDefaults returns a copy of the options, with default values filled in.
+func (o Options) withDefaults() Options {
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	return o
+}
+
+// An Exporter exports trace spans to an OTLP/gRPC collector. You can safely
+// use an Exporter from multiple goroutines.
+type Exporter struct {
+	opts   Options
+	conn   *grpc.ClientConn
+	client TraceServiceClient
+}
+
+// NewExporter returns an Exporter that exports trace spans to the collector
+// specified in the provided options. It doesn't wait for the collector to be
+// reachable.
+func NewExporter(opts Options) (*Exporter, error) {
+	if opts.Endpoint == "" {
+		return nil, fmt.Errorf("otlp: no endpoint provided")
+	}
+	creds := credentials.NewTLS(&tls.Config{})
+	if opts.Insecure {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.Dial(opts.Endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("otlp: dial %q: %w", opts.Endpoint, err)
+	}
+	return &Exporter{
+		opts:   opts.withDefaults(),
+		conn:   conn,
+		client: NewTraceServiceClient(conn),
+	}, nil
+}
+
+// Close closes the connection to the collector.
+func (e *Exporter) Close() error {
+	return e.conn.Close()
+}
+
+// Export exports the provided spans of the provided application version.
+// The spans are attributed to a service named after the application (see
+// ResourceSpans).
+func (e *Exporter) Export(ctx context.Context, app, version string, spans []sdk.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.opts.Timeout)
+	defer cancel()
+	if len(e.opts.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(e.opts.Headers))
+	}
+	reply, err := e.client.Export(ctx, &ExportTraceServiceRequest{
+		ResourceSpans: ToResourceSpans(app, version, spans),
+	})
+	if err != nil {
+		return fmt.Errorf("otlp: export %d spans: %w", len(spans), err)
+	}
+	if p := reply.PartialSuccess; p != nil && p.RejectedSpans > 0 {
+		return fmt.Errorf("otlp: collector rejected %d of %d spans: %s", p.RejectedSpans, len(spans), p.ErrorMessage)
+	}
+	return nil
+}
+
+// ToResourceSpans converts the provided spans of the provided application
+// version to OTLP spans, grouped by resource and instrumentation scope. The
+// service.name and service.version attributes of the resources are set to
+// the application name and version, unless already set, so that collectors
+// show the spans under the application.
+func ToResourceSpans(app, version string, spans []sdk.ReadOnlySpan) []*ResourceSpans {
+	type scopeKey struct {
+		resource attribute.Distinct
+		scope    string
+	}
+	var resources []*ResourceSpans
+	byResource := map[attribute.Distinct]*ResourceSpans{}
+	byScope := map[scopeKey]*ScopeSpans{}
+	for _, span := range spans {
+		res := span.Resource()
+		var rkey attribute.Distinct
+		if res != nil {
+			rkey = res.Equivalent()
+		}
+		rs, ok := byResource[rkey]
+		if !ok {
+			rs = &ResourceSpans{Resource: toResource(app, version, span)}
+			if res != nil {
+				rs.SchemaUrl = res.SchemaURL()
+			}
+			byResource[rkey] = rs
+			resources = append(resources, rs)
+		}
+
+		lib := span.InstrumentationLibrary()
+		skey := scopeKey{rkey, lib.Name + "@" + lib.Version + "@" + lib.SchemaURL}
+		ss, ok := byScope[skey]
+		if !ok {
+			ss = &ScopeSpans{
+				Scope:     &InstrumentationScope{Name: lib.Name, Version: lib.Version},
+				SchemaUrl: lib.SchemaURL,
+			}
+			byScope[skey] = ss
+			rs.ScopeSpans = append(rs.ScopeSpans, ss)
+		}
+		ss.Spans = append(ss.Spans, toSpan(span))
+	}
+	return resources
+}
+
+// toResource returns the resource of the provided span.
+func toResource(app, version string, span sdk.ReadOnlySpan) *Resource {
+	var attrs []attribute.KeyValue
+	if res := span.Resource(); res != nil {
+		attrs = res.Attributes()
+	}
+	hasName, hasVersion := false, false
+	for _, kv := range attrs {
+		switch kv.Key {
+		case semconv.ServiceNameKey:
+			hasName = true
+		case semconv.ServiceVersionKey:
+			hasVersion = true
+		}
+	}
+	r := &Resource{Attributes: toKeyValues(attrs)}
+	if !hasName {
+		r.Attributes = append(r.Attributes, toKeyValue(semconv.ServiceNameKey.String(app)))
+	}
+	if !hasVersion {
+		r.Attributes = append(r.Attributes, toKeyValue(semconv.ServiceVersionKey.String(version)))
+	}
+	if app != "" {
+		r.Attributes = append(r.Attributes, toKeyValue(traceio.AppNameTraceKey.String(app)))
+	}
+	return r
+}
+
+// toSpan converts a span to an OTLP span.
+func toSpan(span sdk.ReadOnlySpan) *Span {
+	sc := span.SpanContext()
+	tid, sid := sc.TraceID(), sc.SpanID()
+	s := &Span{
+		TraceId:                tid[:],
+		SpanId:                 sid[:],
+		TraceState:             sc.TraceState().String(),
+		Name:                   span.Name(),
+		Kind:                   toKind(span.SpanKind()),
+		StartTimeUnixNano:      uint64(span.StartTime().UnixNano()),
+		EndTimeUnixNano:        uint64(span.EndTime().UnixNano()),
+		Attributes:             toKeyValues(span.Attributes()),
+		DroppedAttributesCount: uint32(span.DroppedAttributes()),
+		DroppedEventsCount:     uint32(span.DroppedEvents()),
+		DroppedLinksCount:      uint32(span.DroppedLinks()),
+		Status:                 toStatus(span.Status()),
+	}
+	if parent := span.Parent(); parent.HasSpanID() {
+		psid := parent.SpanID()
+		s.ParentSpanId = psid[:]
+	}
+	for _, e := range span.Events() {
+		s.Events = append(s.Events, &Span_Event{
+			TimeUnixNano:           uint64(e.Time.UnixNano()),
+			Name:                   e.Name,
+			Attributes:             toKeyValues(e.Attributes),
+			DroppedAttributesCount: uint32(e.DroppedAttributeCount),
+		})
+	}
+	for _, l := range span.Links() {
+		ltid, lsid := l.SpanContext.TraceID(), l.SpanContext.SpanID()
+		s.Links = append(s.Links, &Span_Link{
+			TraceId:                ltid[:],
+			SpanId:                 lsid[:],
+			TraceState:             l.SpanContext.TraceState().String(),
+			Attributes:             toKeyValues(l.Attributes),
+			DroppedAttributesCount: uint32(l.DroppedAttributeCount),
+		})
+	}
+	return s
+}
+
+// toKind converts a span kind to an OTLP span kind.
+func toKind(kind trace.SpanKind) Span_SpanKind {
+	switch kind {
+	case trace.SpanKindInternal:
+		return Span_SPAN_KIND_INTERNAL
+	case trace.SpanKindServer:
+		return Span_SPAN_KIND_SERVER
+	case trace.SpanKindClient:
+		return Span_SPAN_KIND_CLIENT
+	case trace.SpanKindProducer:
+		return Span_SPAN_KIND_PRODUCER
+	case trace.SpanKindConsumer:
+		return Span_SPAN_KIND_CONSUMER
+	default:
+		return Span_SPAN_KIND_UNSPECIFIED
+	}
+}
+
+// toStatus converts a span status to an OTLP status.
+func toStatus(s sdk.Status) *Status {
+	status := &Status{Message: s.Description}
+	switch s.Code {
+	case codes.Ok:
+		status.Code = Status_STATUS_CODE_OK
+	case codes.Error:
+		status.Code = Status_STATUS_CODE_ERROR
+	default:
+		status.Code = Status_STATUS_CODE_UNSET
+	}
+	return status
+}
+
+// toKeyValues converts attributes to OTLP attributes.
+func toKeyValues(kvs []attribute.KeyValue) []*KeyValue {
+	if len(kvs) == 0 {
+		return nil
+	}
+	out := make([]*KeyValue, len(kvs))
+	for i, kv := range kvs {
+		out[i] = toKeyValue(kv)
+	}
+	return out
+}
+
+// toKeyValue converts an attribute to an OTLP attribute.
+func toKeyValue(kv attribute.KeyValue) *KeyValue {
+	return &KeyValue{Key: string(kv.Key), Value: toAnyValue(kv.Value)}
+}
+
+// toAnyValue converts an attribute value to an OTLP value.
+func toAnyValue(v attribute.Value) *AnyValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		return &AnyValue{Value: &AnyValue_BoolValue{BoolValue: v.AsBool()}}
+	case attribute.INT64:
+		return &AnyValue{Value: &AnyValue_IntValue{IntValue: v.AsInt64()}}
+	case attribute.FLOAT64:
+		return &AnyValue{Value: &AnyValue_DoubleValue{DoubleValue: v.AsFloat64()}}
+	case attribute.STRING:
+		return &AnyValue{Value: &AnyValue_StringValue{StringValue: v.AsString()}}
+	case attribute.BOOLSLICE:
+		var values []*AnyValue
+		for _, b := range v.AsBoolSlice() {
+			values = append(values, &AnyValue{Value: &AnyValue_BoolValue{BoolValue: b}})
+		}
+		return &AnyValue{Value: &AnyValue_ArrayValue{ArrayValue: &ArrayValue{Values: values}}}
+	case attribute.INT64SLICE:
+		var values []*AnyValue
+		for _, n := range v.AsInt64Slice() {
+			values = append(values, &AnyValue{Value: &AnyValue_IntValue{IntValue: n}})
+		}
+		return &AnyValue{Value: &AnyValue_ArrayValue{ArrayValue: &ArrayValue{Values: values}}}
+	case attribute.FLOAT64SLICE:
+		var values []*AnyValue
+		for _, f := range v.AsFloat64Slice() {
+			values = append(values, &AnyValue{Value: &AnyValue_DoubleValue{DoubleValue: f}})
+		}
+		return &AnyValue{Value: &AnyValue_ArrayValue{ArrayValue: &ArrayValue{Values: values}}}
+	case attribute.STRINGSLICE:
+		var values []*AnyValue
+		for _, s := range v.AsStringSlice() {
+			values = append(values, &AnyValue{Value: &AnyValue_StringValue{StringValue: s}})
+		}
+		return &AnyValue{Value: &AnyValue_ArrayValue{ArrayValue: &ArrayValue{Values: values}}}
+	default:
+		return &AnyValue{Value: &AnyValue_StringValue{StringValue: v.Emit()}}
+	}
+}
@@ -0,0 +1,109 @@
+package otlp
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"greatestworks/aop/protos"
+	"greatestworks/aop/traceio"
+)
+
+// collector is a fake OTLP collector that records the requests it receives.
+type collector struct {
+	UnimplementedTraceServiceServer
+	requests chan *ExportTraceServiceRequest
+	headers  chan metadata.MD
+}
+
+// Export implements the TraceServiceServer interface.
+func (c *collector) Export(ctx context.Context, req *ExportTraceServiceRequest) (*ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.headers <- md
+	c.requests <- req
+	return &ExportTraceServiceResponse{}, nil
+}
+
+func TestExport(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &collector{
+		requests: make(chan *ExportTraceServiceRequest, 1),
+		headers:  make(chan metadata.MD, 1),
+	}
+	server := grpc.NewServer()
+	RegisterTraceServiceServer(server, c)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	exporter, err := NewExporter(Options{
+		Endpoint: lis.Addr().String(),
+		Insecure: true,
+		Headers:  map[string]string{"x-scope-orgid": "game"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporter.Close()
+
+	tid := make([]byte, 16)
+	tid[15] = 1
+	spans := []sdk.ReadOnlySpan{
+		&traceio.ReadSpan{Span: &protos.Span{
+			Name:         "root",
+			TraceId:      tid,
+			SpanId:       []byte{0, 0, 0, 0, 0, 0, 0, 1},
+			ParentSpanId: make([]byte, 8),
+			Kind:         protos.SpanKind_SERVER,
+			Status:       &protos.Span_Status{Code: protos.Span_Status_ERROR, Error: "boom"},
+		}},
+		&traceio.ReadSpan{Span: &protos.Span{
+			Name:         "child",
+			TraceId:      tid,
+			SpanId:       []byte{0, 0, 0, 0, 0, 0, 0, 2},
+			ParentSpanId: []byte{0, 0, 0, 0, 0, 0, 0, 1},
+		}},
+	}
+	if err := exporter.Export(context.Background(), "todo", "v1", spans); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := (<-c.headers).Get("x-scope-orgid"); len(got) != 1 || got[0] != "game" {
+		t.Errorf("x-scope-orgid header: got %v, want [game]", got)
+	}
+	req := <-c.requests
+	if len(req.ResourceSpans) != 1 {
+		t.Fatalf("got %d resource spans, want 1", len(req.ResourceSpans))
+	}
+	rs := req.ResourceSpans[0]
+	attrs := map[string]string{}
+	for _, kv := range rs.Resource.Attributes {
+		attrs[kv.Key] = kv.Value.GetStringValue()
+	}
+	if got, want := attrs["service.name"], "todo"; got != want {
+		t.Errorf("service.name: got %q, want %q", got, want)
+	}
+	if got, want := attrs["service.version"], "v1"; got != want {
+		t.Errorf("service.version: got %q, want %q", got, want)
+	}
+
+	var got []*Span
+	for _, ss := range rs.ScopeSpans {
+		got = append(got, ss.Spans...)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d spans, want 2", len(got))
+	}
+	root, child := got[0], got[1]
+	if root.Name != "root" || root.Kind != Span_SPAN_KIND_SERVER || root.Status.Code != Status_STATUS_CODE_ERROR || len(root.ParentSpanId) != 0 {
+		t.Errorf("root span: got %v", root)
+	}
+	if child.Name != "child" || string(child.ParentSpanId) != string(root.SpanId) {
+		t.Errorf("child span: got %v, want a child of %x", child, root.SpanId)
+	}
+}
@@ -0,0 +1,302 @@
+package traceio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"greatestworks/aop"
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+// This file implements trace sampling, which bounds the number of spans
+// stored in trace databases (e.g., perfetto.DB) for busy applications.
+//
+// A Sampler combines two policies:
+//
+//   - Head-based sampling keeps a fixed fraction of the traces. The decision
+//     is a function of the trace id, so every process keeps or drops all the
+//     spans of a trace.
+//   - Tail-based sampling keeps the traces that head-based sampling drops, if
+//     any of their spans is slower than a threshold or failed. As the spans
+//     of a trace arrive over time, the Sampler buffers the spans of every
+//     trace for a while, and stores them as soon as one of them makes the
+//     trace interesting. The spans of the trace that arrive later are stored
+//     right away. Traces that don't become interesting in time are dropped.
+//
+// Tail-based sampling only sees the spans that pass through the Sampler, so
+// a Sampler should be placed where all the spans of an application meet
+// (e.g., in the manager of an SSH deployment).
+
+var (
+	sampledSpans = metrics.NewCounter(
+		"serviceweaver_system_trace_sampled_spans",
+		"Number of trace spans kept by trace sampling",
+	)
+	droppedSpans = metrics.NewCounter(
+		"serviceweaver_system_trace_dropped_spans",
+		"Number of trace spans dropped by trace sampling",
+	)
+)
+
+// SamplingOptions configure a Sampler.
+type SamplingOptions struct {
+	// Probability is the fraction of traces kept by head-based sampling,
+	// between 0 and 1. 1 keeps all traces, in which case tail-based sampling
+	// is moot.
+	Probability float64
+
+	// SlowThreshold, if positive, is the duration above which a span makes
+	// its trace be kept.
+	SlowThreshold time.Duration
+
+	// KeepErrors, if true, makes a span with an error status make its trace
+	// be kept.
+	KeepErrors bool
+
+	// DecisionWait is how long the spans of a trace dropped by head-based
+	// sampling are buffered, waiting for an interesting span. Defaults to
+	// 10 seconds.
+	DecisionWait time.Duration
+
+	// MaxBufferedSpans is the maximum number of buffered spans. Spans that
+	// arrive when the buffer is full are dropped, unless they are
+	// interesting. Defaults to 100,000.
+	MaxBufferedSpans int
+}
+
+// withDefaults returns a copy of the options, with default values filled in.
+func (o SamplingOptions) withDefaults() SamplingOptions {
+	if o.DecisionWait <= 0 {
+		o.DecisionWait = 10 * time.Second
+	}
+	if o.MaxBufferedSpans <= 0 {
+		o.MaxBufferedSpans = 100_000
+	}
+	return o
+}
+
+// tail returns whether the options enable tail-based sampling.
+func (o SamplingOptions) tail() bool {
+	return o.Probability < 1 && (o.SlowThreshold > 0 || o.KeepErrors)
+}
+
+// A Sampler samples the trace spans passed to its Store method, storing the
+// sampled spans with the store function it was created with. You can safely
+// use a Sampler from multiple goroutines.
+type Sampler struct {
+	opts  SamplingOptions
+	store func([]sdk.ReadOnlySpan) error
+	now   func() time.Time // the current time; replaced in tests
+
+	mu        sync.Mutex
+	pending   map[trace.TraceID]*pendingTrace // traces waiting for a decision
+	kept      map[trace.TraceID]time.Time     // kept traces, with expiration time
+	buffered  int                             // number of spans in pending
+	nextSweep time.Time                       // when to expire pending and kept
+}
+
+// pendingTrace holds the spans of a trace dropped by head-based sampling,
+// until one of them makes the trace interesting or the trace expires.
+type pendingTrace struct {
+	spans   []sdk.ReadOnlySpan
+	expires time.Time
+}
+
+// NewSampler returns a Sampler that samples spans according to the provided
+// options, and stores the sampled spans with the provided function.
+func NewSampler(opts SamplingOptions, store func([]sdk.ReadOnlySpan) error) *Sampler {
+	return &Sampler{
+		opts:    opts.withDefaults(),
+		store:   store,
+		now:     time.Now,
+		pending: map[trace.TraceID]*pendingTrace{},
+		kept:    map[trace.TraceID]time.Time{},
+	}
+}
+
+// Store samples the provided spans and stores the sampled ones, including the
+// buffered spans of the traces that the provided spans make interesting.
+func (s *Sampler) Store(spans []sdk.ReadOnlySpan) error {
+	sampled := s.sample(spans)
+	sampledSpans.Add(float64(len(sampled)))
+	if len(sampled) == 0 {
+		return nil
+	}
+	return s.store(sampled)
+}
+
+// sample returns the spans to store, among the provided spans and the
+// buffered spans.
+func (s *Sampler) sample(spans []sdk.ReadOnlySpan) []sdk.ReadOnlySpan {
+	if !s.opts.tail() {
+		var sampled []sdk.ReadOnlySpan
+		for _, span := range spans {
+			if s.head(span.SpanContext().TraceID()) {
+				sampled = append(sampled, span)
+			}
+		}
+		droppedSpans.Add(float64(len(spans) - len(sampled)))
+		return sampled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	var sampled []sdk.ReadOnlySpan
+	for _, span := range spans {
+		tid := span.SpanContext().TraceID()
+		if s.head(tid) {
+			sampled = append(sampled, span)
+			continue
+		}
+		if _, ok := s.kept[tid]; ok {
+			s.kept[tid] = now.Add(s.opts.DecisionWait)
+			sampled = append(sampled, span)
+			continue
+		}
+		p := s.pending[tid]
+		if s.interesting(span) {
+			if p != nil {
+				sampled = append(sampled, p.spans...)
+				s.buffered -= len(p.spans)
+				delete(s.pending, tid)
+			}
+			s.kept[tid] = now.Add(s.opts.DecisionWait)
+			sampled = append(sampled, span)
+			continue
+		}
+		if s.buffered >= s.opts.MaxBufferedSpans {
+			droppedSpans.Add(1)
+			continue
+		}
+		if p == nil {
+			p = &pendingTrace{}
+			s.pending[tid] = p
+		}
+		p.spans = append(p.spans, span)
+		p.expires = now.Add(s.opts.DecisionWait)
+		s.buffered++
+	}
+	return sampled
+}
+
+// sweep drops the expired pending traces, and forgets the expired kept
+// traces. To keep Store cheap, sweep does so at most once per decision wait.
+//
+// REQUIRES: s.mu is held.
+func (s *Sampler) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(s.opts.DecisionWait)
+	for tid, p := range s.pending {
+		if now.After(p.expires) {
+			droppedSpans.Add(float64(len(p.spans)))
+			s.buffered -= len(p.spans)
+			delete(s.pending, tid)
+		}
+	}
+	for tid, expires := range s.kept {
+		if now.After(expires) {
+			delete(s.kept, tid)
+		}
+	}
+}
+
+// head returns whether head-based sampling keeps the trace with the provided
+// id. Like the OpenTelemetry TraceIDRatioBased sampler, it compares the lower
+// 63 bits of the trace id to the probability.
+func (s *Sampler) head(tid trace.TraceID) bool {
+	if s.opts.Probability >= 1 {
+		return true
+	}
+	if s.opts.Probability <= 0 {
+		return false
+	}
+	bound := uint64(s.opts.Probability * (1 << 63))
+	return binary.BigEndian.Uint64(tid[8:16])>>1 < bound
+}
+
+// interesting returns whether the provided span makes its trace be kept by
+// tail-based sampling.
+func (s *Sampler) interesting(span sdk.ReadOnlySpan) bool {
+	if s.opts.KeepErrors && span.Status().Code == codes.Error {
+		return true
+	}
+	return s.opts.SlowThreshold > 0 && span.EndTime().Sub(span.StartTime()) >= s.opts.SlowThreshold
+}
+
+const (
+	samplingKey      = "greatestworks/trace_sampling"
+	shortSamplingKey = "trace_sampling"
+)
+
+func init() {
+	aop.RegisterConfigSection[SamplingConfig](samplingKey, shortSamplingKey)
+}
+
+// SamplingConfig is the config of the trace sampling of an application, as
+// found in the trace_sampling section of the app config. For example:
+//
+//	[trace_sampling]
+//	probability = 0.01
+//	slow_threshold = "500ms"
+//	keep_errors = true
+//
+// See SamplingOptions. If the section is missing, all traces are kept.
+type SamplingConfig struct {
+	Probability      *float64 `toml:"probability"`
+	SlowThreshold    string   `toml:"slow_threshold"`
+	KeepErrors       bool     `toml:"keep_errors"`
+	DecisionWait     string   `toml:"decision_wait"`
+	MaxBufferedSpans int      `toml:"max_buffered_spans"`
+}
+
+// Validate validates the config.
+func (c *SamplingConfig) Validate() error {
+	_, err := c.options()
+	return err
+}
+
+// options returns the sampling options specified in the config.
+func (c *SamplingConfig) options() (SamplingOptions, error) {
+	opts := SamplingOptions{
+		Probability:      1,
+		KeepErrors:       c.KeepErrors,
+		MaxBufferedSpans: c.MaxBufferedSpans,
+	}
+	if c.Probability != nil {
+		if *c.Probability < 0 || *c.Probability > 1 {
+			return opts, fmt.Errorf("invalid probability %v: must be between 0 and 1", *c.Probability)
+		}
+		opts.Probability = *c.Probability
+	}
+	var err error
+	if c.SlowThreshold != "" {
+		if opts.SlowThreshold, err = time.ParseDuration(c.SlowThreshold); err != nil {
+			return opts, fmt.Errorf("invalid slow threshold %q: %w", c.SlowThreshold, err)
+		}
+	}
+	if c.DecisionWait != "" {
+		if opts.DecisionWait, err = time.ParseDuration(c.DecisionWait); err != nil {
+			return opts, fmt.Errorf("invalid decision wait %q: %w", c.DecisionWait, err)
+		}
+	}
+	return opts, nil
+}
+
+// SamplingOptionsFromConfig returns the sampling options specified in the
+// trace_sampling section of the provided config sections.
+func SamplingOptionsFromConfig(sections map[string]string) (SamplingOptions, error) {
+	var c SamplingConfig
+	if err := aop.ParseConfigSection(samplingKey, shortSamplingKey, sections, &c); err != nil {
+		return SamplingOptions{}, err
+	}
+	return c.options()
+}
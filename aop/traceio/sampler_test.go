@@ -0,0 +1,162 @@
+package traceio
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	sdk "go.opentelemetry.io/otel/sdk/trace"
+	"greatestworks/aop/protos"
+)
+
+// testSpan returns a span of the provided trace, with the provided name and
+// duration. If failed is true, the span has an error status.
+func testSpan(trace byte, name string, duration time.Duration, failed bool) sdk.ReadOnlySpan {
+	tid := make([]byte, 16)
+	tid[15] = trace
+	status := &protos.Span_Status{Code: protos.Span_Status_OK}
+	if failed {
+		status.Code = protos.Span_Status_ERROR
+	}
+	return &ReadSpan{Span: &protos.Span{
+		Name:         name,
+		TraceId:      tid,
+		SpanId:       make([]byte, 8),
+		ParentSpanId: make([]byte, 8),
+		StartMicros:  0,
+		EndMicros:    duration.Microseconds(),
+		Status:       status,
+	}}
+}
+
+// recorder records the names of the stored spans.
+type recorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (r *recorder) store(spans []sdk.ReadOnlySpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, span := range spans {
+		r.names = append(r.names, span.Name())
+	}
+	return nil
+}
+
+func TestHeadSampling(t *testing.T) {
+	for _, p := range []float64{0, 0.25, 1} {
+		var r recorder
+		s := NewSampler(SamplingOptions{Probability: p}, r.store)
+		var spans []sdk.ReadOnlySpan
+		for i := 0; i < 256; i++ {
+			tid := make([]byte, 16)
+			tid[8] = byte(i) // the most significant byte of the lower 64 bits
+			spans = append(spans, &ReadSpan{Span: &protos.Span{TraceId: tid, SpanId: make([]byte, 8), ParentSpanId: make([]byte, 8)}})
+		}
+		if err := s.Store(spans); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(r.names), int(p*256); got != want {
+			t.Errorf("probability %v: got %d spans, want %d", p, got, want)
+		}
+	}
+}
+
+func TestTailSampling(t *testing.T) {
+	var r recorder
+	s := NewSampler(SamplingOptions{
+		Probability:   0,
+		SlowThreshold: time.Second,
+		KeepErrors:    true,
+		DecisionWait:  time.Minute,
+	}, r.store)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	// The spans of trace 1 are kept once its slow root span arrives, and so
+	// are its later spans. The spans of trace 2 are kept once one of them
+	// fails. The spans of trace 3 are buffered.
+	for _, batch := range [][]sdk.ReadOnlySpan{
+		{testSpan(1, "a1", time.Millisecond, false), testSpan(2, "b1", time.Millisecond, false)},
+		{testSpan(3, "c1", time.Millisecond, false), testSpan(1, "a2", 2*time.Second, false)},
+		{testSpan(1, "a3", time.Millisecond, false), testSpan(2, "b2", time.Millisecond, true)},
+	} {
+		if err := s.Store(batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := cmp.Diff([]string{"a1", "a2", "a3", "b1", "b2"}, r.names); diff != "" {
+		t.Fatalf("stored spans (-want +got):\n%s", diff)
+	}
+
+	// Trace 3 expires, so its spans are dropped, even if a slow span of the
+	// trace arrives later.
+	now = now.Add(2 * time.Minute)
+	if err := s.Store([]sdk.ReadOnlySpan{testSpan(3, "c2", 2*time.Second, false)}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"a1", "a2", "a3", "b1", "b2", "c2"}, r.names); diff != "" {
+		t.Fatalf("stored spans (-want +got):\n%s", diff)
+	}
+	if s.buffered != 0 || len(s.pending) != 0 {
+		t.Fatalf("got %d buffered spans in %d traces, want none", s.buffered, len(s.pending))
+	}
+}
+
+func TestSamplingBufferLimit(t *testing.T) {
+	var r recorder
+	s := NewSampler(SamplingOptions{SlowThreshold: time.Second, MaxBufferedSpans: 1}, r.store)
+	spans := []sdk.ReadOnlySpan{
+		testSpan(1, "a1", time.Millisecond, false),
+		testSpan(1, "a2", time.Millisecond, false), // dropped: buffer full
+		testSpan(1, "a3", 2*time.Second, false),
+	}
+	if err := s.Store(spans); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"a1", "a3"}, r.names); diff != "" {
+		t.Fatalf("stored spans (-want +got):\n%s", diff)
+	}
+}
+
+func TestSamplingOptionsFromConfig(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		section string
+		want    SamplingOptions
+		wantErr bool
+	}{
+		{"Missing", "", SamplingOptions{Probability: 1}, false},
+		{"Head", "probability = 0.1", SamplingOptions{Probability: 0.1}, false},
+		{"Tail", `
+			probability = 0.0
+			slow_threshold = "500ms"
+			keep_errors = true
+			decision_wait = "30s"`,
+			SamplingOptions{SlowThreshold: 500 * time.Millisecond, KeepErrors: true, DecisionWait: 30 * time.Second}, false},
+		{"BadProbability", "probability = 2.0", SamplingOptions{}, true},
+		{"BadThreshold", `slow_threshold = "slow"`, SamplingOptions{}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			sections := map[string]string{}
+			if test.section != "" {
+				sections[shortSamplingKey] = test.section
+			}
+			got, err := SamplingOptionsFromConfig(sections)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("unexpected success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Fatalf("options (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
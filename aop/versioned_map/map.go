@@ -17,12 +17,24 @@ type versioned[T proto.Message] struct {
 	version string
 }
 
+// Versioned is a value of a key in a Map, along with its version.
+type Versioned[T proto.Message] struct {
+	Value   T
+	Version string
+}
+
 // Map is a simple, versioned_map, map.
+//
+// A Map is purely in-memory, unless it is created with NewPersistentMap.
 type Map[T proto.Message] struct {
 	mu     sync.Mutex
 	global int
 	data   map[string]versioned[T]
 
+	// store, if not nil, persists every update (see NewPersistentMap).
+	store   Store
+	onError func(key string, err error)
+
 	// changed is a condition variable that wraps m. It's used to notify
 	// versioned Gets when a value has changed.
 	changed *cond.Cond
@@ -42,6 +54,7 @@ func (m *Map[T]) Update(key string, value T) string {
 	version := strconv.Itoa(m.global)
 	m.global += 1
 	m.data[key] = versioned[T]{protomsg.Clone(value), version}
+	m.persist(key, value, version)
 	m.changed.Broadcast()
 	return version
 }
@@ -70,6 +83,38 @@ func (m *Map[T]) Read(ctx context.Context, key string, version string) (T, strin
 	return protomsg.Clone(value), latest, nil
 }
 
+// Watch returns a channel that receives the value of the provided key, along
+// with its version, every time the key changes.
+//
+// If fromVersion is the empty string, the channel first receives the latest
+// value of the key; otherwise, it first receives the first value newer than
+// fromVersion. A slow receiver may skip intermediate values, but it always
+// eventually receives the latest value. The channel is closed when ctx is
+// cancelled.
+//
+// Watch lets a long-polling reader (e.g., an HTTP handler) block on the
+// context of its request, so that a reader that goes away stops waiting.
+func (m *Map[T]) Watch(ctx context.Context, key string, fromVersion string) <-chan Versioned[T] {
+	ch := make(chan Versioned[T])
+	go func() {
+		defer close(ch)
+		version := fromVersion
+		for {
+			value, latest, err := m.Read(ctx, key, version)
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- Versioned[T]{Value: value, Version: latest}:
+				version = latest
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
 func (m *Map[T]) getValue(key string) (T, string) {
 	old, ok := m.data[key]
 	if ok {
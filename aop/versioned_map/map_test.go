@@ -0,0 +1,57 @@
+package versioned_map
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"greatestworks/aop/protos"
+)
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := NewMap[*protos.Assignment]()
+	v0 := m.Update("a", &protos.Assignment{Version: 0})
+
+	// The latest value is received first.
+	ch := m.Watch(ctx, "a", "")
+	if got := <-ch; got.Version != v0 || got.Value.Version != 0 {
+		t.Fatalf("Watch: got %v@%s, want 0@%s", got.Value, got.Version, v0)
+	}
+
+	// Every change is received.
+	for i := uint64(1); i <= 3; i++ {
+		version := m.Update("a", &protos.Assignment{Version: i})
+		if got := <-ch; got.Version != version || got.Value.Version != i {
+			t.Fatalf("Watch: got %v@%s, want %d@%s", got.Value, got.Version, i, version)
+		}
+	}
+
+	// Changes of other keys are not received.
+	m.Update("b", &protos.Assignment{})
+	select {
+	case got := <-ch:
+		t.Fatalf("Watch: unexpected value %v@%s", got.Value, got.Version)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// The channel is closed when the context is cancelled.
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchFromVersion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := NewMap[*protos.Assignment]()
+	v0 := m.Update("a", &protos.Assignment{Version: 0})
+
+	// A watch from the latest version waits for the next change.
+	ch := m.Watch(ctx, "a", v0)
+	v1 := m.Update("a", &protos.Assignment{Version: 1})
+	if got := <-ch; got.Version != v1 {
+		t.Fatalf("Watch(%s): got version %s, want %s", v0, got.Version, v1)
+	}
+}
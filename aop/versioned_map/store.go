@@ -0,0 +1,140 @@
+package versioned_map
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/files"
+)
+
+// A Store persists the contents of a Map, so that the map can be recovered
+// after a restart (see NewPersistentMap).
+type Store interface {
+	// Load returns all the entries in the store.
+	Load() ([]Entry, error)
+
+	// Put (over)writes the entry for entry.Key.
+	Put(entry Entry) error
+}
+
+// An Entry is a persisted key of a Map, with its latest value and version.
+type Entry struct {
+	Key     string
+	Version string
+	Value   []byte // the value, serialized with proto.Marshal
+}
+
+// NewPersistentMap returns a map that persists every update to the provided
+// store, and that initially holds the entries already in the store.
+//
+// Versions of recovered keys are preserved, so that readers waiting for a key
+// to change past a version obtained before a restart keep working. Errors
+// persisting an update are passed to onError; the update is applied to the
+// map regardless.
+func NewPersistentMap[T proto.Message](store Store, onError func(key string, err error)) (*Map[T], error) {
+	entries, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	m := NewMap[T]()
+	for _, e := range entries {
+		var zero T
+		value := zero.ProtoReflect().New().Interface().(T)
+		if err := proto.Unmarshal(e.Value, value); err != nil {
+			return nil, fmt.Errorf("recover key %q: %w", e.Key, err)
+		}
+		version, err := strconv.Atoi(e.Version)
+		if err != nil {
+			return nil, fmt.Errorf("recover key %q: invalid version %q", e.Key, e.Version)
+		}
+		m.data[e.Key] = versioned[T]{value, e.Version}
+		if version >= m.global {
+			m.global = version + 1
+		}
+	}
+	m.store = store
+	m.onError = onError
+	return m, nil
+}
+
+// persist persists the provided value of the provided key.
+//
+// REQUIRES: m.mu is held.
+func (m *Map[T]) persist(key string, value T, version string) {
+	if m.store == nil {
+		return
+	}
+	bytes, err := proto.Marshal(value)
+	if err == nil {
+		err = m.store.Put(Entry{Key: key, Version: version, Value: bytes})
+	}
+	if err != nil && m.onError != nil {
+		m.onError(key, err)
+	}
+}
+
+// fileStore is a Store that stores every key in its own file.
+type fileStore struct {
+	dir string
+}
+
+var _ Store = &fileStore{}
+
+// NewFileStore returns a Store that stores every key in its own file in the
+// provided directory, creating the directory if needed. Every update
+// atomically overwrites the file of its key, so the store always holds the
+// latest snapshot of every key.
+func NewFileStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+// Load implements the Store interface.
+func (s *fileStore) Load() ([]Entry, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			// Skip the temporary files of interrupted writes.
+			continue
+		}
+		bytes, err := os.ReadFile(filepath.Join(s.dir, f.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		var e Entry
+		if err := json.Unmarshal(bytes, &e); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Put implements the Store interface.
+func (s *fileStore) Put(e Entry) error {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	w := files.NewWriter(filepath.Join(s.dir, url.PathEscape(e.Key)+".json"))
+	defer w.Cleanup()
+	if _, err := w.Write(bytes); err != nil {
+		return err
+	}
+	return w.Close()
+}
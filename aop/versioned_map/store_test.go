@@ -0,0 +1,60 @@
+package versioned_map
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"greatestworks/aop/protos"
+)
+
+func TestPersistentMap(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	open := func() *Map[*protos.Assignment] {
+		t.Helper()
+		store, err := NewFileStore(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := NewPersistentMap[*protos.Assignment](store, func(key string, err error) {
+			t.Errorf("persist %q: %v", key, err)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	m := open()
+	m.Update("a/b", &protos.Assignment{Component: "a", Version: 1})
+	version := m.Update("a/b", &protos.Assignment{Component: "a", Version: 2})
+	m.Update("c", &protos.Assignment{Component: "c"})
+
+	// Recover the map, as if after a restart.
+	recovered := open()
+	got, gotVersion, err := recovered.Read(ctx, "a/b", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&protos.Assignment{Component: "a", Version: 2}); !cmp.Equal(want, got, protocmp.Transform()) {
+		t.Fatalf("Read(a/b): got %v, want %v", got, want)
+	}
+	if gotVersion != version {
+		t.Fatalf("Read(a/b): got version %q, want %q", gotVersion, version)
+	}
+
+	// New versions don't collide with recovered versions.
+	newVersion := recovered.Update("a/b", &protos.Assignment{Component: "a", Version: 3})
+	if _, v, _ := recovered.Read(ctx, "c", ""); newVersion == version || newVersion == v {
+		t.Fatalf("Update: version %q collides with a recovered version", newVersion)
+	}
+	got, _, err = recovered.Read(ctx, "a/b", version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 3 {
+		t.Fatalf("Read(a/b, %q): got version %d, want 3", version, got.Version)
+	}
+}
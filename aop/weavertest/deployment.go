@@ -0,0 +1,519 @@
+// Package weavertest runs multi-replica deployments in the test process, so
+// that tests can exercise the behavior of routed components as replicas come
+// and go, deterministically and without launching any process.
+//
+// A Deployment plays the role of a deployer and of the babysitters of its
+// replicas. Every replica is a weavelet that runs in the test process, and
+// talks to its fake babysitter over in-process pipes, which it obtains from
+// aop.GetBootstrap(replica.Context()). The fake babysitters route requests
+// like real ones: they compute the assignments of routed components with
+// babysitter.Assign, and publish routing information and components to start
+// through versioned maps, which weavelets watch with the usual blocking RPCs.
+//
+//	d := weavertest.NewDeployment(t, weavertest.Options{Weavelet: run})
+//	if err := d.StartComponent("Cache", "cache", true); err != nil {
+//	    t.Fatal(err)
+//	}
+//	info, err := d.WaitForRouting(ctx, "cache", weavertest.ReplicaCount(2))
+//	...
+//	d.Replicas("cache")[0].Kill()
+package weavertest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"golang.org/x/exp/maps"
+	"greatestworks/aop"
+	"greatestworks/aop/babysitter"
+	"greatestworks/aop/envelope/conn"
+	"greatestworks/aop/logging"
+	"greatestworks/aop/protos"
+	"greatestworks/aop/versioned_map"
+)
+
+// Options configure a Deployment.
+type Options struct {
+	// App is the name of the application. Defaults to "weavertest".
+	App string
+
+	// Sections are the config sections of the application.
+	Sections map[string]string
+
+	// Replicas is the number of replicas started for a colocation group when
+	// its first component is started. Defaults to 2. Ignored if Weavelet is
+	// nil.
+	Replicas int
+
+	// Weavelet runs the weavelet of a replica, until the provided context is
+	// cancelled (i.e., until the replica is killed or the test ends). The
+	// weavelet gets the pipes to its babysitter with aop.GetBootstrap(ctx).
+	//
+	// If nil, replicas are only started by StartReplica, and the test runs
+	// their weavelets itself, using Replica.Context.
+	Weavelet func(ctx context.Context) error
+}
+
+// withDefaults returns a copy of the options, with default values filled in.
+func (o Options) withDefaults() Options {
+	if o.App == "" {
+		o.App = "weavertest"
+	}
+	if o.Replicas <= 0 {
+		o.Replicas = 2
+	}
+	return o
+}
+
+// A Deployment is a multi-replica deployment of an application, whose
+// weavelets run in the test process. See the package documentation.
+//
+// You can safely use a Deployment from multiple goroutines.
+type Deployment struct {
+	t      testing.TB
+	opts   Options
+	id     string // deployment id
+	logger *logging.TestLogger
+	ctx    context.Context // cancelled when the test ends
+	cancel context.CancelFunc
+	wg     sync.WaitGroup // tracks the goroutines of the replicas
+
+	components *versioned_map.Map[*protos.ComponentsToStart] // by group name
+	routing    *versioned_map.Map[*protos.RoutingInfo]       // by group name
+
+	mu        sync.Mutex
+	groups    map[string]*group  // colocation groups, by name
+	listeners []*protos.Listener // exported listeners
+}
+
+// group is a colocation group of a deployment.
+type group struct {
+	name        string
+	started     bool                          // have the initial replicas been started?
+	components  map[string]bool               // whether components are routed, by name
+	assignments map[string]*protos.Assignment // assignments of routed components
+	replicas    []*Replica                    // live replicas, oldest first
+}
+
+// NewDeployment returns a new deployment, which is shut down when the test
+// ends. No colocation group runs until a component is started (see
+// StartComponent), or a replica is started (see StartReplica).
+func NewDeployment(t testing.TB, opts Options) *Deployment {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Deployment{
+		t:          t,
+		opts:       opts.withDefaults(),
+		id:         uuid.New().String(),
+		logger:     logging.NewTestLogger(t),
+		ctx:        ctx,
+		cancel:     cancel,
+		components: versioned_map.NewMap[*protos.ComponentsToStart](),
+		routing:    versioned_map.NewMap[*protos.RoutingInfo](),
+		groups:     map[string]*group{},
+	}
+	t.Cleanup(d.shutdown)
+	return d
+}
+
+// DeploymentId returns the id of the deployment.
+func (d *Deployment) DeploymentId() string {
+	return d.id
+}
+
+// StartComponent starts the provided component in the provided colocation
+// group, like a weavelet does when it first calls the component. If the group
+// isn't running yet and Options.Weavelet is set, Options.Replicas replicas of
+// the group are started.
+func (d *Deployment) StartComponent(component, group string, routed bool) error {
+	return d.startComponent(&protos.ComponentToStart{
+		ColocationGroup: group,
+		Component:       component,
+		IsRouted:        routed,
+	})
+}
+
+func (d *Deployment) startComponent(req *protos.ComponentToStart) error {
+	d.mu.Lock()
+	g := d.group(req.ColocationGroup)
+	if _, ok := g.components[req.Component]; !ok {
+		g.components[req.Component] = req.IsRouted
+		if req.IsRouted {
+			g.assignments[req.Component] = &protos.Assignment{
+				App:          d.opts.App,
+				DeploymentId: d.id,
+				Component:    req.Component,
+			}
+		}
+		d.components.Update(g.name, &protos.ComponentsToStart{Components: sortedKeys(g.components)})
+		d.publishRouting(g)
+	}
+	start := d.opts.Weavelet != nil && !g.started
+	g.started = true
+	d.mu.Unlock()
+
+	if !start {
+		return nil
+	}
+	for i := 0; i < d.opts.Replicas; i++ {
+		if _, err := d.StartReplica(req.ColocationGroup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartReplica starts a new replica of the provided colocation group. If
+// Options.Weavelet is set, it runs the weavelet of the replica. Otherwise,
+// the test should run a weavelet with the context of the replica.
+//
+// Note that the replica doesn't receive any request until its weavelet
+// registers itself (see conn.WeaveletConn.RegisterReplicaRPC).
+func (d *Deployment) StartReplica(group string) (*Replica, error) {
+	if err := d.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("start replica of %q: deployment is shut down", group)
+	}
+
+	// toWeavelet carries messages from the babysitter to the weavelet, and
+	// toEnvelope carries messages the other way around.
+	toWeaveletR, toWeaveletW := io.Pipe()
+	toEnvelopeR, toEnvelopeW := io.Pipe()
+	info := &protos.WeaveletInfo{
+		App:           d.opts.App,
+		DeploymentId:  d.id,
+		Group:         &protos.ColocationGroup{Name: group},
+		GroupId:       uuid.New().String(),
+		Id:            uuid.New().String(),
+		Sections:      d.opts.Sections,
+		SingleMachine: true,
+	}
+	bootstrap := aop.Bootstrap{ToWeavelet: toWeaveletR, ToEnvelope: toEnvelopeW}
+	ctx, cancel := context.WithCancel(context.WithValue(d.ctx, aop.BootstrapKey{}, bootstrap))
+	r := &Replica{
+		d:      d,
+		info:   info,
+		ctx:    ctx,
+		cancel: cancel,
+		pipes:  []io.Closer{toWeaveletR, toWeaveletW, toEnvelopeR, toEnvelopeW},
+	}
+
+	d.mu.Lock()
+	g := d.group(group)
+	g.replicas = append(g.replicas, r)
+	d.mu.Unlock()
+
+	// Run the babysitter side of the pipes.
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		// NewEnvelopeConn blocks until the weavelet reads the WeaveletInfo.
+		e, err := conn.NewEnvelopeConn(toEnvelopeR, toWeaveletW, &fakeBabysitter{r}, info)
+		if err == nil {
+			err = e.Run()
+		}
+		if ctx.Err() == nil {
+			d.logger.Error("Babysitter stopped", err, "group", group, "weavelet", info.Id)
+		}
+	}()
+
+	// Run the weavelet.
+	if d.opts.Weavelet != nil {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			err := d.opts.Weavelet(ctx)
+			if ctx.Err() == nil {
+				d.t.Errorf("weavelet %s of group %q exited: %v", info.Id, group, err)
+			}
+		}()
+	}
+	return r, nil
+}
+
+// Replicas returns the live replicas of the provided colocation group, oldest
+// first.
+func (d *Deployment) Replicas(group string) []*Replica {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	g, ok := d.groups[group]
+	if !ok {
+		return nil
+	}
+	return append([]*Replica(nil), g.replicas...)
+}
+
+// RoutingInfo returns the latest routing information of the provided
+// colocation group.
+func (d *Deployment) RoutingInfo(group string) *protos.RoutingInfo {
+	info, _, _ := d.routingInfo(context.Background(), group, "" /*version*/)
+	return info
+}
+
+// WaitForRouting waits until the routing information of the provided
+// colocation group satisfies the provided predicate, and returns it.
+func (d *Deployment) WaitForRouting(ctx context.Context, group string, pred func(*protos.RoutingInfo) bool) (*protos.RoutingInfo, error) {
+	// Add the group, so that its routing information exists.
+	d.mu.Lock()
+	d.group(group)
+	d.mu.Unlock()
+
+	version := ""
+	for {
+		info, newVersion, err := d.routingInfo(ctx, group, version)
+		if err != nil {
+			return nil, fmt.Errorf("wait for routing of %q: %w", group, err)
+		}
+		if pred(info) {
+			return info, nil
+		}
+		version = newVersion
+	}
+}
+
+// ReplicaCount returns a predicate for WaitForRouting that holds when the
+// routing information lists n replicas.
+func ReplicaCount(n int) func(*protos.RoutingInfo) bool {
+	return func(info *protos.RoutingInfo) bool { return len(info.Replicas) == n }
+}
+
+// Listeners returns the listeners exported by the weavelets.
+func (d *Deployment) Listeners() []*protos.Listener {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*protos.Listener(nil), d.listeners...)
+}
+
+// routingInfo returns the routing information of the provided colocation
+// group, blocking until it is newer than version (see versioned_map.Map.Read).
+func (d *Deployment) routingInfo(ctx context.Context, group, version string) (*protos.RoutingInfo, string, error) {
+	info, newVersion, err := d.routing.Read(ctx, group, version)
+	if err != nil {
+		return nil, "", err
+	}
+	if info == nil {
+		info = &protos.RoutingInfo{}
+	}
+	return info, newVersion, nil
+}
+
+// group returns the colocation group with the provided name, adding it if
+// needed. The components and routing information of a new group are empty.
+//
+// REQUIRES: d.mu is held.
+func (d *Deployment) group(name string) *group {
+	g, ok := d.groups[name]
+	if !ok {
+		g = &group{
+			name:        name,
+			components:  map[string]bool{},
+			assignments: map[string]*protos.Assignment{},
+		}
+		d.groups[name] = g
+		// Publish the initial values, so that readers can block until they
+		// change (see versioned_map.Map.Read).
+		d.components.Update(name, &protos.ComponentsToStart{})
+		d.routing.Update(name, &protos.RoutingInfo{})
+	}
+	return g
+}
+
+// publishRouting computes new assignments for the routed components of the
+// provided group, given its registered replicas, and publishes the resulting
+// routing information.
+//
+// REQUIRES: d.mu is held.
+func (d *Deployment) publishRouting(g *group) {
+	var replicas []string
+	for _, r := range g.replicas {
+		if r.addr != "" {
+			replicas = append(replicas, r.addr)
+		}
+	}
+	sort.Strings(replicas)
+	info := &protos.RoutingInfo{Replicas: replicas}
+	for _, component := range sortedKeys(g.assignments) {
+		assignment, err := babysitter.Assign(g.assignments[component], replicas)
+		if err != nil {
+			d.logger.Error("Unable to assign slices", err, "component", component)
+			continue
+		}
+		g.assignments[component] = assignment
+		info.Assignments = append(info.Assignments, assignment)
+	}
+	d.routing.Update(g.name, info)
+}
+
+// shutdown kills all replicas, and waits for their goroutines to exit.
+func (d *Deployment) shutdown() {
+	d.cancel()
+	d.mu.Lock()
+	var replicas []*Replica
+	for _, g := range d.groups {
+		replicas = append(replicas, g.replicas...)
+	}
+	d.mu.Unlock()
+	for _, r := range replicas {
+		r.Kill()
+	}
+	d.wg.Wait()
+}
+
+// A Replica is a replica of a colocation group of a Deployment.
+type Replica struct {
+	d      *Deployment
+	info   *protos.WeaveletInfo
+	ctx    context.Context // carries the aop.Bootstrap of the replica
+	cancel context.CancelFunc
+	pipes  []io.Closer // pipes between the weavelet and its babysitter
+
+	// Guarded by d.mu.
+	addr   string // address registered by the weavelet, or ""
+	killed bool
+}
+
+// Context returns a context that carries the aop.Bootstrap of the replica,
+// from which its weavelet gets the pipes to its babysitter. The context is
+// cancelled when the replica is killed.
+func (r *Replica) Context() context.Context {
+	return r.ctx
+}
+
+// Weavelet returns the WeaveletInfo sent to the weavelet of the replica.
+func (r *Replica) Weavelet() *protos.WeaveletInfo {
+	return r.info
+}
+
+// Addr returns the address registered by the weavelet of the replica, or ""
+// if the weavelet hasn't registered yet.
+func (r *Replica) Addr() string {
+	r.d.mu.Lock()
+	defer r.d.mu.Unlock()
+	return r.addr
+}
+
+// Kill simulates the failure of the replica: the replica is removed from the
+// routing information of its colocation group, and the pipes between its
+// weavelet and its babysitter are closed. Killed replicas are not restarted;
+// call StartReplica to replace them.
+func (r *Replica) Kill() {
+	d := r.d
+	d.mu.Lock()
+	if r.killed {
+		d.mu.Unlock()
+		return
+	}
+	r.killed = true
+	g := d.groups[r.info.Group.Name]
+	for i, replica := range g.replicas {
+		if replica == r {
+			g.replicas = append(g.replicas[:i], g.replicas[i+1:]...)
+			break
+		}
+	}
+	if r.addr != "" {
+		d.publishRouting(g)
+	}
+	d.mu.Unlock()
+
+	r.cancel()
+	for _, pipe := range r.pipes {
+		pipe.Close()
+	}
+}
+
+// fakeBabysitter is the babysitter of a replica. It implements the
+// conn.EnvelopeHandler interface on top of the state of the deployment.
+type fakeBabysitter struct {
+	r *Replica
+}
+
+var _ conn.EnvelopeHandler = &fakeBabysitter{}
+
+// StartComponent implements the conn.EnvelopeHandler interface.
+func (b *fakeBabysitter) StartComponent(req *protos.ComponentToStart) error {
+	return b.r.d.startComponent(req)
+}
+
+// RegisterReplica implements the conn.EnvelopeHandler interface.
+func (b *fakeBabysitter) RegisterReplica(req *protos.ReplicaToRegister) error {
+	r, d := b.r, b.r.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if r.killed {
+		return fmt.Errorf("replica %s has been killed", r.info.Id)
+	}
+	if req.Group != r.info.Group.Name {
+		return fmt.Errorf("replica %s of group %q registered in group %q", r.info.Id, r.info.Group.Name, req.Group)
+	}
+	if r.addr == req.Address {
+		return nil
+	}
+	r.addr = req.Address
+	d.publishRouting(d.groups[req.Group])
+	return nil
+}
+
+// ReportLoad implements the conn.EnvelopeHandler interface.
+func (b *fakeBabysitter) ReportLoad(*protos.WeaveletLoadReport) error {
+	return nil
+}
+
+// GetAddress implements the conn.EnvelopeHandler interface.
+func (b *fakeBabysitter) GetAddress(*protos.GetAddressRequest) (*protos.GetAddressReply, error) {
+	return &protos.GetAddressReply{Address: "localhost:0"}, nil
+}
+
+// ExportListener implements the conn.EnvelopeHandler interface. Listeners
+// are not proxied: the proxy address of a listener is its own address.
+func (b *fakeBabysitter) ExportListener(req *protos.ExportListenerRequest) (*protos.ExportListenerReply, error) {
+	d := b.r.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.listeners = append(d.listeners, req.Listener)
+	return &protos.ExportListenerReply{ProxyAddress: req.Listener.Addr}, nil
+}
+
+// GetRoutingInfo implements the conn.EnvelopeHandler interface.
+func (b *fakeBabysitter) GetRoutingInfo(req *protos.GetRoutingInfo) (*protos.RoutingInfo, error) {
+	info, version, err := b.r.d.routingInfo(b.r.ctx, req.Group, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	info.Version = version
+	return info, nil
+}
+
+// GetComponentsToStart implements the conn.EnvelopeHandler interface.
+func (b *fakeBabysitter) GetComponentsToStart(req *protos.GetComponentsToStart) (*protos.ComponentsToStart, error) {
+	components, version, err := b.r.d.components.Read(b.r.ctx, req.Group, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	reply := &protos.ComponentsToStart{Version: version}
+	if components != nil {
+		reply.Components = components.Components
+	}
+	return reply, nil
+}
+
+// RecvLogEntry implements the conn.EnvelopeHandler interface.
+func (b *fakeBabysitter) RecvLogEntry(entry *protos.LogEntry) {
+	b.r.d.logger.Log(entry)
+}
+
+// RecvTraceSpans implements the conn.EnvelopeHandler interface.
+func (b *fakeBabysitter) RecvTraceSpans([]trace.ReadOnlySpan) error {
+	return nil
+}
+
+// sortedKeys returns the sorted keys of the provided map.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := maps.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
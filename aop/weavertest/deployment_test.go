@@ -0,0 +1,202 @@
+package weavertest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"greatestworks/aop"
+	"greatestworks/aop/envelope/conn"
+	"greatestworks/aop/protos"
+)
+
+// connect connects to the babysitter of the replica whose context is ctx, and
+// registers the replica.
+func connect(ctx context.Context) (*conn.WeaveletConn, <-chan error, error) {
+	bootstrap, err := aop.GetBootstrap(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bootstrap.HasPipes() {
+		return nil, nil, fmt.Errorf("no pipes in %v", bootstrap)
+	}
+	toWeavelet, toEnvelope, err := bootstrap.MakePipes()
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := conn.NewWeaveletConn(toWeavelet, toEnvelope)
+	if err != nil {
+		return nil, nil, err
+	}
+	errs := make(chan error, 1)
+	go func() { errs <- c.Run() }()
+
+	wlet := c.Weavelet()
+	if err := c.RegisterReplicaRPC(&protos.ReplicaToRegister{
+		Group:   wlet.Group.Name,
+		Address: "tcp://" + wlet.Id,
+		Pid:     int64(os.Getpid()),
+	}); err != nil {
+		return nil, nil, err
+	}
+	return c, errs, nil
+}
+
+// weavelet is an Options.Weavelet that registers the replica, and waits.
+func weavelet(ctx context.Context) error {
+	_, errs, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// owners returns the replicas that own the slices of the provided component,
+// sorted and without duplicates.
+func owners(info *protos.RoutingInfo, component string) []string {
+	seen := map[string]bool{}
+	for _, a := range info.Assignments {
+		if a.Component != component {
+			continue
+		}
+		for _, slice := range a.Slices {
+			for _, r := range slice.Replicas {
+				seen[r] = true
+			}
+		}
+	}
+	return sortedKeys(seen)
+}
+
+func TestRoutingChurn(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	d := NewDeployment(t, Options{Replicas: 3, Weavelet: weavelet})
+	if err := d.StartComponent("Cache", "cache", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// All replicas register, and own slices of the routed component.
+	info, err := d.WaitForRouting(ctx, "cache", ReplicaCount(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(info.Replicas, owners(info, "Cache")); diff != "" {
+		t.Fatalf("owners (-want +got):\n%s", diff)
+	}
+
+	// Kill a replica. Its slices are reassigned to the other replicas.
+	replicas := d.Replicas("cache")
+	if len(replicas) != 3 {
+		t.Fatalf("got %d replicas, want 3", len(replicas))
+	}
+	replicas[1].Kill()
+	info = d.RoutingInfo("cache")
+	want := []string{replicas[0].Addr(), replicas[2].Addr()}
+	sort.Strings(want)
+	if diff := cmp.Diff(want, info.Replicas); diff != "" {
+		t.Fatalf("replicas (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, owners(info, "Cache")); diff != "" {
+		t.Fatalf("owners (-want +got):\n%s", diff)
+	}
+
+	// Replace the killed replica.
+	if _, err := d.StartReplica("cache"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.WaitForRouting(ctx, "cache", ReplicaCount(3)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWeaveletWatches(t *testing.T) {
+	// Run the weavelet of the replica in the test.
+	d := NewDeployment(t, Options{})
+	r, err := d.StartReplica("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _, err := connect(r.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.Addr(), "tcp://"+r.Weavelet().Id; got != want {
+		t.Fatalf("Addr: got %q, want %q", got, want)
+	}
+
+	// The weavelet starts a component, and learns about it.
+	if err := c.StartComponentRPC(&protos.ComponentToStart{
+		ColocationGroup: "main",
+		Component:       "Lobby",
+		IsRouted:        true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	components, err := c.GetComponentsToStartRPC(&protos.GetComponentsToStart{Group: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"Lobby"}, components.Components); diff != "" {
+		t.Fatalf("components (-want +got):\n%s", diff)
+	}
+	routing, err := c.GetRoutingInfoRPC(&protos.GetRoutingInfo{Group: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{r.Addr()}, owners(routing, "Lobby")); diff != "" {
+		t.Fatalf("owners (-want +got):\n%s", diff)
+	}
+
+	// Blocking calls return once the state changes.
+	go func() {
+		if err := d.StartComponent("Chat", "main", false); err != nil {
+			t.Error(err)
+		}
+	}()
+	components, err = c.GetComponentsToStartRPC(&protos.GetComponentsToStart{Group: "main", Version: components.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"Chat", "Lobby"}, components.Components); diff != "" {
+		t.Fatalf("components (-want +got):\n%s", diff)
+	}
+
+	// A second replica joins, and takes over some slices.
+	r2, err := d.StartReplica("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := connect(r2.Context()); err != nil {
+		t.Fatal(err)
+	}
+	routing, err = c.GetRoutingInfoRPC(&protos.GetRoutingInfo{Group: "main", Version: routing.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for len(routing.Replicas) != 2 {
+		if routing, err = c.GetRoutingInfoRPC(&protos.GetRoutingInfo{Group: "main", Version: routing.Version}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{r.Addr(), r2.Addr()}
+	sort.Strings(want)
+	if diff := cmp.Diff(want, owners(routing, "Lobby")); diff != "" {
+		t.Fatalf("owners (-want +got):\n%s", diff)
+	}
+
+	// Killed replicas can't register again.
+	r2.Kill()
+	if _, _, err := connect(r2.Context()); err == nil {
+		t.Fatal("connect to killed replica: unexpected success")
+	}
+}
@@ -0,0 +1,83 @@
+package writebehind
+
+import (
+	"context"
+	"fmt"
+)
+
+// Mismatch is a field of an entity whose value cached differs from the value
+// in the database.
+type Mismatch struct {
+	Key    string
+	Field  string
+	Cached []byte
+	Stored []byte // nil if the database has no value
+}
+
+// CheckResult sums up a check of the cache against the database.
+type CheckResult struct {
+	Checked    int // entities compared
+	Pending    int // entities skipped, since they have changes not flushed
+	Mismatches int // fields that differ
+}
+
+// Check compares the values cached of the provided entities, or of all the
+// entities cached if none are provided, with their values in the database,
+// and calls fn with the fields that differ. The entities with changes not
+// flushed yet are skipped.
+func (c *Cache) Check(ctx context.Context, keys []string, fn func(Mismatch)) (CheckResult, error) {
+	var res CheckResult
+	pending, err := c.store.Pending(ctx)
+	if err != nil {
+		return res, fmt.Errorf("writebehind: pending %v: %w", c.kind.Kind, err)
+	}
+	check := func(key string) error {
+		if pending[key] {
+			res.Pending++
+			return nil
+		}
+		cached, err := c.store.Read(ctx, key)
+		if err != nil {
+			return fmt.Errorf("writebehind: read %v %v: %w", c.kind.Kind, key, err)
+		}
+		if len(cached) == 0 {
+			return nil
+		}
+		fields := make([]string, 0, len(cached))
+		for f := range cached {
+			fields = append(fields, f)
+		}
+		stored, err := c.sink.Read(ctx, key, fields)
+		if err != nil {
+			return fmt.Errorf("writebehind: read %v %v from the database: %w", c.kind.Kind, key, err)
+		}
+		res.Checked++
+		for _, f := range fields {
+			if s, ok := stored[f]; ok && c.sink.Equal(cached[f], s) {
+				continue
+			}
+			res.Mismatches++
+			fn(Mismatch{Key: key, Field: f, Cached: cached[f], Stored: stored[f]})
+		}
+		return nil
+	}
+	if len(keys) > 0 {
+		for _, key := range keys {
+			if err := check(key); err != nil {
+				return res, err
+			}
+		}
+		return res, nil
+	}
+	return res, c.store.Scan(ctx, check)
+}
+
+// Repair logs again the changes of the fields cached of an entity, so that
+// their values cached are flushed to the database.
+func (c *Cache) Repair(ctx context.Context, key string, fields ...string) error {
+	values, err := c.Read(ctx, key, fields...)
+	if err != nil {
+		return err
+	}
+	return c.Write(ctx, key, values)
+}
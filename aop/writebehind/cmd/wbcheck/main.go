@@ -0,0 +1,104 @@
+// wbcheck compares the entities of the write-behind cache in Redis with
+// their documents in MongoDB, and reports the fields that differ. The
+// entities with changes not flushed yet are skipped. With -repair, the
+// changes of the fields that differ are logged again, so that the running
+// servers flush their values cached.
+//
+// Usage:
+//
+//	wbcheck -redis :7000,:7001,:7002 -mode cluster -mongo mongodb://localhost:27017 -kind player
+//	wbcheck -kind player -key 1001,1002 -repair
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/redis"
+	"greatestworks/aop/writebehind"
+)
+
+func main() {
+	var (
+		redisAddrs = flag.String("redis", ":7000,:7001,:7002,:7003,:7004,:7005", "comma-separated addresses of Redis")
+		mode       = flag.String("mode", redis.ModeCluster, "mode of Redis: standalone, sentinel or cluster")
+		mongoURI   = flag.String("mongo", "mongodb://localhost:27017", "URI of MongoDB")
+		kind       = flag.String("kind", "player", "kind of the entities")
+		shards     = flag.Int("shards", 0, "number of shards of the cache; the default if 0")
+		keys       = flag.String("key", "", "comma-separated keys of the entities to check; all the entities cached if empty")
+		repair     = flag.Bool("repair", false, "log again the changes of the fields that differ")
+	)
+	flag.Parse()
+
+	ctx := context.Background()
+	rdb, err := redis.New(ctx, redis.Options{Mode: *mode, Addrs: strings.Split(*redisAddrs, ",")})
+	if err != nil {
+		fail(err)
+	}
+	if err := mongo.Init(ctx, mongo.Options{URI: *mongoURI}); err != nil {
+		fail(err)
+	}
+	sink, err := newSink(*kind)
+	if err != nil {
+		fail(err)
+	}
+	cache := writebehind.NewRedis(rdb, *kind, sink, writebehind.Options{Shards: *shards})
+
+	var only []string
+	if *keys != "" {
+		only = strings.Split(*keys, ",")
+	}
+	repairs := map[string][]string{}
+	res, err := cache.Check(ctx, only, func(m writebehind.Mismatch) {
+		fmt.Printf("%s %s: cached %s, stored %s\n", m.Key, m.Field, format(m.Cached), format(m.Stored))
+		repairs[m.Key] = append(repairs[m.Key], m.Field)
+	})
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("checked %d, pending %d, mismatches %d\n", res.Checked, res.Pending, res.Mismatches)
+	if *repair {
+		for key, fields := range repairs {
+			if err := cache.Repair(ctx, key, fields...); err != nil {
+				fail(err)
+			}
+		}
+		fmt.Printf("repaired %d\n", len(repairs))
+	}
+	if res.Mismatches > 0 && !*repair {
+		os.Exit(2)
+	}
+}
+
+// newSink returns the sink of a kind of entities.
+func newSink(kind string) (writebehind.Sink, error) {
+	switch kind {
+	case "player":
+		return writebehind.NewMongoSink(&mongo.PlayerDoc{}, mongo.PrimaryKey, func(key string) (interface{}, error) {
+			return strconv.ParseUint(key, 10, 64)
+		}), nil
+	}
+	return nil, fmt.Errorf("unknown kind %q", kind)
+}
+
+// format formats an encoded value as extended JSON.
+func format(b []byte) string {
+	if b == nil {
+		return "none"
+	}
+	v, err := writebehind.DecodeBSON(b)
+	if err != nil {
+		return fmt.Sprintf("%x", b)
+	}
+	return v.String()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "wbcheck:", err)
+	os.Exit(1)
+}
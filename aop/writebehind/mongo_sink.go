@@ -0,0 +1,112 @@
+package writebehind
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"greatestworks/aop/mongo"
+)
+
+// valueField is the field of the BSON document encoding a value.
+const valueField = "v"
+
+// EncodeBSON encodes a value of a field for a MongoSink.
+func EncodeBSON(v interface{}) ([]byte, error) {
+	return bson.Marshal(bson.D{{Key: valueField, Value: v}})
+}
+
+// DecodeBSON decodes a value encoded by EncodeBSON.
+func DecodeBSON(b []byte) (bson.RawValue, error) {
+	return bson.Raw(b).LookupErr(valueField)
+}
+
+// MongoSink writes the entities to the documents of a collection: the fields
+// are the fields of the documents (e.g., "sections.bag"), set with $set, and
+// their values are encoded with EncodeBSON.
+type MongoSink struct {
+	doc      mongo.Document
+	keyField string
+	parseKey func(key string) (interface{}, error)
+}
+
+// NewMongoSink returns a sink of the documents of the collection of doc,
+// identified by keyField. parseKey returns the value of keyField of the key
+// of an entity.
+func NewMongoSink(doc mongo.Document, keyField string, parseKey func(key string) (interface{}, error)) *MongoSink {
+	return &MongoSink{doc: doc, keyField: keyField, parseKey: parseKey}
+}
+
+func (s *MongoSink) collection() *mongodriver.Collection {
+	return mongo.Client.RealCli.Database(s.doc.DB()).Collection(s.doc.C())
+}
+
+func (s *MongoSink) Flush(ctx context.Context, changes []Change) error {
+	models := make([]mongodriver.WriteModel, 0, len(changes))
+	for _, c := range changes {
+		id, err := s.parseKey(c.Key)
+		if err != nil {
+			return err
+		}
+		set := bson.M{}
+		for f, b := range c.Fields {
+			v, err := DecodeBSON(b)
+			if err != nil {
+				return err
+			}
+			set[f] = v
+		}
+		models = append(models, mongodriver.NewUpdateOneModel().
+			SetFilter(bson.M{s.keyField: id}).
+			SetUpdate(bson.M{"$set": set}).
+			SetUpsert(true))
+	}
+	_, err := s.collection().BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (s *MongoSink) Read(ctx context.Context, key string, fields []string) (map[string][]byte, error) {
+	id, err := s.parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	projection := bson.M{}
+	for _, f := range fields {
+		projection[f] = 1
+	}
+	var raw bson.Raw
+	err = s.collection().FindOne(ctx, bson.M{s.keyField: id}, options.FindOne().SetProjection(projection)).Decode(&raw)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return map[string][]byte{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	values := map[string][]byte{}
+	for _, f := range fields {
+		v, err := raw.LookupErr(strings.Split(f, ".")...)
+		if err != nil {
+			continue
+		}
+		b, err := EncodeBSON(v)
+		if err != nil {
+			return nil, err
+		}
+		values[f] = b
+	}
+	return values, nil
+}
+
+// Equal returns whether two values decode alike, whatever the order of the
+// fields of their documents (e.g., encoded from maps).
+func (s *MongoSink) Equal(a, b []byte) bool {
+	var x, y bson.M
+	if bson.Unmarshal(a, &x) != nil || bson.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,316 @@
+package writebehind
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/redis"
+)
+
+// group is the consumer group of the change logs.
+const group = "flush"
+
+// KEYS = [entity, log], ARGV = [ttl in ms, key, fields changed, field, value,
+// field, value...]
+var writeScript = redis.NewScript("writebehind.write", `
+redis.call('hset', KEYS[1], unpack(ARGV, 4))
+redis.call('pexpire', KEYS[1], ARGV[1])
+return redis.call('xadd', KEYS[2], '*', 'k', ARGV[2], 'f', ARGV[3])`)
+
+// RedisStore stores the entities of a kind in Redis: each entity in a hash,
+// and the changes in streams, read by a consumer group. The entities are
+// spread over Options.Shards shards, each of a hash tag (e.g.,
+// "{wb:player:3}"), so that the hash of an entity and the stream of its
+// changes are in the same slot of a cluster, while the shards are spread
+// over the nodes.
+type RedisStore struct {
+	rdb  goredis.UniversalClient
+	kind string
+	opts Options
+
+	// Accessed by the flusher only.
+	created   []bool    // whether the consumer group of the shards exists
+	lastClaim time.Time // last time the changes left over were claimed
+}
+
+// NewRedisStore returns the store of a kind of entities in Redis.
+func NewRedisStore(rdb goredis.UniversalClient, kind string, opts Options) *RedisStore {
+	opts.setDefaults()
+	return &RedisStore{rdb: rdb, kind: kind, opts: opts, created: make([]bool, opts.Shards)}
+}
+
+// NewRedis returns the cache of a kind of entities in Redis, flushed to sink.
+func NewRedis(rdb goredis.UniversalClient, kind string, sink Sink, opts Options) *Cache {
+	return New(kind, NewRedisStore(rdb, kind, opts), sink, opts)
+}
+
+func (s *RedisStore) shard(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(s.opts.Shards))
+}
+
+func (s *RedisStore) tag(shard int) string {
+	return fmt.Sprintf("{wb:%s:%d}", s.kind, shard)
+}
+
+func (s *RedisStore) entityKey(key string) string {
+	return s.tag(s.shard(key)) + ":e:" + key
+}
+
+func (s *RedisStore) logKey(shard int) string {
+	return s.tag(shard) + ":log"
+}
+
+func (s *RedisStore) Write(ctx context.Context, key string, fields map[string][]byte) error {
+	names := make([]string, 0, len(fields))
+	args := make([]interface{}, 0, 3+2*len(fields))
+	args = append(args, s.opts.TTL.Milliseconds(), key, "")
+	for f, v := range fields {
+		names = append(names, f)
+		args = append(args, f, v)
+	}
+	args[2] = strings.Join(names, "\n")
+	keys := []string{s.entityKey(key), s.logKey(s.shard(key))}
+	return writeScript.Run(ctx, s.rdb, keys, args...).Err()
+}
+
+func (s *RedisStore) Read(ctx context.Context, key string, fields ...string) (map[string][]byte, error) {
+	values := map[string][]byte{}
+	if len(fields) == 0 {
+		all, err := s.rdb.HGetAll(ctx, s.entityKey(key)).Result()
+		if err != nil {
+			return nil, err
+		}
+		for f, v := range all {
+			values[f] = []byte(v)
+		}
+		return values, nil
+	}
+	vs, err := s.rdb.HMGet(ctx, s.entityKey(key), fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range vs {
+		if v, ok := v.(string); ok {
+			values[fields[i]] = []byte(v)
+		}
+	}
+	return values, nil
+}
+
+func (s *RedisStore) Next(ctx context.Context, n int) ([]Entry, error) {
+	for shard, ok := range s.created {
+		if ok {
+			continue
+		}
+		err := s.rdb.XGroupCreateMkStream(ctx, s.logKey(shard), group, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return nil, err
+		}
+		s.created[shard] = true
+	}
+
+	// The changes returned before and not acknowledged (e.g., their flush
+	// failed, or the process restarted) first.
+	entries, err := s.read(ctx, "0", n)
+	if err != nil || len(entries) >= n {
+		return truncate(entries, n), err
+	}
+	// Then those of the other processes, left over for long.
+	if time.Since(s.lastClaim) >= s.opts.ClaimAfter/2 {
+		claimed, err := s.claim(ctx, n-len(entries))
+		if err != nil {
+			return nil, err
+		}
+		s.lastClaim = time.Now()
+		entries = append(entries, claimed...)
+		if len(entries) >= n {
+			return truncate(entries, n), nil
+		}
+	}
+	// Then new ones. Those beyond n are returned by the next call, as
+	// changes returned before.
+	fresh, err := s.read(ctx, ">", n-len(entries))
+	if err != nil {
+		return nil, err
+	}
+	return truncate(append(entries, fresh...), n), nil
+}
+
+// read reads up to n changes of every shard from the consumer group, after
+// id: "0" for the changes delivered to this process and not acknowledged,
+// ">" for new changes.
+func (s *RedisStore) read(ctx context.Context, id string, n int) ([]Entry, error) {
+	cmds := make([]*goredis.XStreamSliceCmd, s.opts.Shards)
+	_, err := s.rdb.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for shard := range cmds {
+			cmds[shard] = pipe.XReadGroup(ctx, &goredis.XReadGroupArgs{
+				Group:    group,
+				Consumer: s.opts.Consumer,
+				Streams:  []string{s.logKey(shard), id},
+				Count:    int64(n),
+				Block:    -1, // don't wait
+			})
+		}
+		return nil
+	})
+	if err != nil && err != goredis.Nil {
+		return nil, err
+	}
+	var entries []Entry
+	for shard, cmd := range cmds {
+		streams, err := cmd.Result()
+		if err == goredis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				entries = append(entries, toEntry(shard, msg))
+			}
+		}
+	}
+	return entries, nil
+}
+
+// claim claims up to n changes of every shard, delivered to other processes
+// longer than ClaimAfter ago and not acknowledged: those processes likely
+// crashed.
+func (s *RedisStore) claim(ctx context.Context, n int) ([]Entry, error) {
+	cmds := make([]*goredis.XAutoClaimCmd, s.opts.Shards)
+	_, err := s.rdb.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for shard := range cmds {
+			cmds[shard] = pipe.XAutoClaim(ctx, &goredis.XAutoClaimArgs{
+				Stream:   s.logKey(shard),
+				Group:    group,
+				Consumer: s.opts.Consumer,
+				MinIdle:  s.opts.ClaimAfter,
+				Start:    "0-0",
+				Count:    int64(n),
+			})
+		}
+		return nil
+	})
+	if err != nil && err != goredis.Nil {
+		return nil, err
+	}
+	var entries []Entry
+	for shard, cmd := range cmds {
+		msgs, _, err := cmd.Result()
+		if err == goredis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			entries = append(entries, toEntry(shard, msg))
+		}
+	}
+	return entries, nil
+}
+
+func (s *RedisStore) Ack(ctx context.Context, entries []Entry) error {
+	ids := map[int][]string{}
+	for _, e := range entries {
+		shard, id, err := parseID(e.ID)
+		if err != nil {
+			return err
+		}
+		ids[shard] = append(ids[shard], id)
+	}
+	_, err := s.rdb.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for shard, ids := range ids {
+			pipe.XAck(ctx, s.logKey(shard), group, ids...)
+			pipe.XDel(ctx, s.logKey(shard), ids...)
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Pending(ctx context.Context) (map[string]bool, error) {
+	keys := map[string]bool{}
+	for shard := 0; shard < s.opts.Shards; shard++ {
+		start := "-"
+		for {
+			msgs, err := s.rdb.XRangeN(ctx, s.logKey(shard), start, "+", 1000).Result()
+			if err != nil {
+				return nil, err
+			}
+			for _, msg := range msgs {
+				keys[toEntry(shard, msg).Key] = true
+			}
+			if len(msgs) < 1000 {
+				break
+			}
+			start = "(" + msgs[len(msgs)-1].ID
+		}
+	}
+	return keys, nil
+}
+
+func (s *RedisStore) Scan(ctx context.Context, fn func(key string) error) error {
+	match := fmt.Sprintf("{wb:%s:*}:e:*", s.kind)
+	scan := func(ctx context.Context, c goredis.Cmdable) error {
+		iter := c.Scan(ctx, 0, match, 1000).Iterator()
+		for iter.Next(ctx) {
+			_, key, ok := strings.Cut(iter.Val(), "}:e:")
+			if !ok {
+				continue
+			}
+			if err := fn(key); err != nil {
+				return err
+			}
+		}
+		return iter.Err()
+	}
+	if cluster, ok := s.rdb.(*goredis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *goredis.Client) error {
+			return scan(ctx, node)
+		})
+	}
+	return scan(ctx, s.rdb)
+}
+
+func toEntry(shard int, msg goredis.XMessage) Entry {
+	e := Entry{ID: fmt.Sprintf("%d/%s", shard, msg.ID)}
+	e.Key, _ = msg.Values["k"].(string)
+	if f, _ := msg.Values["f"].(string); f != "" {
+		e.Fields = strings.Split(f, "\n")
+	}
+	// The ids of the stream entries start with their time in milliseconds.
+	if ms, _, ok := strings.Cut(msg.ID, "-"); ok {
+		if ms, err := strconv.ParseInt(ms, 10, 64); err == nil {
+			e.Time = time.UnixMilli(ms)
+		}
+	}
+	return e
+}
+
+func parseID(id string) (int, string, error) {
+	shard, msgID, ok := strings.Cut(id, "/")
+	if !ok {
+		return 0, "", fmt.Errorf("writebehind: bad entry id %q", id)
+	}
+	n, err := strconv.Atoi(shard)
+	if err != nil {
+		return 0, "", fmt.Errorf("writebehind: bad entry id %q", id)
+	}
+	return n, msgID, nil
+}
+
+func truncate(entries []Entry, n int) []Entry {
+	if len(entries) > n {
+		return entries[:n]
+	}
+	return entries
+}
@@ -0,0 +1,317 @@
+// Package writebehind caches the writes of the entities (e.g., the players)
+// in a store (Redis), which they land in at once, and flushes them to their
+// database asynchronously, in batches.
+//
+// A write records the values of fields of an entity, and appends the change
+// (the names of the fields) to a change log, atomically. The flusher reads
+// the changes logged, reads the latest values of their fields, writes them
+// to the database, and only then removes the changes from the log: the
+// changes of a process that crashed are flushed again, by the process once
+// it restarts, or by another one (see Options.ClaimAfter). Flushes write the
+// latest values, so that replaying a change is harmless.
+//
+// The entities are read from the cache first, since the database lags
+// behind it.
+package writebehind
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+var (
+	writes = metrics.NewCounterMap[kindLabels](
+		"writebehind_writes",
+		"Number of writes of entities to the cache",
+	)
+	flushedChanges = metrics.NewCounterMap[kindLabels](
+		"writebehind_flushed_changes",
+		"Number of changes logged flushed to the database",
+	)
+	flushMicros = metrics.NewHistogramMap[kindLabels](
+		"writebehind_flush_micros",
+		"Duration, in microseconds, of the batches written to the database",
+		metrics.NonNegativeBuckets,
+	)
+	flushErrors = metrics.NewCounterMap[kindLabels](
+		"writebehind_flush_errors",
+		"Number of batches that failed to be flushed, and are retried",
+	)
+	flushLag = metrics.NewHistogramMap[kindLabels](
+		"writebehind_flush_lag_millis",
+		"Delay, in milliseconds, between the changes and their flush to the database",
+		metrics.NonNegativeBuckets,
+	)
+	lostFields = metrics.NewCounterMap[kindLabels](
+		"writebehind_lost_fields",
+		"Number of fields changed whose value was missing from the cache when flushed (e.g., expired)",
+	)
+)
+
+type kindLabels struct {
+	Kind string
+}
+
+// Options are the options of a cache.
+type Options struct {
+	// Shards is the number of shards of the change log and the cache in the
+	// store, which the entities are spread over by key. Defaults to 16; it
+	// can't change once deployed.
+	Shards int
+	// Batch is the most changes flushed at once. Defaults to 500.
+	Batch int
+	// Interval is how often the changes are flushed. Defaults to 1 second.
+	Interval time.Duration
+	// TTL is how long an entity stays cached after its last write. Defaults
+	// to 7 days.
+	TTL time.Duration
+	// ClaimAfter is how long the changes of another process stay unflushed
+	// before this process flushes them, as the other one crashed. Defaults
+	// to 1 minute.
+	ClaimAfter time.Duration
+	// Consumer is the name of this process: a process restarted under the
+	// same name flushes its changes pending at once. Defaults to the
+	// hostname and the pid.
+	Consumer string
+}
+
+func (o *Options) setDefaults() {
+	if o.Shards <= 0 {
+		o.Shards = 16
+	}
+	if o.Batch <= 0 {
+		o.Batch = 500
+	}
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.TTL <= 0 {
+		o.TTL = 7 * 24 * time.Hour
+	}
+	if o.ClaimAfter <= 0 {
+		o.ClaimAfter = time.Minute
+	}
+	if o.Consumer == "" {
+		host, _ := os.Hostname()
+		o.Consumer = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+}
+
+// Entry is a change logged: fields of an entity were written.
+type Entry struct {
+	ID     string    // id of the entry in the log
+	Key    string    // key of the entity
+	Fields []string  // fields written
+	Time   time.Time // time of the change
+}
+
+// Change is the latest values of fields of an entity, to write to the
+// database.
+type Change struct {
+	Key    string
+	Fields map[string][]byte
+}
+
+// Store stores the latest values of the fields of the entities, and the
+// change log.
+type Store interface {
+	// Write records the values of fields of an entity, and logs the change,
+	// atomically.
+	Write(ctx context.Context, key string, fields map[string][]byte) error
+
+	// Read returns the values of fields of an entity, or of all of its
+	// fields if none are provided. The fields without value are missing.
+	Read(ctx context.Context, key string, fields ...string) (map[string][]byte, error)
+
+	// Next returns up to n changes to flush, without waiting: the changes
+	// returned before and not acknowledged first, then the changes left
+	// over by other processes for longer than ClaimAfter, then new ones.
+	Next(ctx context.Context, n int) ([]Entry, error)
+
+	// Ack removes changes flushed from the log.
+	Ack(ctx context.Context, entries []Entry) error
+
+	// Pending returns the keys of the entities with changes not flushed.
+	Pending(ctx context.Context) (map[string]bool, error)
+
+	// Scan calls fn with the keys of the entities cached.
+	Scan(ctx context.Context, fn func(key string) error) error
+}
+
+// Sink is the database of the entities.
+type Sink interface {
+	// Flush writes the values of the fields of entities. A change may be
+	// flushed more than once.
+	Flush(ctx context.Context, changes []Change) error
+
+	// Read returns the values of fields of an entity, encoded as the cache
+	// encodes them; the fields without value are missing.
+	Read(ctx context.Context, key string, fields []string) (map[string][]byte, error)
+
+	// Equal returns whether two encoded values are the same.
+	Equal(a, b []byte) bool
+}
+
+// Cache is the write-behind cache of a kind of entities.
+type Cache struct {
+	kind   kindLabels
+	store  Store
+	sink   Sink
+	opts   Options
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// New returns the cache of a kind of entities (e.g., "player"). Its changes
+// are flushed once it's started.
+func New(kind string, store Store, sink Sink, opts Options) *Cache {
+	opts.setDefaults()
+	return &Cache{
+		kind:   kindLabels{Kind: kind},
+		store:  store,
+		sink:   sink,
+		opts:   opts,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+}
+
+// Write writes the values of fields of an entity to the cache; they're
+// flushed to the database later.
+func (c *Cache) Write(ctx context.Context, key string, fields map[string][]byte) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if err := c.store.Write(ctx, key, fields); err != nil {
+		return fmt.Errorf("writebehind: write %v %v: %w", c.kind.Kind, key, err)
+	}
+	writes.Get(c.kind).Add(1)
+	return nil
+}
+
+// Read returns the values cached of fields of an entity, or of all of its
+// fields if none are provided. The fields not cached (e.g., never written,
+// or expired) are missing, and must be read from the database.
+func (c *Cache) Read(ctx context.Context, key string, fields ...string) (map[string][]byte, error) {
+	values, err := c.store.Read(ctx, key, fields...)
+	if err != nil {
+		return nil, fmt.Errorf("writebehind: read %v %v: %w", c.kind.Kind, key, err)
+	}
+	return values, nil
+}
+
+// Start starts flushing the changes, every Options.Interval.
+func (c *Cache) Start() {
+	go c.run()
+}
+
+// Stop stops flushing periodically, and flushes the changes left, until ctx
+// is done.
+func (c *Cache) Stop(ctx context.Context) error {
+	close(c.stopCh)
+	<-c.doneCh
+	return c.drain(ctx)
+}
+
+func (c *Cache) run() {
+	defer close(c.doneCh)
+	ticker := time.NewTicker(c.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.stopCh:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), c.opts.Interval*10)
+		if err := c.drain(ctx); err != nil {
+			logger.Error("[writebehind] %v flush err:%v", c.kind.Kind, err)
+		}
+		cancel()
+	}
+}
+
+// drain flushes batches until no change is left. It returns at the first
+// error: the changes are retried by the next flush.
+func (c *Cache) drain(ctx context.Context) error {
+	for {
+		n, err := c.Flush(ctx)
+		if err != nil {
+			return err
+		}
+		if n < c.opts.Batch {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+}
+
+// Flush flushes a batch of changes, and returns their number.
+func (c *Cache) Flush(ctx context.Context) (int, error) {
+	entries, err := c.store.Next(ctx, c.opts.Batch)
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	// The changes of an entity are merged: the latest values of all the
+	// fields changed are written at once.
+	var keys []string
+	dirty := map[string]map[string]bool{}
+	for _, e := range entries {
+		fields := dirty[e.Key]
+		if fields == nil {
+			fields = map[string]bool{}
+			dirty[e.Key] = fields
+			keys = append(keys, e.Key)
+		}
+		for _, f := range e.Fields {
+			fields[f] = true
+		}
+	}
+	changes := make([]Change, 0, len(keys))
+	for _, key := range keys {
+		names := make([]string, 0, len(dirty[key]))
+		for f := range dirty[key] {
+			names = append(names, f)
+		}
+		values, err := c.store.Read(ctx, key, names...)
+		if err != nil {
+			return 0, err
+		}
+		if lost := len(names) - len(values); lost > 0 {
+			lostFields.Get(c.kind).Add(float64(lost))
+			logger.Error("[writebehind] %v %v lost fields:%v", c.kind.Kind, key, lost)
+		}
+		if len(values) > 0 {
+			changes = append(changes, Change{Key: key, Fields: values})
+		}
+	}
+
+	start := time.Now()
+	if len(changes) > 0 {
+		if err := c.sink.Flush(ctx, changes); err != nil {
+			flushErrors.Get(c.kind).Add(1)
+			return 0, err
+		}
+	}
+	flushMicros.Get(c.kind).Put(float64(time.Since(start).Microseconds()))
+	if err := c.store.Ack(ctx, entries); err != nil {
+		// They're flushed again.
+		return 0, err
+	}
+	now := time.Now()
+	for _, e := range entries {
+		flushLag.Get(c.kind).Put(float64(now.Sub(e.Time).Milliseconds()))
+	}
+	flushedChanges.Get(c.kind).Add(float64(len(entries)))
+	return len(entries), nil
+}
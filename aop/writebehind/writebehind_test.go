@@ -0,0 +1,278 @@
+package writebehind
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStore is a Store in memory, of a single process.
+type memStore struct {
+	mu       sync.Mutex
+	entities map[string]map[string][]byte
+	log      []Entry
+	next     int
+}
+
+func newMemStore() *memStore {
+	return &memStore{entities: map[string]map[string][]byte{}}
+}
+
+func (s *memStore) Write(_ context.Context, key string, fields map[string][]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entities[key]
+	if e == nil {
+		e = map[string][]byte{}
+		s.entities[key] = e
+	}
+	var names []string
+	for f, v := range fields {
+		e[f] = v
+		names = append(names, f)
+	}
+	s.next++
+	s.log = append(s.log, Entry{ID: fmt.Sprint(s.next), Key: key, Fields: names, Time: time.Now()})
+	return nil
+}
+
+func (s *memStore) Read(_ context.Context, key string, fields ...string) (map[string][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := map[string][]byte{}
+	for f, v := range s.entities[key] {
+		values[f] = v
+	}
+	if len(fields) == 0 {
+		return values, nil
+	}
+	some := map[string][]byte{}
+	for _, f := range fields {
+		if v, ok := values[f]; ok {
+			some[f] = v
+		}
+	}
+	return some, nil
+}
+
+func (s *memStore) Next(_ context.Context, n int) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n > len(s.log) {
+		n = len(s.log)
+	}
+	return append([]Entry(nil), s.log[:n]...), nil
+}
+
+func (s *memStore) Ack(_ context.Context, entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acked := map[string]bool{}
+	for _, e := range entries {
+		acked[e.ID] = true
+	}
+	var log []Entry
+	for _, e := range s.log {
+		if !acked[e.ID] {
+			log = append(log, e)
+		}
+	}
+	s.log = log
+	return nil
+}
+
+func (s *memStore) Pending(context.Context) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := map[string]bool{}
+	for _, e := range s.log {
+		keys[e.Key] = true
+	}
+	return keys, nil
+}
+
+func (s *memStore) Scan(_ context.Context, fn func(key string) error) error {
+	s.mu.Lock()
+	var keys []string
+	for key := range s.entities {
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+	for _, key := range keys {
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memSink is a Sink in memory, which fails while err is set.
+type memSink struct {
+	mu       sync.Mutex
+	entities map[string]map[string][]byte
+	batches  int
+	err      error
+}
+
+func newMemSink() *memSink {
+	return &memSink{entities: map[string]map[string][]byte{}}
+}
+
+func (s *memSink) Flush(_ context.Context, changes []Change) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.batches++
+	for _, c := range changes {
+		e := s.entities[c.Key]
+		if e == nil {
+			e = map[string][]byte{}
+			s.entities[c.Key] = e
+		}
+		for f, v := range c.Fields {
+			e[f] = v
+		}
+	}
+	return nil
+}
+
+func (s *memSink) Read(_ context.Context, key string, fields []string) (map[string][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := map[string][]byte{}
+	for _, f := range fields {
+		if v, ok := s.entities[key][f]; ok {
+			values[f] = v
+		}
+	}
+	return values, nil
+}
+
+func (s *memSink) Equal(a, b []byte) bool {
+	return bytes.Equal(a, b)
+}
+
+func (s *memSink) get(key, field string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return string(s.entities[key][field])
+}
+
+func write(t *testing.T, c *Cache, key string, fields ...string) {
+	t.Helper()
+	values := map[string][]byte{}
+	for i := 0; i < len(fields); i += 2 {
+		values[fields[i]] = []byte(fields[i+1])
+	}
+	if err := c.Write(context.Background(), key, values); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	ctx := context.Background()
+	store, sink := newMemStore(), newMemSink()
+	c := New("test", store, sink, Options{Batch: 10})
+
+	write(t, c, "1", "bag", "a", "task", "x")
+	write(t, c, "1", "bag", "b")
+	write(t, c, "2", "bag", "c")
+	// Read from the cache before the flush.
+	if got, _ := c.Read(ctx, "1", "bag"); string(got["bag"]) != "b" {
+		t.Errorf("Read: got %q, want b", got["bag"])
+	}
+	if sink.get("1", "bag") != "" {
+		t.Fatal("flushed before Flush")
+	}
+
+	n, err := c.Flush(ctx)
+	if err != nil || n != 3 {
+		t.Fatalf("Flush: got %d, %v; want 3 changes", n, err)
+	}
+	// The changes of an entity are merged into its latest values.
+	if sink.batches != 1 || sink.get("1", "bag") != "b" || sink.get("1", "task") != "x" || sink.get("2", "bag") != "c" {
+		t.Errorf("flushed %v in %d batches", sink.entities, sink.batches)
+	}
+	if n, _ := c.Flush(ctx); n != 0 {
+		t.Errorf("Flush again: got %d changes, want 0", n)
+	}
+}
+
+func TestFlushFailed(t *testing.T) {
+	ctx := context.Background()
+	store, sink := newMemStore(), newMemSink()
+	c := New("test", store, sink, Options{Batch: 10})
+
+	write(t, c, "1", "bag", "a")
+	sink.err = errors.New("down")
+	if _, err := c.Flush(ctx); err == nil {
+		t.Fatal("Flush: no error while the database is down")
+	}
+	// The change is kept, and flushed once the database is back.
+	write(t, c, "1", "bag", "b")
+	sink.err = nil
+	if n, err := c.Flush(ctx); err != nil || n != 2 {
+		t.Fatalf("Flush: got %d, %v; want 2 changes", n, err)
+	}
+	if got := sink.get("1", "bag"); got != "b" {
+		t.Errorf("flushed %q, want b", got)
+	}
+}
+
+func TestStop(t *testing.T) {
+	store, sink := newMemStore(), newMemSink()
+	c := New("test", store, sink, Options{Batch: 2, Interval: time.Hour})
+	c.Start()
+	for i := 0; i < 5; i++ {
+		write(t, c, fmt.Sprint(i), "bag", "a")
+	}
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// All the batches are flushed.
+	for i := 0; i < 5; i++ {
+		if sink.get(fmt.Sprint(i), "bag") != "a" {
+			t.Errorf("entity %d not flushed", i)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	store, sink := newMemStore(), newMemSink()
+	c := New("test", store, sink, Options{})
+
+	write(t, c, "1", "bag", "a")
+	write(t, c, "2", "bag", "b")
+	c.Flush(ctx)
+	write(t, c, "3", "bag", "c") // pending
+	sink.entities["2"]["bag"] = []byte("stale")
+
+	var mismatches []Mismatch
+	res, err := c.Check(ctx, nil, func(m Mismatch) { mismatches = append(mismatches, m) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CheckResult{Checked: 2, Pending: 1, Mismatches: 1}
+	if res != want {
+		t.Errorf("Check: got %+v, want %+v", res, want)
+	}
+	if len(mismatches) != 1 || mismatches[0].Key != "2" || string(mismatches[0].Stored) != "stale" {
+		t.Errorf("Check: got %+v", mismatches)
+	}
+
+	// Repaired, the value cached is flushed again.
+	if err := c.Repair(ctx, "2", "bag"); err != nil {
+		t.Fatal(err)
+	}
+	c.Flush(ctx)
+	if got := sink.get("2", "bag"); got != "b" {
+		t.Errorf("repaired %q, want b", got)
+	}
+}
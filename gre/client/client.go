@@ -1,8 +1,8 @@
 package main
 
 import (
-	"github.com/phuhao00/greatestworks-proto/messageId"
 	"greatestworks/aop/logger"
+	"greatestworks/aop/msgrouter"
 	"os"
 	"syscall"
 
@@ -10,19 +10,19 @@ import (
 )
 
 type Client struct {
-	cli             *network.Client
-	inputHandlers   map[string]InputHandler
-	messageHandlers map[messageId.MessageId]MessageHandler
-	console         *ClientConsole
-	chInput         chan *InputParam
+	cli           *network.Client
+	inputHandlers map[string]InputHandler
+	router        *msgrouter.Router
+	console       *ClientConsole
+	chInput       chan *InputParam
 }
 
 func NewClient() *Client {
 	c := &Client{
-		cli:             network.NewClient(":8023", 200, logger.Logger),
-		inputHandlers:   map[string]InputHandler{},
-		messageHandlers: map[messageId.MessageId]MessageHandler{},
-		console:         NewClientConsole(),
+		cli:           network.NewClient(":8023", 200, logger.Logger),
+		inputHandlers: map[string]InputHandler{},
+		router:        msgrouter.NewRouter(msgrouter.Recover()),
+		console:       NewClientConsole(),
 	}
 	c.cli.OnMessageCb = c.OnMessage
 	c.cli.ChMsg = make(chan *network.Message, 1)
@@ -50,9 +50,7 @@ func (c *Client) Run() {
 }
 
 func (c *Client) OnMessage(packet *network.Packet) {
-	if handler, ok := c.messageHandlers[messageId.MessageId(packet.Msg.ID)]; ok {
-		handler(packet)
-	}
+	c.router.Dispatch(packet)
 }
 
 func (c *Client) OnSystemSignal(signal os.Signal) bool {
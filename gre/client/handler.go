@@ -4,13 +4,10 @@ import (
 	"fmt"
 	"github.com/phuhao00/greatestworks-proto/player"
 	"github.com/phuhao00/network"
+	"greatestworks/internal/protocol"
 	"strconv"
-
-	"google.golang.org/protobuf/proto"
 )
 
-type MessageHandler func(packet *network.Packet)
-
 type InputHandler func(param *InputParam)
 
 // CreatePlayer 创建角色
@@ -49,14 +46,7 @@ func (c *Client) Login(param *InputParam) {
 
 }
 
-func (c *Client) OnLoginRsp(packet *network.Packet) {
-	rsp := &player.SCLogin{}
-
-	err := proto.Unmarshal(packet.Msg.Data, rsp)
-	if err != nil {
-		return
-	}
-
+func (c *Client) OnLoginRsp(packet *network.Packet, rsp *player.SCLogin) {
 	fmt.Println("登陆成功")
 }
 
@@ -138,3 +128,7 @@ func (c *Client) OnSendChatMsgRsp(packet *network.Packet) {
 	fmt.Println("send  chat message success")
 
 }
+
+func (c *Client) OnChatMsg(packet *network.Packet, msg *protocol.SCChatMsg) {
+	fmt.Printf("[%v] %v: %v\n", msg.Category, msg.UId, msg.Content)
+}
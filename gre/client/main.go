@@ -1,11 +1,19 @@
 package main
 
-import "github.com/phuhao00/sugar"
+import (
+	"fmt"
+	"os"
+
+	"github.com/phuhao00/sugar"
+)
 
 func main() {
 	c := NewClient()
 	c.InputHandlerRegister()
-	c.MessageHandlerRegister()
+	if err := c.MessageHandlerRegister(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	c.Run()
 	sugar.WaitSignal(c.OnSystemSignal)
 }
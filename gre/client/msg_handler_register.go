@@ -1,11 +1,22 @@
 package main
 
-import "github.com/phuhao00/greatestworks-proto/messageId"
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"greatestworks/aop/msgrouter"
+	"greatestworks/internal/protocol"
+)
 
-func (c *Client) MessageHandlerRegister() {
-	c.messageHandlers[messageId.MessageId_SCCreatePlayer] = c.OnCreatePlayerRsp
-	c.messageHandlers[messageId.MessageId_SCLogin] = c.OnLoginRsp
-	c.messageHandlers[messageId.MessageId_SCAddFriend] = c.OnAddFriendRsp
-	c.messageHandlers[messageId.MessageId_SCDelFriend] = c.OnDelFriendRsp
-	c.messageHandlers[messageId.MessageId_SCSendChatMsg] = c.OnSendChatMsgRsp
+// MessageHandlerRegister registers the handlers of the messages of the
+// server, and returns an error if a message ID is registered twice.
+func (c *Client) MessageHandlerRegister() error {
+	player := c.router.Module("player")
+	player.Handle(messageId.MessageId_SCCreatePlayer, c.OnCreatePlayerRsp)
+	player.Handle(messageId.MessageId_SCLogin, msgrouter.Typed(c.OnLoginRsp))
+	friend := c.router.Module("friend")
+	friend.Handle(messageId.MessageId_SCAddFriend, c.OnAddFriendRsp)
+	friend.Handle(messageId.MessageId_SCDelFriend, c.OnDelFriendRsp)
+	chat := c.router.Module("chat")
+	chat.Handle(messageId.MessageId_SCSendChatMsg, c.OnSendChatMsgRsp)
+	chat.Handle(protocol.MessageId_SCChatMsg, msgrouter.Typed(c.OnChatMsg))
+	return c.router.Check()
 }
@@ -0,0 +1,90 @@
+package broadcast
+
+import (
+	"fmt"
+	"time"
+
+	"greatestworks/aop/loader/json"
+)
+
+// Kinds of messages: how the client shows them.
+const (
+	KindMarquee = iota + 1 // 跑马灯
+	KindBanner             // 顶部公告栏
+	KindChat               // 聊天系统频道
+	KindPopup              // 弹窗
+)
+
+// Priorities of the messages. The messages are shown one at a time, the
+// highest priority first; urgent ones are shown at once.
+const (
+	PriorityLow    = iota + 1 // 低
+	PriorityNormal            // 普通
+	PriorityHigh              // 高
+	PriorityUrgent            // 紧急，不排队
+)
+
+// Triggers of the announcements of the system.
+const (
+	TriggerNone     = ""          // 只由GM或代码触发
+	TriggerDrop     = "drop"      // 击杀怪物掉落物品Param
+	TriggerFirstWin = "first_win" // 全服首个赢得模式Param的战斗的玩家
+)
+
+// Conf 公告模板配置
+type Conf struct {
+	Id       uint32 `json:"id"`
+	Template string `json:"template"` // 模板，{name}替换为参数，如"恭喜{player}获得{item}"
+	Kind     int    `json:"kind"`     // 显示方式(Kind*)
+	Priority int    `json:"priority"` // 优先级(Priority*)，0为普通
+	Repeat   int32  `json:"repeat"`   // 跑马灯滚动次数，0为1次
+	Trigger  string `json:"trigger"`  // 系统触发条件(Trigger*)
+	Param    uint32 `json:"param"`    // 触发参数，如物品id、模式
+}
+
+// ModuleConfig is the config of the broadcast module. It must be set before
+// the module is initialized (see Module.Init).
+type ModuleConfig struct {
+	TemplateFile string        // announcement table, see Conf
+	ServerId     string        // id of this server, which labels the messages it relays to the other servers
+	Interval     time.Duration // time between two messages shown, defaults to defaultInterval
+	QueueSize    int           // number of messages waiting to be shown, the lowest priority dropped beyond, defaults to defaultQueueSize
+}
+
+const (
+	defaultInterval  = 3 * time.Second
+	defaultQueueSize = 100
+)
+
+// loadTable loads the announcement table.
+func loadTable(file string) (map[uint32]*Conf, error) {
+	var list []*Conf
+	if !json.ParseJsonFile2Slice(file, true, &list) {
+		return nil, fmt.Errorf("broadcast: announcement table %q not found", file)
+	}
+	confs := make(map[uint32]*Conf, len(list))
+	for _, c := range list {
+		if confs[c.Id] != nil {
+			return nil, fmt.Errorf("broadcast: announcement table %q: repeat announcement %d", file, c.Id)
+		}
+		if c.Kind < KindMarquee || c.Kind > KindPopup {
+			return nil, fmt.Errorf("broadcast: announcement table %q: announcement %d: invalid kind %d", file, c.Id, c.Kind)
+		}
+		if c.Priority == 0 {
+			c.Priority = PriorityNormal
+		}
+		if c.Priority < PriorityLow || c.Priority > PriorityUrgent {
+			return nil, fmt.Errorf("broadcast: announcement table %q: announcement %d: invalid priority %d", file, c.Id, c.Priority)
+		}
+		switch c.Trigger {
+		case TriggerNone, TriggerDrop, TriggerFirstWin:
+		default:
+			return nil, fmt.Errorf("broadcast: announcement table %q: announcement %d: unknown trigger %q", file, c.Id, c.Trigger)
+		}
+		if c.Repeat <= 0 {
+			c.Repeat = 1
+		}
+		confs[c.Id] = c
+	}
+	return confs, nil
+}
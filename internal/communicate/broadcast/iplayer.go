@@ -0,0 +1,12 @@
+package broadcast
+
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+)
+
+// Owner is a player the announcements are shown to.
+type Owner interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+	GetName() string
+}
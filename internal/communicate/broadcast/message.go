@@ -0,0 +1,71 @@
+package broadcast
+
+import (
+	"sort"
+	"strings"
+)
+
+// Message is an announcement, relayed to every server and shown to all the
+// online players. The client shows Text, or localizes the template with the
+// params.
+type Message struct {
+	Template uint32            `json:"template"` // 0 for the custom text of a GM
+	Params   map[string]string `json:"params,omitempty"`
+	Text     string            `json:"text"`
+	Kind     int               `json:"kind"`
+	Priority int               `json:"priority"`
+	Repeat   int32             `json:"repeat"`
+	Time     int64             `json:"time"` // unix seconds
+}
+
+// render replaces the {name} of a template with the params. Unknown names
+// are kept as they are.
+func render(template string, params map[string]string) string {
+	if len(params) == 0 {
+		return template
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for k, v := range params {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// queue is the messages waiting to be shown, the highest priority first, in
+// the order they came within a priority. It isn't safe for concurrent use.
+type queue struct {
+	size int
+	msgs []*Message
+}
+
+// push adds a message, dropping the last one if the queue is full. It
+// returns the message dropped, if any: possibly the one added.
+func (q *queue) push(msg *Message) *Message {
+	i := sort.Search(len(q.msgs), func(i int) bool {
+		return q.msgs[i].Priority < msg.Priority
+	})
+	q.msgs = append(q.msgs, nil)
+	copy(q.msgs[i+1:], q.msgs[i:])
+	q.msgs[i] = msg
+	if len(q.msgs) <= q.size {
+		return nil
+	}
+	dropped := q.msgs[len(q.msgs)-1]
+	q.msgs = q.msgs[:len(q.msgs)-1]
+	return dropped
+}
+
+// pop removes and returns the next message, or nil if there's none.
+func (q *queue) pop() *Message {
+	if len(q.msgs) == 0 {
+		return nil
+	}
+	msg := q.msgs[0]
+	q.msgs[0] = nil
+	q.msgs = q.msgs[1:]
+	return msg
+}
+
+func (q *queue) len() int {
+	return len(q.msgs)
+}
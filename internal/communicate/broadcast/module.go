@@ -0,0 +1,265 @@
+package broadcast
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/module_router"
+	"greatestworks/internal"
+	"greatestworks/internal/protocol"
+)
+
+const (
+	ModuleName = "broadcast"
+)
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNoAnnouncement = errors.New("broadcast: no such announcement")
+	ErrEmpty          = errors.New("broadcast: empty announcement")
+)
+
+var (
+	sent = metrics.NewCounterMap[messageLabels](
+		"broadcast_messages",
+		"Number of announcements made on this server, relayed to every server",
+	)
+	dropped = metrics.NewCounterMap[messageLabels](
+		"broadcast_dropped",
+		"Number of announcements dropped from the full queue of this server",
+	)
+	queued = metrics.NewGauge(
+		"broadcast_queue",
+		"Number of announcements waiting to be shown on this server",
+	)
+)
+
+type messageLabels struct {
+	Kind     string
+	Priority string
+}
+
+func labelsOf(msg *Message) messageLabels {
+	return messageLabels{Kind: strconv.Itoa(msg.Kind), Priority: strconv.Itoa(msg.Priority)}
+}
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Module makes the announcements of the GMs and of the system (e.g., a rare
+// drop, see subscribe), from the templates of the announcement table, and
+// shows them to all the online players of every server: they're relayed to
+// the other servers through Redis pub/sub. Every server shows them one every
+// ModuleConfig.Interval, the highest priority first, but the urgent ones at
+// once.
+type Module struct {
+	*internal.BaseModule
+	initFlag  bool
+	confs     map[uint32]*Conf
+	drops     map[uint32][]*Conf // TriggerDrop announcements, by item
+	firstWins map[uint32][]*Conf // TriggerFirstWin announcements, by mode
+	serverId  string
+	interval  time.Duration
+	stopCh    chan struct{}
+
+	mu     sync.Mutex
+	queue  *queue           // guarded by mu
+	online map[uint64]Owner // guarded by mu
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
+	return Mod
+}
+
+// Init loads the announcement table.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.confs = map[uint32]*Conf{}
+	if conf.TemplateFile != "" {
+		confs, err := loadTable(conf.TemplateFile)
+		if err != nil {
+			return err
+		}
+		m.confs = confs
+	} else {
+		logger.Warn("[broadcast] no announcement table")
+	}
+	m.drops = make(map[uint32][]*Conf)
+	m.firstWins = make(map[uint32][]*Conf)
+	for _, c := range m.confs {
+		switch c.Trigger {
+		case TriggerDrop:
+			m.drops[c.Param] = append(m.drops[c.Param], c)
+		case TriggerFirstWin:
+			m.firstWins[c.Param] = append(m.firstWins[c.Param], c)
+		}
+	}
+	m.serverId = conf.ServerId
+	m.interval = conf.Interval
+	if m.interval <= 0 {
+		m.interval = defaultInterval
+	}
+	size := conf.QueueSize
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	m.queue = &queue{size: size}
+	m.online = make(map[uint64]Owner)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts showing the announcements, and relaying those of the other
+// servers.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	m.subscribe()
+	go m.run()
+	go m.runRelay()
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+		m.unsubscribe()
+	}
+}
+
+// Announce makes an announcement of the announcement table, with the params
+// of its template.
+func (m *Module) Announce(ctx context.Context, id uint32, params map[string]string) error {
+	conf := m.confs[id]
+	if conf == nil {
+		return ErrNoAnnouncement
+	}
+	m.broadcast(ctx, &Message{
+		Template: id,
+		Params:   params,
+		Text:     render(conf.Template, params),
+		Kind:     conf.Kind,
+		Priority: conf.Priority,
+		Repeat:   conf.Repeat,
+		Time:     time.Now().Unix(),
+	})
+	return nil
+}
+
+// Custom makes an announcement of a text, e.g. from a GM.
+func (m *Module) Custom(ctx context.Context, text string, kind, priority int) error {
+	if text == "" {
+		return ErrEmpty
+	}
+	m.broadcast(ctx, &Message{Text: text, Kind: kind, Priority: priority, Repeat: 1, Time: time.Now().Unix()})
+	return nil
+}
+
+// broadcast shows an announcement on this server, and relays it to the
+// others.
+func (m *Module) broadcast(ctx context.Context, msg *Message) {
+	sent.Get(labelsOf(msg)).Add(1)
+	m.receive(msg)
+	m.publish(ctx, msg)
+}
+
+// receive shows an urgent announcement at once, and queues the others.
+func (m *Module) receive(msg *Message) {
+	if msg.Priority >= PriorityUrgent {
+		m.show(msg)
+		return
+	}
+	m.mu.Lock()
+	out := m.queue.push(msg)
+	queued.Set(float64(m.queue.len()))
+	m.mu.Unlock()
+	if out != nil {
+		dropped.Get(labelsOf(out)).Add(1)
+	}
+}
+
+// run shows the next announcement queued every ModuleConfig.Interval, until
+// the module is stopped.
+func (m *Module) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.mu.Lock()
+			msg := m.queue.pop()
+			queued.Set(float64(m.queue.len()))
+			m.mu.Unlock()
+			if msg != nil {
+				m.show(msg)
+			}
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// show pushes an announcement to the players online on this server.
+func (m *Module) show(msg *Message) {
+	m.mu.Lock()
+	owners := make([]Owner, 0, len(m.online))
+	for _, o := range m.online {
+		owners = append(owners, o)
+	}
+	m.mu.Unlock()
+	pb := toProto(msg)
+	for _, o := range owners {
+		o.SendMsg(protocol.MessageId_SCBroadcast, pb)
+	}
+}
+
+// Online registers a player that logged in to this server.
+func (m *Module) Online(uid uint64, owner Owner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.online[uid] = owner
+}
+
+// Offline unregisters a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
+}
+
+// nameOf returns the name of a player online on this server, or its id if
+// it's not.
+func (m *Module) nameOf(uid uint64) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o := m.online[uid]; o != nil {
+		return o.GetName()
+	}
+	return strconv.FormatUint(uid, 10)
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
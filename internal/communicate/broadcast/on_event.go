@@ -0,0 +1,70 @@
+package broadcast
+
+import (
+	"context"
+	"strconv"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
+	"greatestworks/internal"
+	"greatestworks/internal/note/event"
+	"greatestworks/internal/note/event/battleevent"
+	"greatestworks/internal/note/event/monsterevent"
+)
+
+// OnEvent is unused: the announcements of the system subscribe to the event
+// bus instead (see subscribe).
+func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
+}
+
+func (m *Module) SetEventCategoryActive(eventCategory int) {
+}
+
+// subscribe subscribes to the events the announcements of the system are
+// triggered by. The events are published on the server the player is online
+// on, so its name is known.
+func (m *Module) subscribe() {
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e monsterevent.Killed) {
+		for _, item := range e.Loot {
+			for _, c := range m.drops[item.Id] {
+				m.trigger(c, map[string]string{
+					"player":  m.nameOf(e.PlayerId),
+					"monster": strconv.FormatUint(uint64(e.MonsterId), 10),
+					"item":    strconv.FormatUint(uint64(item.Id), 10),
+					"num":     strconv.FormatInt(item.Num, 10),
+				})
+			}
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e battleevent.Finished) {
+		if !e.Won {
+			return
+		}
+		for _, c := range m.firstWins[e.Mode] {
+			ok, err := first(context.Background(), c.Id, e.PlayerId)
+			if err != nil {
+				logger.Error("[broadcast] first of announcement %v PlayerID:%v err:%v", c.Id, e.PlayerId, err)
+				continue
+			}
+			if ok {
+				m.trigger(c, map[string]string{
+					"player": m.nameOf(e.PlayerId),
+					"mode":   strconv.FormatUint(uint64(e.Mode), 10),
+				})
+			}
+		}
+	})
+}
+
+// trigger makes an announcement of the system.
+func (m *Module) trigger(c *Conf, params map[string]string) {
+	if err := m.Announce(context.Background(), c.Id, params); err != nil {
+		logger.Error("[broadcast] announcement %v err:%v", c.Id, err)
+	}
+}
+
+// unsubscribe cancels the subscriptions of the module, once the events
+// already received are handled.
+func (m *Module) unsubscribe() {
+	eventbus.Default.UnsubscribeModule(m.GetName())
+}
@@ -0,0 +1,17 @@
+package broadcast
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+func toProto(msg *Message) *protocol.SCBroadcast {
+	return &protocol.SCBroadcast{
+		Template: msg.Template,
+		Params:   msg.Params,
+		Text:     msg.Text,
+		Kind:     int32(msg.Kind),
+		Priority: int32(msg.Priority),
+		Repeat:   msg.Repeat,
+		Time:     msg.Time,
+	}
+}
@@ -0,0 +1,73 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+)
+
+// relayChannel is the Redis pub/sub channel on which the servers relay the
+// announcements to each other.
+const relayChannel = "broadcast:relay"
+
+// firstKey returns the Redis key of the player who first triggered an
+// announcement of a "first" trigger (e.g., TriggerFirstWin), on any server.
+func firstKey(id uint32) string {
+	return "broadcast:first:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// envelope is an announcement relayed to the other servers.
+type envelope struct {
+	Server string   `json:"server"` // server relaying the announcement
+	Msg    *Message `json:"msg"`
+}
+
+// publish relays an announcement to the other servers.
+func (m *Module) publish(ctx context.Context, msg *Message) {
+	b, err := json.Marshal(envelope{Server: m.serverId, Msg: msg})
+	if err != nil {
+		logger.Error("[broadcast] marshal %+v failed: %v", msg, err)
+		return
+	}
+	if err := redis.Get().Publish(ctx, relayChannel, b).Err(); err != nil {
+		logger.Error("[broadcast] publish failed: %v", err)
+	}
+}
+
+// runRelay shows the announcements relayed by the other servers, until the
+// module is stopped.
+func (m *Module) runRelay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := redis.Get().Subscribe(ctx, relayChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var e envelope
+			if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+				logger.Error("[broadcast] unmarshal %q failed: %v", msg.Payload, err)
+				continue
+			}
+			if e.Server == m.serverId || e.Msg == nil {
+				continue
+			}
+			m.receive(e.Msg)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// first returns whether a player is the first to trigger an announcement,
+// on any server.
+func first(ctx context.Context, id uint32, playerId uint64) (bool, error) {
+	return redis.Get().SetNX(ctx, firstKey(id), playerId, 0).Result()
+}
@@ -0,0 +1,82 @@
+package chat
+
+import (
+	"container/ring"
+	"fmt"
+)
+
+// Channel is a chat channel.
+type Channel int32
+
+const (
+	ChannelWorld   Channel = iota + 1 // all the online players
+	ChannelGuild                      // the members of a guild
+	ChannelTeam                       // the members of a team
+	ChannelPrivate                    // a single player
+)
+
+func (c Channel) String() string {
+	switch c {
+	case ChannelWorld:
+		return "world"
+	case ChannelGuild:
+		return "guild"
+	case ChannelTeam:
+		return "team"
+	case ChannelPrivate:
+		return "private"
+	default:
+		return fmt.Sprintf("Channel(%d)", int32(c))
+	}
+}
+
+// scoped returns whether the members of the channel join it explicitly (see
+// Module.Join).
+func (c Channel) scoped() bool {
+	return c == ChannelGuild || c == ChannelTeam
+}
+
+// Message is a chat message.
+type Message struct {
+	Channel  Channel `json:"channel"`
+	From     uint64  `json:"from"` // id of the sender
+	To       uint64  `json:"to"`   // guild id, team id, or player id, depending on Channel; 0 for the world
+	Content  string  `json:"content"`
+	SendTime int64   `json:"sendTime"` // unix milliseconds
+	Server   string  `json:"server"`   // server of the sender
+}
+
+// channelKey identifies a channel instance (e.g., the channel of a guild).
+type channelKey struct {
+	channel Channel
+	id      uint64
+}
+
+// history holds the recent messages of a channel instance, oldest first.
+type history struct {
+	r *ring.Ring // next slot to write
+	n int        // number of messages
+}
+
+func newHistory(size int) *history {
+	return &history{r: ring.New(size)}
+}
+
+func (h *history) add(msg *Message) {
+	h.r.Value = msg
+	h.r = h.r.Next()
+	if h.n < h.r.Len() {
+		h.n++
+	}
+}
+
+// messages returns the recent messages, oldest first.
+func (h *history) messages() []*Message {
+	msgs := make([]*Message, 0, h.n)
+	r := h.r.Move(-h.n)
+	for i := 0; i < h.n; i++ {
+		msgs = append(msgs, r.Value.(*Message))
+		r = r.Next()
+	}
+	return msgs
+}
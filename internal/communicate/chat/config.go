@@ -0,0 +1,40 @@
+package chat
+
+import (
+	"time"
+
+	"greatestworks/internal/communicate/chat/filter"
+)
+
+const (
+	defaultHistorySize = 50
+	defaultOfflineSize = 100
+	defaultOfflineTTL  = 7 * 24 * time.Hour
+)
+
+// defaultLimits are the rate limits of the channels without a configured
+// limit.
+var defaultLimits = map[Channel]Limit{
+	ChannelWorld:   {Burst: 1, Every: 5 * time.Second},
+	ChannelGuild:   {Burst: 3, Every: time.Second},
+	ChannelTeam:    {Burst: 5, Every: time.Second},
+	ChannelPrivate: {Burst: 5, Every: time.Second},
+}
+
+// ModuleConfig is the config of the chat module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ServerId    string            // id of this server, which labels the messages it relays to the other servers
+	HistorySize int               // number of recent messages kept per channel, defaults to defaultHistorySize
+	OfflineSize int64             // number of private messages kept for an offline player, defaults to defaultOfflineSize
+	OfflineTTL  time.Duration     // how long private messages are kept for an offline player, defaults to defaultOfflineTTL
+	Limits      map[Channel]Limit // rate limits by channel, default to defaultLimits
+	Filter      *filter.Config    // filter of the messages before they're sent; none if nil
+}
+
+// Limit is the rate limit of the messages a player sends to a channel: Burst
+// messages at once, then one message every Every.
+type Limit struct {
+	Burst int           `json:"burst"`
+	Every time.Duration `json:"every"`
+}
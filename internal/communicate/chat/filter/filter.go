@@ -0,0 +1,140 @@
+// Package filter implements the pipeline of rules chat messages go through
+// before they're broadcast: sensitive words are masked, messages that smuggle
+// links or numbers (e.g., of a gold selling site) are blocked, and messages
+// may be submitted to an external moderation service.
+//
+//	p := filter.NewPipeline(words, filter.NewLinkRule(), filter.NewNumberRule(8))
+//	content, err := p.Filter(ctx, "hello")
+//	if errors.Is(err, filter.ErrBlocked) { ... }
+package filter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metrics "greatestworks/aop/metrics/impl"
+)
+
+// ErrBlocked is returned, wrapped with the name of the rule, for the messages
+// that must not be sent.
+var ErrBlocked = errors.New("filter: message blocked")
+
+var ruleHits = metrics.NewCounterMap[hitLabels](
+	"chat_filter_rule_hits",
+	"Number of chat messages masked or blocked by a filter rule",
+)
+
+type hitLabels struct {
+	Rule    string
+	Verdict string // "mask" or "block"
+}
+
+// Verdict is the verdict of a rule on a message.
+type Verdict int
+
+const (
+	Pass  Verdict = iota // the message is sent as is
+	Mask                 // the message is sent with the content returned by the rule
+	Block                // the message isn't sent
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Pass:
+		return "pass"
+	case Mask:
+		return "mask"
+	case Block:
+		return "block"
+	default:
+		return fmt.Sprintf("Verdict(%d)", int(v))
+	}
+}
+
+// Rule is a rule of a pipeline. Check returns the verdict of the rule on the
+// content of a message, and the masked content if the verdict is Mask. Rules
+// are called concurrently.
+type Rule interface {
+	Name() string
+	Check(ctx context.Context, content string) (string, Verdict, error)
+}
+
+// Pipeline applies its rules to the messages, in order: each rule checks the
+// content masked by the rules before it, and the first rule that blocks a
+// message stops the pipeline.
+type Pipeline struct {
+	rules []Rule
+}
+
+// NewPipeline returns a pipeline of the provided rules.
+func NewPipeline(rules ...Rule) *Pipeline {
+	return &Pipeline{rules: rules}
+}
+
+// Filter returns the content of a message once masked by the rules, or an
+// error wrapping ErrBlocked if a rule blocks it. A rule that fails blocks the
+// message.
+func (p *Pipeline) Filter(ctx context.Context, content string) (string, error) {
+	for _, r := range p.rules {
+		masked, verdict, err := r.Check(ctx, content)
+		if err != nil {
+			ruleHits.Get(hitLabels{Rule: r.Name(), Verdict: Block.String()}).Add(1)
+			return "", fmt.Errorf("%w by %s: %v", ErrBlocked, r.Name(), err)
+		}
+		switch verdict {
+		case Mask:
+			ruleHits.Get(hitLabels{Rule: r.Name(), Verdict: verdict.String()}).Add(1)
+			content = masked
+		case Block:
+			ruleHits.Get(hitLabels{Rule: r.Name(), Verdict: verdict.String()}).Add(1)
+			return "", fmt.Errorf("%w by %s", ErrBlocked, r.Name())
+		}
+	}
+	return content, nil
+}
+
+// Config configures the pipeline built by New.
+type Config struct {
+	WordFile      string      // file of the sensitive words, one per line; no word rule if empty
+	WordVerdict   Verdict     // verdict on the messages with sensitive words, Mask or Block; defaults to Mask
+	MaxDigits     int         // number of digits from which a message is blocked; no number rule if 0
+	BlockLinks    bool        // whether messages with links are blocked
+	Moderation    *HTTPConfig // external moderation service; none if nil
+	WatchWordFile bool        // whether the word file is reloaded when it changes
+}
+
+// New returns the pipeline configured by conf: sensitive words, then links,
+// then numbers, then the external moderation service. The returned function
+// stops watching the word file.
+func New(conf *Config) (*Pipeline, func(), error) {
+	var rules []Rule
+	stop := func() {}
+	if conf.WordFile != "" {
+		verdict := conf.WordVerdict
+		if verdict != Block {
+			verdict = Mask
+		}
+		words := NewWordRule(verdict)
+		if err := words.LoadFile(conf.WordFile); err != nil {
+			return nil, nil, err
+		}
+		if conf.WatchWordFile {
+			var err error
+			if stop, err = words.Watch(conf.WordFile); err != nil {
+				return nil, nil, err
+			}
+		}
+		rules = append(rules, words)
+	}
+	if conf.BlockLinks {
+		rules = append(rules, NewLinkRule())
+	}
+	if conf.MaxDigits > 0 {
+		rules = append(rules, NewNumberRule(conf.MaxDigits))
+	}
+	if conf.Moderation != nil {
+		rules = append(rules, NewHTTPRule(*conf.Moderation))
+	}
+	return NewPipeline(rules...), stop, nil
+}
@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"greatestworks/aop/logger"
+)
+
+const defaultModerationTimeout = 500 * time.Millisecond
+
+// HTTPConfig configures an external moderation service.
+type HTTPConfig struct {
+	URL      string        // endpoint the messages are posted to
+	Timeout  time.Duration // time the service may take to answer, defaults to defaultModerationTimeout
+	FailOpen bool          // whether messages pass when the service fails; if not, they're blocked
+}
+
+// moderationRequest is the body posted to the moderation service.
+type moderationRequest struct {
+	Content string `json:"content"`
+}
+
+// moderationResponse is the answer of the moderation service. Action is
+// "pass", "mask", or "block"; Content is the masked content if Action is
+// "mask".
+type moderationResponse struct {
+	Action  string `json:"action"`
+	Content string `json:"content"`
+}
+
+// HTTPRule submits the messages to an external moderation service, which
+// answers with a verdict (see moderationResponse).
+type HTTPRule struct {
+	conf   HTTPConfig
+	client *http.Client
+}
+
+var _ Rule = (*HTTPRule)(nil)
+
+func NewHTTPRule(conf HTTPConfig) *HTTPRule {
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultModerationTimeout
+	}
+	return &HTTPRule{conf: conf, client: &http.Client{Timeout: conf.Timeout}}
+}
+
+func (h *HTTPRule) Name() string {
+	return "moderation"
+}
+
+func (h *HTTPRule) Check(ctx context.Context, content string) (string, Verdict, error) {
+	resp, err := h.post(ctx, content)
+	if err != nil {
+		if h.conf.FailOpen {
+			logger.Warn("[filter] moderation failed, message passes: %v", err)
+			return content, Pass, nil
+		}
+		return "", Block, err
+	}
+	switch resp.Action {
+	case "pass", "":
+		return content, Pass, nil
+	case "mask":
+		return resp.Content, Mask, nil
+	case "block":
+		return "", Block, nil
+	default:
+		return "", Block, fmt.Errorf("unknown moderation action %q", resp.Action)
+	}
+}
+
+func (h *HTTPRule) post(ctx context.Context, content string) (*moderationResponse, error) {
+	body, err := json.Marshal(moderationRequest{Content: content})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.conf.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpResp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation: %s", httpResp.Status)
+	}
+	resp := &moderationResponse{}
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return nil, fmt.Errorf("moderation: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,91 @@
+package filter
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// dotWords are the ways a dot is spelled out to smuggle a link.
+var dotWords = strings.NewReplacer(
+	"(dot)", ".", "[dot]", ".", " dot ", ".",
+	"点", ".", "點", ".", "。", ".", "·", ".", "．", ".",
+)
+
+var linkPattern = regexp.MustCompile(`(?i)(https?:/?/?|www\.|[a-z0-9-]+\.(com|net|org|cn|cc|co|top|xyz|io|me|vip|info|club|site|shop)\b)`)
+
+// LinkRule blocks the messages with links, including obfuscated ones (e.g.,
+// "w w w . example (dot) com").
+type LinkRule struct{}
+
+var _ Rule = LinkRule{}
+
+func NewLinkRule() LinkRule {
+	return LinkRule{}
+}
+
+func (LinkRule) Name() string {
+	return "links"
+}
+
+func (LinkRule) Check(_ context.Context, content string) (string, Verdict, error) {
+	s := dotWords.Replace(strings.ToLower(content))
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return normalize(r)
+	}, s)
+	if linkPattern.MatchString(s) {
+		return content, Block, nil
+	}
+	return content, Pass, nil
+}
+
+// NumberRule blocks the messages with long numbers (e.g., phone numbers), in
+// any script, and split by spaces or punctuation (e.g., "1 3 8-①②③ 四五").
+type NumberRule struct {
+	maxDigits int
+}
+
+var _ Rule = NumberRule{}
+
+// NewNumberRule returns a rule blocking the messages with numbers of at least
+// maxDigits digits.
+func NewNumberRule(maxDigits int) NumberRule {
+	return NumberRule{maxDigits: maxDigits}
+}
+
+func (NumberRule) Name() string {
+	return "numbers"
+}
+
+func (n NumberRule) Check(_ context.Context, content string) (string, Verdict, error) {
+	digits := 0
+	for _, r := range content {
+		switch {
+		case isDigit(r):
+			digits++
+			if digits >= n.maxDigits {
+				return content, Block, nil
+			}
+		case isNoise(r):
+			// Separators don't break numbers.
+		default:
+			digits = 0
+		}
+	}
+	return content, Pass, nil
+}
+
+// isDigit returns whether a rune spells a digit.
+func isDigit(r rune) bool {
+	if unicode.IsDigit(r) {
+		return true
+	}
+	if r >= '①' && r <= '⑳' || r >= '⑴' && r <= '⒇' || r >= '➀' && r <= '➓' {
+		return true
+	}
+	return strings.ContainsRune("零〇一二三四五六七八九壹贰叁肆伍陆柒捌玖两", r)
+}
@@ -0,0 +1,226 @@
+package filter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/fsnotify/fsnotify"
+	"greatestworks/aop/logger"
+)
+
+// maskRune replaces the letters of the sensitive words.
+const maskRune = '*'
+
+// Trie is a trie of sensitive words. Words match regardless of case and
+// width, and of the spaces and punctuation inserted between their letters
+// (e.g., "b.a d" matches "bad"). A Trie is immutable once built.
+type Trie struct {
+	root  *trieNode
+	words int
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	end      bool // whether a word ends here
+}
+
+// NewTrie returns a trie of the provided words. Blank words are skipped.
+func NewTrie(words []string) *Trie {
+	t := &Trie{root: &trieNode{}}
+	for _, w := range words {
+		n := t.root
+		for _, r := range w {
+			if isNoise(r) {
+				continue
+			}
+			r = normalize(r)
+			child := n.children[r]
+			if child == nil {
+				if n.children == nil {
+					n.children = map[rune]*trieNode{}
+				}
+				child = &trieNode{}
+				n.children[r] = child
+			}
+			n = child
+		}
+		if n != t.root && !n.end {
+			n.end = true
+			t.words++
+		}
+	}
+	return t
+}
+
+// Len returns the number of words of the trie.
+func (t *Trie) Len() int {
+	return t.words
+}
+
+// Mask returns s with the letters of the sensitive words it contains
+// replaced by maskRune, longest words first, and whether it contains any.
+func (t *Trie) Mask(s string) (string, bool) {
+	runes := []rune(s)
+	found := false
+	for i := 0; i < len(runes); i++ {
+		if isNoise(runes[i]) {
+			continue
+		}
+		end := t.match(runes, i)
+		if end < 0 {
+			continue
+		}
+		found = true
+		for j := i; j <= end; j++ {
+			if !isNoise(runes[j]) {
+				runes[j] = maskRune
+			}
+		}
+		i = end
+	}
+	if !found {
+		return s, false
+	}
+	return string(runes), true
+}
+
+// match returns the index of the last rune of the longest word starting at
+// runes[i], or -1 if no word starts there.
+func (t *Trie) match(runes []rune, i int) int {
+	n, end := t.root, -1
+	for j := i; j < len(runes); j++ {
+		if isNoise(runes[j]) {
+			continue
+		}
+		if n = n.children[normalize(runes[j])]; n == nil {
+			break
+		}
+		if n.end {
+			end = j
+		}
+	}
+	return end
+}
+
+// isNoise returns whether a rune is ignored when matching words.
+func isNoise(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// normalize folds the case and the width of a rune.
+func normalize(r rune) rune {
+	if r >= 0xFF01 && r <= 0xFF5E { // full-width ASCII
+		r -= 0xFEE0
+	}
+	return unicode.ToLower(r)
+}
+
+// WordRule masks or blocks the messages with sensitive words. Its words can
+// be replaced while it's used, e.g., when the word file changes (see Watch).
+type WordRule struct {
+	verdict Verdict
+	trie    atomic.Value // *Trie
+}
+
+var _ Rule = (*WordRule)(nil)
+
+// NewWordRule returns a rule without words, whose verdict on the messages
+// with sensitive words is the provided one, Mask or Block.
+func NewWordRule(verdict Verdict) *WordRule {
+	w := &WordRule{verdict: verdict}
+	w.trie.Store(NewTrie(nil))
+	return w
+}
+
+func (w *WordRule) Name() string {
+	return "words"
+}
+
+func (w *WordRule) Check(_ context.Context, content string) (string, Verdict, error) {
+	masked, found := w.trie.Load().(*Trie).Mask(content)
+	if !found {
+		return content, Pass, nil
+	}
+	return masked, w.verdict, nil
+}
+
+// SetWords replaces the words of the rule.
+func (w *WordRule) SetWords(words []string) {
+	w.trie.Store(NewTrie(words))
+}
+
+// LoadFile replaces the words of the rule with those of a file, one per line.
+// Blank lines and lines starting with '#' are skipped.
+func (w *WordRule) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("load sensitive words: %w", err)
+	}
+	defer f.Close()
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("load sensitive words: %w", err)
+	}
+	trie := NewTrie(words)
+	w.trie.Store(trie)
+	logger.Info("[filter] loaded %v sensitive words from %v", trie.Len(), path)
+	return nil
+}
+
+// Watch reloads the words of the rule when the provided file changes, until
+// the returned function is called. If the file can't be reloaded, the rule
+// keeps its words.
+func (w *WordRule) Watch(path string) (func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Editors often replace files rather than write them, so watch the
+	// directory.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	name := filepath.Clean(path)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(e.Name) != name || e.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				if err := w.LoadFile(path); err != nil {
+					logger.Error("[filter] reload %v failed: %v", path, err)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Error("[filter] watch %v failed: %v", path, err)
+			}
+		}
+	}()
+	return func() {
+		watcher.Close()
+		<-done
+	}, nil
+}
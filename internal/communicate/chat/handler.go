@@ -1,12 +1,14 @@
 package chat
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"github.com/phuhao00/greatestworks-proto/player"
 	"github.com/phuhao00/network"
 	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
 	"sync"
 )
 
@@ -41,18 +43,38 @@ func GetHandler(id messageId.MessageId) (*PrivateChatHandler, error) {
 }
 
 func HandlerChatRegister() {
-	handlers[0] = &PrivateChatHandler{
-		Id: messageId.MessageId_SCSendChatMsg,
+	handlers = append(handlers, &PrivateChatHandler{
+		Id: messageId.MessageId_CSSendChatMsg,
 		Fn: ResolvePrivateChatMsg,
-	}
+	})
 }
 
+// ResolvePrivateChatMsg sends a chat message of the player to the channel it
+// chose (see Module.Send).
 func ResolvePrivateChatMsg(p *PrivateChat, packet *network.Message) {
 	req := &player.CSSendChatMsg{}
 	err := proto.Unmarshal(packet.Data, req)
-	if err != nil {
+	if err != nil || req.Msg == nil {
+		return
+	}
+	msg := &Message{
+		Channel: Channel(req.Category),
+		From:    p.playerId,
+		To:      req.UId,
+		Content: req.Msg.Content,
+	}
+	if err := GetMod().Send(context.Background(), msg); err != nil {
+		logger.Warn("[chat] PlayerID:%v send to %v failed: %v", p.playerId, msg.Channel, err)
 		return
 	}
-	fmt.Println(req.Msg.Content)
 	p.SendMsg(messageId.MessageId_SCSendChatMsg, &player.SCSendChatMsg{})
 }
+
+// toProto returns the message pushed to the clients.
+func (msg *Message) toProto() *protocol.SCChatMsg {
+	return &protocol.SCChatMsg{
+		UId:      msg.From,
+		Category: int32(msg.Channel),
+		Content:  msg.Content,
+	}
+}
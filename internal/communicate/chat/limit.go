@@ -0,0 +1,97 @@
+package chat
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter rate limits the messages of the players with token buckets, one per
+// player and channel, and tracks the muted players.
+type limiter struct {
+	limits map[Channel]Limit
+
+	mu      sync.Mutex
+	buckets map[limitKey]*bucket // guarded by mu
+	mutes   map[uint64]time.Time // end of the mute, by player; guarded by mu
+}
+
+type limitKey struct {
+	playerId uint64
+	channel  Channel
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time // when tokens was computed
+}
+
+func newLimiter(limits map[Channel]Limit) *limiter {
+	return &limiter{
+		limits:  limits,
+		buckets: map[limitKey]*bucket{},
+		mutes:   map[uint64]time.Time{},
+	}
+}
+
+// allow returns whether the player may send a message to the channel now, and
+// if so, takes a token from its bucket.
+func (l *limiter) allow(playerId uint64, channel Channel, now time.Time) bool {
+	limit, ok := l.limits[channel]
+	if !ok || limit.Burst <= 0 || limit.Every <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := limitKey{playerId, channel}
+	b := l.buckets[key]
+	if b == nil {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens += float64(now.Sub(b.last)) / float64(limit.Every)
+	if b.tokens > float64(limit.Burst) {
+		b.tokens = float64(limit.Burst)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// forget drops the buckets of a player (e.g., when it goes offline).
+func (l *limiter) forget(playerId uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for channel := range l.limits {
+		delete(l.buckets, limitKey{playerId, channel})
+	}
+}
+
+// mute mutes a player until the provided time, or unmutes it if until is
+// zero.
+func (l *limiter) mute(playerId uint64, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.IsZero() {
+		delete(l.mutes, playerId)
+		return
+	}
+	l.mutes[playerId] = until
+}
+
+// muted returns whether the player is muted now, and until when.
+func (l *limiter) muted(playerId uint64, now time.Time) (time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until, ok := l.mutes[playerId]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !now.Before(until) {
+		delete(l.mutes, playerId)
+		return time.Time{}, false
+	}
+	return until, true
+}
@@ -1,31 +1,394 @@
 package chat
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
 	"greatestworks/aop/module_router"
+	"greatestworks/aop/redis"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/communicate/chat/filter"
+	"greatestworks/internal/protocol"
 )
 
 var (
 	Mod         *Module
 	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrMuted       = errors.New("chat: player is muted")
+	ErrRateLimited = errors.New("chat: too many messages")
+	ErrNotMember   = errors.New("chat: player is not a member of the channel")
+	ErrEmpty       = errors.New("chat: empty message")
 )
 
+var (
+	sentMessages = metrics.NewCounterMap[channelLabels](
+		"chat_sent_messages",
+		"Number of chat messages sent by the players of this server",
+	)
+	rejectedMessages = metrics.NewCounterMap[rejectLabels](
+		"chat_rejected_messages",
+		"Number of chat messages rejected before they were sent",
+	)
+)
+
+type channelLabels struct {
+	Channel string
+}
+
+type rejectLabels struct {
+	Channel string
+	Reason  string // "muted", "rate_limited", ...
+}
+
 func init() {
 	internal.ModuleManager.RegisterModule(module.Module_Chat.String(), GetMod())
 }
 
+// Module relays the chat messages of the players: to all the online players
+// (world), to the members of a guild or a team, or to a single player
+// (private). Messages are relayed to the other servers through Redis pub/sub,
+// and private messages to offline players are kept until they log in. Every
+// server keeps the recent messages of the channels.
 type Module struct {
 	*internal.BaseModule
+	initFlag    bool
+	serverId    string
+	historySize int
+	offlineSize int64
+	offlineTTL  time.Duration
+	limiter     *limiter
+	filter      *filter.Pipeline
+	stopFilter  func() // stops reloading the filter
+	stopCh      chan struct{}
+
+	mu        sync.Mutex
+	online    map[uint64]Owner               // players online on this server; guarded by mu
+	members   map[channelKey]map[uint64]bool // local members of the guild and team channels; guarded by mu
+	joined    map[uint64]map[Channel]uint64  // guild and team of the local players; guarded by mu
+	histories map[channelKey]*history        // guarded by mu
 }
 
 func GetMod() *Module {
-	Mod = &Module{internal.NewBaseModule()}
-
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
 	return Mod
 }
 
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.serverId = conf.ServerId
+	m.historySize = conf.HistorySize
+	if m.historySize <= 0 {
+		m.historySize = defaultHistorySize
+	}
+	m.offlineSize = conf.OfflineSize
+	if m.offlineSize <= 0 {
+		m.offlineSize = defaultOfflineSize
+	}
+	m.offlineTTL = conf.OfflineTTL
+	if m.offlineTTL <= 0 {
+		m.offlineTTL = defaultOfflineTTL
+	}
+	limits := make(map[Channel]Limit, len(defaultLimits))
+	for channel, limit := range defaultLimits {
+		limits[channel] = limit
+	}
+	for channel, limit := range conf.Limits {
+		limits[channel] = limit
+	}
+	m.limiter = newLimiter(limits)
+	m.filter, m.stopFilter = filter.NewPipeline(), func() {}
+	if conf.Filter != nil {
+		var err error
+		if m.filter, m.stopFilter, err = filter.New(conf.Filter); err != nil {
+			return err
+		}
+	}
+	m.online = make(map[uint64]Owner)
+	m.members = make(map[channelKey]map[uint64]bool)
+	m.joined = make(map[uint64]map[Channel]uint64)
+	m.histories = make(map[channelKey]*history)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart loads the mutes, and starts relaying the messages of the other
+// servers.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	if err := m.loadMutes(context.Background(), time.Now()); err != nil {
+		logger.Error("[chat] load mutes failed: %v", err)
+	}
+	go m.runRelay()
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		m.stopFilter()
+		close(m.stopCh)
+	}
+}
+
+// Online registers a player that logged in to this server, and delivers the
+// private messages it received while it was offline.
+func (m *Module) Online(ctx context.Context, playerId uint64, owner Owner) {
+	m.mu.Lock()
+	m.online[playerId] = owner
+	m.mu.Unlock()
+	if err := redis.Get().HSet(ctx, presenceKey, strconv.FormatUint(playerId, 10), m.serverId).Err(); err != nil {
+		logger.Error("[chat] set presence of player %v failed: %v", playerId, err)
+	}
+	msgs, err := takeOffline(ctx, playerId)
+	if err != nil {
+		logger.Error("[chat] take offline messages of player %v failed: %v", playerId, err)
+		return
+	}
+	for _, msg := range msgs {
+		owner.SendMsg(protocol.MessageId_SCChatMsg, msg.toProto())
+	}
+}
+
+// Offline unregisters a player that logged out, and removes it from its
+// channels.
+func (m *Module) Offline(ctx context.Context, playerId uint64) {
+	m.mu.Lock()
+	delete(m.online, playerId)
+	for channel, id := range m.joined[playerId] {
+		m.leaveLocked(channelKey{channel, id}, playerId)
+	}
+	delete(m.joined, playerId)
+	m.mu.Unlock()
+	m.limiter.forget(playerId)
+	if err := redis.Get().HDel(ctx, presenceKey, strconv.FormatUint(playerId, 10)).Err(); err != nil {
+		logger.Error("[chat] delete presence of player %v failed: %v", playerId, err)
+	}
+}
+
+// Join adds a local player to the channel of a guild or a team, leaving the
+// channel of its previous guild or team.
+func (m *Module) Join(channel Channel, id uint64, playerId uint64) error {
+	if !channel.scoped() {
+		return fmt.Errorf("chat: can't join channel %v", channel)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	joined := m.joined[playerId]
+	if joined == nil {
+		joined = make(map[Channel]uint64)
+		m.joined[playerId] = joined
+	}
+	if prev, ok := joined[channel]; ok {
+		m.leaveLocked(channelKey{channel, prev}, playerId)
+	}
+	joined[channel] = id
+	key := channelKey{channel, id}
+	if m.members[key] == nil {
+		m.members[key] = make(map[uint64]bool)
+	}
+	m.members[key][playerId] = true
+	return nil
+}
+
+// Leave removes a local player from the channel of its guild or team.
+func (m *Module) Leave(channel Channel, playerId uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id, ok := m.joined[playerId][channel]
+	if !ok {
+		return
+	}
+	delete(m.joined[playerId], channel)
+	m.leaveLocked(channelKey{channel, id}, playerId)
+}
+
+// REQUIRES: m.mu is held.
+func (m *Module) leaveLocked(key channelKey, playerId uint64) {
+	members := m.members[key]
+	delete(members, playerId)
+	if len(members) == 0 {
+		delete(m.members, key)
+	}
+}
+
+// Send sends a message of a player. The channel of the guild or the team of
+// the player is resolved from the channels it joined; msg.To is only read
+// for private messages. Messages of muted players, messages over the rate
+// limit of the channel, and messages blocked by the filter are rejected; the
+// content of the other messages is masked by the filter.
+func (m *Module) Send(ctx context.Context, msg *Message) error {
+	labels := channelLabels{Channel: msg.Channel.String()}
+	err := m.check(msg, time.Now())
+	if err == nil {
+		msg.Content, err = m.filter.Filter(ctx, msg.Content)
+	}
+	if err != nil {
+		reason := "invalid"
+		switch {
+		case errors.Is(err, ErrMuted):
+			reason = "muted"
+		case errors.Is(err, ErrRateLimited):
+			reason = "rate_limited"
+		case errors.Is(err, ErrNotMember):
+			reason = "not_member"
+		case errors.Is(err, filter.ErrBlocked):
+			reason = "filtered"
+		}
+		rejectedMessages.Get(rejectLabels{Channel: labels.Channel, Reason: reason}).Add(1)
+		return err
+	}
+	sentMessages.Get(labels).Add(1)
+
+	if msg.Channel != ChannelPrivate {
+		m.deliver(msg)
+		m.publish(ctx, envelope{Msg: msg})
+		return nil
+	}
+	if m.deliverPrivate(msg) {
+		return nil
+	}
+	server, err := serverOf(ctx, msg.To)
+	if err != nil {
+		return err
+	}
+	if server == "" {
+		m.storeOffline(ctx, msg)
+		return nil
+	}
+	m.publish(ctx, envelope{Msg: msg})
+	return nil
+}
+
+// check checks whether a message may be sent, and completes it.
+func (m *Module) check(msg *Message, now time.Time) error {
+	if msg.Content == "" {
+		return ErrEmpty
+	}
+	switch msg.Channel {
+	case ChannelWorld:
+		msg.To = 0
+	case ChannelGuild, ChannelTeam:
+		m.mu.Lock()
+		id, ok := m.joined[msg.From][msg.Channel]
+		m.mu.Unlock()
+		if !ok {
+			return ErrNotMember
+		}
+		msg.To = id
+	case ChannelPrivate:
+		if msg.To == 0 || msg.To == msg.From {
+			return fmt.Errorf("chat: invalid recipient %v", msg.To)
+		}
+	default:
+		return fmt.Errorf("chat: unknown channel %v", msg.Channel)
+	}
+	if until, ok := m.limiter.muted(msg.From, now); ok {
+		return fmt.Errorf("%w until %v", ErrMuted, until.Format(time.RFC3339))
+	}
+	if !m.limiter.allow(msg.From, msg.Channel, now) {
+		return ErrRateLimited
+	}
+	msg.SendTime = now.UnixMilli()
+	msg.Server = m.serverId
+	return nil
+}
+
+// deliver records a world, guild, or team message in the history of its
+// channel, and sends it to the local members of the channel.
+func (m *Module) deliver(msg *Message) {
+	key := channelKey{msg.Channel, msg.To}
+	var owners []Owner
+	m.mu.Lock()
+	h := m.histories[key]
+	if h == nil {
+		h = newHistory(m.historySize)
+		m.histories[key] = h
+	}
+	h.add(msg)
+	if msg.Channel == ChannelWorld {
+		owners = make([]Owner, 0, len(m.online))
+		for _, owner := range m.online {
+			owners = append(owners, owner)
+		}
+	} else {
+		for playerId := range m.members[key] {
+			if owner := m.online[playerId]; owner != nil {
+				owners = append(owners, owner)
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	pb := msg.toProto()
+	for _, owner := range owners {
+		owner.SendMsg(protocol.MessageId_SCChatMsg, pb)
+	}
+}
+
+// deliverPrivate sends a private message to its recipient, if it's online on
+// this server.
+func (m *Module) deliverPrivate(msg *Message) bool {
+	m.mu.Lock()
+	owner := m.online[msg.To]
+	m.mu.Unlock()
+	if owner == nil {
+		return false
+	}
+	owner.SendMsg(protocol.MessageId_SCChatMsg, msg.toProto())
+	return true
+}
+
+// History returns the recent messages of a channel, oldest first: id is the
+// guild or team id, or 0 for the world. Private messages have no history.
+func (m *Module) History(channel Channel, id uint64) []*Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := m.histories[channelKey{channel, id}]
+	if h == nil {
+		return nil
+	}
+	return h.messages()
+}
+
+// Mute mutes a player on all servers until the provided time, or unmutes it
+// if until is zero.
+func (m *Module) Mute(ctx context.Context, playerId uint64, until time.Time) error {
+	field := strconv.FormatUint(playerId, 10)
+	var err error
+	if until.IsZero() {
+		err = redis.Get().HDel(ctx, muteKey, field).Err()
+	} else {
+		err = redis.Get().HSet(ctx, muteKey, field, until.Unix()).Err()
+	}
+	if err != nil {
+		return err
+	}
+	m.limiter.mute(playerId, until)
+	mu := &mute{PlayerId: playerId}
+	if !until.IsZero() {
+		mu.Until = until.Unix()
+	}
+	m.publish(ctx, envelope{Mute: mu})
+	return nil
+}
+
 func (m *Module) GetName() string {
 	return module.Module_Chat.String()
 }
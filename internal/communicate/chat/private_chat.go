@@ -9,6 +9,7 @@ import (
 type PrivateChat struct {
 	Consumer nsq.Consumer
 	Owner
+	playerId uint64
 }
 
 func NewPrivateChat() *PrivateChat {
@@ -44,8 +45,15 @@ func (p *PrivateChat) Stop() {
 }
 
 func (p *PrivateChat) SendMsg(ID messageId.MessageId, message proto.Message) {
-	//TODO implement me
-	panic("implement me")
+	if p.Owner != nil {
+		p.Owner.SendMsg(ID, message)
+	}
+}
+
+// SetOwner sets the player whose messages are handled.
+func (p *PrivateChat) SetOwner(owner Owner, playerId uint64) {
+	p.Owner = owner
+	p.playerId = playerId
 }
 
 func (p *PrivateChat) SetHandler(handler Handler) {
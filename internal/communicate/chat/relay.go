@@ -0,0 +1,186 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+)
+
+const (
+	// relayChannel is the Redis pub/sub channel on which the servers relay
+	// the chat messages and the mutes to each other.
+	relayChannel = "chat:relay"
+
+	// presenceKey is the Redis hash of the server of the online players, by
+	// player id.
+	presenceKey = "chat:presence"
+
+	// muteKey is the Redis hash of the end of the mutes (unix seconds), by
+	// player id.
+	muteKey = "chat:mute"
+)
+
+// offlineKey returns the Redis list of the private messages kept for an
+// offline player, newest first.
+func offlineKey(playerId uint64) string {
+	return "chat:offline:" + strconv.FormatUint(playerId, 10)
+}
+
+// envelope is a message relayed to the other servers: either a chat message,
+// or the mute of a player.
+type envelope struct {
+	Server string   `json:"server"` // server relaying the message
+	Msg    *Message `json:"msg,omitempty"`
+	Mute   *mute    `json:"mute,omitempty"`
+}
+
+type mute struct {
+	PlayerId uint64 `json:"playerId"`
+	Until    int64  `json:"until"` // unix seconds, 0 to unmute
+}
+
+// publish relays a message to the other servers.
+func (m *Module) publish(ctx context.Context, e envelope) {
+	e.Server = m.serverId
+	b, err := json.Marshal(e)
+	if err != nil {
+		logger.Error("[chat] marshal %+v failed: %v", e, err)
+		return
+	}
+	if err := redis.Get().Publish(ctx, relayChannel, b).Err(); err != nil {
+		logger.Error("[chat] publish failed: %v", err)
+	}
+}
+
+// runRelay handles the messages relayed by the other servers, until the
+// module is stopped.
+func (m *Module) runRelay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := redis.Get().Subscribe(ctx, relayChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var e envelope
+			if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+				logger.Error("[chat] unmarshal %q failed: %v", msg.Payload, err)
+				continue
+			}
+			if e.Server == m.serverId {
+				continue
+			}
+			m.receive(ctx, e)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// receive handles a message relayed by another server.
+func (m *Module) receive(ctx context.Context, e envelope) {
+	if e.Mute != nil {
+		var until time.Time
+		if e.Mute.Until > 0 {
+			until = time.Unix(e.Mute.Until, 0)
+		}
+		m.limiter.mute(e.Mute.PlayerId, until)
+		return
+	}
+	if e.Msg == nil {
+		return
+	}
+	if e.Msg.Channel != ChannelPrivate {
+		m.deliver(e.Msg)
+		return
+	}
+	// The recipient may have gone offline since the sender looked it up.
+	if !m.deliverPrivate(e.Msg) {
+		m.storeOffline(ctx, e.Msg)
+	}
+}
+
+// serverOf returns the server the provided player is online on, or "" if the
+// player is offline.
+func serverOf(ctx context.Context, playerId uint64) (string, error) {
+	server, err := redis.Get().HGet(ctx, presenceKey, strconv.FormatUint(playerId, 10)).Result()
+	if err == goredis.Nil {
+		return "", nil
+	}
+	return server, err
+}
+
+// storeOffline keeps a private message for its offline recipient, until it
+// logs in (see Module.Online).
+func (m *Module) storeOffline(ctx context.Context, msg *Message) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		logger.Error("[chat] marshal %+v failed: %v", msg, err)
+		return
+	}
+	key := offlineKey(msg.To)
+	_, err = redis.Get().TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.LPush(ctx, key, b)
+		pipe.LTrim(ctx, key, 0, m.offlineSize-1)
+		pipe.Expire(ctx, key, m.offlineTTL)
+		return nil
+	})
+	if err != nil {
+		logger.Error("[chat] store offline message for player %v failed: %v", msg.To, err)
+	}
+}
+
+// takeOffline returns the private messages kept for a player, oldest first,
+// and drops them.
+func takeOffline(ctx context.Context, playerId uint64) ([]*Message, error) {
+	key := offlineKey(playerId)
+	var vals *goredis.StringSliceCmd
+	_, err := redis.Get().TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		vals = pipe.LRange(ctx, key, 0, -1)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	raws := vals.Val()
+	msgs := make([]*Message, 0, len(raws))
+	for i := len(raws) - 1; i >= 0; i-- {
+		msg := &Message{}
+		if err := json.Unmarshal([]byte(raws[i]), msg); err != nil {
+			logger.Error("[chat] unmarshal offline message of player %v failed: %v", playerId, err)
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// loadMutes loads the mutes that haven't ended yet.
+func (m *Module) loadMutes(ctx context.Context, now time.Time) error {
+	vals, err := redis.Get().HGetAll(ctx, muteKey).Result()
+	if err != nil {
+		return err
+	}
+	for field, val := range vals {
+		playerId, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		until, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || until <= now.Unix() {
+			continue
+		}
+		m.limiter.mute(playerId, time.Unix(until, 0))
+	}
+	return nil
+}
@@ -1,5 +1,7 @@
 package chat
 
+import "github.com/nsqio/go-nsq"
+
 type SystemMsgHandler struct {
 	Consumer *nsq.Consumer
 	Handler
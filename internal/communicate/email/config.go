@@ -0,0 +1,56 @@
+package email
+
+import "time"
+
+const (
+	defaultMaxMails       = 100
+	defaultDailySendLimit = 20
+	defaultMailTTL        = 30 * 24 * time.Hour
+	defaultPurgeInterval  = time.Hour
+)
+
+const (
+	timersTick       = time.Second
+	timersKey        = "{mail:timers}" // hash tag: the timers and their payloads are in the same slot
+	globalExpiryKind = "mail.global"   // expiry of a global mail, keyed by its id
+)
+
+// ModuleConfig is the config of the email module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ServerId       string        // id of this server, which labels the notifications it relays to the other servers
+	MaxMails       int           // number of mails a mailbox keeps, oldest dropped first, defaults to defaultMaxMails
+	DailySendLimit int16         // number of mails a player may send a day, defaults to defaultDailySendLimit
+	MailTTL        time.Duration // how long mails without an expiry are kept, defaults to defaultMailTTL
+	PurgeInterval  time.Duration // how often expired mails are purged, defaults to defaultPurgeInterval
+}
+
+// Profile is what selects the global mails a player receives (see
+// Segment).
+type Profile struct {
+	Level   uint32
+	RegTime int64 // unix seconds
+}
+
+// Segment selects the players a global mail is sent to. The zero Segment
+// selects all players.
+type Segment struct {
+	MinLevel  uint32 // 0 for no minimum
+	MaxLevel  uint32 // 0 for no maximum
+	RegBefore int64  // players registered before, unix seconds; 0 for all
+}
+
+// contains returns whether the segment selects the player of the provided
+// profile.
+func (s Segment) contains(p Profile) bool {
+	if s.MinLevel > 0 && p.Level < s.MinLevel {
+		return false
+	}
+	if s.MaxLevel > 0 && p.Level > s.MaxLevel {
+		return false
+	}
+	if s.RegBefore > 0 && p.RegTime >= s.RegBefore {
+		return false
+	}
+	return true
+}
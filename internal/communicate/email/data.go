@@ -1,6 +1,11 @@
 package email
 
-import "sync"
+import (
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+)
 
 type MailM struct {
 	Id     uint64 `bson:"id"`
@@ -15,46 +20,46 @@ const (
 	MailStatusUnRead MailStatus = iota + 1
 	MailStatusRead
 	MailStatusDelete
+	MailStatusClaimed // the attachments were claimed
 )
 
-type Data struct {
-	mails sync.Map
-}
-
+// Owner is the player a mailbox belongs to.
 type Owner interface {
-	AddMail(mail IEmail)
-	ReadMail()
-	DelMail()
-	DelAllMail()
-	GetMailItem()
-	GetAllMailItems()
+	SendMsg(ID messageId.MessageId, message proto.Message)
 }
 
-func (o *Data) AddMail(mail IEmail) {
-	o.mails.Store(mail.GetID, mail)
-}
+// Data is the mailbox of an online player. The mails themselves are in the
+// db, and updated there (see Module).
+type Data struct {
+	uid     uint64
+	profile Profile
+	Owner
 
-func (o *Data) ReadMail() {
-	//TODO implement me
-	panic("implement me")
+	mu     sync.Mutex
+	unread int // guarded by mu
 }
 
-func (o *Data) DelMail() {
-	//TODO implement me
-	panic("implement me")
+func NewData() *Data {
+	return &Data{}
 }
 
-func (o *Data) DelAllMail() {
-	//TODO implement me
-	panic("implement me")
+// SetOwner sets the player the mailbox belongs to, and the profile that
+// selects the global mails it receives.
+func (o *Data) SetOwner(owner Owner, uid uint64, profile Profile) {
+	o.Owner = owner
+	o.uid = uid
+	o.profile = profile
 }
 
-func (o *Data) GetMailItem() {
-	//TODO implement me
-	panic("implement me")
+// Unread returns the number of unread mails, as of the last change.
+func (o *Data) Unread() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.unread
 }
 
-func (o *Data) GetAllMailItems() {
-	//TODO implement me
-	panic("implement me")
+func (o *Data) setUnread(n int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.unread = n
 }
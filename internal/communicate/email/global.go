@@ -0,0 +1,180 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/redis"
+	"greatestworks/aop/timewheel"
+)
+
+// relayChannel is the Redis pub/sub channel on which the servers notify the
+// players online on other servers of new mails.
+const relayChannel = "mail:relay"
+
+// Kinds of notifications.
+const (
+	notifyNew    = "new"    // the targets received mails
+	notifyGlobal = "global" // a global mail was sent
+)
+
+// globalRepo is the repository of the global mails, of schema version 0 (see
+// mongo.Repo).
+var globalRepo = mongo.NewRepo[mongo.GlobalMail](0)
+
+type notification struct {
+	Server  string   `json:"server"`
+	Kind    string   `json:"kind"`
+	Targets []uint64 `json:"targets,omitempty"`
+}
+
+// SendGlobal sends a mail to all the players of a segment (e.g., a level
+// range), including the players who are offline: they receive it when they
+// log in, until it expires, when it's purged. The players online receive it
+// now.
+func (m *Module) SendGlobal(ctx context.Context, info *mongo.MailInfo, seg Segment) error {
+	if err := m.complete(info, time.Now()); err != nil {
+		return err
+	}
+	global := &mongo.GlobalMail{
+		Info:      *info,
+		MinLevel:  seg.MinLevel,
+		MaxLevel:  seg.MaxLevel,
+		RegBefore: seg.RegBefore,
+		SendTime:  info.MTime,
+	}
+	if err := globalRepo.Insert(ctx, global); err != nil {
+		return fmt.Errorf("send global mail: %w", err)
+	}
+	expiry := timewheel.Durable{
+		Kind: globalExpiryKind,
+		Key:  strconv.FormatUint(info.MUuid, 10),
+		At:   time.Unix(info.Expire, 0),
+	}
+	if err := m.timers.Schedule(ctx, expiry); err != nil {
+		// The periodic purge purges it instead.
+		logger.Error("[mail] schedule expiry of global mail %v failed: %v", info.MUuid, err)
+	}
+	m.notify(ctx, notification{Kind: notifyGlobal})
+	return nil
+}
+
+// deliverGlobal delivers to an online player the global mails of its
+// segment that it hasn't received yet.
+func (m *Module) deliverGlobal(ctx context.Context, d *Data) error {
+	now := time.Now().Unix()
+	mails, err := globalRepo.Find(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"info.exp": 0},
+			bson.M{"info.exp": bson.M{"$gt": now}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("find global mails: %w", err)
+	}
+	for _, g := range mails {
+		seg := Segment{MinLevel: g.MinLevel, MaxLevel: g.MaxLevel, RegBefore: g.RegBefore}
+		if !seg.contains(d.profile) {
+			continue
+		}
+		info := g.Info
+		if _, err := pushMail(ctx, d.uid, &info, m.maxMails); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeGlobal deletes the expired global mails.
+func purgeGlobal(ctx context.Context, now time.Time) error {
+	_, err := globalRepo.Delete(ctx, bson.M{"info.exp": bson.M{"$gt": 0, "$lte": now.Unix()}})
+	return err
+}
+
+// expireGlobal purges the global mails expired, once the expiry timer of
+// one of them fires. It's idempotent, as the timers may fire more than once.
+func (m *Module) expireGlobal(d timewheel.Durable) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := purgeGlobal(ctx, time.Now()); err != nil {
+		logger.Error("[mail] purge global mail %v failed: %v", d.Key, err)
+	}
+}
+
+// notify notifies the online players of new mails, wherever they're online.
+func (m *Module) notify(ctx context.Context, n notification) {
+	n.Server = m.serverId
+	m.deliverNotification(ctx, n)
+	b, err := json.Marshal(n)
+	if err != nil {
+		logger.Error("[mail] marshal %+v failed: %v", n, err)
+		return
+	}
+	if err := redis.Get().Publish(ctx, relayChannel, b).Err(); err != nil {
+		logger.Error("[mail] publish failed: %v", err)
+	}
+}
+
+// deliverNotification delivers a notification to the players online on this
+// server.
+func (m *Module) deliverNotification(ctx context.Context, n notification) {
+	var targets []*Data
+	m.mu.Lock()
+	switch n.Kind {
+	case notifyNew:
+		for _, uid := range n.Targets {
+			if d := m.online[uid]; d != nil {
+				targets = append(targets, d)
+			}
+		}
+	case notifyGlobal:
+		for _, d := range m.online {
+			targets = append(targets, d)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, d := range targets {
+		if n.Kind == notifyGlobal {
+			if err := m.deliverGlobal(ctx, d); err != nil {
+				logger.Error("[mail] deliver global mails to PlayerID:%v err:%v", d.uid, err)
+			}
+		}
+		m.refresh(ctx, d)
+	}
+}
+
+// runRelay delivers the notifications of the other servers, until the module
+// is stopped.
+func (m *Module) runRelay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := redis.Get().Subscribe(ctx, relayChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var n notification
+			if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+				logger.Error("[mail] unmarshal %q failed: %v", msg.Payload, err)
+				continue
+			}
+			if n.Server == m.serverId {
+				continue
+			}
+			m.deliverNotification(ctx, n)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
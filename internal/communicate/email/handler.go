@@ -1,9 +1,13 @@
 package email
 
 import (
+	"context"
 	"errors"
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
 	"sync"
 )
 
@@ -34,21 +38,60 @@ func GetHandler(id messageId.MessageId) (*Handler, error) {
 
 func init() {
 	onceInit.Do(func() {
-		handlers[0] = &Handler{
-			0,
-			readMail,
-		}
-		handlers[1] = &Handler{
-			1,
-			deleteMail,
-		}
+		handlers = append(handlers,
+			&Handler{
+				protocol.MessageId_CSReadMail,
+				readMail,
+			},
+			&Handler{
+				protocol.MessageId_CSDelMail,
+				deleteMail,
+			},
+			&Handler{
+				protocol.MessageId_CSClaimMail,
+				claimMail,
+			},
+		)
 	})
 }
 
 func readMail(player IPlayer, message *network.Message) {
-
+	req := &protocol.CSReadMail{}
+	if err := proto.Unmarshal(message.Data, req); err != nil {
+		return
+	}
+	d := player.GetEmailData()
+	if err := GetMod().Read(context.Background(), d.uid, req.Uuid); err != nil {
+		logger.Warn("[mail] PlayerID:%v read %v failed: %v", d.uid, req.Uuid, err)
+	}
 }
 
 func deleteMail(player IPlayer, message *network.Message) {
+	req := &protocol.CSDelMail{}
+	if err := proto.Unmarshal(message.Data, req); err != nil {
+		return
+	}
+	d := player.GetEmailData()
+	if err := GetMod().Delete(context.Background(), d.uid, req.Uuid); err != nil {
+		logger.Warn("[mail] PlayerID:%v delete %v failed: %v", d.uid, req.Uuid, err)
+	}
+}
 
+// claimMail claims the attachments of a mail, or of all the mails if the id
+// is 0.
+func claimMail(player IPlayer, message *network.Message) {
+	req := &protocol.CSClaimMail{}
+	if err := proto.Unmarshal(message.Data, req); err != nil {
+		return
+	}
+	d := player.GetEmailData()
+	var err error
+	if req.Uuid == 0 {
+		_, err = GetMod().ClaimAll(context.Background(), d.uid)
+	} else {
+		err = GetMod().Claim(context.Background(), d.uid, req.Uuid)
+	}
+	if err != nil {
+		logger.Warn("[mail] PlayerID:%v claim %v failed: %v", d.uid, req.Uuid, err)
+	}
 }
@@ -0,0 +1,151 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"greatestworks/aop/mongo"
+)
+
+// The mailbox of a player is a mongo.MailSystem document, updated with
+// conditional updates, so that mails can be delivered to a player from any
+// server while it reads and claims them.
+
+// mailboxRepo is the repository of the mailboxes, of schema version 0 (see
+// mongo.Repo).
+var mailboxRepo = mongo.NewRepo[mongo.MailSystem](0)
+
+func mailboxes() *mongodriver.Collection {
+	return mailboxRepo.Collection()
+}
+
+// ensureMailbox creates the mailbox of a player, if it has none.
+func ensureMailbox(ctx context.Context, uid uint64) error {
+	_, err := mailboxes().UpdateOne(ctx,
+		bson.M{mongo.PrimaryKey: uid},
+		bson.M{"$setOnInsert": bson.M{
+			mongo.PrimaryKey:  uid,
+			"normal":          bson.A{},
+			"history":         bson.A{},
+			mongo.SchemaField: mailboxRepo.Version(),
+		}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// loadMailbox returns the mailbox of a player, which is empty if it has no
+// document yet.
+func loadMailbox(ctx context.Context, uid uint64) (*mongo.MailSystem, error) {
+	doc, err := mailboxRepo.Get(ctx, bson.M{mongo.PrimaryKey: uid})
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return &mongo.MailSystem{OwnerID: uid}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load mailbox of player %v: %w", uid, err)
+	}
+	return doc, nil
+}
+
+// pushMail delivers a mail to the mailbox of a player, unless the player has,
+// or had, the mail. The oldest mails are dropped beyond maxMails. It returns
+// whether the mail was delivered.
+func pushMail(ctx context.Context, uid uint64, info *mongo.MailInfo, maxMails int) (bool, error) {
+	if err := ensureMailbox(ctx, uid); err != nil {
+		return false, err
+	}
+	res, err := mailboxes().UpdateOne(ctx,
+		bson.M{
+			mongo.PrimaryKey: uid,
+			"normal.uid":     bson.M{"$ne": info.MUuid},
+			"history":        bson.M{"$ne": info.MUuid},
+		},
+		bson.M{"$push": bson.M{"normal": bson.M{"$each": bson.A{info}, "$slice": -maxMails}}})
+	if err != nil {
+		return false, fmt.Errorf("deliver mail %v to player %v: %w", info.MUuid, uid, err)
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// setStatus sets the status of a mail of a player, if its status is one of
+// from. It returns whether the status was set.
+func setStatus(ctx context.Context, uid, mUid uint64, from []MailStatus, to MailStatus) (bool, error) {
+	res, err := mailboxes().UpdateOne(ctx,
+		bson.M{
+			mongo.PrimaryKey: uid,
+			"normal": bson.M{"$elemMatch": bson.M{
+				"uid":  mUid,
+				"stat": bson.M{"$in": from},
+			}},
+		},
+		bson.M{"$set": bson.M{"normal.$.stat": to}})
+	if err != nil {
+		return false, fmt.Errorf("set status of mail %v of player %v: %w", mUid, uid, err)
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// pullMail removes a mail from the mailbox of a player, and records it in the
+// history, so that it isn't delivered again.
+func pullMail(ctx context.Context, uid, mUid uint64) (bool, error) {
+	res, err := mailboxes().UpdateOne(ctx,
+		bson.M{mongo.PrimaryKey: uid, "normal.uid": mUid},
+		bson.M{
+			"$pull":     bson.M{"normal": bson.M{"uid": mUid}},
+			"$addToSet": bson.M{"history": mUid},
+		})
+	if err != nil {
+		return false, fmt.Errorf("delete mail %v of player %v: %w", mUid, uid, err)
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// purgeExpired removes the expired mails of all the mailboxes, or of the
+// mailbox of the provided player if uid isn't 0, and returns the number of
+// mailboxes purged. Expired mails aren't recorded in the history: they
+// expire for good.
+func purgeExpired(ctx context.Context, uid uint64, now time.Time) (int64, error) {
+	expired := bson.M{"exp": bson.M{"$gt": 0, "$lte": now.Unix()}}
+	filter := bson.M{"normal": bson.M{"$elemMatch": expired}}
+	if uid != 0 {
+		filter[mongo.PrimaryKey] = uid
+	}
+	res, err := mailboxes().UpdateMany(ctx, filter, bson.M{"$pull": bson.M{"normal": expired}})
+	if err != nil {
+		return 0, fmt.Errorf("purge expired mails: %w", err)
+	}
+	return res.ModifiedCount, nil
+}
+
+// findMail returns a mail of a mailbox, or nil.
+func findMail(doc *mongo.MailSystem, mUid uint64) *mongo.MailInfo {
+	for i := range doc.Normal {
+		if doc.Normal[i].MUuid == mUid {
+			return &doc.Normal[i]
+		}
+	}
+	return nil
+}
+
+// hasAttachments returns whether a mail has items or currencies attached.
+func hasAttachments(info *mongo.MailInfo) bool {
+	return len(info.MItems) > 0 || len(info.Currency) > 0
+}
+
+// countUnread returns the number of unread mails of a mailbox.
+func countUnread(doc *mongo.MailSystem, now time.Time) int {
+	n := 0
+	for _, info := range doc.Normal {
+		if info.Expire > 0 && info.Expire <= now.Unix() {
+			continue
+		}
+		if MailStatus(info.MStatus) == MailStatusUnRead || info.MStatus == 0 {
+			n++
+		}
+	}
+	return n
+}
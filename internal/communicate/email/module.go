@@ -1,31 +1,398 @@
 package email
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
 	"greatestworks/aop/module_router"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/redis"
+	"greatestworks/aop/saga"
+	"greatestworks/aop/timewheel"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/protocol"
 )
 
 var (
 	Mod         *Module
 	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNoMail         = errors.New("mail: no such mail")
+	ErrNoAttachments  = errors.New("mail: no attachments")
+	ErrAlreadyClaimed = errors.New("mail: attachments already claimed")
+	ErrUnclaimed      = errors.New("mail: attachments not claimed")
+	ErrSendLimit      = errors.New("mail: too many mails sent today")
 )
 
 func init() {
 	internal.ModuleManager.RegisterModule(module.Module_Email.String(), GetMod())
 }
 
+// Attachments moves the attachments of the mails in and out of the
+// possessions of the players (e.g., their bag and their wallet).
+type Attachments interface {
+	// Grant gives the attachments of a claimed mail to a player.
+	Grant(ctx context.Context, playerId uint64, items []mongo.MailItem, currencies []mongo.MailCurrency) error
+	// Take takes the attachments of a mail from its sender.
+	Take(ctx context.Context, playerId uint64, items []mongo.MailItem, currencies []mongo.MailCurrency) error
+}
+
+// Module delivers the mails to the mailboxes of the players: mails sent by
+// the system (e.g., rewards) or by other players, to a player or to all the
+// players of a segment (see SendGlobal). The attachments of a mail are
+// claimed at most once. Mails expire, and are purged periodically; global
+// mails are purged once they expire, by durable timers that outlive a restart.
 type Module struct {
 	*internal.BaseModule
+	initFlag       bool
+	serverId       string
+	maxMails       int
+	dailySendLimit int16
+	mailTTL        time.Duration
+	purgeInterval  time.Duration
+	attachments    Attachments
+	timers         *timewheel.Wheel
+	stopCh         chan struct{}
+
+	mu     sync.Mutex
+	online map[uint64]*Data // mailboxes of the players online on this server; guarded by mu
 }
 
 func GetMod() *Module {
-	Mod = &Module{internal.NewBaseModule()}
-
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
 	return Mod
 }
 
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.serverId = conf.ServerId
+	m.maxMails = conf.MaxMails
+	if m.maxMails <= 0 {
+		m.maxMails = defaultMaxMails
+	}
+	m.dailySendLimit = conf.DailySendLimit
+	if m.dailySendLimit <= 0 {
+		m.dailySendLimit = defaultDailySendLimit
+	}
+	m.mailTTL = conf.MailTTL
+	if m.mailTTL <= 0 {
+		m.mailTTL = defaultMailTTL
+	}
+	m.purgeInterval = conf.PurgeInterval
+	if m.purgeInterval <= 0 {
+		m.purgeInterval = defaultPurgeInterval
+	}
+	m.timers = timewheel.New(timewheel.Options{
+		Name:  "mail",
+		Tick:  timersTick,
+		Store: timewheel.NewRedisStore(redis.Get(), timersKey),
+	})
+	m.timers.Handle(globalExpiryKind, m.expireGlobal)
+	m.online = make(map[uint64]*Data)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts delivering the notifications of the other servers, and
+// purging the expired mails.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if n, err := m.timers.Restore(ctx); err != nil {
+		logger.Error("[mail] restore timers failed: %v", err)
+	} else if n > 0 {
+		logger.Info("[mail] restored %v timers", n)
+	}
+	cancel()
+	go m.runRelay()
+	go m.runPurge()
+	go m.runTimers()
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+	}
+}
+
+// SetAttachments sets how the attachments are given to and taken from the
+// players. It must be called before the module starts.
+func (m *Module) SetAttachments(a Attachments) {
+	m.attachments = a
+}
+
+// Online registers the mailbox of a player that logged in to this server:
+// its expired mails are purged, it receives the global mails of its segment,
+// and its number of unread mails is pushed.
+func (m *Module) Online(ctx context.Context, d *Data) error {
+	m.mu.Lock()
+	m.online[d.uid] = d
+	m.mu.Unlock()
+	if _, err := purgeExpired(ctx, d.uid, time.Now()); err != nil {
+		logger.Error("[mail] purge PlayerID:%v err:%v", d.uid, err)
+	}
+	if err := m.deliverGlobal(ctx, d); err != nil {
+		return err
+	}
+	m.refresh(ctx, d)
+	return nil
+}
+
+// Offline unregisters the mailbox of a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
+}
+
+// refresh recounts the unread mails of an online player, and pushes the
+// count.
+func (m *Module) refresh(ctx context.Context, d *Data) {
+	doc, err := loadMailbox(ctx, d.uid)
+	if err != nil {
+		logger.Error("[mail] refresh PlayerID:%v err:%v", d.uid, err)
+		return
+	}
+	n := countUnread(doc, time.Now())
+	d.setUnread(n)
+	if d.Owner != nil {
+		d.SendMsg(protocol.MessageId_SCMailUnread, &protocol.SCMailUnread{Count: int32(n)})
+	}
+}
+
+// complete sets the id, the send time, and the expiry of a mail, if unset.
+func (m *Module) complete(info *mongo.MailInfo, now time.Time) error {
+	if info.MUuid == 0 {
+		id, err := idgenerator.NextId()
+		if err != nil {
+			return fmt.Errorf("mail id: %w", err)
+		}
+		info.MUuid = id
+	}
+	if info.MTime == 0 {
+		info.MTime = now.Unix()
+	}
+	if info.Expire == 0 {
+		info.Expire = now.Add(m.mailTTL).Unix()
+	}
+	if info.MStatus == 0 {
+		info.MStatus = uint32(MailStatusUnRead)
+	}
+	return nil
+}
+
+// Send delivers a mail to a player, who may be offline. The mail is
+// identified by its MUuid, generated if 0: delivering a mail that the player
+// has, or had, is a no-op.
+func (m *Module) Send(ctx context.Context, to uint64, info *mongo.MailInfo) error {
+	if err := m.complete(info, time.Now()); err != nil {
+		return err
+	}
+	delivered, err := pushMail(ctx, to, info, m.maxMails)
+	if err != nil {
+		return err
+	}
+	if delivered {
+		m.notify(ctx, notification{Kind: notifyNew, Targets: []uint64{to}})
+	}
+	return nil
+}
+
+// SendPlayerMail sends a mail from a player to another. Its attachments are
+// taken from the sender first, and given back if the mail can't be
+// delivered. A mail with a MUuid is sent once, however many times it's
+// retried. Players send up to ModuleConfig.DailySendLimit mails a day.
+func (m *Module) SendPlayerMail(ctx context.Context, from, to uint64, info *mongo.MailInfo) error {
+	now := time.Now()
+	key := fmt.Sprintf("mail:sent:%d:%s", from, now.Format("20060102"))
+	sent, err := redis.Get().Incr(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	redis.Get().Expire(ctx, key, 25*time.Hour)
+	if sent > int64(m.dailySendLimit) {
+		return ErrSendLimit
+	}
+
+	info.MSender = from
+	s := &saga.Saga{Name: "mail_send"}
+	if info.MUuid != 0 {
+		// A mail is delivered once: so are its attachments taken.
+		s.Key = fmt.Sprintf("mail:send:%d:%d", from, info.MUuid)
+	}
+	if hasAttachments(info) {
+		if m.attachments == nil {
+			return fmt.Errorf("mail: attachments unsupported")
+		}
+		s.Step("take", func(ctx context.Context) error {
+			return m.attachments.Take(ctx, from, info.MItems, info.Currency)
+		}, func(ctx context.Context) error {
+			return m.attachments.Grant(ctx, from, info.MItems, info.Currency)
+		})
+	}
+	s.Step("send", func(ctx context.Context) error {
+		return m.Send(ctx, to, info)
+	}, nil)
+	if err := saga.Get().Run(ctx, s); err != nil && !errors.Is(err, saga.ErrDone) {
+		return err
+	}
+	return nil
+}
+
+// Read marks a mail of a player as read.
+func (m *Module) Read(ctx context.Context, uid, mUid uint64) error {
+	ok, err := setStatus(ctx, uid, mUid, []MailStatus{0, MailStatusUnRead}, MailStatusRead)
+	if err != nil {
+		return err
+	}
+	if ok {
+		m.notify(ctx, notification{Kind: notifyNew, Targets: []uint64{uid}})
+	}
+	return nil
+}
+
+// Claim gives the attachments of a mail to a player. The mail is marked as
+// claimed before the attachments are given, so that they're given at most
+// once, however many times the player claims them; if they can't be given,
+// the mail is marked as read again, and may be claimed again.
+func (m *Module) Claim(ctx context.Context, uid, mUid uint64) error {
+	doc, err := loadMailbox(ctx, uid)
+	if err != nil {
+		return err
+	}
+	info := findMail(doc, mUid)
+	switch {
+	case info == nil || info.Expire > 0 && info.Expire <= time.Now().Unix():
+		return ErrNoMail
+	case !hasAttachments(info):
+		return ErrNoAttachments
+	case m.attachments == nil:
+		return fmt.Errorf("mail: attachments unsupported")
+	}
+	s := (&saga.Saga{Name: "mail_claim"}).
+		Step("claim", func(ctx context.Context) error {
+			ok, err := setStatus(ctx, uid, mUid, []MailStatus{0, MailStatusUnRead, MailStatusRead}, MailStatusClaimed)
+			if err == nil && !ok {
+				err = ErrAlreadyClaimed
+			}
+			return err
+		}, func(ctx context.Context) error {
+			_, err := setStatus(ctx, uid, mUid, []MailStatus{MailStatusClaimed}, MailStatusRead)
+			return err
+		}).
+		Step("grant", func(ctx context.Context) error {
+			return m.attachments.Grant(ctx, uid, info.MItems, info.Currency)
+		}, nil)
+	if err := saga.Get().Run(ctx, s); err != nil {
+		return err
+	}
+	m.notify(ctx, notification{Kind: notifyNew, Targets: []uint64{uid}})
+	return nil
+}
+
+// ClaimAll claims the attachments of all the mails of a player, and returns
+// the number of mails claimed.
+func (m *Module) ClaimAll(ctx context.Context, uid uint64) (int, error) {
+	doc, err := loadMailbox(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for i := range doc.Normal {
+		info := &doc.Normal[i]
+		if !hasAttachments(info) || MailStatus(info.MStatus) == MailStatusClaimed {
+			continue
+		}
+		err := m.Claim(ctx, uid, info.MUuid)
+		if errors.Is(err, ErrAlreadyClaimed) || errors.Is(err, ErrNoMail) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Delete deletes a mail of a player. Mails whose attachments weren't claimed
+// can't be deleted.
+func (m *Module) Delete(ctx context.Context, uid, mUid uint64) error {
+	doc, err := loadMailbox(ctx, uid)
+	if err != nil {
+		return err
+	}
+	info := findMail(doc, mUid)
+	if info == nil {
+		return ErrNoMail
+	}
+	if hasAttachments(info) && MailStatus(info.MStatus) != MailStatusClaimed {
+		return ErrUnclaimed
+	}
+	if _, err := pullMail(ctx, uid, mUid); err != nil {
+		return err
+	}
+	m.notify(ctx, notification{Kind: notifyNew, Targets: []uint64{uid}})
+	return nil
+}
+
+// runPurge purges the expired mails periodically, until the module is
+// stopped.
+func (m *Module) runPurge() {
+	ticker := time.NewTicker(m.purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), m.purgeInterval)
+			n, err := purgeExpired(ctx, 0, now)
+			if err != nil {
+				logger.Error("[mail] purge failed: %v", err)
+			} else if n > 0 {
+				logger.Info("[mail] purged the expired mails of %v mailboxes", n)
+			}
+			if err := purgeGlobal(ctx, now); err != nil {
+				logger.Error("[mail] purge global mails failed: %v", err)
+			}
+			cancel()
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// runTimers fires the timers of the module due, until the module is stopped.
+// They're not fired by the frame scheduler, as they wait on the database.
+func (m *Module) runTimers() {
+	ticker := time.NewTicker(timersTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			m.timers.Advance(now)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
 func (m *Module) GetName() string {
 	return module.Module_Email.String()
 }
@@ -13,11 +13,7 @@ type EventWrap struct {
 }
 
 func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
-	//TODO implement me
-	panic("implement me")
 }
 
 func (m *Module) SetEventCategoryActive(eventCategory int) {
-	//TODO implement me
-	panic("implement me")
 }
@@ -0,0 +1,14 @@
+package email
+
+import (
+	"context"
+
+	"greatestworks/aop/mongo"
+)
+
+// SendSystemMail delivers a system mail (e.g., a reward) to the mailbox of a
+// player, who may be offline. The mail is identified by its MUuid: delivering
+// a mail that the player already has, or had, is a no-op.
+func SendSystemMail(ctx context.Context, playerId uint64, info *mongo.MailInfo) error {
+	return GetMod().Send(ctx, playerId, info)
+}
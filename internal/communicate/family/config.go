@@ -1,40 +1,84 @@
 package family
 
-type Config struct {
-}
+import (
+	"time"
+
+	"greatestworks/internal/purchase/currency"
+)
 
-type MemberPosition int
+// MemberPosition is the position of a member in its family. Lower positions
+// rank higher.
+type MemberPosition int32
 
 const (
 	Leader MemberPosition = iota + 1
+	Elder
 	Normal
 )
 
-type MemberAction int
+// Permission is what the members of a position may do.
+type Permission string
 
 const (
-	RemoveMember MemberAction = iota + 1
-	RewardGIft
+	PermInvite   Permission = "invite"   // invite players
+	PermApprove  Permission = "approve"  // approve or reject the join requests
+	PermKick     Permission = "kick"     // kick out the members of lower positions
+	PermAppoint  Permission = "appoint"  // change the positions of the members of lower positions, up to theirs excluded
+	PermNotice   Permission = "notice"   // edit the notice and the join settings
+	PermWithdraw Permission = "withdraw" // withdraw from the treasury
 )
 
-type RemoveMemberActionParam struct {
-	OpMemberId uint64
-	RemoveIds  []uint64
-	Reason     string
+// PositionConf configures a position.
+type PositionConf struct {
+	Position    MemberPosition `json:"position"`
+	Name        string         `json:"name"`
+	Permissions []Permission   `json:"permissions"`
+	Max         int            `json:"max"` // most members of the position, 0 for no limit
 }
 
-type RewardGiftActionParam struct {
-	OpMemberId uint64
-	RemoveIds  []uint64
-	ItemIds    map[uint32]uint64
+// LevelConf configures a level of the families.
+type LevelConf struct {
+	Exp        int64 `json:"exp"`        // experience since creation to reach the level
+	MaxMembers int   `json:"maxMembers"` // most members
 }
 
-type MemberActionParam struct {
-	Action MemberAction
-	Info   interface{}
+// ContributionRate is the contribution points per unit of a currency donated
+// to the treasury.
+type ContributionRate struct {
+	Currency currency.Type `json:"currency"`
+	Points   int64         `json:"points"`
 }
 
-type ManagerHandlerParam struct {
-	FamilyId          uint64
-	MemberActionParam *MemberActionParam
+// ModuleConfig is the config of the family module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ServerId      string             // id of this server, which tells its notifications from those of the other servers
+	Positions     []PositionConf     // the Leader has every permission, whatever its config
+	Levels        []LevelConf        // by level, from level 1, with increasing Exp
+	CreateCost    []currency.Amount  // price of the creation of a family
+	CreateLevel   uint32             // least player level to create a family
+	MaxNameLen    int                // most runes in the name of a family
+	MaxRequests   int                // most pending join requests, and invites, per family
+	Contributions []ContributionRate // currencies that may be donated, and what they're worth
+	Retries       int                // most attempts of an update conflicting with concurrent ones
 }
+
+const (
+	defaultMaxNameLen  = 12
+	defaultMaxRequests = 50
+	defaultRetries     = 5
+	defaultMaxMembers  = 30
+
+	// requestTTL is how long join requests and invites stay pending.
+	requestTTL = 7 * 24 * time.Hour
+)
+
+var defaultPositions = []PositionConf{
+	{Position: Leader, Name: "leader", Max: 1},
+	{Position: Elder, Name: "elder", Max: 4, Permissions: []Permission{PermInvite, PermApprove, PermKick, PermNotice, PermWithdraw}},
+	{Position: Normal, Name: "member", Permissions: []Permission{PermInvite}},
+}
+
+var defaultLevels = []LevelConf{{Exp: 0, MaxMembers: defaultMaxMembers}}
+
+var defaultContributions = []ContributionRate{{Currency: currency.Gold, Points: 1}}
@@ -1,4 +0,0 @@
-package family
-
-type Model struct {
-}
@@ -1,39 +1,65 @@
 package family
 
-type Family struct {
-	Id       uint64
-	Name     string
-	Desc     string
-	members  map[uint64]*Member
-	requests []interface{}
-	ChIn     chan *MemberActionParam
-	ChOut    chan interface{}
-}
+import (
+	"time"
 
-func (f *Family) Start() {
-	//TODO implement me
-	panic("implement me")
-}
+	"greatestworks/aop/mongo"
+)
 
-func (f *Family) Stop() {
-	//TODO implement me
-	panic("implement me")
+// member returns a member of a family, or nil.
+func member(f *mongo.Family, uid uint64) *mongo.FamilyMember {
+	for i := range f.Members {
+		if f.Members[i].Id == uid {
+			return &f.Members[i]
+		}
+	}
+	return nil
 }
 
-func (f *Family) AddMember(member *Member) {
-	f.members[member.Id] = member
+// removeMember removes a member of a family, and returns whether it was one.
+func removeMember(f *mongo.Family, uid uint64) bool {
+	for i := range f.Members {
+		if f.Members[i].Id == uid {
+			f.Members = append(f.Members[:i], f.Members[i+1:]...)
+			return true
+		}
+	}
+	return false
 }
 
-func (f *Family) DelMember(ids []uint64) {
-	for _, id := range ids {
-		delete(f.members, id)
+// memberIds returns the ids of the members of a family.
+func memberIds(f *mongo.Family) []uint64 {
+	ids := make([]uint64, 0, len(f.Members))
+	for _, mb := range f.Members {
+		ids = append(ids, mb.Id)
 	}
+	return ids
 }
 
-func (f *Family) UpdateMember(member *Member) {
-	f.members[member.Id] = member
+// countPosition returns the number of members of a position.
+func countPosition(f *mongo.Family, pos MemberPosition) int {
+	n := 0
+	for _, mb := range f.Members {
+		if MemberPosition(mb.Position) == pos {
+			n++
+		}
+	}
+	return n
 }
 
-func (f *Family) GetMember(id uint64) *Member {
-	return f.members[id]
+// takeRequest removes the pending request (or invite) of a player from
+// requests, dropping the expired ones, and returns whether it was there.
+func takeRequest(requests *[]mongo.FamilyRequest, uid uint64, now time.Time) bool {
+	found := false
+	kept := (*requests)[:0]
+	for _, r := range *requests {
+		switch {
+		case r.PlayerId == uid:
+			found = found || now.Sub(time.Unix(r.Time, 0)) < requestTTL
+		case now.Sub(time.Unix(r.Time, 0)) < requestTTL:
+			kept = append(kept, r)
+		}
+	}
+	*requests = kept
+	return found
 }
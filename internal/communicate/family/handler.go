@@ -1,2 +1,220 @@
 package family
 
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
+	"greatestworks/internal/purchase/currency"
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player IPlayer, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerFamilyRegister()
+	})
+}
+
+func HandlerFamilyRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSFamilyCreate, Create},
+		&Handler{protocol.MessageId_CSFamilyDisband, Disband},
+		&Handler{protocol.MessageId_CSFamilyApply, Apply},
+		&Handler{protocol.MessageId_CSFamilyApprove, Approve},
+		&Handler{protocol.MessageId_CSFamilyInvite, Invite},
+		&Handler{protocol.MessageId_CSFamilyAcceptInvite, AcceptInvite},
+		&Handler{protocol.MessageId_CSFamilyLeave, Leave},
+		&Handler{protocol.MessageId_CSFamilyKick, Kick},
+		&Handler{protocol.MessageId_CSFamilyAppoint, Appoint},
+		&Handler{protocol.MessageId_CSFamilyTransfer, Transfer},
+		&Handler{protocol.MessageId_CSFamilySetting, Setting},
+		&Handler{protocol.MessageId_CSFamilyDonate, Donate},
+		&Handler{protocol.MessageId_CSFamilyWithdraw, Withdraw},
+		&Handler{protocol.MessageId_CSFamilyInfo, Info},
+	)
+}
+
+// reply sends the result of an operation.
+func reply(p IPlayer, op protocol.FamilyOp, err error) {
+	if err != nil {
+		logger.Warn("[family] %v PlayerID:%v err:%v", op, p.GetUId(), err)
+	}
+	p.SendMsg(protocol.MessageId_SCFamilyResult, resultToProto(op, err))
+}
+
+// Create creates a family.
+func Create(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilyCreate{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	m := GetMod()
+	f, err := m.Create(context.Background(), p, req.Name)
+	reply(p, protocol.FamilyOp_FamilyOpCreate, err)
+	if err == nil {
+		p.SendMsg(protocol.MessageId_SCFamilyInfo, m.toProto(f))
+	}
+}
+
+// Disband disbands the family of its leader.
+func Disband(p IPlayer, packet *network.Message) {
+	err := GetMod().Disband(context.Background(), p.GetUId())
+	reply(p, protocol.FamilyOp_FamilyOpDisband, err)
+}
+
+// Apply applies to join a family.
+func Apply(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilyApply{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Apply(context.Background(), p, req.FamilyId)
+	reply(p, protocol.FamilyOp_FamilyOpApply, err)
+}
+
+// Approve approves, or rejects, a join request.
+func Approve(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilyApprove{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Approve(context.Background(), p.GetUId(), req.PlayerId, req.Accept)
+	reply(p, protocol.FamilyOp_FamilyOpApprove, err)
+}
+
+// Invite invites a player to the family.
+func Invite(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilyInvite{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Invite(context.Background(), p.GetUId(), req.PlayerId)
+	reply(p, protocol.FamilyOp_FamilyOpInvite, err)
+}
+
+// AcceptInvite accepts the invite of a family.
+func AcceptInvite(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilyAcceptInvite{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().AcceptInvite(context.Background(), p, req.FamilyId)
+	reply(p, protocol.FamilyOp_FamilyOpAcceptInvite, err)
+}
+
+// Leave leaves the family.
+func Leave(p IPlayer, packet *network.Message) {
+	err := GetMod().Leave(context.Background(), p.GetUId())
+	reply(p, protocol.FamilyOp_FamilyOpLeave, err)
+}
+
+// Kick kicks a member out of the family.
+func Kick(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilyKick{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Kick(context.Background(), p.GetUId(), req.PlayerId)
+	reply(p, protocol.FamilyOp_FamilyOpKick, err)
+}
+
+// Appoint changes the position of a member.
+func Appoint(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilyAppoint{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Appoint(context.Background(), p.GetUId(), req.PlayerId, MemberPosition(req.Position))
+	reply(p, protocol.FamilyOp_FamilyOpAppoint, err)
+}
+
+// Transfer hands the leadership over to a member.
+func Transfer(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilyTransfer{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().TransferLeader(context.Background(), p.GetUId(), req.PlayerId)
+	reply(p, protocol.FamilyOp_FamilyOpTransfer, err)
+}
+
+// Setting sets the notice and the join settings of the family.
+func Setting(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilySetting{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().SetSettings(context.Background(), p.GetUId(), req.Notice, req.AutoJoin, req.MinLevel)
+	reply(p, protocol.FamilyOp_FamilyOpSetting, err)
+}
+
+// Donate donates to the treasury.
+func Donate(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilyDonate{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Donate(context.Background(), p.GetUId(), currency.Type(req.Currency), req.Amount)
+	reply(p, protocol.FamilyOp_FamilyOpDonate, err)
+}
+
+// Withdraw withdraws from the treasury.
+func Withdraw(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilyWithdraw{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Withdraw(context.Background(), p.GetUId(), currency.Type(req.Currency), req.Amount)
+	reply(p, protocol.FamilyOp_FamilyOpWithdraw, err)
+}
+
+// Info sends a family: the family of the player, or another one.
+func Info(p IPlayer, packet *network.Message) {
+	req := &protocol.CSFamilyInfo{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	m := GetMod()
+	ctx := context.Background()
+	id := req.FamilyId
+	if id == 0 {
+		var err error
+		if id, err = familyOfPlayer(ctx, p.GetUId()); err != nil {
+			reply(p, protocol.FamilyOp_FamilyOpInfo, err)
+			return
+		}
+	}
+	f, err := loadFamily(ctx, id)
+	if err != nil {
+		reply(p, protocol.FamilyOp_FamilyOpInfo, err)
+		return
+	}
+	if member(f, p.GetUId()) == nil {
+		// Only the members see the requests and the treasury.
+		f.Requests, f.Treasury = nil, nil
+	}
+	p.SendMsg(protocol.MessageId_SCFamilyInfo, m.toProto(f))
+}
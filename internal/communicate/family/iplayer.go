@@ -1,4 +1,14 @@
 package family
 
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+)
+
+// IPlayer is the player handling the messages of the families.
 type IPlayer interface {
+	GetUId() uint64
+	GetName() string
+	GetLevel() uint32
+	SendMsg(ID messageId.MessageId, message proto.Message)
 }
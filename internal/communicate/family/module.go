@@ -1,56 +1,258 @@
 package family
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
 	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
 	"greatestworks/aop/module_router"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/communicate/chat"
+	"greatestworks/internal/protocol"
+	"greatestworks/internal/purchase/currency"
 )
 
 var (
 	Mod         *Module
 	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
 )
 
+var (
+	ErrNoFamily        = errors.New("family: no such family")
+	ErrInFamily        = errors.New("family: already in a family")
+	ErrNotInFamily     = errors.New("family: not in a family")
+	ErrNotMember       = errors.New("family: not a member of the family")
+	ErrName            = errors.New("family: invalid name")
+	ErrNameTaken       = errors.New("family: name taken")
+	ErrLevel           = errors.New("family: level too low")
+	ErrPermission      = errors.New("family: permission denied")
+	ErrFull            = errors.New("family: family full")
+	ErrPositionFull    = errors.New("family: position full")
+	ErrPosition        = errors.New("family: invalid position")
+	ErrLeaderLeave     = errors.New("family: the leader can't leave the family")
+	ErrNoRequest       = errors.New("family: no such request or invite")
+	ErrTooManyRequests = errors.New("family: too many pending requests")
+	ErrDonation        = errors.New("family: currency can't be donated")
+	ErrTreasury        = errors.New("family: not enough in the treasury")
+	ErrConflict        = errors.New("family: too many concurrent updates")
+)
+
+var (
+	conflicts = metrics.NewCounter(
+		"family_update_conflicts",
+		"Number of family updates retried because of concurrent updates",
+	)
+	donations = metrics.NewCounterMap[currencyLabels](
+		"family_donations",
+		"Amount of currency donated to the treasuries of the families",
+	)
+)
+
+type currencyLabels struct {
+	Currency string
+}
+
 func init() {
 	internal.ModuleManager.RegisterModule(module.Module_Family.String(), GetMod())
 }
 
+// Module manages the families (guilds): their members and positions, their
+// join requests and invites, their treasury and their level. The families
+// are shared by all the servers: each change is saved at once, and the
+// servers notify each other of the changes (see notify), so that the
+// members online anywhere see them.
 type Module struct {
 	*internal.BaseModule
-	families map[uint64]*Family
-	IWorld
-	ChIn  chan ManagerHandlerParam
-	ChOut chan interface{}
+	initFlag      bool
+	serverId      string
+	positions     map[MemberPosition]*PositionConf
+	levels        []LevelConf
+	createCost    []currency.Amount
+	createLevel   uint32
+	maxNameLen    int
+	maxRequests   int
+	contributions map[currency.Type]int64
+	retries       int
+	stopCh        chan struct{}
+
+	mu     sync.Mutex
+	online map[uint64]IPlayer // guarded by mu
+	local  map[uint64]uint64  // families of the online players, by player; guarded by mu
 }
 
 func GetMod() *Module {
-	Mod = &Module{BaseModule: internal.NewBaseModule()}
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
 	return Mod
 }
 
-func (m *Module) Reload() {
-	for {
-		select {
-		case msg := <-m.ChOut:
-			m.ForwardMsg(msg)
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.serverId = conf.ServerId
+	positions := conf.Positions
+	if len(positions) == 0 {
+		positions = defaultPositions
+	}
+	m.positions = make(map[MemberPosition]*PositionConf, len(positions))
+	for i := range positions {
+		p := &positions[i]
+		if p.Position < Leader {
+			return fmt.Errorf("family: invalid position %d", p.Position)
+		}
+		m.positions[p.Position] = p
+	}
+	if m.positions[Leader] == nil || m.positions[Normal] == nil {
+		return fmt.Errorf("family: positions Leader and Normal must be configured")
+	}
+	m.levels = conf.Levels
+	if len(m.levels) == 0 {
+		m.levels = defaultLevels
+	}
+	for i := 1; i < len(m.levels); i++ {
+		if m.levels[i].Exp <= m.levels[i-1].Exp {
+			return fmt.Errorf("family: level %d: experience not increasing", i+1)
 		}
 	}
+	m.createCost = conf.CreateCost
+	m.createLevel = conf.CreateLevel
+	m.maxNameLen = conf.MaxNameLen
+	if m.maxNameLen <= 0 {
+		m.maxNameLen = defaultMaxNameLen
+	}
+	m.maxRequests = conf.MaxRequests
+	if m.maxRequests <= 0 {
+		m.maxRequests = defaultMaxRequests
+	}
+	contributions := conf.Contributions
+	if len(contributions) == 0 {
+		contributions = defaultContributions
+	}
+	m.contributions = make(map[currency.Type]int64, len(contributions))
+	for _, c := range contributions {
+		m.contributions[c.Currency] = c.Points
+	}
+	m.retries = conf.Retries
+	if m.retries <= 0 {
+		m.retries = defaultRetries
+	}
+	m.online = make(map[uint64]IPlayer)
+	m.local = make(map[uint64]uint64)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts delivering the notifications of the other servers.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	go m.runRelay()
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+	}
+}
+
+// Online registers a player that logged in to this server: it joins the
+// chat channel of its family, and its family is pushed.
+func (m *Module) Online(ctx context.Context, p IPlayer) error {
+	uid := p.GetUId()
+	id, err := familyOf(ctx, uid)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.online[uid] = p
+	if id != 0 {
+		m.local[uid] = id
+	}
+	m.mu.Unlock()
+	if id == 0 {
+		return nil
+	}
+	if err := chat.GetMod().Join(chat.ChannelGuild, id, uid); err != nil {
+		logger.Error("[family] join chat of family %v PlayerID:%v err:%v", id, uid, err)
+	}
+	f, err := loadFamily(ctx, id)
+	if err != nil {
+		return err
+	}
+	p.SendMsg(protocol.MessageId_SCFamilyInfo, m.toProto(f))
+	return nil
+}
+
+// Offline unregisters a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
+	delete(m.local, uid)
 }
 
-func (m *Module) Init() {
-	for {
-		select {
-		case param := <-m.ChIn:
-			m.Handler(param)
+// can returns whether the members of a position have a permission. The
+// leader has every permission.
+func (m *Module) can(pos MemberPosition, perm Permission) bool {
+	if pos == Leader {
+		return true
+	}
+	conf := m.positions[pos]
+	if conf == nil {
+		return false
+	}
+	for _, p := range conf.Permissions {
+		if p == perm {
+			return true
 		}
 	}
+	return false
+}
+
+// levelOf returns the level of a family with some experience.
+func (m *Module) levelOf(exp int64) int32 {
+	i := sort.Search(len(m.levels), func(i int) bool { return m.levels[i].Exp > exp })
+	if i == 0 {
+		return 1
+	}
+	return int32(i)
+}
+
+// maxMembers returns the most members of a family of a level.
+func (m *Module) maxMembers(level int32) int {
+	if level < 1 {
+		level = 1
+	}
+	if int(level) > len(m.levels) {
+		level = int32(len(m.levels))
+	}
+	if n := m.levels[level-1].MaxMembers; n > 0 {
+		return n
+	}
+	return defaultMaxMembers
 }
 
 func (m *Module) GetName() string {
 	return module.Module_Family.String()
 }
 
+// Dependencies returns the modules the family module depends on: the
+// members chat in the channel of their family.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Chat.String()}
+}
+
 func (m *Module) RegisterHandler() {
 	module_router.RegisterModuleMessageHandler(module.Module_Family, 0, nil)
 }
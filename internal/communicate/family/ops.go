@@ -0,0 +1,584 @@
+package family
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"greatestworks/aop/dlock"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/saga"
+	"greatestworks/internal/communicate/friend"
+	"greatestworks/internal/note/event/familyevent"
+	"greatestworks/internal/purchase/currency"
+)
+
+// Reasons of the changes of the wallets made by the families.
+const (
+	reasonCreate   = "family_create"
+	reasonDonate   = "family_donate"
+	reasonWithdraw = "family_withdraw"
+)
+
+// self returns a player as a member of a family, or ErrNotMember.
+func self(f *mongo.Family, uid uint64) (*mongo.FamilyMember, error) {
+	mb := member(f, uid)
+	if mb == nil {
+		return nil, ErrNotMember
+	}
+	return mb, nil
+}
+
+// familyOfPlayer returns the id of the family of a player, or ErrNotInFamily.
+func familyOfPlayer(ctx context.Context, uid uint64) (uint64, error) {
+	id, err := familyOf(ctx, uid)
+	if err == nil && id == 0 {
+		err = ErrNotInFamily
+	}
+	return id, err
+}
+
+// Create creates a family led by a player, for ModuleConfig.CreateCost.
+//
+// The creations of a name are serialized across the servers: a creation
+// failing after it claimed the name releases it, and a concurrent creation
+// of the name waits for the outcome instead of failing with ErrNameTaken.
+func (m *Module) Create(ctx context.Context, p IPlayer, name string) (*mongo.Family, error) {
+	uid := p.GetUId()
+	name = strings.TrimSpace(name)
+	switch {
+	case p.GetLevel() < m.createLevel:
+		return nil, ErrLevel
+	case name == "" || utf8.RuneCountInString(name) > m.maxNameLen:
+		return nil, ErrName
+	}
+	lock, err := dlock.Get().Lock(ctx, "family:name:"+name)
+	if err != nil {
+		return nil, err
+	}
+	// The unique index of the names still guards them if the lock is lost:
+	// the steps below run in ctx, not in the context of the lock, so that
+	// they aren't canceled halfway.
+	defer lock.Unlock()
+	id, err := idgenerator.NextId()
+	if err != nil {
+		return nil, fmt.Errorf("family id: %w", err)
+	}
+	ref := "family:" + strconv.FormatUint(id, 10)
+	now := time.Now().Unix()
+	f := &mongo.Family{
+		Id:     id,
+		Name:   name,
+		Leader: uid,
+		Level:  1,
+		Members: []mongo.FamilyMember{{
+			Id:       uid,
+			Name:     p.GetName(),
+			Position: int32(Leader),
+			JoinTime: now,
+		}},
+		Requests:  []mongo.FamilyRequest{},
+		Invites:   []mongo.FamilyRequest{},
+		Treasury:  map[string]int64{},
+		CreatedAt: now,
+	}
+	s := (&saga.Saga{Name: "family_create"}).
+		Step("membership", func(ctx context.Context) error {
+			return claimMembership(ctx, uid, id)
+		}, func(ctx context.Context) error {
+			return releaseMembership(ctx, uid, id)
+		}).
+		Step("name", func(ctx context.Context) error {
+			_, err := names().InsertOne(ctx, &mongo.FamilyName{Name: name, FamilyId: id})
+			if mongodriver.IsDuplicateKeyError(err) {
+				err = ErrNameTaken
+			}
+			return err
+		}, func(ctx context.Context) error {
+			_, err := names().DeleteOne(ctx, bson.M{"_id": name, "fid": id})
+			return err
+		})
+	if len(m.createCost) > 0 {
+		s.Step("spend", func(ctx context.Context) error {
+			return currency.Spend(ctx, uid, m.createCost, reasonCreate, ref)
+		}, func(ctx context.Context) error {
+			return currency.Add(ctx, uid, m.createCost, reasonCreate, ref)
+		})
+	}
+	s.Step("insert", func(ctx context.Context) error {
+		if err := familyRepo.Insert(ctx, f); err != nil {
+			return fmt.Errorf("create family: %w", err)
+		}
+		return nil
+	}, nil)
+	if err := saga.Get().Run(ctx, s); err != nil {
+		return nil, err
+	}
+	eventbus.Publish(eventbus.Default, familyevent.Created{FamilyId: id, Leader: uid})
+	m.joined(ctx, f, uid)
+	return f, nil
+}
+
+// Disband disbands the family led by a player. The treasury is lost.
+func (m *Module) Disband(ctx context.Context, uid uint64) error {
+	id, err := familyOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	f, err := loadFamily(ctx, id)
+	if err != nil {
+		return err
+	}
+	if f.Leader != uid {
+		return ErrPermission
+	}
+	res, err := families().DeleteOne(ctx, bson.M{"_id": id, "ver": f.Version})
+	if err != nil {
+		return fmt.Errorf("disband family %v: %w", id, err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrConflict
+	}
+	if _, err := memberships().DeleteMany(ctx, bson.M{"fid": id}); err != nil {
+		logger.Error("[family] release memberships of family %v err:%v", id, err)
+	}
+	if _, err := names().DeleteOne(ctx, bson.M{"_id": f.Name, "fid": id}); err != nil {
+		logger.Error("[family] release name of family %v err:%v", id, err)
+	}
+	eventbus.Publish(eventbus.Default, familyevent.Disbanded{FamilyId: id})
+	m.notify(ctx, notification{Kind: notifyLeft, FamilyId: id, Targets: memberIds(f)})
+	return nil
+}
+
+// join adds a player to a family, once accept, which removes its request or
+// invite, succeeds. The membership of the player is claimed first, so that
+// it never joins two families at once.
+func (m *Module) join(ctx context.Context, id, uid uint64, name string, accept func(f *mongo.Family) error) (*mongo.Family, error) {
+	if err := claimMembership(ctx, uid, id); err != nil {
+		return nil, err
+	}
+	f, err := m.update(ctx, id, func(f *mongo.Family) error {
+		if err := accept(f); err != nil {
+			return err
+		}
+		if len(f.Members) >= m.maxMembers(f.Level) {
+			return ErrFull
+		}
+		f.Members = append(f.Members, mongo.FamilyMember{
+			Id:       uid,
+			Name:     name,
+			Position: int32(Normal),
+			JoinTime: time.Now().Unix(),
+		})
+		return nil
+	})
+	if err != nil {
+		if releaseErr := releaseMembership(ctx, uid, id); releaseErr != nil {
+			logger.Error("[family] PlayerID:%v err:%v", uid, releaseErr)
+		}
+		return nil, err
+	}
+	m.joined(ctx, f, uid)
+	return f, nil
+}
+
+// joined publishes that a player joined a family, and notifies the members.
+func (m *Module) joined(ctx context.Context, f *mongo.Family, uid uint64) {
+	eventbus.Publish(eventbus.Default, familyevent.Joined{FamilyId: f.Id, PlayerId: uid, Members: len(f.Members)})
+	if err := friend.GetMod().Met(ctx, uid, memberIds(f)...); err != nil {
+		logger.Warn("[family] met members of family %v PlayerID:%v err:%v", f.Id, uid, err)
+	}
+	m.notify(ctx, notification{Kind: notifyJoined, FamilyId: f.Id, Targets: []uint64{uid}})
+}
+
+// Apply applies for a player to join a family. It joins at once if the
+// family accepts everyone (see SetSettings).
+func (m *Module) Apply(ctx context.Context, p IPlayer, id uint64) error {
+	uid := p.GetUId()
+	if cur, err := familyOf(ctx, uid); err != nil {
+		return err
+	} else if cur != 0 {
+		return ErrInFamily
+	}
+	f, err := loadFamily(ctx, id)
+	if err != nil {
+		return err
+	}
+	if p.GetLevel() < f.MinLevel {
+		return ErrLevel
+	}
+	if f.AutoJoin {
+		_, err := m.join(ctx, id, uid, p.GetName(), func(f *mongo.Family) error { return nil })
+		return err
+	}
+	now := time.Now()
+	f, err = m.update(ctx, id, func(f *mongo.Family) error {
+		takeRequest(&f.Requests, uid, now)
+		if len(f.Requests) >= m.maxRequests {
+			return ErrTooManyRequests
+		}
+		f.Requests = append(f.Requests, mongo.FamilyRequest{PlayerId: uid, Name: p.GetName(), Time: now.Unix()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, FamilyId: id})
+	return nil
+}
+
+// Approve approves, or rejects, the join request of a player to the family
+// of an officer.
+func (m *Module) Approve(ctx context.Context, uid, applicant uint64, accept bool) error {
+	id, err := familyOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	var name string
+	check := func(f *mongo.Family) error {
+		op, err := self(f, uid)
+		if err != nil {
+			return err
+		}
+		if !m.can(MemberPosition(op.Position), PermApprove) {
+			return ErrPermission
+		}
+		for _, r := range f.Requests {
+			if r.PlayerId == applicant {
+				name = r.Name
+			}
+		}
+		if !takeRequest(&f.Requests, applicant, time.Now()) {
+			return ErrNoRequest
+		}
+		return nil
+	}
+	if !accept {
+		if _, err := m.update(ctx, id, check); err != nil {
+			return err
+		}
+		m.notify(ctx, notification{Kind: notifyChanged, FamilyId: id})
+		return nil
+	}
+	_, err = m.join(ctx, id, applicant, name, check)
+	if err == ErrInFamily {
+		// Joined another family since: drop the request.
+		if _, dropErr := m.update(ctx, id, check); dropErr == nil {
+			m.notify(ctx, notification{Kind: notifyChanged, FamilyId: id})
+		}
+	}
+	return err
+}
+
+// Invite invites a player to the family of a member.
+func (m *Module) Invite(ctx context.Context, uid, target uint64) error {
+	id, err := familyOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if cur, err := familyOf(ctx, target); err != nil {
+		return err
+	} else if cur != 0 {
+		return ErrInFamily
+	}
+	now := time.Now()
+	f, err := m.update(ctx, id, func(f *mongo.Family) error {
+		op, err := self(f, uid)
+		if err != nil {
+			return err
+		}
+		if !m.can(MemberPosition(op.Position), PermInvite) {
+			return ErrPermission
+		}
+		takeRequest(&f.Invites, target, now)
+		if len(f.Invites) >= m.maxRequests {
+			return ErrTooManyRequests
+		}
+		f.Invites = append(f.Invites, mongo.FamilyRequest{PlayerId: target, From: uid, Time: now.Unix()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.notify(ctx, notification{Kind: notifyInvited, FamilyId: id, Targets: []uint64{target}, From: uid, Name: f.Name})
+	return nil
+}
+
+// AcceptInvite accepts the invite of a family to a player.
+func (m *Module) AcceptInvite(ctx context.Context, p IPlayer, id uint64) error {
+	uid := p.GetUId()
+	_, err := m.join(ctx, id, uid, p.GetName(), func(f *mongo.Family) error {
+		if !takeRequest(&f.Invites, uid, time.Now()) {
+			return ErrNoRequest
+		}
+		return nil
+	})
+	return err
+}
+
+// Leave removes a player from its family. The leader must transfer the
+// leadership first, or disband the family.
+func (m *Module) Leave(ctx context.Context, uid uint64) error {
+	id, err := familyOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	f, err := m.update(ctx, id, func(f *mongo.Family) error {
+		if f.Leader == uid {
+			return ErrLeaderLeave
+		}
+		if !removeMember(f, uid) {
+			return ErrNotMember
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.left(ctx, f, uid, false)
+	return nil
+}
+
+// Kick kicks a member of a lower position out of the family of an officer.
+func (m *Module) Kick(ctx context.Context, uid, target uint64) error {
+	id, err := familyOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	f, err := m.update(ctx, id, func(f *mongo.Family) error {
+		op, err := self(f, uid)
+		if err != nil {
+			return err
+		}
+		mb := member(f, target)
+		if mb == nil {
+			return ErrNotMember
+		}
+		if !m.can(MemberPosition(op.Position), PermKick) || mb.Position <= op.Position {
+			return ErrPermission
+		}
+		removeMember(f, target)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.left(ctx, f, target, true)
+	return nil
+}
+
+// left releases the membership of a player that left a family, and notifies
+// it and the members.
+func (m *Module) left(ctx context.Context, f *mongo.Family, uid uint64, kicked bool) {
+	if err := releaseMembership(ctx, uid, f.Id); err != nil {
+		logger.Error("[family] PlayerID:%v err:%v", uid, err)
+	}
+	eventbus.Publish(eventbus.Default, familyevent.Left{FamilyId: f.Id, PlayerId: uid, Kicked: kicked, Members: len(f.Members)})
+	m.notify(ctx, notification{Kind: notifyLeft, FamilyId: f.Id, Targets: []uint64{uid}, Kicked: kicked})
+}
+
+// Appoint changes the position of a member of a lower position than an
+// officer, to a position lower than the officer's.
+func (m *Module) Appoint(ctx context.Context, uid, target uint64, pos MemberPosition) error {
+	id, err := familyOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if pos == Leader || m.positions[pos] == nil {
+		return ErrPosition
+	}
+	_, err = m.update(ctx, id, func(f *mongo.Family) error {
+		op, err := self(f, uid)
+		if err != nil {
+			return err
+		}
+		mb := member(f, target)
+		if mb == nil {
+			return ErrNotMember
+		}
+		opPos := MemberPosition(op.Position)
+		if !m.can(opPos, PermAppoint) || MemberPosition(mb.Position) <= opPos || pos <= opPos {
+			return ErrPermission
+		}
+		if limit := m.positions[pos].Max; limit > 0 && countPosition(f, pos) >= limit {
+			return ErrPositionFull
+		}
+		mb.Position = int32(pos)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, FamilyId: id})
+	return nil
+}
+
+// TransferLeader hands the leadership of a family over to a member; the
+// leader takes the position of the member.
+func (m *Module) TransferLeader(ctx context.Context, uid, target uint64) error {
+	id, err := familyOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	_, err = m.update(ctx, id, func(f *mongo.Family) error {
+		if f.Leader != uid {
+			return ErrPermission
+		}
+		op, mb := member(f, uid), member(f, target)
+		if op == nil || mb == nil || target == uid {
+			return ErrNotMember
+		}
+		op.Position, mb.Position = mb.Position, int32(Leader)
+		f.Leader = target
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, FamilyId: id})
+	return nil
+}
+
+// SetSettings sets the notice of the family of an officer, whether it
+// accepts the join requests at once, and the least level to apply.
+func (m *Module) SetSettings(ctx context.Context, uid uint64, notice string, autoJoin bool, minLevel uint32) error {
+	id, err := familyOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	_, err = m.update(ctx, id, func(f *mongo.Family) error {
+		op, err := self(f, uid)
+		if err != nil {
+			return err
+		}
+		if !m.can(MemberPosition(op.Position), PermNotice) {
+			return ErrPermission
+		}
+		f.Notice = notice
+		f.AutoJoin = autoJoin
+		f.MinLevel = minLevel
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, FamilyId: id})
+	return nil
+}
+
+// Donate donates an amount of a currency of a member to the treasury of its
+// family. The member contributes the points of the currency (see
+// ModuleConfig.Contributions) per unit, which the family gains as
+// experience.
+func (m *Module) Donate(ctx context.Context, uid uint64, cur currency.Type, amount int64) error {
+	rate, ok := m.contributions[cur]
+	if !ok || amount <= 0 {
+		return ErrDonation
+	}
+	id, err := familyOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	price := []currency.Amount{{Type: cur, Num: amount}}
+	ref := "family:" + strconv.FormatUint(id, 10)
+	points := amount * rate
+	var before int32
+	var f *mongo.Family
+	s := (&saga.Saga{Name: "family_donate"}).
+		Step("spend", func(ctx context.Context) error {
+			return currency.Spend(ctx, uid, price, reasonDonate, ref)
+		}, func(ctx context.Context) error {
+			return currency.Add(ctx, uid, price, reasonDonate, ref)
+		}).
+		Step("donate", func(ctx context.Context) error {
+			var err error
+			f, err = m.update(ctx, id, func(f *mongo.Family) error {
+				mb, err := self(f, uid)
+				if err != nil {
+					return err
+				}
+				if f.Treasury == nil {
+					f.Treasury = map[string]int64{}
+				}
+				f.Treasury[treasuryKey(cur)] += amount
+				mb.Contribution += points
+				before = f.Level
+				f.Exp += points
+				f.Level = m.levelOf(f.Exp)
+				return nil
+			})
+			return err
+		}, nil)
+	if err := saga.Get().Run(ctx, s); err != nil {
+		return err
+	}
+	donations.Get(currencyLabels{Currency: cur.String()}).Add(float64(amount))
+	eventbus.Publish(eventbus.Default, familyevent.Contributed{FamilyId: id, PlayerId: uid, Points: points, Exp: f.Exp, Level: f.Level})
+	if f.Level > before {
+		eventbus.Publish(eventbus.Default, familyevent.LevelUp{FamilyId: id, Level: f.Level})
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, FamilyId: id})
+	return nil
+}
+
+// Withdraw withdraws an amount of a currency from the treasury of the family
+// of an officer, to the officer.
+func (m *Module) Withdraw(ctx context.Context, uid uint64, cur currency.Type, amount int64) error {
+	if amount <= 0 {
+		return ErrTreasury
+	}
+	id, err := familyOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	key := treasuryKey(cur)
+	ref := "family:" + strconv.FormatUint(id, 10)
+	s := (&saga.Saga{Name: "family_withdraw"}).
+		Step("withdraw", func(ctx context.Context) error {
+			_, err := m.update(ctx, id, func(f *mongo.Family) error {
+				op, err := self(f, uid)
+				if err != nil {
+					return err
+				}
+				if !m.can(MemberPosition(op.Position), PermWithdraw) {
+					return ErrPermission
+				}
+				if f.Treasury[key] < amount {
+					return ErrTreasury
+				}
+				f.Treasury[key] -= amount
+				return nil
+			})
+			return err
+		}, func(ctx context.Context) error {
+			_, err := m.update(ctx, id, func(f *mongo.Family) error {
+				if f.Treasury == nil {
+					f.Treasury = map[string]int64{}
+				}
+				f.Treasury[key] += amount
+				return nil
+			})
+			return err
+		}).
+		Step("add", func(ctx context.Context) error {
+			return currency.Add(ctx, uid, []currency.Amount{{Type: cur, Num: amount}}, reasonWithdraw, ref)
+		}, nil)
+	if err := saga.Get().Run(ctx, s); err != nil {
+		return err
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, FamilyId: id})
+	return nil
+}
+
+// treasuryKey returns the key of a currency in the treasuries.
+func treasuryKey(cur currency.Type) string {
+	return strconv.Itoa(int(cur))
+}
@@ -0,0 +1,45 @@
+package family
+
+import (
+	"strconv"
+
+	"greatestworks/aop/mongo"
+	"greatestworks/internal/protocol"
+)
+
+func (m *Module) toProto(f *mongo.Family) *protocol.SCFamilyInfo {
+	pb := &protocol.SCFamilyInfo{
+		Id:         f.Id,
+		Name:       f.Name,
+		Notice:     f.Notice,
+		Leader:     f.Leader,
+		Level:      f.Level,
+		Exp:        f.Exp,
+		AutoJoin:   f.AutoJoin,
+		MinLevel:   f.MinLevel,
+		MaxMembers: int32(m.maxMembers(f.Level)),
+		Treasury:   map[int32]int64{},
+	}
+	for _, mb := range f.Members {
+		pb.Members = append(pb.Members, &protocol.FamilyMember{
+			Id:           mb.Id,
+			Name:         mb.Name,
+			Position:     mb.Position,
+			Contribution: mb.Contribution,
+			JoinTime:     mb.JoinTime,
+		})
+	}
+	for _, r := range f.Requests {
+		pb.Requests = append(pb.Requests, &protocol.FamilyRequest{PlayerId: r.PlayerId, Name: r.Name, Time: r.Time})
+	}
+	for k, v := range f.Treasury {
+		if cur, err := strconv.Atoi(k); err == nil {
+			pb.Treasury[int32(cur)] = v
+		}
+	}
+	return pb
+}
+
+func resultToProto(op protocol.FamilyOp, err error) *protocol.SCFamilyResult {
+	return &protocol.SCFamilyResult{Op: op, Ok: err == nil}
+}
@@ -0,0 +1,133 @@
+package family
+
+import (
+	"context"
+	"encoding/json"
+
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+	"greatestworks/internal/communicate/chat"
+	"greatestworks/internal/protocol"
+)
+
+// relayChannel is the Redis pub/sub channel on which the servers notify each
+// other of the changes of the families.
+const relayChannel = "family:relay"
+
+// Kinds of notifications.
+const (
+	notifyJoined  = "joined"  // the targets joined the family
+	notifyLeft    = "left"    // the targets left the family, or it was disbanded
+	notifyChanged = "changed" // the family changed
+	notifyInvited = "invited" // the targets were invited to the family
+)
+
+type notification struct {
+	Server   string   `json:"server"`
+	Kind     string   `json:"kind"`
+	FamilyId uint64   `json:"family"`
+	Targets  []uint64 `json:"targets,omitempty"`
+	Kicked   bool     `json:"kicked,omitempty"`
+	From     uint64   `json:"from,omitempty"` // who invited the targets
+	Name     string   `json:"name,omitempty"` // name of the family the targets were invited to
+}
+
+// notify notifies the players concerned by a change of a family, wherever
+// they're online.
+func (m *Module) notify(ctx context.Context, n notification) {
+	n.Server = m.serverId
+	m.deliver(ctx, n)
+	b, err := json.Marshal(n)
+	if err != nil {
+		logger.Error("[family] marshal %+v failed: %v", n, err)
+		return
+	}
+	if err := redis.Get().Publish(ctx, relayChannel, b).Err(); err != nil {
+		logger.Error("[family] publish failed: %v", err)
+	}
+}
+
+// deliver delivers a notification to the players online on this server: the
+// targets that joined or left the family join or leave its chat channel, and
+// the members online get the family again.
+func (m *Module) deliver(ctx context.Context, n notification) {
+	var targets []IPlayer
+	m.mu.Lock()
+	for _, uid := range n.Targets {
+		p := m.online[uid]
+		if p == nil {
+			continue
+		}
+		switch n.Kind {
+		case notifyJoined:
+			m.local[uid] = n.FamilyId
+		case notifyLeft:
+			if m.local[uid] == n.FamilyId {
+				delete(m.local, uid)
+			}
+		}
+		targets = append(targets, p)
+	}
+	var members []IPlayer
+	for uid, id := range m.local {
+		if id == n.FamilyId {
+			members = append(members, m.online[uid])
+		}
+	}
+	m.mu.Unlock()
+
+	for _, p := range targets {
+		switch n.Kind {
+		case notifyJoined:
+			if err := chat.GetMod().Join(chat.ChannelGuild, n.FamilyId, p.GetUId()); err != nil {
+				logger.Error("[family] join chat of family %v PlayerID:%v err:%v", n.FamilyId, p.GetUId(), err)
+			}
+		case notifyLeft:
+			chat.GetMod().Leave(chat.ChannelGuild, p.GetUId())
+			p.SendMsg(protocol.MessageId_SCFamilyLeft, &protocol.SCFamilyLeft{FamilyId: n.FamilyId, Kicked: n.Kicked})
+		case notifyInvited:
+			p.SendMsg(protocol.MessageId_SCFamilyInvite, &protocol.SCFamilyInvite{FamilyId: n.FamilyId, Name: n.Name, From: n.From})
+		}
+	}
+	if len(members) == 0 {
+		return
+	}
+	f, err := loadFamily(ctx, n.FamilyId)
+	if err != nil {
+		// Disbanded since.
+		return
+	}
+	pb := m.toProto(f)
+	for _, p := range members {
+		p.SendMsg(protocol.MessageId_SCFamilyInfo, pb)
+	}
+}
+
+// runRelay delivers the notifications of the other servers, until the module
+// is stopped.
+func (m *Module) runRelay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := redis.Get().Subscribe(ctx, relayChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var n notification
+			if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+				logger.Error("[family] unmarshal %q failed: %v", msg.Payload, err)
+				continue
+			}
+			if n.Server == m.serverId {
+				continue
+			}
+			m.deliver(ctx, n)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package family
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"greatestworks/aop/mongo"
+)
+
+// familyRepo is the repository of the families, of schema version 0 (see
+// mongo.Repo).
+var familyRepo = mongo.NewRepo[mongo.Family](0)
+
+func families() *mongodriver.Collection {
+	return familyRepo.Collection()
+}
+
+func memberships() *mongodriver.Collection {
+	doc := &mongo.FamilyMembership{}
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+func names() *mongodriver.Collection {
+	doc := &mongo.FamilyName{}
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+// loadFamily loads a family.
+func loadFamily(ctx context.Context, id uint64) (*mongo.Family, error) {
+	f, err := familyRepo.Get(ctx, bson.M{"_id": id})
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return nil, ErrNoFamily
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load family %v: %w", id, err)
+	}
+	return f, nil
+}
+
+// familyOf returns the id of the family of a player, or 0.
+func familyOf(ctx context.Context, uid uint64) (uint64, error) {
+	doc := &mongo.FamilyMembership{}
+	err := memberships().FindOne(ctx, bson.M{"_id": uid}).Decode(doc)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("load family of player %v: %w", uid, err)
+	}
+	return doc.FamilyId, nil
+}
+
+// claimMembership records that a player is in a family. It fails with
+// ErrInFamily if the player is in a family already, so that a player joins
+// a single family, whatever the server it joins from.
+func claimMembership(ctx context.Context, uid, familyId uint64) error {
+	_, err := memberships().InsertOne(ctx, &mongo.FamilyMembership{OwnerID: uid, FamilyId: familyId})
+	if mongodriver.IsDuplicateKeyError(err) {
+		return ErrInFamily
+	}
+	if err != nil {
+		return fmt.Errorf("claim membership of player %v: %w", uid, err)
+	}
+	return nil
+}
+
+// releaseMembership records that a player left a family.
+func releaseMembership(ctx context.Context, uid, familyId uint64) error {
+	_, err := memberships().DeleteOne(ctx, bson.M{"_id": uid, "fid": familyId})
+	if err != nil {
+		return fmt.Errorf("release membership of player %v: %w", uid, err)
+	}
+	return nil
+}
+
+// update applies change to a family, and saves it if it didn't change since
+// it was loaded; otherwise it loads it again and retries, up to
+// ModuleConfig.Retries times. change must have no side effect out of the
+// family. It returns the family saved.
+func (m *Module) update(ctx context.Context, id uint64, change func(f *mongo.Family) error) (*mongo.Family, error) {
+	for i := 0; i < m.retries; i++ {
+		f, err := loadFamily(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		version := f.Version
+		if err := change(f); err != nil {
+			return nil, err
+		}
+		f.Version = version + 1
+		saved, err := familyRepo.Replace(ctx, bson.M{"_id": id, "ver": version}, f)
+		if err != nil {
+			return nil, fmt.Errorf("save family %v: %w", id, err)
+		}
+		if saved {
+			return f, nil
+		}
+		conflicts.Add(1)
+	}
+	return nil, ErrConflict
+}
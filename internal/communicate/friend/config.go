@@ -0,0 +1,20 @@
+package friend
+
+import "time"
+
+const (
+	defaultMaxFriends  = 200
+	defaultMaxRequests = 50
+	defaultMaxRecent   = 30
+	defaultRecentTTL   = 3 * 24 * time.Hour
+)
+
+// ModuleConfig is the config of the friend module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ServerId    string        // id of this server, which labels the notifications it relays to the other servers
+	MaxFriends  int           // number of friends a player may have, defaults to defaultMaxFriends
+	MaxRequests int           // number of pending requests a player keeps, oldest dropped first, defaults to defaultMaxRequests
+	MaxRecent   int64         // number of recently met players kept per player, defaults to defaultMaxRecent
+	RecentTTL   time.Duration // how long recently met players are kept, defaults to defaultRecentTTL
+}
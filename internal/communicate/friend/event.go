@@ -11,7 +11,7 @@ type (
 )
 
 var (
-	category2CreateEventFn map[EventCategory]CreateEventFn
+	category2CreateEventFn = map[EventCategory]CreateEventFn{}
 )
 
 const (
@@ -25,7 +25,7 @@ func init() {
 func CreateAddOrDelFriendEvent(system *System) event.IEvent {
 	//todo 使用原子模型做
 	return &friendevent.AddOrDelFriendEvent{
-		CurFriendCount: len(system.FriendList),
+		CurFriendCount: len(system.Friends()),
 		Base:           event.Base{},
 	}
 }
@@ -39,6 +39,9 @@ func (s *System) Publish(e event.IEvent) {
 }
 
 func (s *System) PublishAddOrDelFriend() {
+	if s.IPlayer == nil {
+		return
+	}
 	if s.activeEventCategory[int(CountOfFriend)] {
 		s.Publish(GetCreateEventFn(CountOfFriend)(s))
 	}
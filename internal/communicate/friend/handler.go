@@ -1,12 +1,14 @@
 package friend
 
 import (
+	"context"
 	"errors"
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"github.com/phuhao00/greatestworks-proto/player"
 	"github.com/phuhao00/network"
-	"github.com/phuhao00/sugar"
 	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
 	"sync"
 )
 
@@ -46,14 +48,16 @@ func init() {
 }
 
 func HandlerFriendRegister() {
-	handlers[0] = &Handler{
-		messageId.MessageId_CSAddFriend,
-		AddFriend,
-	}
-	handlers[1] = &Handler{
-		messageId.MessageId_CSDelFriend,
-		DelFriend,
-	}
+	handlers = append(handlers,
+		&Handler{
+			messageId.MessageId_CSAddFriend,
+			AddFriend,
+		},
+		&Handler{
+			messageId.MessageId_CSDelFriend,
+			DelFriend,
+		},
+	)
 }
 
 func GetFriendList(s *System, packet *network.Message) {
@@ -64,6 +68,8 @@ func GetFriendInfo(s *System, packet *network.Message) {
 
 }
 
+// AddFriend sends a friend request to a player, or accepts its request if it
+// sent one (see Module.Request).
 func AddFriend(s *System, packet *network.Message) {
 	req := &player.CSAddFriend{}
 
@@ -71,12 +77,11 @@ func AddFriend(s *System, packet *network.Message) {
 	if err != nil {
 		return
 	}
-
-	if !sugar.CheckInSlice(req.UId, s.FriendList) {
-		s.FriendList = append(s.FriendList, req.UId)
+	if err := GetMod().Request(context.Background(), s.uid, req.UId, 0); err != nil {
+		logger.Warn("[friend] PlayerID:%v add %v failed: %v", s.uid, req.UId, err)
+		return
 	}
-	s.IPlayer.SendMsg(messageId.MessageId_SCAddFriend, &player.SCSendChatMsg{})
-
+	s.IPlayer.SendMsg(messageId.MessageId_SCAddFriend, &protocol.SCAddFriend{})
 }
 
 func DelFriend(s *System, packet *network.Message) {
@@ -85,8 +90,10 @@ func DelFriend(s *System, packet *network.Message) {
 	if err != nil {
 		return
 	}
-	s.FriendList = sugar.DelOneInSlice(req.UId, s.FriendList)
-
+	if err := GetMod().Delete(context.Background(), s.uid, req.UId); err != nil {
+		logger.Warn("[friend] PlayerID:%v delete %v failed: %v", s.uid, req.UId, err)
+		return
+	}
 	s.IPlayer.SendMsg(messageId.MessageId_SCDelFriend, &player.SCDelFriend{})
 }
 
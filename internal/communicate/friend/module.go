@@ -1,31 +1,413 @@
 package friend
 
 import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/phuhao00/greatestworks-proto/module"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
 	"greatestworks/aop/module_router"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/redis"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/note/event/friendevent"
 )
 
 var (
 	Mod         *Module
 	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrSelf           = errors.New("friend: can't befriend oneself")
+	ErrBlocked        = errors.New("friend: player is blocked")
+	ErrAlreadyFriend  = errors.New("friend: already friends")
+	ErrAlreadyPending = errors.New("friend: request already pending")
+	ErrFriendLimit    = errors.New("friend: too many friends")
+	ErrNoRequest      = errors.New("friend: no such request")
+	ErrNotFriend      = errors.New("friend: not friends")
 )
 
 func init() {
 	internal.ModuleManager.RegisterModule(module.Module_Friend.String(), GetMod())
 }
 
+// Module manages the relationships between the players: friend requests,
+// friends, and blocked players. The relationships are stored in the db, and
+// the online players are notified of their changes, and of their friends
+// going online or offline, on whatever server they're online (see notify).
+// Friends added or removed are published on the event bus (see friendevent).
 type Module struct {
 	*internal.BaseModule
+	initFlag    bool
+	serverId    string
+	maxFriends  int
+	maxRequests int
+	maxRecent   int64
+	recentTTL   time.Duration
+	stopCh      chan struct{}
+
+	mu     sync.Mutex
+	online map[uint64]*System // players online on this server; guarded by mu
 }
 
 func GetMod() *Module {
-	Mod = &Module{internal.NewBaseModule()}
-
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
 	return Mod
 }
 
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.serverId = conf.ServerId
+	m.maxFriends = conf.MaxFriends
+	if m.maxFriends <= 0 {
+		m.maxFriends = defaultMaxFriends
+	}
+	m.maxRequests = conf.MaxRequests
+	if m.maxRequests <= 0 {
+		m.maxRequests = defaultMaxRequests
+	}
+	m.maxRecent = conf.MaxRecent
+	if m.maxRecent <= 0 {
+		m.maxRecent = defaultMaxRecent
+	}
+	m.recentTTL = conf.RecentTTL
+	if m.recentTTL <= 0 {
+		m.recentTTL = defaultRecentTTL
+	}
+	m.online = make(map[uint64]*System)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts delivering the notifications of the other servers.
+func (m *Module) OnStart() {
+	if m.initFlag {
+		go m.runRelay()
+	}
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+	}
+}
+
+func (m *Module) system(uid uint64) *System {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.online[uid]
+}
+
+// Online loads the relationships of a player that logged in to this server,
+// sends them to the player along with the friends that are online, and tells
+// its friends it's online.
+func (m *Module) Online(ctx context.Context, s *System) error {
+	doc, err := loadDoc(ctx, s.uid)
+	if err != nil {
+		return err
+	}
+	s.set(doc)
+	m.mu.Lock()
+	m.online[s.uid] = s
+	m.mu.Unlock()
+	if err := redis.Get().HSet(ctx, presenceKey, strconv.FormatUint(s.uid, 10), m.serverId).Err(); err != nil {
+		logger.Error("[friend] set presence of player %v failed: %v", s.uid, err)
+	}
+
+	pushList(s)
+	friends := friendIds(doc)
+	status, err := m.OnlineStatus(ctx, friends)
+	if err != nil {
+		logger.Error("[friend] get status of friends of player %v failed: %v", s.uid, err)
+	}
+	for uid, online := range status {
+		if online {
+			pushStatus(s, uid, true)
+		}
+	}
+	m.notify(ctx, notification{Kind: notifyStatus, From: s.uid, Online: true, Targets: friends})
+	return nil
+}
+
+// Offline tells the friends of a player that logged out that it's offline.
+func (m *Module) Offline(ctx context.Context, uid uint64) {
+	m.mu.Lock()
+	s := m.online[uid]
+	delete(m.online, uid)
+	m.mu.Unlock()
+	if err := redis.Get().HDel(ctx, presenceKey, strconv.FormatUint(uid, 10)).Err(); err != nil {
+		logger.Error("[friend] delete presence of player %v failed: %v", uid, err)
+	}
+	if s == nil {
+		return
+	}
+	var friends []uint64
+	for _, f := range s.Friends() {
+		friends = append(friends, f.UId)
+	}
+	m.notify(ctx, notification{Kind: notifyStatus, From: uid, Online: false, Targets: friends})
+}
+
+// FriendIds returns the friends of a player (e.g., for the friend
+// leaderboards of the rank module).
+func (m *Module) FriendIds(ctx context.Context, uid uint64) ([]uint64, error) {
+	if s := m.system(uid); s != nil {
+		var ids []uint64
+		for _, f := range s.Friends() {
+			ids = append(ids, f.UId)
+		}
+		return ids, nil
+	}
+	doc, err := loadDoc(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return friendIds(doc), nil
+}
+
+// Request sends a friend request from a player to another. If the other
+// player already sent a request to the player, the request is accepted
+// instead.
+func (m *Module) Request(ctx context.Context, from, to uint64, addType int32) error {
+	if from == to {
+		return ErrSelf
+	}
+	doc, err := loadDoc(ctx, from)
+	if err != nil {
+		return err
+	}
+	switch {
+	case isBlocked(doc, to):
+		return ErrBlocked
+	case containsFriend(doc, to):
+		return ErrAlreadyFriend
+	case len(doc.Friends) >= m.maxFriends:
+		return ErrFriendLimit
+	case containsRequest(doc, to):
+		return m.Accept(ctx, from, to)
+	}
+
+	req := Request{UId: from, OpTime: time.Now().Unix(), AddType: addType}
+	updated, err := pushRequest(ctx, to, req, m.maxRequests)
+	if err != nil {
+		return err
+	}
+	if updated == nil {
+		target, err := loadDoc(ctx, to)
+		if err != nil {
+			return err
+		}
+		switch {
+		case isBlocked(target, from):
+			return ErrBlocked
+		case containsFriend(target, from):
+			return ErrAlreadyFriend
+		default:
+			return ErrAlreadyPending
+		}
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, From: from, Targets: []uint64{to}})
+	return nil
+}
+
+// Accept accepts the friend request a player received from another.
+func (m *Module) Accept(ctx context.Context, uid, from uint64) error {
+	doc, err := pullRequest(ctx, uid, from)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		return ErrNoRequest
+	}
+	now := time.Now().Unix()
+	mine, err := pushFriend(ctx, uid, Info{UId: from, AddTime: now}, m.maxFriends)
+	if err != nil {
+		return err
+	}
+	if mine == nil {
+		m.notify(ctx, notification{Kind: notifyChanged, From: uid, Targets: []uint64{uid}})
+		return ErrFriendLimit
+	}
+	theirs, err := pushFriend(ctx, from, Info{UId: uid, AddTime: now}, m.maxFriends)
+	if err == nil && theirs == nil {
+		err = ErrFriendLimit
+	}
+	if err != nil {
+		// Undo the half of the friendship that was added.
+		if _, undoErr := pullFriend(ctx, uid, from); undoErr != nil {
+			logger.Error("[friend] undo friend %v of player %v failed: %v", from, uid, undoErr)
+		}
+		m.notify(ctx, notification{Kind: notifyChanged, From: uid, Targets: []uint64{uid}})
+		return err
+	}
+	eventbus.Publish(eventbus.Default, friendevent.Added{PlayerId: uid, FriendId: from, Count: len(mine.Friends)})
+	eventbus.Publish(eventbus.Default, friendevent.Added{PlayerId: from, FriendId: uid, Count: len(theirs.Friends)})
+	m.notify(ctx, notification{Kind: notifyChanged, From: uid, Targets: []uint64{uid, from}})
+	return nil
+}
+
+// Reject rejects the friend request a player received from another.
+func (m *Module) Reject(ctx context.Context, uid, from uint64) error {
+	doc, err := pullRequest(ctx, uid, from)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		return ErrNoRequest
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, From: uid, Targets: []uint64{uid}})
+	return nil
+}
+
+// Delete ends the friendship of two players.
+func (m *Module) Delete(ctx context.Context, uid, friendId uint64) error {
+	mine, err := pullFriend(ctx, uid, friendId)
+	if err != nil {
+		return err
+	}
+	if mine == nil {
+		return ErrNotFriend
+	}
+	m.unfriend(ctx, friendId, uid, len(mine.Friends))
+	m.notify(ctx, notification{Kind: notifyChanged, From: uid, Targets: []uint64{uid, friendId}})
+	return nil
+}
+
+// unfriend removes uid from the friends of friendId, once friendId was
+// removed from the friends of uid, who has count friends left.
+func (m *Module) unfriend(ctx context.Context, friendId, uid uint64, count int) {
+	theirs, err := pullFriend(ctx, friendId, uid)
+	if err != nil {
+		logger.Error("[friend] delete friend %v of player %v failed: %v", uid, friendId, err)
+	}
+	eventbus.Publish(eventbus.Default, friendevent.Removed{PlayerId: uid, FriendId: friendId, Count: count})
+	if theirs != nil {
+		eventbus.Publish(eventbus.Default, friendevent.Removed{PlayerId: friendId, FriendId: uid, Count: len(theirs.Friends)})
+	}
+}
+
+// Block blocks a player: the friendship of the players, if any, ends, their
+// pending requests are dropped, and the blocked player can't send requests
+// to the player anymore.
+func (m *Module) Block(ctx context.Context, uid, target uint64) error {
+	if uid == target {
+		return ErrSelf
+	}
+	before, err := loadDoc(ctx, uid)
+	if err != nil {
+		return err
+	}
+	doc, err := pushBlocked(ctx, uid, target)
+	if err != nil {
+		return err
+	}
+	if containsFriend(before, target) && doc != nil {
+		m.unfriend(ctx, target, uid, len(doc.Friends))
+	}
+	if _, err := pullRequest(ctx, target, uid); err != nil {
+		logger.Error("[friend] drop request of player %v to %v failed: %v", uid, target, err)
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, From: uid, Targets: []uint64{uid, target}})
+	return nil
+}
+
+// Unblock unblocks a player.
+func (m *Module) Unblock(ctx context.Context, uid, target uint64) error {
+	if _, err := pullBlocked(ctx, uid, target); err != nil {
+		return err
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, From: uid, Targets: []uint64{uid}})
+	return nil
+}
+
+// Met records that a player met others (e.g., in a team or a battle), so
+// that they're suggested as friends (see Suggestions).
+func (m *Module) Met(ctx context.Context, uid uint64, others ...uint64) error {
+	key := metKey(uid)
+	now := float64(time.Now().Unix())
+	members := make([]*goredis.Z, 0, len(others))
+	for _, other := range others {
+		if other != uid {
+			members = append(members, &goredis.Z{Score: now, Member: strconv.FormatUint(other, 10)})
+		}
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	_, err := redis.Get().TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, members...)
+		pipe.ZRemRangeByRank(ctx, key, 0, -m.maxRecent-1)
+		pipe.Expire(ctx, key, m.recentTTL)
+		return nil
+	})
+	return err
+}
+
+// Suggestions returns up to n players a player recently met, most recent
+// first, leaving out its friends, the players it blocked, and the players
+// whose request it has.
+func (m *Module) Suggestions(ctx context.Context, uid uint64, n int) ([]uint64, error) {
+	members, err := redis.Get().ZRevRange(ctx, metKey(uid), 0, m.maxRecent-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	doc, err := loadDoc(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint64
+	for _, member := range members {
+		id, err := strconv.ParseUint(member, 10, 64)
+		if err != nil || containsFriend(doc, id) || isBlocked(doc, id) || containsRequest(doc, id) {
+			continue
+		}
+		ids = append(ids, id)
+		if len(ids) == n {
+			break
+		}
+	}
+	return ids, nil
+}
+
+func friendIds(doc *mongo.FriendSystem) []uint64 {
+	ids := make([]uint64, 0, len(doc.Friends))
+	for _, f := range doc.Friends {
+		ids = append(ids, f.UId)
+	}
+	return ids
+}
+
+func containsFriend(doc *mongo.FriendSystem, uid uint64) bool {
+	for _, f := range doc.Friends {
+		if f.UId == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func containsRequest(doc *mongo.FriendSystem, uid uint64) bool {
+	for _, r := range doc.Requests {
+		if r.UId == uid {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *Module) GetName() string {
 	return module.Module_Friend.String()
 }
@@ -0,0 +1,29 @@
+package friend
+
+import (
+	"github.com/phuhao00/greatestworks-proto/player"
+	"greatestworks/internal/protocol"
+)
+
+// pushStatus tells a player that a friend went online or offline.
+func pushStatus(s *System, uid uint64, online bool) {
+	if s.IPlayer == nil {
+		return
+	}
+	s.SendMsg(protocol.MessageId_SCFriendStatus, &protocol.SCFriendStatus{UId: uid, Online: online})
+}
+
+// pushList sends its friends and friend requests to a player.
+func pushList(s *System) {
+	if s.IPlayer == nil {
+		return
+	}
+	msg := &protocol.SCFriendList{}
+	for _, f := range s.Friends() {
+		msg.Friends = append(msg.Friends, f.UId)
+	}
+	for _, r := range s.Requests() {
+		msg.Requests = append(msg.Requests, r.UId)
+	}
+	s.SendMsg(protocol.MessageId_SCFriendList, msg)
+}
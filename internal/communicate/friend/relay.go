@@ -0,0 +1,132 @@
+package friend
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+)
+
+const (
+	// relayChannel is the Redis pub/sub channel on which the servers notify
+	// the players online on other servers.
+	relayChannel = "friend:relay"
+
+	// presenceKey is the Redis hash of the server of the online players, by
+	// player id.
+	presenceKey = "friend:presence"
+)
+
+// Kinds of notifications.
+const (
+	notifyStatus  = "status"  // From went online or offline
+	notifyChanged = "changed" // the relationships of the targets changed
+)
+
+// notification notifies players of a change.
+type notification struct {
+	Server  string   `json:"server"` // server of the notifier
+	Kind    string   `json:"kind"`
+	From    uint64   `json:"from"`
+	Online  bool     `json:"online,omitempty"` // for notifyStatus
+	Targets []uint64 `json:"targets"`
+}
+
+// notify notifies the provided players, wherever they're online.
+func (m *Module) notify(ctx context.Context, n notification) {
+	if len(n.Targets) == 0 {
+		return
+	}
+	n.Server = m.serverId
+	m.deliver(ctx, n)
+	b, err := json.Marshal(n)
+	if err != nil {
+		logger.Error("[friend] marshal %+v failed: %v", n, err)
+		return
+	}
+	if err := redis.Get().Publish(ctx, relayChannel, b).Err(); err != nil {
+		logger.Error("[friend] publish failed: %v", err)
+	}
+}
+
+// deliver delivers a notification to the targets online on this server.
+func (m *Module) deliver(ctx context.Context, n notification) {
+	for _, uid := range n.Targets {
+		s := m.system(uid)
+		if s == nil {
+			continue
+		}
+		switch n.Kind {
+		case notifyStatus:
+			pushStatus(s, n.From, n.Online)
+		case notifyChanged:
+			doc, err := loadDoc(ctx, uid)
+			if err != nil {
+				logger.Error("[friend] reload PlayerID:%v err:%v", uid, err)
+				continue
+			}
+			s.set(doc)
+			s.PublishAddOrDelFriend()
+			pushList(s)
+		}
+	}
+}
+
+// runRelay delivers the notifications of the other servers, until the module
+// is stopped.
+func (m *Module) runRelay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := redis.Get().Subscribe(ctx, relayChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var n notification
+			if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+				logger.Error("[friend] unmarshal %q failed: %v", msg.Payload, err)
+				continue
+			}
+			if n.Server == m.serverId {
+				continue
+			}
+			m.deliver(ctx, n)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// OnlineStatus returns which of the provided players are online, on any
+// server.
+func (m *Module) OnlineStatus(ctx context.Context, uids []uint64) (map[uint64]bool, error) {
+	status := make(map[uint64]bool, len(uids))
+	if len(uids) == 0 {
+		return status, nil
+	}
+	fields := make([]string, len(uids))
+	for i, uid := range uids {
+		fields[i] = strconv.FormatUint(uid, 10)
+	}
+	vals, err := redis.Get().HMGet(ctx, presenceKey, fields...).Result()
+	if err != nil && err != goredis.Nil {
+		return nil, err
+	}
+	for i, uid := range uids {
+		status[uid] = i < len(vals) && vals[i] != nil
+	}
+	return status, nil
+}
+
+// metKey returns the Redis sorted set of the players a player recently met,
+// scored by when they met.
+func metKey(uid uint64) string {
+	return "friend:met:" + strconv.FormatUint(uid, 10)
+}
@@ -0,0 +1,138 @@
+package friend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"greatestworks/aop/mongo"
+)
+
+// The relationships of a player are stored in a document of its own, updated
+// with conditional updates, so that the servers of two players can update
+// their relationships concurrently.
+
+func collection() *mongodriver.Collection {
+	doc := &mongo.FriendSystem{}
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+// loadDoc returns the relationships of a player, which are empty if the
+// player has no document yet.
+func loadDoc(ctx context.Context, uid uint64) (*mongo.FriendSystem, error) {
+	doc := &mongo.FriendSystem{}
+	err := collection().FindOne(ctx, bson.M{mongo.PrimaryKey: uid}).Decode(doc)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return &mongo.FriendSystem{OwnerID: uid}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load friends of player %v: %w", uid, err)
+	}
+	return doc, nil
+}
+
+// ensureDoc creates the document of a player, if it has none.
+func ensureDoc(ctx context.Context, uid uint64) error {
+	_, err := collection().UpdateOne(ctx,
+		bson.M{mongo.PrimaryKey: uid},
+		bson.M{"$setOnInsert": bson.M{
+			mongo.PrimaryKey: uid,
+			"friends":        bson.A{},
+			"blocked":        bson.A{},
+			"requests":       bson.A{},
+		}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// updateDoc applies an update to the document of a player, if it matches the
+// provided conditions, and returns the updated document, or nil if it
+// doesn't match.
+func updateDoc(ctx context.Context, uid uint64, cond, update bson.M) (*mongo.FriendSystem, error) {
+	if err := ensureDoc(ctx, uid); err != nil {
+		return nil, err
+	}
+	filter := bson.M{mongo.PrimaryKey: uid}
+	for k, v := range cond {
+		filter[k] = v
+	}
+	doc := &mongo.FriendSystem{}
+	err := collection().FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(doc)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update friends of player %v: %w", uid, err)
+	}
+	return doc, nil
+}
+
+// pushRequest adds a request to the requests received by a player, unless the
+// requester already requested, is a friend, or is blocked. The oldest
+// requests are dropped beyond maxRequests.
+func pushRequest(ctx context.Context, uid uint64, req Request, maxRequests int) (*mongo.FriendSystem, error) {
+	return updateDoc(ctx, uid,
+		bson.M{
+			"requests.uid": bson.M{"$ne": req.UId},
+			"friends.uid":  bson.M{"$ne": req.UId},
+			"blocked":      bson.M{"$ne": req.UId},
+		},
+		bson.M{"$push": bson.M{"requests": bson.M{"$each": bson.A{req}, "$slice": -maxRequests}}})
+}
+
+// pullRequest removes the request of the provided requester from the requests
+// received by a player, if it has one.
+func pullRequest(ctx context.Context, uid, from uint64) (*mongo.FriendSystem, error) {
+	return updateDoc(ctx, uid,
+		bson.M{"requests.uid": from},
+		bson.M{"$pull": bson.M{"requests": bson.M{"uid": from}}})
+}
+
+// pushFriend adds a friend to a player, unless it's already a friend, it's
+// blocked, or the player has maxFriends friends.
+func pushFriend(ctx context.Context, uid uint64, info Info, maxFriends int) (*mongo.FriendSystem, error) {
+	return updateDoc(ctx, uid,
+		bson.M{
+			"friends.uid":                           bson.M{"$ne": info.UId},
+			"blocked":                               bson.M{"$ne": info.UId},
+			fmt.Sprintf("friends.%d", maxFriends-1): bson.M{"$exists": false},
+		},
+		bson.M{"$push": bson.M{"friends": info}})
+}
+
+// pullFriend removes a friend of a player, if it's a friend.
+func pullFriend(ctx context.Context, uid, friendId uint64) (*mongo.FriendSystem, error) {
+	return updateDoc(ctx, uid,
+		bson.M{"friends.uid": friendId},
+		bson.M{"$pull": bson.M{"friends": bson.M{"uid": friendId}}})
+}
+
+// pushBlocked blocks a player, dropping it from the friends and the requests.
+func pushBlocked(ctx context.Context, uid, target uint64) (*mongo.FriendSystem, error) {
+	return updateDoc(ctx, uid, nil, bson.M{
+		"$addToSet": bson.M{"blocked": target},
+		"$pull": bson.M{
+			"friends":  bson.M{"uid": target},
+			"requests": bson.M{"uid": target},
+		},
+	})
+}
+
+// pullBlocked unblocks a player.
+func pullBlocked(ctx context.Context, uid, target uint64) (*mongo.FriendSystem, error) {
+	return updateDoc(ctx, uid, nil, bson.M{"$pull": bson.M{"blocked": target}})
+}
+
+// isBlocked returns whether target is blocked by a player.
+func isBlocked(doc *mongo.FriendSystem, target uint64) bool {
+	for _, id := range doc.Blocked {
+		if id == target {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,14 @@
 package friend
 
 import (
-	"time"
+	"sync"
+
+	"greatestworks/aop/mongo"
 )
 
+// System is the friend relationships of an online player, as last loaded
+// from its document. It's reloaded whenever they change, possibly on another
+// server (see Module).
 type System struct {
 	FriendList []uint64 //朋友
 	friends    []Info
@@ -11,15 +16,19 @@ type System struct {
 	requests   []Request
 	IPlayer
 	activeEventCategory map[int]bool
+
+	uid uint64
+	mu  sync.Mutex // guards the relationships
 }
 
 func NewSystem() *System {
 	return &System{
-		FriendList: nil,
-		friends:    nil,
-		BlackList:  nil,
-		requests:   nil,
-		IPlayer:    nil,
+		FriendList:          nil,
+		friends:             nil,
+		BlackList:           nil,
+		requests:            nil,
+		IPlayer:             nil,
+		activeEventCategory: map[int]bool{},
 	}
 }
 
@@ -27,11 +36,42 @@ func GetModule() string {
 	return "friendevent"
 }
 
-func (s *System) SetOwner(owner IPlayer) {
+// SetOwner sets the player whose relationships these are.
+func (s *System) SetOwner(owner IPlayer, uid uint64) {
 	s.IPlayer = owner
+	s.uid = uid
+}
+
+// set replaces the relationships with those of the provided document.
+func (s *System) set(doc *mongo.FriendSystem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.friends = append([]Info(nil), doc.Friends...)
+	s.FriendList = make([]uint64, 0, len(doc.Friends))
+	for _, f := range doc.Friends {
+		s.FriendList = append(s.FriendList, f.UId)
+	}
+	s.BlackList = append([]uint64(nil), doc.Blocked...)
+	s.requests = append([]Request(nil), doc.Requests...)
+}
+
+// Friends returns the friends of the player.
+func (s *System) Friends() []Info {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Info(nil), s.friends...)
+}
+
+// Requests returns the pending friend requests received by the player.
+func (s *System) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
 }
 
 func (s *System) isFriend(uId uint64) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for index, val := range s.friends {
 		if val.UId == uId {
 			return true, index
@@ -41,6 +81,8 @@ func (s *System) isFriend(uId uint64) (bool, int) {
 }
 
 func (s *System) isBlackList(uId uint64) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for index, val := range s.BlackList {
 		if val == uId {
 			return true, index
@@ -50,36 +92,12 @@ func (s *System) isBlackList(uId uint64) (bool, int) {
 }
 
 func (s *System) getRequest(uId uint64) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for index, val := range s.requests {
-		if val.Userid == uId {
+		if val.UId == uId {
 			return true, index
 		}
 	}
 	return false, -1
 }
-
-func (s *System) delRequest(uId uint64) {
-	if ok, index := s.getRequest(uId); ok == true {
-		s.requests = append(s.requests[:index], s.requests[index+1:]...)
-	}
-}
-
-func (s *System) addRequest(uId uint64, addType int32) {
-	s.requests = append(s.requests, Request{
-		Userid:  uId,
-		OpTime:  time.Now().Unix(),
-		AddType: addType,
-	})
-}
-
-func (s *System) addFriend() {
-	//todo ...
-	s.PublishAddOrDelFriend()
-
-}
-
-func (s *System) delFriend() {
-	//todo ...
-	s.PublishAddOrDelFriend()
-
-}
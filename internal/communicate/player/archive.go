@@ -0,0 +1,334 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"greatestworks/aop/dlock"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/saga"
+	"greatestworks/internal/communicate/friend"
+)
+
+// ErrArchiveNotFound is returned for archives that don't exist.
+var ErrArchiveNotFound = errors.New("player archive not found")
+
+var archiveOps = metrics.NewCounterMap[archiveLabels](
+	"player_archive_ops",
+	"Number of snapshots and restores of the persisted state of players",
+)
+
+type archiveLabels struct {
+	Op string // "snapshot" or "restore"
+}
+
+// archivePlayer is the name of the player document in the archives.
+const archivePlayer = "player"
+
+// archivedCollection is a collection holding a document per player, outside
+// of the player document, archived with it.
+type archivedCollection struct {
+	name string // name in the archives
+	doc  mongo.Document
+	key  string // field of the player id
+}
+
+// archivedCollections are the collections of the state of the players
+// archived besides their player documents. The documents shared by players
+// (e.g., the families, the auction listings) and the logs (e.g., the ledger
+// of the currencies) aren't archived.
+var archivedCollections = []archivedCollection{
+	{name: "mail", doc: &mongo.MailSystem{}, key: mongo.PrimaryKey},
+	{name: "friend", doc: &mongo.FriendSystem{}, key: mongo.PrimaryKey},
+	{name: "wallet", doc: &mongo.Wallet{}, key: mongo.PrimaryKey},
+	{name: "match", doc: &mongo.MatchRating{}, key: "_id"},
+}
+
+func collectionOf(doc mongo.Document) *mongodriver.Collection {
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+func archives() *mongodriver.Collection {
+	return collectionOf(&mongo.PlayerArchive{})
+}
+
+// Snapshot archives the persisted state of a player: its player document,
+// read like when it logs in, and its documents in archivedCollections. The
+// state of an online player lags behind it by up to FlushInterval.
+func Snapshot(ctx context.Context, uid uint64, reason, by string) (*mongo.PlayerArchive, error) {
+	return takeSnapshot(ctx, uid, reason, by, 0)
+}
+
+func takeSnapshot(ctx context.Context, uid uint64, reason, by string, restores uint64) (*mongo.PlayerArchive, error) {
+	sections, _, found, err := readDoc(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("snapshot player %v: %w", uid, ErrPlayerNotFound)
+	}
+	id, err := idgenerator.NextId()
+	if err != nil {
+		return nil, fmt.Errorf("archive id: %w", err)
+	}
+	a := &mongo.PlayerArchive{
+		Id:       id,
+		UId:      uid,
+		Reason:   reason,
+		By:       by,
+		Time:     time.Now().Unix(),
+		Restores: restores,
+		Docs:     map[string]bson.Raw{},
+	}
+	// The sections read are upgraded to SchemaVersion.
+	doc, err := bson.Marshal(bson.M{"ver": SchemaVersion, "sections": sections})
+	if err != nil {
+		return nil, fmt.Errorf("snapshot player %v: %w", uid, err)
+	}
+	a.Docs[archivePlayer] = doc
+	for _, c := range archivedCollections {
+		var raw bson.Raw
+		err := collectionOf(c.doc).FindOne(ctx, bson.M{c.key: uid}).Decode(&raw)
+		if errors.Is(err, mongodriver.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("snapshot player %v: %s: %w", uid, c.name, err)
+		}
+		a.Docs[c.name] = raw
+	}
+	if _, err := archives().InsertOne(ctx, a); err != nil {
+		return nil, fmt.Errorf("snapshot player %v: %w", uid, err)
+	}
+	archiveOps.Get(archiveLabels{Op: "snapshot"}).Add(1)
+	logger.Info("[archive] snapshot %v of PlayerID:%v by:%v reason:%v", id, uid, by, reason)
+	return a, nil
+}
+
+// Archives returns the archives of a player, most recent first, without
+// their documents.
+func Archives(ctx context.Context, uid uint64, limit int64) ([]*mongo.PlayerArchive, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "time", Value: -1}}).
+		SetProjection(bson.M{"docs": 0}).
+		SetLimit(limit)
+	cur, err := archives().Find(ctx, bson.M{"uid": uid}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find archives of player %v: %w", uid, err)
+	}
+	var list []*mongo.PlayerArchive
+	if err := cur.All(ctx, &list); err != nil {
+		return nil, fmt.Errorf("find archives of player %v: %w", uid, err)
+	}
+	return list, nil
+}
+
+// LoadArchive returns an archive.
+func LoadArchive(ctx context.Context, id uint64) (*mongo.PlayerArchive, error) {
+	a := &mongo.PlayerArchive{}
+	err := archives().FindOne(ctx, bson.M{"_id": id}).Decode(a)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return nil, fmt.Errorf("archive %v: %w", id, ErrArchiveNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load archive %v: %w", id, err)
+	}
+	return a, nil
+}
+
+// Restore restores the persisted state of a player from an archive, and
+// returns the snapshot of its state taken before, from which it may be
+// restored back. parts selects what is restored: "player" for the player
+// document, "player.<section>" (e.g., "player.bag") for a section of it, or
+// the name of a collection (e.g., "wallet"); everything if it's empty. A
+// collection the player had no document in when archived is emptied of its
+// document.
+//
+// The player must be offline, on every server: it returns ErrPlayerOnline
+// otherwise. If a part fails to be restored, the parts restored before are
+// restored from the snapshot taken before.
+func Restore(ctx context.Context, id uint64, by string, parts ...string) (*mongo.PlayerArchive, error) {
+	a, err := LoadArchive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sections, colls, err := archiveParts(a, parts)
+	if err != nil {
+		return nil, err
+	}
+	uid := a.UId
+	lock, err := dlock.Get().Lock(ctx, "player:restore:"+strconv.FormatUint(uid, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+	online, err := friend.GetMod().OnlineStatus(ctx, []uint64{uid})
+	if err != nil {
+		return nil, fmt.Errorf("restore player %v: %w", uid, err)
+	}
+	if online[uid] {
+		return nil, ErrPlayerOnline
+	}
+	if err := Offline.forget(ctx, uid); err != nil {
+		return nil, err
+	}
+	backup, err := takeSnapshot(ctx, uid, fmt.Sprintf("before restore of archive %d", id), by, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &saga.Saga{Name: "player_restore"}
+	if sections != nil {
+		s.Step(archivePlayer, func(ctx context.Context) error {
+			return restoreSections(ctx, uid, a.Docs[archivePlayer], sections)
+		}, func(ctx context.Context) error {
+			return restoreSections(ctx, uid, backup.Docs[archivePlayer], sections)
+		})
+	}
+	for _, c := range archivedCollections {
+		if !colls[c.name] {
+			continue
+		}
+		c := c
+		s.Step(c.name, func(ctx context.Context) error {
+			return restoreDoc(ctx, c, uid, a.Docs[c.name])
+		}, func(ctx context.Context) error {
+			return restoreDoc(ctx, c, uid, backup.Docs[c.name])
+		})
+	}
+	if err := saga.Get().Run(ctx, s); err != nil {
+		return nil, fmt.Errorf("restore player %v from archive %v (backup %v): %w", uid, id, backup.Id, err)
+	}
+	archiveOps.Get(archiveLabels{Op: "restore"}).Add(1)
+	logger.Info("[archive] restore PlayerID:%v from archive %v by:%v parts:%v backup:%v", uid, id, by, parts, backup.Id)
+	return backup, nil
+}
+
+// archiveParts returns the sections of the player document to restore from
+// an archive (nil for none), and the collections, for parts as passed to
+// Restore.
+func archiveParts(a *mongo.PlayerArchive, parts []string) (map[string]bool, map[string]bool, error) {
+	all, err := archivedSections(a.Docs[archivePlayer])
+	if err != nil {
+		return nil, nil, fmt.Errorf("archive %v: %w", a.Id, err)
+	}
+	known := map[string]bool{}
+	for _, c := range archivedCollections {
+		known[c.name] = true
+	}
+	if len(parts) == 0 {
+		return all, known, nil
+	}
+	var sections map[string]bool
+	colls := map[string]bool{}
+	for _, part := range parts {
+		name, section, isSection := strings.Cut(part, ".")
+		switch {
+		case part == archivePlayer:
+			sections = all
+		case name == archivePlayer && isSection:
+			if !all[section] {
+				return nil, nil, fmt.Errorf("archive %v has no section %q", a.Id, section)
+			}
+			if sections == nil {
+				sections = map[string]bool{}
+			}
+			sections[section] = true
+		case known[part]:
+			colls[part] = true
+		default:
+			return nil, nil, fmt.Errorf("unknown part %q of the archives", part)
+		}
+	}
+	return sections, colls, nil
+}
+
+// archivedSections returns the names of the sections of an archived player
+// document.
+func archivedSections(doc bson.Raw) (map[string]bool, error) {
+	value, err := doc.LookupErr("sections")
+	if err != nil {
+		return nil, fmt.Errorf("sections: %w", err)
+	}
+	sections, ok := value.DocumentOK()
+	if !ok {
+		return nil, fmt.Errorf("sections: not a document")
+	}
+	elems, err := sections.Elements()
+	if err != nil {
+		return nil, fmt.Errorf("sections: %w", err)
+	}
+	names := make(map[string]bool, len(elems))
+	for _, elem := range elems {
+		names[elem.Key()] = true
+	}
+	return names, nil
+}
+
+// restoreSections writes sections of an archived player document to the
+// document of the player, upgraded to SchemaVersion. The sections the player
+// has that the archive lacks (e.g., added since) are kept.
+func restoreSections(ctx context.Context, uid uint64, doc bson.Raw, only map[string]bool) error {
+	var archived struct {
+		Version  uint32 `bson:"ver"`
+		Sections bson.M `bson:"sections"`
+	}
+	if err := bson.Unmarshal(doc, &archived); err != nil {
+		return fmt.Errorf("restore player %v: %w", uid, err)
+	}
+	if err := migrate(archived.Version, archived.Sections); err != nil {
+		return fmt.Errorf("restore player %v: %w", uid, err)
+	}
+	update := bson.M{}
+	for name, value := range archived.Sections {
+		if only[name] {
+			update[sectionKey(name)] = value
+		}
+	}
+	if len(update) == 0 {
+		return nil
+	}
+	return writeDoc(ctx, uid, update)
+}
+
+// restoreDoc replaces the document of a player in a collection with an
+// archived one, or deletes it if doc is nil.
+func restoreDoc(ctx context.Context, c archivedCollection, uid uint64, doc bson.Raw) error {
+	var err error
+	if doc == nil {
+		_, err = collectionOf(c.doc).DeleteOne(ctx, bson.M{c.key: uid})
+	} else {
+		_, err = collectionOf(c.doc).ReplaceOne(ctx, bson.M{c.key: uid}, doc, options.Replace().SetUpsert(true))
+	}
+	if err != nil {
+		return fmt.Errorf("restore player %v: %s: %w", uid, c.name, err)
+	}
+	return nil
+}
+
+// ArchiveParts returns the names of the parts of an archive, as passed to
+// Restore, sorted.
+func ArchiveParts(a *mongo.PlayerArchive) []string {
+	var parts []string
+	for name := range a.Docs {
+		parts = append(parts, name)
+	}
+	if sections, err := archivedSections(a.Docs[archivePlayer]); err == nil {
+		for name := range sections {
+			parts = append(parts, archivePlayer+"."+name)
+		}
+	}
+	sort.Strings(parts)
+	return parts
+}
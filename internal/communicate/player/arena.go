@@ -0,0 +1,23 @@
+package player
+
+import (
+	"context"
+
+	"greatestworks/internal/gameplay/arena"
+)
+
+func init() {
+	// The defenses of the players who aren't online are read from their
+	// documents, through the store of the offline players.
+	arena.GetMod().SetDefenses(func(ctx context.Context, uid uint64) (*arena.Lineup, error) {
+		data, err := Offline.Get(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		var doc arena.Doc
+		if _, err := data.Section(arenaSection, &doc); err != nil {
+			return nil, err
+		}
+		return doc.Defense, nil
+	})
+}
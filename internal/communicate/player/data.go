@@ -1,16 +1,10 @@
 package player
 
 type BaseInfo struct {
-	UId    uint64 `json:"uid"`
-	Name   string `json:"name"`
-	Age    int    `json:"age"`
-	Gender int    `json:"gender"`
-}
-
-func (p *Player) Load() {
-
-}
-
-func (p *Player) Save() {
-	
+	UId    uint64 `json:"uid" bson:"uid"`
+	Name   string `json:"name" bson:"name"`
+	Age    int    `json:"age" bson:"age"`
+	Gender int    `json:"gender" bson:"gender"`
+	Level  uint32 `json:"level" bson:"level"`
+	RegTM  int64  `json:"regTm" bson:"regtm"` // register time, unix seconds
 }
@@ -0,0 +1,347 @@
+package player
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/msgseq"
+	"greatestworks/aop/net/fanout"
+	"greatestworks/aop/protover"
+	"greatestworks/internal/communicate/chat"
+	"greatestworks/internal/communicate/family"
+	"greatestworks/internal/communicate/friend"
+	"greatestworks/internal/communicate/team"
+	"greatestworks/internal/gameplay/achievement"
+	"greatestworks/internal/gameplay/anticheat"
+	"greatestworks/internal/gameplay/arena"
+	"greatestworks/internal/gameplay/bag"
+	"greatestworks/internal/gameplay/battle"
+	"greatestworks/internal/gameplay/buff"
+	"greatestworks/internal/gameplay/equip"
+	"greatestworks/internal/gameplay/match"
+	"greatestworks/internal/gameplay/monster"
+	"greatestworks/internal/gameplay/skill"
+	"greatestworks/internal/gameplay/task"
+	"greatestworks/internal/purchase/activity"
+	"greatestworks/internal/purchase/auction"
+	"greatestworks/internal/purchase/battlepass"
+	"greatestworks/internal/purchase/recharge"
+	"greatestworks/internal/purchase/shop"
+	"greatestworks/internal/purchase/vip"
+)
+
+var (
+	handlerPanics = metrics.NewCounterMap[handlerLabels](
+		"player_handler_panics",
+		"Number of player messages whose handler panicked",
+	)
+	handlerOverruns = metrics.NewCounterMap[handlerLabels](
+		"player_handler_deadline_exceeded",
+		"Number of player messages handled after their deadline",
+	)
+	duplicates = metrics.NewCounterMap[duplicateLabels](
+		"player_duplicate_messages",
+		"Number of duplicate numbered player messages, not handled",
+	)
+)
+
+type duplicateLabels struct {
+	MessageId uint32
+	Verdict   string // "pending", "replay" or "stale", see msgseq.Verdict
+}
+
+type handlerLabels struct {
+	MessageId uint32
+}
+
+// DispatchConf configures how the messages of players are dispatched to
+// their handlers. It must be set before players start.
+var DispatchConf DispatchOptions
+
+// DispatchOptions configures how the messages of a player are dispatched.
+type DispatchOptions struct {
+	// Deadline is the time a message may take to be handled. The context of
+	// the message expires after Deadline, and handlers that overrun it are
+	// logged. Defaults to 5 seconds.
+	Deadline time.Duration
+
+	// Workers is the number of goroutines handling the messages of a player.
+	// Messages of the same module (e.g., bag) are handled in order, by the
+	// same goroutine; messages of different modules are handled
+	// concurrently, so that a slow handler only delays the messages of its
+	// module. Handlers of different modules must not share unguarded state.
+	// If 0, the default, all messages are handled in order by the player
+	// goroutine. Since the sections of the player are saved from the player
+	// goroutine (see Section), sections updated by workers must guard their
+	// data.
+	Workers int
+
+	// QueueSize is the number of messages buffered per worker. Defaults to
+	// 64.
+	QueueSize int
+
+	// SeqWindow is the number of the latest numbered messages of a player
+	// (see msgseq) whose duplicates are suppressed. Defaults to 128.
+	SeqWindow int
+
+	// CacheResponses makes the player answer the duplicates of the numbered
+	// messages it handled with the messages it sent while handling them the
+	// first time, before acking them again. It's ignored if Workers is not 0,
+	// as the messages sent by concurrent handlers can't be told apart.
+	CacheResponses bool
+
+	// SendQueue is the number of messages queued for the client of a player,
+	// sent by a goroutine of the player as the connection makes room for
+	// them. Once full, batches of scene updates are dropped by SendPolicy,
+	// and the client is disconnected if it overflows with other messages
+	// (see fanout.Queue). If 0, the default, messages are sent directly, and
+	// lost if the connection has no room for them.
+	SendQueue  int
+	SendPolicy fanout.Policy
+}
+
+func (o DispatchOptions) withDefaults() DispatchOptions {
+	if o.Deadline <= 0 {
+		o.Deadline = 5 * time.Second
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 64
+	}
+	return o
+}
+
+// dispatch handles the messages received on HandlerParamCh until the player
+// stops.
+func (p *Player) dispatch(opts DispatchOptions) {
+	opts = opts.withDefaults()
+	flush := time.NewTicker(FlushInterval)
+	defer flush.Stop()
+	if opts.Workers <= 0 {
+		for {
+			select {
+			case req := <-p.HandlerParamCh:
+				p.handle(req, opts)
+			case <-flush.C:
+				p.flushAsync()
+			case <-p.stopCh:
+				return
+			}
+		}
+	}
+
+	// When the player stops, the workers handle the messages already
+	// dispatched to them before dispatch returns.
+	var wg sync.WaitGroup
+	lanes := make([]chan *Request, opts.Workers)
+	for i := range lanes {
+		lanes[i] = make(chan *Request, opts.QueueSize)
+		wg.Add(1)
+		go func(lane chan *Request) {
+			defer wg.Done()
+			for req := range lane {
+				p.handle(req, opts)
+			}
+		}(lanes[i])
+	}
+	defer func() {
+		for _, lane := range lanes {
+			close(lane)
+		}
+		wg.Wait()
+	}()
+	for {
+		select {
+		case req := <-p.HandlerParamCh:
+			h := fnv.New32a()
+			h.Write([]byte(moduleOf(messageId.MessageId(req.Msg.ID))))
+			lane := lanes[h.Sum32()%uint32(len(lanes))]
+			select {
+			case lane <- req:
+				continue
+			default:
+			}
+			// The lane is full: wait for it, unless the player stops.
+			select {
+			case lane <- req:
+			case <-p.stopCh:
+				return
+			}
+		case <-flush.C:
+			p.flushAsync()
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// drain handles the messages left in HandlerParamCh once the player stopped.
+func (p *Player) drain(opts DispatchOptions) {
+	opts = opts.withDefaults()
+	for {
+		select {
+		case req := <-p.HandlerParamCh:
+			p.handle(req, opts)
+		default:
+			return
+		}
+	}
+}
+
+// handle handles a message within the deadline of opts. A panicking handler
+// is logged and counted, and doesn't stop the player. Messages over their
+// rate cap are dropped (see anticheat.Module.Action), and so are the
+// duplicates of numbered messages (see handleSeq). The messages of older
+// clients are upgraded first (see protover).
+func (p *Player) handle(req *Request, opts DispatchOptions) {
+	if req.Seq != 0 {
+		p.handleSeq(req, opts)
+		return
+	}
+	id := messageId.MessageId(req.Msg.ID)
+	if !anticheat.GetMod().Action(p.UId, id) {
+		return
+	}
+	if version := atomic.LoadInt32(&p.protocol); version >= 0 {
+		data, err := protover.Default.Upgrade(req.Msg.ID, int(version), req.Msg.Data)
+		if err != nil {
+			logger.Warn("[player] PlayerID:%v msg:%v from protocol %v: %v", p.PlayerID, id, version, err)
+			return
+		}
+		req.Msg.Data = data
+	}
+	deadline := opts.Deadline
+	labels := handlerLabels{MessageId: uint32(req.Msg.ID)}
+	ctx, cancel := context.WithTimeout(req.Ctx, deadline)
+	defer cancel()
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			handlerPanics.Get(labels).Add(1)
+			logger.Error("[player] PlayerID:%v msg:%v handler panic: %v\n%s", p.PlayerID, id, r, debug.Stack())
+			return
+		}
+		if elapsed := time.Since(start); elapsed > deadline {
+			handlerOverruns.Get(labels).Add(1)
+			logger.Warn("[player] PlayerID:%v msg:%v handled in %v, deadline %v", p.PlayerID, id, elapsed, deadline)
+		}
+	}()
+	p.Handler(ctx, id, req.Msg)
+}
+
+// handleSeq handles a numbered message once, and acks it once handled; its
+// duplicates are acked again, after the responses of its first handling if
+// they're cached, or dropped while it's handled.
+func (p *Player) handleSeq(req *Request, opts DispatchOptions) {
+	verdict, responses := p.seqs.Begin(req.Seq)
+	if verdict != msgseq.New {
+		duplicates.Get(duplicateLabels{MessageId: uint32(req.Msg.ID), Verdict: verdict.String()}).Add(1)
+	}
+	switch verdict {
+	case msgseq.New:
+		// Done before the ack, so that the duplicates sent once it's
+		// received are replayed.
+		defer p.ack(req.Seq)
+		if opts.CacheResponses && opts.Workers <= 0 {
+			p.record()
+			defer func() { p.seqs.Done(req.Seq, p.stopRecording()) }()
+		} else {
+			defer p.seqs.Done(req.Seq, nil)
+		}
+		first := *req
+		first.Ctx = msgseq.NewContext(req.Ctx, p.session, req.Seq)
+		first.Seq = 0
+		p.handle(&first, opts)
+	case msgseq.Replay:
+		for _, r := range responses {
+			p.send(uint64(r.id), r.msg)
+		}
+		p.ack(req.Seq)
+	case msgseq.Stale:
+		p.ack(req.Seq)
+	}
+}
+
+// ack acks a numbered message handled.
+func (p *Player) ack(seq uint32) {
+	p.send(msgseq.AckId, wrapperspb.UInt32(seq))
+}
+
+// moduleOf returns the module handling the provided message, which orders the
+// messages dispatched to workers.
+func moduleOf(id messageId.MessageId) string {
+	if h, _ := chat.GetHandler(id); h != nil {
+		return "chat"
+	}
+	if h, _ := achievement.GetHandler(id); h != nil {
+		return "achievement"
+	}
+	if h, _ := shop.GetHandler(id); h != nil {
+		return "shop"
+	}
+	if h, _ := auction.GetHandler(id); h != nil {
+		return "auction"
+	}
+	if h, _ := family.GetHandler(id); h != nil {
+		return "family"
+	}
+	if h, _ := team.GetHandler(id); h != nil {
+		return "team"
+	}
+	if h, _ := match.GetHandler(id); h != nil {
+		// The tickets of the teams are queued by their leader.
+		return "team"
+	}
+	if h, _ := battle.GetHandler(id); h != nil {
+		return "battle"
+	}
+	if h, _ := arena.GetHandler(id); h != nil {
+		return "arena"
+	}
+	if h, _ := monster.GetHandler(id); h != nil {
+		return "monster"
+	}
+	if h, _ := buff.GetHandler(id); h != nil {
+		return "buff"
+	}
+	if h, _ := activity.GetHandler(id); h != nil {
+		return "activity"
+	}
+	if h, _ := recharge.GetHandler(id); h != nil {
+		return "recharge"
+	}
+	if h, _ := vip.GetHandler(id); h != nil {
+		// The rewards go to the bag.
+		return "bag"
+	}
+	if h, _ := battlepass.GetHandler(id); h != nil {
+		// The rewards go to the bag.
+		return "bag"
+	}
+	if h, _ := equip.GetHandler(id); h != nil {
+		// The equipment moves items in and out of the bag.
+		return "bag"
+	}
+	if h, _ := skill.GetHandler(id); h != nil {
+		// The skills consume the materials of the bag.
+		return "bag"
+	}
+	switch {
+	case friend.IsBelongToHere(id):
+		return "friend"
+	case bag.IsBelongToHere(id):
+		return "bag"
+	case task.IsBelongToHere(id):
+		return "task"
+	default:
+		return fmt.Sprint(id)
+	}
+}
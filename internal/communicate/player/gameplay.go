@@ -3,38 +3,83 @@ package player
 import (
 	"greatestworks/internal/communicate/chat"
 	email2 "greatestworks/internal/communicate/email"
+	family2 "greatestworks/internal/communicate/family"
 	"greatestworks/internal/communicate/friend"
+	team2 "greatestworks/internal/communicate/team"
+	achievement2 "greatestworks/internal/gameplay/achievement"
+	arena2 "greatestworks/internal/gameplay/arena"
+	"greatestworks/internal/gameplay/attr"
 	bag2 "greatestworks/internal/gameplay/bag"
+	battle2 "greatestworks/internal/gameplay/battle"
+	buff2 "greatestworks/internal/gameplay/buff"
 	building2 "greatestworks/internal/gameplay/building"
+	equip2 "greatestworks/internal/gameplay/equip"
+	match2 "greatestworks/internal/gameplay/match"
+	monster2 "greatestworks/internal/gameplay/monster"
 	pet2 "greatestworks/internal/gameplay/pet"
 	plant2 "greatestworks/internal/gameplay/plant"
+	reset2 "greatestworks/internal/gameplay/reset"
+	skill2 "greatestworks/internal/gameplay/skill"
 	task2 "greatestworks/internal/gameplay/task"
+	activity2 "greatestworks/internal/purchase/activity"
+	auction2 "greatestworks/internal/purchase/auction"
+	battlepass2 "greatestworks/internal/purchase/battlepass"
+	recharge2 "greatestworks/internal/purchase/recharge"
 	shop2 "greatestworks/internal/purchase/shop"
 	vip2 "greatestworks/internal/purchase/vip"
 )
 
 var (
-	_ pet2.Player       = (*Player)(nil)
-	_ shop2.IPlayer     = (*Player)(nil)
-	_ task2.Player      = (*Player)(nil)
-	_ bag2.IPlayer      = (*Player)(nil)
-	_ plant2.Player     = (*Player)(nil)
-	_ building2.IPlayer = (*Player)(nil)
-	_ email2.IPlayer    = (*Player)(nil)
-	_ vip2.Player       = (*Player)(nil)
+	_ pet2.Player         = (*Player)(nil)
+	_ shop2.IPlayer       = (*Player)(nil)
+	_ task2.Player        = (*Player)(nil)
+	_ bag2.IPlayer        = (*Player)(nil)
+	_ plant2.Player       = (*Player)(nil)
+	_ building2.IPlayer   = (*Player)(nil)
+	_ email2.IPlayer      = (*Player)(nil)
+	_ vip2.Player         = (*Player)(nil)
+	_ equip2.IPlayer      = (*Player)(nil)
+	_ equip2.Owner        = (*Player)(nil)
+	_ achievement2.Player = (*Player)(nil)
+	_ auction2.Player     = (*Player)(nil)
+	_ family2.IPlayer     = (*Player)(nil)
+	_ team2.Player        = (*Player)(nil)
+	_ match2.Player       = (*Player)(nil)
+	_ battle2.Player      = (*Player)(nil)
+	_ monster2.Player     = (*Player)(nil)
+	_ buff2.IPlayer       = (*Player)(nil)
+	_ buff2.Owner         = (*Player)(nil)
+	_ skill2.IPlayer      = (*Player)(nil)
+	_ skill2.Owner        = (*Player)(nil)
+	_ activity2.IPlayer   = (*Player)(nil)
+	_ activity2.Player    = (*Player)(nil)
+	_ recharge2.IPlayer   = (*Player)(nil)
+	_ vip2.Owner          = (*Player)(nil)
+	_ battlepass2.IPlayer = (*Player)(nil)
+	_ battlepass2.Player  = (*Player)(nil)
+	_ arena2.Player       = (*Player)(nil)
 )
 
 type GamePlay struct {
-	friendSystem   *friend.System
-	privateChat    *chat.PrivateChat
-	taskData       *task2.Data
-	petSystem      *pet2.System
-	shopData       *shop2.Data
-	bagSystem      *bag2.System
-	vip            *vip2.Vip
-	buildingSystem *building2.System
-	plantSystem    *plant2.System
-	emailData      *email2.Data
+	friendSystem    *friend.System
+	privateChat     *chat.PrivateChat
+	taskData        *task2.Data
+	petSystem       *pet2.System
+	shopData        *shop2.Data
+	bagSystem       *bag2.System
+	vip             *vip2.Vip
+	buildingSystem  *building2.System
+	plantSystem     *plant2.System
+	emailData       *email2.Data
+	equipSystem     *equip2.System
+	attrs           *attr.Sheet
+	achievementData *achievement2.Data
+	buffSystem      *buff2.System
+	skillSystem     *skill2.System
+	activityData    *activity2.Data
+	resetData       *reset2.Data
+	battlePassData  *battlepass2.Data
+	arenaData       *arena2.Data
 }
 
 func InitGamePlay() GamePlay {
@@ -81,3 +126,35 @@ func (p *GamePlay) GetPlantSystem() *plant2.System {
 func (p *GamePlay) GetEmailData() *email2.Data {
 	return p.emailData
 }
+
+func (p *GamePlay) GetEquipSystem() *equip2.System {
+	return p.equipSystem
+}
+
+func (p *GamePlay) GetAttrs() *attr.Sheet {
+	return p.attrs
+}
+
+func (p *GamePlay) GetAchievementData() *achievement2.Data {
+	return p.achievementData
+}
+
+func (p *GamePlay) GetBuffSystem() *buff2.System {
+	return p.buffSystem
+}
+
+func (p *GamePlay) GetSkillSystem() *skill2.System {
+	return p.skillSystem
+}
+
+func (p *GamePlay) GetActivityData() *activity2.Data {
+	return p.activityData
+}
+
+func (p *GamePlay) GetBattlePassData() *battlepass2.Data {
+	return p.battlePassData
+}
+
+func (p *GamePlay) GetArenaData() *arena2.Data {
+	return p.arenaData
+}
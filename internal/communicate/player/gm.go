@@ -1,12 +1,32 @@
 package player
 
 import (
+	"context"
+	"strconv"
+	"strings"
+
 	"github.com/phuhao00/greatestworks-proto/gm"
 	"greatestworks/aop/fn"
+	"greatestworks/aop/gamedata"
 	"greatestworks/aop/logger"
+	"greatestworks/internal/communicate/broadcast"
+	"greatestworks/internal/gameplay/anticheat"
+	"greatestworks/internal/purchase/activity"
 	"greatestworks/server/world/server"
 )
 
+// Ops of the GM commands.
+const (
+	gmActivitySwitch = 1 // params: activity id, 1 to enable it or 0 to disable it
+	gmAnnounce       = 2 // params: announcement id
+	gmMarquee        = 3 // the params are the text, shown at once as a marquee
+	gmCheatReview    = 4 // params: cheat flag id, 1 to confirm the cheat or 0 to clear the player
+	gmReloadData     = 5 // no params: reloads the config tables of this server
+	gmSnapshot       = 6 // params: player id, reason; archives the state of the player
+	gmArchives       = 7 // params: player id; logs the archives of the player
+	gmRestore        = 8 // params: archive id, parts (see Restore), if any; the player must be offline
+)
+
 func (p *Player) playerGMHandler(msgId uint16, data []byte) {
 	msgReceive := &gm.CSPlayerGMCmd{}
 	err := p.LogicRouter.Unmarshal(data, msgReceive)
@@ -25,5 +45,105 @@ func (p *Player) playerGMHandler(msgId uint16, data []byte) {
 	switch msgReceive.GetOp() {
 	case 0:
 		_ = params[0]
+	case gmActivitySwitch:
+		if len(params) < 2 {
+			return
+		}
+		if err := activity.GetMod().Switch(context.Background(), params[0], params[1] != 0); err != nil {
+			logger.Error("[playerGMHandler] switch activity %v PlayerID:%v err:%v", params[0], p.PlayerID, err)
+		}
+	case gmAnnounce:
+		if len(params) < 1 {
+			return
+		}
+		if err := broadcast.GetMod().Announce(context.Background(), params[0], nil); err != nil {
+			logger.Error("[playerGMHandler] announce %v PlayerID:%v err:%v", params[0], p.PlayerID, err)
+		}
+	case gmMarquee:
+		err := broadcast.GetMod().Custom(context.Background(), msgReceive.ParamStr, broadcast.KindMarquee, broadcast.PriorityUrgent)
+		if err != nil {
+			logger.Error("[playerGMHandler] marquee PlayerID:%v err:%v", p.PlayerID, err)
+		}
+	case gmCheatReview:
+		// Flag ids don't fit in the uint32 params.
+		id, verdict, _ := strings.Cut(msgReceive.ParamStr, ",")
+		flagId, err := strconv.ParseUint(strings.TrimSpace(id), 10, 64)
+		if err != nil {
+			logger.Error("[playerGMHandler] cheat review %q PlayerID:%v err:%v", msgReceive.ParamStr, p.PlayerID, err)
+			return
+		}
+		if err := anticheat.GetMod().Review(context.Background(), flagId, strings.TrimSpace(verdict) == "1", p.UId); err != nil {
+			logger.Error("[playerGMHandler] cheat review %v PlayerID:%v err:%v", flagId, p.PlayerID, err)
+		}
+	case gmReloadData:
+		// A failed reload keeps the tables loaded.
+		prev := gamedata.Default.Version()
+		tables, err := gamedata.Default.Load()
+		if err != nil {
+			logger.Error("[playerGMHandler] reload data PlayerID:%v err:%v", p.PlayerID, err)
+			return
+		}
+		logger.Info("[playerGMHandler] reload data PlayerID:%v version:%v -> %v", p.PlayerID, prev, tables.Version)
+	case gmSnapshot:
+		uid, args, err := archiveArgs(msgReceive.ParamStr)
+		if err != nil {
+			logger.Error("[playerGMHandler] snapshot %q PlayerID:%v err:%v", msgReceive.ParamStr, p.PlayerID, err)
+			return
+		}
+		a, err := Snapshot(context.Background(), uid, strings.Join(args, ","), gmOperator(p))
+		if err != nil {
+			logger.Error("[playerGMHandler] snapshot player %v PlayerID:%v err:%v", uid, p.PlayerID, err)
+			return
+		}
+		logger.Info("[playerGMHandler] snapshot player %v PlayerID:%v archive:%v", uid, p.PlayerID, a.Id)
+	case gmArchives:
+		uid, _, err := archiveArgs(msgReceive.ParamStr)
+		if err != nil {
+			logger.Error("[playerGMHandler] archives %q PlayerID:%v err:%v", msgReceive.ParamStr, p.PlayerID, err)
+			return
+		}
+		list, err := Archives(context.Background(), uid, 20)
+		if err != nil {
+			logger.Error("[playerGMHandler] archives of player %v PlayerID:%v err:%v", uid, p.PlayerID, err)
+			return
+		}
+		for _, a := range list {
+			logger.Info("[playerGMHandler] archive %v of player %v time:%v by:%v reason:%v", a.Id, uid, a.Time, a.By, a.Reason)
+		}
+	case gmRestore:
+		id, parts, err := archiveArgs(msgReceive.ParamStr)
+		if err != nil {
+			logger.Error("[playerGMHandler] restore %q PlayerID:%v err:%v", msgReceive.ParamStr, p.PlayerID, err)
+			return
+		}
+		backup, err := Restore(context.Background(), id, gmOperator(p), parts...)
+		if err != nil {
+			logger.Error("[playerGMHandler] restore archive %v PlayerID:%v err:%v", id, p.PlayerID, err)
+			return
+		}
+		logger.Info("[playerGMHandler] restore archive %v PlayerID:%v backup:%v", id, p.PlayerID, backup.Id)
+	}
+}
+
+// archiveArgs parses the params of the GM commands of the archives: an id,
+// which doesn't fit in the uint32 params, then strings.
+func archiveArgs(params string) (uint64, []string, error) {
+	args := strings.Split(params, ",")
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
 	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(args) == 1 || len(args) == 2 && args[1] == "" {
+		return id, nil, nil
+	}
+	return id, args[1:], nil
+}
+
+// gmOperator returns the operator of the GM commands sent by a player, as
+// recorded in the archives.
+func gmOperator(p *Player) string {
+	return "gm:" + strconv.FormatUint(p.UId, 10)
 }
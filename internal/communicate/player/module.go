@@ -1,10 +1,13 @@
 package player
 
 import (
+	"context"
 	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/logger"
 	"greatestworks/aop/module_router"
 	"greatestworks/internal"
 	"sync"
+	"time"
 )
 
 const (
@@ -23,7 +26,8 @@ func init() {
 type Module struct {
 	*internal.BaseModule
 	*internal.MetricsBase
-	players map[uint64]*Player
+	mu      sync.Mutex
+	players map[uint64]*Player // guarded by mu
 	addPCh  chan *Player
 }
 
@@ -44,6 +48,8 @@ func NewPlayerMgr() *Module {
 
 // Add ...
 func (pm *Module) Add(p *Player) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
 	if pm.players[p.UId] != nil {
 		return
 	}
@@ -51,21 +57,60 @@ func (pm *Module) Add(p *Player) {
 	go p.Start()
 }
 
-// Del ...
-func (pm *Module) Del(p Player) {
+// Del removes a player, and stops handling its messages.
+func (pm *Module) Del(p *Player) {
+	pm.mu.Lock()
 	delete(pm.players, p.UId)
+	pm.mu.Unlock()
+	p.Stop()
+}
+
+// StopAll stops the players, and saves their dirty sections and the updates of
+// offline players (e.g., when the server shuts down). It returns the number of
+// players that failed to save.
+func (pm *Module) StopAll(ctx context.Context) int {
+	pm.mu.Lock()
+	players := make([]*Player, 0, len(pm.players))
+	for _, p := range pm.players {
+		players = append(players, p)
+	}
+	pm.players = make(map[uint64]*Player)
+	pm.mu.Unlock()
+
+	var failed int
+	for _, p := range players {
+		p.Stop()
+		<-p.doneCh
+		if err := p.Flush(ctx); err != nil {
+			logger.Error("[StopAll] PlayerID:%v err:%v", p.UId, err)
+			failed++
+		}
+	}
+	if n := Offline.FlushAll(ctx); n > 0 {
+		logger.Error("[StopAll] offline players failed:%v", n)
+		failed += n
+	}
+	return failed
 }
 
 func (pm *Module) Run() {
+	flush := time.NewTicker(FlushInterval)
+	defer flush.Stop()
 	for {
 		select {
 		case p := <-pm.addPCh:
 			pm.Add(p)
+		case <-flush.C:
+			if n := Offline.FlushAll(context.Background()); n > 0 {
+				logger.Error("[Run] offline players failed to save:%v", n)
+			}
 		}
 	}
 }
 
 func (pm *Module) GetPlayer(uId uint64) *Player {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
 	p, ok := pm.players[uId]
 	if ok {
 		return p
@@ -74,6 +119,8 @@ func (pm *Module) GetPlayer(uId uint64) *Player {
 }
 
 func (pm *Module) GetPlayerNum() int {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
 	return len(pm.players)
 }
 
@@ -0,0 +1,431 @@
+package player
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"greatestworks/aop/logger"
+)
+
+var (
+	// ErrPlayerNotFound is returned for players that have no document.
+	ErrPlayerNotFound = errors.New("player not found")
+
+	// ErrPlayerOnline is returned when updating the data of an online
+	// player, which must be updated through the Player (see MarkDirty).
+	ErrPlayerOnline = errors.New("player is online")
+)
+
+// Offline is the store of the data of offline players, shared by the modules
+// of the process.
+var Offline = NewOfflineStore(1000)
+
+// OfflineStore gives access to the persisted data of offline players (e.g.,
+// the profile of a friend, or the mailbox a reward is sent to), without each
+// module reading the player documents itself.
+//
+// The data of the most recently used players is cached. Updates are written
+// back when their player is evicted from the cache, by FlushAll, and, before
+// anything else, when their player logs in, so that the online session loads
+// them. Once a player is online, its data is updated through the Player, and
+// updates through the store fail with ErrPlayerOnline until it logs out.
+type OfflineStore struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[uint64]*list.Element // guarded by mu; values are *offlineEntry
+	lru     *list.List               // guarded by mu; most recently used first
+	online  map[uint64]bool          // guarded by mu
+
+	// inflight holds, by player, a channel closed once the last write back of
+	// the player is done. Players aren't read from their document while
+	// their updates are being written.
+	inflight map[uint64]chan struct{} // guarded by mu
+}
+
+// offlineEntry is the cached data of an offline player.
+type offlineEntry struct {
+	uid      uint64
+	sections map[string]bson.RawValue
+	dirty    map[string]bool // sections updated since they were written back
+	migrated bool            // whether all sections must be written back
+}
+
+// NewOfflineStore returns a store caching the data of up to size players.
+func NewOfflineStore(size int) *OfflineStore {
+	if size <= 0 {
+		size = 1
+	}
+	return &OfflineStore{
+		size:     size,
+		entries:  map[uint64]*list.Element{},
+		lru:      list.New(),
+		online:   map[uint64]bool{},
+		inflight: map[uint64]chan struct{}{},
+	}
+}
+
+// OfflineData is a read-only view of the data of a player.
+type OfflineData struct {
+	UId      uint64
+	sections map[string]bson.RawValue
+}
+
+// Section decodes a section of the data (e.g., "base") into out. It returns
+// false if the player has no value for the section.
+func (d OfflineData) Section(name string, out interface{}) (bool, error) {
+	value, ok := d.sections[name]
+	if !ok {
+		return false, nil
+	}
+	if err := value.Unmarshal(out); err != nil {
+		return true, fmt.Errorf("player %v: section %q: %w", d.UId, name, err)
+	}
+	return true, nil
+}
+
+// Get returns the data of a player. The data of an online player is read from
+// its document, and lags behind the player by up to FlushInterval.
+func (s *OfflineStore) Get(ctx context.Context, uid uint64) (OfflineData, error) {
+	s.mu.Lock()
+	if e := s.lookup(uid); e != nil {
+		data := OfflineData{UId: uid, sections: copySections(e.sections)}
+		s.mu.Unlock()
+		return data, nil
+	}
+	online := s.online[uid]
+	s.mu.Unlock()
+
+	if online {
+		e, err := s.read(ctx, uid)
+		if err != nil {
+			return OfflineData{}, err
+		}
+		return OfflineData{UId: uid, sections: e.sections}, nil
+	}
+	e, err := s.load(ctx, uid)
+	if err != nil {
+		return OfflineData{}, err
+	}
+	defer s.mu.Unlock()
+	return OfflineData{UId: uid, sections: copySections(e.sections)}, nil
+}
+
+// Update updates a section of the data of an offline player: fn is called
+// with the current value of the section, if any, and returns the new one. The
+// update is written back later (see OfflineStore). fn is called with the store
+// locked, and must not use it. It returns ErrPlayerOnline if the player is
+// online.
+func (s *OfflineStore) Update(ctx context.Context, uid uint64, name string, fn func(cur bson.RawValue, ok bool) (interface{}, error)) error {
+	s.mu.Lock()
+	if s.online[uid] {
+		s.mu.Unlock()
+		return ErrPlayerOnline
+	}
+	e := s.lookup(uid)
+	if e == nil {
+		s.mu.Unlock()
+		var err error
+		if e, err = s.load(ctx, uid); err != nil {
+			return err
+		}
+	}
+	defer s.mu.Unlock()
+
+	cur, ok := e.sections[name]
+	v, err := fn(cur, ok)
+	if err != nil {
+		return err
+	}
+	typ, data, err := bson.MarshalValue(v)
+	if err != nil {
+		return fmt.Errorf("update player %v: section %q: %w", uid, name, err)
+	}
+	e.sections[name] = bson.RawValue{Type: typ, Value: data}
+	e.dirty[name] = true
+	return nil
+}
+
+// FlushAll writes back the updates of the cached players. It returns the
+// number of players whose updates failed to be written; they're retried by the
+// next flush.
+func (s *OfflineStore) FlushAll(ctx context.Context) int {
+	type write struct {
+		e      *offlineEntry
+		update bson.M
+		prev   chan struct{}
+		done   chan struct{}
+	}
+	var writes []write
+	s.mu.Lock()
+	for el := s.lru.Front(); el != nil; el = el.Next() {
+		e := el.Value.(*offlineEntry)
+		if update := e.takeUpdate(); len(update) > 0 {
+			prev, done := s.startWrite(e.uid)
+			writes = append(writes, write{e, update, prev, done})
+		}
+	}
+	s.mu.Unlock()
+
+	var failed int
+	for _, w := range writes {
+		if err := writeBack(ctx, w.e.uid, w.update, w.prev); err != nil {
+			logger.Error("[offline] %v", err)
+			s.restore(w.e, w.update)
+			failed++
+		}
+		s.finishWrite(w.e.uid, w.done)
+	}
+	return failed
+}
+
+// forget drops the cached data of an offline player, with its updates not
+// written back yet, once the updates being written back are done, e.g.
+// before its document is restored from an archive. It returns
+// ErrPlayerOnline if the player is online.
+func (s *OfflineStore) forget(ctx context.Context, uid uint64) error {
+	s.mu.Lock()
+	if s.online[uid] {
+		s.mu.Unlock()
+		return ErrPlayerOnline
+	}
+	if e := s.remove(uid); e != nil && len(e.dirty) > 0 {
+		logger.Warn("[offline] PlayerID:%v dropped updates of %v sections", uid, len(e.dirty))
+	}
+	prev := s.inflight[uid]
+	s.mu.Unlock()
+	return wait(ctx, prev)
+}
+
+// checkIn marks a player online, and writes back its updates before it loads
+// its document. If they fail to be written, the player stays offline.
+func (s *OfflineStore) checkIn(ctx context.Context, uid uint64) error {
+	s.mu.Lock()
+	s.online[uid] = true
+	var update bson.M
+	e := s.remove(uid)
+	if e != nil {
+		update = e.takeUpdate()
+	}
+	if len(update) == 0 {
+		// Wait for the updates written back before, if any.
+		prev := s.inflight[uid]
+		s.mu.Unlock()
+		return wait(ctx, prev)
+	}
+	prev, done := s.startWrite(uid)
+	s.mu.Unlock()
+
+	defer s.finishWrite(uid, done)
+	if err := writeBack(ctx, uid, update, prev); err != nil {
+		s.mu.Lock()
+		delete(s.online, uid)
+		s.mu.Unlock()
+		s.restore(e, update)
+		return err
+	}
+	return nil
+}
+
+// checkOut marks a player offline, once its data is saved.
+func (s *OfflineStore) checkOut(uid uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.online, uid)
+}
+
+// startWrite records that the updates of a player are being written back. It
+// returns the channel of the previous write back of the player, if any, which
+// must be done first, and the channel to pass to finishWrite.
+//
+// REQUIRES: s.mu is held.
+func (s *OfflineStore) startWrite(uid uint64) (prev, done chan struct{}) {
+	prev = s.inflight[uid]
+	done = make(chan struct{})
+	s.inflight[uid] = done
+	return prev, done
+}
+
+// finishWrite records that a write back started by startWrite is done.
+func (s *OfflineStore) finishWrite(uid uint64, done chan struct{}) {
+	s.mu.Lock()
+	if s.inflight[uid] == done {
+		delete(s.inflight, uid)
+	}
+	s.mu.Unlock()
+	close(done)
+}
+
+// writeBack writes the updates of a player, once the previous write back of
+// the player, if any, is done.
+func writeBack(ctx context.Context, uid uint64, update bson.M, prev chan struct{}) error {
+	if prev != nil {
+		// Wait regardless of ctx, so that the writes of a player are ordered.
+		<-prev
+	}
+	return writeDoc(ctx, uid, update)
+}
+
+// wait waits for a write back to be done, if ch isn't nil.
+func wait(ctx context.Context, ch chan struct{}) error {
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// lookup returns the cached entry of a player, if any, and marks it used.
+//
+// REQUIRES: s.mu is held.
+func (s *OfflineStore) lookup(uid uint64) *offlineEntry {
+	el, ok := s.entries[uid]
+	if !ok {
+		return nil
+	}
+	s.lru.MoveToFront(el)
+	return el.Value.(*offlineEntry)
+}
+
+// remove removes the cached entry of a player, if any, and returns it.
+//
+// REQUIRES: s.mu is held.
+func (s *OfflineStore) remove(uid uint64) *offlineEntry {
+	el, ok := s.entries[uid]
+	if !ok {
+		return nil
+	}
+	s.lru.Remove(el)
+	delete(s.entries, uid)
+	return el.Value.(*offlineEntry)
+}
+
+// load reads the document of a player and caches it, evicting the least
+// recently used players if the cache is full. If the player was cached
+// concurrently, the entry already cached is returned.
+//
+// ENSURES: s.mu is held, unless an error is returned.
+func (s *OfflineStore) load(ctx context.Context, uid uint64) (*offlineEntry, error) {
+	s.mu.Lock()
+	prev := s.inflight[uid]
+	s.mu.Unlock()
+	if err := wait(ctx, prev); err != nil {
+		return nil, err
+	}
+	e, err := s.read(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	if s.online[uid] {
+		s.mu.Unlock()
+		return nil, ErrPlayerOnline
+	}
+	if cached := s.lookup(uid); cached != nil {
+		return cached, nil
+	}
+	s.entries[uid] = s.lru.PushFront(e)
+	for s.lru.Len() > s.size {
+		s.evict(s.lru.Back().Value.(*offlineEntry))
+	}
+	return e, nil
+}
+
+// evict removes an entry from the cache, and writes back its updates in the
+// background.
+//
+// REQUIRES: s.mu is held.
+func (s *OfflineStore) evict(e *offlineEntry) {
+	s.remove(e.uid)
+	update := e.takeUpdate()
+	if len(update) == 0 {
+		return
+	}
+	prev, done := s.startWrite(e.uid)
+	go func() {
+		defer s.finishWrite(e.uid, done)
+		if err := writeBack(context.Background(), e.uid, update, prev); err != nil {
+			logger.Error("[offline] %v", err)
+			s.restore(e, update)
+		}
+	}()
+}
+
+// restore marks the sections of a failed write back dirty again, caching the
+// player again if needed. If the player came online, the updates are lost.
+func (s *OfflineStore) restore(e *offlineEntry, update bson.M) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.online[e.uid] {
+		logger.Error("[offline] PlayerID:%v lost updates of %v sections", e.uid, len(update))
+		return
+	}
+	if el, ok := s.entries[e.uid]; ok {
+		e = el.Value.(*offlineEntry)
+	} else {
+		s.entries[e.uid] = s.lru.PushFront(e)
+	}
+	for name := range e.sections {
+		if _, ok := update[sectionKey(name)]; ok {
+			e.dirty[name] = true
+		}
+	}
+}
+
+// read reads the document of a player.
+func (s *OfflineStore) read(ctx context.Context, uid uint64) (*offlineEntry, error) {
+	raw, migrated, found, err := readDoc(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("load player %v: %w", uid, ErrPlayerNotFound)
+	}
+	elems, err := raw.Elements()
+	if err != nil {
+		return nil, fmt.Errorf("load player %v: %w", uid, err)
+	}
+	e := &offlineEntry{
+		uid:      uid,
+		sections: make(map[string]bson.RawValue, len(elems)),
+		dirty:    map[string]bool{},
+		migrated: migrated,
+	}
+	for _, elem := range elems {
+		e.sections[elem.Key()] = elem.Value()
+	}
+	return e, nil
+}
+
+// takeUpdate returns the values of the sections to write back, and marks them
+// written. The values of all sections are written back if the document was
+// migrated.
+//
+// REQUIRES: the store mutex is held, or e isn't cached.
+func (e *offlineEntry) takeUpdate() bson.M {
+	update := bson.M{}
+	for name, value := range e.sections {
+		if e.migrated || e.dirty[name] {
+			update[sectionKey(name)] = value
+		}
+	}
+	e.dirty = map[string]bool{}
+	e.migrated = false
+	return update
+}
+
+func copySections(sections map[string]bson.RawValue) map[string]bson.RawValue {
+	c := make(map[string]bson.RawValue, len(sections))
+	for name, value := range sections {
+		c[name] = value
+	}
+	return c
+}
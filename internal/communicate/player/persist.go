@@ -0,0 +1,460 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/mongo"
+)
+
+// SchemaVersion is the version of the player documents written by this
+// server. Documents written with older versions are upgraded when they're
+// loaded (see RegisterMigration).
+const SchemaVersion = 1
+
+// Sections of the player document.
+const (
+	baseSection        = "base" // the BaseInfo of the player
+	bagSection         = "bag"
+	equipSection       = "equip"
+	taskSection        = "task"
+	achievementSection = "achievement"
+	shopSection        = "shop"
+	buffSection        = "buff"
+	skillSection       = "skill"
+	activitySection    = "activity"
+	resetSection       = "reset"
+	vipSection         = "vip"
+	battlePassSection  = "battlepass"
+	arenaSection       = "arena"
+)
+
+var (
+	saveLatency = metrics.NewHistogram(
+		"player_save_latency_ms",
+		"Latency of player document saves, in milliseconds",
+		metrics.NonNegativeBuckets,
+	)
+	saveFailures = metrics.NewCounter(
+		"player_save_failures",
+		"Number of failed player document saves",
+	)
+)
+
+// FlushInterval is how often the dirty sections of online players are saved.
+// It must be set before players start.
+var FlushInterval = time.Minute
+
+// Migration upgrades the sections of a player document from a schema version
+// to the next one, in place.
+type Migration func(sections bson.M) error
+
+// migrations holds the migrations by the version they upgrade from.
+var migrations = map[uint32]Migration{}
+
+// RegisterMigration registers the migration of player documents from version
+// from to version from+1. It is typically called at init time.
+func RegisterMigration(from uint32, m Migration) {
+	if from >= SchemaVersion {
+		panic(fmt.Sprintf("migration from version %d: schema version is %d", from, SchemaVersion))
+	}
+	if _, ok := migrations[from]; ok {
+		panic(fmt.Sprintf("repeat register migration from version %d", from))
+	}
+	migrations[from] = m
+}
+
+// Section is a part of the data of a player (e.g., its bag), stored as a
+// field of the player document. Save and Load are called on the player
+// goroutine.
+type Section struct {
+	// Save returns the value to store, which must not be modified after Save
+	// returns (e.g., a copy of the data), since it's written asynchronously.
+	Save func() (interface{}, error)
+
+	// Load loads the stored value. It isn't called if the document has no
+	// value for the section (e.g., a new player).
+	Load func(raw bson.RawValue) error
+}
+
+// store tracks the sections of a player, and which of them changed since they
+// were last saved.
+type store struct {
+	sections map[string]Section
+
+	dirtyMu sync.Mutex
+	dirty   map[string]bool // guarded by dirtyMu
+
+	// saving is held while a save is in flight, so that the saves of a
+	// player are written one at a time, in order.
+	saving sync.Mutex
+}
+
+func newStore() *store {
+	return &store{sections: map[string]Section{}, dirty: map[string]bool{}}
+}
+
+// RegisterSection registers a section of the data of the player. It must be
+// called before the player is loaded.
+func (p *Player) RegisterSection(name string, s Section) {
+	p.store.sections[name] = s
+}
+
+// MarkDirty marks a section of the data of the player as changed, so that it's
+// saved by the next flush.
+func (p *Player) MarkDirty(name string) {
+	p.store.dirtyMu.Lock()
+	defer p.store.dirtyMu.Unlock()
+	p.store.dirty[name] = true
+}
+
+// Load loads the document of the player, upgrading it to SchemaVersion if it
+// was written by an older server, once the updates made while it was offline
+// are written (see OfflineStore). A player transferred from another server is
+// resumed from the state it handed off instead (see TransferOut). New players
+// have no document; their sections are all dirty, so that the first flush
+// creates it.
+func (p *Player) Load(ctx context.Context) error {
+	if err := Offline.checkIn(ctx, p.UId); err != nil {
+		return err
+	}
+	if resumed, err := p.resume(ctx); resumed || err != nil {
+		return err
+	}
+	values, migrated, found, err := readDoc(ctx, p.UId)
+	if err != nil {
+		return err
+	}
+	if !found {
+		for name := range p.store.sections {
+			p.MarkDirty(name)
+		}
+		return nil
+	}
+	for name, s := range p.store.sections {
+		value, err := values.LookupErr(name)
+		if err != nil {
+			// A section added since the document was saved.
+			p.MarkDirty(name)
+			continue
+		}
+		if err := s.Load(value); err != nil {
+			return fmt.Errorf("load player %v: section %q: %w", p.UId, name, err)
+		}
+		if migrated {
+			// Save the section with the current schema version.
+			p.MarkDirty(name)
+		}
+	}
+	return nil
+}
+
+// readDoc returns the sections of the document of a player, upgraded to
+// SchemaVersion, and whether they were upgraded. found is false if the player
+// has no document. With the write-behind cache, the sections cached and not
+// flushed yet are read from the cache.
+func readDoc(ctx context.Context, uid uint64) (sections bson.Raw, migrated, found bool, err error) {
+	doc := mongo.PlayerDoc{}
+	var raw bson.Raw
+	err = mongo.Client.FindOne(ctx, doc.DB(), doc.C(), bson.M{mongo.PrimaryKey: uid}).Decode(&raw)
+	found = err == nil
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		err = nil
+	}
+	if err != nil {
+		return nil, false, false, fmt.Errorf("load player %v: %w", uid, err)
+	}
+
+	var header struct {
+		Version  uint32 `bson:"ver"`
+		Sections bson.M `bson:"sections"`
+	}
+	if found {
+		if err := bson.Unmarshal(raw, &header); err != nil {
+			return nil, false, false, fmt.Errorf("load player %v: %w", uid, err)
+		}
+	}
+	if header.Sections == nil {
+		header.Sections = bson.M{}
+	}
+	if writeBehind != nil {
+		cached, err := readCached(ctx, uid, &header.Version, header.Sections)
+		if err != nil {
+			return nil, false, false, fmt.Errorf("load player %v: %w", uid, err)
+		}
+		found = found || cached
+	}
+	if !found {
+		return nil, false, false, nil
+	}
+	values := header.Sections
+	if err := migrate(header.Version, values); err != nil {
+		return nil, false, false, fmt.Errorf("load player %v: %w", uid, err)
+	}
+
+	// Encode the sections back, so that they're read as raw values, be they
+	// migrated or not.
+	data, err := bson.Marshal(values)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("load player %v: %w", uid, err)
+	}
+	return bson.Raw(data), header.Version < SchemaVersion, true, nil
+}
+
+// migrate upgrades the sections of a player document from a schema version
+// to SchemaVersion, in place.
+func migrate(version uint32, sections bson.M) error {
+	if version > SchemaVersion {
+		return fmt.Errorf("document version %d is newer than %d", version, SchemaVersion)
+	}
+	for v := version; v < SchemaVersion; v++ {
+		if m, ok := migrations[v]; ok {
+			if err := m(sections); err != nil {
+				return fmt.Errorf("migrate from version %d: %w", v, err)
+			}
+		}
+	}
+	return nil
+}
+
+// snapshot returns the values of the dirty sections, and marks them clean.
+func (p *Player) snapshot() (bson.M, error) {
+	p.store.dirtyMu.Lock()
+	defer p.store.dirtyMu.Unlock()
+	update := bson.M{}
+	for name := range p.store.dirty {
+		s, ok := p.store.sections[name]
+		if !ok {
+			continue
+		}
+		value, err := s.Save()
+		if err != nil {
+			return nil, fmt.Errorf("section %q: %w", name, err)
+		}
+		update[sectionKey(name)] = value
+	}
+	p.store.dirty = map[string]bool{}
+	return update, nil
+}
+
+// saveAll returns the values of all the sections of the player, by name.
+func (p *Player) saveAll() (bson.M, error) {
+	values := bson.M{}
+	for name, s := range p.store.sections {
+		value, err := s.Save()
+		if err != nil {
+			return nil, fmt.Errorf("section %q: %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// sectionKey returns the key of a section in the player document.
+func sectionKey(name string) string {
+	return "sections." + name
+}
+
+// write writes the provided section values to the document of the player.
+//
+// REQUIRES: p.store.saving is held.
+func (p *Player) write(ctx context.Context, update bson.M) error {
+	return writeDoc(ctx, p.UId, update)
+}
+
+// writeDoc sets the provided fields (e.g., "sections.bag") of the document of
+// a player, creating it if needed, or writes them to the write-behind cache,
+// if enabled (see EnableWriteBehind).
+func writeDoc(ctx context.Context, uid uint64, update bson.M) error {
+	doc := mongo.PlayerDoc{}
+	update["ver"] = SchemaVersion
+	update["stm"] = time.Now().Unix()
+	start := time.Now()
+	var err error
+	if writeBehind != nil {
+		err = writeCached(ctx, uid, update)
+	} else {
+		_, err = mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C()).UpdateOne(ctx,
+			bson.M{mongo.PrimaryKey: uid},
+			bson.M{"$set": update},
+			options.Update().SetUpsert(true))
+	}
+	saveLatency.Put(float64(time.Since(start).Microseconds()) / 1000)
+	if err != nil {
+		saveFailures.Add(1)
+		return fmt.Errorf("save player %v: %w", uid, err)
+	}
+	return nil
+}
+
+// flushAsync saves the dirty sections of the player in the background. If the
+// previous save is still in flight, the sections are saved by the next flush.
+// If the save fails, the sections are marked dirty again, so that the next
+// flush retries them.
+func (p *Player) flushAsync() {
+	if !p.store.saving.TryLock() {
+		return
+	}
+	update, err := p.snapshot()
+	if err != nil || len(update) == 0 {
+		p.store.saving.Unlock()
+		if err != nil {
+			saveFailures.Add(1)
+			logger.Error("[player] PlayerID:%v snapshot err:%v", p.UId, err)
+		}
+		return
+	}
+	go func() {
+		defer p.store.saving.Unlock()
+		if err := p.write(context.Background(), update); err != nil {
+			logger.Error("[player] %v", err)
+			p.remarkFailed(update)
+		}
+	}()
+}
+
+// remarkFailed marks the sections of a failed save dirty again.
+func (p *Player) remarkFailed(update bson.M) {
+	for name := range p.store.sections {
+		if _, ok := update[sectionKey(name)]; ok {
+			p.MarkDirty(name)
+		}
+	}
+}
+
+// Flush saves the dirty sections of the player, once the save in flight, if
+// any, is done. It is called on the player goroutine, or once the player
+// stopped.
+func (p *Player) Flush(ctx context.Context) error {
+	p.store.saving.Lock()
+	defer p.store.saving.Unlock()
+	update, err := p.snapshot()
+	if err != nil {
+		saveFailures.Add(1)
+		return fmt.Errorf("save player %v: %w", p.UId, err)
+	}
+	if len(update) == 0 {
+		return nil
+	}
+	if err := p.write(ctx, update); err != nil {
+		p.remarkFailed(update)
+		return err
+	}
+	return nil
+}
+
+// registerBaseSection registers the section of the BaseInfo of the player.
+func (p *Player) registerBaseSection() {
+	p.RegisterSection(baseSection, Section{
+		Save: func() (interface{}, error) {
+			info := *p.BaseInfo
+			return info, nil
+		},
+		Load: func(raw bson.RawValue) error {
+			return raw.Unmarshal(p.BaseInfo)
+		},
+	})
+}
+
+// registerBagSections registers the sections of the bag of the player, and
+// of its equipment.
+func (p *Player) registerBagSections() {
+	p.RegisterSection(bagSection, Section{
+		Save: p.bagSystem.Save,
+		Load: p.bagSystem.Load,
+	})
+	p.RegisterSection(equipSection, Section{
+		Save: p.equipSystem.Save,
+		Load: p.equipSystem.Load,
+	})
+}
+
+// registerTaskSections registers the sections of the quests of the player,
+// and of its achievements.
+func (p *Player) registerTaskSections() {
+	p.RegisterSection(taskSection, Section{
+		Save: p.taskData.Save,
+		Load: p.taskData.Load,
+	})
+	p.RegisterSection(achievementSection, Section{
+		Save: p.achievementData.Save,
+		Load: p.achievementData.Load,
+	})
+}
+
+// registerSkillSection registers the section of the skills of the player.
+func (p *Player) registerSkillSection() {
+	p.RegisterSection(skillSection, Section{
+		Save: p.skillSystem.Save,
+		Load: p.skillSystem.Load,
+	})
+}
+
+// registerActivitySection registers the section of the participation of the
+// player in the activities.
+func (p *Player) registerActivitySection() {
+	p.RegisterSection(activitySection, Section{
+		Save: p.activityData.Save,
+		Load: p.activityData.Load,
+	})
+}
+
+// registerResetSection registers the section of the last resets the player
+// went through.
+func (p *Player) registerResetSection() {
+	p.RegisterSection(resetSection, Section{
+		Save: p.resetData.Save,
+		Load: p.resetData.Load,
+	})
+}
+
+// registerVipSection registers the section of the VIP level of the player.
+func (p *Player) registerVipSection() {
+	p.RegisterSection(vipSection, Section{
+		Save: p.vip.Save,
+		Load: p.vip.Load,
+	})
+}
+
+// registerBattlePassSection registers the section of the progress of the
+// player in the battle passes.
+func (p *Player) registerBattlePassSection() {
+	p.RegisterSection(battlePassSection, Section{
+		Save: p.battlePassData.Save,
+		Load: p.battlePassData.Load,
+	})
+}
+
+// registerArenaSection registers the section of the arena defense of the
+// player.
+func (p *Player) registerArenaSection() {
+	p.RegisterSection(arenaSection, Section{
+		Save: p.arenaData.Save,
+		Load: p.arenaData.Load,
+	})
+}
+
+// registerBuffSection registers the section of the buffs of the player that
+// outlast its sessions.
+func (p *Player) registerBuffSection() {
+	p.RegisterSection(buffSection, Section{
+		Save: p.buffSystem.Save,
+		Load: p.buffSystem.Load,
+	})
+}
+
+// registerShopSection registers the section of the purchases of the player.
+func (p *Player) registerShopSection() {
+	p.RegisterSection(shopSection, Section{
+		Save: p.shopData.Save,
+		Load: p.shopData.Load,
+	})
+}
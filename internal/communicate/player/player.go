@@ -1,66 +1,246 @@
 package player
 
 import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/phuhao00/fuse"
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"github.com/phuhao00/greatestworks-proto/player"
 	"github.com/phuhao00/greatestworks-proto/server_common"
 	"github.com/phuhao00/network"
 	"google.golang.org/protobuf/proto"
+	eventbus "greatestworks/aop/event"
 	"greatestworks/aop/logger"
+	"greatestworks/aop/msgseq"
+	"greatestworks/aop/msgtrace"
+	"greatestworks/aop/net/fanout"
+	"greatestworks/aop/protover"
+	"greatestworks/aop/redis"
+	"greatestworks/internal/communicate/broadcast"
 	"greatestworks/internal/communicate/chat"
+	"greatestworks/internal/communicate/email"
+	"greatestworks/internal/communicate/family"
 	"greatestworks/internal/communicate/friend"
+	"greatestworks/internal/communicate/team"
+	"greatestworks/internal/gameplay/achievement"
+	"greatestworks/internal/gameplay/anticheat"
+	"greatestworks/internal/gameplay/arena"
+	"greatestworks/internal/gameplay/attr"
 	"greatestworks/internal/gameplay/bag"
+	"greatestworks/internal/gameplay/battle"
+	"greatestworks/internal/gameplay/buff"
+	"greatestworks/internal/gameplay/equip"
+	"greatestworks/internal/gameplay/match"
+	"greatestworks/internal/gameplay/monster"
+	"greatestworks/internal/gameplay/reset"
+	"greatestworks/internal/gameplay/skill"
 	"greatestworks/internal/gameplay/task"
+	_ "greatestworks/internal/note/analytics" // registers the module, which only listens to the event bus
+	"greatestworks/internal/note/event/playerevent"
+	"greatestworks/internal/protocol"
+	"greatestworks/internal/purchase/activity"
+	"greatestworks/internal/purchase/auction"
+	"greatestworks/internal/purchase/battlepass"
+	"greatestworks/internal/purchase/recharge"
+	"greatestworks/internal/purchase/shop"
+	"greatestworks/internal/purchase/vip"
 )
 
 type Player struct {
 	*GamePlay
 	*BaseInfo
-	HandlerParamCh chan *network.Message
+	HandlerParamCh chan *Request
 	Session        *network.TcpSession
 	isOffline      bool
 	PlayerID       uint64
 	chanPlayerMsg  chan *player.PlayerMsgData
 	chanServerMsg  chan *server_common.ServerMsgData
 	LogicRouter    *fuse.LogicRouter
+	store          *store
+	stopOnce       sync.Once
+	stopCh         chan struct{}
+	doneCh         chan struct{} // closed when the player goroutine returns
+	seqs           *msgseq.Window[response]
+	session        string // tells the numbers of the session apart from those of the others (see msgseq.NewContext)
+	recordMu       sync.Mutex
+	recording      *[]response             // messages sent while handling a numbered message, if cached
+	protocol       int32                   // protocol version of the client, -1 if unknown (see protover)
+	sendq          *fanout.Queue[response] // messages pending for the client, if queued (see send)
+	overflowOnce   sync.Once
+}
+
+// response is a message sent to the client.
+type response struct {
+	id  messageId.MessageId
+	msg proto.Message
+}
+
+// Request is a client message handled by the player goroutine, along with the
+// context of the client request, which carries its trace context (see
+// msgtrace), and its sequence number, if numbered (see msgseq).
+type Request struct {
+	Ctx context.Context
+	Msg *network.Message
+	Seq uint32
 }
 
 func NewPlayer() *Player {
 	p := &Player{
-		GamePlay: NewGamePlay(),
+		GamePlay:       NewGamePlay(),
+		BaseInfo:       &BaseInfo{},
+		HandlerParamCh: make(chan *Request, 64),
+		store:          newStore(),
+		stopCh:         make(chan struct{}),
+		doneCh:         make(chan struct{}),
+		seqs:           msgseq.NewWindow[response](DispatchConf.SeqWindow),
+		session:        strconv.FormatInt(time.Now().UnixNano(), 36),
+		protocol:       -1,
+	}
+	if DispatchConf.SendQueue > 0 {
+		p.sendq = fanout.NewQueue[response](DispatchConf.SendQueue, DispatchConf.SendPolicy)
 	}
+	p.equipSystem = equip.NewSystem()
+	p.buffSystem = buff.NewSystem()
+	p.skillSystem = skill.NewSystem()
+	p.activityData = activity.NewData()
+	p.resetData = reset.NewData()
+	p.battlePassData = battlepass.NewData()
+	p.arenaData = arena.NewData()
+	p.achievementData = achievement.NewData()
+	p.attrs = attr.NewSheet()
+	p.attrs.OnChange(func(total attr.Attrs) {
+		p.SendMsg(protocol.MessageId_SCPlayerAttr, attr.ToProto(total))
+	})
+	p.registerBaseSection()
+	p.registerBagSections()
+	p.registerTaskSections()
+	p.registerShopSection()
+	p.registerBuffSection()
+	p.registerSkillSection()
+	p.registerActivitySection()
+	p.registerResetSection()
+	p.registerVipSection()
+	p.registerBattlePassSection()
+	p.registerArenaSection()
 	return p
 }
 
+// Start handles the messages of the player until it stops (see
+// DispatchConf), and saves its dirty sections every FlushInterval.
 func (p *Player) Start() {
-	for {
-		select {
-		case handlerParam := <-p.HandlerParamCh:
-			p.Handler(messageId.MessageId(handlerParam.ID), handlerParam)
-		}
+	defer close(p.doneCh)
+	if p.sendq != nil {
+		go p.writeLoop()
 	}
+	p.dispatch(DispatchConf)
 }
 
 func (p *Player) Stop() {
-
+	p.stopOnce.Do(func() { close(p.stopCh) })
 }
 
 func (p *Player) OnLogin() {
+	atomic.StoreInt32(&p.protocol, loadProtocol(p.UId))
 	//从db加载数据初始化
 	//同步数据给客户端
-	p.taskData.LoadFromDB()
-
+	if err := p.Load(context.Background()); err != nil {
+		logger.Error("[OnLogin] PlayerID:%v err:%v", p.UId, err)
+	}
+	anticheat.GetMod().Online(p.UId, p)
+	p.bagSystem.SetOwner(p, p.UId, func() { p.MarkDirty(bagSection) })
+	bag.GetMod().Online(p.bagSystem)
+	p.equipSystem.SetOwner(p, p.UId, func() { p.MarkDirty(equipSection) })
+	p.buffSystem.SetOwner(p, p.UId, func() { p.MarkDirty(buffSection) })
+	buff.GetMod().Online(p.buffSystem)
+	p.skillSystem.SetOwner(p, p.UId, func() { p.MarkDirty(skillSection) })
+	p.taskData.SetOwner(p, p.UId, func() { p.MarkDirty(taskSection) })
+	task.GetMod().Online(context.Background(), p.taskData)
+	p.achievementData.SetOwner(p, p.UId, func() { p.MarkDirty(achievementSection) })
+	achievement.GetMod().Online(p.achievementData)
+	p.shopData.SetOwner(p, p.UId, func() { p.MarkDirty(shopSection) })
+	shop.GetMod().Online(context.Background(), p.shopData)
+	p.activityData.SetOwner(p, p.UId, func() { p.MarkDirty(activitySection) })
+	activity.GetMod().Online(p.activityData)
+	p.vip.SetOwner(p, p.UId, func() { p.MarkDirty(vipSection) })
+	vip.GetMod().Online(p.vip)
+	p.battlePassData.SetOwner(p, p.UId, func() { p.MarkDirty(battlePassSection) })
+	battlepass.GetMod().Online(context.Background(), p.battlePassData)
+	p.arenaData.SetOwner(p, p.UId, func() { p.MarkDirty(arenaSection) })
+	arena.GetMod().Online(p.arenaData)
+	p.privateChat.SetOwner(p, p.UId)
+	chat.GetMod().Online(context.Background(), p.UId, p)
+	broadcast.GetMod().Online(p.UId, p)
+	p.friendSystem.SetOwner(p, p.UId)
+	if err := friend.GetMod().Online(context.Background(), p.friendSystem); err != nil {
+		logger.Error("[OnLogin] PlayerID:%v friends err:%v", p.UId, err)
+	}
+	if err := family.GetMod().Online(context.Background(), p); err != nil {
+		logger.Error("[OnLogin] PlayerID:%v family err:%v", p.UId, err)
+	}
+	if err := team.GetMod().Online(context.Background(), p); err != nil {
+		logger.Error("[OnLogin] PlayerID:%v team err:%v", p.UId, err)
+	}
+	match.GetMod().Online(p)
+	battle.GetMod().Online(p)
+	if p.emailData == nil {
+		p.emailData = email.NewData()
+	}
+	p.emailData.SetOwner(p, p.UId, email.Profile{Level: p.Level, RegTime: p.RegTM})
+	if err := email.GetMod().Online(context.Background(), p.emailData); err != nil {
+		logger.Error("[OnLogin] PlayerID:%v mails err:%v", p.UId, err)
+	}
+	// Last, so that the modules reset the player have its data online.
+	p.resetData.SetOwner(p.UId, func() { p.MarkDirty(resetSection) })
+	reset.GetMod().Online(p.resetData)
+	eventbus.Publish(eventbus.Default, playerevent.EnterGame{PlayerId: p.UId, Level: p.Level})
 }
 
 func (p *Player) OnLogout() {
+	eventbus.Publish(eventbus.Default, playerevent.LeaveGame{PlayerId: p.UId, Level: p.Level})
+	chat.GetMod().Offline(context.Background(), p.UId)
+	broadcast.GetMod().Offline(p.UId)
+	friend.GetMod().Offline(context.Background(), p.UId)
+	family.GetMod().Offline(p.UId)
+	monster.GetMod().Offline(p.UId)
+	battle.GetMod().Offline(context.Background(), p.UId)
+	match.GetMod().Offline(context.Background(), p.UId)
+	team.GetMod().Offline(context.Background(), p.UId)
+	email.GetMod().Offline(p.UId)
+	bag.GetMod().Offline(p.UId)
+	buff.GetMod().Offline(p.UId)
+	task.GetMod().Offline(p.UId)
+	achievement.GetMod().Offline(p.UId)
+	shop.GetMod().Offline(p.UId)
+	activity.GetMod().Offline(p.UId)
+	vip.GetMod().Offline(p.UId)
+	battlepass.GetMod().Offline(p.UId)
+	arena.GetMod().Offline(p.UId)
+	reset.GetMod().Offline(p.UId)
+	anticheat.GetMod().Offline(p.UId)
 	//存db
+	if err := p.Flush(context.Background()); err != nil {
+		logger.Error("[OnLogout] PlayerID:%v err:%v", p.UId, err)
+	}
+	Offline.checkOut(p.UId)
 }
 
 func (p *Player) GetName() string {
 	return p.Name
 }
 
+func (p *Player) GetLevel() uint32 {
+	return p.Level
+}
+
+func (p *Player) GetUId() uint64 {
+	return p.UId
+}
+
 func (p *Player) HandleClientMsgPacket(msgData *player.PlayerMsgData) {
 	if p.isOffline {
 		return
@@ -85,23 +265,152 @@ func (p *Player) HandleServerMsgPacket(serverMsgData *server_common.ServerMsgDat
 
 func (p *Player) SendMsg(ID messageId.MessageId, message proto.Message) {
 	id := uint64(ID)
-	p.Session.AsyncSend(id, message)
+	if version := atomic.LoadInt32(&p.protocol); version >= 0 {
+		var err error
+		if message, err = protover.Default.Downgrade(id, int(version), message); err != nil {
+			if !errors.Is(err, protover.ErrDrop) {
+				logger.Error("[SendMsg] PlayerID:%v msg:%v err:%v", p.UId, ID, err)
+			}
+			return
+		}
+	}
+	p.send(id, message)
+	p.recordMu.Lock()
+	if p.recording != nil {
+		*p.recording = append(*p.recording, response{ID, proto.Clone(message)})
+	}
+	p.recordMu.Unlock()
 }
 
-func (p *Player) Handler(id messageId.MessageId, msg *network.Message) {
+// loadProtocol returns the protocol version of the client of a player, which
+// the gateway records when it logs in, or -1 if unknown, in which case its
+// messages aren't adapted.
+func loadProtocol(uid uint64) int32 {
+	v, err := redis.CacheRedis().HGet(context.Background(), redis.MakeAccountKey(int64(uid)), "Protocol").Int()
+	if err != nil {
+		return -1
+	}
+	return int32(v)
+}
+
+// record starts recording the messages sent to the client.
+func (p *Player) record() {
+	p.recordMu.Lock()
+	p.recording = &[]response{}
+	p.recordMu.Unlock()
+}
+
+// stopRecording stops recording the messages sent to the client, and returns
+// them.
+func (p *Player) stopRecording() []response {
+	p.recordMu.Lock()
+	defer p.recordMu.Unlock()
+	responses := *p.recording
+	p.recording = nil
+	return responses
+}
+
+func (p *Player) Handler(ctx context.Context, id messageId.MessageId, msg *network.Message) {
 	if handler, _ := friend.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "friend", uint64(id))
 		handler.Fn(p.friendSystem, msg)
+		span.End()
 	}
 	if handler, _ := chat.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "chat", uint64(id))
 		handler.Fn(p.privateChat, msg)
+		span.End()
 	}
 
 	if handler, _ := bag.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "bag", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := achievement.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "achievement", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := equip.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "equip", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := activity.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "activity", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := skill.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "skill", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := buff.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "buff", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := shop.GetHandler(id); handler != nil {
+		ctx, span := msgtrace.Start(ctx, "shop", uint64(id))
+		handler.Fn(ctx, p, msg)
+		span.End()
+	}
+	if handler, _ := vip.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "vip", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := battlepass.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "battlepass", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := arena.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "arena", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := recharge.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "recharge", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := auction.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "auction", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := family.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "family", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := team.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "team", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := match.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "match", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := battle.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "battle", uint64(id))
+		handler.Fn(p, msg)
+		span.End()
+	}
+	if handler, _ := monster.GetHandler(id); handler != nil {
+		_, span := msgtrace.Start(ctx, "monster", uint64(id))
 		handler.Fn(p, msg)
+		span.End()
 	}
 
 	if task.IsBelongToHere(id) {
 		task.GetMod().ChIn <- &task.PlayerActionParam{
+			Ctx:       ctx,
 			MessageId: id,
 			Player:    p,
 			Packet:    msg,
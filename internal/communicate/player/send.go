@@ -0,0 +1,61 @@
+package player
+
+import (
+	"time"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/net/fanout"
+)
+
+// sendRetry is how long the writer waits for a full connection before
+// retrying a message.
+const sendRetry = 10 * time.Millisecond
+
+// SendBatch sends a batch of scene updates (see fanout.Broadcaster), which may
+// be dropped if the client doesn't keep up. The batch is shared with other
+// players and must not be modified. Unlike the responses of SendMsg, batches
+// are never replayed to the duplicates of a numbered message (see msgseq).
+func (p *Player) SendBatch(batch *wrapperspb.BytesValue) {
+	p.send(fanout.BatchId, batch)
+}
+
+// send sends a message to the client, through the send queue of the player
+// if it has one (see DispatchOptions.SendQueue). A client overflowing its
+// queue is disconnected.
+func (p *Player) send(id uint64, msg proto.Message) {
+	if p.sendq == nil {
+		p.Session.AsyncSend(id, msg)
+		return
+	}
+	if err := p.sendq.Push(response{messageId.MessageId(id), msg}, id == fanout.BatchId); err != nil {
+		p.overflowOnce.Do(func() {
+			logger.Error("[send] PlayerID:%v msg:%v queued:%v err:%v, disconnect", p.UId, id, DispatchConf.SendQueue, err)
+			p.Session.Close()
+		})
+	}
+}
+
+// writeLoop sends the messages of the send queue to the client, in order, until
+// the player stops. A message the connection has no room for is retried, so
+// that the queue fills up while the client doesn't keep up.
+func (p *Player) writeLoop() {
+	for {
+		r, ok := p.sendq.Pop(p.stopCh)
+		if !ok {
+			return
+		}
+		for !p.Session.AsyncSend(uint64(r.id), r.msg) {
+			if p.Session.IsClosed() {
+				return
+			}
+			select {
+			case <-time.After(sendRetry):
+			case <-p.stopCh:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+)
+
+// TransferTTL is how long the state of a player handed off by TransferOut is
+// kept for the server it's transferred to.
+var TransferTTL = 5 * time.Minute
+
+// transferRecord is the state of a player handed off to another server.
+type transferRecord struct {
+	From     string `bson:"from"`     // id of the server the player left
+	Saved    bool   `bson:"saved"`    // whether the sections were saved to the db too
+	Sections bson.M `bson:"sections"` // values of all sections, by name
+}
+
+// TransferOut hands a player off to another server (e.g., a cross server
+// battlefield): the player stops, once the messages it received are handled,
+// and the values of all its sections are saved to the db and stored for the
+// server it's transferred to, which resumes the player from them when it
+// loads it (see Load). The gateway of the player must hold its messages
+// meanwhile.
+//
+// If the state of the player can't be handed off, the player keeps running on
+// this server, and an error is returned.
+func (pm *Module) TransferOut(ctx context.Context, uid uint64, from string) error {
+	pm.mu.Lock()
+	p := pm.players[uid]
+	delete(pm.players, uid)
+	pm.mu.Unlock()
+	if p == nil {
+		return fmt.Errorf("transfer player %v: %w", uid, ErrPlayerNotFound)
+	}
+	p.Stop()
+	<-p.doneCh
+	p.drain(DispatchConf)
+
+	flushErr := p.Flush(ctx)
+	if flushErr != nil {
+		logger.Error("[transfer] PlayerID:%v err:%v", uid, flushErr)
+	}
+	err := p.storeTransfer(ctx, transferRecord{From: from, Saved: flushErr == nil})
+	if err != nil && flushErr != nil {
+		// The state of the player is only in memory.
+		p.restart()
+		pm.Add(p)
+		return fmt.Errorf("transfer player %v: %w", uid, err)
+	}
+	if err != nil {
+		logger.Warn("[transfer] PlayerID:%v is loaded from the db: %v", uid, err)
+	}
+	Offline.checkOut(uid)
+	return nil
+}
+
+// storeTransfer stores the values of all the sections of the player, for the
+// server it's transferred to.
+func (p *Player) storeTransfer(ctx context.Context, rec transferRecord) error {
+	sections, err := p.saveAll()
+	if err != nil {
+		return err
+	}
+	rec.Sections = sections
+	data, err := bson.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return redis.CacheRedis().Set(ctx, redis.MakeTransferKey(p.UId), data, TransferTTL).Err()
+}
+
+// resume loads the player from the state handed off by the server it was
+// transferred from, if any. It returns false if there's none.
+func (p *Player) resume(ctx context.Context) (bool, error) {
+	data, err := redis.CacheRedis().GetDel(ctx, redis.MakeTransferKey(p.UId)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("resume player %v: %w", p.UId, err)
+	}
+	var rec struct {
+		From     string   `bson:"from"`
+		Saved    bool     `bson:"saved"`
+		Sections bson.Raw `bson:"sections"`
+	}
+	if err := bson.Unmarshal(data, &rec); err != nil {
+		return false, fmt.Errorf("resume player %v: %w", p.UId, err)
+	}
+	for name, s := range p.store.sections {
+		value, err := rec.Sections.LookupErr(name)
+		if err != nil {
+			p.MarkDirty(name)
+			continue
+		}
+		if err := s.Load(value); err != nil {
+			return false, fmt.Errorf("resume player %v: section %q: %w", p.UId, name, err)
+		}
+		if !rec.Saved {
+			p.MarkDirty(name)
+		}
+	}
+	logger.Info("[transfer] PlayerID:%v resumed from:%v", p.UId, rec.From)
+	return true, nil
+}
+
+// restart prepares a stopped player to start again.
+func (p *Player) restart() {
+	p.stopOnce = sync.Once{}
+	p.stopCh = make(chan struct{})
+	p.doneCh = make(chan struct{})
+}
@@ -0,0 +1,79 @@
+package player
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	goredis "github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/writebehind"
+)
+
+// writeBehindKind is the kind of the player documents in the write-behind
+// cache.
+const writeBehindKind = "player"
+
+// writeBehind is the write-behind cache of the player documents, or nil if
+// they're written to the database directly.
+var writeBehind *writebehind.Cache
+
+// EnableWriteBehind writes the player documents to a write-behind cache in
+// Redis, flushed to the database in the background, and returns it, started;
+// it must be stopped once the players are stopped. It must be called before
+// players start.
+func EnableWriteBehind(rdb goredis.UniversalClient, opts writebehind.Options) *writebehind.Cache {
+	sink := writebehind.NewMongoSink(&mongo.PlayerDoc{}, mongo.PrimaryKey, func(key string) (interface{}, error) {
+		return strconv.ParseUint(key, 10, 64)
+	})
+	writeBehind = writebehind.NewRedis(rdb, writeBehindKind, sink, opts)
+	writeBehind.Start()
+	return writeBehind
+}
+
+func cacheKey(uid uint64) string {
+	return strconv.FormatUint(uid, 10)
+}
+
+// writeCached writes the provided fields of the document of a player to the
+// cache.
+func writeCached(ctx context.Context, uid uint64, update bson.M) error {
+	fields := make(map[string][]byte, len(update))
+	for f, v := range update {
+		b, err := writebehind.EncodeBSON(v)
+		if err != nil {
+			return fmt.Errorf("field %q: %w", f, err)
+		}
+		fields[f] = b
+	}
+	return writeBehind.Write(ctx, cacheKey(uid), fields)
+}
+
+// readCached overlays the fields of the document of a player cached, which
+// may not be flushed yet, on its version and sections read from the
+// database, and returns whether any was cached. The cached version is that
+// of all the sections cached, since the sections are all written after a
+// migration.
+func readCached(ctx context.Context, uid uint64, version *uint32, sections bson.M) (bool, error) {
+	fields, err := writeBehind.Read(ctx, cacheKey(uid))
+	if err != nil {
+		return false, err
+	}
+	for f, b := range fields {
+		v, err := writebehind.DecodeBSON(b)
+		if err != nil {
+			return false, fmt.Errorf("field %q: %w", f, err)
+		}
+		switch {
+		case f == "ver":
+			if err := v.Unmarshal(version); err != nil {
+				return false, fmt.Errorf("field %q: %w", f, err)
+			}
+		case strings.HasPrefix(f, "sections."):
+			sections[strings.TrimPrefix(f, "sections.")] = v
+		}
+	}
+	return len(fields) > 0, nil
+}
@@ -1,4 +1,22 @@
-package template
+package team
 
-type Config struct {
-}
\ No newline at end of file
+import "time"
+
+// ModuleConfig is the config of the team module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ServerId     string        // id of this server, which tells its notifications from those of the other servers
+	MaxMembers   int           // most members of a team, its leader included
+	InviteTTL    time.Duration // how long the invites stay pending
+	ReadyTimeout time.Duration // how long the members have to answer a ready check
+	TTL          time.Duration // how long a team lasts once none of its members is online
+	Retries      int           // most attempts of an update conflicting with concurrent ones
+}
+
+const (
+	defaultMaxMembers   = 5
+	defaultInviteTTL    = time.Minute
+	defaultReadyTimeout = 30 * time.Second
+	defaultTTL          = 10 * time.Minute
+	defaultRetries      = 5
+)
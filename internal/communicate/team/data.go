@@ -1,4 +0,0 @@
-package template
-
-type Data struct {
-}
@@ -1,10 +1,17 @@
-package template
+package team
 
 import (
+	"context"
 	"errors"
+	"sync"
+
 	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/greatestworks-proto/player"
 	"github.com/phuhao00/network"
-	"sync"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+	"greatestworks/internal/protocol"
 )
 
 type Handler struct {
@@ -34,6 +41,116 @@ func GetHandler(id messageId.MessageId) (*Handler, error) {
 
 func init() {
 	onceInit.Do(func() {
-
+		HandlerTeamRegister()
 	})
 }
+
+func HandlerTeamRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSTeamCreate, Create},
+		&Handler{protocol.MessageId_CSTeamInvite, Invite},
+		&Handler{protocol.MessageId_CSTeamAcceptInvite, AcceptInvite},
+		&Handler{protocol.MessageId_CSTeamLeave, Leave},
+		&Handler{protocol.MessageId_CSTeamKick, Kick},
+		&Handler{protocol.MessageId_CSTeamTransfer, Transfer},
+		&Handler{protocol.MessageId_CSTeamReadyCheck, ReadyCheck},
+		&Handler{protocol.MessageId_CSTeamReady, Ready},
+		&Handler{protocol.MessageId_CSTeamInfo, Info},
+	)
+}
+
+// reply sends the result of an operation.
+func reply(p Player, op protocol.TeamOp, err error) {
+	if err != nil {
+		logger.Warn("[team] %v PlayerID:%v err:%v", op, p.GetUId(), err)
+	}
+	p.SendMsg(protocol.MessageId_SCTeamResult, resultToProto(op, err))
+}
+
+// Create creates a team.
+func Create(p Player, packet *network.Message) {
+	_, err := GetMod().Create(context.Background(), p)
+	reply(p, protocol.TeamOp_TeamOpCreate, err)
+}
+
+// Invite invites a player to the team.
+func Invite(p Player, packet *network.Message) {
+	req := &protocol.CSTeamInvite{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Invite(context.Background(), p.GetUId(), req.PlayerId)
+	reply(p, protocol.TeamOp_TeamOpInvite, err)
+}
+
+// AcceptInvite accepts the invite of a team.
+func AcceptInvite(p Player, packet *network.Message) {
+	req := &protocol.CSTeamAcceptInvite{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().AcceptInvite(context.Background(), p, req.TeamId)
+	reply(p, protocol.TeamOp_TeamOpAcceptInvite, err)
+}
+
+// Leave leaves the team.
+func Leave(p Player, packet *network.Message) {
+	err := GetMod().Leave(context.Background(), p.GetUId())
+	reply(p, protocol.TeamOp_TeamOpLeave, err)
+}
+
+// Kick kicks a member out of the team.
+func Kick(p Player, packet *network.Message) {
+	req := &protocol.CSTeamKick{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Kick(context.Background(), p.GetUId(), req.PlayerId)
+	reply(p, protocol.TeamOp_TeamOpKick, err)
+}
+
+// Transfer hands the leadership over to a member.
+func Transfer(p Player, packet *network.Message) {
+	req := &protocol.CSTeamTransfer{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().TransferLeader(context.Background(), p.GetUId(), req.PlayerId)
+	reply(p, protocol.TeamOp_TeamOpTransfer, err)
+}
+
+// ReadyCheck starts a ready check.
+func ReadyCheck(p Player, packet *network.Message) {
+	req := &protocol.CSTeamReadyCheck{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().StartReadyCheck(context.Background(), p.GetUId(), req.Target)
+	reply(p, protocol.TeamOp_TeamOpReadyCheck, err)
+}
+
+// Ready answers the ready check.
+func Ready(p Player, packet *network.Message) {
+	req := &protocol.CSTeamReady{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Ready(context.Background(), p.GetUId(), req.Ready)
+	reply(p, protocol.TeamOp_TeamOpReady, err)
+}
+
+// Info sends the team of the player.
+func Info(p Player, packet *network.Message) {
+	ctx := context.Background()
+	id, err := teamOfPlayer(ctx, p.GetUId())
+	if err != nil {
+		reply(p, protocol.TeamOp_TeamOpInfo, err)
+		return
+	}
+	t, err := loadTeam(ctx, redis.Get(), id)
+	if err != nil {
+		reply(p, protocol.TeamOp_TeamOpInfo, err)
+		return
+	}
+	p.SendMsg(protocol.MessageId_SCTeamInfo, toProto(t))
+}
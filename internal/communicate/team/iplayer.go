@@ -1,4 +1,14 @@
-package template
+package team
 
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+)
+
+// Player is the player handling the messages of the teams.
 type Player interface {
+	GetUId() uint64
+	GetName() string
+	GetLevel() uint32
+	SendMsg(ID messageId.MessageId, message proto.Message)
 }
@@ -1,9 +1,19 @@
-package template
+package team
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
 	"greatestworks/aop/module_router"
+	"greatestworks/aop/redis"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/communicate/chat"
+	"greatestworks/internal/protocol"
 )
 
 const (
@@ -12,26 +22,228 @@ const (
 
 var (
 	Mod         *Module
-	OnceInitMod sync.Once
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNoTeam       = errors.New("team: no such team")
+	ErrInTeam       = errors.New("team: already in a team")
+	ErrNotInTeam    = errors.New("team: not in a team")
+	ErrNotMember    = errors.New("team: not a member of the team")
+	ErrNotLeader    = errors.New("team: not the leader of the team")
+	ErrFull         = errors.New("team: team full")
+	ErrNoInvite     = errors.New("team: no such invite")
+	ErrOffline      = errors.New("team: member offline")
+	ErrChecking     = errors.New("team: ready check in progress")
+	ErrNoReadyCheck = errors.New("team: no ready check in progress")
+	ErrConflict     = errors.New("team: too many concurrent updates")
+)
+
+var (
+	conflicts = metrics.NewCounter(
+		"team_update_conflicts",
+		"Number of team updates retried because of concurrent updates",
+	)
+	readyChecks = metrics.NewCounterMap[readyLabels](
+		"team_ready_checks",
+		"Number of ready checks of the teams, by outcome",
+	)
 )
 
+type readyLabels struct {
+	Ready bool
+}
+
 func init() {
-	internal.ModuleManager.RegisterModule(ModuleName, Mod)
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Module manages the teams (parties). The teams are shared by all the
+// servers, in Redis, so that members online on different servers may play
+// together (e.g., enter an instance once they're all ready, see
+// teamevent.Ready); the servers notify each other of the changes (see
+// notify), so that the members online anywhere see them. The teams of which
+// no member is online expire.
+type Module struct {
+	*internal.BaseModule
+	initFlag     bool
+	serverId     string
+	maxMembers   int
+	inviteTTL    time.Duration
+	readyTimeout time.Duration
+	ttl          time.Duration
+	retries      int
+	stopCh       chan struct{}
+
+	mu     sync.Mutex
+	online map[uint64]Player // guarded by mu
+	local  map[uint64]uint64 // teams of the online players, by player; guarded by mu
 }
 
 func GetMod() *Module {
-	OnceInitMod.Do(func() {
-		Mod = &Module{internal.NewBaseModule()}
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
 	})
 	return Mod
 }
 
-type Module struct {
-	*internal.BaseModule
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.serverId = conf.ServerId
+	m.maxMembers = conf.MaxMembers
+	if m.maxMembers <= 1 {
+		m.maxMembers = defaultMaxMembers
+	}
+	m.inviteTTL = conf.InviteTTL
+	if m.inviteTTL <= 0 {
+		m.inviteTTL = defaultInviteTTL
+	}
+	m.readyTimeout = conf.ReadyTimeout
+	if m.readyTimeout <= 0 {
+		m.readyTimeout = defaultReadyTimeout
+	}
+	m.ttl = conf.TTL
+	if m.ttl <= 0 {
+		m.ttl = defaultTTL
+	}
+	m.retries = conf.Retries
+	if m.retries <= 0 {
+		m.retries = defaultRetries
+	}
+	m.online = make(map[uint64]Player)
+	m.local = make(map[uint64]uint64)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts delivering the notifications of the other servers, and
+// keeping the teams of the online players alive.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	go m.runRelay()
+	go m.runKeepAlive()
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+	}
+}
+
+// Online registers a player that logged in to this server: it's marked
+// online in its team, if any, joins the chat channel of the team, and the
+// team is pushed.
+func (m *Module) Online(ctx context.Context, p Player) error {
+	uid := p.GetUId()
+	m.mu.Lock()
+	m.online[uid] = p
+	m.mu.Unlock()
+	id, err := teamOf(ctx, uid)
+	if err != nil || id == 0 {
+		return err
+	}
+	t, err := m.update(ctx, id, func(t *Team) error {
+		mb := t.member(uid)
+		if mb == nil {
+			return ErrNotMember
+		}
+		mb.Name, mb.Level, mb.Server = p.GetName(), p.GetLevel(), m.serverId
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.local[uid] = id
+	m.mu.Unlock()
+	if err := chat.GetMod().Join(chat.ChannelTeam, id, uid); err != nil {
+		logger.Error("[team] join chat of team %v PlayerID:%v err:%v", id, uid, err)
+	}
+	p.SendMsg(protocol.MessageId_SCTeamInfo, toProto(t))
+	m.notify(ctx, notification{Kind: notifyChanged, TeamId: id})
+	return nil
+}
+
+// Offline unregisters a player that logged out: it's marked offline in its
+// team, which cancels the ready check in progress, and hands the leadership
+// over to a member online if it led the team.
+func (m *Module) Offline(ctx context.Context, uid uint64) {
+	m.mu.Lock()
+	id := m.local[uid]
+	delete(m.online, uid)
+	delete(m.local, uid)
+	m.mu.Unlock()
+	if id == 0 {
+		return
+	}
+	chat.GetMod().Leave(chat.ChannelTeam, uid)
+	_, err := m.update(ctx, id, func(t *Team) error {
+		mb := t.member(uid)
+		if mb == nil {
+			return ErrNotMember
+		}
+		mb.Server = ""
+		t.Check = nil
+		if t.Leader == uid {
+			t.succeed()
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Warn("[team] mark PlayerID:%v offline in team %v err:%v", uid, id, err)
+		return
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, TeamId: id})
+}
+
+// runKeepAlive extends the teams of the online players periodically, until
+// the module is stopped.
+func (m *Module) runKeepAlive() {
+	ticker := time.NewTicker(m.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), m.ttl/3)
+			m.mu.Lock()
+			teams := make(map[uint64]bool, len(m.local))
+			for _, id := range m.local {
+				teams[id] = true
+			}
+			m.mu.Unlock()
+			for id := range teams {
+				t, err := loadTeam(ctx, redis.Get(), id)
+				if err != nil {
+					continue
+				}
+				if err := redis.Get().Expire(ctx, teamKey(id), m.ttl).Err(); err != nil {
+					logger.Error("[team] extend team %v err:%v", id, err)
+					continue
+				}
+				m.keepAlive(ctx, t)
+			}
+			cancel()
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
 }
 
-func (m *Module) SetName(name string) {
-	m.BaseModule.SetName(name)
+// Dependencies returns the modules the team module depends on: the members
+// chat in the channel of their team.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Chat.String()}
 }
 
 func (m *Module) RegisterHandler() {
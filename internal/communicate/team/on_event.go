@@ -1,23 +1,14 @@
-package template
+package team
 
 import (
 	"greatestworks/internal"
 	"greatestworks/internal/note/event"
 )
 
-type EventHandle func(iEvent event.IEvent)
-
-type EventWrap struct {
-	Player
-	event.IEvent
-}
-
+// OnEvent is unused: the teams publish their events on the event bus (see
+// teamevent), and handle none.
 func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
-	//TODO implement me
-	panic("implement me")
 }
 
 func (m *Module) SetEventCategoryActive(eventCategory int) {
-	//TODO implement me
-	panic("implement me")
 }
@@ -0,0 +1,280 @@
+package team
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+	"greatestworks/internal/communicate/friend"
+	"greatestworks/internal/note/event/teamevent"
+)
+
+// teamOfPlayer returns the id of the team of a player, or ErrNotInTeam.
+func teamOfPlayer(ctx context.Context, uid uint64) (uint64, error) {
+	id, err := teamOf(ctx, uid)
+	if err == nil && id == 0 {
+		err = ErrNotInTeam
+	}
+	return id, err
+}
+
+// Get returns the team of a player, or nil.
+func (m *Module) Get(ctx context.Context, uid uint64) (*Team, error) {
+	id, err := teamOf(ctx, uid)
+	if err != nil || id == 0 {
+		return nil, err
+	}
+	t, err := loadTeam(ctx, redis.Get(), id)
+	if err == ErrNoTeam {
+		return nil, nil
+	}
+	return t, err
+}
+
+// Create creates a team led by a player.
+func (m *Module) Create(ctx context.Context, p Player) (*Team, error) {
+	uid := p.GetUId()
+	id, err := idgenerator.NextId()
+	if err != nil {
+		return nil, fmt.Errorf("team id: %w", err)
+	}
+	if err := m.claimMembership(ctx, uid, id); err != nil {
+		return nil, err
+	}
+	t := &Team{
+		Id:      id,
+		Leader:  uid,
+		Members: []Member{{Id: uid, Name: p.GetName(), Level: p.GetLevel(), Server: m.serverId}},
+	}
+	if err := m.save(ctx, t); err != nil {
+		if releaseErr := releaseMembership(ctx, uid, id); releaseErr != nil {
+			logger.Error("[team] PlayerID:%v err:%v", uid, releaseErr)
+		}
+		return nil, fmt.Errorf("create team: %w", err)
+	}
+	eventbus.Publish(eventbus.Default, teamevent.Created{TeamId: id, Leader: uid})
+	m.notify(ctx, notification{Kind: notifyJoined, TeamId: id, Targets: []uint64{uid}})
+	return t, nil
+}
+
+// Invite invites a player to the team of a member.
+func (m *Module) Invite(ctx context.Context, uid, target uint64) error {
+	id, err := teamOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if cur, err := teamOf(ctx, target); err != nil {
+		return err
+	} else if cur != 0 {
+		return ErrInTeam
+	}
+	now := time.Now()
+	_, err = m.update(ctx, id, func(t *Team) error {
+		if t.member(uid) == nil {
+			return ErrNotMember
+		}
+		if len(t.Members) >= m.maxMembers {
+			return ErrFull
+		}
+		t.takeInvite(target, now)
+		t.Invites = append(t.Invites, InviteInfo{PlayerId: target, From: uid, Expire: now.Add(m.inviteTTL).Unix()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.notify(ctx, notification{Kind: notifyInvited, TeamId: id, Targets: []uint64{target}, From: uid})
+	return nil
+}
+
+// AcceptInvite accepts the invite of a team to a player. The membership of
+// the player is claimed first, so that it never joins two teams at once.
+func (m *Module) AcceptInvite(ctx context.Context, p Player, id uint64) error {
+	uid := p.GetUId()
+	if err := m.claimMembership(ctx, uid, id); err != nil {
+		return err
+	}
+	t, err := m.update(ctx, id, func(t *Team) error {
+		if !t.takeInvite(uid, time.Now()) {
+			return ErrNoInvite
+		}
+		if len(t.Members) >= m.maxMembers {
+			return ErrFull
+		}
+		t.Members = append(t.Members, Member{Id: uid, Name: p.GetName(), Level: p.GetLevel(), Server: m.serverId})
+		t.Check = nil
+		return nil
+	})
+	if err != nil {
+		if releaseErr := releaseMembership(ctx, uid, id); releaseErr != nil {
+			logger.Error("[team] PlayerID:%v err:%v", uid, releaseErr)
+		}
+		return err
+	}
+	eventbus.Publish(eventbus.Default, teamevent.Joined{TeamId: id, PlayerId: uid, Members: len(t.Members)})
+	if err := friend.GetMod().Met(ctx, uid, t.ids()...); err != nil {
+		logger.Warn("[team] met members of team %v PlayerID:%v err:%v", id, uid, err)
+	}
+	m.notify(ctx, notification{Kind: notifyJoined, TeamId: id, Targets: []uint64{uid}})
+	return nil
+}
+
+// Leave removes a player from its team. If it led the team, the leadership
+// goes to another member; the team is disbanded once it has no member.
+func (m *Module) Leave(ctx context.Context, uid uint64) error {
+	id, err := teamOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	t, err := m.update(ctx, id, func(t *Team) error {
+		if !t.remove(uid) {
+			return ErrNotMember
+		}
+		t.Check = nil
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.left(ctx, t, uid, false)
+	return nil
+}
+
+// Kick kicks a member out of the team of its leader.
+func (m *Module) Kick(ctx context.Context, uid, target uint64) error {
+	id, err := teamOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	t, err := m.update(ctx, id, func(t *Team) error {
+		if t.Leader != uid || target == uid {
+			return ErrNotLeader
+		}
+		if !t.remove(target) {
+			return ErrNotMember
+		}
+		t.Check = nil
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.left(ctx, t, target, true)
+	return nil
+}
+
+// left releases the membership of a player that left a team, and notifies
+// it and the members.
+func (m *Module) left(ctx context.Context, t *Team, uid uint64, kicked bool) {
+	if err := releaseMembership(ctx, uid, t.Id); err != nil {
+		logger.Error("[team] PlayerID:%v err:%v", uid, err)
+	}
+	eventbus.Publish(eventbus.Default, teamevent.Left{TeamId: t.Id, PlayerId: uid, Kicked: kicked, Members: len(t.Members)})
+	m.notify(ctx, notification{Kind: notifyLeft, TeamId: t.Id, Targets: []uint64{uid}, Kicked: kicked})
+}
+
+// TransferLeader hands the leadership of a team over to a member.
+func (m *Module) TransferLeader(ctx context.Context, uid, target uint64) error {
+	id, err := teamOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	_, err = m.update(ctx, id, func(t *Team) error {
+		if t.Leader != uid {
+			return ErrNotLeader
+		}
+		if t.member(target) == nil {
+			return ErrNotMember
+		}
+		t.Leader = target
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, TeamId: id})
+	return nil
+}
+
+// StartReadyCheck starts checking that all the members of the team of its
+// leader, who must all be online, are ready for target (e.g., to enter an
+// instance). The leader is ready.
+func (m *Module) StartReadyCheck(ctx context.Context, uid uint64, target uint32) error {
+	id, err := teamOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	t, err := m.update(ctx, id, func(t *Team) error {
+		if t.Leader != uid {
+			return ErrNotLeader
+		}
+		if t.Check != nil && t.Check.Deadline > now.Unix() {
+			return ErrChecking
+		}
+		for _, mb := range t.Members {
+			if mb.Server == "" {
+				return ErrOffline
+			}
+		}
+		t.Check = &ReadyCheckState{Target: target, Deadline: now.Add(m.readyTimeout).Unix(), Ready: []uint64{uid}}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(t.Members) == 1 {
+		return m.Ready(ctx, uid, true)
+	}
+	m.notify(ctx, notification{Kind: notifyChanged, TeamId: id})
+	return nil
+}
+
+// Ready answers the ready check of the team of a member. The check is over
+// once a member isn't ready, or all are; then teamevent.Ready is published,
+// on this server.
+func (m *Module) Ready(ctx context.Context, uid uint64, ready bool) error {
+	id, err := teamOfPlayer(ctx, uid)
+	if err != nil {
+		return err
+	}
+	var check ReadyCheckState
+	done := false
+	t, err := m.update(ctx, id, func(t *Team) error {
+		if t.member(uid) == nil {
+			return ErrNotMember
+		}
+		if t.Check == nil || t.Check.Deadline <= time.Now().Unix() {
+			return ErrNoReadyCheck
+		}
+		done = !ready || t.Check.ready(t, uid)
+		check = *t.Check
+		if done {
+			t.Check = nil
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if done {
+		readyChecks.Get(readyLabels{Ready: ready}).Add(1)
+	}
+	if done && ready {
+		e := teamevent.Ready{TeamId: id, Leader: t.Leader, Target: check.Target, Members: map[uint64]string{}}
+		for _, mb := range t.Members {
+			e.Members[mb.Id] = mb.Server
+		}
+		eventbus.Publish(eventbus.Default, e)
+	}
+	n := notification{Kind: notifyChanged, TeamId: id}
+	if done && ready {
+		n.Kind, n.Target = notifyReady, check.Target
+	}
+	m.notify(ctx, n)
+	return nil
+}
@@ -0,0 +1,25 @@
+package team
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+func toProto(t *Team) *protocol.SCTeamInfo {
+	pb := &protocol.SCTeamInfo{Id: t.Id, Leader: t.Leader}
+	for _, mb := range t.Members {
+		pb.Members = append(pb.Members, &protocol.TeamMember{
+			Id:     mb.Id,
+			Name:   mb.Name,
+			Level:  mb.Level,
+			Online: mb.Server != "",
+		})
+	}
+	if t.Check != nil {
+		pb.Check = &protocol.TeamReadyCheck{Target: t.Check.Target, Deadline: t.Check.Deadline, Ready: t.Check.Ready}
+	}
+	return pb
+}
+
+func resultToProto(op protocol.TeamOp, err error) *protocol.SCTeamResult {
+	return &protocol.SCTeamResult{Op: op, Ok: err == nil}
+}
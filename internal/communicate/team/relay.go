@@ -0,0 +1,137 @@
+package team
+
+import (
+	"context"
+	"encoding/json"
+
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+	"greatestworks/internal/communicate/chat"
+	"greatestworks/internal/protocol"
+)
+
+// relayChannel is the Redis pub/sub channel on which the servers notify each
+// other of the changes of the teams.
+const relayChannel = "team:relay"
+
+// Kinds of notifications.
+const (
+	notifyJoined  = "joined"  // the targets joined the team
+	notifyLeft    = "left"    // the targets left the team
+	notifyChanged = "changed" // the team changed
+	notifyInvited = "invited" // the targets were invited to the team
+	notifyReady   = "ready"   // all the members are ready
+)
+
+type notification struct {
+	Server  string   `json:"server"`
+	Kind    string   `json:"kind"`
+	TeamId  uint64   `json:"team"`
+	Targets []uint64 `json:"targets,omitempty"`
+	Kicked  bool     `json:"kicked,omitempty"`
+	From    uint64   `json:"from,omitempty"`   // who invited the targets
+	Target  uint32   `json:"target,omitempty"` // what the members got ready for
+}
+
+// notify notifies the players concerned by a change of a team, wherever
+// they're online.
+func (m *Module) notify(ctx context.Context, n notification) {
+	n.Server = m.serverId
+	m.deliver(ctx, n)
+	b, err := json.Marshal(n)
+	if err != nil {
+		logger.Error("[team] marshal %+v failed: %v", n, err)
+		return
+	}
+	if err := redis.Get().Publish(ctx, relayChannel, b).Err(); err != nil {
+		logger.Error("[team] publish failed: %v", err)
+	}
+}
+
+// deliver delivers a notification to the players online on this server: the
+// targets that joined or left the team join or leave its chat channel, and
+// the members online get the team again.
+func (m *Module) deliver(ctx context.Context, n notification) {
+	var targets []Player
+	m.mu.Lock()
+	for _, uid := range n.Targets {
+		p := m.online[uid]
+		if p == nil {
+			continue
+		}
+		switch n.Kind {
+		case notifyJoined:
+			m.local[uid] = n.TeamId
+		case notifyLeft:
+			if m.local[uid] == n.TeamId {
+				delete(m.local, uid)
+			}
+		}
+		targets = append(targets, p)
+	}
+	var members []Player
+	for uid, id := range m.local {
+		if id == n.TeamId {
+			members = append(members, m.online[uid])
+		}
+	}
+	m.mu.Unlock()
+
+	for _, p := range targets {
+		switch n.Kind {
+		case notifyJoined:
+			if err := chat.GetMod().Join(chat.ChannelTeam, n.TeamId, p.GetUId()); err != nil {
+				logger.Error("[team] join chat of team %v PlayerID:%v err:%v", n.TeamId, p.GetUId(), err)
+			}
+		case notifyLeft:
+			chat.GetMod().Leave(chat.ChannelTeam, p.GetUId())
+			p.SendMsg(protocol.MessageId_SCTeamLeft, &protocol.SCTeamLeft{TeamId: n.TeamId, Kicked: n.Kicked})
+		case notifyInvited:
+			p.SendMsg(protocol.MessageId_SCTeamInvite, &protocol.SCTeamInvite{TeamId: n.TeamId, From: n.From})
+		}
+	}
+	if len(members) == 0 {
+		return
+	}
+	t, err := loadTeam(ctx, redis.Get(), n.TeamId)
+	if err != nil {
+		// Disbanded since.
+		return
+	}
+	pb := toProto(t)
+	for _, p := range members {
+		p.SendMsg(protocol.MessageId_SCTeamInfo, pb)
+		if n.Kind == notifyReady {
+			p.SendMsg(protocol.MessageId_SCTeamReady, &protocol.SCTeamReady{TeamId: n.TeamId, Target: n.Target})
+		}
+	}
+}
+
+// runRelay delivers the notifications of the other servers, until the module
+// is stopped.
+func (m *Module) runRelay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := redis.Get().Subscribe(ctx, relayChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var n notification
+			if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+				logger.Error("[team] unmarshal %q failed: %v", msg.Payload, err)
+				continue
+			}
+			if n.Server == m.serverId {
+				continue
+			}
+			m.deliver(ctx, n)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package team
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+)
+
+// releaseScript deletes the membership of a player, if it's still in the
+// team.
+var releaseScript = redis.NewScript("team.release", "if redis.call('get', KEYS[1]) == ARGV[1] then return redis.call('del', KEYS[1]) else return 0 end")
+
+// teamKey returns the Redis key of a team, in JSON.
+func teamKey(id uint64) string {
+	return "team:" + strconv.FormatUint(id, 10)
+}
+
+// memberKey returns the Redis key of the id of the team of a player. A
+// player is in a single team, whatever the server it joins from.
+func memberKey(uid uint64) string {
+	return "team:of:" + strconv.FormatUint(uid, 10)
+}
+
+// loadTeam loads a team.
+func loadTeam(ctx context.Context, rdb goredis.Cmdable, id uint64) (*Team, error) {
+	b, err := rdb.Get(ctx, teamKey(id)).Bytes()
+	if err == goredis.Nil {
+		return nil, ErrNoTeam
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load team %v: %w", id, err)
+	}
+	t := &Team{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, fmt.Errorf("load team %v: %w", id, err)
+	}
+	return t, nil
+}
+
+// teamOf returns the id of the team of a player, or 0. The membership of a
+// player in a team that expired is dropped.
+func teamOf(ctx context.Context, uid uint64) (uint64, error) {
+	rdb := redis.Get()
+	id, err := rdb.Get(ctx, memberKey(uid)).Uint64()
+	if err == goredis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("load team of player %v: %w", uid, err)
+	}
+	n, err := rdb.Exists(ctx, teamKey(id)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("load team of player %v: %w", uid, err)
+	}
+	if n == 0 {
+		releaseMembership(ctx, uid, id)
+		return 0, nil
+	}
+	return id, nil
+}
+
+// claimMembership records that a player is in a team. It fails with
+// ErrInTeam if the player is in a team already.
+func (m *Module) claimMembership(ctx context.Context, uid, id uint64) error {
+	ok, err := redis.Get().SetNX(ctx, memberKey(uid), id, m.ttl).Result()
+	if err != nil {
+		return fmt.Errorf("claim membership of player %v: %w", uid, err)
+	}
+	if !ok {
+		if cur, err := teamOf(ctx, uid); err != nil || cur != 0 {
+			return ErrInTeam
+		}
+		// The team of the player expired: try again.
+		return m.claimMembership(ctx, uid, id)
+	}
+	return nil
+}
+
+// releaseMembership records that a player left a team.
+func releaseMembership(ctx context.Context, uid, id uint64) error {
+	err := releaseScript.Run(ctx, redis.Get(), []string{memberKey(uid)}, strconv.FormatUint(id, 10)).Err()
+	if err != nil && err != goredis.Nil {
+		return fmt.Errorf("release membership of player %v: %w", uid, err)
+	}
+	return nil
+}
+
+// save saves a new team.
+func (m *Module) save(ctx context.Context, t *Team) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return redis.Get().Set(ctx, teamKey(t.Id), b, m.ttl).Err()
+}
+
+// update applies change to a team, and saves it if it didn't change since it
+// was loaded; otherwise it loads it again and retries, up to
+// ModuleConfig.Retries times. change must have no side effect out of the
+// team. A team left with no member is deleted. It returns the team saved.
+func (m *Module) update(ctx context.Context, id uint64, change func(t *Team) error) (*Team, error) {
+	key := teamKey(id)
+	var t *Team
+	for i := 0; i < m.retries; i++ {
+		err := redis.Get().Watch(ctx, func(tx *goredis.Tx) error {
+			var err error
+			if t, err = loadTeam(ctx, tx, id); err != nil {
+				return err
+			}
+			if err := change(t); err != nil {
+				return err
+			}
+			b, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+				if len(t.Members) == 0 {
+					pipe.Del(ctx, key)
+				} else {
+					pipe.Set(ctx, key, b, m.ttl)
+				}
+				return nil
+			})
+			return err
+		}, key)
+		if errors.Is(err, goredis.TxFailedErr) {
+			conflicts.Add(1)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.keepAlive(ctx, t)
+		return t, nil
+	}
+	return nil, ErrConflict
+}
+
+// keepAlive extends the memberships of the members of a team, which last as
+// long as the team.
+func (m *Module) keepAlive(ctx context.Context, t *Team) {
+	if len(t.Members) == 0 {
+		return
+	}
+	_, err := redis.Get().Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, mb := range t.Members {
+			pipe.Expire(ctx, memberKey(mb.Id), m.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("[team] extend memberships of team %v err:%v", t.Id, err)
+	}
+}
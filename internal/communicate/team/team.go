@@ -0,0 +1,118 @@
+package team
+
+import "time"
+
+// Member is a member of a team.
+type Member struct {
+	Id     uint64 `json:"id"`
+	Name   string `json:"name"`
+	Level  uint32 `json:"level"`
+	Server string `json:"server,omitempty"` // server the member is online on, "" if offline
+}
+
+// InviteInfo is a pending invite of a player to a team.
+type InviteInfo struct {
+	PlayerId uint64 `json:"id"`
+	From     uint64 `json:"from"`
+	Expire   int64  `json:"exp"`
+}
+
+// ReadyCheckState is the check, started by the leader, that all the members
+// are ready for something (e.g., to enter an instance).
+type ReadyCheckState struct {
+	Target   uint32   `json:"target"` // what the members get ready for, e.g. the id of an instance
+	Deadline int64    `json:"deadline"`
+	Ready    []uint64 `json:"ready"` // members who answered they're ready
+}
+
+// Team is a team (party). Teams are shared by all the servers, in Redis, so
+// that members online on different servers may play together.
+type Team struct {
+	Id      uint64           `json:"id"`
+	Leader  uint64           `json:"leader"`
+	Members []Member         `json:"members"`
+	Invites []InviteInfo     `json:"invites,omitempty"`
+	Check   *ReadyCheckState `json:"check,omitempty"`
+}
+
+// member returns a member of the team, or nil.
+func (t *Team) member(uid uint64) *Member {
+	for i := range t.Members {
+		if t.Members[i].Id == uid {
+			return &t.Members[i]
+		}
+	}
+	return nil
+}
+
+// remove removes a member of the team, and returns whether it was one. If it
+// was the leader, the leadership goes to the first member online, if any,
+// or else to the first member.
+func (t *Team) remove(uid uint64) bool {
+	for i := range t.Members {
+		if t.Members[i].Id != uid {
+			continue
+		}
+		t.Members = append(t.Members[:i], t.Members[i+1:]...)
+		if t.Leader == uid {
+			t.succeed()
+		}
+		return true
+	}
+	return false
+}
+
+// succeed hands the leadership over to the first member online, if any, or
+// else to the first member.
+func (t *Team) succeed() {
+	t.Leader = 0
+	for _, mb := range t.Members {
+		if mb.Server != "" {
+			t.Leader = mb.Id
+			return
+		}
+	}
+	if len(t.Members) > 0 {
+		t.Leader = t.Members[0].Id
+	}
+}
+
+// ids returns the ids of the members.
+func (t *Team) ids() []uint64 {
+	ids := make([]uint64, 0, len(t.Members))
+	for _, mb := range t.Members {
+		ids = append(ids, mb.Id)
+	}
+	return ids
+}
+
+// takeInvite removes the pending invite of a player, dropping the expired
+// ones, and returns whether it was there.
+func (t *Team) takeInvite(uid uint64, now time.Time) bool {
+	found := false
+	kept := t.Invites[:0]
+	for _, inv := range t.Invites {
+		switch {
+		case inv.Expire <= now.Unix():
+		case inv.PlayerId == uid:
+			found = true
+		default:
+			kept = append(kept, inv)
+		}
+	}
+	t.Invites = kept
+	return found
+}
+
+// ready records that a member is ready, and returns whether all the members
+// are.
+func (c *ReadyCheckState) ready(t *Team, uid uint64) bool {
+	found := false
+	for _, id := range c.Ready {
+		found = found || id == uid
+	}
+	if !found {
+		c.Ready = append(c.Ready, uid)
+	}
+	return len(c.Ready) >= len(t.Members)
+}
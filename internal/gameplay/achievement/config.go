@@ -0,0 +1,49 @@
+package achievement
+
+import (
+	"fmt"
+
+	"greatestworks/internal/gameplay/bag"
+)
+
+// Statistics of the players, counted from the events of the other modules
+// (see subscribe). The statistics of a kind of target (e.g., kills of a
+// monster) are suffixed with the id of the target (see StatKey).
+const (
+	StatKills   = "kills"   // monsters killed (playerevent.Kill)
+	StatPvP     = "pvp"     // players killed (playerevent.Kill)
+	StatLevel   = "level"   // highest level (playerevent.LevelUp)
+	StatFriends = "friends" // most friends at once (friendevent.Added)
+	StatItems   = "items"   // most of an item held at once (bagevent.Changed)
+)
+
+// StatKey returns the key of the statistic of a target, or of all the
+// targets if param is 0.
+func StatKey(stat string, param uint32) string {
+	if param == 0 {
+		return stat
+	}
+	return fmt.Sprintf("%s:%d", stat, param)
+}
+
+// Tier is a tier of an achievement, reached when its statistic reaches
+// Count.
+type Tier struct {
+	Count   int64       `json:"count"`
+	Rewards []bag.Stack `json:"rewards"`
+}
+
+// Config 成就配置
+type Config struct {
+	Id    uint32 `json:"id"`
+	Name  string `json:"name"`
+	Stat  string `json:"stat"`  // 统计项，见 StatKills 等
+	Param uint32 `json:"param"` // 统计目标，如怪物id，0为全部
+	Tiers []Tier `json:"tiers"` // 各阶，数量递增
+}
+
+// ModuleConfig is the config of the achievement module. It must be set
+// before the module is initialized (see Module.Init).
+type ModuleConfig struct {
+	AchievementFile string // path of the achievement table, a JSON array of Config
+}
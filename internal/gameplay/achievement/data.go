@@ -0,0 +1,133 @@
+package achievement
+
+import (
+	"strconv"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Doc 对应DB -> mongo
+type Doc struct {
+	Stats   map[string]int64 `bson:"stats"`   // 统计
+	Reached map[string]int   `bson:"reached"` // 各成就达成的阶数，key为成就id
+	Claimed map[string]int   `bson:"claimed"` // 各成就已领奖的阶数，key为成就id
+}
+
+// Data is the statistics and the achievements of a player. The statistics
+// are counted from the start of the game, so that achievements added later
+// are reached as soon as the player logs in (see Module.Online). They're
+// counted on the goroutines of the event bus, so they're guarded by mu.
+type Data struct {
+	uid       uint64
+	player    Player
+	markDirty func()
+
+	mu      sync.Mutex
+	stats   map[string]int64 // guarded by mu
+	reached map[uint32]int   // tiers reached, by achievement; guarded by mu
+	claimed map[uint32]int   // tiers claimed, by achievement; guarded by mu
+}
+
+func NewData() *Data {
+	return &Data{
+		stats:   map[string]int64{},
+		reached: map[uint32]int{},
+		claimed: map[uint32]int{},
+	}
+}
+
+// SetOwner sets the player the achievements belong to, and how they're
+// marked dirty.
+func (d *Data) SetOwner(player Player, uid uint64, markDirty func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.player = player
+	d.uid = uid
+	d.markDirty = markDirty
+}
+
+// Stats returns a copy of the statistics of the player.
+func (d *Data) Stats() map[string]int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stats := make(map[string]int64, len(d.stats))
+	for k, v := range d.stats {
+		stats[k] = v
+	}
+	return stats
+}
+
+// Stat returns a statistic of the player.
+func (d *Data) Stat(key string) int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stats[key]
+}
+
+// Save returns a copy of the statistics and the achievements, to be stored.
+func (d *Data) Save() (interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	doc := Doc{
+		Stats:   make(map[string]int64, len(d.stats)),
+		Reached: make(map[string]int, len(d.reached)),
+		Claimed: make(map[string]int, len(d.claimed)),
+	}
+	for k, v := range d.stats {
+		doc.Stats[k] = v
+	}
+	for id, n := range d.reached {
+		doc.Reached[idKey(id)] = n
+	}
+	for id, n := range d.claimed {
+		doc.Claimed[idKey(id)] = n
+	}
+	return doc, nil
+}
+
+// Load loads stored statistics and achievements.
+func (d *Data) Load(raw bson.RawValue) error {
+	var doc Doc
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stats = doc.Stats
+	if d.stats == nil {
+		d.stats = map[string]int64{}
+	}
+	d.reached = make(map[uint32]int, len(doc.Reached))
+	for k, n := range doc.Reached {
+		if id, ok := parseIdKey(k); ok {
+			d.reached[id] = n
+		}
+	}
+	d.claimed = make(map[uint32]int, len(doc.Claimed))
+	for k, n := range doc.Claimed {
+		if id, ok := parseIdKey(k); ok {
+			d.claimed[id] = n
+		}
+	}
+	return nil
+}
+
+// Achievement is the progress of a player on an achievement.
+type Achievement struct {
+	Id      uint32
+	Value   int64 // value of its statistic
+	Reached int   // tiers reached
+	Claimed int   // tiers claimed
+}
+
+// idKey returns the key of an achievement in the documents: bson keys are
+// strings.
+func idKey(id uint32) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func parseIdKey(k string) (uint32, bool) {
+	id, err := strconv.ParseUint(k, 10, 32)
+	return uint32(id), err == nil
+}
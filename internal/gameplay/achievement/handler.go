@@ -0,0 +1,63 @@
+package achievement
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/greatestworks-proto/player"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player Player, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerAchievementRegister()
+	})
+}
+
+func HandlerAchievementRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSClaimAchievement, Claim},
+		&Handler{protocol.MessageId_CSPlayerStats, Stats},
+	)
+}
+
+// Claim claims the rewards of the tiers of an achievement reached.
+func Claim(p Player, packet *network.Message) {
+	req := &protocol.CSClaimAchievement{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	d := p.GetAchievementData()
+	if err := GetMod().Claim(context.Background(), d, req.Id); err != nil {
+		logger.Warn("[achievement] claim %v PlayerID:%v err:%v", req.Id, d.uid, err)
+	}
+}
+
+// Stats sends the statistics of the player.
+func Stats(p Player, packet *network.Message) {
+	p.SendMsg(protocol.MessageId_SCPlayerStats, &protocol.SCPlayerStats{Stats: p.GetAchievementData().Stats()})
+}
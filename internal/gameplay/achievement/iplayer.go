@@ -0,0 +1,13 @@
+package achievement
+
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/bag"
+)
+
+type Player interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+	GetAchievementData() *Data
+	GetBagSystem() *bag.System
+}
@@ -0,0 +1,280 @@
+package achievement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/loader/json"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/module_router"
+	"greatestworks/internal"
+	"greatestworks/internal/gameplay/bag"
+	"greatestworks/internal/protocol"
+)
+
+const (
+	ModuleName = "achievement"
+)
+
+// reasonAchievement is the reason of the changes of the bags made by the
+// achievements.
+const reasonAchievement = "achievement"
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNoAchievement  = errors.New("achievement: no such achievement")
+	ErrNothingToClaim = errors.New("achievement: no tier to claim")
+)
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Module counts the statistics of the players online on this server from
+// the events of the other modules, and evaluates the achievements against
+// them.
+type Module struct {
+	*internal.BaseModule
+	initFlag bool
+	configs  map[uint32]*Config
+	byStat   map[string][]*Config // achievements, by the key of their statistic
+
+	mu     sync.Mutex
+	online map[uint64]*Data // guarded by mu
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
+	return Mod
+}
+
+// Init loads the achievement table.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.configs = map[uint32]*Config{}
+	m.byStat = map[string][]*Config{}
+	if conf.AchievementFile != "" {
+		if err := m.loadConfigs(conf.AchievementFile); err != nil {
+			return err
+		}
+	} else {
+		logger.Warn("[achievement] no achievement table")
+	}
+	m.online = make(map[uint64]*Data)
+	m.initFlag = true
+	return nil
+}
+
+// loadConfigs loads the achievement table.
+func (m *Module) loadConfigs(path string) error {
+	var rows []*Config
+	if !json.ParseJsonFile2Slice(path, true, &rows) {
+		return fmt.Errorf("achievement: achievement table %q not found", path)
+	}
+	for _, row := range rows {
+		if _, ok := m.configs[row.Id]; ok {
+			return fmt.Errorf("achievement: achievement table %q: repeat achievement %d", path, row.Id)
+		}
+		if len(row.Tiers) == 0 {
+			return fmt.Errorf("achievement: achievement table %q: achievement %d has no tier", path, row.Id)
+		}
+		for i := 1; i < len(row.Tiers); i++ {
+			if row.Tiers[i].Count <= row.Tiers[i-1].Count {
+				return fmt.Errorf("achievement: achievement table %q: achievement %d: tier counts not increasing", path, row.Id)
+			}
+		}
+		m.configs[row.Id] = row
+		key := StatKey(row.Stat, row.Param)
+		m.byStat[key] = append(m.byStat[key], row)
+	}
+	return nil
+}
+
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	m.subscribe()
+}
+
+func (m *Module) OnStop() {
+	if !m.initFlag {
+		return
+	}
+	m.unsubscribe()
+}
+
+// Online registers the achievements of a player that logged in to this
+// server, and evaluates all of them, so that the achievements added since
+// the player last logged in are reached from its statistics. It pushes them
+// to the client.
+func (m *Module) Online(d *Data) {
+	m.mu.Lock()
+	m.online[d.uid] = d
+	m.mu.Unlock()
+
+	d.mu.Lock()
+	changed := false
+	for _, conf := range m.configs {
+		changed = m.evaluate(d, conf) || changed
+	}
+	all := m.progressOf(d, nil)
+	d.mu.Unlock()
+	if changed && d.markDirty != nil {
+		d.markDirty()
+	}
+	d.player.SendMsg(protocol.MessageId_SCAchievementList, listToProto(all, d.Stats()))
+}
+
+// Offline unregisters the achievements of a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
+}
+
+// dataOf returns the achievements of a player online on this server, or nil.
+func (m *Module) dataOf(uid uint64) *Data {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.online[uid]
+}
+
+// count updates statistics of a player: adds value to them, or raises them
+// to value if highest (e.g., the highest level), and evaluates the
+// achievements of the statistics changed.
+func (m *Module) count(d *Data, keys []string, value int64, highest bool) {
+	d.mu.Lock()
+	if d.player == nil {
+		d.mu.Unlock()
+		return
+	}
+	var changed []*Config
+	dirty := false
+	for _, key := range keys {
+		old := d.stats[key]
+		switch {
+		case !highest:
+			d.stats[key] = old + value
+		case value > old:
+			d.stats[key] = value
+		default:
+			continue
+		}
+		dirty = true
+		for _, conf := range m.byStat[key] {
+			if m.evaluate(d, conf) {
+				changed = append(changed, conf)
+			}
+		}
+	}
+	progress := m.progressOf(d, changed)
+	d.mu.Unlock()
+
+	if dirty && d.markDirty != nil {
+		d.markDirty()
+	}
+	for i := range progress {
+		d.player.SendMsg(protocol.MessageId_SCAchievementUpdate, toProto(&progress[i]))
+	}
+}
+
+// evaluate updates the tiers of an achievement reached by a player, and
+// returns whether they changed.
+//
+// REQUIRES: d.mu is held.
+func (m *Module) evaluate(d *Data, conf *Config) bool {
+	value := d.stats[StatKey(conf.Stat, conf.Param)]
+	n := 0
+	for n < len(conf.Tiers) && conf.Tiers[n].Count <= value {
+		n++
+	}
+	if n <= d.reached[conf.Id] {
+		return false
+	}
+	d.reached[conf.Id] = n
+	return true
+}
+
+// progressOf returns the progress of a player on achievements, or on all of
+// them if confs is nil.
+//
+// REQUIRES: d.mu is held.
+func (m *Module) progressOf(d *Data, confs []*Config) []Achievement {
+	if confs == nil {
+		for _, conf := range m.configs {
+			confs = append(confs, conf)
+		}
+	}
+	progress := make([]Achievement, 0, len(confs))
+	for _, conf := range confs {
+		progress = append(progress, Achievement{
+			Id:      conf.Id,
+			Value:   d.stats[StatKey(conf.Stat, conf.Param)],
+			Reached: d.reached[conf.Id],
+			Claimed: d.claimed[conf.Id],
+		})
+	}
+	return progress
+}
+
+// Claim gives the rewards of the tiers of an achievement reached by a player
+// and not claimed yet, all at once.
+func (m *Module) Claim(ctx context.Context, d *Data, id uint32) error {
+	conf := m.configs[id]
+	if conf == nil {
+		return ErrNoAchievement
+	}
+	d.mu.Lock()
+	from, to := d.claimed[id], d.reached[id]
+	if from >= to {
+		d.mu.Unlock()
+		return ErrNothingToClaim
+	}
+	var rewards []bag.Stack
+	for _, tier := range conf.Tiers[from:to] {
+		rewards = append(rewards, tier.Rewards...)
+	}
+	if len(rewards) > 0 {
+		if _, err := d.player.GetBagSystem().Grant(ctx, reasonAchievement, rewards); err != nil {
+			d.mu.Unlock()
+			return err
+		}
+	}
+	d.claimed[id] = to
+	progress := m.progressOf(d, []*Config{conf})
+	d.mu.Unlock()
+
+	if d.markDirty != nil {
+		d.markDirty()
+	}
+	d.player.SendMsg(protocol.MessageId_SCAchievementUpdate, toProto(&progress[0]))
+	return nil
+}
+
+// Dependencies returns the modules the achievement module depends on: the
+// rewards go to the bags.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Bag.String()}
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
@@ -0,0 +1,49 @@
+package achievement
+
+import (
+	eventbus "greatestworks/aop/event"
+	"greatestworks/internal/note/event/bagevent"
+	"greatestworks/internal/note/event/friendevent"
+	"greatestworks/internal/note/event/playerevent"
+)
+
+// subscribe subscribes the module to the events counted in the statistics of
+// the players.
+func (m *Module) subscribe() {
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e playerevent.Kill) {
+		d := m.dataOf(e.PlayerId)
+		if d == nil {
+			return
+		}
+		if e.IsPlayer {
+			m.count(d, []string{StatPvP}, 1, false)
+			return
+		}
+		m.count(d, []string{StatKills, StatKey(StatKills, uint32(e.TargetId))}, 1, false)
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e playerevent.LevelUp) {
+		if d := m.dataOf(e.PlayerId); d != nil {
+			m.count(d, []string{StatLevel}, int64(e.Level), true)
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e friendevent.Added) {
+		if d := m.dataOf(e.PlayerId); d != nil {
+			m.count(d, []string{StatFriends}, int64(e.Count), true)
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e bagevent.Changed) {
+		d := m.dataOf(e.PlayerId)
+		if d == nil {
+			return
+		}
+		for _, ch := range e.Changes {
+			m.count(d, []string{StatKey(StatItems, ch.Id)}, ch.Num, true)
+		}
+	})
+}
+
+// unsubscribe cancels the subscriptions of the module, once the events
+// already received are handled.
+func (m *Module) unsubscribe() {
+	eventbus.Default.UnsubscribeModule(m.GetName())
+}
@@ -0,0 +1,28 @@
+package achievement
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+func toProto(a *Achievement) *protocol.SCAchievementUpdate {
+	return &protocol.SCAchievementUpdate{Achievement: infoToProto(a)}
+}
+
+func infoToProto(a *Achievement) *protocol.AchievementInfo {
+	return &protocol.AchievementInfo{
+		Id:      a.Id,
+		Value:   a.Value,
+		Reached: int32(a.Reached),
+		Claimed: int32(a.Claimed),
+	}
+}
+
+// listToProto returns the achievements and the statistics of a player, as
+// pushed to the client when it logs in.
+func listToProto(all []Achievement, stats map[string]int64) *protocol.SCAchievementList {
+	msg := &protocol.SCAchievementList{Stats: stats}
+	for i := range all {
+		msg.Achievements = append(msg.Achievements, infoToProto(&all[i]))
+	}
+	return msg
+}
@@ -0,0 +1,158 @@
+package anticheat
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"greatestworks/aop/loader/json"
+)
+
+// Kinds of violations.
+const (
+	ViolationSpeed    = "speed"    // moved faster than the scene allows
+	ViolationTeleport = "teleport" // jumped farther than the scene allows, or entered a scene away from its portals
+	ViolationBounds   = "bounds"   // moved out of the scene
+	ViolationRate     = "rate"     // sent a message more often than its cap
+	ViolationAnomaly  = "anomaly"  // moved faster than the other players on average, by a statistical margin
+)
+
+// defaultWeights is the score of a violation of every kind, scaled by its
+// severity (e.g., how much faster than allowed).
+var defaultWeights = map[string]float64{
+	ViolationSpeed:    2,
+	ViolationTeleport: 10,
+	ViolationBounds:   10,
+	ViolationRate:     0.5,
+	ViolationAnomaly:  15,
+}
+
+// Vec is a position on the ground of a scene.
+type Vec struct {
+	X float64 `json:"x"`
+	Z float64 `json:"z"`
+}
+
+func (v Vec) dist(o Vec) float64 {
+	return math.Hypot(v.X-o.X, v.Z-o.Z)
+}
+
+// SceneConf 场景移动校验配置
+type SceneConf struct {
+	Id       uint32  `json:"id"`
+	MaxSpeed float64 `json:"maxSpeed"` // 最大移动速度，每秒，含加速效果
+	Teleport float64 `json:"teleport"` // 单次同步最大位移，超过视为瞬移，0为不限
+	Min      Vec     `json:"min"`      // 场景范围，min和max都为0则不校验
+	Max      Vec     `json:"max"`
+	Portals  []Vec   `json:"portals"` // 进入场景的落点(传送门、出生点)，空则不校验
+	Radius   float64 `json:"radius"`  // 落点半径
+}
+
+// bounded returns whether the scene checks that positions are inside it.
+func (c *SceneConf) bounded() bool {
+	return c.Min != (Vec{}) || c.Max != (Vec{})
+}
+
+// inside returns whether a position is inside the scene.
+func (c *SceneConf) inside(p Vec) bool {
+	return !c.bounded() || p.X >= c.Min.X && p.X <= c.Max.X && p.Z >= c.Min.Z && p.Z <= c.Max.Z
+}
+
+// entrance returns whether a position is at a portal of the scene, where
+// players may enter it.
+func (c *SceneConf) entrance(p Vec) bool {
+	if len(c.Portals) == 0 {
+		return true
+	}
+	for _, portal := range c.Portals {
+		if p.dist(portal) <= c.Radius {
+			return true
+		}
+	}
+	return false
+}
+
+// RateConf 消息频率上限配置
+type RateConf struct {
+	MessageId uint32 `json:"messageId"`
+	Burst     int    `json:"burst"` // 连续发送上限
+	Every     int64  `json:"every"` // 每隔多少毫秒恢复一次，0为不限
+}
+
+// ModuleConfig is the config of the anti-cheat module. It must be set before
+// the module is initialized (see Module.Init).
+type ModuleConfig struct {
+	SceneFile string // scene table, see SceneConf; the scenes missing are checked with DefaultSpeed only
+	RateFile  string // message rate table, see RateConf; the messages missing are capped by DefaultRate
+	ServerId  string // id of this server, in the flags
+
+	DefaultSpeed float64       // max speed of the scenes missing from the scene table, defaults to defaultSpeed
+	DefaultRate  RateConf      // cap of the messages missing from the rate table, none if Every is 0
+	Tolerance    float64       // ratio of the max speed tolerated for latency, defaults to defaultTolerance
+	Slack        time.Duration // latency tolerated on top of the time between two moves, defaults to defaultSlack
+
+	Weights       map[string]float64 // score of the violations, by kind, see defaultWeights
+	HalfLife      time.Duration      // time for a score to halve, defaults to defaultHalfLife
+	FlagScore     float64            // score flagging a player for review, defaults to defaultFlagScore
+	MuteScore     float64            // score muting a player, defaults to defaultMuteScore
+	KickScore     float64            // score kicking a player, defaults to defaultKickScore
+	MuteDuration  time.Duration      // defaults to defaultMuteDuration
+	ConfirmMute   time.Duration      // mute of the players confirmed cheating by a GM, defaults to defaultConfirmMute
+	MinSamples    int                // moves of a player before it's compared with the others, defaults to defaultMinSamples
+	AnomalyZScore float64            // z-score of the average speed of a player flagging it, defaults to defaultAnomalyZScore
+}
+
+const (
+	defaultSpeed         = 10
+	defaultTolerance     = 1.2
+	defaultSlack         = 300 * time.Millisecond
+	defaultHalfLife      = 10 * time.Minute
+	defaultFlagScore     = 20
+	defaultMuteScore     = 50
+	defaultKickScore     = 100
+	defaultMuteDuration  = time.Hour
+	defaultConfirmMute   = 7 * 24 * time.Hour
+	defaultMinSamples    = 50
+	defaultAnomalyZScore = 3
+)
+
+// loadScenes loads the scene table.
+func loadScenes(file string) (map[uint32]*SceneConf, error) {
+	var list []*SceneConf
+	if !json.ParseJsonFile2Slice(file, true, &list) {
+		return nil, fmt.Errorf("anticheat: scene table %q not found", file)
+	}
+	confs := make(map[uint32]*SceneConf, len(list))
+	for _, c := range list {
+		if confs[c.Id] != nil {
+			return nil, fmt.Errorf("anticheat: scene table %q: repeat scene %d", file, c.Id)
+		}
+		if c.MaxSpeed <= 0 || c.Teleport < 0 {
+			return nil, fmt.Errorf("anticheat: scene table %q: scene %d: invalid max speed %v, teleport %v", file, c.Id, c.MaxSpeed, c.Teleport)
+		}
+		if c.bounded() && (c.Min.X > c.Max.X || c.Min.Z > c.Max.Z) {
+			return nil, fmt.Errorf("anticheat: scene table %q: scene %d: invalid bounds", file, c.Id)
+		}
+		confs[c.Id] = c
+	}
+	return confs, nil
+}
+
+// loadRates loads the message rate table.
+func loadRates(file string) (map[uint32]*RateConf, error) {
+	var list []*RateConf
+	if !json.ParseJsonFile2Slice(file, true, &list) {
+		return nil, fmt.Errorf("anticheat: rate table %q not found", file)
+	}
+	confs := make(map[uint32]*RateConf, len(list))
+	for _, c := range list {
+		if confs[c.MessageId] != nil {
+			return nil, fmt.Errorf("anticheat: rate table %q: repeat message %d", file, c.MessageId)
+		}
+		if c.Every < 0 || c.Every > 0 && c.Burst <= 0 {
+			return nil, fmt.Errorf("anticheat: rate table %q: message %d: invalid burst %d, every %d", file, c.MessageId, c.Burst, c.Every)
+		}
+		confs[c.MessageId] = c
+	}
+	return confs, nil
+}
@@ -0,0 +1,11 @@
+package anticheat
+
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+)
+
+// Owner is a player whose actions are checked.
+type Owner interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+}
@@ -0,0 +1,443 @@
+package anticheat
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/module_router"
+	"greatestworks/aop/mongo"
+	"greatestworks/internal"
+	"greatestworks/internal/communicate/chat"
+	"greatestworks/internal/note/event/anticheatevent"
+)
+
+const (
+	ModuleName = "anticheat"
+)
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrInvalidMove = errors.New("anticheat: invalid move")
+	ErrNoFlag      = errors.New("anticheat: no such pending flag")
+)
+
+// Statuses of the flags in the review queue.
+const (
+	FlagPending = iota
+	FlagConfirmed
+	FlagCleared
+)
+
+var (
+	violations = metrics.NewCounterMap[violationLabels](
+		"anticheat_violations",
+		"Number of actions of the players flagged as violations, by kind",
+	)
+	dropped = metrics.NewCounterMap[messageLabels](
+		"anticheat_dropped_messages",
+		"Number of messages of the players dropped over their rate cap, or kicked",
+	)
+	sanctions = metrics.NewCounterMap[levelLabels](
+		"anticheat_sanctions",
+		"Number of sanctions of the players, by level",
+	)
+	reviews = metrics.NewCounterMap[reviewLabels](
+		"anticheat_reviews",
+		"Number of flags reviewed by the GMs",
+	)
+)
+
+type violationLabels struct {
+	Kind string
+}
+
+type messageLabels struct {
+	MessageId uint32
+}
+
+type levelLabels struct {
+	Level int
+}
+
+type reviewLabels struct {
+	Confirm bool
+}
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Module checks the actions of the players on the server: their moves
+// against the data of their scene, and the rate of their messages against
+// caps per message. Every violation adds to a suspicion score of the player,
+// which decays with time, and a move faster than the other players on
+// average by a statistical margin adds to it too. As the score grows, the
+// player is flagged for review by a GM, muted, then kicked.
+type Module struct {
+	*internal.BaseModule
+	initFlag bool
+	rules    *rules
+	conf     ModuleConfig
+
+	mu       sync.Mutex
+	suspects map[uint64]*suspect // online players; guarded by mu
+	pop      population          // guarded by mu
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
+	return Mod
+}
+
+// Init loads the scene and rate tables.
+func (m *Module) Init() error {
+	if ModuleConf != nil {
+		m.conf = *ModuleConf
+	}
+	conf := &m.conf
+	r := &rules{scenes: map[uint32]*SceneConf{}, rates: map[uint32]*RateConf{}}
+	if conf.SceneFile != "" {
+		scenes, err := loadScenes(conf.SceneFile)
+		if err != nil {
+			return err
+		}
+		r.scenes = scenes
+	} else {
+		logger.Warn("[anticheat] no scene table")
+	}
+	if conf.RateFile != "" {
+		rates, err := loadRates(conf.RateFile)
+		if err != nil {
+			return err
+		}
+		r.rates = rates
+	}
+	r.defaultScene = SceneConf{MaxSpeed: conf.DefaultSpeed}
+	if r.defaultScene.MaxSpeed <= 0 {
+		r.defaultScene.MaxSpeed = defaultSpeed
+	}
+	r.defaultRate = conf.DefaultRate
+	r.tolerance = conf.Tolerance
+	if r.tolerance < 1 {
+		r.tolerance = defaultTolerance
+	}
+	r.slack = conf.Slack
+	if r.slack <= 0 {
+		r.slack = defaultSlack
+	}
+	r.weights = make(map[string]float64, len(defaultWeights))
+	for kind, w := range defaultWeights {
+		r.weights[kind] = w
+	}
+	for kind, w := range conf.Weights {
+		r.weights[kind] = w
+	}
+	r.halfLife = conf.HalfLife
+	if r.halfLife <= 0 {
+		r.halfLife = defaultHalfLife
+	}
+	r.scores = [...]float64{LevelFlag: conf.FlagScore, LevelMute: conf.MuteScore, LevelKick: conf.KickScore}
+	if r.scores[LevelFlag] <= 0 {
+		r.scores[LevelFlag] = defaultFlagScore
+	}
+	if r.scores[LevelMute] <= 0 {
+		r.scores[LevelMute] = defaultMuteScore
+	}
+	if r.scores[LevelKick] <= 0 {
+		r.scores[LevelKick] = defaultKickScore
+	}
+	r.minSamples = conf.MinSamples
+	if r.minSamples <= 0 {
+		r.minSamples = defaultMinSamples
+	}
+	r.zScore = conf.AnomalyZScore
+	if r.zScore <= 0 {
+		r.zScore = defaultAnomalyZScore
+	}
+	if conf.MuteDuration <= 0 {
+		conf.MuteDuration = defaultMuteDuration
+	}
+	if conf.ConfirmMute <= 0 {
+		conf.ConfirmMute = defaultConfirmMute
+	}
+	m.rules = r
+	m.suspects = make(map[uint64]*suspect)
+	m.initFlag = true
+	return nil
+}
+
+func (m *Module) OnStart() {
+}
+
+func (m *Module) OnStop() {
+}
+
+// Dependencies returns the chat module, which mutes the players.
+func (m *Module) Dependencies() []string {
+	return []string{chat.GetMod().GetName()}
+}
+
+func cheatFlags() *mongodriver.Collection {
+	doc := &mongo.CheatFlag{}
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+// Online starts checking the actions of a player that logged in.
+func (m *Module) Online(uid uint64, owner Owner) {
+	if !m.initFlag {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.suspects[uid] = newSuspect(owner)
+}
+
+// Offline stops checking the actions of a player that logged out. Its score
+// is forgotten; its flag stays in the review queue.
+func (m *Module) Offline(uid uint64) {
+	if !m.initFlag {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.suspects, uid)
+}
+
+// Action returns whether a player may send a message now: it's dropped if
+// it's over the rate cap of the message, or if the player was kicked.
+func (m *Module) Action(uid uint64, id messageId.MessageId) bool {
+	if !m.initFlag {
+		return true
+	}
+	now := time.Now()
+	m.mu.Lock()
+	s := m.suspects[uid]
+	if s == nil {
+		// Not online yet (e.g., logging in).
+		m.mu.Unlock()
+		return true
+	}
+	if s.level >= LevelKick {
+		m.mu.Unlock()
+		dropped.Get(messageLabels{MessageId: uint32(id)}).Add(1)
+		return false
+	}
+	if s.allow(m.rules, uint32(id), now) {
+		m.mu.Unlock()
+		return true
+	}
+	from, to := s.level, s.violate(m.rules, ViolationRate, 1, now)
+	m.mu.Unlock()
+	dropped.Get(messageLabels{MessageId: uint32(id)}).Add(1)
+	m.violated(uid, ViolationRate, from, to)
+	return false
+}
+
+// Move checks a move of a player to a position of a scene. An invalid move
+// isn't accepted: the next one is checked from the last valid position.
+func (m *Module) Move(uid uint64, sceneId uint32, pos Vec) error {
+	if !m.initFlag {
+		return nil
+	}
+	now := time.Now()
+	m.mu.Lock()
+	s := m.suspects[uid]
+	if s == nil {
+		m.mu.Unlock()
+		return nil
+	}
+	violation, severity, ratio := s.move(m.rules, sceneId, pos, now)
+	if violation == "" && ratio > 0 && s.sample(m.rules, &m.pop, ratio) {
+		violation, severity = ViolationAnomaly, 1
+	}
+	if violation == "" {
+		m.mu.Unlock()
+		return nil
+	}
+	from, to := s.level, s.violate(m.rules, violation, severity, now)
+	m.mu.Unlock()
+	m.violated(uid, violation, from, to)
+	if violation == ViolationAnomaly {
+		// The move itself is valid.
+		return nil
+	}
+	return ErrInvalidMove
+}
+
+// violated counts a violation of a player, and sanctions it if its score
+// reached a new level.
+func (m *Module) violated(uid uint64, violation string, from, to int) {
+	violations.Get(violationLabels{Kind: violation}).Add(1)
+	logger.Debug("[anticheat] PlayerID:%v violation:%v", uid, violation)
+	if to == LevelNone {
+		return
+	}
+	m.sanction(context.Background(), uid, from, to)
+}
+
+// sanction applies the sanctions of the levels above from, up to to: it
+// flags the player, or updates its flag, then mutes and kicks it.
+func (m *Module) sanction(ctx context.Context, uid uint64, from, to int) {
+	m.mu.Lock()
+	s := m.suspects[uid]
+	if s == nil {
+		m.mu.Unlock()
+		return
+	}
+	flagId, score := s.flagId, s.score
+	counts := make(map[string]int, len(s.violations))
+	for k, v := range s.violations {
+		counts[k] = v
+	}
+	owner := s.owner
+	m.mu.Unlock()
+
+	flagId, err := m.flag(ctx, uid, flagId, to, score, counts)
+	if err != nil {
+		logger.Error("[anticheat] flag PlayerID:%v err:%v", uid, err)
+	} else {
+		m.mu.Lock()
+		if s := m.suspects[uid]; s != nil {
+			s.flagId = flagId
+		}
+		m.mu.Unlock()
+	}
+	for l := from + 1; l <= to; l++ {
+		sanctions.Get(levelLabels{Level: l}).Add(1)
+	}
+	logger.Warn("[anticheat] PlayerID:%v level:%v score:%.1f violations:%v", uid, to, score, counts)
+	if from < LevelMute && to >= LevelMute {
+		if err := chat.GetMod().Mute(ctx, uid, time.Now().Add(m.conf.MuteDuration)); err != nil {
+			logger.Error("[anticheat] mute PlayerID:%v err:%v", uid, err)
+		}
+	}
+	if from < LevelKick && to >= LevelKick {
+		owner.SendMsg(messageId.MessageId_SCKick, kickToProto(kickReasonCheat))
+	}
+	eventbus.Publish(eventbus.Default, anticheatevent.Sanctioned{PlayerId: uid, Level: to, Score: score, FlagId: flagId})
+}
+
+// flag adds a player to the review queue, or updates its pending flag, and
+// returns the id of the flag.
+func (m *Module) flag(ctx context.Context, uid, flagId uint64, level int, score float64, counts map[string]int) (uint64, error) {
+	now := time.Now().Unix()
+	if flagId != 0 {
+		res, err := cheatFlags().UpdateOne(ctx, bson.M{"_id": flagId, "status": FlagPending}, bson.M{"$set": bson.M{
+			"level":      level,
+			"score":      score,
+			"violations": counts,
+			"time":       now,
+		}})
+		if err != nil {
+			return 0, err
+		}
+		if res.MatchedCount > 0 {
+			return flagId, nil
+		}
+		// Reviewed meanwhile: flag it again.
+	}
+	id, err := idgenerator.NextId()
+	if err != nil {
+		return 0, err
+	}
+	doc := &mongo.CheatFlag{
+		Id:         id,
+		PlayerId:   uid,
+		Server:     m.conf.ServerId,
+		Score:      score,
+		Level:      level,
+		Violations: counts,
+		Status:     FlagPending,
+		Time:       now,
+	}
+	if _, err := cheatFlags().InsertOne(ctx, doc); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Pending returns the flags waiting for review, the highest scores first.
+func (m *Module) Pending(ctx context.Context, limit int64) ([]*mongo.CheatFlag, error) {
+	cursor, err := cheatFlags().Find(ctx, bson.M{"status": FlagPending}, options.Find().
+		SetSort(bson.D{{Key: "score", Value: -1}}).
+		SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	var flags []*mongo.CheatFlag
+	if err := cursor.All(ctx, &flags); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// Review closes a pending flag, by a GM. A confirmed cheater is muted for
+// ModuleConfig.ConfirmMute, and kicked if it's online on this server; a
+// cleared player starts over with no score.
+func (m *Module) Review(ctx context.Context, flagId uint64, confirm bool, reviewer uint64) error {
+	status := FlagCleared
+	if confirm {
+		status = FlagConfirmed
+	}
+	flag := &mongo.CheatFlag{}
+	err := cheatFlags().FindOneAndUpdate(ctx, bson.M{"_id": flagId, "status": FlagPending}, bson.M{"$set": bson.M{
+		"status":     status,
+		"reviewer":   reviewer,
+		"reviewTime": time.Now().Unix(),
+	}}).Decode(flag)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return ErrNoFlag
+	}
+	if err != nil {
+		return err
+	}
+	reviews.Get(reviewLabels{Confirm: confirm}).Add(1)
+	logger.Info("[anticheat] flag:%v PlayerID:%v reviewed by %v confirm:%v", flagId, flag.PlayerId, reviewer, confirm)
+
+	m.mu.Lock()
+	var owner Owner
+	if s := m.suspects[flag.PlayerId]; s != nil {
+		if confirm {
+			s.level = LevelKick
+			owner = s.owner
+		} else {
+			s.clear()
+		}
+	}
+	m.mu.Unlock()
+	if confirm {
+		if err := chat.GetMod().Mute(ctx, flag.PlayerId, time.Now().Add(m.conf.ConfirmMute)); err != nil {
+			logger.Error("[anticheat] mute PlayerID:%v err:%v", flag.PlayerId, err)
+		}
+		if owner != nil {
+			owner.SendMsg(messageId.MessageId_SCKick, kickToProto(kickReasonCheat))
+		}
+	}
+	eventbus.Publish(eventbus.Default, anticheatevent.Reviewed{FlagId: flagId, PlayerId: flag.PlayerId, Confirm: confirm})
+	return nil
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
@@ -1,23 +1,14 @@
-package shushu
+package anticheat
 
 import (
 	"greatestworks/internal"
 	"greatestworks/internal/note/event"
 )
 
-type EventHandle func(iEvent event.IEvent)
-
-type EventWrap struct {
-	IPlayer
-	event.IEvent
-}
-
+// OnEvent is unused: the actions of the players are checked by the modules
+// handling them (see Action and Move).
 func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
-	//TODO implement me
-	panic("implement me")
 }
 
 func (m *Module) SetEventCategoryActive(eventCategory int) {
-	//TODO implement me
-	panic("implement me")
 }
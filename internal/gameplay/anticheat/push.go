@@ -0,0 +1,15 @@
+package anticheat
+
+import (
+	"github.com/phuhao00/greatestworks-proto/player"
+	"greatestworks/internal/protocol"
+)
+
+// Reasons of the kicks, for the client to tell the player.
+const (
+	kickReasonCheat = 1
+)
+
+func kickToProto(reason int32) *protocol.SCKick {
+	return &protocol.SCKick{Reason: reason}
+}
@@ -0,0 +1,234 @@
+package anticheat
+
+import (
+	"math"
+	"time"
+)
+
+// Levels of the sanctions, escalating with the score of a player.
+const (
+	LevelNone = iota
+	LevelFlag // flagged for review by a GM
+	LevelMute // muted for ModuleConfig.MuteDuration
+	LevelKick // kicked: its messages are dropped until it logs in again
+)
+
+const (
+	// minSample is the time between two moves for the second to be a sample
+	// of the speed of a player: shorter ones are mostly latency jitter.
+	minSample = 200 * time.Millisecond
+
+	// minRunning is the speed ratio of a move for it to be a sample: slower
+	// moves are the player stopping or walking, which would widen the
+	// distribution of the speeds the running players are compared with.
+	minRunning = 0.5
+
+	// playerAlpha and populationAlpha are the weights of a new sample in the
+	// moving averages of the speed of a player, and of all the players.
+	playerAlpha     = 0.1
+	populationAlpha = 0.001
+)
+
+// rules is how the actions of the players are checked and scored, from the
+// config of the module.
+type rules struct {
+	scenes       map[uint32]*SceneConf
+	rates        map[uint32]*RateConf
+	defaultScene SceneConf
+	defaultRate  RateConf
+	tolerance    float64
+	slack        time.Duration
+	weights      map[string]float64
+	halfLife     time.Duration
+	scores       [LevelKick + 1]float64 // score reaching every level
+	minSamples   int
+	zScore       float64
+}
+
+func (r *rules) scene(id uint32) *SceneConf {
+	if c := r.scenes[id]; c != nil {
+		return c
+	}
+	return &r.defaultScene
+}
+
+func (r *rules) rate(messageId uint32) *RateConf {
+	if c := r.rates[messageId]; c != nil {
+		return c
+	}
+	return &r.defaultRate
+}
+
+// population is the distribution of the speed of the moves of all the
+// players, as a ratio of the max speed of their scene, in exponentially
+// weighted moving averages.
+type population struct {
+	n        int
+	mean     float64
+	variance float64
+}
+
+func (p *population) add(x float64) {
+	p.n++
+	alpha := 1 / float64(p.n)
+	if alpha < populationAlpha {
+		alpha = populationAlpha
+	}
+	d := x - p.mean
+	p.mean += alpha * d
+	p.variance = (1 - alpha) * (p.variance + alpha*d*d)
+}
+
+// zScore returns how many standard deviations x is above the mean.
+func (p *population) zScore(x float64) float64 {
+	sd := math.Sqrt(p.variance)
+	if sd == 0 {
+		return 0
+	}
+	return (x - p.mean) / sd
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time // when tokens was computed
+}
+
+// suspect is the state of an online player, as seen by the anti-cheat.
+type suspect struct {
+	owner Owner
+
+	// Last accepted position.
+	placed bool
+	scene  uint32
+	pos    Vec
+	at     time.Time
+
+	buckets map[uint32]*bucket // by message
+
+	score      float64 // decayed from scoredAt
+	scoredAt   time.Time
+	level      int            // highest sanction of the session
+	violations map[string]int // by kind, in the session
+	flagId     uint64         // flag in the review queue, 0 if none
+
+	speed   float64 // moving average of the speed ratios of its moves
+	samples int     // since it was last compared with the others
+}
+
+func newSuspect(owner Owner) *suspect {
+	return &suspect{owner: owner, buckets: map[uint32]*bucket{}, violations: map[string]int{}}
+}
+
+// move checks a move of the player, and accepts it if it's valid. It returns
+// the violation of the move if it's not, with its severity, or the speed of
+// the move as a ratio of the max speed of the scene if it's a sample.
+func (s *suspect) move(r *rules, sceneId uint32, pos Vec, now time.Time) (violation string, severity, ratio float64) {
+	conf := r.scene(sceneId)
+	if !conf.inside(pos) {
+		return ViolationBounds, 1, 0
+	}
+	if !s.placed || s.scene != sceneId {
+		// The first position of a session is where the player was saved.
+		if s.placed && !conf.entrance(pos) {
+			return ViolationTeleport, 1, 0
+		}
+		s.place(sceneId, pos, now)
+		return "", 0, 0
+	}
+	dt := now.Sub(s.at)
+	d := pos.dist(s.pos)
+	if conf.Teleport > 0 && d > conf.Teleport {
+		return ViolationTeleport, d / conf.Teleport, 0
+	}
+	if allowed := conf.MaxSpeed * r.tolerance * (dt + r.slack).Seconds(); d > allowed {
+		return ViolationSpeed, d / allowed, 0
+	}
+	s.place(sceneId, pos, now)
+	if dt >= minSample {
+		if ratio = d / (conf.MaxSpeed * dt.Seconds()); ratio < minRunning {
+			ratio = 0
+		}
+	}
+	return "", 0, ratio
+}
+
+func (s *suspect) place(sceneId uint32, pos Vec, now time.Time) {
+	s.placed, s.scene, s.pos, s.at = true, sceneId, pos, now
+}
+
+// sample adds the speed ratio of a move to the average of the player, and
+// returns whether the player is anomalous: faster than the max speed on
+// average, by more than r.zScore standard deviations of all the players.
+func (s *suspect) sample(r *rules, pop *population, ratio float64) bool {
+	pop.add(ratio)
+	if s.samples == 0 {
+		s.speed = ratio
+	} else {
+		s.speed += playerAlpha * (ratio - s.speed)
+	}
+	s.samples++
+	if s.samples < r.minSamples || pop.n < r.minSamples {
+		return false
+	}
+	if s.speed <= 1 || pop.zScore(s.speed) <= r.zScore {
+		return false
+	}
+	// Compared again once it has enough new samples.
+	s.samples = 0
+	return true
+}
+
+// allow returns whether the player may send a message now, and if so,
+// takes a token from its bucket.
+func (s *suspect) allow(r *rules, messageId uint32, now time.Time) bool {
+	conf := r.rate(messageId)
+	if conf.Every <= 0 {
+		return true
+	}
+	b := s.buckets[messageId]
+	if b == nil {
+		b = &bucket{tokens: float64(conf.Burst), last: now}
+		s.buckets[messageId] = b
+	}
+	b.tokens += float64(now.Sub(b.last)) / float64(time.Duration(conf.Every)*time.Millisecond)
+	if b.tokens > float64(conf.Burst) {
+		b.tokens = float64(conf.Burst)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// violate adds a violation to the score of the player, decayed by now, and
+// returns the sanction level it reaches, if it's higher than the ones the
+// player already got; LevelNone otherwise.
+func (s *suspect) violate(r *rules, violation string, severity float64, now time.Time) int {
+	if !s.scoredAt.IsZero() {
+		s.score *= math.Exp2(-float64(now.Sub(s.scoredAt)) / float64(r.halfLife))
+	}
+	s.scoredAt = now
+	s.score += r.weights[violation] * severity
+	s.violations[violation]++
+	level := LevelNone
+	for l := LevelKick; l > LevelNone; l-- {
+		if s.score >= r.scores[l] {
+			level = l
+			break
+		}
+	}
+	if level <= s.level {
+		return LevelNone
+	}
+	s.level = level
+	return level
+}
+
+// clear resets the score of the player, e.g. when a GM clears it.
+func (s *suspect) clear() {
+	s.score, s.scoredAt, s.level, s.flagId = 0, time.Time{}, LevelNone, 0
+	s.violations = map[string]int{}
+	s.speed, s.samples = 0, 0
+}
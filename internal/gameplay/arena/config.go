@@ -0,0 +1,108 @@
+package arena
+
+import (
+	"fmt"
+	"time"
+
+	"greatestworks/aop/mongo"
+)
+
+const (
+	timeLayout = "2006-01-02 15:04:05"
+
+	defaultInitialPoints = 1000
+	defaultK             = 32
+	defaultOpponents     = 3
+	defaultRange         = 20
+	defaultOfferTTL      = 10 * time.Minute
+	defaultRevengeSize   = 10
+	defaultRevengeTTL    = 3 * 24 * time.Hour
+	defaultLogSize       = 20
+	defaultCheckInterval = time.Minute
+	defaultRetain        = 7 * 24 * time.Hour
+)
+
+// ModuleConfig is the config of the arena module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ServerId      string        // id of this server, the home of the players joining the arena from it; servers without an id read all the defenses locally
+	Mode          uint32        // battle mode whose rules the fights follow (see battle.ModeConf)
+	InitialPoints int64         // points of the players joining the ladder, defaults to defaultInitialPoints
+	K             int64         // most points won or lost in a fight, defaults to defaultK
+	Opponents     int           // number of opponents offered at a time, defaults to defaultOpponents
+	Range         int64         // the opponents are picked within Range places of the player on the ladder, defaults to defaultRange
+	OfferTTL      time.Duration // how long the opponents offered may be attacked, defaults to defaultOfferTTL
+	RevengeSize   int           // number of attackers kept on a revenge list, defaults to defaultRevengeSize
+	RevengeTTL    time.Duration // how long the attackers stay on a revenge list, and the fights in a log, defaults to defaultRevengeTTL
+	LogSize       int           // number of fights kept in the log of a player, defaults to defaultLogSize
+	Season        *SeasonConfig // nil if the ladder has no seasons
+	CheckInterval time.Duration // how often the end of the season is checked, defaults to defaultCheckInterval
+	Retain        time.Duration // how long the ladder of a season is kept once it ended, defaults to defaultRetain
+}
+
+// SeasonConfig configures the seasons of the ladder. Seasons last Days days
+// each, back to back, starting at Start. When a season ends, the rewards are
+// mailed according to the final standings, and the next season starts with
+// an empty ladder.
+type SeasonConfig struct {
+	Start   string          `json:"start"` // start of the first season, e.g. "2023-01-01 00:00:00" (local time)
+	Days    uint32          `json:"days"`
+	Rewards []*SeasonReward `json:"rewards"`
+}
+
+// SeasonReward is the reward mailed to the players ranked between MinRank and
+// MaxRank (inclusive, 1 is the top player) at the end of a season.
+type SeasonReward struct {
+	MinRank uint32           `json:"minRank"`
+	MaxRank uint32           `json:"maxRank"`
+	MailId  uint32           `json:"mailId"` // mail template
+	Items   []mongo.MailItem `json:"items"`
+}
+
+// season is a season of the ladder.
+type season struct {
+	Id    uint32 // 1 for the first season, 0 for a ladder without seasons
+	Start int64  // unix seconds, inclusive
+	End   int64  // unix seconds, exclusive
+}
+
+// seasonAt returns the season at the provided time. Fights before the first
+// season count for the first season.
+func (c *SeasonConfig) seasonAt(now time.Time) (*season, error) {
+	start, err := time.ParseInLocation(timeLayout, c.Start, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("arena: invalid season start %q: %w", c.Start, err)
+	}
+	if c.Days == 0 {
+		return nil, fmt.Errorf("arena: invalid season length: got 0 days")
+	}
+	length := time.Duration(c.Days) * 24 * time.Hour
+	var n int64
+	if now.After(start) {
+		n = int64(now.Sub(start) / length)
+	}
+	begin := start.Add(time.Duration(n) * length)
+	return &season{Id: uint32(n) + 1, Start: begin.Unix(), End: begin.Add(length).Unix()}, nil
+}
+
+// reward returns the reward of the provided rank, or nil if the rank isn't
+// rewarded.
+func (c *SeasonConfig) reward(rank uint32) *SeasonReward {
+	for _, r := range c.Rewards {
+		if rank >= r.MinRank && rank <= r.MaxRank {
+			return r
+		}
+	}
+	return nil
+}
+
+// rewarded returns the number of players rewarded at the end of a season.
+func (c *SeasonConfig) rewarded() uint32 {
+	var n uint32
+	for _, r := range c.Rewards {
+		if r.MaxRank > n {
+			n = r.MaxRank
+		}
+	}
+	return n
+}
@@ -0,0 +1,96 @@
+package arena
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"greatestworks/internal/gameplay/attr"
+)
+
+// Lineup is the lineup a player defends with in the arena: a snapshot of its
+// attributes and of the levels of its skills (see Module.SetDefense). A
+// lineup isn't modified once snapshotted.
+type Lineup struct {
+	Attrs  attr.Attrs        `bson:"attrs" json:"attrs"`
+	Skills map[uint32]uint32 `bson:"skills" json:"skills"`
+	Time   int64             `bson:"time" json:"time"` // unix seconds it was snapshotted at
+}
+
+// snapshot returns the current lineup of a player.
+func snapshot(p Player) *Lineup {
+	return &Lineup{
+		Attrs:  p.GetAttrs().Total(),
+		Skills: p.GetSkillSystem().Levels(),
+		Time:   time.Now().Unix(),
+	}
+}
+
+// Doc 对应DB -> mongo
+type Doc struct {
+	Defense *Lineup `bson:"defense"` // 防守阵容快照，nil为未加入竞技场
+}
+
+// Data is the arena data of a player. Its defense is read by the attackers
+// from any goroutine (see Module.defense), so it's guarded by mu.
+type Data struct {
+	uid       uint64
+	player    Player
+	markDirty func()
+
+	mu  sync.Mutex
+	doc Doc // guarded by mu
+}
+
+func NewData() *Data {
+	return &Data{}
+}
+
+// SetOwner sets the player the data belongs to, and how it's marked dirty.
+func (d *Data) SetOwner(player Player, uid uint64, markDirty func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.player = player
+	d.uid = uid
+	d.markDirty = markDirty
+}
+
+// Save returns a copy of the data, to be stored.
+func (d *Data) Save() (interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.doc, nil
+}
+
+// Load loads the stored data.
+func (d *Data) Load(raw bson.RawValue) error {
+	var doc Doc
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.doc = doc
+	return nil
+}
+
+// Defense returns the defense lineup of the player, nil if it never joined
+// the arena.
+func (d *Data) Defense() *Lineup {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.doc.Defense
+}
+
+func (d *Data) owner() Player {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.player
+}
+
+func (d *Data) setDefense(l *Lineup) {
+	d.mu.Lock()
+	d.doc.Defense = l
+	d.mu.Unlock()
+	d.markDirty()
+}
@@ -0,0 +1,245 @@
+package arena
+
+import (
+	"context"
+	"time"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+	"greatestworks/aop/rpc"
+	"greatestworks/internal/gameplay/battle"
+	"greatestworks/internal/note/event/arenaevent"
+	"greatestworks/internal/protocol"
+)
+
+// Opponent is an opponent offered to a player (see Module.Opponents).
+type Opponent struct {
+	PlayerId uint64
+	Points   int64
+}
+
+// Revenge is an attacker on the revenge list of a player (see
+// Module.Revenges).
+type Revenge struct {
+	PlayerId uint64
+	Time     int64 // unix seconds of its last win against the defense
+}
+
+// Record is a fight of the arena: an attack of the defense of a player.
+type Record struct {
+	Id             uint64 `json:"id"`
+	Time           int64  `json:"time"` // unix seconds
+	Attacker       uint64 `json:"attacker"`
+	Defender       uint64 `json:"defender"`
+	Won            bool   `json:"won"`     // whether the attacker won
+	Revenge        bool   `json:"revenge"` // whether the defender was on the revenge list of the attacker
+	AttackerPoints int64  `json:"attackerPoints"`
+	AttackerDelta  int64  `json:"attackerDelta"`
+	DefenderPoints int64  `json:"defenderPoints"`
+	DefenderDelta  int64  `json:"defenderDelta"`
+}
+
+// DefenseArgs are the arguments of the Defense method of the arena.
+type DefenseArgs struct {
+	PlayerId uint64
+}
+
+// DefenseReply is the reply of the Defense method of the arena.
+type DefenseReply struct {
+	Lineup *Lineup
+}
+
+// DefendedReply is the reply of the Defended method of the arena.
+type DefendedReply struct{}
+
+// rpcModule returns the name the arena of a server is called by: the home
+// servers are routed to by name, with the Nodes of the RPC config.
+func rpcModule(serverId string) string {
+	if serverId == "" {
+		return ModuleName
+	}
+	return ModuleName + ":" + serverId
+}
+
+// serve registers the methods the other servers call on the arena of this
+// one: to read the defenses of its players, and to tell them their defense
+// was attacked.
+func (m *Module) serve() {
+	name := rpcModule(m.serverId)
+	rpc.Handle(name, "Defense", func(ctx context.Context, args *DefenseArgs) (*DefenseReply, error) {
+		l, _, err := m.localDefense(ctx, args.PlayerId)
+		if err != nil {
+			return nil, err
+		}
+		return &DefenseReply{Lineup: l}, nil
+	})
+	rpc.Handle(name, "Defended", func(ctx context.Context, r *Record) (*DefendedReply, error) {
+		m.defended(r)
+		return &DefendedReply{}, nil
+	})
+}
+
+// Attack attacks the defense of a target: an opponent offered to the player,
+// or an attacker on its revenge list. The current lineup of the player is
+// snapshotted as its defense, and fights the defense of the target, read on
+// its home server, in a battle simulated at once. The points of both players
+// are updated, the fight is logged, and the target is told of it.
+func (m *Module) Attack(ctx context.Context, p Player, target uint64) (*Record, error) {
+	uid := p.GetUId()
+	if uid == target {
+		return nil, ErrSelf
+	}
+	// The id is taken first, so that the claim isn't lost if it fails.
+	id, err := idgenerator.NextId()
+	if err != nil {
+		return nil, err
+	}
+	revenge, err := m.claim(ctx, uid, target)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.SetDefense(ctx, p); err != nil {
+		return nil, err
+	}
+	attack := p.GetArenaData().Defense()
+	defense, err := m.defense(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	s := m.current(time.Now())
+	a, err := m.points(ctx, s, uid)
+	if err != nil {
+		return nil, err
+	}
+	d, err := m.points(ctx, s, target)
+	if err != nil {
+		return nil, err
+	}
+	winner, _ := battle.GetMod().Simulate(id, m.mode, [][]battle.Fighter{
+		{{Id: uid, Attrs: attack.Attrs, Skills: attack.Skills}},
+		{{Id: target, Attrs: defense.Attrs, Skills: defense.Skills}},
+	})
+	won := winner == 0
+	res, err := fightScript.Run(ctx, redis.Get(), []string{ladderKey(s)},
+		member(uid), member(target), m.initialPoints, delta(a, d, m.k, won)).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	r := &Record{
+		Id:             id,
+		Time:           time.Now().Unix(),
+		Attacker:       uid,
+		Defender:       target,
+		Won:            won,
+		Revenge:        revenge,
+		AttackerPoints: res[0],
+		AttackerDelta:  res[1],
+		DefenderPoints: res[2],
+		DefenderDelta:  res[3],
+	}
+	m.record(ctx, r)
+	result := "lost"
+	if won {
+		result = "won"
+	}
+	fights.Get(resultLabels{Result: result}).Add(1)
+	eventbus.Publish(eventbus.Default, arenaevent.Attacked{
+		FightId:  id,
+		PlayerId: uid,
+		Defender: target,
+		Won:      won,
+		Revenge:  revenge,
+		Points:   r.AttackerPoints,
+		Delta:    r.AttackerDelta,
+	})
+	m.notify(ctx, r)
+	return r, nil
+}
+
+// defense returns the defense of a player, read on its home server.
+func (m *Module) defense(ctx context.Context, uid uint64) (*Lineup, error) {
+	server, err := home(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if server == m.serverId {
+		l, source, err := m.localDefense(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		fetches.Get(sourceLabels{Source: source}).Add(1)
+		return l, nil
+	}
+	var reply DefenseReply
+	err = rpc.Call(ctx, rpcModule(server), "Defense", &DefenseArgs{PlayerId: uid}, &reply, rpc.CallOptions{Idempotent: true, ShardKey: uid})
+	if err != nil {
+		return nil, err
+	}
+	if reply.Lineup == nil {
+		return nil, ErrNoDefense
+	}
+	fetches.Get(sourceLabels{Source: "rpc"}).Add(1)
+	return reply.Lineup, nil
+}
+
+// localDefense returns the defense of a player of this server, from its data
+// if it's online, or else from its persisted data, and where it was read
+// from.
+func (m *Module) localDefense(ctx context.Context, uid uint64) (*Lineup, string, error) {
+	if d := m.dataOf(uid); d != nil {
+		if l := d.Defense(); l != nil {
+			return l, "online", nil
+		}
+		return nil, "", ErrNoDefense
+	}
+	if m.defenses == nil {
+		return nil, "", ErrNoDefense
+	}
+	l, err := m.defenses(ctx, uid)
+	if err != nil {
+		return nil, "", err
+	}
+	if l == nil {
+		return nil, "", ErrNoDefense
+	}
+	return l, "offline", nil
+}
+
+// notify tells the home server of the defender of a fight of it, on a
+// best-effort basis: the fight is logged either way.
+func (m *Module) notify(ctx context.Context, r *Record) {
+	server, err := home(ctx, r.Defender)
+	if err != nil {
+		logger.Warn("[arena] home of PlayerID:%v err:%v", r.Defender, err)
+		return
+	}
+	if server == m.serverId {
+		m.defended(r)
+		return
+	}
+	var reply DefendedReply
+	if err := rpc.Call(ctx, rpcModule(server), "Defended", r, &reply, rpc.CallOptions{ShardKey: r.Defender}); err != nil {
+		logger.Warn("[arena] notify fight %v to server %v err:%v", r.Id, server, err)
+	}
+}
+
+// defended handles a fight against the defense of a player of this server:
+// the player is told of it if it's online, and Defended is published.
+func (m *Module) defended(r *Record) {
+	if d := m.dataOf(r.Defender); d != nil {
+		if p := d.owner(); p != nil {
+			p.SendMsg(protocol.MessageId_SCArenaDefended, defendedPush(r))
+		}
+	}
+	eventbus.Publish(eventbus.Default, arenaevent.Defended{
+		FightId:  r.Id,
+		PlayerId: r.Defender,
+		Attacker: r.Attacker,
+		Won:      !r.Won,
+		Points:   r.DefenderPoints,
+		Delta:    r.DefenderDelta,
+	})
+}
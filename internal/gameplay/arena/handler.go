@@ -0,0 +1,104 @@
+package arena
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/greatestworks-proto/player"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player Player, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerArenaRegister()
+	})
+}
+
+func HandlerArenaRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSArenaInfo, Info},
+		&Handler{protocol.MessageId_CSArenaDefense, Defense},
+		&Handler{protocol.MessageId_CSArenaOpponents, Opponents},
+		&Handler{protocol.MessageId_CSArenaAttack, Attack},
+	)
+}
+
+// Info sends the player its standing in the ladder, its revenge list, and its
+// last fights.
+func Info(p Player, packet *network.Message) {
+	ctx := context.Background()
+	uid := p.GetUId()
+	points, place, err := GetMod().Standing(ctx, uid)
+	if err != nil {
+		logger.Warn("[arena] standing PlayerID:%v err:%v", uid, err)
+		return
+	}
+	revenges, err := GetMod().Revenges(ctx, uid)
+	if err != nil {
+		logger.Warn("[arena] revenges PlayerID:%v err:%v", uid, err)
+	}
+	records, err := GetMod().Records(ctx, uid)
+	if err != nil {
+		logger.Warn("[arena] records PlayerID:%v err:%v", uid, err)
+	}
+	p.SendMsg(protocol.MessageId_SCArenaInfo, infoToProto(points, place, revenges, records))
+}
+
+// Defense snapshots the current lineup of the player as its defense.
+func Defense(p Player, packet *network.Message) {
+	err := GetMod().SetDefense(context.Background(), p)
+	if err != nil {
+		logger.Warn("[arena] set defense PlayerID:%v err:%v", p.GetUId(), err)
+	}
+	p.SendMsg(protocol.MessageId_SCArenaDefense, &protocol.SCArenaDefense{Ok: err == nil})
+}
+
+// Opponents offers new opponents to the player.
+func Opponents(p Player, packet *network.Message) {
+	opponents, err := GetMod().Opponents(context.Background(), p)
+	if err != nil {
+		logger.Warn("[arena] opponents PlayerID:%v err:%v", p.GetUId(), err)
+		return
+	}
+	p.SendMsg(protocol.MessageId_SCArenaOpponents, opponentsToProto(opponents))
+}
+
+// Attack attacks the defense of an opponent offered to the player, or of an
+// attacker on its revenge list.
+func Attack(p Player, packet *network.Message) {
+	req := &protocol.CSArenaAttack{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	r, err := GetMod().Attack(context.Background(), p, req.Target)
+	if err != nil {
+		logger.Warn("[arena] attack PlayerID:%v target:%v err:%v", p.GetUId(), req.Target, err)
+		p.SendMsg(protocol.MessageId_SCArenaFight, &protocol.SCArenaFight{Target: req.Target})
+		return
+	}
+	p.SendMsg(protocol.MessageId_SCArenaFight, &protocol.SCArenaFight{Ok: true, Target: req.Target, Record: recordToProto(r)})
+}
@@ -0,0 +1,17 @@
+package arena
+
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/attr"
+	"greatestworks/internal/gameplay/skill"
+)
+
+// Player is the player handling the messages of the arena.
+type Player interface {
+	GetUId() uint64
+	GetArenaData() *Data
+	GetAttrs() *attr.Sheet
+	GetSkillSystem() *skill.System
+	SendMsg(ID messageId.MessageId, message proto.Message)
+}
@@ -0,0 +1,244 @@
+package arena
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+)
+
+// homeKey is the Redis hash of the home servers of the players in the arena,
+// by player id: the servers their defenses are read from.
+const homeKey = "arena:home"
+
+// fightScript adds points to the attacker of a fight, and takes them from the
+// defender, none going below 0. KEYS = [ladder], ARGV = [attacker, defender,
+// initial points, points won by the attacker]. Returns the points of the
+// attacker, the points it won, the points of the defender, and the points it
+// won (negative if lost).
+var fightScript = redis.NewScript("arena.fight", `
+local function add(member, delta)
+	local cur = tonumber(redis.call('zscore', KEYS[1], member) or ARGV[3])
+	local v = cur + delta
+	if v < 0 then v = 0 end
+	redis.call('zadd', KEYS[1], v, member)
+	return {v, v - cur}
+end
+local d = tonumber(ARGV[4])
+local a = add(ARGV[1], d)
+local b = add(ARGV[2], -d)
+return {a[1], a[2], b[1], b[2]}`)
+
+// ladderKey returns the Redis ZSet of the points of the players in the
+// ladder of a season, by player id. The ladder is shared by all the servers.
+func ladderKey(s *season) string {
+	if s.Id == 0 {
+		return "arena:ladder"
+	}
+	return fmt.Sprintf("arena:ladder:s%d", s.Id)
+}
+
+// settledKey returns the Redis key marking the rewards of a season mailed to
+// the players of a server.
+func settledKey(s *season, serverId string) string {
+	return fmt.Sprintf("%s:settled:%s", ladderKey(s), serverId)
+}
+
+// offerKey returns the Redis set of the opponents offered to a player, which
+// it may attack once each.
+func offerKey(uid uint64) string {
+	return "arena:offer:" + strconv.FormatUint(uid, 10)
+}
+
+// revengeKey returns the Redis ZSet of the revenge list of a player: the
+// players who won against its defense, by the time of their last win.
+func revengeKey(uid uint64) string {
+	return "arena:revenge:" + strconv.FormatUint(uid, 10)
+}
+
+// logKey returns the Redis list of the fights of a player, attacks and
+// defenses, in JSON, most recent first.
+func logKey(uid uint64) string {
+	return "arena:log:" + strconv.FormatUint(uid, 10)
+}
+
+func member(uid uint64) string {
+	return strconv.FormatUint(uid, 10)
+}
+
+func parseMember(m interface{}) (uint64, error) {
+	s, ok := m.(string)
+	if !ok {
+		return 0, fmt.Errorf("arena: invalid member %v", m)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// delta returns the points won by an attacker with a points against a
+// defender with d points, at most k, negative if lost: the change of its Elo
+// rating, so that beating a stronger defender is worth more than beating a
+// weaker one.
+func delta(a, d, k int64, won bool) int64 {
+	expected := 1 / (1 + math.Pow(10, float64(d-a)/400))
+	var score float64
+	if won {
+		score = 1
+	}
+	return int64(math.Round(float64(k) * (score - expected)))
+}
+
+// join puts a player in the ladder of a season with the initial points,
+// unless it's in it, and records this server as its home.
+func (m *Module) join(ctx context.Context, s *season, uid uint64) error {
+	_, err := redis.Get().Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.ZAddNX(ctx, ladderKey(s), &goredis.Z{Score: float64(m.initialPoints), Member: member(uid)})
+		pipe.HSet(ctx, homeKey, member(uid), m.serverId)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("arena: join PlayerID:%v: %w", uid, err)
+	}
+	return nil
+}
+
+// points returns the points of a player in the ladder of a season: the
+// initial points if it hasn't fought in the season.
+func (m *Module) points(ctx context.Context, s *season, uid uint64) (int64, error) {
+	score, err := redis.Get().ZScore(ctx, ladderKey(s), member(uid)).Result()
+	if err == goredis.Nil {
+		return m.initialPoints, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int64(score), nil
+}
+
+// home returns the home server of a player, or ErrNotJoined.
+func home(ctx context.Context, uid uint64) (string, error) {
+	server, err := redis.Get().HGet(ctx, homeKey, member(uid)).Result()
+	if err == goredis.Nil {
+		return "", ErrNotJoined
+	}
+	return server, err
+}
+
+// offer replaces the opponents offered to a player.
+func (m *Module) offer(ctx context.Context, uid uint64, opponents []Opponent) error {
+	key := offerKey(uid)
+	_, err := redis.Get().TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(opponents) == 0 {
+			return nil
+		}
+		members := make([]interface{}, len(opponents))
+		for i, o := range opponents {
+			members[i] = member(o.PlayerId)
+		}
+		pipe.SAdd(ctx, key, members...)
+		pipe.Expire(ctx, key, m.offerTTL)
+		return nil
+	})
+	return err
+}
+
+// claim claims the right of a player to attack a target: an opponent offered
+// to it, which it may attack once, or an attacker on its revenge list. It
+// returns whether the attack is a revenge, or ErrNotOffered.
+func (m *Module) claim(ctx context.Context, uid, target uint64) (bool, error) {
+	rdb := redis.Get()
+	n, err := rdb.SRem(ctx, offerKey(uid), member(target)).Result()
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return false, nil
+	}
+	at, err := rdb.ZScore(ctx, revengeKey(uid), member(target)).Result()
+	if err == goredis.Nil || (err == nil && time.Unix(int64(at), 0).Before(time.Now().Add(-m.revengeTTL))) {
+		return false, ErrNotOffered
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// record records a fight: the attacker is put on the revenge list of the
+// defender if it won, and taken off the revenge list of the attacker if it
+// took its revenge, and the fight is logged for both players. It's done on a
+// best-effort basis: the points are what matters.
+func (m *Module) record(ctx context.Context, r *Record) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		logger.Error("[arena] marshal fight %+v err:%v", r, err)
+		return
+	}
+	_, err = redis.Get().Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		if r.Won {
+			key := revengeKey(r.Defender)
+			pipe.ZAdd(ctx, key, &goredis.Z{Score: float64(r.Time), Member: member(r.Attacker)})
+			pipe.ZRemRangeByRank(ctx, key, 0, int64(-m.revengeSize-1))
+			pipe.Expire(ctx, key, m.revengeTTL)
+			if r.Revenge {
+				pipe.ZRem(ctx, revengeKey(r.Attacker), member(r.Defender))
+			}
+		}
+		for _, uid := range []uint64{r.Attacker, r.Defender} {
+			key := logKey(uid)
+			pipe.LPush(ctx, key, b)
+			pipe.LTrim(ctx, key, 0, int64(m.logSize-1))
+			pipe.Expire(ctx, key, m.revengeTTL)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("[arena] record fight %v err:%v", r.Id, err)
+	}
+}
+
+// Revenges returns the revenge list of a player: the players who won against
+// its defense lately, and whom it may attack back, most recent first.
+func (m *Module) Revenges(ctx context.Context, uid uint64) ([]Revenge, error) {
+	since := time.Now().Add(-m.revengeTTL).Unix()
+	zs, err := redis.Get().ZRevRangeByScoreWithScores(ctx, revengeKey(uid), &goredis.ZRangeBy{
+		Min: strconv.FormatInt(since, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Revenge, 0, len(zs))
+	for _, z := range zs {
+		id, err := parseMember(z.Member)
+		if err != nil {
+			continue
+		}
+		list = append(list, Revenge{PlayerId: id, Time: int64(z.Score)})
+	}
+	return list, nil
+}
+
+// Records returns the log of the fights of a player, most recent first.
+func (m *Module) Records(ctx context.Context, uid uint64) ([]*Record, error) {
+	raws, err := redis.Get().LRange(ctx, logKey(uid), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*Record, 0, len(raws))
+	for _, raw := range raws {
+		r := &Record{}
+		if err := json.Unmarshal([]byte(raw), r); err != nil {
+			logger.Error("[arena] unmarshal fight of PlayerID:%v err:%v", uid, err)
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
@@ -0,0 +1,300 @@
+package arena
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/greatestworks-proto/module"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/module_router"
+	"greatestworks/aop/redis"
+	"greatestworks/internal"
+	"greatestworks/internal/gameplay/battle"
+)
+
+const (
+	ModuleName = "arena"
+)
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNotJoined  = errors.New("arena: player not in the arena")
+	ErrNoDefense  = errors.New("arena: no defense")
+	ErrNotOffered = errors.New("arena: opponent not offered, nor on the revenge list")
+	ErrSelf       = errors.New("arena: can't attack oneself")
+)
+
+var (
+	fights = metrics.NewCounterMap[resultLabels](
+		"arena_fights",
+		"Number of attacks fought from this server, by result",
+	)
+	fetches = metrics.NewCounterMap[sourceLabels](
+		"arena_defense_fetches",
+		"Number of defenses fetched for the attacks from this server, by source (online, offline, rpc)",
+	)
+)
+
+type resultLabels struct {
+	Result string
+}
+
+type sourceLabels struct {
+	Source string
+}
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// DefenseReader reads the defense of a player of this server who isn't online
+// on it from its persisted data (see player.OfflineStore). It returns nil if
+// the player has none.
+type DefenseReader func(ctx context.Context, uid uint64) (*Lineup, error)
+
+// Module is the arena. The players snapshot their lineup as their defense
+// (see SetDefense), and attack the defenses of the others, offline or on
+// other servers, in battles simulated at once (see battle.Module.Simulate):
+// their points in the ladder, shared by all the servers, go up or down with
+// the results. The players whose defense lost may take their revenge on the
+// attackers. At the end of a season, the rewards are mailed according to the
+// final standings.
+type Module struct {
+	*internal.BaseModule
+	initFlag      bool
+	serverId      string
+	mode          uint32
+	initialPoints int64
+	k             int64
+	opponents     int
+	rangeN        int64
+	offerTTL      time.Duration
+	revengeSize   int
+	revengeTTL    time.Duration
+	logSize       int
+	seasonConf    *SeasonConfig
+	checkInterval time.Duration
+	retain        time.Duration
+	defenses      DefenseReader
+	stopCh        chan struct{}
+
+	mu     sync.Mutex
+	online map[uint64]*Data // guarded by mu
+	season *season          // season to settle next; guarded by mu
+	rand   *rand.Rand       // guarded by mu
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
+	return Mod
+}
+
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.serverId = conf.ServerId
+	m.mode = conf.Mode
+	m.initialPoints = conf.InitialPoints
+	if m.initialPoints <= 0 {
+		m.initialPoints = defaultInitialPoints
+	}
+	m.k = conf.K
+	if m.k <= 0 {
+		m.k = defaultK
+	}
+	m.opponents = conf.Opponents
+	if m.opponents <= 0 {
+		m.opponents = defaultOpponents
+	}
+	m.rangeN = conf.Range
+	if m.rangeN <= 0 {
+		m.rangeN = defaultRange
+	}
+	m.offerTTL = conf.OfferTTL
+	if m.offerTTL <= 0 {
+		m.offerTTL = defaultOfferTTL
+	}
+	m.revengeSize = conf.RevengeSize
+	if m.revengeSize <= 0 {
+		m.revengeSize = defaultRevengeSize
+	}
+	m.revengeTTL = conf.RevengeTTL
+	if m.revengeTTL <= 0 {
+		m.revengeTTL = defaultRevengeTTL
+	}
+	m.logSize = conf.LogSize
+	if m.logSize <= 0 {
+		m.logSize = defaultLogSize
+	}
+	m.checkInterval = conf.CheckInterval
+	if m.checkInterval <= 0 {
+		m.checkInterval = defaultCheckInterval
+	}
+	m.retain = conf.Retain
+	if m.retain <= 0 {
+		m.retain = defaultRetain
+	}
+	m.seasonConf = conf.Season
+	if m.seasonConf != nil {
+		s, err := m.seasonConf.seasonAt(time.Now())
+		if err != nil {
+			return err
+		}
+		m.season = s
+	}
+	m.online = make(map[uint64]*Data)
+	m.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	m.stopCh = make(chan struct{})
+	m.serve()
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts settling the seasons of the ladder when they end.
+func (m *Module) OnStart() {
+	if m.initFlag && m.seasonConf != nil {
+		go m.runSeasons()
+	}
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+	}
+}
+
+// SetDefenses sets how the defenses of the players of this server who aren't
+// online are read. It must be called before the module starts.
+func (m *Module) SetDefenses(r DefenseReader) {
+	m.defenses = r
+}
+
+// Online registers the arena data of a player that logged in.
+func (m *Module) Online(d *Data) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.online[d.uid] = d
+}
+
+// Offline unregisters the arena data of a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
+}
+
+func (m *Module) dataOf(uid uint64) *Data {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.online[uid]
+}
+
+// current returns the season of the ladder at the provided time.
+func (m *Module) current(now time.Time) *season {
+	if m.seasonConf != nil {
+		// The season config is validated by Init.
+		if s, err := m.seasonConf.seasonAt(now); err == nil {
+			return s
+		}
+	}
+	return &season{}
+}
+
+// SetDefense snapshots the current lineup of a player as its defense, and
+// joins it to the ladder, with this server as its home. The attackers fight
+// the snapshot, whatever the player does since, until it's snapshotted
+// again, here or when the player attacks.
+func (m *Module) SetDefense(ctx context.Context, p Player) error {
+	p.GetArenaData().setDefense(snapshot(p))
+	return m.join(ctx, m.current(time.Now()), p.GetUId())
+}
+
+// Opponents offers opponents to a player, joining it to the ladder if it
+// isn't in it: players picked at random within ModuleConfig.Range places of
+// it. It may attack each of them once, until they're replaced by the next
+// offer, or ModuleConfig.OfferTTL is up.
+func (m *Module) Opponents(ctx context.Context, p Player) ([]Opponent, error) {
+	uid := p.GetUId()
+	if p.GetArenaData().Defense() == nil {
+		if err := m.SetDefense(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+	s := m.current(time.Now())
+	if err := m.join(ctx, s, uid); err != nil {
+		return nil, err
+	}
+	rdb := redis.Get()
+	key := ladderKey(s)
+	place, err := rdb.ZRevRank(ctx, key, member(uid)).Result()
+	if err != nil {
+		return nil, err
+	}
+	start := place - m.rangeN
+	if start < 0 {
+		start = 0
+	}
+	zs, err := rdb.ZRevRangeWithScores(ctx, key, start, place+m.rangeN).Result()
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]Opponent, 0, len(zs))
+	for _, z := range zs {
+		id, err := parseMember(z.Member)
+		if err != nil || id == uid {
+			continue
+		}
+		candidates = append(candidates, Opponent{PlayerId: id, Points: int64(z.Score)})
+	}
+	m.mu.Lock()
+	m.rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	m.mu.Unlock()
+	if len(candidates) > m.opponents {
+		candidates = candidates[:m.opponents]
+	}
+	if err := m.offer(ctx, uid, candidates); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// Standing returns the points of a player in the ladder of the current
+// season, and its place, 1 for the top player, 0 if it isn't in it.
+func (m *Module) Standing(ctx context.Context, uid uint64) (int64, int64, error) {
+	s := m.current(time.Now())
+	points, err := m.points(ctx, s, uid)
+	if err != nil {
+		return 0, 0, err
+	}
+	place, err := redis.Get().ZRevRank(ctx, ladderKey(s), member(uid)).Result()
+	if err != nil {
+		return points, 0, nil
+	}
+	return points, place + 1, nil
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
+
+// Dependencies returns the modules the arena depends on: the fights follow
+// the rules of the battles, and the season rewards are mailed.
+func (m *Module) Dependencies() []string {
+	return []string{battle.ModuleName, module.Module_Email.String()}
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
@@ -0,0 +1,14 @@
+package arena
+
+import (
+	"greatestworks/internal"
+	"greatestworks/internal/note/event"
+)
+
+// OnEvent is unused: the arena publishes its events on the event bus (see
+// arenaevent), and handles none.
+func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
+}
+
+func (m *Module) SetEventCategoryActive(eventCategory int) {
+}
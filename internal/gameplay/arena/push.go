@@ -0,0 +1,43 @@
+package arena
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+func recordToProto(r *Record) *protocol.ArenaRecord {
+	return &protocol.ArenaRecord{
+		Id:             r.Id,
+		Time:           r.Time,
+		Attacker:       r.Attacker,
+		Defender:       r.Defender,
+		Won:            r.Won,
+		Revenge:        r.Revenge,
+		AttackerPoints: r.AttackerPoints,
+		AttackerDelta:  r.AttackerDelta,
+		DefenderPoints: r.DefenderPoints,
+		DefenderDelta:  r.DefenderDelta,
+	}
+}
+
+func opponentsToProto(opponents []Opponent) *protocol.SCArenaOpponents {
+	msg := &protocol.SCArenaOpponents{}
+	for _, o := range opponents {
+		msg.Opponents = append(msg.Opponents, &protocol.ArenaOpponent{PlayerId: o.PlayerId, Points: o.Points})
+	}
+	return msg
+}
+
+func infoToProto(points, place int64, revenges []Revenge, records []*Record) *protocol.SCArenaInfo {
+	msg := &protocol.SCArenaInfo{Points: points, Place: place}
+	for _, r := range revenges {
+		msg.Revenges = append(msg.Revenges, &protocol.ArenaRevenge{PlayerId: r.PlayerId, Time: r.Time})
+	}
+	for _, r := range records {
+		msg.Records = append(msg.Records, recordToProto(r))
+	}
+	return msg
+}
+
+func defendedPush(r *Record) *protocol.SCArenaDefended {
+	return &protocol.SCArenaDefended{Record: recordToProto(r)}
+}
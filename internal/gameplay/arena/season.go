@@ -0,0 +1,148 @@
+package arena
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"greatestworks/aop/dlock"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/redis"
+	"greatestworks/internal/communicate/email"
+)
+
+// runSeasons settles the seasons of the ladder when they end, until the
+// module is stopped.
+func (m *Module) runSeasons() {
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			m.checkSeason(now)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// checkSeason settles the season if it ended before now.
+func (m *Module) checkSeason(now time.Time) {
+	m.mu.Lock()
+	s := m.season
+	m.mu.Unlock()
+	if now.Unix() < s.End {
+		return
+	}
+	if err := m.settle(context.Background(), s); err != nil {
+		logger.Error("[arena] settle season %v failed: %v", s.Id, err)
+		return
+	}
+	next := m.current(now)
+	m.mu.Lock()
+	m.season = next
+	m.mu.Unlock()
+}
+
+// settle mails the rewards of a season to the players of this server,
+// according to their final standings in the ladder. Fights since the season
+// ended already go to the ladder of the next season (see ladderKey). The
+// ladder is shared by all the servers, so each server mails the rewards of
+// the players whose home it is, and the ladder is kept for
+// ModuleConfig.Retain, for the servers to settle it.
+//
+// When several processes serve the same server, only one of them settles a
+// season, holding the lock of the season; the others return an error until
+// it's done, and retry.
+func (m *Module) settle(ctx context.Context, s *season) error {
+	rdb := redis.Get()
+	doneKey := settledKey(s, m.serverId)
+	done, err := rdb.Exists(ctx, doneKey).Result()
+	if err != nil {
+		return err
+	}
+	if done > 0 {
+		// Another process has settled the season.
+		return nil
+	}
+	lock, err := dlock.Get().TryLock(ctx, fmt.Sprintf("arena:settle:s%d:%s", s.Id, m.serverId))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	// Stop if the lock is lost, as another process may settle the season.
+	ctx = lock.Context()
+	// Check again: the season may have been settled since.
+	if done, err := rdb.Exists(ctx, doneKey).Result(); err != nil || done > 0 {
+		return err
+	}
+
+	n := m.seasonConf.rewarded()
+	var mailed int
+	if n > 0 {
+		zs, err := rdb.ZRevRangeWithScores(ctx, ladderKey(s), 0, int64(n)-1).Result()
+		if err != nil {
+			return err
+		}
+		members := make([]string, len(zs))
+		for i, z := range zs {
+			members[i], _ = z.Member.(string)
+		}
+		homes := make([]interface{}, len(zs))
+		if len(members) > 0 {
+			if homes, err = rdb.HMGet(ctx, homeKey, members...).Result(); err != nil {
+				return err
+			}
+		}
+		// The rewards are mailed on a best-effort basis: sending a mail
+		// twice is a no-op, so they can be sent again.
+		for i, z := range zs {
+			if server, _ := homes[i].(string); server != m.serverId {
+				continue
+			}
+			uid, err := parseMember(z.Member)
+			if err != nil {
+				continue
+			}
+			rank := uint32(i + 1)
+			reward := m.seasonConf.reward(rank)
+			if reward == nil {
+				continue
+			}
+			if err := email.SendSystemMail(ctx, uid, seasonRewardMail(s, uid, rank, reward)); err != nil {
+				logger.Error("[arena] mail reward of season %v to player %v failed: %v", s.Id, uid, err)
+				continue
+			}
+			mailed++
+		}
+	}
+
+	if err := rdb.Set(ctx, doneKey, time.Now().Unix(), m.retain).Err(); err != nil {
+		return fmt.Errorf("mark season settled: %w", err)
+	}
+	if err := rdb.Expire(ctx, ladderKey(s), m.retain).Err(); err != nil {
+		return fmt.Errorf("expire season ladder: %w", err)
+	}
+	logger.Info("[arena] season %v settled, %v players rewarded", s.Id, mailed)
+	return nil
+}
+
+// seasonRewardMail returns the mail of the season reward of a player. The id
+// of the mail is derived from the season and the player, so that the reward
+// is mailed at most once.
+func seasonRewardMail(s *season, uid uint64, rank uint32, reward *SeasonReward) *mongo.MailInfo {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "arena:season:%d:player:%d", s.Id, uid)
+	info := &mongo.MailInfo{
+		MUuid:    h.Sum64(),
+		MailID:   reward.MailId,
+		MContent: fmt.Sprintf("arena season %d: rank %d", s.Id, rank),
+		MTime:    time.Now().Unix(),
+	}
+	for _, item := range reward.Items {
+		info.MItems = append(info.MItems, mongo.MailItem{ItemId: item.ItemId, Num: item.Num})
+	}
+	return info
+}
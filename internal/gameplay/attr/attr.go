@@ -0,0 +1,132 @@
+package attr
+
+import "sync"
+
+// Kind is a kind of attribute of a player.
+type Kind int
+
+const (
+	HP         Kind = iota + 1 // 生命
+	Attack                     // 攻击
+	Defense                    // 防御
+	Speed                      // 速度
+	CritRate                   // 暴击率，万分比
+	CritDamage                 // 暴击伤害，万分比
+	Hit                        // 命中
+	Dodge                      // 闪避
+	MP                         // 法力上限
+)
+
+// Attrs are amounts of attributes, by kind.
+type Attrs map[Kind]int64
+
+// Add adds other to the attributes.
+func (a Attrs) Add(other Attrs) {
+	for k, v := range other {
+		a[k] += v
+	}
+}
+
+// AddScaled adds other, scaled by rate/10000, to the attributes.
+func (a Attrs) AddScaled(other Attrs, rate int64) {
+	for k, v := range other {
+		a[k] += v * rate / 10000
+	}
+}
+
+// Clone returns a copy of the attributes.
+func (a Attrs) Clone() Attrs {
+	c := make(Attrs, len(a))
+	for k, v := range a {
+		c[k] = v
+	}
+	return c
+}
+
+// Sheet holds the attributes of a player, by source (e.g., "base", "equip",
+// "buff"): each source sets its own part, and the total is recalculated
+// whenever a part changes. It's safe for concurrent use.
+type Sheet struct {
+	mu       sync.Mutex
+	sources  map[string]Attrs // guarded by mu
+	total    Attrs            // guarded by mu
+	onChange []func(Attrs)    // guarded by mu
+}
+
+func NewSheet() *Sheet {
+	return &Sheet{sources: map[string]Attrs{}, total: Attrs{}}
+}
+
+// Set sets the part of the attributes provided by a source, replacing its
+// previous part, and calls the OnChange functions with the new total if it
+// changed.
+func (s *Sheet) Set(source string, attrs Attrs) {
+	s.mu.Lock()
+	if len(attrs) == 0 {
+		delete(s.sources, source)
+	} else {
+		s.sources[source] = attrs.Clone()
+	}
+	total := Attrs{}
+	for _, part := range s.sources {
+		total.Add(part)
+	}
+	changed := !equal(total, s.total)
+	s.total = total
+	fns := s.onChange
+	s.mu.Unlock()
+
+	if changed {
+		for _, fn := range fns {
+			fn(total.Clone())
+		}
+	}
+}
+
+// Total returns a copy of the total attributes.
+func (s *Sheet) Total() Attrs {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.total.Clone()
+}
+
+// Get returns the total amount of an attribute.
+func (s *Sheet) Get(k Kind) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.total[k]
+}
+
+// Part returns a copy of the part of the attributes provided by a source.
+func (s *Sheet) Part(source string) Attrs {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sources[source].Clone()
+}
+
+// OnChange registers a function called with the new total whenever it
+// changes, without holding the lock of the sheet.
+func (s *Sheet) OnChange(fn func(total Attrs)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = append(s.onChange, fn)
+}
+
+func equal(a, b Attrs) bool {
+	n := 0
+	for k, v := range a {
+		if v == 0 {
+			continue
+		}
+		if b[k] != v {
+			return false
+		}
+		n++
+	}
+	for _, v := range b {
+		if v != 0 {
+			n--
+		}
+	}
+	return n == 0
+}
@@ -0,0 +1,15 @@
+package attr
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+// ToProto returns the message pushing the total attributes of a player to
+// the client.
+func ToProto(total Attrs) *protocol.SCPlayerAttr {
+	msg := &protocol.SCPlayerAttr{}
+	for k, v := range total {
+		msg.Attrs = append(msg.Attrs, &protocol.AttrValue{Kind: int32(k), Value: v})
+	}
+	return msg
+}
@@ -0,0 +1,17 @@
+package bag
+
+const (
+	defaultCapacity    = 100
+	defaultMaxCapacity = 300
+	defaultExpandStep  = 10
+)
+
+// ModuleConfig is the config of the bag module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ItemFile    string  // path of the item table, a JSON array or a CSV file of template.Conf (see gamedata)
+	Capacity    int     // number of slots of a new bag, defaults to defaultCapacity
+	MaxCapacity int     // number of slots a bag may be expanded to, defaults to defaultMaxCapacity
+	ExpandStep  int     // number of slots added by an expansion, defaults to defaultExpandStep
+	ExpandCost  []Stack // items consumed by an expansion; expansions are free if empty
+}
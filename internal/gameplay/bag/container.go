@@ -0,0 +1,250 @@
+package bag
+
+import (
+	"errors"
+	"fmt"
+
+	"greatestworks/internal/gameplay/bag/item/template"
+	"greatestworks/internal/note/event/bagevent"
+)
+
+var (
+	ErrUnknownItem = errors.New("bag: unknown item")
+	ErrInvalidNum  = errors.New("bag: invalid number of items")
+	ErrBagFull     = errors.New("bag: not enough free slots")
+	ErrNotEnough   = errors.New("bag: not enough items")
+	ErrMaxCapacity = errors.New("bag: capacity at its maximum")
+)
+
+// Stack is an amount of an item, granted or consumed.
+type Stack struct {
+	Id  uint32 `json:"id"`
+	Num int64  `json:"num"`
+}
+
+// Slot is a slot of a bag. A slot holds up to Conf.PerSlot of a stackable
+// item, or a single instance of a non stackable item, identified by its Uid.
+type Slot struct {
+	Uid uint64 `bson:"uid,omitempty"` // 不可叠加道具的实例id
+	Id  uint32 `bson:"id"`
+	Num int64  `bson:"num"`
+}
+
+// Change is the change of the amount of an item in a bag.
+type Change = bagevent.ItemChange
+
+// container holds the slots of a bag. Its operations are all-or-nothing: they
+// work on a copy of the slots, which replaces them only if the operation
+// succeeds as a whole.
+type container struct {
+	capacity int
+	slots    []Slot
+}
+
+// count returns the amount of an item in the container.
+func (c *container) count(id uint32) int64 {
+	var n int64
+	for _, s := range c.slots {
+		if s.Id == id {
+			n += s.Num
+		}
+	}
+	return n
+}
+
+// free returns the number of free slots.
+func (c *container) free() int {
+	return c.capacity - len(c.slots)
+}
+
+// grant adds items to the container: stackable items fill the slots holding
+// the same item first, then new slots; each instance of a non stackable item
+// takes a slot of its own, identified by an id from newUid. Items that convert
+// to another item (see template.Conf.ConvertTo) are added as that item. It
+// fails, adding nothing, if any item is unknown or the items don't fit, as
+// soon as a slot is missing, without taking ids for the rest.
+func (c *container) grant(stacks []Stack, conf func(uint32) *template.Conf, newUid func() (uint64, error)) ([]Change, error) {
+	slots := append([]Slot(nil), c.slots...)
+	changes := newChangeSet()
+	for _, st := range stacks {
+		if st.Num <= 0 {
+			return nil, fmt.Errorf("%w: %d of item %d", ErrInvalidNum, st.Num, st.Id)
+		}
+		ic := conf(st.Id)
+		if ic != nil && ic.ConvertTo != 0 {
+			ic = conf(ic.ConvertTo)
+		}
+		if ic == nil {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownItem, st.Id)
+		}
+		left := st.Num
+		if ic.Stackable() {
+			for i := range slots {
+				if left == 0 {
+					break
+				}
+				if slots[i].Id != ic.Id || slots[i].Num >= ic.PerSlot() {
+					continue
+				}
+				n := min64(left, ic.PerSlot()-slots[i].Num)
+				slots[i].Num += n
+				left -= n
+			}
+			for left > 0 {
+				if len(slots) >= c.capacity {
+					return nil, ErrBagFull
+				}
+				n := min64(left, ic.PerSlot())
+				slots = append(slots, Slot{Id: ic.Id, Num: n})
+				left -= n
+			}
+			changes.add(ic.Id, st.Num)
+		} else {
+			for ; left > 0; left-- {
+				if len(slots) >= c.capacity {
+					return nil, ErrBagFull
+				}
+				uid, err := newUid()
+				if err != nil {
+					return nil, fmt.Errorf("bag: item instance id: %w", err)
+				}
+				slots = append(slots, Slot{Uid: uid, Id: ic.Id, Num: 1})
+				changes.add(ic.Id, 1, uid)
+			}
+		}
+	}
+	c.slots = slots
+	return changes.done(c), nil
+}
+
+// consume removes items from the container, from the last slots holding
+// them first, so that partial stacks go first. It fails, removing nothing,
+// if there aren't enough of any item.
+func (c *container) consume(stacks []Stack) ([]Change, error) {
+	need := make(map[uint32]int64, len(stacks))
+	for _, st := range stacks {
+		if st.Num <= 0 {
+			return nil, fmt.Errorf("%w: %d of item %d", ErrInvalidNum, st.Num, st.Id)
+		}
+		need[st.Id] += st.Num
+	}
+	for id, n := range need {
+		if c.count(id) < n {
+			return nil, fmt.Errorf("%w: %d of item %d", ErrNotEnough, n, id)
+		}
+	}
+
+	slots := append([]Slot(nil), c.slots...)
+	changes := newChangeSet()
+	for i := len(slots) - 1; i >= 0; i-- {
+		s := &slots[i]
+		left := need[s.Id]
+		if left == 0 {
+			continue
+		}
+		n := min64(left, s.Num)
+		s.Num -= n
+		need[s.Id] = left - n
+		if s.Uid != 0 {
+			changes.add(s.Id, -n, s.Uid)
+		} else {
+			changes.add(s.Id, -n)
+		}
+	}
+	c.slots = compact(slots)
+	return changes.done(c), nil
+}
+
+// remove removes instances of non stackable items from the container, by
+// their ids. It fails, removing nothing, if any instance isn't in the
+// container.
+func (c *container) remove(uids []uint64) ([]Change, error) {
+	return c.exchange(uids, nil)
+}
+
+// exchange removes instances of non stackable items from the container, by
+// their ids, and puts instances back (e.g., unequipped equipment), keeping
+// their ids. It fails, changing nothing, if any instance to remove isn't in
+// the container, or the instances to put don't fit.
+func (c *container) exchange(remove []uint64, put []Slot) ([]Change, error) {
+	drop := make(map[uint64]bool, len(remove))
+	for _, uid := range remove {
+		drop[uid] = true
+	}
+	slots := append([]Slot(nil), c.slots...)
+	changes := newChangeSet()
+	for i := range slots {
+		if slots[i].Uid != 0 && drop[slots[i].Uid] {
+			changes.add(slots[i].Id, -slots[i].Num, slots[i].Uid)
+			delete(drop, slots[i].Uid)
+			slots[i].Num = 0
+		}
+	}
+	if len(drop) > 0 {
+		return nil, fmt.Errorf("%w: %d instances missing", ErrNotEnough, len(drop))
+	}
+	slots = compact(slots)
+	for _, s := range put {
+		if s.Uid == 0 || s.Num != 1 {
+			return nil, fmt.Errorf("%w: put %d of item %d", ErrInvalidNum, s.Num, s.Id)
+		}
+		slots = append(slots, s)
+		changes.add(s.Id, 1, s.Uid)
+	}
+	if len(slots) > c.capacity {
+		return nil, ErrBagFull
+	}
+	c.slots = slots
+	return changes.done(c), nil
+}
+
+// compact drops the empty slots.
+func compact(slots []Slot) []Slot {
+	kept := slots[:0]
+	for _, s := range slots {
+		if s.Num > 0 {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// changeSet accumulates the changes of an operation, by item, in the order
+// the items were first changed.
+type changeSet struct {
+	order []uint32
+	byId  map[uint32]*Change
+}
+
+func newChangeSet() *changeSet {
+	return &changeSet{byId: map[uint32]*Change{}}
+}
+
+func (cs *changeSet) add(id uint32, delta int64, uids ...uint64) {
+	ch := cs.byId[id]
+	if ch == nil {
+		ch = &Change{Id: id}
+		cs.byId[id] = ch
+		cs.order = append(cs.order, id)
+	}
+	ch.Delta += delta
+	ch.Uids = append(ch.Uids, uids...)
+}
+
+// done returns the changes, with the amounts of the items in c.
+func (cs *changeSet) done(c *container) []Change {
+	changes := make([]Change, 0, len(cs.order))
+	for _, id := range cs.order {
+		ch := *cs.byId[id]
+		ch.Num = c.count(id)
+		changes = append(changes, ch)
+	}
+	return changes
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
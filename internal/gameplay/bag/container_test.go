@@ -0,0 +1,300 @@
+package bag
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"greatestworks/internal/gameplay/bag/item/template"
+)
+
+const (
+	itemPotion  = 1 // stackable, 10 per slot
+	itemSword   = 2 // non stackable
+	itemPotions = 3 // converts to itemPotion
+	itemUnknown = 4
+)
+
+var confs = map[uint32]*template.Conf{
+	itemPotion:  {Id: itemPotion, StackLimit: 10},
+	itemSword:   {Id: itemSword},
+	itemPotions: {Id: itemPotions, StackLimit: 10, ConvertTo: itemPotion},
+}
+
+func conf(id uint32) *template.Conf {
+	return confs[id]
+}
+
+// uids returns a newUid giving 100, 101..., or an error after n ids if n >= 0.
+func uids(n int) func() (uint64, error) {
+	next := uint64(100)
+	return func() (uint64, error) {
+		if n == 0 {
+			return 0, errors.New("no more ids")
+		}
+		n--
+		next++
+		return next - 1, nil
+	}
+}
+
+// checkResult checks the slots and the changes of an operation on c, which
+// held before: a failed operation must leave the slots as they were.
+func checkResult(t *testing.T, c *container, before []Slot, changes []Change, err error, wantSlots []Slot, wantChanges []Change, wantErr error) {
+	t.Helper()
+	if wantErr != nil {
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("error: got %v, want %v", err, wantErr)
+		}
+		if changes != nil {
+			t.Errorf("changes: got %+v, want none", changes)
+		}
+		wantSlots = before
+	} else if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("changes: got %+v, want %+v", changes, wantChanges)
+	}
+	if len(c.slots) != len(wantSlots) || len(wantSlots) > 0 && !reflect.DeepEqual(c.slots, wantSlots) {
+		t.Errorf("slots: got %+v, want %+v", c.slots, wantSlots)
+	}
+}
+
+func TestGrant(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		capacity int
+		slots    []Slot
+		uids     int // ids available, -1 for no limit
+		grant    []Stack
+		want     []Slot
+		changes  []Change
+		err      error
+	}{
+		{
+			name:     "partial stack first",
+			capacity: 3,
+			slots:    []Slot{{Id: itemPotion, Num: 7}, {Uid: 1, Id: itemSword, Num: 1}},
+			uids:     -1,
+			grant:    []Stack{{Id: itemPotion, Num: 5}},
+			want:     []Slot{{Id: itemPotion, Num: 10}, {Uid: 1, Id: itemSword, Num: 1}, {Id: itemPotion, Num: 2}},
+			changes:  []Change{{Id: itemPotion, Delta: 5, Num: 12}},
+		},
+		{
+			name:     "new stacks",
+			capacity: 3,
+			uids:     -1,
+			grant:    []Stack{{Id: itemPotion, Num: 25}},
+			want:     []Slot{{Id: itemPotion, Num: 10}, {Id: itemPotion, Num: 10}, {Id: itemPotion, Num: 5}},
+			changes:  []Change{{Id: itemPotion, Delta: 25, Num: 25}},
+		},
+		{
+			name:     "instances",
+			capacity: 3,
+			slots:    []Slot{{Id: itemPotion, Num: 1}},
+			uids:     -1,
+			grant:    []Stack{{Id: itemSword, Num: 2}},
+			want:     []Slot{{Id: itemPotion, Num: 1}, {Uid: 100, Id: itemSword, Num: 1}, {Uid: 101, Id: itemSword, Num: 1}},
+			changes:  []Change{{Id: itemSword, Delta: 2, Num: 2, Uids: []uint64{100, 101}}},
+		},
+		{
+			name:     "converted",
+			capacity: 2,
+			slots:    []Slot{{Id: itemPotion, Num: 7}},
+			uids:     -1,
+			grant:    []Stack{{Id: itemPotions, Num: 4}},
+			want:     []Slot{{Id: itemPotion, Num: 10}, {Id: itemPotion, Num: 1}},
+			changes:  []Change{{Id: itemPotion, Delta: 4, Num: 11}},
+		},
+		{
+			name:     "full bag",
+			capacity: 1,
+			slots:    []Slot{{Id: itemPotion, Num: 10}},
+			uids:     -1,
+			grant:    []Stack{{Id: itemPotion, Num: 1}},
+			err:      ErrBagFull,
+		},
+		{
+			name:     "overflow rolls back the stacks that fit",
+			capacity: 2,
+			slots:    []Slot{{Id: itemPotion, Num: 7}},
+			uids:     -1,
+			grant:    []Stack{{Id: itemPotion, Num: 3}, {Id: itemSword, Num: 2}},
+			err:      ErrBagFull,
+		},
+		{
+			name:     "overflow of a stack",
+			capacity: 2,
+			slots:    []Slot{{Id: itemPotion, Num: 7}},
+			uids:     -1,
+			grant:    []Stack{{Id: itemPotion, Num: 14}},
+			err:      ErrBagFull,
+		},
+		{
+			name:     "unknown item",
+			capacity: 2,
+			slots:    []Slot{{Id: itemPotion, Num: 7}},
+			uids:     -1,
+			grant:    []Stack{{Id: itemPotion, Num: 1}, {Id: itemUnknown, Num: 1}},
+			err:      ErrUnknownItem,
+		},
+		{
+			name:     "invalid number",
+			capacity: 2,
+			uids:     -1,
+			grant:    []Stack{{Id: itemPotion, Num: 1}, {Id: itemPotion, Num: 0}},
+			err:      ErrInvalidNum,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &container{capacity: tc.capacity, slots: tc.slots}
+			before := append([]Slot(nil), tc.slots...)
+			changes, err := c.grant(tc.grant, conf, uids(tc.uids))
+			checkResult(t, c, before, changes, err, tc.want, tc.changes, tc.err)
+		})
+	}
+}
+
+func TestGrantUidError(t *testing.T) {
+	c := &container{capacity: 3, slots: []Slot{{Id: itemPotion, Num: 7}}}
+	changes, err := c.grant([]Stack{{Id: itemPotion, Num: 1}, {Id: itemSword, Num: 2}}, conf, uids(1))
+	if err == nil {
+		t.Fatalf("grant: got %+v, want an error", changes)
+	}
+	if want := []Slot{{Id: itemPotion, Num: 7}}; !reflect.DeepEqual(c.slots, want) {
+		t.Errorf("slots: got %+v, want %+v", c.slots, want)
+	}
+}
+
+func TestConsume(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		slots   []Slot
+		consume []Stack
+		want    []Slot
+		changes []Change
+		err     error
+	}{
+		{
+			name:    "partial stacks first",
+			slots:   []Slot{{Id: itemPotion, Num: 10}, {Id: itemPotion, Num: 4}},
+			consume: []Stack{{Id: itemPotion, Num: 6}},
+			want:    []Slot{{Id: itemPotion, Num: 8}},
+			changes: []Change{{Id: itemPotion, Delta: -6, Num: 8}},
+		},
+		{
+			name:    "whole stacks",
+			slots:   []Slot{{Id: itemPotion, Num: 10}, {Uid: 1, Id: itemSword, Num: 1}, {Id: itemPotion, Num: 4}},
+			consume: []Stack{{Id: itemPotion, Num: 14}},
+			want:    []Slot{{Uid: 1, Id: itemSword, Num: 1}},
+			changes: []Change{{Id: itemPotion, Delta: -14, Num: 0}},
+		},
+		{
+			name:    "instances",
+			slots:   []Slot{{Uid: 1, Id: itemSword, Num: 1}, {Uid: 2, Id: itemSword, Num: 1}},
+			consume: []Stack{{Id: itemSword, Num: 1}},
+			want:    []Slot{{Uid: 1, Id: itemSword, Num: 1}},
+			changes: []Change{{Id: itemSword, Delta: -1, Num: 1, Uids: []uint64{2}}},
+		},
+		{
+			name:    "several items",
+			slots:   []Slot{{Id: itemPotion, Num: 10}, {Uid: 1, Id: itemSword, Num: 1}},
+			consume: []Stack{{Id: itemPotion, Num: 3}, {Id: itemSword, Num: 1}},
+			want:    []Slot{{Id: itemPotion, Num: 7}},
+			changes: []Change{{Id: itemSword, Delta: -1, Num: 0, Uids: []uint64{1}}, {Id: itemPotion, Delta: -3, Num: 7}},
+		},
+		{
+			name:    "not enough of an item",
+			slots:   []Slot{{Id: itemPotion, Num: 10}, {Uid: 1, Id: itemSword, Num: 1}},
+			consume: []Stack{{Id: itemPotion, Num: 3}, {Id: itemSword, Num: 2}},
+			err:     ErrNotEnough,
+		},
+		{
+			name:    "not enough in total",
+			slots:   []Slot{{Id: itemPotion, Num: 10}},
+			consume: []Stack{{Id: itemPotion, Num: 6}, {Id: itemPotion, Num: 6}},
+			err:     ErrNotEnough,
+		},
+		{
+			name:    "invalid number",
+			slots:   []Slot{{Id: itemPotion, Num: 10}},
+			consume: []Stack{{Id: itemPotion, Num: -1}},
+			err:     ErrInvalidNum,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &container{capacity: 3, slots: tc.slots}
+			before := append([]Slot(nil), tc.slots...)
+			changes, err := c.consume(tc.consume)
+			checkResult(t, c, before, changes, err, tc.want, tc.changes, tc.err)
+		})
+	}
+}
+
+func TestExchange(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		capacity int
+		slots    []Slot
+		remove   []uint64
+		put      []Slot
+		want     []Slot
+		changes  []Change
+		err      error
+	}{
+		{
+			name:     "swap",
+			capacity: 2,
+			slots:    []Slot{{Uid: 1, Id: itemSword, Num: 1}, {Id: itemPotion, Num: 10}},
+			remove:   []uint64{1},
+			put:      []Slot{{Uid: 2, Id: itemSword, Num: 1}},
+			want:     []Slot{{Id: itemPotion, Num: 10}, {Uid: 2, Id: itemSword, Num: 1}},
+			changes:  []Change{{Id: itemSword, Delta: 0, Num: 1, Uids: []uint64{1, 2}}},
+		},
+		{
+			name:     "remove only",
+			capacity: 2,
+			slots:    []Slot{{Uid: 1, Id: itemSword, Num: 1}, {Uid: 2, Id: itemSword, Num: 1}},
+			remove:   []uint64{2},
+			want:     []Slot{{Uid: 1, Id: itemSword, Num: 1}},
+			changes:  []Change{{Id: itemSword, Delta: -1, Num: 1, Uids: []uint64{2}}},
+		},
+		{
+			name:     "full bag",
+			capacity: 2,
+			slots:    []Slot{{Uid: 1, Id: itemSword, Num: 1}, {Id: itemPotion, Num: 10}},
+			put:      []Slot{{Uid: 2, Id: itemSword, Num: 1}},
+			err:      ErrBagFull,
+		},
+		{
+			name:     "overflow rolls back the removal",
+			capacity: 2,
+			slots:    []Slot{{Uid: 1, Id: itemSword, Num: 1}, {Id: itemPotion, Num: 10}},
+			remove:   []uint64{1},
+			put:      []Slot{{Uid: 2, Id: itemSword, Num: 1}, {Uid: 3, Id: itemSword, Num: 1}},
+			err:      ErrBagFull,
+		},
+		{
+			name:     "missing instance",
+			capacity: 2,
+			slots:    []Slot{{Uid: 1, Id: itemSword, Num: 1}},
+			remove:   []uint64{1, 5},
+			err:      ErrNotEnough,
+		},
+		{
+			name:     "stack put",
+			capacity: 2,
+			slots:    []Slot{{Uid: 1, Id: itemSword, Num: 1}},
+			remove:   []uint64{1},
+			put:      []Slot{{Id: itemPotion, Num: 3}},
+			err:      ErrInvalidNum,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &container{capacity: tc.capacity, slots: tc.slots}
+			before := append([]Slot(nil), tc.slots...)
+			changes, err := c.exchange(tc.remove, tc.put)
+			checkResult(t, c, before, changes, err, tc.want, tc.changes, tc.err)
+		})
+	}
+}
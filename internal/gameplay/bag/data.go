@@ -1,10 +1,7 @@
 package bag
 
-import (
-	"greatestworks/internal"
-)
-
 // Data 对应DB -> mongo
 type Data struct {
-	internal.DataAsPublisher
+	Capacity int    `bson:"cap"`   // 格子数
+	Slots    []Slot `bson:"slots"` // 格子
 }
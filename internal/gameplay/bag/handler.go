@@ -1,10 +1,15 @@
 package bag
 
 import (
+	"context"
 	"errors"
+	"sync"
+
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"github.com/phuhao00/network"
-	"sync"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
 )
 
 type Handler struct {
@@ -39,19 +44,41 @@ func init() {
 }
 
 func HandlerBagRegister() {
-	handlers[0] = &Handler{
-		0,
-		AddItem,
-	}
-	handlers[1] = &Handler{
-		1,
-		DelItem,
-	}
+	handlers = append(handlers,
+		&Handler{
+			protocol.MessageId_CSDelItem,
+			DelItem,
+		},
+		&Handler{
+			protocol.MessageId_CSExpandBag,
+			ExpandBag,
+		},
+	)
 }
-func AddItem(player IPlayer, packet *network.Message) {
 
+// DelItem discards items of the bag: an amount of a stackable item, or
+// instances of a non stackable item.
+func DelItem(p IPlayer, packet *network.Message) {
+	req := &protocol.CSDelItem{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	s := p.GetBagSystem()
+	var err error
+	if len(req.Uids) > 0 {
+		_, err = s.Remove(context.Background(), ReasonDiscard, req.Uids)
+	} else {
+		_, err = s.Consume(context.Background(), ReasonDiscard, []Stack{{Id: req.ItemId, Num: req.Num}})
+	}
+	if err != nil {
+		logger.Warn("[bag] discard PlayerID:%v err:%v", s.uid, err)
+	}
 }
 
-func DelItem(player IPlayer, packet *network.Message) {
-
+// ExpandBag expands the bag.
+func ExpandBag(p IPlayer, packet *network.Message) {
+	s := p.GetBagSystem()
+	if err := s.Expand(context.Background()); err != nil {
+		logger.Warn("[bag] expand PlayerID:%v err:%v", s.uid, err)
+	}
 }
@@ -1,10 +0,0 @@
-package bag
-
-import (
-	"greatestworks/internal/gameplay/bag/item"
-)
-
-type Bag interface {
-	AddItem(item item.Item)
-	DelItem(item item.Item)
-}
@@ -1,7 +1,32 @@
 package template
 
+// Category 道具类别
+type Category int
+
+const (
+	CategoryConsumable      Category = iota + 1 // 消耗品
+	CategoryMaterial                            // 材料
+	CategoryEquip                               // 装备
+	CategoryIllustratedBook                     // 图鉴
+)
+
 // Conf  配置
 type Conf struct {
-	Id       uint32
-	Category int
+	Id         uint32   `json:"id"`
+	Category   Category `json:"category"`
+	StackLimit int64    `json:"stackLimit"` // 单格叠加上限，0或1为不可叠加
+	ConvertTo  uint32   `json:"convertTo"`  // 获得时转换成的道具，0为不转换
+}
+
+// Stackable returns whether several of the item fit in a single slot.
+func (c *Conf) Stackable() bool {
+	return c.StackLimit > 1
+}
+
+// PerSlot returns how many of the item fit in a single slot.
+func (c *Conf) PerSlot() int64 {
+	if c.StackLimit > 1 {
+		return c.StackLimit
+	}
+	return 1
 }
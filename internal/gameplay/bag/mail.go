@@ -0,0 +1,65 @@
+package bag
+
+import (
+	"context"
+
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/saga"
+	"greatestworks/internal/purchase/currency"
+)
+
+// mailAttachments gives the items attached to the mails to the bags of the
+// players, and the currencies to their wallets, and takes them from there.
+type mailAttachments struct {
+	m *Module
+}
+
+func (a mailAttachments) Grant(ctx context.Context, playerId uint64, items []mongo.MailItem, currencies []mongo.MailCurrency) error {
+	s := &saga.Saga{Name: "mail_grant"}
+	if len(items) > 0 {
+		s.Step("items", func(ctx context.Context) error {
+			return a.m.Grant(ctx, playerId, ReasonMail, mailStacks(items))
+		}, func(ctx context.Context) error {
+			return a.m.Consume(ctx, playerId, ReasonMail, mailStacks(items))
+		})
+	}
+	if len(currencies) > 0 {
+		s.Step("currencies", func(ctx context.Context) error {
+			return currency.Add(ctx, playerId, mailAmounts(currencies), ReasonMail, "")
+		}, nil)
+	}
+	return saga.Get().Run(ctx, s)
+}
+
+func (a mailAttachments) Take(ctx context.Context, playerId uint64, items []mongo.MailItem, currencies []mongo.MailCurrency) error {
+	s := &saga.Saga{Name: "mail_take"}
+	if len(currencies) > 0 {
+		s.Step("currencies", func(ctx context.Context) error {
+			return currency.Spend(ctx, playerId, mailAmounts(currencies), ReasonMail, "")
+		}, func(ctx context.Context) error {
+			return currency.Add(ctx, playerId, mailAmounts(currencies), ReasonMail, "")
+		})
+	}
+	if len(items) > 0 {
+		s.Step("items", func(ctx context.Context) error {
+			return a.m.Consume(ctx, playerId, ReasonMail, mailStacks(items))
+		}, nil)
+	}
+	return saga.Get().Run(ctx, s)
+}
+
+func mailStacks(items []mongo.MailItem) []Stack {
+	stacks := make([]Stack, 0, len(items))
+	for _, it := range items {
+		stacks = append(stacks, Stack{Id: it.ItemId, Num: int64(it.Num)})
+	}
+	return stacks
+}
+
+func mailAmounts(currencies []mongo.MailCurrency) []currency.Amount {
+	amounts := make([]currency.Amount, 0, len(currencies))
+	for _, c := range currencies {
+		amounts = append(amounts, currency.Amount{Type: currency.Type(c.Type), Num: c.Amount})
+	}
+	return amounts
+}
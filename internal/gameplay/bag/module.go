@@ -1,31 +1,156 @@
 package bag
 
 import (
+	"context"
+	"errors"
+	"sync"
+
 	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/logger"
 	"greatestworks/aop/module_router"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/communicate/email"
+	"greatestworks/internal/gameplay/bag/item/template"
 )
 
 var (
 	Mod         *Module
 	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
 )
 
+var ErrOffline = errors.New("bag: player not online on this server")
+
 func init() {
 	internal.ModuleManager.RegisterModule(module.Module_Bag.String(), GetMod())
 }
 
+// Module holds the item table, and the bags of the players online on this
+// server, so that other modules can give items to them (see Grant).
 type Module struct {
 	*internal.BaseModule
+	initFlag    bool
+	capacity    int
+	maxCapacity int
+	expandStep  int
+	expandCost  []Stack
+
+	mu     sync.Mutex
+	online map[uint64]*System // guarded by mu
 }
 
 func GetMod() *Module {
-	Mod = &Module{internal.NewBaseModule()}
-
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
 	return Mod
 }
 
+// Init sets the file of the item table, loaded with the other tables (see
+// gamedata.Registry.Load), and gives the attachments of the mails to the
+// bags and the wallets of the players.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	Items.SetFile(conf.ItemFile)
+	if conf.ItemFile == "" {
+		logger.Warn("[bag] no item table")
+	}
+	m.capacity = conf.Capacity
+	if m.capacity <= 0 {
+		m.capacity = defaultCapacity
+	}
+	m.maxCapacity = conf.MaxCapacity
+	if m.maxCapacity < m.capacity {
+		m.maxCapacity = defaultMaxCapacity
+		if m.maxCapacity < m.capacity {
+			m.maxCapacity = m.capacity
+		}
+	}
+	m.expandStep = conf.ExpandStep
+	if m.expandStep <= 0 {
+		m.expandStep = defaultExpandStep
+	}
+	m.expandCost = conf.ExpandCost
+	m.online = make(map[uint64]*System)
+	email.GetMod().SetAttachments(mailAttachments{m})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart subscribes the module to the loot of the monsters.
+func (m *Module) OnStart() {
+	if m.initFlag {
+		m.subscribe()
+	}
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		m.unsubscribe()
+	}
+}
+
+// ItemConf returns the config of an item, or nil if there's no such item.
+func (m *Module) ItemConf(id uint32) *template.Conf {
+	return Items.Get(id)
+}
+
+// Online registers the bag of a player that logged in to this server.
+func (m *Module) Online(s *System) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.online[s.uid] = s
+}
+
+// Offline unregisters the bag of a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
+}
+
+// bagOf returns the bag of a player online on this server.
+func (m *Module) bagOf(uid uint64) (*System, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.online[uid]
+	if s == nil {
+		return nil, ErrOffline
+	}
+	return s, nil
+}
+
+// Grant adds items to the bag of a player online on this server, all of them
+// or none.
+func (m *Module) Grant(ctx context.Context, uid uint64, reason string, stacks []Stack) error {
+	s, err := m.bagOf(uid)
+	if err != nil {
+		return err
+	}
+	_, err = s.Grant(ctx, reason, stacks)
+	return err
+}
+
+// Consume removes items from the bag of a player online on this server, all
+// of them or none.
+func (m *Module) Consume(ctx context.Context, uid uint64, reason string, stacks []Stack) error {
+	s, err := m.bagOf(uid)
+	if err != nil {
+		return err
+	}
+	_, err = s.Consume(ctx, reason, stacks)
+	return err
+}
+
+// Dependencies returns the modules the bag module depends on: the
+// attachments of the mails go to the bags.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Email.String()}
+}
+
 func (m *Module) RegisterHandler() {
 	module_router.RegisterModuleMessageHandler(module.Module_Bag, 0, nil)
 }
@@ -1,8 +1,16 @@
 package bag
 
 import (
+	"context"
+	"fmt"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/mongo"
 	"greatestworks/internal"
+	"greatestworks/internal/communicate/email"
 	"greatestworks/internal/note/event"
+	"greatestworks/internal/note/event/monsterevent"
 )
 
 type EventHandle func(iEvent event.IEvent)
@@ -12,12 +20,48 @@ type EventWrap struct {
 	event.IEvent
 }
 
+// OnEvent is a no-op: the changes of the bags are published on the event bus
+// (see bagevent).
 func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
-	//TODO implement me
-	panic("implement me")
 }
 
 func (m *Module) SetEventCategoryActive(eventCategory int) {
-	//TODO implement me
-	panic("implement me")
+}
+
+// subscribe subscribes the module to the events that give items to the
+// players.
+func (m *Module) subscribe() {
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e monsterevent.Killed) {
+		if len(e.Loot) > 0 {
+			m.loot(context.Background(), e)
+		}
+	})
+}
+
+// unsubscribe cancels the subscriptions of the module, once the events
+// already received are handled.
+func (m *Module) unsubscribe() {
+	eventbus.Default.UnsubscribeModule(m.GetName())
+}
+
+// loot gives the loot of a monster to the player who gets it, or mails it if
+// it doesn't fit in its bag, or the player is gone.
+func (m *Module) loot(ctx context.Context, e monsterevent.Killed) {
+	stacks := make([]Stack, 0, len(e.Loot))
+	items := make([]mongo.MailItem, 0, len(e.Loot))
+	for _, it := range e.Loot {
+		stacks = append(stacks, Stack{Id: it.Id, Num: it.Num})
+		items = append(items, mongo.MailItem{ItemId: it.Id, Num: int32(it.Num)})
+	}
+	err := m.Grant(ctx, e.PlayerId, ReasonLoot, stacks)
+	if err == nil {
+		return
+	}
+	logger.Info("[bag] loot of monster %v to PlayerID:%v mailed: %v", e.Uid, e.PlayerId, err)
+	if err := email.GetMod().Send(ctx, e.PlayerId, &mongo.MailInfo{
+		MContent: fmt.Sprintf("loot of monster %d", e.MonsterId),
+		MItems:   items,
+	}); err != nil {
+		logger.Error("[bag] mail loot of monster %v to PlayerID:%v err:%v", e.Uid, e.PlayerId, err)
+	}
 }
@@ -0,0 +1,20 @@
+package bag
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+// toProto returns the message pushing changes of a bag to the client.
+// capacity is the new capacity of the bag, or 0 if unchanged.
+func toProto(changes []Change, capacity int) *protocol.SCBagChange {
+	msg := &protocol.SCBagChange{Capacity: int32(capacity)}
+	for _, ch := range changes {
+		msg.Items = append(msg.Items, &protocol.BagItemChange{
+			Id:    ch.Id,
+			Delta: ch.Delta,
+			Num:   ch.Num,
+			Uids:  ch.Uids,
+		})
+	}
+	return msg
+}
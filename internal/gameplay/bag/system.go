@@ -1,11 +1,208 @@
 package bag
 
+import (
+	"context"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/protobuf/proto"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/internal/note/event/bagevent"
+	"greatestworks/internal/protocol"
+)
+
+// Reasons of the changes of the bags.
+const (
+	ReasonMail    = "mail"
+	ReasonExpand  = "expand"
+	ReasonDiscard = "discard"
+	ReasonLoot    = "loot"
+)
+
+// Owner is the player a bag belongs to.
+type Owner interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+}
+
+// System is the bag of a player. It's changed by the player goroutine and by
+// the modules giving items to the player (e.g., mail), so its slots are
+// guarded by mu. Every change is pushed to the client, published on the
+// event bus, and marks the bag dirty, so that it's saved by the next flush.
 type System struct {
-	Normal Bag
+	uid uint64
+	Owner
+	markDirty func()
+
+	mu  sync.Mutex
+	bag container // guarded by mu
 }
 
 func NewSystem() *System {
-	return &System{
-		Normal: nil,
+	return &System{}
+}
+
+// SetOwner sets the player the bag belongs to, and how the bag is marked
+// dirty. A bag that wasn't loaded gets the capacity of a new bag.
+func (s *System) SetOwner(owner Owner, uid uint64, markDirty func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Owner = owner
+	s.uid = uid
+	s.markDirty = markDirty
+	if s.bag.capacity == 0 {
+		s.bag.capacity = GetMod().capacity
+	}
+}
+
+// Save returns a copy of the bag, to be stored.
+func (s *System) Save() (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Data{
+		Capacity: s.bag.capacity,
+		Slots:    append([]Slot{}, s.bag.slots...),
+	}, nil
+}
+
+// Load loads a stored bag.
+func (s *System) Load(raw bson.RawValue) error {
+	var d Data
+	if err := raw.Unmarshal(&d); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bag = container{capacity: d.Capacity, slots: d.Slots}
+	return nil
+}
+
+// Count returns the amount of an item in the bag.
+func (s *System) Count(id uint32) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bag.count(id)
+}
+
+// Capacity returns the number of slots of the bag.
+func (s *System) Capacity() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bag.capacity
+}
+
+// Slots returns a copy of the slots of the bag.
+func (s *System) Slots() []Slot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Slot(nil), s.bag.slots...)
+}
+
+// Grant adds items to the bag, all of them or none (see container.grant).
+func (s *System) Grant(ctx context.Context, reason string, stacks []Stack) ([]Change, error) {
+	s.mu.Lock()
+	changes, err := s.bag.grant(stacks, GetMod().ItemConf, idgenerator.NextId)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	s.changed(reason, changes, 0)
+	return changes, nil
+}
+
+// Consume removes items from the bag, all of them or none (see
+// container.consume).
+func (s *System) Consume(ctx context.Context, reason string, stacks []Stack) ([]Change, error) {
+	s.mu.Lock()
+	changes, err := s.bag.consume(stacks)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	s.changed(reason, changes, 0)
+	return changes, nil
+}
+
+// Remove removes instances of non stackable items from the bag, all of them
+// or none.
+func (s *System) Remove(ctx context.Context, reason string, uids []uint64) ([]Change, error) {
+	s.mu.Lock()
+	changes, err := s.bag.remove(uids)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	s.changed(reason, changes, 0)
+	return changes, nil
+}
+
+// Exchange removes instances of non stackable items from the bag and puts
+// others back, keeping their ids, all at once (e.g., to swap equipment).
+func (s *System) Exchange(ctx context.Context, reason string, remove []uint64, put []Slot) ([]Change, error) {
+	s.mu.Lock()
+	changes, err := s.bag.exchange(remove, put)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	s.changed(reason, changes, 0)
+	return changes, nil
+}
+
+// Find returns the slot holding an instance of a non stackable item.
+func (s *System) Find(uid uint64) (Slot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, slot := range s.bag.slots {
+		if slot.Uid == uid {
+			return slot, true
+		}
+	}
+	return Slot{}, false
+}
+
+// Expand adds ModuleConfig.ExpandStep slots to the bag, up to
+// ModuleConfig.MaxCapacity, consuming ModuleConfig.ExpandCost.
+func (s *System) Expand(ctx context.Context) error {
+	m := GetMod()
+	s.mu.Lock()
+	if s.bag.capacity >= m.maxCapacity {
+		s.mu.Unlock()
+		return ErrMaxCapacity
+	}
+	var changes []Change
+	if len(m.expandCost) > 0 {
+		var err error
+		if changes, err = s.bag.consume(m.expandCost); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+	}
+	s.bag.capacity += m.expandStep
+	if s.bag.capacity > m.maxCapacity {
+		s.bag.capacity = m.maxCapacity
+	}
+	capacity := s.bag.capacity
+	s.mu.Unlock()
+
+	s.changed(ReasonExpand, changes, capacity)
+	eventbus.Publish(eventbus.Default, bagevent.Expanded{PlayerId: s.uid, Capacity: capacity})
+	return nil
+}
+
+// changed marks the bag dirty, and notifies the client and the event bus of
+// changes. capacity is the new capacity of the bag, or 0 if unchanged. It's
+// called without holding mu, since the subscribers of the events may change
+// the bag in turn.
+func (s *System) changed(reason string, changes []Change, capacity int) {
+	if s.markDirty != nil {
+		s.markDirty()
+	}
+	if s.Owner != nil {
+		s.SendMsg(protocol.MessageId_SCBagChange, toProto(changes, capacity))
+	}
+	if len(changes) > 0 {
+		eventbus.Publish(eventbus.Default, bagevent.Changed{PlayerId: s.uid, Reason: reason, Changes: changes})
 	}
 }
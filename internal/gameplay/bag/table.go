@@ -0,0 +1,32 @@
+package bag
+
+import (
+	"fmt"
+
+	"greatestworks/aop/gamedata"
+	"greatestworks/internal/gameplay/bag/item/template"
+)
+
+// Items is the item table, by item id (see ModuleConfig.ItemFile).
+var Items = gamedata.Register(gamedata.Default, "item", func(c *template.Conf) uint32 { return c.Id }, checkItems)
+
+// checkItems checks the conversions of the items.
+func checkItems(_ *gamedata.Snapshot, t *gamedata.Table[uint32, template.Conf]) error {
+	var err error
+	t.Range(func(id uint32, row *template.Conf) bool {
+		if row.ConvertTo == 0 {
+			return true
+		}
+		to := t.Get(row.ConvertTo)
+		if to == nil {
+			err = fmt.Errorf("item %d converts to unknown item %d", id, row.ConvertTo)
+			return false
+		}
+		if to.ConvertTo != 0 {
+			err = fmt.Errorf("item %d converts to item %d, which converts too", id, row.ConvertTo)
+			return false
+		}
+		return true
+	})
+	return err
+}
@@ -0,0 +1,57 @@
+package battle
+
+import (
+	"time"
+
+	"greatestworks/aop/mongo"
+)
+
+// ModeConf configures the battles of a mode of the matchmaking (see
+// match.ModeConf).
+type ModeConf struct {
+	Id          uint32           `json:"id"`
+	TimeLimit   time.Duration    `json:"timeLimit"`   // the team with the most HP left wins when it's up
+	WinRewards  []mongo.MailItem `json:"winRewards"`  // mailed to the winners
+	LoseRewards []mongo.MailItem `json:"loseRewards"` // mailed to the others
+	WinRating   int64            `json:"winRating"`   // MMR won by the winners
+	LoseRating  int64            `json:"loseRating"`  // MMR lost by the losers
+}
+
+// Formula holds the constants of the damage formula (see damage).
+type Formula struct {
+	DefenseConst int64 // the damage is reduced by def/(def+DefenseConst)
+	MinHitRate   int64 // lowest chance to hit, per 10000, however high the dodge
+	Variance     int64 // the damage varies randomly by up to Variance/10000
+}
+
+// Resources holds the rules of the resources consumed by the casts of the
+// skills (see skill.Resource*).
+type Resources struct {
+	MPRegen       int64 // MP regenerated per second, per 10000 of the maximum MP
+	MaxEnergy     int64 //
+	EnergyPerHit  int64 // energy gained by dealing damage
+	EnergyPerHurt int64 // energy gained by taking damage
+}
+
+// ModuleConfig is the config of the battle module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ServerId     string        // id of this server, which tells its notifications from those of the other servers
+	TickRate     int           // ticks per second of the instances
+	Modes        []ModeConf    //
+	Formula      Formula       //
+	Resources    Resources     //
+	EnterTimeout time.Duration // how long the players may take to enter an instance before they're counted out
+}
+
+const (
+	defaultTickRate      = 10
+	defaultTimeLimit     = 5 * time.Minute
+	defaultEnterTimeout  = 30 * time.Second
+	defaultDefenseConst  = 1000
+	defaultMinHitRate    = 2000
+	defaultMPRegen       = 200
+	defaultMaxEnergy     = 100
+	defaultEnergyPerHit  = 10
+	defaultEnergyPerHurt = 5
+)
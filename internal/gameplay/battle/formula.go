@@ -0,0 +1,60 @@
+package battle
+
+import (
+	"math/rand"
+
+	"greatestworks/internal/gameplay/attr"
+	"greatestworks/internal/gameplay/skill"
+)
+
+// hitRate returns the chance, per 10000, of an attacker to hit a defender:
+// certain, less the dodge of the defender over the hit of the attacker, but
+// at least f.MinHitRate.
+func (f *Formula) hitRate(atk, def attr.Attrs) int64 {
+	rate := 10000 + atk[attr.Hit] - def[attr.Dodge]
+	if rate > 10000 {
+		rate = 10000
+	}
+	if rate < f.MinHitRate {
+		rate = f.MinHitRate
+	}
+	return rate
+}
+
+// damage returns the damage dealt by a skill, at a level, of an attacker to
+// a defender, and whether it was a critical hit; 0 if it missed. The damage
+// of the skill is reduced by the defense of the defender, raised by the
+// critical damage of the attacker if critical, then varied by up to
+// f.Variance; it's at least 1. The rolls are drawn from r in a fixed order,
+// so that a battle replays the same from the same seed.
+func (f *Formula) damage(r *rand.Rand, lv *skill.Level, atk, def attr.Attrs) (int64, bool) {
+	if r.Int63n(10000) >= f.hitRate(atk, def) {
+		return 0, false
+	}
+	dmg := atk[attr.Attack]*lv.Power/10000 + lv.Damage
+	if d := def[attr.Defense]; d > 0 {
+		dmg = dmg * f.DefenseConst / (f.DefenseConst + d)
+	}
+	crit := r.Int63n(10000) < atk[attr.CritRate]
+	if crit {
+		dmg = dmg * (10000 + atk[attr.CritDamage]) / 10000
+	}
+	if f.Variance > 0 {
+		dmg = dmg * (10000 - f.Variance + r.Int63n(2*f.Variance+1)) / 10000
+	}
+	if dmg < 1 {
+		dmg = 1
+	}
+	return dmg, crit
+}
+
+// heal returns the HP healed by a skill of a caster, at a level.
+func heal(lv *skill.Level, caster attr.Attrs) int64 {
+	return caster[attr.Attack] * lv.Heal / 10000
+}
+
+// ticks returns the number of ticks, at rate ticks per second, lasting at
+// least ms milliseconds.
+func ticks(ms int64, rate int) int64 {
+	return (ms*int64(rate) + 999) / 1000
+}
@@ -0,0 +1,88 @@
+package battle
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/greatestworks-proto/player"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/gameplay/skill"
+	"greatestworks/internal/protocol"
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player Player, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerBattleRegister()
+	})
+}
+
+func HandlerBattleRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSBattleEnter, Enter},
+		&Handler{protocol.MessageId_CSBattleCast, Cast},
+		&Handler{protocol.MessageId_CSBattleLeave, Leave},
+	)
+}
+
+// Enter enters the player in the instance it was matched in.
+func Enter(p Player, packet *network.Message) {
+	req := &protocol.CSBattleEnter{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Enter(context.Background(), p, req.InstanceId)
+	if err != nil {
+		logger.Warn("[battle] enter instance %v PlayerID:%v err:%v", req.InstanceId, p.GetUId(), err)
+	}
+	p.SendMsg(protocol.MessageId_SCBattleResult, resultToProto(protocol.BattleOp_BattleOpEnter, err))
+}
+
+// Cast casts a skill of the player. Only failures are answered: the cast
+// itself, or its rejection by the instance (e.g., on cooldown), shows in the
+// next frame. Skills the player didn't learn are rejected here already.
+func Cast(p Player, packet *network.Message) {
+	req := &protocol.CSBattleCast{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := skill.ErrNotLearned
+	if p.GetSkillSystem().Level(req.Skill) > 0 {
+		err = GetMod().Cast(context.Background(), p.GetUId(), req.InstanceId, req.Skill, req.Target)
+	}
+	if err != nil {
+		logger.Warn("[battle] cast skill %v in instance %v PlayerID:%v err:%v", req.Skill, req.InstanceId, p.GetUId(), err)
+		p.SendMsg(protocol.MessageId_SCBattleResult, resultToProto(protocol.BattleOp_BattleOpCast, err))
+	}
+}
+
+// Leave takes the player out of its instance.
+func Leave(p Player, packet *network.Message) {
+	err := GetMod().Leave(context.Background(), p.GetUId())
+	if err != nil {
+		logger.Warn("[battle] leave PlayerID:%v err:%v", p.GetUId(), err)
+	}
+	p.SendMsg(protocol.MessageId_SCBattleResult, resultToProto(protocol.BattleOp_BattleOpLeave, err))
+}
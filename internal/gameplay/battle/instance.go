@@ -0,0 +1,551 @@
+package battle
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"greatestworks/internal/gameplay/attr"
+	"greatestworks/internal/gameplay/buff"
+	"greatestworks/internal/gameplay/skill"
+)
+
+// Ops of the commands of an instance.
+const (
+	opEnter = iota + 1 // a player enters, with its attributes
+	opCast             // a player casts a skill
+	opLeave            // a player leaves
+	opAdmit            // a player is admitted in a team, to replace one who left (see Module.Admit)
+)
+
+// command is a command of a player to an instance. The commands are applied
+// at the start of the next tick, in the order of the players, so that an
+// instance replays the same from the same seed and commands per tick.
+type command struct {
+	Op     int               `json:"op"`
+	Player uint64            `json:"player"`
+	Server string            `json:"server,omitempty"` // server the player is online on
+	Attrs  attr.Attrs        `json:"attrs,omitempty"`  // opEnter
+	Skills map[uint32]uint32 `json:"skills,omitempty"` // opEnter: levels of the skills learned, by skill
+	Skill  uint32            `json:"skill,omitempty"`  // opCast
+	Target uint64            `json:"target,omitempty"` // opCast
+	Team   int               `json:"team,omitempty"`   // opAdmit
+	MMR    int64             `json:"mmr,omitempty"`    // opAdmit
+}
+
+// Kinds of the events of a frame.
+const (
+	EventEnter    = iota + 1 // Source entered, with Value HP
+	EventLeave               // Source left
+	EventCast                // Source cast skill Id on Target
+	EventMiss                // skill Id of Source missed Target
+	EventDamage              // Source dealt Value damage to Target, with skill or buff Id
+	EventHeal                // Source healed Target by Value, with skill or buff Id
+	EventBuff                // Source gave buff Id to Target, which has Value stacks of it
+	EventBuffEnd             // buff Id of Target ran out, or was dispelled
+	EventDeath               // Target was killed by Source
+	EventImmune              // Target is immune to buff Id of Source
+	EventReject              // cast of skill Id by Source rejected, for reason Value (Reject*)
+	EventResource            // Source has Value of resource Id (skill.Resource*)
+)
+
+// Reasons of the rejections of the casts: the casts the clients claim are
+// checked by the instance, which rejects those that break the rules.
+const (
+	RejectNotLearned = iota + 1 // 未学习
+	RejectTarget                // 目标无效
+	RejectCooldown              // 冷却中，且没有充能
+	RejectCombo                 // 不在连招窗口内
+	RejectResource              // 资源不足
+)
+
+// rejectReasons are the labels of the reasons of the rejections, for the
+// metrics.
+var rejectReasons = [...]string{
+	RejectNotLearned: "not_learned",
+	RejectTarget:     "target",
+	RejectCooldown:   "cooldown",
+	RejectCombo:      "combo",
+	RejectResource:   "resource",
+}
+
+// Event is what happened in an instance during a tick, pushed to the
+// players in the frame of the tick.
+type Event struct {
+	Kind   int    `json:"kind"`
+	Source uint64 `json:"source,omitempty"`
+	Target uint64 `json:"target,omitempty"`
+	Id     uint32 `json:"id,omitempty"`
+	Value  int64  `json:"value,omitempty"`
+	Crit   bool   `json:"crit,omitempty"`
+}
+
+// unit is a player in an instance.
+type unit struct {
+	id      uint64
+	team    int
+	mmr     int64
+	server  string
+	entered bool
+	left    bool
+	base    attr.Attrs           // attributes the player entered with
+	attrs   attr.Attrs           // base, with the buffs
+	hp      int64                //
+	mp      int64                //
+	energy  int64                //
+	skills  map[uint32]uint32    // levels of the skills learned, by skill
+	cds     map[uint32]*cooldown // by skill
+	last    uint32               // skill cast last, for the combos
+	lastAt  int64                // tick it was cast at
+	buffs   buff.Set             // on the clock of the instance (see Instance.now)
+	kills   []uint64             // players killed
+	dealt   int64                // damage dealt
+}
+
+// cooldown is the cooldown of a skill of a unit: the charges it has left,
+// one of which comes back each cooldown until they're full.
+type cooldown struct {
+	charges int
+	next    int64 // tick the next charge comes back at, if not full
+}
+
+func (u *unit) alive() bool {
+	return u.entered && !u.left && u.hp > 0
+}
+
+// Instance is a battle instance: the players of a match fight in teams,
+// until a team is the only one left, or the time is up. An instance runs on
+// its own goroutine, on the server that reserved it, at ModuleConfig.TickRate
+// ticks per second; its randomness is seeded with its id.
+type Instance struct {
+	Id    uint64
+	Match uint64
+	Mode  uint32
+
+	conf    *ModeConf
+	labels  modeLabels
+	rate    int // ticks per second
+	res     Resources
+	rand    *rand.Rand
+	tick    int64
+	limit   int64 // tick the time is up at
+	enterBy int64 // tick the players must have entered by
+	teams   int
+	units   map[uint64]*unit
+	order   []uint64 // units, in the order they act in
+	cmds    chan command
+	pending []command
+	events  []Event // of the current tick
+	cpu     time.Duration
+}
+
+func newInstance(m *Module, id, match uint64, mode uint32, teams int) *Instance {
+	conf := m.modes[mode]
+	if conf == nil {
+		conf = &ModeConf{Id: mode}
+	}
+	limit := conf.TimeLimit
+	if limit <= 0 {
+		limit = defaultTimeLimit
+	}
+	return &Instance{
+		Id:      id,
+		Match:   match,
+		Mode:    mode,
+		conf:    conf,
+		labels:  modeLabels{Mode: strconv.FormatUint(uint64(mode), 10)},
+		rate:    m.tickRate,
+		res:     m.resources,
+		rand:    rand.New(rand.NewSource(int64(id))),
+		limit:   ticks(limit.Milliseconds(), m.tickRate),
+		enterBy: ticks(m.enterTimeout.Milliseconds(), m.tickRate),
+		teams:   teams,
+		units:   map[uint64]*unit{},
+		cmds:    make(chan command, 64),
+	}
+}
+
+// add adds a player to a team. It has to enter before it fights.
+func (in *Instance) add(id uint64, team int, mmr int64, server string) {
+	if in.units[id] != nil {
+		return
+	}
+	in.units[id] = &unit{id: id, team: team, mmr: mmr, server: server, cds: map[uint32]*cooldown{}}
+	in.order = append(in.order, id)
+	sort.Slice(in.order, func(i, j int) bool { return in.order[i] < in.order[j] })
+}
+
+// run runs the instance until it's over, then settles it, or until the
+// module is stopped.
+func (in *Instance) run(m *Module) {
+	ticker := time.NewTicker(m.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case c := <-in.cmds:
+			in.pending = append(in.pending, c)
+		case <-ticker.C:
+			start := time.Now()
+			winner, over := in.step(m)
+			d := time.Since(start)
+			in.cpu += d
+			tickDurations.Get(in.labels).Put(float64(d.Microseconds()))
+			m.flush(in)
+			if over {
+				m.settle(in, winner)
+				return
+			}
+		case <-m.stopCh:
+			// The instance is dropped unsettled: nobody wins or loses
+			// anything.
+			m.remove(in)
+			return
+		}
+	}
+}
+
+// step runs a tick: it applies the commands received since the last tick,
+// then the buffs, then regenerates the MP. It returns the winning team, -1 for none, once the
+// instance is over.
+func (in *Instance) step(m *Module) (int, bool) {
+	in.tick++
+	sort.SliceStable(in.pending, func(i, j int) bool { return in.pending[i].Player < in.pending[j].Player })
+	for _, c := range in.pending {
+		in.apply(m, c)
+	}
+	in.pending = in.pending[:0]
+	for _, id := range in.order {
+		in.tickBuffs(in.units[id])
+	}
+	in.regen()
+	return in.result()
+}
+
+// apply applies a command.
+func (in *Instance) apply(m *Module, c command) {
+	if c.Op == opAdmit {
+		if c.Team >= 0 && c.Team < in.teams {
+			in.add(c.Player, c.Team, c.MMR, c.Server)
+		}
+		return
+	}
+	u := in.units[c.Player]
+	if u == nil {
+		return
+	}
+	switch c.Op {
+	case opEnter:
+		if u.entered {
+			// Reconnected, e.g. on another server.
+			u.server = c.Server
+			return
+		}
+		u.entered = true
+		u.server = c.Server
+		u.base = c.Attrs.Clone()
+		u.skills = c.Skills
+		u.recalc()
+		u.hp = u.attrs[attr.HP]
+		u.mp = u.attrs[attr.MP]
+		in.emit(Event{Kind: EventEnter, Source: u.id, Value: u.hp})
+	case opCast:
+		if u.alive() {
+			in.cast(m, u, c.Skill, c.Target)
+		}
+	case opLeave:
+		if !u.left {
+			u.left = true
+			in.emit(Event{Kind: EventLeave, Source: u.id})
+			m.requestBackfill(in, u)
+		}
+	}
+}
+
+// cast casts a skill of a unit on a target. The cast is rejected unless the
+// unit learned the skill, and has a charge of it, the resource it costs,
+// and, for a combo, cast the skill it follows within its window.
+func (in *Instance) cast(m *Module, u *unit, skillId uint32, targetId uint64) {
+	conf := skill.GetMod().Conf(skillId)
+	var lv *skill.Level
+	if conf != nil {
+		lv = conf.Level(u.skills[skillId])
+	}
+	if lv == nil {
+		in.reject(u, skillId, RejectNotLearned)
+		return
+	}
+	target := u
+	if conf.Target != skill.TargetSelf {
+		target = in.units[targetId]
+		if target == nil || !target.alive() || (conf.Target == skill.TargetEnemy) != (target.team != u.team) {
+			in.reject(u, skillId, RejectTarget)
+			return
+		}
+	}
+	cdTicks := ticks(lv.Cooldown, in.rate)
+	cd := u.cooldown(conf, cdTicks, in.tick)
+	switch {
+	case cd.charges == 0:
+		in.reject(u, skillId, RejectCooldown)
+		return
+	case conf.Follows != 0 && (u.last != conf.Follows || in.tick > u.lastAt+ticks(conf.Window, in.rate)):
+		in.reject(u, skillId, RejectCombo)
+		return
+	case u.resource(conf.Resource) < lv.Cost:
+		in.reject(u, skillId, RejectResource)
+		return
+	}
+	if cd.charges == conf.Charges {
+		cd.next = in.tick + cdTicks
+	}
+	cd.charges--
+	u.last, u.lastAt = skillId, in.tick
+	in.gain(u, conf.Resource, -lv.Cost)
+	in.emit(Event{Kind: EventCast, Source: u.id, Target: target.id, Id: skillId})
+
+	if conf.Target == skill.TargetEnemy {
+		dmg, crit := m.formula.damage(in.rand, lv, u.attrs, target.attrs)
+		if dmg == 0 {
+			in.emit(Event{Kind: EventMiss, Source: u.id, Target: target.id, Id: skillId})
+			return
+		}
+		in.hurt(u.id, target, skillId, dmg, crit)
+	}
+	if h := heal(lv, u.attrs); h > 0 && conf.Target != skill.TargetEnemy {
+		in.heal(u.id, target, skillId, h)
+	}
+	if !target.alive() {
+		return
+	}
+	if len(lv.Dispel) > 0 {
+		in.buffEffects(target, target.buffs.Dispel(lv.Dispel...))
+	}
+	for _, id := range lv.Buffs {
+		in.addBuff(buff.GetMod().Conf(id), u.id, target)
+	}
+}
+
+// reject rejects a cast of a unit, which has no effect; the player is told
+// why in the frame.
+func (in *Instance) reject(u *unit, skillId uint32, reason int) {
+	rejects.Get(rejectLabels{Reason: rejectReasons[reason]}).Add(1)
+	in.emit(Event{Kind: EventReject, Source: u.id, Id: skillId, Value: int64(reason)})
+}
+
+// cooldown returns the cooldown of a skill of a unit, with the charges that
+// came back by tick.
+func (u *unit) cooldown(conf *skill.Conf, cdTicks, tick int64) *cooldown {
+	cd := u.cds[conf.Id]
+	if cd == nil {
+		cd = &cooldown{charges: conf.Charges}
+		u.cds[conf.Id] = cd
+	}
+	for cd.charges < conf.Charges && cd.next <= tick {
+		cd.charges++
+		if cd.charges < conf.Charges {
+			cd.next += cdTicks
+		}
+	}
+	return cd
+}
+
+// resource returns the amount of a resource of a unit.
+func (u *unit) resource(kind int) int64 {
+	switch kind {
+	case skill.ResourceMP:
+		return u.mp
+	case skill.ResourceEnergy:
+		return u.energy
+	}
+	return 0
+}
+
+// gain changes the amount of a resource of a unit by n, within its bounds.
+func (in *Instance) gain(u *unit, kind int, n int64) {
+	var v *int64
+	var limit int64
+	switch kind {
+	case skill.ResourceMP:
+		v, limit = &u.mp, u.attrs[attr.MP]
+	case skill.ResourceEnergy:
+		v, limit = &u.energy, in.res.MaxEnergy
+	default:
+		return
+	}
+	amount := *v + n
+	if amount > limit {
+		amount = limit
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	if amount == *v {
+		return
+	}
+	*v = amount
+	in.emit(Event{Kind: EventResource, Source: u.id, Id: uint32(kind), Value: amount})
+}
+
+// regen regenerates the MP of the units alive, once a second.
+func (in *Instance) regen() {
+	if in.tick%int64(in.rate) != 0 {
+		return
+	}
+	for _, id := range in.order {
+		if u := in.units[id]; u.alive() {
+			in.gain(u, skill.ResourceMP, u.attrs[attr.MP]*in.res.MPRegen/10000)
+		}
+	}
+}
+
+// hurt deals damage to a unit, from a skill or a buff.
+func (in *Instance) hurt(source uint64, target *unit, id uint32, dmg int64, crit bool) {
+	if dmg > target.hp {
+		dmg = target.hp
+	}
+	target.hp -= dmg
+	if u := in.units[source]; u != nil && u.team != target.team {
+		u.dealt += dmg
+	}
+	in.emit(Event{Kind: EventDamage, Source: source, Target: target.id, Id: id, Value: dmg, Crit: crit})
+	if u := in.units[source]; u != nil && u != target && u.alive() {
+		in.gain(u, skill.ResourceEnergy, in.res.EnergyPerHit)
+	}
+	if target.hp > 0 {
+		in.gain(target, skill.ResourceEnergy, in.res.EnergyPerHurt)
+		return
+	}
+	target.buffs.Clear()
+	if u := in.units[source]; u != nil && u.team != target.team {
+		u.kills = append(u.kills, target.id)
+	}
+	in.emit(Event{Kind: EventDeath, Source: source, Target: target.id})
+}
+
+// heal heals a unit, up to its maximum HP.
+func (in *Instance) heal(source uint64, target *unit, id uint32, h int64) {
+	if room := target.attrs[attr.HP] - target.hp; h > room {
+		h = room
+	}
+	if h <= 0 {
+		return
+	}
+	target.hp += h
+	in.emit(Event{Kind: EventHeal, Source: source, Target: target.id, Id: id, Value: h})
+}
+
+// addBuff gives a buff to a unit, following its stacking rule.
+func (in *Instance) addBuff(conf *buff.Conf, source uint64, u *unit) {
+	effects, err := u.buffs.Add(conf, source, in.now())
+	if err == buff.ErrImmune {
+		in.emit(Event{Kind: EventImmune, Source: source, Target: u.id, Id: conf.Id})
+	}
+	in.buffEffects(u, effects)
+}
+
+// tickBuffs applies the periodic effects of the buffs of a unit, and ends
+// the buffs that ran out.
+func (in *Instance) tickBuffs(u *unit) {
+	if !u.alive() || u.buffs.Len() == 0 {
+		return
+	}
+	in.buffEffects(u, u.buffs.Tick(in.now()))
+}
+
+// buffEffects applies what the buffs of a unit did, and emits it. The
+// effects left once the unit is killed (by a periodic effect) are dropped.
+func (in *Instance) buffEffects(u *unit, effects []buff.Effect) {
+	if len(effects) == 0 {
+		return
+	}
+	for _, e := range effects {
+		if !u.alive() {
+			return
+		}
+		switch e.Kind {
+		case buff.EffectAdded:
+			in.emit(Event{Kind: EventBuff, Source: e.Source, Target: u.id, Id: e.Id, Value: e.Value})
+		case buff.EffectTick:
+			switch {
+			case e.Value > 0:
+				in.hurt(e.Source, u, e.Id, e.Value, false)
+			case e.Value < 0:
+				in.heal(e.Source, u, e.Id, -e.Value)
+			}
+		case buff.EffectExpired, buff.EffectDispelled:
+			in.emit(Event{Kind: EventBuffEnd, Target: u.id, Id: e.Id})
+		}
+	}
+	u.recalc()
+}
+
+// now returns the time of the current tick, in milliseconds since the
+// instance started: the clock of the buffs of its units.
+func (in *Instance) now() int64 {
+	return in.tick * 1000 / int64(in.rate)
+}
+
+// recalc sets the attributes of a unit: its base attributes, with its buffs.
+// Its HP and MP stay within their maximum.
+func (u *unit) recalc() {
+	u.attrs = u.base.Clone()
+	u.attrs.Add(u.buffs.Attrs())
+	if u.hp > u.attrs[attr.HP] {
+		u.hp = u.attrs[attr.HP]
+	}
+	if u.mp > u.attrs[attr.MP] {
+		u.mp = u.attrs[attr.MP]
+	}
+}
+
+// result returns the winning team, -1 for none, once the instance is over:
+// when a single team, or none, has units alive, once the players entered or
+// ModuleConfig.EnterTimeout is up, or when the time is up, in which case the
+// team with the most HP left wins.
+func (in *Instance) result() (int, bool) {
+	hp := make([]int64, in.teams)
+	alive := make([]bool, in.teams)
+	waiting := false
+	for _, id := range in.order {
+		u := in.units[id]
+		if !u.entered && !u.left {
+			waiting = true
+		}
+		if u.alive() {
+			alive[u.team] = true
+			hp[u.team] += u.hp
+		}
+	}
+	if in.tick >= in.limit {
+		winner := -1
+		var best int64
+		for t, v := range hp {
+			switch {
+			case v > best:
+				winner, best = t, v
+			case v == best:
+				winner = -1
+			}
+		}
+		return winner, true
+	}
+	if waiting && in.tick < in.enterBy {
+		return -1, false
+	}
+	winner, teams := -1, 0
+	for t, ok := range alive {
+		if ok {
+			winner = t
+			teams++
+		}
+	}
+	if teams > 1 {
+		return -1, false
+	}
+	return winner, true
+}
+
+func (in *Instance) emit(e Event) {
+	in.events = append(in.events, e)
+}
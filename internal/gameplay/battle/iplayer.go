@@ -0,0 +1,16 @@
+package battle
+
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/attr"
+	"greatestworks/internal/gameplay/skill"
+)
+
+// Player is the player handling the messages of the battles.
+type Player interface {
+	GetUId() uint64
+	GetAttrs() *attr.Sheet
+	GetSkillSystem() *skill.System
+	SendMsg(ID messageId.MessageId, message proto.Message)
+}
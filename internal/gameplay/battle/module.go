@@ -0,0 +1,348 @@
+package battle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/module_router"
+	"greatestworks/aop/redis"
+	"greatestworks/internal"
+	"greatestworks/internal/gameplay/buff"
+	"greatestworks/internal/gameplay/match"
+)
+
+const (
+	ModuleName = "battle"
+)
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNoInstance  = errors.New("battle: no such instance")
+	ErrNotInBattle = errors.New("battle: not in this instance")
+	ErrBusy        = errors.New("battle: instance busy")
+)
+
+var (
+	tickDurations = metrics.NewHistogramMap[modeLabels](
+		"battle_tick_duration_us",
+		"Time taken by a tick of an instance of a mode, in microseconds",
+		metrics.NonNegativeBuckets,
+	)
+	instanceCPU = metrics.NewHistogramMap[modeLabels](
+		"battle_instance_cpu_ms",
+		"Time taken by all the ticks of an instance of a mode, in milliseconds, observed once it's over",
+		metrics.NonNegativeBuckets,
+	)
+	running = metrics.NewGauge(
+		"battle_instances",
+		"Number of instances running on this server",
+	)
+	settled = metrics.NewCounterMap[modeLabels](
+		"battle_settled",
+		"Number of instances of a mode settled",
+	)
+	rejects = metrics.NewCounterMap[rejectLabels](
+		"battle_cast_rejects",
+		"Number of casts rejected by the instances, by reason",
+	)
+)
+
+type modeLabels struct {
+	Mode string
+}
+
+type rejectLabels struct {
+	Reason string
+}
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Module runs the battle instances of the matches (see match.Reserver). An
+// instance runs on the server that reserved it; the players, wherever
+// they're online, send their commands to it, and receive its frames, through
+// the relay.
+type Module struct {
+	*internal.BaseModule
+	initFlag     bool
+	serverId     string
+	tickRate     int
+	tick         time.Duration
+	enterTimeout time.Duration
+	formula      Formula
+	resources    Resources
+	modes        map[uint32]*ModeConf
+	stopCh       chan struct{}
+
+	mu        sync.Mutex
+	online    map[uint64]Player    // guarded by mu
+	playing   map[uint64]uint64    // instance of each player online on this server; guarded by mu
+	instances map[uint64]*Instance // instances running on this server; guarded by mu
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
+	return Mod
+}
+
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.serverId = conf.ServerId
+	m.tickRate = conf.TickRate
+	if m.tickRate <= 0 {
+		m.tickRate = defaultTickRate
+	}
+	m.tick = time.Second / time.Duration(m.tickRate)
+	m.enterTimeout = conf.EnterTimeout
+	if m.enterTimeout <= 0 {
+		m.enterTimeout = defaultEnterTimeout
+	}
+	m.formula = conf.Formula
+	if m.formula.DefenseConst <= 0 {
+		m.formula.DefenseConst = defaultDefenseConst
+	}
+	if m.formula.MinHitRate <= 0 {
+		m.formula.MinHitRate = defaultMinHitRate
+	}
+	m.resources = conf.Resources
+	if m.resources.MPRegen <= 0 {
+		m.resources.MPRegen = defaultMPRegen
+	}
+	if m.resources.MaxEnergy <= 0 {
+		m.resources.MaxEnergy = defaultMaxEnergy
+	}
+	if m.resources.EnergyPerHit <= 0 {
+		m.resources.EnergyPerHit = defaultEnergyPerHit
+	}
+	if m.resources.EnergyPerHurt <= 0 {
+		m.resources.EnergyPerHurt = defaultEnergyPerHurt
+	}
+	m.modes = make(map[uint32]*ModeConf, len(conf.Modes))
+	for i := range conf.Modes {
+		m.modes[conf.Modes[i].Id] = &conf.Modes[i]
+	}
+	m.online = make(map[uint64]Player)
+	m.playing = make(map[uint64]uint64)
+	m.instances = make(map[uint64]*Instance)
+	m.stopCh = make(chan struct{})
+	match.GetMod().SetReserver(m)
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts handling the commands and the frames relayed by the other
+// servers.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	go m.runRelay()
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+	}
+}
+
+// Online registers a player that logged in to this server.
+func (m *Module) Online(p Player) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.online[p.GetUId()] = p
+}
+
+// Offline unregisters a player that logged out, and takes it out of its
+// instance.
+func (m *Module) Offline(ctx context.Context, uid uint64) {
+	m.mu.Lock()
+	delete(m.online, uid)
+	m.mu.Unlock()
+	if err := m.Leave(ctx, uid); err != nil && err != ErrNotInBattle {
+		logger.Warn("[battle] leave PlayerID:%v err:%v", uid, err)
+	}
+}
+
+// instanceKey is the key of the server running an instance.
+func instanceKey(id uint64) string {
+	return "battle:instance:" + strconv.FormatUint(id, 10)
+}
+
+// exists returns ErrNoInstance unless an instance runs, on any server.
+func exists(ctx context.Context, instanceId uint64) error {
+	n, err := redis.Get().Exists(ctx, instanceKey(instanceId)).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoInstance
+	}
+	return nil
+}
+
+// Reserve creates an instance for a match, on this server, and starts it.
+// The players of the match have until ModuleConfig.EnterTimeout to enter.
+func (m *Module) Reserve(ctx context.Context, mt *match.Match) (uint64, error) {
+	id, err := idgenerator.NextId()
+	if err != nil {
+		return 0, fmt.Errorf("instance id: %w", err)
+	}
+	in := newInstance(m, id, mt.Id, mt.Mode, len(mt.Teams))
+	for team, tickets := range mt.Teams {
+		for _, t := range tickets {
+			for _, e := range t.Players {
+				in.add(e.PlayerId, team, e.MMR, e.Server)
+			}
+		}
+	}
+	ttl := time.Duration(in.limit+in.enterBy)*m.tick + time.Minute
+	if err := redis.Get().Set(ctx, instanceKey(id), m.serverId, ttl).Err(); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	m.instances[id] = in
+	m.mu.Unlock()
+	running.Add(1)
+	go in.run(m)
+	return id, nil
+}
+
+// Admit admits players in a team of a running instance, wherever it runs,
+// to replace players who left.
+func (m *Module) Admit(ctx context.Context, instanceId uint64, team int, players []match.Entry) error {
+	if err := exists(ctx, instanceId); err != nil {
+		return err
+	}
+	for _, e := range players {
+		c := command{Op: opAdmit, Player: e.PlayerId, Server: e.Server, Team: team, MMR: e.MMR}
+		if err := m.command(ctx, instanceId, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Enter enters a player in the instance it was matched in, with its current
+// attributes and the levels of its skills.
+func (m *Module) Enter(ctx context.Context, p Player, instanceId uint64) error {
+	if err := exists(ctx, instanceId); err != nil {
+		return err
+	}
+	uid := p.GetUId()
+	c := command{
+		Op:     opEnter,
+		Player: uid,
+		Server: m.serverId,
+		Attrs:  p.GetAttrs().Total(),
+		Skills: p.GetSkillSystem().Levels(),
+	}
+	if err := m.command(ctx, instanceId, c); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.playing[uid] = instanceId
+	m.mu.Unlock()
+	return nil
+}
+
+// Cast casts a skill of a player on a target, at the next tick of its
+// instance.
+func (m *Module) Cast(ctx context.Context, uid, instanceId uint64, skill uint32, target uint64) error {
+	m.mu.Lock()
+	playing := m.playing[uid]
+	m.mu.Unlock()
+	if playing == 0 || playing != instanceId {
+		return ErrNotInBattle
+	}
+	return m.command(ctx, instanceId, command{Op: opCast, Player: uid, Skill: skill, Target: target})
+}
+
+// Leave takes a player out of its instance. Its team may be backfilled.
+func (m *Module) Leave(ctx context.Context, uid uint64) error {
+	m.mu.Lock()
+	instanceId := m.playing[uid]
+	delete(m.playing, uid)
+	m.mu.Unlock()
+	if instanceId == 0 {
+		return ErrNotInBattle
+	}
+	return m.command(ctx, instanceId, command{Op: opLeave, Player: uid})
+}
+
+// command sends a command to an instance: to its goroutine if it runs on
+// this server, else to the server running it.
+func (m *Module) command(ctx context.Context, instanceId uint64, c command) error {
+	m.mu.Lock()
+	in := m.instances[instanceId]
+	m.mu.Unlock()
+	if in == nil {
+		return m.publish(ctx, notification{Kind: notifyCommand, Instance: instanceId, Command: &c})
+	}
+	select {
+	case in.cmds <- c:
+		return nil
+	default:
+		return ErrBusy
+	}
+}
+
+// requestBackfill requests a player to replace one who left an instance, if
+// the mode is backfilled. The request is dropped by the matchmaking once the
+// instance is over.
+func (m *Module) requestBackfill(in *Instance, u *unit) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := match.GetMod().RequestBackfill(ctx, in.Mode, in.Id, u.team, 1, u.mmr)
+		if err != nil && err != match.ErrNoBackfill && err != match.ErrNoMode {
+			logger.Error("[battle] request backfill of instance %v err:%v", in.Id, err)
+		}
+	}()
+}
+
+// remove removes an instance that's over.
+func (m *Module) remove(in *Instance) {
+	m.mu.Lock()
+	delete(m.instances, in.Id)
+	m.mu.Unlock()
+	running.Sub(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := redis.Get().Del(ctx, instanceKey(in.Id)).Err(); err != nil {
+		logger.Error("[battle] remove instance %v err:%v", in.Id, err)
+	}
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
+
+// Dependencies returns the modules the battle module depends on: it
+// reserves the instances of the matches, casts the skills of the skill
+// table, gives the buffs of the buff table, and mails the rewards.
+func (m *Module) Dependencies() []string {
+	return []string{match.ModuleName, module.Module_Skill.String(), buff.ModuleName, module.Module_Email.String()}
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
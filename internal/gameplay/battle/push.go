@@ -0,0 +1,35 @@
+package battle
+
+import (
+	"greatestworks/internal/note/event/battleevent"
+	"greatestworks/internal/protocol"
+)
+
+func frameToProto(n notification) *protocol.SCBattleFrame {
+	msg := &protocol.SCBattleFrame{InstanceId: n.Instance, Tick: n.Tick}
+	for _, e := range n.Events {
+		msg.Events = append(msg.Events, &protocol.BattleEvent{
+			Kind:   int32(e.Kind),
+			Source: e.Source,
+			Target: e.Target,
+			Id:     e.Id,
+			Value:  e.Value,
+			Crit:   e.Crit,
+		})
+	}
+	return msg
+}
+
+func endToProto(n notification, r *battleevent.Result) *protocol.SCBattleEnd {
+	return &protocol.SCBattleEnd{
+		InstanceId: n.Instance,
+		Winner:     int32(n.Winner),
+		Won:        r.Won,
+		Kills:      int32(len(r.Kills)),
+		Damage:     r.Damage,
+	}
+}
+
+func resultToProto(op protocol.BattleOp, err error) *protocol.SCBattleResult {
+	return &protocol.SCBattleResult{Op: op, Ok: err == nil}
+}
@@ -0,0 +1,163 @@
+package battle
+
+import (
+	"context"
+	"encoding/json"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+	"greatestworks/internal/note/event/battleevent"
+	"greatestworks/internal/note/event/playerevent"
+	"greatestworks/internal/protocol"
+)
+
+// relayChannel is the Redis pub/sub channel on which the servers send the
+// commands of their players to the instances running on other servers, and
+// the instances send their frames to the players online on other servers.
+const relayChannel = "battle:relay"
+
+// Kinds of notifications.
+const (
+	notifyCommand = "command" // Command for Instance, handled by the server running it
+	notifyFrame   = "frame"   // Events of a tick of Instance, for the targets
+	notifyEnd     = "end"     // Instance is over, with Results, for the targets
+)
+
+type notification struct {
+	Server   string               `json:"server"`
+	Kind     string               `json:"kind"`
+	Instance uint64               `json:"instance"`
+	Mode     uint32               `json:"mode,omitempty"`
+	Targets  []uint64             `json:"targets,omitempty"`
+	Command  *command             `json:"command,omitempty"`
+	Tick     int64                `json:"tick,omitempty"`
+	Events   []Event              `json:"events,omitempty"`
+	Winner   int                  `json:"winner,omitempty"`
+	Results  []battleevent.Result `json:"results,omitempty"`
+}
+
+// notify delivers a notification on this server, and relays it to the other
+// servers unless it was delivered in full.
+func (m *Module) notify(ctx context.Context, n notification) {
+	if m.deliver(n) {
+		return
+	}
+	if err := m.publish(ctx, n); err != nil {
+		logger.Error("[battle] publish failed: %v", err)
+	}
+}
+
+func (m *Module) publish(ctx context.Context, n notification) error {
+	n.Server = m.serverId
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	return redis.Get().Publish(ctx, relayChannel, b).Err()
+}
+
+// flush sends the events of the tick of an instance to its players.
+func (m *Module) flush(in *Instance) {
+	if len(in.events) == 0 {
+		return
+	}
+	n := notification{Kind: notifyFrame, Instance: in.Id, Tick: in.tick, Events: in.events}
+	for _, id := range in.order {
+		if u := in.units[id]; u.entered && !u.left {
+			n.Targets = append(n.Targets, id)
+		}
+	}
+	in.events = nil
+	ctx, cancel := context.WithTimeout(context.Background(), m.tick)
+	defer cancel()
+	m.notify(ctx, n)
+}
+
+// deliver delivers a notification on this server, and returns whether it
+// has nothing left to deliver on the other servers.
+func (m *Module) deliver(n notification) bool {
+	if n.Kind == notifyCommand {
+		m.mu.Lock()
+		in := m.instances[n.Instance]
+		m.mu.Unlock()
+		if in == nil || n.Command == nil {
+			return false
+		}
+		select {
+		case in.cmds <- *n.Command:
+		default:
+			logger.Warn("[battle] instance %v busy, command %+v dropped", n.Instance, *n.Command)
+		}
+		return true
+	}
+
+	var targets []Player
+	m.mu.Lock()
+	for _, uid := range n.Targets {
+		if p := m.online[uid]; p != nil {
+			targets = append(targets, p)
+		}
+		if n.Kind == notifyEnd && m.playing[uid] == n.Instance {
+			delete(m.playing, uid)
+		}
+	}
+	m.mu.Unlock()
+	switch n.Kind {
+	case notifyFrame:
+		pb := frameToProto(n)
+		for _, p := range targets {
+			p.SendMsg(protocol.MessageId_SCBattleFrame, pb)
+		}
+	case notifyEnd:
+		for _, p := range targets {
+			m.finished(p, n)
+		}
+	}
+	return len(targets) == len(n.Targets)
+}
+
+// finished pushes the end of an instance to a player online on this server,
+// and publishes its result on the event bus.
+func (m *Module) finished(p Player, n notification) {
+	for _, r := range n.Results {
+		if r.PlayerId != p.GetUId() {
+			continue
+		}
+		p.SendMsg(protocol.MessageId_SCBattleEnd, endToProto(n, &r))
+		for _, target := range r.Kills {
+			eventbus.Publish(eventbus.Default, playerevent.Kill{PlayerId: r.PlayerId, TargetId: target, IsPlayer: true})
+		}
+		eventbus.Publish(eventbus.Default, battleevent.Finished{InstanceId: n.Instance, Mode: n.Mode, Result: r})
+		return
+	}
+}
+
+// runRelay handles the notifications of the other servers, until the module
+// is stopped.
+func (m *Module) runRelay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := redis.Get().Subscribe(ctx, relayChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var n notification
+			if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+				logger.Error("[battle] unmarshal %q failed: %v", msg.Payload, err)
+				continue
+			}
+			if n.Server == m.serverId {
+				continue
+			}
+			m.deliver(n)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package battle
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/mongo"
+	"greatestworks/internal/communicate/email"
+	"greatestworks/internal/gameplay/match"
+	"greatestworks/internal/note/event/battleevent"
+)
+
+// settleTimeout bounds the time taken to settle an instance.
+const settleTimeout = 30 * time.Second
+
+// mailId returns the id of the mail of the rewards of a player in an
+// instance, so that they're never mailed twice.
+func mailId(format string, a ...interface{}) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, format, a...)
+	return h.Sum64()
+}
+
+// results returns the results of the players of an instance.
+func (in *Instance) results(winner int) []battleevent.Result {
+	results := make([]battleevent.Result, 0, len(in.order))
+	for _, id := range in.order {
+		u := in.units[id]
+		results = append(results, battleevent.Result{
+			PlayerId: u.id,
+			Team:     u.team,
+			Won:      u.team == winner && !u.left,
+			Left:     u.left || !u.entered,
+			Kills:    u.kills,
+			Damage:   u.dealt,
+		})
+	}
+	return results
+}
+
+// settle settles an instance that's over: the players are rewarded by mail,
+// their MMR is adjusted, and they're told the results.
+func (m *Module) settle(in *Instance, winner int) {
+	m.remove(in)
+	instanceCPU.Get(in.labels).Put(in.cpu.Seconds() * 1000)
+	settled.Get(in.labels).Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), settleTimeout)
+	defer cancel()
+	results := in.results(winner)
+	for i := range results {
+		m.reward(ctx, in, winner, &results[i])
+	}
+	eventbus.Publish(eventbus.Default, battleevent.Settled{
+		InstanceId: in.Id,
+		MatchId:    in.Match,
+		Mode:       in.Mode,
+		Winner:     winner,
+		Results:    results,
+	})
+	n := notification{Kind: notifyEnd, Instance: in.Id, Mode: in.Mode, Winner: winner, Results: results}
+	for _, r := range results {
+		n.Targets = append(n.Targets, r.PlayerId)
+	}
+	m.notify(ctx, n)
+}
+
+// reward mails the rewards of a player, and adjusts its MMR: the winners get
+// the rewards of the winners, the others those of the losers, except the
+// players who left, who get none. A draw leaves the MMR as is.
+func (m *Module) reward(ctx context.Context, in *Instance, winner int, r *battleevent.Result) {
+	items, delta, outcome := in.conf.LoseRewards, -in.conf.LoseRating, "lost"
+	switch {
+	case r.Won:
+		items, delta, outcome = in.conf.WinRewards, in.conf.WinRating, "won"
+	case winner < 0:
+		delta, outcome = 0, "draw"
+	}
+	if len(items) > 0 && !r.Left {
+		err := email.GetMod().Send(ctx, r.PlayerId, &mongo.MailInfo{
+			MUuid:    mailId("battle:%d:%d", in.Id, r.PlayerId),
+			MContent: fmt.Sprintf("battle %d: %s", in.Id, outcome),
+			MItems:   items,
+		})
+		if err != nil {
+			logger.Error("[battle] mail rewards of instance %v to PlayerID:%v err:%v", in.Id, r.PlayerId, err)
+		}
+	}
+	if delta != 0 {
+		if _, err := match.GetMod().AddRating(ctx, r.PlayerId, in.Mode, delta); err != nil {
+			logger.Error("[battle] rate PlayerID:%v in mode %v err:%v", r.PlayerId, in.Mode, err)
+		}
+	}
+}
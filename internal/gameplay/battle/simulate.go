@@ -0,0 +1,110 @@
+package battle
+
+import (
+	"sort"
+
+	"greatestworks/internal/gameplay/attr"
+	"greatestworks/internal/gameplay/skill"
+	"greatestworks/internal/note/event/battleevent"
+)
+
+// Fighter is a player fighting in a simulated battle (see Module.Simulate):
+// the attributes and the levels of the skills it enters with.
+type Fighter struct {
+	Id     uint64
+	Attrs  attr.Attrs
+	Skills map[uint32]uint32
+}
+
+// Simulate runs a battle between teams of fighters to its end at once, on
+// the calling goroutine, with no player behind the fighters: they cast their
+// skills by themselves (see autoCasts), e.g., when a player attacks the
+// defense of another in the arena. The battle follows the rules of the
+// instances of a mode (formulas, skills, buffs, time limit), and is seeded
+// with id, so that it replays the same from the same id and fighters. It
+// returns the winning team, -1 for none, and the results of the fighters.
+// Nothing is rewarded or published.
+func (m *Module) Simulate(id uint64, mode uint32, teams [][]Fighter) (int, []battleevent.Result) {
+	in := newInstance(m, id, 0, mode, len(teams))
+	for t, fighters := range teams {
+		for _, f := range fighters {
+			in.add(f.Id, t, 0, m.serverId)
+			in.pending = append(in.pending, command{Op: opEnter, Player: f.Id, Attrs: f.Attrs, Skills: f.Skills})
+		}
+	}
+	for {
+		winner, over := in.step(m)
+		in.events = in.events[:0]
+		if over {
+			return winner, in.results(winner)
+		}
+		in.pending = append(in.pending, in.autoCasts()...)
+	}
+}
+
+// autoCasts returns the casts of the units alive at the next tick: each
+// casts the first of its skills, by id, it can cast then, on the enemy alive
+// with the least HP, or, for the skills on allies, on the ally alive with the
+// least HP. As for the players, a cast on a target killed earlier in the tick
+// is rejected.
+func (in *Instance) autoCasts() []command {
+	tick := in.tick + 1
+	var cmds []command
+	for _, id := range in.order {
+		u := in.units[id]
+		if !u.alive() {
+			continue
+		}
+		skills := make([]uint32, 0, len(u.skills))
+		for s := range u.skills {
+			skills = append(skills, s)
+		}
+		sort.Slice(skills, func(i, j int) bool { return skills[i] < skills[j] })
+		for _, s := range skills {
+			conf := skill.GetMod().Conf(s)
+			if conf == nil {
+				continue
+			}
+			lv := conf.Level(u.skills[s])
+			if lv == nil || !in.castable(u, conf, lv, tick) {
+				continue
+			}
+			if target := in.autoTarget(u, conf.Target); target != nil {
+				cmds = append(cmds, command{Op: opCast, Player: u.id, Skill: s, Target: target.id})
+				break
+			}
+		}
+	}
+	return cmds
+}
+
+// castable returns whether a unit can cast a level of a skill at a tick:
+// the checks of cast, bar the target.
+func (in *Instance) castable(u *unit, conf *skill.Conf, lv *skill.Level, tick int64) bool {
+	if u.cooldown(conf, ticks(lv.Cooldown, in.rate), tick).charges == 0 {
+		return false
+	}
+	if conf.Follows != 0 && (u.last != conf.Follows || tick > u.lastAt+ticks(conf.Window, in.rate)) {
+		return false
+	}
+	return u.resource(conf.Resource) >= lv.Cost
+}
+
+// autoTarget returns the target of a skill of a unit cast by itself, nil if
+// there's none.
+func (in *Instance) autoTarget(u *unit, target int) *unit {
+	if target == skill.TargetSelf {
+		return u
+	}
+	var best *unit
+	for _, id := range in.order {
+		v := in.units[id]
+		if !v.alive() || (target == skill.TargetEnemy) != (v.team != u.team) {
+			continue
+		}
+		if best == nil || v.hp < best.hp {
+			best = v
+		}
+	}
+	return best
+}
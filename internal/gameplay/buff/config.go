@@ -0,0 +1,68 @@
+package buff
+
+import (
+	"fmt"
+	"time"
+
+	"greatestworks/aop/loader/json"
+	"greatestworks/internal/gameplay/attr"
+)
+
+// Stacking rules: what adding a buff does to the same buff already there.
+const (
+	StackRefresh     = iota // 刷新持续时间
+	StackAdd                // 叠加层数(最多MaxStacks)，并刷新持续时间
+	StackIgnore             // 已有则不生效
+	StackIndependent        // 各自独立计时，最多MaxStacks个，满了顶掉最早的
+)
+
+// Conf 状态效果(buff)配置
+type Conf struct {
+	Id         uint32     `json:"id"`
+	Category   uint32     `json:"category"`   // 类别，用于免疫和驱散
+	Stack      int        `json:"stack"`      // 叠加规则(Stack*)
+	MaxStacks  int        `json:"maxStacks"`  // 最大层数
+	Duration   int64      `json:"duration"`   // 持续时间，毫秒
+	Interval   int64      `json:"interval"`   // 周期效果间隔，毫秒，0为无周期效果
+	Damage     int64      `json:"damage"`     // 每层每周期的伤害，负数为治疗
+	Attrs      attr.Attrs `json:"attrs"`      // 每层增减的属性
+	Immune     []uint32   `json:"immune"`     // 持续期间免疫的类别，生效时驱散已有的这些类别
+	Dispelable bool       `json:"dispelable"` // 可否被驱散
+	Persist    bool       `json:"persist"`    // 下线后是否保留(按实际时间过期)
+}
+
+// ModuleConfig is the config of the buff module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	BuffFile     string        // buff table, see Conf
+	TickInterval time.Duration // resolution of the timers of the buffs of the players
+}
+
+const (
+	defaultTickInterval = time.Second
+)
+
+// loadTable loads the buff table.
+func loadTable(file string) (map[uint32]*Conf, error) {
+	var list []*Conf
+	if !json.ParseJsonFile2Slice(file, true, &list) {
+		return nil, fmt.Errorf("buff: buff table %q not found", file)
+	}
+	confs := make(map[uint32]*Conf, len(list))
+	for _, c := range list {
+		if confs[c.Id] != nil {
+			return nil, fmt.Errorf("buff: buff table %q: repeat buff %d", file, c.Id)
+		}
+		if c.Duration <= 0 || c.Interval < 0 {
+			return nil, fmt.Errorf("buff: buff table %q: buff %d: invalid duration %d, interval %d", file, c.Id, c.Duration, c.Interval)
+		}
+		if c.Stack < StackRefresh || c.Stack > StackIndependent {
+			return nil, fmt.Errorf("buff: buff table %q: buff %d: invalid stacking rule %d", file, c.Id, c.Stack)
+		}
+		if c.MaxStacks <= 0 {
+			c.MaxStacks = 1
+		}
+		confs[c.Id] = c
+	}
+	return confs, nil
+}
@@ -0,0 +1,55 @@
+package buff
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player IPlayer, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerBuffRegister()
+	})
+}
+
+func HandlerBuffRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSBuffCancel, Cancel},
+	)
+}
+
+// Cancel removes a dispelable buff of the player, at its request.
+func Cancel(p IPlayer, packet *network.Message) {
+	req := &protocol.CSBuffCancel{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	s := p.GetBuffSystem()
+	if err := s.Cancel(req.Id); err != nil {
+		logger.Warn("[buff] cancel PlayerID:%v buff:%v err:%v", s.uid, req.Id, err)
+	}
+}
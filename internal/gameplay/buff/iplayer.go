@@ -0,0 +1,17 @@
+package buff
+
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/attr"
+)
+
+type IPlayer interface {
+	GetBuffSystem() *System
+}
+
+// Owner is the player a System belongs to.
+type Owner interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+	GetAttrs() *attr.Sheet
+}
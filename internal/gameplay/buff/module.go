@@ -0,0 +1,127 @@
+package buff
+
+import (
+	"sync"
+
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/module_router"
+	"greatestworks/aop/tick"
+	"greatestworks/aop/timewheel"
+	"greatestworks/internal"
+)
+
+const (
+	ModuleName = "buff"
+)
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var onlineBuffs = metrics.NewGauge(
+	"buff_online_players",
+	"Number of online players whose buffs are ticked",
+)
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Module holds the buff table, shared by the players and the battles, and
+// ticks the buffs of the online players when they're due, from a timer wheel
+// of ModuleConfig.TickInterval ticks advanced every frame: an online player
+// has a single timer, at the time its next buff is due, and the players
+// without buffs have none. The buffs of a player are in its System; those of
+// the units of a battle are in a Set of the battle, ticked by the battle.
+type Module struct {
+	*internal.BaseModule
+	initFlag bool
+	confs    map[uint32]*Conf
+	wheel    *timewheel.Wheel
+
+	mu     sync.Mutex
+	online map[uint64]*System // guarded by mu
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
+	return Mod
+}
+
+// Init loads the buff table.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.confs = map[uint32]*Conf{}
+	if conf.BuffFile != "" {
+		confs, err := loadTable(conf.BuffFile)
+		if err != nil {
+			return err
+		}
+		m.confs = confs
+	} else {
+		logger.Warn("[buff] no buff table")
+	}
+	tickInterval := conf.TickInterval
+	if tickInterval <= 0 {
+		tickInterval = defaultTickInterval
+	}
+	m.wheel = timewheel.New(timewheel.Options{Name: ModuleName, Tick: tickInterval})
+	m.online = make(map[uint64]*System)
+	m.initFlag = true
+	return nil
+}
+
+// Tick fires the timers of the buffs due.
+func (m *Module) Tick(f tick.Frame) {
+	if m.initFlag {
+		m.wheel.Advance(f.Time)
+	}
+}
+
+func (m *Module) TickPriority() tick.Priority {
+	return tick.Normal
+}
+
+// Conf returns the config of a buff, or nil if there's no such buff.
+func (m *Module) Conf(id uint32) *Conf {
+	return m.confs[id]
+}
+
+// Online starts ticking the buffs of a player that logged in.
+func (m *Module) Online(s *System) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.online[s.uid]; !ok {
+		onlineBuffs.Add(1)
+	}
+	m.online[s.uid] = s
+	s.setWheel(m.wheel)
+}
+
+// Offline stops ticking the buffs of a player that logged out. Its
+// persistent buffs keep running out while it's offline.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.online[uid]; ok {
+		onlineBuffs.Sub(1)
+		delete(m.online, uid)
+		s.setWheel(nil)
+	}
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
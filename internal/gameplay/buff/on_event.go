@@ -0,0 +1,13 @@
+package buff
+
+import (
+	"greatestworks/internal"
+	"greatestworks/internal/note/event"
+)
+
+// OnEvent is unused: buffs are added by the modules that cast them.
+func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
+}
+
+func (m *Module) SetEventCategoryActive(eventCategory int) {
+}
@@ -0,0 +1,28 @@
+package buff
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+// toProto returns the message pushing the buffs of a player to the client,
+// with the effects that changed them, if any.
+func toProto(buffs []Buff, effects []Effect) *protocol.SCBuffUpdate {
+	msg := &protocol.SCBuffUpdate{}
+	for _, b := range buffs {
+		msg.Buffs = append(msg.Buffs, &protocol.BuffInfo{
+			Id:     b.Id,
+			Source: b.Source,
+			Stacks: int32(b.Stacks),
+			Expire: b.Expire,
+		})
+	}
+	for _, e := range effects {
+		msg.Effects = append(msg.Effects, &protocol.BuffEffect{
+			Kind:   int32(e.Kind),
+			Id:     e.Id,
+			Source: e.Source,
+			Value:  e.Value,
+		})
+	}
+	return msg
+}
@@ -0,0 +1,254 @@
+package buff
+
+import (
+	"errors"
+
+	"greatestworks/internal/gameplay/attr"
+)
+
+var (
+	ErrNoBuff  = errors.New("buff: no such buff")
+	ErrImmune  = errors.New("buff: immune to the category of the buff")
+	ErrIgnored = errors.New("buff: buff already there")
+)
+
+// Kinds of effects.
+const (
+	EffectAdded     = iota + 1 // Id was added, or stacked, to Value stacks
+	EffectTick                 // periodic effect of Id: Value damage, healing if negative
+	EffectExpired              // Id ran out
+	EffectDispelled            // Id was dispelled
+)
+
+// Effect is what a Set did, for its owner to act on (e.g., deal the damage
+// of a periodic effect).
+type Effect struct {
+	Kind   int
+	Id     uint32
+	Source uint64
+	Value  int64
+}
+
+// Buff is a buff in a Set. Times are in milliseconds, on the clock of the
+// set: the wall clock for the players, the ticks of an instance for the
+// battles.
+type Buff struct {
+	Id     uint32 `bson:"id"`
+	Source uint64 `bson:"src"` // 施加者
+	Stacks int    `bson:"stk"` // 层数
+	Expire int64  `bson:"exp"` // 过期时间
+	Next   int64  `bson:"nxt"` // 下次周期效果时间
+	conf   *Conf
+}
+
+// Set is the buffs of a unit (e.g., a player, or a unit of a battle). It
+// isn't safe for concurrent use.
+type Set struct {
+	buffs []*Buff
+}
+
+// Add adds a buff from a source, following its stacking rule. It fails if
+// the set is immune to the category of the buff. A buff giving immunities
+// dispels the buffs of the categories it's immune to.
+func (s *Set) Add(conf *Conf, source uint64, now int64) ([]Effect, error) {
+	if s.Immune(conf.Category) {
+		return nil, ErrImmune
+	}
+	var effects []Effect
+	var same []*Buff
+	for _, b := range s.buffs {
+		if b.Id == conf.Id {
+			same = append(same, b)
+		}
+	}
+	switch {
+	case len(same) > 0 && conf.Stack == StackIgnore:
+		return nil, ErrIgnored
+	case len(same) > 0 && conf.Stack != StackIndependent:
+		b := same[0]
+		if conf.Stack == StackAdd && b.Stacks < conf.MaxStacks {
+			b.Stacks++
+		}
+		b.Source = source
+		b.Expire = now + conf.Duration
+		effects = append(effects, Effect{Kind: EffectAdded, Id: conf.Id, Source: source, Value: int64(b.Stacks)})
+	default:
+		if len(same) >= conf.MaxStacks {
+			// The oldest goes.
+			s.remove(same[0])
+		}
+		b := &Buff{Id: conf.Id, Source: source, Stacks: 1, Expire: now + conf.Duration, conf: conf}
+		if conf.Interval > 0 {
+			b.Next = now + conf.Interval
+		}
+		s.buffs = append(s.buffs, b)
+		effects = append(effects, Effect{Kind: EffectAdded, Id: conf.Id, Source: source, Value: 1})
+	}
+	if len(conf.Immune) > 0 {
+		effects = append(effects, s.purge(conf.Immune, false)...)
+	}
+	return effects, nil
+}
+
+// Immune returns whether a buff of the set makes it immune to a category.
+func (s *Set) Immune(category uint32) bool {
+	for _, b := range s.buffs {
+		for _, c := range b.conf.Immune {
+			if c == category {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Dispel removes the dispelable buffs of some categories.
+func (s *Set) Dispel(categories ...uint32) []Effect {
+	return s.purge(categories, true)
+}
+
+// Cancel removes a buff, e.g. at the request of its owner.
+func (s *Set) Cancel(id uint32) error {
+	found := false
+	kept := s.buffs[:0]
+	for _, b := range s.buffs {
+		if b.Id == id {
+			found = true
+			continue
+		}
+		kept = append(kept, b)
+	}
+	s.buffs = kept
+	if !found {
+		return ErrNoBuff
+	}
+	return nil
+}
+
+// Clear removes all the buffs, e.g. when their owner dies.
+func (s *Set) Clear() {
+	s.buffs = nil
+}
+
+// purge removes the buffs of some categories; only the dispelable ones if
+// dispelableOnly.
+func (s *Set) purge(categories []uint32, dispelableOnly bool) []Effect {
+	var effects []Effect
+	kept := s.buffs[:0]
+	for _, b := range s.buffs {
+		if !(dispelableOnly && !b.conf.Dispelable) && contains(categories, b.conf.Category) {
+			effects = append(effects, Effect{Kind: EffectDispelled, Id: b.Id, Source: b.Source})
+			continue
+		}
+		kept = append(kept, b)
+	}
+	s.buffs = kept
+	return effects
+}
+
+// Tick applies the periodic effects due by now, and removes the buffs that
+// ran out. A periodic effect due at the time a buff runs out still applies.
+func (s *Set) Tick(now int64) []Effect {
+	var effects []Effect
+	kept := s.buffs[:0]
+	for _, b := range s.buffs {
+		if b.conf.Interval > 0 {
+			for b.Next <= now && b.Next <= b.Expire {
+				effects = append(effects, Effect{Kind: EffectTick, Id: b.Id, Source: b.Source, Value: b.conf.Damage * int64(b.Stacks)})
+				b.Next += b.conf.Interval
+			}
+		}
+		if b.Expire <= now {
+			effects = append(effects, Effect{Kind: EffectExpired, Id: b.Id, Source: b.Source})
+			continue
+		}
+		kept = append(kept, b)
+	}
+	s.buffs = kept
+	return effects
+}
+
+// NextDue returns the time the next periodic effect is due at, or the next
+// buff runs out, whichever comes first; 0 if there are no buffs.
+func (s *Set) NextDue() int64 {
+	var due int64
+	for _, b := range s.buffs {
+		next := b.Expire
+		if b.conf.Interval > 0 && b.Next < next {
+			next = b.Next
+		}
+		if due == 0 || next < due {
+			due = next
+		}
+	}
+	return due
+}
+
+// Attrs returns the attributes given by the buffs, by their stacks.
+func (s *Set) Attrs() attr.Attrs {
+	total := attr.Attrs{}
+	for _, b := range s.buffs {
+		total.AddScaled(b.conf.Attrs, int64(b.Stacks)*10000)
+	}
+	return total
+}
+
+// List returns a copy of the buffs.
+func (s *Set) List() []Buff {
+	list := make([]Buff, 0, len(s.buffs))
+	for _, b := range s.buffs {
+		list = append(list, *b)
+	}
+	return list
+}
+
+// Len returns the number of buffs.
+func (s *Set) Len() int {
+	return len(s.buffs)
+}
+
+// Persistent returns a copy of the buffs that outlast the session of their
+// owner (see Conf.Persist).
+func (s *Set) Persistent() []Buff {
+	var list []Buff
+	for _, b := range s.buffs {
+		if b.conf.Persist {
+			list = append(list, *b)
+		}
+	}
+	return list
+}
+
+// Restore puts back stored buffs, dropping those whose config is gone, or
+// that ran out by now. Their periodic effects missed meanwhile are skipped.
+func (s *Set) Restore(buffs []Buff, conf func(uint32) *Conf, now int64) {
+	for i := range buffs {
+		b := buffs[i]
+		b.conf = conf(b.Id)
+		if b.conf == nil || b.Expire <= now {
+			continue
+		}
+		if b.conf.Interval > 0 && b.Next <= now {
+			b.Next = now + b.conf.Interval
+		}
+		s.buffs = append(s.buffs, &b)
+	}
+}
+
+func (s *Set) remove(target *Buff) {
+	for i, b := range s.buffs {
+		if b == target {
+			s.buffs = append(s.buffs[:i], s.buffs[i+1:]...)
+			return
+		}
+	}
+}
+
+func contains(list []uint32, v uint32) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package buff
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"greatestworks/aop/timewheel"
+	"greatestworks/internal/protocol"
+)
+
+// attrSource is the source of the attributes given by the buffs (see
+// attr.Sheet).
+const attrSource = "buff"
+
+// Data is the stored buffs of a player: those that outlast its session (see
+// Conf.Persist), with their expiry on the wall clock, in unix milliseconds.
+type Data struct {
+	Buffs []Buff `bson:"buffs"`
+}
+
+// System is the buffs of a player, on the wall clock. It's used by the
+// handlers of the player, by the timer wheel of the module, which ticks it
+// when its next buff is due, and by the player goroutine, which saves it, so
+// it's guarded by mu.
+type System struct {
+	uid uint64
+	Owner
+	markDirty func()
+
+	mu    sync.Mutex
+	set   Set              // guarded by mu
+	wheel *timewheel.Wheel // wheel of the module while the player is online, nil otherwise; guarded by mu
+	timer *timewheel.Timer // tick of the next buff due; guarded by mu
+}
+
+func NewSystem() *System {
+	return &System{}
+}
+
+// SetOwner sets the player the buffs belong to, and how they're marked
+// dirty, applies their attributes and pushes them.
+func (s *System) SetOwner(owner Owner, uid uint64, markDirty func()) {
+	s.mu.Lock()
+	s.Owner = owner
+	s.uid = uid
+	s.markDirty = markDirty
+	s.mu.Unlock()
+	s.changed(nil)
+}
+
+// Save returns a copy of the buffs that outlast the session of the player,
+// to be stored.
+func (s *System) Save() (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := Data{Buffs: s.set.Persistent()}
+	if d.Buffs == nil {
+		d.Buffs = []Buff{}
+	}
+	return d, nil
+}
+
+// Load loads stored buffs, dropping those that expired while the player was
+// offline.
+func (s *System) Load(raw bson.RawValue) error {
+	var d Data
+	if err := raw.Unmarshal(&d); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set = Set{}
+	s.set.Restore(d.Buffs, GetMod().Conf, time.Now().UnixMilli())
+	s.reschedule()
+	return nil
+}
+
+// Add adds a buff to the player, from a source (e.g., an item, or another
+// player).
+func (s *System) Add(id uint32, source uint64) error {
+	conf := GetMod().Conf(id)
+	if conf == nil {
+		return ErrNoBuff
+	}
+	s.mu.Lock()
+	effects, err := s.set.Add(conf, source, time.Now().UnixMilli())
+	s.reschedule()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if conf.Persist {
+		s.markDirty()
+	}
+	s.changed(effects)
+	return nil
+}
+
+// Dispel removes the dispelable buffs of some categories from the player.
+func (s *System) Dispel(categories ...uint32) {
+	s.mu.Lock()
+	effects := s.set.Dispel(categories...)
+	s.reschedule()
+	s.mu.Unlock()
+	if len(effects) == 0 {
+		return
+	}
+	s.markDirty()
+	s.changed(effects)
+}
+
+// Cancel removes a dispelable buff at the request of the player.
+func (s *System) Cancel(id uint32) error {
+	conf := GetMod().Conf(id)
+	if conf == nil || !conf.Dispelable {
+		return ErrNoBuff
+	}
+	s.mu.Lock()
+	err := s.set.Cancel(id)
+	s.reschedule()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	s.markDirty()
+	s.changed(nil)
+	return nil
+}
+
+// List returns a copy of the buffs of the player.
+func (s *System) List() []Buff {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set.List()
+}
+
+// tick applies the periodic effects of the buffs due by now, and removes the
+// buffs that ran out.
+func (s *System) tick(now time.Time) {
+	s.mu.Lock()
+	effects := s.set.Tick(now.UnixMilli())
+	s.reschedule()
+	s.mu.Unlock()
+	if len(effects) == 0 {
+		return
+	}
+	for _, e := range effects {
+		if e.Kind == EffectExpired {
+			s.markDirty()
+			break
+		}
+	}
+	s.changed(effects)
+}
+
+// setWheel sets the wheel the buffs of the player are ticked by, once it
+// logged in, or nil once it logged out.
+func (s *System) setWheel(w *timewheel.Wheel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wheel = w
+	s.reschedule()
+}
+
+// reschedule schedules the tick of the buffs at the time the next one is
+// due, while the player is online. s.mu must be held.
+func (s *System) reschedule() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	due := s.set.NextDue()
+	if s.wheel == nil || due == 0 {
+		return
+	}
+	s.timer = s.wheel.At(time.UnixMilli(due), func() { s.tick(time.Now()) })
+}
+
+// changed applies the attributes of the buffs, and pushes them with the
+// effects that changed them.
+func (s *System) changed(effects []Effect) {
+	s.mu.Lock()
+	attrs := s.set.Attrs()
+	list := s.set.List()
+	owner := s.Owner
+	s.mu.Unlock()
+	if owner == nil {
+		return
+	}
+	owner.GetAttrs().Set(attrSource, attrs)
+	owner.SendMsg(protocol.MessageId_SCBuffUpdate, toProto(list, effects))
+}
@@ -0,0 +1,48 @@
+package equip
+
+import (
+	"greatestworks/internal/gameplay/attr"
+	"greatestworks/internal/gameplay/bag"
+)
+
+const defaultRefineBonus = 1000
+
+// Part is the part of the body a piece of equipment is worn on.
+type Part int
+
+const (
+	PartWeapon   Part = iota + 1 // 武器
+	PartHelmet                   // 头盔
+	PartArmor                    // 衣服
+	PartGloves                   // 手套
+	PartBoots                    // 鞋子
+	PartRing                     // 戒指
+	PartNecklace                 // 项链
+)
+
+// Conf 装备配置
+type Conf struct {
+	Id         uint32     `json:"id"`         // 道具id
+	Part       Part       `json:"part"`       // 部位
+	Level      uint32     `json:"level"`      // 穿戴等级
+	Durability int32      `json:"durability"` // 耐久上限，0为无耐久
+	Attrs      attr.Attrs `json:"attrs"`      // 基础属性
+	LevelAttrs attr.Attrs `json:"levelAttrs"` // 每强化等级增加的属性
+}
+
+// Step is an enhancement or refinement step of a piece of equipment.
+type Step struct {
+	Rate      int64       `json:"rate"`      // 成功率，万分比
+	Materials []bag.Stack `json:"materials"` // 消耗材料，成功与否都消耗
+	Downgrade bool        `json:"downgrade"` // 失败是否降级
+}
+
+// ModuleConfig is the config of the equipment module. It must be set before
+// the module is initialized (see Module.Init).
+type ModuleConfig struct {
+	EquipFile   string      // path of the equipment table, a JSON array of Conf
+	Enhance     []Step      // enhancement steps, by current enhancement level
+	Refine      []Step      // refinement steps, by current refinement level
+	RefineBonus int64       // base attributes added per refinement level, per 10000, defaults to defaultRefineBonus
+	RepairCost  []bag.Stack // items consumed by a repair; repairs are free if empty
+}
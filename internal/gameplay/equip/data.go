@@ -0,0 +1,24 @@
+package equip
+
+// Instance is the state of a piece of equipment of a player, identified by
+// the id of its instance in the bag (see bag.Slot).
+type Instance struct {
+	Uid        uint64 `bson:"uid"`
+	Id         uint32 `bson:"id"`   // 道具id
+	Part       Part   `bson:"part"` // 穿戴部位，未穿戴为0
+	Level      uint32 `bson:"lv"`   // 强化等级
+	Refine     uint32 `bson:"ref"`  // 精炼等级
+	Durability int32  `bson:"dur"`  // 耐久
+}
+
+// broken returns whether the equipment has no durability left, so that it
+// gives no attributes.
+func (inst *Instance) broken(conf *Conf) bool {
+	return conf.Durability > 0 && inst.Durability <= 0
+}
+
+// Data 对应DB -> mongo
+type Data struct {
+	Worn  []Instance `bson:"worn"`  // 穿戴中的装备
+	Items []Instance `bson:"items"` // 背包中强化过的装备
+}
@@ -0,0 +1,103 @@
+package equip
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player IPlayer, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerEquipRegister()
+	})
+}
+
+func HandlerEquipRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSEquip, Equip},
+		&Handler{protocol.MessageId_CSUnequip, Unequip},
+		&Handler{protocol.MessageId_CSEnhanceEquip, Enhance},
+		&Handler{protocol.MessageId_CSRefineEquip, Refine},
+		&Handler{protocol.MessageId_CSRepairEquip, Repair},
+	)
+}
+
+func Equip(p IPlayer, packet *network.Message) {
+	req := &protocol.CSEquip{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	s := p.GetEquipSystem()
+	if err := s.Equip(context.Background(), req.Uid, Part(req.Part)); err != nil {
+		logger.Warn("[equip] equip PlayerID:%v uid:%v err:%v", s.uid, req.Uid, err)
+	}
+}
+
+func Unequip(p IPlayer, packet *network.Message) {
+	req := &protocol.CSUnequip{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	s := p.GetEquipSystem()
+	if err := s.Unequip(context.Background(), Part(req.Part)); err != nil {
+		logger.Warn("[equip] unequip PlayerID:%v part:%v err:%v", s.uid, req.Part, err)
+	}
+}
+
+func Enhance(p IPlayer, packet *network.Message) {
+	req := &protocol.CSEnhanceEquip{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	s := p.GetEquipSystem()
+	if _, err := s.Enhance(context.Background(), req.Uid); err != nil {
+		logger.Warn("[equip] enhance PlayerID:%v uid:%v err:%v", s.uid, req.Uid, err)
+	}
+}
+
+func Refine(p IPlayer, packet *network.Message) {
+	req := &protocol.CSRefineEquip{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	s := p.GetEquipSystem()
+	if _, err := s.Refine(context.Background(), req.Uid); err != nil {
+		logger.Warn("[equip] refine PlayerID:%v uid:%v err:%v", s.uid, req.Uid, err)
+	}
+}
+
+func Repair(p IPlayer, packet *network.Message) {
+	req := &protocol.CSRepairEquip{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	s := p.GetEquipSystem()
+	if err := s.Repair(context.Background(), req.Uid); err != nil {
+		logger.Warn("[equip] repair PlayerID:%v uid:%v err:%v", s.uid, req.Uid, err)
+	}
+}
@@ -0,0 +1,20 @@
+package equip
+
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/attr"
+	"greatestworks/internal/gameplay/bag"
+)
+
+type IPlayer interface {
+	GetEquipSystem() *System
+}
+
+// Owner is the player a System belongs to.
+type Owner interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+	GetBagSystem() *bag.System
+	GetAttrs() *attr.Sheet
+	GetLevel() uint32
+}
@@ -0,0 +1,102 @@
+package equip
+
+import (
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/module_router"
+	"greatestworks/internal"
+	"greatestworks/internal/gameplay/bag"
+)
+
+const (
+	ModuleName = "equip"
+)
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var upgrades = metrics.NewCounterMap[upgradeLabels](
+	"equip_upgrades",
+	"Number of enhancement and refinement attempts",
+)
+
+type upgradeLabels struct {
+	Kind    string // "enhance" or "refine"
+	Success bool
+}
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Module holds the equipment table, and the enhancement, refinement, and
+// repair rules. The equipment of the players is in their System.
+type Module struct {
+	*internal.BaseModule
+	initFlag    bool
+	equips      map[uint32]*Conf
+	enhance     []Step
+	refine      []Step
+	refineBonus int64
+	repairCost  []bag.Stack
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
+	return Mod
+}
+
+// Init loads the equipment table, once the item table is loaded.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.equips = map[uint32]*Conf{}
+	if conf.EquipFile != "" {
+		equips, err := loadTable(conf.EquipFile)
+		if err != nil {
+			return err
+		}
+		m.equips = equips
+	} else {
+		logger.Warn("[equip] no equipment table")
+	}
+	m.enhance = conf.Enhance
+	m.refine = conf.Refine
+	m.refineBonus = conf.RefineBonus
+	if m.refineBonus <= 0 {
+		m.refineBonus = defaultRefineBonus
+	}
+	m.repairCost = conf.RepairCost
+	m.initFlag = true
+	return nil
+}
+
+// conf returns the config of a piece of equipment, or nil if there's no such
+// piece.
+func (m *Module) conf(id uint32) *Conf {
+	return m.equips[id]
+}
+
+// Dependencies returns the modules the equipment module depends on: the
+// pieces of equipment are items of the bag.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Bag.String()}
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
@@ -0,0 +1,23 @@
+package equip
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+// toProto returns the message pushing the change of a piece of equipment to
+// the client. part is the part it's worn on, or was taken off; success is
+// false if an enhancement or refinement failed.
+func toProto(part Part, inst *Instance, success bool) *protocol.SCEquipUpdate {
+	return &protocol.SCEquipUpdate{
+		Part:    int32(part),
+		Worn:    inst.Part != 0,
+		Success: success,
+		Equip: &protocol.EquipInfo{
+			Uid:        inst.Uid,
+			Id:         inst.Id,
+			Level:      inst.Level,
+			Refine:     inst.Refine,
+			Durability: inst.Durability,
+		},
+	}
+}
@@ -0,0 +1,363 @@
+package equip
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"greatestworks/internal/gameplay/attr"
+	"greatestworks/internal/gameplay/bag"
+	"greatestworks/internal/protocol"
+)
+
+// attrSource is the source of the attributes given by the equipment (see
+// attr.Sheet).
+const attrSource = "equip"
+
+// Reasons of the changes of the bags made by the equipment.
+const (
+	reasonEquip   = "equip"
+	reasonEnhance = "enhance"
+	reasonRefine  = "refine"
+	reasonRepair  = "repair"
+)
+
+var (
+	ErrNotEquipment = errors.New("equip: not a piece of equipment")
+	ErrNoItem       = errors.New("equip: no such piece of equipment")
+	ErrWrongPart    = errors.New("equip: equipment not worn on this part")
+	ErrLevel        = errors.New("equip: level too low")
+	ErrNotWorn      = errors.New("equip: nothing worn on this part")
+	ErrWorn         = errors.New("equip: equipment already worn")
+	ErrMaxLevel     = errors.New("equip: equipment at its maximum level")
+	ErrNotDamaged   = errors.New("equip: equipment not damaged")
+)
+
+// System is the equipment of a player: the pieces it wears, and the state of
+// the pieces in its bag (e.g., their enhancement level). Worn pieces are out
+// of the bag. It's used by the bag lane of the player (see player.moduleOf)
+// and by the player goroutine, which saves it, so it's guarded by mu; mu is
+// held across the changes of the bag, so that a save never sees a piece both
+// out of the bag and not worn.
+type System struct {
+	uid uint64
+	Owner
+	markDirty func()
+
+	mu    sync.Mutex
+	worn  map[Part]*Instance   // guarded by mu
+	items map[uint64]*Instance // pieces in the bag, by uid; guarded by mu
+}
+
+func NewSystem() *System {
+	return &System{worn: map[Part]*Instance{}, items: map[uint64]*Instance{}}
+}
+
+// SetOwner sets the player the equipment belongs to, and how it's marked
+// dirty, and applies the attributes of the worn pieces.
+func (s *System) SetOwner(owner Owner, uid uint64, markDirty func()) {
+	s.mu.Lock()
+	s.Owner = owner
+	s.uid = uid
+	s.markDirty = markDirty
+	s.mu.Unlock()
+	s.recalc()
+}
+
+// Save returns a copy of the equipment, to be stored. The state of the
+// pieces no longer in the bag (e.g., discarded) is dropped.
+func (s *System) Save() (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := Data{Worn: []Instance{}, Items: []Instance{}}
+	for _, inst := range s.worn {
+		d.Worn = append(d.Worn, *inst)
+	}
+	for uid, inst := range s.items {
+		if s.Owner != nil {
+			if _, ok := s.GetBagSystem().Find(uid); !ok {
+				delete(s.items, uid)
+				continue
+			}
+		}
+		d.Items = append(d.Items, *inst)
+	}
+	return d, nil
+}
+
+// Load loads stored equipment.
+func (s *System) Load(raw bson.RawValue) error {
+	var d Data
+	if err := raw.Unmarshal(&d); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.worn = make(map[Part]*Instance, len(d.Worn))
+	for i := range d.Worn {
+		s.worn[d.Worn[i].Part] = &d.Worn[i]
+	}
+	s.items = make(map[uint64]*Instance, len(d.Items))
+	for i := range d.Items {
+		s.items[d.Items[i].Uid] = &d.Items[i]
+	}
+	return nil
+}
+
+// Worn returns a copy of the piece worn on a part.
+func (s *System) Worn(part Part) (Instance, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst := s.worn[part]
+	if inst == nil {
+		return Instance{}, false
+	}
+	return *inst, true
+}
+
+// find returns a piece of equipment, worn or in the bag, and its config. A
+// piece in the bag that has no state yet gets a new one.
+//
+// REQUIRES: s.mu is held.
+func (s *System) find(uid uint64) (*Instance, *Conf, error) {
+	for _, inst := range s.worn {
+		if inst.Uid == uid {
+			return inst, GetMod().conf(inst.Id), nil
+		}
+	}
+	if inst := s.items[uid]; inst != nil {
+		return inst, GetMod().conf(inst.Id), nil
+	}
+	slot, ok := s.GetBagSystem().Find(uid)
+	if !ok {
+		return nil, nil, ErrNoItem
+	}
+	conf := GetMod().conf(slot.Id)
+	if conf == nil {
+		return nil, nil, ErrNotEquipment
+	}
+	inst := &Instance{Uid: uid, Id: slot.Id, Durability: conf.Durability}
+	s.items[uid] = inst
+	return inst, conf, nil
+}
+
+// Equip wears a piece of equipment of the bag on its part, putting the piece
+// worn there, if any, back in the bag.
+func (s *System) Equip(ctx context.Context, uid uint64, part Part) error {
+	s.mu.Lock()
+	inst, conf, err := s.find(uid)
+	if err == nil && inst.Part != 0 {
+		err = ErrWorn
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	switch {
+	case conf.Part != part:
+		s.mu.Unlock()
+		return ErrWrongPart
+	case s.GetLevel() < conf.Level:
+		s.mu.Unlock()
+		return ErrLevel
+	}
+	var put []bag.Slot
+	old := s.worn[part]
+	if old != nil {
+		put = append(put, bag.Slot{Uid: old.Uid, Id: old.Id, Num: 1})
+	}
+	if _, err := s.GetBagSystem().Exchange(ctx, reasonEquip, []uint64{uid}, put); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if old != nil {
+		old.Part = 0
+		s.items[old.Uid] = old
+	}
+	delete(s.items, uid)
+	inst.Part = part
+	s.worn[part] = inst
+	snapshot := *inst
+	s.mu.Unlock()
+
+	s.changed(part, &snapshot, true)
+	s.recalc()
+	return nil
+}
+
+// Unequip puts the piece worn on a part back in the bag.
+func (s *System) Unequip(ctx context.Context, part Part) error {
+	s.mu.Lock()
+	inst := s.worn[part]
+	if inst == nil {
+		s.mu.Unlock()
+		return ErrNotWorn
+	}
+	put := []bag.Slot{{Uid: inst.Uid, Id: inst.Id, Num: 1}}
+	if _, err := s.GetBagSystem().Exchange(ctx, reasonEquip, nil, put); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	delete(s.worn, part)
+	inst.Part = 0
+	s.items[inst.Uid] = inst
+	snapshot := *inst
+	s.mu.Unlock()
+
+	s.changed(part, &snapshot, true)
+	s.recalc()
+	return nil
+}
+
+// Enhance tries to raise the enhancement level of a piece of equipment,
+// worn or in the bag, consuming the materials of its step whether it succeeds
+// or not. It returns whether it succeeded.
+func (s *System) Enhance(ctx context.Context, uid uint64) (bool, error) {
+	return s.upgrade(ctx, uid, reasonEnhance)
+}
+
+// Refine tries to raise the refinement level of a piece of equipment, worn
+// or in the bag, consuming the materials of its step whether it succeeds or
+// not. It returns whether it succeeded.
+func (s *System) Refine(ctx context.Context, uid uint64) (bool, error) {
+	return s.upgrade(ctx, uid, reasonRefine)
+}
+
+func (s *System) upgrade(ctx context.Context, uid uint64, reason string) (bool, error) {
+	m := GetMod()
+	s.mu.Lock()
+	inst, _, err := s.find(uid)
+	if err != nil {
+		s.mu.Unlock()
+		return false, err
+	}
+	steps, level := m.enhance, &inst.Level
+	if reason == reasonRefine {
+		steps, level = m.refine, &inst.Refine
+	}
+	if int(*level) >= len(steps) {
+		s.mu.Unlock()
+		return false, ErrMaxLevel
+	}
+	step := steps[*level]
+	if len(step.Materials) > 0 {
+		if _, err := s.GetBagSystem().Consume(ctx, reason, step.Materials); err != nil {
+			s.mu.Unlock()
+			return false, err
+		}
+	}
+	success := rand.Int63n(10000) < step.Rate
+	switch {
+	case success:
+		*level++
+	case step.Downgrade && *level > 0:
+		*level--
+	}
+	snapshot := *inst
+	s.mu.Unlock()
+
+	upgrades.Get(upgradeLabels{Kind: reason, Success: success}).Add(1)
+	s.changed(snapshot.Part, &snapshot, success)
+	if snapshot.Part != 0 {
+		s.recalc()
+	}
+	return success, nil
+}
+
+// Wear wears down the worn pieces that have a durability (e.g., when the
+// player dies); the pieces with no durability left give no attributes until
+// they're repaired.
+func (s *System) Wear(amount int32) {
+	m := GetMod()
+	broke := false
+	var worn []Instance
+	s.mu.Lock()
+	for _, inst := range s.worn {
+		conf := m.conf(inst.Id)
+		if conf == nil || conf.Durability == 0 || inst.Durability <= 0 {
+			continue
+		}
+		inst.Durability -= amount
+		if inst.Durability <= 0 {
+			inst.Durability = 0
+			broke = true
+		}
+		worn = append(worn, *inst)
+	}
+	s.mu.Unlock()
+
+	for i := range worn {
+		s.changed(worn[i].Part, &worn[i], true)
+	}
+	if broke {
+		s.recalc()
+	}
+}
+
+// Repair restores the durability of a piece of equipment, worn or in the bag,
+// consuming ModuleConfig.RepairCost.
+func (s *System) Repair(ctx context.Context, uid uint64) error {
+	m := GetMod()
+	s.mu.Lock()
+	inst, conf, err := s.find(uid)
+	if err == nil && inst.Durability >= conf.Durability {
+		err = ErrNotDamaged
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if len(m.repairCost) > 0 {
+		if _, err := s.GetBagSystem().Consume(ctx, reasonRepair, m.repairCost); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+	}
+	broken := inst.broken(conf)
+	inst.Durability = conf.Durability
+	snapshot := *inst
+	s.mu.Unlock()
+
+	s.changed(snapshot.Part, &snapshot, true)
+	if broken && snapshot.Part != 0 {
+		s.recalc()
+	}
+	return nil
+}
+
+// recalc sets the attributes given by the worn pieces: their base
+// attributes, raised by their refinement level, plus the attributes of their
+// enhancement level. Broken pieces give none.
+func (s *System) recalc() {
+	m := GetMod()
+	total := attr.Attrs{}
+	s.mu.Lock()
+	owner := s.Owner
+	for _, inst := range s.worn {
+		conf := m.conf(inst.Id)
+		if conf == nil || inst.broken(conf) {
+			continue
+		}
+		total.AddScaled(conf.Attrs, 10000+int64(inst.Refine)*m.refineBonus)
+		for k, v := range conf.LevelAttrs {
+			total[k] += v * int64(inst.Level)
+		}
+	}
+	s.mu.Unlock()
+	if owner != nil {
+		owner.GetAttrs().Set(attrSource, total)
+	}
+}
+
+// changed marks the equipment dirty, and pushes the change of a piece to the
+// client.
+func (s *System) changed(part Part, inst *Instance, success bool) {
+	if s.markDirty != nil {
+		s.markDirty()
+	}
+	if s.Owner != nil {
+		s.SendMsg(protocol.MessageId_SCEquipUpdate, toProto(part, inst, success))
+	}
+}
@@ -0,0 +1,33 @@
+package equip
+
+import (
+	"fmt"
+
+	"greatestworks/aop/loader/json"
+	"greatestworks/internal/gameplay/bag"
+	"greatestworks/internal/gameplay/bag/item/template"
+)
+
+// loadTable loads the equipment table, by item id. Every piece of equipment
+// must be an equipment item of the item table.
+func loadTable(path string) (map[uint32]*Conf, error) {
+	var rows []*Conf
+	if !json.ParseJsonFile2Slice(path, true, &rows) {
+		return nil, fmt.Errorf("equip: equipment table %q not found", path)
+	}
+	table := make(map[uint32]*Conf, len(rows))
+	for _, row := range rows {
+		if _, ok := table[row.Id]; ok {
+			return nil, fmt.Errorf("equip: equipment table %q: repeat item %d", path, row.Id)
+		}
+		ic := bag.GetMod().ItemConf(row.Id)
+		if ic == nil || ic.Category != template.CategoryEquip || ic.Stackable() {
+			return nil, fmt.Errorf("equip: equipment table %q: item %d isn't a non stackable equipment item", path, row.Id)
+		}
+		if row.Part < PartWeapon || row.Part > PartNecklace {
+			return nil, fmt.Errorf("equip: equipment table %q: item %d: invalid part %d", path, row.Id, row.Part)
+		}
+		table[row.Id] = row
+	}
+	return table, nil
+}
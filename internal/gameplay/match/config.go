@@ -0,0 +1,30 @@
+package match
+
+import "time"
+
+// ModeConf configures a mode of the matchmaking, e.g. 3v3.
+type ModeConf struct {
+	Id        uint32 `json:"id"`
+	Teams     int    `json:"teams"`     // number of teams of a match
+	TeamSize  int    `json:"teamSize"`  // number of players of a team
+	Window    int64  `json:"window"`    // MMR difference accepted at first
+	Widen     int64  `json:"widen"`     // MMR difference accepted in addition per second waited
+	MaxWindow int64  `json:"maxWindow"` // most MMR difference accepted, 0 for no limit
+	Backfill  bool   `json:"backfill"`  // whether the instances of the mode may be backfilled
+}
+
+// ModuleConfig is the config of the match module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ServerId     string        // id of this server, which tells its notifications from those of the other servers
+	Modes        []ModeConf    //
+	TickInterval time.Duration // how often the queues are matched
+	DefaultMMR   int64         // MMR of the players never rated
+	TicketTTL    time.Duration // how long a ticket may wait in a queue
+}
+
+const (
+	defaultTickInterval = time.Second
+	defaultMMR          = 1000
+	defaultTicketTTL    = 10 * time.Minute
+)
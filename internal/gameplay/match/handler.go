@@ -0,0 +1,67 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player Player, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerMatchRegister()
+	})
+}
+
+func HandlerMatchRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSMatchEnqueue, Enqueue},
+		&Handler{protocol.MessageId_CSMatchCancel, Cancel},
+	)
+}
+
+// Enqueue queues the player, or its team, in a mode.
+func Enqueue(p Player, packet *network.Message) {
+	req := &protocol.CSMatchEnqueue{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	_, err := GetMod().Enqueue(context.Background(), p, req.Mode)
+	if err != nil {
+		logger.Warn("[match] enqueue in mode %v PlayerID:%v err:%v", req.Mode, p.GetUId(), err)
+	}
+	p.SendMsg(protocol.MessageId_SCMatchResult, resultToProto(protocol.MatchOp_MatchOpEnqueue, err))
+}
+
+// Cancel takes the ticket of the player out of the queue.
+func Cancel(p Player, packet *network.Message) {
+	err := GetMod().Cancel(context.Background(), p.GetUId())
+	if err != nil {
+		logger.Warn("[match] cancel PlayerID:%v err:%v", p.GetUId(), err)
+	}
+	p.SendMsg(protocol.MessageId_SCMatchResult, resultToProto(protocol.MatchOp_MatchOpCancel, err))
+}
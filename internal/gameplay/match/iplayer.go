@@ -0,0 +1,12 @@
+package match
+
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+)
+
+// Player is the player handling the messages of the matchmaking.
+type Player interface {
+	GetUId() uint64
+	SendMsg(ID messageId.MessageId, message proto.Message)
+}
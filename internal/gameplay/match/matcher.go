@@ -0,0 +1,211 @@
+package match
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+	"greatestworks/internal/note/event/matchevent"
+)
+
+// runMatcher matches the queues every ModuleConfig.TickInterval, until the
+// module is stopped.
+func (m *Module) runMatcher() {
+	ticker := time.NewTicker(m.tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, conf := range m.modes {
+				ctx, cancel := context.WithTimeout(context.Background(), m.tickInterval)
+				if err := m.matchMode(ctx, conf, now); err != nil {
+					logger.Error("[match] match mode %v failed: %v", conf.Id, err)
+				}
+				cancel()
+			}
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// matchMode matches the queue of a mode: it drops the expired tickets,
+// backfills the running instances, then forms the matches. Only one server
+// matches a mode per tick.
+func (m *Module) matchMode(ctx context.Context, conf *ModeConf, now time.Time) error {
+	locked, err := redis.Get().SetNX(ctx, lockKey(conf.Id), now.Unix(), m.tickInterval).Result()
+	if err != nil {
+		return err
+	}
+	if !locked {
+		// Another server matches the mode.
+		return nil
+	}
+	labels := modeLabels{Mode: strconv.FormatUint(uint64(conf.Id), 10)}
+	queue, err := loadQueue(ctx, conf.Id)
+	if err != nil {
+		return err
+	}
+	queue = m.expire(ctx, conf, queue, now)
+	players := 0
+	for _, t := range queue {
+		players += t.size()
+	}
+	queueLengths.Get(labels).Put(float64(players))
+
+	if conf.Backfill {
+		if queue, err = m.backfill(ctx, conf, queue, now); err != nil {
+			return err
+		}
+	}
+	matches, _ := form(conf, queue, now)
+	for _, match := range matches {
+		m.start(ctx, conf, match, now)
+	}
+	return nil
+}
+
+// expire drops the tickets that waited longer than ModuleConfig.TicketTTL,
+// and returns the tickets left.
+func (m *Module) expire(ctx context.Context, conf *ModeConf, queue []*Ticket, now time.Time) []*Ticket {
+	kept := queue[:0]
+	for _, t := range queue {
+		if now.Sub(time.Unix(t.Enqueued, 0)) < m.ticketTTL {
+			kept = append(kept, t)
+			continue
+		}
+		ok, err := take(ctx, conf.Id, []*Ticket{t})
+		if err != nil || !ok {
+			continue
+		}
+		release(ctx, t)
+		m.notify(ctx, notification{Kind: notifyExpired, Mode: conf.Id, Targets: t.ids()})
+	}
+	return kept
+}
+
+// backfill fills the backfill requests of a mode, oldest first, with the
+// tickets of its queue, and returns the tickets left.
+func (m *Module) backfill(ctx context.Context, conf *ModeConf, queue []*Ticket, now time.Time) ([]*Ticket, error) {
+	backfills, err := loadBackfills(ctx, conf.Id)
+	if err != nil {
+		return queue, err
+	}
+	sort.Slice(backfills, func(i, j int) bool { return backfills[i].Requested < backfills[j].Requested })
+	for _, b := range backfills {
+		if now.Sub(time.Unix(b.Requested, 0)) >= m.ticketTTL {
+			b.Slots = 0
+			if err := saveBackfill(ctx, b); err != nil {
+				logger.Error("[match] drop backfill %v failed: %v", b.Id, err)
+			}
+			continue
+		}
+		var picked []*Ticket
+		picked, queue = fill(conf, b, queue, now)
+		if len(picked) == 0 {
+			continue
+		}
+		ok, err := take(ctx, conf.Id, picked)
+		if err != nil || !ok {
+			continue
+		}
+		var entries []Entry
+		for _, t := range picked {
+			entries = append(entries, t.Players...)
+		}
+		if m.reserver == nil {
+			err = ErrNoReserver
+		} else {
+			err = m.reserver.Admit(ctx, b.InstanceId, b.Team, entries)
+		}
+		if err != nil {
+			logger.Error("[match] admit backfill %v in instance %v failed: %v", b.Id, b.InstanceId, err)
+			m.requeue(ctx, picked)
+			// The instance is likely over.
+			b.Slots = 0
+			if err := saveBackfill(ctx, b); err != nil {
+				logger.Error("[match] drop backfill %v failed: %v", b.Id, err)
+			}
+			continue
+		}
+		b.Slots -= len(entries)
+		if err := saveBackfill(ctx, b); err != nil {
+			logger.Error("[match] save backfill %v failed: %v", b.Id, err)
+		}
+		e := matchevent.Backfilled{Mode: conf.Id, InstanceId: b.InstanceId, Team: b.Team}
+		for _, t := range picked {
+			m.matched(ctx, t, now)
+			e.Players = append(e.Players, t.ids()...)
+			m.notify(ctx, notification{Kind: notifyFound, Mode: conf.Id, InstanceId: b.InstanceId, Team: b.Team, Targets: t.ids()})
+		}
+		eventbus.Publish(eventbus.Default, e)
+	}
+	return queue, nil
+}
+
+// start takes the tickets of a match out of the queue, and reserves its
+// battle instance. If it can't, the tickets go back to the queue.
+func (m *Module) start(ctx context.Context, conf *ModeConf, match *Match, now time.Time) {
+	var tickets []*Ticket
+	for _, team := range match.Teams {
+		tickets = append(tickets, team...)
+	}
+	ok, err := take(ctx, conf.Id, tickets)
+	if err != nil || !ok {
+		// A ticket was cancelled meanwhile.
+		return
+	}
+	labels := modeLabels{Mode: strconv.FormatUint(uint64(conf.Id), 10)}
+	if match.Id, err = idgenerator.NextId(); err == nil {
+		if m.reserver == nil {
+			err = ErrNoReserver
+		} else {
+			match.InstanceId, err = m.reserver.Reserve(ctx, match)
+		}
+	}
+	if err != nil {
+		logger.Error("[match] reserve instance of match of mode %v failed: %v", conf.Id, err)
+		reserveFailures.Get(labels).Add(1)
+		m.requeue(ctx, tickets)
+		return
+	}
+	matched.Get(labels).Add(1)
+	e := matchevent.Matched{MatchId: match.Id, Mode: conf.Id, InstanceId: match.InstanceId}
+	for i, team := range match.Teams {
+		var ids []uint64
+		for _, t := range team {
+			m.matched(ctx, t, now)
+			ids = append(ids, t.ids()...)
+			m.notify(ctx, notification{Kind: notifyFound, Mode: conf.Id, InstanceId: match.InstanceId, Team: i, Targets: t.ids()})
+		}
+		e.Teams = append(e.Teams, ids)
+	}
+	eventbus.Publish(eventbus.Default, e)
+}
+
+// matched releases the ticket of the players matched, and records how long
+// they waited.
+func (m *Module) matched(ctx context.Context, t *Ticket, now time.Time) {
+	release(ctx, t)
+	waited := now.Sub(time.Unix(t.Enqueued, 0)).Seconds()
+	labels := modeLabels{Mode: strconv.FormatUint(uint64(t.Mode), 10)}
+	for range t.Players {
+		waitTimes.Get(labels).Put(waited)
+	}
+}
+
+// requeue puts tickets taken out of the queue back, keeping their place.
+func (m *Module) requeue(ctx context.Context, tickets []*Ticket) {
+	for _, t := range tickets {
+		if err := put(ctx, t); err != nil {
+			logger.Error("[match] requeue ticket %v failed: %v", t.Id, err)
+			release(ctx, t)
+			m.notify(ctx, notification{Kind: notifyCancelled, Mode: t.Mode, Targets: t.ids()})
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/module_router"
+	"greatestworks/internal"
+	"greatestworks/internal/communicate/team"
+)
+
+const (
+	ModuleName = "match"
+)
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNoMode     = errors.New("match: no such mode")
+	ErrQueued     = errors.New("match: already in a queue")
+	ErrNotQueued  = errors.New("match: not in a queue")
+	ErrNotLeader  = errors.New("match: only the leader queues the team")
+	ErrOffline    = errors.New("match: member offline")
+	ErrTeamSize   = errors.New("match: team too large for the mode")
+	ErrNoBackfill = errors.New("match: mode not backfilled")
+	ErrNoReserver = errors.New("match: no battle instance reserver")
+)
+
+var (
+	queueLengths = metrics.NewHistogramMap[modeLabels](
+		"match_queue_length",
+		"Number of players waiting in the queue of a mode, sampled at each match",
+		metrics.NonNegativeBuckets,
+	)
+	waitTimes = metrics.NewHistogramMap[modeLabels](
+		"match_wait_seconds",
+		"Time waited in the queue of a mode by the players matched, in seconds",
+		metrics.NonNegativeBuckets,
+	)
+	matched = metrics.NewCounterMap[modeLabels](
+		"match_matches",
+		"Number of matches formed",
+	)
+	reserveFailures = metrics.NewCounterMap[modeLabels](
+		"match_reserve_failures",
+		"Number of matches whose battle instance couldn't be reserved",
+	)
+)
+
+type modeLabels struct {
+	Mode string
+}
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Reserver reserves the battle instances of the matches.
+type Reserver interface {
+	// Reserve reserves an instance for a match, and returns its id.
+	Reserve(ctx context.Context, match *Match) (uint64, error)
+	// Admit admits players in a team of a running instance, as requested
+	// by RequestBackfill.
+	Admit(ctx context.Context, instanceId uint64, team int, players []Entry) error
+}
+
+// Module is the matchmaking. The players, alone or with their team, wait in
+// the queue of a mode with their MMR; the matcher forms balanced matches,
+// accepting larger MMR differences the longer the players wait, and reserves
+// their battle instance. The queues are shared by all the servers, in Redis,
+// and matched by one server at a time.
+type Module struct {
+	*internal.BaseModule
+	initFlag     bool
+	serverId     string
+	modes        map[uint32]*ModeConf
+	tickInterval time.Duration
+	defaultMMR   int64
+	ticketTTL    time.Duration
+	reserver     Reserver
+	stopCh       chan struct{}
+
+	mu     sync.Mutex
+	online map[uint64]Player // guarded by mu
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
+	return Mod
+}
+
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.serverId = conf.ServerId
+	m.modes = make(map[uint32]*ModeConf, len(conf.Modes))
+	for i := range conf.Modes {
+		mode := &conf.Modes[i]
+		if mode.Teams < 1 || mode.TeamSize < 1 {
+			return fmt.Errorf("match: mode %d: invalid teams %dx%d", mode.Id, mode.Teams, mode.TeamSize)
+		}
+		m.modes[mode.Id] = mode
+	}
+	m.tickInterval = conf.TickInterval
+	if m.tickInterval <= 0 {
+		m.tickInterval = defaultTickInterval
+	}
+	m.defaultMMR = conf.DefaultMMR
+	if m.defaultMMR <= 0 {
+		m.defaultMMR = defaultMMR
+	}
+	m.ticketTTL = conf.TicketTTL
+	if m.ticketTTL <= 0 {
+		m.ticketTTL = defaultTicketTTL
+	}
+	m.online = make(map[uint64]Player)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts delivering the notifications of the other servers, and
+// matching the queues.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	go m.runRelay()
+	go m.runMatcher()
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+	}
+}
+
+// SetReserver sets how the battle instances of the matches are reserved. It
+// must be called before the module starts.
+func (m *Module) SetReserver(r Reserver) {
+	m.reserver = r
+}
+
+// Online registers a player that logged in to this server.
+func (m *Module) Online(p Player) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.online[p.GetUId()] = p
+}
+
+// Offline unregisters a player that logged out, and takes its ticket, and
+// that of its team, out of the queue.
+func (m *Module) Offline(ctx context.Context, uid uint64) {
+	m.mu.Lock()
+	delete(m.online, uid)
+	m.mu.Unlock()
+	if err := m.Cancel(ctx, uid); err != nil && err != ErrNotQueued {
+		logger.Warn("[match] cancel ticket of PlayerID:%v err:%v", uid, err)
+	}
+}
+
+// Enqueue puts a player in the queue of a mode, with its team if it's in
+// one: the leader queues the team, whose members must all be online.
+func (m *Module) Enqueue(ctx context.Context, p Player, mode uint32) (*Ticket, error) {
+	conf := m.modes[mode]
+	if conf == nil {
+		return nil, ErrNoMode
+	}
+	uid := p.GetUId()
+	id, err := idgenerator.NextId()
+	if err != nil {
+		return nil, fmt.Errorf("ticket id: %w", err)
+	}
+	t := &Ticket{Id: id, Mode: mode, Enqueued: time.Now().Unix()}
+	party, err := team.GetMod().Get(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if party == nil {
+		t.Players = []Entry{{PlayerId: uid, Server: m.serverId}}
+	} else {
+		if party.Leader != uid {
+			return nil, ErrNotLeader
+		}
+		t.Party = party.Id
+		for _, mb := range party.Members {
+			if mb.Server == "" {
+				return nil, ErrOffline
+			}
+			t.Players = append(t.Players, Entry{PlayerId: mb.Id, Server: mb.Server})
+		}
+	}
+	if t.size() > conf.TeamSize {
+		return nil, ErrTeamSize
+	}
+	for i := range t.Players {
+		if t.Players[i].MMR, err = m.Rating(ctx, t.Players[i].PlayerId, mode); err != nil {
+			return nil, err
+		}
+	}
+	t.average()
+	if err := m.claim(ctx, t); err != nil {
+		return nil, err
+	}
+	if err := put(ctx, t); err != nil {
+		release(ctx, t)
+		return nil, fmt.Errorf("enqueue: %w", err)
+	}
+	m.notify(ctx, notification{Kind: notifyQueued, Mode: mode, Targets: t.ids()})
+	return t, nil
+}
+
+// Cancel takes the ticket of a player, with its team if any, out of the
+// queue.
+func (m *Module) Cancel(ctx context.Context, uid uint64) error {
+	mode, id, err := ticketOf(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if id == 0 {
+		return ErrNotQueued
+	}
+	queue, err := loadQueue(ctx, mode)
+	if err != nil {
+		return err
+	}
+	for _, t := range queue {
+		if t.Id != id {
+			continue
+		}
+		ok, err := take(ctx, mode, []*Ticket{t})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// Matched meanwhile.
+			return ErrNotQueued
+		}
+		release(ctx, t)
+		m.notify(ctx, notification{Kind: notifyCancelled, Mode: mode, Targets: t.ids()})
+		return nil
+	}
+	// The ticket is gone, e.g. matched but the match is being reserved.
+	return ErrNotQueued
+}
+
+// RequestBackfill requests players for a team of a running instance of a
+// mode, to replace the players who left. The players matched are admitted
+// in the instance (see Reserver.Admit). It returns the id of the request.
+func (m *Module) RequestBackfill(ctx context.Context, mode uint32, instanceId uint64, teamIdx, slots int, mmr int64) (uint64, error) {
+	conf := m.modes[mode]
+	if conf == nil {
+		return 0, ErrNoMode
+	}
+	if !conf.Backfill {
+		return 0, ErrNoBackfill
+	}
+	id, err := idgenerator.NextId()
+	if err != nil {
+		return 0, fmt.Errorf("backfill id: %w", err)
+	}
+	b := &Backfill{Id: id, Mode: mode, InstanceId: instanceId, Team: teamIdx, Slots: slots, MMR: mmr, Requested: time.Now().Unix()}
+	if err := saveBackfill(ctx, b); err != nil {
+		return 0, fmt.Errorf("request backfill: %w", err)
+	}
+	return id, nil
+}
+
+// CancelBackfill cancels a backfill request (e.g., once the instance is
+// over).
+func (m *Module) CancelBackfill(ctx context.Context, mode uint32, id uint64) error {
+	return saveBackfill(ctx, &Backfill{Id: id, Mode: mode})
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
+
+// Dependencies returns the modules the match module depends on: the teams
+// are queued together.
+func (m *Module) Dependencies() []string {
+	return []string{team.ModuleName}
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
@@ -0,0 +1,14 @@
+package match
+
+import (
+	"greatestworks/internal"
+	"greatestworks/internal/note/event"
+)
+
+// OnEvent is unused: the matchmaking publishes its events on the event bus
+// (see matchevent), and handles none.
+func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
+}
+
+func (m *Module) SetEventCategoryActive(eventCategory int) {
+}
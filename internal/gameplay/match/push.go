@@ -0,0 +1,25 @@
+package match
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+var statuses = map[string]protocol.MatchStatus{
+	notifyQueued:    protocol.MatchStatus_MatchQueued,
+	notifyCancelled: protocol.MatchStatus_MatchCancelled,
+	notifyExpired:   protocol.MatchStatus_MatchExpired,
+	notifyFound:     protocol.MatchStatus_MatchFound,
+}
+
+func statusToProto(n notification) *protocol.SCMatchStatus {
+	return &protocol.SCMatchStatus{
+		Status:     statuses[n.Kind],
+		Mode:       n.Mode,
+		InstanceId: n.InstanceId,
+		Team:       int32(n.Team),
+	}
+}
+
+func resultToProto(op protocol.MatchOp, err error) *protocol.SCMatchResult {
+	return &protocol.SCMatchResult{Op: op, Ok: err == nil}
+}
@@ -0,0 +1,93 @@
+package match
+
+import (
+	"context"
+	"encoding/json"
+
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+	"greatestworks/internal/protocol"
+)
+
+// relayChannel is the Redis pub/sub channel on which the servers notify the
+// players online on other servers of their tickets.
+const relayChannel = "match:relay"
+
+// Kinds of notifications.
+const (
+	notifyQueued    = "queued"    // the targets were queued
+	notifyCancelled = "cancelled" // the ticket of the targets was cancelled
+	notifyExpired   = "expired"   // the ticket of the targets waited too long
+	notifyFound     = "found"     // the targets were matched
+)
+
+type notification struct {
+	Server     string   `json:"server"`
+	Kind       string   `json:"kind"`
+	Mode       uint32   `json:"mode"`
+	Targets    []uint64 `json:"targets"`
+	InstanceId uint64   `json:"instance,omitempty"` // instance the targets were matched in
+	Team       int      `json:"team,omitempty"`     // team of the targets in the instance
+}
+
+// notify notifies players of their tickets, wherever they're online.
+func (m *Module) notify(ctx context.Context, n notification) {
+	n.Server = m.serverId
+	m.deliver(n)
+	b, err := json.Marshal(n)
+	if err != nil {
+		logger.Error("[match] marshal %+v failed: %v", n, err)
+		return
+	}
+	if err := redis.Get().Publish(ctx, relayChannel, b).Err(); err != nil {
+		logger.Error("[match] publish failed: %v", err)
+	}
+}
+
+// deliver delivers a notification to the players online on this server.
+func (m *Module) deliver(n notification) {
+	var targets []Player
+	m.mu.Lock()
+	for _, uid := range n.Targets {
+		if p := m.online[uid]; p != nil {
+			targets = append(targets, p)
+		}
+	}
+	m.mu.Unlock()
+	if len(targets) == 0 {
+		return
+	}
+	pb := statusToProto(n)
+	for _, p := range targets {
+		p.SendMsg(protocol.MessageId_SCMatchStatus, pb)
+	}
+}
+
+// runRelay delivers the notifications of the other servers, until the module
+// is stopped.
+func (m *Module) runRelay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := redis.Get().Subscribe(ctx, relayChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var n notification
+			if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+				logger.Error("[match] unmarshal %q failed: %v", msg.Payload, err)
+				continue
+			}
+			if n.Server == m.serverId {
+				continue
+			}
+			m.deliver(n)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package match
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	goredis "github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/redis"
+)
+
+// takeScript deletes fields of a hash if they're all there, and returns
+// whether it did: the tickets of a match are taken out of their queue all at
+// once, or not at all (e.g., if one was cancelled).
+var takeScript = redis.NewScript("match.take", `for _, f in ipairs(ARGV) do
+	if redis.call('hexists', KEYS[1], f) == 0 then return 0 end
+end
+redis.call('hdel', KEYS[1], unpack(ARGV))
+return 1`)
+
+// releaseScript deletes the ticket of a player, if it's still that ticket.
+var releaseScript = redis.NewScript("match.release", "if redis.call('get', KEYS[1]) == ARGV[1] then return redis.call('del', KEYS[1]) else return 0 end")
+
+// queueKey returns the Redis hash of the tickets of a mode, in JSON, by id.
+func queueKey(mode uint32) string {
+	return "match:queue:" + strconv.FormatUint(uint64(mode), 10)
+}
+
+// backfillKey returns the Redis hash of the backfill requests of a mode, in
+// JSON, by id.
+func backfillKey(mode uint32) string {
+	return "match:backfill:" + strconv.FormatUint(uint64(mode), 10)
+}
+
+// lockKey returns the Redis key locking the matching of a mode, so that a
+// single server matches it at a time.
+func lockKey(mode uint32) string {
+	return "match:lock:" + strconv.FormatUint(uint64(mode), 10)
+}
+
+// playerKey returns the Redis key of the ticket of a player ("<mode>:<ticket
+// id>"). A player waits in a single queue, whatever the server it enqueued
+// from.
+func playerKey(uid uint64) string {
+	return "match:player:" + strconv.FormatUint(uid, 10)
+}
+
+func ticketRef(t *Ticket) string {
+	return fmt.Sprintf("%d:%d", t.Mode, t.Id)
+}
+
+// ticketOf returns the mode and the id of the ticket of a player, or 0.
+func ticketOf(ctx context.Context, uid uint64) (uint32, uint64, error) {
+	ref, err := redis.Get().Get(ctx, playerKey(uid)).Result()
+	if err == goredis.Nil {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("load ticket of player %v: %w", uid, err)
+	}
+	mode, id, ok := strings.Cut(ref, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid ticket of player %v: %q", uid, ref)
+	}
+	m, err := strconv.ParseUint(mode, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid ticket of player %v: %q", uid, ref)
+	}
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid ticket of player %v: %q", uid, ref)
+	}
+	return uint32(m), n, nil
+}
+
+// claim records the ticket of its players. It fails with ErrQueued, claiming
+// nothing, if any player has a ticket already.
+func (m *Module) claim(ctx context.Context, t *Ticket) error {
+	rdb := redis.Get()
+	for i, e := range t.Players {
+		ok, err := rdb.SetNX(ctx, playerKey(e.PlayerId), ticketRef(t), m.ticketTTL).Result()
+		if err == nil && !ok {
+			err = ErrQueued
+		}
+		if err != nil {
+			release(ctx, &Ticket{Id: t.Id, Mode: t.Mode, Players: t.Players[:i]})
+			return err
+		}
+	}
+	return nil
+}
+
+// release deletes the ticket of its players.
+func release(ctx context.Context, t *Ticket) {
+	ref := ticketRef(t)
+	for _, e := range t.Players {
+		err := releaseScript.Run(ctx, redis.Get(), []string{playerKey(e.PlayerId)}, ref).Err()
+		if err != nil && err != goredis.Nil {
+			logger.Error("[match] release ticket %v of PlayerID:%v err:%v", t.Id, e.PlayerId, err)
+		}
+	}
+}
+
+// put puts a ticket in the queue of its mode.
+func put(ctx context.Context, t *Ticket) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return redis.Get().HSet(ctx, queueKey(t.Mode), strconv.FormatUint(t.Id, 10), b).Err()
+}
+
+// take takes tickets out of the queue of a mode, all at once, and returns
+// whether they were all there.
+func take(ctx context.Context, mode uint32, tickets []*Ticket) (bool, error) {
+	ids := make([]interface{}, 0, len(tickets))
+	for _, t := range tickets {
+		ids = append(ids, strconv.FormatUint(t.Id, 10))
+	}
+	n, err := takeScript.Run(ctx, redis.Get(), []string{queueKey(mode)}, ids...).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// loadQueue loads the tickets of a mode.
+func loadQueue(ctx context.Context, mode uint32) ([]*Ticket, error) {
+	vals, err := redis.Get().HGetAll(ctx, queueKey(mode)).Result()
+	if err != nil {
+		return nil, err
+	}
+	queue := make([]*Ticket, 0, len(vals))
+	for id, val := range vals {
+		t := &Ticket{}
+		if err := json.Unmarshal([]byte(val), t); err != nil {
+			logger.Error("[match] unmarshal ticket %v failed: %v", id, err)
+			continue
+		}
+		queue = append(queue, t)
+	}
+	return queue, nil
+}
+
+// loadBackfills loads the backfill requests of a mode.
+func loadBackfills(ctx context.Context, mode uint32) ([]*Backfill, error) {
+	vals, err := redis.Get().HGetAll(ctx, backfillKey(mode)).Result()
+	if err != nil {
+		return nil, err
+	}
+	backfills := make([]*Backfill, 0, len(vals))
+	for id, val := range vals {
+		b := &Backfill{}
+		if err := json.Unmarshal([]byte(val), b); err != nil {
+			logger.Error("[match] unmarshal backfill %v failed: %v", id, err)
+			continue
+		}
+		backfills = append(backfills, b)
+	}
+	return backfills, nil
+}
+
+// saveBackfill saves a backfill request, or deletes it once it has no slot
+// left.
+func saveBackfill(ctx context.Context, b *Backfill) error {
+	field := strconv.FormatUint(b.Id, 10)
+	if b.Slots <= 0 {
+		return redis.Get().HDel(ctx, backfillKey(b.Mode), field).Err()
+	}
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return redis.Get().HSet(ctx, backfillKey(b.Mode), field, raw).Err()
+}
+
+func ratings() *mongodriver.Collection {
+	doc := &mongo.MatchRating{}
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+// Rating returns the MMR of a player in a mode.
+func (m *Module) Rating(ctx context.Context, uid uint64, mode uint32) (int64, error) {
+	doc := &mongo.MatchRating{}
+	err := ratings().FindOne(ctx, bson.M{"_id": uid}).Decode(doc)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return m.defaultMMR, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("load rating of player %v: %w", uid, err)
+	}
+	if mmr, ok := doc.MMR[strconv.FormatUint(uint64(mode), 10)]; ok {
+		return mmr, nil
+	}
+	return m.defaultMMR, nil
+}
+
+// AddRating adds delta to the MMR of a player in a mode (e.g., once a match
+// is over), and returns the MMR.
+func (m *Module) AddRating(ctx context.Context, uid uint64, mode uint32, delta int64) (int64, error) {
+	field := "mmr." + strconv.FormatUint(uint64(mode), 10)
+	update := bson.A{bson.M{"$set": bson.M{field: bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{"$" + field, m.defaultMMR}},
+		delta,
+	}}}}}
+	doc := &mongo.MatchRating{}
+	err := ratings().FindOneAndUpdate(ctx, bson.M{"_id": uid}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(doc)
+	if err != nil {
+		return 0, fmt.Errorf("rate player %v: %w", uid, err)
+	}
+	return doc.MMR[strconv.FormatUint(uint64(mode), 10)], nil
+}
@@ -0,0 +1,212 @@
+package match
+
+import (
+	"sort"
+	"time"
+)
+
+// Entry is a player in a queue.
+type Entry struct {
+	PlayerId uint64 `json:"id"`
+	MMR      int64  `json:"mmr"`
+	Server   string `json:"server"` // server the player is online on
+}
+
+// Ticket is a player, or a party (see team), waiting in the queue of a mode.
+// The players of a ticket are matched together, in the same team.
+type Ticket struct {
+	Id       uint64  `json:"id"`
+	Mode     uint32  `json:"mode"`
+	Party    uint64  `json:"party,omitempty"` // team of the players, 0 for a single player
+	Players  []Entry `json:"players"`
+	MMR      int64   `json:"mmr"` // average MMR of the players
+	Enqueued int64   `json:"enqueued"`
+}
+
+// Match is a match formed by the matcher: tickets of balanced MMRs, split in
+// the teams of the mode.
+type Match struct {
+	Id         uint64
+	Mode       uint32
+	InstanceId uint64      // battle instance reserved for the match
+	Teams      [][]*Ticket // tickets, by team
+}
+
+// Backfill is the request of a running instance for players, to replace the
+// players of a team who left.
+type Backfill struct {
+	Id         uint64 `json:"id"`
+	Mode       uint32 `json:"mode"`
+	InstanceId uint64 `json:"instance"`
+	Team       int    `json:"team"`
+	Slots      int    `json:"slots"` // number of players wanted
+	MMR        int64  `json:"mmr"`   // average MMR of the team
+	Requested  int64  `json:"requested"`
+}
+
+// size returns the number of players of the ticket.
+func (t *Ticket) size() int {
+	return len(t.Players)
+}
+
+// ids returns the ids of the players of the ticket.
+func (t *Ticket) ids() []uint64 {
+	ids := make([]uint64, 0, len(t.Players))
+	for _, e := range t.Players {
+		ids = append(ids, e.PlayerId)
+	}
+	return ids
+}
+
+// average sets the MMR of the ticket, the average MMR of its players.
+func (t *Ticket) average() {
+	var sum int64
+	for _, e := range t.Players {
+		sum += e.MMR
+	}
+	if n := int64(len(t.Players)); n > 0 {
+		t.MMR = sum / n
+	}
+}
+
+// window returns the MMR difference accepted by a mode after waiting since
+// a time: it widens as time goes by, up to MaxWindow.
+func (c *ModeConf) window(since int64, now time.Time) int64 {
+	waited := now.Unix() - since
+	if waited < 0 {
+		waited = 0
+	}
+	w := c.Window + c.Widen*waited
+	if c.MaxWindow > 0 && w > c.MaxWindow {
+		w = c.MaxWindow
+	}
+	return w
+}
+
+// form forms the matches of a queue, oldest tickets first: the tickets
+// closest in MMR to the oldest ticket left, within its window, make a match
+// if they split evenly in the teams of the mode. It returns the matches, and
+// the tickets left.
+func form(conf *ModeConf, queue []*Ticket, now time.Time) ([]*Match, []*Ticket) {
+	need := conf.Teams * conf.TeamSize
+	left := append([]*Ticket(nil), queue...)
+	sort.SliceStable(left, func(i, j int) bool { return left[i].Enqueued < left[j].Enqueued })
+	var matches []*Match
+	for i := 0; i < len(left); {
+		anchor := left[i]
+		w := conf.window(anchor.Enqueued, now)
+		var candidates []*Ticket
+		for _, t := range left[i:] {
+			if abs64(t.MMR-anchor.MMR) <= w && t.size() <= conf.TeamSize {
+				candidates = append(candidates, t)
+			}
+		}
+		// The anchor first, then the closest.
+		sort.SliceStable(candidates[1:], func(a, b int) bool {
+			return abs64(candidates[a+1].MMR-anchor.MMR) < abs64(candidates[b+1].MMR-anchor.MMR)
+		})
+		picked, n := []*Ticket(nil), 0
+		for _, t := range candidates {
+			if n+t.size() <= need {
+				picked = append(picked, t)
+				n += t.size()
+			}
+			if n == need {
+				break
+			}
+		}
+		var teams [][]*Ticket
+		if n == need && len(candidates) > 0 && candidates[0] == anchor {
+			teams = balance(picked, conf.Teams, conf.TeamSize)
+		}
+		if teams == nil {
+			i++
+			continue
+		}
+		matches = append(matches, &Match{Mode: conf.Id, Teams: teams})
+		left = without(left, picked)
+	}
+	return matches, left
+}
+
+// balance splits tickets in teams of a size, so that the sums of the MMRs of
+// the teams are close: the largest tickets first, the highest MMR first, each
+// to the team of the lowest sum that has room for it. It returns nil if the
+// tickets don't fit.
+func balance(tickets []*Ticket, teams, size int) [][]*Ticket {
+	sorted := append([]*Ticket(nil), tickets...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].size() != sorted[j].size() {
+			return sorted[i].size() > sorted[j].size()
+		}
+		return sorted[i].MMR > sorted[j].MMR
+	})
+	split := make([][]*Ticket, teams)
+	count := make([]int, teams)
+	sum := make([]int64, teams)
+	for _, t := range sorted {
+		best := -1
+		for k := 0; k < teams; k++ {
+			if count[k]+t.size() > size {
+				continue
+			}
+			if best < 0 || sum[k] < sum[best] {
+				best = k
+			}
+		}
+		if best < 0 {
+			return nil
+		}
+		split[best] = append(split[best], t)
+		count[best] += t.size()
+		sum[best] += t.MMR * int64(t.size())
+	}
+	return split
+}
+
+// fill picks the tickets of a queue closest in MMR to a backfill request,
+// within the window of the request, up to its slots. It returns the tickets
+// picked, and the tickets left.
+func fill(conf *ModeConf, b *Backfill, queue []*Ticket, now time.Time) ([]*Ticket, []*Ticket) {
+	w := conf.window(b.Requested, now)
+	var candidates []*Ticket
+	for _, t := range queue {
+		if abs64(t.MMR-b.MMR) <= w {
+			candidates = append(candidates, t)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return abs64(candidates[i].MMR-b.MMR) < abs64(candidates[j].MMR-b.MMR)
+	})
+	var picked []*Ticket
+	n := 0
+	for _, t := range candidates {
+		if n+t.size() <= b.Slots {
+			picked = append(picked, t)
+			n += t.size()
+		}
+	}
+	return picked, without(queue, picked)
+}
+
+// without returns the tickets of a queue but those removed.
+func without(queue, removed []*Ticket) []*Ticket {
+	drop := make(map[*Ticket]bool, len(removed))
+	for _, t := range removed {
+		drop[t] = true
+	}
+	kept := make([]*Ticket, 0, len(queue))
+	for _, t := range queue {
+		if !drop[t] {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
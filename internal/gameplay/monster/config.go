@@ -0,0 +1,134 @@
+package monster
+
+import (
+	"fmt"
+	"time"
+
+	"greatestworks/aop/loader/json"
+	"greatestworks/internal/gameplay/attr"
+)
+
+// MonsterConf 怪物配置
+type MonsterConf struct {
+	Id             uint32     `json:"id"`
+	Attrs          attr.Attrs `json:"attrs"`          // 属性
+	Speed          float64    `json:"speed"`          // 移动速度，每秒
+	AggroRange     float64    `json:"aggroRange"`     // 主动攻击范围，0为被动怪
+	LeashRange     float64    `json:"leashRange"`     // 离出生点超过该距离则脱战返回
+	AttackRange    float64    `json:"attackRange"`    // 攻击距离
+	AttackInterval int64      `json:"attackInterval"` // 攻击间隔，毫秒
+	FleeHP         int64      `json:"fleeHP"`         // 血量低于该万分比时逃跑，0为不逃跑
+	Loot           uint32     `json:"loot"`           // 掉落表id，0为无掉落
+}
+
+// SpawnConf 刷怪点配置
+type SpawnConf struct {
+	Id      uint32  `json:"id"`
+	Scene   uint32  `json:"scene"`   // 场景id
+	Monster uint32  `json:"monster"` // 怪物id
+	Count   int     `json:"count"`   // 数量
+	Pos     Vec     `json:"pos"`     // 中心点
+	Radius  float64 `json:"radius"`  // 出生范围半径
+	Patrol  float64 `json:"patrol"`  // 巡逻范围半径，0为不巡逻
+	Respawn int64   `json:"respawn"` // 死亡后重生时间，毫秒
+}
+
+// LootConf 掉落表配置。各项独立判定。
+type LootConf struct {
+	Id      uint32       `json:"id"`
+	Entries []*LootEntry `json:"entries"`
+}
+
+// LootEntry 掉落项
+type LootEntry struct {
+	ItemId uint32 `json:"itemId"`
+	Rate   int64  `json:"rate"` // 掉落概率，万分比
+	Min    int64  `json:"min"`  // 最少数量
+	Max    int64  `json:"max"`  // 最多数量
+}
+
+// ModuleConfig is the config of the monster module. It must be set before
+// the module is initialized (see Module.Init).
+type ModuleConfig struct {
+	MonsterFile  string        // monster table, see MonsterConf
+	SpawnFile    string        // spawn point table, see SpawnConf
+	LootFile     string        // loot table, see LootConf
+	TickInterval time.Duration // how often the monsters think
+	DefenseConst int64         // the damage is reduced by def/(def+DefenseConst)
+	PlayerRange  float64       // attack range of the players
+	PatrolPause  time.Duration // how long the monsters rest between patrol points
+}
+
+const (
+	defaultTickInterval = 200 * time.Millisecond
+	defaultDefenseConst = 1000
+	defaultPlayerRange  = 3
+	defaultPatrolPause  = 3 * time.Second
+)
+
+// tables holds the data tables of the monsters.
+type tables struct {
+	monsters map[uint32]*MonsterConf
+	spawns   []*SpawnConf
+	loots    map[uint32]*LootConf
+}
+
+// loadTables loads the loot, monster and spawn point tables, checking the
+// references between them.
+func loadTables(monsterFile, spawnFile, lootFile string) (*tables, error) {
+	t := &tables{monsters: map[uint32]*MonsterConf{}, loots: map[uint32]*LootConf{}}
+	if lootFile != "" {
+		var list []*LootConf
+		if !json.ParseJsonFile2Slice(lootFile, true, &list) {
+			return nil, fmt.Errorf("monster: loot table %q not found", lootFile)
+		}
+		for _, l := range list {
+			if t.loots[l.Id] != nil {
+				return nil, fmt.Errorf("monster: loot table %q: repeat loot %d", lootFile, l.Id)
+			}
+			for _, e := range l.Entries {
+				if e.Min <= 0 || e.Max < e.Min {
+					return nil, fmt.Errorf("monster: loot table %q: loot %d: invalid amount %d-%d of item %d", lootFile, l.Id, e.Min, e.Max, e.ItemId)
+				}
+			}
+			t.loots[l.Id] = l
+		}
+	}
+	if monsterFile != "" {
+		var list []*MonsterConf
+		if !json.ParseJsonFile2Slice(monsterFile, true, &list) {
+			return nil, fmt.Errorf("monster: monster table %q not found", monsterFile)
+		}
+		for _, mc := range list {
+			if t.monsters[mc.Id] != nil {
+				return nil, fmt.Errorf("monster: monster table %q: repeat monster %d", monsterFile, mc.Id)
+			}
+			if mc.Attrs[attr.HP] <= 0 {
+				return nil, fmt.Errorf("monster: monster table %q: monster %d has no HP", monsterFile, mc.Id)
+			}
+			if mc.Loot != 0 && t.loots[mc.Loot] == nil {
+				return nil, fmt.Errorf("monster: monster table %q: monster %d: unknown loot %d", monsterFile, mc.Id, mc.Loot)
+			}
+			t.monsters[mc.Id] = mc
+		}
+	}
+	if spawnFile != "" {
+		if !json.ParseJsonFile2Slice(spawnFile, true, &t.spawns) {
+			return nil, fmt.Errorf("monster: spawn table %q not found", spawnFile)
+		}
+		seen := make(map[uint32]bool, len(t.spawns))
+		for _, s := range t.spawns {
+			if seen[s.Id] {
+				return nil, fmt.Errorf("monster: spawn table %q: repeat spawn point %d", spawnFile, s.Id)
+			}
+			seen[s.Id] = true
+			if t.monsters[s.Monster] == nil {
+				return nil, fmt.Errorf("monster: spawn table %q: spawn point %d: unknown monster %d", spawnFile, s.Id, s.Monster)
+			}
+			if s.Count <= 0 {
+				return nil, fmt.Errorf("monster: spawn table %q: spawn point %d: invalid count %d", spawnFile, s.Id, s.Count)
+			}
+		}
+	}
+	return t, nil
+}
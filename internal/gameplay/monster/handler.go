@@ -0,0 +1,73 @@
+package monster
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/greatestworks-proto/player"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/gameplay/anticheat"
+	"greatestworks/internal/protocol"
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player Player, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerMonsterRegister()
+	})
+}
+
+func HandlerMonsterRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSMonsterSync, Sync},
+		&Handler{protocol.MessageId_CSMonsterAttack, Attack},
+	)
+}
+
+// Sync sets the position of the player in its scene. Moves the anti-cheat
+// rejects are ignored.
+func Sync(p Player, packet *network.Message) {
+	req := &protocol.CSMonsterSync{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	if err := anticheat.GetMod().Move(p.GetUId(), req.SceneId, anticheat.Vec{X: req.X, Z: req.Z}); err != nil {
+		logger.Debug("[monster] sync scene %v PlayerID:%v err:%v", req.SceneId, p.GetUId(), err)
+		return
+	}
+	GetMod().Sync(p, req.SceneId, Vec{X: req.X, Z: req.Z})
+}
+
+// Attack attacks a monster of the scene of the player. Only failures are
+// answered: the hit itself is pushed with the monster.
+func Attack(p Player, packet *network.Message) {
+	req := &protocol.CSMonsterAttack{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	if err := GetMod().Attack(p, req.Uid); err != nil {
+		logger.Debug("[monster] attack monster %v PlayerID:%v err:%v", req.Uid, p.GetUId(), err)
+		p.SendMsg(protocol.MessageId_SCMonsterResult, resultToProto(protocol.MonsterOp_MonsterOpAttack, err))
+	}
+}
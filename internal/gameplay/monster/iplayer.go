@@ -0,0 +1,14 @@
+package monster
+
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/attr"
+)
+
+// Player is a player fighting the monsters.
+type Player interface {
+	GetUId() uint64
+	GetAttrs() *attr.Sheet
+	SendMsg(ID messageId.MessageId, message proto.Message)
+}
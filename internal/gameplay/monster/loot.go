@@ -0,0 +1,20 @@
+package monster
+
+import (
+	"math/rand"
+
+	"greatestworks/internal/note/event/monsterevent"
+)
+
+// roll rolls a loot table: each entry drops, with its own chance, between
+// its Min and Max of its item.
+func roll(r *rand.Rand, conf *LootConf) []monsterevent.Item {
+	var items []monsterevent.Item
+	for _, e := range conf.Entries {
+		if r.Int63n(10000) >= e.Rate {
+			continue
+		}
+		items = append(items, monsterevent.Item{Id: e.ItemId, Num: e.Min + r.Int63n(e.Max-e.Min+1)})
+	}
+	return items
+}
@@ -0,0 +1,199 @@
+package monster
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/module_router"
+	"greatestworks/internal"
+)
+
+const (
+	ModuleName = "monster"
+)
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNotInScene = errors.New("monster: player not in a scene with monsters")
+	ErrNoMonster  = errors.New("monster: no such monster")
+	ErrOutOfRange = errors.New("monster: monster out of range")
+)
+
+var (
+	alives = metrics.NewGaugeMap[sceneLabels](
+		"monster_alive",
+		"Number of monsters alive in a scene",
+	)
+	kills = metrics.NewCounterMap[sceneLabels](
+		"monster_kills",
+		"Number of monsters killed in a scene",
+	)
+)
+
+type sceneLabels struct {
+	Scene string
+}
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Module runs the monsters of the scenes of this server: they spawn at the
+// spawn points of the data tables, think every ModuleConfig.TickInterval,
+// and respawn some time after they're killed, dropping their loot.
+type Module struct {
+	*internal.BaseModule
+	initFlag     bool
+	tables       *tables
+	tickInterval time.Duration
+	defenseConst int64
+	playerRange  float64
+	patrolPause  time.Duration
+	scenes       map[uint32]*Scene // by scene id, set at Init
+	stopCh       chan struct{}
+
+	mu    sync.Mutex
+	where map[uint64]uint32 // scene of each player; guarded by mu
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
+	return Mod
+}
+
+// Init loads the data tables, and places the monsters of the spawn points in
+// their scenes.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	t, err := loadTables(conf.MonsterFile, conf.SpawnFile, conf.LootFile)
+	if err != nil {
+		return err
+	}
+	m.tables = t
+	m.tickInterval = conf.TickInterval
+	if m.tickInterval <= 0 {
+		m.tickInterval = defaultTickInterval
+	}
+	m.defenseConst = conf.DefenseConst
+	if m.defenseConst <= 0 {
+		m.defenseConst = defaultDefenseConst
+	}
+	m.playerRange = conf.PlayerRange
+	if m.playerRange <= 0 {
+		m.playerRange = defaultPlayerRange
+	}
+	m.patrolPause = conf.PatrolPause
+	if m.patrolPause <= 0 {
+		m.patrolPause = defaultPatrolPause
+	}
+	m.scenes = make(map[uint32]*Scene)
+	for _, spawn := range t.spawns {
+		s := m.scenes[spawn.Scene]
+		if s == nil {
+			s = newScene(spawn.Scene)
+			m.scenes[spawn.Scene] = s
+		}
+		s.add(t.monsters[spawn.Monster], spawn)
+	}
+	if len(m.scenes) == 0 {
+		logger.Warn("[monster] no spawn points")
+	}
+	m.where = make(map[uint64]uint32)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts running the monsters.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	go m.run()
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+	}
+}
+
+// run runs the monsters of the scenes every ModuleConfig.TickInterval, until
+// the module is stopped.
+func (m *Module) run() {
+	ticker := time.NewTicker(m.tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, s := range m.scenes {
+				s.tick(m, now)
+			}
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// Sync sets the position of a player in a scene: the monsters of the scene
+// see it, and it sees them. Moving to another scene leaves the previous one.
+func (m *Module) Sync(p Player, sceneId uint32, pos Vec) {
+	uid := p.GetUId()
+	m.mu.Lock()
+	prev, ok := m.where[uid]
+	m.where[uid] = sceneId
+	m.mu.Unlock()
+	if ok && prev != sceneId {
+		if s := m.scenes[prev]; s != nil {
+			s.leave(uid)
+		}
+	}
+	if s := m.scenes[sceneId]; s != nil {
+		s.sync(p, pos)
+	}
+}
+
+// Attack deals the damage of an attack of a player to a monster of its
+// scene, within its range.
+func (m *Module) Attack(p Player, monsterUid uint64) error {
+	m.mu.Lock()
+	sceneId, ok := m.where[p.GetUId()]
+	m.mu.Unlock()
+	s := m.scenes[sceneId]
+	if !ok || s == nil {
+		return ErrNotInScene
+	}
+	return s.hit(m, p.GetUId(), monsterUid, time.Now())
+}
+
+// Offline takes a player that logged out out of its scene.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	sceneId, ok := m.where[uid]
+	delete(m.where, uid)
+	m.mu.Unlock()
+	if s := m.scenes[sceneId]; ok && s != nil {
+		s.leave(uid)
+	}
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
@@ -0,0 +1,211 @@
+package monster
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"greatestworks/internal/gameplay/attr"
+)
+
+// Vec is a position on the ground of a scene.
+type Vec struct {
+	X float64 `json:"x"`
+	Z float64 `json:"z"`
+}
+
+func (v Vec) dist(o Vec) float64 {
+	return math.Hypot(v.X-o.X, v.Z-o.Z)
+}
+
+// toward returns the position reached moving from v toward o by at most
+// step.
+func (v Vec) toward(o Vec, step float64) Vec {
+	d := v.dist(o)
+	if d <= step || d == 0 {
+		return o
+	}
+	return Vec{X: v.X + (o.X-v.X)*step/d, Z: v.Z + (o.Z-v.Z)*step/d}
+}
+
+// away returns the position reached moving from v away from o by step.
+func (v Vec) away(o Vec, step float64) Vec {
+	d := v.dist(o)
+	if d == 0 {
+		return Vec{X: v.X + step, Z: v.Z}
+	}
+	return Vec{X: v.X + (v.X-o.X)*step/d, Z: v.Z + (v.Z-o.Z)*step/d}
+}
+
+// around returns a random position within radius of v.
+func around(r *rand.Rand, v Vec, radius float64) Vec {
+	if radius <= 0 {
+		return v
+	}
+	a := r.Float64() * 2 * math.Pi
+	d := radius * math.Sqrt(r.Float64())
+	return Vec{X: v.X + d*math.Cos(a), Z: v.Z + d*math.Sin(a)}
+}
+
+// State is the state of the AI of a monster.
+type State int
+
+const (
+	StateIdle   State = iota + 1 // 待机，范围内有玩家则追击
+	StatePatrol                  // 巡逻
+	StatePursue                  // 追击目标，进入攻击距离后攻击
+	StateFlee                    // 血量低，远离目标
+	StateReturn                  // 脱战，返回出生点并回满血
+	StateDead                    // 死亡，等待重生
+)
+
+// Monster is a monster of a scene, driven by a finite state machine (see
+// think).
+type Monster struct {
+	Uid   uint64
+	conf  *MonsterConf
+	spawn *SpawnConf
+	Home  Vec // where it spawned
+	Pos   Vec
+	State State
+	HP    int64
+
+	target     uint64           // player pursued or fled
+	waypoint   Vec              // patrol point
+	rest       time.Time        // end of the rest at the patrol point
+	nextAttack time.Time        //
+	respawn    time.Time        // when it respawns, if dead
+	damage     map[uint64]int64 // damage taken, by player
+}
+
+func newMonster(uid uint64, conf *MonsterConf, spawn *SpawnConf) *Monster {
+	return &Monster{Uid: uid, conf: conf, spawn: spawn, State: StateDead}
+}
+
+// revive spawns the monster, at full HP, somewhere around its spawn point.
+func (mon *Monster) revive(r *rand.Rand) {
+	mon.Home = around(r, mon.spawn.Pos, mon.spawn.Radius)
+	mon.Pos = mon.Home
+	mon.State = StateIdle
+	mon.HP = mon.conf.Attrs[attr.HP]
+	mon.target = 0
+	mon.damage = map[uint64]int64{}
+}
+
+// think runs the AI of the monster for a tick of dt, given the positions of
+// the players of its scene, and returns the player it attacks, if any, and
+// whether it changed (e.g., moved).
+//
+//   - Idle, Patrol: pursues the nearest player within its aggro range;
+//     otherwise, if it patrols, walks to random points around home, resting
+//     patrolPause at each.
+//   - Pursue: attacks its target when within attack range, else walks to it;
+//     returns when the target is gone or it's pulled beyond its leash range.
+//   - Flee: once its HP is low, runs from its target until out of its aggro
+//     range, then returns.
+//   - Return: walks home, ignoring the players, and heals up there.
+func (mon *Monster) think(r *rand.Rand, players map[uint64]Vec, now time.Time, dt time.Duration, patrolPause time.Duration) (uint64, bool) {
+	conf := mon.conf
+	step := conf.Speed * dt.Seconds()
+	old := *mon
+	var attacked uint64
+
+	if mon.State == StatePursue && conf.FleeHP > 0 && mon.HP*10000 < conf.Attrs[attr.HP]*conf.FleeHP {
+		mon.State = StateFlee
+	}
+	switch mon.State {
+	case StateIdle, StatePatrol:
+		if id, ok := mon.nearest(players); ok {
+			mon.State, mon.target = StatePursue, id
+			break
+		}
+		if mon.spawn.Patrol <= 0 {
+			break
+		}
+		if mon.State == StateIdle {
+			if now.Before(mon.rest) {
+				break
+			}
+			mon.State = StatePatrol
+			mon.waypoint = around(r, mon.Home, mon.spawn.Patrol)
+		}
+		mon.Pos = mon.Pos.toward(mon.waypoint, step)
+		if mon.Pos == mon.waypoint {
+			mon.State = StateIdle
+			mon.rest = now.Add(patrolPause)
+		}
+	case StatePursue:
+		pos, ok := players[mon.target]
+		if !ok || conf.LeashRange > 0 && mon.Home.dist(pos) > conf.LeashRange {
+			mon.State, mon.target = StateReturn, 0
+			break
+		}
+		if mon.Pos.dist(pos) > conf.AttackRange {
+			mon.Pos = mon.Pos.toward(pos, step)
+			break
+		}
+		if !now.Before(mon.nextAttack) {
+			attacked = mon.target
+			mon.nextAttack = now.Add(time.Duration(conf.AttackInterval) * time.Millisecond)
+		}
+	case StateFlee:
+		pos, ok := players[mon.target]
+		if !ok || mon.Pos.dist(pos) > conf.AggroRange {
+			mon.State, mon.target = StateReturn, 0
+			break
+		}
+		mon.Pos = mon.Pos.away(pos, step)
+	case StateReturn:
+		mon.Pos = mon.Pos.toward(mon.Home, step)
+		if mon.Pos == mon.Home {
+			mon.State = StateIdle
+			mon.HP = conf.Attrs[attr.HP]
+			mon.damage = map[uint64]int64{}
+		}
+	}
+	changed := mon.State != old.State || mon.Pos != old.Pos || mon.HP != old.HP
+	return attacked, changed
+}
+
+// nearest returns the nearest player within the aggro range of the monster.
+func (mon *Monster) nearest(players map[uint64]Vec) (uint64, bool) {
+	var found uint64
+	best := mon.conf.AggroRange
+	for id, pos := range players {
+		d := mon.Pos.dist(pos)
+		if d < best || d == best && id < found {
+			found, best = id, d
+		}
+	}
+	return found, found != 0
+}
+
+// hurt deals damage from a player to the monster, which pursues it if it
+// wasn't busy with another player. It returns whether the monster died.
+func (mon *Monster) hurt(player uint64, dmg int64) bool {
+	if dmg > mon.HP {
+		dmg = mon.HP
+	}
+	mon.HP -= dmg
+	mon.damage[player] += dmg
+	if mon.HP <= 0 {
+		mon.State, mon.target = StateDead, 0
+		return true
+	}
+	if mon.State == StateIdle || mon.State == StatePatrol {
+		mon.State, mon.target = StatePursue, player
+	}
+	return false
+}
+
+// looter returns the player who dealt the most damage to the monster.
+func (mon *Monster) looter() uint64 {
+	var found uint64
+	var best int64
+	for id, dmg := range mon.damage {
+		if dmg > best || dmg == best && id < found {
+			found, best = id, dmg
+		}
+	}
+	return found
+}
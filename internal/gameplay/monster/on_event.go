@@ -0,0 +1,14 @@
+package monster
+
+import (
+	"greatestworks/internal"
+	"greatestworks/internal/note/event"
+)
+
+// OnEvent is unused: the monsters publish their events on the event bus (see
+// monsterevent), and handle none.
+func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
+}
+
+func (m *Module) SetEventCategoryActive(eventCategory int) {
+}
@@ -0,0 +1,30 @@
+package monster
+
+import (
+	"greatestworks/internal/note/event/monsterevent"
+	"greatestworks/internal/protocol"
+)
+
+func updateToProto(sceneId uint32, monsters []Monster) *protocol.SCMonsterUpdate {
+	msg := &protocol.SCMonsterUpdate{SceneId: sceneId}
+	for i := range monsters {
+		mon := &monsters[i]
+		msg.Monsters = append(msg.Monsters, &protocol.MonsterInfo{
+			Uid:    mon.Uid,
+			ConfId: mon.conf.Id,
+			X:      mon.Pos.X,
+			Z:      mon.Pos.Z,
+			State:  int32(mon.State),
+			Hp:     mon.HP,
+		})
+	}
+	return msg
+}
+
+func attackToProto(e monsterevent.Attacked) *protocol.SCMonsterAttack {
+	return &protocol.SCMonsterAttack{Uid: e.Uid, PlayerId: e.PlayerId, Damage: e.Damage}
+}
+
+func resultToProto(op protocol.MonsterOp, err error) *protocol.SCMonsterResult {
+	return &protocol.SCMonsterResult{Op: op, Ok: err == nil}
+}
@@ -0,0 +1,203 @@
+package monster
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/internal/gameplay/attr"
+	"greatestworks/internal/note/event/monsterevent"
+	"greatestworks/internal/note/event/playerevent"
+	"greatestworks/internal/protocol"
+)
+
+// watcher is a player in a scene, seen by its monsters.
+type watcher struct {
+	Player
+	pos Vec
+}
+
+// Scene holds the monsters of the spawn points of a scene, and the players
+// in it. Its monsters think on the goroutine of the module, and are hit on
+// the goroutines of the players, so it's guarded by mu.
+type Scene struct {
+	Id     uint32
+	labels sceneLabels
+
+	mu       sync.Mutex
+	rand     *rand.Rand          // guarded by mu
+	monsters map[uint64]*Monster // guarded by mu
+	order    []uint64            // monsters, in the order they think; guarded by mu
+	players  map[uint64]*watcher // guarded by mu
+}
+
+func newScene(id uint32) *Scene {
+	return &Scene{
+		Id:       id,
+		labels:   sceneLabels{Scene: strconv.FormatUint(uint64(id), 10)},
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano() + int64(id))),
+		monsters: map[uint64]*Monster{},
+		players:  map[uint64]*watcher{},
+	}
+}
+
+// add adds the monsters of a spawn point, dead, so that they spawn at the
+// next tick. It's called before the module starts.
+func (s *Scene) add(conf *MonsterConf, spawn *SpawnConf) {
+	for i := 0; i < spawn.Count; i++ {
+		uid := uint64(spawn.Id)<<32 | uint64(i)
+		s.monsters[uid] = newMonster(uid, conf, spawn)
+		s.order = append(s.order, uid)
+	}
+}
+
+// tick respawns the monsters due, and runs the AI of the others. The
+// monsters that changed are pushed to the players of the scene.
+func (s *Scene) tick(m *Module, now time.Time) {
+	var changed []Monster
+	var hits []monsterevent.Attacked
+	alive := 0
+	s.mu.Lock()
+	positions := make(map[uint64]Vec, len(s.players))
+	for id, w := range s.players {
+		positions[id] = w.pos
+	}
+	for _, uid := range s.order {
+		mon := s.monsters[uid]
+		if mon.State == StateDead {
+			if now.Before(mon.respawn) {
+				continue
+			}
+			mon.revive(s.rand)
+			changed = append(changed, *mon)
+			alive++
+			continue
+		}
+		alive++
+		target, moved := mon.think(s.rand, positions, now, m.tickInterval, m.patrolPause)
+		if moved {
+			changed = append(changed, *mon)
+		}
+		if target == 0 {
+			continue
+		}
+		w := s.players[target]
+		dmg := m.damage(mon.conf.Attrs, w.GetAttrs().Total())
+		hits = append(hits, monsterevent.Attacked{SceneId: s.Id, MonsterId: mon.conf.Id, Uid: uid, PlayerId: target, Damage: dmg})
+	}
+	watchers := s.watchers()
+	s.mu.Unlock()
+
+	alives.Get(s.labels).Set(float64(alive))
+	if len(changed) > 0 {
+		pb := updateToProto(s.Id, changed)
+		for _, w := range watchers {
+			w.SendMsg(protocol.MessageId_SCMonsterUpdate, pb)
+		}
+	}
+	for _, e := range hits {
+		pb := attackToProto(e)
+		for _, w := range watchers {
+			w.SendMsg(protocol.MessageId_SCMonsterAttack, pb)
+		}
+		eventbus.Publish(eventbus.Default, e)
+	}
+}
+
+// hit deals the damage of an attack of a player to a monster within its
+// range. If the monster dies, the loot is rolled for the player who dealt it
+// the most damage.
+func (s *Scene) hit(m *Module, uid, monsterUid uint64, now time.Time) error {
+	s.mu.Lock()
+	w := s.players[uid]
+	mon := s.monsters[monsterUid]
+	switch {
+	case w == nil:
+		s.mu.Unlock()
+		return ErrNotInScene
+	case mon == nil || mon.State == StateDead:
+		s.mu.Unlock()
+		return ErrNoMonster
+	case w.pos.dist(mon.Pos) > m.playerRange:
+		s.mu.Unlock()
+		return ErrOutOfRange
+	}
+	dmg := m.damage(w.GetAttrs().Total(), mon.conf.Attrs)
+	var killed *monsterevent.Killed
+	if mon.hurt(uid, dmg) {
+		mon.respawn = now.Add(time.Duration(mon.spawn.Respawn) * time.Millisecond)
+		killed = &monsterevent.Killed{SceneId: s.Id, MonsterId: mon.conf.Id, Uid: monsterUid, PlayerId: mon.looter()}
+		if loot := m.tables.loots[mon.conf.Loot]; loot != nil {
+			killed.Loot = roll(s.rand, loot)
+		}
+	}
+	snapshot := *mon
+	watchers := s.watchers()
+	s.mu.Unlock()
+
+	pb := updateToProto(s.Id, []Monster{snapshot})
+	for _, w := range watchers {
+		w.SendMsg(protocol.MessageId_SCMonsterUpdate, pb)
+	}
+	if killed == nil {
+		return nil
+	}
+	kills.Get(s.labels).Add(1)
+	eventbus.Publish(eventbus.Default, playerevent.Kill{PlayerId: uid, TargetId: uint64(killed.MonsterId)})
+	eventbus.Publish(eventbus.Default, *killed)
+	return nil
+}
+
+// sync sets the position of a player in the scene, adding it if it's new,
+// in which case the monsters alive are pushed to it.
+func (s *Scene) sync(p Player, pos Vec) {
+	s.mu.Lock()
+	w := s.players[p.GetUId()]
+	if w != nil {
+		w.pos = pos
+		s.mu.Unlock()
+		return
+	}
+	s.players[p.GetUId()] = &watcher{Player: p, pos: pos}
+	var monsters []Monster
+	for _, uid := range s.order {
+		if mon := s.monsters[uid]; mon.State != StateDead {
+			monsters = append(monsters, *mon)
+		}
+	}
+	s.mu.Unlock()
+	p.SendMsg(protocol.MessageId_SCMonsterUpdate, updateToProto(s.Id, monsters))
+}
+
+// leave removes a player from the scene. The monsters pursuing it return.
+func (s *Scene) leave(uid uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.players, uid)
+}
+
+// watchers returns the players of the scene.
+//
+// REQUIRES: s.mu is held.
+func (s *Scene) watchers() []Player {
+	watchers := make([]Player, 0, len(s.players))
+	for _, w := range s.players {
+		watchers = append(watchers, w.Player)
+	}
+	return watchers
+}
+
+// damage returns the damage dealt by an attacker to a defender: the attack of
+// the attacker, reduced by the defense of the defender, at least 1.
+func (m *Module) damage(atk, def attr.Attrs) int64 {
+	dmg := atk[attr.Attack]
+	if d := def[attr.Defense]; d > 0 {
+		dmg = dmg * m.defenseConst / (m.defenseConst + d)
+	}
+	if dmg < 1 {
+		dmg = 1
+	}
+	return dmg
+}
@@ -1,20 +1,50 @@
 package rank
 
-import "sync"
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+)
+
+// invalidateChannel is the Redis pub/sub channel on which the servers
+// announce that the top of a rank changed. Messages are ZSet names.
+const invalidateChannel = "molerank:invalidate"
+
+// Cache caches the top entries of the current season of a rank, in the scope
+// of this server or of all servers, so that the
+// leaderboard pages viewed by players are served without hitting Redis. The
+// entries are reloaded from Redis when they are older than the cache TTL, or
+// when a server invalidates them because a score changed the top (see
+// Module.SetScore).
 type Cache struct {
 	size          int64
-	lastDataIndex int64
+	lastDataIndex int64 // number of cached entries
 	rankID        uint32
 	sortType      uint32
 	rankData      []KV
+	rankName      string    // ZSet of the cached entries
+	loadTime      time.Time // zero if the entries must be reloaded
 	dataMutex     sync.Mutex
 }
 
 type KV struct {
 	PlayerId uint64
 	Score    int64
-	SetTM    int64 //set time
+	SetTM    int64  //set time
+	ServerId string // server of the player
+}
+
+// cacheKey identifies the cache of a rank in a scope.
+type cacheKey struct {
+	rankId uint32
+	scope  Scope
 }
 
 func newCacheRank(rankID uint32, size int64, sortType uint32) *Cache {
@@ -27,77 +57,225 @@ func newCacheRank(rankID uint32, size int64, sortType uint32) *Cache {
 	return cr
 }
 
+// getRank returns the cached entries between begin and end (0-based,
+// inclusive), skipping the provided players.
 func (c *Cache) getRank(begin, end int64, blackPlayerIds []uint64) ([]KV, bool) {
+	c.dataMutex.Lock()
+	defer c.dataMutex.Unlock()
 
-	if end > c.lastDataIndex {
-		end = c.lastDataIndex
+	if end >= c.lastDataIndex {
+		end = c.lastDataIndex - 1
 	}
 
-	getLen := end - begin
-	if begin < 0 || getLen <= 0 || end >= c.size {
+	getLen := end - begin + 1
+	if begin < 0 || getLen <= 0 {
 		return nil, false // 参数错误
 	}
 
 	kvs := make([]KV, 0, getLen)
-
-	c.dataMutex.Lock()
+outer:
 	for idx := begin; idx <= end; idx++ {
-		kv := KV{
-			PlayerId: c.rankData[idx].PlayerId,
-			Score:    c.rankData[idx].Score,
-			SetTM:    c.rankData[idx].SetTM,
-		}
+		kv := c.rankData[idx]
 		for _, id := range blackPlayerIds {
 			if kv.PlayerId == id {
-				continue
+				continue outer
 			}
 		}
-
 		kvs = append(kvs, kv)
 	}
-	c.dataMutex.Unlock()
 
 	return kvs, true
 }
 
-func (c *Cache) refresh(kvData []KV) {
-
-	var (
-		getCnt        int64 = 500
-		curStartIndex int64 = 0
-		curEndIndex   int64 = 499
-	)
-	for curEndIndex < c.size {
-		dataLen := len(kvData)
-		if dataLen <= 0 {
-			curEndIndex = 0
-			break
+// contains returns whether the provided player is in the cached entries.
+func (c *Cache) contains(playerId uint64) bool {
+	c.dataMutex.Lock()
+	defer c.dataMutex.Unlock()
+	for _, kv := range c.rankData[:c.lastDataIndex] {
+		if kv.PlayerId == playerId {
+			return true
 		}
+	}
+	return false
+}
+
+// fresh returns whether the cached entries are those of the provided ZSet,
+// loaded less than ttl ago.
+//
+// REQUIRES: c.dataMutex is held.
+func (c *Cache) fresh(rankName string, ttl time.Duration, now time.Time) bool {
+	return c.rankName == rankName && !c.loadTime.IsZero() && now.Sub(c.loadTime) < ttl
+}
 
-		c.setCacheData(curStartIndex, curStartIndex+int64(dataLen), kvData)
+// load replaces the cached entries with the top entries of the provided ZSet.
+//
+// REQUIRES: c.dataMutex is held.
+// Members without a server label belong to the provided server.
+//
+// REQUIRES: c.dataMutex is held.
+func (c *Cache) load(ctx context.Context, rdb goredis.Cmdable, rankName, serverId string, season *Season, now time.Time) error {
+	zs, err := top(ctx, rdb, rankName, SortType(c.sortType) == Des, c.size)
+	if err != nil {
+		return err
+	}
 
-		if dataLen < int(getCnt) {
-			curEndIndex = curStartIndex + int64(dataLen)
-			break
+	c.lastDataIndex = 0
+	for _, z := range zs {
+		member, _ := z.Member.(string)
+		server, playerId, err := parseMember(member, serverId)
+		if err != nil {
+			continue
 		}
+		score, achieved := DecodeScore(z.Score, SortType(c.sortType), season)
+		c.rankData[c.lastDataIndex] = KV{PlayerId: playerId, Score: score, SetTM: achieved.Unix(), ServerId: server}
+		c.lastDataIndex++
+	}
+	c.rankName = rankName
+	c.loadTime = now
+	return nil
+}
 
-		curStartIndex += getCnt
-		curEndIndex += getCnt
+// parseMember returns the server and the player of a ZSet member, which is
+// either a player id, or a player id labeled with its server (see aggregate).
+func parseMember(member, serverId string) (string, uint64, error) {
+	if i := strings.LastIndexByte(member, ':'); i >= 0 {
+		serverId, member = member[:i], member[i+1:]
 	}
-	c.lastDataIndex = curEndIndex
+	playerId, err := strconv.ParseUint(member, 10, 64)
+	return serverId, playerId, err
 }
 
-func (c *Cache) setCacheData(begin, end int64, data []KV) {
-	getLen := end - begin
-	if begin < 0 || getLen <= 0 || end > c.size {
-		return
+// cacheOf returns the cache of the provided rank in the provided scope.
+func (m *Module) cacheOf(conf *Config, scope Scope) *Cache {
+	c, _ := m.cache.LoadOrStore(cacheKey{conf.ID, scope}, newCacheRank(conf.ID, m.cacheSize, conf.SortType))
+	return c.(*Cache)
+}
+
+// GetTop returns the entries of the current season of a rank in the provided
+// scope between begin and end (0-based, inclusive), best first, excluding
+// blacklisted players. Only the top ModuleConfig.CacheSize entries can be
+// read. The global scope is only available for global ranks, and lags behind
+// the servers by up to ModuleConfig.GlobalInterval.
+func (m *Module) GetTop(ctx context.Context, rankId uint32, scope Scope, begin, end int64) ([]KV, error) {
+	conf, ok := m.configs[rankId]
+	if !ok {
+		return nil, fmt.Errorf("unknown rank %v", rankId)
+	}
+	if scope == ScopeGlobal && !conf.Global {
+		return nil, fmt.Errorf("rank %v is not global", rankId)
+	}
+	now := time.Now()
+	season := m.currentSeason(conf, now)
+	rankName := conf.getRankName(m.serverId, season)
+	if scope == ScopeGlobal {
+		rankName = conf.getGlobalRankName(season)
 	}
 
+	c := m.cacheOf(conf, scope)
 	c.dataMutex.Lock()
-	for idx := begin; idx < end; idx++ {
-		c.rankData[idx].PlayerId = data[idx-begin].PlayerId
-		c.rankData[idx].Score = data[idx-begin].Score
-		c.rankData[idx].SetTM = data[idx-begin].SetTM
+	if !c.fresh(rankName, m.cacheTTL, now) {
+		if err := c.load(ctx, redis.Get(), rankName, m.serverId, season, now); err != nil {
+			c.dataMutex.Unlock()
+			return nil, err
+		}
+		if scope == ScopeServer {
+			m.setLastScore(conf, c)
+		}
 	}
 	c.dataMutex.Unlock()
+
+	kvs, _ := c.getRank(begin, end, m.blackPlayerIds(rankId))
+	return kvs, nil
+}
+
+// setLastScore records the score that a score must beat to enter the cached
+// top of a rank, or -1 if the cache isn't full (any score enters it).
+//
+// REQUIRES: c.dataMutex is held.
+func (m *Module) setLastScore(conf *Config, c *Cache) {
+	last := int64(-1)
+	if c.lastDataIndex == c.size {
+		last = c.rankData[c.lastDataIndex-1].Score
+	}
+	m.rlsMutex.Lock()
+	defer m.rlsMutex.Unlock()
+	m.rankLastScoreList[conf.ID] = last
+}
+
+// changesTop returns whether setting the score of a player may change the
+// cached top of a rank: the score beats the last cached score, or the player
+// is in the cached top.
+func (m *Module) changesTop(conf *Config, playerId uint64, score int64) bool {
+	m.rlsMutex.Lock()
+	last, ok := m.rankLastScoreList[conf.ID]
+	m.rlsMutex.Unlock()
+	if !ok {
+		// Nothing cached here, but other servers may have cached the top.
+		return true
+	}
+	if last < 0 {
+		return true
+	}
+	if SortType(conf.SortType) == Des && score > last || SortType(conf.SortType) == Aes && score < last {
+		return true
+	}
+	c, ok := m.cache.Load(cacheKey{conf.ID, ScopeServer})
+	return ok && c.(*Cache).contains(playerId)
+}
+
+// invalidateTop invalidates the cached tops of a ZSet, on all servers.
+func (m *Module) invalidateTop(ctx context.Context, rankName string) {
+	m.invalidateLocal(rankName)
+	if err := redis.Get().Publish(ctx, invalidateChannel, rankName).Err(); err != nil {
+		logger.Error("[rank] publish invalidation of %v failed: %v", rankName, err)
+	}
+}
+
+// invalidateLocal invalidates the cached tops of a ZSet on this server.
+func (m *Module) invalidateLocal(rankName string) {
+	m.cache.Range(func(_, v interface{}) bool {
+		c := v.(*Cache)
+		c.dataMutex.Lock()
+		if c.rankName == rankName {
+			c.loadTime = time.Time{}
+		}
+		c.dataMutex.Unlock()
+		return true
+	})
+}
+
+// runInvalidations invalidates the cached tops announced by the other
+// servers, until the module is stopped.
+func (m *Module) runInvalidations() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := redis.Get().Subscribe(ctx, invalidateChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			m.invalidateLocal(msg.Payload)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// blackPlayerIds returns the blacklisted players of a rank.
+func (m *Module) blackPlayerIds(rankId uint32) []uint64 {
+	m.bMutex.Lock()
+	defer m.bMutex.Unlock()
+	black := m.blackList[rankId]
+	if black == nil {
+		return nil
+	}
+	ids := make([]uint64, 0, len(black.BlackList))
+	for _, info := range black.BlackList {
+		ids = append(ids, info.PlayerId)
+	}
+	return ids
 }
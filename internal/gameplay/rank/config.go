@@ -5,18 +5,133 @@ import (
 	"time"
 )
 
+const (
+	timeLayout       = "2006-01-02 15:04:05"
+	defaultStartTime = "2023-01-01 00:00:00"
+	defaultFinalTime = "2100-01-01 00:00:00"
+
+	defaultSeasonCheckInterval = time.Minute
+	defaultCacheSize           = 100
+	defaultCacheTTL            = 5 * time.Second
+	defaultGlobalInterval      = 5 * time.Minute
+)
+
+// ModuleConfig is the config of the rank module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	StartTime           string        // start of the score time factor of ranks without seasons, defaults to defaultStartTime
+	FinalTime           string        // end of the score time factor of ranks without seasons, defaults to defaultFinalTime
+	SeasonCheckInterval time.Duration // how often season rollovers are checked, defaults to defaultSeasonCheckInterval
+	CacheSize           int64         // number of top entries cached per rank, at most MaxNum, defaults to defaultCacheSize
+	CacheTTL            time.Duration // how long cached tops are served without reloading, defaults to defaultCacheTTL
+	ServerId            string        // id of this server, which labels its ZSets; servers without an id share their ZSets
+	GlobalServers       []string      // ids of the servers whose ranks are aggregated into the global ranks
+	GlobalInterval      time.Duration // how often the global ranks are aggregated, defaults to defaultGlobalInterval
+	Ranks               []*Config
+}
+
 type Config struct {
-	ID          uint32 `json:"id"`
-	Desc        string `json:"desc"`
-	Name        string `json:"name"`
-	Category    uint32 `json:"category"`
-	SortType    uint32 `json:"sortType"`
-	RefreshTime uint32 `json:"refreshTime"`
-	Reward      uint32 `json:"reward"`
+	ID          uint32        `json:"id"`
+	Desc        string        `json:"desc"`
+	Name        string        `json:"name"`
+	Category    uint32        `json:"category"`
+	SortType    uint32        `json:"sortType"`
+	RefreshTime uint32        `json:"refreshTime"`
+	Reward      uint32        `json:"reward"`
+	Season      *SeasonConfig `json:"season"` // nil if the rank has no seasons
+	Global      bool          `json:"global"` // whether the rank is aggregated across servers
+	Source      string        `json:"source"` // event that updates the scores (see Source*), empty if set by SetScore only
+}
+
+// Sources of the scores of the ranks, updated from the events of the event bus
+// (see Module.subscribe).
+const (
+	SourceLevel = "level" // the level of the player (playerevent.LevelUp)
+	SourceKills = "kills" // the number of kills of the player (playerevent.Kill)
+	SourceWins  = "wins"  // the number of battles won by the player (battleevent.Settled)
+	SourceArena = "arena" // the points of the player in the arena ladder (arenaevent.Attacked, arenaevent.Defended)
+
+	// The ranks of these sources rank the families, by family id.
+	SourceFamilyLevel = "family_level" // the level of the family (familyevent.LevelUp)
+	SourceFamilyExp   = "family_exp"   // the experience of the family (familyevent.Contributed)
+)
+
+// SeasonConfig configures the seasons of a rank. Seasons last Days days
+// each, back to back, starting at Start. When a season ends, its final
+// standings are archived, the rewards are mailed, and the next season starts
+// with an empty ZSet.
+type SeasonConfig struct {
+	Start   string          `json:"start"` // start of the first season, e.g. "2023-01-01 00:00:00" (local time)
+	Days    uint32          `json:"days"`
+	Rewards []*SeasonReward `json:"rewards"`
+}
+
+// SeasonReward is the reward mailed to the players ranked between MinRank and
+// MaxRank (inclusive, 1 is the top player) at the end of a season.
+type SeasonReward struct {
+	MinRank uint32        `json:"minRank"`
+	MaxRank uint32        `json:"maxRank"`
+	MailId  uint32        `json:"mailId"` // mail template
+	Items   []*RewardItem `json:"items"`
 }
 
-func (c *Config) getRankName(rankId uint32) (rankName string) {
+type RewardItem struct {
+	ItemId uint32 `json:"itemId"`
+	Num    int32  `json:"num"`
+}
+
+// getRankName returns the ZSet of the provided season of the rank on the
+// provided server. Servers without an id share the ZSet.
+func (c *Config) getRankName(serverId string, season *Season) string {
+	if serverId == "" {
+		return "molerank:" + c.rankSuffix(season)
+	}
+	return fmt.Sprintf("molerank:%s:%s", serverId, c.rankSuffix(season))
+}
+
+// getGlobalRankName returns the ZSet of the provided season of the global
+// rank, which aggregates the ranks of all servers (see aggregate). The name
+// is a hash tag, so that the ZSet and the ZSet it's built in (see
+// globalBuildName) are in the same Redis cluster slot.
+func (c *Config) getGlobalRankName(season *Season) string {
+	return "{molerank:global:" + c.rankSuffix(season) + "}"
+}
+
+func (c *Config) rankSuffix(season *Season) string {
+	if c.Season != nil {
+		return fmt.Sprintf("%v:s%d", c.Category, season.Id)
+	}
 	now := time.Now()
-	rankName = fmt.Sprintf("molerank:%v:%04d%02d%02d", c.Category, now.Year(), now.Month(), now.Day())
-	return rankName
+	return fmt.Sprintf("%v:%04d%02d%02d", c.Category, now.Year(), now.Month(), now.Day())
+}
+
+// parse returns the start of the first season and the length of a season.
+func (c *SeasonConfig) parse() (time.Time, time.Duration, error) {
+	start, err := time.ParseInLocation(timeLayout, c.Start, time.Local)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid season start %q: %w", c.Start, err)
+	}
+	if c.Days == 0 {
+		return time.Time{}, 0, fmt.Errorf("invalid season length: got 0 days")
+	}
+	return start, time.Duration(c.Days) * 24 * time.Hour, nil
+}
+
+// reward returns the reward of the provided rank, or nil if the rank isn't
+// rewarded.
+func (c *SeasonConfig) reward(rank uint32) *SeasonReward {
+	for _, r := range c.Rewards {
+		if rank >= r.MinRank && rank <= r.MaxRank {
+			return r
+		}
+	}
+	return nil
+}
+
+// parseTime parses the provided local time, or def if s is empty.
+func parseTime(s, def string) (time.Time, error) {
+	if s == "" {
+		s = def
+	}
+	return time.ParseInLocation(timeLayout, s, time.Local)
 }
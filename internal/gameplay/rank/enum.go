@@ -3,8 +3,16 @@ package rank
 type SortType int
 
 const (
-	Des SortType = 1
-	Aes
+	Des SortType = iota + 1 // higher scores first
+	Aes                     // lower scores first
+)
+
+// Scope selects the players a rank query covers.
+type Scope int
+
+const (
+	ScopeServer Scope = iota // players of this server
+	ScopeGlobal              // players of all servers (see aggregate)
 )
 
 const (
@@ -1,44 +1,61 @@
 package rank
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 var (
 	startTime int64
 	finalTime int64
 )
 
-func calScore(score int64, timeUnit int64, timeBitLen uint32, sortType uint32) int64 {
-	var (
-		scoreWithTime int64
-		timeFactor    int64
-	)
-
-	nowTime := time.Now().Unix()
+// Scores are stored in the ZSets as integers that pack the score of a player
+// with the time at which it was achieved (see readme.txt), so that players
+// with equal scores are ordered by achievement time, earliest first:
+//
+//	zscore = score << TimeBitLen | timeFactor
+//
+// The time factor counts the TimeBlock seconds elapsed since the start of the
+// season. It is inverted for Des ranks, in which higher zscores rank first,
+// so that earlier achievements get higher factors. A ZSet score is a float64,
+// which represents integers exactly up to 2^53, so scores must not exceed
+// MaxScore. Players whose scores are achieved within the same TimeBlock are
+// ordered by player id (Redis orders equal zscores by member).
+
+const (
+	// MaxScore is the maximum score that can be encoded.
+	MaxScore = int64(1)<<(53-TimeBitLen) - 1
+
+	maxTimeFactor = int64(1)<<TimeBitLen - 1
+)
 
-	if sortType == 1 {
-		timeFactor = (nowTime - startTime) / timeUnit
-	} else {
-		timeFactor = (finalTime - nowTime) / timeUnit
+// EncodeScore returns the ZSet score of the provided score, achieved at the
+// provided time during the provided season of a rank sorted by sortType.
+func EncodeScore(score int64, achieved time.Time, sortType SortType, season *Season) (float64, error) {
+	if score < 0 || score > MaxScore {
+		return 0, fmt.Errorf("score %d out of range [0, %d]", score, MaxScore)
 	}
-
-	scoreWithTime = (score << timeBitLen) | timeFactor
-
-	return scoreWithTime
-}
-
-func getRealScore(tmScore int64, timeBitLen uint32) int64 {
-	var realScore int64
-	realScore = tmScore >> timeBitLen
-	return realScore
+	factor := (achieved.Unix() - season.Start) / TimeBlock
+	if factor < 0 {
+		factor = 0
+	}
+	if factor > maxTimeFactor {
+		factor = maxTimeFactor
+	}
+	if sortType == Des {
+		factor = maxTimeFactor - factor
+	}
+	return float64(score<<TimeBitLen | factor), nil
 }
 
-func getRealScoreTime(tmScore int64, timeUnit int64, timeBitLen uint32, sortType uint32) int64 {
-	var realTM int64
-	timeFactor := tmScore & ((1 << timeBitLen) - 1)
-	if sortType == 1 {
-		realTM = (timeFactor * timeUnit) + startTime
-	} else {
-		realTM = finalTime - (timeFactor * timeUnit)
+// DecodeScore returns the score encoded in the provided ZSet score, and the
+// time at which it was achieved, truncated to TimeBlock seconds.
+func DecodeScore(zscore float64, sortType SortType, season *Season) (score int64, achieved time.Time) {
+	z := int64(zscore)
+	factor := z & maxTimeFactor
+	if sortType == Des {
+		factor = maxTimeFactor - factor
 	}
-	return realTM
+	return z >> TimeBitLen, time.Unix(season.Start+factor*TimeBlock, 0)
 }
@@ -0,0 +1,153 @@
+package rank
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
+)
+
+const (
+	// globalExpiry is how long a global rank outlives its last aggregation,
+	// e.g. after its season ended.
+	globalExpiry = 24 * time.Hour
+
+	// globalBatchSize is the maximum number of entries written by a single
+	// ZADD when a global rank is built.
+	globalBatchSize = 1000
+)
+
+// runAggregation aggregates the global ranks every interval, until the module
+// is stopped.
+func (m *Module) runAggregation(interval time.Duration) {
+	m.aggregateAll(time.Now())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			m.aggregateAll(now)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// aggregateAll aggregates the current seasons of the global ranks.
+func (m *Module) aggregateAll(now time.Time) {
+	for _, conf := range m.configs {
+		if !conf.Global {
+			continue
+		}
+		season := m.currentSeason(conf, now)
+		if err := m.aggregate(context.Background(), conf, season); err != nil {
+			logger.Error("[rank] aggregation of global rank %v failed: %v", conf.ID, err)
+		}
+	}
+}
+
+// aggregate merges the top entries of the provided season of a rank on the
+// servers listed in ModuleConfig.GlobalServers into the global rank. Entries
+// are labeled with their server ("<server id>:<player id>"). A player ranked
+// on several servers (e.g., after moving) keeps the best of its scores.
+//
+// The global rank is built aside and then renamed, so that readers never see
+// a partial rank. Only one server aggregates a rank per interval.
+func (m *Module) aggregate(ctx context.Context, conf *Config, season *Season) error {
+	rdb := redis.Get()
+	globalName := conf.getGlobalRankName(season)
+	lockKey := globalName + ":lock"
+	locked, err := rdb.SetNX(ctx, lockKey, time.Now().Unix(), m.globalInterval).Result()
+	if err != nil {
+		return err
+	}
+	if !locked {
+		// Another server aggregates the rank.
+		return nil
+	}
+
+	m.bMutex.Lock()
+	black := m.blackList[conf.ID]
+	m.bMutex.Unlock()
+
+	des := SortType(conf.SortType) == Des
+	best := make(map[uint64]goredis.Z)
+	for _, serverId := range m.globalServers {
+		zs, err := top(ctx, rdb, conf.getRankName(serverId, season), des, MaxNum)
+		if err != nil {
+			return fmt.Errorf("server %v: %w", serverId, err)
+		}
+		for _, z := range zs {
+			member, _ := z.Member.(string)
+			if black != nil && black.BlackList[member] != nil {
+				continue
+			}
+			playerId, err := strconv.ParseUint(member, 10, 64)
+			if err != nil {
+				continue
+			}
+			if b, ok := best[playerId]; ok && (b.Score >= z.Score) == des {
+				continue
+			}
+			best[playerId] = goredis.Z{Score: z.Score, Member: serverId + ":" + member}
+		}
+	}
+
+	merged := make([]*goredis.Z, 0, len(best))
+	for _, z := range best {
+		z := z
+		merged = append(merged, &z)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return (merged[i].Score > merged[j].Score) == des
+	})
+	if len(merged) > MaxNum {
+		merged = merged[:MaxNum]
+	}
+
+	if len(merged) == 0 {
+		if err := rdb.Del(ctx, globalName).Err(); err != nil {
+			return err
+		}
+	} else {
+		buildName := globalBuildName(globalName)
+		if err := rdb.Del(ctx, buildName).Err(); err != nil {
+			return err
+		}
+		for begin := 0; begin < len(merged); begin += globalBatchSize {
+			end := begin + globalBatchSize
+			if end > len(merged) {
+				end = len(merged)
+			}
+			if err := rdb.ZAdd(ctx, buildName, merged[begin:end]...).Err(); err != nil {
+				return err
+			}
+		}
+		if err := rdb.Rename(ctx, buildName, globalName).Err(); err != nil {
+			return err
+		}
+		if err := rdb.Expire(ctx, globalName, globalExpiry).Err(); err != nil {
+			return err
+		}
+	}
+	m.invalidateTop(ctx, globalName)
+	return nil
+}
+
+// globalBuildName returns the ZSet in which the provided global rank is built.
+func globalBuildName(globalName string) string {
+	return globalName + ":build"
+}
+
+// top returns the top n entries of a ZSet, best first.
+func top(ctx context.Context, rdb goredis.Cmdable, rankName string, des bool, n int64) ([]goredis.Z, error) {
+	if des {
+		return rdb.ZRevRangeWithScores(ctx, rankName, 0, n-1).Result()
+	}
+	return rdb.ZRangeWithScores(ctx, rankName, 0, n-1).Result()
+}
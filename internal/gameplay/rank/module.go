@@ -1,11 +1,14 @@
 package rank
 
 import (
+	"context"
 	"fmt"
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/phuhao00/greatestworks-proto/module"
 	"greatestworks/aop/module_router"
 	"greatestworks/aop/redis"
 	"greatestworks/internal"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -13,6 +16,7 @@ import (
 var (
 	Mod         *Module
 	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
 )
 
 func init() {
@@ -23,9 +27,19 @@ type Module struct {
 	initFlag          bool
 	bMutex            sync.Mutex
 	rlsMutex          sync.Mutex
+	sMutex            sync.Mutex
 	cache             sync.Map
 	rankLastScoreList map[uint32]int64
 	blackList         map[uint32]*BlackList
+	configs           map[uint32]*Config
+	seasons           map[uint32]*Season // season being played, by rank id; guarded by sMutex
+	checkInterval     time.Duration
+	cacheSize         int64         // number of top entries cached per rank
+	cacheTTL          time.Duration // how long cached entries are served
+	serverId          string
+	globalServers     []string
+	globalInterval    time.Duration
+	stopCh            chan struct{}
 	*internal.BaseModule
 }
 
@@ -37,36 +51,94 @@ func GetMod() *Module {
 }
 
 func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
 
-	stringStartTime := "2023-01-01 00:00:00"
-	loc, _ := time.LoadLocation("Local")
-	start, err := time.ParseInLocation("2006-01-02 15:04:05", stringStartTime, loc)
-
+	start, err := parseTime(conf.StartTime, defaultStartTime)
 	if err != nil {
 		return err
 	}
-
 	startTime = start.Unix()
-	stringFinalTime := "2100-01-01 00:00:00"
-	final, err := time.ParseInLocation("2006-01-02 15:04:05", stringFinalTime, loc)
-
+	final, err := parseTime(conf.FinalTime, defaultFinalTime)
 	if err != nil {
 		return err
 	}
 	finalTime = final.Unix()
+
+	m.checkInterval = conf.SeasonCheckInterval
+	if m.checkInterval <= 0 {
+		m.checkInterval = defaultSeasonCheckInterval
+	}
+	m.cacheSize = conf.CacheSize
+	if m.cacheSize <= 0 || m.cacheSize > MaxNum {
+		m.cacheSize = defaultCacheSize
+	}
+	m.cacheTTL = conf.CacheTTL
+	if m.cacheTTL <= 0 {
+		m.cacheTTL = defaultCacheTTL
+	}
+	m.serverId = conf.ServerId
+	m.globalServers = conf.GlobalServers
+	m.globalInterval = conf.GlobalInterval
+	if m.globalInterval <= 0 {
+		m.globalInterval = defaultGlobalInterval
+	}
+	m.configs = make(map[uint32]*Config, len(conf.Ranks))
+	m.seasons = make(map[uint32]*Season)
+	now := time.Now()
+	for _, c := range conf.Ranks {
+		m.configs[c.ID] = c
+		if c.Season == nil {
+			continue
+		}
+		season, err := c.Season.seasonAt(now)
+		if err != nil {
+			return fmt.Errorf("rank %v: %w", c.ID, err)
+		}
+		m.seasons[c.ID] = season
+	}
 	m.blackList = make(map[uint32]*BlackList, 16)
 	m.rankLastScoreList = make(map[uint32]int64)
+	m.stopCh = make(chan struct{})
 
 	m.initFlag = true
 
 	return nil
 }
 
+// OnStart starts rolling the seasons of the ranks over, aggregating the global
+// ranks, and listening for the invalidations of the cached tops of the ranks.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	m.subscribe()
+	go m.runInvalidations()
+	if len(m.seasons) > 0 {
+		go m.runSeasons(m.checkInterval)
+	}
+	if len(m.globalServers) > 0 {
+		go m.runAggregation(m.globalInterval)
+	}
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		m.unsubscribe()
+		close(m.stopCh)
+	}
+}
+
 func (m *Module) GetRank(rankId uint32, playerId uint64, sortType SortType) {
-	conf := &Config{}
-	rankName := conf.getRankName(rankId)
+	conf, ok := m.configs[rankId]
+	if !ok {
+		return
+	}
+	rankName := conf.getRankName(m.serverId, m.currentSeason(conf, time.Now()))
 	if sortType == Aes {
-		redis.GetMockInstance()
+		redis.Get()
 		//todo ZRank(rankName, playerId)
 	}
 	if sortType == Des {
@@ -77,9 +149,75 @@ func (m *Module) GetRank(rankId uint32, playerId uint64, sortType SortType) {
 
 }
 
+// SetScore sets the score of a player in the current season of a rank. Among
+// players with equal scores, the player who achieved the score first ranks
+// first (see EncodeScore). If the score may change the top of the rank, the
+// cached tops are invalidated on all servers.
+func (m *Module) SetScore(ctx context.Context, rankId uint32, playerId uint64, score int64) error {
+	conf, ok := m.configs[rankId]
+	if !ok {
+		return fmt.Errorf("unknown rank %v", rankId)
+	}
+	now := time.Now()
+	season := m.currentSeason(conf, now)
+	zscore, err := EncodeScore(score, now, SortType(conf.SortType), season)
+	if err != nil {
+		return err
+	}
+	member := &goredis.Z{Score: zscore, Member: strconv.FormatUint(playerId, 10)}
+	rankName := conf.getRankName(m.serverId, season)
+	if err := redis.Get().ZAdd(ctx, rankName, member).Err(); err != nil {
+		return err
+	}
+	if m.changesTop(conf, playerId, score) {
+		m.invalidateTop(ctx, rankName)
+	}
+	return nil
+}
+
+// AddScore adds delta to the score of a player in the current season of a
+// rank. The score counts as achieved now. Concurrent calls for the same player
+// must not be made from several servers.
+func (m *Module) AddScore(ctx context.Context, rankId uint32, playerId uint64, delta int64) error {
+	conf, ok := m.configs[rankId]
+	if !ok {
+		return fmt.Errorf("unknown rank %v", rankId)
+	}
+	season := m.currentSeason(conf, time.Now())
+	zscore, err := redis.Get().ZScore(ctx, conf.getRankName(m.serverId, season), strconv.FormatUint(playerId, 10)).Result()
+	if err != nil && err != goredis.Nil {
+		return err
+	}
+	var score int64
+	if err == nil {
+		score, _ = DecodeScore(zscore, SortType(conf.SortType), season)
+	}
+	return m.SetScore(ctx, rankId, playerId, score+delta)
+}
+
+// Remove removes a member (e.g., a disbanded family) from the current season
+// of a rank, invalidating the cached tops on all servers if it was in them.
+func (m *Module) Remove(ctx context.Context, rankId uint32, id uint64) error {
+	conf, ok := m.configs[rankId]
+	if !ok {
+		return fmt.Errorf("unknown rank %v", rankId)
+	}
+	rankName := conf.getRankName(m.serverId, m.currentSeason(conf, time.Now()))
+	if err := redis.Get().ZRem(ctx, rankName, strconv.FormatUint(id, 10)).Err(); err != nil {
+		return err
+	}
+	if c, ok := m.cache.Load(cacheKey{conf.ID, ScopeServer}); ok && c.(*Cache).contains(id) {
+		m.invalidateTop(ctx, rankName)
+	}
+	return nil
+}
+
 func (m *Module) GetZCard(rankId uint32) int64 {
-	conf := &Config{}
-	rankName := conf.getRankName(rankId)
+	conf, ok := m.configs[rankId]
+	if !ok {
+		return 0
+	}
+	rankName := conf.getRankName(m.serverId, m.currentSeason(conf, time.Now()))
 	//todo  ZCard(rankName)
 	fmt.Println(rankName)
 	return 0
@@ -93,6 +231,12 @@ func (m *Module) Save() {
 
 }
 
+// Dependencies returns the modules the rank module depends on: season
+// rewards are mailed.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Email.String()}
+}
+
 func (m *Module) GetName() string {
 	return module.Module_Rank.String()
 }
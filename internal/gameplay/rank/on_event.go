@@ -1,8 +1,16 @@
 package rank
 
 import (
+	"context"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
 	"greatestworks/internal"
 	"greatestworks/internal/note/event"
+	"greatestworks/internal/note/event/arenaevent"
+	"greatestworks/internal/note/event/battleevent"
+	"greatestworks/internal/note/event/familyevent"
+	"greatestworks/internal/note/event/playerevent"
 )
 
 type EventHandle func(iEvent event.IEvent)
@@ -12,12 +20,105 @@ type EventWrap struct {
 	event.IEvent
 }
 
+// OnEvent is a no-op: the rank module reacts to the events it subscribes to
+// on the event bus (see subscribe).
 func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
-	//TODO implement me
-	panic("implement me")
 }
 
 func (m *Module) SetEventCategoryActive(eventCategory int) {
-	//TODO implement me
-	panic("implement me")
+}
+
+// subscribe subscribes the module to the events that update the scores of
+// the ranks, according to their Source.
+func (m *Module) subscribe() {
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e playerevent.LevelUp) {
+		for _, conf := range m.configs {
+			if conf.Source != SourceLevel {
+				continue
+			}
+			if err := m.SetScore(context.Background(), conf.ID, e.PlayerId, int64(e.Level)); err != nil {
+				logger.Error("[rank] set level of player %v in rank %v failed: %v", e.PlayerId, conf.ID, err)
+			}
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e playerevent.Kill) {
+		for _, conf := range m.configs {
+			if conf.Source != SourceKills {
+				continue
+			}
+			if err := m.AddScore(context.Background(), conf.ID, e.PlayerId, 1); err != nil {
+				logger.Error("[rank] add kill of player %v in rank %v failed: %v", e.PlayerId, conf.ID, err)
+			}
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e battleevent.Settled) {
+		for _, conf := range m.configs {
+			if conf.Source != SourceWins {
+				continue
+			}
+			for _, r := range e.Results {
+				if !r.Won {
+					continue
+				}
+				if err := m.AddScore(context.Background(), conf.ID, r.PlayerId, 1); err != nil {
+					logger.Error("[rank] add win of player %v in rank %v failed: %v", r.PlayerId, conf.ID, err)
+				}
+			}
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e arenaevent.Attacked) {
+		m.setArenaPoints(e.PlayerId, e.Points)
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e arenaevent.Defended) {
+		m.setArenaPoints(e.PlayerId, e.Points)
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e familyevent.LevelUp) {
+		for _, conf := range m.configs {
+			if conf.Source != SourceFamilyLevel {
+				continue
+			}
+			if err := m.SetScore(context.Background(), conf.ID, e.FamilyId, int64(e.Level)); err != nil {
+				logger.Error("[rank] set level of family %v in rank %v failed: %v", e.FamilyId, conf.ID, err)
+			}
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e familyevent.Contributed) {
+		for _, conf := range m.configs {
+			if conf.Source != SourceFamilyExp {
+				continue
+			}
+			if err := m.SetScore(context.Background(), conf.ID, e.FamilyId, e.Exp); err != nil {
+				logger.Error("[rank] set experience of family %v in rank %v failed: %v", e.FamilyId, conf.ID, err)
+			}
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e familyevent.Disbanded) {
+		for _, conf := range m.configs {
+			if conf.Source != SourceFamilyLevel && conf.Source != SourceFamilyExp {
+				continue
+			}
+			if err := m.Remove(context.Background(), conf.ID, e.FamilyId); err != nil {
+				logger.Error("[rank] remove family %v from rank %v failed: %v", e.FamilyId, conf.ID, err)
+			}
+		}
+	})
+}
+
+// setArenaPoints sets the points of a player in the arena ladder in the ranks
+// of the arena.
+func (m *Module) setArenaPoints(playerId uint64, points int64) {
+	for _, conf := range m.configs {
+		if conf.Source != SourceArena {
+			continue
+		}
+		if err := m.SetScore(context.Background(), conf.ID, playerId, points); err != nil {
+			logger.Error("[rank] set arena points of player %v in rank %v failed: %v", playerId, conf.ID, err)
+		}
+	}
+}
+
+// unsubscribe cancels the subscriptions of the module, once the events
+// already received are handled.
+func (m *Module) unsubscribe() {
+	eventbus.Default.UnsubscribeModule(m.GetName())
 }
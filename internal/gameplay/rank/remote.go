@@ -0,0 +1,73 @@
+package rank
+
+import (
+	"context"
+
+	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/rpc"
+)
+
+// The methods of the rank module are served to the other processes (see
+// aop/rpc), so that the modules of a process without the ranks (e.g. the
+// players on another node) read and update them through the rank module of
+// their node, rather than through Redis. The Call* functions call them on
+// the node configured for the rank module, or in process.
+
+type TopArgs struct {
+	RankId uint32
+	Scope  Scope
+	Begin  int64
+	End    int64
+}
+
+type TopReply struct {
+	Entries []KV
+}
+
+type ScoreArgs struct {
+	RankId   uint32
+	PlayerId uint64
+	Score    int64 // the score for SetScore, the delta for AddScore
+}
+
+type ScoreReply struct{}
+
+func init() {
+	name := module.Module_Rank.String()
+	rpc.Handle(name, "GetTop", func(ctx context.Context, args *TopArgs) (*TopReply, error) {
+		kvs, err := GetMod().GetTop(ctx, args.RankId, args.Scope, args.Begin, args.End)
+		if err != nil {
+			return nil, err
+		}
+		return &TopReply{Entries: kvs}, nil
+	})
+	rpc.Handle(name, "SetScore", func(ctx context.Context, args *ScoreArgs) (*ScoreReply, error) {
+		return &ScoreReply{}, GetMod().SetScore(ctx, args.RankId, args.PlayerId, args.Score)
+	})
+	rpc.Handle(name, "AddScore", func(ctx context.Context, args *ScoreArgs) (*ScoreReply, error) {
+		return &ScoreReply{}, GetMod().AddScore(ctx, args.RankId, args.PlayerId, args.Score)
+	})
+}
+
+// CallGetTop calls Module.GetTop on the node of the rank module.
+func CallGetTop(ctx context.Context, rankId uint32, scope Scope, begin, end int64) ([]KV, error) {
+	var reply TopReply
+	args := &TopArgs{RankId: rankId, Scope: scope, Begin: begin, End: end}
+	if err := rpc.Call(ctx, module.Module_Rank.String(), "GetTop", args, &reply, rpc.CallOptions{Idempotent: true}); err != nil {
+		return nil, err
+	}
+	return reply.Entries, nil
+}
+
+// CallSetScore calls Module.SetScore on the node of the rank module.
+func CallSetScore(ctx context.Context, rankId uint32, playerId uint64, score int64) error {
+	args := &ScoreArgs{RankId: rankId, PlayerId: playerId, Score: score}
+	return rpc.Call(ctx, module.Module_Rank.String(), "SetScore", args, nil, rpc.CallOptions{Idempotent: true, ShardKey: playerId})
+}
+
+// CallAddScore calls Module.AddScore on the node of the rank module. The
+// calls for the same player go to the same node, so that they don't race.
+func CallAddScore(ctx context.Context, rankId uint32, playerId uint64, delta int64) error {
+	args := &ScoreArgs{RankId: rankId, PlayerId: playerId, Score: delta}
+	return rpc.Call(ctx, module.Module_Rank.String(), "AddScore", args, nil, rpc.CallOptions{ShardKey: playerId})
+}
@@ -0,0 +1,111 @@
+package rank
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/redis"
+)
+
+// scopeBatchSize is the maximum number of players whose scores are fetched
+// by a single ZMSCORE.
+const scopeBatchSize = 500
+
+// GetScoped returns the entries of the provided players (e.g., the friends of
+// a player and the player, or the members of a guild) in the current season
+// of a rank, best first. Players without a score and blacklisted players are
+// left out. The scores are fetched in batches of ZMSCORE, so the cost depends
+// on the number of players, not on the size of the rank.
+func (m *Module) GetScoped(ctx context.Context, rankId uint32, playerIds []uint64) ([]KV, error) {
+	conf, ok := m.configs[rankId]
+	if !ok {
+		return nil, fmt.Errorf("unknown rank %v", rankId)
+	}
+	season := m.currentSeason(conf, time.Now())
+	rankName := conf.getRankName(m.serverId, season)
+
+	m.bMutex.Lock()
+	black := m.blackList[rankId]
+	m.bMutex.Unlock()
+
+	members := make([]string, 0, len(playerIds))
+	seen := make(map[uint64]bool, len(playerIds))
+	for _, id := range playerIds {
+		member := strconv.FormatUint(id, 10)
+		if seen[id] || black != nil && black.BlackList[member] != nil {
+			continue
+		}
+		seen[id] = true
+		members = append(members, member)
+	}
+
+	rdb := redis.Get()
+	var zs []goredis.Z
+	for begin := 0; begin < len(members); begin += scopeBatchSize {
+		end := begin + scopeBatchSize
+		if end > len(members) {
+			end = len(members)
+		}
+		batch, err := zmscore(ctx, rdb, rankName, members[begin:end])
+		if err != nil {
+			return nil, err
+		}
+		zs = append(zs, batch...)
+	}
+
+	// Order the entries the way Redis orders the ZSet: by zscore, then by
+	// member.
+	des := SortType(conf.SortType) == Des
+	sort.Slice(zs, func(i, j int) bool {
+		if zs[i].Score != zs[j].Score {
+			return (zs[i].Score > zs[j].Score) == des
+		}
+		return (zs[i].Member.(string) > zs[j].Member.(string)) == des
+	})
+
+	kvs := make([]KV, 0, len(zs))
+	for _, z := range zs {
+		playerId, _ := strconv.ParseUint(z.Member.(string), 10, 64)
+		score, achieved := DecodeScore(z.Score, SortType(conf.SortType), season)
+		kvs = append(kvs, KV{PlayerId: playerId, Score: score, SetTM: achieved.Unix(), ServerId: m.serverId})
+	}
+	return kvs, nil
+}
+
+// zmscore returns the ZSet scores of the provided members, leaving out the
+// members that aren't in the ZSet. Unlike ZMScore, which reports missing
+// members as 0, it tells missing members from members with a zero score.
+func zmscore(ctx context.Context, rdb redis.Client, key string, members []string) ([]goredis.Z, error) {
+	args := make([]interface{}, 0, len(members)+2)
+	args = append(args, "zmscore", key)
+	for _, member := range members {
+		args = append(args, member)
+	}
+	vals, err := rdb.Do(ctx, args...).Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) != len(members) {
+		return nil, fmt.Errorf("zmscore: got %d scores, want %d", len(vals), len(members))
+	}
+	zs := make([]goredis.Z, 0, len(vals))
+	for i, val := range vals {
+		if val == nil {
+			continue
+		}
+		s, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("zmscore: unexpected score %v", val)
+		}
+		score, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("zmscore: %w", err)
+		}
+		zs = append(zs, goredis.Z{Score: score, Member: members[i]})
+	}
+	return zs, nil
+}
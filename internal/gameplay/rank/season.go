@@ -0,0 +1,211 @@
+package rank
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"greatestworks/aop/dlock"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/redis"
+	"greatestworks/internal/communicate/email"
+)
+
+// Season is a window of time during which a rank accumulates scores.
+type Season struct {
+	Id    uint32 // 1 for the first season, 0 for ranks without seasons
+	Start int64  // unix seconds, inclusive
+	End   int64  // unix seconds, exclusive
+}
+
+// seasonAt returns the season at the provided time. Scores set before the
+// first season count for the first season.
+func (c *SeasonConfig) seasonAt(now time.Time) (*Season, error) {
+	start, length, err := c.parse()
+	if err != nil {
+		return nil, err
+	}
+	var n int64
+	if now.After(start) {
+		n = int64(now.Sub(start) / length)
+	}
+	begin := start.Add(time.Duration(n) * length)
+	return &Season{Id: uint32(n) + 1, Start: begin.Unix(), End: begin.Add(length).Unix()}, nil
+}
+
+// currentSeason returns the season of the provided rank at the provided time.
+// Ranks without seasons have a single season spanning startTime to finalTime.
+func (m *Module) currentSeason(conf *Config, now time.Time) *Season {
+	if conf.Season != nil {
+		// The season config is validated by Init.
+		if season, err := conf.Season.seasonAt(now); err == nil {
+			return season
+		}
+	}
+	return &Season{Start: startTime, End: finalTime}
+}
+
+// runSeasons rolls the seasons of the ranks over when they end, until the
+// module is stopped.
+func (m *Module) runSeasons(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			m.checkSeasons(now)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// checkSeasons rolls over the ranks whose season ended before now.
+func (m *Module) checkSeasons(now time.Time) {
+	m.sMutex.Lock()
+	defer m.sMutex.Unlock()
+	for rankId, season := range m.seasons {
+		if now.Unix() < season.End {
+			continue
+		}
+		conf := m.configs[rankId]
+		if err := m.rollover(context.Background(), conf, season); err != nil {
+			logger.Error("[rank] rollover of rank %v season %v failed: %v", rankId, season.Id, err)
+			continue
+		}
+		m.seasons[rankId] = m.currentSeason(conf, now)
+	}
+}
+
+// rollover archives the final standings of the provided season of a rank,
+// mails the season rewards, and deletes the ZSet of the season. Scores set
+// since the season ended already go to the ZSet of the next season (see
+// getRankName). Each server rolls its own ZSet over.
+//
+// When several servers share a rank, only one of them rolls a season over,
+// holding the lock of the season; the others return an error until it's
+// done, and retry. If the server holding the lock crashes, the lock is
+// released once its lease runs out.
+func (m *Module) rollover(ctx context.Context, conf *Config, season *Season) error {
+	rdb := redis.Get()
+	rankName := conf.getRankName(m.serverId, season)
+	doneKey := rankName + ":archived"
+	done, err := rdb.Exists(ctx, doneKey).Result()
+	if err != nil {
+		return err
+	}
+	if done > 0 {
+		// Another server has rolled the season over.
+		return nil
+	}
+	lock, err := dlock.Get().TryLock(ctx, "rank:rollover:"+rankName)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	// Stop if the lock is lost, as another server may roll the season over.
+	ctx = lock.Context()
+	// Check again: the season may have been rolled over since.
+	if done, err := rdb.Exists(ctx, doneKey).Result(); err != nil || done > 0 {
+		return err
+	}
+
+	standings, err := m.standings(ctx, rdb, conf, season)
+	if err != nil {
+		return err
+	}
+	archive := &mongo.RankSeasonArchive{
+		RankId:      conf.ID,
+		ServerId:    m.serverId,
+		SeasonId:    season.Id,
+		Start:       season.Start,
+		End:         season.End,
+		ArchiveTime: time.Now().Unix(),
+		Standings:   standings,
+	}
+	if _, err := mongo.Client.InsertOne(ctx, archive.DB(), archive.C(), archive); err != nil {
+		return fmt.Errorf("archive standings: %w", err)
+	}
+
+	// The rewards are mailed on a best-effort basis: the standings are
+	// archived, and the mails can be sent again from the archive, since
+	// sending a mail twice is a no-op.
+	for _, s := range standings {
+		reward := conf.Season.reward(s.Rank)
+		if reward == nil {
+			continue
+		}
+		if err := email.SendSystemMail(ctx, s.PlayerId, seasonRewardMail(conf, season, s, reward)); err != nil {
+			logger.Error("[rank] mail reward of rank %v season %v to player %v failed: %v", conf.ID, season.Id, s.PlayerId, err)
+		}
+	}
+
+	if err := rdb.Set(ctx, doneKey, archive.ArchiveTime, 0).Err(); err != nil {
+		return fmt.Errorf("mark season archived: %w", err)
+	}
+	if err := rdb.Del(ctx, rankName).Err(); err != nil {
+		return fmt.Errorf("delete season ZSet: %w", err)
+	}
+	m.rlsMutex.Lock()
+	delete(m.rankLastScoreList, conf.ID)
+	m.rlsMutex.Unlock()
+	m.cache.Delete(cacheKey{conf.ID, ScopeServer})
+	logger.Info("[rank] rank %v season %v archived, %v players ranked", conf.ID, season.Id, len(standings))
+	return nil
+}
+
+// standings returns the final standings of the provided season of a rank,
+// excluding blacklisted players, best first.
+func (m *Module) standings(ctx context.Context, rdb goredis.Cmdable, conf *Config, season *Season) ([]mongo.RankStanding, error) {
+	rankName := conf.getRankName(m.serverId, season)
+	zs, err := top(ctx, rdb, rankName, SortType(conf.SortType) == Des, MaxNum)
+	if err != nil {
+		return nil, err
+	}
+
+	m.bMutex.Lock()
+	black := m.blackList[conf.ID]
+	m.bMutex.Unlock()
+
+	standings := make([]mongo.RankStanding, 0, len(zs))
+	for _, z := range zs {
+		member, _ := z.Member.(string)
+		if black != nil && black.BlackList[member] != nil {
+			continue
+		}
+		playerId, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		score, achieved := DecodeScore(z.Score, SortType(conf.SortType), season)
+		standings = append(standings, mongo.RankStanding{
+			Rank:     uint32(len(standings) + 1),
+			PlayerId: playerId,
+			Score:    score,
+			SetTM:    achieved.Unix(),
+		})
+	}
+	return standings, nil
+}
+
+// seasonRewardMail returns the mail of the season reward of a player. The id
+// of the mail is derived from the rank, the season, and the player, so that
+// the reward is mailed at most once.
+func seasonRewardMail(conf *Config, season *Season, s mongo.RankStanding, reward *SeasonReward) *mongo.MailInfo {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "rank:%d:season:%d:player:%d", conf.ID, season.Id, s.PlayerId)
+	info := &mongo.MailInfo{
+		MUuid:    h.Sum64(),
+		MailID:   reward.MailId,
+		MContent: fmt.Sprintf("%s season %d: rank %d", conf.Name, season.Id, s.Rank),
+		MTime:    time.Now().Unix(),
+	}
+	for _, item := range reward.Items {
+		info.MItems = append(info.MItems, mongo.MailItem{ItemId: item.ItemId, Num: item.Num})
+	}
+	return info
+}
@@ -0,0 +1,84 @@
+package reset
+
+import (
+	"fmt"
+	"time"
+
+	"greatestworks/aop/fn"
+	"greatestworks/aop/loader/json"
+	"greatestworks/internal/note/event/resetevent"
+)
+
+// Conf 区域的重置时间
+type Conf struct {
+	Region   string `json:"region"`   // 区域
+	Timezone string `json:"timezone"` // 时区，如Asia/Shanghai，为空则用服务器时区
+	Hour     int    `json:"hour"`     // 重置的小时(0-23)，每日、每周、每月都在这个小时
+	Weekday  int    `json:"weekday"`  // 每周重置在星期几，0为周日
+	MonthDay int    `json:"monthDay"` // 每月重置在几号(1-28)，0为1号
+	loc      *time.Location
+}
+
+// last returns the last reset of a period at or before now.
+func (c *Conf) last(period int, now time.Time) time.Time {
+	now = now.In(c.loc)
+	switch period {
+	case resetevent.Weekly:
+		return fn.LastWeekdayReset(now, time.Weekday(c.Weekday), c.Hour)
+	case resetevent.Monthly:
+		return fn.LastMonthlyReset(now, c.MonthDay, c.Hour)
+	default:
+		return fn.LastDailyReset(now, c.Hour)
+	}
+}
+
+// periods are the periods of the resets, in the order they're done: a
+// player is reset daily before weekly before monthly.
+var periods = []int{resetevent.Daily, resetevent.Weekly, resetevent.Monthly}
+
+// ModuleConfig is the config of the reset module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	RegionFile   string        // reset times by region, see Conf
+	Region       string        // region of this server, whose reset times are used
+	ServerId     string        // id of this server, recorded in the reset log
+	TickInterval time.Duration // how often the boundaries are checked
+}
+
+const (
+	defaultTickInterval = time.Second
+)
+
+// loadTable loads the reset times of a region from the region table. The
+// default times (at midnight, on Mondays and on the 1st, on the time zone of
+// the server) are used if there is no table.
+func loadTable(file, region string) (*Conf, error) {
+	if file == "" {
+		return &Conf{Region: region, Weekday: int(time.Monday), MonthDay: 1, loc: time.Local}, nil
+	}
+	var list []*Conf
+	if !json.ParseJsonFile2Slice(file, true, &list) {
+		return nil, fmt.Errorf("reset: region table %q not found", file)
+	}
+	for _, c := range list {
+		if c.Region != region {
+			continue
+		}
+		if c.Hour < 0 || c.Hour > 23 || c.Weekday < 0 || c.Weekday > 6 || c.MonthDay < 0 || c.MonthDay > 28 {
+			return nil, fmt.Errorf("reset: region table %q: region %q: invalid hour %d, weekday %d, month day %d", file, region, c.Hour, c.Weekday, c.MonthDay)
+		}
+		if c.MonthDay == 0 {
+			c.MonthDay = 1
+		}
+		c.loc = time.Local
+		if c.Timezone != "" {
+			loc, err := time.LoadLocation(c.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("reset: region table %q: region %q: %w", file, region, err)
+			}
+			c.loc = loc
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("reset: region table %q: no region %q", file, region)
+}
@@ -0,0 +1,76 @@
+package reset
+
+import (
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"greatestworks/internal/note/event/resetevent"
+)
+
+// Doc 对应DB -> mongo
+type Doc struct {
+	Daily   int64 `bson:"daily"`   // 最近一次每日重置的时间点
+	Weekly  int64 `bson:"weekly"`  // 最近一次每周重置的时间点
+	Monthly int64 `bson:"monthly"` // 最近一次每月重置的时间点
+}
+
+// Data is the last resets a player went through, by period. It's reset on
+// the goroutine of the module at the boundaries, and on the goroutine of the
+// player when it logs in, so it's guarded by mu.
+type Data struct {
+	uid       uint64
+	markDirty func()
+
+	mu  sync.Mutex
+	doc Doc // guarded by mu
+}
+
+func NewData() *Data {
+	return &Data{}
+}
+
+// SetOwner sets the player the data belongs to, and how it's marked dirty.
+func (d *Data) SetOwner(uid uint64, markDirty func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.uid = uid
+	d.markDirty = markDirty
+}
+
+// Save returns a copy of the last resets, to be stored.
+func (d *Data) Save() (interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.doc, nil
+}
+
+// Load loads the stored last resets.
+func (d *Data) Load(raw bson.RawValue) error {
+	var doc Doc
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.doc = doc
+	return nil
+}
+
+// advance records the reset of a period at a time, and returns whether the
+// player wasn't reset for it yet.
+//
+// REQUIRES: d.mu is held.
+func (d *Data) advance(period int, at int64) bool {
+	last := &d.doc.Daily
+	switch period {
+	case resetevent.Weekly:
+		last = &d.doc.Weekly
+	case resetevent.Monthly:
+		last = &d.doc.Monthly
+	}
+	if *last >= at {
+		return false
+	}
+	*last = at
+	return true
+}
@@ -0,0 +1,265 @@
+package reset
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/module_router"
+	"greatestworks/aop/mongo"
+	"greatestworks/internal"
+	"greatestworks/internal/note/event/resetevent"
+)
+
+const (
+	ModuleName = "reset"
+)
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	boundaries = metrics.NewCounterMap[periodLabels](
+		"reset_boundaries",
+		"Number of reset boundaries run by this server",
+	)
+	resets = metrics.NewCounterMap[resetLabels](
+		"reset_players",
+		"Number of players reset, at the boundaries or when they logged in",
+	)
+)
+
+type periodLabels struct {
+	Period string
+}
+
+type resetLabels struct {
+	Period string
+	Lazy   bool
+}
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Module runs the daily, weekly and monthly resets at the times of the region
+// of the server. At a boundary, it publishes resetevent.Boundary, resets the
+// players online, and records it in the reset log; the players offline are
+// reset when they log in. A player is reset by publishing resetevent.Reset,
+// at most once per reset.
+type Module struct {
+	*internal.BaseModule
+	initFlag     bool
+	conf         *Conf
+	serverId     string
+	tickInterval time.Duration
+	stopCh       chan struct{}
+
+	mu     sync.Mutex
+	last   map[int]int64    // last boundary run, by period; guarded by mu
+	online map[uint64]*Data // guarded by mu
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
+	return Mod
+}
+
+// Init loads the reset times of the region of the server.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	if conf.RegionFile == "" {
+		logger.Warn("[reset] no region table, using the default reset times")
+	}
+	c, err := loadTable(conf.RegionFile, conf.Region)
+	if err != nil {
+		return err
+	}
+	m.conf = c
+	m.serverId = conf.ServerId
+	m.tickInterval = conf.TickInterval
+	if m.tickInterval <= 0 {
+		m.tickInterval = defaultTickInterval
+	}
+	m.last = make(map[int]int64)
+	m.online = make(map[uint64]*Data)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts watching the boundaries. The boundaries passed while the
+// server was down aren't run: there's no player online to reset, and the
+// players are reset when they log in.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	now := time.Now()
+	m.mu.Lock()
+	for _, p := range periods {
+		m.last[p] = m.conf.last(p, now).Unix()
+	}
+	m.mu.Unlock()
+	go m.run()
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+	}
+}
+
+// run checks the boundaries every ModuleConfig.TickInterval, until the
+// module is stopped.
+func (m *Module) run() {
+	ticker := time.NewTicker(m.tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			m.tick(now)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// tick runs the boundaries passed since the last tick.
+func (m *Module) tick(now time.Time) {
+	for _, p := range periods {
+		at := m.conf.last(p, now).Unix()
+		m.mu.Lock()
+		due := at > m.last[p]
+		if due {
+			m.last[p] = at
+		}
+		m.mu.Unlock()
+		if due {
+			m.boundary(p, at)
+		}
+	}
+}
+
+// boundary runs the boundary of a period: it publishes it, resets the
+// players online, and records it in the reset log.
+func (m *Module) boundary(period int, at int64) {
+	started := time.Now()
+	eventbus.Publish(eventbus.Default, resetevent.Boundary{Region: m.conf.Region, Period: period, At: at})
+	players := 0
+	for _, d := range m.onlineData() {
+		if m.reset(d, period, at, false) {
+			players++
+		}
+	}
+	boundaries.Get(periodLabels{Period: periodName(period)}).Add(1)
+	logger.Info("[reset] %v reset at %v: %v players online reset in %v", periodName(period), at, players, time.Since(started))
+	m.record(context.Background(), period, at, players, started)
+}
+
+// reset resets a player for the boundary of a period at a time, unless it
+// was already, and returns whether it was.
+func (m *Module) reset(d *Data, period int, at int64, lazy bool) bool {
+	d.mu.Lock()
+	due := d.advance(period, at)
+	uid, markDirty := d.uid, d.markDirty
+	d.mu.Unlock()
+	if !due {
+		return false
+	}
+	markDirty()
+	resets.Get(resetLabels{Period: periodName(period), Lazy: lazy}).Add(1)
+	eventbus.Publish(eventbus.Default, resetevent.Reset{PlayerId: uid, Period: period, At: at, Lazy: lazy})
+	return true
+}
+
+func resetLog() *mongodriver.Collection {
+	doc := &mongo.ResetLog{}
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+// record appends the run of a boundary to the reset log. The boundary ran
+// anyway: a failure is only logged.
+func (m *Module) record(ctx context.Context, period int, at int64, players int, now time.Time) {
+	id, err := idgenerator.NextId()
+	if err != nil {
+		logger.Error("[reset] reset log id %v reset at %v err:%v", periodName(period), at, err)
+		return
+	}
+	doc := &mongo.ResetLog{
+		Id:      id,
+		Region:  m.conf.Region,
+		Server:  m.serverId,
+		Period:  period,
+		At:      at,
+		Players: players,
+		Time:    now.Unix(),
+	}
+	if _, err := resetLog().InsertOne(ctx, doc); err != nil {
+		logger.Error("[reset] reset log %v reset at %v err:%v", periodName(period), at, err)
+	}
+}
+
+// Online registers the data of a player that logged in, and resets it for
+// the boundaries it missed while offline.
+func (m *Module) Online(d *Data) {
+	m.mu.Lock()
+	m.online[d.uid] = d
+	m.mu.Unlock()
+	now := time.Now()
+	for _, p := range periods {
+		m.reset(d, p, m.conf.last(p, now).Unix(), true)
+	}
+}
+
+// Offline unregisters the data of a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
+}
+
+// onlineData returns the data of the players online on this server.
+func (m *Module) onlineData() []*Data {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := make([]*Data, 0, len(m.online))
+	for _, d := range m.online {
+		all = append(all, d)
+	}
+	return all
+}
+
+func periodName(period int) string {
+	switch period {
+	case resetevent.Daily:
+		return "daily"
+	case resetevent.Weekly:
+		return "weekly"
+	case resetevent.Monthly:
+		return "monthly"
+	default:
+		return "unknown"
+	}
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
@@ -1,6 +1,9 @@
 package actor
 
-import "google.golang.org/protobuf/proto"
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
 
 type Player struct {
 	*Base
@@ -30,3 +33,9 @@ func (p *Player) OnMove() {
 func (p *Player) SendMsg(message proto.Message) {
 	p.real.SendMsg(message)
 }
+
+// SendBatch sends a batch of the updates nearby, shared with the other players
+// of its cell (see fanout.Broadcaster).
+func (p *Player) SendBatch(batch *wrapperspb.BytesValue) {
+	p.real.SendBatch(batch)
+}
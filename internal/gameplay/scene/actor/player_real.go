@@ -1,7 +1,11 @@
 package actor
 
-import "google.golang.org/protobuf/proto"
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
 
 type PlayerReal interface {
 	SendMsg(message proto.Message)
+	SendBatch(batch *wrapperspb.BytesValue)
 }
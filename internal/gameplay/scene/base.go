@@ -2,6 +2,10 @@ package scene
 
 import (
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/net/fanout"
+	"greatestworks/aop/tick"
 	actor2 "greatestworks/internal/gameplay/scene/actor"
 	"sync"
 	"sync/atomic"
@@ -15,6 +19,8 @@ type Base struct {
 	FinishTime uint64
 	Players    sync.Map
 	Status     atomic.Value
+	Broadcast  *fanout.Broadcaster // 附近广播，每帧合批发送
+	cancelTick func()
 }
 
 func NewBase() *Base {
@@ -26,6 +32,7 @@ func NewBase() *Base {
 		FinishTime: 0,
 		Players:    sync.Map{},
 		Status:     atomic.Value{},
+		Broadcast:  fanout.NewBroadcaster(fanout.Options{}),
 	}
 }
 
@@ -37,9 +44,18 @@ func (b *Base) NotifyAll(message proto.Message) {
 	})
 }
 
-func (b *Base) NotifyNearby(actor IActor, message proto.Message) {
-	//TODO implement me
-	panic("implement me")
+// Move places an entity of the scene, which receives the updates nearby if
+// it's a player.
+func (b *Base) Move(entity uint64, x, y float64, player bool) {
+	b.Broadcast.Move(entity, x, y, player)
+}
+
+// NotifyNearby sends an update of an entity (e.g., its position) to the
+// players nearby at the next frame (see tick), batched with the other updates
+// of the frame; a later update of the entity with the same message ID
+// supersedes it.
+func (b *Base) NotifyNearby(entity uint64, id uint64, message proto.Message) {
+	b.Broadcast.Update(entity, id, message)
 }
 
 func (b *Base) NotifyPlayer(playerId uint64, message proto.Message) {
@@ -55,15 +71,30 @@ func (b *Base) OnCreate() {
 }
 
 func (b *Base) Run() {
-
+	b.cancelTick = tick.Register("scene", tick.Normal, func(tick.Frame) { b.loop() })
 }
 
 func (b *Base) OnDestroy() {
-
+	if b.cancelTick != nil {
+		b.cancelTick()
+	}
 }
 
+// loop runs a frame of the scene.
 func (b *Base) loop() {
+	b.flush()
+}
 
+// flush sends the updates of the frame to the players nearby.
+func (b *Base) flush() {
+	err := b.Broadcast.Flush(func(playerId uint64, batch *wrapperspb.BytesValue) {
+		if v, ok := b.Players.Load(playerId); ok {
+			v.(*actor2.Player).SendBatch(batch)
+		}
+	})
+	if err != nil {
+		logger.Error("[flush] scene:%v err:%v", b.Id, err)
+	}
 }
 
 func (b *Base) monitor() {
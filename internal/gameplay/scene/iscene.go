@@ -14,7 +14,7 @@ type IScene interface {
 
 type Notify interface {
 	NotifyAll(message proto.Message)
-	NotifyNearby(actor IActor, message proto.Message)
+	NotifyNearby(entity uint64, id uint64, message proto.Message)
 	NotifyPlayer(playerId uint64, message proto.Message)
 }
 
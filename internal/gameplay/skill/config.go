@@ -0,0 +1,114 @@
+package skill
+
+import (
+	"fmt"
+
+	"greatestworks/aop/gamedata"
+	"greatestworks/internal/gameplay/bag"
+	"greatestworks/internal/gameplay/bag/item/template"
+	"greatestworks/internal/gameplay/buff"
+)
+
+// Targets of the skills.
+const (
+	TargetEnemy = iota // 敌方单位
+	TargetSelf         // 自己
+	TargetAlly         // 友方单位，包括自己
+)
+
+// Resources consumed by the casts of the skills.
+const (
+	ResourceNone   = iota // 无消耗
+	ResourceMP            // 法力，上限为属性attr.MP，随时间恢复
+	ResourceEnergy        // 能量，进入战斗时为0，造成和受到伤害时获得
+)
+
+// Conf 技能配置
+type Conf struct {
+	Id       uint32   `json:"id"`
+	Target   int      `json:"target"`   // 目标(Target*)
+	Resource int      `json:"resource"` // 释放消耗的资源(Resource*)
+	Charges  int      `json:"charges"`  // 充能次数，每次冷却恢复一次，0同1
+	Follows  uint32   `json:"follows"`  // 连招：只能在释放该技能后window毫秒内释放，0为不限
+	Window   int64    `json:"window"`   // 连招窗口，毫秒
+	Levels   []*Level `json:"levels"`   // 各级配置，从1级开始
+}
+
+// Level 技能每级的配置
+type Level struct {
+	PlayerLevel uint32      `json:"playerLevel"` // 学习或升到该级需要的玩家等级
+	Materials   []bag.Stack `json:"materials"`   // 学习或升到该级消耗的道具
+	Cooldown    int64       `json:"cooldown"`    // 冷却，毫秒
+	Cost        int64       `json:"cost"`        // 每次释放消耗的资源
+	Power       int64       `json:"power"`       // 伤害，攻击力的万分比
+	Damage      int64       `json:"damage"`      // 固定伤害
+	Heal        int64       `json:"heal"`        // 治疗，攻击力的万分比，仅对自己和友方技能
+	Dispel      []uint32    `json:"dispel"`      // 命中后驱散目标的buff类别
+	Buffs       []uint32    `json:"buffs"`       // 命中后给目标加的buff，见buff.Conf
+}
+
+// Level returns the config of a level of the skill, or nil if there's no
+// such level.
+func (c *Conf) Level(level uint32) *Level {
+	if level == 0 || int(level) > len(c.Levels) {
+		return nil
+	}
+	return c.Levels[level-1]
+}
+
+// MaxLevel returns the highest level of the skill.
+func (c *Conf) MaxLevel() uint32 {
+	return uint32(len(c.Levels))
+}
+
+// ModuleConfig is the config of the skill module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	SkillFile string // skill table, a JSON array or a CSV file of Conf (see gamedata)
+}
+
+// Skills is the skill table, by skill id (see ModuleConfig.SkillFile).
+var Skills = gamedata.Register(gamedata.Default, "skill", func(c *Conf) uint32 { return c.Id }, checkSkills)
+
+// checkSkills checks the skills, their materials in the item table, and
+// their buffs in the buff table, which must be loaded.
+func checkSkills(s *gamedata.Snapshot, t *gamedata.Table[uint32, Conf]) error {
+	items := bag.Items.In(s)
+	var err error
+	t.Range(func(id uint32, c *Conf) bool {
+		err = checkSkill(c, t, items)
+		return err == nil
+	})
+	return err
+}
+
+func checkSkill(c *Conf, skills *gamedata.Table[uint32, Conf], items *gamedata.Table[uint32, template.Conf]) error {
+	if c.Target < TargetEnemy || c.Target > TargetAlly {
+		return fmt.Errorf("skill %d: invalid target %d", c.Id, c.Target)
+	}
+	if c.Resource < ResourceNone || c.Resource > ResourceEnergy {
+		return fmt.Errorf("skill %d: invalid resource %d", c.Id, c.Resource)
+	}
+	if len(c.Levels) == 0 {
+		return fmt.Errorf("skill %d: no levels", c.Id)
+	}
+	for i, lv := range c.Levels {
+		for _, id := range lv.Buffs {
+			if buff.GetMod().Conf(id) == nil {
+				return fmt.Errorf("skill %d level %d: unknown buff %d", c.Id, i+1, id)
+			}
+		}
+		for _, m := range lv.Materials {
+			if items.Get(m.Id) == nil {
+				return fmt.Errorf("skill %d level %d: unknown material %d", c.Id, i+1, m.Id)
+			}
+		}
+	}
+	if c.Follows != 0 && skills.Get(c.Follows) == nil {
+		return fmt.Errorf("skill %d: follows unknown skill %d", c.Id, c.Follows)
+	}
+	if c.Charges <= 0 {
+		c.Charges = 1
+	}
+	return nil
+}
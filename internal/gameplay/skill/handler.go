@@ -1,5 +1,67 @@
 package skill
 
-//升级
+import (
+	"context"
+	"errors"
+	"sync"
 
-//升阶
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player IPlayer, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerSkillRegister()
+	})
+}
+
+func HandlerSkillRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSSkillLearn, Learn},
+		&Handler{protocol.MessageId_CSSkillUpgrade, Upgrade},
+	)
+}
+
+func Learn(p IPlayer, packet *network.Message) {
+	req := &protocol.CSSkillLearn{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	s := p.GetSkillSystem()
+	if err := s.Learn(context.Background(), req.Id); err != nil {
+		logger.Warn("[skill] learn PlayerID:%v skill:%v err:%v", s.uid, req.Id, err)
+	}
+}
+
+func Upgrade(p IPlayer, packet *network.Message) {
+	req := &protocol.CSSkillUpgrade{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	s := p.GetSkillSystem()
+	if err := s.Upgrade(context.Background(), req.Id); err != nil {
+		logger.Warn("[skill] upgrade PlayerID:%v skill:%v err:%v", s.uid, req.Id, err)
+	}
+}
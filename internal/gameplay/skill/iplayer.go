@@ -1,4 +1,18 @@
 package skill
 
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/bag"
+)
+
 type IPlayer interface {
+	GetSkillSystem() *System
+}
+
+// Owner is the player a System belongs to.
+type Owner interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+	GetBagSystem() *bag.System
+	GetLevel() uint32
 }
@@ -1,38 +1,84 @@
 package skill
 
 import (
+	"sync"
+
 	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
 	"greatestworks/aop/module_router"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/gameplay/buff"
 )
 
 var (
 	Mod         *Module
-	OnceInitMod sync.Once
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var raises = metrics.NewCounterMap[raiseLabels](
+	"skill_raises",
+	"Number of skills learned and upgraded",
 )
 
+type raiseLabels struct {
+	Kind string // "learn" or "upgrade"
+}
+
 func init() {
 	internal.ModuleManager.RegisterModule(module.Module_Skill.String(), GetMod())
 }
 
+// Module serves the skill table (see Skills). The skills learned by the
+// players are in their System; they're cast in the battles (see
+// battle.Instance), which check the cooldowns and the resources.
 type Module struct {
 	*internal.BaseModule
+	initFlag bool
 }
 
 func GetMod() *Module {
-	OnceInitMod.Do(func() {
-		Mod = &Module{
-			BaseModule: internal.NewBaseModule(),
-		}
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
 	})
 	return Mod
 }
 
+// Init sets the file of the skill table, loaded with the other tables (see
+// gamedata.Registry.Load) once the buff table is.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	Skills.SetFile(conf.SkillFile)
+	if conf.SkillFile == "" {
+		logger.Warn("[skill] no skill table")
+	}
+	m.initFlag = true
+	return nil
+}
+
+// Conf returns the config of a skill, or nil if there's no such skill.
+func (m *Module) Conf(id uint32) *Conf {
+	return Skills.Get(id)
+}
+
+// Dependencies returns the modules the skill module depends on: the skills
+// consume the items of the bag, and give the buffs of the buff table.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Bag.String(), buff.ModuleName}
+}
+
 func (m *Module) SetName(name string) {
 	m.BaseModule.SetName(name)
 }
 
+func (m *Module) GetName() string {
+	return module.Module_Skill.String()
+}
+
 func (m *Module) RegisterHandler() {
 	module_router.RegisterModuleMessageHandler(module.Module_Skill, 0, nil)
 }
@@ -1,10 +0,0 @@
-package skill
-
-type Normal struct {
-	Base
-}
-
-func (n *Normal) GetAttack() {
-	//TODO implement me
-	panic("implement me")
-}
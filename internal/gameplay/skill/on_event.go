@@ -5,19 +5,9 @@ import (
 	"greatestworks/internal/note/event"
 )
 
-type EventHandle func(iEvent event.IEvent)
-
-type EventWrap struct {
-	IPlayer
-	event.IEvent
-}
-
+// OnEvent is unused: the skills handle no events.
 func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
-	//TODO implement me
-	panic("implement me")
 }
 
 func (m *Module) SetEventCategoryActive(eventCategory int) {
-	//TODO implement me
-	panic("implement me")
 }
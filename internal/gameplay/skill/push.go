@@ -0,0 +1,15 @@
+package skill
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+// toProto returns the message pushing the levels of some skills of a player
+// to the client.
+func toProto(levels map[uint32]uint32) *protocol.SCSkillUpdate {
+	msg := &protocol.SCSkillUpdate{}
+	for id, lv := range levels {
+		msg.Skills = append(msg.Skills, &protocol.SkillInfo{Id: id, Level: lv})
+	}
+	return msg
+}
@@ -0,0 +1,183 @@
+package skill
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"greatestworks/internal/protocol"
+)
+
+// reason is the reason of the changes of the bags made by the skills.
+const reason = "skill"
+
+var (
+	ErrNoSkill     = errors.New("skill: no such skill")
+	ErrLearned     = errors.New("skill: skill already learned")
+	ErrNotLearned  = errors.New("skill: skill not learned")
+	ErrMaxLevel    = errors.New("skill: skill at its maximum level")
+	ErrPlayerLevel = errors.New("skill: player level too low")
+)
+
+// Learned is a skill learned by a player.
+type Learned struct {
+	Id    uint32 `bson:"id"`
+	Level uint32 `bson:"lv"`
+}
+
+// Data is the stored skills of a player.
+type Data struct {
+	Skills []Learned `bson:"skills"`
+}
+
+// System is the skills learned by a player, with their levels. It's used by
+// the bag lane of the player (see player.moduleOf), by the battle lane,
+// which reads the levels, and by the player goroutine, which saves it, so
+// it's guarded by mu; mu is held across the changes of the bag, so that the
+// materials of a level are never consumed twice.
+type System struct {
+	uid uint64
+	Owner
+	markDirty func()
+
+	mu     sync.Mutex
+	levels map[uint32]uint32 // guarded by mu
+}
+
+func NewSystem() *System {
+	return &System{levels: map[uint32]uint32{}}
+}
+
+// SetOwner sets the player the skills belong to, and how they're marked
+// dirty, and pushes them.
+func (s *System) SetOwner(owner Owner, uid uint64, markDirty func()) {
+	s.mu.Lock()
+	s.Owner = owner
+	s.uid = uid
+	s.markDirty = markDirty
+	s.mu.Unlock()
+	s.changed()
+}
+
+// Save returns a copy of the skills, to be stored.
+func (s *System) Save() (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := Data{Skills: make([]Learned, 0, len(s.levels))}
+	for id, lv := range s.levels {
+		d.Skills = append(d.Skills, Learned{Id: id, Level: lv})
+	}
+	return d, nil
+}
+
+// Load loads stored skills. Skills no longer in the table are dropped, and
+// levels beyond their maximum are lowered.
+func (s *System) Load(raw bson.RawValue) error {
+	var d Data
+	if err := raw.Unmarshal(&d); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.levels = make(map[uint32]uint32, len(d.Skills))
+	for _, l := range d.Skills {
+		conf := GetMod().Conf(l.Id)
+		if conf == nil {
+			continue
+		}
+		if l.Level > conf.MaxLevel() {
+			l.Level = conf.MaxLevel()
+		}
+		s.levels[l.Id] = l.Level
+	}
+	return nil
+}
+
+// Level returns the level of a skill, 0 if it isn't learned.
+func (s *System) Level(id uint32) uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.levels[id]
+}
+
+// Levels returns a copy of the levels of the skills, by skill.
+func (s *System) Levels() map[uint32]uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	levels := make(map[uint32]uint32, len(s.levels))
+	for id, lv := range s.levels {
+		levels[id] = lv
+	}
+	return levels
+}
+
+// Learn learns a skill, at level 1.
+func (s *System) Learn(ctx context.Context, id uint32) error {
+	return s.raise(ctx, id, true)
+}
+
+// Upgrade raises the level of a learned skill by one.
+func (s *System) Upgrade(ctx context.Context, id uint32) error {
+	return s.raise(ctx, id, false)
+}
+
+// raise raises the level of a skill by one, if the player has the level and
+// the materials required by the next level, which are consumed.
+func (s *System) raise(ctx context.Context, id uint32, learn bool) error {
+	conf := GetMod().Conf(id)
+	if conf == nil {
+		return ErrNoSkill
+	}
+	s.mu.Lock()
+	cur, err := s.next(ctx, conf, learn)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	kind := "upgrade"
+	if learn {
+		kind = "learn"
+	}
+	raises.Get(raiseLabels{Kind: kind}).Add(1)
+	s.markDirty()
+	s.SendMsg(protocol.MessageId_SCSkillUpdate, toProto(map[uint32]uint32{conf.Id: cur}))
+	return nil
+}
+
+// next raises the level of a skill by one, and returns it.
+//
+// REQUIRES: s.mu is held.
+func (s *System) next(ctx context.Context, conf *Conf, learn bool) (uint32, error) {
+	cur := s.levels[conf.Id]
+	switch {
+	case learn && cur > 0:
+		return 0, ErrLearned
+	case !learn && cur == 0:
+		return 0, ErrNotLearned
+	case cur >= conf.MaxLevel():
+		return 0, ErrMaxLevel
+	}
+	next := conf.Level(cur + 1)
+	if s.GetLevel() < next.PlayerLevel {
+		return 0, ErrPlayerLevel
+	}
+	if len(next.Materials) > 0 {
+		if _, err := s.GetBagSystem().Consume(ctx, reason, next.Materials); err != nil {
+			return 0, err
+		}
+	}
+	s.levels[conf.Id] = cur + 1
+	return cur + 1, nil
+}
+
+// changed pushes all the skills.
+func (s *System) changed() {
+	s.mu.Lock()
+	owner := s.Owner
+	s.mu.Unlock()
+	if owner == nil {
+		return
+	}
+	owner.SendMsg(protocol.MessageId_SCSkillUpdate, toProto(s.Levels()))
+}
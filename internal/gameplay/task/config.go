@@ -1,8 +1,12 @@
 package task
 
 import (
+	"context"
+	"time"
+
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"github.com/phuhao00/network"
+	"greatestworks/internal/gameplay/bag"
 )
 
 type Status int
@@ -14,18 +18,43 @@ const (
 	SUBMIT
 )
 
+// Categories of the quests.
+const (
+	CategoryMainLine = iota + 1 // 主线
+	CategoryDaily               // 日常，每日重置
+	CategoryWeekly              // 周常，每周重置
+)
+
+// Accept and submit types of the quests.
+const (
+	Manual = iota // 手动
+	Auto          // 自动
+)
+
+// Kinds of the targets of the quests, and the events that drive them.
+const (
+	TargetKill    = "kill"    // kill Param monsters (playerevent.Kill), any if Param is 0
+	TargetCollect = "collect" // have Param items in the bag (bagevent.Changed)
+	TargetLevel   = "level"   // reach a level (playerevent.LevelUp)
+	TargetBattle  = "battle"  // win battles of mode Param (battleevent.Finished), any if Param is 0
+)
+
 const (
-	defaultLoopNum     = 50
-	defaultMonitor     = 100
-	defaultChanInSize  = 1000
-	defaultChanOutSize = 500
+	defaultLoopNum       = 50
+	defaultMonitor       = 100
+	defaultChanInSize    = 1000
+	defaultChanOutSize   = 500
+	defaultResetInterval = time.Minute
 )
 
 type ModuleConfig struct {
-	LoopNum    int
-	MonitorNum int
-	ChInSize   int
-	ChOutSize  int
+	LoopNum       int
+	MonitorNum    int
+	ChInSize      int
+	ChOutSize     int
+	QuestFile     string        // path of the quest table, a JSON array or a CSV file of Config (see gamedata)
+	ResetHour     int           // hour of the day (local time) the daily and weekly (on Mondays) quests reset
+	ResetInterval time.Duration // how often the quests of the online players are checked for resets, defaults to defaultResetInterval
 }
 
 type Config struct {
@@ -39,6 +68,10 @@ type Config struct {
 	CompleteNtf     int           `json:"completeNtf"` //完成是否推送
 	UnlockCondition int           `json:"unlockCondition"`
 	Module          string        `json:"module.proto"`
+	Level           uint32        `json:"level"`   // 解锁等级
+	PreId           uint32        `json:"preId"`   // 前置任务，提交后解锁
+	NextId          uint32        `json:"nextId"`  // 后续任务，提交后自动接取
+	Rewards         []bag.Stack   `json:"rewards"` // 奖励
 }
 
 type TargetConf struct {
@@ -46,9 +79,13 @@ type TargetConf struct {
 	DropId        uint32
 	Name          string
 	CompleteParam string
+	Kind          string `json:"kind"`  // 目标类型，见 TargetKill 等
+	Param         uint32 `json:"param"` // 怪物id或道具id
+	Count         int64  `json:"count"` // 目标数量
 }
 
 type PlayerActionParam struct {
+	Ctx       context.Context // carries the trace context of the client request
 	MessageId messageId.MessageId
 	Player    Player
 	Packet    *network.Message
@@ -2,11 +2,55 @@ package task
 
 import (
 	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"greatestworks/internal/protocol"
 )
 
+// Quest is a quest accepted by a player.
+type Quest struct {
+	Id       uint32  `bson:"id"`   // 配置id
+	Status   Status  `bson:"st"`   // 状态
+	Progress []int64 `bson:"prog"` // 各目标进度
+	AcceptTM int64   `bson:"atm"`  // 接取时间
+}
+
+// done returns whether every target of the quest is reached.
+func (q *Quest) done(conf *Config) bool {
+	for i, t := range conf.Targets {
+		if q.Progress[i] < t.Count {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *Quest) clone() Quest {
+	c := *q
+	c.Progress = append([]int64(nil), q.Progress...)
+	return c
+}
+
+// Doc 对应DB -> mongo
+type Doc struct {
+	Quests   []Quest  `bson:"quests"` // 已接取的任务
+	Done     []uint32 `bson:"done"`   // 已提交的主线任务
+	DailyTM  int64    `bson:"dtm"`    // 上次日重置时间
+	WeeklyTM int64    `bson:"wtm"`    // 上次周重置时间
+}
+
+// Data is the quests of a player. They progress on the goroutines of the
+// event bus and of the module, so they're guarded by mu.
 type Data struct {
-	Tasks        sync.Map
-	Achievements sync.Map
+	uid       uint64
+	player    Player
+	markDirty func()
+
+	mu       sync.Mutex
+	quests   map[uint32]*Quest // accepted quests, by config id; guarded by mu
+	done     map[uint32]bool   // submitted main line quests; guarded by mu
+	dailyTM  int64             // guarded by mu
+	weeklyTM int64             // guarded by mu
 }
 
 type GroupKey struct {
@@ -16,27 +60,72 @@ type GroupKey struct {
 
 func NewTaskData() *Data {
 	return &Data{
-		Tasks:        sync.Map{},
-		Achievements: sync.Map{},
+		quests: map[uint32]*Quest{},
+		done:   map[uint32]bool{},
 	}
 }
 
-func (d *Data) ToDB() {
-
+// SetOwner sets the player the quests belong to, and how they're marked
+// dirty.
+func (d *Data) SetOwner(player Player, uid uint64, markDirty func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.player = player
+	d.uid = uid
+	d.markDirty = markDirty
 }
 
-func (d *Data) LoadFromDB() {
-
+// Save returns a copy of the quests, to be stored.
+func (d *Data) Save() (interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	doc := Doc{Quests: []Quest{}, Done: []uint32{}, DailyTM: d.dailyTM, WeeklyTM: d.weeklyTM}
+	for _, q := range d.quests {
+		doc.Quests = append(doc.Quests, q.clone())
+	}
+	for id := range d.done {
+		doc.Done = append(doc.Done, id)
+	}
+	return doc, nil
 }
 
-func (d *Data) GetTaskGroup(moduleName string, category int) []ITask {
-	value, ok := d.Tasks.Load(GroupKey{ModuleName: moduleName, Category: category})
-	if ok {
-		return value.([]ITask)
+// Load loads stored quests. The quests whose config is gone are dropped.
+func (d *Data) Load(raw bson.RawValue) error {
+	var doc Doc
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.quests = make(map[uint32]*Quest, len(doc.Quests))
+	for i := range doc.Quests {
+		q := &doc.Quests[i]
+		conf := GetMod().getTaskConfig(q.Id)
+		if conf == nil {
+			continue
+		}
+		// Targets may have been added since the quest was accepted.
+		for len(q.Progress) < len(conf.Targets) {
+			q.Progress = append(q.Progress, 0)
+		}
+		d.quests[q.Id] = q
 	}
+	d.done = make(map[uint32]bool, len(doc.Done))
+	for _, id := range doc.Done {
+		d.done[id] = true
+	}
+	d.dailyTM = doc.DailyTM
+	d.weeklyTM = doc.WeeklyTM
 	return nil
 }
 
+// SyncAllTasks pushes all the accepted quests to the client.
 func (d *Data) SyncAllTasks(player Player) {
-	//todo
+	d.mu.Lock()
+	quests := make([]Quest, 0, len(d.quests))
+	for _, q := range d.quests {
+		quests = append(quests, q.clone())
+	}
+	d.mu.Unlock()
+	player.SendMsg(protocol.MessageId_SCTaskList, listToProto(quests))
 }
@@ -1,9 +1,13 @@
 package task
 
 import (
+	"context"
 	"errors"
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
 	"sync"
 )
 
@@ -20,6 +24,9 @@ var (
 )
 
 func IsBelongToHere(id messageId.MessageId) bool {
+	if _, err := GetHandler(id); err == nil {
+		return true
+	}
 	return id > MinMessageId && id < MaxMessageId
 }
 
@@ -39,20 +46,38 @@ func init() {
 }
 
 func HandlerFriendRegister() {
-	handlers[0] = &Handler{
-		0,
-		AcceptTask,
-	}
-	handlers[1] = &Handler{
-		0,
-		Submit,
-	}
+	handlers = append(handlers,
+		&Handler{
+			protocol.MessageId_CSAcceptTask,
+			AcceptTask,
+		},
+		&Handler{
+			protocol.MessageId_CSSubmitTask,
+			Submit,
+		},
+	)
 }
 
 // AcceptTask accept task_category_group
-func AcceptTask(player Player, packet *network.Message) {
+func AcceptTask(p Player, packet *network.Message) {
+	req := &protocol.CSAcceptTask{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	d := p.GetTaskData()
+	if err := GetMod().Accept(context.Background(), d, req.Id); err != nil {
+		logger.Warn("[task] accept quest %v PlayerID:%v err:%v", req.Id, d.uid, err)
+	}
 }
 
 // Submit submit task_category_group
-func Submit(player Player, packet *network.Message) {
+func Submit(p Player, packet *network.Message) {
+	req := &protocol.CSSubmitTask{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	d := p.GetTaskData()
+	if err := GetMod().Submit(context.Background(), d, req.Id); err != nil {
+		logger.Warn("[task] submit quest %v PlayerID:%v err:%v", req.Id, d.uid, err)
+	}
 }
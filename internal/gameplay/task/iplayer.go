@@ -3,9 +3,12 @@ package task
 import (
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/bag"
 )
 
 type Player interface {
 	SendMsg(ID messageId.MessageId, message proto.Message)
 	GetTaskData() *Data
+	GetBagSystem() *bag.System
+	GetLevel() uint32
 }
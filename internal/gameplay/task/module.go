@@ -1,11 +1,19 @@
 package task
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/gamedata"
+	"greatestworks/aop/logger"
 	"greatestworks/aop/module_router"
+	"greatestworks/aop/msgtrace"
 	"greatestworks/internal"
+	"greatestworks/internal/gameplay/bag"
 	"greatestworks/internal/note/event"
-	"sync"
 )
 
 var (
@@ -18,53 +26,133 @@ func init() {
 	internal.ModuleManager.RegisterModule(module.Module_Task.String(), GetMod())
 }
 
+// Module handles the quest messages of the players on its monitor
+// goroutines, and advances their quests on the events of the other modules
+// (see subscribe).
 type Module struct {
-	configs      sync.Map
-	ChIn         chan *PlayerActionParam
-	ChOut        chan interface{}
-	ChEvent      chan *EventWrap
-	LoopNum      int
-	MonitorNum   int
-	events       sync.Map
-	eventHandles map[event.IEvent]EventHandle
+	ChIn          chan *PlayerActionParam
+	ChOut         chan interface{}
+	ChEvent       chan *EventWrap
+	LoopNum       int
+	MonitorNum    int
+	events        sync.Map
+	eventHandles  map[event.IEvent]EventHandle
+	initFlag      bool
+	resetHour     int
+	resetInterval time.Duration
+	stopCh        chan struct{}
 	*internal.BaseModule
+
+	mu     sync.Mutex
+	online map[uint64]*Data // quests of the players online on this server; guarded by mu
 }
 
 func GetMod() *Module {
-	onceInit.Do(func() {
-		Mod = NewModule(ModuleConf)
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
 	})
 	return Mod
 }
 
-func NewModule(conf *ModuleConfig) *Module {
-	var (
-		loopNum    int
-		monitorNum int
-		chOutSize  int
-		chInSize   int
-	)
-	if conf.LoopNum == 0 {
-		loopNum = defaultLoopNum
+// Init sets the file of the quest table, loaded with the other tables (see
+// gamedata.Registry.Load), and sizes the monitors.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.LoopNum = conf.LoopNum
+	if m.LoopNum == 0 {
+		m.LoopNum = defaultLoopNum
 	}
-	if conf.MonitorNum == 0 {
-		monitorNum = defaultMonitor
+	m.MonitorNum = conf.MonitorNum
+	if m.MonitorNum == 0 {
+		m.MonitorNum = defaultMonitor
 	}
-	if conf.ChInSize == 0 {
+	chInSize := conf.ChInSize
+	if chInSize == 0 {
 		chInSize = defaultChanInSize
 	}
-	if conf.ChOutSize == 0 {
+	chOutSize := conf.ChOutSize
+	if chOutSize == 0 {
 		chOutSize = defaultChanOutSize
 	}
+	m.ChIn = make(chan *PlayerActionParam, chInSize)
+	m.ChOut = make(chan interface{}, chOutSize)
+	m.ChEvent = make(chan *EventWrap, chInSize)
+	m.resetHour = conf.ResetHour
+	m.resetInterval = conf.ResetInterval
+	if m.resetInterval <= 0 {
+		m.resetInterval = defaultResetInterval
+	}
+	Quests.SetFile(conf.QuestFile)
+	if conf.QuestFile == "" {
+		logger.Warn("[task] no quest table")
+	}
+	m.online = make(map[uint64]*Data)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// Quests is the quest table, by quest id (see ModuleConfig.QuestFile).
+var Quests = gamedata.Register(gamedata.Default, "quest", func(c *Config) uint32 { return c.Id }, checkQuests)
+
+// checkQuests checks the targets of the quests, the quests they follow, and
+// their items in the item table.
+func checkQuests(s *gamedata.Snapshot, t *gamedata.Table[uint32, Config]) error {
+	items := bag.Items.In(s)
+	var err error
+	t.Range(func(id uint32, row *Config) bool {
+		for _, tc := range row.Targets {
+			switch tc.Kind {
+			case TargetKill, TargetLevel, TargetBattle:
+			case TargetCollect:
+				if items.Get(tc.Param) == nil {
+					err = fmt.Errorf("quest %d: collect target of unknown item %d", id, tc.Param)
+					return false
+				}
+			default:
+				err = fmt.Errorf("quest %d: unknown target kind %q", id, tc.Kind)
+				return false
+			}
+		}
+		if row.PreId != 0 && t.Get(row.PreId) == nil {
+			err = fmt.Errorf("quest %d: unknown previous quest %d", id, row.PreId)
+			return false
+		}
+		if row.NextId != 0 && t.Get(row.NextId) == nil {
+			err = fmt.Errorf("quest %d: unknown next quest %d", id, row.NextId)
+			return false
+		}
+		for _, r := range row.Rewards {
+			if items.Get(r.Id) == nil {
+				err = fmt.Errorf("quest %d: unknown reward item %d", id, r.Id)
+				return false
+			}
+		}
+		return true
+	})
+	return err
+}
 
-	Mod = &Module{
-		configs:    sync.Map{},
-		ChIn:       make(chan *PlayerActionParam, chInSize),
-		ChOut:      make(chan interface{}, chOutSize),
-		LoopNum:    loopNum,
-		MonitorNum: monitorNum,
+// OnStart starts the monitors, and the resets of the daily and weekly
+// quests.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
 	}
-	return Mod
+	m.subscribe()
+	m.Run()
+	go m.runReset()
+}
+
+func (m *Module) OnStop() {
+	if !m.initFlag {
+		return
+	}
+	m.unsubscribe()
+	close(m.stopCh)
 }
 
 func (m *Module) SetName(name string) {
@@ -86,7 +174,8 @@ func (m *Module) Loop() {
 	for {
 		select {
 		case <-m.ChOut:
-
+		case <-m.stopCh:
+			return
 		}
 	}
 }
@@ -98,30 +187,71 @@ func (m *Module) Monitor() {
 			m.Handle(p)
 		case e := <-m.ChEvent:
 			m.OnEvent(nil, e)
+		case <-m.stopCh:
+			return
 		}
 	}
 }
 
 func (m *Module) Handle(param *PlayerActionParam) {
+	ctx := param.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, span := msgtrace.Start(ctx, "task", uint64(param.MessageId))
+	defer span.End()
 	handler, err := GetHandler(param.MessageId)
 	if err != nil {
-		//todo log
+		logger.Warn("[task] no handler of msg:%v", param.MessageId)
+		return
 	}
 	handler.Fn(param.Player, param.Packet)
 }
 
+// Online registers the quests of a player that logged in to this server,
+// resets them if due, and pushes them to the client.
+func (m *Module) Online(ctx context.Context, d *Data) {
+	m.mu.Lock()
+	m.online[d.uid] = d
+	m.mu.Unlock()
+	m.refresh(ctx, d)
+	d.SyncAllTasks(d.player)
+}
+
+// Offline unregisters the quests of a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
+}
+
+// dataOf returns the quests of a player online on this server, or nil.
+func (m *Module) dataOf(uid uint64) *Data {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.online[uid]
+}
+
+// onlineData returns the quests of the players online on this server.
+func (m *Module) onlineData() []*Data {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := make([]*Data, 0, len(m.online))
+	for _, d := range m.online {
+		all = append(all, d)
+	}
+	return all
+}
+
 // getTaskConfig get task_category_group config
-func (m *Module) getTaskConfig(confId uint32) (ret *Config) {
-	m.configs.Range(func(key, value any) bool {
-		if val, ok := value.(*Config); ok {
-			if val.Id == confId {
-				ret = val
-				return false
-			}
-		}
-		return true
-	})
-	return ret
+func (m *Module) getTaskConfig(confId uint32) *Config {
+	return Quests.Get(confId)
+}
+
+// Dependencies returns the modules the task module depends on: the rewards
+// go to the bags.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Bag.String()}
 }
 
 func (m *Module) GetName() string {
@@ -1,7 +1,14 @@
 package task
 
 import (
+	"context"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/internal"
 	"greatestworks/internal/note/event"
+	"greatestworks/internal/note/event/bagevent"
+	"greatestworks/internal/note/event/battleevent"
+	"greatestworks/internal/note/event/playerevent"
 )
 
 type EventHandle func(iEvent event.IEvent)
@@ -10,3 +17,53 @@ type EventWrap struct {
 	Player
 	event.IEvent
 }
+
+// OnEvent is a no-op: the quests progress on the events the module
+// subscribes to on the event bus (see subscribe).
+func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
+}
+
+// subscribe subscribes the module to the events that drive the targets of
+// the quests.
+func (m *Module) subscribe() {
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e playerevent.Kill) {
+		if e.IsPlayer {
+			return
+		}
+		if d := m.dataOf(e.PlayerId); d != nil {
+			m.progress(context.Background(), d, TargetKill, uint32(e.TargetId), 1, false)
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e bagevent.Changed) {
+		d := m.dataOf(e.PlayerId)
+		if d == nil {
+			return
+		}
+		for _, ch := range e.Changes {
+			m.progress(context.Background(), d, TargetCollect, ch.Id, ch.Num, true)
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e playerevent.LevelUp) {
+		d := m.dataOf(e.PlayerId)
+		if d == nil {
+			return
+		}
+		m.progress(context.Background(), d, TargetLevel, 0, int64(e.Level), true)
+		// A new level may unlock quests.
+		m.refresh(context.Background(), d)
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e battleevent.Finished) {
+		if !e.Won {
+			return
+		}
+		if d := m.dataOf(e.PlayerId); d != nil {
+			m.progress(context.Background(), d, TargetBattle, e.Mode, 1, false)
+		}
+	})
+}
+
+// unsubscribe cancels the subscriptions of the module, once the events
+// already received are handled.
+func (m *Module) unsubscribe() {
+	eventbus.Default.UnsubscribeModule(m.GetName())
+}
@@ -0,0 +1,27 @@
+package task
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+// toProto returns a quest, as pushed to the client. A quest whose status is
+// 0 was dropped (e.g., reset).
+func toProto(q *Quest) *protocol.TaskInfo {
+	return &protocol.TaskInfo{
+		Id:       q.Id,
+		Status:   int32(q.Status),
+		Progress: q.Progress,
+	}
+}
+
+func updateToProto(q *Quest) *protocol.SCTaskUpdate {
+	return &protocol.SCTaskUpdate{Task: toProto(q)}
+}
+
+func listToProto(quests []Quest) *protocol.SCTaskList {
+	msg := &protocol.SCTaskList{}
+	for i := range quests {
+		msg.Tasks = append(msg.Tasks, toProto(&quests[i]))
+	}
+	return msg
+}
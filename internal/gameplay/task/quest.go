@@ -0,0 +1,255 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/note/event/taskevent"
+	"greatestworks/internal/protocol"
+)
+
+// reasonQuest is the reason of the changes of the bags made by the quests.
+const reasonQuest = "quest"
+
+var (
+	ErrNoQuest     = errors.New("task: no such quest")
+	ErrLocked      = errors.New("task: quest locked")
+	ErrAccepted    = errors.New("task: quest already accepted")
+	ErrNotFinished = errors.New("task: quest not finished")
+)
+
+// unlocked returns whether a player may accept a quest.
+//
+// REQUIRES: d.mu is held.
+func (d *Data) unlocked(conf *Config) bool {
+	if d.player.GetLevel() < conf.Level {
+		return false
+	}
+	return conf.PreId == 0 || d.done[conf.PreId]
+}
+
+// accept accepts a quest. The targets that count what the player has (e.g.,
+// items) start from what it has now.
+//
+// REQUIRES: d.mu is held.
+func (d *Data) accept(conf *Config, now time.Time) (*Quest, error) {
+	switch {
+	case d.quests[conf.Id] != nil || d.done[conf.Id]:
+		return nil, ErrAccepted
+	case !d.unlocked(conf):
+		return nil, ErrLocked
+	}
+	q := &Quest{
+		Id:       conf.Id,
+		Status:   ING,
+		Progress: make([]int64, len(conf.Targets)),
+		AcceptTM: now.Unix(),
+	}
+	for i, t := range conf.Targets {
+		switch t.Kind {
+		case TargetCollect:
+			q.Progress[i] = d.player.GetBagSystem().Count(t.Param)
+		case TargetLevel:
+			q.Progress[i] = int64(d.player.GetLevel())
+		}
+	}
+	if q.done(conf) {
+		q.Status = FINISH
+	}
+	d.quests[conf.Id] = q
+	return q, nil
+}
+
+// submit gives the rewards of a finished quest. Main line quests are done
+// for good; daily and weekly quests stay submitted until they reset.
+//
+// REQUIRES: d.mu is held.
+func (d *Data) submit(ctx context.Context, conf *Config) (*Quest, error) {
+	q := d.quests[conf.Id]
+	switch {
+	case q == nil:
+		return nil, ErrNoQuest
+	case q.Status != FINISH:
+		return nil, ErrNotFinished
+	}
+	if len(conf.Rewards) > 0 {
+		if _, err := d.player.GetBagSystem().Grant(ctx, reasonQuest, conf.Rewards); err != nil {
+			return nil, err
+		}
+	}
+	q.Status = SUBMIT
+	if conf.Category != CategoryDaily && conf.Category != CategoryWeekly {
+		delete(d.quests, conf.Id)
+		d.done[conf.Id] = true
+	}
+	return q, nil
+}
+
+// Accept accepts a quest for a player.
+func (m *Module) Accept(ctx context.Context, d *Data, id uint32) error {
+	conf := m.getTaskConfig(id)
+	if conf == nil {
+		return ErrNoQuest
+	}
+	d.mu.Lock()
+	q, err := d.accept(conf, time.Now())
+	var changed []Quest
+	if err == nil {
+		changed = append(changed, q.clone())
+		changed = append(changed, m.settle(ctx, d)...)
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	m.changed(d, changed)
+	return nil
+}
+
+// Submit gives the rewards of a finished quest of a player.
+func (m *Module) Submit(ctx context.Context, d *Data, id uint32) error {
+	conf := m.getTaskConfig(id)
+	if conf == nil {
+		return ErrNoQuest
+	}
+	d.mu.Lock()
+	q, err := d.submit(ctx, conf)
+	var changed []Quest
+	if err == nil {
+		changed = append(changed, q.clone())
+		changed = append(changed, m.settle(ctx, d)...)
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	m.changed(d, changed)
+	eventbus.Publish(eventbus.Default, taskevent.Completed{PlayerId: d.uid, QuestId: conf.Id, Category: conf.Category})
+	return nil
+}
+
+// progress advances the targets of a kind of the quests of a player: by
+// delta, or to value if absolute (e.g., the amount of an item in the bag).
+// param selects the targets (e.g., a monster id); targets whose Param is 0
+// match any.
+func (m *Module) progress(ctx context.Context, d *Data, kind string, param uint32, value int64, absolute bool) {
+	var changed []Quest
+	d.mu.Lock()
+	if d.player == nil {
+		d.mu.Unlock()
+		return
+	}
+	for _, q := range d.quests {
+		if q.Status != ING {
+			continue
+		}
+		conf := m.getTaskConfig(q.Id)
+		if conf == nil {
+			continue
+		}
+		updated := false
+		for i, t := range conf.Targets {
+			if t.Kind != kind || t.Param != 0 && t.Param != param {
+				continue
+			}
+			old := q.Progress[i]
+			if absolute {
+				q.Progress[i] = value
+			} else {
+				q.Progress[i] += value
+			}
+			updated = updated || q.Progress[i] != old
+		}
+		if !updated {
+			continue
+		}
+		if q.done(conf) {
+			q.Status = FINISH
+		}
+		changed = append(changed, q.clone())
+	}
+	if len(changed) > 0 {
+		changed = append(changed, m.settle(ctx, d)...)
+	}
+	d.mu.Unlock()
+	m.changed(d, changed)
+}
+
+// settle submits the finished quests that submit automatically, and accepts
+// the unlocked quests that are accepted automatically, including the next
+// quests of the submitted ones, until there's nothing left to do. It returns
+// the quests changed.
+//
+// REQUIRES: d.mu is held.
+func (m *Module) settle(ctx context.Context, d *Data) []Quest {
+	var changed []Quest
+	for more := true; more; {
+		more = false
+		for _, q := range d.quests {
+			conf := m.getTaskConfig(q.Id)
+			if conf == nil || q.Status != FINISH || conf.SubmitType != Auto {
+				continue
+			}
+			if _, err := d.submit(ctx, conf); err != nil {
+				logger.Warn("[task] auto submit quest %v of PlayerID:%v err:%v", q.Id, d.uid, err)
+				continue
+			}
+			changed = append(changed, q.clone())
+			more = true
+		}
+		Quests.Table().Range(func(_ uint32, conf *Config) bool {
+			if d.quests[conf.Id] != nil || d.done[conf.Id] {
+				return true
+			}
+			if conf.AcceptType != Auto && !m.isNext(d, conf.Id) {
+				return true
+			}
+			q, err := d.accept(conf, time.Now())
+			if err != nil {
+				return true
+			}
+			changed = append(changed, q.clone())
+			more = true
+			return true
+		})
+	}
+	return changed
+}
+
+// isNext returns whether a quest is the next quest (see Config.NextId) of a
+// quest the player submitted.
+//
+// REQUIRES: d.mu is held.
+func (m *Module) isNext(d *Data, id uint32) bool {
+	for pre := range d.done {
+		if conf := m.getTaskConfig(pre); conf != nil && conf.NextId == id {
+			return true
+		}
+	}
+	for _, q := range d.quests {
+		if q.Status != SUBMIT {
+			continue
+		}
+		if conf := m.getTaskConfig(q.Id); conf != nil && conf.NextId == id {
+			return true
+		}
+	}
+	return false
+}
+
+// changed marks the quests of a player dirty, and pushes the quests changed
+// to the client.
+func (m *Module) changed(d *Data, quests []Quest) {
+	if len(quests) == 0 {
+		return
+	}
+	if d.markDirty != nil {
+		d.markDirty()
+	}
+	for i := range quests {
+		d.player.SendMsg(protocol.MessageId_SCTaskUpdate, updateToProto(&quests[i]))
+	}
+}
@@ -0,0 +1,70 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"greatestworks/aop/fn"
+)
+
+// reset drops the daily and weekly quests of a player accepted before the
+// last resets, so that they can be accepted again, and returns the quests
+// dropped.
+//
+// REQUIRES: d.mu is held.
+func (m *Module) reset(d *Data, now time.Time) []Quest {
+	daily := fn.LastDailyReset(now, m.resetHour).Unix()
+	weekly := fn.LastWeeklyReset(now, m.resetHour).Unix()
+	if d.dailyTM >= daily && d.weeklyTM >= weekly {
+		return nil
+	}
+	var dropped []Quest
+	for id, q := range d.quests {
+		conf := m.getTaskConfig(id)
+		if conf == nil {
+			continue
+		}
+		if conf.Category == CategoryDaily && d.dailyTM < daily || conf.Category == CategoryWeekly && d.weeklyTM < weekly {
+			q.Status = 0
+			dropped = append(dropped, q.clone())
+			delete(d.quests, id)
+		}
+	}
+	d.dailyTM = now.Unix()
+	if d.weeklyTM < weekly {
+		d.weeklyTM = now.Unix()
+	}
+	return dropped
+}
+
+// refresh resets the quests of a player if due, and accepts the quests that
+// are accepted automatically (e.g., the daily quests again, or the quests
+// unlocked by a new level).
+func (m *Module) refresh(ctx context.Context, d *Data) {
+	d.mu.Lock()
+	if d.player == nil {
+		d.mu.Unlock()
+		return
+	}
+	changed := m.reset(d, time.Now())
+	changed = append(changed, m.settle(ctx, d)...)
+	d.mu.Unlock()
+	m.changed(d, changed)
+}
+
+// runReset refreshes the quests of the online players every
+// ModuleConfig.ResetInterval, until the module is stopped.
+func (m *Module) runReset() {
+	ticker := time.NewTicker(m.resetInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, d := range m.onlineData() {
+				m.refresh(context.Background(), d)
+			}
+		case <-m.stopCh:
+			return
+		}
+	}
+}
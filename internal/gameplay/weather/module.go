@@ -5,18 +5,29 @@ import (
 	"greatestworks/internal"
 )
 
+const (
+	ModuleName = "weather"
+)
+
 var (
 	Mod *Module
 )
 
 func init() {
-	internal.ModuleManager.RegisterModule("", Mod)
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
 }
 
 type Module struct {
 	*internal.BaseModule
 }
 
+func GetMod() *Module {
+	if Mod == nil {
+		Mod = &Module{internal.NewBaseModule()}
+	}
+	return Mod
+}
+
 func (m *Module) RegisterHandler() {
 	module_router.RegisterModuleMessageHandler(0, 0, nil)
 }
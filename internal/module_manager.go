@@ -1,13 +1,101 @@
 package internal
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"greatestworks/aop/tick"
+)
 
 var (
 	ModuleManager ManagerOfModule
 )
 
+// ModuleInitializer is implemented by modules that must be initialized
+// before they start. Init is called once, after the modules they depend on
+// are initialized.
+type ModuleInitializer interface {
+	Init() error
+}
+
+// ModuleDependent is implemented by modules that depend on other modules.
+// Modules are initialized and started after the modules they depend on, and
+// stopped before them.
+type ModuleDependent interface {
+	Dependencies() []string // names of the modules
+}
+
+// ModuleHealthChecker is implemented by modules that can report whether they
+// are healthy once started.
+type ModuleHealthChecker interface {
+	CheckHealth() error
+}
+
+// ModuleTicker is implemented by modules with periodic work: Tick is called
+// at every frame of tick.Default while the module is started, from the
+// goroutine of the scheduler.
+type ModuleTicker interface {
+	Tick(f tick.Frame)
+	TickPriority() tick.Priority
+}
+
+// ModuleState is the lifecycle state of a module.
+type ModuleState int
+
+const (
+	ModuleRegistered ModuleState = iota
+	ModuleInitialized
+	ModuleStarted
+	ModuleStopped
+	ModuleFailed
+)
+
+func (s ModuleState) String() string {
+	switch s {
+	case ModuleRegistered:
+		return "registered"
+	case ModuleInitialized:
+		return "initialized"
+	case ModuleStarted:
+		return "started"
+	case ModuleStopped:
+		return "stopped"
+	case ModuleFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("ModuleState(%d)", int(s))
+	}
+}
+
+// ModuleHealth is the health of a module.
+type ModuleHealth struct {
+	Name  string    `json:"name"`
+	State string    `json:"state"`
+	Error string    `json:"error,omitempty"` // why the module failed or is unhealthy
+	Since time.Time `json:"since"`           // when the module entered State
+}
+
+type moduleStatus struct {
+	state ModuleState
+	err   error
+	since time.Time
+}
+
+// ManagerOfModule manages the lifecycle of the modules: modules register
+// themselves at init time, and the manager initializes, starts, and stops
+// them in dependency order. Init, OnStart, and OnStop are called in this
+// order, by a single goroutine.
 type ManagerOfModule struct {
-	moduleName2Module map[string]IModule
+	moduleName2Module map[string]IModule // written at init time only
+	order             []string           // dependencies first; set by Init
+
+	mu     sync.Mutex
+	status map[string]*moduleStatus // guarded by mu
+	ticks  map[string]func()        // cancel the callbacks of the tickers; guarded by mu
 }
 
 func (m *ManagerOfModule) GetModule(name string) IModule {
@@ -15,18 +103,247 @@ func (m *ManagerOfModule) GetModule(name string) IModule {
 }
 
 func (m *ManagerOfModule) RegisterModule(moduleName string, module IModule) {
+	if moduleName == "" {
+		panic(fmt.Sprintf("register module.proto with empty name: %T", module))
+	}
+	if module == nil || reflect.ValueOf(module).Kind() == reflect.Ptr && reflect.ValueOf(module).IsNil() {
+		panic(fmt.Sprintf("register nil module.proto :%v", moduleName))
+	}
 	if _, exist := m.moduleName2Module[moduleName]; exist {
 		panic(fmt.Sprintf("repeat register module.proto :%v", moduleName))
 	}
+	if m.moduleName2Module == nil {
+		m.moduleName2Module = map[string]IModule{}
+	}
 	m.moduleName2Module[moduleName] = module
+	m.setState(moduleName, ModuleRegistered, nil)
 }
 
-func (m *ManagerOfModule) OnStart() {
-	for _, module := range m.moduleName2Module {
-		module.RegisterHandler()
+// Init orders the modules by their dependencies, and initializes them. It
+// returns an error if a dependency is missing, if the dependencies form a
+// cycle, or if a module fails to initialize, in which case the modules after
+// it aren't initialized.
+func (m *ManagerOfModule) Init() error {
+	order, err := m.sort()
+	if err != nil {
+		return err
+	}
+	m.order = order
+	for _, name := range m.order {
+		if init, ok := m.moduleName2Module[name].(ModuleInitializer); ok {
+			if err := safeCall(init.Init); err != nil {
+				m.setState(name, ModuleFailed, err)
+				return fmt.Errorf("init module %v: %w", name, err)
+			}
+		}
+		m.setState(name, ModuleInitialized, nil)
 	}
+	return nil
 }
 
-func (m *ManagerOfModule) OnStop() {
+// OnStart starts the modules initialized by Init, dependencies first. If a
+// module fails to start, the modules started before it are stopped, and the
+// error is returned.
+func (m *ManagerOfModule) OnStart() error {
+	if m.order == nil && len(m.moduleName2Module) > 0 {
+		return fmt.Errorf("start modules: not initialized")
+	}
+	for i, name := range m.order {
+		module := m.moduleName2Module[name]
+		err := safeCall(func() error {
+			module.RegisterHandler()
+			module.OnStart()
+			return nil
+		})
+		if err != nil {
+			m.setState(name, ModuleFailed, err)
+			m.stop(m.order[:i])
+			return fmt.Errorf("start module %v: %w", name, err)
+		}
+		m.setState(name, ModuleStarted, nil)
+	}
+	for _, name := range m.order {
+		module := m.moduleName2Module[name]
+		if err := safeCall(func() error { module.AfterStart(); return nil }); err != nil {
+			m.setState(name, ModuleFailed, err)
+			m.stop(m.order)
+			return fmt.Errorf("start module %v: %w", name, err)
+		}
+	}
+	for _, name := range m.order {
+		if ticker, ok := m.moduleName2Module[name].(ModuleTicker); ok {
+			cancel := tick.Register(name, ticker.TickPriority(), ticker.Tick)
+			m.mu.Lock()
+			if m.ticks == nil {
+				m.ticks = map[string]func(){}
+			}
+			m.ticks[name] = cancel
+			m.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// OnStop stops the started modules, dependents first. All of them are
+// stopped, even if some fail to stop; the first error is returned.
+func (m *ManagerOfModule) OnStop() error {
+	return m.stop(m.order)
+}
+
+// stop stops the started modules among the provided ones, in reverse order.
+func (m *ManagerOfModule) stop(names []string) error {
+	var first error
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		if m.state(name) != ModuleStarted {
+			continue
+		}
+		m.mu.Lock()
+		if cancel := m.ticks[name]; cancel != nil {
+			cancel()
+			delete(m.ticks, name)
+		}
+		m.mu.Unlock()
+		module := m.moduleName2Module[name]
+		err := safeCall(func() error {
+			module.OnStop()
+			module.AfterStop()
+			return nil
+		})
+		if err != nil {
+			m.setState(name, ModuleFailed, err)
+			if first == nil {
+				first = fmt.Errorf("stop module %v: %w", name, err)
+			}
+			continue
+		}
+		m.setState(name, ModuleStopped, nil)
+	}
+	return first
+}
+
+// Health returns the health of the modules, dependencies first. Started
+// modules that implement ModuleHealthChecker are checked.
+func (m *ManagerOfModule) Health() []ModuleHealth {
+	names := m.order
+	if names == nil {
+		for name := range m.moduleName2Module {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	m.mu.Lock()
+	health := make([]ModuleHealth, 0, len(names))
+	var checkers []ModuleHealthChecker // checkers[i] checks health[i], if not nil
+	for _, name := range names {
+		s := m.status[name]
+		h := ModuleHealth{Name: name, State: s.state.String(), Since: s.since}
+		if s.err != nil {
+			h.Error = s.err.Error()
+		}
+		checker, _ := m.moduleName2Module[name].(ModuleHealthChecker)
+		if s.state != ModuleStarted {
+			checker = nil
+		}
+		health = append(health, h)
+		checkers = append(checkers, checker)
+	}
+	m.mu.Unlock()
+
+	// Check the modules without holding mu, since they may use the manager.
+	for i, checker := range checkers {
+		if checker == nil {
+			continue
+		}
+		if err := safeCall(checker.CheckHealth); err != nil {
+			health[i].Error = err.Error()
+		}
+	}
+	return health
+}
+
+// Healthy returns whether all modules are started and healthy.
+func (m *ManagerOfModule) Healthy() bool {
+	for _, h := range m.Health() {
+		if h.State != ModuleStarted.String() || h.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// sort returns the names of the modules, dependencies first. Modules that
+// don't depend on each other are ordered by name.
+func (m *ManagerOfModule) sort() ([]string, error) {
+	names := make([]string, 0, len(m.moduleName2Module))
+	for name := range m.moduleName2Module {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("module dependency cycle: %v", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+		marks[name] = visiting
+		if dep, ok := m.moduleName2Module[name].(ModuleDependent); ok {
+			deps := append([]string(nil), dep.Dependencies()...)
+			sort.Strings(deps)
+			for _, d := range deps {
+				if _, ok := m.moduleName2Module[d]; !ok {
+					return fmt.Errorf("module %v depends on unregistered module %v", name, d)
+				}
+				if err := visit(d, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		marks[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// state returns the state of a module.
+func (m *ManagerOfModule) state(name string) ModuleState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status[name].state
+}
+
+// setState records the state of a module.
+func (m *ManagerOfModule) setState(name string, state ModuleState, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.status == nil {
+		m.status = map[string]*moduleStatus{}
+	}
+	m.status[name] = &moduleStatus{state: state, err: err, since: time.Now()}
+}
 
+// safeCall calls fn, turning a panic into an error.
+func safeCall(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn()
 }
@@ -0,0 +1,56 @@
+package analytics
+
+import (
+	"fmt"
+	"time"
+)
+
+// ModuleConfig is the config of the analytics module. It must be set before
+// the module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ServerId string            // id of this server, in every event
+	Sink     string            // sink the events are shipped to: Sink* or a registered one; none if empty
+	Dir      string            // directory of the files of SinkFile
+	URL      string            // endpoint of SinkHTTP, or REST proxy of SinkKafka
+	Topic    string            // topic of SinkKafka
+	Headers  map[string]string // headers of the requests of SinkHTTP and SinkKafka
+
+	BatchSize    int           // events shipped at most in a batch, defaults to defaultBatchSize
+	BatchDelay   time.Duration // time an event waits at most for its batch to fill, defaults to defaultBatchDelay
+	BufferSize   int           // events waiting at most for the sink, dropped beyond, defaults to defaultBufferSize
+	MaxAttempts  int           // attempts to ship a batch before it's dropped, defaults to defaultMaxAttempts
+	FlushTimeout time.Duration // time spent at most shipping the waiting events on stop, defaults to defaultFlushTimeout
+}
+
+const (
+	defaultBatchSize    = 500
+	defaultBatchDelay   = 5 * time.Second
+	defaultBufferSize   = 10000
+	defaultMaxAttempts  = 5
+	defaultFlushTimeout = 10 * time.Second
+)
+
+// requestTimeout bounds every attempt to ship a batch.
+const requestTimeout = 10 * time.Second
+
+// newSink returns the built-in sink of the config, nil if it names another.
+func newSink(conf *ModuleConfig) (Sink, error) {
+	switch conf.Sink {
+	case SinkFile:
+		if conf.Dir == "" {
+			return nil, fmt.Errorf("analytics: file sink: no directory")
+		}
+		return NewFileSink(conf.Dir)
+	case SinkHTTP:
+		if conf.URL == "" {
+			return nil, fmt.Errorf("analytics: http sink: no url")
+		}
+		return NewHTTPSink(conf.URL, conf.Headers), nil
+	case SinkKafka:
+		if conf.URL == "" || conf.Topic == "" {
+			return nil, fmt.Errorf("analytics: kafka sink: no url or topic")
+		}
+		return NewKafkaSink(conf.URL, conf.Topic, conf.Headers), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,64 @@
+package analytics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSink appends the events, as JSON lines, to a file per UTC day in a
+// directory (analytics-20060102.log), e.g. for a log shipper to pick up.
+type FileSink struct {
+	dir  string
+	day  string
+	file *os.File
+}
+
+// NewFileSink returns a sink writing to dir, created if it's missing.
+func NewFileSink(dir string) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSink{dir: dir}, nil
+}
+
+// Write implements the Sink interface.
+func (s *FileSink) Write(ctx context.Context, batch []Event) error {
+	b, err := encodeLines(batch)
+	if err != nil {
+		return err
+	}
+	if err := s.rotate(time.Now().UTC()); err != nil {
+		return err
+	}
+	_, err = s.file.Write(b)
+	return err
+}
+
+// rotate opens the file of the day of now, if it isn't open.
+func (s *FileSink) rotate(now time.Time) error {
+	day := now.Format("20060102")
+	if s.file != nil && s.day == day {
+		return nil
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, "analytics-"+day+".log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file, s.day = f, day
+	return nil
+}
+
+// Close implements the Sink interface.
+func (s *FileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,35 @@
+package analytics
+
+import (
+	"context"
+	"net/http"
+)
+
+// HTTPSink posts every batch of events, as JSON lines
+// (application/x-ndjson), to an endpoint of the data team.
+type HTTPSink struct {
+	url     string
+	headers map[string]string // e.g., an authorization header
+	client  http.Client
+}
+
+// NewHTTPSink returns a sink posting to url, with the headers.
+func NewHTTPSink(url string, headers map[string]string) *HTTPSink {
+	return &HTTPSink{url: url, headers: headers}
+}
+
+// Write implements the Sink interface.
+func (s *HTTPSink) Write(ctx context.Context, batch []Event) error {
+	b, err := encodeLines(batch)
+	if err != nil {
+		return err
+	}
+	_, err = post(ctx, &s.client, s.url, "application/x-ndjson", s.headers, b)
+	return err
+}
+
+// Close implements the Sink interface.
+func (s *HTTPSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
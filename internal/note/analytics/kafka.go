@@ -0,0 +1,78 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// KafkaSink produces the events to a Kafka topic through a Kafka REST proxy
+// (the v2 API of the Confluent REST proxy), keyed by player, so that the
+// events of a player stay in order in a partition.
+type KafkaSink struct {
+	url     string // URL + "/topics/" + topic
+	headers map[string]string
+	client  http.Client
+}
+
+// NewKafkaSink returns a sink producing to topic through the REST proxy at
+// proxyURL (e.g., "http://kafka-rest:8082").
+func NewKafkaSink(proxyURL, topic string, headers map[string]string) *KafkaSink {
+	return &KafkaSink{
+		url:     strings.TrimSuffix(proxyURL, "/") + "/topics/" + url.PathEscape(topic),
+		headers: headers,
+	}
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value *Event `json:"value"`
+}
+
+type kafkaRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaReply struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Write implements the Sink interface. If the proxy fails to produce some
+// of the events, the whole batch is retried.
+func (s *KafkaSink) Write(ctx context.Context, batch []Event) error {
+	req := kafkaRequest{Records: make([]kafkaRecord, len(batch))}
+	for i := range batch {
+		req.Records[i] = kafkaRecord{Key: strconv.FormatUint(batch[i].PlayerId, 10), Value: &batch[i]}
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	b, err = post(ctx, &s.client, s.url, "application/vnd.kafka.json.v2+json", s.headers, b)
+	if err != nil {
+		return err
+	}
+	var reply kafkaReply
+	if err := json.Unmarshal(b, &reply); err != nil {
+		return fmt.Errorf("analytics: kafka reply: %w", err)
+	}
+	for _, o := range reply.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("analytics: kafka: %d: %s", *o.ErrorCode, o.Error)
+		}
+	}
+	return nil
+}
+
+// Close implements the Sink interface.
+func (s *KafkaSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
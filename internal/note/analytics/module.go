@@ -0,0 +1,284 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/module_router"
+	"greatestworks/aop/retry"
+	"greatestworks/internal"
+)
+
+const (
+	ModuleName = "analytics"
+)
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	tracked = metrics.NewCounterMap[eventLabels](
+		"analytics_events",
+		"Number of analytics events tracked on this server",
+	)
+	shipped = metrics.NewCounter(
+		"analytics_shipped",
+		"Number of analytics events shipped to the sink",
+	)
+	dropped = metrics.NewCounterMap[dropLabels](
+		"analytics_dropped",
+		"Number of analytics events dropped, by reason",
+	)
+)
+
+type eventLabels struct {
+	Event string
+}
+
+type dropLabels struct {
+	Reason string // "full": the buffer was full; "sink": the sink failed; "stopped": the module was stopped
+}
+
+// backoff is the backoff between two attempts to ship a batch.
+var backoff = retry.Options{BackoffMultiplier: 2, BackoffMinDuration: 100 * time.Millisecond}
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Module tracks the gameplay events of the players (see subscribe) for the
+// data team: it batches them in the background, and ships the batches to a
+// sink (a file, an HTTP endpoint or Kafka), retrying with exponential
+// backoff. Tracking never blocks the gameplay: if the sink can't keep up,
+// the events beyond ModuleConfig.BufferSize are dropped, and counted.
+type Module struct {
+	*internal.BaseModule
+	initFlag bool
+	conf     ModuleConfig
+	stopCh   chan struct{}
+	stopped  chan struct{} // closed when run returns
+	events   chan Event    // buffered events; nil if there's no sink
+
+	mu     sync.Mutex
+	sinks  map[string]Sink      // guarded by mu
+	sink   Sink                 // guarded by mu
+	logins map[uint64]time.Time // login time of the online players; guarded by mu
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{
+			BaseModule: internal.NewBaseModule(),
+			sinks:      map[string]Sink{},
+		}
+	})
+	return Mod
+}
+
+// Init creates the sink of the config.
+func (m *Module) Init() error {
+	if ModuleConf != nil {
+		m.conf = *ModuleConf
+	}
+	if m.conf.BatchSize <= 0 {
+		m.conf.BatchSize = defaultBatchSize
+	}
+	if m.conf.BatchDelay <= 0 {
+		m.conf.BatchDelay = defaultBatchDelay
+	}
+	if m.conf.BufferSize <= 0 {
+		m.conf.BufferSize = defaultBufferSize
+	}
+	if m.conf.MaxAttempts <= 0 {
+		m.conf.MaxAttempts = defaultMaxAttempts
+	}
+	if m.conf.FlushTimeout <= 0 {
+		m.conf.FlushTimeout = defaultFlushTimeout
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sink = m.sinks[m.conf.Sink]
+	if m.sink == nil {
+		sink, err := newSink(&m.conf)
+		if err != nil {
+			return err
+		}
+		m.sink = sink
+	}
+	if m.sink == nil {
+		logger.Warn("[analytics] no sink %q, events aren't tracked", m.conf.Sink)
+	} else {
+		m.events = make(chan Event, m.conf.BufferSize)
+	}
+	m.logins = make(map[uint64]time.Time)
+	m.stopCh = make(chan struct{})
+	m.stopped = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// RegisterSink registers a sink under a name, for ModuleConfig.Sink to
+// select it, e.g. a client of another data pipeline. It must be called
+// before the module is initialized.
+func (m *Module) RegisterSink(name string, s Sink) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sinks[name] = s
+}
+
+// OnStart starts shipping the events.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	if m.events == nil {
+		close(m.stopped)
+		return
+	}
+	m.subscribe()
+	go m.run()
+}
+
+// OnStop ships the buffered events, for at most ModuleConfig.FlushTimeout,
+// and closes the sink.
+func (m *Module) OnStop() {
+	if !m.initFlag {
+		return
+	}
+	m.unsubscribe()
+	close(m.stopCh)
+	timer := time.NewTimer(m.conf.FlushTimeout)
+	defer timer.Stop()
+	select {
+	case <-m.stopped:
+	case <-timer.C:
+		logger.Warn("[analytics] flush timed out")
+		return
+	}
+	if m.sink != nil {
+		if err := m.sink.Close(); err != nil {
+			logger.Error("[analytics] close sink err:%v", err)
+		}
+	}
+}
+
+// Track tracks an event of a player, with the properties of its kind (e.g.,
+// Login for EventLogin). It never blocks: the event is dropped if the buffer
+// is full.
+func (m *Module) Track(uid uint64, name string, props interface{}) error {
+	schema, ok := schemas[name]
+	if !ok {
+		return ErrUnknownEvent
+	}
+	if m.events == nil {
+		return nil
+	}
+	id, err := idgenerator.NextId()
+	if err != nil {
+		return err
+	}
+	e := Event{
+		Id:       strconv.FormatUint(id, 10),
+		Name:     name,
+		Schema:   schema,
+		Server:   m.conf.ServerId,
+		PlayerId: uid,
+		Time:     time.Now().UnixMilli(),
+		Props:    props,
+	}
+	tracked.Get(eventLabels{Event: name}).Add(1)
+	select {
+	case <-m.stopCh:
+		dropped.Get(dropLabels{Reason: "stopped"}).Add(1)
+		return nil
+	default:
+	}
+	select {
+	case m.events <- e:
+	default:
+		dropped.Get(dropLabels{Reason: "full"}).Add(1)
+	}
+	return nil
+}
+
+// run batches the buffered events, and ships the batches, until the module
+// is stopped; then it ships the events left.
+func (m *Module) run() {
+	defer close(m.stopped)
+	var batch []Event
+	var timeout <-chan time.Time // fires when the batch must be shipped
+	for {
+		select {
+		case e := <-m.events:
+			batch = append(batch, e)
+			if len(batch) == 1 {
+				timeout = time.After(m.conf.BatchDelay)
+			}
+			if len(batch) >= m.conf.BatchSize {
+				m.ship(batch)
+				batch, timeout = nil, nil
+			}
+
+		case <-timeout:
+			m.ship(batch)
+			batch, timeout = nil, nil
+
+		case <-m.stopCh:
+			for {
+				select {
+				case e := <-m.events:
+					batch = append(batch, e)
+					if len(batch) >= m.conf.BatchSize {
+						m.ship(batch)
+						batch = nil
+					}
+				default:
+					m.ship(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// ship writes a batch to the sink, retrying with exponential backoff, and
+// drops it after ModuleConfig.MaxAttempts attempts.
+func (m *Module) ship(batch []Event) {
+	if len(batch) == 0 {
+		return
+	}
+	var err error
+	attempts := 0
+	for r := retry.BeginWithOptions(backoff); attempts < m.conf.MaxAttempts && r.Continue(context.Background()); attempts++ {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		err = m.sink.Write(ctx, batch)
+		cancel()
+		if err == nil {
+			shipped.Add(float64(len(batch)))
+			return
+		}
+		if errors.Is(err, ErrRejected) {
+			attempts++
+			break
+		}
+	}
+	dropped.Get(dropLabels{Reason: "sink"}).Add(float64(len(batch)))
+	logger.Error("[analytics] %d events dropped after %d attempts: %v", len(batch), attempts, err)
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
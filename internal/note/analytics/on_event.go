@@ -0,0 +1,73 @@
+package analytics
+
+import (
+	"time"
+
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
+	"greatestworks/internal"
+	"greatestworks/internal/note/event"
+	"greatestworks/internal/note/event/playerevent"
+	"greatestworks/internal/note/event/rechargeevent"
+	"greatestworks/internal/note/event/shopevent"
+	"greatestworks/internal/note/event/taskevent"
+)
+
+// OnEvent is unused: the events are tracked from the event bus instead (see
+// subscribe).
+func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
+}
+
+func (m *Module) SetEventCategoryActive(eventCategory int) {
+}
+
+// subscribe subscribes to the gameplay events tracked.
+func (m *Module) subscribe() {
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e playerevent.EnterGame) {
+		m.mu.Lock()
+		m.logins[e.PlayerId] = time.Now()
+		m.mu.Unlock()
+		m.track(e.PlayerId, EventLogin, Login{Level: e.Level})
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e playerevent.LeaveGame) {
+		var online int64
+		m.mu.Lock()
+		if at, ok := m.logins[e.PlayerId]; ok {
+			online = int64(time.Since(at) / time.Second)
+			delete(m.logins, e.PlayerId)
+		}
+		m.mu.Unlock()
+		m.track(e.PlayerId, EventLogout, Logout{Level: e.Level, Online: online})
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e playerevent.LevelUp) {
+		m.track(e.PlayerId, EventLevelUp, LevelUp{Level: e.Level})
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e shopevent.Purchased) {
+		m.track(e.PlayerId, EventPurchase, Purchase{ShopId: e.ShopId, GoodsId: e.GoodsId, Count: e.Count})
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e rechargeevent.Recharged) {
+		m.track(e.PlayerId, EventRecharge, Recharge{
+			OrderId:   e.OrderId,
+			ProductId: e.ProductId,
+			Money:     e.Money,
+			Price:     e.Price,
+			Sandbox:   e.Sandbox,
+			First:     e.First,
+		})
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e taskevent.Completed) {
+		m.track(e.PlayerId, EventQuestComplete, QuestComplete{QuestId: e.QuestId, Category: e.Category})
+	})
+}
+
+func (m *Module) track(uid uint64, name string, props interface{}) {
+	if err := m.Track(uid, name, props); err != nil {
+		logger.Error("[analytics] track %v PlayerID:%v err:%v", name, uid, err)
+	}
+}
+
+// unsubscribe cancels the subscriptions of the module, once the events
+// already received are handled.
+func (m *Module) unsubscribe() {
+	eventbus.Default.UnsubscribeModule(m.GetName())
+}
@@ -0,0 +1,79 @@
+package analytics
+
+import "errors"
+
+var ErrUnknownEvent = errors.New("analytics: unknown event")
+
+// Names of the events shipped to the data team.
+const (
+	EventLogin         = "login"
+	EventLogout        = "logout"
+	EventLevelUp       = "level_up"
+	EventPurchase      = "purchase"
+	EventRecharge      = "recharge"
+	EventQuestComplete = "quest_complete"
+)
+
+// schemas is the version of the schema of the properties of every event.
+// Adding a property keeps the version; renaming, removing or changing the
+// meaning of one bumps it, so that the data team can tell the old events
+// from the new ones.
+var schemas = map[string]int{
+	EventLogin:         1,
+	EventLogout:        1,
+	EventLevelUp:       1,
+	EventPurchase:      1,
+	EventRecharge:      1,
+	EventQuestComplete: 1,
+}
+
+// Event is an event shipped to the sink, along with the properties of its
+// kind (e.g., Login for EventLogin).
+type Event struct {
+	Id       string      `json:"id"`     // unique, for the sinks that deliver an event more than once
+	Name     string      `json:"event"`  // Event*
+	Schema   int         `json:"schema"` // version of the schema of Props
+	Server   string      `json:"server"`
+	PlayerId uint64      `json:"playerId"`
+	Time     int64       `json:"time"` // unix milliseconds
+	Props    interface{} `json:"props"`
+}
+
+// Login is the properties of EventLogin.
+type Login struct {
+	Level uint32 `json:"level"`
+}
+
+// Logout is the properties of EventLogout.
+type Logout struct {
+	Level  uint32 `json:"level"`
+	Online int64  `json:"online"` // length of the session, seconds; 0 if unknown
+}
+
+// LevelUp is the properties of EventLevelUp.
+type LevelUp struct {
+	Level uint32 `json:"level"`
+}
+
+// Purchase is the properties of EventPurchase: goods bought in a shop.
+type Purchase struct {
+	ShopId  uint32 `json:"shopId"`
+	GoodsId uint32 `json:"goodsId"`
+	Count   int64  `json:"count"`
+}
+
+// Recharge is the properties of EventRecharge.
+type Recharge struct {
+	OrderId   string `json:"orderId"`
+	ProductId uint32 `json:"productId"`
+	Money     int32  `json:"money"` // recharge.MoneyCategory
+	Price     int64  `json:"price"` // cents
+	Sandbox   bool   `json:"sandbox"`
+	First     bool   `json:"first"`
+}
+
+// QuestComplete is the properties of EventQuestComplete.
+type QuestComplete struct {
+	QuestId  uint32 `json:"questId"`
+	Category int    `json:"category"` // task.Category*
+}
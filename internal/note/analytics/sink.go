@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Names of the built-in sinks (see ModuleConfig.Sink).
+const (
+	SinkFile  = "file"
+	SinkHTTP  = "http"
+	SinkKafka = "kafka"
+)
+
+// ErrRejected is returned, wrapped, by a sink that rejected a batch for good
+// (e.g., a malformed request): the batch isn't retried.
+var ErrRejected = errors.New("analytics: batch rejected")
+
+// A Sink ships batches of events to the data team. Its methods are called by
+// the goroutine of the module only. Other sinks can be registered with
+// Module.RegisterSink.
+type Sink interface {
+	// Write ships a batch of events. If it fails, the batch is retried
+	// (unless the error wraps ErrRejected), so the same event may be
+	// shipped more than once: Event.Id tells the copies apart.
+	Write(ctx context.Context, batch []Event) error
+
+	// Close releases the sink, once the last batch is written.
+	Close() error
+}
+
+// encodeLines encodes a batch as JSON lines.
+func encodeLines(batch []Event) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range batch {
+		if err := enc.Encode(&batch[i]); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// post posts a body to url, and returns an error if the reply isn't a 2xx;
+// wrapping ErrRejected if it's a 4xx but 429, that retries wouldn't fix.
+func post(ctx context.Context, client *http.Client, url, contentType string, headers map[string]string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	reply, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode/100 == 2:
+		return reply, nil
+	case resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%w: %s: %s", ErrRejected, resp.Status, reply)
+	default:
+		return nil, fmt.Errorf("analytics: %s: %s", resp.Status, reply)
+	}
+}
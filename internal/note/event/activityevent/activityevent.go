@@ -1 +1,23 @@
 package activityevent
+
+// Opened is published when a round of an activity opens, on every server.
+type Opened struct {
+	ActivityId uint32
+	Round      int64 // start of the round, unix seconds
+	End        int64 // end of the round, unix seconds
+}
+
+// Closed is published when a round of an activity closes, or the activity
+// is disabled, on every server.
+type Closed struct {
+	ActivityId uint32
+	Round      int64
+}
+
+// Joined is published when a player joins a round of an activity.
+type Joined struct {
+	ActivityId uint32
+	Round      int64
+	PlayerId   uint64
+	Joins      int64 // times the player joined the round
+}
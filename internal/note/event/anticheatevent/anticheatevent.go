@@ -0,0 +1,17 @@
+package anticheatevent
+
+// Sanctioned is published on the event bus when the suspicion score of a
+// player reaches a sanction level: flagged for review, muted or kicked.
+type Sanctioned struct {
+	PlayerId uint64
+	Level    int // anticheat.Level*
+	Score    float64
+	FlagId   uint64 // the flag in the review queue
+}
+
+// Reviewed is published on the event bus when a GM reviews a flag.
+type Reviewed struct {
+	FlagId   uint64
+	PlayerId uint64
+	Confirm  bool // whether the GM confirmed the cheat, or cleared the player
+}
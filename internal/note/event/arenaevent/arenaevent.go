@@ -0,0 +1,24 @@
+package arenaevent
+
+// Attacked is published on the event bus of the server a player attacked the
+// defense of another player from, in the arena.
+type Attacked struct {
+	FightId  uint64
+	PlayerId uint64
+	Defender uint64
+	Won      bool
+	Revenge  bool  // whether the defender was on the revenge list of the player
+	Points   int64 // points of the player in the ladder, after the fight
+	Delta    int64 // points won, negative if lost
+}
+
+// Defended is published on the event bus of the home server of a player (see
+// arena.Module.SetDefense), when its defense was attacked in the arena.
+type Defended struct {
+	FightId  uint64
+	PlayerId uint64
+	Attacker uint64
+	Won      bool  // whether the defense held
+	Points   int64 // points of the player in the ladder, after the fight
+	Delta    int64 // points won, negative if lost
+}
@@ -0,0 +1,14 @@
+package auctionevent
+
+// Sold is published on the event bus when a listing of the auction house is
+// sold, by buyout or to the highest bidder when it expires.
+type Sold struct {
+	ListingId uint64
+	Seller    uint64
+	Buyer     uint64
+	ItemId    uint32
+	Num       int64
+	Currency  int32
+	Price     int64
+	Tax       int64 // part of Price kept by the auction house
+}
@@ -1 +1,24 @@
 package bagevent
+
+// ItemChange is the change of the amount of an item in a bag.
+type ItemChange struct {
+	Id    uint32   `json:"id"`
+	Delta int64    `json:"delta"`          // amount added, or removed if negative
+	Num   int64    `json:"num"`            // amount in the bag after the change
+	Uids  []uint64 `json:"uids,omitempty"` // instances of a non stackable item added or removed
+}
+
+// Changed is published on the event bus when items are added to or removed
+// from the bag of a player, once per operation.
+type Changed struct {
+	PlayerId uint64
+	Reason   string // what changed the bag (e.g., "mail", "shop")
+	Changes  []ItemChange
+}
+
+// Expanded is published on the event bus when the bag of a player is
+// expanded.
+type Expanded struct {
+	PlayerId uint64
+	Capacity int
+}
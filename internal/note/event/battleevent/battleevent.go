@@ -0,0 +1,29 @@
+package battleevent
+
+// Result is the result of a player in a battle instance.
+type Result struct {
+	PlayerId uint64
+	Team     int
+	Won      bool
+	Left     bool     // whether the player left before the end
+	Kills    []uint64 // players killed
+	Damage   int64    // damage dealt to the other teams
+}
+
+// Settled is published on the event bus of the server that ran a battle
+// instance, once it's over and its rewards are mailed.
+type Settled struct {
+	InstanceId uint64
+	MatchId    uint64
+	Mode       uint32
+	Winner     int // winning team, -1 for a draw
+	Results    []Result
+}
+
+// Finished is published on the event bus of the server a player is online
+// on, when a battle instance it fought in is over.
+type Finished struct {
+	InstanceId uint64
+	Mode       uint32
+	Result
+}
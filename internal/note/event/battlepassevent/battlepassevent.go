@@ -1 +1,17 @@
 package battlepassevent
+
+// LevelUp is published when a player reaches tiers of the season of a pass.
+type LevelUp struct {
+	PlayerId uint64
+	PassId   uint32
+	Round    int64 // the season
+	Level    int   // tiers reached
+}
+
+// Unlocked is published when a player unlocks the premium rewards of the
+// season of a pass.
+type Unlocked struct {
+	PlayerId uint64
+	PassId   uint32
+	Round    int64
+}
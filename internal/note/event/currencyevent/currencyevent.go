@@ -0,0 +1,11 @@
+package currencyevent
+
+// Changed is published on the event bus when the balance of a currency of a
+// player changes, once per currency.
+type Changed struct {
+	PlayerId uint64
+	Currency int32
+	Delta    int64 // amount added, or spent if negative
+	Balance  int64 // balance after the change
+	Reason   string
+}
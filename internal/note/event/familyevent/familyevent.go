@@ -1 +1,45 @@
 package familyevent
+
+// Created is published on the event bus when a player creates a family.
+type Created struct {
+	FamilyId uint64
+	Leader   uint64
+}
+
+// Disbanded is published on the event bus when a family is disbanded.
+type Disbanded struct {
+	FamilyId uint64
+}
+
+// Joined is published on the event bus when a player joins a family,
+// including its leader when it creates it.
+type Joined struct {
+	FamilyId uint64
+	PlayerId uint64
+	Members  int // number of members of the family
+}
+
+// Left is published on the event bus when a player leaves a family, or is
+// kicked out.
+type Left struct {
+	FamilyId uint64
+	PlayerId uint64
+	Kicked   bool
+	Members  int // number of members of the family
+}
+
+// Contributed is published on the event bus when a member contributes to the
+// treasury of its family.
+type Contributed struct {
+	FamilyId uint64
+	PlayerId uint64
+	Points   int64 // contribution of the donation
+	Exp      int64 // experience of the family since it was created
+	Level    int32 // level of the family
+}
+
+// LevelUp is published on the event bus when a family levels up.
+type LevelUp struct {
+	FamilyId uint64
+	Level    int32
+}
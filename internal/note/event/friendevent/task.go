@@ -12,3 +12,19 @@ type AddOrDelFriendEvent struct {
 func (e *AddOrDelFriendEvent) GetDesc() string {
 	return ""
 }
+
+// Added is published on the event bus when two players become friends, once
+// for each of them.
+type Added struct {
+	PlayerId uint64
+	FriendId uint64
+	Count    int // number of friends of the player
+}
+
+// Removed is published on the event bus when two players stop being friends
+// (including when one blocks the other), once for each of them.
+type Removed struct {
+	PlayerId uint64
+	FriendId uint64
+	Count    int // number of friends of the player
+}
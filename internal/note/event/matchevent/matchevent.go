@@ -0,0 +1,19 @@
+package matchevent
+
+// Matched is published on the event bus of the matching server when the
+// matcher forms a match, and reserves its battle instance.
+type Matched struct {
+	MatchId    uint64
+	Mode       uint32
+	InstanceId uint64
+	Teams      [][]uint64 // players, by team
+}
+
+// Backfilled is published on the event bus of the matching server when
+// players are matched to replace the players who left a running instance.
+type Backfilled struct {
+	Mode       uint32
+	InstanceId uint64
+	Team       int
+	Players    []uint64
+}
@@ -0,0 +1,26 @@
+package monsterevent
+
+// Item is an amount of an item dropped by a monster.
+type Item struct {
+	Id  uint32
+	Num int64
+}
+
+// Killed is published on the event bus when a player kills a monster. The
+// player who dealt it the most damage gets the loot, if any.
+type Killed struct {
+	SceneId   uint32
+	MonsterId uint32 // monster config id
+	Uid       uint64 // id of the monster in the scene
+	PlayerId  uint64 // the player who gets the loot
+	Loot      []Item
+}
+
+// Attacked is published on the event bus when a monster hits a player.
+type Attacked struct {
+	SceneId   uint32
+	MonsterId uint32
+	Uid       uint64
+	PlayerId  uint64
+	Damage    int64
+}
@@ -1,10 +1,31 @@
 package playerevent
 
+// EnterGame is published on the event bus when a player logs in, once its
+// data is online.
 type EnterGame struct {
+	PlayerId uint64
+	Level    uint32
 }
 
+// LeaveGame is published on the event bus when a player logs out.
 type LeaveGame struct {
+	PlayerId uint64
+	Level    uint32
 }
 
 type DailyRefresh struct {
 }
+
+// LevelUp is published on the event bus when a player levels up.
+type LevelUp struct {
+	PlayerId uint64
+	Level    uint32 // new level
+}
+
+// Kill is published on the event bus when a player kills a monster or
+// another player.
+type Kill struct {
+	PlayerId uint64
+	TargetId uint64 // monster config id, or player id
+	IsPlayer bool   // whether the target is a player
+}
@@ -1 +1,13 @@
 package rechargeevent
+
+// Recharged is published on the server a player recharged on, once the
+// currency of the order is granted.
+type Recharged struct {
+	PlayerId  uint64
+	OrderId   string // channel:order id on the store
+	ProductId uint32
+	Money     int32 // MoneyCategory of the price
+	Price     int64 // cents
+	Sandbox   bool
+	First     bool // first recharge of the player
+}
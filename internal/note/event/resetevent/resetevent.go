@@ -0,0 +1,26 @@
+package resetevent
+
+// Periods of the resets.
+const (
+	Daily   = iota + 1 // 每日
+	Weekly             // 每周
+	Monthly            // 每月
+)
+
+// Boundary is published when a reset of a period comes, on every server, at
+// the time of the region of the server.
+type Boundary struct {
+	Region string
+	Period int
+	At     int64 // the reset, unix seconds
+}
+
+// Reset is published when a player is reset for a period: at the boundary if
+// it's online, or when it logs in after the boundary otherwise. The modules
+// reset their data of the player (e.g., the daily quests) on it.
+type Reset struct {
+	PlayerId uint64
+	Period   int
+	At       int64 // the reset, unix seconds
+	Lazy     bool  // on login, rather than at the boundary
+}
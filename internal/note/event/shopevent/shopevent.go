@@ -1 +1,10 @@
 package shopevent
+
+// Purchased is published on the event bus when a player buys goods in a
+// shop.
+type Purchased struct {
+	PlayerId uint64
+	ShopId   uint32
+	GoodsId  uint32
+	Count    int64
+}
@@ -0,0 +1,9 @@
+package taskevent
+
+// Completed is published on the event bus when a player submits a finished
+// quest, once its rewards are given.
+type Completed struct {
+	PlayerId uint64
+	QuestId  uint32
+	Category int // task.Category*
+}
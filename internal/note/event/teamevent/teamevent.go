@@ -1 +1,33 @@
 package teamevent
+
+// Created is published on the event bus when a player creates a team.
+type Created struct {
+	TeamId uint64
+	Leader uint64
+}
+
+// Joined is published on the event bus when a player joins a team.
+type Joined struct {
+	TeamId   uint64
+	PlayerId uint64
+	Members  int // number of members of the team
+}
+
+// Left is published on the event bus when a player leaves a team, or is
+// kicked out. The team is disbanded once it has no member.
+type Left struct {
+	TeamId   uint64
+	PlayerId uint64
+	Kicked   bool
+	Members  int // number of members of the team
+}
+
+// Ready is published on the event bus of the server of the member who
+// answered last, when all the members of a team answered a ready check that
+// they're ready (e.g., to enter an instance together).
+type Ready struct {
+	TeamId  uint64
+	Leader  uint64
+	Target  uint32            // what the members got ready for, e.g. the id of an instance
+	Members map[uint64]string // servers the members are online on, by member
+}
@@ -1,7 +1,7 @@
 package vipevent
 
-type EventCategory int
-
-const (
-	VIPLevelUpgrade EventCategory = iota + 1
-)
+// LevelUp is published when a player reaches a VIP level.
+type LevelUp struct {
+	PlayerId uint64
+	Level    uint32
+}
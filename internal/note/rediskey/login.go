@@ -20,3 +20,13 @@ func MakePlayerCacheKey(playerId uint64) string {
 func MakeTokenKey(userid uint64) string {
 	return "token:" + strconv.FormatInt(int64(userid), 10)
 }
+
+func MakeBanDeviceKey(device string) string {
+	return "ban:device:" + device
+}
+
+// MakeLoginLimitKey returns the key of the count of the logins of an IP or a
+// device (kind "ip" or "device") in the window starting at windowStart.
+func MakeLoginLimitKey(kind, id string, windowStart int64) string {
+	return fmt.Sprintf("loginLimit:%s:%s:%d", kind, id, windowStart)
+}
@@ -1,4 +0,0 @@
-package shushu
-
-type IPlayer interface {
-}
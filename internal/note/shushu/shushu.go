@@ -1,9 +0,0 @@
-package shushu
-
-import (
-	"greatestworks/internal"
-)
-
-type ShuShu struct {
-	internal.DataAsSubscriber
-}
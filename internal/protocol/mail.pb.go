@@ -0,0 +1,330 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        (unknown)
+// source: internal/protocol/mail.proto
+
+package protocol
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CSClaimMail struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uuid uint64 `protobuf:"varint,1,opt,name=Uuid,proto3" json:"Uuid,omitempty"`
+}
+
+func (x *CSClaimMail) Reset() {
+	*x = CSClaimMail{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_mail_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSClaimMail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSClaimMail) ProtoMessage() {}
+
+func (x *CSClaimMail) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_mail_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSClaimMail.ProtoReflect.Descriptor instead.
+func (*CSClaimMail) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_mail_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CSClaimMail) GetUuid() uint64 {
+	if x != nil {
+		return x.Uuid
+	}
+	return 0
+}
+
+type CSDelMail struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uuid uint64 `protobuf:"varint,1,opt,name=Uuid,proto3" json:"Uuid,omitempty"`
+}
+
+func (x *CSDelMail) Reset() {
+	*x = CSDelMail{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_mail_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSDelMail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSDelMail) ProtoMessage() {}
+
+func (x *CSDelMail) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_mail_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSDelMail.ProtoReflect.Descriptor instead.
+func (*CSDelMail) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_mail_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CSDelMail) GetUuid() uint64 {
+	if x != nil {
+		return x.Uuid
+	}
+	return 0
+}
+
+type CSReadMail struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uuid uint64 `protobuf:"varint,1,opt,name=Uuid,proto3" json:"Uuid,omitempty"`
+}
+
+func (x *CSReadMail) Reset() {
+	*x = CSReadMail{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_mail_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSReadMail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSReadMail) ProtoMessage() {}
+
+func (x *CSReadMail) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_mail_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSReadMail.ProtoReflect.Descriptor instead.
+func (*CSReadMail) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_mail_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CSReadMail) GetUuid() uint64 {
+	if x != nil {
+		return x.Uuid
+	}
+	return 0
+}
+
+type SCMailUnread struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Count int32 `protobuf:"varint,1,opt,name=Count,proto3" json:"Count,omitempty"`
+}
+
+func (x *SCMailUnread) Reset() {
+	*x = SCMailUnread{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_mail_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCMailUnread) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCMailUnread) ProtoMessage() {}
+
+func (x *SCMailUnread) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_mail_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCMailUnread.ProtoReflect.Descriptor instead.
+func (*SCMailUnread) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_mail_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SCMailUnread) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+var File_internal_protocol_mail_proto protoreflect.FileDescriptor
+
+var file_internal_protocol_mail_proto_rawDesc = []byte{
+	0x0a, 0x1c, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x2f, 0x6d, 0x61, 0x69, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x22, 0x21, 0x0a, 0x0b, 0x43, 0x53, 0x43, 0x6c,
+	0x61, 0x69, 0x6d, 0x4d, 0x61, 0x69, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x55, 0x75, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x55, 0x75, 0x69, 0x64, 0x22, 0x1f, 0x0a, 0x09, 0x43,
+	0x53, 0x44, 0x65, 0x6c, 0x4d, 0x61, 0x69, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x55, 0x75, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x55, 0x75, 0x69, 0x64, 0x22, 0x20, 0x0a, 0x0a,
+	0x43, 0x53, 0x52, 0x65, 0x61, 0x64, 0x4d, 0x61, 0x69, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x55, 0x75,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x55, 0x75, 0x69, 0x64, 0x22, 0x24,
+	0x0a, 0x0c, 0x53, 0x43, 0x4d, 0x61, 0x69, 0x6c, 0x55, 0x6e, 0x72, 0x65, 0x61, 0x64, 0x12, 0x14,
+	0x0a, 0x05, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x42, 0x21, 0x5a, 0x1f, 0x67, 0x72, 0x65, 0x61, 0x74, 0x65, 0x73, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x73, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_internal_protocol_mail_proto_rawDescOnce sync.Once
+	file_internal_protocol_mail_proto_rawDescData = file_internal_protocol_mail_proto_rawDesc
+)
+
+func file_internal_protocol_mail_proto_rawDescGZIP() []byte {
+	file_internal_protocol_mail_proto_rawDescOnce.Do(func() {
+		file_internal_protocol_mail_proto_rawDescData = protoimpl.X.CompressGZIP(file_internal_protocol_mail_proto_rawDescData)
+	})
+	return file_internal_protocol_mail_proto_rawDescData
+}
+
+var file_internal_protocol_mail_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_internal_protocol_mail_proto_goTypes = []interface{}{
+	(*CSClaimMail)(nil),  // 0: protocol.CSClaimMail
+	(*CSDelMail)(nil),    // 1: protocol.CSDelMail
+	(*CSReadMail)(nil),   // 2: protocol.CSReadMail
+	(*SCMailUnread)(nil), // 3: protocol.SCMailUnread
+}
+var file_internal_protocol_mail_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_internal_protocol_mail_proto_init() }
+func file_internal_protocol_mail_proto_init() {
+	if File_internal_protocol_mail_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_internal_protocol_mail_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSClaimMail); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_mail_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSDelMail); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_mail_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSReadMail); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_mail_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCMailUnread); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_internal_protocol_mail_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_protocol_mail_proto_goTypes,
+		DependencyIndexes: file_internal_protocol_mail_proto_depIdxs,
+		MessageInfos:      file_internal_protocol_mail_proto_msgTypes,
+	}.Build()
+	File_internal_protocol_mail_proto = out.File
+	file_internal_protocol_mail_proto_rawDesc = nil
+	file_internal_protocol_mail_proto_goTypes = nil
+	file_internal_protocol_mail_proto_depIdxs = nil
+}
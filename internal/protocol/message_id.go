@@ -0,0 +1,163 @@
+package protocol
+
+import "github.com/phuhao00/greatestworks-proto/messageId"
+
+// The IDs of the messages of this package, above those of greatestworks-proto:
+// every module has a range of 100 IDs, its requests (CS) first.
+const (
+	// broadcast
+	MessageId_SCBroadcast messageId.MessageId = 20000
+
+	// chat
+	MessageId_SCChatMsg messageId.MessageId = 20100
+
+	// email
+	MessageId_CSClaimMail  messageId.MessageId = 20200
+	MessageId_CSDelMail    messageId.MessageId = 20201
+	MessageId_CSReadMail   messageId.MessageId = 20202
+	MessageId_SCMailUnread messageId.MessageId = 20203
+
+	// family
+	MessageId_CSFamilyAcceptInvite messageId.MessageId = 20300
+	MessageId_CSFamilyApply        messageId.MessageId = 20301
+	MessageId_CSFamilyAppoint      messageId.MessageId = 20302
+	MessageId_CSFamilyApprove      messageId.MessageId = 20303
+	MessageId_CSFamilyCreate       messageId.MessageId = 20304
+	MessageId_CSFamilyDisband      messageId.MessageId = 20305
+	MessageId_CSFamilyDonate       messageId.MessageId = 20306
+	MessageId_CSFamilyInfo         messageId.MessageId = 20307
+	MessageId_CSFamilyInvite       messageId.MessageId = 20308
+	MessageId_CSFamilyKick         messageId.MessageId = 20309
+	MessageId_CSFamilyLeave        messageId.MessageId = 20310
+	MessageId_CSFamilySetting      messageId.MessageId = 20311
+	MessageId_CSFamilyTransfer     messageId.MessageId = 20312
+	MessageId_CSFamilyWithdraw     messageId.MessageId = 20313
+	MessageId_SCFamilyInfo         messageId.MessageId = 20314
+	MessageId_SCFamilyInvite       messageId.MessageId = 20315
+	MessageId_SCFamilyLeft         messageId.MessageId = 20316
+	MessageId_SCFamilyResult       messageId.MessageId = 20317
+
+	// friend
+	MessageId_SCFriendList   messageId.MessageId = 20400
+	MessageId_SCFriendStatus messageId.MessageId = 20401
+
+	// player
+	MessageId_SCPlayerAttr messageId.MessageId = 20500
+
+	// team
+	MessageId_CSTeamAcceptInvite messageId.MessageId = 20600
+	MessageId_CSTeamCreate       messageId.MessageId = 20601
+	MessageId_CSTeamInfo         messageId.MessageId = 20602
+	MessageId_CSTeamInvite       messageId.MessageId = 20603
+	MessageId_CSTeamKick         messageId.MessageId = 20604
+	MessageId_CSTeamLeave        messageId.MessageId = 20605
+	MessageId_CSTeamReady        messageId.MessageId = 20606
+	MessageId_CSTeamReadyCheck   messageId.MessageId = 20607
+	MessageId_CSTeamTransfer     messageId.MessageId = 20608
+	MessageId_SCTeamInfo         messageId.MessageId = 20609
+	MessageId_SCTeamInvite       messageId.MessageId = 20610
+	MessageId_SCTeamLeft         messageId.MessageId = 20611
+	MessageId_SCTeamReady        messageId.MessageId = 20612
+	MessageId_SCTeamResult       messageId.MessageId = 20613
+
+	// achievement
+	MessageId_CSClaimAchievement  messageId.MessageId = 20700
+	MessageId_CSPlayerStats       messageId.MessageId = 20701
+	MessageId_SCAchievementList   messageId.MessageId = 20702
+	MessageId_SCAchievementUpdate messageId.MessageId = 20703
+	MessageId_SCPlayerStats       messageId.MessageId = 20704
+
+	// arena
+	MessageId_CSArenaAttack    messageId.MessageId = 20800
+	MessageId_CSArenaDefense   messageId.MessageId = 20801
+	MessageId_CSArenaInfo      messageId.MessageId = 20802
+	MessageId_CSArenaOpponents messageId.MessageId = 20803
+	MessageId_SCArenaDefended  messageId.MessageId = 20804
+	MessageId_SCArenaDefense   messageId.MessageId = 20805
+	MessageId_SCArenaFight     messageId.MessageId = 20806
+	MessageId_SCArenaInfo      messageId.MessageId = 20807
+	MessageId_SCArenaOpponents messageId.MessageId = 20808
+
+	// bag
+	MessageId_CSDelItem   messageId.MessageId = 20900
+	MessageId_CSExpandBag messageId.MessageId = 20901
+	MessageId_SCBagChange messageId.MessageId = 20902
+
+	// battle
+	MessageId_CSBattleCast   messageId.MessageId = 21000
+	MessageId_CSBattleEnter  messageId.MessageId = 21001
+	MessageId_CSBattleLeave  messageId.MessageId = 21002
+	MessageId_SCBattleEnd    messageId.MessageId = 21003
+	MessageId_SCBattleFrame  messageId.MessageId = 21004
+	MessageId_SCBattleResult messageId.MessageId = 21005
+
+	// buff
+	MessageId_CSBuffCancel messageId.MessageId = 21100
+	MessageId_SCBuffUpdate messageId.MessageId = 21101
+
+	// equip
+	MessageId_CSEnhanceEquip messageId.MessageId = 21200
+	MessageId_CSEquip        messageId.MessageId = 21201
+	MessageId_CSRefineEquip  messageId.MessageId = 21202
+	MessageId_CSRepairEquip  messageId.MessageId = 21203
+	MessageId_CSUnequip      messageId.MessageId = 21204
+	MessageId_SCEquipUpdate  messageId.MessageId = 21205
+
+	// match
+	MessageId_CSMatchCancel  messageId.MessageId = 21300
+	MessageId_CSMatchEnqueue messageId.MessageId = 21301
+	MessageId_SCMatchResult  messageId.MessageId = 21302
+	MessageId_SCMatchStatus  messageId.MessageId = 21303
+
+	// monster
+	MessageId_CSMonsterAttack messageId.MessageId = 21400
+	MessageId_CSMonsterSync   messageId.MessageId = 21401
+	MessageId_SCMonsterAttack messageId.MessageId = 21402
+	MessageId_SCMonsterResult messageId.MessageId = 21403
+	MessageId_SCMonsterUpdate messageId.MessageId = 21404
+
+	// skill
+	MessageId_CSSkillLearn   messageId.MessageId = 21500
+	MessageId_CSSkillUpgrade messageId.MessageId = 21501
+	MessageId_SCSkillUpdate  messageId.MessageId = 21502
+
+	// task
+	MessageId_CSAcceptTask messageId.MessageId = 21600
+	MessageId_CSSubmitTask messageId.MessageId = 21601
+	MessageId_SCTaskList   messageId.MessageId = 21602
+	MessageId_SCTaskUpdate messageId.MessageId = 21603
+
+	// activity
+	MessageId_CSActivityJoin   messageId.MessageId = 21700
+	MessageId_SCActivityUpdate messageId.MessageId = 21701
+
+	// auction
+	MessageId_CSAuctionBid    messageId.MessageId = 21800
+	MessageId_CSAuctionBuyout messageId.MessageId = 21801
+	MessageId_CSAuctionCancel messageId.MessageId = 21802
+	MessageId_CSAuctionList   messageId.MessageId = 21803
+	MessageId_CSAuctionSearch messageId.MessageId = 21804
+	MessageId_SCAuctionResult messageId.MessageId = 21805
+	MessageId_SCAuctionSearch messageId.MessageId = 21806
+
+	// battlepass
+	MessageId_CSBattlePassClaim  messageId.MessageId = 21900
+	MessageId_SCBattlePassUpdate messageId.MessageId = 21901
+
+	// recharge
+	MessageId_CSRecharge messageId.MessageId = 22000
+	MessageId_SCRecharge messageId.MessageId = 22001
+
+	// shop
+	MessageId_CSBuyGoods       messageId.MessageId = 22100
+	MessageId_CSShopList       messageId.MessageId = 22101
+	MessageId_SCBuyGoods       messageId.MessageId = 22102
+	MessageId_SCCurrencyChange messageId.MessageId = 22103
+	MessageId_SCCurrencyList   messageId.MessageId = 22104
+	MessageId_SCShopList       messageId.MessageId = 22105
+
+	// vip
+	MessageId_CSVipInfo   messageId.MessageId = 22200
+	MessageId_CSVipReward messageId.MessageId = 22201
+	MessageId_SCVipUpdate messageId.MessageId = 22202
+)
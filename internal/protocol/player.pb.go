@@ -0,0 +1,9681 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        (unknown)
+// source: internal/protocol/player.proto
+
+// The messages of the modules of the server that greatestworks-proto doesn't
+// have yet. Their message IDs are in message_id.go.
+
+package protocol
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AuctionOp int32
+
+const (
+	AuctionOp_AuctionOpNone   AuctionOp = 0
+	AuctionOp_AuctionOpBid    AuctionOp = 1
+	AuctionOp_AuctionOpBuyout AuctionOp = 2
+	AuctionOp_AuctionOpCancel AuctionOp = 3
+	AuctionOp_AuctionOpList   AuctionOp = 4
+)
+
+// Enum value maps for AuctionOp.
+var (
+	AuctionOp_name = map[int32]string{
+		0: "AuctionOpNone",
+		1: "AuctionOpBid",
+		2: "AuctionOpBuyout",
+		3: "AuctionOpCancel",
+		4: "AuctionOpList",
+	}
+	AuctionOp_value = map[string]int32{
+		"AuctionOpNone":   0,
+		"AuctionOpBid":    1,
+		"AuctionOpBuyout": 2,
+		"AuctionOpCancel": 3,
+		"AuctionOpList":   4,
+	}
+)
+
+func (x AuctionOp) Enum() *AuctionOp {
+	p := new(AuctionOp)
+	*p = x
+	return p
+}
+
+func (x AuctionOp) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AuctionOp) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_protocol_player_proto_enumTypes[0].Descriptor()
+}
+
+func (AuctionOp) Type() protoreflect.EnumType {
+	return &file_internal_protocol_player_proto_enumTypes[0]
+}
+
+func (x AuctionOp) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AuctionOp.Descriptor instead.
+func (AuctionOp) EnumDescriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{0}
+}
+
+type BattleOp int32
+
+const (
+	BattleOp_BattleOpNone  BattleOp = 0
+	BattleOp_BattleOpCast  BattleOp = 1
+	BattleOp_BattleOpEnter BattleOp = 2
+	BattleOp_BattleOpLeave BattleOp = 3
+)
+
+// Enum value maps for BattleOp.
+var (
+	BattleOp_name = map[int32]string{
+		0: "BattleOpNone",
+		1: "BattleOpCast",
+		2: "BattleOpEnter",
+		3: "BattleOpLeave",
+	}
+	BattleOp_value = map[string]int32{
+		"BattleOpNone":  0,
+		"BattleOpCast":  1,
+		"BattleOpEnter": 2,
+		"BattleOpLeave": 3,
+	}
+)
+
+func (x BattleOp) Enum() *BattleOp {
+	p := new(BattleOp)
+	*p = x
+	return p
+}
+
+func (x BattleOp) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BattleOp) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_protocol_player_proto_enumTypes[1].Descriptor()
+}
+
+func (BattleOp) Type() protoreflect.EnumType {
+	return &file_internal_protocol_player_proto_enumTypes[1]
+}
+
+func (x BattleOp) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BattleOp.Descriptor instead.
+func (BattleOp) EnumDescriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{1}
+}
+
+type FamilyOp int32
+
+const (
+	FamilyOp_FamilyOpNone         FamilyOp = 0
+	FamilyOp_FamilyOpAcceptInvite FamilyOp = 1
+	FamilyOp_FamilyOpApply        FamilyOp = 2
+	FamilyOp_FamilyOpAppoint      FamilyOp = 3
+	FamilyOp_FamilyOpApprove      FamilyOp = 4
+	FamilyOp_FamilyOpCreate       FamilyOp = 5
+	FamilyOp_FamilyOpDisband      FamilyOp = 6
+	FamilyOp_FamilyOpDonate       FamilyOp = 7
+	FamilyOp_FamilyOpInfo         FamilyOp = 8
+	FamilyOp_FamilyOpInvite       FamilyOp = 9
+	FamilyOp_FamilyOpKick         FamilyOp = 10
+	FamilyOp_FamilyOpLeave        FamilyOp = 11
+	FamilyOp_FamilyOpSetting      FamilyOp = 12
+	FamilyOp_FamilyOpTransfer     FamilyOp = 13
+	FamilyOp_FamilyOpWithdraw     FamilyOp = 14
+)
+
+// Enum value maps for FamilyOp.
+var (
+	FamilyOp_name = map[int32]string{
+		0:  "FamilyOpNone",
+		1:  "FamilyOpAcceptInvite",
+		2:  "FamilyOpApply",
+		3:  "FamilyOpAppoint",
+		4:  "FamilyOpApprove",
+		5:  "FamilyOpCreate",
+		6:  "FamilyOpDisband",
+		7:  "FamilyOpDonate",
+		8:  "FamilyOpInfo",
+		9:  "FamilyOpInvite",
+		10: "FamilyOpKick",
+		11: "FamilyOpLeave",
+		12: "FamilyOpSetting",
+		13: "FamilyOpTransfer",
+		14: "FamilyOpWithdraw",
+	}
+	FamilyOp_value = map[string]int32{
+		"FamilyOpNone":         0,
+		"FamilyOpAcceptInvite": 1,
+		"FamilyOpApply":        2,
+		"FamilyOpAppoint":      3,
+		"FamilyOpApprove":      4,
+		"FamilyOpCreate":       5,
+		"FamilyOpDisband":      6,
+		"FamilyOpDonate":       7,
+		"FamilyOpInfo":         8,
+		"FamilyOpInvite":       9,
+		"FamilyOpKick":         10,
+		"FamilyOpLeave":        11,
+		"FamilyOpSetting":      12,
+		"FamilyOpTransfer":     13,
+		"FamilyOpWithdraw":     14,
+	}
+)
+
+func (x FamilyOp) Enum() *FamilyOp {
+	p := new(FamilyOp)
+	*p = x
+	return p
+}
+
+func (x FamilyOp) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FamilyOp) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_protocol_player_proto_enumTypes[2].Descriptor()
+}
+
+func (FamilyOp) Type() protoreflect.EnumType {
+	return &file_internal_protocol_player_proto_enumTypes[2]
+}
+
+func (x FamilyOp) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FamilyOp.Descriptor instead.
+func (FamilyOp) EnumDescriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{2}
+}
+
+type MatchOp int32
+
+const (
+	MatchOp_MatchOpNone    MatchOp = 0
+	MatchOp_MatchOpCancel  MatchOp = 1
+	MatchOp_MatchOpEnqueue MatchOp = 2
+)
+
+// Enum value maps for MatchOp.
+var (
+	MatchOp_name = map[int32]string{
+		0: "MatchOpNone",
+		1: "MatchOpCancel",
+		2: "MatchOpEnqueue",
+	}
+	MatchOp_value = map[string]int32{
+		"MatchOpNone":    0,
+		"MatchOpCancel":  1,
+		"MatchOpEnqueue": 2,
+	}
+)
+
+func (x MatchOp) Enum() *MatchOp {
+	p := new(MatchOp)
+	*p = x
+	return p
+}
+
+func (x MatchOp) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MatchOp) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_protocol_player_proto_enumTypes[3].Descriptor()
+}
+
+func (MatchOp) Type() protoreflect.EnumType {
+	return &file_internal_protocol_player_proto_enumTypes[3]
+}
+
+func (x MatchOp) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MatchOp.Descriptor instead.
+func (MatchOp) EnumDescriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{3}
+}
+
+type MatchStatus int32
+
+const (
+	MatchStatus_MatchNone      MatchStatus = 0
+	MatchStatus_MatchCancelled MatchStatus = 1
+	MatchStatus_MatchExpired   MatchStatus = 2
+	MatchStatus_MatchFound     MatchStatus = 3
+	MatchStatus_MatchQueued    MatchStatus = 4
+)
+
+// Enum value maps for MatchStatus.
+var (
+	MatchStatus_name = map[int32]string{
+		0: "MatchNone",
+		1: "MatchCancelled",
+		2: "MatchExpired",
+		3: "MatchFound",
+		4: "MatchQueued",
+	}
+	MatchStatus_value = map[string]int32{
+		"MatchNone":      0,
+		"MatchCancelled": 1,
+		"MatchExpired":   2,
+		"MatchFound":     3,
+		"MatchQueued":    4,
+	}
+)
+
+func (x MatchStatus) Enum() *MatchStatus {
+	p := new(MatchStatus)
+	*p = x
+	return p
+}
+
+func (x MatchStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MatchStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_protocol_player_proto_enumTypes[4].Descriptor()
+}
+
+func (MatchStatus) Type() protoreflect.EnumType {
+	return &file_internal_protocol_player_proto_enumTypes[4]
+}
+
+func (x MatchStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MatchStatus.Descriptor instead.
+func (MatchStatus) EnumDescriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{4}
+}
+
+type MonsterOp int32
+
+const (
+	MonsterOp_MonsterOpNone   MonsterOp = 0
+	MonsterOp_MonsterOpAttack MonsterOp = 1
+)
+
+// Enum value maps for MonsterOp.
+var (
+	MonsterOp_name = map[int32]string{
+		0: "MonsterOpNone",
+		1: "MonsterOpAttack",
+	}
+	MonsterOp_value = map[string]int32{
+		"MonsterOpNone":   0,
+		"MonsterOpAttack": 1,
+	}
+)
+
+func (x MonsterOp) Enum() *MonsterOp {
+	p := new(MonsterOp)
+	*p = x
+	return p
+}
+
+func (x MonsterOp) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MonsterOp) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_protocol_player_proto_enumTypes[5].Descriptor()
+}
+
+func (MonsterOp) Type() protoreflect.EnumType {
+	return &file_internal_protocol_player_proto_enumTypes[5]
+}
+
+func (x MonsterOp) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MonsterOp.Descriptor instead.
+func (MonsterOp) EnumDescriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{5}
+}
+
+type TeamOp int32
+
+const (
+	TeamOp_TeamOpNone         TeamOp = 0
+	TeamOp_TeamOpAcceptInvite TeamOp = 1
+	TeamOp_TeamOpCreate       TeamOp = 2
+	TeamOp_TeamOpInfo         TeamOp = 3
+	TeamOp_TeamOpInvite       TeamOp = 4
+	TeamOp_TeamOpKick         TeamOp = 5
+	TeamOp_TeamOpLeave        TeamOp = 6
+	TeamOp_TeamOpReady        TeamOp = 7
+	TeamOp_TeamOpReadyCheck   TeamOp = 8
+	TeamOp_TeamOpTransfer     TeamOp = 9
+)
+
+// Enum value maps for TeamOp.
+var (
+	TeamOp_name = map[int32]string{
+		0: "TeamOpNone",
+		1: "TeamOpAcceptInvite",
+		2: "TeamOpCreate",
+		3: "TeamOpInfo",
+		4: "TeamOpInvite",
+		5: "TeamOpKick",
+		6: "TeamOpLeave",
+		7: "TeamOpReady",
+		8: "TeamOpReadyCheck",
+		9: "TeamOpTransfer",
+	}
+	TeamOp_value = map[string]int32{
+		"TeamOpNone":         0,
+		"TeamOpAcceptInvite": 1,
+		"TeamOpCreate":       2,
+		"TeamOpInfo":         3,
+		"TeamOpInvite":       4,
+		"TeamOpKick":         5,
+		"TeamOpLeave":        6,
+		"TeamOpReady":        7,
+		"TeamOpReadyCheck":   8,
+		"TeamOpTransfer":     9,
+	}
+)
+
+func (x TeamOp) Enum() *TeamOp {
+	p := new(TeamOp)
+	*p = x
+	return p
+}
+
+func (x TeamOp) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TeamOp) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_protocol_player_proto_enumTypes[6].Descriptor()
+}
+
+func (TeamOp) Type() protoreflect.EnumType {
+	return &file_internal_protocol_player_proto_enumTypes[6]
+}
+
+func (x TeamOp) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TeamOp.Descriptor instead.
+func (TeamOp) EnumDescriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{6}
+}
+
+type AchievementInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id      uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Value   int64  `protobuf:"varint,2,opt,name=Value,proto3" json:"Value,omitempty"`
+	Reached int32  `protobuf:"varint,3,opt,name=Reached,proto3" json:"Reached,omitempty"`
+	Claimed int32  `protobuf:"varint,4,opt,name=Claimed,proto3" json:"Claimed,omitempty"`
+}
+
+func (x *AchievementInfo) Reset() {
+	*x = AchievementInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AchievementInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AchievementInfo) ProtoMessage() {}
+
+func (x *AchievementInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AchievementInfo.ProtoReflect.Descriptor instead.
+func (*AchievementInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AchievementInfo) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AchievementInfo) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *AchievementInfo) GetReached() int32 {
+	if x != nil {
+		return x.Reached
+	}
+	return 0
+}
+
+func (x *AchievementInfo) GetClaimed() int32 {
+	if x != nil {
+		return x.Claimed
+	}
+	return 0
+}
+
+type ActivityInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id       uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Open     bool   `protobuf:"varint,2,opt,name=Open,proto3" json:"Open,omitempty"`
+	Next     int64  `protobuf:"varint,3,opt,name=Next,proto3" json:"Next,omitempty"`
+	Joins    int64  `protobuf:"varint,4,opt,name=Joins,proto3" json:"Joins,omitempty"`
+	Times    int64  `protobuf:"varint,5,opt,name=Times,proto3" json:"Times,omitempty"`
+	Progress int64  `protobuf:"varint,6,opt,name=Progress,proto3" json:"Progress,omitempty"`
+	Stages   int32  `protobuf:"varint,7,opt,name=Stages,proto3" json:"Stages,omitempty"`
+	Start    int64  `protobuf:"varint,8,opt,name=Start,proto3" json:"Start,omitempty"`
+	End      int64  `protobuf:"varint,9,opt,name=End,proto3" json:"End,omitempty"`
+}
+
+func (x *ActivityInfo) Reset() {
+	*x = ActivityInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ActivityInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActivityInfo) ProtoMessage() {}
+
+func (x *ActivityInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActivityInfo.ProtoReflect.Descriptor instead.
+func (*ActivityInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ActivityInfo) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ActivityInfo) GetOpen() bool {
+	if x != nil {
+		return x.Open
+	}
+	return false
+}
+
+func (x *ActivityInfo) GetNext() int64 {
+	if x != nil {
+		return x.Next
+	}
+	return 0
+}
+
+func (x *ActivityInfo) GetJoins() int64 {
+	if x != nil {
+		return x.Joins
+	}
+	return 0
+}
+
+func (x *ActivityInfo) GetTimes() int64 {
+	if x != nil {
+		return x.Times
+	}
+	return 0
+}
+
+func (x *ActivityInfo) GetProgress() int64 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *ActivityInfo) GetStages() int32 {
+	if x != nil {
+		return x.Stages
+	}
+	return 0
+}
+
+func (x *ActivityInfo) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *ActivityInfo) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type ArenaOpponent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId uint64 `protobuf:"varint,1,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+	Points   int64  `protobuf:"varint,2,opt,name=Points,proto3" json:"Points,omitempty"`
+}
+
+func (x *ArenaOpponent) Reset() {
+	*x = ArenaOpponent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ArenaOpponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArenaOpponent) ProtoMessage() {}
+
+func (x *ArenaOpponent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArenaOpponent.ProtoReflect.Descriptor instead.
+func (*ArenaOpponent) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ArenaOpponent) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+func (x *ArenaOpponent) GetPoints() int64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+type ArenaRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id             uint64 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Time           int64  `protobuf:"varint,2,opt,name=Time,proto3" json:"Time,omitempty"`
+	Attacker       uint64 `protobuf:"varint,3,opt,name=Attacker,proto3" json:"Attacker,omitempty"`
+	Defender       uint64 `protobuf:"varint,4,opt,name=Defender,proto3" json:"Defender,omitempty"`
+	Won            bool   `protobuf:"varint,5,opt,name=Won,proto3" json:"Won,omitempty"`
+	Revenge        bool   `protobuf:"varint,6,opt,name=Revenge,proto3" json:"Revenge,omitempty"`
+	AttackerPoints int64  `protobuf:"varint,7,opt,name=AttackerPoints,proto3" json:"AttackerPoints,omitempty"`
+	AttackerDelta  int64  `protobuf:"varint,8,opt,name=AttackerDelta,proto3" json:"AttackerDelta,omitempty"`
+	DefenderPoints int64  `protobuf:"varint,9,opt,name=DefenderPoints,proto3" json:"DefenderPoints,omitempty"`
+	DefenderDelta  int64  `protobuf:"varint,10,opt,name=DefenderDelta,proto3" json:"DefenderDelta,omitempty"`
+}
+
+func (x *ArenaRecord) Reset() {
+	*x = ArenaRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ArenaRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArenaRecord) ProtoMessage() {}
+
+func (x *ArenaRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArenaRecord.ProtoReflect.Descriptor instead.
+func (*ArenaRecord) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ArenaRecord) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ArenaRecord) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *ArenaRecord) GetAttacker() uint64 {
+	if x != nil {
+		return x.Attacker
+	}
+	return 0
+}
+
+func (x *ArenaRecord) GetDefender() uint64 {
+	if x != nil {
+		return x.Defender
+	}
+	return 0
+}
+
+func (x *ArenaRecord) GetWon() bool {
+	if x != nil {
+		return x.Won
+	}
+	return false
+}
+
+func (x *ArenaRecord) GetRevenge() bool {
+	if x != nil {
+		return x.Revenge
+	}
+	return false
+}
+
+func (x *ArenaRecord) GetAttackerPoints() int64 {
+	if x != nil {
+		return x.AttackerPoints
+	}
+	return 0
+}
+
+func (x *ArenaRecord) GetAttackerDelta() int64 {
+	if x != nil {
+		return x.AttackerDelta
+	}
+	return 0
+}
+
+func (x *ArenaRecord) GetDefenderPoints() int64 {
+	if x != nil {
+		return x.DefenderPoints
+	}
+	return 0
+}
+
+func (x *ArenaRecord) GetDefenderDelta() int64 {
+	if x != nil {
+		return x.DefenderDelta
+	}
+	return 0
+}
+
+type ArenaRevenge struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId uint64 `protobuf:"varint,1,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+	Time     int64  `protobuf:"varint,2,opt,name=Time,proto3" json:"Time,omitempty"`
+}
+
+func (x *ArenaRevenge) Reset() {
+	*x = ArenaRevenge{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ArenaRevenge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArenaRevenge) ProtoMessage() {}
+
+func (x *ArenaRevenge) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArenaRevenge.ProtoReflect.Descriptor instead.
+func (*ArenaRevenge) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ArenaRevenge) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+func (x *ArenaRevenge) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+type AttrValue struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Kind  int32 `protobuf:"varint,1,opt,name=Kind,proto3" json:"Kind,omitempty"`
+	Value int64 `protobuf:"varint,2,opt,name=Value,proto3" json:"Value,omitempty"`
+}
+
+func (x *AttrValue) Reset() {
+	*x = AttrValue{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AttrValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttrValue) ProtoMessage() {}
+
+func (x *AttrValue) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttrValue.ProtoReflect.Descriptor instead.
+func (*AttrValue) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AttrValue) GetKind() int32 {
+	if x != nil {
+		return x.Kind
+	}
+	return 0
+}
+
+func (x *AttrValue) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type AuctionListing struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id       uint64 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Seller   uint64 `protobuf:"varint,2,opt,name=Seller,proto3" json:"Seller,omitempty"`
+	ItemId   uint32 `protobuf:"varint,3,opt,name=ItemId,proto3" json:"ItemId,omitempty"`
+	Num      int64  `protobuf:"varint,4,opt,name=Num,proto3" json:"Num,omitempty"`
+	Currency int32  `protobuf:"varint,5,opt,name=Currency,proto3" json:"Currency,omitempty"`
+	Price    int64  `protobuf:"varint,6,opt,name=Price,proto3" json:"Price,omitempty"`
+	Bidder   uint64 `protobuf:"varint,7,opt,name=Bidder,proto3" json:"Bidder,omitempty"`
+	Buyout   int64  `protobuf:"varint,8,opt,name=Buyout,proto3" json:"Buyout,omitempty"`
+	Status   int32  `protobuf:"varint,9,opt,name=Status,proto3" json:"Status,omitempty"`
+	Expire   int64  `protobuf:"varint,10,opt,name=Expire,proto3" json:"Expire,omitempty"`
+}
+
+func (x *AuctionListing) Reset() {
+	*x = AuctionListing{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AuctionListing) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuctionListing) ProtoMessage() {}
+
+func (x *AuctionListing) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuctionListing.ProtoReflect.Descriptor instead.
+func (*AuctionListing) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AuctionListing) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AuctionListing) GetSeller() uint64 {
+	if x != nil {
+		return x.Seller
+	}
+	return 0
+}
+
+func (x *AuctionListing) GetItemId() uint32 {
+	if x != nil {
+		return x.ItemId
+	}
+	return 0
+}
+
+func (x *AuctionListing) GetNum() int64 {
+	if x != nil {
+		return x.Num
+	}
+	return 0
+}
+
+func (x *AuctionListing) GetCurrency() int32 {
+	if x != nil {
+		return x.Currency
+	}
+	return 0
+}
+
+func (x *AuctionListing) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *AuctionListing) GetBidder() uint64 {
+	if x != nil {
+		return x.Bidder
+	}
+	return 0
+}
+
+func (x *AuctionListing) GetBuyout() int64 {
+	if x != nil {
+		return x.Buyout
+	}
+	return 0
+}
+
+func (x *AuctionListing) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *AuctionListing) GetExpire() int64 {
+	if x != nil {
+		return x.Expire
+	}
+	return 0
+}
+
+type BagItemChange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id    uint32   `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Delta int64    `protobuf:"varint,2,opt,name=Delta,proto3" json:"Delta,omitempty"`
+	Num   int64    `protobuf:"varint,3,opt,name=Num,proto3" json:"Num,omitempty"`
+	Uids  []uint64 `protobuf:"varint,4,rep,packed,name=Uids,proto3" json:"Uids,omitempty"`
+}
+
+func (x *BagItemChange) Reset() {
+	*x = BagItemChange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BagItemChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BagItemChange) ProtoMessage() {}
+
+func (x *BagItemChange) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BagItemChange.ProtoReflect.Descriptor instead.
+func (*BagItemChange) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BagItemChange) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BagItemChange) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *BagItemChange) GetNum() int64 {
+	if x != nil {
+		return x.Num
+	}
+	return 0
+}
+
+func (x *BagItemChange) GetUids() []uint64 {
+	if x != nil {
+		return x.Uids
+	}
+	return nil
+}
+
+type BattleEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Kind   int32  `protobuf:"varint,1,opt,name=Kind,proto3" json:"Kind,omitempty"`
+	Source uint64 `protobuf:"varint,2,opt,name=Source,proto3" json:"Source,omitempty"`
+	Target uint64 `protobuf:"varint,3,opt,name=Target,proto3" json:"Target,omitempty"`
+	Id     uint32 `protobuf:"varint,4,opt,name=Id,proto3" json:"Id,omitempty"`
+	Value  int64  `protobuf:"varint,5,opt,name=Value,proto3" json:"Value,omitempty"`
+	Crit   bool   `protobuf:"varint,6,opt,name=Crit,proto3" json:"Crit,omitempty"`
+}
+
+func (x *BattleEvent) Reset() {
+	*x = BattleEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BattleEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattleEvent) ProtoMessage() {}
+
+func (x *BattleEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattleEvent.ProtoReflect.Descriptor instead.
+func (*BattleEvent) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *BattleEvent) GetKind() int32 {
+	if x != nil {
+		return x.Kind
+	}
+	return 0
+}
+
+func (x *BattleEvent) GetSource() uint64 {
+	if x != nil {
+		return x.Source
+	}
+	return 0
+}
+
+func (x *BattleEvent) GetTarget() uint64 {
+	if x != nil {
+		return x.Target
+	}
+	return 0
+}
+
+func (x *BattleEvent) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BattleEvent) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *BattleEvent) GetCrit() bool {
+	if x != nil {
+		return x.Crit
+	}
+	return false
+}
+
+type BattlePassInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id      uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Round   int64  `protobuf:"varint,2,opt,name=Round,proto3" json:"Round,omitempty"`
+	End     int64  `protobuf:"varint,3,opt,name=End,proto3" json:"End,omitempty"`
+	Exp     int64  `protobuf:"varint,4,opt,name=Exp,proto3" json:"Exp,omitempty"`
+	Level   int32  `protobuf:"varint,5,opt,name=Level,proto3" json:"Level,omitempty"`
+	Premium bool   `protobuf:"varint,6,opt,name=Premium,proto3" json:"Premium,omitempty"`
+	Free    int32  `protobuf:"varint,7,opt,name=Free,proto3" json:"Free,omitempty"`
+	Paid    int32  `protobuf:"varint,8,opt,name=Paid,proto3" json:"Paid,omitempty"`
+}
+
+func (x *BattlePassInfo) Reset() {
+	*x = BattlePassInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BattlePassInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattlePassInfo) ProtoMessage() {}
+
+func (x *BattlePassInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattlePassInfo.ProtoReflect.Descriptor instead.
+func (*BattlePassInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BattlePassInfo) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BattlePassInfo) GetRound() int64 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *BattlePassInfo) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *BattlePassInfo) GetExp() int64 {
+	if x != nil {
+		return x.Exp
+	}
+	return 0
+}
+
+func (x *BattlePassInfo) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *BattlePassInfo) GetPremium() bool {
+	if x != nil {
+		return x.Premium
+	}
+	return false
+}
+
+func (x *BattlePassInfo) GetFree() int32 {
+	if x != nil {
+		return x.Free
+	}
+	return 0
+}
+
+func (x *BattlePassInfo) GetPaid() int32 {
+	if x != nil {
+		return x.Paid
+	}
+	return 0
+}
+
+type BuffEffect struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Kind   int32  `protobuf:"varint,1,opt,name=Kind,proto3" json:"Kind,omitempty"`
+	Id     uint32 `protobuf:"varint,2,opt,name=Id,proto3" json:"Id,omitempty"`
+	Source uint64 `protobuf:"varint,3,opt,name=Source,proto3" json:"Source,omitempty"`
+	Value  int64  `protobuf:"varint,4,opt,name=Value,proto3" json:"Value,omitempty"`
+}
+
+func (x *BuffEffect) Reset() {
+	*x = BuffEffect{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BuffEffect) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuffEffect) ProtoMessage() {}
+
+func (x *BuffEffect) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuffEffect.ProtoReflect.Descriptor instead.
+func (*BuffEffect) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *BuffEffect) GetKind() int32 {
+	if x != nil {
+		return x.Kind
+	}
+	return 0
+}
+
+func (x *BuffEffect) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BuffEffect) GetSource() uint64 {
+	if x != nil {
+		return x.Source
+	}
+	return 0
+}
+
+func (x *BuffEffect) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type BuffInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id     uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Source uint64 `protobuf:"varint,2,opt,name=Source,proto3" json:"Source,omitempty"`
+	Stacks int32  `protobuf:"varint,3,opt,name=Stacks,proto3" json:"Stacks,omitempty"`
+	Expire int64  `protobuf:"varint,4,opt,name=Expire,proto3" json:"Expire,omitempty"`
+}
+
+func (x *BuffInfo) Reset() {
+	*x = BuffInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BuffInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuffInfo) ProtoMessage() {}
+
+func (x *BuffInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuffInfo.ProtoReflect.Descriptor instead.
+func (*BuffInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *BuffInfo) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *BuffInfo) GetSource() uint64 {
+	if x != nil {
+		return x.Source
+	}
+	return 0
+}
+
+func (x *BuffInfo) GetStacks() int32 {
+	if x != nil {
+		return x.Stacks
+	}
+	return 0
+}
+
+func (x *BuffInfo) GetExpire() int64 {
+	if x != nil {
+		return x.Expire
+	}
+	return 0
+}
+
+type CSAcceptTask struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+}
+
+func (x *CSAcceptTask) Reset() {
+	*x = CSAcceptTask{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSAcceptTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSAcceptTask) ProtoMessage() {}
+
+func (x *CSAcceptTask) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSAcceptTask.ProtoReflect.Descriptor instead.
+func (*CSAcceptTask) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CSAcceptTask) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CSActivityJoin struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+}
+
+func (x *CSActivityJoin) Reset() {
+	*x = CSActivityJoin{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSActivityJoin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSActivityJoin) ProtoMessage() {}
+
+func (x *CSActivityJoin) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSActivityJoin.ProtoReflect.Descriptor instead.
+func (*CSActivityJoin) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CSActivityJoin) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CSArenaAttack struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Target uint64 `protobuf:"varint,1,opt,name=Target,proto3" json:"Target,omitempty"`
+}
+
+func (x *CSArenaAttack) Reset() {
+	*x = CSArenaAttack{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSArenaAttack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSArenaAttack) ProtoMessage() {}
+
+func (x *CSArenaAttack) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSArenaAttack.ProtoReflect.Descriptor instead.
+func (*CSArenaAttack) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CSArenaAttack) GetTarget() uint64 {
+	if x != nil {
+		return x.Target
+	}
+	return 0
+}
+
+type CSAuctionBid struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id     uint64 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Amount int64  `protobuf:"varint,2,opt,name=Amount,proto3" json:"Amount,omitempty"`
+}
+
+func (x *CSAuctionBid) Reset() {
+	*x = CSAuctionBid{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSAuctionBid) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSAuctionBid) ProtoMessage() {}
+
+func (x *CSAuctionBid) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSAuctionBid.ProtoReflect.Descriptor instead.
+func (*CSAuctionBid) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CSAuctionBid) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *CSAuctionBid) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type CSAuctionBuyout struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint64 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+}
+
+func (x *CSAuctionBuyout) Reset() {
+	*x = CSAuctionBuyout{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSAuctionBuyout) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSAuctionBuyout) ProtoMessage() {}
+
+func (x *CSAuctionBuyout) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSAuctionBuyout.ProtoReflect.Descriptor instead.
+func (*CSAuctionBuyout) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CSAuctionBuyout) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CSAuctionCancel struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint64 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+}
+
+func (x *CSAuctionCancel) Reset() {
+	*x = CSAuctionCancel{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSAuctionCancel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSAuctionCancel) ProtoMessage() {}
+
+func (x *CSAuctionCancel) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSAuctionCancel.ProtoReflect.Descriptor instead.
+func (*CSAuctionCancel) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CSAuctionCancel) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CSAuctionList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ItemId   uint32 `protobuf:"varint,1,opt,name=ItemId,proto3" json:"ItemId,omitempty"`
+	Num      int64  `protobuf:"varint,2,opt,name=Num,proto3" json:"Num,omitempty"`
+	Currency int32  `protobuf:"varint,3,opt,name=Currency,proto3" json:"Currency,omitempty"`
+	Price    int64  `protobuf:"varint,4,opt,name=Price,proto3" json:"Price,omitempty"`
+	Buyout   int64  `protobuf:"varint,5,opt,name=Buyout,proto3" json:"Buyout,omitempty"`
+}
+
+func (x *CSAuctionList) Reset() {
+	*x = CSAuctionList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSAuctionList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSAuctionList) ProtoMessage() {}
+
+func (x *CSAuctionList) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSAuctionList.ProtoReflect.Descriptor instead.
+func (*CSAuctionList) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CSAuctionList) GetItemId() uint32 {
+	if x != nil {
+		return x.ItemId
+	}
+	return 0
+}
+
+func (x *CSAuctionList) GetNum() int64 {
+	if x != nil {
+		return x.Num
+	}
+	return 0
+}
+
+func (x *CSAuctionList) GetCurrency() int32 {
+	if x != nil {
+		return x.Currency
+	}
+	return 0
+}
+
+func (x *CSAuctionList) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *CSAuctionList) GetBuyout() int64 {
+	if x != nil {
+		return x.Buyout
+	}
+	return 0
+}
+
+type CSAuctionSearch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ItemId   uint32 `protobuf:"varint,1,opt,name=ItemId,proto3" json:"ItemId,omitempty"`
+	Category int32  `protobuf:"varint,2,opt,name=Category,proto3" json:"Category,omitempty"`
+	Currency int32  `protobuf:"varint,3,opt,name=Currency,proto3" json:"Currency,omitempty"`
+	MinPrice int64  `protobuf:"varint,4,opt,name=MinPrice,proto3" json:"MinPrice,omitempty"`
+	MaxPrice int64  `protobuf:"varint,5,opt,name=MaxPrice,proto3" json:"MaxPrice,omitempty"`
+	Sort     int32  `protobuf:"varint,6,opt,name=Sort,proto3" json:"Sort,omitempty"`
+	Page     int64  `protobuf:"varint,7,opt,name=Page,proto3" json:"Page,omitempty"`
+	Mine     bool   `protobuf:"varint,8,opt,name=Mine,proto3" json:"Mine,omitempty"`
+}
+
+func (x *CSAuctionSearch) Reset() {
+	*x = CSAuctionSearch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSAuctionSearch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSAuctionSearch) ProtoMessage() {}
+
+func (x *CSAuctionSearch) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSAuctionSearch.ProtoReflect.Descriptor instead.
+func (*CSAuctionSearch) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *CSAuctionSearch) GetItemId() uint32 {
+	if x != nil {
+		return x.ItemId
+	}
+	return 0
+}
+
+func (x *CSAuctionSearch) GetCategory() int32 {
+	if x != nil {
+		return x.Category
+	}
+	return 0
+}
+
+func (x *CSAuctionSearch) GetCurrency() int32 {
+	if x != nil {
+		return x.Currency
+	}
+	return 0
+}
+
+func (x *CSAuctionSearch) GetMinPrice() int64 {
+	if x != nil {
+		return x.MinPrice
+	}
+	return 0
+}
+
+func (x *CSAuctionSearch) GetMaxPrice() int64 {
+	if x != nil {
+		return x.MaxPrice
+	}
+	return 0
+}
+
+func (x *CSAuctionSearch) GetSort() int32 {
+	if x != nil {
+		return x.Sort
+	}
+	return 0
+}
+
+func (x *CSAuctionSearch) GetPage() int64 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *CSAuctionSearch) GetMine() bool {
+	if x != nil {
+		return x.Mine
+	}
+	return false
+}
+
+type CSBattleCast struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	InstanceId uint64 `protobuf:"varint,1,opt,name=InstanceId,proto3" json:"InstanceId,omitempty"`
+	Skill      uint32 `protobuf:"varint,2,opt,name=Skill,proto3" json:"Skill,omitempty"`
+	Target     uint64 `protobuf:"varint,3,opt,name=Target,proto3" json:"Target,omitempty"`
+}
+
+func (x *CSBattleCast) Reset() {
+	*x = CSBattleCast{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSBattleCast) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSBattleCast) ProtoMessage() {}
+
+func (x *CSBattleCast) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSBattleCast.ProtoReflect.Descriptor instead.
+func (*CSBattleCast) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *CSBattleCast) GetInstanceId() uint64 {
+	if x != nil {
+		return x.InstanceId
+	}
+	return 0
+}
+
+func (x *CSBattleCast) GetSkill() uint32 {
+	if x != nil {
+		return x.Skill
+	}
+	return 0
+}
+
+func (x *CSBattleCast) GetTarget() uint64 {
+	if x != nil {
+		return x.Target
+	}
+	return 0
+}
+
+type CSBattleEnter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	InstanceId uint64 `protobuf:"varint,1,opt,name=InstanceId,proto3" json:"InstanceId,omitempty"`
+}
+
+func (x *CSBattleEnter) Reset() {
+	*x = CSBattleEnter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSBattleEnter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSBattleEnter) ProtoMessage() {}
+
+func (x *CSBattleEnter) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSBattleEnter.ProtoReflect.Descriptor instead.
+func (*CSBattleEnter) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CSBattleEnter) GetInstanceId() uint64 {
+	if x != nil {
+		return x.InstanceId
+	}
+	return 0
+}
+
+type CSBattlePassClaim struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id    uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Track int32  `protobuf:"varint,2,opt,name=Track,proto3" json:"Track,omitempty"`
+}
+
+func (x *CSBattlePassClaim) Reset() {
+	*x = CSBattlePassClaim{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSBattlePassClaim) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSBattlePassClaim) ProtoMessage() {}
+
+func (x *CSBattlePassClaim) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSBattlePassClaim.ProtoReflect.Descriptor instead.
+func (*CSBattlePassClaim) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *CSBattlePassClaim) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *CSBattlePassClaim) GetTrack() int32 {
+	if x != nil {
+		return x.Track
+	}
+	return 0
+}
+
+type CSBuffCancel struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+}
+
+func (x *CSBuffCancel) Reset() {
+	*x = CSBuffCancel{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSBuffCancel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSBuffCancel) ProtoMessage() {}
+
+func (x *CSBuffCancel) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSBuffCancel.ProtoReflect.Descriptor instead.
+func (*CSBuffCancel) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CSBuffCancel) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CSBuyGoods struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ShopId  uint32 `protobuf:"varint,1,opt,name=ShopId,proto3" json:"ShopId,omitempty"`
+	GoodsId uint32 `protobuf:"varint,2,opt,name=GoodsId,proto3" json:"GoodsId,omitempty"`
+	Count   int64  `protobuf:"varint,3,opt,name=Count,proto3" json:"Count,omitempty"`
+}
+
+func (x *CSBuyGoods) Reset() {
+	*x = CSBuyGoods{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSBuyGoods) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSBuyGoods) ProtoMessage() {}
+
+func (x *CSBuyGoods) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSBuyGoods.ProtoReflect.Descriptor instead.
+func (*CSBuyGoods) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CSBuyGoods) GetShopId() uint32 {
+	if x != nil {
+		return x.ShopId
+	}
+	return 0
+}
+
+func (x *CSBuyGoods) GetGoodsId() uint32 {
+	if x != nil {
+		return x.GoodsId
+	}
+	return 0
+}
+
+func (x *CSBuyGoods) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type CSClaimAchievement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+}
+
+func (x *CSClaimAchievement) Reset() {
+	*x = CSClaimAchievement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSClaimAchievement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSClaimAchievement) ProtoMessage() {}
+
+func (x *CSClaimAchievement) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSClaimAchievement.ProtoReflect.Descriptor instead.
+func (*CSClaimAchievement) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *CSClaimAchievement) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CSDelItem struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ItemId uint32   `protobuf:"varint,1,opt,name=ItemId,proto3" json:"ItemId,omitempty"`
+	Num    int64    `protobuf:"varint,2,opt,name=Num,proto3" json:"Num,omitempty"`
+	Uids   []uint64 `protobuf:"varint,3,rep,packed,name=Uids,proto3" json:"Uids,omitempty"`
+}
+
+func (x *CSDelItem) Reset() {
+	*x = CSDelItem{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSDelItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSDelItem) ProtoMessage() {}
+
+func (x *CSDelItem) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSDelItem.ProtoReflect.Descriptor instead.
+func (*CSDelItem) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *CSDelItem) GetItemId() uint32 {
+	if x != nil {
+		return x.ItemId
+	}
+	return 0
+}
+
+func (x *CSDelItem) GetNum() int64 {
+	if x != nil {
+		return x.Num
+	}
+	return 0
+}
+
+func (x *CSDelItem) GetUids() []uint64 {
+	if x != nil {
+		return x.Uids
+	}
+	return nil
+}
+
+type CSEnhanceEquip struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uid uint64 `protobuf:"varint,1,opt,name=Uid,proto3" json:"Uid,omitempty"`
+}
+
+func (x *CSEnhanceEquip) Reset() {
+	*x = CSEnhanceEquip{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSEnhanceEquip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSEnhanceEquip) ProtoMessage() {}
+
+func (x *CSEnhanceEquip) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSEnhanceEquip.ProtoReflect.Descriptor instead.
+func (*CSEnhanceEquip) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *CSEnhanceEquip) GetUid() uint64 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+type CSEquip struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uid  uint64 `protobuf:"varint,1,opt,name=Uid,proto3" json:"Uid,omitempty"`
+	Part int32  `protobuf:"varint,2,opt,name=Part,proto3" json:"Part,omitempty"`
+}
+
+func (x *CSEquip) Reset() {
+	*x = CSEquip{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSEquip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSEquip) ProtoMessage() {}
+
+func (x *CSEquip) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSEquip.ProtoReflect.Descriptor instead.
+func (*CSEquip) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *CSEquip) GetUid() uint64 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+func (x *CSEquip) GetPart() int32 {
+	if x != nil {
+		return x.Part
+	}
+	return 0
+}
+
+type CSFamilyAcceptInvite struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FamilyId uint64 `protobuf:"varint,1,opt,name=FamilyId,proto3" json:"FamilyId,omitempty"`
+}
+
+func (x *CSFamilyAcceptInvite) Reset() {
+	*x = CSFamilyAcceptInvite{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilyAcceptInvite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilyAcceptInvite) ProtoMessage() {}
+
+func (x *CSFamilyAcceptInvite) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilyAcceptInvite.ProtoReflect.Descriptor instead.
+func (*CSFamilyAcceptInvite) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *CSFamilyAcceptInvite) GetFamilyId() uint64 {
+	if x != nil {
+		return x.FamilyId
+	}
+	return 0
+}
+
+type CSFamilyApply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FamilyId uint64 `protobuf:"varint,1,opt,name=FamilyId,proto3" json:"FamilyId,omitempty"`
+}
+
+func (x *CSFamilyApply) Reset() {
+	*x = CSFamilyApply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilyApply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilyApply) ProtoMessage() {}
+
+func (x *CSFamilyApply) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilyApply.ProtoReflect.Descriptor instead.
+func (*CSFamilyApply) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *CSFamilyApply) GetFamilyId() uint64 {
+	if x != nil {
+		return x.FamilyId
+	}
+	return 0
+}
+
+type CSFamilyAppoint struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId uint64 `protobuf:"varint,1,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+	Position int32  `protobuf:"varint,2,opt,name=Position,proto3" json:"Position,omitempty"`
+}
+
+func (x *CSFamilyAppoint) Reset() {
+	*x = CSFamilyAppoint{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilyAppoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilyAppoint) ProtoMessage() {}
+
+func (x *CSFamilyAppoint) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilyAppoint.ProtoReflect.Descriptor instead.
+func (*CSFamilyAppoint) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *CSFamilyAppoint) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+func (x *CSFamilyAppoint) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+type CSFamilyApprove struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId uint64 `protobuf:"varint,1,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+	Accept   bool   `protobuf:"varint,2,opt,name=Accept,proto3" json:"Accept,omitempty"`
+}
+
+func (x *CSFamilyApprove) Reset() {
+	*x = CSFamilyApprove{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilyApprove) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilyApprove) ProtoMessage() {}
+
+func (x *CSFamilyApprove) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilyApprove.ProtoReflect.Descriptor instead.
+func (*CSFamilyApprove) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *CSFamilyApprove) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+func (x *CSFamilyApprove) GetAccept() bool {
+	if x != nil {
+		return x.Accept
+	}
+	return false
+}
+
+type CSFamilyCreate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+}
+
+func (x *CSFamilyCreate) Reset() {
+	*x = CSFamilyCreate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[33]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilyCreate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilyCreate) ProtoMessage() {}
+
+func (x *CSFamilyCreate) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[33]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilyCreate.ProtoReflect.Descriptor instead.
+func (*CSFamilyCreate) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *CSFamilyCreate) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type CSFamilyDonate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Currency int32 `protobuf:"varint,1,opt,name=Currency,proto3" json:"Currency,omitempty"`
+	Amount   int64 `protobuf:"varint,2,opt,name=Amount,proto3" json:"Amount,omitempty"`
+}
+
+func (x *CSFamilyDonate) Reset() {
+	*x = CSFamilyDonate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[34]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilyDonate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilyDonate) ProtoMessage() {}
+
+func (x *CSFamilyDonate) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[34]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilyDonate.ProtoReflect.Descriptor instead.
+func (*CSFamilyDonate) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *CSFamilyDonate) GetCurrency() int32 {
+	if x != nil {
+		return x.Currency
+	}
+	return 0
+}
+
+func (x *CSFamilyDonate) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type CSFamilyInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FamilyId uint64 `protobuf:"varint,1,opt,name=FamilyId,proto3" json:"FamilyId,omitempty"`
+}
+
+func (x *CSFamilyInfo) Reset() {
+	*x = CSFamilyInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[35]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilyInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilyInfo) ProtoMessage() {}
+
+func (x *CSFamilyInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[35]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilyInfo.ProtoReflect.Descriptor instead.
+func (*CSFamilyInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *CSFamilyInfo) GetFamilyId() uint64 {
+	if x != nil {
+		return x.FamilyId
+	}
+	return 0
+}
+
+type CSFamilyInvite struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId uint64 `protobuf:"varint,1,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+}
+
+func (x *CSFamilyInvite) Reset() {
+	*x = CSFamilyInvite{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[36]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilyInvite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilyInvite) ProtoMessage() {}
+
+func (x *CSFamilyInvite) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[36]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilyInvite.ProtoReflect.Descriptor instead.
+func (*CSFamilyInvite) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *CSFamilyInvite) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+type CSFamilyKick struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId uint64 `protobuf:"varint,1,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+}
+
+func (x *CSFamilyKick) Reset() {
+	*x = CSFamilyKick{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[37]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilyKick) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilyKick) ProtoMessage() {}
+
+func (x *CSFamilyKick) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[37]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilyKick.ProtoReflect.Descriptor instead.
+func (*CSFamilyKick) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *CSFamilyKick) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+type CSFamilySetting struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Notice   string `protobuf:"bytes,1,opt,name=Notice,proto3" json:"Notice,omitempty"`
+	MinLevel uint32 `protobuf:"varint,2,opt,name=MinLevel,proto3" json:"MinLevel,omitempty"`
+	AutoJoin bool   `protobuf:"varint,3,opt,name=AutoJoin,proto3" json:"AutoJoin,omitempty"`
+}
+
+func (x *CSFamilySetting) Reset() {
+	*x = CSFamilySetting{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[38]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilySetting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilySetting) ProtoMessage() {}
+
+func (x *CSFamilySetting) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[38]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilySetting.ProtoReflect.Descriptor instead.
+func (*CSFamilySetting) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *CSFamilySetting) GetNotice() string {
+	if x != nil {
+		return x.Notice
+	}
+	return ""
+}
+
+func (x *CSFamilySetting) GetMinLevel() uint32 {
+	if x != nil {
+		return x.MinLevel
+	}
+	return 0
+}
+
+func (x *CSFamilySetting) GetAutoJoin() bool {
+	if x != nil {
+		return x.AutoJoin
+	}
+	return false
+}
+
+type CSFamilyTransfer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId uint64 `protobuf:"varint,1,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+}
+
+func (x *CSFamilyTransfer) Reset() {
+	*x = CSFamilyTransfer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[39]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilyTransfer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilyTransfer) ProtoMessage() {}
+
+func (x *CSFamilyTransfer) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[39]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilyTransfer.ProtoReflect.Descriptor instead.
+func (*CSFamilyTransfer) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *CSFamilyTransfer) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+type CSFamilyWithdraw struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Currency int32 `protobuf:"varint,1,opt,name=Currency,proto3" json:"Currency,omitempty"`
+	Amount   int64 `protobuf:"varint,2,opt,name=Amount,proto3" json:"Amount,omitempty"`
+}
+
+func (x *CSFamilyWithdraw) Reset() {
+	*x = CSFamilyWithdraw{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[40]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSFamilyWithdraw) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSFamilyWithdraw) ProtoMessage() {}
+
+func (x *CSFamilyWithdraw) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[40]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSFamilyWithdraw.ProtoReflect.Descriptor instead.
+func (*CSFamilyWithdraw) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *CSFamilyWithdraw) GetCurrency() int32 {
+	if x != nil {
+		return x.Currency
+	}
+	return 0
+}
+
+func (x *CSFamilyWithdraw) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type CSMatchEnqueue struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Mode uint32 `protobuf:"varint,1,opt,name=Mode,proto3" json:"Mode,omitempty"`
+}
+
+func (x *CSMatchEnqueue) Reset() {
+	*x = CSMatchEnqueue{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[41]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSMatchEnqueue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSMatchEnqueue) ProtoMessage() {}
+
+func (x *CSMatchEnqueue) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[41]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSMatchEnqueue.ProtoReflect.Descriptor instead.
+func (*CSMatchEnqueue) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *CSMatchEnqueue) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+type CSMonsterAttack struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uid uint64 `protobuf:"varint,1,opt,name=Uid,proto3" json:"Uid,omitempty"`
+}
+
+func (x *CSMonsterAttack) Reset() {
+	*x = CSMonsterAttack{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[42]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSMonsterAttack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSMonsterAttack) ProtoMessage() {}
+
+func (x *CSMonsterAttack) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[42]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSMonsterAttack.ProtoReflect.Descriptor instead.
+func (*CSMonsterAttack) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *CSMonsterAttack) GetUid() uint64 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+type CSMonsterSync struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SceneId uint32  `protobuf:"varint,1,opt,name=SceneId,proto3" json:"SceneId,omitempty"`
+	X       float64 `protobuf:"fixed64,2,opt,name=X,proto3" json:"X,omitempty"`
+	Z       float64 `protobuf:"fixed64,3,opt,name=Z,proto3" json:"Z,omitempty"`
+}
+
+func (x *CSMonsterSync) Reset() {
+	*x = CSMonsterSync{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[43]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSMonsterSync) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSMonsterSync) ProtoMessage() {}
+
+func (x *CSMonsterSync) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[43]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSMonsterSync.ProtoReflect.Descriptor instead.
+func (*CSMonsterSync) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *CSMonsterSync) GetSceneId() uint32 {
+	if x != nil {
+		return x.SceneId
+	}
+	return 0
+}
+
+func (x *CSMonsterSync) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *CSMonsterSync) GetZ() float64 {
+	if x != nil {
+		return x.Z
+	}
+	return 0
+}
+
+type CSRecharge struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProductId   uint32 `protobuf:"varint,1,opt,name=ProductId,proto3" json:"ProductId,omitempty"`
+	Channel     string `protobuf:"bytes,2,opt,name=Channel,proto3" json:"Channel,omitempty"`
+	Transaction string `protobuf:"bytes,3,opt,name=Transaction,proto3" json:"Transaction,omitempty"`
+	Receipt     string `protobuf:"bytes,4,opt,name=Receipt,proto3" json:"Receipt,omitempty"`
+	Signature   string `protobuf:"bytes,5,opt,name=Signature,proto3" json:"Signature,omitempty"`
+}
+
+func (x *CSRecharge) Reset() {
+	*x = CSRecharge{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[44]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSRecharge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSRecharge) ProtoMessage() {}
+
+func (x *CSRecharge) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[44]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSRecharge.ProtoReflect.Descriptor instead.
+func (*CSRecharge) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *CSRecharge) GetProductId() uint32 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *CSRecharge) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *CSRecharge) GetTransaction() string {
+	if x != nil {
+		return x.Transaction
+	}
+	return ""
+}
+
+func (x *CSRecharge) GetReceipt() string {
+	if x != nil {
+		return x.Receipt
+	}
+	return ""
+}
+
+func (x *CSRecharge) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+type CSRefineEquip struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uid uint64 `protobuf:"varint,1,opt,name=Uid,proto3" json:"Uid,omitempty"`
+}
+
+func (x *CSRefineEquip) Reset() {
+	*x = CSRefineEquip{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[45]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSRefineEquip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSRefineEquip) ProtoMessage() {}
+
+func (x *CSRefineEquip) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[45]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSRefineEquip.ProtoReflect.Descriptor instead.
+func (*CSRefineEquip) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *CSRefineEquip) GetUid() uint64 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+type CSRepairEquip struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uid uint64 `protobuf:"varint,1,opt,name=Uid,proto3" json:"Uid,omitempty"`
+}
+
+func (x *CSRepairEquip) Reset() {
+	*x = CSRepairEquip{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[46]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSRepairEquip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSRepairEquip) ProtoMessage() {}
+
+func (x *CSRepairEquip) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[46]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSRepairEquip.ProtoReflect.Descriptor instead.
+func (*CSRepairEquip) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *CSRepairEquip) GetUid() uint64 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+type CSSkillLearn struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+}
+
+func (x *CSSkillLearn) Reset() {
+	*x = CSSkillLearn{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[47]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSSkillLearn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSSkillLearn) ProtoMessage() {}
+
+func (x *CSSkillLearn) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[47]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSSkillLearn.ProtoReflect.Descriptor instead.
+func (*CSSkillLearn) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *CSSkillLearn) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CSSkillUpgrade struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+}
+
+func (x *CSSkillUpgrade) Reset() {
+	*x = CSSkillUpgrade{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[48]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSSkillUpgrade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSSkillUpgrade) ProtoMessage() {}
+
+func (x *CSSkillUpgrade) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[48]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSSkillUpgrade.ProtoReflect.Descriptor instead.
+func (*CSSkillUpgrade) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *CSSkillUpgrade) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CSSubmitTask struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+}
+
+func (x *CSSubmitTask) Reset() {
+	*x = CSSubmitTask{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[49]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSSubmitTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSSubmitTask) ProtoMessage() {}
+
+func (x *CSSubmitTask) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[49]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSSubmitTask.ProtoReflect.Descriptor instead.
+func (*CSSubmitTask) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *CSSubmitTask) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CSTeamAcceptInvite struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TeamId uint64 `protobuf:"varint,1,opt,name=TeamId,proto3" json:"TeamId,omitempty"`
+}
+
+func (x *CSTeamAcceptInvite) Reset() {
+	*x = CSTeamAcceptInvite{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[50]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSTeamAcceptInvite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSTeamAcceptInvite) ProtoMessage() {}
+
+func (x *CSTeamAcceptInvite) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[50]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSTeamAcceptInvite.ProtoReflect.Descriptor instead.
+func (*CSTeamAcceptInvite) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *CSTeamAcceptInvite) GetTeamId() uint64 {
+	if x != nil {
+		return x.TeamId
+	}
+	return 0
+}
+
+type CSTeamInvite struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId uint64 `protobuf:"varint,1,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+}
+
+func (x *CSTeamInvite) Reset() {
+	*x = CSTeamInvite{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[51]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSTeamInvite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSTeamInvite) ProtoMessage() {}
+
+func (x *CSTeamInvite) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[51]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSTeamInvite.ProtoReflect.Descriptor instead.
+func (*CSTeamInvite) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *CSTeamInvite) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+type CSTeamKick struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId uint64 `protobuf:"varint,1,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+}
+
+func (x *CSTeamKick) Reset() {
+	*x = CSTeamKick{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[52]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSTeamKick) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSTeamKick) ProtoMessage() {}
+
+func (x *CSTeamKick) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[52]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSTeamKick.ProtoReflect.Descriptor instead.
+func (*CSTeamKick) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *CSTeamKick) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+type CSTeamReady struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ready bool `protobuf:"varint,1,opt,name=Ready,proto3" json:"Ready,omitempty"`
+}
+
+func (x *CSTeamReady) Reset() {
+	*x = CSTeamReady{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[53]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSTeamReady) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSTeamReady) ProtoMessage() {}
+
+func (x *CSTeamReady) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[53]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSTeamReady.ProtoReflect.Descriptor instead.
+func (*CSTeamReady) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *CSTeamReady) GetReady() bool {
+	if x != nil {
+		return x.Ready
+	}
+	return false
+}
+
+type CSTeamReadyCheck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Target uint32 `protobuf:"varint,1,opt,name=Target,proto3" json:"Target,omitempty"`
+}
+
+func (x *CSTeamReadyCheck) Reset() {
+	*x = CSTeamReadyCheck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[54]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSTeamReadyCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSTeamReadyCheck) ProtoMessage() {}
+
+func (x *CSTeamReadyCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[54]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSTeamReadyCheck.ProtoReflect.Descriptor instead.
+func (*CSTeamReadyCheck) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *CSTeamReadyCheck) GetTarget() uint32 {
+	if x != nil {
+		return x.Target
+	}
+	return 0
+}
+
+type CSTeamTransfer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId uint64 `protobuf:"varint,1,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+}
+
+func (x *CSTeamTransfer) Reset() {
+	*x = CSTeamTransfer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[55]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSTeamTransfer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSTeamTransfer) ProtoMessage() {}
+
+func (x *CSTeamTransfer) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[55]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSTeamTransfer.ProtoReflect.Descriptor instead.
+func (*CSTeamTransfer) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *CSTeamTransfer) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+type CSUnequip struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Part int32 `protobuf:"varint,1,opt,name=Part,proto3" json:"Part,omitempty"`
+}
+
+func (x *CSUnequip) Reset() {
+	*x = CSUnequip{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[56]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CSUnequip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CSUnequip) ProtoMessage() {}
+
+func (x *CSUnequip) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[56]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CSUnequip.ProtoReflect.Descriptor instead.
+func (*CSUnequip) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *CSUnequip) GetPart() int32 {
+	if x != nil {
+		return x.Part
+	}
+	return 0
+}
+
+type CurrencyInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type    int32 `protobuf:"varint,1,opt,name=Type,proto3" json:"Type,omitempty"`
+	Balance int64 `protobuf:"varint,2,opt,name=Balance,proto3" json:"Balance,omitempty"`
+}
+
+func (x *CurrencyInfo) Reset() {
+	*x = CurrencyInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[57]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CurrencyInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CurrencyInfo) ProtoMessage() {}
+
+func (x *CurrencyInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[57]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CurrencyInfo.ProtoReflect.Descriptor instead.
+func (*CurrencyInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *CurrencyInfo) GetType() int32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *CurrencyInfo) GetBalance() int64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+type EquipInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uid        uint64 `protobuf:"varint,1,opt,name=Uid,proto3" json:"Uid,omitempty"`
+	Id         uint32 `protobuf:"varint,2,opt,name=Id,proto3" json:"Id,omitempty"`
+	Level      uint32 `protobuf:"varint,3,opt,name=Level,proto3" json:"Level,omitempty"`
+	Refine     uint32 `protobuf:"varint,4,opt,name=Refine,proto3" json:"Refine,omitempty"`
+	Durability int32  `protobuf:"varint,5,opt,name=Durability,proto3" json:"Durability,omitempty"`
+}
+
+func (x *EquipInfo) Reset() {
+	*x = EquipInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[58]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EquipInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EquipInfo) ProtoMessage() {}
+
+func (x *EquipInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[58]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EquipInfo.ProtoReflect.Descriptor instead.
+func (*EquipInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *EquipInfo) GetUid() uint64 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+func (x *EquipInfo) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *EquipInfo) GetLevel() uint32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *EquipInfo) GetRefine() uint32 {
+	if x != nil {
+		return x.Refine
+	}
+	return 0
+}
+
+func (x *EquipInfo) GetDurability() int32 {
+	if x != nil {
+		return x.Durability
+	}
+	return 0
+}
+
+type FamilyMember struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id           uint64 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Name         string `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	Position     int32  `protobuf:"varint,3,opt,name=Position,proto3" json:"Position,omitempty"`
+	Contribution int64  `protobuf:"varint,4,opt,name=Contribution,proto3" json:"Contribution,omitempty"`
+	JoinTime     int64  `protobuf:"varint,5,opt,name=JoinTime,proto3" json:"JoinTime,omitempty"`
+}
+
+func (x *FamilyMember) Reset() {
+	*x = FamilyMember{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[59]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FamilyMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FamilyMember) ProtoMessage() {}
+
+func (x *FamilyMember) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[59]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FamilyMember.ProtoReflect.Descriptor instead.
+func (*FamilyMember) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *FamilyMember) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *FamilyMember) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FamilyMember) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *FamilyMember) GetContribution() int64 {
+	if x != nil {
+		return x.Contribution
+	}
+	return 0
+}
+
+func (x *FamilyMember) GetJoinTime() int64 {
+	if x != nil {
+		return x.JoinTime
+	}
+	return 0
+}
+
+type FamilyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name     string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	PlayerId uint64 `protobuf:"varint,2,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+	Time     int64  `protobuf:"varint,3,opt,name=Time,proto3" json:"Time,omitempty"`
+}
+
+func (x *FamilyRequest) Reset() {
+	*x = FamilyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[60]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FamilyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FamilyRequest) ProtoMessage() {}
+
+func (x *FamilyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[60]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FamilyRequest.ProtoReflect.Descriptor instead.
+func (*FamilyRequest) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *FamilyRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FamilyRequest) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+func (x *FamilyRequest) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+type MonsterInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uid    uint64  `protobuf:"varint,1,opt,name=Uid,proto3" json:"Uid,omitempty"`
+	ConfId uint32  `protobuf:"varint,2,opt,name=ConfId,proto3" json:"ConfId,omitempty"`
+	X      float64 `protobuf:"fixed64,3,opt,name=X,proto3" json:"X,omitempty"`
+	Z      float64 `protobuf:"fixed64,4,opt,name=Z,proto3" json:"Z,omitempty"`
+	State  int32   `protobuf:"varint,5,opt,name=State,proto3" json:"State,omitempty"`
+	Hp     int64   `protobuf:"varint,6,opt,name=Hp,proto3" json:"Hp,omitempty"`
+}
+
+func (x *MonsterInfo) Reset() {
+	*x = MonsterInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[61]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MonsterInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MonsterInfo) ProtoMessage() {}
+
+func (x *MonsterInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[61]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MonsterInfo.ProtoReflect.Descriptor instead.
+func (*MonsterInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *MonsterInfo) GetUid() uint64 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+func (x *MonsterInfo) GetConfId() uint32 {
+	if x != nil {
+		return x.ConfId
+	}
+	return 0
+}
+
+func (x *MonsterInfo) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *MonsterInfo) GetZ() float64 {
+	if x != nil {
+		return x.Z
+	}
+	return 0
+}
+
+func (x *MonsterInfo) GetState() int32 {
+	if x != nil {
+		return x.State
+	}
+	return 0
+}
+
+func (x *MonsterInfo) GetHp() int64 {
+	if x != nil {
+		return x.Hp
+	}
+	return 0
+}
+
+type SCAchievementList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Stats        map[string]int64   `protobuf:"bytes,1,rep,name=Stats,proto3" json:"Stats,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	Achievements []*AchievementInfo `protobuf:"bytes,2,rep,name=Achievements,proto3" json:"Achievements,omitempty"`
+}
+
+func (x *SCAchievementList) Reset() {
+	*x = SCAchievementList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[62]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCAchievementList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCAchievementList) ProtoMessage() {}
+
+func (x *SCAchievementList) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[62]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCAchievementList.ProtoReflect.Descriptor instead.
+func (*SCAchievementList) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *SCAchievementList) GetStats() map[string]int64 {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+func (x *SCAchievementList) GetAchievements() []*AchievementInfo {
+	if x != nil {
+		return x.Achievements
+	}
+	return nil
+}
+
+type SCAchievementUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Achievement *AchievementInfo `protobuf:"bytes,1,opt,name=Achievement,proto3" json:"Achievement,omitempty"`
+}
+
+func (x *SCAchievementUpdate) Reset() {
+	*x = SCAchievementUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[63]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCAchievementUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCAchievementUpdate) ProtoMessage() {}
+
+func (x *SCAchievementUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[63]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCAchievementUpdate.ProtoReflect.Descriptor instead.
+func (*SCAchievementUpdate) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *SCAchievementUpdate) GetAchievement() *AchievementInfo {
+	if x != nil {
+		return x.Achievement
+	}
+	return nil
+}
+
+type SCActivityUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Activities []*ActivityInfo `protobuf:"bytes,1,rep,name=Activities,proto3" json:"Activities,omitempty"`
+}
+
+func (x *SCActivityUpdate) Reset() {
+	*x = SCActivityUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[64]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCActivityUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCActivityUpdate) ProtoMessage() {}
+
+func (x *SCActivityUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[64]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCActivityUpdate.ProtoReflect.Descriptor instead.
+func (*SCActivityUpdate) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *SCActivityUpdate) GetActivities() []*ActivityInfo {
+	if x != nil {
+		return x.Activities
+	}
+	return nil
+}
+
+type SCAddFriend struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SCAddFriend) Reset() {
+	*x = SCAddFriend{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[65]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCAddFriend) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCAddFriend) ProtoMessage() {}
+
+func (x *SCAddFriend) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[65]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCAddFriend.ProtoReflect.Descriptor instead.
+func (*SCAddFriend) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{65}
+}
+
+type SCArenaDefended struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Record *ArenaRecord `protobuf:"bytes,1,opt,name=Record,proto3" json:"Record,omitempty"`
+}
+
+func (x *SCArenaDefended) Reset() {
+	*x = SCArenaDefended{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[66]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCArenaDefended) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCArenaDefended) ProtoMessage() {}
+
+func (x *SCArenaDefended) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[66]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCArenaDefended.ProtoReflect.Descriptor instead.
+func (*SCArenaDefended) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *SCArenaDefended) GetRecord() *ArenaRecord {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+type SCArenaDefense struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ok bool `protobuf:"varint,1,opt,name=Ok,proto3" json:"Ok,omitempty"`
+}
+
+func (x *SCArenaDefense) Reset() {
+	*x = SCArenaDefense{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[67]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCArenaDefense) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCArenaDefense) ProtoMessage() {}
+
+func (x *SCArenaDefense) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[67]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCArenaDefense.ProtoReflect.Descriptor instead.
+func (*SCArenaDefense) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *SCArenaDefense) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+type SCArenaFight struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ok     bool         `protobuf:"varint,1,opt,name=Ok,proto3" json:"Ok,omitempty"`
+	Record *ArenaRecord `protobuf:"bytes,2,opt,name=Record,proto3" json:"Record,omitempty"`
+	Target uint64       `protobuf:"varint,3,opt,name=Target,proto3" json:"Target,omitempty"`
+}
+
+func (x *SCArenaFight) Reset() {
+	*x = SCArenaFight{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[68]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCArenaFight) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCArenaFight) ProtoMessage() {}
+
+func (x *SCArenaFight) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[68]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCArenaFight.ProtoReflect.Descriptor instead.
+func (*SCArenaFight) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *SCArenaFight) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SCArenaFight) GetRecord() *ArenaRecord {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *SCArenaFight) GetTarget() uint64 {
+	if x != nil {
+		return x.Target
+	}
+	return 0
+}
+
+type SCArenaInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Place    int64           `protobuf:"varint,1,opt,name=Place,proto3" json:"Place,omitempty"`
+	Points   int64           `protobuf:"varint,2,opt,name=Points,proto3" json:"Points,omitempty"`
+	Revenges []*ArenaRevenge `protobuf:"bytes,3,rep,name=Revenges,proto3" json:"Revenges,omitempty"`
+	Records  []*ArenaRecord  `protobuf:"bytes,4,rep,name=Records,proto3" json:"Records,omitempty"`
+}
+
+func (x *SCArenaInfo) Reset() {
+	*x = SCArenaInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[69]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCArenaInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCArenaInfo) ProtoMessage() {}
+
+func (x *SCArenaInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[69]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCArenaInfo.ProtoReflect.Descriptor instead.
+func (*SCArenaInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *SCArenaInfo) GetPlace() int64 {
+	if x != nil {
+		return x.Place
+	}
+	return 0
+}
+
+func (x *SCArenaInfo) GetPoints() int64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+func (x *SCArenaInfo) GetRevenges() []*ArenaRevenge {
+	if x != nil {
+		return x.Revenges
+	}
+	return nil
+}
+
+func (x *SCArenaInfo) GetRecords() []*ArenaRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type SCArenaOpponents struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Opponents []*ArenaOpponent `protobuf:"bytes,1,rep,name=Opponents,proto3" json:"Opponents,omitempty"`
+}
+
+func (x *SCArenaOpponents) Reset() {
+	*x = SCArenaOpponents{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[70]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCArenaOpponents) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCArenaOpponents) ProtoMessage() {}
+
+func (x *SCArenaOpponents) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[70]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCArenaOpponents.ProtoReflect.Descriptor instead.
+func (*SCArenaOpponents) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *SCArenaOpponents) GetOpponents() []*ArenaOpponent {
+	if x != nil {
+		return x.Opponents
+	}
+	return nil
+}
+
+type SCAuctionResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id      uint64          `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Ok      bool            `protobuf:"varint,2,opt,name=Ok,proto3" json:"Ok,omitempty"`
+	Op      AuctionOp       `protobuf:"varint,3,opt,name=Op,proto3,enum=protocol.AuctionOp" json:"Op,omitempty"`
+	Listing *AuctionListing `protobuf:"bytes,4,opt,name=Listing,proto3" json:"Listing,omitempty"`
+}
+
+func (x *SCAuctionResult) Reset() {
+	*x = SCAuctionResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[71]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCAuctionResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCAuctionResult) ProtoMessage() {}
+
+func (x *SCAuctionResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[71]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCAuctionResult.ProtoReflect.Descriptor instead.
+func (*SCAuctionResult) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{71}
+}
+
+func (x *SCAuctionResult) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SCAuctionResult) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SCAuctionResult) GetOp() AuctionOp {
+	if x != nil {
+		return x.Op
+	}
+	return AuctionOp_AuctionOpNone
+}
+
+func (x *SCAuctionResult) GetListing() *AuctionListing {
+	if x != nil {
+		return x.Listing
+	}
+	return nil
+}
+
+type SCAuctionSearch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Page     int64             `protobuf:"varint,1,opt,name=Page,proto3" json:"Page,omitempty"`
+	Total    int64             `protobuf:"varint,2,opt,name=Total,proto3" json:"Total,omitempty"`
+	Listings []*AuctionListing `protobuf:"bytes,3,rep,name=Listings,proto3" json:"Listings,omitempty"`
+}
+
+func (x *SCAuctionSearch) Reset() {
+	*x = SCAuctionSearch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[72]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCAuctionSearch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCAuctionSearch) ProtoMessage() {}
+
+func (x *SCAuctionSearch) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[72]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCAuctionSearch.ProtoReflect.Descriptor instead.
+func (*SCAuctionSearch) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *SCAuctionSearch) GetPage() int64 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *SCAuctionSearch) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *SCAuctionSearch) GetListings() []*AuctionListing {
+	if x != nil {
+		return x.Listings
+	}
+	return nil
+}
+
+type SCBagChange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Capacity int32            `protobuf:"varint,1,opt,name=Capacity,proto3" json:"Capacity,omitempty"`
+	Items    []*BagItemChange `protobuf:"bytes,2,rep,name=Items,proto3" json:"Items,omitempty"`
+}
+
+func (x *SCBagChange) Reset() {
+	*x = SCBagChange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[73]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCBagChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCBagChange) ProtoMessage() {}
+
+func (x *SCBagChange) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[73]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCBagChange.ProtoReflect.Descriptor instead.
+func (*SCBagChange) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{73}
+}
+
+func (x *SCBagChange) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *SCBagChange) GetItems() []*BagItemChange {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type SCBattleEnd struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	InstanceId uint64 `protobuf:"varint,1,opt,name=InstanceId,proto3" json:"InstanceId,omitempty"`
+	Winner     int32  `protobuf:"varint,2,opt,name=Winner,proto3" json:"Winner,omitempty"`
+	Won        bool   `protobuf:"varint,3,opt,name=Won,proto3" json:"Won,omitempty"`
+	Kills      int32  `protobuf:"varint,4,opt,name=Kills,proto3" json:"Kills,omitempty"`
+	Damage     int64  `protobuf:"varint,5,opt,name=Damage,proto3" json:"Damage,omitempty"`
+}
+
+func (x *SCBattleEnd) Reset() {
+	*x = SCBattleEnd{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[74]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCBattleEnd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCBattleEnd) ProtoMessage() {}
+
+func (x *SCBattleEnd) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[74]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCBattleEnd.ProtoReflect.Descriptor instead.
+func (*SCBattleEnd) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{74}
+}
+
+func (x *SCBattleEnd) GetInstanceId() uint64 {
+	if x != nil {
+		return x.InstanceId
+	}
+	return 0
+}
+
+func (x *SCBattleEnd) GetWinner() int32 {
+	if x != nil {
+		return x.Winner
+	}
+	return 0
+}
+
+func (x *SCBattleEnd) GetWon() bool {
+	if x != nil {
+		return x.Won
+	}
+	return false
+}
+
+func (x *SCBattleEnd) GetKills() int32 {
+	if x != nil {
+		return x.Kills
+	}
+	return 0
+}
+
+func (x *SCBattleEnd) GetDamage() int64 {
+	if x != nil {
+		return x.Damage
+	}
+	return 0
+}
+
+type SCBattleFrame struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	InstanceId uint64         `protobuf:"varint,1,opt,name=InstanceId,proto3" json:"InstanceId,omitempty"`
+	Tick       int64          `protobuf:"varint,2,opt,name=Tick,proto3" json:"Tick,omitempty"`
+	Events     []*BattleEvent `protobuf:"bytes,3,rep,name=Events,proto3" json:"Events,omitempty"`
+}
+
+func (x *SCBattleFrame) Reset() {
+	*x = SCBattleFrame{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[75]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCBattleFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCBattleFrame) ProtoMessage() {}
+
+func (x *SCBattleFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[75]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCBattleFrame.ProtoReflect.Descriptor instead.
+func (*SCBattleFrame) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *SCBattleFrame) GetInstanceId() uint64 {
+	if x != nil {
+		return x.InstanceId
+	}
+	return 0
+}
+
+func (x *SCBattleFrame) GetTick() int64 {
+	if x != nil {
+		return x.Tick
+	}
+	return 0
+}
+
+func (x *SCBattleFrame) GetEvents() []*BattleEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type SCBattlePassUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Passes []*BattlePassInfo `protobuf:"bytes,1,rep,name=Passes,proto3" json:"Passes,omitempty"`
+}
+
+func (x *SCBattlePassUpdate) Reset() {
+	*x = SCBattlePassUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[76]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCBattlePassUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCBattlePassUpdate) ProtoMessage() {}
+
+func (x *SCBattlePassUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[76]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCBattlePassUpdate.ProtoReflect.Descriptor instead.
+func (*SCBattlePassUpdate) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{76}
+}
+
+func (x *SCBattlePassUpdate) GetPasses() []*BattlePassInfo {
+	if x != nil {
+		return x.Passes
+	}
+	return nil
+}
+
+type SCBattleResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ok bool     `protobuf:"varint,1,opt,name=Ok,proto3" json:"Ok,omitempty"`
+	Op BattleOp `protobuf:"varint,2,opt,name=Op,proto3,enum=protocol.BattleOp" json:"Op,omitempty"`
+}
+
+func (x *SCBattleResult) Reset() {
+	*x = SCBattleResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[77]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCBattleResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCBattleResult) ProtoMessage() {}
+
+func (x *SCBattleResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[77]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCBattleResult.ProtoReflect.Descriptor instead.
+func (*SCBattleResult) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{77}
+}
+
+func (x *SCBattleResult) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SCBattleResult) GetOp() BattleOp {
+	if x != nil {
+		return x.Op
+	}
+	return BattleOp_BattleOpNone
+}
+
+type SCBroadcast struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Template uint32            `protobuf:"varint,1,opt,name=Template,proto3" json:"Template,omitempty"`
+	Params   map[string]string `protobuf:"bytes,2,rep,name=Params,proto3" json:"Params,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Text     string            `protobuf:"bytes,3,opt,name=Text,proto3" json:"Text,omitempty"`
+	Kind     int32             `protobuf:"varint,4,opt,name=Kind,proto3" json:"Kind,omitempty"`
+	Priority int32             `protobuf:"varint,5,opt,name=Priority,proto3" json:"Priority,omitempty"`
+	Repeat   int32             `protobuf:"varint,6,opt,name=Repeat,proto3" json:"Repeat,omitempty"`
+	Time     int64             `protobuf:"varint,7,opt,name=Time,proto3" json:"Time,omitempty"`
+}
+
+func (x *SCBroadcast) Reset() {
+	*x = SCBroadcast{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[78]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCBroadcast) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCBroadcast) ProtoMessage() {}
+
+func (x *SCBroadcast) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[78]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCBroadcast.ProtoReflect.Descriptor instead.
+func (*SCBroadcast) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{78}
+}
+
+func (x *SCBroadcast) GetTemplate() uint32 {
+	if x != nil {
+		return x.Template
+	}
+	return 0
+}
+
+func (x *SCBroadcast) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *SCBroadcast) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SCBroadcast) GetKind() int32 {
+	if x != nil {
+		return x.Kind
+	}
+	return 0
+}
+
+func (x *SCBroadcast) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *SCBroadcast) GetRepeat() int32 {
+	if x != nil {
+		return x.Repeat
+	}
+	return 0
+}
+
+func (x *SCBroadcast) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+type SCBuffUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Buffs   []*BuffInfo   `protobuf:"bytes,1,rep,name=Buffs,proto3" json:"Buffs,omitempty"`
+	Effects []*BuffEffect `protobuf:"bytes,2,rep,name=Effects,proto3" json:"Effects,omitempty"`
+}
+
+func (x *SCBuffUpdate) Reset() {
+	*x = SCBuffUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[79]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCBuffUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCBuffUpdate) ProtoMessage() {}
+
+func (x *SCBuffUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[79]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCBuffUpdate.ProtoReflect.Descriptor instead.
+func (*SCBuffUpdate) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{79}
+}
+
+func (x *SCBuffUpdate) GetBuffs() []*BuffInfo {
+	if x != nil {
+		return x.Buffs
+	}
+	return nil
+}
+
+func (x *SCBuffUpdate) GetEffects() []*BuffEffect {
+	if x != nil {
+		return x.Effects
+	}
+	return nil
+}
+
+type SCBuyGoods struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Count  int64      `protobuf:"varint,1,opt,name=Count,proto3" json:"Count,omitempty"`
+	Goods  *ShopGoods `protobuf:"bytes,2,opt,name=Goods,proto3" json:"Goods,omitempty"`
+	ShopId uint32     `protobuf:"varint,3,opt,name=ShopId,proto3" json:"ShopId,omitempty"`
+}
+
+func (x *SCBuyGoods) Reset() {
+	*x = SCBuyGoods{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[80]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCBuyGoods) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCBuyGoods) ProtoMessage() {}
+
+func (x *SCBuyGoods) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[80]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCBuyGoods.ProtoReflect.Descriptor instead.
+func (*SCBuyGoods) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{80}
+}
+
+func (x *SCBuyGoods) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *SCBuyGoods) GetGoods() *ShopGoods {
+	if x != nil {
+		return x.Goods
+	}
+	return nil
+}
+
+func (x *SCBuyGoods) GetShopId() uint32 {
+	if x != nil {
+		return x.ShopId
+	}
+	return 0
+}
+
+type SCCurrencyChange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Currency *CurrencyInfo `protobuf:"bytes,1,opt,name=Currency,proto3" json:"Currency,omitempty"`
+	Delta    int64         `protobuf:"varint,2,opt,name=Delta,proto3" json:"Delta,omitempty"`
+	Reason   string        `protobuf:"bytes,3,opt,name=Reason,proto3" json:"Reason,omitempty"`
+}
+
+func (x *SCCurrencyChange) Reset() {
+	*x = SCCurrencyChange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[81]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCCurrencyChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCCurrencyChange) ProtoMessage() {}
+
+func (x *SCCurrencyChange) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[81]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCCurrencyChange.ProtoReflect.Descriptor instead.
+func (*SCCurrencyChange) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{81}
+}
+
+func (x *SCCurrencyChange) GetCurrency() *CurrencyInfo {
+	if x != nil {
+		return x.Currency
+	}
+	return nil
+}
+
+func (x *SCCurrencyChange) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *SCCurrencyChange) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type SCCurrencyList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Currencies []*CurrencyInfo `protobuf:"bytes,1,rep,name=Currencies,proto3" json:"Currencies,omitempty"`
+}
+
+func (x *SCCurrencyList) Reset() {
+	*x = SCCurrencyList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[82]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCCurrencyList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCCurrencyList) ProtoMessage() {}
+
+func (x *SCCurrencyList) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[82]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCCurrencyList.ProtoReflect.Descriptor instead.
+func (*SCCurrencyList) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{82}
+}
+
+func (x *SCCurrencyList) GetCurrencies() []*CurrencyInfo {
+	if x != nil {
+		return x.Currencies
+	}
+	return nil
+}
+
+type SCEquipUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Part    int32      `protobuf:"varint,1,opt,name=Part,proto3" json:"Part,omitempty"`
+	Worn    bool       `protobuf:"varint,2,opt,name=Worn,proto3" json:"Worn,omitempty"`
+	Success bool       `protobuf:"varint,3,opt,name=Success,proto3" json:"Success,omitempty"`
+	Equip   *EquipInfo `protobuf:"bytes,4,opt,name=Equip,proto3" json:"Equip,omitempty"`
+}
+
+func (x *SCEquipUpdate) Reset() {
+	*x = SCEquipUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[83]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCEquipUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCEquipUpdate) ProtoMessage() {}
+
+func (x *SCEquipUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[83]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCEquipUpdate.ProtoReflect.Descriptor instead.
+func (*SCEquipUpdate) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{83}
+}
+
+func (x *SCEquipUpdate) GetPart() int32 {
+	if x != nil {
+		return x.Part
+	}
+	return 0
+}
+
+func (x *SCEquipUpdate) GetWorn() bool {
+	if x != nil {
+		return x.Worn
+	}
+	return false
+}
+
+func (x *SCEquipUpdate) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SCEquipUpdate) GetEquip() *EquipInfo {
+	if x != nil {
+		return x.Equip
+	}
+	return nil
+}
+
+type SCFamilyInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id         uint64           `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Name       string           `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	Notice     string           `protobuf:"bytes,3,opt,name=Notice,proto3" json:"Notice,omitempty"`
+	Leader     uint64           `protobuf:"varint,4,opt,name=Leader,proto3" json:"Leader,omitempty"`
+	Level      int32            `protobuf:"varint,5,opt,name=Level,proto3" json:"Level,omitempty"`
+	Exp        int64            `protobuf:"varint,6,opt,name=Exp,proto3" json:"Exp,omitempty"`
+	AutoJoin   bool             `protobuf:"varint,7,opt,name=AutoJoin,proto3" json:"AutoJoin,omitempty"`
+	MinLevel   uint32           `protobuf:"varint,8,opt,name=MinLevel,proto3" json:"MinLevel,omitempty"`
+	MaxMembers int32            `protobuf:"varint,9,opt,name=MaxMembers,proto3" json:"MaxMembers,omitempty"`
+	Treasury   map[int32]int64  `protobuf:"bytes,10,rep,name=Treasury,proto3" json:"Treasury,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	Members    []*FamilyMember  `protobuf:"bytes,11,rep,name=Members,proto3" json:"Members,omitempty"`
+	Requests   []*FamilyRequest `protobuf:"bytes,12,rep,name=Requests,proto3" json:"Requests,omitempty"`
+}
+
+func (x *SCFamilyInfo) Reset() {
+	*x = SCFamilyInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[84]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCFamilyInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCFamilyInfo) ProtoMessage() {}
+
+func (x *SCFamilyInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[84]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCFamilyInfo.ProtoReflect.Descriptor instead.
+func (*SCFamilyInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{84}
+}
+
+func (x *SCFamilyInfo) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SCFamilyInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SCFamilyInfo) GetNotice() string {
+	if x != nil {
+		return x.Notice
+	}
+	return ""
+}
+
+func (x *SCFamilyInfo) GetLeader() uint64 {
+	if x != nil {
+		return x.Leader
+	}
+	return 0
+}
+
+func (x *SCFamilyInfo) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *SCFamilyInfo) GetExp() int64 {
+	if x != nil {
+		return x.Exp
+	}
+	return 0
+}
+
+func (x *SCFamilyInfo) GetAutoJoin() bool {
+	if x != nil {
+		return x.AutoJoin
+	}
+	return false
+}
+
+func (x *SCFamilyInfo) GetMinLevel() uint32 {
+	if x != nil {
+		return x.MinLevel
+	}
+	return 0
+}
+
+func (x *SCFamilyInfo) GetMaxMembers() int32 {
+	if x != nil {
+		return x.MaxMembers
+	}
+	return 0
+}
+
+func (x *SCFamilyInfo) GetTreasury() map[int32]int64 {
+	if x != nil {
+		return x.Treasury
+	}
+	return nil
+}
+
+func (x *SCFamilyInfo) GetMembers() []*FamilyMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *SCFamilyInfo) GetRequests() []*FamilyRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type SCFamilyInvite struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FamilyId uint64 `protobuf:"varint,1,opt,name=FamilyId,proto3" json:"FamilyId,omitempty"`
+	From     uint64 `protobuf:"varint,2,opt,name=From,proto3" json:"From,omitempty"`
+	Name     string `protobuf:"bytes,3,opt,name=Name,proto3" json:"Name,omitempty"`
+}
+
+func (x *SCFamilyInvite) Reset() {
+	*x = SCFamilyInvite{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[85]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCFamilyInvite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCFamilyInvite) ProtoMessage() {}
+
+func (x *SCFamilyInvite) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[85]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCFamilyInvite.ProtoReflect.Descriptor instead.
+func (*SCFamilyInvite) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{85}
+}
+
+func (x *SCFamilyInvite) GetFamilyId() uint64 {
+	if x != nil {
+		return x.FamilyId
+	}
+	return 0
+}
+
+func (x *SCFamilyInvite) GetFrom() uint64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *SCFamilyInvite) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type SCFamilyLeft struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FamilyId uint64 `protobuf:"varint,1,opt,name=FamilyId,proto3" json:"FamilyId,omitempty"`
+	Kicked   bool   `protobuf:"varint,2,opt,name=Kicked,proto3" json:"Kicked,omitempty"`
+}
+
+func (x *SCFamilyLeft) Reset() {
+	*x = SCFamilyLeft{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[86]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCFamilyLeft) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCFamilyLeft) ProtoMessage() {}
+
+func (x *SCFamilyLeft) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[86]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCFamilyLeft.ProtoReflect.Descriptor instead.
+func (*SCFamilyLeft) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{86}
+}
+
+func (x *SCFamilyLeft) GetFamilyId() uint64 {
+	if x != nil {
+		return x.FamilyId
+	}
+	return 0
+}
+
+func (x *SCFamilyLeft) GetKicked() bool {
+	if x != nil {
+		return x.Kicked
+	}
+	return false
+}
+
+type SCFamilyResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ok bool     `protobuf:"varint,1,opt,name=Ok,proto3" json:"Ok,omitempty"`
+	Op FamilyOp `protobuf:"varint,2,opt,name=Op,proto3,enum=protocol.FamilyOp" json:"Op,omitempty"`
+}
+
+func (x *SCFamilyResult) Reset() {
+	*x = SCFamilyResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[87]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCFamilyResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCFamilyResult) ProtoMessage() {}
+
+func (x *SCFamilyResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[87]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCFamilyResult.ProtoReflect.Descriptor instead.
+func (*SCFamilyResult) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{87}
+}
+
+func (x *SCFamilyResult) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SCFamilyResult) GetOp() FamilyOp {
+	if x != nil {
+		return x.Op
+	}
+	return FamilyOp_FamilyOpNone
+}
+
+type SCFriendList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Friends  []uint64 `protobuf:"varint,1,rep,packed,name=Friends,proto3" json:"Friends,omitempty"`
+	Requests []uint64 `protobuf:"varint,2,rep,packed,name=Requests,proto3" json:"Requests,omitempty"`
+}
+
+func (x *SCFriendList) Reset() {
+	*x = SCFriendList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[88]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCFriendList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCFriendList) ProtoMessage() {}
+
+func (x *SCFriendList) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[88]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCFriendList.ProtoReflect.Descriptor instead.
+func (*SCFriendList) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{88}
+}
+
+func (x *SCFriendList) GetFriends() []uint64 {
+	if x != nil {
+		return x.Friends
+	}
+	return nil
+}
+
+func (x *SCFriendList) GetRequests() []uint64 {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type SCFriendStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Online bool   `protobuf:"varint,1,opt,name=Online,proto3" json:"Online,omitempty"`
+	UId    uint64 `protobuf:"varint,2,opt,name=UId,proto3" json:"UId,omitempty"`
+}
+
+func (x *SCFriendStatus) Reset() {
+	*x = SCFriendStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[89]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCFriendStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCFriendStatus) ProtoMessage() {}
+
+func (x *SCFriendStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[89]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCFriendStatus.ProtoReflect.Descriptor instead.
+func (*SCFriendStatus) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{89}
+}
+
+func (x *SCFriendStatus) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+func (x *SCFriendStatus) GetUId() uint64 {
+	if x != nil {
+		return x.UId
+	}
+	return 0
+}
+
+type SCKick struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reason int32 `protobuf:"varint,1,opt,name=Reason,proto3" json:"Reason,omitempty"`
+}
+
+func (x *SCKick) Reset() {
+	*x = SCKick{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[90]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCKick) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCKick) ProtoMessage() {}
+
+func (x *SCKick) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[90]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCKick.ProtoReflect.Descriptor instead.
+func (*SCKick) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{90}
+}
+
+func (x *SCKick) GetReason() int32 {
+	if x != nil {
+		return x.Reason
+	}
+	return 0
+}
+
+type SCMatchResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ok bool    `protobuf:"varint,1,opt,name=Ok,proto3" json:"Ok,omitempty"`
+	Op MatchOp `protobuf:"varint,2,opt,name=Op,proto3,enum=protocol.MatchOp" json:"Op,omitempty"`
+}
+
+func (x *SCMatchResult) Reset() {
+	*x = SCMatchResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[91]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCMatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCMatchResult) ProtoMessage() {}
+
+func (x *SCMatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[91]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCMatchResult.ProtoReflect.Descriptor instead.
+func (*SCMatchResult) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{91}
+}
+
+func (x *SCMatchResult) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SCMatchResult) GetOp() MatchOp {
+	if x != nil {
+		return x.Op
+	}
+	return MatchOp_MatchOpNone
+}
+
+type SCMatchStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status     MatchStatus `protobuf:"varint,1,opt,name=Status,proto3,enum=protocol.MatchStatus" json:"Status,omitempty"`
+	Mode       uint32      `protobuf:"varint,2,opt,name=Mode,proto3" json:"Mode,omitempty"`
+	InstanceId uint64      `protobuf:"varint,3,opt,name=InstanceId,proto3" json:"InstanceId,omitempty"`
+	Team       int32       `protobuf:"varint,4,opt,name=Team,proto3" json:"Team,omitempty"`
+}
+
+func (x *SCMatchStatus) Reset() {
+	*x = SCMatchStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[92]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCMatchStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCMatchStatus) ProtoMessage() {}
+
+func (x *SCMatchStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[92]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCMatchStatus.ProtoReflect.Descriptor instead.
+func (*SCMatchStatus) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{92}
+}
+
+func (x *SCMatchStatus) GetStatus() MatchStatus {
+	if x != nil {
+		return x.Status
+	}
+	return MatchStatus_MatchNone
+}
+
+func (x *SCMatchStatus) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *SCMatchStatus) GetInstanceId() uint64 {
+	if x != nil {
+		return x.InstanceId
+	}
+	return 0
+}
+
+func (x *SCMatchStatus) GetTeam() int32 {
+	if x != nil {
+		return x.Team
+	}
+	return 0
+}
+
+type SCMonsterAttack struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Damage   int64  `protobuf:"varint,1,opt,name=Damage,proto3" json:"Damage,omitempty"`
+	PlayerId uint64 `protobuf:"varint,2,opt,name=PlayerId,proto3" json:"PlayerId,omitempty"`
+	Uid      uint64 `protobuf:"varint,3,opt,name=Uid,proto3" json:"Uid,omitempty"`
+}
+
+func (x *SCMonsterAttack) Reset() {
+	*x = SCMonsterAttack{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[93]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCMonsterAttack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCMonsterAttack) ProtoMessage() {}
+
+func (x *SCMonsterAttack) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[93]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCMonsterAttack.ProtoReflect.Descriptor instead.
+func (*SCMonsterAttack) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{93}
+}
+
+func (x *SCMonsterAttack) GetDamage() int64 {
+	if x != nil {
+		return x.Damage
+	}
+	return 0
+}
+
+func (x *SCMonsterAttack) GetPlayerId() uint64 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+func (x *SCMonsterAttack) GetUid() uint64 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+type SCMonsterResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ok bool      `protobuf:"varint,1,opt,name=Ok,proto3" json:"Ok,omitempty"`
+	Op MonsterOp `protobuf:"varint,2,opt,name=Op,proto3,enum=protocol.MonsterOp" json:"Op,omitempty"`
+}
+
+func (x *SCMonsterResult) Reset() {
+	*x = SCMonsterResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[94]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCMonsterResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCMonsterResult) ProtoMessage() {}
+
+func (x *SCMonsterResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[94]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCMonsterResult.ProtoReflect.Descriptor instead.
+func (*SCMonsterResult) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{94}
+}
+
+func (x *SCMonsterResult) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SCMonsterResult) GetOp() MonsterOp {
+	if x != nil {
+		return x.Op
+	}
+	return MonsterOp_MonsterOpNone
+}
+
+type SCMonsterUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SceneId  uint32         `protobuf:"varint,1,opt,name=SceneId,proto3" json:"SceneId,omitempty"`
+	Monsters []*MonsterInfo `protobuf:"bytes,2,rep,name=Monsters,proto3" json:"Monsters,omitempty"`
+}
+
+func (x *SCMonsterUpdate) Reset() {
+	*x = SCMonsterUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[95]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCMonsterUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCMonsterUpdate) ProtoMessage() {}
+
+func (x *SCMonsterUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[95]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCMonsterUpdate.ProtoReflect.Descriptor instead.
+func (*SCMonsterUpdate) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{95}
+}
+
+func (x *SCMonsterUpdate) GetSceneId() uint32 {
+	if x != nil {
+		return x.SceneId
+	}
+	return 0
+}
+
+func (x *SCMonsterUpdate) GetMonsters() []*MonsterInfo {
+	if x != nil {
+		return x.Monsters
+	}
+	return nil
+}
+
+type SCPlayerAttr struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Attrs []*AttrValue `protobuf:"bytes,1,rep,name=Attrs,proto3" json:"Attrs,omitempty"`
+}
+
+func (x *SCPlayerAttr) Reset() {
+	*x = SCPlayerAttr{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[96]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCPlayerAttr) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCPlayerAttr) ProtoMessage() {}
+
+func (x *SCPlayerAttr) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[96]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCPlayerAttr.ProtoReflect.Descriptor instead.
+func (*SCPlayerAttr) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{96}
+}
+
+func (x *SCPlayerAttr) GetAttrs() []*AttrValue {
+	if x != nil {
+		return x.Attrs
+	}
+	return nil
+}
+
+type SCPlayerStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Stats map[string]int64 `protobuf:"bytes,1,rep,name=Stats,proto3" json:"Stats,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+func (x *SCPlayerStats) Reset() {
+	*x = SCPlayerStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[97]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCPlayerStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCPlayerStats) ProtoMessage() {}
+
+func (x *SCPlayerStats) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[97]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCPlayerStats.ProtoReflect.Descriptor instead.
+func (*SCPlayerStats) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{97}
+}
+
+func (x *SCPlayerStats) GetStats() map[string]int64 {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+type SCRecharge struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProductId   uint32 `protobuf:"varint,1,opt,name=ProductId,proto3" json:"ProductId,omitempty"`
+	Transaction string `protobuf:"bytes,2,opt,name=Transaction,proto3" json:"Transaction,omitempty"`
+	OrderId     string `protobuf:"bytes,3,opt,name=OrderId,proto3" json:"OrderId,omitempty"`
+	Result      int32  `protobuf:"varint,4,opt,name=Result,proto3" json:"Result,omitempty"`
+}
+
+func (x *SCRecharge) Reset() {
+	*x = SCRecharge{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[98]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCRecharge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCRecharge) ProtoMessage() {}
+
+func (x *SCRecharge) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[98]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCRecharge.ProtoReflect.Descriptor instead.
+func (*SCRecharge) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{98}
+}
+
+func (x *SCRecharge) GetProductId() uint32 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *SCRecharge) GetTransaction() string {
+	if x != nil {
+		return x.Transaction
+	}
+	return ""
+}
+
+func (x *SCRecharge) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *SCRecharge) GetResult() int32 {
+	if x != nil {
+		return x.Result
+	}
+	return 0
+}
+
+type SCShopList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Shops []*ShopInfo `protobuf:"bytes,1,rep,name=Shops,proto3" json:"Shops,omitempty"`
+}
+
+func (x *SCShopList) Reset() {
+	*x = SCShopList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[99]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCShopList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCShopList) ProtoMessage() {}
+
+func (x *SCShopList) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[99]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCShopList.ProtoReflect.Descriptor instead.
+func (*SCShopList) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{99}
+}
+
+func (x *SCShopList) GetShops() []*ShopInfo {
+	if x != nil {
+		return x.Shops
+	}
+	return nil
+}
+
+type SCSkillUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Skills []*SkillInfo `protobuf:"bytes,1,rep,name=Skills,proto3" json:"Skills,omitempty"`
+}
+
+func (x *SCSkillUpdate) Reset() {
+	*x = SCSkillUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[100]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCSkillUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCSkillUpdate) ProtoMessage() {}
+
+func (x *SCSkillUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[100]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCSkillUpdate.ProtoReflect.Descriptor instead.
+func (*SCSkillUpdate) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{100}
+}
+
+func (x *SCSkillUpdate) GetSkills() []*SkillInfo {
+	if x != nil {
+		return x.Skills
+	}
+	return nil
+}
+
+type SCTaskList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Tasks []*TaskInfo `protobuf:"bytes,1,rep,name=Tasks,proto3" json:"Tasks,omitempty"`
+}
+
+func (x *SCTaskList) Reset() {
+	*x = SCTaskList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[101]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCTaskList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCTaskList) ProtoMessage() {}
+
+func (x *SCTaskList) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[101]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCTaskList.ProtoReflect.Descriptor instead.
+func (*SCTaskList) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{101}
+}
+
+func (x *SCTaskList) GetTasks() []*TaskInfo {
+	if x != nil {
+		return x.Tasks
+	}
+	return nil
+}
+
+type SCTaskUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Task *TaskInfo `protobuf:"bytes,1,opt,name=Task,proto3" json:"Task,omitempty"`
+}
+
+func (x *SCTaskUpdate) Reset() {
+	*x = SCTaskUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[102]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCTaskUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCTaskUpdate) ProtoMessage() {}
+
+func (x *SCTaskUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[102]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCTaskUpdate.ProtoReflect.Descriptor instead.
+func (*SCTaskUpdate) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{102}
+}
+
+func (x *SCTaskUpdate) GetTask() *TaskInfo {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+type SCTeamInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id      uint64          `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Leader  uint64          `protobuf:"varint,2,opt,name=Leader,proto3" json:"Leader,omitempty"`
+	Members []*TeamMember   `protobuf:"bytes,3,rep,name=Members,proto3" json:"Members,omitempty"`
+	Check   *TeamReadyCheck `protobuf:"bytes,4,opt,name=Check,proto3" json:"Check,omitempty"`
+}
+
+func (x *SCTeamInfo) Reset() {
+	*x = SCTeamInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[103]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCTeamInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCTeamInfo) ProtoMessage() {}
+
+func (x *SCTeamInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[103]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCTeamInfo.ProtoReflect.Descriptor instead.
+func (*SCTeamInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{103}
+}
+
+func (x *SCTeamInfo) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SCTeamInfo) GetLeader() uint64 {
+	if x != nil {
+		return x.Leader
+	}
+	return 0
+}
+
+func (x *SCTeamInfo) GetMembers() []*TeamMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *SCTeamInfo) GetCheck() *TeamReadyCheck {
+	if x != nil {
+		return x.Check
+	}
+	return nil
+}
+
+type SCTeamInvite struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From   uint64 `protobuf:"varint,1,opt,name=From,proto3" json:"From,omitempty"`
+	TeamId uint64 `protobuf:"varint,2,opt,name=TeamId,proto3" json:"TeamId,omitempty"`
+}
+
+func (x *SCTeamInvite) Reset() {
+	*x = SCTeamInvite{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[104]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCTeamInvite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCTeamInvite) ProtoMessage() {}
+
+func (x *SCTeamInvite) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[104]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCTeamInvite.ProtoReflect.Descriptor instead.
+func (*SCTeamInvite) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{104}
+}
+
+func (x *SCTeamInvite) GetFrom() uint64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *SCTeamInvite) GetTeamId() uint64 {
+	if x != nil {
+		return x.TeamId
+	}
+	return 0
+}
+
+type SCTeamLeft struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Kicked bool   `protobuf:"varint,1,opt,name=Kicked,proto3" json:"Kicked,omitempty"`
+	TeamId uint64 `protobuf:"varint,2,opt,name=TeamId,proto3" json:"TeamId,omitempty"`
+}
+
+func (x *SCTeamLeft) Reset() {
+	*x = SCTeamLeft{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[105]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCTeamLeft) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCTeamLeft) ProtoMessage() {}
+
+func (x *SCTeamLeft) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[105]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCTeamLeft.ProtoReflect.Descriptor instead.
+func (*SCTeamLeft) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{105}
+}
+
+func (x *SCTeamLeft) GetKicked() bool {
+	if x != nil {
+		return x.Kicked
+	}
+	return false
+}
+
+func (x *SCTeamLeft) GetTeamId() uint64 {
+	if x != nil {
+		return x.TeamId
+	}
+	return 0
+}
+
+type SCTeamReady struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Target uint32 `protobuf:"varint,1,opt,name=Target,proto3" json:"Target,omitempty"`
+	TeamId uint64 `protobuf:"varint,2,opt,name=TeamId,proto3" json:"TeamId,omitempty"`
+}
+
+func (x *SCTeamReady) Reset() {
+	*x = SCTeamReady{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[106]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCTeamReady) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCTeamReady) ProtoMessage() {}
+
+func (x *SCTeamReady) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[106]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCTeamReady.ProtoReflect.Descriptor instead.
+func (*SCTeamReady) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{106}
+}
+
+func (x *SCTeamReady) GetTarget() uint32 {
+	if x != nil {
+		return x.Target
+	}
+	return 0
+}
+
+func (x *SCTeamReady) GetTeamId() uint64 {
+	if x != nil {
+		return x.TeamId
+	}
+	return 0
+}
+
+type SCTeamResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ok bool   `protobuf:"varint,1,opt,name=Ok,proto3" json:"Ok,omitempty"`
+	Op TeamOp `protobuf:"varint,2,opt,name=Op,proto3,enum=protocol.TeamOp" json:"Op,omitempty"`
+}
+
+func (x *SCTeamResult) Reset() {
+	*x = SCTeamResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[107]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCTeamResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCTeamResult) ProtoMessage() {}
+
+func (x *SCTeamResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[107]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCTeamResult.ProtoReflect.Descriptor instead.
+func (*SCTeamResult) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{107}
+}
+
+func (x *SCTeamResult) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SCTeamResult) GetOp() TeamOp {
+	if x != nil {
+		return x.Op
+	}
+	return TeamOp_TeamOpNone
+}
+
+type SCVipUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Level   uint32 `protobuf:"varint,1,opt,name=Level,proto3" json:"Level,omitempty"`
+	Exp     int64  `protobuf:"varint,2,opt,name=Exp,proto3" json:"Exp,omitempty"`
+	Claimed uint32 `protobuf:"varint,3,opt,name=Claimed,proto3" json:"Claimed,omitempty"`
+}
+
+func (x *SCVipUpdate) Reset() {
+	*x = SCVipUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[108]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCVipUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCVipUpdate) ProtoMessage() {}
+
+func (x *SCVipUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[108]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCVipUpdate.ProtoReflect.Descriptor instead.
+func (*SCVipUpdate) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{108}
+}
+
+func (x *SCVipUpdate) GetLevel() uint32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *SCVipUpdate) GetExp() int64 {
+	if x != nil {
+		return x.Exp
+	}
+	return 0
+}
+
+func (x *SCVipUpdate) GetClaimed() uint32 {
+	if x != nil {
+		return x.Claimed
+	}
+	return 0
+}
+
+type ShopGoods struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id     uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Today  uint64 `protobuf:"varint,2,opt,name=Today,proto3" json:"Today,omitempty"`
+	Weekly uint64 `protobuf:"varint,3,opt,name=Weekly,proto3" json:"Weekly,omitempty"`
+	Total  uint64 `protobuf:"varint,4,opt,name=Total,proto3" json:"Total,omitempty"`
+}
+
+func (x *ShopGoods) Reset() {
+	*x = ShopGoods{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[109]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ShopGoods) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShopGoods) ProtoMessage() {}
+
+func (x *ShopGoods) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[109]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShopGoods.ProtoReflect.Descriptor instead.
+func (*ShopGoods) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{109}
+}
+
+func (x *ShopGoods) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ShopGoods) GetToday() uint64 {
+	if x != nil {
+		return x.Today
+	}
+	return 0
+}
+
+func (x *ShopGoods) GetWeekly() uint64 {
+	if x != nil {
+		return x.Weekly
+	}
+	return 0
+}
+
+func (x *ShopGoods) GetTotal() uint64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type ShopInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id        uint32       `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	RefreshTM int64        `protobuf:"varint,2,opt,name=RefreshTM,proto3" json:"RefreshTM,omitempty"`
+	Goods     []*ShopGoods `protobuf:"bytes,3,rep,name=Goods,proto3" json:"Goods,omitempty"`
+}
+
+func (x *ShopInfo) Reset() {
+	*x = ShopInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[110]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ShopInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShopInfo) ProtoMessage() {}
+
+func (x *ShopInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[110]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShopInfo.ProtoReflect.Descriptor instead.
+func (*ShopInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{110}
+}
+
+func (x *ShopInfo) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ShopInfo) GetRefreshTM() int64 {
+	if x != nil {
+		return x.RefreshTM
+	}
+	return 0
+}
+
+func (x *ShopInfo) GetGoods() []*ShopGoods {
+	if x != nil {
+		return x.Goods
+	}
+	return nil
+}
+
+type SkillInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id    uint32 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Level uint32 `protobuf:"varint,2,opt,name=Level,proto3" json:"Level,omitempty"`
+}
+
+func (x *SkillInfo) Reset() {
+	*x = SkillInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[111]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SkillInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SkillInfo) ProtoMessage() {}
+
+func (x *SkillInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[111]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SkillInfo.ProtoReflect.Descriptor instead.
+func (*SkillInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{111}
+}
+
+func (x *SkillInfo) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SkillInfo) GetLevel() uint32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+type TaskInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id       uint32  `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Status   int32   `protobuf:"varint,2,opt,name=Status,proto3" json:"Status,omitempty"`
+	Progress []int64 `protobuf:"varint,3,rep,packed,name=Progress,proto3" json:"Progress,omitempty"`
+}
+
+func (x *TaskInfo) Reset() {
+	*x = TaskInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[112]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TaskInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskInfo) ProtoMessage() {}
+
+func (x *TaskInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[112]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskInfo.ProtoReflect.Descriptor instead.
+func (*TaskInfo) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{112}
+}
+
+func (x *TaskInfo) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TaskInfo) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *TaskInfo) GetProgress() []int64 {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+type TeamMember struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id     uint64 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Name   string `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	Level  uint32 `protobuf:"varint,3,opt,name=Level,proto3" json:"Level,omitempty"`
+	Online bool   `protobuf:"varint,4,opt,name=Online,proto3" json:"Online,omitempty"`
+}
+
+func (x *TeamMember) Reset() {
+	*x = TeamMember{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[113]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TeamMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TeamMember) ProtoMessage() {}
+
+func (x *TeamMember) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[113]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TeamMember.ProtoReflect.Descriptor instead.
+func (*TeamMember) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{113}
+}
+
+func (x *TeamMember) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TeamMember) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TeamMember) GetLevel() uint32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *TeamMember) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+type TeamReadyCheck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Deadline int64    `protobuf:"varint,1,opt,name=Deadline,proto3" json:"Deadline,omitempty"`
+	Ready    []uint64 `protobuf:"varint,2,rep,packed,name=Ready,proto3" json:"Ready,omitempty"`
+	Target   uint32   `protobuf:"varint,3,opt,name=Target,proto3" json:"Target,omitempty"`
+}
+
+func (x *TeamReadyCheck) Reset() {
+	*x = TeamReadyCheck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[114]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TeamReadyCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TeamReadyCheck) ProtoMessage() {}
+
+func (x *TeamReadyCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[114]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TeamReadyCheck.ProtoReflect.Descriptor instead.
+func (*TeamReadyCheck) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{114}
+}
+
+func (x *TeamReadyCheck) GetDeadline() int64 {
+	if x != nil {
+		return x.Deadline
+	}
+	return 0
+}
+
+func (x *TeamReadyCheck) GetReady() []uint64 {
+	if x != nil {
+		return x.Ready
+	}
+	return nil
+}
+
+func (x *TeamReadyCheck) GetTarget() uint32 {
+	if x != nil {
+		return x.Target
+	}
+	return 0
+}
+
+// SCChatMsg pushes a chat message to the players of its channel; the
+// SCSendChatMsg reply to CSSendChatMsg stays empty.
+type SCChatMsg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UId      uint64 `protobuf:"varint,1,opt,name=UId,proto3" json:"UId,omitempty"`
+	Category int32  `protobuf:"varint,2,opt,name=Category,proto3" json:"Category,omitempty"`
+	Content  string `protobuf:"bytes,3,opt,name=Content,proto3" json:"Content,omitempty"`
+}
+
+func (x *SCChatMsg) Reset() {
+	*x = SCChatMsg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_protocol_player_proto_msgTypes[115]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SCChatMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCChatMsg) ProtoMessage() {}
+
+func (x *SCChatMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_player_proto_msgTypes[115]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCChatMsg.ProtoReflect.Descriptor instead.
+func (*SCChatMsg) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_player_proto_rawDescGZIP(), []int{115}
+}
+
+func (x *SCChatMsg) GetUId() uint64 {
+	if x != nil {
+		return x.UId
+	}
+	return 0
+}
+
+func (x *SCChatMsg) GetCategory() int32 {
+	if x != nil {
+		return x.Category
+	}
+	return 0
+}
+
+func (x *SCChatMsg) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+var File_internal_protocol_player_proto protoreflect.FileDescriptor
+
+var file_internal_protocol_player_proto_rawDesc = []byte{
+	0x0a, 0x1e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x2f, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x22, 0x6b, 0x0a, 0x0f, 0x41, 0x63,
+	0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a,
+	0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x12, 0x14, 0x0a,
+	0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x56, 0x61,
+	0x6c, 0x75, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x52, 0x65, 0x61, 0x63, 0x68, 0x65, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x52, 0x65, 0x61, 0x63, 0x68, 0x65, 0x64, 0x12, 0x18, 0x0a,
+	0x07, 0x43, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07,
+	0x43, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x64, 0x22, 0xce, 0x01, 0x0a, 0x0c, 0x41, 0x63, 0x74, 0x69,
+	0x76, 0x69, 0x74, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x4f, 0x70, 0x65, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x4f, 0x70, 0x65, 0x6e, 0x12, 0x12, 0x0a, 0x04,
+	0x4e, 0x65, 0x78, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x4e, 0x65, 0x78, 0x74,
+	0x12, 0x14, 0x0a, 0x05, 0x4a, 0x6f, 0x69, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x4a, 0x6f, 0x69, 0x6e, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08,
+	0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08,
+	0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x67,
+	0x65, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x53, 0x74, 0x61, 0x67, 0x65, 0x73,
+	0x12, 0x14, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x45, 0x6e, 0x64, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x03, 0x45, 0x6e, 0x64, 0x22, 0x43, 0x0a, 0x0d, 0x41, 0x72, 0x65, 0x6e,
+	0x61, 0x4f, 0x70, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x50, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x22, 0xb1, 0x02,
+	0x0a, 0x0b, 0x41, 0x72, 0x65, 0x6e, 0x61, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x0e, 0x0a,
+	0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x49, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x54, 0x69, 0x6d,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x41, 0x74, 0x74, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x08, 0x41, 0x74, 0x74, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x12, 0x1a, 0x0a,
+	0x08, 0x44, 0x65, 0x66, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x08, 0x44, 0x65, 0x66, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x57, 0x6f, 0x6e,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x57, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x52,
+	0x65, 0x76, 0x65, 0x6e, 0x67, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x52, 0x65,
+	0x76, 0x65, 0x6e, 0x67, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x41, 0x74, 0x74, 0x61, 0x63, 0x6b, 0x65,
+	0x72, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x41,
+	0x74, 0x74, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x24, 0x0a,
+	0x0d, 0x41, 0x74, 0x74, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x41, 0x74, 0x74, 0x61, 0x63, 0x6b, 0x65, 0x72, 0x44, 0x65,
+	0x6c, 0x74, 0x61, 0x12, 0x26, 0x0a, 0x0e, 0x44, 0x65, 0x66, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x50,
+	0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x44, 0x65, 0x66,
+	0x65, 0x6e, 0x64, 0x65, 0x72, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x44,
+	0x65, 0x66, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x18, 0x0a, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0d, 0x44, 0x65, 0x66, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x44, 0x65, 0x6c, 0x74,
+	0x61, 0x22, 0x3e, 0x0a, 0x0c, 0x41, 0x72, 0x65, 0x6e, 0x61, 0x52, 0x65, 0x76, 0x65, 0x6e, 0x67,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x54, 0x69, 0x6d,
+	0x65, 0x22, 0x35, 0x0a, 0x09, 0x41, 0x74, 0x74, 0x72, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12,
+	0x0a, 0x04, 0x4b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x4b, 0x69,
+	0x6e, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x22, 0xf4, 0x01, 0x0a, 0x0e, 0x41, 0x75, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x0e, 0x0a, 0x02, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x53,
+	0x65, 0x6c, 0x6c, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x53, 0x65, 0x6c,
+	0x6c, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x49, 0x74, 0x65, 0x6d, 0x49, 0x64, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x06, 0x49, 0x74, 0x65, 0x6d, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x4e,
+	0x75, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x4e, 0x75, 0x6d, 0x12, 0x1a, 0x0a,
+	0x08, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x08, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x72, 0x69,
+	0x63, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12,
+	0x16, 0x0a, 0x06, 0x42, 0x69, 0x64, 0x64, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x06, 0x42, 0x69, 0x64, 0x64, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x42, 0x75, 0x79, 0x6f, 0x75,
+	0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x42, 0x75, 0x79, 0x6f, 0x75, 0x74, 0x12,
+	0x16, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x45, 0x78, 0x70, 0x69, 0x72,
+	0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x22,
+	0x5b, 0x0a, 0x0d, 0x42, 0x61, 0x67, 0x49, 0x74, 0x65, 0x6d, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65,
+	0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64,
+	0x12, 0x14, 0x0a, 0x05, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x12, 0x10, 0x0a, 0x03, 0x4e, 0x75, 0x6d, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x03, 0x4e, 0x75, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x55, 0x69, 0x64, 0x73,
+	0x18, 0x04, 0x20, 0x03, 0x28, 0x04, 0x52, 0x04, 0x55, 0x69, 0x64, 0x73, 0x22, 0x8b, 0x01, 0x0a,
+	0x0b, 0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x4b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x4b, 0x69, 0x6e, 0x64,
+	0x12, 0x16, 0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x54, 0x61, 0x72, 0x67,
+	0x65, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64,
+	0x12, 0x14, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x43, 0x72, 0x69, 0x74, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x43, 0x72, 0x69, 0x74, 0x22, 0xb2, 0x01, 0x0a, 0x0e, 0x42,
+	0x61, 0x74, 0x74, 0x6c, 0x65, 0x50, 0x61, 0x73, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a,
+	0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x12, 0x14, 0x0a,
+	0x05, 0x52, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x52, 0x6f,
+	0x75, 0x6e, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x45, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x03, 0x45, 0x6e, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x45, 0x78, 0x70, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x03, 0x45, 0x78, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x4c, 0x65, 0x76, 0x65, 0x6c,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x18, 0x0a,
+	0x07, 0x50, 0x72, 0x65, 0x6d, 0x69, 0x75, 0x6d, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07,
+	0x50, 0x72, 0x65, 0x6d, 0x69, 0x75, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x46, 0x72, 0x65, 0x65, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x46, 0x72, 0x65, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x50,
+	0x61, 0x69, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x50, 0x61, 0x69, 0x64, 0x22,
+	0x5e, 0x0a, 0x0a, 0x42, 0x75, 0x66, 0x66, 0x45, 0x66, 0x66, 0x65, 0x63, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x4b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x4b, 0x69, 0x6e,
+	0x64, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49,
+	0x64, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x22,
+	0x62, 0x0a, 0x08, 0x42, 0x75, 0x66, 0x66, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x53,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x53, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x63, 0x6b, 0x73, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x06, 0x53, 0x74, 0x61, 0x63, 0x6b, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x45,
+	0x78, 0x70, 0x69, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x45, 0x78, 0x70,
+	0x69, 0x72, 0x65, 0x22, 0x1e, 0x0a, 0x0c, 0x43, 0x53, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x54,
+	0x61, 0x73, 0x6b, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x02, 0x49, 0x64, 0x22, 0x20, 0x0a, 0x0e, 0x43, 0x53, 0x41, 0x63, 0x74, 0x69, 0x76, 0x69, 0x74,
+	0x79, 0x4a, 0x6f, 0x69, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x02, 0x49, 0x64, 0x22, 0x27, 0x0a, 0x0d, 0x43, 0x53, 0x41, 0x72, 0x65, 0x6e, 0x61,
+	0x41, 0x74, 0x74, 0x61, 0x63, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x22, 0x36,
+	0x0a, 0x0c, 0x43, 0x53, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x69, 0x64, 0x12, 0x0e,
+	0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x49, 0x64, 0x12, 0x16,
+	0x0a, 0x06, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06,
+	0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x21, 0x0a, 0x0f, 0x43, 0x53, 0x41, 0x75, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x42, 0x75, 0x79, 0x6f, 0x75, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x49, 0x64, 0x22, 0x21, 0x0a, 0x0f, 0x43, 0x53, 0x41,
+	0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x12, 0x0e, 0x0a, 0x02,
+	0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x49, 0x64, 0x22, 0x83, 0x01, 0x0a,
+	0x0d, 0x43, 0x53, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x49, 0x74, 0x65, 0x6d, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06,
+	0x49, 0x74, 0x65, 0x6d, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x4e, 0x75, 0x6d, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x03, 0x4e, 0x75, 0x6d, 0x12, 0x1a, 0x0a, 0x08, 0x43, 0x75, 0x72, 0x72,
+	0x65, 0x6e, 0x63, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x43, 0x75, 0x72, 0x72,
+	0x65, 0x6e, 0x63, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x72, 0x69, 0x63, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x42, 0x75,
+	0x79, 0x6f, 0x75, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x42, 0x75, 0x79, 0x6f,
+	0x75, 0x74, 0x22, 0xd5, 0x01, 0x0a, 0x0f, 0x43, 0x53, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x49, 0x74, 0x65, 0x6d, 0x49, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x49, 0x74, 0x65, 0x6d, 0x49, 0x64, 0x12, 0x1a,
+	0x0a, 0x08, 0x43, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x08, 0x43, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x43, 0x75,
+	0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x43, 0x75,
+	0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x4d, 0x69, 0x6e, 0x50, 0x72, 0x69,
+	0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x4d, 0x69, 0x6e, 0x50, 0x72, 0x69,
+	0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x4d, 0x61, 0x78, 0x50, 0x72, 0x69, 0x63, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x4d, 0x61, 0x78, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x12,
+	0x0a, 0x04, 0x53, 0x6f, 0x72, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x53, 0x6f,
+	0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x61, 0x67, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x04, 0x50, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x4d, 0x69, 0x6e, 0x65, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x4d, 0x69, 0x6e, 0x65, 0x22, 0x5c, 0x0a, 0x0c, 0x43, 0x53,
+	0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x43, 0x61, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x49, 0x6e,
+	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a,
+	0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x53, 0x6b,
+	0x69, 0x6c, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x53, 0x6b, 0x69, 0x6c, 0x6c,
+	0x12, 0x16, 0x0a, 0x06, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x06, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x22, 0x2f, 0x0a, 0x0d, 0x43, 0x53, 0x42, 0x61,
+	0x74, 0x74, 0x6c, 0x65, 0x45, 0x6e, 0x74, 0x65, 0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x49, 0x6e, 0x73,
+	0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x49,
+	0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x22, 0x39, 0x0a, 0x11, 0x43, 0x53, 0x42,
+	0x61, 0x74, 0x74, 0x6c, 0x65, 0x50, 0x61, 0x73, 0x73, 0x43, 0x6c, 0x61, 0x69, 0x6d, 0x12, 0x0e,
+	0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x12, 0x14,
+	0x0a, 0x05, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x54,
+	0x72, 0x61, 0x63, 0x6b, 0x22, 0x1e, 0x0a, 0x0c, 0x43, 0x53, 0x42, 0x75, 0x66, 0x66, 0x43, 0x61,
+	0x6e, 0x63, 0x65, 0x6c, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x02, 0x49, 0x64, 0x22, 0x54, 0x0a, 0x0a, 0x43, 0x53, 0x42, 0x75, 0x79, 0x47, 0x6f, 0x6f,
+	0x64, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x68, 0x6f, 0x70, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x06, 0x53, 0x68, 0x6f, 0x70, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x47, 0x6f,
+	0x6f, 0x64, 0x73, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x47, 0x6f, 0x6f,
+	0x64, 0x73, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x24, 0x0a, 0x12, 0x43, 0x53,
+	0x43, 0x6c, 0x61, 0x69, 0x6d, 0x41, 0x63, 0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64,
+	0x22, 0x49, 0x0a, 0x09, 0x43, 0x53, 0x44, 0x65, 0x6c, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x16, 0x0a,
+	0x06, 0x49, 0x74, 0x65, 0x6d, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x49,
+	0x74, 0x65, 0x6d, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x4e, 0x75, 0x6d, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x03, 0x4e, 0x75, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x55, 0x69, 0x64, 0x73, 0x18,
+	0x03, 0x20, 0x03, 0x28, 0x04, 0x52, 0x04, 0x55, 0x69, 0x64, 0x73, 0x22, 0x22, 0x0a, 0x0e, 0x43,
+	0x53, 0x45, 0x6e, 0x68, 0x61, 0x6e, 0x63, 0x65, 0x45, 0x71, 0x75, 0x69, 0x70, 0x12, 0x10, 0x0a,
+	0x03, 0x55, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x55, 0x69, 0x64, 0x22,
+	0x2f, 0x0a, 0x07, 0x43, 0x53, 0x45, 0x71, 0x75, 0x69, 0x70, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x55, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x50, 0x61, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x50, 0x61, 0x72, 0x74,
+	0x22, 0x32, 0x0a, 0x14, 0x43, 0x53, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x41, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x49, 0x6e, 0x76, 0x69, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x46, 0x61, 0x6d, 0x69,
+	0x6c, 0x79, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x46, 0x61, 0x6d, 0x69,
+	0x6c, 0x79, 0x49, 0x64, 0x22, 0x2b, 0x0a, 0x0d, 0x43, 0x53, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79,
+	0x41, 0x70, 0x70, 0x6c, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x49,
+	0x64, 0x22, 0x49, 0x0a, 0x0f, 0x43, 0x53, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x41, 0x70, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64,
+	0x12, 0x1a, 0x0a, 0x08, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x08, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x45, 0x0a, 0x0f,
+	0x43, 0x53, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x41, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x41,
+	0x63, 0x63, 0x65, 0x70, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x41, 0x63, 0x63,
+	0x65, 0x70, 0x74, 0x22, 0x24, 0x0a, 0x0e, 0x43, 0x53, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x43,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x44, 0x0a, 0x0e, 0x43, 0x53, 0x46,
+	0x61, 0x6d, 0x69, 0x6c, 0x79, 0x44, 0x6f, 0x6e, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x43,
+	0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x43,
+	0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x41, 0x6d, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x22,
+	0x2a, 0x0a, 0x0c, 0x43, 0x53, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x1a, 0x0a, 0x08, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x08, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x49, 0x64, 0x22, 0x2c, 0x0a, 0x0e, 0x43,
+	0x53, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x49, 0x6e, 0x76, 0x69, 0x74, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x22, 0x2a, 0x0a, 0x0c, 0x43, 0x53, 0x46,
+	0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4b, 0x69, 0x63, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x50, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x49, 0x64, 0x22, 0x61, 0x0a, 0x0f, 0x43, 0x53, 0x46, 0x61, 0x6d, 0x69, 0x6c,
+	0x79, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x4e, 0x6f, 0x74, 0x69,
+	0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x4e, 0x6f, 0x74, 0x69, 0x63, 0x65,
+	0x12, 0x1a, 0x0a, 0x08, 0x4d, 0x69, 0x6e, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x08, 0x4d, 0x69, 0x6e, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x1a, 0x0a, 0x08,
+	0x41, 0x75, 0x74, 0x6f, 0x4a, 0x6f, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08,
+	0x41, 0x75, 0x74, 0x6f, 0x4a, 0x6f, 0x69, 0x6e, 0x22, 0x2e, 0x0a, 0x10, 0x43, 0x53, 0x46, 0x61,
+	0x6d, 0x69, 0x6c, 0x79, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08,
+	0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08,
+	0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x22, 0x46, 0x0a, 0x10, 0x43, 0x53, 0x46, 0x61,
+	0x6d, 0x69, 0x6c, 0x79, 0x57, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x12, 0x1a, 0x0a, 0x08,
+	0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08,
+	0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x41, 0x6d, 0x6f, 0x75,
+	0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74,
+	0x22, 0x24, 0x0a, 0x0e, 0x43, 0x53, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x45, 0x6e, 0x71, 0x75, 0x65,
+	0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x4d, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x04, 0x4d, 0x6f, 0x64, 0x65, 0x22, 0x23, 0x0a, 0x0f, 0x43, 0x53, 0x4d, 0x6f, 0x6e, 0x73,
+	0x74, 0x65, 0x72, 0x41, 0x74, 0x74, 0x61, 0x63, 0x6b, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x55, 0x69, 0x64, 0x22, 0x45, 0x0a, 0x0d, 0x43,
+	0x53, 0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x53, 0x79, 0x6e, 0x63, 0x12, 0x18, 0x0a, 0x07,
+	0x53, 0x63, 0x65, 0x6e, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x53,
+	0x63, 0x65, 0x6e, 0x65, 0x49, 0x64, 0x12, 0x0c, 0x0a, 0x01, 0x58, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x01, 0x58, 0x12, 0x0c, 0x0a, 0x01, 0x5a, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x01, 0x5a, 0x22, 0x9e, 0x01, 0x0a, 0x0a, 0x43, 0x53, 0x52, 0x65, 0x63, 0x68, 0x61, 0x72, 0x67,
+	0x65, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x49, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x50, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x49, 0x64, 0x12,
+	0x18, 0x0a, 0x07, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x20, 0x0a, 0x0b, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x52,
+	0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x52, 0x65,
+	0x63, 0x65, 0x69, 0x70, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x22, 0x21, 0x0a, 0x0d, 0x43, 0x53, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x45,
+	0x71, 0x75, 0x69, 0x70, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x03, 0x55, 0x69, 0x64, 0x22, 0x21, 0x0a, 0x0d, 0x43, 0x53, 0x52, 0x65, 0x70, 0x61,
+	0x69, 0x72, 0x45, 0x71, 0x75, 0x69, 0x70, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x55, 0x69, 0x64, 0x22, 0x1e, 0x0a, 0x0c, 0x43, 0x53, 0x53,
+	0x6b, 0x69, 0x6c, 0x6c, 0x4c, 0x65, 0x61, 0x72, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x22, 0x20, 0x0a, 0x0e, 0x43, 0x53, 0x53,
+	0x6b, 0x69, 0x6c, 0x6c, 0x55, 0x70, 0x67, 0x72, 0x61, 0x64, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x22, 0x1e, 0x0a, 0x0c, 0x43,
+	0x53, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x0e, 0x0a, 0x02, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x22, 0x2c, 0x0a, 0x12, 0x43,
+	0x53, 0x54, 0x65, 0x61, 0x6d, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x49, 0x6e, 0x76, 0x69, 0x74,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x54, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x06, 0x54, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x22, 0x2a, 0x0a, 0x0c, 0x43, 0x53, 0x54,
+	0x65, 0x61, 0x6d, 0x49, 0x6e, 0x76, 0x69, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x50, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x49, 0x64, 0x22, 0x28, 0x0a, 0x0a, 0x43, 0x53, 0x54, 0x65, 0x61, 0x6d, 0x4b,
+	0x69, 0x63, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x22,
+	0x23, 0x0a, 0x0b, 0x43, 0x53, 0x54, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x52, 0x65, 0x61, 0x64, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x52,
+	0x65, 0x61, 0x64, 0x79, 0x22, 0x2a, 0x0a, 0x10, 0x43, 0x53, 0x54, 0x65, 0x61, 0x6d, 0x52, 0x65,
+	0x61, 0x64, 0x79, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x54, 0x61, 0x72, 0x67,
+	0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x22, 0x2c, 0x0a, 0x0e, 0x43, 0x53, 0x54, 0x65, 0x61, 0x6d, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66,
+	0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x22, 0x1f,
+	0x0a, 0x09, 0x43, 0x53, 0x55, 0x6e, 0x65, 0x71, 0x75, 0x69, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x50,
+	0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x50, 0x61, 0x72, 0x74, 0x22,
+	0x3c, 0x0a, 0x0c, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x12, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x22, 0x7b, 0x0a,
+	0x09, 0x45, 0x71, 0x75, 0x69, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x55, 0x69, 0x64, 0x12, 0x0e, 0x0a, 0x02,
+	0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05,
+	0x4c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x4c, 0x65, 0x76,
+	0x65, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x06, 0x52, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x44, 0x75,
+	0x72, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a,
+	0x44, 0x75, 0x72, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x22, 0x8e, 0x01, 0x0a, 0x0c, 0x46,
+	0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x4e,
+	0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x08, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x43,
+	0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0c, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x1a, 0x0a, 0x08, 0x4a, 0x6f, 0x69, 0x6e, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x08, 0x4a, 0x6f, 0x69, 0x6e, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x53, 0x0a, 0x0d, 0x46,
+	0x61, 0x6d, 0x69, 0x6c, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x1a, 0x0a, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x08, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x54, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x54, 0x69, 0x6d, 0x65,
+	0x22, 0x79, 0x0a, 0x0b, 0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x10, 0x0a, 0x03, 0x55, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x55, 0x69,
+	0x64, 0x12, 0x16, 0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x49, 0x64, 0x12, 0x0c, 0x0a, 0x01, 0x58, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x01, 0x58, 0x12, 0x0c, 0x0a, 0x01, 0x5a, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x01, 0x5a, 0x12, 0x14, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x48,
+	0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x48, 0x70, 0x22, 0xca, 0x01, 0x0a, 0x11,
+	0x53, 0x43, 0x41, 0x63, 0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73,
+	0x74, 0x12, 0x3c, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x26, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x43, 0x41, 0x63,
+	0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x2e, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12,
+	0x3d, 0x0a, 0x0c, 0x41, 0x63, 0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x2e, 0x41, 0x63, 0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f,
+	0x52, 0x0c, 0x41, 0x63, 0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x1a, 0x38,
+	0x0a, 0x0a, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x52, 0x0a, 0x13, 0x53, 0x43, 0x41, 0x63,
+	0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12,
+	0x3b, 0x0a, 0x0b, 0x41, 0x63, 0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e,
+	0x41, 0x63, 0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52,
+	0x0b, 0x41, 0x63, 0x68, 0x69, 0x65, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x22, 0x4a, 0x0a, 0x10,
+	0x53, 0x43, 0x41, 0x63, 0x74, 0x69, 0x76, 0x69, 0x74, 0x79, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x12, 0x36, 0x0a, 0x0a, 0x41, 0x63, 0x74, 0x69, 0x76, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e,
+	0x41, 0x63, 0x74, 0x69, 0x76, 0x69, 0x74, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0a, 0x41, 0x63,
+	0x74, 0x69, 0x76, 0x69, 0x74, 0x69, 0x65, 0x73, 0x22, 0x0d, 0x0a, 0x0b, 0x53, 0x43, 0x41, 0x64,
+	0x64, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x22, 0x40, 0x0a, 0x0f, 0x53, 0x43, 0x41, 0x72, 0x65,
+	0x6e, 0x61, 0x44, 0x65, 0x66, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x12, 0x2d, 0x0a, 0x06, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x41, 0x72, 0x65, 0x6e, 0x61, 0x52, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x52, 0x06, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22, 0x20, 0x0a, 0x0e, 0x53, 0x43, 0x41,
+	0x72, 0x65, 0x6e, 0x61, 0x44, 0x65, 0x66, 0x65, 0x6e, 0x73, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x4f,
+	0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x4f, 0x6b, 0x22, 0x65, 0x0a, 0x0c, 0x53,
+	0x43, 0x41, 0x72, 0x65, 0x6e, 0x61, 0x46, 0x69, 0x67, 0x68, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x4f,
+	0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x4f, 0x6b, 0x12, 0x2d, 0x0a, 0x06, 0x52,
+	0x65, 0x63, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x41, 0x72, 0x65, 0x6e, 0x61, 0x52, 0x65, 0x63, 0x6f,
+	0x72, 0x64, 0x52, 0x06, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x54, 0x61,
+	0x72, 0x67, 0x65, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x54, 0x61, 0x72, 0x67,
+	0x65, 0x74, 0x22, 0xa0, 0x01, 0x0a, 0x0b, 0x53, 0x43, 0x41, 0x72, 0x65, 0x6e, 0x61, 0x49, 0x6e,
+	0x66, 0x6f, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x6c, 0x61, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x05, 0x50, 0x6c, 0x61, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x50, 0x6f, 0x69, 0x6e,
+	0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73,
+	0x12, 0x32, 0x0a, 0x08, 0x52, 0x65, 0x76, 0x65, 0x6e, 0x67, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x41, 0x72,
+	0x65, 0x6e, 0x61, 0x52, 0x65, 0x76, 0x65, 0x6e, 0x67, 0x65, 0x52, 0x08, 0x52, 0x65, 0x76, 0x65,
+	0x6e, 0x67, 0x65, 0x73, 0x12, 0x2f, 0x0a, 0x07, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18,
+	0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x2e, 0x41, 0x72, 0x65, 0x6e, 0x61, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x07, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x73, 0x22, 0x49, 0x0a, 0x10, 0x53, 0x43, 0x41, 0x72, 0x65, 0x6e, 0x61,
+	0x4f, 0x70, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x35, 0x0a, 0x09, 0x4f, 0x70, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x41, 0x72, 0x65, 0x6e, 0x61, 0x4f, 0x70, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x52, 0x09, 0x4f, 0x70, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73,
+	0x22, 0x8a, 0x01, 0x0a, 0x0f, 0x53, 0x43, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x02, 0x49, 0x64, 0x12, 0x0e, 0x0a, 0x02, 0x4f, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x02, 0x4f, 0x6b, 0x12, 0x23, 0x0a, 0x02, 0x4f, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x41, 0x75, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x4f, 0x70, 0x52, 0x02, 0x4f, 0x70, 0x12, 0x32, 0x0a, 0x07, 0x4c, 0x69, 0x73,
+	0x74, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x73,
+	0x74, 0x69, 0x6e, 0x67, 0x52, 0x07, 0x4c, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x22, 0x71, 0x0a,
+	0x0f, 0x53, 0x43, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68,
+	0x12, 0x12, 0x0a, 0x04, 0x50, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04,
+	0x50, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x34, 0x0a, 0x08, 0x4c, 0x69,
+	0x73, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4c,
+	0x69, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x08, 0x4c, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x73,
+	0x22, 0x58, 0x0a, 0x0b, 0x53, 0x43, 0x42, 0x61, 0x67, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x08, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x12, 0x2d, 0x0a, 0x05, 0x49,
+	0x74, 0x65, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x42, 0x61, 0x67, 0x49, 0x74, 0x65, 0x6d, 0x43, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x52, 0x05, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x22, 0x85, 0x01, 0x0a, 0x0b, 0x53,
+	0x43, 0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x45, 0x6e, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x49, 0x6e,
+	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a,
+	0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x57, 0x69,
+	0x6e, 0x6e, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x57, 0x69, 0x6e, 0x6e,
+	0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x57, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x03, 0x57, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x4b, 0x69, 0x6c, 0x6c, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x05, 0x4b, 0x69, 0x6c, 0x6c, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x44, 0x61,
+	0x6d, 0x61, 0x67, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x44, 0x61, 0x6d, 0x61,
+	0x67, 0x65, 0x22, 0x72, 0x0a, 0x0d, 0x53, 0x43, 0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x46, 0x72,
+	0x61, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63,
+	0x65, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x54, 0x69, 0x63, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x04, 0x54, 0x69, 0x63, 0x6b, 0x12, 0x2d, 0x0a, 0x06, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x6f, 0x6c, 0x2e, 0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x06,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x46, 0x0a, 0x12, 0x53, 0x43, 0x42, 0x61, 0x74, 0x74,
+	0x6c, 0x65, 0x50, 0x61, 0x73, 0x73, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x30, 0x0a, 0x06,
+	0x50, 0x61, 0x73, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x50, 0x61,
+	0x73, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x50, 0x61, 0x73, 0x73, 0x65, 0x73, 0x22, 0x44,
+	0x0a, 0x0e, 0x53, 0x43, 0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x12, 0x0e, 0x0a, 0x02, 0x4f, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x4f, 0x6b,
+	0x12, 0x22, 0x0a, 0x02, 0x4f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x4f, 0x70,
+	0x52, 0x02, 0x4f, 0x70, 0x22, 0x8f, 0x02, 0x0a, 0x0b, 0x53, 0x43, 0x42, 0x72, 0x6f, 0x61, 0x64,
+	0x63, 0x61, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65,
+	0x12, 0x39, 0x0a, 0x06, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x21, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x43, 0x42, 0x72,
+	0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x2e, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x06, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x54,
+	0x65, 0x78, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x54, 0x65, 0x78, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x4b, 0x69, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x4b,
+	0x69, 0x6e, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x12,
+	0x16, 0x0a, 0x06, 0x52, 0x65, 0x70, 0x65, 0x61, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x06, 0x52, 0x65, 0x70, 0x65, 0x61, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x54, 0x69, 0x6d, 0x65, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x54, 0x69, 0x6d, 0x65, 0x1a, 0x39, 0x0a, 0x0b, 0x50,
+	0x61, 0x72, 0x61, 0x6d, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x68, 0x0a, 0x0c, 0x53, 0x43, 0x42, 0x75, 0x66, 0x66,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x28, 0x0a, 0x05, 0x42, 0x75, 0x66, 0x66, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x2e, 0x42, 0x75, 0x66, 0x66, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05, 0x42, 0x75, 0x66, 0x66, 0x73,
+	0x12, 0x2e, 0x0a, 0x07, 0x45, 0x66, 0x66, 0x65, 0x63, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x42, 0x75, 0x66,
+	0x66, 0x45, 0x66, 0x66, 0x65, 0x63, 0x74, 0x52, 0x07, 0x45, 0x66, 0x66, 0x65, 0x63, 0x74, 0x73,
+	0x22, 0x65, 0x0a, 0x0a, 0x53, 0x43, 0x42, 0x75, 0x79, 0x47, 0x6f, 0x6f, 0x64, 0x73, 0x12, 0x14,
+	0x0a, 0x05, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x12, 0x29, 0x0a, 0x05, 0x47, 0x6f, 0x6f, 0x64, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53,
+	0x68, 0x6f, 0x70, 0x47, 0x6f, 0x6f, 0x64, 0x73, 0x52, 0x05, 0x47, 0x6f, 0x6f, 0x64, 0x73, 0x12,
+	0x16, 0x0a, 0x06, 0x53, 0x68, 0x6f, 0x70, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x06, 0x53, 0x68, 0x6f, 0x70, 0x49, 0x64, 0x22, 0x74, 0x0a, 0x10, 0x53, 0x43, 0x43, 0x75, 0x72,
+	0x72, 0x65, 0x6e, 0x63, 0x79, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x32, 0x0a, 0x08, 0x43,
+	0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63,
+	0x79, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05,
+	0x44, 0x65, 0x6c, 0x74, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0x48, 0x0a,
+	0x0e, 0x53, 0x43, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x4c, 0x69, 0x73, 0x74, 0x12,
+	0x36, 0x0a, 0x0a, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43,
+	0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0a, 0x43, 0x75, 0x72,
+	0x72, 0x65, 0x6e, 0x63, 0x69, 0x65, 0x73, 0x22, 0x7c, 0x0a, 0x0d, 0x53, 0x43, 0x45, 0x71, 0x75,
+	0x69, 0x70, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x61, 0x72, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x50, 0x61, 0x72, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x57, 0x6f, 0x72, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x57, 0x6f, 0x72, 0x6e,
+	0x12, 0x18, 0x0a, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x07, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x29, 0x0a, 0x05, 0x45, 0x71,
+	0x75, 0x69, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x45, 0x71, 0x75, 0x69, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05,
+	0x45, 0x71, 0x75, 0x69, 0x70, 0x22, 0xc8, 0x03, 0x0a, 0x0c, 0x53, 0x43, 0x46, 0x61, 0x6d, 0x69,
+	0x6c, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x02, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x4e, 0x6f,
+	0x74, 0x69, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x4e, 0x6f, 0x74, 0x69,
+	0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x06, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x4c, 0x65,
+	0x76, 0x65, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x4c, 0x65, 0x76, 0x65, 0x6c,
+	0x12, 0x10, 0x0a, 0x03, 0x45, 0x78, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x45,
+	0x78, 0x70, 0x12, 0x1a, 0x0a, 0x08, 0x41, 0x75, 0x74, 0x6f, 0x4a, 0x6f, 0x69, 0x6e, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x41, 0x75, 0x74, 0x6f, 0x4a, 0x6f, 0x69, 0x6e, 0x12, 0x1a,
+	0x0a, 0x08, 0x4d, 0x69, 0x6e, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x08, 0x4d, 0x69, 0x6e, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x1e, 0x0a, 0x0a, 0x4d, 0x61,
+	0x78, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a,
+	0x4d, 0x61, 0x78, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x12, 0x40, 0x0a, 0x08, 0x54, 0x72,
+	0x65, 0x61, 0x73, 0x75, 0x72, 0x79, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x43, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79,
+	0x49, 0x6e, 0x66, 0x6f, 0x2e, 0x54, 0x72, 0x65, 0x61, 0x73, 0x75, 0x72, 0x79, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x08, 0x54, 0x72, 0x65, 0x61, 0x73, 0x75, 0x72, 0x79, 0x12, 0x30, 0x0a, 0x07,
+	0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4d,
+	0x65, 0x6d, 0x62, 0x65, 0x72, 0x52, 0x07, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x12, 0x33,
+	0x0a, 0x08, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x46, 0x61, 0x6d, 0x69,
+	0x6c, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x08, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x73, 0x1a, 0x3b, 0x0a, 0x0d, 0x54, 0x72, 0x65, 0x61, 0x73, 0x75, 0x72, 0x79, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
+	0x22, 0x54, 0x0a, 0x0e, 0x53, 0x43, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x49, 0x6e, 0x76, 0x69,
+	0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x49, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x49, 0x64, 0x12, 0x12,
+	0x0a, 0x04, 0x46, 0x72, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x46, 0x72,
+	0x6f, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x42, 0x0a, 0x0c, 0x53, 0x43, 0x46, 0x61, 0x6d, 0x69,
+	0x6c, 0x79, 0x4c, 0x65, 0x66, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79,
+	0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79,
+	0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x4b, 0x69, 0x63, 0x6b, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x06, 0x4b, 0x69, 0x63, 0x6b, 0x65, 0x64, 0x22, 0x44, 0x0a, 0x0e, 0x53, 0x43,
+	0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02,
+	0x4f, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x4f, 0x6b, 0x12, 0x22, 0x0a, 0x02,
+	0x4f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x2e, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70, 0x52, 0x02, 0x4f, 0x70,
+	0x22, 0x44, 0x0a, 0x0c, 0x53, 0x43, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x4c, 0x69, 0x73, 0x74,
+	0x12, 0x18, 0x0a, 0x07, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x04, 0x52, 0x07, 0x46, 0x72, 0x69, 0x65, 0x6e, 0x64, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x04, 0x52, 0x08, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x22, 0x3a, 0x0a, 0x0e, 0x53, 0x43, 0x46, 0x72, 0x69, 0x65,
+	0x6e, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x4f, 0x6e, 0x6c, 0x69,
+	0x6e, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x4f, 0x6e, 0x6c, 0x69, 0x6e, 0x65,
+	0x12, 0x10, 0x0a, 0x03, 0x55, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x55,
+	0x49, 0x64, 0x22, 0x20, 0x0a, 0x06, 0x53, 0x43, 0x4b, 0x69, 0x63, 0x6b, 0x12, 0x16, 0x0a, 0x06,
+	0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x52, 0x65,
+	0x61, 0x73, 0x6f, 0x6e, 0x22, 0x42, 0x0a, 0x0d, 0x53, 0x43, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x4f, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x02, 0x4f, 0x6b, 0x12, 0x21, 0x0a, 0x02, 0x4f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x4d, 0x61, 0x74,
+	0x63, 0x68, 0x4f, 0x70, 0x52, 0x02, 0x4f, 0x70, 0x22, 0x86, 0x01, 0x0a, 0x0d, 0x53, 0x43, 0x4d,
+	0x61, 0x74, 0x63, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2d, 0x0a, 0x06, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x4d, 0x6f, 0x64,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x1e, 0x0a,
+	0x0a, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x0a, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x54, 0x65, 0x61, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x54, 0x65, 0x61,
+	0x6d, 0x22, 0x57, 0x0a, 0x0f, 0x53, 0x43, 0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x41, 0x74,
+	0x74, 0x61, 0x63, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x44, 0x61, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x44, 0x61, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08,
+	0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x55, 0x69, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x55, 0x69, 0x64, 0x22, 0x46, 0x0a, 0x0f, 0x53, 0x43,
+	0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a,
+	0x02, 0x4f, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x4f, 0x6b, 0x12, 0x23, 0x0a,
+	0x02, 0x4f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x4f, 0x70, 0x52, 0x02,
+	0x4f, 0x70, 0x22, 0x5e, 0x0a, 0x0f, 0x53, 0x43, 0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x55,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x53, 0x63, 0x65, 0x6e, 0x65, 0x49, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x53, 0x63, 0x65, 0x6e, 0x65, 0x49, 0x64, 0x12,
+	0x31, 0x0a, 0x08, 0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x4d, 0x6f, 0x6e,
+	0x73, 0x74, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65,
+	0x72, 0x73, 0x22, 0x39, 0x0a, 0x0c, 0x53, 0x43, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x41, 0x74,
+	0x74, 0x72, 0x12, 0x29, 0x0a, 0x05, 0x41, 0x74, 0x74, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x41, 0x74, 0x74,
+	0x72, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x41, 0x74, 0x74, 0x72, 0x73, 0x22, 0x83, 0x01,
+	0x0a, 0x0d, 0x53, 0x43, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12,
+	0x38, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x43, 0x50, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x1a, 0x38, 0x0a, 0x0a, 0x53, 0x74, 0x61,
+	0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x22, 0x7e, 0x0a, 0x0a, 0x53, 0x43, 0x52, 0x65, 0x63, 0x68, 0x61, 0x72, 0x67,
+	0x65, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x49, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x50, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x49, 0x64, 0x12,
+	0x20, 0x0a, 0x0b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x18, 0x0a, 0x07, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x22, 0x36, 0x0a, 0x0a, 0x53, 0x43, 0x53, 0x68, 0x6f, 0x70, 0x4c, 0x69, 0x73,
+	0x74, 0x12, 0x28, 0x0a, 0x05, 0x53, 0x68, 0x6f, 0x70, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x68, 0x6f, 0x70,
+	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05, 0x53, 0x68, 0x6f, 0x70, 0x73, 0x22, 0x3c, 0x0a, 0x0d, 0x53,
+	0x43, 0x53, 0x6b, 0x69, 0x6c, 0x6c, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x2b, 0x0a, 0x06,
+	0x53, 0x6b, 0x69, 0x6c, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x6b, 0x69, 0x6c, 0x6c, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x06, 0x53, 0x6b, 0x69, 0x6c, 0x6c, 0x73, 0x22, 0x36, 0x0a, 0x0a, 0x53, 0x43, 0x54,
+	0x61, 0x73, 0x6b, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x28, 0x0a, 0x05, 0x54, 0x61, 0x73, 0x6b, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05, 0x54, 0x61, 0x73, 0x6b,
+	0x73, 0x22, 0x36, 0x0a, 0x0c, 0x53, 0x43, 0x54, 0x61, 0x73, 0x6b, 0x55, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x12, 0x26, 0x0a, 0x04, 0x54, 0x61, 0x73, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x49,
+	0x6e, 0x66, 0x6f, 0x52, 0x04, 0x54, 0x61, 0x73, 0x6b, 0x22, 0x94, 0x01, 0x0a, 0x0a, 0x53, 0x43,
+	0x54, 0x65, 0x61, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x4c, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72,
+	0x12, 0x2e, 0x0a, 0x07, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x54, 0x65, 0x61,
+	0x6d, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x52, 0x07, 0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x73,
+	0x12, 0x2e, 0x0a, 0x05, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x54, 0x65, 0x61, 0x6d, 0x52,
+	0x65, 0x61, 0x64, 0x79, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x52, 0x05, 0x43, 0x68, 0x65, 0x63, 0x6b,
+	0x22, 0x3a, 0x0a, 0x0c, 0x53, 0x43, 0x54, 0x65, 0x61, 0x6d, 0x49, 0x6e, 0x76, 0x69, 0x74, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x46, 0x72, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04,
+	0x46, 0x72, 0x6f, 0x6d, 0x12, 0x16, 0x0a, 0x06, 0x54, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x54, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x22, 0x3c, 0x0a, 0x0a,
+	0x53, 0x43, 0x54, 0x65, 0x61, 0x6d, 0x4c, 0x65, 0x66, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x4b, 0x69,
+	0x63, 0x6b, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x4b, 0x69, 0x63, 0x6b,
+	0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x54, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x06, 0x54, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x22, 0x3d, 0x0a, 0x0b, 0x53, 0x43,
+	0x54, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x61, 0x64, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x54, 0x61, 0x72,
+	0x67, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x54, 0x61, 0x72, 0x67, 0x65,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x54, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x06, 0x54, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x22, 0x40, 0x0a, 0x0c, 0x53, 0x43, 0x54,
+	0x65, 0x61, 0x6d, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x4f, 0x6b, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x4f, 0x6b, 0x12, 0x20, 0x0a, 0x02, 0x4f, 0x70, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x2e, 0x54, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x52, 0x02, 0x4f, 0x70, 0x22, 0x4f, 0x0a, 0x0b, 0x53,
+	0x43, 0x56, 0x69, 0x70, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x4c, 0x65,
+	0x76, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x4c, 0x65, 0x76, 0x65, 0x6c,
+	0x12, 0x10, 0x0a, 0x03, 0x45, 0x78, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x45,
+	0x78, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x43, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x64, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x07, 0x43, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x64, 0x22, 0x5f, 0x0a, 0x09,
+	0x53, 0x68, 0x6f, 0x70, 0x47, 0x6f, 0x6f, 0x64, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x64,
+	0x61, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x54, 0x6f, 0x64, 0x61, 0x79, 0x12,
+	0x16, 0x0a, 0x06, 0x57, 0x65, 0x65, 0x6b, 0x6c, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x06, 0x57, 0x65, 0x65, 0x6b, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x74, 0x61, 0x6c,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x22, 0x63, 0x0a,
+	0x08, 0x53, 0x68, 0x6f, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x52, 0x65, 0x66,
+	0x72, 0x65, 0x73, 0x68, 0x54, 0x4d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x52, 0x65,
+	0x66, 0x72, 0x65, 0x73, 0x68, 0x54, 0x4d, 0x12, 0x29, 0x0a, 0x05, 0x47, 0x6f, 0x6f, 0x64, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x2e, 0x53, 0x68, 0x6f, 0x70, 0x47, 0x6f, 0x6f, 0x64, 0x73, 0x52, 0x05, 0x47, 0x6f, 0x6f,
+	0x64, 0x73, 0x22, 0x31, 0x0a, 0x09, 0x53, 0x6b, 0x69, 0x6c, 0x6c, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49, 0x64, 0x12,
+	0x14, 0x0a, 0x05, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05,
+	0x4c, 0x65, 0x76, 0x65, 0x6c, 0x22, 0x4e, 0x0a, 0x08, 0x54, 0x61, 0x73, 0x6b, 0x49, 0x6e, 0x66,
+	0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x49,
+	0x64, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x72, 0x6f,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x03, 0x52, 0x08, 0x50, 0x72, 0x6f,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x22, 0x5e, 0x0a, 0x0a, 0x54, 0x65, 0x61, 0x6d, 0x4d, 0x65, 0x6d,
+	0x62, 0x65, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x02, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x4c, 0x65, 0x76, 0x65, 0x6c,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x16, 0x0a,
+	0x06, 0x4f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x4f,
+	0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x22, 0x5a, 0x0a, 0x0e, 0x54, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x61,
+	0x64, 0x79, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x44, 0x65, 0x61, 0x64, 0x6c,
+	0x69, 0x6e, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x44, 0x65, 0x61, 0x64, 0x6c,
+	0x69, 0x6e, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x52, 0x65, 0x61, 0x64, 0x79, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x04, 0x52, 0x05, 0x52, 0x65, 0x61, 0x64, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x54, 0x61, 0x72,
+	0x67, 0x65, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x54, 0x61, 0x72, 0x67, 0x65,
+	0x74, 0x22, 0x53, 0x0a, 0x09, 0x53, 0x43, 0x43, 0x68, 0x61, 0x74, 0x4d, 0x73, 0x67, 0x12, 0x10,
+	0x0a, 0x03, 0x55, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x55, 0x49, 0x64,
+	0x12, 0x1a, 0x0a, 0x08, 0x43, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x08, 0x43, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x12, 0x18, 0x0a, 0x07,
+	0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x43,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x2a, 0x6d, 0x0a, 0x09, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x4f, 0x70, 0x12, 0x11, 0x0a, 0x0d, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4f, 0x70,
+	0x4e, 0x6f, 0x6e, 0x65, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x4f, 0x70, 0x42, 0x69, 0x64, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x41, 0x75, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x4f, 0x70, 0x42, 0x75, 0x79, 0x6f, 0x75, 0x74, 0x10, 0x02, 0x12, 0x13, 0x0a,
+	0x0f, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4f, 0x70, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c,
+	0x10, 0x03, 0x12, 0x11, 0x0a, 0x0d, 0x41, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4f, 0x70, 0x4c,
+	0x69, 0x73, 0x74, 0x10, 0x04, 0x2a, 0x54, 0x0a, 0x08, 0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x4f,
+	0x70, 0x12, 0x10, 0x0a, 0x0c, 0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x4f, 0x70, 0x4e, 0x6f, 0x6e,
+	0x65, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x4f, 0x70, 0x43,
+	0x61, 0x73, 0x74, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x42, 0x61, 0x74, 0x74, 0x6c, 0x65, 0x4f,
+	0x70, 0x45, 0x6e, 0x74, 0x65, 0x72, 0x10, 0x02, 0x12, 0x11, 0x0a, 0x0d, 0x42, 0x61, 0x74, 0x74,
+	0x6c, 0x65, 0x4f, 0x70, 0x4c, 0x65, 0x61, 0x76, 0x65, 0x10, 0x03, 0x2a, 0xbc, 0x02, 0x0a, 0x08,
+	0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70, 0x12, 0x10, 0x0a, 0x0c, 0x46, 0x61, 0x6d, 0x69,
+	0x6c, 0x79, 0x4f, 0x70, 0x4e, 0x6f, 0x6e, 0x65, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x46, 0x61,
+	0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x49, 0x6e, 0x76, 0x69,
+	0x74, 0x65, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70,
+	0x41, 0x70, 0x70, 0x6c, 0x79, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f, 0x46, 0x61, 0x6d, 0x69, 0x6c,
+	0x79, 0x4f, 0x70, 0x41, 0x70, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x10, 0x03, 0x12, 0x13, 0x0a, 0x0f,
+	0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70, 0x41, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x10,
+	0x04, 0x12, 0x12, 0x0a, 0x0e, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70, 0x43, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x10, 0x05, 0x12, 0x13, 0x0a, 0x0f, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4f,
+	0x70, 0x44, 0x69, 0x73, 0x62, 0x61, 0x6e, 0x64, 0x10, 0x06, 0x12, 0x12, 0x0a, 0x0e, 0x46, 0x61,
+	0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70, 0x44, 0x6f, 0x6e, 0x61, 0x74, 0x65, 0x10, 0x07, 0x12, 0x10,
+	0x0a, 0x0c, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x10, 0x08,
+	0x12, 0x12, 0x0a, 0x0e, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70, 0x49, 0x6e, 0x76, 0x69,
+	0x74, 0x65, 0x10, 0x09, 0x12, 0x10, 0x0a, 0x0c, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70,
+	0x4b, 0x69, 0x63, 0x6b, 0x10, 0x0a, 0x12, 0x11, 0x0a, 0x0d, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79,
+	0x4f, 0x70, 0x4c, 0x65, 0x61, 0x76, 0x65, 0x10, 0x0b, 0x12, 0x13, 0x0a, 0x0f, 0x46, 0x61, 0x6d,
+	0x69, 0x6c, 0x79, 0x4f, 0x70, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x10, 0x0c, 0x12, 0x14,
+	0x0a, 0x10, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66,
+	0x65, 0x72, 0x10, 0x0d, 0x12, 0x14, 0x0a, 0x10, 0x46, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4f, 0x70,
+	0x57, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x10, 0x0e, 0x2a, 0x41, 0x0a, 0x07, 0x4d, 0x61,
+	0x74, 0x63, 0x68, 0x4f, 0x70, 0x12, 0x0f, 0x0a, 0x0b, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x4f, 0x70,
+	0x4e, 0x6f, 0x6e, 0x65, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x4f,
+	0x70, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x10, 0x01, 0x12, 0x12, 0x0a, 0x0e, 0x4d, 0x61, 0x74,
+	0x63, 0x68, 0x4f, 0x70, 0x45, 0x6e, 0x71, 0x75, 0x65, 0x75, 0x65, 0x10, 0x02, 0x2a, 0x63, 0x0a,
+	0x0b, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0d, 0x0a, 0x09,
+	0x4d, 0x61, 0x74, 0x63, 0x68, 0x4e, 0x6f, 0x6e, 0x65, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x4d,
+	0x61, 0x74, 0x63, 0x68, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x6c, 0x65, 0x64, 0x10, 0x01, 0x12,
+	0x10, 0x0a, 0x0c, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x64, 0x10,
+	0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x46, 0x6f, 0x75, 0x6e, 0x64, 0x10,
+	0x03, 0x12, 0x0f, 0x0a, 0x0b, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x51, 0x75, 0x65, 0x75, 0x65, 0x64,
+	0x10, 0x04, 0x2a, 0x33, 0x0a, 0x09, 0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x4f, 0x70, 0x12,
+	0x11, 0x0a, 0x0d, 0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x4f, 0x70, 0x4e, 0x6f, 0x6e, 0x65,
+	0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x4d, 0x6f, 0x6e, 0x73, 0x74, 0x65, 0x72, 0x4f, 0x70, 0x41,
+	0x74, 0x74, 0x61, 0x63, 0x6b, 0x10, 0x01, 0x2a, 0xc0, 0x01, 0x0a, 0x06, 0x54, 0x65, 0x61, 0x6d,
+	0x4f, 0x70, 0x12, 0x0e, 0x0a, 0x0a, 0x54, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x4e, 0x6f, 0x6e, 0x65,
+	0x10, 0x00, 0x12, 0x16, 0x0a, 0x12, 0x54, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x41, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x49, 0x6e, 0x76, 0x69, 0x74, 0x65, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x54, 0x65,
+	0x61, 0x6d, 0x4f, 0x70, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a,
+	0x54, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x10, 0x03, 0x12, 0x10, 0x0a, 0x0c,
+	0x54, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x49, 0x6e, 0x76, 0x69, 0x74, 0x65, 0x10, 0x04, 0x12, 0x0e,
+	0x0a, 0x0a, 0x54, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x4b, 0x69, 0x63, 0x6b, 0x10, 0x05, 0x12, 0x0f,
+	0x0a, 0x0b, 0x54, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x4c, 0x65, 0x61, 0x76, 0x65, 0x10, 0x06, 0x12,
+	0x0f, 0x0a, 0x0b, 0x54, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x52, 0x65, 0x61, 0x64, 0x79, 0x10, 0x07,
+	0x12, 0x14, 0x0a, 0x10, 0x54, 0x65, 0x61, 0x6d, 0x4f, 0x70, 0x52, 0x65, 0x61, 0x64, 0x79, 0x43,
+	0x68, 0x65, 0x63, 0x6b, 0x10, 0x08, 0x12, 0x12, 0x0a, 0x0e, 0x54, 0x65, 0x61, 0x6d, 0x4f, 0x70,
+	0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x10, 0x09, 0x42, 0x21, 0x5a, 0x1f, 0x67, 0x72,
+	0x65, 0x61, 0x74, 0x65, 0x73, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x2f, 0x69, 0x6e, 0x74, 0x65,
+	0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_internal_protocol_player_proto_rawDescOnce sync.Once
+	file_internal_protocol_player_proto_rawDescData = file_internal_protocol_player_proto_rawDesc
+)
+
+func file_internal_protocol_player_proto_rawDescGZIP() []byte {
+	file_internal_protocol_player_proto_rawDescOnce.Do(func() {
+		file_internal_protocol_player_proto_rawDescData = protoimpl.X.CompressGZIP(file_internal_protocol_player_proto_rawDescData)
+	})
+	return file_internal_protocol_player_proto_rawDescData
+}
+
+var file_internal_protocol_player_proto_enumTypes = make([]protoimpl.EnumInfo, 7)
+var file_internal_protocol_player_proto_msgTypes = make([]protoimpl.MessageInfo, 120)
+var file_internal_protocol_player_proto_goTypes = []interface{}{
+	(AuctionOp)(0),               // 0: protocol.AuctionOp
+	(BattleOp)(0),                // 1: protocol.BattleOp
+	(FamilyOp)(0),                // 2: protocol.FamilyOp
+	(MatchOp)(0),                 // 3: protocol.MatchOp
+	(MatchStatus)(0),             // 4: protocol.MatchStatus
+	(MonsterOp)(0),               // 5: protocol.MonsterOp
+	(TeamOp)(0),                  // 6: protocol.TeamOp
+	(*AchievementInfo)(nil),      // 7: protocol.AchievementInfo
+	(*ActivityInfo)(nil),         // 8: protocol.ActivityInfo
+	(*ArenaOpponent)(nil),        // 9: protocol.ArenaOpponent
+	(*ArenaRecord)(nil),          // 10: protocol.ArenaRecord
+	(*ArenaRevenge)(nil),         // 11: protocol.ArenaRevenge
+	(*AttrValue)(nil),            // 12: protocol.AttrValue
+	(*AuctionListing)(nil),       // 13: protocol.AuctionListing
+	(*BagItemChange)(nil),        // 14: protocol.BagItemChange
+	(*BattleEvent)(nil),          // 15: protocol.BattleEvent
+	(*BattlePassInfo)(nil),       // 16: protocol.BattlePassInfo
+	(*BuffEffect)(nil),           // 17: protocol.BuffEffect
+	(*BuffInfo)(nil),             // 18: protocol.BuffInfo
+	(*CSAcceptTask)(nil),         // 19: protocol.CSAcceptTask
+	(*CSActivityJoin)(nil),       // 20: protocol.CSActivityJoin
+	(*CSArenaAttack)(nil),        // 21: protocol.CSArenaAttack
+	(*CSAuctionBid)(nil),         // 22: protocol.CSAuctionBid
+	(*CSAuctionBuyout)(nil),      // 23: protocol.CSAuctionBuyout
+	(*CSAuctionCancel)(nil),      // 24: protocol.CSAuctionCancel
+	(*CSAuctionList)(nil),        // 25: protocol.CSAuctionList
+	(*CSAuctionSearch)(nil),      // 26: protocol.CSAuctionSearch
+	(*CSBattleCast)(nil),         // 27: protocol.CSBattleCast
+	(*CSBattleEnter)(nil),        // 28: protocol.CSBattleEnter
+	(*CSBattlePassClaim)(nil),    // 29: protocol.CSBattlePassClaim
+	(*CSBuffCancel)(nil),         // 30: protocol.CSBuffCancel
+	(*CSBuyGoods)(nil),           // 31: protocol.CSBuyGoods
+	(*CSClaimAchievement)(nil),   // 32: protocol.CSClaimAchievement
+	(*CSDelItem)(nil),            // 33: protocol.CSDelItem
+	(*CSEnhanceEquip)(nil),       // 34: protocol.CSEnhanceEquip
+	(*CSEquip)(nil),              // 35: protocol.CSEquip
+	(*CSFamilyAcceptInvite)(nil), // 36: protocol.CSFamilyAcceptInvite
+	(*CSFamilyApply)(nil),        // 37: protocol.CSFamilyApply
+	(*CSFamilyAppoint)(nil),      // 38: protocol.CSFamilyAppoint
+	(*CSFamilyApprove)(nil),      // 39: protocol.CSFamilyApprove
+	(*CSFamilyCreate)(nil),       // 40: protocol.CSFamilyCreate
+	(*CSFamilyDonate)(nil),       // 41: protocol.CSFamilyDonate
+	(*CSFamilyInfo)(nil),         // 42: protocol.CSFamilyInfo
+	(*CSFamilyInvite)(nil),       // 43: protocol.CSFamilyInvite
+	(*CSFamilyKick)(nil),         // 44: protocol.CSFamilyKick
+	(*CSFamilySetting)(nil),      // 45: protocol.CSFamilySetting
+	(*CSFamilyTransfer)(nil),     // 46: protocol.CSFamilyTransfer
+	(*CSFamilyWithdraw)(nil),     // 47: protocol.CSFamilyWithdraw
+	(*CSMatchEnqueue)(nil),       // 48: protocol.CSMatchEnqueue
+	(*CSMonsterAttack)(nil),      // 49: protocol.CSMonsterAttack
+	(*CSMonsterSync)(nil),        // 50: protocol.CSMonsterSync
+	(*CSRecharge)(nil),           // 51: protocol.CSRecharge
+	(*CSRefineEquip)(nil),        // 52: protocol.CSRefineEquip
+	(*CSRepairEquip)(nil),        // 53: protocol.CSRepairEquip
+	(*CSSkillLearn)(nil),         // 54: protocol.CSSkillLearn
+	(*CSSkillUpgrade)(nil),       // 55: protocol.CSSkillUpgrade
+	(*CSSubmitTask)(nil),         // 56: protocol.CSSubmitTask
+	(*CSTeamAcceptInvite)(nil),   // 57: protocol.CSTeamAcceptInvite
+	(*CSTeamInvite)(nil),         // 58: protocol.CSTeamInvite
+	(*CSTeamKick)(nil),           // 59: protocol.CSTeamKick
+	(*CSTeamReady)(nil),          // 60: protocol.CSTeamReady
+	(*CSTeamReadyCheck)(nil),     // 61: protocol.CSTeamReadyCheck
+	(*CSTeamTransfer)(nil),       // 62: protocol.CSTeamTransfer
+	(*CSUnequip)(nil),            // 63: protocol.CSUnequip
+	(*CurrencyInfo)(nil),         // 64: protocol.CurrencyInfo
+	(*EquipInfo)(nil),            // 65: protocol.EquipInfo
+	(*FamilyMember)(nil),         // 66: protocol.FamilyMember
+	(*FamilyRequest)(nil),        // 67: protocol.FamilyRequest
+	(*MonsterInfo)(nil),          // 68: protocol.MonsterInfo
+	(*SCAchievementList)(nil),    // 69: protocol.SCAchievementList
+	(*SCAchievementUpdate)(nil),  // 70: protocol.SCAchievementUpdate
+	(*SCActivityUpdate)(nil),     // 71: protocol.SCActivityUpdate
+	(*SCAddFriend)(nil),          // 72: protocol.SCAddFriend
+	(*SCArenaDefended)(nil),      // 73: protocol.SCArenaDefended
+	(*SCArenaDefense)(nil),       // 74: protocol.SCArenaDefense
+	(*SCArenaFight)(nil),         // 75: protocol.SCArenaFight
+	(*SCArenaInfo)(nil),          // 76: protocol.SCArenaInfo
+	(*SCArenaOpponents)(nil),     // 77: protocol.SCArenaOpponents
+	(*SCAuctionResult)(nil),      // 78: protocol.SCAuctionResult
+	(*SCAuctionSearch)(nil),      // 79: protocol.SCAuctionSearch
+	(*SCBagChange)(nil),          // 80: protocol.SCBagChange
+	(*SCBattleEnd)(nil),          // 81: protocol.SCBattleEnd
+	(*SCBattleFrame)(nil),        // 82: protocol.SCBattleFrame
+	(*SCBattlePassUpdate)(nil),   // 83: protocol.SCBattlePassUpdate
+	(*SCBattleResult)(nil),       // 84: protocol.SCBattleResult
+	(*SCBroadcast)(nil),          // 85: protocol.SCBroadcast
+	(*SCBuffUpdate)(nil),         // 86: protocol.SCBuffUpdate
+	(*SCBuyGoods)(nil),           // 87: protocol.SCBuyGoods
+	(*SCCurrencyChange)(nil),     // 88: protocol.SCCurrencyChange
+	(*SCCurrencyList)(nil),       // 89: protocol.SCCurrencyList
+	(*SCEquipUpdate)(nil),        // 90: protocol.SCEquipUpdate
+	(*SCFamilyInfo)(nil),         // 91: protocol.SCFamilyInfo
+	(*SCFamilyInvite)(nil),       // 92: protocol.SCFamilyInvite
+	(*SCFamilyLeft)(nil),         // 93: protocol.SCFamilyLeft
+	(*SCFamilyResult)(nil),       // 94: protocol.SCFamilyResult
+	(*SCFriendList)(nil),         // 95: protocol.SCFriendList
+	(*SCFriendStatus)(nil),       // 96: protocol.SCFriendStatus
+	(*SCKick)(nil),               // 97: protocol.SCKick
+	(*SCMatchResult)(nil),        // 98: protocol.SCMatchResult
+	(*SCMatchStatus)(nil),        // 99: protocol.SCMatchStatus
+	(*SCMonsterAttack)(nil),      // 100: protocol.SCMonsterAttack
+	(*SCMonsterResult)(nil),      // 101: protocol.SCMonsterResult
+	(*SCMonsterUpdate)(nil),      // 102: protocol.SCMonsterUpdate
+	(*SCPlayerAttr)(nil),         // 103: protocol.SCPlayerAttr
+	(*SCPlayerStats)(nil),        // 104: protocol.SCPlayerStats
+	(*SCRecharge)(nil),           // 105: protocol.SCRecharge
+	(*SCShopList)(nil),           // 106: protocol.SCShopList
+	(*SCSkillUpdate)(nil),        // 107: protocol.SCSkillUpdate
+	(*SCTaskList)(nil),           // 108: protocol.SCTaskList
+	(*SCTaskUpdate)(nil),         // 109: protocol.SCTaskUpdate
+	(*SCTeamInfo)(nil),           // 110: protocol.SCTeamInfo
+	(*SCTeamInvite)(nil),         // 111: protocol.SCTeamInvite
+	(*SCTeamLeft)(nil),           // 112: protocol.SCTeamLeft
+	(*SCTeamReady)(nil),          // 113: protocol.SCTeamReady
+	(*SCTeamResult)(nil),         // 114: protocol.SCTeamResult
+	(*SCVipUpdate)(nil),          // 115: protocol.SCVipUpdate
+	(*ShopGoods)(nil),            // 116: protocol.ShopGoods
+	(*ShopInfo)(nil),             // 117: protocol.ShopInfo
+	(*SkillInfo)(nil),            // 118: protocol.SkillInfo
+	(*TaskInfo)(nil),             // 119: protocol.TaskInfo
+	(*TeamMember)(nil),           // 120: protocol.TeamMember
+	(*TeamReadyCheck)(nil),       // 121: protocol.TeamReadyCheck
+	(*SCChatMsg)(nil),            // 122: protocol.SCChatMsg
+	nil,                          // 123: protocol.SCAchievementList.StatsEntry
+	nil,                          // 124: protocol.SCBroadcast.ParamsEntry
+	nil,                          // 125: protocol.SCFamilyInfo.TreasuryEntry
+	nil,                          // 126: protocol.SCPlayerStats.StatsEntry
+}
+var file_internal_protocol_player_proto_depIdxs = []int32{
+	123, // 0: protocol.SCAchievementList.Stats:type_name -> protocol.SCAchievementList.StatsEntry
+	7,   // 1: protocol.SCAchievementList.Achievements:type_name -> protocol.AchievementInfo
+	7,   // 2: protocol.SCAchievementUpdate.Achievement:type_name -> protocol.AchievementInfo
+	8,   // 3: protocol.SCActivityUpdate.Activities:type_name -> protocol.ActivityInfo
+	10,  // 4: protocol.SCArenaDefended.Record:type_name -> protocol.ArenaRecord
+	10,  // 5: protocol.SCArenaFight.Record:type_name -> protocol.ArenaRecord
+	11,  // 6: protocol.SCArenaInfo.Revenges:type_name -> protocol.ArenaRevenge
+	10,  // 7: protocol.SCArenaInfo.Records:type_name -> protocol.ArenaRecord
+	9,   // 8: protocol.SCArenaOpponents.Opponents:type_name -> protocol.ArenaOpponent
+	0,   // 9: protocol.SCAuctionResult.Op:type_name -> protocol.AuctionOp
+	13,  // 10: protocol.SCAuctionResult.Listing:type_name -> protocol.AuctionListing
+	13,  // 11: protocol.SCAuctionSearch.Listings:type_name -> protocol.AuctionListing
+	14,  // 12: protocol.SCBagChange.Items:type_name -> protocol.BagItemChange
+	15,  // 13: protocol.SCBattleFrame.Events:type_name -> protocol.BattleEvent
+	16,  // 14: protocol.SCBattlePassUpdate.Passes:type_name -> protocol.BattlePassInfo
+	1,   // 15: protocol.SCBattleResult.Op:type_name -> protocol.BattleOp
+	124, // 16: protocol.SCBroadcast.Params:type_name -> protocol.SCBroadcast.ParamsEntry
+	18,  // 17: protocol.SCBuffUpdate.Buffs:type_name -> protocol.BuffInfo
+	17,  // 18: protocol.SCBuffUpdate.Effects:type_name -> protocol.BuffEffect
+	116, // 19: protocol.SCBuyGoods.Goods:type_name -> protocol.ShopGoods
+	64,  // 20: protocol.SCCurrencyChange.Currency:type_name -> protocol.CurrencyInfo
+	64,  // 21: protocol.SCCurrencyList.Currencies:type_name -> protocol.CurrencyInfo
+	65,  // 22: protocol.SCEquipUpdate.Equip:type_name -> protocol.EquipInfo
+	125, // 23: protocol.SCFamilyInfo.Treasury:type_name -> protocol.SCFamilyInfo.TreasuryEntry
+	66,  // 24: protocol.SCFamilyInfo.Members:type_name -> protocol.FamilyMember
+	67,  // 25: protocol.SCFamilyInfo.Requests:type_name -> protocol.FamilyRequest
+	2,   // 26: protocol.SCFamilyResult.Op:type_name -> protocol.FamilyOp
+	3,   // 27: protocol.SCMatchResult.Op:type_name -> protocol.MatchOp
+	4,   // 28: protocol.SCMatchStatus.Status:type_name -> protocol.MatchStatus
+	5,   // 29: protocol.SCMonsterResult.Op:type_name -> protocol.MonsterOp
+	68,  // 30: protocol.SCMonsterUpdate.Monsters:type_name -> protocol.MonsterInfo
+	12,  // 31: protocol.SCPlayerAttr.Attrs:type_name -> protocol.AttrValue
+	126, // 32: protocol.SCPlayerStats.Stats:type_name -> protocol.SCPlayerStats.StatsEntry
+	117, // 33: protocol.SCShopList.Shops:type_name -> protocol.ShopInfo
+	118, // 34: protocol.SCSkillUpdate.Skills:type_name -> protocol.SkillInfo
+	119, // 35: protocol.SCTaskList.Tasks:type_name -> protocol.TaskInfo
+	119, // 36: protocol.SCTaskUpdate.Task:type_name -> protocol.TaskInfo
+	120, // 37: protocol.SCTeamInfo.Members:type_name -> protocol.TeamMember
+	121, // 38: protocol.SCTeamInfo.Check:type_name -> protocol.TeamReadyCheck
+	6,   // 39: protocol.SCTeamResult.Op:type_name -> protocol.TeamOp
+	116, // 40: protocol.ShopInfo.Goods:type_name -> protocol.ShopGoods
+	41,  // [41:41] is the sub-list for method output_type
+	41,  // [41:41] is the sub-list for method input_type
+	41,  // [41:41] is the sub-list for extension type_name
+	41,  // [41:41] is the sub-list for extension extendee
+	0,   // [0:41] is the sub-list for field type_name
+}
+
+func init() { file_internal_protocol_player_proto_init() }
+func file_internal_protocol_player_proto_init() {
+	if File_internal_protocol_player_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_internal_protocol_player_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AchievementInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ActivityInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ArenaOpponent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ArenaRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ArenaRevenge); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AttrValue); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuctionListing); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BagItemChange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BattleEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BattlePassInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BuffEffect); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BuffInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSAcceptTask); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSActivityJoin); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSArenaAttack); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSAuctionBid); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSAuctionBuyout); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSAuctionCancel); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSAuctionList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSAuctionSearch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSBattleCast); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSBattleEnter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSBattlePassClaim); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSBuffCancel); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSBuyGoods); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSClaimAchievement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSDelItem); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSEnhanceEquip); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSEquip); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilyAcceptInvite); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilyApply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilyAppoint); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilyApprove); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilyCreate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilyDonate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilyInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilyInvite); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilyKick); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilySetting); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilyTransfer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSFamilyWithdraw); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSMatchEnqueue); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSMonsterAttack); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSMonsterSync); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSRecharge); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSRefineEquip); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSRepairEquip); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSSkillLearn); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSSkillUpgrade); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSSubmitTask); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSTeamAcceptInvite); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[51].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSTeamInvite); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[52].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSTeamKick); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[53].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSTeamReady); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[54].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSTeamReadyCheck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[55].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSTeamTransfer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[56].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CSUnequip); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[57].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CurrencyInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[58].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EquipInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[59].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FamilyMember); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[60].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FamilyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[61].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MonsterInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[62].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCAchievementList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[63].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCAchievementUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[64].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCActivityUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[65].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCAddFriend); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[66].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCArenaDefended); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[67].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCArenaDefense); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[68].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCArenaFight); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[69].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCArenaInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[70].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCArenaOpponents); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[71].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCAuctionResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[72].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCAuctionSearch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[73].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCBagChange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[74].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCBattleEnd); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[75].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCBattleFrame); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[76].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCBattlePassUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[77].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCBattleResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[78].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCBroadcast); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[79].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCBuffUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[80].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCBuyGoods); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[81].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCCurrencyChange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[82].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCCurrencyList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[83].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCEquipUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[84].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCFamilyInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[85].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCFamilyInvite); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[86].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCFamilyLeft); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[87].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCFamilyResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[88].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCFriendList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[89].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCFriendStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[90].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCKick); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[91].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCMatchResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[92].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCMatchStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[93].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCMonsterAttack); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[94].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCMonsterResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[95].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCMonsterUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[96].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCPlayerAttr); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[97].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCPlayerStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[98].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCRecharge); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[99].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCShopList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[100].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCSkillUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[101].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCTaskList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[102].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCTaskUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[103].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCTeamInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[104].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCTeamInvite); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[105].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCTeamLeft); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[106].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCTeamReady); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[107].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCTeamResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[108].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCVipUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[109].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ShopGoods); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[110].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ShopInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[111].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SkillInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[112].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TaskInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[113].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TeamMember); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[114].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TeamReadyCheck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_protocol_player_proto_msgTypes[115].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SCChatMsg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_internal_protocol_player_proto_rawDesc,
+			NumEnums:      7,
+			NumMessages:   120,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_protocol_player_proto_goTypes,
+		DependencyIndexes: file_internal_protocol_player_proto_depIdxs,
+		EnumInfos:         file_internal_protocol_player_proto_enumTypes,
+		MessageInfos:      file_internal_protocol_player_proto_msgTypes,
+	}.Build()
+	File_internal_protocol_player_proto = out.File
+	file_internal_protocol_player_proto_rawDesc = nil
+	file_internal_protocol_player_proto_goTypes = nil
+	file_internal_protocol_player_proto_depIdxs = nil
+}
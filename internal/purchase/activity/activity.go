@@ -1,5 +0,0 @@
-package activity
-
-type Activity interface {
-	CheckInTimeRange() bool
-}
@@ -0,0 +1,156 @@
+package activity
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"greatestworks/aop/loader/json"
+	"greatestworks/aop/mongo"
+)
+
+// Targets of the progress of the activities, on the events of the other
+// modules.
+const (
+	TargetJoin   = "join"   // join the activity (Module.Join)
+	TargetKill   = "kill"   // kill Param monsters (playerevent.Kill), any if Param is 0
+	TargetBattle = "battle" // win battles of mode Param (battleevent.Finished), any if Param is 0
+)
+
+// timeLayout is the layout of the dates of the activity table.
+const timeLayout = "2006-01-02 15:04:05"
+
+// Stage 活动进度阶段
+type Stage struct {
+	Progress int64            `json:"progress"` // 需要达到的进度
+	Rewards  []mongo.MailItem `json:"rewards"`  // 达到后邮件发放的奖励
+}
+
+// Conf 活动配置
+type Conf struct {
+	Id       uint32  `json:"id"`
+	Name     string  `json:"name"`
+	Schedule string  `json:"schedule"` // 开启时间，cron表达式(分 时 日 月 周)
+	Timezone string  `json:"timezone"` // 时区，如Asia/Shanghai，为空则用服务器时区
+	Duration int64   `json:"duration"` // 每次开启的时长，秒
+	Begin    string  `json:"begin"`    // 上线时间(2006-01-02 15:04:05，按时区)，为空则不限
+	End      string  `json:"end"`      // 下线时间，为空则不限
+	Level    uint32  `json:"level"`    // 参与等级
+	Times    int64   `json:"times"`    // 每次开启每个玩家可参与的次数，0为不限
+	Target   string  `json:"target"`   // 进度目标(Target*)
+	Param    uint32  `json:"param"`    // 目标参数
+	Stages   []Stage `json:"stages"`   // 进度阶段，按进度升序
+	Disabled bool    `json:"disabled"` // 默认关闭，由GM开启
+
+	schedule   cron.Schedule
+	begin, end time.Time // zero if unbounded
+}
+
+// round returns the start of the round of the activity open at now, if
+// it's open; a round lasts Duration from a time of the schedule, within the
+// begin and the end of the activity.
+func (c *Conf) round(now time.Time) (time.Time, bool) {
+	if !c.begin.IsZero() && now.Before(c.begin) || !c.end.IsZero() && !now.Before(c.end) {
+		return time.Time{}, false
+	}
+	// The earliest round that started after the last one that ended, and
+	// not before the begin.
+	from := now.Add(-time.Duration(c.Duration) * time.Second)
+	if from.Before(c.begin) {
+		from = c.begin.Add(-time.Second)
+	}
+	start := c.schedule.Next(from)
+	if start.IsZero() || start.After(now) {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// next returns the start of the next round of the activity after now, if
+// there's one.
+func (c *Conf) next(now time.Time) (time.Time, bool) {
+	if now.Before(c.begin) {
+		now = c.begin.Add(-time.Second)
+	}
+	start := c.schedule.Next(now)
+	if start.IsZero() || !c.end.IsZero() && !start.Before(c.end) {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// ModuleConfig is the config of the activity module. It must be set before
+// the module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ActivityFile  string        // activity table, see Conf
+	TickInterval  time.Duration // how often the activities are opened and closed
+	FetchInterval time.Duration // how often the GM switches are fetched from redis
+}
+
+const (
+	defaultTickInterval  = time.Second
+	defaultFetchInterval = 10 * time.Second
+)
+
+// loadTable loads the activity table, parsing the schedules in their
+// timezone.
+func loadTable(file string) (map[uint32]*Conf, error) {
+	var list []*Conf
+	if !json.ParseJsonFile2Slice(file, true, &list) {
+		return nil, fmt.Errorf("activity: activity table %q not found", file)
+	}
+	confs := make(map[uint32]*Conf, len(list))
+	for _, c := range list {
+		if confs[c.Id] != nil {
+			return nil, fmt.Errorf("activity: activity table %q: repeat activity %d", file, c.Id)
+		}
+		if err := c.parse(); err != nil {
+			return nil, fmt.Errorf("activity: activity table %q: activity %d: %w", file, c.Id, err)
+		}
+		confs[c.Id] = c
+	}
+	return confs, nil
+}
+
+// parse parses the schedule and the dates of an activity, and checks the
+// rest.
+func (c *Conf) parse() error {
+	loc := time.Local
+	spec := c.Schedule
+	if c.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(c.Timezone); err != nil {
+			return err
+		}
+		spec = "CRON_TZ=" + c.Timezone + " " + spec
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("schedule %q: %w", c.Schedule, err)
+	}
+	c.schedule = schedule
+	if c.Begin != "" {
+		if c.begin, err = time.ParseInLocation(timeLayout, c.Begin, loc); err != nil {
+			return err
+		}
+	}
+	if c.End != "" {
+		if c.end, err = time.ParseInLocation(timeLayout, c.End, loc); err != nil {
+			return err
+		}
+	}
+	if c.Duration <= 0 {
+		return fmt.Errorf("invalid duration %d", c.Duration)
+	}
+	switch c.Target {
+	case "", TargetJoin, TargetKill, TargetBattle:
+	default:
+		return fmt.Errorf("unknown target %q", c.Target)
+	}
+	for i := 1; i < len(c.Stages); i++ {
+		if c.Stages[i].Progress <= c.Stages[i-1].Progress {
+			return fmt.Errorf("stage %d: progress not ascending", i)
+		}
+	}
+	return nil
+}
@@ -1,4 +1,86 @@
 package activity
 
-type Model struct {
+import (
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Record is the participation of a player in the current, or last, round of
+// an activity.
+type Record struct {
+	Id       uint32 `bson:"id"`    // 活动id
+	Round    int64  `bson:"round"` // 本轮开启时间
+	Joins    int64  `bson:"joins"` // 参与次数
+	Progress int64  `bson:"prog"`  // 进度
+	Stages   int    `bson:"stg"`   // 已发奖的阶段数
+}
+
+// Doc 对应DB -> mongo
+type Doc struct {
+	Records []Record `bson:"records"`
+}
+
+// Data is the participation of a player in the activities. It progresses on
+// the goroutines of the event bus, and is joined on the lane of the player,
+// so it's guarded by mu.
+type Data struct {
+	uid       uint64
+	player    Player
+	markDirty func()
+
+	mu      sync.Mutex
+	records map[uint32]*Record // by activity; guarded by mu
+}
+
+func NewData() *Data {
+	return &Data{records: map[uint32]*Record{}}
+}
+
+// SetOwner sets the player the data belongs to, and how it's marked dirty.
+func (d *Data) SetOwner(player Player, uid uint64, markDirty func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.player = player
+	d.uid = uid
+	d.markDirty = markDirty
+}
+
+// Save returns a copy of the records, to be stored.
+func (d *Data) Save() (interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	doc := Doc{Records: make([]Record, 0, len(d.records))}
+	for _, r := range d.records {
+		doc.Records = append(doc.Records, *r)
+	}
+	return doc, nil
+}
+
+// Load loads stored records.
+func (d *Data) Load(raw bson.RawValue) error {
+	var doc Doc
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.records = make(map[uint32]*Record, len(doc.Records))
+	for i := range doc.Records {
+		d.records[doc.Records[i].Id] = &doc.Records[i]
+	}
+	return nil
+}
+
+// record returns the record of a player in a round of an activity, starting
+// it over if it was of a previous round.
+//
+// REQUIRES: d.mu is held.
+func (d *Data) record(id uint32, round int64) *Record {
+	r := d.records[id]
+	if r == nil || r.Round != round {
+		r = &Record{Id: id, Round: round}
+		d.records[id] = r
+	}
+	return r
 }
@@ -0,0 +1,57 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player IPlayer, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerActivityRegister()
+	})
+}
+
+func HandlerActivityRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSActivityJoin, Join},
+	)
+}
+
+// Join takes part in an open activity. The participation is pushed with the
+// activity.
+func Join(p IPlayer, packet *network.Message) {
+	req := &protocol.CSActivityJoin{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	d := p.GetActivityData()
+	if err := GetMod().Join(context.Background(), d, req.Id); err != nil {
+		logger.Warn("[activity] join activity %v PlayerID:%v err:%v", req.Id, d.uid, err)
+	}
+}
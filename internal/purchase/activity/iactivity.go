@@ -1,4 +0,0 @@
-package activity
-
-type IActivity interface {
-}
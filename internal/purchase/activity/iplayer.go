@@ -1,4 +1,16 @@
 package activity
 
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+)
+
 type IPlayer interface {
+	GetActivityData() *Data
+}
+
+// Player is the player the Data belongs to.
+type Player interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+	GetLevel() uint32
 }
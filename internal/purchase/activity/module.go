@@ -1,33 +1,436 @@
 package activity
 
 import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/phuhao00/greatestworks-proto/module"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
 	"greatestworks/aop/module_router"
+	"greatestworks/aop/redis"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/note/event/activityevent"
+	"greatestworks/internal/protocol"
 )
 
+// switchKey is the redis hash of the GM switches of the activities: "1" if
+// enabled, "0" if disabled, by activity id. They override Conf.Disabled.
+const switchKey = "activity:switch"
+
 var (
 	Mod         *Module
 	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNoActivity = errors.New("activity: no such activity")
+	ErrClosed     = errors.New("activity: activity not open")
+	ErrLevel      = errors.New("activity: level too low")
+	ErrTimes      = errors.New("activity: no participation left")
+)
+
+var (
+	opens = metrics.NewGaugeMap[activityLabels](
+		"activity_open",
+		"Whether an activity is open on this server",
+	)
+	joins = metrics.NewCounterMap[activityLabels](
+		"activity_joins",
+		"Number of participations in an activity",
+	)
+	rewards = metrics.NewCounterMap[activityLabels](
+		"activity_rewards",
+		"Number of stage rewards of an activity mailed",
+	)
 )
 
+type activityLabels struct {
+	Activity string
+}
+
 func init() {
 	internal.ModuleManager.RegisterModule(module.Module_Activity.String(), GetMod())
 }
 
+// Module opens and closes the activities of the activity table on their
+// schedules, unless disabled, and advances the players in the open rounds
+// on the events of the other modules (see subscribe), mailing them the
+// rewards of the stages they reach. Every server runs the schedules on its
+// own; the GM switches are shared through redis.
 type Module struct {
 	*internal.BaseModule
-	*internal.MetricsBase
-	*internal.DBActionBase
+	initFlag      bool
+	confs         map[uint32]*Conf
+	tickInterval  time.Duration
+	fetchInterval time.Duration
+	stopCh        chan struct{}
+
+	mu       sync.Mutex
+	rounds   map[uint32]int64 // start of the open round, by activity; guarded by mu
+	switches map[uint32]bool  // GM switches, by activity; guarded by mu
+	online   map[uint64]*Data // guarded by mu
 }
 
 func GetMod() *Module {
-	Mod = &Module{BaseModule: internal.NewBaseModule()}
-
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
 	return Mod
 }
 
+// Init loads the activity table.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.confs = map[uint32]*Conf{}
+	if conf.ActivityFile != "" {
+		confs, err := loadTable(conf.ActivityFile)
+		if err != nil {
+			return err
+		}
+		m.confs = confs
+	} else {
+		logger.Warn("[activity] no activity table")
+	}
+	m.tickInterval = conf.TickInterval
+	if m.tickInterval <= 0 {
+		m.tickInterval = defaultTickInterval
+	}
+	m.fetchInterval = conf.FetchInterval
+	if m.fetchInterval <= 0 {
+		m.fetchInterval = defaultFetchInterval
+	}
+	m.rounds = make(map[uint32]int64)
+	m.switches = make(map[uint32]bool)
+	m.online = make(map[uint64]*Data)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart fetches the GM switches, and starts running the schedules.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	m.fetch()
+	m.tick(time.Now())
+	m.subscribe()
+	go m.run()
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+		m.unsubscribe()
+	}
+}
+
+// run opens and closes the activities every ModuleConfig.TickInterval, and
+// fetches the GM switches every ModuleConfig.FetchInterval, until the module
+// is stopped.
+func (m *Module) run() {
+	ticker := time.NewTicker(m.tickInterval)
+	defer ticker.Stop()
+	fetch := time.NewTicker(m.fetchInterval)
+	defer fetch.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			m.tick(now)
+		case <-fetch.C:
+			m.fetch()
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// tick opens the rounds of the enabled activities that start by now, and
+// closes those that are over, or disabled. The online players are pushed
+// the activities that changed.
+func (m *Module) tick(now time.Time) {
+	var opened []activityevent.Opened
+	var closed []activityevent.Closed
+	m.mu.Lock()
+	for id, c := range m.confs {
+		start, open := c.round(now)
+		open = open && m.enabled(c)
+		prev, was := m.rounds[id]
+		switch {
+		case open && (!was || prev != start.Unix()):
+			if was {
+				closed = append(closed, activityevent.Closed{ActivityId: id, Round: prev})
+			}
+			m.rounds[id] = start.Unix()
+			opened = append(opened, activityevent.Opened{ActivityId: id, Round: start.Unix(), End: start.Unix() + c.Duration})
+		case !open && was:
+			delete(m.rounds, id)
+			closed = append(closed, activityevent.Closed{ActivityId: id, Round: prev})
+		}
+	}
+	m.mu.Unlock()
+	if len(opened) == 0 && len(closed) == 0 {
+		return
+	}
+
+	var ids []uint32
+	for _, e := range closed {
+		opens.Get(labelsOf(e.ActivityId)).Set(0)
+		logger.Info("[activity] close activity %v round %v", e.ActivityId, e.Round)
+		eventbus.Publish(eventbus.Default, e)
+		ids = append(ids, e.ActivityId)
+	}
+	for _, e := range opened {
+		opens.Get(labelsOf(e.ActivityId)).Set(1)
+		logger.Info("[activity] open activity %v round %v", e.ActivityId, e.Round)
+		eventbus.Publish(eventbus.Default, e)
+		ids = append(ids, e.ActivityId)
+	}
+	for _, d := range m.onlineData() {
+		m.push(d, now, ids...)
+	}
+}
+
+// enabled returns whether an activity is enabled: by its GM switch if it has
+// one, else unless its config disables it.
+//
+// REQUIRES: m.mu is held.
+func (m *Module) enabled(c *Conf) bool {
+	if on, ok := m.switches[c.Id]; ok {
+		return on
+	}
+	return !c.Disabled
+}
+
+// fetch fetches the GM switches, set on any server.
+func (m *Module) fetch() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	vals, err := redis.Get().HGetAll(ctx, switchKey).Result()
+	if err != nil {
+		logger.Error("[activity] fetch switches err:%v", err)
+		return
+	}
+	switches := make(map[uint32]bool, len(vals))
+	for field, v := range vals {
+		id, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			continue
+		}
+		switches[uint32(id)] = v == "1"
+	}
+	m.mu.Lock()
+	m.switches = switches
+	m.mu.Unlock()
+}
+
+// Switch enables or disables an activity, at once on this server, and
+// within ModuleConfig.FetchInterval on the others. A disabled activity
+// closes, and doesn't open until it's enabled again.
+func (m *Module) Switch(ctx context.Context, id uint32, enabled bool) error {
+	if m.confs[id] == nil {
+		return ErrNoActivity
+	}
+	v := "0"
+	if enabled {
+		v = "1"
+	}
+	if err := redis.Get().HSet(ctx, switchKey, strconv.FormatUint(uint64(id), 10), v).Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.switches[id] = enabled
+	m.mu.Unlock()
+	logger.Info("[activity] switch activity %v enabled:%v", id, enabled)
+	m.tick(time.Now())
+	return nil
+}
+
+// round returns the start of the open round of an activity, if it's open.
+func (m *Module) round(id uint32) (int64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	start, ok := m.rounds[id]
+	return start, ok
+}
+
+// Round returns the start and the end of the open round of an activity, in
+// unix seconds, if it's open, e.g. for the modules whose seasons are the
+// rounds of an activity.
+func (m *Module) Round(id uint32) (start, end int64, open bool) {
+	conf := m.confs[id]
+	if conf == nil {
+		return 0, 0, false
+	}
+	start, open = m.round(id)
+	if !open {
+		return 0, 0, false
+	}
+	return start, start + conf.Duration, true
+}
+
+// Join takes part in the open round of an activity, within the times a
+// player may join it.
+func (m *Module) Join(ctx context.Context, d *Data, id uint32) error {
+	conf := m.confs[id]
+	if conf == nil {
+		return ErrNoActivity
+	}
+	round, ok := m.round(id)
+	if !ok {
+		return ErrClosed
+	}
+	if d.player.GetLevel() < conf.Level {
+		return ErrLevel
+	}
+	d.mu.Lock()
+	r := d.record(id, round)
+	if conf.Times > 0 && r.Joins >= conf.Times {
+		d.mu.Unlock()
+		return ErrTimes
+	}
+	r.Joins++
+	n := r.Joins
+	d.mu.Unlock()
+	d.markDirty()
+
+	joins.Get(labelsOf(id)).Add(1)
+	eventbus.Publish(eventbus.Default, activityevent.Joined{ActivityId: id, Round: round, PlayerId: d.uid, Joins: n})
+	if conf.Target == TargetJoin {
+		m.progress(ctx, d, conf, round, 1)
+		return nil
+	}
+	m.push(d, time.Now(), id)
+	return nil
+}
+
+// advance advances a player online on this server in the open activities
+// with a target, by n.
+func (m *Module) advance(ctx context.Context, uid uint64, target string, param uint32, n int64) {
+	d := m.dataOf(uid)
+	if d == nil {
+		return
+	}
+	for id, conf := range m.confs {
+		if conf.Target != target || conf.Param != 0 && conf.Param != param {
+			continue
+		}
+		round, ok := m.round(id)
+		if !ok || d.player.GetLevel() < conf.Level {
+			continue
+		}
+		m.progress(ctx, d, conf, round, n)
+	}
+}
+
+// progress adds n to the progress of a player in a round of an activity,
+// and mails it the rewards of the stages it reaches.
+func (m *Module) progress(ctx context.Context, d *Data, conf *Conf, round int64, n int64) {
+	d.mu.Lock()
+	r := d.record(conf.Id, round)
+	r.Progress += n
+	first := r.Stages
+	for r.Stages < len(conf.Stages) && r.Progress >= conf.Stages[r.Stages].Progress {
+		r.Stages++
+	}
+	last := r.Stages
+	d.mu.Unlock()
+	d.markDirty()
+
+	for i := first; i < last; i++ {
+		m.reward(ctx, d.uid, conf, round, i)
+	}
+	m.push(d, time.Now(), conf.Id)
+}
+
+// Online registers the data of a player that logged in, and pushes it the
+// activities.
+func (m *Module) Online(d *Data) {
+	m.mu.Lock()
+	m.online[d.uid] = d
+	m.mu.Unlock()
+	m.push(d, time.Now())
+}
+
+// Offline unregisters the data of a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
+}
+
+// dataOf returns the data of a player online on this server, or nil.
+func (m *Module) dataOf(uid uint64) *Data {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.online[uid]
+}
+
+// onlineData returns the data of the players online on this server.
+func (m *Module) onlineData() []*Data {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := make([]*Data, 0, len(m.online))
+	for _, d := range m.online {
+		all = append(all, d)
+	}
+	return all
+}
+
+// push pushes some activities to a player, all of them if none is given.
+func (m *Module) push(d *Data, now time.Time, ids ...uint32) {
+	if len(ids) == 0 {
+		for id := range m.confs {
+			ids = append(ids, id)
+		}
+	}
+	infos := make([]info, 0, len(ids))
+	for _, id := range ids {
+		conf := m.confs[id]
+		i := info{conf: conf}
+		i.round, i.open = m.round(id)
+		if !i.open {
+			if next, ok := conf.next(now); ok {
+				i.next = next.Unix()
+			}
+		}
+		d.mu.Lock()
+		if r := d.records[id]; r != nil && i.open && r.Round == i.round {
+			i.record = *r
+		}
+		d.mu.Unlock()
+		infos = append(infos, i)
+	}
+	d.player.SendMsg(protocol.MessageId_SCActivityUpdate, toProto(infos))
+}
+
+func labelsOf(id uint32) activityLabels {
+	return activityLabels{Activity: strconv.FormatUint(uint64(id), 10)}
+}
+
+// Dependencies returns the modules the activity module depends on: it mails
+// the rewards.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Email.String()}
+}
+
+func (m *Module) SetName(name string) {
+	m.BaseModule.SetName(name)
+}
+
+func (m *Module) GetName() string {
+	return module.Module_Activity.String()
+}
+
 func (m *Module) RegisterHandler() {
 	module_router.RegisterModuleMessageHandler(module.Module_Activity, 0, nil)
 }
@@ -1,23 +1,39 @@
 package activity
 
 import (
+	"context"
+
+	eventbus "greatestworks/aop/event"
 	"greatestworks/internal"
 	"greatestworks/internal/note/event"
+	"greatestworks/internal/note/event/battleevent"
+	"greatestworks/internal/note/event/playerevent"
 )
 
-type EventHandle func(iEvent event.IEvent)
+// OnEvent is unused: the activities subscribe to the event bus instead (see
+// subscribe).
+func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
+}
 
-type EventWrap struct {
-	IPlayer
-	event.IEvent
+func (m *Module) SetEventCategoryActive(eventCategory int) {
 }
 
-func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
-	//TODO implement me
-	panic("implement me")
+// subscribe subscribes to the events the activities progress on.
+func (m *Module) subscribe() {
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e playerevent.Kill) {
+		if !e.IsPlayer {
+			m.advance(context.Background(), e.PlayerId, TargetKill, uint32(e.TargetId), 1)
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e battleevent.Finished) {
+		if e.Won {
+			m.advance(context.Background(), e.PlayerId, TargetBattle, e.Mode, 1)
+		}
+	})
 }
 
-func (m *Module) SetEventCategoryActive(eventCategory int) {
-	//TODO implement me
-	panic("implement me")
+// unsubscribe cancels the subscriptions of the module, once the events
+// already received are handled.
+func (m *Module) unsubscribe() {
+	eventbus.Default.UnsubscribeModule(m.GetName())
 }
@@ -0,0 +1,36 @@
+package activity
+
+import (
+	"github.com/phuhao00/greatestworks-proto/player"
+	"greatestworks/internal/protocol"
+)
+
+// info is the state of an activity for a player.
+type info struct {
+	conf   *Conf
+	open   bool
+	round  int64  // start of the open round, if open
+	next   int64  // start of the next round, if closed; 0 if none
+	record Record // of the player in the open round
+}
+
+func toProto(infos []info) *protocol.SCActivityUpdate {
+	msg := &protocol.SCActivityUpdate{}
+	for _, i := range infos {
+		a := &protocol.ActivityInfo{
+			Id:       i.conf.Id,
+			Open:     i.open,
+			Next:     i.next,
+			Joins:    i.record.Joins,
+			Times:    i.conf.Times,
+			Progress: i.record.Progress,
+			Stages:   int32(i.record.Stages),
+		}
+		if i.open {
+			a.Start = i.round
+			a.End = i.round + i.conf.Duration
+		}
+		msg.Activities = append(msg.Activities, a)
+	}
+	return msg
+}
@@ -0,0 +1,37 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"greatestworks/aop/logger"
+	"greatestworks/aop/mongo"
+	"greatestworks/internal/communicate/email"
+)
+
+// mailId returns the id of the mail of the rewards of a stage of a round of
+// an activity to a player, so that they're never mailed twice.
+func mailId(format string, a ...interface{}) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, format, a...)
+	return h.Sum64()
+}
+
+// reward mails a player the rewards of a stage of a round of an activity.
+func (m *Module) reward(ctx context.Context, uid uint64, conf *Conf, round int64, stage int) {
+	items := conf.Stages[stage].Rewards
+	if len(items) == 0 {
+		return
+	}
+	err := email.GetMod().Send(ctx, uid, &mongo.MailInfo{
+		MUuid:    mailId("activity:%d:%d:%d:%d", conf.Id, round, stage, uid),
+		MContent: fmt.Sprintf("%s: stage %d", conf.Name, stage+1),
+		MItems:   items,
+	})
+	if err != nil {
+		logger.Error("[activity] mail rewards of activity %v stage %v to PlayerID:%v err:%v", conf.Id, stage, uid, err)
+		return
+	}
+	rewards.Get(labelsOf(conf.Id)).Add(1)
+}
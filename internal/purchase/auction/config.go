@@ -0,0 +1,30 @@
+package auction
+
+import (
+	"time"
+
+	"greatestworks/internal/purchase/currency"
+)
+
+// ModuleConfig is the config of the auction module. It must be set before
+// the module is initialized (see Module.Init).
+type ModuleConfig struct {
+	Duration      time.Duration   // how long the listings stay on sale
+	TaxRate       int64           // part of the price of the listings sold kept by the auction house, per 10000
+	MinRaise      int64           // least raise of a bid over the previous one, per 10000 of it
+	MaxListings   int64           // most listings on sale per player
+	PageSize      int64           // most listings per page of search results
+	SweepInterval time.Duration   // how often the expired listings are settled
+	Currencies    []currency.Type // currencies the listings are priced in
+}
+
+const (
+	defaultDuration      = 24 * time.Hour
+	defaultTaxRate       = 500
+	defaultMinRaise      = 500
+	defaultMaxListings   = 20
+	defaultPageSize      = 20
+	defaultSweepInterval = 30 * time.Second
+)
+
+var defaultCurrencies = []currency.Type{currency.Gold, currency.Diamond}
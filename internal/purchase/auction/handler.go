@@ -0,0 +1,131 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
+	"greatestworks/internal/purchase/currency"
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player Player, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerAuctionRegister()
+	})
+}
+
+func HandlerAuctionRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSAuctionList, List},
+		&Handler{protocol.MessageId_CSAuctionSearch, Search},
+		&Handler{protocol.MessageId_CSAuctionBid, Bid},
+		&Handler{protocol.MessageId_CSAuctionBuyout, Buyout},
+		&Handler{protocol.MessageId_CSAuctionCancel, Cancel},
+	)
+}
+
+// List puts items of the bag on sale.
+func List(p Player, packet *network.Message) {
+	req := &protocol.CSAuctionList{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	l, err := GetMod().List(context.Background(), p, req.ItemId, req.Num, currency.Type(req.Currency), req.Price, req.Buyout)
+	if err != nil {
+		logger.Warn("[auction] list item %v PlayerID:%v err:%v", req.ItemId, p.GetUId(), err)
+	}
+	var id uint64
+	if l != nil {
+		id = l.Id
+	}
+	p.SendMsg(protocol.MessageId_SCAuctionResult, resultToProto(protocol.AuctionOp_AuctionOpList, id, l, err))
+}
+
+// Search sends a page of the listings on sale.
+func Search(p Player, packet *network.Message) {
+	req := &protocol.CSAuctionSearch{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	q := Query{
+		ItemId:   req.ItemId,
+		Category: req.Category,
+		Currency: req.Currency,
+		MinPrice: req.MinPrice,
+		MaxPrice: req.MaxPrice,
+		Sort:     Sort(req.Sort),
+		Page:     req.Page,
+	}
+	if req.Mine {
+		q.Seller = p.GetUId()
+	}
+	page, total, err := GetMod().Search(context.Background(), q)
+	if err != nil {
+		logger.Error("[auction] search PlayerID:%v err:%v", p.GetUId(), err)
+		return
+	}
+	p.SendMsg(protocol.MessageId_SCAuctionSearch, searchToProto(page, total, q.Page))
+}
+
+// Bid bids on a listing.
+func Bid(p Player, packet *network.Message) {
+	req := &protocol.CSAuctionBid{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	l, err := GetMod().Bid(context.Background(), p.GetUId(), req.Id, req.Amount)
+	if err != nil {
+		logger.Warn("[auction] bid %v on listing %v PlayerID:%v err:%v", req.Amount, req.Id, p.GetUId(), err)
+	}
+	p.SendMsg(protocol.MessageId_SCAuctionResult, resultToProto(protocol.AuctionOp_AuctionOpBid, req.Id, l, err))
+}
+
+// Buyout buys a listing out.
+func Buyout(p Player, packet *network.Message) {
+	req := &protocol.CSAuctionBuyout{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	l, err := GetMod().Buyout(context.Background(), p.GetUId(), req.Id)
+	if err != nil {
+		logger.Warn("[auction] buyout listing %v PlayerID:%v err:%v", req.Id, p.GetUId(), err)
+	}
+	p.SendMsg(protocol.MessageId_SCAuctionResult, resultToProto(protocol.AuctionOp_AuctionOpBuyout, req.Id, l, err))
+}
+
+// Cancel takes a listing off sale.
+func Cancel(p Player, packet *network.Message) {
+	req := &protocol.CSAuctionCancel{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	err := GetMod().Cancel(context.Background(), p.GetUId(), req.Id)
+	if err != nil {
+		logger.Warn("[auction] cancel listing %v PlayerID:%v err:%v", req.Id, p.GetUId(), err)
+	}
+	p.SendMsg(protocol.MessageId_SCAuctionResult, resultToProto(protocol.AuctionOp_AuctionOpCancel, req.Id, nil, err))
+}
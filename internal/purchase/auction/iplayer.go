@@ -0,0 +1,14 @@
+package auction
+
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/bag"
+)
+
+// Player is the player handling the messages of the auction house.
+type Player interface {
+	GetUId() uint64
+	SendMsg(ID messageId.MessageId, message proto.Message)
+	GetBagSystem() *bag.System
+}
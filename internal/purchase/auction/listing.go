@@ -0,0 +1,301 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/saga"
+	"greatestworks/internal/gameplay/bag"
+	"greatestworks/internal/purchase/currency"
+)
+
+// Status is the status of a listing.
+type Status int32
+
+const (
+	StatusActive    Status = iota + 1 // on sale
+	StatusSold                        // sold, by buyout or to the highest bidder
+	StatusExpired                     // expired with no bid
+	StatusCancelled                   // taken off sale by the seller
+)
+
+// Reasons of the changes of the bags and the wallets made by the auction
+// house.
+const (
+	reasonList      = "auction_list"
+	reasonBid       = "auction_bid"
+	reasonBidRefund = "auction_bid_refund"
+)
+
+var (
+	ErrNoListing     = errors.New("auction: no such listing")
+	ErrNotTradable   = errors.New("auction: item not tradable")
+	ErrInvalidPrice  = errors.New("auction: invalid price")
+	ErrTooMany       = errors.New("auction: too many listings on sale")
+	ErrClosed        = errors.New("auction: listing not on sale")
+	ErrOwnListing    = errors.New("auction: own listing")
+	ErrLowBid        = errors.New("auction: bid too low")
+	ErrTopBidder     = errors.New("auction: already the highest bidder")
+	ErrNoBuyout      = errors.New("auction: no buyout price")
+	ErrChanged       = errors.New("auction: listing changed, try again")
+	ErrCannotCancel  = errors.New("auction: listing has bids or isn't on sale")
+	ErrItemsReturned = errors.New("auction: listing failed, items returned")
+)
+
+func listings() *mongodriver.Collection {
+	doc := &mongo.AuctionListing{}
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+// load loads a listing.
+func load(ctx context.Context, id uint64) (*mongo.AuctionListing, error) {
+	l := &mongo.AuctionListing{}
+	err := listings().FindOne(ctx, bson.M{"_id": id}).Decode(l)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return nil, ErrNoListing
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load listing %v: %w", id, err)
+	}
+	return l, nil
+}
+
+// transition applies an update to a listing on sale if it's still in the
+// state it was loaded in, and matches filter, so that concurrent bids,
+// buyouts and expiries, from any server, never both succeed. It returns the
+// listing updated, or nil if it changed.
+func transition(ctx context.Context, l *mongo.AuctionListing, filter, update bson.M) (*mongo.AuctionListing, error) {
+	filter["_id"] = l.Id
+	filter["st"] = int32(StatusActive)
+	filter["price"] = l.Price
+	filter["bidder"] = l.Bidder
+	updated := &mongo.AuctionListing{}
+	err := listings().FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(updated)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update listing %v: %w", l.Id, err)
+	}
+	return updated, nil
+}
+
+// List puts items of the bag of a player on sale, starting at price, and
+// for buyout if buyout isn't 0. The items are held by the auction house
+// until the listing is settled.
+func (m *Module) List(ctx context.Context, p Player, itemId uint32, num int64, cur currency.Type, price, buyout int64) (*mongo.AuctionListing, error) {
+	conf := bag.GetMod().ItemConf(itemId)
+	switch {
+	case conf == nil || !conf.Stackable():
+		// The state of the instances of the non stackable items (e.g., the
+		// enhancement of equipment) doesn't go through the mails.
+		return nil, ErrNotTradable
+	case num <= 0 || num > math.MaxInt32:
+		return nil, fmt.Errorf("%w: %d of item %d", bag.ErrInvalidNum, num, itemId)
+	case !m.accepts(cur) || price <= 0 || buyout != 0 && buyout < price:
+		return nil, fmt.Errorf("%w: %d, buyout %d of %v", ErrInvalidPrice, price, buyout, cur)
+	}
+	uid := p.GetUId()
+	n, err := listings().CountDocuments(ctx, bson.M{"seller": uid, "st": int32(StatusActive)})
+	if err != nil {
+		return nil, fmt.Errorf("count listings of player %v: %w", uid, err)
+	}
+	if n >= m.maxListings {
+		return nil, ErrTooMany
+	}
+
+	id, err := idgenerator.NextId()
+	if err != nil {
+		return nil, fmt.Errorf("listing id: %w", err)
+	}
+	now := time.Now()
+	l := &mongo.AuctionListing{
+		Id:       id,
+		Seller:   uid,
+		ItemId:   itemId,
+		Num:      num,
+		Category: int32(conf.Category),
+		Currency: int32(cur),
+		Price:    price,
+		Buyout:   buyout,
+		Status:   int32(StatusActive),
+		Refunds:  []mongo.AuctionRefund{},
+		Time:     now.Unix(),
+		Expire:   now.Add(m.duration).Unix(),
+	}
+	stacks := []bag.Stack{{Id: itemId, Num: num}}
+	s := (&saga.Saga{Name: "auction_list"}).
+		Step("consume", func(ctx context.Context) error {
+			_, err := p.GetBagSystem().Consume(ctx, reasonList, stacks)
+			return err
+		}, func(ctx context.Context) error {
+			_, err := p.GetBagSystem().Grant(ctx, reasonList, stacks)
+			return err
+		}).
+		Step("insert", func(ctx context.Context) error {
+			_, err := listings().InsertOne(ctx, l)
+			return err
+		}, nil)
+	if err := saga.Get().Run(ctx, s); err != nil {
+		var serr *saga.Error
+		if errors.As(err, &serr) && serr.Step == "insert" && serr.Compensated() {
+			logger.Error("[auction] list item %v PlayerID:%v err:%v", itemId, uid, err)
+			return nil, ErrItemsReturned
+		}
+		return nil, err
+	}
+	listed.Get(currencyLabels{Currency: cur.String()}).Add(1)
+	return l, nil
+}
+
+// accepts returns whether the listings may be priced in a currency.
+func (m *Module) accepts(cur currency.Type) bool {
+	for _, c := range m.currencies {
+		if c == cur {
+			return true
+		}
+	}
+	return false
+}
+
+// minBid returns the least bid on a listing: its starting price if it has
+// no bid, or the highest bid raised by ModuleConfig.MinRaise.
+func (m *Module) minBid(l *mongo.AuctionListing) int64 {
+	if l.Bidder == 0 {
+		return l.Price
+	}
+	raise := l.Price * m.minRaise / 10000
+	if raise < 1 {
+		raise = 1
+	}
+	return l.Price + raise
+}
+
+// Bid bids an amount on a listing for a player. The amount is held by the
+// auction house until the player is outbid, when it's mailed back, or the
+// listing is sold to it. A bid reaching the buyout price buys the listing
+// out.
+func (m *Module) Bid(ctx context.Context, uid, id uint64, amount int64) (*mongo.AuctionListing, error) {
+	l, err := load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	switch {
+	case Status(l.Status) != StatusActive || l.Expire <= now.Unix():
+		return nil, ErrClosed
+	case l.Seller == uid:
+		return nil, ErrOwnListing
+	case l.Bidder == uid:
+		return nil, ErrTopBidder
+	case l.Buyout > 0 && amount >= l.Buyout:
+		return m.buyout(ctx, uid, l, now)
+	case amount < m.minBid(l):
+		return nil, fmt.Errorf("%w: %d, at least %d", ErrLowBid, amount, m.minBid(l))
+	}
+	updated, err := m.take(ctx, uid, l, amount, bson.M{"price": amount, "bidder": uid}, now)
+	if err != nil {
+		return nil, err
+	}
+	// The listing is still on sale: settling it only mails the bids outbid
+	// back, as the sweeps do.
+	m.settle(ctx, updated)
+	return updated, nil
+}
+
+// Buyout buys a listing out for a player, at its buyout price.
+func (m *Module) Buyout(ctx context.Context, uid, id uint64) (*mongo.AuctionListing, error) {
+	l, err := load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	switch {
+	case Status(l.Status) != StatusActive || l.Expire <= now.Unix():
+		return nil, ErrClosed
+	case l.Seller == uid:
+		return nil, ErrOwnListing
+	case l.Buyout == 0:
+		return nil, ErrNoBuyout
+	}
+	return m.buyout(ctx, uid, l, now)
+}
+
+func (m *Module) buyout(ctx context.Context, uid uint64, l *mongo.AuctionListing, now time.Time) (*mongo.AuctionListing, error) {
+	set := bson.M{"price": l.Buyout, "bidder": uid, "st": int32(StatusSold)}
+	updated, err := m.take(ctx, uid, l, l.Buyout, set, now)
+	if err != nil {
+		return nil, err
+	}
+	m.sold(updated)
+	m.settle(ctx, updated)
+	return updated, nil
+}
+
+// take takes an amount from the wallet of a player, then updates the
+// listing, if it didn't change, with the player as its highest bidder; the
+// previous highest bid is queued for refund (see refund). If the listing
+// changed, the amount is given back. A player takes a listing in a given
+// state once: the retries of a bid fail with ErrChanged.
+func (m *Module) take(ctx context.Context, uid uint64, l *mongo.AuctionListing, amount int64, set bson.M, now time.Time) (*mongo.AuctionListing, error) {
+	ref := fmt.Sprintf("auction:%d", l.Id)
+	price := []currency.Amount{{Type: currency.Type(l.Currency), Num: amount}}
+	update := bson.M{"$set": set}
+	if l.Bidder != 0 {
+		update["$push"] = bson.M{"refunds": mongo.AuctionRefund{Bidder: l.Bidder, Amount: l.Price}}
+	}
+	var updated *mongo.AuctionListing
+	s := (&saga.Saga{
+		Name: "auction_bid",
+		Key:  fmt.Sprintf("auction:%d:%d:%d:bid:%d", l.Id, l.Price, l.Bidder, uid),
+	}).
+		Step("spend", func(ctx context.Context) error {
+			return currency.Spend(ctx, uid, price, reasonBid, ref)
+		}, func(ctx context.Context) error {
+			return currency.Add(ctx, uid, price, reasonBidRefund, ref)
+		}).
+		Step("bid", func(ctx context.Context) error {
+			var err error
+			updated, err = transition(ctx, l, bson.M{"exp": bson.M{"$gt": now.Unix()}}, update)
+			if err == nil && updated == nil {
+				err = ErrChanged
+			}
+			return err
+		}, nil)
+	err := saga.Get().Run(ctx, s)
+	if errors.Is(err, saga.ErrDone) || errors.Is(err, saga.ErrInProgress) {
+		return nil, ErrChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Cancel takes a listing with no bid off sale; its items are mailed back to
+// the seller.
+func (m *Module) Cancel(ctx context.Context, uid, id uint64) error {
+	updated := &mongo.AuctionListing{}
+	err := listings().FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "seller": uid, "st": int32(StatusActive), "bidder": uint64(0)},
+		bson.M{"$set": bson.M{"st": int32(StatusCancelled)}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(updated)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return ErrCannotCancel
+	}
+	if err != nil {
+		return fmt.Errorf("cancel listing %v: %w", id, err)
+	}
+	m.settle(ctx, updated)
+	return nil
+}
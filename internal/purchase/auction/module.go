@@ -0,0 +1,139 @@
+package auction
+
+import (
+	"sync"
+	"time"
+
+	"github.com/phuhao00/greatestworks-proto/module"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/module_router"
+	"greatestworks/internal"
+	"greatestworks/internal/purchase/currency"
+)
+
+const (
+	ModuleName = "auction"
+)
+
+var (
+	Mod         *Module
+	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	listed = metrics.NewCounterMap[currencyLabels](
+		"auction_listings",
+		"Number of listings put on sale in the auction house",
+	)
+	sales = metrics.NewCounterMap[currencyLabels](
+		"auction_sales",
+		"Amount of currency paid for the listings sold in the auction house",
+	)
+	taxes = metrics.NewCounterMap[currencyLabels](
+		"auction_taxes",
+		"Amount of currency kept by the auction house as tax",
+	)
+	expiries = metrics.NewCounter(
+		"auction_expiries",
+		"Number of listings expired with no bid",
+	)
+)
+
+type currencyLabels struct {
+	Currency string
+}
+
+func init() {
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
+}
+
+// Module is the auction house, where the players sell items to each other,
+// by auction or buyout. The listings are shared by all the servers: the
+// items on sale and the highest bids are held in escrow by the listings,
+// and settled by mail, so that the players needn't be online.
+type Module struct {
+	*internal.BaseModule
+	initFlag      bool
+	duration      time.Duration
+	taxRate       int64
+	minRaise      int64
+	maxListings   int64
+	pageSize      int64
+	sweepInterval time.Duration
+	currencies    []currency.Type
+	stopCh        chan struct{}
+}
+
+func GetMod() *Module {
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
+	return Mod
+}
+
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.duration = conf.Duration
+	if m.duration <= 0 {
+		m.duration = defaultDuration
+	}
+	m.taxRate = conf.TaxRate
+	if m.taxRate <= 0 || m.taxRate > 10000 {
+		m.taxRate = defaultTaxRate
+	}
+	m.minRaise = conf.MinRaise
+	if m.minRaise <= 0 {
+		m.minRaise = defaultMinRaise
+	}
+	m.maxListings = conf.MaxListings
+	if m.maxListings <= 0 {
+		m.maxListings = defaultMaxListings
+	}
+	m.pageSize = conf.PageSize
+	if m.pageSize <= 0 {
+		m.pageSize = defaultPageSize
+	}
+	m.sweepInterval = conf.SweepInterval
+	if m.sweepInterval <= 0 {
+		m.sweepInterval = defaultSweepInterval
+	}
+	m.currencies = conf.Currencies
+	if len(m.currencies) == 0 {
+		m.currencies = defaultCurrencies
+	}
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts closing the expired listings.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	go m.runSweep()
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		close(m.stopCh)
+	}
+}
+
+func (m *Module) GetName() string {
+	return ModuleName
+}
+
+// Dependencies returns the modules the auction module depends on: the items
+// on sale come from the bag, and the listings are settled by mail.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Bag.String(), module.Module_Email.String()}
+}
+
+func (m *Module) RegisterHandler() {
+	module_router.RegisterModuleMessageHandler(0, 0, nil)
+}
@@ -0,0 +1,39 @@
+package auction
+
+import (
+	"greatestworks/aop/mongo"
+	"greatestworks/internal/protocol"
+)
+
+func listingToProto(l *mongo.AuctionListing) *protocol.AuctionListing {
+	return &protocol.AuctionListing{
+		Id:       l.Id,
+		Seller:   l.Seller,
+		ItemId:   l.ItemId,
+		Num:      l.Num,
+		Currency: l.Currency,
+		Price:    l.Price,
+		Bidder:   l.Bidder,
+		Buyout:   l.Buyout,
+		Status:   l.Status,
+		Expire:   l.Expire,
+	}
+}
+
+func searchToProto(page []*mongo.AuctionListing, total, pageNo int64) *protocol.SCAuctionSearch {
+	pb := &protocol.SCAuctionSearch{Total: total, Page: pageNo}
+	for _, l := range page {
+		pb.Listings = append(pb.Listings, listingToProto(l))
+	}
+	return pb
+}
+
+// resultToProto returns the result of an operation on a listing; l is nil if
+// it failed.
+func resultToProto(op protocol.AuctionOp, id uint64, l *mongo.AuctionListing, err error) *protocol.SCAuctionResult {
+	pb := &protocol.SCAuctionResult{Op: op, Id: id, Ok: err == nil}
+	if l != nil {
+		pb.Listing = listingToProto(l)
+	}
+	return pb
+}
@@ -0,0 +1,96 @@
+package auction
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"greatestworks/aop/mongo"
+)
+
+// Sort is the order of the results of a search.
+type Sort int32
+
+const (
+	SortNewest Sort = iota // newest listings first
+	SortPrice              // cheapest listings first
+	SortEnding             // listings ending soonest first
+)
+
+// Query filters the listings on sale. Zero fields don't filter.
+type Query struct {
+	Seller   uint64 // listings of a player
+	ItemId   uint32
+	Category int32
+	Currency int32
+	MinPrice int64
+	MaxPrice int64
+	Sort     Sort
+	Page     int64 // from 0
+}
+
+// filter returns the filter of the listings matching the query.
+func (q *Query) filter(now time.Time) bson.M {
+	filter := bson.M{"st": int32(StatusActive), "exp": bson.M{"$gt": now.Unix()}}
+	if q.Seller != 0 {
+		filter["seller"] = q.Seller
+	}
+	if q.ItemId != 0 {
+		filter["item"] = q.ItemId
+	}
+	if q.Category != 0 {
+		filter["cat"] = q.Category
+	}
+	if q.Currency != 0 {
+		filter["cur"] = q.Currency
+	}
+	price := bson.M{}
+	if q.MinPrice > 0 {
+		price["$gte"] = q.MinPrice
+	}
+	if q.MaxPrice > 0 {
+		price["$lte"] = q.MaxPrice
+	}
+	if len(price) > 0 {
+		filter["price"] = price
+	}
+	return filter
+}
+
+func (q *Query) sort() bson.D {
+	switch q.Sort {
+	case SortPrice:
+		return bson.D{{Key: "price", Value: 1}, {Key: "_id", Value: 1}}
+	case SortEnding:
+		return bson.D{{Key: "exp", Value: 1}, {Key: "_id", Value: 1}}
+	default:
+		return bson.D{{Key: "time", Value: -1}, {Key: "_id", Value: -1}}
+	}
+}
+
+// Search returns a page of the listings on sale matching a query, and the
+// number of listings matching it.
+func (m *Module) Search(ctx context.Context, q Query) ([]*mongo.AuctionListing, int64, error) {
+	if q.Page < 0 {
+		q.Page = 0
+	}
+	filter := q.filter(time.Now())
+	total, err := listings().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count listings: %w", err)
+	}
+	cursor, err := listings().Find(ctx, filter, options.Find().
+		SetSort(q.sort()).
+		SetSkip(q.Page*m.pageSize).
+		SetLimit(m.pageSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("search listings: %w", err)
+	}
+	var page []*mongo.AuctionListing
+	if err := cursor.All(ctx, &page); err != nil {
+		return nil, 0, fmt.Errorf("search listings: %w", err)
+	}
+	return page, total, nil
+}
@@ -0,0 +1,209 @@
+package auction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"greatestworks/aop/dlock"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/mongo"
+	"greatestworks/internal/communicate/email"
+	"greatestworks/internal/note/event/auctionevent"
+	"greatestworks/internal/purchase/currency"
+)
+
+// sweepBatch is the most listings settled by a sweep.
+const sweepBatch = 100
+
+// mailId returns the id of a mail settling a listing. It's derived from the
+// listing, so that settling a listing again (e.g., after a failure) never
+// mails the same thing twice.
+func mailId(format string, a ...interface{}) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, format, a...)
+	return h.Sum64()
+}
+
+// tax returns the part of a price kept by the auction house.
+func (m *Module) tax(price int64) int64 {
+	return price * m.taxRate / 10000
+}
+
+// sold counts a listing sold.
+func (m *Module) sold(l *mongo.AuctionListing) {
+	cur := currency.Type(l.Currency)
+	tax := m.tax(l.Price)
+	sales.Get(currencyLabels{Currency: cur.String()}).Add(float64(l.Price))
+	taxes.Get(currencyLabels{Currency: cur.String()}).Add(float64(tax))
+	eventbus.Publish(eventbus.Default, auctionevent.Sold{
+		ListingId: l.Id,
+		Seller:    l.Seller,
+		Buyer:     l.Bidder,
+		ItemId:    l.ItemId,
+		Num:       l.Num,
+		Currency:  l.Currency,
+		Price:     l.Price,
+		Tax:       tax,
+	})
+}
+
+// settle mails the items of a listing no longer on sale to its buyer, or back
+// to its seller, and the price, less the tax, to the seller, unless they
+// were mailed already; then it mails the bids outbid back to their bidders.
+//
+// A listing is settled by one server at a time, holding its lock: the sweeps
+// of the servers and the bids may settle the same listing. If another server
+// holds it, settle returns, and the sweeps retry what it fails. The updates
+// of the listing are fenced with the token of the lock, so that a server
+// that lost it can't update the listing after the next holder.
+func (m *Module) settle(ctx context.Context, l *mongo.AuctionListing) {
+	lock, err := dlock.Get().TryLock(ctx, "auction:listing:"+strconv.FormatUint(l.Id, 10))
+	if err != nil {
+		if !errors.Is(err, dlock.ErrLocked) {
+			logger.Error("[auction] lock listing %v err:%v", l.Id, err)
+		}
+		return
+	}
+	defer lock.Unlock()
+	ctx = lock.Context()
+	if Status(l.Status) != StatusActive && !l.Settled {
+		if err := m.mailSettlement(ctx, l); err != nil {
+			logger.Error("[auction] settle listing %v err:%v", l.Id, err)
+		} else if _, err := listings().UpdateOne(ctx, fenced(l.Id, lock.Token()), bson.M{"$set": bson.M{"settled": true, "fence": lock.Token()}}); err != nil {
+			logger.Error("[auction] mark listing %v settled err:%v", l.Id, err)
+		}
+	}
+	m.refund(ctx, l, lock.Token())
+}
+
+// fenced returns the filter of a listing, unless it was updated by a later
+// holder of its lock than token.
+func fenced(id, token uint64) bson.M {
+	return bson.M{"_id": id, "fence": bson.M{"$not": bson.M{"$gt": token}}}
+}
+
+func (m *Module) mailSettlement(ctx context.Context, l *mongo.AuctionListing) error {
+	items := []mongo.MailItem{{ItemId: l.ItemId, Num: int32(l.Num)}}
+	if Status(l.Status) != StatusSold {
+		return email.GetMod().Send(ctx, l.Seller, &mongo.MailInfo{
+			MUuid:    mailId("auction:%d:items", l.Id),
+			MContent: fmt.Sprintf("auction %d: not sold", l.Id),
+			MItems:   items,
+		})
+	}
+	if err := email.GetMod().Send(ctx, l.Bidder, &mongo.MailInfo{
+		MUuid:    mailId("auction:%d:items", l.Id),
+		MContent: fmt.Sprintf("auction %d: bought for %d", l.Id, l.Price),
+		MItems:   items,
+	}); err != nil {
+		return err
+	}
+	proceeds := l.Price - m.tax(l.Price)
+	if proceeds <= 0 {
+		return nil
+	}
+	return email.GetMod().Send(ctx, l.Seller, &mongo.MailInfo{
+		MUuid:    mailId("auction:%d:proceeds", l.Id),
+		MContent: fmt.Sprintf("auction %d: sold for %d, tax %d", l.Id, l.Price, m.tax(l.Price)),
+		Currency: []mongo.MailCurrency{{Type: uint32(l.Currency), Amount: proceeds}},
+	})
+}
+
+// refund mails the bids outbid on a listing back to their bidders, and drops
+// them from the listing, holding its lock with token.
+func (m *Module) refund(ctx context.Context, l *mongo.AuctionListing, token uint64) {
+	for _, r := range l.Refunds {
+		err := email.GetMod().Send(ctx, r.Bidder, &mongo.MailInfo{
+			MUuid:    mailId("auction:%d:refund:%d:%d", l.Id, r.Bidder, r.Amount),
+			MContent: fmt.Sprintf("auction %d: outbid", l.Id),
+			Currency: []mongo.MailCurrency{{Type: uint32(l.Currency), Amount: r.Amount}},
+		})
+		if err != nil {
+			logger.Error("[auction] refund bid %v of listing %v PlayerID:%v err:%v", r.Amount, l.Id, r.Bidder, err)
+			continue
+		}
+		update := bson.M{"$pull": bson.M{"refunds": r}, "$set": bson.M{"fence": token}}
+		if _, err := listings().UpdateOne(ctx, fenced(l.Id, token), update); err != nil {
+			logger.Error("[auction] drop refund of listing %v err:%v", l.Id, err)
+		}
+	}
+}
+
+// runSweep closes the expired listings, and retries the settlements that
+// failed, periodically, until the module is stopped.
+func (m *Module) runSweep() {
+	ticker := time.NewTicker(m.sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), m.sweepInterval)
+			if err := m.sweep(ctx, now); err != nil {
+				logger.Error("[auction] sweep failed: %v", err)
+			}
+			cancel()
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// sweep closes the listings expired at now: sold to their highest bidder,
+// or expired if they have none. Then it settles them, along with the
+// listings whose settlement failed.
+func (m *Module) sweep(ctx context.Context, now time.Time) error {
+	cursor, err := listings().Find(ctx,
+		bson.M{"st": int32(StatusActive), "exp": bson.M{"$lte": now.Unix()}},
+		options.Find().SetLimit(sweepBatch))
+	if err != nil {
+		return err
+	}
+	var expired []*mongo.AuctionListing
+	if err := cursor.All(ctx, &expired); err != nil {
+		return err
+	}
+	for _, l := range expired {
+		st := StatusExpired
+		if l.Bidder != 0 {
+			st = StatusSold
+		}
+		updated, err := transition(ctx, l, bson.M{}, bson.M{"$set": bson.M{"st": int32(st)}})
+		if err != nil {
+			logger.Error("[auction] close listing %v err:%v", l.Id, err)
+			continue
+		}
+		if updated == nil {
+			// Closed by another server.
+			continue
+		}
+		if st == StatusSold {
+			m.sold(updated)
+		} else {
+			expiries.Add(1)
+		}
+		m.settle(ctx, updated)
+	}
+
+	cursor, err = listings().Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"st": bson.M{"$ne": int32(StatusActive)}, "settled": false},
+		bson.M{"refunds.0": bson.M{"$exists": true}},
+	}}, options.Find().SetLimit(sweepBatch))
+	if err != nil {
+		return err
+	}
+	var pending []*mongo.AuctionListing
+	if err := cursor.All(ctx, &pending); err != nil {
+		return err
+	}
+	for _, l := range pending {
+		m.settle(ctx, l)
+	}
+	return nil
+}
@@ -1,20 +1,115 @@
 package battlepass
 
-import "time"
+import (
+	"fmt"
 
-type Config struct {
-	Id        uint32
-	Score     []uint64
-	Free      []uint32
-	Gold      []uint32
-	Price     uint64
-	Desc      string
-	OpenTime  time.Time
-	CloseTime time.Time
-}
-type CardCategory uint8
+	"greatestworks/aop/loader/json"
+	"greatestworks/internal/gameplay/bag"
+)
+
+// Tracks of the rewards of a pass.
+const (
+	TrackFree    = iota + 1 // 免费奖励
+	TrackPremium            // 付费奖励，购买Conf.Product后解锁
+)
 
+// Sources of the experience of the passes.
 const (
-	Free CardCategory = iota + 1
-	Gold
+	SourceRecharge = "recharge" // 充值，每元Exp
+	SourceShop     = "shop"     // 商店购买商品Param，每件Exp
+	SourceActivity = "activity" // 参与活动Param，每次Exp
+	SourceBattle   = "battle"   // 赢得模式Param的战斗，每场Exp
 )
+
+// Conf 通行证配置，活动Activity每开启一轮为一个赛季
+type Conf struct {
+	Id       uint32    `json:"id"`
+	Activity uint32    `json:"activity"` // 赛季对应的活动
+	Product  uint32    `json:"product"`  // 解锁付费奖励的充值商品
+	Tiers    []Tier    `json:"tiers"`    // 等级，按经验升序
+	Exp      []ExpRule `json:"exp"`      // 经验来源
+}
+
+// Tier 通行证等级
+type Tier struct {
+	Exp     int64       `json:"exp"`     // 达到该等级的累计经验
+	Free    []bag.Stack `json:"free"`    // 免费奖励
+	Premium []bag.Stack `json:"premium"` // 付费奖励
+}
+
+// ExpRule 经验来源
+type ExpRule struct {
+	Source string `json:"source"` // 来源(Source*)
+	Param  uint32 `json:"param"`  // 0为任意
+	Exp    int64  `json:"exp"`
+}
+
+// level returns the number of tiers reached with some experience.
+func (c *Conf) level(exp int64) int {
+	n := 0
+	for n < len(c.Tiers) && c.Tiers[n].Exp <= exp {
+		n++
+	}
+	return n
+}
+
+// expOf returns the experience given by a source.
+func (c *Conf) expOf(source string, param uint32) int64 {
+	var exp int64
+	for _, r := range c.Exp {
+		if r.Source == source && (r.Param == 0 || r.Param == param) {
+			exp += r.Exp
+		}
+	}
+	return exp
+}
+
+// rewards returns the rewards of a track of the tiers from and to.
+func (c *Conf) rewards(track, from, to int) []bag.Stack {
+	var stacks []bag.Stack
+	for _, t := range c.Tiers[from:to] {
+		if track == TrackPremium {
+			stacks = append(stacks, t.Premium...)
+		} else {
+			stacks = append(stacks, t.Free...)
+		}
+	}
+	return stacks
+}
+
+// ModuleConfig is the config of the battle pass module. It must be set
+// before the module is initialized (see Module.Init).
+type ModuleConfig struct {
+	PassFile string // battle pass table, see Conf
+}
+
+// loadTable loads the battle pass table.
+func loadTable(file string) (map[uint32]*Conf, error) {
+	var list []*Conf
+	if !json.ParseJsonFile2Slice(file, true, &list) {
+		return nil, fmt.Errorf("battlepass: pass table %q not found", file)
+	}
+	confs := make(map[uint32]*Conf, len(list))
+	for _, c := range list {
+		if confs[c.Id] != nil {
+			return nil, fmt.Errorf("battlepass: pass table %q: repeat pass %d", file, c.Id)
+		}
+		if c.Activity == 0 || len(c.Tiers) == 0 {
+			return nil, fmt.Errorf("battlepass: pass table %q: pass %d: no activity or tiers", file, c.Id)
+		}
+		for i, t := range c.Tiers {
+			if t.Exp <= 0 || i > 0 && t.Exp <= c.Tiers[i-1].Exp {
+				return nil, fmt.Errorf("battlepass: pass table %q: pass %d: tier %d: exp %d not ascending", file, c.Id, i+1, t.Exp)
+			}
+		}
+		for _, r := range c.Exp {
+			switch r.Source {
+			case SourceRecharge, SourceShop, SourceActivity, SourceBattle:
+			default:
+				return nil, fmt.Errorf("battlepass: pass table %q: pass %d: unknown exp source %q", file, c.Id, r.Source)
+			}
+		}
+		confs[c.Id] = c
+	}
+	return confs, nil
+}
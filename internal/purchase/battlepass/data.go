@@ -1,31 +1,81 @@
 package battlepass
 
-// BattlePass 战斗通行证
-type BattlePass struct {
-	HistorySeasons []*Season //保留多少根据策划要求
-	Cur            *Season   //当前赛季
-}
+import (
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
 
-// Season 赛季
-type Season struct {
-	ConfigId uint32
-	Score    uint64
-	Records  map[CardCategory][]uint8
-	Card     map[CardCategory]bool
+// Progress is the progress of a player in the season of a pass.
+type Progress struct {
+	Id      uint32 `bson:"id"`      // 通行证id
+	Round   int64  `bson:"round"`   // 赛季，活动本轮开启时间
+	Exp     int64  `bson:"exp"`     // 经验
+	Premium bool   `bson:"premium"` // 是否解锁付费奖励
+	Free    int    `bson:"free"`    // 已领取免费奖励的等级数
+	Paid    int    `bson:"paid"`    // 已领取付费奖励的等级数
 }
 
-func (p *BattlePass) Tag() {
+// claimed returns the number of tiers of a track claimed.
+func (p *Progress) claimed(track int) *int {
+	if track == TrackPremium {
+		return &p.Paid
+	}
+	return &p.Free
+}
 
+// Doc 对应DB -> mongo
+type Doc struct {
+	Passes []Progress `bson:"passes"`
 }
 
-func (p *BattlePass) Restore() {
+// Data is the progress of a player in the passes. It progresses on the
+// goroutines of the event bus, and is claimed on the lane of the player, so
+// it's guarded by mu.
+type Data struct {
+	uid       uint64
+	player    Player
+	markDirty func()
 
+	mu     sync.Mutex
+	passes map[uint32]*Progress // by pass; guarded by mu
 }
 
-func (p *BattlePass) Reload() {
+func NewData() *Data {
+	return &Data{passes: map[uint32]*Progress{}}
+}
 
+// SetOwner sets the player the data belongs to, and how it's marked dirty.
+func (d *Data) SetOwner(player Player, uid uint64, markDirty func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.player = player
+	d.uid = uid
+	d.markDirty = markDirty
 }
 
-func (p *BattlePass) Refresh() {
+// Save returns a copy of the progress, to be stored.
+func (d *Data) Save() (interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	doc := Doc{Passes: make([]Progress, 0, len(d.passes))}
+	for _, p := range d.passes {
+		doc.Passes = append(doc.Passes, *p)
+	}
+	return doc, nil
+}
 
+// Load loads the stored progress.
+func (d *Data) Load(raw bson.RawValue) error {
+	var doc Doc
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.passes = make(map[uint32]*Progress, len(doc.Passes))
+	for i := range doc.Passes {
+		d.passes[doc.Passes[i].Id] = &doc.Passes[i]
+	}
+	return nil
 }
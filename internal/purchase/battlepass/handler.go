@@ -1,10 +1,15 @@
 package battlepass
 
 import (
+	"context"
 	"errors"
+	"sync"
+
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"github.com/phuhao00/network"
-	"sync"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
 )
 
 type Handler struct {
@@ -13,16 +18,10 @@ type Handler struct {
 }
 
 var (
-	handlers     []*Handler
-	onceInit     sync.Once
-	MinMessageId messageId.MessageId
-	MaxMessageId messageId.MessageId //handle 的消息范围
+	handlers []*Handler
+	onceInit sync.Once
 )
 
-func IsBelongToHere(id messageId.MessageId) bool {
-	return id > MinMessageId && id < MaxMessageId
-}
-
 func GetHandler(id messageId.MessageId) (*Handler, error) {
 	for _, handler := range handlers {
 		if handler.Id == id {
@@ -34,13 +33,25 @@ func GetHandler(id messageId.MessageId) (*Handler, error) {
 
 func init() {
 	onceInit.Do(func() {
-		handlers[0] = &Handler{
-			0,
-			Receive,
-		}
+		HandlerBattlePassRegister()
 	})
 }
 
-func Receive(player IPlayer, message *network.Message) {
+func HandlerBattlePassRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSBattlePassClaim, Claim},
+	)
+}
 
+// Claim claims the rewards of a track of a pass reached and not claimed yet.
+// The progress is pushed with the passes.
+func Claim(p IPlayer, packet *network.Message) {
+	req := &protocol.CSBattlePassClaim{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	d := p.GetBattlePassData()
+	if err := GetMod().Claim(context.Background(), d, req.Id, int(req.Track)); err != nil {
+		logger.Warn("[battlepass] claim track %v of pass %v PlayerID:%v err:%v", req.Track, req.Id, d.uid, err)
+	}
 }
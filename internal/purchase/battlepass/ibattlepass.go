@@ -1,5 +0,0 @@
-package battlepass
-
-type IBattlepass interface {
-	DailyRefresh()
-}
@@ -1,5 +1,17 @@
 package battlepass
 
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/bag"
+)
+
 type IPlayer interface {
-	GetBattlePass() *BattlePass
+	GetBattlePassData() *Data
+}
+
+// Player is the player the Data belongs to.
+type Player interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+	GetBagSystem() *bag.System
 }
@@ -1,31 +1,397 @@
 package battlepass
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
 	"github.com/phuhao00/greatestworks-proto/module"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
 	"greatestworks/aop/module_router"
+	"greatestworks/aop/mongo"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/communicate/email"
+	"greatestworks/internal/note/event/battlepassevent"
+	"greatestworks/internal/protocol"
+	"greatestworks/internal/purchase/activity"
 )
 
+// reason is the reason of the rewards of the passes, in the bags.
+const reason = "battlepass"
+
 var (
 	Mod         *Module
 	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNoPass         = errors.New("battlepass: no such pass")
+	ErrClosed         = errors.New("battlepass: no season of the pass open")
+	ErrLocked         = errors.New("battlepass: premium rewards locked")
+	ErrNothingToClaim = errors.New("battlepass: nothing to claim")
 )
 
+var (
+	exps = metrics.NewCounterMap[expLabels](
+		"battlepass_exp",
+		"Experience gained in the passes, by source",
+	)
+	claims = metrics.NewCounterMap[claimLabels](
+		"battlepass_claims",
+		"Number of tiers of the passes claimed, mailed at the end of the seasons included",
+	)
+	unlocks = metrics.NewCounterMap[passLabels](
+		"battlepass_unlocks",
+		"Number of premium tracks unlocked",
+	)
+)
+
+type passLabels struct {
+	Pass string
+}
+
+type expLabels struct {
+	Pass   string
+	Source string
+}
+
+type claimLabels struct {
+	Pass    string
+	Premium bool
+	Mailed  bool
+}
+
 func init() {
 	internal.ModuleManager.RegisterModule(module.Module_BattlePass.String(), GetMod())
 }
 
+// Module runs the battle passes: every round of the activity of a pass is a
+// season, in which the players gain experience from their purchases and
+// activities (see subscribe), reaching the tiers of the pass, whose rewards
+// they claim: the free ones, and the premium ones once they've bought the
+// product of the pass. When a season ends, the players are mailed the
+// rewards they didn't claim, and start over in the next one.
 type Module struct {
 	*internal.BaseModule
+	initFlag   bool
+	confs      map[uint32]*Conf
+	byActivity map[uint32][]*Conf
+	byProduct  map[uint32][]*Conf
+
+	mu     sync.Mutex
+	online map[uint64]*Data // guarded by mu
 }
 
 func GetMod() *Module {
-	Mod = &Module{internal.NewBaseModule()}
-
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
 	return Mod
 }
 
+// Init loads the battle pass table.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.confs = map[uint32]*Conf{}
+	if conf.PassFile != "" {
+		confs, err := loadTable(conf.PassFile)
+		if err != nil {
+			return err
+		}
+		m.confs = confs
+	} else {
+		logger.Warn("[battlepass] no pass table")
+	}
+	m.byActivity = make(map[uint32][]*Conf)
+	m.byProduct = make(map[uint32][]*Conf)
+	for _, c := range m.confs {
+		m.byActivity[c.Activity] = append(m.byActivity[c.Activity], c)
+		if c.Product != 0 {
+			m.byProduct[c.Product] = append(m.byProduct[c.Product], c)
+		}
+	}
+	m.online = make(map[uint64]*Data)
+	m.initFlag = true
+	return nil
+}
+
+func (m *Module) OnStart() {
+	if m.initFlag {
+		m.subscribe()
+	}
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		m.unsubscribe()
+	}
+}
+
+// current returns the progress of a player in the open season of a pass,
+// starting it if it's new, or nil if no season is open. The progress in a
+// season over is settled first.
+//
+// REQUIRES: d.mu is held.
+func (m *Module) current(ctx context.Context, d *Data, conf *Conf) *Progress {
+	start, _, open := activity.GetMod().Round(conf.Activity)
+	p := d.passes[conf.Id]
+	if p != nil && (!open || p.Round != start) {
+		m.settle(ctx, d.uid, conf, p)
+		delete(d.passes, conf.Id)
+		d.markDirty()
+		p = nil
+	}
+	if p == nil && open {
+		p = &Progress{Id: conf.Id, Round: start}
+		d.passes[conf.Id] = p
+		d.markDirty()
+	}
+	return p
+}
+
+// settle mails a player the rewards of the tiers it reached in a season over
+// and didn't claim.
+func (m *Module) settle(ctx context.Context, uid uint64, conf *Conf, p *Progress) {
+	level := conf.level(p.Exp)
+	var items []mongo.MailItem
+	for _, track := range []int{TrackFree, TrackPremium} {
+		from := *p.claimed(track)
+		if from >= level || track == TrackPremium && !p.Premium {
+			continue
+		}
+		for _, s := range conf.rewards(track, from, level) {
+			items = append(items, mongo.MailItem{ItemId: s.Id, Num: int32(s.Num)})
+		}
+		claims.Get(claimLabels{Pass: passName(conf.Id), Premium: track == TrackPremium, Mailed: true}).Add(float64(level - from))
+	}
+	if len(items) == 0 {
+		return
+	}
+	err := email.GetMod().Send(ctx, uid, &mongo.MailInfo{
+		MUuid:    mailId("battlepass:%d:%d:%d", conf.Id, p.Round, uid),
+		MContent: fmt.Sprintf("battle pass %d: unclaimed rewards", conf.Id),
+		MItems:   items,
+	})
+	if err != nil {
+		logger.Error("[battlepass] mail rewards of pass %v season %v to PlayerID:%v err:%v", conf.Id, p.Round, uid, err)
+	}
+}
+
+// mailId returns the id of the mail of the unclaimed rewards of a season of a
+// pass to a player, so that they're never mailed twice.
+func mailId(format string, a ...interface{}) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, format, a...)
+	return h.Sum64()
+}
+
+// refresh settles the seasons of a player that are over, starts those that
+// are open, and pushes the passes.
+func (m *Module) refresh(ctx context.Context, d *Data, confs []*Conf) {
+	d.mu.Lock()
+	for _, conf := range confs {
+		m.current(ctx, d, conf)
+	}
+	d.mu.Unlock()
+	m.push(d)
+}
+
+// addExp gives a player online on this server the experience of a source,
+// n times, in the open seasons of the passes.
+func (m *Module) addExp(ctx context.Context, uid uint64, source string, param uint32, n int64) {
+	d := m.dataOf(uid)
+	if d == nil || n <= 0 {
+		return
+	}
+	var ups []battlepassevent.LevelUp
+	changed := false
+	d.mu.Lock()
+	for _, conf := range m.confs {
+		exp := conf.expOf(source, param) * n
+		if exp <= 0 {
+			continue
+		}
+		p := m.current(ctx, d, conf)
+		if p == nil {
+			continue
+		}
+		before := conf.level(p.Exp)
+		p.Exp += exp
+		changed = true
+		exps.Get(expLabels{Pass: passName(conf.Id), Source: source}).Add(float64(exp))
+		if after := conf.level(p.Exp); after > before {
+			ups = append(ups, battlepassevent.LevelUp{PlayerId: uid, PassId: conf.Id, Round: p.Round, Level: after})
+		}
+	}
+	d.mu.Unlock()
+	if !changed {
+		return
+	}
+	d.markDirty()
+	m.push(d)
+	for _, e := range ups {
+		eventbus.Publish(eventbus.Default, e)
+	}
+}
+
+// unlock unlocks the premium rewards of the passes of a product bought by a
+// player online on this server, in their open seasons.
+func (m *Module) unlock(ctx context.Context, uid uint64, product uint32) {
+	confs := m.byProduct[product]
+	if len(confs) == 0 {
+		return
+	}
+	d := m.dataOf(uid)
+	if d == nil {
+		logger.Error("[battlepass] premium of product %v bought offline PlayerID:%v", product, uid)
+		return
+	}
+	var unlocked []battlepassevent.Unlocked
+	d.mu.Lock()
+	for _, conf := range confs {
+		p := m.current(ctx, d, conf)
+		if p == nil {
+			// Paid for nothing: it's for the customer service.
+			logger.Error("[battlepass] premium of pass %v bought out of season PlayerID:%v", conf.Id, uid)
+			continue
+		}
+		if p.Premium {
+			logger.Error("[battlepass] premium of pass %v season %v bought again PlayerID:%v", conf.Id, p.Round, uid)
+			continue
+		}
+		p.Premium = true
+		unlocks.Get(passLabels{Pass: passName(conf.Id)}).Add(1)
+		unlocked = append(unlocked, battlepassevent.Unlocked{PlayerId: uid, PassId: conf.Id, Round: p.Round})
+	}
+	d.mu.Unlock()
+	if len(unlocked) == 0 {
+		return
+	}
+	d.markDirty()
+	m.push(d)
+	for _, e := range unlocked {
+		eventbus.Publish(eventbus.Default, e)
+	}
+}
+
+// Claim gives the rewards of the tiers of a track of a pass reached by a
+// player in the open season and not claimed yet, all at once.
+func (m *Module) Claim(ctx context.Context, d *Data, id uint32, track int) error {
+	conf := m.confs[id]
+	if conf == nil || track != TrackFree && track != TrackPremium {
+		return ErrNoPass
+	}
+	d.mu.Lock()
+	p := m.current(ctx, d, conf)
+	if p == nil {
+		d.mu.Unlock()
+		return ErrClosed
+	}
+	if track == TrackPremium && !p.Premium {
+		d.mu.Unlock()
+		return ErrLocked
+	}
+	claimed, level := p.claimed(track), conf.level(p.Exp)
+	if *claimed >= level {
+		d.mu.Unlock()
+		return ErrNothingToClaim
+	}
+	if rewards := conf.rewards(track, *claimed, level); len(rewards) > 0 {
+		if _, err := d.player.GetBagSystem().Grant(ctx, reason, rewards); err != nil {
+			d.mu.Unlock()
+			return err
+		}
+	}
+	claims.Get(claimLabels{Pass: passName(id), Premium: track == TrackPremium}).Add(float64(level - *claimed))
+	*claimed = level
+	d.mu.Unlock()
+	d.markDirty()
+	m.push(d)
+	return nil
+}
+
+// Online registers the data of a player that logged in, settles its seasons
+// over, and pushes it the passes.
+func (m *Module) Online(ctx context.Context, d *Data) {
+	m.mu.Lock()
+	m.online[d.uid] = d
+	m.mu.Unlock()
+	confs := make([]*Conf, 0, len(m.confs))
+	for _, c := range m.confs {
+		confs = append(confs, c)
+	}
+	m.refresh(ctx, d, confs)
+}
+
+// Offline unregisters the data of a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
+}
+
+// dataOf returns the data of a player online on this server, or nil.
+func (m *Module) dataOf(uid uint64) *Data {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.online[uid]
+}
+
+// onlineData returns the data of the players online on this server.
+func (m *Module) onlineData() []*Data {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := make([]*Data, 0, len(m.online))
+	for _, d := range m.online {
+		all = append(all, d)
+	}
+	return all
+}
+
+// push pushes the passes to a player.
+func (m *Module) push(d *Data) {
+	infos := make([]info, 0, len(m.confs))
+	d.mu.Lock()
+	for id, conf := range m.confs {
+		i := info{conf: conf}
+		if p := d.passes[id]; p != nil {
+			i.progress = *p
+			_, i.end, _ = activity.GetMod().Round(conf.Activity)
+		}
+		infos = append(infos, i)
+	}
+	d.mu.Unlock()
+	d.player.SendMsg(protocol.MessageId_SCBattlePassUpdate, toProto(infos))
+}
+
+func passName(id uint32) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// Dependencies returns the modules the battle pass module depends on: the
+// seasons are the rounds of the activities, the rewards go to the bags, or
+// are mailed.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Activity.String(), module.Module_Bag.String(), module.Module_Email.String()}
+}
+
+func (m *Module) SetName(name string) {
+	m.BaseModule.SetName(name)
+}
+
+func (m *Module) GetName() string {
+	return module.Module_BattlePass.String()
+}
+
 func (m *Module) RegisterHandler() {
 	module_router.RegisterModuleMessageHandler(module.Module_BattlePass, 0, nil)
 }
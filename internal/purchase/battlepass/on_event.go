@@ -1,23 +1,66 @@
 package battlepass
 
 import (
+	"context"
+
+	eventbus "greatestworks/aop/event"
 	"greatestworks/internal"
 	"greatestworks/internal/note/event"
+	"greatestworks/internal/note/event/activityevent"
+	"greatestworks/internal/note/event/battleevent"
+	"greatestworks/internal/note/event/rechargeevent"
+	"greatestworks/internal/note/event/shopevent"
 )
 
-type EventHandle func(iEvent event.IEvent)
+// OnEvent is unused: the passes subscribe to the event bus instead (see
+// subscribe).
+func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
+}
+
+func (m *Module) SetEventCategoryActive(eventCategory int) {
+}
 
-type EventWrap struct {
-	IPlayer
-	event.IEvent
+// subscribe subscribes to the events the passes progress on, and to the
+// rounds of the activities, which are their seasons.
+func (m *Module) subscribe() {
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e rechargeevent.Recharged) {
+		ctx := context.Background()
+		m.unlock(ctx, e.PlayerId, e.ProductId)
+		m.addExp(ctx, e.PlayerId, SourceRecharge, 0, e.Price/100)
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e shopevent.Purchased) {
+		m.addExp(context.Background(), e.PlayerId, SourceShop, e.GoodsId, e.Count)
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e activityevent.Joined) {
+		m.addExp(context.Background(), e.PlayerId, SourceActivity, e.ActivityId, 1)
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e battleevent.Finished) {
+		if e.Won {
+			m.addExp(context.Background(), e.PlayerId, SourceBattle, e.Mode, 1)
+		}
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e activityevent.Opened) {
+		m.seasonChanged(e.ActivityId)
+	})
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e activityevent.Closed) {
+		m.seasonChanged(e.ActivityId)
+	})
 }
 
-func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
-	//TODO implement me
-	panic("implement me")
+// seasonChanged settles the seasons over of the online players, and starts
+// the new ones, when a round of an activity opens or closes.
+func (m *Module) seasonChanged(activityId uint32) {
+	confs := m.byActivity[activityId]
+	if len(confs) == 0 {
+		return
+	}
+	for _, d := range m.onlineData() {
+		m.refresh(context.Background(), d, confs)
+	}
 }
 
-func (m *Module) SetEventCategoryActive(eventCategory int) {
-	//TODO implement me
-	panic("implement me")
+// unsubscribe cancels the subscriptions of the module, once the events
+// already received are handled.
+func (m *Module) unsubscribe() {
+	eventbus.Default.UnsubscribeModule(m.GetName())
 }
@@ -0,0 +1,30 @@
+package battlepass
+
+import (
+	"github.com/phuhao00/greatestworks-proto/player"
+	"greatestworks/internal/protocol"
+)
+
+// info is the state of a pass for a player.
+type info struct {
+	conf     *Conf
+	progress Progress // in the open season, if any
+	end      int64    // end of the open season, if any
+}
+
+func toProto(infos []info) *protocol.SCBattlePassUpdate {
+	msg := &protocol.SCBattlePassUpdate{}
+	for _, i := range infos {
+		msg.Passes = append(msg.Passes, &protocol.BattlePassInfo{
+			Id:      i.conf.Id,
+			Round:   i.progress.Round,
+			End:     i.end,
+			Exp:     i.progress.Exp,
+			Level:   int32(i.conf.level(i.progress.Exp)),
+			Premium: i.progress.Premium,
+			Free:    int32(i.progress.Free),
+			Paid:    int32(i.progress.Paid),
+		})
+	}
+	return msg
+}
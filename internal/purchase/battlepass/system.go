@@ -1,4 +0,0 @@
-package battlepass
-
-type System struct {
-}
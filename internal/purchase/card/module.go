@@ -5,16 +5,27 @@ import (
 	"greatestworks/internal"
 )
 
+const (
+	ModuleName = "card"
+)
+
+var (
+	Mod *Module
+)
+
 type Module struct {
 	*internal.BaseModule
 }
 
 func init() {
-	internal.ModuleManager.RegisterModule("", GetMod())
+	internal.ModuleManager.RegisterModule(ModuleName, GetMod())
 }
 
 func GetMod() *Module {
-	return nil
+	if Mod == nil {
+		Mod = &Module{internal.NewBaseModule()}
+	}
+	return Mod
 }
 
 func (m *Module) RegisterHandler() {
@@ -0,0 +1,259 @@
+// Package currency keeps the balances of the currencies of the players (e.g.,
+// gold, diamonds) in their wallet document, and an append-only ledger of all
+// the transactions. Balances are changed with conditional updates, so that
+// they're changed safely from any server, whether the player is online or
+// not, and never go negative, in MongoDB transactions with the ledger: the
+// deployment must be a replica set (a single node one will do).
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/idgenerator"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/aop/mongo"
+	"greatestworks/internal/note/event/currencyevent"
+)
+
+// Type is a type of currency.
+type Type int32
+
+const (
+	Gold    Type = iota + 1 // 金币
+	Diamond                 // 钻石
+	Token                   // 代金券
+)
+
+func (t Type) String() string {
+	switch t {
+	case Gold:
+		return "gold"
+	case Diamond:
+		return "diamond"
+	case Token:
+		return "token"
+	default:
+		return fmt.Sprintf("currency(%d)", int32(t))
+	}
+}
+
+// key returns the key of the balance of the currency in the wallets.
+func (t Type) key() string {
+	return "bal." + strconv.Itoa(int(t))
+}
+
+// Amount is an amount of a currency.
+type Amount struct {
+	Type Type  `json:"type"`
+	Num  int64 `json:"num"`
+}
+
+var (
+	ErrInvalidAmount = errors.New("currency: invalid amount")
+	ErrInsufficient  = errors.New("currency: insufficient balance")
+	ErrApplied       = errors.New("currency: operation applied already")
+)
+
+var flows = metrics.NewCounterMap[flowLabels](
+	"currency_flow",
+	"Amount of currency added to or spent from the wallets of the players",
+)
+
+type flowLabels struct {
+	Currency string
+	Reason   string
+	Spent    bool
+}
+
+func wallets() *mongodriver.Collection {
+	doc := &mongo.Wallet{}
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+func ledger() *mongodriver.Collection {
+	doc := &mongo.CurrencyTxn{}
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+// Balances returns the balances of a player, by currency.
+func Balances(ctx context.Context, uid uint64) (map[Type]int64, error) {
+	doc := &mongo.Wallet{}
+	err := wallets().FindOne(ctx, bson.M{mongo.PrimaryKey: uid}).Decode(doc)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return map[Type]int64{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load wallet of player %v: %w", uid, err)
+	}
+	return balances(doc), nil
+}
+
+// Add adds amounts of currencies to a player.
+func Add(ctx context.Context, uid uint64, amounts []Amount, reason, ref string) error {
+	return apply(ctx, uid, amounts, 1, reason, ref, nil)
+}
+
+// AddOnce adds amounts of currencies to a player in the same transaction as
+// mark, which marks the operation granting them as done (e.g., an order as
+// granted), with the context of the transaction, and returns false if it was
+// done already: then nothing is added, and AddOnce returns ErrApplied.
+func AddOnce(ctx context.Context, uid uint64, amounts []Amount, reason, ref string, mark func(ctx context.Context) (bool, error)) error {
+	return apply(ctx, uid, amounts, 1, reason, ref, mark)
+}
+
+// Spend spends amounts of currencies of a player, all of them or none. It
+// returns ErrInsufficient if the player doesn't have enough of any.
+func Spend(ctx context.Context, uid uint64, amounts []Amount, reason, ref string) error {
+	return apply(ctx, uid, amounts, -1, reason, ref, nil)
+}
+
+// apply adds (sign 1) or spends (sign -1) amounts of currencies, in a single
+// conditional update of the wallet, and appends the transactions to the
+// ledger, both in a transaction: the balances never change without their
+// transactions in the ledger. mark, if not nil, is called first in the
+// transaction (see AddOnce).
+func apply(ctx context.Context, uid uint64, amounts []Amount, sign int64, reason, ref string, mark func(ctx context.Context) (bool, error)) error {
+	merged, err := merge(amounts)
+	if err != nil || len(merged) == 0 {
+		return err
+	}
+	// The ids of the transactions are taken first, so that nothing fails
+	// once the wallet is updated but the transaction as a whole.
+	ids := make([]uint64, len(merged))
+	for i := range ids {
+		if ids[i], err = idgenerator.NextId(); err != nil {
+			return fmt.Errorf("currency transaction id: %w", err)
+		}
+	}
+
+	filter := bson.M{mongo.PrimaryKey: uid}
+	inc := bson.M{}
+	for _, a := range merged {
+		if sign < 0 {
+			filter[a.Type.key()] = bson.M{"$gte": a.Num}
+		}
+		inc[a.Type.key()] = sign * a.Num
+	}
+	var after map[Type]int64
+	err = transaction(ctx, func(sc mongodriver.SessionContext) error {
+		if mark != nil {
+			ok, err := mark(sc)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrApplied
+			}
+		}
+		if _, err := wallets().UpdateOne(sc,
+			bson.M{mongo.PrimaryKey: uid},
+			bson.M{"$setOnInsert": bson.M{mongo.PrimaryKey: uid, "bal": bson.M{}}},
+			options.Update().SetUpsert(true)); err != nil {
+			return fmt.Errorf("create wallet of player %v: %w", uid, err)
+		}
+		doc := &mongo.Wallet{}
+		err := wallets().FindOneAndUpdate(sc, filter, bson.M{"$inc": inc},
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(doc)
+		if errors.Is(err, mongodriver.ErrNoDocuments) {
+			return ErrInsufficient
+		}
+		if err != nil {
+			return fmt.Errorf("update wallet of player %v: %w", uid, err)
+		}
+
+		after = balances(doc)
+		now := time.Now().Unix()
+		txns := make([]interface{}, 0, len(merged))
+		for i, a := range merged {
+			txns = append(txns, &mongo.CurrencyTxn{
+				Id:       ids[i],
+				OwnerID:  uid,
+				Currency: int32(a.Type),
+				Delta:    sign * a.Num,
+				Balance:  after[a.Type],
+				Reason:   reason,
+				Ref:      ref,
+				Time:     now,
+			})
+		}
+		if _, err := ledger().InsertMany(sc, txns); err != nil {
+			return fmt.Errorf("append ledger of player %v: %w", uid, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, a := range merged {
+		flows.Get(flowLabels{Currency: a.Type.String(), Reason: reason, Spent: sign < 0}).Add(float64(a.Num))
+		eventbus.Publish(eventbus.Default, currencyevent.Changed{
+			PlayerId: uid,
+			Currency: int32(a.Type),
+			Delta:    sign * a.Num,
+			Balance:  after[a.Type],
+			Reason:   reason,
+		})
+	}
+	return nil
+}
+
+// transaction runs fn in a MongoDB transaction, committed if it returns nil
+// and aborted otherwise. The driver runs fn again on the transient errors
+// (e.g., a write conflict with another transaction).
+func transaction(ctx context.Context, fn func(sc mongodriver.SessionContext) error) error {
+	sess, err := mongo.Client.RealCli.StartSession()
+	if err != nil {
+		return fmt.Errorf("currency: start session: %w", err)
+	}
+	defer sess.EndSession(ctx)
+	_, err = sess.WithTransaction(ctx, func(sc mongodriver.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// merge sums the amounts by currency, in the order of the currencies.
+func merge(amounts []Amount) ([]Amount, error) {
+	sums := map[Type]int64{}
+	for _, a := range amounts {
+		if a.Num <= 0 || a.Type <= 0 {
+			return nil, fmt.Errorf("%w: %d of %v", ErrInvalidAmount, a.Num, a.Type)
+		}
+		sums[a.Type] += a.Num
+	}
+	merged := make([]Amount, 0, len(sums))
+	for t, n := range sums {
+		merged = append(merged, Amount{Type: t, Num: n})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Type < merged[j].Type })
+	return merged, nil
+}
+
+// Scale returns amounts multiplied by n (e.g., the price of n goods).
+func Scale(amounts []Amount, n int64) []Amount {
+	scaled := make([]Amount, len(amounts))
+	for i, a := range amounts {
+		scaled[i] = Amount{Type: a.Type, Num: a.Num * n}
+	}
+	return scaled
+}
+
+func balances(doc *mongo.Wallet) map[Type]int64 {
+	bal := make(map[Type]int64, len(doc.Balances))
+	for k, v := range doc.Balances {
+		if t, err := strconv.Atoi(k); err == nil {
+			bal[Type(t)] = v
+		}
+	}
+	return bal
+}
@@ -0,0 +1,138 @@
+package recharge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	appStoreURL        = "https://buy.itunes.apple.com/verifyReceipt"
+	appStoreSandboxURL = "https://sandbox.itunes.apple.com/verifyReceipt"
+
+	// appStoreSandboxStatus is the status of the production endpoint for a
+	// receipt of the sandbox, which is then verified by the sandbox
+	// endpoint.
+	appStoreSandboxStatus = 21007
+
+	defaultAppStoreTimeout = 5 * time.Second
+)
+
+// AppStoreConfig configures the verification of the App Store receipts.
+type AppStoreConfig struct {
+	BundleId     string        // bundle id of the game, the receipts of other apps are invalid
+	Password     string        // shared secret of the app, if any
+	AllowSandbox bool          // whether the receipts of the sandbox are accepted, e.g. on the test servers
+	Timeout      time.Duration // time the App Store may take to answer, defaults to defaultAppStoreTimeout
+	URL          string        // production endpoint, defaults to appStoreURL
+	SandboxURL   string        // sandbox endpoint, defaults to appStoreSandboxURL
+}
+
+// AppStoreVerifier verifies the receipts of the App Store with its
+// verifyReceipt endpoints. The client sends the receipt of the app, base64
+// encoded, and the id of the transaction to verify in it.
+type AppStoreVerifier struct {
+	conf   AppStoreConfig
+	client *http.Client
+}
+
+var _ Verifier = (*AppStoreVerifier)(nil)
+
+func NewAppStoreVerifier(conf AppStoreConfig) *AppStoreVerifier {
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultAppStoreTimeout
+	}
+	if conf.URL == "" {
+		conf.URL = appStoreURL
+	}
+	if conf.SandboxURL == "" {
+		conf.SandboxURL = appStoreSandboxURL
+	}
+	return &AppStoreVerifier{conf: conf, client: &http.Client{Timeout: conf.Timeout}}
+}
+
+type appStoreRequest struct {
+	Receipt  string `json:"receipt-data"`
+	Password string `json:"password,omitempty"`
+}
+
+type appStoreResponse struct {
+	Status      int    `json:"status"`
+	Environment string `json:"environment"`
+	Receipt     struct {
+		BundleId string `json:"bundle_id"`
+		InApp    []struct {
+			ProductId      string `json:"product_id"`
+			TransactionId  string `json:"transaction_id"`
+			PurchaseDateMs string `json:"purchase_date_ms"`
+			CancellationMs string `json:"cancellation_date_ms"`
+		} `json:"in_app"`
+	} `json:"receipt"`
+}
+
+func (v *AppStoreVerifier) Verify(ctx context.Context, r *Receipt) (*Purchase, error) {
+	if r.Data == "" || r.Transaction == "" {
+		return nil, ErrInvalidReceipt
+	}
+	resp, err := v.post(ctx, v.conf.URL, r.Data)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == appStoreSandboxStatus {
+		if !v.conf.AllowSandbox {
+			return nil, fmt.Errorf("%w: receipt of the sandbox", ErrInvalidReceipt)
+		}
+		if resp, err = v.post(ctx, v.conf.SandboxURL, r.Data); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case resp.Status >= 21100 && resp.Status <= 21199:
+		// Internal errors of the App Store.
+		return nil, fmt.Errorf("app store: status %d", resp.Status)
+	case resp.Status != 0:
+		return nil, fmt.Errorf("%w: status %d", ErrInvalidReceipt, resp.Status)
+	case resp.Receipt.BundleId != v.conf.BundleId:
+		return nil, fmt.Errorf("%w: bundle %q", ErrInvalidReceipt, resp.Receipt.BundleId)
+	}
+	for _, t := range resp.Receipt.InApp {
+		if t.TransactionId != r.Transaction {
+			continue
+		}
+		if t.CancellationMs != "" {
+			return nil, ErrNotPurchased
+		}
+		ms, _ := strconv.ParseInt(t.PurchaseDateMs, 10, 64)
+		return &Purchase{OrderId: t.TransactionId, Sku: t.ProductId, Sandbox: resp.Environment == "Sandbox", Time: ms}, nil
+	}
+	return nil, fmt.Errorf("%w: no transaction %q", ErrInvalidReceipt, r.Transaction)
+}
+
+func (v *AppStoreVerifier) post(ctx context.Context, url, receipt string) (*appStoreResponse, error) {
+	body, err := json.Marshal(appStoreRequest{Receipt: receipt, Password: v.conf.Password})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpResp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("app store: %s", httpResp.Status)
+	}
+	resp := &appStoreResponse{}
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return nil, fmt.Errorf("app store: %w", err)
+	}
+	return resp, nil
+}
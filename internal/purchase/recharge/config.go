@@ -0,0 +1,69 @@
+package recharge
+
+import (
+	"fmt"
+
+	"greatestworks/aop/loader/json"
+	"greatestworks/internal/purchase/currency"
+)
+
+// MoneyCategory is the real money a product is priced in.
+type MoneyCategory int32
+
+const (
+	MoneyCategoryCNY MoneyCategory = iota + 1
+	MoneyCategoryUSD
+)
+
+func (c MoneyCategory) String() string {
+	switch c {
+	case MoneyCategoryCNY:
+		return "CNY"
+	case MoneyCategoryUSD:
+		return "USD"
+	default:
+		return fmt.Sprintf("money(%d)", int32(c))
+	}
+}
+
+// Product 充值商品配置
+type Product struct {
+	Id       uint32            `json:"id"`
+	Skus     map[string]string `json:"skus"`     // 各渠道商店里的商品id，key为渠道，如appstore
+	Money    MoneyCategory     `json:"money"`    // 货币类型
+	Price    int64             `json:"price"`    // 价格，分
+	Currency []currency.Amount `json:"currency"` // 获得的货币
+}
+
+// ModuleConfig is the config of the recharge module. It must be set before
+// the module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ProductFile string            // recharge product table, see Product
+	AppStore    *AppStoreConfig   // verification of the App Store receipts; none if nil
+	GooglePlay  *GooglePlayConfig // verification of the Google Play receipts; none if nil
+	Sandbox     bool              // whether the made up receipts of the tests are accepted (see SandboxVerifier); never on the live servers
+}
+
+// loadTable loads the recharge product table.
+func loadTable(file string) (map[uint32]*Product, error) {
+	var list []*Product
+	if !json.ParseJsonFile2Slice(file, true, &list) {
+		return nil, fmt.Errorf("recharge: product table %q not found", file)
+	}
+	products := make(map[uint32]*Product, len(list))
+	for _, p := range list {
+		if products[p.Id] != nil {
+			return nil, fmt.Errorf("recharge: product table %q: repeat product %d", file, p.Id)
+		}
+		if p.Price <= 0 || len(p.Currency) == 0 {
+			return nil, fmt.Errorf("recharge: product table %q: product %d: invalid price %d, currency %v", file, p.Id, p.Price, p.Currency)
+		}
+		for _, a := range p.Currency {
+			if a.Num <= 0 || a.Type <= 0 {
+				return nil, fmt.Errorf("recharge: product table %q: product %d: invalid currency %v", file, p.Id, a)
+			}
+		}
+		products[p.Id] = p
+	}
+	return products, nil
+}
@@ -0,0 +1,85 @@
+package recharge
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// GooglePlayConfig configures the verification of the Google Play receipts.
+type GooglePlayConfig struct {
+	PackageName  string // package name of the game, the receipts of other apps are invalid
+	PublicKey    string // license key of the app, from the Play Console: a base64 encoded RSA public key
+	AllowSandbox bool   // whether the test purchases of the license testers are accepted, e.g. on the test servers
+}
+
+// GooglePlayVerifier verifies the receipts of Google Play with the license
+// key of the app, without calling Google: the client sends the purchase
+// data (INAPP_PURCHASE_DATA) and its signature (INAPP_DATA_SIGNATURE), as
+// the billing library gives them.
+type GooglePlayVerifier struct {
+	conf GooglePlayConfig
+	key  *rsa.PublicKey
+}
+
+var _ Verifier = (*GooglePlayVerifier)(nil)
+
+func NewGooglePlayVerifier(conf GooglePlayConfig) (*GooglePlayVerifier, error) {
+	der, err := base64.StdEncoding.DecodeString(conf.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("recharge: google play key: %w", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("recharge: google play key: %w", err)
+	}
+	key, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("recharge: google play key: not an RSA key")
+	}
+	return &GooglePlayVerifier{conf: conf, key: key}, nil
+}
+
+// googlePlayPurchase is the purchase data signed by Google Play.
+type googlePlayPurchase struct {
+	OrderId       string `json:"orderId"`
+	PackageName   string `json:"packageName"`
+	ProductId     string `json:"productId"`
+	PurchaseTime  int64  `json:"purchaseTime"`
+	PurchaseState int    `json:"purchaseState"` // 0 purchased, 1 canceled, 2 pending
+	PurchaseToken string `json:"purchaseToken"`
+}
+
+func (v *GooglePlayVerifier) Verify(ctx context.Context, r *Receipt) (*Purchase, error) {
+	sig, err := base64.StdEncoding.DecodeString(r.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidReceipt, err)
+	}
+	digest := sha1.Sum([]byte(r.Data))
+	if err := rsa.VerifyPKCS1v15(v.key, crypto.SHA1, digest[:], sig); err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidReceipt, err)
+	}
+	var p googlePlayPurchase
+	if err := json.Unmarshal([]byte(r.Data), &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReceipt, err)
+	}
+	switch {
+	case p.PackageName != v.conf.PackageName:
+		return nil, fmt.Errorf("%w: package %q", ErrInvalidReceipt, p.PackageName)
+	case p.PurchaseState != 0:
+		return nil, ErrNotPurchased
+	}
+	// The test purchases, of the license testers, have no order id.
+	if p.OrderId == "" {
+		if !v.conf.AllowSandbox || p.PurchaseToken == "" {
+			return nil, fmt.Errorf("%w: test purchase", ErrInvalidReceipt)
+		}
+		return &Purchase{OrderId: p.PurchaseToken, Sku: p.ProductId, Sandbox: true, Time: p.PurchaseTime}, nil
+	}
+	return &Purchase{OrderId: p.OrderId, Sku: p.ProductId, Time: p.PurchaseTime}, nil
+}
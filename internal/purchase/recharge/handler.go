@@ -1 +1,88 @@
 package recharge
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/network"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
+)
+
+// Results of the recharges, sent to the client.
+const (
+	ResultOK           = iota // 成功，货币已到账
+	ResultInvalid             // 收据无效
+	ResultNotPurchased        // 支付未完成
+	ResultDuplicate           // 订单已到账过
+	ResultRetry               // 暂时失败，客户端稍后重发收据
+)
+
+type Handler struct {
+	Id messageId.MessageId
+	Fn func(player IPlayer, packet *network.Message)
+}
+
+var (
+	handlers []*Handler
+	onceInit sync.Once
+)
+
+func GetHandler(id messageId.MessageId) (*Handler, error) {
+	for _, handler := range handlers {
+		if handler.Id == id {
+			return handler, nil
+		}
+	}
+	return nil, errors.New("not exist")
+}
+
+func init() {
+	onceInit.Do(func() {
+		HandlerRechargeRegister()
+	})
+}
+
+func HandlerRechargeRegister() {
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSRecharge, Recharge},
+	)
+}
+
+// Recharge verifies the receipt of a recharge product bought by the player,
+// and grants the currency. The client keeps the receipt until the result
+// isn't ResultRetry (e.g., finishes the transaction on the store).
+func Recharge(p IPlayer, packet *network.Message) {
+	req := &protocol.CSRecharge{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	receipt := &Receipt{
+		Channel:     req.Channel,
+		Transaction: req.Transaction,
+		Data:        req.Receipt,
+		Signature:   req.Signature,
+	}
+	rsp := &protocol.SCRecharge{ProductId: req.ProductId, Transaction: req.Transaction}
+	order, err := GetMod().Recharge(context.Background(), p.GetUId(), req.ProductId, receipt)
+	switch {
+	case err == nil:
+		rsp.OrderId = order.Id
+		rsp.Result = ResultOK
+	case errors.Is(err, ErrDuplicate):
+		rsp.Result = ResultDuplicate
+	case errors.Is(err, ErrNotPurchased):
+		rsp.Result = ResultNotPurchased
+	case errors.Is(err, ErrInvalidReceipt), errors.Is(err, ErrNoProduct), errors.Is(err, ErrNoChannel):
+		rsp.Result = ResultInvalid
+	default:
+		rsp.Result = ResultRetry
+	}
+	if err != nil {
+		logger.Warn("[recharge] recharge product %v on %v PlayerID:%v err:%v", req.ProductId, req.Channel, p.GetUId(), err)
+	}
+	p.SendMsg(protocol.MessageId_SCRecharge, rsp)
+}
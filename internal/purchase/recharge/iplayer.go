@@ -1,4 +1,12 @@
 package recharge
 
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+)
+
+// IPlayer is the player handling the messages of the recharges.
 type IPlayer interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+	GetUId() uint64
 }
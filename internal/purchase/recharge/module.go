@@ -1,43 +1,277 @@
 package recharge
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/phuhao00/greatestworks-proto/module"
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
 	"greatestworks/aop/module_router"
+	"greatestworks/aop/mongo"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/note/event/rechargeevent"
+	"greatestworks/internal/purchase/currency"
 )
 
+// reason is the reason of the currency granted by the recharges, in the
+// currency ledger.
+const reason = "recharge"
+
 var (
 	Mod         *Module
 	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNoProduct = errors.New("recharge: no such product")
+	ErrNoChannel = errors.New("recharge: unsupported channel")
+	ErrDuplicate = errors.New("recharge: order already granted")
+)
+
+var (
+	orders = metrics.NewCounterMap[orderLabels](
+		"recharge_orders",
+		"Number of recharge receipts verified, by result",
+	)
+	revenue = metrics.NewCounterMap[revenueLabels](
+		"recharge_revenue",
+		"Revenue of the recharges granted, in cents, test purchases excluded",
+	)
 )
 
+type orderLabels struct {
+	Channel string
+	Result  string // "granted", "duplicate", "invalid", ...
+}
+
+type revenueLabels struct {
+	Channel string
+	Money   string
+}
+
 func init() {
 	internal.ModuleManager.RegisterModule(module.Module_Recharge.String(), GetMod())
 }
 
+// Module grants the currency of the recharge products the players buy on the
+// stores, once the receipts are verified by the verifier of their channel
+// (see RegisterVerifier). Every purchase is granted once: its order is
+// appended to the order ledger before the currency is granted, keyed by its
+// id on the store, and marked granted in the transaction granting the
+// currency.
 type Module struct {
 	*internal.BaseModule
+	initFlag bool
+	products map[uint32]*Product
+
+	mu        sync.Mutex
+	verifiers map[string]Verifier // by channel; guarded by mu
 }
 
 func GetMod() *Module {
-	Mod = &Module{internal.NewBaseModule()}
-
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule(), verifiers: map[string]Verifier{}}
+	})
 	return Mod
 }
 
-// CreateOrder 创建支付订单
-func (m *Module) CreateOrder() {
+// Init loads the product table, and registers the verifiers configured.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	m.products = map[uint32]*Product{}
+	if conf.ProductFile != "" {
+		products, err := loadTable(conf.ProductFile)
+		if err != nil {
+			return err
+		}
+		m.products = products
+	} else {
+		logger.Warn("[recharge] no product table")
+	}
+	if conf.AppStore != nil {
+		m.RegisterVerifier(ChannelAppStore, NewAppStoreVerifier(*conf.AppStore))
+	}
+	if conf.GooglePlay != nil {
+		v, err := NewGooglePlayVerifier(*conf.GooglePlay)
+		if err != nil {
+			return err
+		}
+		m.RegisterVerifier(ChannelGooglePlay, v)
+	}
+	if conf.Sandbox {
+		logger.Warn("[recharge] sandbox receipts accepted")
+		m.RegisterVerifier(ChannelSandbox, SandboxVerifier{})
+	}
+	m.initFlag = true
+	return nil
+}
+
+// RegisterVerifier sets the verifier of the receipts of a channel, e.g. of
+// another store.
+func (m *Module) RegisterVerifier(channel string, v Verifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.verifiers[channel] = v
+}
+
+func (m *Module) verifier(channel string) Verifier {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.verifiers[channel]
+}
 
+func orderLedger() *mongodriver.Collection {
+	doc := &mongo.RechargeOrder{}
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
 }
 
-// OnSdkOrderRsp sdk 订单返回逻辑
-func (m *Module) OnSdkOrderRsp() {
+// Recharge verifies the receipt of a product bought by a player, and grants
+// it the currency of the product. It returns ErrDuplicate if the purchase
+// was granted already. If it fails once the order is in the ledger, the
+// receipt can be sent again: the grant is resumed.
+//
+// The recharges of a player are handled on its lane, so that the same
+// receipt isn't granted twice at once.
+func (m *Module) Recharge(ctx context.Context, uid uint64, productId uint32, r *Receipt) (*mongo.RechargeOrder, error) {
+	product := m.products[productId]
+	if product == nil {
+		return nil, ErrNoProduct
+	}
+	v := m.verifier(r.Channel)
+	if v == nil {
+		return nil, ErrNoChannel
+	}
+	p, err := v.Verify(ctx, r)
+	if err == nil && p.Sku != product.Skus[r.Channel] {
+		err = fmt.Errorf("%w: sku %q of product %d", ErrInvalidReceipt, p.Sku, productId)
+	}
+	if err != nil {
+		orders.Get(orderLabels{Channel: r.Channel, Result: resultOf(err)}).Add(1)
+		return nil, err
+	}
+
+	order := &mongo.RechargeOrder{
+		Id:      r.Channel + ":" + p.OrderId,
+		OwnerID: uid,
+		Product: productId,
+		Channel: r.Channel,
+		Sku:     p.Sku,
+		Money:   int32(product.Money),
+		Price:   product.Price,
+		Sandbox: p.Sandbox,
+		PayTime: p.Time,
+		Time:    time.Now().Unix(),
+	}
+	if order, err = m.claim(ctx, order); err != nil {
+		orders.Get(orderLabels{Channel: r.Channel, Result: resultOf(err)}).Add(1)
+		return nil, err
+	}
+	err = currency.AddOnce(ctx, uid, product.Currency, reason, order.Id, func(ctx context.Context) (bool, error) {
+		return markGranted(ctx, order.Id)
+	})
+	if errors.Is(err, currency.ErrApplied) {
+		orders.Get(orderLabels{Channel: r.Channel, Result: "duplicate"}).Add(1)
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		orders.Get(orderLabels{Channel: r.Channel, Result: "failed"}).Add(1)
+		return nil, fmt.Errorf("grant order %v: %w", order.Id, err)
+	}
+	orders.Get(orderLabels{Channel: r.Channel, Result: "granted"}).Add(1)
+	if !order.Sandbox {
+		revenue.Get(revenueLabels{Channel: r.Channel, Money: product.Money.String()}).Add(float64(order.Price))
+	}
+	eventbus.Publish(eventbus.Default, rechargeevent.Recharged{
+		PlayerId:  uid,
+		OrderId:   order.Id,
+		ProductId: productId,
+		Money:     order.Money,
+		Price:     order.Price,
+		Sandbox:   order.Sandbox,
+		First:     m.first(ctx, uid),
+	})
+	return order, nil
+}
 
+// claim appends an order to the order ledger, and returns it. If the order
+// is there already, it returns the order if it isn't marked granted yet (the
+// grant failed), and ErrDuplicate otherwise, or if it's of another player.
+func (m *Module) claim(ctx context.Context, order *mongo.RechargeOrder) (*mongo.RechargeOrder, error) {
+	_, err := orderLedger().InsertOne(ctx, order)
+	if err == nil {
+		return order, nil
+	}
+	if !mongodriver.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("append order %v: %w", order.Id, err)
+	}
+	existing := &mongo.RechargeOrder{}
+	if err := orderLedger().FindOne(ctx, bson.M{"_id": order.Id}).Decode(existing); err != nil {
+		return nil, fmt.Errorf("load order %v: %w", order.Id, err)
+	}
+	if existing.OwnerID != order.OwnerID {
+		logger.Warn("[recharge] order %v of player %v sent by PlayerID:%v", order.Id, existing.OwnerID, order.OwnerID)
+		return nil, ErrDuplicate
+	}
+	if existing.Granted {
+		return nil, ErrDuplicate
+	}
+	logger.Info("[recharge] resume grant of order %v PlayerID:%v", existing.Id, existing.OwnerID)
+	return existing, nil
+}
+
+// markGranted marks an order as granted, in the transaction granting its
+// currency, and returns false if it was granted already.
+func markGranted(ctx context.Context, id string) (bool, error) {
+	res, err := orderLedger().UpdateOne(ctx,
+		bson.M{"_id": id, "granted": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"granted": true}})
+	if err != nil {
+		return false, fmt.Errorf("mark order %v granted: %w", id, err)
+	}
+	return res.MatchedCount == 1, nil
+}
+
+// first returns whether a player has a single order in the ledger.
+func (m *Module) first(ctx context.Context, uid uint64) bool {
+	n, err := orderLedger().CountDocuments(ctx, bson.M{"uid": uid})
+	if err != nil {
+		logger.Error("[recharge] count orders PlayerID:%v err:%v", uid, err)
+		return false
+	}
+	return n == 1
+}
+
+// resultOf returns the result label of a failed recharge.
+func resultOf(err error) string {
+	switch {
+	case errors.Is(err, ErrDuplicate):
+		return "duplicate"
+	case errors.Is(err, ErrInvalidReceipt):
+		return "invalid"
+	case errors.Is(err, ErrNotPurchased):
+		return "not_purchased"
+	default:
+		return "failed"
+	}
 }
 
 func (m *Module) GetName() string {
-	return ""
+	return module.Module_Recharge.String()
+}
+
+func (m *Module) SetName(name string) {
+	m.BaseModule.SetName(name)
 }
 
 func (m *Module) RegisterHandler() {
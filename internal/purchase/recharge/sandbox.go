@@ -0,0 +1,22 @@
+package recharge
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// SandboxVerifier accepts the receipts made up by the tests, of the form
+// "<order id>:<sku>", as sandbox purchases. It must only be enabled on the
+// test servers (see ModuleConfig.Sandbox).
+type SandboxVerifier struct{}
+
+var _ Verifier = SandboxVerifier{}
+
+func (SandboxVerifier) Verify(ctx context.Context, r *Receipt) (*Purchase, error) {
+	order, sku, ok := strings.Cut(r.Data, ":")
+	if !ok || order == "" || sku == "" {
+		return nil, ErrInvalidReceipt
+	}
+	return &Purchase{OrderId: order, Sku: sku, Sandbox: true, Time: time.Now().UnixMilli()}, nil
+}
@@ -0,0 +1,44 @@
+package recharge
+
+import (
+	"context"
+	"errors"
+)
+
+// Channels of the payments: the stores the receipts come from.
+const (
+	ChannelAppStore   = "appstore"
+	ChannelGooglePlay = "googleplay"
+	ChannelSandbox    = "sandbox" // receipts made up for tests, see SandboxVerifier
+)
+
+var (
+	ErrInvalidReceipt = errors.New("recharge: invalid receipt")
+	ErrNotPurchased   = errors.New("recharge: receipt of a purchase not completed")
+)
+
+// Receipt is the proof of a purchase sent by the client, as the store gave
+// it.
+type Receipt struct {
+	Channel     string
+	Transaction string // id of the purchase on the store, if the store gives it apart
+	Data        string // the receipt
+	Signature   string // signature of Data, if the store signs it apart
+}
+
+// Purchase is a purchase verified with the store.
+type Purchase struct {
+	OrderId string // id of the purchase on the store, unique on the channel
+	Sku     string // id of the product on the store
+	Sandbox bool   // paid in the test environment of the store, not for real
+	Time    int64  // unix milliseconds
+}
+
+// Verifier verifies the receipts of a channel. Verify returns
+// ErrInvalidReceipt if a receipt is forged, or not of the game, and
+// ErrNotPurchased if the purchase isn't completed (e.g., pending, or
+// refunded); other errors are failures of the store, on which the receipt
+// can be verified again later.
+type Verifier interface {
+	Verify(ctx context.Context, r *Receipt) (*Purchase, error)
+}
@@ -0,0 +1,215 @@
+package shop
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/phuhao00/greatestworks-proto/player"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/fn"
+	"greatestworks/aop/msgseq"
+	"greatestworks/aop/saga"
+	"greatestworks/internal/gameplay/bag"
+	"greatestworks/internal/note/event/shopevent"
+	"greatestworks/internal/protocol"
+	"greatestworks/internal/purchase/currency"
+)
+
+// Reasons of the changes of the wallets and the bags made by the shops.
+const (
+	reasonShop   = "shop"
+	reasonRefund = "shop_refund"
+)
+
+// maxBuyCount is the most goods bought at once.
+const maxBuyCount = 999
+
+// refresh resets the purchase counts of a player at the daily and weekly
+// resets, and refreshes the goods its mystery shops offer at their refresh
+// times. It returns whether anything changed.
+//
+// REQUIRES: d.mu is held.
+func (m *Module) refresh(d *Data, now time.Time) bool {
+	changed := false
+	if daily := fn.LastDailyReset(now, m.resetHour).Unix(); d.dailyTM < daily {
+		for _, it := range d.items {
+			it.TodayBuyCount = 0
+		}
+		d.dailyTM = daily
+		changed = true
+	}
+	if weekly := fn.LastWeeklyReset(now, m.resetHour).Unix(); d.weeklyTM < weekly {
+		for _, it := range d.items {
+			it.WeeklyBuyCount = 0
+		}
+		d.weeklyTM = weekly
+		changed = true
+	}
+	for id := range d.offers {
+		if conf := getConfig(id); conf == nil || conf.Category != CategoryMystery {
+			delete(d.offers, id)
+			changed = true
+		}
+	}
+	Shops.Table().Range(func(_ uint32, conf *Config) bool {
+		if conf.Category != CategoryMystery {
+			return true
+		}
+		last := lastRefresh(conf, now, m.resetHour).Unix()
+		if o := d.offers[conf.Id]; o != nil && o.RefreshTM >= last {
+			return true
+		}
+		d.offers[conf.Id] = &Mystery{Id: conf.Id, Goods: pick(conf), RefreshTM: last}
+		changed = true
+		return true
+	})
+	return changed
+}
+
+// offered returns whether a shop offers goods to a player.
+//
+// REQUIRES: d.mu is held.
+func offered(d *Data, conf *Config, goods uint32) bool {
+	if conf.Category == CategoryMystery {
+		o := d.offers[conf.Id]
+		return o != nil && o.offers(goods)
+	}
+	for _, id := range conf.ItemIds {
+		if id == goods {
+			return true
+		}
+	}
+	return false
+}
+
+// Buy buys count goods of a shop for a player: the price is taken from its
+// wallet, then the items are granted to its bag. If the items can't be
+// granted (e.g., the bag is full), the price is refunded. The goods are
+// reserved while they're bought, so that the concurrent purchases of a
+// player never exceed the limits of the goods. A purchase made for a
+// numbered message (see msgseq) is made at most once, however many times the
+// client retries it.
+func (m *Module) Buy(ctx context.Context, d *Data, shopId, goodsId uint32, count int64) error {
+	conf := getConfig(shopId)
+	if conf == nil {
+		return ErrNoShop
+	}
+	g := getGoods(goodsId)
+	if g == nil {
+		return ErrNotOffered
+	}
+	if count <= 0 || count > maxBuyCount {
+		return fmt.Errorf("%w: %d", ErrInvalidCount, count)
+	}
+
+	d.mu.Lock()
+	refreshed := m.refresh(d, time.Now())
+	err := reserve(d, conf, g, count)
+	var list *protocol.SCShopList
+	if refreshed {
+		list = listToProto(d)
+	}
+	d.mu.Unlock()
+	if refreshed && d.markDirty != nil {
+		d.markDirty()
+	}
+	if refreshed {
+		d.SendMsg(protocol.MessageId_SCShopList, list)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = pay(ctx, d, conf, g, count)
+	d.mu.Lock()
+	d.reserved[goodsId] -= count
+	if d.reserved[goodsId] == 0 {
+		delete(d.reserved, goodsId)
+	}
+	if err == nil {
+		bought(d, g, count)
+	}
+	update := goodsToProto(d, goodsId)
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if d.markDirty != nil {
+		d.markDirty()
+	}
+
+	purchases.Get(purchaseLabels{Shop: shopId, Goods: goodsId}).Add(float64(count))
+	for _, p := range g.Price {
+		spent.Get(spentLabels{Shop: shopId, Currency: p.Type.String()}).Add(float64(p.Num * count))
+	}
+	d.SendMsg(protocol.MessageId_SCBuyGoods, buyToProto(shopId, count, update))
+	eventbus.Publish(eventbus.Default, shopevent.Purchased{
+		PlayerId: d.uid,
+		ShopId:   shopId,
+		GoodsId:  goodsId,
+		Count:    count,
+	})
+	return nil
+}
+
+// reserve checks that a shop offers goods to a player, and that their limits
+// leave count of them, counting the ones being bought, and reserves them.
+//
+// REQUIRES: d.mu is held.
+func reserve(d *Data, conf *Config, g *Goods, count int64) error {
+	if !offered(d, conf, g.Id) {
+		return ErrNotOffered
+	}
+	it := d.items[g.Id]
+	if it == nil {
+		it = &Item{Id: g.Id}
+	}
+	if left := it.left(g); left >= 0 && left-d.reserved[g.Id] < count {
+		return ErrLimit
+	}
+	d.reserved[g.Id] += count
+	return nil
+}
+
+// pay takes the price of goods, and grants their items, in a saga whose key
+// is the one of the numbered message that bought them, if any.
+func pay(ctx context.Context, d *Data, conf *Config, g *Goods, count int64) error {
+	ref := fmt.Sprintf("shop:%d:goods:%d*%d", conf.Id, g.Id, count)
+	price := currency.Scale(g.Price, count)
+	items := make([]bag.Stack, len(g.Items))
+	for i, st := range g.Items {
+		items[i] = bag.Stack{Id: st.Id, Num: st.Num * count}
+	}
+	s := &saga.Saga{Name: "shop_buy"}
+	if key := msgseq.Key(ctx); key != "" {
+		s.Key = fmt.Sprintf("shop:%d:%s", d.uid, key)
+	}
+	if len(price) > 0 {
+		s.Step("spend", func(ctx context.Context) error {
+			return currency.Spend(ctx, d.uid, price, reasonShop, ref)
+		}, func(ctx context.Context) error {
+			refunds.Add(1)
+			return currency.Add(ctx, d.uid, price, reasonRefund, ref)
+		})
+	}
+	s.Step("grant", func(ctx context.Context) error {
+		_, err := d.GetBagSystem().Grant(ctx, reasonShop, items)
+		return err
+	}, nil)
+	return saga.Get().Run(ctx, s)
+}
+
+// bought counts goods bought against their limits.
+//
+// REQUIRES: d.mu is held.
+func bought(d *Data, g *Goods, count int64) {
+	it := d.items[g.Id]
+	if it == nil {
+		it = &Item{Id: g.Id}
+	}
+	it.TotalBuyCount += uint64(count)
+	it.TodayBuyCount += uint64(count)
+	it.WeeklyBuyCount += uint64(count)
+	d.items[g.Id] = it
+}
@@ -1,27 +1,124 @@
 package shop
 
-import "sync"
+import (
+	"fmt"
+	"time"
 
+	"greatestworks/aop/gamedata"
+	"greatestworks/internal/gameplay/bag"
+	"greatestworks/internal/purchase/currency"
+)
+
+// Config 商店配置，一个商店对应客户端的一个页签
 type Config struct {
 	Id          uint32   `json:"id"`
 	Desc        string   `json:"desc"`
-	ItemIds     []uint32 `json:"itemIds"`
-	Weights     []uint32 `json:"weights"`
-	Category    uint32   `json:"category"`    //商店类型
-	RefreshTime []string `json:"refreshTime"` //刷新时间
+	ItemIds     []uint32 `json:"itemIds"`     //商品id
+	Weights     []uint32 `json:"weights"`     //神秘商店商品权重，与ItemIds一一对应
+	Slots       int      `json:"slots"`       //神秘商店每次刷出的商品数
+	Category    Category `json:"category"`    //商店类型
+	RefreshTime []string `json:"refreshTime"` //刷新时间，如 "12:00"，为空则在每日重置时刷新
+
+	refreshAt []time.Duration // RefreshTime, from midnight
+}
+
+// Goods 商品配置
+type Goods struct {
+	Id          uint32            `json:"id"`
+	Items       []bag.Stack       `json:"items"`       //获得的道具
+	Price       []currency.Amount `json:"price"`       //价格，为空则免费
+	DailyLimit  uint64            `json:"dailyLimit"`  //每日限购，0为不限
+	WeeklyLimit uint64            `json:"weeklyLimit"` //每周限购，0为不限
+	TotalLimit  uint64            `json:"totalLimit"`  //终身限购，0为不限
+}
+
+// ModuleConfig is the config of the shop module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	ShopFile      string        // path of the shop table, a JSON array or a CSV file of Config (see gamedata)
+	GoodsFile     string        // path of the goods table, a JSON array or a CSV file of Goods
+	ResetHour     int           // hour of the daily and weekly resets of the purchase limits
+	RefreshPeriod time.Duration // how often the shops of the online players are checked for refreshes
 }
 
+const (
+	defaultResetHour     = 5
+	defaultRefreshPeriod = time.Minute
+)
+
 var (
-	Configs        sync.Map //配置
-	onceInitConfig sync.Once
+	Shops      = gamedata.Register(gamedata.Default, "shop", func(c *Config) uint32 { return c.Id }, checkShops) //商店配置，key为商店id
+	GoodsTable = gamedata.Register(gamedata.Default, "goods", func(g *Goods) uint32 { return g.Id }, checkGoods) //商品配置，key为商品id
 )
 
-func init() {
-	onceInitConfig.Do(func() {
-		LoadConfigs()
+// checkGoods checks the goods, and their items in the item table.
+func checkGoods(s *gamedata.Snapshot, t *gamedata.Table[uint32, Goods]) error {
+	items := bag.Items.In(s)
+	var err error
+	t.Range(func(id uint32, g *Goods) bool {
+		if len(g.Items) == 0 {
+			err = fmt.Errorf("goods %d has no item", id)
+			return false
+		}
+		for _, it := range g.Items {
+			if items.Get(it.Id) == nil {
+				err = fmt.Errorf("goods %d: unknown item %d", id, it.Id)
+				return false
+			}
+		}
+		for _, p := range g.Price {
+			if p.Type <= 0 || p.Num <= 0 {
+				err = fmt.Errorf("goods %d: invalid price %d of %v", id, p.Num, p.Type)
+				return false
+			}
+		}
+		return true
+	})
+	return err
+}
+
+// checkShops checks the shops, whose goods must all be in the goods table,
+// and parses their refresh times.
+func checkShops(s *gamedata.Snapshot, t *gamedata.Table[uint32, Config]) error {
+	goods := GoodsTable.In(s)
+	var err error
+	t.Range(func(_ uint32, c *Config) bool {
+		err = checkShop(c, goods)
+		return err == nil
 	})
+	return err
 }
 
-func LoadConfigs() {
+func checkShop(c *Config, goods *gamedata.Table[uint32, Goods]) error {
+	for _, id := range c.ItemIds {
+		if goods.Get(id) == nil {
+			return fmt.Errorf("shop %d: unknown goods %d", c.Id, id)
+		}
+	}
+	if c.Category == CategoryMystery {
+		if len(c.Weights) != len(c.ItemIds) {
+			return fmt.Errorf("shop %d: %d weights for %d goods", c.Id, len(c.Weights), len(c.ItemIds))
+		}
+		if c.Slots <= 0 || c.Slots > len(c.ItemIds) {
+			return fmt.Errorf("shop %d: invalid slots %d", c.Id, c.Slots)
+		}
+	}
+	for _, rt := range c.RefreshTime {
+		at, err := time.Parse("15:04", rt)
+		if err != nil {
+			return fmt.Errorf("shop %d: refresh time %q: %w", c.Id, rt, err)
+		}
+		c.refreshAt = append(c.refreshAt, time.Duration(at.Hour())*time.Hour+time.Duration(at.Minute())*time.Minute)
+	}
+	return nil
+}
+
+// getConfig returns the config of a shop, or nil.
+func getConfig(id uint32) *Config {
+	return Shops.Get(id)
+}
 
+// getGoods returns the config of goods, or nil.
+func getGoods(id uint32) *Goods {
+	return GoodsTable.Get(id)
 }
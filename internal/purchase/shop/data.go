@@ -1,11 +1,86 @@
 package shop
 
+import (
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Doc 对应DB -> mongo
+type Doc struct {
+	Items    []Item    `bson:"items"`  // 商品购买次数
+	Offers   []Mystery `bson:"offers"` // 神秘商店刷出的商品
+	DailyTM  int64     `bson:"dtm"`    // 上次日重置时间
+	WeeklyTM int64     `bson:"wtm"`    // 上次周重置时间
+}
+
+// Data is the purchases of a player, and the goods its mystery shops offer.
+// It's used by the shop lane of the player (see player.moduleOf) and by the
+// refreshes of the module, so it's guarded by mu.
 type Data struct {
-	Shops map[uint32]Shop
+	uid uint64
+	Owner
+	markDirty func()
+
+	mu       sync.Mutex
+	items    map[uint32]*Item    // by goods id; guarded by mu
+	reserved map[uint32]int64    // goods being bought, by goods id; guarded by mu
+	offers   map[uint32]*Mystery // by shop id; guarded by mu
+	dailyTM  int64               // guarded by mu
+	weeklyTM int64               // guarded by mu
 }
 
 func NewData() *Data {
 	return &Data{
-		Shops: nil,
+		items:    map[uint32]*Item{},
+		reserved: map[uint32]int64{},
+		offers:   map[uint32]*Mystery{},
+	}
+}
+
+// SetOwner sets the player the purchases belong to, and how they're marked
+// dirty.
+func (d *Data) SetOwner(owner Owner, uid uint64, markDirty func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Owner = owner
+	d.uid = uid
+	d.markDirty = markDirty
+}
+
+// Save returns a copy of the purchases, to be stored.
+func (d *Data) Save() (interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	doc := Doc{Items: []Item{}, Offers: []Mystery{}, DailyTM: d.dailyTM, WeeklyTM: d.weeklyTM}
+	for _, it := range d.items {
+		doc.Items = append(doc.Items, *it)
+	}
+	for _, o := range d.offers {
+		o := *o
+		o.Goods = append([]uint32(nil), o.Goods...)
+		doc.Offers = append(doc.Offers, o)
+	}
+	return doc, nil
+}
+
+// Load loads stored purchases.
+func (d *Data) Load(raw bson.RawValue) error {
+	var doc Doc
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.items = make(map[uint32]*Item, len(doc.Items))
+	for i := range doc.Items {
+		d.items[doc.Items[i].Id] = &doc.Items[i]
+	}
+	d.offers = make(map[uint32]*Mystery, len(doc.Offers))
+	for i := range doc.Offers {
+		d.offers[doc.Offers[i].Id] = &doc.Offers[i]
 	}
+	d.dailyTM = doc.DailyTM
+	d.weeklyTM = doc.WeeklyTM
+	return nil
 }
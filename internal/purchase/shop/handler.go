@@ -1,15 +1,21 @@
 package shop
 
 import (
+	"context"
 	"errors"
+	"sync"
+
 	"github.com/phuhao00/greatestworks-proto/messageId"
+	"github.com/phuhao00/greatestworks-proto/player"
 	"github.com/phuhao00/network"
-	"sync"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
 )
 
 type Handler struct {
 	Id messageId.MessageId
-	Fn func(player IPlayer, packet *network.Message)
+	Fn func(ctx context.Context, player IPlayer, packet *network.Message)
 }
 
 var (
@@ -20,7 +26,11 @@ var (
 )
 
 func IsBelongToHere(id messageId.MessageId) bool {
-	return id > MinMessageId && id < MaxMessageId
+	if id > MinMessageId && id < MaxMessageId {
+		return true
+	}
+	h, _ := GetHandler(id)
+	return h != nil
 }
 
 func GetHandler(id messageId.MessageId) (*Handler, error) {
@@ -34,14 +44,30 @@ func GetHandler(id messageId.MessageId) (*Handler, error) {
 
 func init() {
 	onceInit.Do(func() {
-		handlers[0] = &Handler{
-			0,
-			BuyItem,
-		}
+		handlers = append(handlers,
+			&Handler{protocol.MessageId_CSBuyGoods, BuyItem},
+			&Handler{protocol.MessageId_CSShopList, ShopList},
+		)
 	})
 }
 
 // BuyItem  buy  item
-func BuyItem(player IPlayer, packet *network.Message) {
-	player.GetShopData()
+func BuyItem(ctx context.Context, p IPlayer, packet *network.Message) {
+	req := &protocol.CSBuyGoods{}
+	if err := proto.Unmarshal(packet.Data, req); err != nil {
+		return
+	}
+	d := p.GetShopData()
+	if err := GetMod().Buy(ctx, d, req.ShopId, req.GoodsId, req.Count); err != nil {
+		logger.Warn("[shop] buy goods %v of shop %v PlayerID:%v err:%v", req.GoodsId, req.ShopId, d.uid, err)
+	}
+}
+
+// ShopList sends the shops, with the goods they offer to the player.
+func ShopList(ctx context.Context, p IPlayer, packet *network.Message) {
+	d := p.GetShopData()
+	d.mu.Lock()
+	list := listToProto(d)
+	d.mu.Unlock()
+	p.SendMsg(protocol.MessageId_SCShopList, list)
 }
@@ -1,5 +1,19 @@
 package shop
 
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/bag"
+)
+
+// IPlayer is the player handling the messages of the shops.
 type IPlayer interface {
+	Owner
 	GetShopData() *Data
 }
+
+// Owner is the player the purchases belong to.
+type Owner interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+	GetBagSystem() *bag.System
+}
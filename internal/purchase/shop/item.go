@@ -1,9 +1,32 @@
 package shop
 
+// Item is the purchases of goods by a player, counted against the limits of
+// the goods.
 type Item struct {
-	Id              uint32
-	TotalBuyCount   uint64
-	TodayBuyCount   uint64
-	WeeklyBuyCount  uint64
-	MonthlyBuyCount uint64
+	Id             uint32 `bson:"id"`    //商品id
+	TotalBuyCount  uint64 `bson:"total"` //累计购买次数
+	TodayBuyCount  uint64 `bson:"day"`   //今日购买次数
+	WeeklyBuyCount uint64 `bson:"week"`  //本周购买次数
+}
+
+// left returns how many more of the goods the player may buy, or -1 if
+// unlimited.
+func (it *Item) left(g *Goods) int64 {
+	left := int64(-1)
+	limit := func(most, bought uint64) {
+		if most == 0 {
+			return
+		}
+		n := int64(most) - int64(bought)
+		if n < 0 {
+			n = 0
+		}
+		if left < 0 || n < left {
+			left = n
+		}
+	}
+	limit(g.DailyLimit, it.TodayBuyCount)
+	limit(g.WeeklyLimit, it.WeeklyBuyCount)
+	limit(g.TotalLimit, it.TotalBuyCount)
+	return left
 }
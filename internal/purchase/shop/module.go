@@ -1,50 +1,207 @@
 package shop
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
 	"github.com/phuhao00/greatestworks-proto/module"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
 	"greatestworks/aop/module_router"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/protocol"
+	"greatestworks/internal/purchase/currency"
 )
 
 var (
 	Mod         *Module
 	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var (
+	ErrNoShop       = errors.New("shop: no such shop")
+	ErrNotOffered   = errors.New("shop: goods not offered by the shop")
+	ErrInvalidCount = errors.New("shop: invalid count")
+	ErrLimit        = errors.New("shop: purchase limit reached")
 )
 
+var (
+	purchases = metrics.NewCounterMap[purchaseLabels](
+		"shop_purchases",
+		"Number of goods bought in the shops",
+	)
+	spent = metrics.NewCounterMap[spentLabels](
+		"shop_currency_spent",
+		"Amount of currency spent in the shops",
+	)
+	refunds = metrics.NewCounter(
+		"shop_refunds",
+		"Number of purchases refunded because the items couldn't be granted",
+	)
+)
+
+type purchaseLabels struct {
+	Shop  uint32
+	Goods uint32
+}
+
+type spentLabels struct {
+	Shop     uint32
+	Currency string
+}
+
 func init() {
-	internal.ModuleManager.RegisterModule(module.Module_Shop.String(), Mod)
+	internal.ModuleManager.RegisterModule(module.Module_Shop.String(), GetMod())
 }
 
+// Module sells the goods of the shops to the players for currencies (see
+// Buy), and refreshes the shops of the players online on this server.
 type Module struct {
 	*internal.BaseModule
+	initFlag      bool
+	resetHour     int
+	refreshPeriod time.Duration
+	stopCh        chan struct{}
+
+	mu     sync.Mutex
+	online map[uint64]*Data // guarded by mu
 }
 
 func GetMod() *Module {
-	Mod = &Module{internal.NewBaseModule()}
-
+	onceInitMod.Do(func() {
+		Mod = &Module{BaseModule: internal.NewBaseModule()}
+	})
 	return Mod
 }
 
-// ResourceBuy eg:金币，钻石
-func (m *Module) ResourceBuy() {
+// Init sets the files of the shop and goods tables, loaded with the other
+// tables (see gamedata.Registry.Load).
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	Shops.SetFile(conf.ShopFile)
+	GoodsTable.SetFile(conf.GoodsFile)
+	if conf.ShopFile == "" || conf.GoodsFile == "" {
+		logger.Warn("[shop] no shop table")
+	}
+	m.resetHour = conf.ResetHour
+	if m.resetHour <= 0 || m.resetHour >= 24 {
+		m.resetHour = defaultResetHour
+	}
+	m.refreshPeriod = conf.RefreshPeriod
+	if m.refreshPeriod <= 0 {
+		m.refreshPeriod = defaultRefreshPeriod
+	}
+	m.online = make(map[uint64]*Data)
+	m.stopCh = make(chan struct{})
+	m.initFlag = true
+	return nil
+}
+
+// OnStart starts pushing the changes of the wallets, and refreshing the
+// shops of the online players.
+func (m *Module) OnStart() {
+	if !m.initFlag {
+		return
+	}
+	m.subscribe()
+	go m.runRefresh()
+}
 
+func (m *Module) OnStop() {
+	if !m.initFlag {
+		return
+	}
+	close(m.stopCh)
+	m.unsubscribe()
 }
 
-// MoneyBuy 直购
-func (m *Module) MoneyBuy() {
+// Online registers the purchases of a player that logged in to this server,
+// refreshes its shops, and pushes them, along with its wallet.
+func (m *Module) Online(ctx context.Context, d *Data) {
+	m.mu.Lock()
+	m.online[d.uid] = d
+	m.mu.Unlock()
 
+	d.mu.Lock()
+	refreshed := m.refresh(d, time.Now())
+	list := listToProto(d)
+	d.mu.Unlock()
+	if refreshed && d.markDirty != nil {
+		d.markDirty()
+	}
+	d.SendMsg(protocol.MessageId_SCShopList, list)
+
+	balances, err := currency.Balances(ctx, d.uid)
+	if err != nil {
+		logger.Error("[shop] wallet PlayerID:%v err:%v", d.uid, err)
+		return
+	}
+	d.SendMsg(protocol.MessageId_SCCurrencyList, balancesToProto(balances))
+}
+
+// Offline unregisters the purchases of a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
 }
 
-// TokenMoneyBuy 代金券
-func (m *Module) TokenMoneyBuy() {
+// dataOf returns the purchases of a player online on this server, or nil.
+func (m *Module) dataOf(uid uint64) *Data {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.online[uid]
+}
 
+// runRefresh refreshes the shops of the online players periodically, and
+// pushes them if they changed, until the module is stopped.
+func (m *Module) runRefresh() {
+	ticker := time.NewTicker(m.refreshPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			m.mu.Lock()
+			online := make([]*Data, 0, len(m.online))
+			for _, d := range m.online {
+				online = append(online, d)
+			}
+			m.mu.Unlock()
+			for _, d := range online {
+				d.mu.Lock()
+				refreshed := m.refresh(d, now)
+				list := listToProto(d)
+				d.mu.Unlock()
+				if !refreshed {
+					continue
+				}
+				if d.markDirty != nil {
+					d.markDirty()
+				}
+				d.SendMsg(protocol.MessageId_SCShopList, list)
+			}
+		case <-m.stopCh:
+			return
+		}
+	}
 }
 
 func (m *Module) GetName() string {
 	return module.Module_Shop.String()
 }
 
+// Dependencies returns the modules the shop module depends on: the goods
+// bought go to the bag.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Bag.String()}
+}
+
 func (m *Module) RegisterHandler() {
 	module_router.RegisterModuleMessageHandler(module.Module_Shop, 0, nil)
 }
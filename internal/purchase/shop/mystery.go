@@ -1,11 +1,19 @@
 package shop
 
+// Mystery is the goods a mystery shop offers to a player, until its next
+// refresh.
 type Mystery struct {
-	Id       uint32 //configId
-	Category Category
-	Pool     []Item
+	Id        uint32   `bson:"id"`    //configId
+	Goods     []uint32 `bson:"goods"` //刷出的商品
+	RefreshTM int64    `bson:"rtm"`   //刷新时间
 }
 
-func (m *Mystery) Refresh() {
-
+// offers returns whether the shop offers goods.
+func (m *Mystery) offers(goods uint32) bool {
+	for _, id := range m.Goods {
+		if id == goods {
+			return true
+		}
+	}
+	return false
 }
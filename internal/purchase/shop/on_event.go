@@ -1,23 +1,39 @@
 package shop
 
 import (
+	eventbus "greatestworks/aop/event"
 	"greatestworks/internal"
 	"greatestworks/internal/note/event"
+	"greatestworks/internal/note/event/currencyevent"
+	"greatestworks/internal/protocol"
 )
 
-type EventHandle func(iEvent event.IEvent)
+// OnEvent is unused: the module subscribes to the event bus (see subscribe).
+func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
+}
 
-type EventWrap struct {
-	IPlayer
-	event.IEvent
+func (m *Module) SetEventCategoryActive(eventCategory int) {
 }
 
-func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
-	//TODO implement me
-	panic("implement me")
+// subscribe subscribes the module to the changes of the wallets, which it
+// pushes to the online players, whatever changed them (e.g., a purchase, or
+// a mail).
+func (m *Module) subscribe() {
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e currencyevent.Changed) {
+		d := m.dataOf(e.PlayerId)
+		if d == nil {
+			return
+		}
+		d.SendMsg(protocol.MessageId_SCCurrencyChange, &protocol.SCCurrencyChange{
+			Currency: &protocol.CurrencyInfo{Type: e.Currency, Balance: e.Balance},
+			Delta:    e.Delta,
+			Reason:   e.Reason,
+		})
+	})
 }
 
-func (m *Module) SetEventCategoryActive(eventCategory int) {
-	//TODO implement me
-	panic("implement me")
+// unsubscribe cancels the subscriptions of the module, once the events
+// already received are handled.
+func (m *Module) unsubscribe() {
+	eventbus.Default.UnsubscribeModule(m.GetName())
 }
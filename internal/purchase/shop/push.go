@@ -0,0 +1,61 @@
+package shop
+
+import (
+	"sort"
+
+	"github.com/phuhao00/greatestworks-proto/player"
+	"greatestworks/internal/protocol"
+	"greatestworks/internal/purchase/currency"
+)
+
+// goodsToProto returns the purchases of goods by a player.
+//
+// REQUIRES: d.mu is held.
+func goodsToProto(d *Data, id uint32) *protocol.ShopGoods {
+	pb := &protocol.ShopGoods{Id: id}
+	if it := d.items[id]; it != nil {
+		pb.Today = it.TodayBuyCount
+		pb.Weekly = it.WeeklyBuyCount
+		pb.Total = it.TotalBuyCount
+	}
+	return pb
+}
+
+// listToProto returns the message pushing the shops, with the goods they
+// offer to a player, and its purchases.
+//
+// REQUIRES: d.mu is held.
+func listToProto(d *Data) *protocol.SCShopList {
+	pb := &protocol.SCShopList{}
+	Shops.Table().Range(func(_ uint32, conf *Config) bool {
+		info := &protocol.ShopInfo{Id: conf.Id}
+		goods := conf.ItemIds
+		if conf.Category == CategoryMystery {
+			goods = nil
+			if o := d.offers[conf.Id]; o != nil {
+				goods = o.Goods
+				info.RefreshTM = o.RefreshTM
+			}
+		}
+		for _, id := range goods {
+			info.Goods = append(info.Goods, goodsToProto(d, id))
+		}
+		pb.Shops = append(pb.Shops, info)
+		return true
+	})
+	sort.Slice(pb.Shops, func(i, j int) bool { return pb.Shops[i].Id < pb.Shops[j].Id })
+	return pb
+}
+
+func buyToProto(shopId uint32, count int64, goods *protocol.ShopGoods) *protocol.SCBuyGoods {
+	return &protocol.SCBuyGoods{ShopId: shopId, Count: count, Goods: goods}
+}
+
+func balancesToProto(balances map[currency.Type]int64) *protocol.SCCurrencyList {
+	pb := &protocol.SCCurrencyList{}
+	for t, n := range balances {
+		pb.Currencies = append(pb.Currencies, &protocol.CurrencyInfo{Type: int32(t), Balance: n})
+	}
+	sort.Slice(pb.Currencies, func(i, j int) bool { return pb.Currencies[i].Type < pb.Currencies[j].Type })
+	return pb
+}
@@ -1,12 +1,65 @@
 package shop
 
+import (
+	"math/rand"
+	"time"
+
+	"greatestworks/aop/fn"
+)
+
 type Category int
 
 const (
-	CategoryNormal Category = iota + 1
-	CategoryMystery
+	CategoryNormal  Category = iota + 1 // sells all its goods
+	CategoryMystery                     // sells Config.Slots of its goods, picked by weight at each refresh
 )
 
-type Shop interface {
-	Refresh()
+// lastRefresh returns the last refresh of a shop at or before now: the
+// latest of its refresh times, today or yesterday, or the last daily reset
+// if it has none.
+func lastRefresh(conf *Config, now time.Time, resetHour int) time.Time {
+	if len(conf.refreshAt) == 0 {
+		return fn.LastDailyReset(now, resetHour)
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var last time.Time
+	for _, at := range conf.refreshAt {
+		t := midnight.Add(at)
+		if t.After(now) {
+			t = t.AddDate(0, 0, -1)
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+// pick picks Config.Slots distinct goods of a mystery shop, by weight.
+func pick(conf *Config) []uint32 {
+	ids := append([]uint32(nil), conf.ItemIds...)
+	weights := append([]uint32(nil), conf.Weights...)
+	picked := make([]uint32, 0, conf.Slots)
+	for len(picked) < conf.Slots && len(ids) > 0 {
+		var total int64
+		for _, w := range weights {
+			total += int64(w)
+		}
+		i := 0
+		if total > 0 {
+			r := rand.Int63n(total)
+			for ; i < len(weights)-1; i++ {
+				if r < int64(weights[i]) {
+					break
+				}
+				r -= int64(weights[i])
+			}
+		} else {
+			i = rand.Intn(len(ids))
+		}
+		picked = append(picked, ids[i])
+		ids = append(ids[:i], ids[i+1:]...)
+		weights = append(weights[:i], weights[i+1:]...)
+	}
+	return picked
 }
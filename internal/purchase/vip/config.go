@@ -1,7 +1,43 @@
 package vip
 
+import (
+	"fmt"
+
+	"greatestworks/aop/loader/json"
+	"greatestworks/internal/gameplay/bag"
+)
+
+// Config VIP等级配置
 type Config struct {
-	Level  uint32
-	EXP    uint32
-	Reward uint32
+	Level   uint32      `json:"level"`   // 等级，从1起连续
+	Exp     int64       `json:"exp"`     // 达到该等级的累计经验
+	Rewards []bag.Stack `json:"rewards"` // 达到该等级可领取的奖励
+}
+
+// ModuleConfig is the config of the VIP module. It must be set before the
+// module is initialized (see Module.Init).
+type ModuleConfig struct {
+	LevelFile  string // VIP level table, see Config
+	ExpPerYuan int64  // experience per yuan recharged, defaults to defaultExpPerYuan
+}
+
+const (
+	defaultExpPerYuan = 10
+)
+
+// loadTable loads the VIP level table, ordered by level.
+func loadTable(file string) ([]*Config, error) {
+	var list []*Config
+	if !json.ParseJsonFile2Slice(file, true, &list) {
+		return nil, fmt.Errorf("vip: level table %q not found", file)
+	}
+	for i, c := range list {
+		if c.Level != uint32(i+1) {
+			return nil, fmt.Errorf("vip: level table %q: level %d out of order", file, c.Level)
+		}
+		if c.Exp <= 0 || i > 0 && c.Exp <= list[i-1].Exp {
+			return nil, fmt.Errorf("vip: level table %q: level %d: exp %d not ascending", file, c.Level, c.Exp)
+		}
+	}
+	return list, nil
 }
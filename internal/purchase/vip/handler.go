@@ -1,10 +1,14 @@
 package vip
 
 import (
+	"context"
 	"errors"
+	"sync"
+
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"github.com/phuhao00/network"
-	"sync"
+	"greatestworks/aop/logger"
+	"greatestworks/internal/protocol"
 )
 
 type Handler struct {
@@ -13,16 +17,10 @@ type Handler struct {
 }
 
 var (
-	handlers     []*Handler
-	onceInit     sync.Once
-	MinMessageId messageId.MessageId
-	MaxMessageId messageId.MessageId //handle 的消息范围
+	handlers []*Handler
+	onceInit sync.Once
 )
 
-func IsBelongToHere(id messageId.MessageId) bool {
-	return id > MinMessageId && id < MaxMessageId
-}
-
 func GetHandler(id messageId.MessageId) (*Handler, error) {
 	for _, handler := range handlers {
 		if handler.Id == id {
@@ -39,18 +37,22 @@ func init() {
 }
 
 func HandlerVipRegister() {
-	handlers[0] = &Handler{
-		0,
-		getDailyReward,
-	}
-	handlers[1] = &Handler{
-		0,
-		getVipInfo,
-	}
+	handlers = append(handlers,
+		&Handler{protocol.MessageId_CSVipReward, getReward},
+		&Handler{protocol.MessageId_CSVipInfo, getVipInfo},
+	)
 }
 
-func getDailyReward(player Player, packet *network.Message) {
+// getReward claims the rewards of the VIP levels reached and not claimed
+// yet. The VIP level is pushed with what's claimed.
+func getReward(player Player, packet *network.Message) {
+	v := player.GetVip()
+	if err := GetMod().Claim(context.Background(), v); err != nil {
+		logger.Warn("[vip] claim rewards PlayerID:%v err:%v", v.uid, err)
+	}
 }
 
+// getVipInfo pushes the VIP level.
 func getVipInfo(player Player, packet *network.Message) {
+	GetMod().push(player.GetVip())
 }
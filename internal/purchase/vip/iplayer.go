@@ -1,5 +1,17 @@
 package vip
 
+import (
+	"github.com/phuhao00/greatestworks-proto/messageId"
+	"google.golang.org/protobuf/proto"
+	"greatestworks/internal/gameplay/bag"
+)
+
 type Player interface {
 	GetVip() *Vip
 }
+
+// Owner is the player the Vip belongs to.
+type Owner interface {
+	SendMsg(ID messageId.MessageId, message proto.Message)
+	GetBagSystem() *bag.System
+}
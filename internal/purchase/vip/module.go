@@ -1,23 +1,56 @@
 package vip
 
 import (
+	"context"
+	"errors"
+	"sync"
+
 	"github.com/phuhao00/greatestworks-proto/module"
+	eventbus "greatestworks/aop/event"
+	"greatestworks/aop/logger"
+	metrics "greatestworks/aop/metrics/impl"
 	"greatestworks/aop/module_router"
 	"greatestworks/internal"
-	"sync"
+	"greatestworks/internal/gameplay/bag"
+	"greatestworks/internal/note/event/vipevent"
+	"greatestworks/internal/protocol"
 )
 
+// reason is the reason of the rewards of the VIP levels, in the bags.
+const reason = "vip"
+
 var (
 	Mod         *Module
 	onceInitMod sync.Once
+	ModuleConf  *ModuleConfig
+)
+
+var ErrNothingToClaim = errors.New("vip: nothing to claim")
+
+var levelUps = metrics.NewCounterMap[levelLabels](
+	"vip_level_ups",
+	"Number of players who reached a VIP level",
 )
 
+type levelLabels struct {
+	Level uint32
+}
+
 func init() {
 	internal.ModuleManager.RegisterModule(module.Module_Vip.String(), GetMod())
 }
 
+// Module raises the VIP levels of the players with the experience of their
+// recharges (see subscribe), and gives them the rewards of the levels they
+// reach when they claim them.
 type Module struct {
 	*internal.BaseModule
+	initFlag   bool
+	levels     []*Config
+	expPerYuan int64
+
+	mu     sync.Mutex
+	online map[uint64]*Vip // guarded by mu
 }
 
 func GetMod() *Module {
@@ -29,16 +62,128 @@ func GetMod() *Module {
 	return Mod
 }
 
+// Init loads the VIP level table.
+func (m *Module) Init() error {
+	conf := ModuleConf
+	if conf == nil {
+		conf = &ModuleConfig{}
+	}
+	if conf.LevelFile != "" {
+		levels, err := loadTable(conf.LevelFile)
+		if err != nil {
+			return err
+		}
+		m.levels = levels
+	} else {
+		logger.Warn("[vip] no level table")
+	}
+	m.expPerYuan = conf.ExpPerYuan
+	if m.expPerYuan <= 0 {
+		m.expPerYuan = defaultExpPerYuan
+	}
+	m.online = make(map[uint64]*Vip)
+	m.initFlag = true
+	return nil
+}
+
+func (m *Module) OnStart() {
+	if m.initFlag {
+		m.subscribe()
+	}
+}
+
+func (m *Module) OnStop() {
+	if m.initFlag {
+		m.unsubscribe()
+	}
+}
+
 func (m *Module) SetName(name string) {
 	m.BaseModule.SetName(name)
 }
 
-func (m *Module) OnDailyReset() {
-	//
+// AddExp gives VIP experience to a player online on this server, raising its
+// level.
+func (m *Module) AddExp(uid uint64, exp int64) {
+	v := m.vipOf(uid)
+	if v == nil || exp <= 0 {
+		return
+	}
+	v.mu.Lock()
+	v.doc.Exp += exp
+	from := v.doc.Level
+	for int(v.doc.Level) < len(m.levels) && m.levels[v.doc.Level].Exp <= v.doc.Exp {
+		v.doc.Level++
+	}
+	to := v.doc.Level
+	v.mu.Unlock()
+	v.markDirty()
+	m.push(v)
+	for level := from + 1; level <= to; level++ {
+		levelUps.Get(levelLabels{Level: level}).Add(1)
+		eventbus.Publish(eventbus.Default, vipevent.LevelUp{PlayerId: uid, Level: level})
+	}
+}
+
+// Claim gives the rewards of the VIP levels reached by a player and not
+// claimed yet, all at once.
+func (m *Module) Claim(ctx context.Context, v *Vip) error {
+	v.mu.Lock()
+	from, to := v.doc.Claimed, v.doc.Level
+	if from >= to {
+		v.mu.Unlock()
+		return ErrNothingToClaim
+	}
+	var rewards []bag.Stack
+	for _, c := range m.levels[from:to] {
+		rewards = append(rewards, c.Rewards...)
+	}
+	if len(rewards) > 0 {
+		if _, err := v.player.GetBagSystem().Grant(ctx, reason, rewards); err != nil {
+			v.mu.Unlock()
+			return err
+		}
+	}
+	v.doc.Claimed = to
+	v.mu.Unlock()
+	v.markDirty()
+	m.push(v)
+	return nil
+}
+
+// Online registers the VIP level of a player that logged in, and pushes it.
+func (m *Module) Online(v *Vip) {
+	m.mu.Lock()
+	m.online[v.uid] = v
+	m.mu.Unlock()
+	m.push(v)
+}
+
+// Offline unregisters the VIP level of a player that logged out.
+func (m *Module) Offline(uid uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.online, uid)
+}
+
+func (m *Module) vipOf(uid uint64) *Vip {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.online[uid]
+}
+
+// push pushes the VIP level to its player.
+func (m *Module) push(v *Vip) {
+	v.mu.Lock()
+	doc := v.doc
+	v.mu.Unlock()
+	v.player.SendMsg(protocol.MessageId_SCVipUpdate, toProto(doc))
 }
 
-func (m *Module) OnRecharge() {
-	//add exp
+// Dependencies returns the modules the VIP module depends on: the rewards go
+// to the bags.
+func (m *Module) Dependencies() []string {
+	return []string{module.Module_Bag.String()}
 }
 
 func (m *Module) GetName() string {
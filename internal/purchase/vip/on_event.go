@@ -1,29 +1,29 @@
 package vip
 
 import (
+	eventbus "greatestworks/aop/event"
 	"greatestworks/internal"
 	"greatestworks/internal/note/event"
+	"greatestworks/internal/note/event/rechargeevent"
 )
 
-type EventHandle func(iEvent event.IEvent)
-
-type EventWrap struct {
-	Player
-	event.IEvent
-}
-
+// OnEvent is unused: the VIP levels subscribe to the event bus instead (see
+// subscribe).
 func (m *Module) OnEvent(c internal.Character, event event.IEvent) {
-
 }
 
 func (m *Module) SetEventCategoryActive(eventCategory int) {
-
 }
 
-func (m *Module) AddSubscriber(e event.IEvent, subscriber event.Subscriber) {
-
+// subscribe subscribes to the recharges, which give the VIP experience.
+func (m *Module) subscribe() {
+	eventbus.Subscribe(eventbus.Default, m.GetName(), func(e rechargeevent.Recharged) {
+		m.AddExp(e.PlayerId, e.Price/100*m.expPerYuan)
+	})
 }
 
-func (m *Module) Publish(iEvent event.IEvent) {
-
+// unsubscribe cancels the subscriptions of the module, once the events
+// already received are handled.
+func (m *Module) unsubscribe() {
+	eventbus.Default.UnsubscribeModule(m.GetName())
 }
@@ -0,0 +1,13 @@
+package vip
+
+import (
+	"greatestworks/internal/protocol"
+)
+
+func toProto(doc Doc) *protocol.SCVipUpdate {
+	return &protocol.SCVipUpdate{
+		Level:   doc.Level,
+		Exp:     doc.Exp,
+		Claimed: doc.Claimed,
+	}
+}
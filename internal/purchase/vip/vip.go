@@ -1,32 +1,66 @@
 package vip
 
+import (
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Doc 对应DB -> mongo
+type Doc struct {
+	Exp     int64  `bson:"exp"`     // 累计经验
+	Level   uint32 `bson:"level"`   // 等级
+	Claimed uint32 `bson:"claimed"` // 已领取奖励的等级数
+}
+
+// Vip is the VIP level of a player. It gains experience on the goroutines of
+// the event bus, and is claimed on the lane of the player, so it's guarded
+// by mu.
 type Vip struct {
-	Level uint32
-	Exp   uint32
+	uid       uint64
+	player    Owner
+	markDirty func()
+
+	mu  sync.Mutex
+	doc Doc // guarded by mu
 }
 
 func NewVip() *Vip {
-	return &Vip{
-		Level: 0,
-		Exp:   0,
-	}
+	return &Vip{}
 }
 
-func (v *Vip) Load() {
-	//TODO implement me
-	panic("implement me")
+// SetOwner sets the player the VIP level belongs to, and how it's marked
+// dirty.
+func (v *Vip) SetOwner(player Owner, uid uint64, markDirty func()) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.player = player
+	v.uid = uid
+	v.markDirty = markDirty
 }
 
-func (v *Vip) Save() {
-	//TODO implement me
-	panic("implement me")
+// Save returns a copy of the VIP level, to be stored.
+func (v *Vip) Save() (interface{}, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.doc, nil
 }
 
-func (v *Vip) AddExp(delta uint32) {
-	v.Exp += delta
-	//todo check can upgrade level
+// Load loads the stored VIP level.
+func (v *Vip) Load(raw bson.RawValue) error {
+	var doc Doc
+	if err := raw.Unmarshal(&doc); err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.doc = doc
+	return nil
 }
 
-func (v *Vip) getReward() {
-
+// Level returns the VIP level of the player.
+func (v *Vip) Level() uint32 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.doc.Level
 }
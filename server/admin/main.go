@@ -0,0 +1,39 @@
+// admin is the command line tool of the operations of the game, e.g.,
+// migrating the schemas of its databases before deploying the servers, or
+// restoring players from their archives.
+//
+// Usage:
+//
+//	admin migrate --mongo=mongodb://localhost:27017 status
+//	admin migrate --mongo=mongodb://localhost:27017 up
+//	admin player --mongo=mongodb://localhost:27017 snapshot 1001 "ticket 4321"
+//	admin player --write-behind restore 7302498130014208 player.bag
+package main
+
+import (
+	"context"
+	"flag"
+
+	"greatestworks/aop/migrate"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/tool"
+)
+
+func main() {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	mongoURI := flags.String("mongo", "mongodb://localhost:27017", "URI of MongoDB")
+	tool.Run("admin", map[string]*tool.Command{
+		"migrate": tool.MigrateCmd(&tool.MigrateSpec{
+			Tool:  "admin",
+			Flags: flags,
+			Sets: func(ctx context.Context) ([]*migrate.Set, error) {
+				if err := mongo.Init(ctx, mongo.Options{URI: *mongoURI}); err != nil {
+					return nil, err
+				}
+				db := mongo.Client.RealCli.Database((&mongo.PlayerDoc{}).DB())
+				return []*migrate.Set{mongo.Migrations(db)}, nil
+			},
+		}),
+		"player": playerCmd(),
+	})
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/redis"
+	"greatestworks/aop/tool"
+	"greatestworks/aop/writebehind"
+	"greatestworks/internal/communicate/player"
+)
+
+// playerSpec configures the player command.
+type playerSpec struct {
+	flags       *flag.FlagSet
+	mongoURI    string
+	redisAddrs  string
+	redisMode   string
+	writeBehind bool
+	shards      int
+	by          string
+	limit       int64
+}
+
+// playerCmd returns the command archiving the persisted state of players,
+// and restoring it.
+func playerCmd() *tool.Command {
+	s := &playerSpec{flags: flag.NewFlagSet("player", flag.ContinueOnError)}
+	s.flags.StringVar(&s.mongoURI, "mongo", "mongodb://localhost:27017", "URI of MongoDB")
+	s.flags.StringVar(&s.redisAddrs, "redis", ":7000,:7001,:7002,:7003,:7004,:7005", "Comma-separated addresses of Redis")
+	s.flags.StringVar(&s.redisMode, "redis-mode", redis.ModeCluster, "Mode of Redis: standalone, sentinel or cluster")
+	s.flags.BoolVar(&s.writeBehind, "write-behind", false, "Read and write the player documents through the write-behind cache, as the servers do")
+	s.flags.IntVar(&s.shards, "shards", 0, "Number of shards of the write-behind cache; the default if 0")
+	s.flags.StringVar(&s.by, "by", os.Getenv("USER"), "Operator recorded in the archives")
+	s.flags.Int64Var(&s.limit, "limit", 20, "Number of archives listed")
+	help := `Usage:
+  admin player [flags] snapshot <player id> [reason]
+  admin player [flags] list <player id>
+  admin player [flags] show <archive id>
+  admin player [flags] restore <archive id> [part...]
+
+Flags:
+  -h, --help	Print this help message.
+` + tool.FlagsHelp(s.flags) + `
+
+Description:
+  "snapshot" archives the persisted state of a player: its player document
+  and its documents of the modules (mailbox, friends, wallet, match rating).
+  "list" lists the archives of a player, most recent first, and "show" prints
+  an archive as extended JSON.
+
+  "restore" restores a player from an archive: all of it, or the parts
+  provided, e.g., "player.bag" for its bag, "player" for its whole player
+  document, or "wallet". The player must be offline. Its state is archived
+  first, and the id of this backup printed: restore it to undo the restore.
+
+  Use --write-behind if the servers write the player documents to the
+  write-behind cache, so that the changes not flushed yet are archived, and
+  the restores aren't overwritten by them.
+
+Examples:
+  admin player snapshot 1001 "ticket 4321: lost sword"
+  admin player list 1001
+  admin player restore 7302498130014208 player.bag`
+	return &tool.Command{
+		Name:        "player",
+		Description: "Archive and restore the state of players",
+		Help:        help,
+		Flags:       s.flags,
+		Fn:          s.run,
+	}
+}
+
+func (s *playerSpec) run(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: admin player snapshot|list|show|restore <id>")
+	}
+	action := args[0]
+	id, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("player: invalid id %q", args[1])
+	}
+	switch action {
+	case "snapshot", "list", "show", "restore":
+	default:
+		return fmt.Errorf("player: unknown action %q", action)
+	}
+
+	if err := mongo.Init(ctx, mongo.Options{URI: s.mongoURI}); err != nil {
+		return err
+	}
+	if err := redis.Init(ctx, redis.Options{Mode: s.redisMode, Addrs: strings.Split(s.redisAddrs, ",")}); err != nil {
+		return err
+	}
+	if s.writeBehind {
+		cache := player.EnableWriteBehind(redis.Get(), writebehind.Options{Shards: s.shards})
+		defer func() {
+			stopCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			if err := cache.Stop(stopCtx); err != nil {
+				fmt.Fprintln(os.Stderr, "player: flush write-behind cache:", err)
+			}
+		}()
+	}
+
+	switch action {
+	case "snapshot":
+		a, err := player.Snapshot(ctx, id, strings.Join(args[2:], " "), s.by)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("archive %d of player %d: %s\n", a.Id, id, strings.Join(player.ArchiveParts(a), " "))
+	case "list":
+		list, err := player.Archives(ctx, id, s.limit)
+		if err != nil {
+			return err
+		}
+		for _, a := range list {
+			line := fmt.Sprintf("%d %s by %s: %s", a.Id, time.Unix(a.Time, 0).Format("2006-01-02 15:04:05"), a.By, a.Reason)
+			if a.Restores != 0 {
+				line += fmt.Sprintf(" (backup before restoring %d)", a.Restores)
+			}
+			fmt.Println(line)
+		}
+	case "show":
+		a, err := player.LoadArchive(ctx, id)
+		if err != nil {
+			return err
+		}
+		b, err := bson.MarshalExtJSONIndent(a, false, false, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	case "restore":
+		backup, err := player.Restore(ctx, id, s.by, args[2:]...)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("restored player %d from archive %d; backup %d\n", backup.UId, id, backup.Id)
+	}
+	return nil
+}
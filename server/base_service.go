@@ -132,6 +132,13 @@ func (e *BaseService) serverStatus(ctx context.Context) error {
 	return <-errs
 }
 
+// ServeStatus runs the status server of the service, registered in the status
+// registry, e.g. for the gateways to discover and check the world servers.
+// It returns once ctx is done.
+func (e *BaseService) ServeStatus(ctx context.Context) error {
+	return e.serverStatus(ctx)
+}
+
 // Status implements the status.Server interface.
 func (e *BaseService) Status(ctx context.Context) (*status.Status, error) {
 	e.mu.Lock()
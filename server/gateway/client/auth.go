@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"greatestworks/aop/auth"
+	"greatestworks/aop/redis"
+	"greatestworks/server/gateway/server"
+)
+
+var (
+	errUser    = errors.New("token of another user")
+	errSession = errors.New("session replaced or over")
+	errBanned  = errors.New("user or device banned")
+	errBridged = errors.New("token of another session than the transport's")
+)
+
+// verifyToken verifies the session token a client sent for a user: signed by
+// the login server, not expired, of the current session of the user and of
+// the session its transport was handshaken with, if any, of a supported
+// protocol version, and neither the user nor its device banned since.
+func verifyToken(session *Session, userId uint64, token string) (*auth.Claims, error) {
+	claims, err := server.GetServer().Signer().Verify(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.Uid != userId {
+		return nil, errUser
+	}
+	if session.TransportSession != "" && session.TransportSession != claims.Session {
+		return nil, errBridged
+	}
+	if err := server.GetServer().Config.Global.Protocol.Check(claims.Version); err != nil {
+		return nil, err
+	}
+	if redis.CacheRedis().Get(context.TODO(), redis.MakeTokenKey(userId)).Val() != claims.Session {
+		return nil, errSession
+	}
+	if redis.CacheRedis().Get(context.TODO(), redis.MakeBanUserKey(userId)).Val() != "" {
+		return nil, errBanned
+	}
+	if claims.Device != "" && redis.CacheRedis().Exists(context.TODO(), redis.MakeBanDeviceKey(claims.Device)).Val() > 0 {
+		return nil, errBanned
+	}
+	return claims, nil
+}
@@ -2,11 +2,13 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/phuhao00/greatestworks-proto/chat"
 	"github.com/phuhao00/greatestworks-proto/gateway"
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"greatestworks/aop/logger"
 	"greatestworks/aop/redis"
+	common "greatestworks/server"
 	"greatestworks/server/gateway/server"
 	"greatestworks/server/gateway/world"
 	"sync"
@@ -152,6 +154,9 @@ func (m *Manager) ClientOnline(msg *gateway.ClientOnlineRet, srvID string) {
 		client.JoinWorldServerId.Store("")
 		client.IsBindWorldServer.Store(true)
 		client.WorldServerId.Store(worldServer.SessionId)
+		client.endTransfer(worldServer.SessionId)
+	} else {
+		client.transferFailed(worldServer.SessionId)
 	}
 
 	if msg.Reconnection {
@@ -183,16 +188,39 @@ func (m *Manager) ClientOnline(msg *gateway.ClientOnlineRet, srvID string) {
 	logger.Info("[ClientOnline] userID:%v isNew %v online:%v, clientIP: %v", msg.Userid, msg.IsNew, msg.ProcIndex, client.RemoteIp)
 }
 
+// RunTransfers transfers the players of this gateway whose world server asks
+// to (see common.TransferRequest).
+func (m *Manager) RunTransfers() {
+	sub := redis.CacheRedis().Subscribe(context.Background(), common.TransferChannel)
+	defer sub.Close()
+	for msg := range sub.Channel() {
+		req := common.TransferRequest{}
+		if err := json.Unmarshal([]byte(msg.Payload), &req); err != nil {
+			logger.Error("[RunTransfers] payload:%v err:%v", msg.Payload, err)
+			continue
+		}
+		if client := m.GetClientByUserID(req.UserID); client != nil {
+			client.startTransfer(req.To)
+		}
+	}
+}
+
 func (m *Manager) getPlayers() int32 {
 	return m.players
 }
 
+// WorldServerDisconnected fails the players of a world server that went down
+// over to the world servers they're routed to now (see Session.failover); the
+// ones that can't fail over are disconnected.
 func (m *Manager) WorldServerDisconnected(srvID, srvAddr string, zoneId int, proIndx uint32) {
 	m.clients.Range(func(k, v interface{}) bool {
 		client, ok := v.(*Session)
 		if ok && client.WorldServerId.Load().(string) == srvID {
 			if client.UserID != 0 {
 				logger.Info("[WorldServerDisconnected] srvAddr:%v zoneId:%v pidx:%v  uid:%v", srvAddr, zoneId, proIndx, client.UserID)
+				if client.failover(srvID) {
+					return true
+				}
 			}
 			GetMe().removeClient(client.ConnID)
 			GetMe().unbindUserID2Client(client.UserID, client)
@@ -8,6 +8,7 @@ import (
 	"github.com/phuhao00/network"
 	"greatestworks/aop/logger"
 	"greatestworks/aop/redis"
+	common "greatestworks/server"
 	"greatestworks/server/gateway/server"
 	"greatestworks/server/gateway/world"
 	"sync"
@@ -35,13 +36,19 @@ type Session struct {
 	DisconnectedMessages []interface{}     // 断线缓存消息
 	IsReconnection       atomic.Value      // bool
 	RemoteIp             string            // 对端IP
+	Protocol             string            // 接入协议：tcp、websocket、transport，收到第一个包时确定
+	TransportSession     string            // 压缩、加密传输握手的会话，只能登录该会话
+	ClientVersion        int               // 客户端协议版本(aop/protover)，登录时从token得到
 	LastPingTime         time.Time         // 上次ping的时间
 	LastCheckTime        int64             // 上次收到数据的时间点
 	RequestSpeed         int               // 平均上行流速
 	ReqFrequency         int               // 平均上行评率
 	MsgRegisterOnce      sync.Once         // 代理消息注册一次
 	ProcIndex            uint32            // 服id编号
+	transferFrom         string            // 转服中的源world server id, guarded by mu
+	transferMessages     [][]byte          // 转服期间缓存的消息, guarded by mu
 	mu                   sync.Mutex        // mutex
+	resolveOnce          sync.Once         // 确定对端IP和接入协议
 
 }
 
@@ -77,6 +84,7 @@ func (s *Session) registerForwardMsgHandler(msgID uint64, handler fuse.ForwardMe
 }
 
 func (s *Session) HandleMessage(data []byte) {
+	s.resolveOnce.Do(s.resolveRemote)
 	now := time.Now().Unix()
 	delta := int(now - s.LastCheckTime)
 
@@ -106,6 +114,10 @@ func (s *Session) HandleMessage(data []byte) {
 
 	msgID, err := s.LogicRouter.Route(data)
 	if err != nil && s.Verified() {
+		if s.holdDuringTransfer(data) {
+			s.LastPingTime = time.Now()
+			return
+		}
 		msgID, err = s.LogicRouter.ForwardRoute(s.WorldServerId.Load().(string), s.UserID, data)
 	}
 
@@ -123,6 +135,10 @@ func (s *Session) HandleMessage(data []byte) {
 
 // OnClose ...
 func (s *Session) OnClose() {
+	s.resolveOnce.Do(func() {}) // no packet: never counted
+	if s.Protocol != "" {
+		connections.Get(connLabels{Protocol: s.Protocol}).Sub(1)
+	}
 	if s.UserID == 0 {
 		GetMe().removeClient(s.ConnID)
 		GetMe().unbindUserID2Client(s.UserID, s)
@@ -265,6 +281,139 @@ func (s *Session) ClientLogout() {
 	s.sendLastMsg(messageId.MessageId_CSGatewayLogout, msgSend)
 }
 
+// startTransfer transfers the player from its world server to another one: the
+// messages of the client are held, and the world server is asked to hand the
+// player off (see TransferredOut).
+func (s *Session) startTransfer(to string) {
+	from := s.WorldServerId.Load().(string)
+	if from == "" || from == to || s.JoinWorldServerId.Load().(string) != "" {
+		logger.Warn("[startTransfer] UserID:%v online:%v joining:%v, can not transfer to:%v",
+			s.UserID, from, s.JoinWorldServerId.Load(), to)
+		return
+	}
+	if _, err := world.GetMe().GetEndpoint(to); err != nil {
+		logger.Error("[startTransfer] UserID:%v to:%v err:%v", s.UserID, to, err)
+		return
+	}
+	s.mu.Lock()
+	s.transferFrom = from
+	s.mu.Unlock()
+	s.JoinWorldServerId.Store(to)
+	s.WorldServerId.Store("")
+	s.sendClientOfflineMsg(common.OfflineTransfer, from)
+	logger.Info("[startTransfer] UserID:%v from:%v to:%v", s.UserID, from, to)
+}
+
+// TransferredOut is called once the world server the player is transferred
+// from handed it off, or failed to: the player joins the target world server,
+// or stays on its world server.
+func (s *Session) TransferredOut(ok bool, version string) {
+	s.mu.Lock()
+	from := s.transferFrom
+	s.mu.Unlock()
+	if from == "" {
+		return
+	}
+	if ok {
+		s.SendClientOnlineMsg(s.JoinWorldServerId.Load().(string), version, false)
+		return
+	}
+	s.JoinWorldServerId.Store("")
+	s.endTransfer(from)
+}
+
+// transferFailed is called when the target world server failed to resume the
+// player: the player rejoins the world server it's transferred from, which
+// resumes it from the state it handed off.
+func (s *Session) transferFailed(srvID string) {
+	s.mu.Lock()
+	from := s.transferFrom
+	if from == srvID {
+		// The player failed to rejoin its world server too.
+		logger.Error("[transferFailed] UserID:%v drop %v messages", s.UserID, len(s.transferMessages))
+		s.transferFrom = ""
+		s.transferMessages = nil
+	}
+	s.mu.Unlock()
+	if from == "" {
+		return
+	}
+	if from == srvID {
+		s.JoinWorldServerId.Store("")
+		return
+	}
+	if _, err := world.GetMe().GetEndpoint(from); err != nil {
+		// Failing over from a world server that's down.
+		logger.Error("[transferFailed] UserID:%v failover to:%v failed", s.UserID, srvID)
+		s.mu.Lock()
+		s.transferFrom = ""
+		s.transferMessages = nil
+		s.mu.Unlock()
+		s.clientDisConnection()
+		return
+	}
+	s.JoinWorldServerId.Store(from)
+	s.SendClientOnlineMsg(from, "", false)
+}
+
+// failover moves the player off a world server that went down, to the world
+// server its user id is routed to now, which resumes it from its saved data.
+// As during a transfer, the messages of the client are held until the player
+// joined, and the client is told it reconnected. It returns false if there's
+// no world server to fail over to.
+func (s *Session) failover(down string) bool {
+	to, ok := world.GetMe().Route(s.UserID)
+	if !ok {
+		logger.Error("[failover] UserID:%v online:%v no world server to fail over to", s.UserID, down)
+		return false
+	}
+	s.mu.Lock()
+	s.transferFrom = down
+	s.mu.Unlock()
+	s.WorldServerId.Store("")
+	s.IsBindWorldServer.Store(false)
+	s.JoinWorldServerId.Store(to)
+	s.IsReconnection.Store(true)
+	s.SendClientOnlineMsg(to, "", true)
+	logger.Info("[failover] UserID:%v from:%v to:%v", s.UserID, down, to)
+	return true
+}
+
+// holdDuringTransfer holds a message of the client while the player is
+// transferred, and returns whether it did.
+func (s *Session) holdDuringTransfer(data []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.transferFrom == "" {
+		return false
+	}
+	if int64(len(s.transferMessages)) >= server.GetServer().PriMsgBuffSize {
+		logger.Error("[holdDuringTransfer] UserID:%v 转服缓存消息已满", s.UserID)
+		return true
+	}
+	s.transferMessages = append(s.transferMessages, append([]byte(nil), data...))
+	return true
+}
+
+// endTransfer binds the player to a world server once its transfer ended, and
+// forwards it the messages held meanwhile, in order.
+func (s *Session) endTransfer(worldServerId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.transferFrom == "" {
+		return
+	}
+	s.WorldServerId.Store(worldServerId)
+	for _, data := range s.transferMessages {
+		if msgID, err := s.LogicRouter.ForwardRoute(worldServerId, s.UserID, data); err != nil {
+			logger.Error("[endTransfer] UserID:%v 消息:%v error: %v", s.UserID, msgID, err)
+		}
+	}
+	logger.Info("[endTransfer] UserID:%v online:%v 转服期间缓存的消息数量:%v", s.UserID, worldServerId, len(s.transferMessages))
+	s.transferFrom = ""
+	s.transferMessages = nil
+}
+
 func (s *Session) SetProcIndex(procIndex uint32) {
 	s.ProcIndex = procIndex
 }
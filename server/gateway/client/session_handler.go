@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"errors"
 	"github.com/phuhao00/fuse"
 	"github.com/phuhao00/greatestworks-proto/ErrCode"
 	"github.com/phuhao00/greatestworks-proto/gateway"
@@ -11,6 +12,7 @@ import (
 	"github.com/phuhao00/network"
 	"github.com/phuhao00/spoor/logger"
 	"google.golang.org/protobuf/proto"
+	"greatestworks/aop/auth"
 	"greatestworks/aop/redis"
 	"greatestworks/server/gateway/gm"
 	"greatestworks/server/gateway/server"
@@ -60,11 +62,10 @@ func loginHandler(packet *network.Packet, principal fuse.Principal) {
 		return
 	}
 
-	key := redis.MakeTokenKey(msg.Userid)
-	ret := redis.CacheRedis().Get(context.TODO(), key)
-	if msg.Token == "" || ret.Val() == "" || ret.Val() != msg.Token {
+	claims, err := verifyToken(session, msg.Userid, msg.Token)
+	if err != nil {
 		msgSend.ErrCode = uint32(ErrCode.ErrCode_gateway_verify)
-		logger.Error("[loginHandler] err token:%v userID:%v token:%v", ret.Val(), msg.Userid, msg.Token)
+		logger.Error("[loginHandler] err:%v userID:%v token:%v", err, msg.Userid, msg.Token)
 		session.sendMsg(messageId.MessageId_SCGatewayLogin, msgSend)
 		return
 	}
@@ -102,6 +103,9 @@ func loginHandler(packet *network.Packet, principal fuse.Principal) {
 		GetMe().removeClient(client.ConnID)
 	}
 	session.onUserVerify(msg.Userid)
+	session.ClientVersion = claims.Version
+	// The world adapts the messages of the player to its protocol version.
+	redis.CacheRedis().HSet(context.TODO(), accountKey, "Protocol", claims.Version)
 	msgSend.ErrCode = uint32(gateway.GatewayErr_Success)
 	session.sendMsg(messageId.MessageId_SCGatewayLogin, msgSend)
 	redis.CacheRedis().SAdd(context.TODO(), redis.MakeGatewayKey(server.GetServer().GetTcpAddress()), msg.Userid)
@@ -155,8 +159,8 @@ func joinOnlineHandler(packet *network.Packet, principal fuse.Principal) {
 	}
 
 	logger.Info("[joinOnlineHandler] 玩家:%v请求进入在线服务器:%v 快速进入:%v", session.UserID, msg.Sid, msg.Quick)
-	if msg.Quick {
-		if sid, ok := world.GetMe().GetOptimalOnline(); ok {
+	if msg.Quick || msg.Sid == "" {
+		if sid, ok := world.GetMe().Route(session.UserID); ok {
 			msg.Sid = sid
 		}
 	}
@@ -242,12 +246,10 @@ func reconnectionHandler(packet *network.Packet, principal fuse.Principal) {
 		return
 	}
 
-	key := redis.MakeTokenKey(msg.Userid)
-	ret := redis.CacheRedis().Get(context.TODO(), key)
-	if msg.Token == "" || ret.Val() == "" || ret.Val() != msg.Token {
+	if _, err := verifyToken(session, msg.Userid, msg.Token); err != nil {
 		msgSend.Ret = uint32(gateway.GatewayErr_Verify)
 		session.sendMsg(messageId.MessageId_SCReconnection, msgSend)
-		logger.Error("[reconnectionHandler] fail token:%v userID:%v token:%v", ret.Val(), msg.Userid, msg.Token)
+		logger.Error("[reconnectionHandler] fail err:%v userID:%v token:%v", err, msg.Userid, msg.Token)
 		return
 	}
 
@@ -303,10 +305,12 @@ func logoutHandler(packet *network.Packet, principal fuse.Principal) {
 		return
 	}
 
-	key := redis.MakeTokenKey(msg.UserId)
-	ret := redis.CacheRedis().Get(context.TODO(), key)
-	if msg.Token == "" || ret.Val() == "" || ret.Val() != msg.Token {
-		logger.Error("[logoutHandler] 登录验证失败 token:%v userID:%v token:%v", ret.Val(), msg.UserId, msg.Token)
+	if msg.UserId != session.UserID {
+		logger.Error("[logoutHandler] 登录验证失败 session userID:%v userID:%v", session.UserID, msg.UserId)
+		return
+	}
+	if _, err := verifyToken(session, msg.UserId, msg.Token); err != nil && !errors.Is(err, auth.ErrExpired) {
+		logger.Error("[logoutHandler] 登录验证失败 err:%v userID:%v token:%v", err, msg.UserId, msg.Token)
 		return
 	}
 
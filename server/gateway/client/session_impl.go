@@ -3,21 +3,46 @@ package client
 import (
 	"github.com/phuhao00/network"
 	"github.com/phuhao00/spoor/logger"
+	metrics "greatestworks/aop/metrics/impl"
+	"greatestworks/server/gateway/server"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// connections are the client connections of the gateway, by protocol.
+var connections = metrics.NewGaugeMap[connLabels](
+	"gateway_client_connections",
+	"Number of client connections to the gateway, by protocol, once they sent a packet",
+)
+
+type connLabels struct {
+	Protocol string // "tcp", "websocket" or "transport"
+}
+
 func (s *Session) OnConnect() {
 	GetMe().addClient(s.ConnID, s)
 	logger.Info("[OnConnect]  local:%s remote:%s ConnID:%v", s.LocalAddr(), s.RemoteAddr(), s.ConnID)
-	info := strings.Split(s.RemoteAddr().String(), ":")
-	if len(info) > 0 {
-		s.RemoteIp = info[0]
+}
+
+// resolveRemote sets the IP and the protocol of the client, when it sends its
+// first packet: the ones of the bridged client, if the connection is bridged
+// from the WebSocket or transport frontend, which stores it before it copies
+// anything, or else the ones of the connection.
+func (s *Session) resolveRemote() {
+	if c, ok := server.GetServer().Bridged(s.RemoteAddr().String()); ok {
+		s.RemoteIp, s.Protocol, s.TransportSession = c.IP, c.Protocol, c.Session
 	} else {
-		s.RemoteIp = s.RemoteAddr().String()
+		s.Protocol = "tcp"
+		info := strings.Split(s.RemoteAddr().String(), ":")
+		if len(info) > 0 {
+			s.RemoteIp = info[0]
+		} else {
+			s.RemoteIp = s.RemoteAddr().String()
+		}
 	}
+	connections.Get(connLabels{Protocol: s.Protocol}).Add(1)
 }
 
 func NewSession(session *network.TcpSession) network.ISession {
@@ -1,6 +1,12 @@
 package config
 
-import "greatestworks/aop/redis"
+import (
+	"time"
+
+	"greatestworks/aop/auth"
+	"greatestworks/aop/protover"
+	"greatestworks/aop/redis"
+)
 
 // GlobalConfig ...
 type GlobalConfig struct {
@@ -8,6 +14,18 @@ type GlobalConfig struct {
 	IsOpenNow         bool
 	ZoneId            int
 	RedisInfo         *redis.Config
+	Auth              *auth.Config     // 会话token签名配置，需与login一致
+	Discovery         *DiscoveryConfig // 通过status注册表发现和检查world服，空不开启
+	Protocol          protover.Policy  // 支持的客户端协议版本，需与login一致
+}
+
+// DiscoveryConfig 通过status注册表发现和检查world服
+type DiscoveryConfig struct {
+	Registry string        // 共享注册表地址，如redis://host:6379，空则用环境变量WEAVER_REGISTRY，都为空不开启
+	App      string        // world服在注册表里的应用名，默认world
+	Interval time.Duration // 检查间隔，默认5秒
+	Timeout  time.Duration // 单次检查超时，默认2秒
+	Failures int           // 连续失败多少次剔除，默认3
 }
 
 // ServerConfig ...
@@ -16,6 +34,10 @@ type ServerConfig struct {
 	PublicIP        string
 	Port            int
 	InnerPort       int
+	WsPort          int              // websocket端口，0不开启
+	WsPath          string           // websocket路径，默认/
+	WsOrigins       []string         // 允许的浏览器Origin，如https://h5.example.com，空不限制
+	Transport       *TransportConfig // 压缩、加密传输，空不开启
 	MaxConnNum      int
 	PriMsgBuffSize  string
 	PriConnBuffSize string
@@ -23,6 +45,14 @@ type ServerConfig struct {
 	PubConnBuffSize string
 }
 
+// TransportConfig 压缩、加密传输(aop/net/transport)，在单独的端口接入，桥接到TCP端口
+type TransportConfig struct {
+	Port        int    // 端口，0不开启
+	Compression string // 压缩算法：zlib、snappy，空不压缩
+	Threshold   int    // 超过多少字节才压缩，默认512
+	Encrypt     bool   // 是否用login下发的会话密钥加密(AES-GCM)
+}
+
 // LogConfig ...
 type LogConfig struct {
 	LogPath  string
@@ -2,9 +2,11 @@ package server
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"github.com/phuhao00/network"
 	"greatestworks/aop/logger"
+	"greatestworks/server/gateway/world"
 	"net"
 	"net/http"
 	"net/http/pprof"
@@ -55,6 +57,7 @@ func startHTTPServer(HTTPPort int, handler *HTTPHandler, TLSCertFile *string, TL
 
 func (hs *HTTPHandler) Register() {
 	hs.Router.HandleFunc("GET", "/health", HealthCheck)
+	hs.Router.HandleFunc("GET", "/debug/backends", backendsReport)
 
 }
 
@@ -85,6 +88,15 @@ func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// backendsReport reports the world servers connected to the gateway or in the
+// status registry, with their health.
+func backendsReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(world.GetMe().Backends()); err != nil {
+		logger.Error("[backendsReport] err:%v", err)
+	}
+}
+
 func setLogLevel(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json; charset=utf-8")
 	err := r.ParseForm()
@@ -5,6 +5,7 @@ import (
 	"github.com/phuhao00/broker/timerassistant"
 	_ "github.com/phuhao00/broker/timerassistant"
 	"github.com/phuhao00/network"
+	"greatestworks/aop/auth"
 	"greatestworks/aop/consul"
 	"greatestworks/aop/fn"
 	"greatestworks/aop/idgenerator"
@@ -14,6 +15,7 @@ import (
 	"greatestworks/server/gateway/client"
 	"greatestworks/server/gateway/config"
 	"greatestworks/server/gateway/gm"
+	"greatestworks/server/gateway/world"
 	"math/rand"
 	"runtime/debug"
 	"strconv"
@@ -49,6 +51,7 @@ type Server struct {
 	PriConnBuffSize int64
 	PubMsgBuffSize  int64
 	PubConnBuffSize int64
+	signer          *auth.Signer
 }
 
 var (
@@ -86,6 +89,12 @@ func (s *Server) GetDeploymentId() string {
 	return s.DeploymentId
 }
 
+// Signer returns the signer verifying the session tokens the login servers
+// issue.
+func (s *Server) Signer() *auth.Signer {
+	return s.signer
+}
+
 func (s *Server) GetTcpAddress() string {
 	return s.tcpAddr
 }
@@ -153,7 +162,12 @@ func (s *Server) Run() {
 	go s.tcpServer.Run()
 	go s.innerServer.Run()
 	startHTTPServer(s.httpPort, s.httpHandler, s.Config.HTTP.TLSCertFile, s.Config.HTTP.TLSKeyFile)
+	s.startWebSocketServer()
+	s.startTransportServer()
 	s.serviceRegister()
+	if s.Config.Global.Discovery != nil {
+		go world.GetMe().Discover(s.Ctx, *s.Config.Global.Discovery)
+	}
 
 	go func() {
 		tick := time.NewTicker(time.Second * 1)
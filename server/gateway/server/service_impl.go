@@ -1,6 +1,7 @@
 package server
 
 import (
+	"greatestworks/aop/auth"
 	"greatestworks/aop/fn"
 	"greatestworks/aop/logger"
 	"greatestworks/server/gateway/client"
@@ -12,6 +13,7 @@ import (
 )
 
 func (s *Server) Start() {
+	go client.GetMe().RunTransfers()
 	s.Run()
 }
 
@@ -25,6 +27,17 @@ func (s *Server) Init(cfg interface{}, processIdx int) {
 
 	s.runPath = fn.GetCurrentDirectory()
 
+	authConf := auth.Config{}
+	if configInstance.Global.Auth != nil {
+		authConf = *configInstance.Global.Auth
+	}
+	signer, err := auth.NewSigner(authConf)
+	if err != nil {
+		logger.Error("[Init] token signer error:%v", err)
+		panic(err)
+	}
+	s.signer = signer
+
 	s.processIdx = processIdx
 
 	sizeNums := fn.SplitStringToUint32Slice(configInstance.Server.PriMsgBuffSize, "*")
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"greatestworks/aop/logger"
+	"greatestworks/aop/net/transport"
+)
+
+// The transport frontend accepts the clients that compress or encrypt their
+// connections (aop/net/transport). Like a WebSocket connection, every
+// connection is bridged to a connection to the TCP listener once its
+// transport is handshaken with the token of a session, and its client may
+// only log in to that session.
+
+func (s *Server) startTransportServer() {
+	conf := s.Config.Server.Transport
+	if conf == nil || conf.Port <= 0 {
+		return
+	}
+	opts := transport.Options{Compression: conf.Compression, Threshold: conf.Threshold, Encrypt: conf.Encrypt}
+	port := conf.Port + s.processIdx
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		logger.Error("[startTransportServer] port:%v err:%v", port, err)
+		return
+	}
+	go func() {
+		<-s.Ctx.Done()
+		ln.Close()
+	}()
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				logger.Error("[startTransportServer] panic ", err, "\n", string(debug.Stack()))
+			}
+		}()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if s.Ctx.Err() == nil {
+					logger.Error("[startTransportServer] port:%v err:%v", port, err)
+				}
+				return
+			}
+			go s.bridgeTransport(conn, opts)
+		}
+	}()
+	logger.Info("[startTransportServer] Transport Listening on :%d compression:%q encrypt:%v", port, conf.Compression, conf.Encrypt)
+}
+
+// bridgeTransport handshakes the transport of a client, and bridges it to the
+// TCP listener. The token of the handshake is verified by its signature only:
+// the client's login checks the rest.
+func (s *Server) bridgeTransport(conn net.Conn, opts transport.Options) {
+	defer conn.Close()
+	var session string
+	tc, err := transport.Server(conn, opts, func(token string) ([]byte, error) {
+		claims, err := s.signer.Verify(token, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		session = claims.Session
+		return s.signer.SessionKey(token)
+	})
+	if err != nil {
+		logger.Warn("[bridgeTransport] remote:%v err:%v", conn.RemoteAddr(), err)
+		return
+	}
+	ip := conn.RemoteAddr().String()
+	if i := strings.LastIndexByte(ip, ':'); i >= 0 {
+		ip = ip[:i]
+	}
+	s.bridgeTo(tc, Bridged{IP: ip, Protocol: "transport", Session: session})
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"golang.org/x/net/websocket"
+	"greatestworks/aop/fn"
+	"greatestworks/aop/logger"
+)
+
+// The WebSocket frontend accepts the clients that can't open TCP connections
+// (e.g. browsers). Every WebSocket connection is bridged to a connection to
+// the TCP listener of the gateway: the client sends and receives the same
+// packets as over TCP, in binary frames, and its session is the same as a TCP
+// client's, authenticated at CSGatewayLogin.
+
+// bridges are the clients bridged to the TCP listener, by the local address
+// of their bridge, which is the remote address of their session.
+var bridges sync.Map
+
+// Bridged is a client bridged to the TCP listener.
+type Bridged struct {
+	IP       string
+	Protocol string // "websocket" or "transport"
+	Session  string // of the token of the transport handshake, if any: the client may only log in to that session
+}
+
+// Bridged returns the client bridged from a remote address of the TCP
+// listener, if it's a bridge. It's known by the time its session receives
+// its first packet.
+func (s *Server) Bridged(remoteAddr string) (Bridged, bool) {
+	c, ok := bridges.Load(remoteAddr)
+	if !ok {
+		return Bridged{}, false
+	}
+	return c.(Bridged), true
+}
+
+func (s *Server) startWebSocketServer() {
+	if s.Config.Server.WsPort <= 0 {
+		return
+	}
+	port := s.Config.Server.WsPort + s.processIdx
+	path := s.Config.Server.WsPath
+	if path == "" {
+		path = "/"
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, websocket.Server{Handler: s.bridge, Handshake: s.checkOrigin})
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux}
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				logger.Error("[startWebSocketServer] panic ", err, "\n", string(debug.Stack()))
+			}
+		}()
+		var err error
+		if s.Config.HTTP.TLSCertFile != nil && s.Config.HTTP.TLSKeyFile != nil {
+			err = srv.ListenAndServeTLS(*s.Config.HTTP.TLSCertFile, *s.Config.HTTP.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		logger.Error("[startWebSocketServer] port:%v err:%v", port, err)
+	}()
+	logger.Info("[startWebSocketServer] WebSocket Listening on :%d", port)
+}
+
+// bridge bridges a WebSocket client to the TCP listener.
+func (s *Server) bridge(ws *websocket.Conn) {
+	ws.PayloadType = websocket.BinaryFrame
+	defer ws.Close()
+	s.bridgeTo(ws, Bridged{IP: fn.ClientIP(ws.Request()), Protocol: "websocket"})
+}
+
+// checkOrigin accepts the WebSocket handshakes from the origins of
+// Server.WsOrigins, if any, so that other sites can't connect their visitors.
+func (s *Server) checkOrigin(_ *websocket.Config, r *http.Request) error {
+	origins := s.Config.Server.WsOrigins
+	if len(origins) == 0 {
+		return nil
+	}
+	origin := r.Header.Get("Origin")
+	for _, o := range origins {
+		if o == origin {
+			return nil
+		}
+	}
+	logger.Warn("[checkOrigin] origin:%q ip:%v not allowed", origin, fn.ClientIP(r))
+	return fmt.Errorf("origin %q not allowed", origin)
+}
+
+// bridgeTo copies the packets of a client to a connection to the TCP
+// listener, and back, until either side closes. Nothing is copied before the
+// client is stored, so that its session finds it by the time it receives its
+// first packet.
+func (s *Server) bridgeTo(client io.ReadWriter, c Bridged) {
+	conn, err := net.Dial("tcp", s.tcpServer.Addr)
+	if err != nil {
+		logger.Error("[bridge] dial tcp addr:%v err:%v", s.tcpServer.Addr, err)
+		return
+	}
+	defer conn.Close()
+	local := conn.LocalAddr().String()
+	bridges.Store(local, c)
+	defer bridges.Delete(local)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(conn, client)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, conn)
+		done <- struct{}{}
+	}()
+	<-done
+}
@@ -0,0 +1,197 @@
+package world
+
+import (
+	"context"
+	"os"
+	"sort"
+	"time"
+
+	"greatestworks/aop/logger"
+	"greatestworks/aop/status"
+	"greatestworks/server/gateway/config"
+)
+
+// backend is the state of a world server in the status registry.
+type backend struct {
+	addr     string // status server
+	failures int    // consecutive failed checks
+	ejected  bool
+	seen     bool // in the last listing of the registry
+}
+
+// Backend is the state of a world server, as seen by the gateway.
+type Backend struct {
+	SessionId  string
+	Registered bool // in the status registry
+	Connected  bool // registered to the gateway
+	Ejected    bool // failed its checks, or left the registry while connected
+	Failures   int
+	Players    int32
+	MaxPlayer  int32
+}
+
+// Discover keeps the world servers of the gateway in sync with the status
+// registry, until ctx is done.
+//
+// The world servers register there under their server id (see
+// server.BaseService.ServeStatus), and their status servers are checked
+// every conf.Interval. A world server whose checks fail conf.Failures times
+// in a row, or that leaves the registry, is ejected: no player is routed to
+// it, and if it's still connected to the gateway (e.g. it's wedged), its
+// connection is closed, so that its players fail over to other world
+// servers. It's readmitted once it passes a check. The world servers that
+// never registered aren't checked, and the ones registered but not connected
+// to the gateway are logged.
+func (m *Manager) Discover(ctx context.Context, conf config.DiscoveryConfig) {
+	addr := conf.Registry
+	if addr == "" {
+		addr = os.Getenv(status.RegistryEnvKey)
+	}
+	store, err := status.OpenRegistryStore(ctx, addr)
+	if err != nil || store == nil {
+		logger.Error("[Discover] registry:%v err:%v, world server discovery disabled", addr, err)
+		return
+	}
+	if conf.App == "" {
+		conf.App = "world"
+	}
+	if conf.Interval <= 0 {
+		conf.Interval = 5 * time.Second
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = 2 * time.Second
+	}
+	if conf.Failures <= 0 {
+		conf.Failures = 3
+	}
+
+	tick := time.NewTicker(conf.Interval)
+	defer tick.Stop()
+	for {
+		m.discover(ctx, store, conf)
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// discover lists the world servers of the registry, and checks them.
+func (m *Manager) discover(ctx context.Context, store status.RegistryStore, conf config.DiscoveryConfig) {
+	regs, err := store.List(ctx)
+	if err != nil {
+		// Keep the world servers as they are, rather than eject them all.
+		logger.Error("[discover] list registry err:%v", err)
+		return
+	}
+
+	m.mu.Lock()
+	for _, b := range m.backends {
+		b.seen = false
+	}
+	for _, reg := range regs {
+		if reg.App != conf.App {
+			continue
+		}
+		b := m.backends[reg.DeploymentId]
+		if b == nil {
+			b = &backend{}
+			m.backends[reg.DeploymentId] = b
+		}
+		b.addr, b.seen = reg.Addr, true
+	}
+	var gone []string
+	checks := make(map[string]string, len(m.backends))
+	for id, b := range m.backends {
+		if b.seen {
+			checks[id] = b.addr
+			continue
+		}
+		delete(m.backends, id)
+		if _, err := m.GetEndpoint(id); err == nil {
+			gone = append(gone, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, id := range gone {
+		m.eject(id, "left the registry")
+	}
+	for id, addr := range checks {
+		cctx, cancel := context.WithTimeout(ctx, conf.Timeout)
+		_, err := status.NewClient(addr).Status(cctx)
+		cancel()
+
+		m.mu.Lock()
+		b := m.backends[id]
+		if b == nil {
+			m.mu.Unlock()
+			continue
+		}
+		if err == nil {
+			if b.ejected {
+				logger.Info("[discover] world server:%v readmitted", id)
+			}
+			b.failures, b.ejected = 0, false
+			m.mu.Unlock()
+			if _, err := m.GetEndpoint(id); err != nil {
+				logger.Warn("[discover] world server:%v registered, but not connected to the gateway", id)
+			}
+			continue
+		}
+		b.failures++
+		eject := !b.ejected && b.failures >= conf.Failures
+		if eject {
+			b.ejected = true
+		}
+		m.mu.Unlock()
+		if eject {
+			m.eject(id, err.Error())
+		}
+	}
+}
+
+// eject closes the connection of a world server, if it's connected: its
+// players fail over to other world servers.
+func (m *Manager) eject(srvID, reason string) {
+	s, err := m.GetEndpoint(srvID)
+	if err != nil {
+		return
+	}
+	logger.Error("[eject] world server:%v addr:%v ejected: %v", srvID, s.serverAddr, reason)
+	s.Close()
+}
+
+// ejected returns whether a world server is ejected.
+func (m *Manager) ejected(srvID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := m.backends[srvID]
+	return b != nil && b.ejected
+}
+
+// Backends returns the world servers connected to the gateway or in the
+// status registry.
+func (m *Manager) Backends() []Backend {
+	byId := map[string]*Backend{}
+	for _, s := range m.GetSessionList() {
+		byId[s.SessionId] = &Backend{SessionId: s.SessionId, Connected: true, Players: s.Players, MaxPlayer: s.MaxPlayer}
+	}
+	m.mu.Lock()
+	for id, b := range m.backends {
+		if byId[id] == nil {
+			byId[id] = &Backend{SessionId: id}
+		}
+		byId[id].Registered, byId[id].Ejected, byId[id].Failures = true, b.ejected, b.failures
+	}
+	m.mu.Unlock()
+	backends := make([]Backend, 0, len(byId))
+	for _, b := range byId {
+		backends = append(backends, *b)
+	}
+	sort.Slice(backends, func(i, j int) bool {
+		return backends[i].SessionId < backends[j].SessionId
+	})
+	return backends
+}
@@ -21,11 +21,13 @@ var (
 
 type Manager struct {
 	Endpoints sync.Map
+	mu        sync.Mutex
+	backends  map[string]*backend // world servers in the status registry, by server id, guarded by mu
 }
 
 func GetMe() *Manager {
 	onceInit.Do(func() {
-		worldServerManager = &Manager{Endpoints: sync.Map{}}
+		worldServerManager = &Manager{Endpoints: sync.Map{}, backends: map[string]*backend{}}
 	})
 	return worldServerManager
 }
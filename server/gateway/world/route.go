@@ -0,0 +1,47 @@
+package world
+
+import (
+	"hash/fnv"
+	"math"
+	"strconv"
+)
+
+// Route returns the world server a player is routed to: the one with the
+// highest rendezvous score for its user id, weighted by the max players of
+// the world servers, among the ones that aren't full nor ejected (see
+// Discover). A player is routed to the same world server every time, as long
+// as it's up, and only the players of a world server that goes down are
+// routed elsewhere.
+func (m *Manager) Route(userID uint64) (string, bool) {
+	key := strconv.FormatUint(userID, 10)
+	var best string
+	bestScore := math.Inf(-1)
+	for _, s := range m.GetSessionList() {
+		if s.MaxPlayer <= 0 || s.Players >= s.MaxPlayer || m.ejected(s.SessionId) {
+			continue
+		}
+		if score := score(key, s.SessionId, s.MaxPlayer); score > bestScore {
+			best, bestScore = s.SessionId, score
+		}
+	}
+	return best, best != ""
+}
+
+// score returns the weighted rendezvous score of a world server for a key.
+func score(key, srvID string, weight int32) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(srvID))
+
+	// Mix the bits of the hash, and map it to a uniform value in (0, 1), as
+	// in aop/proxy.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	u := (float64(x>>11) + 0.5) / (1 << 53)
+	return -float64(weight) / math.Log(u)
+}
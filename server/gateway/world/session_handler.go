@@ -6,6 +6,7 @@ import (
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"github.com/phuhao00/greatestworks-proto/server_common"
 	"greatestworks/aop/logger"
+	common "greatestworks/server"
 	"greatestworks/server/gateway/client"
 	"greatestworks/server/gateway/server"
 	"reflect"
@@ -101,6 +102,13 @@ func (s *Session) clientOfflineHandler(msgID uint64, data []byte) {
 		logger.Error("[clientOnlineHandler] receive data:%v, msg:%v", data, reflect.TypeOf(msg))
 		return
 	}
+	if msg.OpType == common.OfflineTransfer {
+		s.Players = msg.GetPlayers()
+		if clientInstance := client.GetMe().GetClientByUserID(msg.Userid); clientInstance != nil {
+			clientInstance.TransferredOut(msg.Result == 1, msg.Version)
+		}
+		return
+	}
 	if msg.Result != 1 {
 		logger.Error("[clientOnlineHandler] offline error result:%v ,clientID:%v", msg.Result, msg.Userid)
 		return
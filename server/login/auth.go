@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+	"greatestworks/aop/auth"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/mongo"
+	"greatestworks/internal/note/rediskey"
+	"greatestworks/server/login/config"
+)
+
+var (
+	errPassword = errors.New("wrong account or password")
+	errToken    = errors.New("third party token rejected")
+)
+
+// Backend verifies the credentials of an account at Login. The backends are
+// picked by name with config.Auth.Backend.
+type Backend interface {
+	// Verify returns the account the credentials are of, and whether it was
+	// just registered, or errPassword or errToken if they're wrong.
+	Verify(ctx context.Context, acc *config.AccountData) (*mongo.Account, bool, error)
+}
+
+var (
+	backendsMu sync.Mutex
+	backends   = map[string]Backend{
+		"password":   passwordBackend{},
+		"thirdparty": thirdPartyBackend{client: &http.Client{Timeout: 5 * time.Second}},
+	}
+)
+
+// RegisterBackend registers a backend, e.g. for a channel's SDK.
+func RegisterBackend(name string, b Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[name] = b
+}
+
+func authConf() *config.Auth {
+	if conf := GetServer().Conf.Auth; conf != nil {
+		return conf
+	}
+	return &config.Auth{}
+}
+
+func backend() (Backend, error) {
+	name := authConf().Backend
+	if name == "" {
+		name = "password"
+		if GetServer().Conf.WhiteList.TokenCheck {
+			name = "thirdparty"
+		}
+	}
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if b := backends[name]; b != nil {
+		return b, nil
+	}
+	return nil, fmt.Errorf("auth backend %q not found", name)
+}
+
+var (
+	signerOnce sync.Once
+	signer     *auth.Signer
+	signerErr  error
+)
+
+func tokenSigner() (*auth.Signer, error) {
+	signerOnce.Do(func() {
+		signer, signerErr = auth.NewSigner(authConf().Token)
+	})
+	return signer, signerErr
+}
+
+// sessionKey returns the key encrypting the gateway connections of the
+// session of a token (see aop/net/transport), in base64.
+func sessionKey(token string) string {
+	s, err := tokenSigner()
+	if err != nil {
+		return ""
+	}
+	key, err := s.SessionKey(token)
+	if err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func accounts() *mongodriver.Collection {
+	doc := &mongo.Account{}
+	return mongo.Client.RealCli.Database(doc.DB()).Collection(doc.C())
+}
+
+// findAccount returns an account by name, nil if there's none.
+func findAccount(ctx context.Context, name string) (*mongo.Account, error) {
+	doc := &mongo.Account{}
+	err := accounts().FindOne(ctx, bson.M{"account": name}).Decode(doc)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load account %q: %w", name, err)
+	}
+	return doc, nil
+}
+
+// registerAccount returns an account by name, registering it with a user
+// id if there's none, and whether it did.
+func registerAccount(ctx context.Context, name string, password []byte) (*mongo.Account, bool, error) {
+	uid, err := idgenerator.NextId()
+	if err != nil {
+		return nil, false, err
+	}
+	doc := &mongo.Account{Id: uid, Account: name, Password: password, Time: time.Now().Unix()}
+	err = accounts().FindOneAndUpdate(ctx,
+		bson.M{"account": name},
+		bson.M{"$setOnInsert": doc},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)).Decode(&mongo.Account{})
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return doc, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("register account %q: %w", name, err)
+	}
+	// Registered concurrently.
+	doc, err = findAccount(ctx, name)
+	return doc, false, err
+}
+
+// passwordBackend verifies accounts with their passwords, hashed with
+// bcrypt. Unknown accounts are registered if config.Auth.Register.
+type passwordBackend struct{}
+
+func (passwordBackend) Verify(ctx context.Context, acc *config.AccountData) (*mongo.Account, bool, error) {
+	if acc.Password == "" {
+		return nil, false, errPassword
+	}
+	doc, err := findAccount(ctx, acc.Account)
+	if err != nil {
+		return nil, false, err
+	}
+	if doc == nil {
+		if !authConf().Register {
+			return nil, false, errPassword
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(acc.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, false, err
+		}
+		var created bool
+		if doc, created, err = registerAccount(ctx, acc.Account, hash); err != nil || created {
+			return doc, created, err
+		}
+	}
+	if bcrypt.CompareHashAndPassword(doc.Password, []byte(acc.Password)) != nil {
+		return nil, false, errPassword
+	}
+	return doc, false, nil
+}
+
+// thirdPartyBackend verifies the token a third party (e.g. a channel's SDK)
+// gave the client, at config.WhiteList.TokenCheckURL. The accounts are named
+// "thirdparty:<id of the user at the third party>", and registered at their
+// first login.
+type thirdPartyBackend struct {
+	client *http.Client
+}
+
+type thirdPartyRequest struct {
+	Account string `json:"account"`
+	Sid     string `json:"sid"`
+	Token   string `json:"token"`
+}
+
+type thirdPartyResponse struct {
+	Code   int    `json:"code"` // 0 if the token is valid
+	OpenId string `json:"openId"`
+}
+
+func (b thirdPartyBackend) Verify(ctx context.Context, acc *config.AccountData) (*mongo.Account, bool, error) {
+	if acc.Token == "" || acc.Sid == "" {
+		return nil, false, errToken
+	}
+	body, err := json.Marshal(thirdPartyRequest{Account: acc.Account, Sid: acc.Sid, Token: acc.Token})
+	if err != nil {
+		return nil, false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, GetServer().Conf.WhiteList.TokenCheckURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("third party token check: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("third party token check: %s", resp.Status)
+	}
+	var ret thirdPartyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ret); err != nil {
+		return nil, false, fmt.Errorf("third party token check: %w", err)
+	}
+	if ret.Code != 0 || ret.OpenId == "" {
+		return nil, false, errToken
+	}
+	return registerAccount(ctx, "thirdparty:"+ret.OpenId, nil)
+}
+
+// startSession issues a token for a new session of an account, on a client of
+// a protocol version, which replaces its previous one: the gateways only
+// accept the tokens of the current session of a user.
+func startSession(ctx context.Context, acc *mongo.Account, device string, version int) (string, *auth.Claims, error) {
+	s, err := tokenSigner()
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	token, claims, err := s.Issue(acc.Id, acc.Account, device, version, now)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := redisCluster.Set(ctx, rediskey.MakeTokenKey(acc.Id), claims.Session, s.SessionEnd(claims).Sub(now)).Err(); err != nil {
+		return "", nil, err
+	}
+	accounts().UpdateOne(ctx, bson.M{"_id": acc.Id}, bson.M{"$set": bson.M{"device": device, "loginTime": now.Unix()}})
+	return token, claims, nil
+}
+
+// Refresh is the HTTP handler refreshing the token of a session, before or
+// after it expires, until the session ends or is replaced by another login.
+func Refresh(w http.ResponseWriter, r *http.Request) {
+	ret := &config.RefreshData{Result: config.Succ}
+	defer returnHandler(w, r, ret)
+	if r.Body == nil {
+		ret.Result = config.UnknownErr
+		return
+	}
+	var req config.RefreshData
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ret.Result = config.DecodeErr
+		return
+	}
+	s, err := tokenSigner()
+	if err != nil {
+		ret.Result = config.UnknownErr
+		return
+	}
+	now := time.Now()
+	token, claims, err := s.Refresh(req.Token, now)
+	if err != nil {
+		ret.Result = config.VerifyTokenErr
+		return
+	}
+	key := rediskey.MakeTokenKey(claims.Uid)
+	if redisCluster.Get(r.Context(), key).Val() != claims.Session {
+		ret.Result = config.VerifyTokenErr
+		return
+	}
+	if checkBanUser(claims.Uid) {
+		ret.Result = config.BanUserErr
+		return
+	}
+	if checkBanDevice(claims.Device) {
+		ret.Result = config.BanMacLoginErr
+		return
+	}
+	ret.Token = token
+	ret.ExpireAt = claims.Expires
+	ret.SessionKey = sessionKey(token)
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"time"
+
+	"greatestworks/aop/auth"
+	"greatestworks/aop/protover"
+)
+
+// Auth 登录验证配置
+type Auth struct {
+	Token       auth.Config     // 会话token签名配置，需与gateway一致
+	Backend     string          // 账号验证方式：password 账号密码，thirdparty 第三方token；为空时开启WhiteList.TokenCheck则为thirdparty，否则为password
+	Register    bool            // password方式下，账号不存在时自动注册
+	IPLimit     Limit           // 每个IP的登录频率限制
+	DeviceLimit Limit           // 每台设备的登录频率限制
+	Protocol    protover.Policy // 支持的客户端协议版本，不支持的客户端登录时提示升级；需与gateway一致
+}
+
+// Limit 频率限制：Window内最多Count次，Count为0不限
+type Limit struct {
+	Count  int64
+	Window time.Duration
+}
@@ -1,6 +1,9 @@
 package config
 
-import jsoniter "github.com/json-iterator/go"
+import (
+	jsoniter "github.com/json-iterator/go"
+	"greatestworks/aop/idgenerator"
+)
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
@@ -13,10 +16,12 @@ type Config struct {
 	RabbitMq   *RabbitMq
 	WhiteList  *WhiteList
 	ThirdParty *ThirdParty
+	Auth       *Auth
 	Me         *Me
 	Consul     *Consul
 	Etcd       *Etcd
 	GateWays   []*GateWay
+	IdGen      *idgenerator.Config // 唯一ID生成(玩家id)，nil则按本机IP取worker id
 }
 
 func Deserialize(str string) *Config {
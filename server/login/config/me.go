@@ -1,7 +1,5 @@
 package config
 
-import "time"
-
 type Me struct {
 	Name                 string
 	HTTPAddr             string  `json:"http_addr"`
@@ -103,9 +101,8 @@ const (
 	OnlineError    = 10 // 指定的online不存在
 	BanMacLoginErr = 11 // 封Mac登录
 	BanMacChatErr  = 12 // 封Mac聊天
-)
-const (
-	TokenExpireDuration = 24 * time.Hour
+	LoginThrottled = 13 // 登录过于频繁
+	ProtocolErr    = 14 // 客户端协议版本不支持，需升级时返回Upgrade
 )
 
 type AccountData struct {
@@ -115,6 +112,17 @@ type AccountData struct {
 	Sign     string
 	Sid      string
 	Token    string
+	Device   string // 设备号(Mac)，用于封设备和登录限频
+	Build    string // 客户端版本号，记日志
+	Protocol int    // 客户端协议版本(aop/protover)，未发送为0
+}
+
+// RefreshData 刷新token的请求和结果
+type RefreshData struct {
+	Result     int32
+	Token      string
+	ExpireAt   int64  // token过期时间，秒
+	SessionKey string // 会话密钥(base64)，用于加密gateway连接，轮换密钥后可能变化
 }
 
 type LimitInfo struct {
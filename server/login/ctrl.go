@@ -113,6 +113,34 @@ func checkBanUser(userid uint64) bool {
 	return false
 }
 
+func checkBanDevice(device string) bool {
+	if device == "" {
+		return false
+	}
+	return redisCluster.Exists(context.TODO(), rediskey2.MakeBanDeviceKey(device)).Val() > 0
+}
+
+// checkLoginLimit counts a login of an IP or a device (kind "ip" or
+// "device"), and returns whether it's within the limit.
+func checkLoginLimit(kind, id string, limit config.Limit) bool {
+	if id == "" || limit.Count <= 0 || limit.Window <= 0 {
+		return true
+	}
+	window := int64(limit.Window / time.Second)
+	if window <= 0 {
+		window = 1
+	}
+	key := rediskey2.MakeLoginLimitKey(kind, id, time.Now().Unix()/window*window)
+	cnt, err := redisCluster.Incr(context.TODO(), key).Result()
+	if err != nil {
+		return true
+	}
+	if cnt == 1 {
+		redisCluster.Expire(context.TODO(), key, time.Duration(window)*time.Second)
+	}
+	return cnt <= limit.Count
+}
+
 func checkInWhiteList(account string) bool {
 	return redisCluster.SIsMember(context.TODO(), "WhiteList", account).Val()
 }
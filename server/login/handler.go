@@ -3,8 +3,11 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	loginpb "github.com/phuhao00/greatestworks-proto/login"
 	"greatestworks/aop/fn"
+	"greatestworks/aop/logger"
+	"greatestworks/aop/protover"
 	"greatestworks/internal/note/rediskey"
 	"greatestworks/server/login/config"
 	"net/http"
@@ -39,9 +42,28 @@ func returnHandler(w http.ResponseWriter, r *http.Request, data interface{}) {
 	}
 }
 
+// loginReply is the reply of Login: the login data, the key encrypting the
+// gateway connections of the session, and why to upgrade the client if its
+// protocol isn't supported.
+type loginReply struct {
+	*loginpb.LoginData
+	SessionKey string   `json:",omitempty"` // base64
+	Upgrade    *upgrade `json:",omitempty"`
+}
+
+// upgrade tells the player of a client whose protocol isn't supported to
+// upgrade it.
+type upgrade struct {
+	Message string
+	URL     string
+	Min     int // oldest protocol version supported
+	Current int // protocol version of the servers
+}
+
 func Login(w http.ResponseWriter, r *http.Request) {
 	loginInfo := &loginpb.LoginData{Result: config.Succ}
-	defer returnHandler(w, r, loginInfo)
+	reply := &loginReply{LoginData: loginInfo}
+	defer returnHandler(w, r, reply)
 	loginInfo.ServerTime = time.Now().Unix()
 	loginInfo.RegRegTimeStart = config.StartPreRegisterTime
 	loginInfo.RegRegTimeEnd = config.EndPreRegisterTime
@@ -61,7 +83,6 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(accData.Account) == 0 ||
-		len(accData.Password) == 0 ||
 		len(accData.Sign) == 0 {
 		loginInfo.Result = config.UnknownErr
 		return
@@ -72,6 +93,28 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var incompatible *protover.IncompatibleError
+	if err := authConf().Protocol.Check(accData.Protocol); errors.As(err, &incompatible) {
+		loginInfo.Result = config.ProtocolErr
+		if incompatible.Upgrade() {
+			p := incompatible.Policy
+			reply.Upgrade = &upgrade{Message: p.Message, URL: p.URL, Min: p.Min, Current: p.Current}
+		}
+		logger.Info("[Login] account:%v build:%v protocol:%v rejected: %v", accData.Account, accData.Build, accData.Protocol, err)
+		return
+	}
+
+	if !checkLoginLimit("ip", fn.ClientIP(r), authConf().IPLimit) ||
+		!checkLoginLimit("device", accData.Device, authConf().DeviceLimit) {
+		loginInfo.Result = config.LoginThrottled
+		return
+	}
+
+	if checkBanDevice(accData.Device) {
+		loginInfo.Result = config.BanMacLoginErr
+		return
+	}
+
 	if GetServer().Conf.WhiteList.Check && !checkInWhiteList(accData.Account) {
 		loginInfo.Result = config.WhiteListErr
 		return
@@ -85,25 +128,36 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if GetServer().Conf.WhiteList.TokenCheck {
-		var ltToken, ltSid string
-		if len(accData.Token) > 0 && len(accData.Sid) > 0 {
-			ltToken = accData.Token
-			ltSid = accData.Sid
-		} else {
-			ltSid, ltToken = GetToken()
-		}
-		if len(ltToken) > 0 && len(ltSid) > 0 {
-			//todo third party token check
-		}
-	}
-
 	if !dailyRegCtrl.checkDailyRegCntLimit("channel") {
 		loginInfo.Result = config.DailyIncrOver
 		return
 	}
-	//todo db check exist
-	dailyRegCtrl.increaseDailyRegCnt("channel", 1)
+
+	b, err := backend()
+	if err != nil {
+		loginInfo.Result = config.UnknownErr
+		return
+	}
+	account, registered, err := b.Verify(r.Context(), &accData)
+	switch {
+	case errors.Is(err, errPassword):
+		loginInfo.Result = config.PassWdErr
+		return
+	case errors.Is(err, errToken):
+		loginInfo.Result = config.VerifyTokenErr
+		return
+	case err != nil:
+		loginInfo.Result = config.UnknownErr
+		return
+	}
+	if registered {
+		dailyRegCtrl.increaseDailyRegCnt("channel", 1)
+	}
+
+	if checkBanUser(account.Id) {
+		loginInfo.Result = config.BanUserErr
+		return
+	}
 
 	config.GetIdxByLimitInfo(nil, 0)
 
@@ -115,9 +169,7 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	loginInfo.UserID = 0
-	loginInfo.Token = ""
-	loginInfo.SessionID = loginInfo.Token
+	loginInfo.UserID = account.Id
 	if accData.ZoneId == 0 {
 		loginInfo.ZoneId = int32(GetZoneManager().recommendZone())
 	} else {
@@ -141,11 +193,17 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		loginInfo.RecommendWorld = rList
 
 		loginInfo.WorldList = GetZoneManager().GetZoneOnlineList(int(loginInfo.ZoneId))
+		token, claims, err := startSession(r.Context(), account, accData.Device, accData.Protocol)
+		if err != nil {
+			loginInfo.Result = config.UnknownErr
+			return
+		}
+		loginInfo.Token = token
+		loginInfo.SessionID = claims.Session
+		reply.SessionKey = sessionKey(token)
 		loginInfo.Result = config.Succ
-		tokenKey := rediskey.MakeTokenKey(loginInfo.UserID)
-		redisCluster.Set(context.TODO(), tokenKey, loginInfo.Token, config.TokenExpireDuration)
 		if inner {
-			key := rediskey.MakeAccountKey(0) //todo
+			key := rediskey.MakeAccountKey(int64(loginInfo.UserID))
 			redisCluster.HSet(context.TODO(), key, "AccountRobot", accData.Account)
 		}
 	} else {
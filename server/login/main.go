@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+
 	"greatestworks/aop/consul"
+	"greatestworks/aop/idgenerator"
 	"greatestworks/aop/logger"
+	"greatestworks/aop/redis"
 	"greatestworks/server/login/config"
 )
 
@@ -20,6 +24,12 @@ func main() {
 	//todo name mod init
 	//todo nsq init
 	//todo redis init
+	if cfg.IdGen != nil {
+		if err := idgenerator.Setup(context.Background(), *cfg.IdGen, redis.Get()); err != nil {
+			logger.Fatal("[main] login id generator err:%v", err)
+			return
+		}
+	}
 	//todo dirty filter init
 	//todo token load
 
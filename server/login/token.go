@@ -1,7 +0,0 @@
-package main
-
-//token 生成规则
-
-func GetToken() (id, token string) {
-	return "", ""
-}
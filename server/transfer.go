@@ -0,0 +1,20 @@
+package server
+
+// OpTypes of the ClientOffline messages sent by gateways to world servers, and
+// echoed by their ClientOfflineRet replies.
+const (
+	OfflineLogout   = 1 // the client left
+	OfflineSwitch   = 2 // the client joins another world server
+	OfflineTransfer = 3 // the player is handed off to another world server, along with its state
+)
+
+// TransferChannel is the redis channel on which world servers ask gateways to
+// transfer players (see TransferRequest).
+const TransferChannel = "player:transfer"
+
+// TransferRequest asks the gateway of a player to transfer it from its world
+// server to another one.
+type TransferRequest struct {
+	UserID uint64 `json:"uid"`
+	To     string `json:"to"` // id of the target world server
+}
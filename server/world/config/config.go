@@ -1,6 +1,17 @@
 package config
 
-import "greatestworks/aop/redis"
+import (
+	"time"
+
+	"greatestworks/aop/dlock"
+	"greatestworks/aop/idgenerator"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/net/flood"
+	"greatestworks/aop/redis"
+	"greatestworks/aop/saga"
+	"greatestworks/aop/tick"
+	"greatestworks/aop/writebehind"
+)
 
 type Config struct {
 	MaxPlayerNum int32
@@ -13,6 +24,14 @@ type Config struct {
 	RpcServer    *RpcConfig
 	Stat         *StatConfig
 	Settings     *SettingsConfig
+	Flood        *flood.Options       // 客户端消息限流，nil则用默认值
+	Tick         *tick.Options        // 帧调度，nil则用默认值(20帧/秒)
+	IdGen        *idgenerator.Config  // 唯一ID生成，nil则按本机IP取worker id
+	Redis        *redis.Options       // Redis客户端(单机、哨兵或集群)，nil则连本机7000-7005端口的集群
+	Mongo        *mongo.Options       // MongoDB连接池，nil则连本机27017端口
+	WriteBehind  *writebehind.Options // 玩家存档先写Redis再批量落库，nil则直接写库
+	Lock         *dlock.Options       // 跨服的分布式锁(Redis)，nil则用默认值
+	Saga         *saga.Options        // 跨模块事务的补偿和幂等记录(Redis)，nil则用默认值
 }
 
 type Global struct {
@@ -64,7 +83,9 @@ type HTTPConfig struct {
 // RpcConfig ...
 type RpcConfig struct {
 	RpcIp   string
-	RpcPort int
+	RpcPort int                 // 对其他进程提供本进程模块的rpc, 0则不提供
+	Nodes   map[string][]string // 其他进程上模块的rpc地址, 按模块名; 未配置的模块在本进程调用
+	Timeout time.Duration       // 没有deadline的调用的超时(含重试), 默认3秒
 }
 
 // StatConfig ...
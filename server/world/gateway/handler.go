@@ -1,12 +1,15 @@
 package gateway
 
 import (
+	"context"
 	"github.com/phuhao00/greatestworks-proto/gateway"
 	"github.com/phuhao00/greatestworks-proto/messageId"
 	"github.com/phuhao00/greatestworks-proto/player"
 	"github.com/phuhao00/greatestworks-proto/server_common"
 	"greatestworks/aop/logger"
+	common "greatestworks/server"
 	"greatestworks/server/world/server"
+	"time"
 )
 
 // registerToGatewayHandler ...
@@ -102,6 +105,10 @@ func (c *Client) clientOfflineHandler(msgID uint64, data []byte) {
 	if err != nil {
 		logger.Error("receive data:%v msg:%v", data, msg)
 	}
+	if msg.OpType == common.OfflineTransfer {
+		go c.transferOut(msg.Userid)
+		return
+	}
 	pData := &player.PlayerData{
 		PlayerID:  msg.Userid,
 		GatewayID: c.serverID,
@@ -110,3 +117,22 @@ func (c *Client) clientOfflineHandler(msgID uint64, data []byte) {
 	logger.Info("[clientOfflineHandler] player offline UserId:%v下线消息 通知 world goroutine", msg.Userid)
 	server.Oasis.ChanPlayerOffline <- pData
 }
+
+// transferOut hands a player off to the world server it's transferred to, and
+// tells the gateway whether it was.
+func (c *Client) transferOut(userID uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	msgSend := &gateway.ClientOfflineRet{
+		Result: 1,
+		Userid: userID,
+		OpType: common.OfflineTransfer,
+	}
+	if err := server.Oasis.TransferOut(ctx, userID); err != nil {
+		logger.Error("[transferOut] UserId:%v err:%v", userID, err)
+		msgSend.Result = 0
+	}
+	msgSend.Players = int32(server.Oasis.GetPlayersNum())
+	c.sendMsg(messageId.MessageId_ClientOfflineRet, msgSend)
+	logger.Info("[transferOut] UserId:%v result:%v", userID, msgSend.Result)
+}
@@ -9,23 +9,13 @@ import (
 	logicPlayer "greatestworks/internal/communicate/player"
 )
 
-func (w *World) CreatePlayer(message *network.Packet) {
-	msg := &player.CSCreateUser{}
-	err := proto.Unmarshal(message.Msg.Data, msg)
-	if err != nil {
-		return
-	}
+func (w *World) CreatePlayer(message *network.Packet, msg *player.CSCreateUser) {
 	fmt.Println("[World.CreatePlayer]", msg)
 	w.SendMsg(uint64(messageId.MessageId_SCCreatePlayer), &player.SCCreateUser{}, message.Conn)
 
 }
 
-func (w *World) UserLogin(message *network.Packet) {
-	msg := &player.CSLogin{}
-	err := proto.Unmarshal(message.Msg.Data, msg)
-	if err != nil {
-		return
-	}
+func (w *World) UserLogin(message *network.Packet, msg *player.CSLogin) {
 	newPlayer := logicPlayer.NewPlayer()
 	newPlayer.UId = 111
 	newPlayer.Session = message.Conn
@@ -2,9 +2,15 @@ package server
 
 import (
 	"github.com/phuhao00/greatestworks-proto/messageId"
+	"greatestworks/aop/msgrouter"
 )
 
-func (w *World) HandlerRegister() {
-	w.Handlers[messageId.MessageId_CSCreatePlayer] = w.CreatePlayer
-	w.Handlers[messageId.MessageId_CSLogin] = w.UserLogin
+// HandlerRegister registers the handlers of the messages the world server
+// handles itself, before the players, and returns an error if a message ID
+// is registered twice.
+func (w *World) HandlerRegister() error {
+	player := w.Router.Module("player")
+	player.Handle(messageId.MessageId_CSCreatePlayer, msgrouter.Typed(w.CreatePlayer))
+	player.Handle(messageId.MessageId_CSLogin, msgrouter.Typed(w.UserLogin))
+	return w.Router.Check()
 }
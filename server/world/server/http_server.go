@@ -2,9 +2,11 @@ package server
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"github.com/phuhao00/network"
 	"greatestworks/aop/logger"
+	"greatestworks/internal"
 	"net"
 	"net/http"
 	"net/http/pprof"
@@ -57,6 +59,8 @@ func startHTTPServer(HTTPPort int, handler *HTTPHandler, TLSCertFile *string, TL
 // Register ...
 func (hs *HTTPHandler) Register() {
 	hs.Router.HandleFunc("GET", "/health", healthCheck)
+	hs.Router.HandleFunc("GET", "/debug/modules", moduleHealth)
+	hs.Router.HandleFunc("GET", "/debug/flood", floodReport)
 }
 
 func (hs *HTTPHandler) RegisterProfiler() {
@@ -86,6 +90,33 @@ func healthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// moduleHealth reports the lifecycle state and health of every module. It
+// responds 503 if a module isn't started or is unhealthy.
+func moduleHealth(w http.ResponseWriter, r *http.Request) {
+	health := internal.ModuleManager.Health()
+	status := http.StatusOK
+	for _, h := range health {
+		if h.State != internal.ModuleStarted.String() || h.Error != "" {
+			status = http.StatusServiceUnavailable
+			break
+		}
+	}
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(health); err != nil {
+		logger.Error("[moduleHealth] Write err:%v", err.Error())
+	}
+}
+
+// floodReport reports the connections that flooded the server the most, with
+// the messages they flooded.
+func floodReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(Oasis.flood.Top(50)); err != nil {
+		logger.Error("[floodReport] Write err:%v", err.Error())
+	}
+}
+
 func setLogLevel(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json; charset=utf-8")
 	err := r.ParseForm()
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"greatestworks/aop/logger"
+	"greatestworks/aop/rpc"
+	_ "greatestworks/internal/gameplay/rank" // registers the module, which serves its methods over rpc
+)
+
+// startRpc connects the modules of the world server to the modules of the
+// other processes, and serves its modules to them (see aop/rpc).
+func (w *World) startRpc() {
+	if w.Config == nil || w.Config.RpcServer == nil {
+		return
+	}
+	conf := w.Config.RpcServer
+	ctx, caller := context.Background(), ""
+	if w.BaseService != nil {
+		ctx, caller = w.Ctx, w.Id
+	}
+	if err := rpc.Init(ctx, rpc.Options{Caller: caller, Nodes: conf.Nodes, Timeout: conf.Timeout}); err != nil {
+		logger.Error("[startRpc] connect nodes:%v err:%v", conf.Nodes, err)
+	}
+	if conf.RpcPort <= 0 {
+		return
+	}
+	w.rpcAddr, w.rpcPort = conf.RpcIp, conf.RpcPort
+	go func() {
+		if err := rpc.Serve(ctx, fmt.Sprintf("%s:%d", w.rpcAddr, w.rpcPort)); err != nil {
+			logger.Error("[startRpc] serve port:%v err:%v", w.rpcPort, err)
+		}
+	}()
+}
@@ -1,11 +1,32 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"greatestworks/aop/dlock"
+	"greatestworks/aop/gamedata"
+	"greatestworks/aop/idgenerator"
 	"greatestworks/aop/logger"
+	"greatestworks/aop/mongo"
+	"greatestworks/aop/net/flood"
+	"greatestworks/aop/redis"
+	"greatestworks/aop/saga"
+	"greatestworks/aop/tick"
+	"greatestworks/internal"
+	"greatestworks/internal/communicate/player"
+	"os"
+	"time"
 )
 
+// Reload reloads the config tables, on SIGHUP.
 func (w *World) Reload() {
 	logger.Info("[Reload] World Reload ")
+	tables, err := gamedata.Default.Load()
+	if err != nil {
+		logger.Error("[Reload] World reload config tables err:%v", err)
+		return
+	}
+	logger.Info("[Reload] World config tables version:%v", tables.Version)
 }
 
 func (w *World) Init(config interface{}, processId int) {
@@ -13,11 +34,110 @@ func (w *World) Init(config interface{}, processId int) {
 }
 
 func (w *World) Start() {
-	w.HandlerRegister()
+	if w.Config != nil && w.Config.Tick != nil {
+		tick.Default = tick.New(*w.Config.Tick)
+	}
+	if w.Config != nil && w.Config.Redis != nil {
+		if err := redis.Init(context.Background(), *w.Config.Redis); err != nil {
+			logger.Fatal("[Start] World redis err:%v", err)
+			return
+		}
+	}
+	if w.Config != nil && w.Config.Mongo != nil {
+		if err := mongo.Init(context.Background(), *w.Config.Mongo); err != nil {
+			logger.Fatal("[Start] World mongo err:%v", err)
+			return
+		}
+		// Refuse to start on a database lacking migrations (see admin migrate).
+		db := mongo.Client.RealCli.Database((&mongo.PlayerDoc{}).DB())
+		if err := mongo.Migrations(db).Check(context.Background()); err != nil {
+			logger.Fatal("[Start] World mongo schema err:%v", err)
+			return
+		}
+	}
+	if w.Config != nil && w.Config.IdGen != nil {
+		if err := idgenerator.Setup(context.Background(), *w.Config.IdGen, redis.Get()); err != nil {
+			logger.Fatal("[Start] World id generator err:%v", err)
+			return
+		}
+	}
+	var lockOpts dlock.Options
+	if w.Config != nil && w.Config.Lock != nil {
+		lockOpts = *w.Config.Lock
+	}
+	if lockOpts.Owner == "" && w.BaseService != nil {
+		lockOpts.Owner = fmt.Sprintf("%s-%d", w.Id, os.Getpid())
+	}
+	dlock.SetDefault(dlock.New(dlock.NewRedisStore(redis.Get()), lockOpts))
+	var sagaOpts saga.Options
+	if w.Config != nil && w.Config.Saga != nil {
+		sagaOpts = *w.Config.Saga
+	}
+	saga.SetDefault(saga.New(saga.NewRedisStore(redis.Get()), sagaOpts))
+	if w.Config != nil && w.Config.WriteBehind != nil {
+		opts := *w.Config.WriteBehind
+		if opts.Consumer == "" && w.BaseService != nil {
+			opts.Consumer = w.Id
+		}
+		w.writeBehind = player.EnableWriteBehind(redis.Get(), opts)
+	}
+	if err := internal.ModuleManager.Init(); err != nil {
+		logger.Fatal("[Start] World init modules err:%v", err)
+		return
+	}
+	// The modules set the files of their tables in Init.
+	tables, err := gamedata.Default.Load()
+	if err != nil {
+		logger.Fatal("[Start] World load config tables err:%v", err)
+		return
+	}
+	logger.Info("[Start] World config tables version:%v", tables.Version)
+	if err := internal.ModuleManager.OnStart(); err != nil {
+		logger.Fatal("[Start] World start modules err:%v", err)
+		return
+	}
+	if w.Config != nil && w.Config.Flood != nil {
+		w.flood = flood.New(*w.Config.Flood)
+	}
+	if err := w.HandlerRegister(); err != nil {
+		logger.Fatal("[Start] World register handlers err:%v", err)
+		return
+	}
+	w.startRpc()
 	go w.Run()
+	ctx := context.Background()
+	if w.BaseService != nil {
+		ctx = w.Ctx
+	}
+	go tick.Default.Run(ctx)
+	if w.BaseService != nil {
+		// The gateways find the world servers in the status registry by the
+		// server id they register to the gateways with.
+		if w.DeploymentId == "" {
+			w.DeploymentId = w.Id
+		}
+		go func() {
+			if err := w.ServeStatus(w.Ctx); err != nil {
+				logger.Error("[Start] World status server err:%v", err)
+			}
+		}()
+	}
 
 }
 
 func (w *World) Stop() {
-
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if failed := w.playerManager.StopAll(ctx); failed > 0 {
+		logger.Error("[Stop] World save players failed:%v", failed)
+	}
+	if w.writeBehind != nil {
+		if err := w.writeBehind.Stop(ctx); err != nil {
+			logger.Error("[Stop] World flush write-behind cache err:%v", err)
+		}
+	}
+	if err := internal.ModuleManager.OnStop(); err != nil {
+		logger.Error("[Stop] World stop modules err:%v", err)
+	}
+	idgenerator.Default.Close()
 }
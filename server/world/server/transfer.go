@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"greatestworks/aop/redis"
+	"greatestworks/internal/communicate/player"
+	"greatestworks/server"
+)
+
+// TransferPlayer transfers a player to another world server (e.g., a cross
+// server battlefield). The gateway of the player holds its messages, and asks
+// this server to hand it off (see TransferOut), before the player joins the
+// target server, which resumes it from the state it handed off.
+func (w *World) TransferPlayer(ctx context.Context, playerID uint64, to string) error {
+	if w.GetPlayer(playerID) == nil {
+		return fmt.Errorf("transfer player %v: %w", playerID, player.ErrPlayerNotFound)
+	}
+	if to == w.Id {
+		return fmt.Errorf("transfer player %v: already on world server %v", playerID, to)
+	}
+	data, err := json.Marshal(server.TransferRequest{UserID: playerID, To: to})
+	if err != nil {
+		return err
+	}
+	return redis.CacheRedis().Publish(ctx, server.TransferChannel, data).Err()
+}
+
+// TransferOut hands a player off to the world server it's transferred to.
+func (w *World) TransferOut(ctx context.Context, playerID uint64) error {
+	return w.playerManager.TransferOut(ctx, playerID, w.Id)
+}
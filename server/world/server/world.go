@@ -1,14 +1,19 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"github.com/phuhao00/broker/timerassistant"
 	"github.com/phuhao00/fuse"
 	pbChat "github.com/phuhao00/greatestworks-proto/chat"
-	"github.com/phuhao00/greatestworks-proto/messageId"
 	pbPLayer "github.com/phuhao00/greatestworks-proto/player"
 	"github.com/phuhao00/greatestworks-proto/server_common"
 	"greatestworks/aop/logger"
+	"greatestworks/aop/msgrouter"
+	"greatestworks/aop/msgseq"
+	"greatestworks/aop/msgtrace"
+	"greatestworks/aop/net/flood"
+	"greatestworks/aop/writebehind"
 	"greatestworks/internal/communicate/chat"
 	"greatestworks/internal/communicate/family"
 	"greatestworks/internal/communicate/player"
@@ -16,6 +21,7 @@ import (
 	"greatestworks/server/world/config"
 	"os"
 	"syscall"
+	"time"
 
 	"github.com/phuhao00/network"
 )
@@ -25,7 +31,7 @@ type World struct {
 	*server.BaseService
 	Pid               int
 	Server            *network.TcpServer
-	Handlers          map[messageId.MessageId]func(message *network.Packet)
+	Router            *msgrouter.Router // the messages handled before the players
 	chSessionPacket   chan *network.Packet
 	chatSystem        *chat.System
 	familyManager     *family.Module
@@ -43,25 +49,47 @@ type World struct {
 	StartTM           int64
 	crossZoneChatMsg  chan *pbChat.SCCrossSrvChatMsg //跨区聊天
 	systemMsgChan     chan *pbChat.SCSystemMessage
+	flood             *flood.Limiter     // rate limits the messages of the connections
+	writeBehind       *writebehind.Cache // the write-behind cache of the players, if enabled
 }
 
 func NewWorld() *World {
-	m := &World{playerManager: player.NewPlayerMgr()}
+	m := &World{playerManager: player.NewPlayerMgr(), flood: flood.New(flood.Options{})}
 	m.Server = network.NewTcpServer(":8023", 100, 200, logger.GetLogger())
 	m.Server.MessageHandler = m.OnSessionPacket
-	m.Handlers = make(map[messageId.MessageId]func(message *network.Packet))
+	m.Router = msgrouter.NewRouter(msgrouter.Recover(), msgrouter.Logging(time.Second))
 	return m
 }
 
 var Oasis *World
 
 func (w *World) OnSessionPacket(packet *network.Packet) {
-	if handler, ok := w.Handlers[messageId.MessageId(packet.Msg.ID)]; ok {
-		handler(packet)
+	// Continue the trace of the sender, if it sent one, so that all the
+	// modules handling the message contribute to the same trace.
+	ctx, data := msgtrace.Extract(context.Background(), packet.Msg.Data)
+	// Then the sequence number of the message, if the client numbered it,
+	// so that the player handles it once however many times it's retried.
+	seq, data := msgseq.Extract(data)
+	packet.Msg.Data = data
+	ctx, span := msgtrace.Start(ctx, "world", uint64(packet.Msg.ID))
+	defer span.End()
+
+	// Drop the messages of the connections flooding the server, before they
+	// reach the players.
+	switch w.flood.Allow(uint64(packet.Conn.ConnID), uint32(packet.Msg.ID), time.Now()) {
+	case flood.Drop:
+		return
+	case flood.Disconnect:
+		logger.Warn("[OnSessionPacket] ConnID:%v flooding msg:%v, disconnected", packet.Conn.ConnID, packet.Msg.ID)
+		packet.Conn.Close()
+		return
+	}
+
+	if w.Router.Dispatch(packet) {
 		return
 	}
 	if p := w.playerManager.GetPlayer(uint64(packet.Conn.ConnID)); p != nil {
-		p.HandlerParamCh <- packet.Msg
+		p.HandlerParamCh <- &player.Request{Ctx: ctx, Msg: packet.Msg, Seq: seq}
 	}
 }
 